@@ -0,0 +1,87 @@
+// docflow-api runs the headless JSON API: versioned endpoints for document
+// registration, lookup, lists, assignments, acknowledgments and attachments
+// for other internal systems. It uses the same config.json, database, storage
+// and service graph as the desktop application and can run next to it.
+//
+// Clients obtain a bearer token with POST /api/v1/auth/token using the
+// credentials of a regular (typically dedicated service) user; every request
+// is then authorized with that user's permissions. The OpenAPI document is
+// served at /api/v1/openapi.json.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/app"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/httpapi"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/logger"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/startupdiag"
+)
+
+func main() {
+	configPath := flag.String("config", config.GetDefaultConfigPath(), "path to config.json")
+	addr := flag.String("addr", "127.0.0.1:8080", "listen address")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file; serves plain HTTP when empty")
+	tlsKey := flag.String("tls-key", "", "TLS private key file")
+	tokenTTL := flag.Duration("token-ttl", httpapi.DefaultTokenTTL, "lifetime of issued access tokens")
+	maxUpload := flag.Int64("max-upload", httpapi.DefaultMaxUploadBytes, "maximum size of an attachment upload request in bytes")
+	flag.Parse()
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		failStartup(startupdiag.Failure{
+			Component: "HTTP API",
+			Summary:   "Для TLS нужно указать и -tls-cert, и -tls-key.",
+			NextStep:  "Передайте оба параметра или ни одного, чтобы запустить API без TLS за обратным прокси.",
+		})
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		failStartup(startupdiag.Failure{
+			Component:  "configuration",
+			ConfigPath: *configPath,
+			Summary:    "Не удалось загрузить config.json.",
+			NextStep:   "Проверьте -config или DOCFLOW_CONFIG_PATH, наличие файла, права чтения, JSON-синтаксис и ENCRYPTION_KEY для ENC:-значений.",
+			Err:        err,
+		})
+	}
+
+	_, closeLogger := logger.Init(cfg.Seq)
+	defer closeLogger()
+
+	server, failure := app.NewAPIServer(cfg, app.APIServerParams{
+		ConfigPath:     *configPath,
+		Addr:           *addr,
+		TLSCertFile:    *tlsCert,
+		TLSKeyFile:     *tlsKey,
+		TokenTTL:       *tokenTTL,
+		MaxUploadBytes: *maxUpload,
+	})
+	if failure != nil {
+		failStartup(*failure)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := server.Run(ctx); err != nil {
+		failStartup(startupdiag.Failure{
+			Component:  "HTTP API",
+			ConfigPath: *configPath,
+			Summary:    "HTTP API остановлен с ошибкой.",
+			NextStep:   "Проверьте, что адрес -addr свободен, и пути к TLS-сертификату и ключу.",
+			Err:        err,
+		})
+	}
+}
+
+func failStartup(failure startupdiag.Failure) {
+	startupdiag.Log(slog.Default(), failure)
+	startupdiag.Write(os.Stderr, failure)
+	os.Exit(1)
+}
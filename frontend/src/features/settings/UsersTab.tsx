@@ -301,7 +301,7 @@ const UsersTab: React.FC = () => {
       <Table columns={columns} dataSource={data} rowKey="id" loading={loading} size="small" pagination={false} />
 
       <Typography.Text type="secondary" style={{ marginTop: 8, display: 'block' }}>
-        Пользователь со статусом «Заблокирован» был деактивирован автоматически после 5 неверных попыток входа.
+        Пользователь со статусом «Заблокирован» был деактивирован автоматически после неверных попыток входа.
         Для восстановления откройте его карточку и снова включите флаг «Активен».
       </Typography.Text>
 
@@ -390,7 +390,7 @@ const UsersTab: React.FC = () => {
                 <>
                   {isBruteforceLocked(editItem) && (
                     <Typography.Text type="warning" style={{ display: 'block', marginBottom: 12 }}>
-                      Пользователь автоматически заблокирован после 5 неверных попыток входа. Включение флага «Активен» разблокирует его и сбросит счетчик ошибок.
+                      Пользователь автоматически заблокирован после неверных попыток входа. Включение флага «Активен» разблокирует его и сбросит счетчик ошибок.
                     </Typography.Text>
                   )}
                   <Form.Item name="isActive" label="Активен" valuePropName="checked" style={compactUserFormItemStyle}>
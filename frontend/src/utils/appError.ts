@@ -22,8 +22,8 @@ const CODE_COPY: Record<string, { message: string; action: string; allowDetail?:
         action: 'Обратитесь к администратору для восстановления доступа.',
     },
     USER_LOCKED: {
-        message: 'Учетная запись заблокирована после 5 неверных попыток входа',
-        action: 'Обратитесь к администратору для повторной активации.',
+        message: 'Учетная запись заблокирована после неверных попыток входа',
+        action: 'Повторите попытку позже или обратитесь к администратору.',
     },
     PASSWORD_CHANGE_REQUIRED: {
         message: 'Необходимо сменить пароль',
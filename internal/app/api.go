@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/httpapi"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/services"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/startupdiag"
+)
+
+// APIServerParams contains process-level settings of the HTTP API entry point.
+type APIServerParams struct {
+	ConfigPath     string
+	Addr           string
+	TLSCertFile    string
+	TLSKeyFile     string
+	TokenTTL       time.Duration
+	MaxUploadBytes int64
+}
+
+// APIServer serves the headless JSON API over the same repositories, storage
+// and background workers as the desktop application.
+type APIServer struct {
+	application *application
+	server      *http.Server
+	tlsCertFile string
+	tlsKeyFile  string
+}
+
+// NewAPIServer builds the application graph for the HTTP API mode.
+func NewAPIServer(cfg *config.Config, params APIServerParams) (*APIServer, *startupdiag.Failure) {
+	application, failure := newApplication(cfg, params.ConfigPath)
+	if failure != nil {
+		return nil, failure
+	}
+
+	api := httpapi.NewServer(
+		httpapi.Config{TokenTTL: params.TokenTTL, MaxUploadBytes: params.MaxUploadBytes},
+		func(login, password, oneTimeCode string) (*dto.User, error) {
+			return services.AuthenticateCredentials(application.services.auth, login, password, oneTimeCode)
+		},
+		application.apiServices(),
+		func(ctx context.Context, userID uuid.UUID) (context.Context, error) {
+			// The desktop reloads custom kinds on navigation; the API has no such
+			// hook, so every request refreshes a catalog older than its TTL.
+			if err := application.services.customDocumentKinds.RefreshCatalog(); err != nil {
+				slog.Warn("custom document kinds were not refreshed", "error", err)
+			}
+			return services.PrincipalContext(ctx, application.services.auth, userID)
+		},
+	)
+	return &APIServer{
+		application: application,
+		server: &http.Server{
+			Addr:              params.Addr,
+			Handler:           api.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		},
+		tlsCertFile: params.TLSCertFile,
+		tlsKeyFile:  params.TLSKeyFile,
+	}, nil
+}
+
+// Run starts the background workers and serves requests until ctx is cancelled.
+// In-flight requests get the same shutdown budget as desktop operations.
+func (s *APIServer) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		s.application.db.Close()
+		return err
+	}
+	s.server.BaseContext = func(net.Listener) context.Context { return ctx }
+	s.application.start(ctx)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.tlsCertFile != "" {
+			serveErr <- s.server.ServeTLS(listener, s.tlsCertFile, s.tlsKeyFile)
+			return
+		}
+		serveErr <- s.server.Serve(listener)
+	}()
+	slog.Info("HTTP API started", "addr", listener.Addr().String(), "tls", s.tlsCertFile != "")
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err = s.server.Shutdown(shutdownCtx)
+		cancel()
+	}
+	s.application.shutdown()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// apiServices exposes the shared service graph to the HTTP API. Each call acts
+// on behalf of the principal that the server puts into the request context, so
+// one set of services serves every token user and never touches the desktop
+// session.
+func (a *application) apiServices() httpapi.Services {
+	return httpapi.Services{
+		Users:             services.NewCurrentUserAPI(a.services.auth),
+		Documents:         services.NewDocumentAPI(a.services.documentQuery, a.services.documentRegistration),
+		Registration:      services.NewDocumentAPI(a.services.documentQuery, a.services.documentRegistration),
+		Assignments:       services.NewAssignmentAPI(a.services.assignments),
+		Acknowledgments:   services.NewAcknowledgmentAPI(a.services.acknowledgments),
+		Attachments:       services.NewAttachmentAPI(a.services.attachments),
+		AttachmentContent: services.NewAttachmentContentService(a.services.attachments),
+	}
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/services"
+)
+
+func TestAPIServicesShareGraphWithoutDesktopSession(t *testing.T) {
+	deps := serviceDeps{repos: newRepositories(nil), operations: services.NewOperationLifecycle(0)}
+	desktopAuth := newAuthService(deps)
+	application := &application{deps: deps, services: newServiceGraph(deps, desktopAuth)}
+
+	api := application.apiServices()
+	require.NotNil(t, api.Users)
+	require.NotNil(t, api.Documents)
+	require.NotNil(t, api.Registration)
+	require.NotNil(t, api.Assignments)
+	require.NotNil(t, api.Acknowledgments)
+	require.NotNil(t, api.Attachments)
+	require.NotNil(t, api.AttachmentContent)
+
+	// Without a principal in context calls are rejected instead of running as the desktop session.
+	_, err := api.Users.GetCurrentUser(context.Background())
+	require.ErrorIs(t, err, models.ErrUnauthorized)
+	_, err = api.Assignments.GetByID(context.Background(), uuid.NewString())
+	require.ErrorIs(t, err, models.ErrUnauthorized)
+	require.False(t, desktopAuth.IsAuthenticated(), "API calls must not open the desktop session")
+}
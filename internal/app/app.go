@@ -18,7 +18,6 @@ import (
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/observability"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/outbox"
-	"github.com/Volkov-D-A/docs-register-and-track/internal/repository"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/services"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/startupdiag"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/storage"
@@ -32,143 +31,161 @@ type WailsOptionsParams struct {
 	CloseLogger        func()
 }
 
-// NewWailsOptions builds the desktop application graph and returns Wails options.
-func NewWailsOptions(cfg *config.Config, params WailsOptionsParams) (*options.App, *startupdiag.Failure) {
+// application is the process-level composition shared by the desktop and the
+// HTTP API entry points: database, repositories, storage, the service graph of
+// the local session and the schema-dependent background workers.
+type application struct {
+	db         *database.DB
+	deps       serviceDeps
+	services   *serviceGraph
+	background *backgroundLifecycle
+}
+
+func newApplication(cfg *config.Config, configPath string) (*application, *startupdiag.Failure) {
 	db, err := database.Connect(cfg.Database)
 	if err != nil {
 		return nil, &startupdiag.Failure{
 			Component:  "PostgreSQL",
-			ConfigPath: params.ConfigPath,
+			ConfigPath: configPath,
 			Summary:    "Не удалось подключиться к базе данных.",
 			NextStep:   "Проверьте host/port/dbname/user/sslmode в config.json, расшифровку пароля и доступность PostgreSQL из рабочего места.",
 			Err:        err,
 		}
 	}
-	created := false
-	defer func() {
-		if !created {
-			db.Close()
-		}
-	}()
 	metrics := observability.NewRegistry(256)
 	db.SetMetrics(metrics)
 
-	userRepo := repository.NewUserRepository(db)
-	userSubstitutionRepo := repository.NewUserSubstitutionRepository(db)
-	nomenclatureRepo := repository.NewNomenclatureRepository(db)
-	referenceRepo := repository.NewReferenceRepository(db)
-	documentAccessRepo := repository.NewDocumentAccessRepository(db)
-	documentRepo := repository.NewDocumentRepository(db)
-	incomingDocRepo := repository.NewIncomingDocumentRepository(db)
-	outgoingDocRepo := repository.NewOutgoingDocumentRepository(db)
-	citizenAppealRepo := repository.NewCitizenAppealRepository(db)
-	administrativeOrderRepo := repository.NewAdministrativeOrderRepository(db)
-	assignmentRepo := repository.NewAssignmentRepository(db)
-	departmentRepo := repository.NewDepartmentRepository(db)
-	settingsRepo := repository.NewSettingsRepository(db)
-	attachmentRepo := repository.NewAttachmentRepository(db)
-	linkRepo := repository.NewLinkRepository(db)
-	acknowledgmentRepo := repository.NewAcknowledgmentRepository(db)
-	dashboardRepo := repository.NewDashboardRepository(db)
-	statisticsRepo := repository.NewStatisticsRepository(db)
-	journalRepo := repository.NewJournalRepository(db)
-	adminAuditLogRepo := repository.NewAdminAuditLogRepository(db)
-	userEventRepo := repository.NewUserEventRepository(db)
-	outboxRepo := repository.NewOutboxRepository(db)
-	acknowledgmentRepo.SetOutbox(outboxRepo)
-	attachmentRepo.SetOutbox(outboxRepo)
-	assignmentRepo.SetOutbox(outboxRepo)
-	linkRepo.SetOutbox(outboxRepo)
-	nomenclatureRepo.SetOutbox(outboxRepo)
-	departmentRepo.SetOutbox(outboxRepo)
-	userSubstitutionRepo.SetOutbox(outboxRepo)
-	referenceRepo.SetOutbox(outboxRepo)
-	userRepo.SetOutbox(outboxRepo)
-	settingsRepo.SetOutbox(outboxRepo)
-	outgoingDocRepo.SetOutbox(outboxRepo)
-	incomingDocRepo.SetOutbox(outboxRepo)
-	citizenAppealRepo.SetOutbox(outboxRepo)
-	administrativeOrderRepo.SetOutbox(outboxRepo)
-
-	operationLifecycle := services.NewOperationLifecycle(5 * time.Minute)
-
-	authService := services.NewAuthService(db, userRepo)
-	authService.SetOperationMetrics(metrics)
-	authService.SetAccessStore(documentAccessRepo)
-	authService.SetSettingsStore(settingsRepo)
-
-	logger.GetAppUserID = func() string {
-		return authService.GetCurrentUserID()
-	}
-
-	adminAuditLogService := services.NewAdminAuditLogService(adminAuditLogRepo, authService)
-	outboxAdminService := services.NewOutboxAdminService(outboxRepo, authService)
-	settingsService := services.NewSettingsService(db, settingsRepo, authService, adminAuditLogService)
-	userService := services.NewUserService(userRepo, authService)
-	userSubstitutionService := services.NewUserSubstitutionService(userSubstitutionRepo, userRepo, authService)
-	nomenclatureService := services.NewNomenclatureService(nomenclatureRepo, authService)
-	referenceService := services.NewReferenceService(referenceRepo, authService)
-	documentAccessService := services.NewDocumentAccessService(authService, departmentRepo, assignmentRepo, acknowledgmentRepo, documentAccessRepo, documentRepo, incomingDocRepo, outgoingDocRepo, userSubstitutionRepo)
-	documentAccessAdminService := services.NewDocumentAccessAdminService(authService, documentAccessRepo, userRepo)
-	documentKindService := services.NewDocumentKindService(documentAccessService)
-	journalService := services.NewJournalService(journalRepo, authService, documentAccessService)
-	journalService.SetOperationLifecycle(operationLifecycle)
-	documentKindQueryRegistry := services.NewDocumentKindQueryRegistry(
-		services.NewIncomingLetterQueryHandler(incomingDocRepo),
-		services.NewOutgoingLetterQueryHandler(outgoingDocRepo),
-		services.NewCitizenAppealQueryHandler(citizenAppealRepo),
-		services.NewAdministrativeOrderQueryHandler(administrativeOrderRepo),
-	)
-	documentQueryService := services.NewDocumentQueryService(documentKindQueryRegistry, documentAccessService)
-	documentQueryService.SetOperationMetrics(metrics)
-	documentKindCommandRegistry := services.NewDocumentKindCommandRegistry(
-		services.NewIncomingLetterCommandHandler(incomingDocRepo, nomenclatureRepo, referenceRepo, authService, journalService, documentAccessService),
-		services.NewOutgoingLetterCommandHandler(outgoingDocRepo, referenceRepo, nomenclatureRepo, authService, journalService, documentAccessService),
-		services.NewCitizenAppealCommandHandler(citizenAppealRepo, nomenclatureRepo, referenceRepo, authService, journalService, documentAccessService),
-		services.NewAdministrativeOrderCommandHandler(administrativeOrderRepo, nomenclatureRepo, authService, journalService, documentAccessService),
-	)
-	documentRegistrationService := services.NewDocumentRegistrationService(documentKindCommandRegistry)
-	documentRegistrationService.SetOperationLifecycle(operationLifecycle)
-	documentRegistrationService.SetOperationMetrics(metrics)
-	userEventService := services.NewUserEventService(userEventRepo, authService)
-	administrativeOrderService := services.NewAdministrativeOrderService(administrativeOrderRepo, authService, documentAccessService)
-	assignmentService := services.NewAssignmentService(assignmentRepo, userRepo, authService, documentAccessService, userEventService)
-	assignmentService.SetSubstitutionStore(userSubstitutionRepo)
-	departmentService := services.NewDepartmentService(departmentRepo, authService)
-
-	minioService, err := storage.NewMinioService(cfg.Minio)
+	fileStorage, err := storage.New(*cfg)
 	if err != nil {
-		return nil, &startupdiag.Failure{
+		db.Close()
+		failure := &startupdiag.Failure{
 			Component:  "MinIO",
-			ConfigPath: params.ConfigPath,
+			ConfigPath: configPath,
 			Summary:    "Не удалось подключиться к объектному хранилищу.",
 			NextStep:   "Проверьте endpoint/useSSL/bucket/accessKeyId в config.json, расшифровку secretAccessKey и доступность MinIO из рабочего места.",
 			Err:        err,
 		}
+		if cfg.Storage.DriverName() != config.StorageDriverMinio {
+			failure.Component = "file storage"
+			failure.Summary = "Не удалось открыть файловое хранилище вложений."
+			failure.NextStep = "Проверьте storage.driver и storage.localPath в config.json и права записи в каталог хранилища."
+		}
+		return nil, failure
+	}
+
+	repos := newRepositories(db)
+	directoryService, err := newDirectoryService(cfg.LDAP, repos.users)
+	if err != nil {
+		db.Close()
+		return nil, &startupdiag.Failure{
+			Component:  "LDAP",
+			ConfigPath: configPath,
+			Summary:    "Неверные настройки каталога пользователей.",
+			NextStep:   "Проверьте раздел ldap в config.json: url, baseDN, localLogin и groupMappings.",
+			Err:        err,
+		}
+	}
+	emailSender, err := newEmailSender(cfg.SMTP)
+	if err != nil {
+		db.Close()
+		return nil, &startupdiag.Failure{
+			Component:  "SMTP",
+			ConfigPath: configPath,
+			Summary:    "Неверные настройки почтовых уведомлений.",
+			NextStep:   "Проверьте раздел smtp в config.json: host, port, security, from и расшифровку пароля.",
+			Err:        err,
+		}
 	}
-	attachmentService := services.NewAttachmentService(attachmentRepo, settingsService, authService, minioService, documentAccessService)
-	attachmentService.SetOperationLifecycle(operationLifecycle)
-	attachmentService.SetOperationMetrics(metrics)
-	outboxWorker := outbox.NewWorker(outboxRepo, userEventRepo, journalRepo, adminAuditLogRepo, attachmentRepo, minioService)
+
+	deps := serviceDeps{
+		db:          db,
+		repos:       repos,
+		fileStorage: fileStorage,
+		metrics:     metrics,
+		operations:  services.NewOperationLifecycle(5 * time.Minute),
+	}
+	authService := newAuthService(deps)
+	if directoryService != nil {
+		authService.SetAuthenticator(directoryService, cfg.LDAP.LocalLoginMode() == config.LDAPLocalLoginAdmins)
+	}
+	graph := newServiceGraph(deps, authService)
+
+	outboxWorker := outbox.NewWorker(repos.outbox, repos.userEvents, repos.journal, repos.adminAuditLog, repos.attachments, fileStorage)
+	outboxWorker.SetAttachmentTexts(repos.attachmentTexts)
 	outboxWorker.SetMetrics(metrics)
+	workers := backgroundWorkerGroup{
+		outboxWorker,
+		backgroundWorkerFunc(graph.attachments.RunIntegrityVerification),
+		backgroundWorkerFunc(graph.userSessions.RunExpiry),
+		backgroundWorkerFunc(graph.userSubstitutions.RunExpiryNotifications),
+		backgroundWorkerFunc(graph.reminders.RunScheduler),
+		backgroundWorkerFunc(graph.assignments.RunSeriesGenerator),
+	}
+	if directoryService != nil {
+		workers = append(workers, backgroundWorkerFunc(directoryService.RunSync))
+	}
+	// Mail is queued on every workstation; only workers with SMTP settings
+	// claim and send it.
+	outboxWorker.SetEmailRecipients(repos.emailNotifications, cfg.SMTP.DigestSendHour())
+	workers = append(workers, backgroundWorkerFunc(outboxWorker.RunEmailDigests))
+	if emailSender != nil {
+		outboxWorker.SetEmailSender(emailSender)
+	}
 	backgroundServices := newBackgroundLifecycle(
 		db,
-		outboxWorker,
-		attachmentService.ProcessPendingDeletions,
+		workers,
+		func(ctx context.Context) error {
+			if err := graph.customDocumentKinds.ReloadCatalog(); err != nil {
+				slog.Warn("custom document kinds were not loaded", "error", err)
+			}
+			return graph.attachments.ProcessPendingDeletions(ctx)
+		},
 	)
-	services.ConfigureSchemaLifecycle(authService, settingsService, backgroundServices)
+	services.ConfigureSchemaLifecycle(graph.auth, graph.settings, backgroundServices)
+
+	return &application{db: db, deps: deps, services: graph, background: backgroundServices}, nil
+}
 
-	dashboardService := services.NewDashboardService(dashboardRepo, authService, documentAccessService)
-	dashboardService.SetOperationMetrics(metrics)
-	statisticsService := services.NewStatisticsService(statisticsRepo, authService, minioService)
-	statisticsService.SetOperationLifecycle(operationLifecycle)
-	statisticsService.SetOperationMetrics(metrics)
-	linkService := services.NewLinkService(linkRepo, incomingDocRepo, outgoingDocRepo, citizenAppealRepo, administrativeOrderRepo, documentAccessService, authService)
-	linkService.SetOperationLifecycle(operationLifecycle)
-	linkService.SetOperationMetrics(metrics)
-	acknowledgmentService := services.NewAcknowledgmentService(acknowledgmentRepo, userRepo, authService, documentAccessService, userEventService)
-	acknowledgmentService.SetSubstitutionStore(userSubstitutionRepo)
-	systemService := services.NewSystemService(db)
+// start launches periodic metrics logging and the background workers.
+func (a *application) start(ctx context.Context) {
+	go observability.LogPeriodically(ctx, a.deps.metrics, slog.Default(), time.Minute)
+	a.background.SetApplicationContext(ctx)
+	a.background.ReconcileSchema()
+}
+
+// shutdown waits for background workers and in-flight operations, then closes the database.
+func (a *application) shutdown() {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := a.background.Stop(shutdownCtx); err != nil {
+		slog.Warn("shutdown continued before background services stopped", "error", err)
+	}
+	if err := a.deps.operations.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("shutdown continued before all backend operations finished", "error", err)
+	}
+	a.deps.metrics.LogSnapshot(slog.Default())
+	a.db.Close()
+}
+
+// NewWailsOptions builds the desktop application graph and returns Wails options.
+func NewWailsOptions(cfg *config.Config, params WailsOptionsParams) (*options.App, *startupdiag.Failure) {
+	application, failure := newApplication(cfg, params.ConfigPath)
+	if failure != nil {
+		return nil, failure
+	}
+	created := false
+	defer func() {
+		if !created {
+			application.db.Close()
+		}
+	}()
+	graph := application.services
+
+	logger.GetAppUserID = func() string {
+		return graph.auth.GetCurrentUserID()
+	}
+
+	systemService := services.NewSystemService(application.db)
 	releaseNoteService, err := services.NewReleaseNoteService(params.ReleaseNotesSource)
 	if err != nil {
 		return nil, &startupdiag.Failure{
@@ -201,53 +218,54 @@ func NewWailsOptions(cfg *config.Config, params WailsOptionsParams) (*options.Ap
 		LogLevel:       wailslogger.ERROR,
 		ErrorFormatter: formatBackendError,
 		OnStartup: func(ctx context.Context) {
-			go observability.LogPeriodically(ctx, metrics, slog.Default(), time.Minute)
-			attachmentService.Startup(ctx)
-			backgroundServices.SetApplicationContext(ctx)
-			backgroundServices.ReconcileSchema()
+			graph.attachments.Startup(ctx)
+			application.start(ctx)
 		},
 		BackgroundColour: &options.RGBA{R: 255, G: 255, B: 255, A: 1},
 		OnShutdown: func(ctx context.Context) {
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-			defer cancel()
-			if err := backgroundServices.Stop(shutdownCtx); err != nil {
-				slog.Warn("shutdown continued before background services stopped", "error", err)
-			}
-			if err := operationLifecycle.Shutdown(shutdownCtx); err != nil {
-				slog.Warn("shutdown continued before all backend operations finished", "error", err)
-			}
-			metrics.LogSnapshot(slog.Default())
-			db.Close()
+			services.CloseDesktopSession(graph.auth)
+			application.shutdown()
 			if params.CloseLogger != nil {
 				params.CloseLogger()
 			}
 		},
 		Bind: []interface{}{
-			authService,
-			userService,
-			userSubstitutionService,
-			nomenclatureService,
-			referenceService,
-			documentAccessAdminService,
-			documentKindService,
-			documentQueryService,
-			documentRegistrationService,
-			administrativeOrderService,
-			assignmentService,
-			dashboardService,
-			statisticsService,
-			departmentService,
-			settingsService,
-			attachmentService,
-			linkService,
-			acknowledgmentService,
+			graph.auth,
+			graph.users,
+			graph.userSubstitutions,
+			graph.userSessions,
+			graph.nomenclature,
+			graph.references,
+			graph.documentAccessAdmin,
+			graph.accessRoles,
+			graph.documentKinds,
+			graph.customDocumentKinds,
+			graph.documentQuery,
+			graph.registerExport,
+			graph.printForms,
+			graph.search,
+			graph.documentRegistration,
+			graph.outgoingApprovals,
+			graph.administrativeOrders,
+			graph.citizenAppeals,
+			graph.workingCalendar,
+			graph.assignments,
+			graph.dashboard,
+			graph.statistics,
+			graph.departments,
+			graph.settings,
+			graph.attachments,
+			graph.links,
+			graph.acknowledgments,
+			graph.reminders,
+			graph.emailNotifications,
 			systemService,
 			releaseNoteService,
 			themeService,
-			journalService,
-			adminAuditLogService,
-			userEventService,
-			outboxAdminService,
+			graph.journal,
+			graph.adminAuditLog,
+			graph.userEvents,
+			graph.outboxAdmin,
 		},
 	}
 	created = true
@@ -20,6 +20,27 @@ type backgroundWorker interface {
 	Run(context.Context)
 }
 
+// backgroundWorkerFunc adapts a blocking service loop to backgroundWorker.
+type backgroundWorkerFunc func(context.Context)
+
+func (f backgroundWorkerFunc) Run(ctx context.Context) { f(ctx) }
+
+// backgroundWorkerGroup runs several workers under one lifecycle. Run returns
+// only after every worker has stopped, so Stop waits for all of them.
+type backgroundWorkerGroup []backgroundWorker
+
+func (g backgroundWorkerGroup) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, worker := range g {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker.Run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
 type backgroundLifecycleState uint8
 
 const (
@@ -186,3 +186,23 @@ func TestBackgroundLifecycleSuccessfulRollbackStaysInMaintenance(t *testing.T) {
 	require.NoError(t, lifecycle.CheckReady())
 	stopLifecycle(t, lifecycle)
 }
+
+func TestBackgroundWorkerGroupStopsAfterAllWorkers(t *testing.T) {
+	reader := &fakeMigrationStatusReader{status: readyMigrationStatus()}
+	first := &blockingBackgroundWorker{}
+	var loopStops atomic.Int32
+	group := backgroundWorkerGroup{first, backgroundWorkerFunc(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		loopStops.Add(1)
+	})}
+	lifecycle := newBackgroundLifecycle(reader, group, nil)
+	lifecycle.SetApplicationContext(context.Background())
+
+	lifecycle.ReconcileSchema()
+	require.Eventually(t, func() bool { return first.starts.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	stopLifecycle(t, lifecycle)
+	assert.Equal(t, int32(1), first.stops.Load())
+	assert.Equal(t, int32(1), loopStops.Load())
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/directory"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/services"
+)
+
+// newDirectoryService connects the LDAP directory from config.json. It returns
+// nil when the directory is disabled and local accounts are the only login.
+func newDirectoryService(cfg config.LDAPConfig, store services.DirectoryUserStore) (*services.DirectoryService, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	ldapDirectory, err := directory.NewLDAP(cfg)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := directoryPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewDirectoryService(ldapDirectory, store, models.UserAuthSourceLDAP, policy, cfg.SyncInterval()), nil
+}
+
+// directoryPolicy converts the group mapping of config.json to the service
+// model and rejects unknown system permissions before the first login.
+func directoryPolicy(cfg config.LDAPConfig) (models.DirectoryPolicy, error) {
+	policy := models.DirectoryPolicy{RequiredGroup: strings.TrimSpace(cfg.RequiredGroup)}
+	for i, mapping := range cfg.GroupMappings {
+		item := models.DirectoryGroupMapping{
+			Group:               strings.TrimSpace(mapping.Group),
+			DocumentParticipant: mapping.DocumentParticipant,
+		}
+		for _, permission := range mapping.SystemPermissions {
+			if !models.IsSystemPermission(permission) {
+				return models.DirectoryPolicy{}, fmt.Errorf("ldap.groupMappings[%d]: unknown system permission %q", i, permission)
+			}
+			item.SystemPermissions = append(item.SystemPermissions, permission)
+		}
+		if mapping.DepartmentID != "" {
+			departmentID, err := uuid.Parse(mapping.DepartmentID)
+			if err != nil {
+				return models.DirectoryPolicy{}, fmt.Errorf("ldap.groupMappings[%d].departmentId must be a UUID", i)
+			}
+			item.DepartmentID = &departmentID
+		}
+		policy.Groups = append(policy.Groups, item)
+	}
+	return policy, nil
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestDirectoryPolicy(t *testing.T) {
+	departmentID := uuid.New()
+	policy, err := directoryPolicy(config.LDAPConfig{
+		RequiredGroup: " cn=docflow,ou=groups,dc=example,dc=local ",
+		GroupMappings: []config.LDAPGroupMapping{
+			{Group: "cn=admins,ou=groups,dc=example,dc=local", SystemPermissions: []string{models.SystemPermissionAdmin}},
+			{Group: "cn=office,ou=groups,dc=example,dc=local", DepartmentID: departmentID.String(), DocumentParticipant: true},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "cn=docflow,ou=groups,dc=example,dc=local", policy.RequiredGroup)
+	require.Len(t, policy.Groups, 2)
+	require.Equal(t, []string{models.SystemPermissionAdmin}, policy.Groups[0].SystemPermissions)
+	require.Nil(t, policy.Groups[0].DepartmentID)
+	require.Equal(t, departmentID, *policy.Groups[1].DepartmentID)
+	require.True(t, policy.Groups[1].DocumentParticipant)
+
+	_, err = directoryPolicy(config.LDAPConfig{GroupMappings: []config.LDAPGroupMapping{
+		{Group: "cn=office", SystemPermissions: []string{"superuser"}},
+	}})
+	require.ErrorContains(t, err, `unknown system permission "superuser"`)
+}
+
+func TestNewDirectoryServiceDisabled(t *testing.T) {
+	service, err := newDirectoryService(config.LDAPConfig{}, nil)
+	require.NoError(t, err)
+	require.Nil(t, service)
+}
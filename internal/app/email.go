@@ -0,0 +1,15 @@
+package app
+
+import (
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mailer"
+)
+
+// newEmailSender creates the SMTP sender from config.json. It returns nil when
+// the e-mail channel is disabled and user events stay in-app only.
+func newEmailSender(cfg config.SMTPConfig) (*mailer.SMTP, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	return mailer.NewSMTP(cfg)
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+)
+
+func TestNewEmailSender(t *testing.T) {
+	sender, err := newEmailSender(config.SMTPConfig{})
+	require.NoError(t, err)
+	require.Nil(t, sender)
+
+	_, err = newEmailSender(config.SMTPConfig{Enabled: true, From: "docflow@example.local"})
+	require.Error(t, err)
+
+	sender, err = newEmailSender(config.SMTPConfig{Enabled: true, Host: "mail.example.local", From: "docflow@example.local"})
+	require.NoError(t, err)
+	require.NotNil(t, sender)
+}
@@ -0,0 +1,262 @@
+package app
+
+import (
+	"log/slog"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/observability"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/repository"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/services"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/storage"
+)
+
+// repositories holds the process-wide repository set. Repositories keep no
+// per-user state, so the desktop session and every API request share them.
+type repositories struct {
+	users                *repository.UserRepository
+	userSubstitutions    *repository.UserSubstitutionRepository
+	userSessions         *repository.UserSessionRepository
+	twoFactor            *repository.TwoFactorRepository
+	nomenclature         *repository.NomenclatureRepository
+	references           *repository.ReferenceRepository
+	documentAccess       *repository.DocumentAccessRepository
+	accessRoles          *repository.AccessRoleRepository
+	documents            *repository.DocumentRepository
+	incomingDocs         *repository.IncomingDocumentRepository
+	outgoingDocs         *repository.OutgoingDocumentRepository
+	citizenAppeals       *repository.CitizenAppealRepository
+	administrativeOrders *repository.AdministrativeOrderRepository
+	customDocumentKinds  *repository.CustomDocumentKindRepository
+	customDocuments      *repository.CustomDocumentRepository
+	assignments          *repository.AssignmentRepository
+	departments          *repository.DepartmentRepository
+	settings             *repository.SettingsRepository
+	attachments          *repository.AttachmentRepository
+	links                *repository.LinkRepository
+	acknowledgments      *repository.AcknowledgmentRepository
+	dashboard            *repository.DashboardRepository
+	statistics           *repository.StatisticsRepository
+	journal              *repository.JournalRepository
+	adminAuditLog        *repository.AdminAuditLogRepository
+	userEvents           *repository.UserEventRepository
+	workingCalendar      *repository.WorkingCalendarRepository
+	documentSearch       *repository.DocumentSearchRepository
+	attachmentTexts      *repository.AttachmentTextRepository
+	outgoingApprovals    *repository.OutgoingApprovalRepository
+	reminders            *repository.ReminderRepository
+	emailNotifications   *repository.EmailNotificationRepository
+	outbox               *repository.OutboxRepository
+}
+
+func newRepositories(db *database.DB) *repositories {
+	r := &repositories{
+		users:                repository.NewUserRepository(db),
+		userSubstitutions:    repository.NewUserSubstitutionRepository(db),
+		userSessions:         repository.NewUserSessionRepository(db),
+		twoFactor:            repository.NewTwoFactorRepository(db),
+		nomenclature:         repository.NewNomenclatureRepository(db),
+		references:           repository.NewReferenceRepository(db),
+		documentAccess:       repository.NewDocumentAccessRepository(db),
+		accessRoles:          repository.NewAccessRoleRepository(db),
+		documents:            repository.NewDocumentRepository(db),
+		incomingDocs:         repository.NewIncomingDocumentRepository(db),
+		outgoingDocs:         repository.NewOutgoingDocumentRepository(db),
+		citizenAppeals:       repository.NewCitizenAppealRepository(db),
+		administrativeOrders: repository.NewAdministrativeOrderRepository(db),
+		customDocumentKinds:  repository.NewCustomDocumentKindRepository(db),
+		customDocuments:      repository.NewCustomDocumentRepository(db),
+		assignments:          repository.NewAssignmentRepository(db),
+		departments:          repository.NewDepartmentRepository(db),
+		settings:             repository.NewSettingsRepository(db),
+		attachments:          repository.NewAttachmentRepository(db),
+		links:                repository.NewLinkRepository(db),
+		acknowledgments:      repository.NewAcknowledgmentRepository(db),
+		dashboard:            repository.NewDashboardRepository(db),
+		statistics:           repository.NewStatisticsRepository(db),
+		journal:              repository.NewJournalRepository(db),
+		adminAuditLog:        repository.NewAdminAuditLogRepository(db),
+		userEvents:           repository.NewUserEventRepository(db),
+		workingCalendar:      repository.NewWorkingCalendarRepository(db),
+		documentSearch:       repository.NewDocumentSearchRepository(db),
+		attachmentTexts:      repository.NewAttachmentTextRepository(db),
+		outgoingApprovals:    repository.NewOutgoingApprovalRepository(db),
+		reminders:            repository.NewReminderRepository(db),
+		emailNotifications:   repository.NewEmailNotificationRepository(db),
+		outbox:               repository.NewOutboxRepository(db),
+	}
+	r.acknowledgments.SetOutbox(r.outbox)
+	r.attachments.SetOutbox(r.outbox)
+	r.assignments.SetOutbox(r.outbox)
+	r.links.SetOutbox(r.outbox)
+	r.nomenclature.SetOutbox(r.outbox)
+	r.departments.SetOutbox(r.outbox)
+	r.userSubstitutions.SetOutbox(r.outbox)
+	r.userSessions.SetOutbox(r.outbox)
+	r.twoFactor.SetOutbox(r.outbox)
+	r.twoFactor.SetSecretCipher(config.SecretCipher{})
+	if !config.HasEncryptionKey() {
+		slog.Warn("ENCRYPTION_KEY is not set; two-factor authentication is unavailable")
+	}
+	r.references.SetOutbox(r.outbox)
+	r.users.SetOutbox(r.outbox)
+	r.settings.SetOutbox(r.outbox)
+	r.outgoingDocs.SetOutbox(r.outbox)
+	r.incomingDocs.SetOutbox(r.outbox)
+	r.citizenAppeals.SetOutbox(r.outbox)
+	r.administrativeOrders.SetOutbox(r.outbox)
+	r.customDocumentKinds.SetOutbox(r.outbox)
+	r.accessRoles.SetOutbox(r.outbox)
+	r.customDocuments.SetOutbox(r.outbox)
+	r.workingCalendar.SetOutbox(r.outbox)
+	r.outgoingApprovals.SetOutbox(r.outbox)
+	r.reminders.SetOutbox(r.outbox)
+	return r
+}
+
+// serviceDeps contains the shared, process-level dependencies of the service graph.
+type serviceDeps struct {
+	db          *database.DB
+	repos       *repositories
+	fileStorage storage.Backend
+	metrics     *observability.Registry
+	operations  *services.OperationLifecycle
+}
+
+// newAuthService creates the AuthService that holds the desktop session and
+// loads principals: the session one for Wails calls and per-request ones for
+// the HTTP API via services.PrincipalContext.
+func newAuthService(deps serviceDeps) *services.AuthService {
+	authService := services.NewAuthService(deps.db, deps.repos.users)
+	authService.SetOperationMetrics(deps.metrics)
+	authService.SetAccessStore(deps.repos.documentAccess)
+	authService.SetSettingsStore(deps.repos.settings)
+	authService.SetSubstitutionStore(deps.repos.userSubstitutions)
+	authService.SetSessionStore(deps.repos.userSessions)
+	authService.SetTwoFactorStore(deps.repos.twoFactor)
+	return authService
+}
+
+// serviceGraph is the set of domain services of the process. Desktop calls act
+// on behalf of the local session; HTTP API calls pass the principal of the
+// request token in context, so both entry points share one graph.
+type serviceGraph struct {
+	auth                 *services.AuthService
+	adminAuditLog        *services.AdminAuditLogService
+	outboxAdmin          *services.OutboxAdminService
+	settings             *services.SettingsService
+	users                *services.UserService
+	userSubstitutions    *services.UserSubstitutionService
+	userSessions         *services.UserSessionService
+	nomenclature         *services.NomenclatureService
+	references           *services.ReferenceService
+	documentAccess       *services.DocumentAccessService
+	documentAccessAdmin  *services.DocumentAccessAdminService
+	accessRoles          *services.AccessRoleService
+	customDocumentKinds  *services.CustomDocumentKindService
+	documentKinds        *services.DocumentKindService
+	journal              *services.JournalService
+	workingCalendar      *services.WorkingCalendarService
+	documentQuery        *services.DocumentQueryService
+	registerExport       *services.RegisterExportService
+	printForms           *services.PrintFormService
+	search               *services.SearchService
+	documentRegistration *services.DocumentRegistrationService
+	outgoingApprovals    *services.OutgoingApprovalService
+	userEvents           *services.UserEventService
+	administrativeOrders *services.AdministrativeOrderService
+	citizenAppeals       *services.CitizenAppealService
+	assignments          *services.AssignmentService
+	departments          *services.DepartmentService
+	attachments          *services.AttachmentService
+	dashboard            *services.DashboardService
+	statistics           *services.StatisticsService
+	links                *services.LinkService
+	acknowledgments      *services.AcknowledgmentService
+	reminders            *services.ReminderService
+	emailNotifications   *services.EmailNotificationService
+}
+
+func newServiceGraph(deps serviceDeps, authService *services.AuthService) *serviceGraph {
+	db, repos, metrics, operationLifecycle := deps.db, deps.repos, deps.metrics, deps.operations
+	g := &serviceGraph{auth: authService}
+
+	g.adminAuditLog = services.NewAdminAuditLogService(repos.adminAuditLog, authService)
+	g.outboxAdmin = services.NewOutboxAdminService(repos.outbox, authService)
+	g.settings = services.NewSettingsService(db, repos.settings, authService, g.adminAuditLog)
+	g.settings.SetPasswordStatusStore(repos.users)
+	g.settings.SetTwoFactorCoverageStore(repos.twoFactor)
+	g.users = services.NewUserService(repos.users, authService)
+	g.userSubstitutions = services.NewUserSubstitutionService(repos.userSubstitutions, repos.users, authService)
+	g.userSessions = services.NewUserSessionService(repos.userSessions, authService)
+	g.nomenclature = services.NewNomenclatureService(repos.nomenclature, authService)
+	g.references = services.NewReferenceService(repos.references, authService)
+	g.documentAccess = services.NewDocumentAccessService(authService, repos.departments, repos.assignments, repos.acknowledgments, repos.documentAccess, repos.documents, repos.incomingDocs, repos.outgoingDocs)
+	g.documentAccessAdmin = services.NewDocumentAccessAdminService(authService, repos.documentAccess, repos.users)
+	g.accessRoles = services.NewAccessRoleService(authService, repos.accessRoles, repos.documentAccess, g.documentAccess, repos.users)
+	g.customDocumentKinds = services.NewCustomDocumentKindService(repos.customDocumentKinds, authService)
+	g.documentKinds = services.NewDocumentKindService(g.documentAccess)
+	g.documentKinds.SetCatalogLoader(g.customDocumentKinds.ReloadCatalog)
+	g.journal = services.NewJournalService(repos.journal, authService, g.documentAccess)
+	g.journal.SetOperationLifecycle(operationLifecycle)
+	g.workingCalendar = services.NewWorkingCalendarService(repos.workingCalendar, authService)
+	citizenAppealDeadlinePolicy := services.NewCitizenAppealDeadlinePolicy(g.settings, g.workingCalendar)
+	citizenAppealQueryHandler := services.NewCitizenAppealQueryHandler(repos.citizenAppeals)
+	citizenAppealQueryHandler.SetDeadlinePolicy(citizenAppealDeadlinePolicy)
+	documentKindQueryRegistry := services.NewDocumentKindQueryRegistry(
+		services.NewIncomingLetterQueryHandler(repos.incomingDocs),
+		services.NewOutgoingLetterQueryHandler(repos.outgoingDocs),
+		citizenAppealQueryHandler,
+		services.NewAdministrativeOrderQueryHandler(repos.administrativeOrders),
+	)
+	documentKindQueryRegistry.SetResolver(services.NewCustomDocumentQueryHandler(repos.customDocumentKinds, repos.customDocuments))
+	g.documentQuery = services.NewDocumentQueryService(documentKindQueryRegistry, g.documentAccess)
+	g.documentQuery.SetOperationMetrics(metrics)
+	g.registerExport = services.NewRegisterExportService(g.documentQuery, authService, g.adminAuditLog)
+	g.registerExport.SetOperationMetrics(metrics)
+	g.printForms = services.NewPrintFormService(g.documentQuery, repos.nomenclature, g.settings, authService)
+	g.printForms.SetOperationMetrics(metrics)
+	g.printForms.SetAcknowledgments(repos.acknowledgments)
+	g.search = services.NewSearchService(repos.documentSearch, authService, g.documentAccess)
+	g.search.SetOperationMetrics(metrics)
+	citizenAppealCommandHandler := services.NewCitizenAppealCommandHandler(repos.citizenAppeals, repos.nomenclature, repos.references, g.journal, g.documentAccess)
+	citizenAppealCommandHandler.SetDeadlinePolicy(citizenAppealDeadlinePolicy)
+	documentKindCommandRegistry := services.NewDocumentKindCommandRegistry(
+		services.NewIncomingLetterCommandHandler(repos.incomingDocs, repos.nomenclature, repos.references, g.journal, g.documentAccess),
+		services.NewOutgoingLetterCommandHandler(repos.outgoingDocs, repos.references, repos.nomenclature, g.journal, g.documentAccess),
+		citizenAppealCommandHandler,
+		services.NewAdministrativeOrderCommandHandler(repos.administrativeOrders, repos.nomenclature, g.journal, g.documentAccess),
+	)
+	documentKindCommandRegistry.SetResolver(services.NewCustomDocumentCommandHandler(repos.customDocumentKinds, repos.customDocuments, g.documentAccess))
+	g.documentRegistration = services.NewDocumentRegistrationService(documentKindCommandRegistry, authService)
+	g.documentRegistration.SetOperationLifecycle(operationLifecycle)
+	g.documentRegistration.SetOperationMetrics(metrics)
+	g.outgoingApprovals = services.NewOutgoingApprovalService(repos.outgoingApprovals, repos.users, repos.nomenclature, authService, g.documentAccess, documentKindCommandRegistry)
+	g.outgoingApprovals.SetSubstitutionStore(repos.userSubstitutions)
+	g.outgoingApprovals.SetOperationMetrics(metrics)
+	g.userEvents = services.NewUserEventService(repos.userEvents, authService)
+	g.administrativeOrders = services.NewAdministrativeOrderService(repos.administrativeOrders, authService, g.documentAccess)
+	g.citizenAppeals = services.NewCitizenAppealService(repos.citizenAppeals, repos.users, authService, g.documentAccess)
+	g.assignments = services.NewAssignmentService(repos.assignments, repos.users, authService, g.documentAccess, g.userEvents)
+	g.assignments.SetWorkingCalendar(g.workingCalendar)
+	g.departments = services.NewDepartmentService(repos.departments, authService)
+
+	g.attachments = services.NewAttachmentService(repos.attachments, g.settings, authService, deps.fileStorage, g.documentAccess)
+	g.attachments.SetOperationLifecycle(operationLifecycle)
+	g.attachments.SetOperationMetrics(metrics)
+	g.attachments.SetTextStore(repos.attachmentTexts)
+
+	g.dashboard = services.NewDashboardService(repos.dashboard, authService, g.documentAccess)
+	g.dashboard.SetOperationMetrics(metrics)
+	g.dashboard.SetCitizenAppealDeadlines(repos.citizenAppeals, citizenAppealDeadlinePolicy)
+	g.statistics = services.NewStatisticsService(repos.statistics, authService, deps.fileStorage)
+	g.statistics.SetOperationLifecycle(operationLifecycle)
+	g.statistics.SetOperationMetrics(metrics)
+	g.links = services.NewLinkService(repos.links, repos.incomingDocs, repos.outgoingDocs, repos.citizenAppeals, repos.administrativeOrders, g.documentAccess, authService)
+	g.links.SetOperationLifecycle(operationLifecycle)
+	g.links.SetOperationMetrics(metrics)
+	g.acknowledgments = services.NewAcknowledgmentService(repos.acknowledgments, repos.users, authService, g.documentAccess, g.userEvents)
+	g.reminders = services.NewReminderService(repos.reminders, repos.settings, authService)
+	g.emailNotifications = services.NewEmailNotificationService(repos.emailNotifications, authService, repos.settings)
+	return g
+}
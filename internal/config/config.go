@@ -22,7 +22,47 @@ const (
 type Config struct {
 	Database DatabaseConfig `json:"database"`
 	Minio    MinioConfig    `json:"minio"`
+	Storage  StorageConfig  `json:"storage"`
 	Seq      SeqConfig      `json:"seq"`
+	LDAP     LDAPConfig     `json:"ldap"`
+	SMTP     SMTPConfig     `json:"smtp"`
+}
+
+// Драйверы файлового хранилища вложений.
+const (
+	StorageDriverMinio = "minio"
+	StorageDriverLocal = "local"
+)
+
+// StorageConfig выбирает драйвер файлового хранилища вложений.
+// Пустой драйвер означает MinIO, чтобы существующие config.json продолжали работать.
+type StorageConfig struct {
+	Driver    string `json:"driver"`
+	LocalPath string `json:"localPath"`
+}
+
+// DriverName возвращает нормализованное имя драйвера хранилища.
+func (s StorageConfig) DriverName() string {
+	driver := strings.ToLower(strings.TrimSpace(s.Driver))
+	if driver == "" {
+		return StorageDriverMinio
+	}
+	return driver
+}
+
+// Validate проверяет, что драйвер известен и для локального хранилища задан каталог.
+func (s StorageConfig) Validate() error {
+	switch s.DriverName() {
+	case StorageDriverMinio:
+		return nil
+	case StorageDriverLocal:
+		if strings.TrimSpace(s.LocalPath) == "" {
+			return fmt.Errorf("storage.localPath is required for the local storage driver")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown storage driver %q", s.Driver)
+	}
 }
 
 // SeqConfig хранит настройки подключения к Seq
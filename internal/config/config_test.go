@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -157,3 +158,105 @@ func TestLoadConfig(t *testing.T) {
 		assert.Nil(t, cfg)
 	})
 }
+
+func TestStorageConfigDriver(t *testing.T) {
+	assert.Equal(t, StorageDriverMinio, StorageConfig{}.DriverName())
+	assert.Equal(t, StorageDriverLocal, StorageConfig{Driver: " Local "}.DriverName())
+
+	require.NoError(t, StorageConfig{}.Validate())
+	require.NoError(t, StorageConfig{Driver: "local", LocalPath: "/var/lib/docflow/files"}.Validate())
+	assert.EqualError(t, StorageConfig{Driver: "local"}.Validate(), "storage.localPath is required for the local storage driver")
+	assert.EqualError(t, StorageConfig{Driver: "s3"}.Validate(), `unknown storage driver "s3"`)
+}
+
+func TestLDAPConfig(t *testing.T) {
+	t.Run("disabled config is not validated", func(t *testing.T) {
+		assert.NoError(t, LDAPConfig{}.Validate())
+	})
+
+	t.Run("active directory defaults", func(t *testing.T) {
+		cfg := LDAPConfig{Enabled: true, URL: "ldaps://dc.example.local", BaseDN: "dc=example,dc=local"}
+
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, "sAMAccountName", cfg.LoginAttributeName())
+		assert.Equal(t, "objectGUID", cfg.IDAttributeName())
+		assert.Equal(t, "memberOf", cfg.GroupAttributeName())
+		assert.Equal(t, LDAPLocalLoginAdmins, cfg.LocalLoginMode())
+		assert.Equal(t, time.Hour, cfg.SyncInterval())
+		assert.Contains(t, cfg.UserObjectFilter(), "objectCategory=person")
+	})
+
+	t.Run("negative sync interval disables sync", func(t *testing.T) {
+		assert.Zero(t, LDAPConfig{SyncIntervalMinutes: -1}.SyncInterval())
+		assert.Equal(t, 15*time.Minute, LDAPConfig{SyncIntervalMinutes: 15}.SyncInterval())
+	})
+
+	t.Run("encrypted bind password", func(t *testing.T) {
+		encrypted, err := EncryptPassword("bind-secret")
+		require.NoError(t, err)
+
+		assert.Equal(t, "bind-secret", LDAPConfig{BindPassword: encrypted}.GetBindPassword())
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		valid := LDAPConfig{Enabled: true, URL: "ldap://dc.example.local:389", BaseDN: "dc=example,dc=local"}
+		for name, mutate := range map[string]func(*LDAPConfig){
+			"scheme":      func(c *LDAPConfig) { c.URL = "http://dc.example.local" },
+			"base dn":     func(c *LDAPConfig) { c.BaseDN = " " },
+			"starttls":    func(c *LDAPConfig) { c.URL, c.StartTLS = "ldaps://dc.example.local", true },
+			"local login": func(c *LDAPConfig) { c.LocalLogin = "nobody" },
+			"group":       func(c *LDAPConfig) { c.GroupMappings = []LDAPGroupMapping{{}} },
+			"department":  func(c *LDAPConfig) { c.GroupMappings = []LDAPGroupMapping{{Group: "cn=g", DepartmentID: "sales"}} },
+		} {
+			cfg := valid
+			mutate(&cfg)
+			assert.Error(t, cfg.Validate(), name)
+		}
+	})
+}
+
+func TestSMTPConfig(t *testing.T) {
+	t.Run("disabled config is not validated", func(t *testing.T) {
+		assert.NoError(t, SMTPConfig{}.Validate())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg := SMTPConfig{Enabled: true, Host: "mail.example.local", From: "docflow@example.local"}
+
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, SMTPSecurityNone, cfg.SecurityMode())
+		assert.Equal(t, "mail.example.local:25", cfg.Address())
+		assert.Equal(t, 30*time.Second, cfg.Timeout())
+		assert.Equal(t, 8, cfg.DigestSendHour())
+	})
+
+	t.Run("port follows security mode", func(t *testing.T) {
+		assert.Equal(t, "mail:465", SMTPConfig{Host: "mail", Security: "TLS"}.Address())
+		assert.Equal(t, "mail:587", SMTPConfig{Host: "mail", Security: SMTPSecurityStartTLS}.Address())
+		assert.Equal(t, "mail:2525", SMTPConfig{Host: "mail", Port: 2525, Security: SMTPSecurityStartTLS}.Address())
+	})
+
+	t.Run("encrypted password", func(t *testing.T) {
+		encrypted, err := EncryptPassword("smtp-secret")
+		require.NoError(t, err)
+
+		assert.Equal(t, "smtp-secret", SMTPConfig{Password: encrypted}.GetPassword())
+		assert.Equal(t, "plain", SMTPConfig{Password: "plain"}.GetPassword())
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		valid := SMTPConfig{Enabled: true, Host: "mail.example.local", From: "Документооборот <docflow@example.local>"}
+		require.NoError(t, valid.Validate())
+		for name, mutate := range map[string]func(*SMTPConfig){
+			"host":        func(c *SMTPConfig) { c.Host = " " },
+			"port":        func(c *SMTPConfig) { c.Port = 70000 },
+			"security":    func(c *SMTPConfig) { c.Security = "ssl3" },
+			"from":        func(c *SMTPConfig) { c.From = "docflow" },
+			"digest hour": func(c *SMTPConfig) { c.DigestHour = 24 },
+		} {
+			cfg := valid
+			mutate(&cfg)
+			assert.Error(t, cfg.Validate(), name)
+		}
+	})
+}
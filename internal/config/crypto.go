@@ -5,6 +5,7 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -103,3 +104,31 @@ func EncryptPassword(password string) (string, error) {
 	ciphertext := gcm.Seal(nonce, nonce, []byte(password), nil)
 	return encPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
 }
+
+// HasEncryptionKey сообщает, задан ли ключ шифрования приложения.
+func HasEncryptionKey() bool {
+	return len(encryptionKey) > 0 || rawEncryptionKey != "" || os.Getenv("ENCRYPTION_KEY") != ""
+}
+
+// ErrEncryptionKeyMissing возвращается SecretCipher, когда ключ шифрования не задан.
+var ErrEncryptionKeyMissing = errors.New("encryption key is not set")
+
+// SecretCipher шифрует ключом приложения секреты, хранимые в БД.
+// Без ключа шифрование невозможно: вместо паники возвращается ErrEncryptionKeyMissing.
+type SecretCipher struct{}
+
+// Encrypt шифрует value и возвращает строку с префиксом ENC:.
+func (SecretCipher) Encrypt(value string) (string, error) {
+	if !HasEncryptionKey() {
+		return "", ErrEncryptionKeyMissing
+	}
+	return EncryptPassword(value)
+}
+
+// Decrypt дешифрует значение с префиксом ENC: и возвращает остальные как есть.
+func (SecretCipher) Decrypt(value string) (string, error) {
+	if IsEncrypted(value) && !HasEncryptionKey() {
+		return "", ErrEncryptionKeyMissing
+	}
+	return DecryptPassword(value)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -94,3 +95,50 @@ func TestIsEncrypted(t *testing.T) {
 		t.Fatal("ENC: prefix should be detected as encrypted")
 	}
 }
+
+func TestSecretCipher(t *testing.T) {
+	// Секрет TOTP шифруется ключом приложения; прежние незашифрованные
+	// значения читаются как есть
+	cipher := SecretCipher{}
+	encrypted, err := cipher.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !IsEncrypted(encrypted) || len(encrypted) > 100 {
+		t.Fatalf("unexpected encrypted secret: %s", encrypted)
+	}
+	for _, value := range []string{encrypted, "JBSWY3DPEHPK3PXP"} {
+		decrypted, err := cipher.Decrypt(value)
+		if err != nil {
+			t.Fatalf("Decrypt failed: %v", err)
+		}
+		if decrypted != "JBSWY3DPEHPK3PXP" {
+			t.Fatalf("decrypted mismatch: got %q", decrypted)
+		}
+	}
+	if !HasEncryptionKey() {
+		t.Fatal("encryption key should be reported as set")
+	}
+}
+
+func TestSecretCipher_NoKey(t *testing.T) {
+	// Без ключа шифрование отклоняется явной ошибкой, а не паникой
+	savedRaw, savedKey := rawEncryptionKey, encryptionKey
+	t.Cleanup(func() { rawEncryptionKey, encryptionKey = savedRaw, savedKey })
+	encrypted, err := SecretCipher{}.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	rawEncryptionKey, encryptionKey = "", nil
+	t.Setenv("ENCRYPTION_KEY", "")
+
+	if _, err := (SecretCipher{}).Encrypt("JBSWY3DPEHPK3PXP"); !errors.Is(err, ErrEncryptionKeyMissing) {
+		t.Fatalf("expected ErrEncryptionKeyMissing on encrypt, got %v", err)
+	}
+	if _, err := (SecretCipher{}).Decrypt(encrypted); !errors.Is(err, ErrEncryptionKeyMissing) {
+		t.Fatalf("expected ErrEncryptionKeyMissing on decrypt, got %v", err)
+	}
+	if plain, err := (SecretCipher{}).Decrypt("JBSWY3DPEHPK3PXP"); err != nil || plain != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("plain secret should be returned as is, got %q, %v", plain, err)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Режимы входа локальных учетных записей при подключенном каталоге.
+const (
+	// LDAPLocalLoginAdmins оставляет локальный вход только администраторам
+	// (break-glass на случай недоступности каталога).
+	LDAPLocalLoginAdmins = "admins"
+	// LDAPLocalLoginAll сохраняет локальный вход всем локальным учетным записям.
+	LDAPLocalLoginAll = "all"
+)
+
+const (
+	defaultLDAPUserFilter        = "(&(objectCategory=person)(objectClass=user)(!(userAccountControl:1.2.840.113556.1.4.803:=2)))"
+	defaultLDAPLoginAttribute    = "sAMAccountName"
+	defaultLDAPIDAttribute       = "objectGUID"
+	defaultLDAPFullNameAttribute = "displayName"
+	defaultLDAPGroupAttribute    = "memberOf"
+	defaultLDAPSyncInterval      = time.Hour
+	defaultLDAPTimeout           = 10 * time.Second
+)
+
+// LDAPConfig подключает вход через LDAP / Active Directory.
+// Пустые атрибуты и фильтр принимают значения по умолчанию для AD.
+type LDAPConfig struct {
+	Enabled            bool   `json:"enabled"`
+	URL                string `json:"url"`
+	StartTLS           bool   `json:"startTLS"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	BindDN             string `json:"bindDN"`
+	BindPassword       string `json:"bindPassword"`
+	BaseDN             string `json:"baseDN"`
+	UserFilter         string `json:"userFilter"`
+	LoginAttribute     string `json:"loginAttribute"`
+	IDAttribute        string `json:"idAttribute"`
+	FullNameAttribute  string `json:"fullNameAttribute"`
+	GroupAttribute     string `json:"groupAttribute"`
+	// RequiredGroup — DN группы, членство в которой обязательно для входа.
+	RequiredGroup string             `json:"requiredGroup"`
+	GroupMappings []LDAPGroupMapping `json:"groupMappings"`
+	LocalLogin    string             `json:"localLogin"`
+	// SyncIntervalMinutes задает период синхронизации; отрицательное значение ее отключает.
+	SyncIntervalMinutes int `json:"syncIntervalMinutes"`
+	TimeoutSeconds      int `json:"timeoutSeconds"`
+}
+
+// LDAPGroupMapping сопоставляет группе каталога системные права, подразделение
+// и признак участника документооборота.
+type LDAPGroupMapping struct {
+	Group               string   `json:"group"`
+	SystemPermissions   []string `json:"systemPermissions"`
+	DepartmentID        string   `json:"departmentId"`
+	DocumentParticipant bool     `json:"documentParticipant"`
+}
+
+// GetBindPassword возвращает пароль служебной учетной записи.
+// Если он зашифрован (префикс ENC:), автоматически дешифрует его.
+func (l LDAPConfig) GetBindPassword() string {
+	password := l.BindPassword
+	if decrypted, err := DecryptPassword(l.BindPassword); err == nil {
+		password = decrypted
+	}
+	return password
+}
+
+// UserObjectFilter возвращает фильтр учетных записей пользователей.
+func (l LDAPConfig) UserObjectFilter() string {
+	return valueOrDefault(l.UserFilter, defaultLDAPUserFilter)
+}
+
+// LoginAttributeName возвращает атрибут, по которому ищется логин.
+func (l LDAPConfig) LoginAttributeName() string {
+	return valueOrDefault(l.LoginAttribute, defaultLDAPLoginAttribute)
+}
+
+// IDAttributeName возвращает неизменяемый идентификатор записи каталога.
+func (l LDAPConfig) IDAttributeName() string {
+	return valueOrDefault(l.IDAttribute, defaultLDAPIDAttribute)
+}
+
+// FullNameAttributeName возвращает атрибут с ФИО пользователя.
+func (l LDAPConfig) FullNameAttributeName() string {
+	return valueOrDefault(l.FullNameAttribute, defaultLDAPFullNameAttribute)
+}
+
+// GroupAttributeName возвращает атрибут со списком групп пользователя.
+func (l LDAPConfig) GroupAttributeName() string {
+	return valueOrDefault(l.GroupAttribute, defaultLDAPGroupAttribute)
+}
+
+// LocalLoginMode возвращает нормализованный режим локального входа.
+func (l LDAPConfig) LocalLoginMode() string {
+	return strings.ToLower(valueOrDefault(l.LocalLogin, LDAPLocalLoginAdmins))
+}
+
+// SyncInterval возвращает период синхронизации; 0 — синхронизация отключена.
+func (l LDAPConfig) SyncInterval() time.Duration {
+	switch {
+	case l.SyncIntervalMinutes < 0:
+		return 0
+	case l.SyncIntervalMinutes == 0:
+		return defaultLDAPSyncInterval
+	default:
+		return time.Duration(l.SyncIntervalMinutes) * time.Minute
+	}
+}
+
+// Timeout возвращает таймаут подключения и операций с каталогом.
+func (l LDAPConfig) Timeout() time.Duration {
+	if l.TimeoutSeconds <= 0 {
+		return defaultLDAPTimeout
+	}
+	return time.Duration(l.TimeoutSeconds) * time.Second
+}
+
+// Validate проверяет настройки включенного каталога.
+func (l LDAPConfig) Validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	parsed, err := url.Parse(strings.TrimSpace(l.URL))
+	if err != nil || (parsed.Scheme != "ldap" && parsed.Scheme != "ldaps") || parsed.Host == "" {
+		return fmt.Errorf("ldap.url must be an ldap:// or ldaps:// URL")
+	}
+	if l.StartTLS && parsed.Scheme == "ldaps" {
+		return fmt.Errorf("ldap.startTLS cannot be combined with an ldaps:// URL")
+	}
+	if strings.TrimSpace(l.BaseDN) == "" {
+		return fmt.Errorf("ldap.baseDN is required")
+	}
+	switch l.LocalLoginMode() {
+	case LDAPLocalLoginAdmins, LDAPLocalLoginAll:
+	default:
+		return fmt.Errorf("unknown ldap.localLogin %q", l.LocalLogin)
+	}
+	for i, mapping := range l.GroupMappings {
+		if strings.TrimSpace(mapping.Group) == "" {
+			return fmt.Errorf("ldap.groupMappings[%d].group is required", i)
+		}
+		if mapping.DepartmentID != "" {
+			if _, err := uuid.Parse(mapping.DepartmentID); err != nil {
+				return fmt.Errorf("ldap.groupMappings[%d].departmentId must be a UUID", i)
+			}
+		}
+	}
+	return nil
+}
+
+func valueOrDefault(value, fallback string) string {
+	if trimmed := strings.TrimSpace(value); trimmed != "" {
+		return trimmed
+	}
+	return fallback
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Режимы защиты соединения с SMTP-сервером.
+const (
+	// SMTPSecurityNone — без шифрования; допустимо для локального релея.
+	SMTPSecurityNone = "none"
+	// SMTPSecurityStartTLS — переход на TLS командой STARTTLS.
+	SMTPSecurityStartTLS = "starttls"
+	// SMTPSecurityTLS — TLS с момента подключения (SMTPS).
+	SMTPSecurityTLS = "tls"
+)
+
+const (
+	defaultSMTPTimeout    = 30 * time.Second
+	defaultSMTPDigestHour = 8
+)
+
+// SMTPConfig подключает отправку уведомлений по электронной почте.
+// Письма отправляются через SMTP-сервер организации; пустые порт, режим
+// защиты и час сводки принимают значения по умолчанию.
+type SMTPConfig struct {
+	Enabled            bool   `json:"enabled"`
+	Host               string `json:"host"`
+	Port               int    `json:"port"`
+	Security           string `json:"security"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	Username           string `json:"username"`
+	Password           string `json:"password"`
+	From               string `json:"from"`
+	FromName           string `json:"fromName"`
+	TimeoutSeconds     int    `json:"timeoutSeconds"`
+	// DigestHour — час локального времени, после которого отправляется
+	// ежедневная сводка за прошедший день; 0 — 8 часов.
+	DigestHour int `json:"digestHour"`
+}
+
+// GetPassword возвращает пароль учетной записи SMTP.
+// Если он зашифрован (префикс ENC:), автоматически дешифрует его.
+func (s SMTPConfig) GetPassword() string {
+	password := s.Password
+	if decrypted, err := DecryptPassword(s.Password); err == nil {
+		password = decrypted
+	}
+	return password
+}
+
+// SecurityMode возвращает нормализованный режим защиты соединения.
+func (s SMTPConfig) SecurityMode() string {
+	return strings.ToLower(valueOrDefault(s.Security, SMTPSecurityNone))
+}
+
+// Address возвращает адрес сервера; порт по умолчанию зависит от режима защиты.
+func (s SMTPConfig) Address() string {
+	port := s.Port
+	if port <= 0 {
+		switch s.SecurityMode() {
+		case SMTPSecurityTLS:
+			port = 465
+		case SMTPSecurityStartTLS:
+			port = 587
+		default:
+			port = 25
+		}
+	}
+	return net.JoinHostPort(strings.TrimSpace(s.Host), strconv.Itoa(port))
+}
+
+// Timeout возвращает таймаут подключения и отправки одного письма.
+func (s SMTPConfig) Timeout() time.Duration {
+	if s.TimeoutSeconds <= 0 {
+		return defaultSMTPTimeout
+	}
+	return time.Duration(s.TimeoutSeconds) * time.Second
+}
+
+// DigestSendHour возвращает час отправки ежедневной сводки.
+func (s SMTPConfig) DigestSendHour() int {
+	if s.DigestHour <= 0 {
+		return defaultSMTPDigestHour
+	}
+	return s.DigestHour
+}
+
+// Validate проверяет настройки включенной почты.
+func (s SMTPConfig) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if strings.TrimSpace(s.Host) == "" {
+		return fmt.Errorf("smtp.host is required")
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("smtp.port must be between 1 and 65535")
+	}
+	switch s.SecurityMode() {
+	case SMTPSecurityNone, SMTPSecurityStartTLS, SMTPSecurityTLS:
+	default:
+		return fmt.Errorf("unknown smtp.security %q", s.Security)
+	}
+	if _, err := mail.ParseAddress(strings.TrimSpace(s.From)); err != nil {
+		return fmt.Errorf("smtp.from must be an e-mail address: %w", err)
+	}
+	if s.DigestHour < 0 || s.DigestHour > 23 {
+		return fmt.Errorf("smtp.digestHour must be between 0 and 23")
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+DELETE FROM system_settings
+WHERE key IN (
+    'citizen_appeal_response_days',
+    'citizen_appeal_deadline_working_days',
+    'citizen_appeal_due_soon_days'
+);
+
+DROP TABLE IF EXISTS citizen_appeal_deadline_extensions;
+
+DROP INDEX IF EXISTS idx_citizen_appeal_details_open_due;
+
+ALTER TABLE citizen_appeal_details
+    DROP COLUMN IF EXISTS closed_at,
+    DROP COLUMN IF EXISTS closed_by_document_id,
+    DROP COLUMN IF EXISTS response_due_date;
+
+DROP TABLE IF EXISTS working_calendar_days;
@@ -0,0 +1,73 @@
+-- 11. Citizen appeal response deadlines
+CREATE TABLE working_calendar_days (
+    day DATE PRIMARY KEY,
+    is_working_day BOOLEAN NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE citizen_appeal_details
+    ADD COLUMN response_due_date DATE,
+    ADD COLUMN closed_by_document_id UUID REFERENCES documents (id) ON DELETE SET NULL,
+    ADD COLUMN closed_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE citizen_appeal_details ca
+SET response_due_date = d.registration_date + 30
+FROM documents d
+WHERE d.id = ca.document_id;
+
+-- Existing outgoing replies close their appeals immediately.
+UPDATE citizen_appeal_details ca
+SET (closed_by_document_id, closed_at) = (
+    SELECT reply.id, l.created_at
+    FROM document_links l
+    JOIN documents reply ON reply.id = CASE
+        WHEN l.source_document_id = ca.document_id THEN l.target_document_id
+        ELSE l.source_document_id
+    END
+    WHERE l.link_type = 'reply'
+      AND reply.kind = 'outgoing_letter'
+      AND (l.source_document_id = ca.document_id OR l.target_document_id = ca.document_id)
+    ORDER BY l.created_at, l.id
+    LIMIT 1
+);
+
+ALTER TABLE citizen_appeal_details ALTER COLUMN response_due_date SET NOT NULL;
+
+CREATE INDEX idx_citizen_appeal_details_open_due
+    ON citizen_appeal_details (response_due_date)
+    WHERE closed_by_document_id IS NULL;
+
+CREATE TABLE citizen_appeal_deadline_extensions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    document_id UUID NOT NULL REFERENCES citizen_appeal_details (document_id) ON DELETE CASCADE,
+    previous_due_date DATE NOT NULL,
+    new_due_date DATE NOT NULL,
+    reason TEXT NOT NULL CHECK (btrim(reason) <> ''),
+    approved_by UUID NOT NULL REFERENCES users (id),
+    created_by UUID NOT NULL REFERENCES users (id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (new_due_date > previous_due_date)
+);
+
+CREATE INDEX idx_citizen_appeal_deadline_extensions_document
+    ON citizen_appeal_deadline_extensions (document_id, created_at);
+
+INSERT INTO
+    system_settings (key, value, description)
+VALUES (
+        'citizen_appeal_response_days',
+        '30',
+        'Срок ответа на обращение гражданина (дней)'
+    ),
+    (
+        'citizen_appeal_deadline_working_days',
+        'false',
+        'Считать срок ответа на обращение в рабочих днях'
+    ),
+    (
+        'citizen_appeal_due_soon_days',
+        '5',
+        'За сколько дней до срока обращение считается подходящим к сроку'
+    )
+ON CONFLICT (key) DO NOTHING;
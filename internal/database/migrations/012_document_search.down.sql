@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS document_search_index_organizations ON organizations;
+DROP TRIGGER IF EXISTS document_search_index_order_details ON administrative_order_details;
+DROP TRIGGER IF EXISTS document_search_index_citizen_appeal_details ON citizen_appeal_details;
+DROP TRIGGER IF EXISTS document_search_index_outgoing_details ON outgoing_document_details;
+DROP TRIGGER IF EXISTS document_search_index_incoming_details ON incoming_document_details;
+DROP TRIGGER IF EXISTS document_search_index_resolutions ON document_resolutions;
+DROP TRIGGER IF EXISTS document_search_index_correspondents ON document_correspondent_registrations;
+DROP TRIGGER IF EXISTS document_search_index_documents ON documents;
+
+DROP FUNCTION IF EXISTS document_search_index_refresh_organization();
+DROP FUNCTION IF EXISTS document_search_index_refresh();
+DROP FUNCTION IF EXISTS refresh_document_search_index(UUID);
+DROP FUNCTION IF EXISTS document_search_attachment_text(UUID);
+
+DROP TABLE IF EXISTS document_search_index;
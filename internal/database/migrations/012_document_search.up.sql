@@ -0,0 +1,221 @@
+-- 12. Full-text document search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Денормализованный поисковый индекс: одна строка на документ любого вида.
+CREATE TABLE document_search_index (
+    document_id UUID PRIMARY KEY REFERENCES documents (id) ON DELETE CASCADE,
+    kind VARCHAR(40) NOT NULL,
+    registration_date DATE NOT NULL,
+    numbers_text TEXT NOT NULL DEFAULT '',
+    body_text TEXT NOT NULL DEFAULT '',
+    attachment_text TEXT NOT NULL DEFAULT '',
+    search_vector TSVECTOR NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_document_search_index_vector
+    ON document_search_index USING GIN (search_vector);
+
+CREATE INDEX idx_document_search_index_numbers_trgm
+    ON document_search_index USING GIN (numbers_text gin_trgm_ops);
+
+CREATE INDEX idx_document_search_index_kind_date
+    ON document_search_index (kind, registration_date DESC);
+
+-- Текст вложений в индекс подставляет эта функция. Таблицу attachment_texts
+-- создает миграция 013 и заменяет функцию; до нее текст вложений пуст.
+CREATE OR REPLACE FUNCTION document_search_attachment_text(p_document_id UUID)
+RETURNS TEXT
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT ''::TEXT;
+$$;
+
+-- Веса: A — номера, заявитель и заголовок; B — содержание и корреспонденты;
+-- C — резолюции и реквизиты; D — текст вложений.
+CREATE OR REPLACE FUNCTION refresh_document_search_index(p_document_id UUID)
+RETURNS VOID
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_kind VARCHAR(40);
+    v_registration_date DATE;
+    v_numbers TEXT;
+    v_primary TEXT;
+    v_body TEXT;
+    v_details TEXT;
+    v_attachments TEXT;
+BEGIN
+    SELECT
+        d.kind,
+        d.registration_date,
+        concat_ws(' ',
+            d.registration_number,
+            inc.incoming_number,
+            og.outgoing_number,
+            ord.order_number,
+            (SELECT string_agg(cr.registration_number, ' ' ORDER BY cr.position)
+             FROM document_correspondent_registrations cr
+             WHERE cr.document_id = d.id)
+        ),
+        concat_ws(' ', ca.applicant_full_name, ord.title),
+        concat_ws(' ',
+            d.content,
+            (SELECT string_agg(o.name, ' ' ORDER BY cr.position)
+             FROM document_correspondent_registrations cr
+             JOIN organizations o ON o.id = cr.correspondent_org_id
+             WHERE cr.document_id = d.id),
+            ro.name,
+            og.addressee
+        ),
+        concat_ws(' ',
+            (SELECT string_agg(concat_ws(' ', r.resolution, r.resolution_author, r.resolution_executors), ' ' ORDER BY r.position)
+             FROM document_resolutions r
+             WHERE r.document_id = d.id),
+            inc.sender_signatory,
+            og.sender_signatory,
+            og.sender_executor,
+            ca.registration_address,
+            ca.applicant_category,
+            ca.appeal_type,
+            ord.execution_controller
+        ),
+        document_search_attachment_text(d.id)
+    INTO v_kind, v_registration_date, v_numbers, v_primary, v_body, v_details, v_attachments
+    FROM documents d
+    LEFT JOIN incoming_document_details inc ON inc.document_id = d.id
+    LEFT JOIN outgoing_document_details og ON og.document_id = d.id
+    LEFT JOIN organizations ro ON ro.id = og.recipient_org_id
+    LEFT JOIN citizen_appeal_details ca ON ca.document_id = d.id
+    LEFT JOIN administrative_order_details ord ON ord.document_id = d.id
+    WHERE d.id = p_document_id;
+
+    IF NOT FOUND THEN
+        DELETE FROM document_search_index WHERE document_id = p_document_id;
+        RETURN;
+    END IF;
+
+    -- tsvector ограничен 1 МБ, поэтому текст вложений индексируется с обрезкой.
+    v_attachments := left(v_attachments, 262144);
+
+    INSERT INTO document_search_index (
+        document_id, kind, registration_date, numbers_text, body_text,
+        attachment_text, search_vector, updated_at
+    )
+    VALUES (
+        p_document_id,
+        v_kind,
+        v_registration_date,
+        v_numbers,
+        concat_ws(' ', v_primary, v_body, v_details),
+        v_attachments,
+        setweight(to_tsvector('simple', v_numbers), 'A') ||
+        setweight(to_tsvector('russian', v_primary), 'A') ||
+        setweight(to_tsvector('russian', v_body), 'B') ||
+        setweight(to_tsvector('russian', v_details), 'C') ||
+        setweight(to_tsvector('russian', v_attachments), 'D'),
+        CURRENT_TIMESTAMP
+    )
+    ON CONFLICT (document_id) DO UPDATE SET
+        kind = EXCLUDED.kind,
+        registration_date = EXCLUDED.registration_date,
+        numbers_text = EXCLUDED.numbers_text,
+        body_text = EXCLUDED.body_text,
+        attachment_text = EXCLUDED.attachment_text,
+        search_vector = EXCLUDED.search_vector,
+        updated_at = EXCLUDED.updated_at;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION document_search_index_refresh()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'documents' THEN
+        PERFORM refresh_document_search_index(NEW.id);
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM refresh_document_search_index(OLD.document_id);
+    ELSE
+        PERFORM refresh_document_search_index(NEW.document_id);
+        IF TG_OP = 'UPDATE' AND OLD.document_id IS DISTINCT FROM NEW.document_id THEN
+            PERFORM refresh_document_search_index(OLD.document_id);
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION document_search_index_refresh_organization()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    PERFORM refresh_document_search_index(linked.document_id)
+    FROM (
+        SELECT cr.document_id
+        FROM document_correspondent_registrations cr
+        WHERE cr.correspondent_org_id = NEW.id
+        UNION
+        SELECT og.document_id
+        FROM outgoing_document_details og
+        WHERE og.recipient_org_id = NEW.id
+    ) linked;
+    RETURN NULL;
+END;
+$$;
+
+-- Триггеры отложены до COMMIT: регистрация документа пишет карточку,
+-- реквизиты и корреспондентов несколькими запросами одной транзакции.
+CREATE CONSTRAINT TRIGGER document_search_index_documents
+AFTER INSERT OR UPDATE OF kind, registration_number, registration_date, content ON documents
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh();
+
+CREATE CONSTRAINT TRIGGER document_search_index_correspondents
+AFTER INSERT OR UPDATE OR DELETE ON document_correspondent_registrations
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh();
+
+CREATE CONSTRAINT TRIGGER document_search_index_resolutions
+AFTER INSERT OR UPDATE OR DELETE ON document_resolutions
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh();
+
+CREATE CONSTRAINT TRIGGER document_search_index_incoming_details
+AFTER INSERT OR UPDATE OR DELETE ON incoming_document_details
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh();
+
+CREATE CONSTRAINT TRIGGER document_search_index_outgoing_details
+AFTER INSERT OR UPDATE OR DELETE ON outgoing_document_details
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh();
+
+CREATE CONSTRAINT TRIGGER document_search_index_citizen_appeal_details
+AFTER INSERT OR UPDATE OF applicant_full_name, registration_address, appeal_type, applicant_category OR DELETE
+ON citizen_appeal_details
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh();
+
+CREATE CONSTRAINT TRIGGER document_search_index_order_details
+AFTER INSERT OR UPDATE OF order_number, title, execution_controller OR DELETE
+ON administrative_order_details
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh();
+
+CREATE CONSTRAINT TRIGGER document_search_index_organizations
+AFTER UPDATE OF name ON organizations
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh_organization();
+
+SELECT refresh_document_search_index(id) FROM documents;
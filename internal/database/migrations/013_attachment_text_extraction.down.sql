@@ -0,0 +1,17 @@
+DELETE FROM event_outbox
+WHERE event_type = 'attachment_text_extract'
+  AND processed_at IS NULL;
+
+DROP TRIGGER IF EXISTS document_search_index_attachment_texts ON attachment_texts;
+
+CREATE OR REPLACE FUNCTION document_search_attachment_text(p_document_id UUID)
+RETURNS TEXT
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT ''::TEXT;
+$$;
+
+DROP TABLE IF EXISTS attachment_texts;
+
+SELECT refresh_document_search_index(id) FROM documents;
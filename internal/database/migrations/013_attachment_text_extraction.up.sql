@@ -0,0 +1,56 @@
+-- 13. Attachment text extraction
+-- Извлеченный текст вложений заполняется фоновым обработчиком.
+CREATE TABLE attachment_texts (
+    attachment_id UUID PRIMARY KEY REFERENCES attachments (id) ON DELETE CASCADE,
+    document_id UUID NOT NULL REFERENCES documents (id) ON DELETE CASCADE,
+    content TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (
+        status IN ('pending', 'extracted', 'unsupported', 'failed')
+    ),
+    truncated BOOLEAN NOT NULL DEFAULT false,
+    error TEXT,
+    extracted_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachment_texts_document ON attachment_texts (document_id);
+
+CREATE INDEX idx_attachment_texts_status
+    ON attachment_texts (status)
+    WHERE status IN ('pending', 'failed');
+
+-- Поисковый индекс документов (миграция 012) получает текст вложений.
+CREATE OR REPLACE FUNCTION document_search_attachment_text(p_document_id UUID)
+RETURNS TEXT
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(
+        (SELECT string_agg(at.content, ' ' ORDER BY at.extracted_at, at.attachment_id)
+         FROM attachment_texts at
+         WHERE at.document_id = p_document_id),
+        ''
+    );
+$$;
+
+CREATE CONSTRAINT TRIGGER document_search_index_attachment_texts
+AFTER INSERT OR UPDATE OR DELETE ON attachment_texts
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION document_search_index_refresh();
+
+-- Уже загруженные вложения ставятся в очередь извлечения так же, как новые.
+INSERT INTO attachment_texts (attachment_id, document_id)
+SELECT id, document_id
+FROM attachments
+WHERE deletion_requested_at IS NULL
+ON CONFLICT (attachment_id) DO NOTHING;
+
+INSERT INTO event_outbox (event_type, deduplication_key, payload)
+SELECT
+    'attachment_text_extract',
+    'attachment:' || attachment_id || ':text',
+    jsonb_build_object('attachmentId', attachment_id)
+FROM attachment_texts
+WHERE status = 'pending'
+ON CONFLICT (deduplication_key) DO NOTHING;
@@ -0,0 +1,8 @@
+DELETE FROM user_events WHERE document_id IS NULL;
+ALTER TABLE user_events DROP CONSTRAINT IF EXISTS user_events_document_required;
+ALTER TABLE user_events ALTER COLUMN document_id SET NOT NULL;
+
+DROP TABLE IF EXISTS outgoing_draft_decisions;
+DROP TABLE IF EXISTS outgoing_draft_approvers;
+DROP TABLE IF EXISTS outgoing_draft_stages;
+DROP TABLE IF EXISTS outgoing_drafts;
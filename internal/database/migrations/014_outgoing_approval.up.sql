@@ -0,0 +1,72 @@
+-- 14. Approval route for outgoing letter drafts
+CREATE TABLE outgoing_drafts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    author_id UUID NOT NULL REFERENCES users(id),
+    nomenclature_id UUID NOT NULL REFERENCES nomenclature(id),
+    document_type VARCHAR(100) NOT NULL,
+    recipient_org_name VARCHAR(255) NOT NULL,
+    addressee VARCHAR(255) NOT NULL,
+    outgoing_date DATE NOT NULL,
+    content TEXT NOT NULL,
+    pages_count INT NOT NULL DEFAULT 1,
+    sender_signatory VARCHAR(255) NOT NULL,
+    sender_executor VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (
+        status IN ('draft', 'in_approval', 'returned', 'rejected', 'approved', 'registered')
+    ),
+    round INT NOT NULL DEFAULT 0,
+    revision INT NOT NULL DEFAULT 1,
+    -- Ключ идемпотентности регистрации: повторная попытка после сбоя не создает второй документ.
+    registration_key UUID NOT NULL DEFAULT gen_random_uuid(),
+    document_id UUID REFERENCES documents(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (status <> 'registered' OR document_id IS NOT NULL)
+);
+
+CREATE INDEX idx_outgoing_drafts_author ON outgoing_drafts (author_id, updated_at DESC);
+CREATE INDEX idx_outgoing_drafts_active ON outgoing_drafts (status)
+    WHERE status IN ('in_approval', 'approved');
+
+CREATE TABLE outgoing_draft_stages (
+    draft_id UUID NOT NULL REFERENCES outgoing_drafts(id) ON DELETE CASCADE,
+    position INT NOT NULL CHECK (position > 0),
+    mode VARCHAR(20) NOT NULL CHECK (mode IN ('sequential', 'parallel')),
+    PRIMARY KEY (draft_id, position)
+);
+
+CREATE TABLE outgoing_draft_approvers (
+    draft_id UUID NOT NULL,
+    stage_position INT NOT NULL,
+    position INT NOT NULL CHECK (position > 0),
+    user_id UUID NOT NULL REFERENCES users(id),
+    decision VARCHAR(20) CHECK (decision IN ('approve', 'reject', 'return')),
+    decided_by UUID REFERENCES users(id),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (draft_id, stage_position, user_id),
+    UNIQUE (draft_id, stage_position, position),
+    FOREIGN KEY (draft_id, stage_position)
+        REFERENCES outgoing_draft_stages (draft_id, position) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_outgoing_draft_approvers_user ON outgoing_draft_approvers (user_id);
+
+-- История решений по всем кругам согласования; строки не изменяются.
+CREATE TABLE outgoing_draft_decisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    draft_id UUID NOT NULL REFERENCES outgoing_drafts(id) ON DELETE CASCADE,
+    round INT NOT NULL,
+    stage_position INT NOT NULL,
+    approver_id UUID NOT NULL REFERENCES users(id),
+    decided_by UUID NOT NULL REFERENCES users(id),
+    decision VARCHAR(20) NOT NULL CHECK (decision IN ('approve', 'reject', 'return')),
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_outgoing_draft_decisions_draft ON outgoing_draft_decisions (draft_id, created_at);
+
+-- Уведомления о согласовании относятся к черновику, у которого еще нет документа.
+ALTER TABLE user_events ALTER COLUMN document_id DROP NOT NULL;
+ALTER TABLE user_events ADD CONSTRAINT user_events_document_required
+    CHECK (document_id IS NOT NULL OR entity_type = 'outgoing_draft');
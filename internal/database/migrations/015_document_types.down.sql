@@ -0,0 +1,45 @@
+ALTER TABLE outgoing_drafts ADD COLUMN document_type VARCHAR(100);
+
+UPDATE outgoing_drafts d
+SET document_type = t.name
+FROM document_types t
+WHERE t.id = d.document_type_id;
+
+ALTER TABLE outgoing_drafts
+    ALTER COLUMN document_type SET NOT NULL,
+    DROP COLUMN document_type_id;
+
+ALTER TABLE documents ADD COLUMN document_type VARCHAR(100);
+
+-- Типы, добавленные после миграции, не проходят исходный CHECK и сворачиваются в «Письмо».
+UPDATE documents d
+SET document_type = CASE
+    WHEN t.name IN ('Письмо', 'Договор', 'Акт', 'Счёт', 'Запрос', 'Ответ', 'Уведомление', 'Обращение', 'Приказ') THEN t.name
+    ELSE 'Письмо'
+END
+FROM document_types t
+WHERE t.id = d.document_type_id;
+
+DROP INDEX IF EXISTS idx_documents_document_type;
+
+ALTER TABLE documents
+    DROP CONSTRAINT IF EXISTS documents_document_type_kind_fkey,
+    DROP CONSTRAINT IF EXISTS documents_document_type_id_fkey,
+    DROP COLUMN document_type_id,
+    ALTER COLUMN document_type SET NOT NULL,
+    ADD CONSTRAINT documents_document_type_check CHECK (
+        document_type IN (
+            'Письмо',
+            'Договор',
+            'Акт',
+            'Счёт',
+            'Запрос',
+            'Ответ',
+            'Уведомление',
+            'Обращение',
+            'Приказ'
+        )
+    );
+
+DROP TABLE IF EXISTS document_type_kinds;
+DROP TABLE IF EXISTS document_types;
@@ -0,0 +1,82 @@
+-- 15. Document types reference
+CREATE TABLE document_types (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (btrim(name) <> '')
+);
+
+CREATE UNIQUE INDEX idx_document_types_name ON document_types (lower(name));
+
+-- Виды документов, для которых тип доступен при регистрации.
+CREATE TABLE document_type_kinds (
+    document_type_id UUID NOT NULL REFERENCES document_types(id) ON DELETE CASCADE,
+    kind VARCHAR(40) NOT NULL CHECK (kind IN ('incoming_letter', 'outgoing_letter', 'citizen_appeal', 'administrative_order')),
+    PRIMARY KEY (document_type_id, kind)
+);
+
+CREATE INDEX idx_document_type_kinds_kind ON document_type_kinds (kind);
+
+INSERT INTO document_types (name)
+VALUES
+    ('Письмо'),
+    ('Договор'),
+    ('Акт'),
+    ('Счёт'),
+    ('Запрос'),
+    ('Ответ'),
+    ('Уведомление'),
+    ('Обращение'),
+    ('Приказ');
+
+INSERT INTO document_type_kinds (document_type_id, kind)
+SELECT t.id, k.kind
+FROM document_types t
+CROSS JOIN (VALUES ('incoming_letter'), ('outgoing_letter')) AS k(kind)
+WHERE t.name IN ('Письмо', 'Договор', 'Акт', 'Счёт', 'Запрос', 'Ответ', 'Уведомление');
+
+INSERT INTO document_type_kinds (document_type_id, kind)
+SELECT id, 'citizen_appeal' FROM document_types WHERE name = 'Обращение'
+UNION ALL
+SELECT id, 'administrative_order' FROM document_types WHERE name = 'Приказ';
+
+-- Существующие документы сохраняют свой тип, даже если он не входит в набор по умолчанию для вида.
+INSERT INTO document_type_kinds (document_type_id, kind)
+SELECT DISTINCT t.id, d.kind
+FROM documents d
+JOIN document_types t ON t.name = d.document_type
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE documents ADD COLUMN document_type_id UUID;
+
+UPDATE documents d
+SET document_type_id = t.id
+FROM document_types t
+WHERE t.name = d.document_type;
+
+ALTER TABLE documents
+    ALTER COLUMN document_type_id SET NOT NULL,
+    DROP COLUMN document_type,
+    ADD CONSTRAINT documents_document_type_id_fkey
+        FOREIGN KEY (document_type_id) REFERENCES document_types (id),
+    ADD CONSTRAINT documents_document_type_kind_fkey
+        FOREIGN KEY (document_type_id, kind) REFERENCES document_type_kinds (document_type_id, kind);
+
+CREATE INDEX idx_documents_document_type ON documents (document_type_id);
+
+ALTER TABLE outgoing_drafts ADD COLUMN document_type_id UUID REFERENCES document_types(id);
+
+UPDATE outgoing_drafts d
+SET document_type_id = t.id
+FROM document_types t
+WHERE t.name = d.document_type;
+
+UPDATE outgoing_drafts
+SET document_type_id = (SELECT id FROM document_types WHERE name = 'Письмо')
+WHERE document_type_id IS NULL;
+
+ALTER TABLE outgoing_drafts
+    ALTER COLUMN document_type_id SET NOT NULL,
+    DROP COLUMN document_type;
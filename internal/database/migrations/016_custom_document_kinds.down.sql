@@ -0,0 +1,37 @@
+DROP TABLE IF EXISTS custom_document_details;
+DROP TABLE IF EXISTS document_kind_fields;
+
+-- Документы и дела пользовательских видов не проходят исходные CHECK и удаляются.
+DELETE FROM documents
+WHERE kind NOT IN ('incoming_letter', 'outgoing_letter', 'citizen_appeal', 'administrative_order');
+
+DELETE FROM nomenclature
+WHERE kind_code NOT IN ('incoming_letter', 'outgoing_letter', 'citizen_appeal', 'administrative_order');
+
+DELETE FROM document_kinds WHERE NOT is_system;
+
+ALTER TABLE document_type_kinds
+    DROP CONSTRAINT IF EXISTS document_type_kinds_kind_fkey,
+    ADD CONSTRAINT document_type_kinds_kind_check CHECK (
+        kind IN ('incoming_letter', 'outgoing_letter', 'citizen_appeal', 'administrative_order')
+    );
+
+ALTER TABLE document_permissions
+    DROP CONSTRAINT IF EXISTS document_permissions_kind_code_fkey,
+    ADD CONSTRAINT document_permissions_kind_code_check CHECK (
+        kind_code IN ('incoming_letter', 'outgoing_letter', 'citizen_appeal', 'administrative_order')
+    );
+
+ALTER TABLE nomenclature
+    DROP CONSTRAINT IF EXISTS nomenclature_kind_code_fkey,
+    ADD CONSTRAINT nomenclature_kind_code_check CHECK (
+        kind_code IN ('incoming_letter', 'outgoing_letter', 'citizen_appeal', 'administrative_order')
+    );
+
+ALTER TABLE documents
+    DROP CONSTRAINT IF EXISTS documents_kind_fkey,
+    ADD CONSTRAINT documents_kind_check CHECK (
+        kind IN ('incoming_letter', 'outgoing_letter', 'citizen_appeal', 'administrative_order')
+    );
+
+DROP TABLE IF EXISTS document_kinds;
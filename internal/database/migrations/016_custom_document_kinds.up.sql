@@ -0,0 +1,64 @@
+-- 16. Custom document kinds
+CREATE TABLE document_kinds (
+    code VARCHAR(40) PRIMARY KEY CHECK (code ~ '^[a-z][a-z0-9_]{2,39}$'),
+    name VARCHAR(100) NOT NULL,
+    is_system BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (btrim(name) <> '')
+);
+
+CREATE UNIQUE INDEX idx_document_kinds_name ON document_kinds (lower(name));
+
+INSERT INTO document_kinds (code, name, is_system)
+VALUES
+    ('incoming_letter', 'Входящее письмо', TRUE),
+    ('outgoing_letter', 'Исходящее письмо', TRUE),
+    ('citizen_appeal', 'Обращения граждан', TRUE),
+    ('administrative_order', 'Приказы', TRUE);
+
+-- Перечни видов в CHECK заменяются внешними ключами на справочник видов.
+ALTER TABLE documents
+    DROP CONSTRAINT documents_kind_check,
+    ADD CONSTRAINT documents_kind_fkey FOREIGN KEY (kind) REFERENCES document_kinds (code);
+
+ALTER TABLE nomenclature
+    DROP CONSTRAINT nomenclature_kind_code_check,
+    ADD CONSTRAINT nomenclature_kind_code_fkey FOREIGN KEY (kind_code) REFERENCES document_kinds (code);
+
+ALTER TABLE document_permissions
+    DROP CONSTRAINT document_permissions_kind_code_check,
+    ADD CONSTRAINT document_permissions_kind_code_fkey
+        FOREIGN KEY (kind_code) REFERENCES document_kinds (code) ON DELETE CASCADE;
+
+ALTER TABLE document_type_kinds
+    DROP CONSTRAINT document_type_kinds_kind_check,
+    ADD CONSTRAINT document_type_kinds_kind_fkey
+        FOREIGN KEY (kind) REFERENCES document_kinds (code) ON DELETE CASCADE;
+
+-- Поля карточки пользовательского вида документа.
+CREATE TABLE document_kind_fields (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind_code VARCHAR(40) NOT NULL REFERENCES document_kinds (code) ON DELETE CASCADE,
+    code VARCHAR(40) NOT NULL CHECK (code ~ '^[a-z][a-z0-9_]{0,39}$'),
+    label VARCHAR(100) NOT NULL CHECK (btrim(label) <> ''),
+    field_type VARCHAR(20) NOT NULL CHECK (
+        field_type IN ('text', 'date', 'number', 'enum', 'organization', 'user')
+    ),
+    is_required BOOLEAN NOT NULL DEFAULT FALSE,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (kind_code, code)
+);
+
+CREATE INDEX idx_document_kind_fields_kind_position ON document_kind_fields (kind_code, position);
+
+-- Значения полей хранятся в каноническом строковом виде: даты YYYY-MM-DD, числа без
+-- лишних нулей, ссылки на организации и пользователей — UUID.
+CREATE TABLE custom_document_details (
+    document_id UUID PRIMARY KEY REFERENCES documents (id) ON DELETE CASCADE,
+    field_values JSONB NOT NULL DEFAULT '{}'::jsonb
+);
+
+CREATE INDEX idx_custom_document_details_field_values ON custom_document_details USING GIN (field_values);
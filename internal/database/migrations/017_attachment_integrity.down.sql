@@ -0,0 +1,12 @@
+DELETE FROM admin_audit_log WHERE user_id IS NULL;
+
+ALTER TABLE admin_audit_log
+    ALTER COLUMN user_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_attachments_integrity_due;
+DROP INDEX IF EXISTS idx_attachments_document_sha256;
+
+ALTER TABLE attachments
+    DROP COLUMN IF EXISTS integrity_checked_at,
+    DROP COLUMN IF EXISTS integrity_status,
+    DROP COLUMN IF EXISTS sha256;
@@ -0,0 +1,21 @@
+-- 17. Attachment integrity
+ALTER TABLE attachments
+    ADD COLUMN sha256 CHAR(64),
+    ADD COLUMN integrity_status VARCHAR(20) NOT NULL DEFAULT 'unverified' CHECK (
+        integrity_status IN ('unverified', 'ok', 'corrupted', 'truncated', 'missing')
+    ),
+    ADD COLUMN integrity_checked_at TIMESTAMP WITH TIME ZONE;
+
+-- Повторная загрузка того же содержимого в документ отклоняется. Вложения,
+-- загруженные до появления хэша, получают его при первой успешной проверке.
+CREATE UNIQUE INDEX idx_attachments_document_sha256
+    ON attachments (document_id, sha256)
+    WHERE sha256 IS NOT NULL AND deletion_requested_at IS NULL;
+
+CREATE INDEX idx_attachments_integrity_due
+    ON attachments (integrity_checked_at NULLS FIRST)
+    WHERE deletion_requested_at IS NULL;
+
+-- Записи фоновой проверки целостности не имеют автора-пользователя.
+ALTER TABLE admin_audit_log
+    ALTER COLUMN user_id DROP NOT NULL;
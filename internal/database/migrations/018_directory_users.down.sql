@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_external_id;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS directory_synced_at,
+    DROP COLUMN IF EXISTS external_id,
+    DROP COLUMN IF EXISTS auth_source;
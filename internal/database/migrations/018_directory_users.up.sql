@@ -0,0 +1,11 @@
+-- 18. Directory (LDAP / AD) users
+ALTER TABLE users
+    ADD COLUMN auth_source VARCHAR(20) NOT NULL DEFAULT 'local' CHECK (auth_source IN ('local', 'ldap')),
+    ADD COLUMN external_id TEXT,
+    ADD COLUMN directory_synced_at TIMESTAMP WITH TIME ZONE;
+
+-- Пользователь каталога сопоставляется по неизменяемому идентификатору записи,
+-- а не по логину: логин в каталоге может быть переименован.
+CREATE UNIQUE INDEX idx_users_external_id
+    ON users (auth_source, external_id)
+    WHERE external_id IS NOT NULL;
@@ -0,0 +1,4 @@
+DELETE FROM system_settings
+WHERE key IN ('session_idle_timeout_minutes', 'session_absolute_timeout_hours');
+
+DROP TABLE IF EXISTS user_sessions;
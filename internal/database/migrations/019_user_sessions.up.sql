@@ -0,0 +1,33 @@
+-- 19. Desktop sessions
+CREATE TABLE user_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    host_name VARCHAR(255) NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_activity_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    end_reason VARCHAR(20) CHECK (
+        end_reason IN ('logout', 'expired', 'terminated', 'user_inactive', 'replaced', 'shutdown')
+    ),
+    terminated_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    CHECK ((ended_at IS NULL) = (end_reason IS NULL))
+);
+
+CREATE INDEX idx_user_sessions_started ON user_sessions (started_at DESC);
+CREATE INDEX idx_user_sessions_user ON user_sessions (user_id, started_at DESC);
+CREATE INDEX idx_user_sessions_open ON user_sessions (started_at)
+    WHERE ended_at IS NULL;
+
+INSERT INTO system_settings (key, value, description)
+VALUES
+    (
+        'session_idle_timeout_minutes',
+        '0',
+        'Блокировка сеанса при бездействии (минут, 0 - без блокировки)'
+    ),
+    (
+        'session_absolute_timeout_hours',
+        '0',
+        'Максимальная длительность сеанса (часов, 0 - без ограничения)'
+    )
+ON CONFLICT (key) DO NOTHING;
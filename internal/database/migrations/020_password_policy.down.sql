@@ -0,0 +1,13 @@
+DELETE FROM system_settings
+WHERE key IN (
+    'password_min_length',
+    'password_required_classes',
+    'password_reject_common',
+    'password_history_depth',
+    'password_lockout_threshold',
+    'password_lockout_minutes'
+);
+
+DROP TABLE IF EXISTS user_password_history;
+
+ALTER TABLE users DROP COLUMN IF EXISTS locked_at;
@@ -0,0 +1,52 @@
+-- 20. Password policy
+-- locked_at отличает блокировку после неверных попыток входа от
+-- деактивации администратором; от нее отсчитывается автоматическая
+-- разблокировка.
+ALTER TABLE users ADD COLUMN locked_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE users
+SET locked_at = updated_at
+WHERE is_active = false AND failed_login_attempts >= 5;
+
+CREATE TABLE user_password_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_password_history_user ON user_password_history (user_id, created_at DESC);
+
+INSERT INTO system_settings (key, value, description)
+VALUES
+    (
+        'password_min_length',
+        '8',
+        'Минимальная длина пароля (символов)'
+    ),
+    (
+        'password_required_classes',
+        'upper,lower,digit|special',
+        'Обязательные классы символов пароля (upper, lower, digit, special; альтернативы через |)'
+    ),
+    (
+        'password_reject_common',
+        'false',
+        'Запрет распространенных паролей'
+    ),
+    (
+        'password_history_depth',
+        '0',
+        'Сколько последних паролей нельзя использовать повторно (0 - без проверки)'
+    ),
+    (
+        'password_lockout_threshold',
+        '5',
+        'Неверных попыток входа до блокировки'
+    ),
+    (
+        'password_lockout_minutes',
+        '0',
+        'Автоматическая разблокировка через (минут, 0 - только администратором)'
+    )
+ON CONFLICT (key) DO NOTHING;
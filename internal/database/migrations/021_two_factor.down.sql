@@ -0,0 +1,5 @@
+DELETE FROM system_settings
+WHERE key = 'two_factor_required_permissions';
+
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
@@ -0,0 +1,31 @@
+-- 21. Two-factor authentication (TOTP)
+-- enabled_at IS NULL — подключение начато, но не подтверждено кодом.
+-- last_used_step — последний принятый интервал TOTP; коды интервалов не
+-- новее него отклоняются, чтобы один код нельзя было использовать повторно.
+-- secret шифруется ключом приложения (ENCRYPTION_KEY) и хранится с
+-- префиксом ENC:.
+CREATE TABLE user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE user_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
+
+INSERT INTO system_settings (key, value, description)
+VALUES
+    (
+        'two_factor_required_permissions',
+        '',
+        'Системные права, требующие второго фактора при входе (через запятую, например admin)'
+    )
+ON CONFLICT (key) DO NOTHING;
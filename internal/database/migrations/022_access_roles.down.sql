@@ -0,0 +1,9 @@
+DROP VIEW IF EXISTS user_effective_system_permissions;
+
+DELETE FROM document_permissions
+WHERE subject_type = 'role';
+
+DROP TABLE IF EXISTS department_access_roles;
+DROP TABLE IF EXISTS user_access_roles;
+DROP TABLE IF EXISTS access_role_system_permissions;
+DROP TABLE IF EXISTS access_roles;
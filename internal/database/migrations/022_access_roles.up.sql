@@ -0,0 +1,66 @@
+-- 22. Access roles
+-- Роль — именованный шаблон прав, назначаемый пользователям и подразделениям.
+-- Разрешения роли в матрице доступа хранятся в document_permissions с
+-- subject_type = 'role' и subject_key = id роли; роль только разрешает,
+-- запреты задаются прямыми правилами пользователя или подразделения.
+CREATE TABLE access_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_access_roles_name ON access_roles (lower(name));
+
+-- Право admin ролью не выдается: инвариант «хотя бы один активный
+-- администратор» проверяется по прямым правам в user_system_permissions.
+CREATE TABLE access_role_system_permissions (
+    role_id UUID NOT NULL REFERENCES access_roles (id) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL CHECK (
+        permission IN ('references', 'stats_documents', 'stats_assignments', 'stats_system')
+    ),
+    PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE user_access_roles (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES access_roles (id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_access_roles_role_id ON user_access_roles (role_id);
+
+CREATE TABLE department_access_roles (
+    department_id UUID NOT NULL REFERENCES departments (id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES access_roles (id) ON DELETE CASCADE,
+    PRIMARY KEY (department_id, role_id)
+);
+
+CREATE INDEX idx_department_access_roles_role_id ON department_access_roles (role_id);
+
+-- Действующие системные права: прямые разрешения и права ролей пользователя
+-- и его подразделения. Прямой запрет сильнее любого разрешения.
+CREATE VIEW user_effective_system_permissions AS
+SELECT granted.user_id, granted.permission
+FROM (
+    SELECT usp.user_id, usp.permission
+    FROM user_system_permissions usp
+    WHERE usp.is_allowed = true
+    UNION
+    SELECT uar.user_id, rsp.permission
+    FROM user_access_roles uar
+    JOIN access_role_system_permissions rsp ON rsp.role_id = uar.role_id
+    UNION
+    SELECT u.id, rsp.permission
+    FROM users u
+    JOIN department_access_roles dar ON dar.department_id = u.department_id
+    JOIN access_role_system_permissions rsp ON rsp.role_id = dar.role_id
+) granted
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM user_system_permissions denied
+    WHERE denied.user_id = granted.user_id
+      AND denied.permission = granted.permission
+      AND denied.is_allowed = false
+);
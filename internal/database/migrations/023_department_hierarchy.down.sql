@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS department_hierarchy_acyclic ON departments;
+DROP FUNCTION IF EXISTS ensure_department_hierarchy_acyclic();
+
+DROP INDEX IF EXISTS idx_departments_head_user_id;
+DROP INDEX IF EXISTS idx_departments_parent_id;
+
+ALTER TABLE departments
+    DROP CONSTRAINT IF EXISTS departments_parent_not_self,
+    DROP COLUMN IF EXISTS head_user_id,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,48 @@
+-- 23. Department hierarchy
+-- Подразделения образуют дерево «управление → отдел → сектор». Номенклатура
+-- наследуется вниз: сотрудники видят дела своего подразделения и всех
+-- вышестоящих, руководитель — дополнительно дела всех подчиненных подразделений.
+ALTER TABLE departments
+    ADD COLUMN parent_id UUID REFERENCES departments (id),
+    ADD COLUMN head_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    ADD CONSTRAINT departments_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX idx_departments_parent_id ON departments (parent_id);
+CREATE INDEX idx_departments_head_user_id ON departments (head_user_id);
+
+-- Перенос подразделения под собственного потомка разорвал бы дерево.
+CREATE OR REPLACE FUNCTION ensure_department_hierarchy_acyclic()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+	IF NEW.parent_id IS NULL THEN
+		RETURN NEW;
+	END IF;
+
+	-- Сериализуем переносы: две встречные перестановки по отдельности
+	-- корректны, но вместе образовали бы цикл.
+	PERFORM pg_advisory_xact_lock(78652402);
+
+	IF EXISTS (
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM departments WHERE id = NEW.parent_id
+			UNION
+			SELECT d.id, d.parent_id
+			FROM departments d
+			JOIN ancestors a ON d.id = a.parent_id
+		)
+		SELECT 1 FROM ancestors WHERE id = NEW.id
+	) THEN
+		RAISE EXCEPTION 'department hierarchy must not contain cycles'
+			USING ERRCODE = 'check_violation', CONSTRAINT = 'departments_no_cycle';
+	END IF;
+
+	RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER department_hierarchy_acyclic
+BEFORE INSERT OR UPDATE OF parent_id ON departments
+FOR EACH ROW
+EXECUTE FUNCTION ensure_department_hierarchy_acyclic();
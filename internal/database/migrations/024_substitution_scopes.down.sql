@@ -0,0 +1,24 @@
+ALTER TABLE document_journal DROP COLUMN IF EXISTS on_behalf_of_user_id;
+
+DELETE FROM user_events WHERE entity_type = 'user_substitution';
+ALTER TABLE user_events DROP CONSTRAINT IF EXISTS user_events_document_required;
+ALTER TABLE user_events ADD CONSTRAINT user_events_document_required
+    CHECK (document_id IS NOT NULL OR entity_type = 'outgoing_draft');
+
+DROP INDEX IF EXISTS idx_user_substitutions_expiry;
+
+-- Прежняя схема допускает одно замещение на пользователя: сохраняется
+-- последнее неотмененное, остальная история удаляется.
+DELETE FROM user_substitutions WHERE revoked_at IS NOT NULL;
+DELETE FROM user_substitutions us
+USING user_substitutions newer
+WHERE newer.principal_user_id = us.principal_user_id
+  AND (newer.created_at, newer.id) > (us.created_at, us.id);
+
+ALTER TABLE user_substitutions
+    DROP CONSTRAINT IF EXISTS user_substitutions_scopes_known,
+    DROP COLUMN IF EXISTS expiry_notified_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS scopes,
+    DROP COLUMN IF EXISTS document_kinds,
+    ADD CONSTRAINT user_substitutions_principal_user_id_key UNIQUE (principal_user_id);
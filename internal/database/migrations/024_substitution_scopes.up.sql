@@ -0,0 +1,33 @@
+-- 24. Substitution scopes and history
+-- У пользователя может быть несколько замещающих одновременно, в том числе
+-- на будущие периоды. Записи не заменяются и не удаляются: отмененное
+-- замещение получает revoked_at и остается в истории.
+-- document_kinds и scopes ограничивают полномочия замещающего видами
+-- документов и областями действий; пустой массив — без ограничения.
+ALTER TABLE user_substitutions
+    DROP CONSTRAINT IF EXISTS user_substitutions_principal_user_id_key,
+    ADD COLUMN document_kinds TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN expiry_notified_at TIMESTAMP WITH TIME ZONE,
+    ADD CONSTRAINT user_substitutions_scopes_known CHECK (
+        scopes <@ ARRAY['assignments', 'acknowledgments', 'approvals']::TEXT[]
+    );
+
+-- Уже завершившиеся замещения не должны порождать уведомления об окончании.
+UPDATE user_substitutions
+SET expiry_notified_at = CURRENT_TIMESTAMP
+WHERE ends_at < CURRENT_DATE;
+
+CREATE INDEX idx_user_substitutions_expiry
+    ON user_substitutions (ends_at)
+    WHERE revoked_at IS NULL AND expiry_notified_at IS NULL;
+
+-- Уведомления об окончании замещения не относятся к документу.
+ALTER TABLE user_events DROP CONSTRAINT IF EXISTS user_events_document_required;
+ALTER TABLE user_events ADD CONSTRAINT user_events_document_required
+    CHECK (document_id IS NOT NULL OR entity_type IN ('outgoing_draft', 'user_substitution'));
+
+-- Действие замещающего записывается от его имени с указанием замещаемого.
+ALTER TABLE document_journal
+    ADD COLUMN on_behalf_of_user_id UUID REFERENCES users (id) ON DELETE RESTRICT;
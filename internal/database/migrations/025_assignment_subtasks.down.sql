@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_assignments_parent;
+
+-- Подпоручения становятся самостоятельными поручениями по документу.
+ALTER TABLE assignments
+    DROP CONSTRAINT IF EXISTS assignments_parent_not_self,
+    DROP COLUMN IF EXISTS parent_id;
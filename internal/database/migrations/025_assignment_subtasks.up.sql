@@ -0,0 +1,10 @@
+-- 25. Assignment sub-tasks
+-- Исполнитель поручения может передать его части другим сотрудникам:
+-- подпоручение ссылается на родительское и относится к тому же документу.
+-- Ограничение срока (не позже срока родителя) проверяется сервисом.
+ALTER TABLE assignments
+    ADD COLUMN parent_id UUID REFERENCES assignments (id),
+    ADD CONSTRAINT assignments_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX idx_assignments_parent ON assignments (parent_id)
+    WHERE parent_id IS NOT NULL;
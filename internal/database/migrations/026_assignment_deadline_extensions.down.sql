@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS assignment_deadline_history;
+DROP TABLE IF EXISTS assignment_deadline_extensions;
+ALTER TABLE assignments DROP COLUMN IF EXISTS original_deadline;
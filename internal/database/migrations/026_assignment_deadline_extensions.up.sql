@@ -0,0 +1,43 @@
+-- 26. Assignment deadline extensions
+-- Исполнитель запрашивает продление срока, распорядитель (для подпоручения —
+-- исполнитель родительского поручения) одобряет или отклоняет запрос.
+-- original_deadline хранит первый плановый срок до продлений и правок
+-- распорядителя; NULL — срок не менялся.
+ALTER TABLE assignments ADD COLUMN original_deadline DATE;
+
+CREATE TABLE assignment_deadline_extensions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    assignment_id UUID NOT NULL REFERENCES assignments (id) ON DELETE CASCADE,
+    requested_by UUID NOT NULL REFERENCES users (id),
+    on_behalf_of_user_id UUID REFERENCES users (id),
+    previous_deadline DATE,
+    requested_deadline DATE NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected')),
+    decided_by UUID REFERENCES users (id),
+    decision_comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    decided_at TIMESTAMP WITH TIME ZONE
+);
+
+-- По поручению рассматривается не больше одного запроса одновременно.
+CREATE UNIQUE INDEX idx_assignment_deadline_extensions_pending
+    ON assignment_deadline_extensions (assignment_id)
+    WHERE status = 'pending';
+CREATE INDEX idx_assignment_deadline_extensions_assignment
+    ON assignment_deadline_extensions (assignment_id, created_at);
+
+-- История всех изменений срока: продления и правки распорядителя.
+CREATE TABLE assignment_deadline_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    assignment_id UUID NOT NULL REFERENCES assignments (id) ON DELETE CASCADE,
+    old_deadline DATE,
+    new_deadline DATE,
+    changed_by UUID NOT NULL REFERENCES users (id),
+    extension_id UUID REFERENCES assignment_deadline_extensions (id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_assignment_deadline_history_assignment
+    ON assignment_deadline_history (assignment_id, created_at);
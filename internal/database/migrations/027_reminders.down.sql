@@ -0,0 +1,12 @@
+DELETE FROM system_settings
+WHERE key IN (
+    'reminder_assignment_days_before',
+    'reminder_assignment_on_deadline',
+    'reminder_assignment_overdue_every_days',
+    'reminder_assignment_escalation_days',
+    'reminder_acknowledgment_every_days'
+);
+
+DROP TABLE IF EXISTS user_reminder_mutes;
+
+ALTER TABLE assignments DROP COLUMN IF EXISTS created_by;
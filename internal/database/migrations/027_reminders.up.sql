@@ -0,0 +1,50 @@
+-- 27. Reminders and escalations
+-- created_by — автор поручения; ему уходит эскалация просрочки. Для старых
+-- подпоручений автор — исполнитель родительского поручения, для старых
+-- поручений по документу автор неизвестен.
+ALTER TABLE assignments ADD COLUMN created_by UUID REFERENCES users (id) ON DELETE SET NULL;
+
+UPDATE assignments a
+SET created_by = p.executor_id
+FROM assignments p
+WHERE a.parent_id = p.id;
+
+-- Виды напоминаний, отключенные пользователем. Повторная отправка одного и
+-- того же напоминания исключается ключом дедупликации event_outbox.
+CREATE TABLE user_reminder_mutes (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reminder_kind VARCHAR(50) NOT NULL CHECK (
+        reminder_kind IN ('assignment_deadline', 'assignment_overdue', 'assignment_escalation', 'acknowledgment_pending')
+    ),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, reminder_kind)
+);
+
+INSERT INTO system_settings (key, value, description)
+VALUES
+    (
+        'reminder_assignment_days_before',
+        '3,1',
+        'За сколько дней до срока напоминать исполнителю (через запятую, пусто - не напоминать)'
+    ),
+    (
+        'reminder_assignment_on_deadline',
+        'true',
+        'Напоминать исполнителю в день срока'
+    ),
+    (
+        'reminder_assignment_overdue_every_days',
+        '1',
+        'Периодичность напоминаний о просроченном поручении (дней, 0 - не напоминать)'
+    ),
+    (
+        'reminder_assignment_escalation_days',
+        '3',
+        'Через сколько дней просрочки уведомлять автора поручения и руководителя подразделения (0 - не уведомлять)'
+    ),
+    (
+        'reminder_acknowledgment_every_days',
+        '2',
+        'Периодичность напоминаний о неподтвержденном ознакомлении (дней, 0 - не напоминать)'
+    )
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,7 @@
+DELETE FROM system_settings WHERE key = 'email_notifications_enabled';
+
+DROP INDEX IF EXISTS idx_users_email_digest;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_notifications_address_check;
+ALTER TABLE users DROP COLUMN IF EXISTS email_notifications;
+ALTER TABLE users DROP COLUMN IF EXISTS email;
@@ -0,0 +1,24 @@
+-- 28. E-mail notifications
+-- Адрес и режим почтовых уведомлений пользователя. Письма о событиях и
+-- ежедневные сводки отправляет outbox worker рабочего места, где почта
+-- настроена в config.json.
+ALTER TABLE users ADD COLUMN email VARCHAR(255);
+ALTER TABLE users ADD COLUMN email_notifications VARCHAR(20) NOT NULL DEFAULT 'off' CHECK (
+    email_notifications IN ('off', 'immediate', 'digest')
+);
+ALTER TABLE users ADD CONSTRAINT users_email_notifications_address_check CHECK (
+    email_notifications = 'off' OR NULLIF(email, '') IS NOT NULL
+);
+
+CREATE INDEX idx_users_email_digest ON users (id) WHERE email_notifications = 'digest' AND is_active = true;
+
+-- Общий выключатель почтового канала: письма ставятся в очередь, только когда
+-- он включен, то есть когда есть рабочее место с настроенным SMTP.
+INSERT INTO system_settings (key, value, description)
+VALUES
+    (
+        'email_notifications_enabled',
+        'false',
+        'Почтовые уведомления включены (true, если хотя бы на одном рабочем месте настроен SMTP)'
+    )
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_assignments_series_deadline;
+ALTER TABLE assignments DROP COLUMN IF EXISTS series_deadline;
+ALTER TABLE assignments DROP COLUMN IF EXISTS series_id;
+DROP TABLE IF EXISTS assignment_series;
@@ -0,0 +1,41 @@
+-- 29. Recurring assignments
+-- Серия — повторяющееся поручение по документу. Генератор заранее создает из
+-- нее обычные поручения: срок по расписанию (schedule_kind), создание за
+-- lead_days дней до срока. last_deadline — срок последнего созданного
+-- поручения; следующий срок каждый раз пересчитывается по текущему
+-- производственному календарю. paused_on/resumed_on — даты последней
+-- приостановки и возобновления: сроки внутри паузы пропускаются.
+CREATE TABLE assignment_series (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    document_id UUID NOT NULL REFERENCES documents (id) ON DELETE CASCADE,
+    executor_id UUID NOT NULL REFERENCES users (id),
+    co_executor_ids UUID[] NOT NULL DEFAULT '{}',
+    content TEXT NOT NULL,
+    schedule_kind VARCHAR(20) NOT NULL CHECK (schedule_kind IN ('cron', 'working_day')),
+    cron_expression VARCHAR(100) NOT NULL DEFAULT '',
+    working_day INTEGER NOT NULL DEFAULT 0,
+    month_interval INTEGER NOT NULL DEFAULT 1 CHECK (month_interval BETWEEN 1 AND 12),
+    lead_days INTEGER NOT NULL DEFAULT 0 CHECK (lead_days BETWEEN 0 AND 90),
+    starts_on DATE NOT NULL,
+    ends_on DATE,
+    last_deadline DATE,
+    paused_on DATE,
+    resumed_on DATE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'paused', 'stopped', 'finished')),
+    created_by UUID NOT NULL REFERENCES users (id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (ends_on IS NULL OR ends_on >= starts_on)
+);
+
+CREATE INDEX idx_assignment_series_document ON assignment_series (document_id);
+CREATE INDEX idx_assignment_series_active ON assignment_series (status) WHERE status = 'active';
+
+-- Поручение серии. series_deadline — срок по расписанию, он не меняется при
+-- продлении срока поручения и уникален в серии: повторный проход генератора
+-- не создаст второе поручение на тот же срок.
+ALTER TABLE assignments
+    ADD COLUMN series_id UUID REFERENCES assignment_series (id) ON DELETE SET NULL,
+    ADD COLUMN series_deadline DATE;
+CREATE UNIQUE INDEX idx_assignments_series_deadline ON assignments (series_id, series_deadline) WHERE series_id IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_acknowledgments_open_deadline;
+ALTER TABLE acknowledgment_users DROP COLUMN IF EXISTS confirmed_by;
+ALTER TABLE acknowledgment_users DROP COLUMN IF EXISTS viewed_by;
+ALTER TABLE acknowledgments DROP COLUMN IF EXISTS deadline;
@@ -0,0 +1,12 @@
+-- 30. Acknowledgment deadlines
+-- deadline — необязательный срок ознакомления; задача просрочена, если к
+-- этой дате ознакомились не все. viewed_by и confirmed_by заполняются, когда
+-- просмотр или подтверждение выполнил замещающий, а не сам пользователь.
+ALTER TABLE acknowledgments ADD COLUMN deadline DATE;
+
+ALTER TABLE acknowledgment_users
+    ADD COLUMN viewed_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    ADD COLUMN confirmed_by UUID REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_acknowledgments_open_deadline ON acknowledgments (deadline)
+    WHERE completed_at IS NULL AND deadline IS NOT NULL;
@@ -201,8 +201,8 @@ func TestDB_MigratorCloseKeepsSharedDatabaseOpen(t *testing.T) {
 func TestEmbeddedMigrationsAvailable(t *testing.T) {
 	catalog, err := inspectMigrationCatalog(DefaultMigrationsPath)
 	require.NoError(t, err)
-	assert.Equal(t, 10, catalog.AvailableCount)
-	assert.Equal(t, uint(10), catalog.LatestAvailableVersion)
+	assert.Equal(t, 30, catalog.AvailableCount)
+	assert.Equal(t, uint(30), catalog.LatestAvailableVersion)
 }
 
 func TestInspectMigrationCatalog(t *testing.T) {
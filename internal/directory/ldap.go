@@ -0,0 +1,194 @@
+// Package directory подключает внешние каталоги пользователей (LDAP / Active Directory)
+// для входа и синхронизации учетных записей.
+package directory
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// ldapPageSize — размер страницы при выгрузке пользователей; AD по умолчанию
+// отдает не более 1000 записей на запрос.
+const ldapPageSize = 500
+
+// LDAP проверяет пароли простым bind от имени найденной записи и выгружает
+// пользователей для синхронизации. Каждая операция открывает отдельное
+// соединение: вход выполняется редко, а общий пул потребовал бы повторного
+// bind служебной учетной записью после каждой проверки пароля.
+type LDAP struct {
+	cfg       config.LDAPConfig
+	tlsConfig *tls.Config
+}
+
+// NewLDAP создает клиент каталога по настройкам cfg.
+func NewLDAP(cfg config.LDAPConfig) (*LDAP, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("ldap directory is disabled")
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if parsed, err := url.Parse(cfg.URL); err == nil {
+		tlsConfig.ServerName = parsed.Hostname()
+	}
+	return &LDAP{cfg: cfg, tlsConfig: tlsConfig}, nil
+}
+
+// Authenticate ищет пользователя по логину и проверяет пароль bind-ом от его
+// имени. Неизвестный логин и неверный пароль неразличимы для вызывающего:
+// оба возвращают models.ErrInvalidCredentials.
+func (d *LDAP) Authenticate(ctx context.Context, login, password string) (*models.DirectoryUser, error) {
+	login = strings.TrimSpace(login)
+	// Пустой пароль означает unauthenticated bind, который многие серверы
+	// принимают как успешный, — такой вход недопустим.
+	if login == "" || password == "" {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	conn, release, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	filter := fmt.Sprintf("(&%s(%s=%s))", d.cfg.UserObjectFilter(), d.cfg.LoginAttributeName(), ldap.EscapeFilter(login))
+	result, err := conn.Search(d.searchRequest(filter, 2))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, unavailable(err)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return nil, models.ErrInvalidCredentials
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, models.ErrInvalidCredentials
+		}
+		return nil, unavailable(err)
+	}
+
+	user, ok := d.directoryUser(entry)
+	if !ok {
+		return nil, fmt.Errorf("ldap entry %q has no %s or %s attribute", entry.DN, d.cfg.IDAttributeName(), d.cfg.LoginAttributeName())
+	}
+	return user, nil
+}
+
+// ListUsers возвращает всех пользователей каталога, подходящих под фильтр
+// учетных записей. Записи без идентификатора или логина пропускаются.
+func (d *LDAP) ListUsers(ctx context.Context) ([]models.DirectoryUser, error) {
+	conn, release, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result, err := conn.SearchWithPaging(d.searchRequest(d.cfg.UserObjectFilter(), 0), ldapPageSize)
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	users := make([]models.DirectoryUser, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		if user, ok := d.directoryUser(entry); ok {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+// connect открывает соединение, включает StartTLS и выполняет bind служебной
+// учетной записью. release закрывает соединение; отмена ctx прерывает
+// текущую операцию закрытием соединения.
+func (d *LDAP) connect(ctx context.Context) (*ldap.Conn, func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	dialer := &net.Dialer{Timeout: d.cfg.Timeout()}
+	conn, err := ldap.DialURL(d.cfg.URL, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(d.tlsConfig))
+	if err != nil {
+		return nil, nil, unavailable(err)
+	}
+	conn.SetTimeout(d.cfg.Timeout())
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	release := func() {
+		stop()
+		conn.Close()
+	}
+
+	if d.cfg.StartTLS {
+		if err := conn.StartTLS(d.tlsConfig); err != nil {
+			release()
+			return nil, nil, unavailable(err)
+		}
+	}
+	if d.cfg.BindDN != "" {
+		if err := conn.Bind(d.cfg.BindDN, d.cfg.GetBindPassword()); err != nil {
+			release()
+			return nil, nil, unavailable(fmt.Errorf("service account bind: %w", err))
+		}
+	}
+	return conn, release, nil
+}
+
+func (d *LDAP) searchRequest(filter string, sizeLimit int) *ldap.SearchRequest {
+	return ldap.NewSearchRequest(
+		d.cfg.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, sizeLimit, int(d.cfg.Timeout().Seconds()), false,
+		filter,
+		[]string{d.cfg.IDAttributeName(), d.cfg.LoginAttributeName(), d.cfg.FullNameAttributeName(), d.cfg.GroupAttributeName()},
+		nil,
+	)
+}
+
+func (d *LDAP) directoryUser(entry *ldap.Entry) (*models.DirectoryUser, bool) {
+	user := &models.DirectoryUser{
+		ExternalID: externalID(d.cfg.IDAttributeName(), entry.GetRawAttributeValue(d.cfg.IDAttributeName())),
+		DN:         entry.DN,
+		Login:      strings.TrimSpace(entry.GetAttributeValue(d.cfg.LoginAttributeName())),
+		FullName:   strings.TrimSpace(entry.GetAttributeValue(d.cfg.FullNameAttributeName())),
+		Groups:     entry.GetAttributeValues(d.cfg.GroupAttributeName()),
+	}
+	if user.ExternalID == "" || user.Login == "" {
+		return nil, false
+	}
+	if user.FullName == "" {
+		user.FullName = user.Login
+	}
+	return user, true
+}
+
+// externalID приводит идентификатор записи к строке. objectGUID в AD хранится
+// как 16 байт, первые три поля которых записаны в little-endian.
+func externalID(attribute string, raw []byte) string {
+	if strings.EqualFold(attribute, "objectGUID") && len(raw) == 16 {
+		b := make([]byte, 16)
+		copy(b, raw)
+		b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+		b[4], b[5] = b[5], b[4]
+		b[6], b[7] = b[7], b[6]
+		if id, err := uuid.FromBytes(b); err == nil {
+			return id.String()
+		}
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+func unavailable(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", models.ErrDirectoryUnavailable, err)
+}
@@ -0,0 +1,115 @@
+package directory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/testutil/ldapstub"
+)
+
+const (
+	testBaseDN    = "dc=example,dc=local"
+	testServiceDN = "cn=svc-docflow,ou=service,dc=example,dc=local"
+	testGroupDN   = "cn=docflow-users,ou=groups,dc=example,dc=local"
+)
+
+func testDirectory(t *testing.T) (*LDAP, *ldapstub.Server) {
+	t.Helper()
+	server := ldapstub.Start(t,
+		ldapstub.Entry{DN: testServiceDN, Password: "service-secret"},
+		ldapstub.Entry{
+			DN:       "cn=Ivanov,ou=staff,dc=example,dc=local",
+			Password: "user-secret",
+			Attributes: map[string][]string{
+				"objectClass":    {"user"},
+				"entryUUID":      {"6f1c2a8e-0c7a-4a57-9c43-2b8f1f0e4d11"},
+				"sAMAccountName": {"ivanov"},
+				"displayName":    {"Иванов Иван Иванович"},
+				"memberOf":       {testGroupDN},
+			},
+		},
+		ldapstub.Entry{
+			DN: "cn=Petrov,ou=staff,dc=example,dc=local",
+			Attributes: map[string][]string{
+				"objectClass":    {"user"},
+				"entryUUID":      {"0d7f9b35-8f0e-4c55-a7c4-5c0c1c7b9e22"},
+				"sAMAccountName": {"petrov"},
+			},
+		},
+		ldapstub.Entry{
+			DN:         "cn=Printer,ou=staff,dc=example,dc=local",
+			Attributes: map[string][]string{"objectClass": {"device"}, "sAMAccountName": {"printer"}},
+		},
+	)
+	directory, err := NewLDAP(config.LDAPConfig{
+		Enabled:      true,
+		URL:          server.URL(),
+		BindDN:       testServiceDN,
+		BindPassword: "service-secret",
+		BaseDN:       testBaseDN,
+		UserFilter:   "(objectClass=user)",
+		IDAttribute:  "entryUUID",
+	})
+	require.NoError(t, err)
+	return directory, server
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	directory, server := testDirectory(t)
+	ctx := context.Background()
+
+	user, err := directory.Authenticate(ctx, "ivanov", "user-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "6f1c2a8e-0c7a-4a57-9c43-2b8f1f0e4d11", user.ExternalID)
+	assert.Equal(t, "ivanov", user.Login)
+	assert.Equal(t, "Иванов Иван Иванович", user.FullName)
+	assert.Equal(t, []string{testGroupDN}, user.Groups)
+	assert.Equal(t, []string{testServiceDN, "cn=Ivanov,ou=staff,dc=example,dc=local"}, server.Binds())
+
+	_, err = directory.Authenticate(ctx, "ivanov", "wrong")
+	assert.ErrorIs(t, err, models.ErrInvalidCredentials)
+
+	_, err = directory.Authenticate(ctx, "unknown", "user-secret")
+	assert.ErrorIs(t, err, models.ErrInvalidCredentials)
+
+	_, err = directory.Authenticate(ctx, "ivanov", "")
+	assert.ErrorIs(t, err, models.ErrInvalidCredentials, "empty password must not reach an unauthenticated bind")
+
+	_, err = directory.Authenticate(ctx, "*", "user-secret")
+	assert.ErrorIs(t, err, models.ErrInvalidCredentials, "login is escaped in the search filter")
+}
+
+func TestLDAPAuthenticate_DirectoryUnavailable(t *testing.T) {
+	directory, server := testDirectory(t)
+
+	server.SetDown(true)
+	_, err := directory.Authenticate(context.Background(), "ivanov", "user-secret")
+	assert.ErrorIs(t, err, models.ErrDirectoryUnavailable)
+
+	server.Close()
+	_, err = directory.ListUsers(context.Background())
+	assert.ErrorIs(t, err, models.ErrDirectoryUnavailable)
+}
+
+func TestLDAPListUsers(t *testing.T) {
+	directory, _ := testDirectory(t)
+
+	users, err := directory.ListUsers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "ivanov", users[0].Login)
+	assert.Equal(t, "petrov", users[1].Login)
+	assert.Equal(t, "petrov", users[1].FullName, "login is used when the name attribute is empty")
+}
+
+func TestExternalID_ObjectGUID(t *testing.T) {
+	raw := []byte{0x8e, 0x2a, 0x1c, 0x6f, 0x7a, 0x0c, 0x57, 0x4a, 0x9c, 0x43, 0x2b, 0x8f, 0x1f, 0x0e, 0x4d, 0x11}
+
+	assert.Equal(t, "6f1c2a8e-0c7a-4a57-9c43-2b8f1f0e4d11", externalID("objectGUID", raw))
+	assert.Equal(t, "uid-1", externalID("entryUUID", []byte(" uid-1 ")))
+}
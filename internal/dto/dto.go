@@ -29,14 +29,93 @@ type UserSubstitution struct {
 	StartsAt         *time.Time `json:"startsAt,omitempty"`
 	EndsAt           *time.Time `json:"endsAt,omitempty"`
 	IsActive         bool       `json:"isActive"`
-	CreatedAt        time.Time  `json:"createdAt"`
-	UpdatedAt        time.Time  `json:"updatedAt"`
+	DocumentKinds    []string   `json:"documentKinds"`
+	Scopes           []string   `json:"scopes"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	// Status — состояние на сегодня: scheduled, active, expired, disabled или revoked.
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UserSession описывает DTO записи журнала сеансов.
+type UserSession struct {
+	ID               string     `json:"id"`
+	UserID           string     `json:"userId"`
+	UserLogin        string     `json:"userLogin"`
+	UserFullName     string     `json:"userFullName"`
+	HostName         string     `json:"hostName"`
+	StartedAt        time.Time  `json:"startedAt"`
+	LastActivityAt   time.Time  `json:"lastActivityAt"`
+	EndedAt          *time.Time `json:"endedAt,omitempty"`
+	EndReason        string     `json:"endReason,omitempty"`
+	TerminatedByName string     `json:"terminatedByName,omitempty"`
+	IsActive         bool       `json:"isActive"`
+	IsCurrent        bool       `json:"isCurrent"`
+}
+
+// SessionState описывает состояние текущего desktop-сеанса для экрана блокировки.
+type SessionState struct {
+	SessionID          string     `json:"sessionId"`
+	StartedAt          time.Time  `json:"startedAt"`
+	Locked             bool       `json:"locked"`
+	IdleTimeoutSeconds int        `json:"idleTimeoutSeconds"`
+	ExpiresAt          *time.Time `json:"expiresAt,omitempty"`
+}
+
+// TwoFactorStatus описывает состояние второго фактора текущего пользователя.
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabledAt,omitempty"`
+	Required          bool       `json:"required"`
+	RecoveryCodesLeft int        `json:"recoveryCodesLeft"`
+}
+
+// TwoFactorEnrollment — данные для добавления учетной записи в
+// приложение-аутентификатор: QR-код (PNG data URL) или секрет для ручного ввода.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+	QRCode string `json:"qrCode"`
+}
+
+// TwoFactorEnrollmentResult — коды восстановления, показываемые один раз.
+// User заполнен, если подключение завершило вход.
+type TwoFactorEnrollmentResult struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+	User          *User    `json:"user,omitempty"`
+}
+
+// PasswordPolicyReport — оценка того, как предлагаемая политика паролей
+// затронет активных локальных пользователей. StricterPolicy означает, что
+// после сохранения смена пароля потребуется у всех локальных пользователей.
+type PasswordPolicyReport struct {
+	EvaluatedAt         time.Time                  `json:"evaluatedAt"`
+	StricterPolicy      bool                       `json:"stricterPolicy"`
+	TotalUsers          int                        `json:"totalUsers"`
+	ChangeRequired      int                        `json:"changeRequired"`
+	PasswordExpired     int                        `json:"passwordExpired"`
+	LockedOnNextFailure int                        `json:"lockedOnNextFailure"`
+	Users               []PasswordPolicyReportUser `json:"users"`
+}
+
+// PasswordPolicyReportUser — пользователь, которого затронет политика.
+type PasswordPolicyReportUser struct {
+	ID                  string     `json:"id"`
+	Login               string     `json:"login"`
+	FullName            string     `json:"fullName"`
+	ChangeRequired      bool       `json:"changeRequired"`
+	PasswordChangedAt   *time.Time `json:"passwordChangedAt,omitempty"`
+	PasswordExpired     bool       `json:"passwordExpired"`
+	FailedLoginAttempts int        `json:"failedLoginAttempts"`
 }
 
 // Department описывает DTO подразделения.
 type Department struct {
 	ID              string         `json:"id"`
 	Name            string         `json:"name"`
+	ParentID        string         `json:"parentId,omitempty"`
+	HeadUserID      string         `json:"headUserId,omitempty"`
 	NomenclatureIDs []string       `json:"nomenclatureIds"`
 	Nomenclature    []Nomenclature `json:"nomenclature"`
 	CreatedAt       time.Time      `json:"createdAt"`
@@ -67,9 +146,13 @@ type Organization struct {
 
 // DocumentType описывает DTO типа документа.
 type DocumentType struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Kinds      []string  `json:"kinds"`
+	IsActive   bool      `json:"isActive"`
+	UsageCount int       `json:"usageCount"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
 }
 
 // DocumentKind описывает DTO системного вида документа.
@@ -80,6 +163,13 @@ type DocumentKind struct {
 	RegistryGroup        string   `json:"registryGroup"`
 	SupportedActions     []string `json:"supportedActions"`
 	AvailableActions     []string `json:"availableActions"`
+	IsCustom             bool     `json:"isCustom"`
+}
+
+// PrintTemplate описывает DTO шаблона печатной формы документа.
+type PrintTemplate struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
 }
 
 // CurrentAccessSummary описывает текущие права пользователя для навигации и UI.
@@ -116,6 +206,29 @@ type DocumentKindAccessSummary struct {
 	CanOpenPage          bool     `json:"canOpenPage"`
 	CanRegister          bool     `json:"canRegister"`
 	CanReadFull          bool     `json:"canReadFull"`
+	IsCustom             bool     `json:"isCustom"`
+}
+
+// CustomDocumentKindField описывает DTO поля пользовательского вида документа.
+type CustomDocumentKindField struct {
+	ID         string   `json:"id"`
+	Code       string   `json:"code"`
+	Label      string   `json:"label"`
+	FieldType  string   `json:"fieldType"`
+	IsRequired bool     `json:"isRequired"`
+	Options    []string `json:"options"`
+	Position   int      `json:"position"`
+}
+
+// CustomDocumentKind описывает DTO пользовательского вида документа со схемой полей.
+type CustomDocumentKind struct {
+	Code           string                    `json:"code"`
+	Name           string                    `json:"name"`
+	IsActive       bool                      `json:"isActive"`
+	Fields         []CustomDocumentKindField `json:"fields"`
+	DocumentsCount int                       `json:"documentsCount"`
+	CreatedAt      time.Time                 `json:"createdAt"`
+	UpdatedAt      time.Time                 `json:"updatedAt"`
 }
 
 // ResolutionExecutor описывает DTO исполнителя резолюции.
@@ -201,6 +314,13 @@ type CitizenAppealDocument struct {
 	HasEnvelope          bool   `json:"hasEnvelope"`
 	ReceivedFromPOS      bool   `json:"receivedFromPos"`
 
+	ResponseDueDate        *time.Time `json:"responseDueDate,omitempty"`
+	ClosedAt               *time.Time `json:"closedAt,omitempty"`
+	ClosedByDocumentID     string     `json:"closedByDocumentId,omitempty"`
+	ClosedByDocumentNumber string     `json:"closedByDocumentNumber,omitempty"`
+	DeadlineExtensionCount int        `json:"deadlineExtensionCount,omitempty"`
+	DeadlineStatus         string     `json:"deadlineStatus,omitempty"`
+
 	Correspondents []DocumentCorrespondentRegistration `json:"correspondents,omitempty"`
 	Resolutions    []DocumentResolution                `json:"resolutions,omitempty"`
 
@@ -213,6 +333,20 @@ type CitizenAppealDocument struct {
 	AssignmentsCount int `json:"assignmentsCount,omitempty"`
 }
 
+// CitizenAppealDeadlineExtension описывает DTO продления срока ответа на обращение.
+type CitizenAppealDeadlineExtension struct {
+	ID              string    `json:"id"`
+	DocumentID      string    `json:"documentId"`
+	PreviousDueDate time.Time `json:"previousDueDate"`
+	NewDueDate      time.Time `json:"newDueDate"`
+	Reason          string    `json:"reason"`
+	ApprovedBy      string    `json:"approvedBy"`
+	ApprovedByName  string    `json:"approvedByName,omitempty"`
+	CreatedBy       string    `json:"createdBy"`
+	CreatedByName   string    `json:"createdByName,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
 // OutgoingDocument описывает DTO исходящего документа.
 type OutgoingDocument struct {
 	ID               string `json:"id"`
@@ -242,6 +376,95 @@ type OutgoingDocument struct {
 	AttachmentsCount int `json:"attachmentsCount,omitempty"`
 }
 
+// OutgoingDraft описывает DTO черновика исходящего письма на согласовании.
+type OutgoingDraft struct {
+	ID         string `json:"id"`
+	AuthorID   string `json:"authorId"`
+	AuthorName string `json:"authorName,omitempty"`
+
+	NomenclatureID   string    `json:"nomenclatureId"`
+	DocumentTypeID   string    `json:"documentTypeId"`
+	RecipientOrgName string    `json:"recipientOrgName"`
+	Addressee        string    `json:"addressee"`
+	OutgoingDate     time.Time `json:"outgoingDate"`
+	Content          string    `json:"content"`
+	PagesCount       int       `json:"pagesCount"`
+	SenderSignatory  string    `json:"senderSignatory"`
+	SenderExecutor   string    `json:"senderExecutor"`
+
+	Status     string    `json:"status"`
+	Round      int       `json:"round"`
+	DocumentID string    `json:"documentId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	Stages    []ApprovalStage    `json:"stages"`
+	Decisions []ApprovalDecision `json:"decisions"`
+
+	CanEdit   bool `json:"canEdit"`
+	CanDecide bool `json:"canDecide"`
+}
+
+// ApprovalStage описывает DTO этапа маршрута согласования.
+type ApprovalStage struct {
+	Position  int                `json:"position"`
+	Mode      string             `json:"mode"`
+	Approvers []ApprovalApprover `json:"approvers"`
+}
+
+// ApprovalApprover описывает DTO участника этапа согласования.
+type ApprovalApprover struct {
+	UserID        string     `json:"userId"`
+	UserName      string     `json:"userName,omitempty"`
+	Position      int        `json:"position"`
+	Decision      string     `json:"decision,omitempty"`
+	DecidedBy     string     `json:"decidedBy,omitempty"`
+	DecidedByName string     `json:"decidedByName,omitempty"`
+	DecidedAt     *time.Time `json:"decidedAt,omitempty"`
+}
+
+// ApprovalDecision описывает DTO записи истории согласования.
+type ApprovalDecision struct {
+	ID            string    `json:"id"`
+	Round         int       `json:"round"`
+	StagePosition int       `json:"stagePosition"`
+	ApproverID    string    `json:"approverId"`
+	ApproverName  string    `json:"approverName,omitempty"`
+	DecidedBy     string    `json:"decidedBy"`
+	DecidedByName string    `json:"decidedByName,omitempty"`
+	Decision      string    `json:"decision"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// CustomDocumentFieldValue описывает DTO значения поля документа пользовательского вида.
+type CustomDocumentFieldValue struct {
+	Code         string `json:"code"`
+	Label        string `json:"label"`
+	FieldType    string `json:"fieldType"`
+	Value        string `json:"value"`
+	DisplayValue string `json:"displayValue"`
+}
+
+// CustomDocument описывает DTO документа пользовательского вида.
+type CustomDocument struct {
+	ID                 string                     `json:"id"`
+	KindCode           string                     `json:"kindCode"`
+	NomenclatureID     string                     `json:"nomenclatureId"`
+	NomenclatureName   string                     `json:"nomenclatureName,omitempty"`
+	RegistrationNumber string                     `json:"registrationNumber"`
+	RegistrationDate   time.Time                  `json:"registrationDate"`
+	DocumentTypeID     string                     `json:"documentTypeId"`
+	DocumentTypeName   string                     `json:"documentTypeName,omitempty"`
+	Content            string                     `json:"content"`
+	PagesCount         int                        `json:"pagesCount"`
+	Fields             []CustomDocumentFieldValue `json:"fields"`
+	CreatedBy          string                     `json:"createdBy"`
+	CreatedByName      string                     `json:"createdByName,omitempty"`
+	CreatedAt          time.Time                  `json:"createdAt"`
+	UpdatedAt          time.Time                  `json:"updatedAt"`
+}
+
 // AdministrativeOrderDocument описывает DTO приказа.
 type AdministrativeOrderDocument struct {
 	ID               string `json:"id"`
@@ -305,6 +528,7 @@ type DocumentCard struct {
 	OutgoingLetter      *OutgoingDocument            `json:"outgoingLetter,omitempty"`
 	CitizenAppeal       *CitizenAppealDocument       `json:"citizenAppeal,omitempty"`
 	AdministrativeOrder *AdministrativeOrderDocument `json:"administrativeOrder,omitempty"`
+	CustomDocument      *CustomDocument              `json:"customDocument,omitempty"`
 }
 
 // DocumentListItem описывает общую строку списка документов с detail-полями для конкретного вида.
@@ -347,6 +571,10 @@ type DocumentListItem struct {
 	AttachmentPagesCount        int                                       `json:"attachmentPagesCount,omitempty"`
 	HasEnvelope                 bool                                      `json:"hasEnvelope,omitempty"`
 	ReceivedFromPOS             bool                                      `json:"receivedFromPos,omitempty"`
+	ResponseDueDate             *time.Time                                `json:"responseDueDate,omitempty"`
+	ClosedAt                    *time.Time                                `json:"closedAt,omitempty"`
+	ClosedByDocumentNumber      string                                    `json:"closedByDocumentNumber,omitempty"`
+	DeadlineStatus              string                                    `json:"deadlineStatus,omitempty"`
 	OrderNumber                 string                                    `json:"orderNumber,omitempty"`
 	OrderDate                   *time.Time                                `json:"orderDate,omitempty"`
 	Title                       string                                    `json:"title,omitempty"`
@@ -356,6 +584,7 @@ type DocumentListItem struct {
 	CancelledAt                 *time.Time                                `json:"cancelledAt,omitempty"`
 	PendingAcknowledgmentsCount int                                       `json:"pendingAcknowledgmentsCount,omitempty"`
 	AcknowledgmentPeople        []AdministrativeOrderAcknowledgmentPerson `json:"acknowledgmentPeople,omitempty"`
+	CustomFields                []CustomDocumentFieldValue                `json:"customFields,omitempty"`
 }
 
 // DocumentLink описывает DTO связи между документами.
@@ -374,17 +603,60 @@ type DocumentLink struct {
 	TargetSubject string `json:"targetSubject,omitempty"`
 }
 
+// SearchHit описывает DTO найденного документа сквозного поиска.
+type SearchHit struct {
+	DocumentID         string            `json:"documentId"`
+	KindCode           string            `json:"kindCode"`
+	KindName           string            `json:"kindName"`
+	RegistrationNumber string            `json:"registrationNumber"`
+	RegistrationDate   time.Time         `json:"registrationDate"`
+	Content            string            `json:"content"`
+	Rank               float64           `json:"rank"`
+	Highlights         []SearchHighlight `json:"highlights"`
+}
+
+// SearchHighlight описывает фрагмент текста документа или вложения с отмеченными совпадениями.
+type SearchHighlight struct {
+	Source   string                   `json:"source"`
+	Segments []SearchHighlightSegment `json:"segments"`
+}
+
+// SearchHighlightSegment описывает часть фрагмента подсветки; Match отмечает совпадение с запросом.
+type SearchHighlightSegment struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match"`
+}
+
+// SearchResult описывает страницу результатов сквозного поиска.
+type SearchResult struct {
+	Items      []SearchHit `json:"items"`
+	NextOffset int         `json:"nextOffset"`
+	HasMore    bool        `json:"hasMore"`
+}
+
 // Attachment описывает DTO прикрепленного файла.
 type Attachment struct {
-	ID             string    `json:"id"`
-	DocumentID     string    `json:"documentId"`
-	Filename       string    `json:"filename"`
-	Filepath       string    `json:"filepath"`
-	FileSize       int64     `json:"fileSize"`
-	ContentType    string    `json:"contentType"`
-	UploadedBy     string    `json:"uploadedBy"`
-	UploadedByName string    `json:"uploadedByName,omitempty"`
-	UploadedAt     time.Time `json:"uploadedAt"`
+	ID              string    `json:"id"`
+	DocumentID      string    `json:"documentId"`
+	Filename        string    `json:"filename"`
+	Filepath        string    `json:"filepath"`
+	FileSize        int64     `json:"fileSize"`
+	ContentType     string    `json:"contentType"`
+	UploadedBy      string    `json:"uploadedBy"`
+	UploadedByName  string    `json:"uploadedByName,omitempty"`
+	UploadedAt      time.Time `json:"uploadedAt"`
+	SHA256          string    `json:"sha256,omitempty"`
+	IntegrityStatus string    `json:"integrityStatus,omitempty"`
+}
+
+// AttachmentText описывает DTO предпросмотра текста, извлеченного из вложения.
+type AttachmentText struct {
+	AttachmentID string     `json:"attachmentId"`
+	Status       string     `json:"status"`
+	Text         string     `json:"text"`
+	Truncated    bool       `json:"truncated"`
+	Error        string     `json:"error,omitempty"`
+	ExtractedAt  *time.Time `json:"extractedAt,omitempty"`
 }
 
 // Assignment описывает DTO поручения.
@@ -409,10 +681,88 @@ type Assignment struct {
 	CoExecutors   []User   `json:"coExecutors,omitempty"`
 	CoExecutorIDs []string `json:"coExecutorIds,omitempty"`
 
+	ParentID         string       `json:"parentId,omitempty"`
+	SubtaskCount     int          `json:"subtaskCount"`
+	OpenSubtaskCount int          `json:"openSubtaskCount"`
+	Children         []Assignment `json:"children,omitempty"` // дерево подпоручений, только в карточке
+	SeriesID         string       `json:"seriesId,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// AssignmentDeadlineExtension описывает DTO запроса на продление срока поручения.
+type AssignmentDeadlineExtension struct {
+	ID                 string     `json:"id"`
+	AssignmentID       string     `json:"assignmentId"`
+	RequestedBy        string     `json:"requestedBy"`
+	RequestedByName    string     `json:"requestedByName"`
+	OnBehalfOfUserID   string     `json:"onBehalfOfUserId,omitempty"`
+	OnBehalfOfUserName string     `json:"onBehalfOfUserName,omitempty"`
+	PreviousDeadline   *time.Time `json:"previousDeadline,omitempty"`
+	RequestedDeadline  time.Time  `json:"requestedDeadline"`
+	Reason             string     `json:"reason"`
+	Status             string     `json:"status"`
+	DecidedBy          string     `json:"decidedBy,omitempty"`
+	DecidedByName      string     `json:"decidedByName,omitempty"`
+	DecisionComment    string     `json:"decisionComment,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	DecidedAt          *time.Time `json:"decidedAt,omitempty"`
+}
+
+// AssignmentDeadlineChange описывает DTO записи истории срока поручения.
+type AssignmentDeadlineChange struct {
+	ID            string     `json:"id"`
+	OldDeadline   *time.Time `json:"oldDeadline,omitempty"`
+	NewDeadline   *time.Time `json:"newDeadline,omitempty"`
+	ChangedBy     string     `json:"changedBy"`
+	ChangedByName string     `json:"changedByName"`
+	ExtensionID   string     `json:"extensionId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// AssignmentDeadlineHistory описывает DTO истории срока поручения и запросов на продление.
+type AssignmentDeadlineHistory struct {
+	OriginalDeadline *time.Time                    `json:"originalDeadline,omitempty"`
+	Deadline         *time.Time                    `json:"deadline,omitempty"`
+	Extensions       []AssignmentDeadlineExtension `json:"extensions"`
+	Changes          []AssignmentDeadlineChange    `json:"changes"`
+}
+
+// AssignmentSchedule описывает DTO расписания серии поручений.
+type AssignmentSchedule struct {
+	Kind          string `json:"kind"`
+	Cron          string `json:"cron,omitempty"`
+	WorkingDay    int    `json:"workingDay,omitempty"`
+	MonthInterval int    `json:"monthInterval,omitempty"`
+}
+
+// AssignmentSeries описывает DTO серии повторяющихся поручений.
+type AssignmentSeries struct {
+	ID                string             `json:"id"`
+	DocumentID        string             `json:"documentId"`
+	DocumentKind      string             `json:"documentKind"`
+	DocumentNumber    string             `json:"documentNumber,omitempty"`
+	ExecutorID        string             `json:"executorId"`
+	ExecutorName      string             `json:"executorName,omitempty"`
+	CoExecutorIDs     []string           `json:"coExecutorIds,omitempty"`
+	Content           string             `json:"content"`
+	Schedule          AssignmentSchedule `json:"schedule"`
+	ScheduleLabel     string             `json:"scheduleLabel"`
+	LeadDays          int                `json:"leadDays"`
+	StartsOn          time.Time          `json:"startsOn"`
+	EndsOn            *time.Time         `json:"endsOn,omitempty"`
+	LastDeadline      *time.Time         `json:"lastDeadline,omitempty"`
+	NextDeadline      *time.Time         `json:"nextDeadline,omitempty"`
+	Status            string             `json:"status"`
+	CreatedBy         string             `json:"createdBy"`
+	CreatedByName     string             `json:"createdByName,omitempty"`
+	CreatedAt         time.Time          `json:"createdAt"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+	InstanceCount     int                `json:"instanceCount"`
+	OpenInstanceCount int                `json:"openInstanceCount"`
+}
+
 // DashboardActivity описывает оперативные данные главного экрана.
 type DashboardActivity struct {
 	ExpiringAssignments []Assignment `json:"expiringAssignments,omitempty"`
@@ -431,6 +781,8 @@ type Acknowledgment struct {
 	Content     string     `json:"content"`
 	CreatedAt   time.Time  `json:"createdAt"`
 	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Deadline    *time.Time `json:"deadline,omitempty"`
+	Overdue     bool       `json:"overdue"`
 
 	Users   []AcknowledgmentUser `json:"users,omitempty"`
 	UserIDs []string             `json:"userIds,omitempty"`
@@ -444,6 +796,12 @@ type AcknowledgmentUser struct {
 	ViewedAt    *time.Time `json:"viewedAt,omitempty"`
 	ConfirmedAt *time.Time `json:"confirmedAt,omitempty"`
 	CreatedAt   time.Time  `json:"createdAt"`
+
+	// Заместитель, сделавший отметку за пользователя.
+	ViewedBy        string `json:"viewedBy,omitempty"`
+	ViewedByName    string `json:"viewedByName,omitempty"`
+	ConfirmedBy     string `json:"confirmedBy,omitempty"`
+	ConfirmedByName string `json:"confirmedByName,omitempty"`
 }
 
 // PagedResult описывает DTO постраничного результата.
@@ -461,7 +819,7 @@ type UserEvent struct {
 	ID             string     `json:"id"`
 	ActorUserID    string     `json:"actorUserId,omitempty"`
 	ActorUserName  string     `json:"actorUserName,omitempty"`
-	DocumentID     string     `json:"documentId"`
+	DocumentID     string     `json:"documentId,omitempty"`
 	DocumentKind   string     `json:"documentKind"`
 	DocumentNumber string     `json:"documentNumber,omitempty"`
 	EntityType     string     `json:"entityType"`
@@ -476,12 +834,14 @@ type UserEvent struct {
 
 // JournalEntry описывает DTO записи в журнале (истории) документа.
 type JournalEntry struct {
-	ID         string    `json:"id"`
-	DocumentID string    `json:"documentId"`
-	UserName   string    `json:"userName,omitempty"`
-	Action     string    `json:"action"`
-	Details    string    `json:"details"`
-	CreatedAt  time.Time `json:"createdAt"`
+	ID         string `json:"id"`
+	DocumentID string `json:"documentId"`
+	UserName   string `json:"userName,omitempty"`
+	// OnBehalfOfUserName — замещаемый, за которого действовал UserName.
+	OnBehalfOfUserName string    `json:"onBehalfOfUserName,omitempty"`
+	Action             string    `json:"action"`
+	Details            string    `json:"details"`
+	CreatedAt          time.Time `json:"createdAt"`
 }
 
 // AdminAuditLog описывает DTO записи журнала действий администраторов.
@@ -0,0 +1,37 @@
+package dto
+
+import "github.com/Volkov-D-A/docs-register-and-track/internal/models"
+
+func MapOutgoingDraft(m *models.OutgoingDraft) *OutgoingDraft {
+	if m == nil {
+		return nil
+	}
+	res := &OutgoingDraft{ID: m.ID.String(), AuthorID: m.AuthorID.String(), AuthorName: m.AuthorName, NomenclatureID: m.NomenclatureID.String(), DocumentTypeID: m.DocumentTypeID, RecipientOrgName: m.RecipientOrgName, Addressee: m.Addressee, OutgoingDate: m.OutgoingDate, Content: m.Content, PagesCount: m.PagesCount, SenderSignatory: m.SenderSignatory, SenderExecutor: m.SenderExecutor, Status: m.Status, Round: m.Round, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+	if m.DocumentID != nil {
+		res.DocumentID = m.DocumentID.String()
+	}
+	res.Stages = make([]ApprovalStage, len(m.Stages))
+	for i, stage := range m.Stages {
+		res.Stages[i] = MapApprovalStage(stage)
+	}
+	res.Decisions = make([]ApprovalDecision, len(m.Decisions))
+	for i, decision := range m.Decisions {
+		res.Decisions[i] = MapApprovalDecision(decision)
+	}
+	return res
+}
+
+func MapApprovalStage(m models.ApprovalStage) ApprovalStage {
+	res := ApprovalStage{Position: m.Position, Mode: m.Mode, Approvers: make([]ApprovalApprover, len(m.Approvers))}
+	for i, approver := range m.Approvers {
+		res.Approvers[i] = ApprovalApprover{UserID: approver.UserID.String(), UserName: approver.UserName, Position: approver.Position, Decision: approver.Decision, DecidedByName: approver.DecidedByName, DecidedAt: approver.DecidedAt}
+		if approver.DecidedBy != nil {
+			res.Approvers[i].DecidedBy = approver.DecidedBy.String()
+		}
+	}
+	return res
+}
+
+func MapApprovalDecision(m models.ApprovalDecision) ApprovalDecision {
+	return ApprovalDecision{ID: m.ID.String(), Round: m.Round, StagePosition: m.StagePosition, ApproverID: m.ApproverID.String(), ApproverName: m.ApproverName, DecidedBy: m.DecidedBy.String(), DecidedByName: m.DecidedByName, Decision: m.Decision, Comment: m.Comment, CreatedAt: m.CreatedAt}
+}
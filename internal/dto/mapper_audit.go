@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/Volkov-D-A/docs-register-and-track/internal/models"
+import (
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
 
 // MapUserEvent преобразует событие пользователя в DTO.
 func MapUserEvent(m *models.UserEvent) *UserEvent {
@@ -11,7 +15,11 @@ func MapUserEvent(m *models.UserEvent) *UserEvent {
 	if m.ActorUserID != nil {
 		actorUserID = m.ActorUserID.String()
 	}
-	return &UserEvent{ID: m.ID.String(), ActorUserID: actorUserID, ActorUserName: m.ActorUserName, DocumentID: m.DocumentID.String(), DocumentKind: m.DocumentKind, DocumentNumber: m.DocumentNumber, EntityType: m.EntityType, EntityID: m.EntityID.String(), EventType: m.EventType, Title: m.Title, Message: m.Message, Metadata: m.Metadata, CreatedAt: m.CreatedAt, ReadAt: m.ReadAt}
+	documentID := ""
+	if m.DocumentID != uuid.Nil {
+		documentID = m.DocumentID.String()
+	}
+	return &UserEvent{ID: m.ID.String(), ActorUserID: actorUserID, ActorUserName: m.ActorUserName, DocumentID: documentID, DocumentKind: m.DocumentKind, DocumentNumber: m.DocumentNumber, EntityType: m.EntityType, EntityID: m.EntityID.String(), EventType: m.EventType, Title: m.Title, Message: m.Message, Metadata: m.Metadata, CreatedAt: m.CreatedAt, ReadAt: m.ReadAt}
 }
 
 func MapUserEvents(m []models.UserEvent) []UserEvent {
@@ -31,7 +39,7 @@ func MapJournalEntry(m *models.JournalEntry) *JournalEntry {
 	if m == nil {
 		return nil
 	}
-	return &JournalEntry{ID: m.ID.String(), DocumentID: m.DocumentID.String(), UserName: m.UserName, Action: m.Action, Details: m.Details, CreatedAt: m.CreatedAt}
+	return &JournalEntry{ID: m.ID.String(), DocumentID: m.DocumentID.String(), UserName: m.UserName, OnBehalfOfUserName: m.OnBehalfOfUserName, Action: m.Action, Details: m.Details, CreatedAt: m.CreatedAt}
 }
 
 func MapJournalEntries(m []models.JournalEntry) []JournalEntry {
@@ -0,0 +1,152 @@
+package dto
+
+import (
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// MapCustomDocumentKind преобразует пользовательский вид документа в DTO.
+func MapCustomDocumentKind(m *models.CustomDocumentKind) *CustomDocumentKind {
+	if m == nil {
+		return nil
+	}
+	fields := make([]CustomDocumentKindField, len(m.Fields))
+	for i, field := range m.Fields {
+		options := field.Options
+		if options == nil {
+			options = []string{}
+		}
+		fields[i] = CustomDocumentKindField{
+			ID:         field.ID.String(),
+			Code:       field.Code,
+			Label:      field.Label,
+			FieldType:  string(field.FieldType),
+			IsRequired: field.IsRequired,
+			Options:    options,
+			Position:   field.Position,
+		}
+	}
+	return &CustomDocumentKind{
+		Code:           string(m.Code),
+		Name:           m.Name,
+		IsActive:       m.IsActive,
+		Fields:         fields,
+		DocumentsCount: m.DocumentsCount,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
+
+// MapCustomDocumentKinds преобразует список пользовательских видов документов в DTO.
+func MapCustomDocumentKinds(m []models.CustomDocumentKind) []CustomDocumentKind {
+	if m == nil {
+		return nil
+	}
+	res := make([]CustomDocumentKind, 0, len(m))
+	for _, item := range m {
+		res = append(res, *MapCustomDocumentKind(&item))
+	}
+	return res
+}
+
+// MapCustomDocumentFieldValues преобразует значения полей документа пользовательского вида в DTO.
+func MapCustomDocumentFieldValues(m []models.CustomDocumentFieldValue) []CustomDocumentFieldValue {
+	res := make([]CustomDocumentFieldValue, len(m))
+	for i, value := range m {
+		res[i] = CustomDocumentFieldValue{
+			Code:         value.Code,
+			Label:        value.Label,
+			FieldType:    string(value.FieldType),
+			Value:        value.Value,
+			DisplayValue: value.DisplayValue,
+		}
+	}
+	return res
+}
+
+// MapCustomDocument преобразует документ пользовательского вида в DTO.
+func MapCustomDocument(m *models.CustomDocument) *CustomDocument {
+	if m == nil {
+		return nil
+	}
+	return &CustomDocument{
+		ID:                 m.ID.String(),
+		KindCode:           string(m.Kind),
+		NomenclatureID:     m.NomenclatureID.String(),
+		NomenclatureName:   m.NomenclatureName,
+		RegistrationNumber: m.RegistrationNumber,
+		RegistrationDate:   m.RegistrationDate,
+		DocumentTypeID:     m.DocumentTypeID.String(),
+		DocumentTypeName:   m.DocumentTypeName,
+		Content:            m.Content,
+		PagesCount:         m.PagesCount,
+		Fields:             MapCustomDocumentFieldValues(m.Fields),
+		CreatedBy:          m.CreatedBy.String(),
+		CreatedByName:      m.CreatedByName,
+		CreatedAt:          m.CreatedAt,
+		UpdatedAt:          m.UpdatedAt,
+	}
+}
+
+// MapCustomDocumentCard преобразует документ пользовательского вида в общий DTO карточки документа.
+func MapCustomDocumentCard(m *models.CustomDocument) *DocumentCard {
+	if m == nil {
+		return nil
+	}
+	return &DocumentCard{
+		ID:                 m.ID.String(),
+		KindCode:           string(m.Kind),
+		KindName:           m.Kind.Label(),
+		RegistrationNumber: m.RegistrationNumber,
+		RegistrationDate:   m.RegistrationDate,
+		NomenclatureID:     m.NomenclatureID.String(),
+		NomenclatureName:   m.NomenclatureName,
+		DocumentTypeID:     m.DocumentTypeID.String(),
+		DocumentTypeName:   m.DocumentTypeName,
+		Content:            m.Content,
+		CreatedBy:          m.CreatedBy.String(),
+		CreatedByName:      m.CreatedByName,
+		CreatedAt:          m.CreatedAt,
+		UpdatedAt:          m.UpdatedAt,
+		CustomDocument:     MapCustomDocument(m),
+	}
+}
+
+// MapCustomDocumentListItem преобразует документ пользовательского вида в общую строку списка документов.
+func MapCustomDocumentListItem(m *models.CustomDocument) *DocumentListItem {
+	if m == nil {
+		return nil
+	}
+	return &DocumentListItem{
+		ID:                 m.ID.String(),
+		KindCode:           string(m.Kind),
+		KindName:           m.Kind.Label(),
+		RegistrationNumber: m.RegistrationNumber,
+		RegistrationDate:   m.RegistrationDate,
+		NomenclatureID:     m.NomenclatureID.String(),
+		NomenclatureName:   m.NomenclatureName,
+		DocumentTypeID:     m.DocumentTypeID.String(),
+		DocumentTypeName:   m.DocumentTypeName,
+		Content:            m.Content,
+		PagesCount:         m.PagesCount,
+		CreatedBy:          m.CreatedBy.String(),
+		CreatedByName:      m.CreatedByName,
+		CreatedAt:          m.CreatedAt,
+		UpdatedAt:          m.UpdatedAt,
+		CustomFields:       MapCustomDocumentFieldValues(m.Fields),
+	}
+}
+
+// MapDocumentListItemsFromCustomDocuments преобразует документы пользовательского вида в общие строки списка.
+func MapDocumentListItemsFromCustomDocuments(m []models.CustomDocument) []DocumentListItem {
+	if m == nil {
+		return nil
+	}
+	res := make([]DocumentListItem, 0, len(m))
+	for _, item := range m {
+		mapped := MapCustomDocumentListItem(&item)
+		if mapped != nil {
+			res = append(res, *mapped)
+		}
+	}
+	return res
+}
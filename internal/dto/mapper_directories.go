@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/Volkov-D-A/docs-register-and-track/internal/models"
+import (
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
 
 func MapUser(m *models.User) *User {
 	if m == nil {
@@ -16,7 +20,20 @@ func MapUserSubstitution(m *models.UserSubstitution) *UserSubstitution {
 	if m == nil {
 		return nil
 	}
-	return &UserSubstitution{ID: m.ID.String(), PrincipalUserID: m.PrincipalUserID.String(), SubstituteUserID: m.SubstituteUserID.String(), PrincipalName: m.PrincipalName, SubstituteName: m.SubstituteName, StartsAt: m.StartsAt, EndsAt: m.EndsAt, IsActive: m.IsActive, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+	return &UserSubstitution{ID: m.ID.String(), PrincipalUserID: m.PrincipalUserID.String(), SubstituteUserID: m.SubstituteUserID.String(), PrincipalName: m.PrincipalName, SubstituteName: m.SubstituteName, StartsAt: m.StartsAt, EndsAt: m.EndsAt, IsActive: m.IsActive, DocumentKinds: m.DocumentKinds, Scopes: m.Scopes, RevokedAt: m.RevokedAt, Status: m.StatusOn(time.Now()), CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+}
+func MapUserSession(m *models.UserSession) *UserSession {
+	if m == nil {
+		return nil
+	}
+	return &UserSession{ID: m.ID.String(), UserID: m.UserID.String(), UserLogin: m.UserLogin, UserFullName: m.UserFullName, HostName: m.HostName, StartedAt: m.StartedAt, LastActivityAt: m.LastActivityAt, EndedAt: m.EndedAt, EndReason: m.EndReason, TerminatedByName: m.TerminatedByName, IsActive: m.IsActive()}
+}
+func MapUserSessions(items []models.UserSession) []UserSession {
+	result := make([]UserSession, len(items))
+	for i := range items {
+		result[i] = *MapUserSession(&items[i])
+	}
+	return result
 }
 func MapUserSubstitutions(items []models.UserSubstitution) []UserSubstitution {
 	if items == nil {
@@ -39,7 +56,14 @@ func MapDepartment(m *models.Department) *Department {
 			nomenclature[i] = *MapNomenclature(&item)
 		}
 	}
-	return &Department{ID: m.ID.String(), Name: m.Name, NomenclatureIDs: m.NomenclatureIDs, Nomenclature: nomenclature, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+	res := &Department{ID: m.ID.String(), Name: m.Name, NomenclatureIDs: m.NomenclatureIDs, Nomenclature: nomenclature, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+	if m.ParentID != nil {
+		res.ParentID = m.ParentID.String()
+	}
+	if m.HeadUserID != nil {
+		res.HeadUserID = m.HeadUserID.String()
+	}
+	return res
 }
 func MapNomenclature(m *models.Nomenclature) *Nomenclature {
 	if m == nil {
@@ -57,7 +81,11 @@ func MapDocumentType(m *models.DocumentType) *DocumentType {
 	if m == nil {
 		return nil
 	}
-	return &DocumentType{ID: m.ID.String(), Name: m.Name, CreatedAt: m.CreatedAt}
+	kinds := make([]string, len(m.Kinds))
+	for i, kind := range m.Kinds {
+		kinds[i] = string(kind)
+	}
+	return &DocumentType{ID: m.ID.String(), Name: m.Name, Kinds: kinds, IsActive: m.IsActive, UsageCount: m.UsageCount, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
 }
 
 func MapUsers(m []models.User) []User {
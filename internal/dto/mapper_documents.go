@@ -75,34 +75,67 @@ func MapCitizenAppealDocument(m *models.CitizenAppealDocument) *CitizenAppealDoc
 	if m == nil {
 		return nil
 	}
+	closedByDocumentID := ""
+	if m.ClosedByDocumentID != nil {
+		closedByDocumentID = m.ClosedByDocumentID.String()
+	}
 	return &CitizenAppealDocument{
-		ID:                   m.ID.String(),
-		NomenclatureID:       m.NomenclatureID.String(),
-		NomenclatureName:     m.NomenclatureName,
-		RegistrationNumber:   m.RegistrationNumber,
-		RegistrationDate:     m.RegistrationDate,
-		AppealDate:           m.AppealDate,
-		DocumentTypeID:       m.DocumentTypeID,
-		DocumentTypeName:     m.DocumentTypeName,
-		Content:              m.Content,
-		PagesCount:           m.PagesCount,
-		ApplicantFullName:    m.ApplicantFullName,
-		RegistrationAddress:  m.RegistrationAddress,
-		AppealType:           m.AppealType,
-		ApplicantCategory:    m.ApplicantCategory,
-		AppealPagesCount:     m.AppealPagesCount,
-		AttachmentPagesCount: m.AttachmentPagesCount,
-		HasEnvelope:          m.HasEnvelope,
-		ReceivedFromPOS:      m.ReceivedFromPOS,
-		Correspondents:       MapDocumentCorrespondentRegistrations(m.Correspondents),
-		Resolutions:          MapDocumentResolutions(m.Resolutions),
-		CreatedBy:            m.CreatedBy.String(),
-		CreatedByName:        m.CreatedByName,
-		CreatedAt:            m.CreatedAt,
-		UpdatedAt:            m.UpdatedAt,
-		AttachmentsCount:     m.AttachmentsCount,
-		AssignmentsCount:     m.AssignmentsCount,
+		ID:                     m.ID.String(),
+		NomenclatureID:         m.NomenclatureID.String(),
+		NomenclatureName:       m.NomenclatureName,
+		RegistrationNumber:     m.RegistrationNumber,
+		RegistrationDate:       m.RegistrationDate,
+		AppealDate:             m.AppealDate,
+		DocumentTypeID:         m.DocumentTypeID,
+		DocumentTypeName:       m.DocumentTypeName,
+		Content:                m.Content,
+		PagesCount:             m.PagesCount,
+		ApplicantFullName:      m.ApplicantFullName,
+		RegistrationAddress:    m.RegistrationAddress,
+		AppealType:             m.AppealType,
+		ApplicantCategory:      m.ApplicantCategory,
+		AppealPagesCount:       m.AppealPagesCount,
+		AttachmentPagesCount:   m.AttachmentPagesCount,
+		HasEnvelope:            m.HasEnvelope,
+		ReceivedFromPOS:        m.ReceivedFromPOS,
+		ResponseDueDate:        m.ResponseDueDate,
+		ClosedAt:               m.ClosedAt,
+		ClosedByDocumentID:     closedByDocumentID,
+		ClosedByDocumentNumber: m.ClosedByDocumentNumber,
+		DeadlineExtensionCount: m.DeadlineExtensionCount,
+		DeadlineStatus:         m.DeadlineStatus,
+		Correspondents:         MapDocumentCorrespondentRegistrations(m.Correspondents),
+		Resolutions:            MapDocumentResolutions(m.Resolutions),
+		CreatedBy:              m.CreatedBy.String(),
+		CreatedByName:          m.CreatedByName,
+		CreatedAt:              m.CreatedAt,
+		UpdatedAt:              m.UpdatedAt,
+		AttachmentsCount:       m.AttachmentsCount,
+		AssignmentsCount:       m.AssignmentsCount,
+	}
+}
+
+// MapCitizenAppealDeadlineExtensions преобразует историю продлений срока ответа в DTO.
+func MapCitizenAppealDeadlineExtensions(m []models.CitizenAppealDeadlineExtension) []CitizenAppealDeadlineExtension {
+	if m == nil {
+		return nil
 	}
+	res := make([]CitizenAppealDeadlineExtension, len(m))
+	for i, v := range m {
+		res[i] = CitizenAppealDeadlineExtension{
+			ID:              v.ID.String(),
+			DocumentID:      v.DocumentID.String(),
+			PreviousDueDate: v.PreviousDueDate,
+			NewDueDate:      v.NewDueDate,
+			Reason:          v.Reason,
+			ApprovedBy:      v.ApprovedBy.String(),
+			ApprovedByName:  v.ApprovedByName,
+			CreatedBy:       v.CreatedBy.String(),
+			CreatedByName:   v.CreatedByName,
+			CreatedAt:       v.CreatedAt,
+		}
+	}
+	return res
 }
 
 // MapOutgoingDocument преобразует модель OutgoingDocument в DTO.
@@ -200,6 +233,7 @@ func MapDocumentKindSpec(spec models.DocumentKindSpec) *DocumentKind {
 		RegistrationFormCode: spec.RegistrationFormCode,
 		RegistryGroup:        spec.RegistryGroup,
 		SupportedActions:     actions,
+		IsCustom:             spec.IsCustom,
 	}
 }
 
@@ -370,32 +404,36 @@ func MapCitizenAppealDocumentListItem(m *models.CitizenAppealDocument) *Document
 		firstResolution = &m.Resolutions[0]
 	}
 	item := &DocumentListItem{
-		ID:                   m.ID.String(),
-		KindCode:             string(models.DocumentKindCitizenAppeal),
-		KindName:             models.DocumentKindCitizenAppeal.Label(),
-		RegistrationNumber:   m.RegistrationNumber,
-		RegistrationDate:     m.RegistrationDate,
-		NomenclatureID:       m.NomenclatureID.String(),
-		NomenclatureName:     m.NomenclatureName,
-		DocumentTypeID:       m.DocumentTypeID,
-		DocumentTypeName:     m.DocumentTypeName,
-		Content:              m.Content,
-		PagesCount:           m.PagesCount,
-		CreatedBy:            m.CreatedBy.String(),
-		CreatedByName:        m.CreatedByName,
-		CreatedAt:            m.CreatedAt,
-		UpdatedAt:            m.UpdatedAt,
-		AppealDate:           &m.AppealDate,
-		Correspondents:       MapDocumentCorrespondentRegistrations(m.Correspondents),
-		Resolutions:          MapDocumentResolutions(m.Resolutions),
-		ApplicantFullName:    m.ApplicantFullName,
-		RegistrationAddress:  m.RegistrationAddress,
-		AppealType:           m.AppealType,
-		ApplicantCategory:    m.ApplicantCategory,
-		AppealPagesCount:     m.AppealPagesCount,
-		AttachmentPagesCount: m.AttachmentPagesCount,
-		HasEnvelope:          m.HasEnvelope,
-		ReceivedFromPOS:      m.ReceivedFromPOS,
+		ID:                     m.ID.String(),
+		KindCode:               string(models.DocumentKindCitizenAppeal),
+		KindName:               models.DocumentKindCitizenAppeal.Label(),
+		RegistrationNumber:     m.RegistrationNumber,
+		RegistrationDate:       m.RegistrationDate,
+		NomenclatureID:         m.NomenclatureID.String(),
+		NomenclatureName:       m.NomenclatureName,
+		DocumentTypeID:         m.DocumentTypeID,
+		DocumentTypeName:       m.DocumentTypeName,
+		Content:                m.Content,
+		PagesCount:             m.PagesCount,
+		CreatedBy:              m.CreatedBy.String(),
+		CreatedByName:          m.CreatedByName,
+		CreatedAt:              m.CreatedAt,
+		UpdatedAt:              m.UpdatedAt,
+		AppealDate:             &m.AppealDate,
+		Correspondents:         MapDocumentCorrespondentRegistrations(m.Correspondents),
+		Resolutions:            MapDocumentResolutions(m.Resolutions),
+		ApplicantFullName:      m.ApplicantFullName,
+		RegistrationAddress:    m.RegistrationAddress,
+		AppealType:             m.AppealType,
+		ApplicantCategory:      m.ApplicantCategory,
+		AppealPagesCount:       m.AppealPagesCount,
+		AttachmentPagesCount:   m.AttachmentPagesCount,
+		HasEnvelope:            m.HasEnvelope,
+		ReceivedFromPOS:        m.ReceivedFromPOS,
+		ResponseDueDate:        m.ResponseDueDate,
+		ClosedAt:               m.ClosedAt,
+		ClosedByDocumentNumber: m.ClosedByDocumentNumber,
+		DeadlineStatus:         m.DeadlineStatus,
 	}
 	if firstResolution != nil {
 		item.Resolution = firstResolution.Resolution
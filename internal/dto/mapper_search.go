@@ -0,0 +1,34 @@
+package dto
+
+import "github.com/Volkov-D-A/docs-register-and-track/internal/models"
+
+// MapSearchHit преобразует результат поиска в DTO, разбирая подсветку ts_headline на сегменты.
+func MapSearchHit(m *models.DocumentSearchHit) *SearchHit {
+	if m == nil {
+		return nil
+	}
+	highlights := make([]SearchHighlight, 0)
+	highlights = appendSearchHighlights(highlights, models.SearchHighlightDocument, m.Headline)
+	highlights = appendSearchHighlights(highlights, models.SearchHighlightAttachment, m.AttachmentHeadline)
+	return &SearchHit{
+		DocumentID:         m.DocumentID.String(),
+		KindCode:           string(m.Kind),
+		KindName:           m.Kind.Label(),
+		RegistrationNumber: m.RegistrationNumber,
+		RegistrationDate:   m.RegistrationDate,
+		Content:            m.Content,
+		Rank:               m.Rank,
+		Highlights:         highlights,
+	}
+}
+
+func appendSearchHighlights(highlights []SearchHighlight, source, headline string) []SearchHighlight {
+	for _, fragment := range models.ParseSearchHeadline(headline) {
+		segments := make([]SearchHighlightSegment, len(fragment))
+		for i, segment := range fragment {
+			segments[i] = SearchHighlightSegment{Text: segment.Text, Match: segment.Match}
+		}
+		highlights = append(highlights, SearchHighlight{Source: source, Segments: segments})
+	}
+	return highlights
+}
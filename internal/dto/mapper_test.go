@@ -63,6 +63,16 @@ func TestMapDepartment(t *testing.T) {
 		assert.Equal(t, []string{nomID.String()}, d.NomenclatureIDs)
 		assert.Len(t, d.Nomenclature, 1)
 		assert.Equal(t, "Cases", d.Nomenclature[0].Name)
+		assert.Empty(t, d.ParentID)
+		assert.Empty(t, d.HeadUserID)
+	})
+
+	t.Run("hierarchy", func(t *testing.T) {
+		parentID := uuid.New()
+		headID := uuid.New()
+		d := MapDepartment(&models.Department{ID: uuid.New(), Name: "Sector", ParentID: &parentID, HeadUserID: &headID})
+		assert.Equal(t, parentID.String(), d.ParentID)
+		assert.Equal(t, headID.String(), d.HeadUserID)
 	})
 }
 
@@ -106,10 +116,13 @@ func TestMapDocumentType(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		id := uuid.New()
-		m := &models.DocumentType{ID: id, Name: "Type"}
+		m := &models.DocumentType{ID: id, Name: "Type", Kinds: []models.DocumentKind{models.DocumentKindIncomingLetter}, IsActive: true, UsageCount: 3}
 		d := MapDocumentType(m)
 		assert.Equal(t, id.String(), d.ID)
 		assert.Equal(t, "Type", d.Name)
+		assert.Equal(t, []string{"incoming_letter"}, d.Kinds)
+		assert.True(t, d.IsActive)
+		assert.Equal(t, 3, d.UsageCount)
 	})
 }
 
@@ -143,6 +156,22 @@ func TestMapAttachment(t *testing.T) {
 	})
 }
 
+func TestMapAttachmentText(t *testing.T) {
+	// Тестирование маппинга извлеченного текста вложения в DTO
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, MapAttachmentText(nil))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		id := uuid.New()
+		failure := "invalid pdf file"
+		d := MapAttachmentText(&models.AttachmentText{AttachmentID: id, Status: models.AttachmentTextFailed, Error: &failure})
+		assert.Equal(t, id.String(), d.AttachmentID)
+		assert.Equal(t, models.AttachmentTextFailed, d.Status)
+		assert.Equal(t, failure, d.Error)
+	})
+}
+
 func TestMapAcknowledgmentUser(t *testing.T) {
 	// Тестирование маппинга записи ознакомления конкретного пользователя в DTO
 	t.Run("nil", func(t *testing.T) {
@@ -179,6 +208,15 @@ func TestMapAssignment(t *testing.T) {
 		assert.Equal(t, id.String(), d.ID)
 		assert.Len(t, d.CoExecutors, 1)
 		assert.Equal(t, "CoExec", d.CoExecutors[0].FullName)
+		assert.Empty(t, d.ParentID)
+	})
+
+	t.Run("subtask keeps parent and counters", func(t *testing.T) {
+		parentID := uuid.New()
+		d := MapAssignment(&models.Assignment{ID: uuid.New(), ParentID: &parentID, SubtaskCount: 2, OpenSubtaskCount: 1})
+		assert.Equal(t, parentID.String(), d.ParentID)
+		assert.Equal(t, 2, d.SubtaskCount)
+		assert.Equal(t, 1, d.OpenSubtaskCount)
 	})
 }
 
@@ -458,12 +496,13 @@ func TestMapJournalAndAdminAuditLogs(t *testing.T) {
 
 	t.Run("journal", func(t *testing.T) {
 		entry := models.JournalEntry{
-			ID:         uuid.New(),
-			DocumentID: uuid.New(),
-			UserName:   "Регистратор",
-			Action:     "CREATE",
-			Details:    "Создан документ",
-			CreatedAt:  now,
+			ID:                 uuid.New(),
+			DocumentID:         uuid.New(),
+			UserName:           "Регистратор",
+			OnBehalfOfUserName: "Исполнитель",
+			Action:             "CREATE",
+			Details:            "Создан документ",
+			CreatedAt:          now,
 		}
 
 		mapped := MapJournalEntry(&entry)
@@ -472,6 +511,7 @@ func TestMapJournalAndAdminAuditLogs(t *testing.T) {
 		assert.Equal(t, entry.ID.String(), mapped.ID)
 		assert.Equal(t, entry.DocumentID.String(), mapped.DocumentID)
 		assert.Equal(t, entry.UserName, mapped.UserName)
+		assert.Equal(t, entry.OnBehalfOfUserName, mapped.OnBehalfOfUserName)
 		assert.Nil(t, MapJournalEntry(nil))
 		assert.Empty(t, MapJournalEntries(nil))
 
@@ -895,3 +935,58 @@ func TestMapCitizenAppealAndAdministrativeOrderSlices(t *testing.T) {
 		assert.Equal(t, "ORD-1", listItems[0].OrderNumber)
 	})
 }
+
+func TestMapSearchHit(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, MapSearchHit(nil))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		id := uuid.New()
+		d := MapSearchHit(&models.DocumentSearchHit{
+			DocumentID:         id,
+			Kind:               models.DocumentKindCitizenAppeal,
+			RegistrationNumber: "П-12",
+			Rank:               0.5,
+			Headline:           "Начало без совпадений",
+			AttachmentHeadline: "скан \x02жалобы\x03",
+		})
+		require.NotNil(t, d)
+		assert.Equal(t, id.String(), d.DocumentID)
+		assert.Equal(t, "citizen_appeal", d.KindCode)
+		assert.Equal(t, models.DocumentKindCitizenAppeal.Label(), d.KindName)
+		require.Len(t, d.Highlights, 1)
+		assert.Equal(t, models.SearchHighlightAttachment, d.Highlights[0].Source)
+		assert.Equal(t, []SearchHighlightSegment{{Text: "скан "}, {Text: "жалобы", Match: true}}, d.Highlights[0].Segments)
+	})
+}
+
+func TestMapOutgoingDraft(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, MapOutgoingDraft(nil))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		id, approverID, deputyID, documentID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		decidedAt := time.Now()
+		d := MapOutgoingDraft(&models.OutgoingDraft{
+			ID:         id,
+			Status:     models.OutgoingDraftStatusRegistered,
+			Round:      2,
+			DocumentID: &documentID,
+			Stages: []models.ApprovalStage{{Position: 1, Mode: models.ApprovalStageParallel, Approvers: []models.ApprovalApprover{
+				{UserID: approverID, UserName: "Согласующий", Position: 1, Decision: models.ApprovalDecisionApprove, DecidedBy: &deputyID, DecidedByName: "Заместитель", DecidedAt: &decidedAt},
+			}}},
+			Decisions: []models.ApprovalDecision{{ID: uuid.New(), Round: 1, StagePosition: 1, ApproverID: approverID, DecidedBy: approverID, Decision: models.ApprovalDecisionReturn, Comment: "Уточнить"}},
+		})
+		require.NotNil(t, d)
+		assert.Equal(t, id.String(), d.ID)
+		assert.Equal(t, documentID.String(), d.DocumentID)
+		require.Len(t, d.Stages, 1)
+		require.Len(t, d.Stages[0].Approvers, 1)
+		assert.Equal(t, approverID.String(), d.Stages[0].Approvers[0].UserID)
+		assert.Equal(t, deputyID.String(), d.Stages[0].Approvers[0].DecidedBy)
+		require.Len(t, d.Decisions, 1)
+		assert.Equal(t, "Уточнить", d.Decisions[0].Comment)
+	})
+}
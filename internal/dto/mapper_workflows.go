@@ -1,6 +1,12 @@
 package dto
 
-import "github.com/Volkov-D-A/docs-register-and-track/internal/models"
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
 
 func MapDocumentLink(m *models.DocumentLink) *DocumentLink {
 	if m == nil {
@@ -13,7 +19,7 @@ func MapAttachment(m *models.Attachment) *Attachment {
 	if m == nil {
 		return nil
 	}
-	return &Attachment{ID: m.ID.String(), DocumentID: m.DocumentID.String(), Filename: m.Filename, Filepath: m.Filepath, FileSize: m.FileSize, ContentType: m.ContentType, UploadedBy: m.UploadedBy.String(), UploadedByName: m.UploadedByName, UploadedAt: m.UploadedAt}
+	return &Attachment{ID: m.ID.String(), DocumentID: m.DocumentID.String(), Filename: m.Filename, Filepath: m.Filepath, FileSize: m.FileSize, ContentType: m.ContentType, UploadedBy: m.UploadedBy.String(), UploadedByName: m.UploadedByName, UploadedAt: m.UploadedAt, SHA256: m.SHA256, IntegrityStatus: m.IntegrityStatus}
 }
 
 func MapAssignment(m *models.Assignment) *Assignment {
@@ -29,7 +35,43 @@ func MapAssignment(m *models.Assignment) *Assignment {
 			}
 		}
 	}
-	return &Assignment{ID: m.ID.String(), DocumentID: m.DocumentID.String(), DocumentKind: m.DocumentKind, ExecutorID: m.ExecutorID.String(), ExecutorName: m.ExecutorName, Content: m.Content, Deadline: m.Deadline, Status: m.Status, Report: m.Report, CompletedAt: m.CompletedAt, DocumentNumber: m.DocumentNumber, DocumentSubject: m.DocumentSubject, CoExecutors: coExecutors, CoExecutorIDs: m.CoExecutorIDs, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+	var parentID string
+	if m.ParentID != nil {
+		parentID = m.ParentID.String()
+	}
+	return &Assignment{ID: m.ID.String(), DocumentID: m.DocumentID.String(), DocumentKind: m.DocumentKind, ExecutorID: m.ExecutorID.String(), ExecutorName: m.ExecutorName, Content: m.Content, Deadline: m.Deadline, Status: m.Status, Report: m.Report, CompletedAt: m.CompletedAt, DocumentNumber: m.DocumentNumber, DocumentSubject: m.DocumentSubject, CoExecutors: coExecutors, CoExecutorIDs: m.CoExecutorIDs, ParentID: parentID, SubtaskCount: m.SubtaskCount, OpenSubtaskCount: m.OpenSubtaskCount, SeriesID: optionalUUIDString(m.SeriesID), CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+}
+
+func MapAssignmentDeadlineExtension(m *models.AssignmentDeadlineExtension) *AssignmentDeadlineExtension {
+	if m == nil {
+		return nil
+	}
+	return &AssignmentDeadlineExtension{ID: m.ID.String(), AssignmentID: m.AssignmentID.String(), RequestedBy: m.RequestedBy.String(), RequestedByName: m.RequestedByName, OnBehalfOfUserID: optionalUUIDString(m.OnBehalfOfUserID), OnBehalfOfUserName: m.OnBehalfOfUserName, PreviousDeadline: m.PreviousDeadline, RequestedDeadline: m.RequestedDeadline, Reason: m.Reason, Status: m.Status, DecidedBy: optionalUUIDString(m.DecidedBy), DecidedByName: m.DecidedByName, DecisionComment: m.DecisionComment, CreatedAt: m.CreatedAt, DecidedAt: m.DecidedAt}
+}
+
+func MapAssignmentDeadlineExtensions(m []models.AssignmentDeadlineExtension) []AssignmentDeadlineExtension {
+	res := make([]AssignmentDeadlineExtension, len(m))
+	for i := range m {
+		res[i] = *MapAssignmentDeadlineExtension(&m[i])
+	}
+	return res
+}
+
+func MapAssignmentDeadlineChanges(m []models.AssignmentDeadlineChange) []AssignmentDeadlineChange {
+	res := make([]AssignmentDeadlineChange, len(m))
+	for i, change := range m {
+		res[i] = AssignmentDeadlineChange{ID: change.ID.String(), OldDeadline: change.OldDeadline, NewDeadline: change.NewDeadline, ChangedBy: change.ChangedBy.String(), ChangedByName: change.ChangedByName, ExtensionID: optionalUUIDString(change.ExtensionID), CreatedAt: change.CreatedAt}
+	}
+	return res
+}
+
+// MapAssignmentSeries преобразует серию поручений; nextDeadline — срок
+// следующего поручения по текущему производственному календарю.
+func MapAssignmentSeries(m *models.AssignmentSeries, nextDeadline *time.Time) *AssignmentSeries {
+	if m == nil {
+		return nil
+	}
+	return &AssignmentSeries{ID: m.ID.String(), DocumentID: m.DocumentID.String(), DocumentKind: m.DocumentKind, DocumentNumber: m.DocumentNumber, ExecutorID: m.ExecutorID.String(), ExecutorName: m.ExecutorName, CoExecutorIDs: m.CoExecutorIDs, Content: m.Content, Schedule: AssignmentSchedule(m.Schedule), ScheduleLabel: m.Schedule.Label(), LeadDays: m.LeadDays, StartsOn: m.StartsOn, EndsOn: m.EndsOn, LastDeadline: m.LastDeadline, NextDeadline: nextDeadline, Status: m.Status, CreatedBy: m.CreatedBy.String(), CreatedByName: m.CreatedByName, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt, InstanceCount: m.InstanceCount, OpenInstanceCount: m.OpenInstanceCount}
 }
 
 func MapAcknowledgment(m *models.Acknowledgment) *Acknowledgment {
@@ -45,14 +87,14 @@ func MapAcknowledgment(m *models.Acknowledgment) *Acknowledgment {
 			}
 		}
 	}
-	return &Acknowledgment{ID: m.ID.String(), DocumentID: m.DocumentID.String(), DocumentKind: m.DocumentKind, DocumentNumber: m.DocumentNumber, CreatorID: m.CreatorID.String(), CreatorName: m.CreatorName, Content: m.Content, CreatedAt: m.CreatedAt, CompletedAt: m.CompletedAt, Users: users, UserIDs: m.UserIDs}
+	return &Acknowledgment{ID: m.ID.String(), DocumentID: m.DocumentID.String(), DocumentKind: m.DocumentKind, DocumentNumber: m.DocumentNumber, CreatorID: m.CreatorID.String(), CreatorName: m.CreatorName, Content: m.Content, CreatedAt: m.CreatedAt, CompletedAt: m.CompletedAt, Deadline: m.Deadline, Overdue: m.Overdue, Users: users, UserIDs: m.UserIDs}
 }
 
 func MapAcknowledgmentUser(m *models.AcknowledgmentUser) *AcknowledgmentUser {
 	if m == nil {
 		return nil
 	}
-	return &AcknowledgmentUser{ID: m.ID.String(), UserID: m.UserID.String(), UserName: m.UserName, ViewedAt: m.ViewedAt, ConfirmedAt: m.ConfirmedAt, CreatedAt: m.CreatedAt}
+	return &AcknowledgmentUser{ID: m.ID.String(), UserID: m.UserID.String(), UserName: m.UserName, ViewedAt: m.ViewedAt, ConfirmedAt: m.ConfirmedAt, CreatedAt: m.CreatedAt, ViewedBy: optionalUUIDString(m.ViewedBy), ViewedByName: m.ViewedByName, ConfirmedBy: optionalUUIDString(m.ConfirmedBy), ConfirmedByName: m.ConfirmedByName}
 }
 
 func MapDocumentLinks(m []models.DocumentLink) []DocumentLink {
@@ -67,6 +109,17 @@ func MapDocumentLinks(m []models.DocumentLink) []DocumentLink {
 	}
 	return res
 }
+func MapAttachmentText(m *models.AttachmentText) *AttachmentText {
+	if m == nil {
+		return nil
+	}
+	res := &AttachmentText{AttachmentID: m.AttachmentID.String(), Status: m.Status, Text: m.Content, Truncated: m.Truncated, ExtractedAt: m.ExtractedAt}
+	if m.Error != nil {
+		res.Error = *m.Error
+	}
+	return res
+}
+
 func MapAttachments(m []models.Attachment) []Attachment {
 	if m == nil {
 		return nil
@@ -103,3 +156,10 @@ func MapAcknowledgments(m []models.Acknowledgment) []Acknowledgment {
 	}
 	return res
 }
+
+func optionalUUIDString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
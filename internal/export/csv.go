@@ -0,0 +1,32 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// utf8BOM позволяет Excel корректно определить кодировку CSV-файла.
+const utf8BOM = "\ufeff"
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	cw.UseCRLF = true
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) WriteRow(cells []string) error {
+	return c.w.Write(cells)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
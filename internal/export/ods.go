@@ -0,0 +1,94 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+const odsMimeType = "application/vnd.oasis.opendocument.spreadsheet"
+
+const odsManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="application/vnd.oasis.opendocument.spreadsheet"/>
+<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+</manifest:manifest>`
+
+const odsContentHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" office:version="1.2">
+<office:automatic-styles><style:style style:name="header" style:family="table-cell"><style:text-properties fo:font-weight="bold"/></style:style></office:automatic-styles>
+<office:body><office:spreadsheet>`
+
+const odsContentFooter = `</table:table></office:spreadsheet></office:body></office:document-content>`
+
+type odsWriter struct {
+	zip     *zip.Writer
+	content *bufio.Writer
+	rows    int
+}
+
+func newODSWriter(w io.Writer, sheetName string) (*odsWriter, error) {
+	zw := zip.NewWriter(w)
+
+	// По спецификации ODF mimetype должен быть первой записью архива и храниться без сжатия.
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(mimetype, odsMimeType); err != nil {
+		return nil, err
+	}
+	if err := writeZipPart(zw, "META-INF/manifest.xml", odsManifest); err != nil {
+		return nil, err
+	}
+
+	content, err := zw.Create("content.xml")
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(content)
+	bw.WriteString(odsContentHeader)
+	bw.WriteString(`<table:table table:name="`)
+	if err := xml.EscapeText(bw, []byte(sheetTitle(sheetName))); err != nil {
+		return nil, err
+	}
+	if _, err := bw.WriteString(`">`); err != nil {
+		return nil, err
+	}
+	return &odsWriter{zip: zw, content: bw}, nil
+}
+
+func (o *odsWriter) WriteRow(cells []string) error {
+	style := ""
+	if o.rows == 0 {
+		style = ` table:style-name="header"`
+	}
+	o.rows++
+	o.content.WriteString("<table:table-row>")
+	for _, cell := range cells {
+		o.content.WriteString(`<table:table-cell office:value-type="string"` + style + `>`)
+		// Перевод строки внутри ячейки в ODF передается отдельными абзацами.
+		for _, line := range strings.Split(cell, "\n") {
+			o.content.WriteString("<text:p>")
+			if err := xml.EscapeText(o.content, []byte(line)); err != nil {
+				return err
+			}
+			o.content.WriteString("</text:p>")
+		}
+		o.content.WriteString("</table:table-cell>")
+	}
+	_, err := o.content.WriteString("</table:table-row>")
+	return err
+}
+
+func (o *odsWriter) Close() error {
+	if _, err := o.content.WriteString(odsContentFooter); err != nil {
+		return err
+	}
+	if err := o.content.Flush(); err != nil {
+		return err
+	}
+	return o.zip.Close()
+}
@@ -0,0 +1,88 @@
+// Package export содержит потоковые writer'ы табличных выгрузок (XLSX, CSV, ODS).
+// Строки записываются по одной, поэтому объем выгрузки не ограничен памятью процесса.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format описывает формат табличной выгрузки.
+type Format string
+
+const (
+	FormatXLSX Format = "xlsx"
+	FormatCSV  Format = "csv"
+	FormatODS  Format = "ods"
+)
+
+// ParseFormat нормализует и проверяет код формата выгрузки.
+func ParseFormat(value string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(value)))
+	switch format {
+	case FormatXLSX, FormatCSV, FormatODS:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", value)
+	}
+}
+
+// Extension возвращает расширение файла без точки.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// ContentType возвращает MIME-тип файла выгрузки.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatODS:
+		return "application/vnd.oasis.opendocument.spreadsheet"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// TableWriter последовательно записывает строки таблицы.
+// Первая строка считается заголовком. Close дописывает служебные части файла
+// и должен быть вызван ровно один раз; закрытие исходного io.Writer остается за вызывающим.
+type TableWriter interface {
+	WriteRow(cells []string) error
+	Close() error
+}
+
+// NewTableWriter создает writer указанного формата.
+func NewTableWriter(format Format, w io.Writer, sheetName string) (TableWriter, error) {
+	switch format {
+	case FormatXLSX:
+		return newXLSXWriter(w, sheetName)
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatODS:
+		return newODSWriter(w, sheetName)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// sheetTitle ограничивает имя листа требованиями табличных редакторов.
+func sheetTitle(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '\\', '/', '?', '*', '[', ']', ':':
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "Лист1"
+	}
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	return name
+}
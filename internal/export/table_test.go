@@ -0,0 +1,102 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTable(t *testing.T, format Format, rows [][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := NewTableWriter(format, &buf, "Журнал")
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, writer.WriteRow(row))
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func readZipPart(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		require.NoError(t, err)
+		defer rc.Close()
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		return string(body)
+	}
+	t.Fatalf("zip part %s not found", name)
+	return ""
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat(" XLSX ")
+	require.NoError(t, err)
+	assert.Equal(t, FormatXLSX, format)
+	assert.Equal(t, "xlsx", format.Extension())
+
+	_, err = ParseFormat("pdf")
+	assert.Error(t, err)
+}
+
+func TestCSVWriter(t *testing.T) {
+	data := writeTable(t, FormatCSV, [][]string{
+		{"Номер", "Содержание"},
+		{"В-1", "Текст; с разделителем"},
+	})
+
+	assert.True(t, bytes.HasPrefix(data, []byte("\xef\xbb\xbf")))
+	assert.Equal(t, "\ufeffНомер;Содержание\r\nВ-1;\"Текст; с разделителем\"\r\n", string(data))
+}
+
+func TestXLSXWriter(t *testing.T) {
+	data := writeTable(t, FormatXLSX, [][]string{
+		{"Номер", "Содержание"},
+		{"В-1", "<Договор> & акт"},
+	})
+
+	assert.Contains(t, readZipPart(t, data, "[Content_Types].xml"), "/xl/worksheets/sheet1.xml")
+	assert.Contains(t, readZipPart(t, data, "xl/workbook.xml"), `name="Журнал"`)
+	sheet := readZipPart(t, data, "xl/worksheets/sheet1.xml")
+	assert.Equal(t, 2, strings.Count(sheet, "<row>"))
+	assert.Contains(t, sheet, `<c t="inlineStr" s="1"><is><t xml:space="preserve">Номер</t>`)
+	assert.Contains(t, sheet, "&lt;Договор&gt; &amp; акт")
+	assert.True(t, strings.HasSuffix(sheet, "</sheetData></worksheet>"))
+}
+
+func TestODSWriter(t *testing.T) {
+	data := writeTable(t, FormatODS, [][]string{
+		{"Номер"},
+		{"строка 1\nстрока 2"},
+	})
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.NotEmpty(t, archive.File)
+	assert.Equal(t, "mimetype", archive.File[0].Name)
+	assert.Equal(t, zip.Store, archive.File[0].Method)
+	assert.Equal(t, odsMimeType, readZipPart(t, data, "mimetype"))
+
+	content := readZipPart(t, data, "content.xml")
+	assert.Contains(t, content, `table:name="Журнал"`)
+	assert.Contains(t, content, "<text:p>строка 1</text:p><text:p>строка 2</text:p>")
+}
+
+func TestSheetTitle(t *testing.T) {
+	assert.Equal(t, "Лист1", sheetTitle(" "))
+	assert.Equal(t, "a b", sheetTitle("a/b"))
+	assert.Len(t, []rune(sheetTitle(strings.Repeat("я", 40))), 31)
+}
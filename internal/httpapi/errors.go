@@ -0,0 +1,62 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// ErrorResponse — тело ответа с ошибкой. Поля совпадают с ошибками, которые
+// desktop-клиент получает из Wails: code, message и HTTP-статус.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Status  int    `json:"status"`
+}
+
+var (
+	errNotFound         = models.NewNotFound("ресурс не найден")
+	errRequestCancelled = &models.AppError{Code: 499, Kind: "REQUEST_CANCELLED", Message: "запрос отменен клиентом", Production: true}
+)
+
+// errorResponse преобразует ошибку сервиса в ответ API. Внутренние ошибки
+// журналируются и не раскрываются клиенту.
+func errorResponse(r *http.Request, err error) ErrorResponse {
+	if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+		err = errRequestCancelled
+	}
+	if appErr, ok := models.AsAppError(err); ok {
+		if appErr.StatusCode() >= 500 {
+			attrs := []any{"type", "http_api", "method", r.Method, "path", r.URL.Path, "code", appErr.SafeKind(), "status", appErr.StatusCode(), "error", appErr.Error()}
+			if appErr.Internal != nil {
+				attrs = append(attrs, "internal", appErr.Internal.Error())
+			}
+			slog.Error("HTTP API request failed", attrs...)
+		}
+		return ErrorResponse{Code: appErr.SafeKind(), Message: appErr.SafeMessage(), Status: appErr.StatusCode()}
+	}
+	slog.Error("HTTP API request failed", "type", "http_api", "method", r.Method, "path", r.URL.Path, "error_type", fmt.Sprintf("%T", err), "error", err.Error())
+	return ErrorResponse{Code: "INTERNAL_ERROR", Message: "произошла внутренняя ошибка", Status: http.StatusInternalServerError}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	response := errorResponse(r, err)
+	if response.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="docflow"`)
+	}
+	writeJSON(w, response.Status, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Warn("failed to write HTTP API response", "error", err)
+	}
+}
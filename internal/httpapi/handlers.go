@@ -0,0 +1,420 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/services"
+)
+
+// TokenRequest — учетные данные для получения токена доступа.
+type TokenRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	// OneTimeCode — код из приложения-аутентификатора или код восстановления,
+	// если у пользователя подключен второй фактор.
+	OneTimeCode string `json:"oneTimeCode,omitempty"`
+}
+
+// TokenResponse — выданный токен доступа.
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"tokenType"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      *dto.User `json:"user"`
+}
+
+// AssignmentCreateRequest — создание поручения по документу.
+type AssignmentCreateRequest struct {
+	DocumentID    string   `json:"documentId"`
+	ExecutorID    string   `json:"executorId"`
+	Content       string   `json:"content"`
+	Deadline      string   `json:"deadline,omitempty"`
+	CoExecutorIDs []string `json:"coExecutorIds,omitempty"`
+}
+
+// AssignmentStatusRequest — смена статуса поручения.
+type AssignmentStatusRequest struct {
+	Status string `json:"status"`
+	Report string `json:"report,omitempty"`
+}
+
+// AcknowledgmentCreateRequest — направление документа на ознакомление.
+type AcknowledgmentCreateRequest struct {
+	Content  string   `json:"content"`
+	Deadline string   `json:"deadline,omitempty"`
+	UserIDs  []string `json:"userIds"`
+}
+
+var (
+	errPayloadTooLarge = &models.AppError{Code: http.StatusRequestEntityTooLarge, Kind: "PAYLOAD_TOO_LARGE", Message: "тело запроса превышает допустимый размер", Production: true}
+
+	registerRequestTypes = []any{
+		services.IncomingLetterRegisterRequest{},
+		services.OutgoingLetterRegisterRequest{},
+		services.CitizenAppealRegisterRequest{},
+		services.AdministrativeOrderRegisterRequest{},
+		services.CustomDocumentRegisterRequest{},
+	}
+	updateRequestTypes = []any{
+		services.IncomingLetterUpdateRequest{},
+		services.OutgoingLetterUpdateRequest{},
+		services.CitizenAppealUpdateRequest{},
+		services.AdministrativeOrderUpdateRequest{},
+		services.CustomDocumentUpdateRequest{},
+	}
+)
+
+func (s *Server) routeTable() []route {
+	return []route{
+		{
+			method: http.MethodGet, path: basePath + "/openapi.json", public: true,
+			operationID: "getOpenAPI", summary: "Описание API в формате OpenAPI", tag: "meta",
+			status: http.StatusOK,
+			handle: func(w http.ResponseWriter, _ *http.Request, _ Services) error {
+				writeJSON(w, http.StatusOK, s.OpenAPI())
+				return nil
+			},
+		},
+		{
+			method: http.MethodPost, path: basePath + "/auth/token", public: true,
+			operationID: "createToken", summary: "Получить токен доступа по логину и паролю", tag: "auth",
+			request: []any{TokenRequest{}}, status: http.StatusCreated, response: TokenResponse{},
+			handle: s.createToken,
+		},
+		{
+			method: http.MethodDelete, path: basePath + "/auth/token",
+			operationID: "revokeToken", summary: "Отозвать текущий токен доступа", tag: "auth",
+			status: http.StatusNoContent,
+			handle: func(w http.ResponseWriter, r *http.Request, _ Services) error {
+				s.tokens.Revoke(bearerToken(r))
+				w.WriteHeader(http.StatusNoContent)
+				return nil
+			},
+		},
+		{
+			method: http.MethodGet, path: basePath + "/me",
+			operationID: "getCurrentUser", summary: "Пользователь, которому выдан токен", tag: "auth",
+			status: http.StatusOK, response: dto.User{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respond(w, http.StatusOK)(svc.Users.GetCurrentUser(r.Context()))
+			},
+		},
+		{
+			method: http.MethodGet, path: basePath + "/documents",
+			operationID: "listDocuments", summary: "Список документов указанного вида", tag: "documents",
+			query: models.DocumentFilter{}, requiredQuery: []string{"kind"},
+			status: http.StatusOK, response: dto.PagedResult[dto.DocumentListItem]{},
+			handle: listDocuments,
+		},
+		{
+			method: http.MethodGet, path: basePath + "/documents/{id}",
+			operationID: "getDocument", summary: "Карточка документа", tag: "documents",
+			status: http.StatusOK, response: dto.DocumentCard{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respond(w, http.StatusOK)(svc.Documents.GetByID(r.Context(), r.PathValue("id")))
+			},
+		},
+		{
+			method: http.MethodPost, path: basePath + "/documents/{kind}",
+			operationID: "registerDocument", summary: "Зарегистрировать документ указанного вида", tag: "documents",
+			request: registerRequestTypes, status: http.StatusCreated,
+			handle: registerDocument,
+		},
+		{
+			method: http.MethodPut, path: basePath + "/documents/{kind}/{id}",
+			operationID: "updateDocument", summary: "Изменить зарегистрированный документ", tag: "documents",
+			request: updateRequestTypes, status: http.StatusOK,
+			handle: updateDocument,
+		},
+		{
+			method: http.MethodGet, path: basePath + "/assignments",
+			operationID: "listAssignments", summary: "Список поручений", tag: "assignments",
+			query: models.AssignmentFilter{}, status: http.StatusOK, response: dto.PagedResult[dto.Assignment]{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				var filter models.AssignmentFilter
+				if err := decodeQuery(r.URL.Query(), &filter); err != nil {
+					return err
+				}
+				return respond(w, http.StatusOK)(svc.Assignments.GetList(r.Context(), filter))
+			},
+		},
+		{
+			method: http.MethodPost, path: basePath + "/assignments",
+			operationID: "createAssignment", summary: "Создать поручение по документу", tag: "assignments",
+			request: []any{AssignmentCreateRequest{}}, status: http.StatusCreated, response: dto.Assignment{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				var req AssignmentCreateRequest
+				if err := decodeJSON(w, r, &req); err != nil {
+					return err
+				}
+				return respond(w, http.StatusCreated)(svc.Assignments.Create(r.Context(), req.DocumentID, req.ExecutorID, req.Content, req.Deadline, req.CoExecutorIDs))
+			},
+		},
+		{
+			method: http.MethodGet, path: basePath + "/assignments/{id}",
+			operationID: "getAssignment", summary: "Поручение", tag: "assignments",
+			status: http.StatusOK, response: dto.Assignment{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respond(w, http.StatusOK)(svc.Assignments.GetByID(r.Context(), r.PathValue("id")))
+			},
+		},
+		{
+			method: http.MethodPut, path: basePath + "/assignments/{id}/status",
+			operationID: "updateAssignmentStatus", summary: "Изменить статус поручения", tag: "assignments",
+			request: []any{AssignmentStatusRequest{}}, status: http.StatusOK, response: dto.Assignment{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				var req AssignmentStatusRequest
+				if err := decodeJSON(w, r, &req); err != nil {
+					return err
+				}
+				return respond(w, http.StatusOK)(svc.Assignments.UpdateStatus(r.Context(), r.PathValue("id"), req.Status, req.Report))
+			},
+		},
+		{
+			method: http.MethodGet, path: basePath + "/documents/{id}/acknowledgments",
+			operationID: "listDocumentAcknowledgments", summary: "Ознакомления по документу", tag: "acknowledgments",
+			status: http.StatusOK, response: []dto.Acknowledgment{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respond(w, http.StatusOK)(svc.Acknowledgments.GetList(r.Context(), r.PathValue("id")))
+			},
+		},
+		{
+			method: http.MethodPost, path: basePath + "/documents/{id}/acknowledgments",
+			operationID: "createAcknowledgment", summary: "Направить документ на ознакомление", tag: "acknowledgments",
+			request: []any{AcknowledgmentCreateRequest{}}, status: http.StatusCreated, response: dto.Acknowledgment{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				var req AcknowledgmentCreateRequest
+				if err := decodeJSON(w, r, &req); err != nil {
+					return err
+				}
+				return respond(w, http.StatusCreated)(svc.Acknowledgments.Create(r.Context(), r.PathValue("id"), req.Content, req.Deadline, req.UserIDs))
+			},
+		},
+		{
+			method: http.MethodGet, path: basePath + "/acknowledgments/pending",
+			operationID: "listPendingAcknowledgments", summary: "Ознакомления, ожидающие текущего пользователя", tag: "acknowledgments",
+			status: http.StatusOK, response: []dto.Acknowledgment{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respond(w, http.StatusOK)(svc.Acknowledgments.GetPendingForCurrentUser(r.Context()))
+			},
+		},
+		{
+			method: http.MethodPost, path: basePath + "/acknowledgments/{id}/view",
+			operationID: "markAcknowledgmentViewed", summary: "Отметить документ просмотренным", tag: "acknowledgments",
+			status: http.StatusNoContent,
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respondNoContent(w, svc.Acknowledgments.MarkViewed(r.Context(), r.PathValue("id")))
+			},
+		},
+		{
+			method: http.MethodPost, path: basePath + "/acknowledgments/{id}/confirm",
+			operationID: "confirmAcknowledgment", summary: "Подтвердить ознакомление", tag: "acknowledgments",
+			status: http.StatusNoContent,
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respondNoContent(w, svc.Acknowledgments.MarkConfirmed(r.Context(), r.PathValue("id")))
+			},
+		},
+		{
+			method: http.MethodGet, path: basePath + "/documents/{id}/attachments",
+			operationID: "listAttachments", summary: "Вложения документа", tag: "attachments",
+			status: http.StatusOK, response: []dto.Attachment{},
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respond(w, http.StatusOK)(svc.Attachments.GetList(r.Context(), r.PathValue("id")))
+			},
+		},
+		{
+			method: http.MethodPost, path: basePath + "/documents/{id}/attachments",
+			operationID: "uploadAttachment", summary: "Загрузить вложение", tag: "attachments",
+			multipartField: "file", status: http.StatusCreated, response: dto.Attachment{},
+			handle: s.uploadAttachment,
+		},
+		{
+			method: http.MethodGet, path: basePath + "/attachments/{id}/content",
+			operationID: "downloadAttachment", summary: "Скачать содержимое вложения", tag: "attachments",
+			status: http.StatusOK, binaryResponse: true,
+			handle: downloadAttachment,
+		},
+		{
+			method: http.MethodDelete, path: basePath + "/attachments/{id}",
+			operationID: "deleteAttachment", summary: "Удалить вложение", tag: "attachments",
+			status: http.StatusNoContent,
+			handle: func(w http.ResponseWriter, r *http.Request, svc Services) error {
+				return respondNoContent(w, svc.Attachments.Delete(r.Context(), r.PathValue("id")))
+			},
+		},
+	}
+}
+
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request, _ Services) error {
+	var req TokenRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		return err
+	}
+	user, err := s.authenticate(req.Login, req.Password, req.OneTimeCode)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return models.ErrInvalidCredentials
+	}
+	userID, err := uuid.Parse(user.ID)
+	if err != nil {
+		return fmt.Errorf("invalid authenticated user ID: %w", err)
+	}
+	token, expiresAt, err := s.tokens.Issue(userID)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, TokenResponse{Token: token, TokenType: "Bearer", ExpiresAt: expiresAt, User: user})
+	return nil
+}
+
+func listDocuments(w http.ResponseWriter, r *http.Request, svc Services) error {
+	query := r.URL.Query()
+	kind := query.Get("kind")
+	if kind == "" {
+		return models.NewBadRequest("не указан вид документа (параметр kind)")
+	}
+	var filter models.DocumentFilter
+	if err := decodeQuery(query, &filter, "kind"); err != nil {
+		return err
+	}
+	return respond(w, http.StatusOK)(svc.Documents.GetList(r.Context(), kind, filter))
+}
+
+func registerDocument(w http.ResponseWriter, r *http.Request, svc Services) error {
+	var req map[string]any
+	if err := decodeJSON(w, r, &req); err != nil {
+		return err
+	}
+	return respond(w, http.StatusCreated)(svc.Registration.Register(r.Context(), r.PathValue("kind"), req))
+}
+
+func updateDocument(w http.ResponseWriter, r *http.Request, svc Services) error {
+	var req map[string]any
+	if err := decodeJSON(w, r, &req); err != nil {
+		return err
+	}
+	id := r.PathValue("id")
+	if bodyID, ok := req["id"]; ok && bodyID != id {
+		return models.NewBadRequest("ID документа в теле запроса не совпадает с адресом")
+	}
+	req["id"] = id
+	return respond(w, http.StatusOK)(svc.Registration.Update(r.Context(), r.PathValue("kind"), req))
+}
+
+func (s *Server) uploadAttachment(w http.ResponseWriter, r *http.Request, svc Services) error {
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxUploadBytes)
+	if err := r.ParseMultipartForm(multipartMemoryBytes); err != nil {
+		return bodyError(err, "неверный формат multipart-запроса")
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return models.NewBadRequestWrapped("не передан файл (поле file)", err)
+	}
+	defer file.Close()
+	return respond(w, http.StatusCreated)(svc.AttachmentContent.Upload(r.Context(), r.PathValue("id"), header.Filename, file, header.Size))
+}
+
+// downloadAttachment сначала сохраняет вложение во временный файл: целостность
+// содержимого проверяется после чтения, и поврежденный файл не должен уйти
+// клиенту с успешным статусом.
+func downloadAttachment(w http.ResponseWriter, r *http.Request, svc Services) error {
+	tmp, err := os.CreateTemp("", "docflow-api-download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary download file: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	attachment, err := svc.AttachmentContent.Download(r.Context(), r.PathValue("id"), tmp)
+	if err != nil {
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if attachment.SHA256 != "" {
+		w.Header().Set("X-Content-SHA256", attachment.SHA256)
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, tmp)
+	return nil
+}
+
+// respond пишет результат вызова сервиса со статусом status либо возвращает ошибку.
+func respond(w http.ResponseWriter, status int) func(any, error) error {
+	return func(result any, err error) error {
+		if err != nil {
+			return err
+		}
+		writeJSON(w, status, result)
+		return nil
+	}
+}
+
+func respondNoContent(w http.ResponseWriter, err error) error {
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// decodeJSON читает тело запроса строго: неизвестные поля и лишние данные после
+// объекта отклоняются.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return bodyError(err, "не удалось прочитать тело запроса")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(dst); err != nil {
+		return models.NewBadRequestWrapped("неверный формат JSON", err)
+	}
+	if decoder.More() {
+		return models.NewBadRequest("неверный формат JSON: лишние данные после объекта")
+	}
+	return nil
+}
+
+func bodyError(err error, message string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errPayloadTooLarge
+	}
+	return models.NewBadRequestWrapped(message, err)
+}
@@ -0,0 +1,276 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const openAPIVersion = "3.0.3"
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	uuidType        = reflect.TypeOf(uuid.UUID{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	pathParamRegexp = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+// OpenAPI строит описание API по таблице маршрутов. Схемы тел запросов,
+// ответов и параметров фильтров выводятся из тех же Go-типов, которые
+// принимают и возвращают handlers, поэтому документ не расходится с кодом.
+func (s *Server) OpenAPI() map[string]any {
+	schemas := newSchemaRegistry()
+	errorSchema := schemas.schemaFor(reflect.TypeOf(ErrorResponse{}))
+	paths := make(map[string]any)
+
+	for _, rt := range s.routes {
+		operation := map[string]any{
+			"operationId": rt.operationID,
+			"summary":     rt.summary,
+			"tags":        []string{rt.tag},
+		}
+		if rt.public {
+			operation["security"] = []any{}
+		}
+
+		var parameters []any
+		for _, match := range pathParamRegexp.FindAllStringSubmatch(rt.path, -1) {
+			parameters = append(parameters, map[string]any{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		if rt.query != nil {
+			for _, field := range queryFields(reflect.TypeOf(rt.query)) {
+				parameter := map[string]any{
+					"name":   field.name,
+					"in":     "query",
+					"schema": schemas.schemaFor(field.typ),
+				}
+				if field.typ.Kind() == reflect.Slice {
+					parameter["explode"] = true
+				}
+				parameters = append(parameters, parameter)
+			}
+		}
+		for _, name := range rt.requiredQuery {
+			parameters = append(parameters, map[string]any{
+				"name":     name,
+				"in":       "query",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		switch {
+		case rt.multipartField != "":
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"multipart/form-data": map[string]any{
+						"schema": map[string]any{
+							"type":     "object",
+							"required": []string{rt.multipartField},
+							"properties": map[string]any{
+								rt.multipartField: map[string]any{"type": "string", "format": "binary"},
+							},
+						},
+					},
+				},
+			}
+		case len(rt.request) == 1:
+			operation["requestBody"] = jsonBody(schemas.schemaFor(reflect.TypeOf(rt.request[0])))
+		case len(rt.request) > 1:
+			variants := make([]any, 0, len(rt.request))
+			for _, request := range rt.request {
+				variants = append(variants, schemas.schemaFor(reflect.TypeOf(request)))
+			}
+			operation["requestBody"] = jsonBody(map[string]any{"oneOf": variants})
+		}
+
+		responses := map[string]any{
+			"default": map[string]any{
+				"description": "Ошибка",
+				"content":     map[string]any{"application/json": map[string]any{"schema": errorSchema}},
+			},
+		}
+		status := http.StatusText(rt.status)
+		switch {
+		case rt.binaryResponse:
+			responses[strconv.Itoa(rt.status)] = map[string]any{
+				"description": status,
+				"content": map[string]any{
+					"application/octet-stream": map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}},
+				},
+			}
+		case rt.status == http.StatusNoContent:
+			responses[strconv.Itoa(rt.status)] = map[string]any{"description": status}
+		default:
+			var schema map[string]any
+			if rt.response == nil {
+				schema = map[string]any{"type": "object"}
+			} else {
+				schema = schemas.schemaFor(reflect.TypeOf(rt.response))
+			}
+			responses[strconv.Itoa(rt.status)] = map[string]any{
+				"description": status,
+				"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+			}
+		}
+		operation["responses"] = responses
+
+		item, _ := paths[rt.path].(map[string]any)
+		if item == nil {
+			item = make(map[string]any)
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":   "Docflow API",
+			"version": APIVersion,
+		},
+		"paths":    paths,
+		"security": []any{map[string]any{"bearerAuth": []string{}}},
+		"components": map[string]any{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func jsonBody(schema map[string]any) map[string]any {
+	return map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+// schemaRegistry собирает именованные схемы components/schemas.
+type schemaRegistry struct {
+	schemas map[string]any
+	types   map[string]reflect.Type
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: make(map[string]any), types: make(map[string]reflect.Type)}
+}
+
+func (g *schemaRegistry) schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+// structRef регистрирует схему структуры и возвращает ссылку на нее.
+func (g *schemaRegistry) structRef(t reflect.Type) map[string]any {
+	if t.Name() == "" {
+		properties := make(map[string]any)
+		g.collectProperties(t, properties)
+		return map[string]any{"type": "object", "properties": properties}
+	}
+	name := g.schemaName(t)
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if _, ok := g.types[name]; ok {
+		return ref
+	}
+	g.types[name] = t
+
+	properties := make(map[string]any)
+	g.collectProperties(t, properties)
+	g.schemas[name] = map[string]any{"type": "object", "properties": properties}
+	return ref
+}
+
+func (g *schemaRegistry) collectProperties(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.collectProperties(embedded, properties)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaFor(field.Type)
+	}
+}
+
+// schemaName возвращает имя схемы: имя типа, а для обобщенных типов — имя с
+// аргументами (PagedResult[dto.Assignment] → PagedResultAssignment). При
+// совпадении имен типов из разных пакетов добавляется имя пакета.
+func (g *schemaRegistry) schemaName(t reflect.Type) string {
+	name := t.Name()
+	if base, args, ok := strings.Cut(name, "["); ok {
+		name = base
+		for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+			name += arg[strings.LastIndex(arg, ".")+1:]
+		}
+	}
+	if existing, ok := g.types[name]; ok && existing != t {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + name
+	}
+	return name
+}
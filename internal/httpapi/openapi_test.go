@@ -0,0 +1,93 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+)
+
+func TestOpenAPIDescribesRoutes(t *testing.T) {
+	server, _ := newTestServer(t, &fakeServices{userID: uuid.New()})
+
+	rec := serve(server, http.MethodGet, "/api/v1/openapi.json", "", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var document struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string            `json:"operationId"`
+			Security    []json.RawMessage `json:"security"`
+			Parameters  []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+			} `json:"parameters"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &document))
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+
+	operationIDs := make(map[string]bool)
+	for _, rt := range server.routes {
+		operation, ok := document.Paths[rt.path][map[string]string{
+			http.MethodGet: "get", http.MethodPost: "post", http.MethodPut: "put", http.MethodDelete: "delete",
+		}[rt.method]]
+		require.True(t, ok, "%s %s", rt.method, rt.path)
+		assert.False(t, operationIDs[operation.OperationID], "duplicate operationId %s", operation.OperationID)
+		operationIDs[operation.OperationID] = true
+		assert.Contains(t, operation.Responses, "default")
+	}
+
+	login := document.Paths["/api/v1/auth/token"]["post"]
+	assert.NotNil(t, login.Security)
+	assert.Empty(t, login.Security, "token endpoint is public")
+
+	list := document.Paths["/api/v1/documents"]["get"]
+	parameters := make(map[string]bool)
+	for _, parameter := range list.Parameters {
+		assert.Equal(t, "query", parameter.In)
+		parameters[parameter.Name] = parameter.Required
+	}
+	assert.True(t, parameters["kind"])
+	assert.Contains(t, parameters, "nomenclatureIds")
+	assert.NotContains(t, parameters, "customFields")
+	assert.NotContains(t, parameters, "AccessibleByUserID")
+
+	card := document.Paths["/api/v1/documents/{id}"]["get"]
+	require.Len(t, card.Parameters, 1)
+	assert.Equal(t, "path", card.Parameters[0].In)
+
+	assert.Contains(t, document.Components.Schemas, "PagedResultAssignment")
+	assert.Contains(t, document.Components.Schemas, "IncomingLetterRegisterRequest")
+	assert.Contains(t, document.Components.Schemas["ErrorResponse"].Properties, "code")
+	assert.Contains(t, document.Components.Schemas["Attachment"].Properties, "sha256")
+}
+
+func TestSchemaRegistryNames(t *testing.T) {
+	type Attachment struct {
+		Name string `json:"name"`
+	}
+	registry := newSchemaRegistry()
+
+	assert.Equal(t, "#/components/schemas/Attachment", registry.schemaFor(reflect.TypeFor[dto.Attachment]())["$ref"])
+	assert.Equal(t, "#/components/schemas/httpapiAttachment", registry.schemaFor(reflect.TypeFor[Attachment]())["$ref"])
+	assert.Equal(t, "#/components/schemas/PagedResultAssignment", registry.schemaFor(reflect.TypeFor[*dto.PagedResult[dto.Assignment]]())["$ref"])
+
+	inline := registry.schemaFor(reflect.TypeFor[struct {
+		Nested TokenRequest `json:"nested"`
+	}]())
+	assert.Equal(t, "object", inline["type"], "anonymous structs are inlined")
+	assert.Contains(t, registry.schemas, "TokenRequest")
+}
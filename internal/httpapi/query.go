@@ -0,0 +1,117 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// queryField — поле фильтра, принимаемое как параметр строки запроса.
+// Поддерживаются строки, числа, флаги и списки строк; вложенные структуры
+// (например, фильтры пользовательских полей) через query не передаются.
+type queryField struct {
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+// queryFields перечисляет параметры строки запроса по json-тегам структуры
+// фильтра. Поля с тегом "-" — серверный скоуп доступа, клиент их не задает.
+func queryFields(t reflect.Type) []queryField {
+	var fields []queryField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if !isQueryType(field.Type) {
+			continue
+		}
+		fields = append(fields, queryField{name: name, index: field.Index, typ: field.Type})
+	}
+	return fields
+}
+
+func isQueryType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+// decodeQuery заполняет структуру фильтра dst из values. Неизвестные параметры
+// отклоняются, кроме перечисленных в extra, которые обрабатывает сам handler.
+func decodeQuery(values url.Values, dst any, extra ...string) error {
+	target := reflect.ValueOf(dst).Elem()
+	known := make(map[string]bool)
+	for _, field := range queryFields(target.Type()) {
+		known[field.name] = true
+		raw, ok := values[field.name]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		value := target.FieldByIndex(field.index)
+		if err := setQueryValue(value, raw); err != nil {
+			return models.NewBadRequestWrapped(fmt.Sprintf("неверное значение параметра %s", field.name), err)
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if !known[name] && !slices.Contains(extra, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return models.NewBadRequest(fmt.Sprintf("неизвестные параметры запроса: %s", strings.Join(unknown, ", ")))
+	}
+	return nil
+}
+
+func setQueryValue(value reflect.Value, raw []string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw[0])
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw[0])
+		if err != nil {
+			return err
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(raw[0]), 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(parsed)
+	case reflect.Slice:
+		items := make([]string, 0, len(raw))
+		for _, item := range raw {
+			for _, part := range strings.Split(item, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					items = append(items, part)
+				}
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
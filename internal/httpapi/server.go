@@ -0,0 +1,195 @@
+// Package httpapi предоставляет версионированный JSON API поверх того же графа
+// сервисов, что и desktop-приложение. Каждый запрос выполняется от имени
+// пользователя, которому выдан токен доступа: его principal передается
+// сервисам через context запроса, а не через desktop-сессию.
+package httpapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// APIVersion — версия API, входящая в префикс всех маршрутов.
+const APIVersion = "v1"
+
+const (
+	basePath = "/api/" + APIVersion
+	// DefaultMaxUploadBytes ограничивает тело multipart-запроса с файлом.
+	// Итоговый лимит размера вложения задается системными настройками.
+	DefaultMaxUploadBytes = 1 << 30
+	maxJSONBodyBytes      = 4 << 20
+	multipartMemoryBytes  = 8 << 20
+)
+
+// CurrentUserReader возвращает пользователя, от имени которого выполняется запрос.
+type CurrentUserReader interface {
+	GetCurrentUser(ctx context.Context) (*dto.User, error)
+}
+
+// DocumentReader — чтение карточек и списков документов.
+type DocumentReader interface {
+	GetByID(ctx context.Context, id string) (*dto.DocumentCard, error)
+	GetList(ctx context.Context, kindCode string, filter models.DocumentFilter) (*dto.PagedResult[dto.DocumentListItem], error)
+}
+
+// DocumentRegistrar — регистрация и изменение документов любого вида.
+type DocumentRegistrar interface {
+	Register(ctx context.Context, kindCode string, req any) (any, error)
+	Update(ctx context.Context, kindCode string, req any) (any, error)
+}
+
+// AssignmentManager — поручения по документам.
+type AssignmentManager interface {
+	Create(ctx context.Context, documentID, executorID, content, deadline string, coExecutorIDs []string) (*dto.Assignment, error)
+	UpdateStatus(ctx context.Context, id, status, report string) (*dto.Assignment, error)
+	GetByID(ctx context.Context, id string) (*dto.Assignment, error)
+	GetList(ctx context.Context, filter models.AssignmentFilter) (*dto.PagedResult[dto.Assignment], error)
+}
+
+// AcknowledgmentManager — ознакомление пользователей с документами.
+type AcknowledgmentManager interface {
+	Create(ctx context.Context, documentID, content, deadline string, userIDs []string) (*dto.Acknowledgment, error)
+	GetList(ctx context.Context, documentID string) ([]dto.Acknowledgment, error)
+	GetPendingForCurrentUser(ctx context.Context) ([]dto.Acknowledgment, error)
+	MarkViewed(ctx context.Context, id string) error
+	MarkConfirmed(ctx context.Context, id string) error
+}
+
+// AttachmentManager — метаданные вложений документа.
+type AttachmentManager interface {
+	GetList(ctx context.Context, documentID string) ([]dto.Attachment, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// AttachmentContent — передача содержимого вложений.
+type AttachmentContent interface {
+	Upload(ctx context.Context, documentID, filename string, content io.Reader, size int64) (*dto.Attachment, error)
+	Download(ctx context.Context, id string, writer io.Writer) (*dto.Attachment, error)
+}
+
+// Services — сервисы API. Пользователя запроса они получают из ctx.
+type Services struct {
+	Users             CurrentUserReader
+	Documents         DocumentReader
+	Registration      DocumentRegistrar
+	Assignments       AssignmentManager
+	Acknowledgments   AcknowledgmentManager
+	Attachments       AttachmentManager
+	AttachmentContent AttachmentContent
+}
+
+// PrincipalResolver загружает principal пользователя userID и возвращает ctx,
+// от имени которого выполняются вызовы сервисов.
+type PrincipalResolver func(ctx context.Context, userID uuid.UUID) (context.Context, error)
+
+// Authenticator проверяет логин, пароль и код второго фактора, не открывая
+// desktop-сессию.
+type Authenticator func(login, password, oneTimeCode string) (*dto.User, error)
+
+// Config задает параметры HTTP API.
+type Config struct {
+	TokenTTL       time.Duration
+	MaxUploadBytes int64
+}
+
+// Server обслуживает HTTP API.
+type Server struct {
+	cfg          Config
+	tokens       *TokenStore
+	authenticate Authenticator
+	services     Services
+	principal    PrincipalResolver
+	routes       []route
+}
+
+// NewServer создает HTTP API с собственным хранилищем токенов.
+func NewServer(cfg Config, authenticate Authenticator, services Services, principal PrincipalResolver) *Server {
+	if cfg.MaxUploadBytes <= 0 {
+		cfg.MaxUploadBytes = DefaultMaxUploadBytes
+	}
+	s := &Server{
+		cfg:          cfg,
+		tokens:       NewTokenStore(cfg.TokenTTL),
+		authenticate: authenticate,
+		services:     services,
+		principal:    principal,
+	}
+	s.routes = s.routeTable()
+	return s
+}
+
+// Handler возвращает http.Handler со всеми маршрутами API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range s.routes {
+		mux.Handle(rt.method+" "+rt.path, s.wrap(rt))
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, errNotFound)
+	})
+	return mux
+}
+
+// handlerFunc обрабатывает запрос. Для защищенных маршрутов r.Context()
+// содержит principal пользователя из токена.
+type handlerFunc func(w http.ResponseWriter, r *http.Request, svc Services) error
+
+// route описывает маршрут и его представление в OpenAPI.
+type route struct {
+	method      string
+	path        string
+	operationID string
+	summary     string
+	tag         string
+	public      bool
+
+	// query — структура фильтра, поля которой принимаются как параметры строки запроса.
+	query         any
+	requiredQuery []string
+	// request — варианты тела JSON-запроса; несколько вариантов описываются как oneOf.
+	request        []any
+	multipartField string
+
+	status         int
+	response       any
+	binaryResponse bool
+
+	handle handlerFunc
+}
+
+func (s *Server) wrap(rt route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rt.public {
+			userID, ok := s.tokens.Resolve(bearerToken(r))
+			if !ok {
+				writeError(w, r, models.ErrUnauthorized)
+				return
+			}
+			ctx, err := s.principal(r.Context(), userID)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			r = r.WithContext(ctx)
+		}
+		if err := rt.handle(w, r, s.services); err != nil {
+			writeError(w, r, err)
+		}
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
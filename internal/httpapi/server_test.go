@@ -0,0 +1,301 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// fakePrincipalKey хранит в контексте запроса пользователя, которого вернул
+// PrincipalResolver тестового сервера.
+type fakePrincipalKey struct{}
+
+// fakeServices реализует все интерфейсы API и запоминает аргументы вызовов.
+type fakeServices struct {
+	userID       uuid.UUID
+	err          error
+	principalErr error
+	oneTimeCode  string
+
+	documentKind   string
+	documentFilter models.DocumentFilter
+	registerReq    any
+	uploadName     string
+	uploadContent  string
+	uploadSize     int64
+	downloadErr    error
+}
+
+func (f *fakeServices) GetCurrentUser(ctx context.Context) (*dto.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	userID, ok := ctx.Value(fakePrincipalKey{}).(uuid.UUID)
+	if !ok {
+		return nil, models.ErrUnauthorized
+	}
+	return &dto.User{ID: userID.String(), Login: "api"}, nil
+}
+
+func (f *fakeServices) GetByID(_ context.Context, id string) (*dto.DocumentCard, error) {
+	return nil, models.NewNotFound("документ не найден")
+}
+
+func (f *fakeServices) GetList(_ context.Context, kindCode string, filter models.DocumentFilter) (*dto.PagedResult[dto.DocumentListItem], error) {
+	f.documentKind, f.documentFilter = kindCode, filter
+	return &dto.PagedResult[dto.DocumentListItem]{Items: []dto.DocumentListItem{}, Page: filter.Page, PageSize: filter.PageSize}, nil
+}
+
+func (f *fakeServices) Register(_ context.Context, kindCode string, req any) (any, error) {
+	f.documentKind, f.registerReq = kindCode, req
+	return map[string]any{"id": "new"}, f.err
+}
+
+func (f *fakeServices) Update(_ context.Context, kindCode string, req any) (any, error) {
+	f.documentKind, f.registerReq = kindCode, req
+	return req, f.err
+}
+
+func (f *fakeServices) Upload(_ context.Context, documentID, filename string, content io.Reader, size int64) (*dto.Attachment, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	f.uploadName, f.uploadContent, f.uploadSize = filename, string(data), size
+	return &dto.Attachment{DocumentID: documentID, Filename: filename, FileSize: size}, nil
+}
+
+func (f *fakeServices) Download(_ context.Context, id string, writer io.Writer) (*dto.Attachment, error) {
+	if _, err := io.WriteString(writer, "partial"); err != nil {
+		return nil, err
+	}
+	if f.downloadErr != nil {
+		return nil, f.downloadErr
+	}
+	return &dto.Attachment{ID: id, Filename: "отчет.pdf", ContentType: "application/pdf", SHA256: "abc"}, nil
+}
+
+func newTestServer(t *testing.T, fake *fakeServices) (*Server, string) {
+	t.Helper()
+	server := NewServer(Config{}, func(login, password, oneTimeCode string) (*dto.User, error) {
+		if login != "api" || password != "secret" {
+			return nil, models.ErrInvalidCredentials
+		}
+		if fake.oneTimeCode != "" && oneTimeCode != fake.oneTimeCode {
+			return nil, models.ErrTwoFactorRequired
+		}
+		return &dto.User{ID: fake.userID.String(), Login: login}, nil
+	}, Services{Users: fake, Documents: fake, Registration: fake, AttachmentContent: fake},
+		func(ctx context.Context, userID uuid.UUID) (context.Context, error) {
+			require.Equal(t, fake.userID, userID)
+			if fake.principalErr != nil {
+				return nil, fake.principalErr
+			}
+			return context.WithValue(ctx, fakePrincipalKey{}, userID), nil
+		})
+	token, _, err := server.tokens.Issue(fake.userID)
+	require.NoError(t, err)
+	return server, token
+}
+
+func serve(server *Server, method, target, token string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeErrorResponse(t *testing.T, rec *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	var response ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return response
+}
+
+func TestServerTokenLifecycle(t *testing.T) {
+	fake := &fakeServices{userID: uuid.New()}
+	server, _ := newTestServer(t, fake)
+
+	rec := serve(server, http.MethodPost, "/api/v1/auth/token", "", strings.NewReader(`{"login":"api","password":"wrong"}`), "application/json")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "INVALID_CREDENTIALS", decodeErrorResponse(t, rec).Code)
+
+	rec = serve(server, http.MethodPost, "/api/v1/auth/token", "", strings.NewReader(`{"login":"api","password":"secret"}`), "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var token TokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &token))
+	assert.Equal(t, "Bearer", token.TokenType)
+	require.NotEmpty(t, token.Token)
+
+	rec = serve(server, http.MethodGet, "/api/v1/me", token.Token, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), fake.userID.String())
+
+	rec = serve(server, http.MethodDelete, "/api/v1/auth/token", token.Token, nil, "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serve(server, http.MethodGet, "/api/v1/me", token.Token, nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer realm="docflow"`, rec.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, ErrorResponse{Code: "UNAUTHORIZED", Message: "требуется авторизация", Status: 401}, decodeErrorResponse(t, rec))
+}
+
+func TestServerTokenRequiresSecondFactor(t *testing.T) {
+	fake := &fakeServices{userID: uuid.New(), oneTimeCode: "123456"}
+	server, _ := newTestServer(t, fake)
+
+	rec := serve(server, http.MethodPost, "/api/v1/auth/token", "", strings.NewReader(`{"login":"api","password":"secret"}`), "application/json")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "TWO_FACTOR_REQUIRED", decodeErrorResponse(t, rec).Code)
+
+	rec = serve(server, http.MethodPost, "/api/v1/auth/token", "", strings.NewReader(`{"login":"api","password":"secret","oneTimeCode":"123456"}`), "application/json")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestServerMapsServiceErrors(t *testing.T) {
+	fake := &fakeServices{userID: uuid.New()}
+	server, token := newTestServer(t, fake)
+
+	rec := serve(server, http.MethodGet, "/api/v1/documents/"+uuid.NewString(), token, nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, ErrorResponse{Code: "NOT_FOUND", Message: "документ не найден", Status: 404}, decodeErrorResponse(t, rec))
+
+	// Деактивированный пользователь теряет доступ и с действующим токеном:
+	// principal запроса не загружается, и сервисы не вызываются.
+	fake.principalErr = models.ErrUnauthorized
+	rec = serve(server, http.MethodGet, "/api/v1/me", token, nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	fake.principalErr = nil
+
+	// Внутренние ошибки не раскрываются клиенту.
+	fake.err = errors.New("pq: connection refused")
+	rec = serve(server, http.MethodGet, "/api/v1/me", token, nil, "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "произошла внутренняя ошибка", decodeErrorResponse(t, rec).Message)
+	assert.NotContains(t, rec.Body.String(), "pq:")
+
+	rec = serve(server, http.MethodGet, "/api/v1/unknown", token, nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "NOT_FOUND", decodeErrorResponse(t, rec).Code)
+}
+
+func TestServerListDocumentsDecodesFilter(t *testing.T) {
+	fake := &fakeServices{userID: uuid.New()}
+	server, token := newTestServer(t, fake)
+
+	rec := serve(server, http.MethodGet, "/api/v1/documents?kind=incoming_letter&page=2&pageSize=50&nomenclatureIds=a,b&nomenclatureIds=c&noResolution=true", token, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "incoming_letter", fake.documentKind)
+	assert.Equal(t, 2, fake.documentFilter.Page)
+	assert.Equal(t, 50, fake.documentFilter.PageSize)
+	assert.Equal(t, []string{"a", "b", "c"}, fake.documentFilter.NomenclatureIDs)
+	assert.True(t, fake.documentFilter.NoResolution)
+
+	rec = serve(server, http.MethodGet, "/api/v1/documents?page=1", token, nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Поля скоупа доступа не принимаются из запроса.
+	rec = serve(server, http.MethodGet, "/api/v1/documents?kind=incoming_letter&AccessibleByUserID=x", token, nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, decodeErrorResponse(t, rec).Message, "AccessibleByUserID")
+
+	rec = serve(server, http.MethodGet, "/api/v1/documents?kind=incoming_letter&page=first", token, nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "неверное значение параметра page", decodeErrorResponse(t, rec).Message)
+}
+
+func TestServerDocumentCommands(t *testing.T) {
+	fake := &fakeServices{userID: uuid.New()}
+	server, token := newTestServer(t, fake)
+
+	rec := serve(server, http.MethodPost, "/api/v1/documents/outgoing_letter", token, strings.NewReader(`{"content":"text","pages":3}`), "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "outgoing_letter", fake.documentKind)
+	assert.Equal(t, map[string]any{"content": "text", "pages": json.Number("3")}, fake.registerReq)
+
+	id := uuid.NewString()
+	rec = serve(server, http.MethodPut, "/api/v1/documents/outgoing_letter/"+id, token, strings.NewReader(`{"content":"new"}`), "application/json")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, id, fake.registerReq.(map[string]any)["id"])
+
+	rec = serve(server, http.MethodPut, "/api/v1/documents/outgoing_letter/"+id, token, strings.NewReader(`{"id":"other"}`), "application/json")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(server, http.MethodPost, "/api/v1/documents/outgoing_letter", token, strings.NewReader(`{"content":`), "application/json")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "неверный формат JSON", decodeErrorResponse(t, rec).Message)
+
+	fake.err = models.ErrForbidden
+	rec = serve(server, http.MethodPost, "/api/v1/documents/outgoing_letter", token, strings.NewReader(`{}`), "application/json")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestServerAttachmentContent(t *testing.T) {
+	fake := &fakeServices{userID: uuid.New()}
+	server, token := newTestServer(t, fake)
+	documentID := uuid.NewString()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "scan.pdf")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("%PDF-1.7"))
+	require.NoError(t, writer.Close())
+
+	rec := serve(server, http.MethodPost, "/api/v1/documents/"+documentID+"/attachments", token, &body, writer.FormDataContentType())
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "scan.pdf", fake.uploadName)
+	assert.Equal(t, "%PDF-1.7", fake.uploadContent)
+	assert.Equal(t, int64(8), fake.uploadSize)
+
+	rec = serve(server, http.MethodGet, "/api/v1/attachments/"+uuid.NewString()+"/content", token, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "partial", rec.Body.String())
+	assert.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "7", rec.Header().Get("Content-Length"))
+	assert.Equal(t, "attachment; filename*=utf-8''%D0%BE%D1%82%D1%87%D0%B5%D1%82.pdf", rec.Header().Get("Content-Disposition"))
+
+	// Поврежденное содержимое не отдается клиенту даже частично.
+	fake.downloadErr = models.NewConflict("файл «отчет.pdf» поврежден в хранилище")
+	rec = serve(server, http.MethodGet, "/api/v1/attachments/"+uuid.NewString()+"/content", token, nil, "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "partial")
+}
+
+func TestServerRejectsOversizedUpload(t *testing.T) {
+	fake := &fakeServices{userID: uuid.New()}
+	server, token := newTestServer(t, fake)
+	server.cfg.MaxUploadBytes = 64
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "big.pdf")
+	require.NoError(t, err)
+	_, _ = part.Write(bytes.Repeat([]byte("x"), 1024))
+	require.NoError(t, writer.Close())
+
+	rec := serve(server, http.MethodPost, "/api/v1/documents/"+uuid.NewString()+"/attachments", token, &body, writer.FormDataContentType())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "PAYLOAD_TOO_LARGE", decodeErrorResponse(t, rec).Code)
+	assert.Empty(t, fake.uploadName)
+}
@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTokenTTL — срок жизни токена доступа по умолчанию.
+const DefaultTokenTTL = 12 * time.Hour
+
+// TokenStore выдает и проверяет токены доступа API. Токены живут в памяти
+// процесса: после перезапуска сервера клиент получает новый токен по логину и
+// паролю. Хранятся только SHA-256 токенов, сам токен возвращается один раз.
+type TokenStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	now      func() time.Time
+	sessions map[string]tokenSession
+}
+
+type tokenSession struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+}
+
+// NewTokenStore создает хранилище токенов с абсолютным сроком жизни ttl.
+func NewTokenStore(ttl time.Duration) *TokenStore {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &TokenStore{
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: make(map[string]tokenSession),
+	}
+}
+
+// Issue выдает новый токен пользователю userID.
+func (s *TokenStore) Issue(userID uuid.UUID) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, session := range s.sessions {
+		if !now.Before(session.expiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+	expiresAt := now.Add(s.ttl)
+	s.sessions[tokenKey(token)] = tokenSession{userID: userID, expiresAt: expiresAt}
+	return token, expiresAt, nil
+}
+
+// Resolve возвращает пользователя действующего токена.
+func (s *TokenStore) Resolve(token string) (uuid.UUID, bool) {
+	if token == "" {
+		return uuid.Nil, false
+	}
+	key := tokenKey(token)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[key]
+	if !ok {
+		return uuid.Nil, false
+	}
+	if !s.now().Before(session.expiresAt) {
+		delete(s.sessions, key)
+		return uuid.Nil, false
+	}
+	return session.userID, true
+}
+
+// Revoke отзывает токен. Отзыв неизвестного токена не считается ошибкой.
+func (s *TokenStore) Revoke(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, tokenKey(token))
+}
+
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
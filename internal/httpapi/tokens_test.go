@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenStore(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := NewTokenStore(time.Hour)
+	store.now = func() time.Time { return now }
+	userID := uuid.New()
+
+	token, expiresAt, err := store.Issue(userID)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), expiresAt)
+	other, _, err := store.Issue(userID)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+
+	resolved, ok := store.Resolve(token)
+	require.True(t, ok)
+	assert.Equal(t, userID, resolved)
+	_, ok = store.Resolve("unknown")
+	assert.False(t, ok)
+
+	// Хранилище содержит только хеши токенов.
+	for key := range store.sessions {
+		assert.NotEqual(t, token, key)
+	}
+
+	store.Revoke(other)
+	_, ok = store.Resolve(other)
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+	_, ok = store.Resolve(token)
+	assert.False(t, ok)
+	assert.Empty(t, store.sessions)
+}
@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// buildMessage собирает письмо multipart/alternative в UTF-8: почтовые
+// клиенты без HTML показывают текстовую часть.
+func buildMessage(from, to *mail.Address, message Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	headers := []struct{ name, value string }{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.BEncoding.Encode("utf-8", message.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(from.Address)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", body.Boundary())},
+		{"Auto-Submitted", "auto-generated"},
+	}
+	var head bytes.Buffer
+	for _, header := range headers {
+		fmt.Fprintf(&head, "%s: %s\r\n", header.name, header.value)
+	}
+	head.WriteString("\r\n")
+
+	parts := []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		writer, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(normalizeLineBreaks(part.content))); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+// normalizeLineBreaks приводит переводы строк к CRLF, как требует SMTP.
+func normalizeLineBreaks(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return strings.ReplaceAll(value, "\n", "\r\n")
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	random := make([]byte, 16)
+	_, _ = rand.Read(random)
+	return "<" + hex.EncodeToString(random) + "@" + domain + ">"
+}
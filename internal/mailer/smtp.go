@@ -0,0 +1,132 @@
+// Package mailer отправляет уведомления пользователей по электронной почте
+// через SMTP-сервер организации и формирует тексты писем.
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+)
+
+// Message — письмо одному получателю с текстовой и HTML-версией.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// SMTP отправляет письма через SMTP-сервер. Каждое письмо отправляется в
+// отдельном соединении: уведомлений немного, а долгоживущее соединение
+// пришлось бы восстанавливать после таймаута простоя на сервере.
+type SMTP struct {
+	cfg       config.SMTPConfig
+	from      *mail.Address
+	tlsConfig *tls.Config
+	now       func() time.Time
+}
+
+// NewSMTP создает отправителя по настройкам cfg.
+func NewSMTP(cfg config.SMTPConfig) (*SMTP, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("smtp is disabled")
+	}
+	from, err := mail.ParseAddress(strings.TrimSpace(cfg.From))
+	if err != nil {
+		return nil, err
+	}
+	if name := strings.TrimSpace(cfg.FromName); name != "" {
+		from.Name = name
+	}
+	return &SMTP{
+		cfg:       cfg,
+		from:      from,
+		tlsConfig: &tls.Config{ServerName: strings.TrimSpace(cfg.Host), InsecureSkipVerify: cfg.InsecureSkipVerify},
+		now:       time.Now,
+	}, nil
+}
+
+// Send отправляет письмо. Ошибка сервера или сети возвращается вызывающему
+// для повторной попытки; письмо не считается отправленным, пока сервер не
+// принял его данные.
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	to, err := mail.ParseAddress(strings.TrimSpace(message.To))
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+	data, err := buildMessage(s.from, to, message, s.now())
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(s.cfg.Timeout())
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", s.cfg.Address())
+	if err != nil {
+		return fmt.Errorf("connect to smtp server: %w", err)
+	}
+	if s.cfg.SecurityMode() == config.SMTPSecurityTLS {
+		conn = tls.Client(conn, s.tlsConfig)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	// Отмена ctx прерывает диалог с сервером, не дожидаясь таймаута.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, strings.TrimSpace(s.cfg.Host))
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if s.cfg.SecurityMode() == config.SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(s.tlsConfig); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if username := strings.TrimSpace(s.cfg.Username); username != "" {
+		if err := client.Auth(smtp.PlainAuth("", username, s.cfg.GetPassword(), strings.TrimSpace(s.cfg.Host))); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp RCPT TO: %w", err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return fmt.Errorf("smtp write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("smtp message rejected: %w", err)
+	}
+	// Письмо уже принято сервером; ошибка QUIT не повод отправлять его повторно.
+	_ = client.Quit()
+	return nil
+}
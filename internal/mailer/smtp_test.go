@@ -0,0 +1,229 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+)
+
+// smtpStub — минимальный SMTP-сервер для тестов: принимает одно письмо на
+// соединение и запоминает конверт и данные.
+type smtpStub struct {
+	listener net.Listener
+	// rejectRcpt отвечает ошибкой на RCPT TO, как перегруженный сервер.
+	rejectRcpt bool
+
+	mu       sync.Mutex
+	from     string
+	rcpt     []string
+	auth     string
+	data     string
+	sessions int
+}
+
+func startSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	stub := &smtpStub{listener: listener}
+	go stub.serve()
+	t.Cleanup(func() { listener.Close() })
+	return stub
+}
+
+func (s *smtpStub) config() config.SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return config.SMTPConfig{Enabled: true, Host: host, Port: portNumber, From: "docflow@example.local", FromName: "Документооборот", TimeoutSeconds: 5}
+}
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStub) handle(conn net.Conn) {
+	defer conn.Close()
+	s.mu.Lock()
+	s.sessions++
+	s.mu.Unlock()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 stub ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250-stub")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(command, "AUTH PLAIN"):
+			s.mu.Lock()
+			s.auth = strings.TrimSpace(line[len("AUTH PLAIN"):])
+			s.mu.Unlock()
+			reply("235 accepted")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = line[len("MAIL FROM:"):]
+			s.mu.Unlock()
+			reply("250 ok")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			if s.rejectRcpt {
+				reply("451 try again later")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, line[len("RCPT TO:"):])
+			s.mu.Unlock()
+			reply("250 ok")
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpStub) received() (from string, rcpt []string, auth, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.from, append([]string(nil), s.rcpt...), s.auth, s.data
+}
+
+func TestSMTPSendDeliversMultipartMessage(t *testing.T) {
+	stub := startSMTPStub(t)
+	cfg := stub.config()
+	cfg.Username, cfg.Password = "docflow", "secret"
+	sender, err := NewSMTP(cfg)
+	require.NoError(t, err)
+	sender.now = func() time.Time { return time.Date(2026, 7, 16, 9, 30, 0, 0, time.UTC) }
+
+	err = sender.Send(context.Background(), Message{
+		To:      "petrov@example.local",
+		Subject: "Новое поручение по документу № ВХ-1",
+		Text:    "Вам назначено поручение.\nПодготовить ответ",
+		HTML:    "<p>Вам назначено поручение.</p>",
+	})
+	require.NoError(t, err)
+
+	from, rcpt, auth, data := stub.received()
+	assert.Equal(t, "<docflow@example.local>", from)
+	assert.Equal(t, []string{"<petrov@example.local>"}, rcpt)
+	credentials, err := base64.StdEncoding.DecodeString(auth)
+	require.NoError(t, err)
+	assert.Equal(t, "\x00docflow\x00secret", string(credentials))
+
+	message, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Новое поручение по документу № ВХ-1", subject)
+	fromList, err := message.Header.AddressList("From")
+	require.NoError(t, err)
+	assert.Equal(t, "Документооборот", fromList[0].Name)
+	assert.Equal(t, "auto-generated", message.Header.Get("Auto-Submitted"))
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	bodies := make(map[string]string)
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		bodies[part.Header.Get("Content-Type")] = string(content)
+	}
+	assert.Equal(t, "Вам назначено поручение.\r\nПодготовить ответ", bodies["text/plain; charset=utf-8"])
+	assert.Equal(t, "<p>Вам назначено поручение.</p>", bodies["text/html; charset=utf-8"])
+}
+
+func TestSMTPSendReturnsServerRejection(t *testing.T) {
+	stub := startSMTPStub(t)
+	stub.rejectRcpt = true
+	sender, err := NewSMTP(stub.config())
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), Message{To: "petrov@example.local", Subject: "Тема", Text: "Текст"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "451")
+}
+
+func TestSMTPSendRequiresStartTLSSupport(t *testing.T) {
+	stub := startSMTPStub(t)
+	cfg := stub.config()
+	cfg.Security = config.SMTPSecurityStartTLS
+	sender, err := NewSMTP(cfg)
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), Message{To: "petrov@example.local", Subject: "Тема", Text: "Текст"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "STARTTLS")
+	_, rcpt, _, _ := stub.received()
+	assert.Empty(t, rcpt)
+}
+
+func TestSMTPSendRejectsInvalidRecipient(t *testing.T) {
+	stub := startSMTPStub(t)
+	sender, err := NewSMTP(stub.config())
+	require.NoError(t, err)
+
+	require.Error(t, sender.Send(context.Background(), Message{To: "not an address", Subject: "Тема"}))
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	assert.Zero(t, stub.sessions)
+}
+
+func TestNewSMTPRequiresEnabledValidConfig(t *testing.T) {
+	_, err := NewSMTP(config.SMTPConfig{})
+	require.Error(t, err)
+	_, err = NewSMTP(config.SMTPConfig{Enabled: true, Host: "mail", From: "docflow"})
+	require.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package mailer
+
+import (
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// eventTemplate задает тему письма и вводную фразу для типа события.
+type eventTemplate struct {
+	Subject string
+	Lead    string
+}
+
+// eventTemplates — тексты писем по типам UserEvent. Тип без шаблона
+// отправляется с заголовком события в теме.
+var eventTemplates = map[string]eventTemplate{
+	models.UserEventAssignmentCreated:       {"Новое поручение", "Вам назначено поручение."},
+	models.UserEventAssignmentUpdated:       {"Поручение изменено", "Изменено поручение, в котором вы участвуете."},
+	models.UserEventAssignmentCompleted:     {"Поручение ожидает приемки", "Исполнитель отчитался о выполнении поручения."},
+	models.UserEventAssignmentFinished:      {"Поручение принято", "Поручение принято и закрыто."},
+	models.UserEventAssignmentReturned:      {"Поручение возвращено на доработку", "Поручение возвращено исполнителю на доработку."},
+	models.UserEventExtensionRequested:      {"Запрос продления срока поручения", "Исполнитель просит продлить срок поручения."},
+	models.UserEventExtensionApproved:       {"Срок поручения продлен", "Запрос на продление срока поручения одобрен."},
+	models.UserEventExtensionRejected:       {"Продление срока отклонено", "Запрос на продление срока поручения отклонен."},
+	models.UserEventAssignmentReminder:      {"Приближается срок поручения", "Напоминаем о сроке поручения."},
+	models.UserEventAssignmentOverdue:       {"Поручение просрочено", "Срок поручения истек, поручение не выполнено."},
+	models.UserEventAssignmentEscalated:     {"Эскалация просроченного поручения", "Поручение, которое вы выдали или которое исполняет ваше подразделение, просрочено."},
+	models.UserEventAcknowledgmentCreated:   {"Ознакомление с документом", "Вам необходимо ознакомиться с документом."},
+	models.UserEventAcknowledgmentConfirmed: {"Ознакомление подтверждено", "Пользователь подтвердил ознакомление с документом."},
+	models.UserEventAcknowledgmentReminder:  {"Ожидается ознакомление", "Напоминаем о неподтвержденном ознакомлении с документом."},
+	models.UserEventApprovalRequested:       {"Проект письма на согласовании", "Вам направлен проект исходящего письма на согласование."},
+	models.UserEventApprovalReturned:        {"Проект письма возвращен", "Проект исходящего письма возвращен на доработку."},
+	models.UserEventApprovalRejected:        {"Проект письма отклонен", "Проект исходящего письма отклонен."},
+	models.UserEventApprovalApproved:        {"Проект письма согласован", "Проект исходящего письма согласован."},
+	models.UserEventApprovalRegistered:      {"Письмо зарегистрировано", "Согласованное исходящее письмо зарегистрировано."},
+	models.UserEventSubstitutionExpiring:    {"Замещение заканчивается", "Срок замещения подходит к концу."},
+}
+
+const mailFooter = "Письмо отправлено системой документооборота автоматически, отвечать на него не нужно. Режим почтовых уведомлений меняется в профиле пользователя."
+
+type eventView struct {
+	Name     string
+	Lead     string
+	Title    string
+	Message  string
+	Document string
+	Footer   string
+}
+
+type digestItemView struct {
+	Time     string
+	Title    string
+	Message  string
+	Document string
+}
+
+type digestView struct {
+	Name   string
+	Date   string
+	Events []digestItemView
+	Footer string
+}
+
+var eventText = texttemplate.Must(texttemplate.New("event").Parse(`{{if .Name}}{{.Name}}, здравствуйте!
+
+{{end}}{{.Lead}}
+
+{{.Title}}
+{{if .Message}}{{.Message}}
+{{end}}{{if .Document}}Документ: {{.Document}}
+{{end}}
+--
+{{.Footer}}
+`))
+
+var eventHTML = htmltemplate.Must(htmltemplate.New("event").Parse(`<!DOCTYPE html>
+<html lang="ru"><body style="font-family: Arial, sans-serif; font-size: 14px; color: #1f1f1f;">
+{{if .Name}}<p>{{.Name}}, здравствуйте!</p>
+{{end}}<p>{{.Lead}}</p>
+<p><strong>{{.Title}}</strong>{{if .Message}}<br>{{.Message}}{{end}}</p>
+{{if .Document}}<p>Документ: {{.Document}}</p>
+{{end}}<hr><p style="font-size: 12px; color: #6b6b6b;">{{.Footer}}</p>
+</body></html>
+`))
+
+var digestText = texttemplate.Must(texttemplate.New("digest").Parse(`{{if .Name}}{{.Name}}, здравствуйте!
+
+{{end}}События за {{.Date}}:
+{{range .Events}}
+{{.Time}} {{.Title}}
+{{if .Message}}{{.Message}}
+{{end}}{{if .Document}}Документ: {{.Document}}
+{{end}}{{end}}
+--
+{{.Footer}}
+`))
+
+var digestHTML = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html lang="ru"><body style="font-family: Arial, sans-serif; font-size: 14px; color: #1f1f1f;">
+{{if .Name}}<p>{{.Name}}, здравствуйте!</p>
+{{end}}<p>События за {{.Date}}:</p>
+<ul>
+{{range .Events}}<li><p>{{.Time}} <strong>{{.Title}}</strong>{{if .Message}}<br>{{.Message}}{{end}}{{if .Document}}<br>Документ: {{.Document}}{{end}}</p></li>
+{{end}}</ul>
+<hr><p style="font-size: 12px; color: #6b6b6b;">{{.Footer}}</p>
+</body></html>
+`))
+
+// RenderUserEvent формирует письмо о событии пользователя. Адрес получателя
+// заполняет вызывающий.
+func RenderUserEvent(recipientName string, event models.CreateUserEventRequest) (Message, error) {
+	tmpl, ok := eventTemplates[event.EventType]
+	if !ok {
+		tmpl = eventTemplate{Subject: event.Title}
+	}
+	document := documentLabel(event.DocumentKind, event.DocumentNumber)
+	subject := tmpl.Subject
+	if event.DocumentNumber != "" {
+		subject += " по документу № " + event.DocumentNumber
+	}
+	view := eventView{
+		Name:     strings.TrimSpace(recipientName),
+		Lead:     tmpl.Lead,
+		Title:    event.Title,
+		Message:  event.Message,
+		Document: document,
+		Footer:   mailFooter,
+	}
+	return render(subject, eventText, eventHTML, view)
+}
+
+// RenderDigest формирует ежедневную сводку событий пользователя за date.
+func RenderDigest(recipientName string, date time.Time, events []models.UserEvent) (Message, error) {
+	view := digestView{
+		Name:   strings.TrimSpace(recipientName),
+		Date:   date.Format("02.01.2006"),
+		Events: make([]digestItemView, 0, len(events)),
+		Footer: mailFooter,
+	}
+	for _, event := range events {
+		view.Events = append(view.Events, digestItemView{
+			Time:     event.CreatedAt.In(date.Location()).Format("15:04"),
+			Title:    event.Title,
+			Message:  event.Message,
+			Document: documentLabel(event.DocumentKind, event.DocumentNumber),
+		})
+	}
+	subject := "Сводка событий за " + view.Date + ": " + strconv.Itoa(len(events))
+	return render(subject, digestText, digestHTML, view)
+}
+
+func render(subject string, text *texttemplate.Template, html *htmltemplate.Template, data any) (Message, error) {
+	var textBody, htmlBody strings.Builder
+	if err := text.Execute(&textBody, data); err != nil {
+		return Message{}, err
+	}
+	if err := html.Execute(&htmlBody, data); err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: subject, Text: textBody.String(), HTML: htmlBody.String()}, nil
+}
+
+func documentLabel(kind, number string) string {
+	label := ""
+	if kind != "" {
+		label = models.NormalizeDocumentKind(kind).Label()
+	}
+	if number == "" {
+		return label
+	}
+	if label == "" {
+		return "№ " + number
+	}
+	return label + " № " + number
+}
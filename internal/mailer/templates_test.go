@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestRenderUserEvent(t *testing.T) {
+	message, err := RenderUserEvent("Петров П.П.", models.CreateUserEventRequest{
+		DocumentKind:   "incoming_letter",
+		DocumentNumber: "ВХ-1",
+		EventType:      models.UserEventAssignmentCreated,
+		Title:          "Новое поручение",
+		Message:        "Подготовить ответ <до пятницы>",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Новое поручение по документу № ВХ-1", message.Subject)
+	assert.Contains(t, message.Text, "Петров П.П., здравствуйте!")
+	assert.Contains(t, message.Text, "Вам назначено поручение.")
+	assert.Contains(t, message.Text, "Подготовить ответ <до пятницы>")
+	assert.Contains(t, message.Text, "Документ: Входящее письмо № ВХ-1")
+	assert.Contains(t, message.HTML, "Подготовить ответ &lt;до пятницы&gt;")
+	assert.NotContains(t, message.HTML, "<до пятницы>")
+}
+
+func TestRenderUserEventCoversEventTypes(t *testing.T) {
+	for _, eventType := range []string{
+		models.UserEventAssignmentCreated, models.UserEventAssignmentUpdated, models.UserEventAssignmentCompleted,
+		models.UserEventAssignmentFinished, models.UserEventAssignmentReturned, models.UserEventExtensionRequested,
+		models.UserEventExtensionApproved, models.UserEventExtensionRejected, models.UserEventAssignmentReminder,
+		models.UserEventAssignmentOverdue, models.UserEventAssignmentEscalated, models.UserEventAcknowledgmentCreated,
+		models.UserEventAcknowledgmentConfirmed, models.UserEventAcknowledgmentReminder, models.UserEventApprovalRequested,
+		models.UserEventApprovalReturned, models.UserEventApprovalRejected, models.UserEventApprovalApproved,
+		models.UserEventApprovalRegistered, models.UserEventSubstitutionExpiring,
+	} {
+		tmpl, ok := eventTemplates[eventType]
+		require.True(t, ok, eventType)
+		assert.NotEmpty(t, tmpl.Subject, eventType)
+		assert.NotEmpty(t, tmpl.Lead, eventType)
+	}
+
+	message, err := RenderUserEvent("", models.CreateUserEventRequest{EventType: "custom_event", Title: "Событие"})
+	require.NoError(t, err)
+	assert.Equal(t, "Событие", message.Subject)
+	assert.NotContains(t, message.Text, "здравствуйте")
+}
+
+func TestRenderDigest(t *testing.T) {
+	date := time.Date(2026, 7, 16, 0, 0, 0, 0, time.UTC)
+	message, err := RenderDigest("Петров П.П.", date, []models.UserEvent{
+		{DocumentKind: "outgoing_letter", DocumentNumber: "ИСХ-5", Title: "Ознакомление с документом", Message: "Ознакомьтесь", CreatedAt: date.Add(9*time.Hour + 15*time.Minute)},
+		{Title: "Замещение заканчивается", CreatedAt: date.Add(17 * time.Hour)},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Сводка событий за 16.07.2026: 2", message.Subject)
+	assert.Contains(t, message.Text, "09:15 Ознакомление с документом")
+	assert.Contains(t, message.Text, "Документ: Исходящее письмо № ИСХ-5")
+	assert.Contains(t, message.HTML, "<strong>Замещение заканчивается</strong>")
+}
@@ -18,7 +18,7 @@ type AssignmentStore struct {
 	Effects []models.OutboxEvent
 }
 
-func (_m *AssignmentStore) CreateWithOutbox(id, documentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, effects []models.OutboxEvent) (*models.Assignment, error) {
+func (_m *AssignmentStore) CreateWithOutbox(id, documentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, _ uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
 	// The generated mock delegates persistence expectations to Create while
 	// retaining the outbox intent for assertions in service tests.
 	_m.Effects = append(_m.Effects, effects...)
@@ -29,7 +29,7 @@ func (_m *AssignmentStore) CreateWithOutbox(id, documentID, executorID uuid.UUID
 	return assignment, err
 }
 
-func (_m *AssignmentStore) UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, effects []models.OutboxEvent) (*models.Assignment, error) {
+func (_m *AssignmentStore) UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, _ uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
 	_m.Effects = append(_m.Effects, effects...)
 	return _m.Update(id, executorID, content, deadline, status, report, completedAt, coExecutorIDs)
 }
@@ -123,6 +123,36 @@ func (_m *DepartmentStore) GetNomenclatureIDs(departmentID uuid.UUID) ([]string,
 	return r0, r1
 }
 
+// GetVisibleNomenclatureIDs provides a mock function with given fields: departmentID, userID
+func (_m *DepartmentStore) GetVisibleNomenclatureIDs(departmentID *uuid.UUID, userID uuid.UUID) ([]string, error) {
+	ret := _m.Called(departmentID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVisibleNomenclatureIDs")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*uuid.UUID, uuid.UUID) ([]string, error)); ok {
+		return rf(departmentID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(*uuid.UUID, uuid.UUID) []string); ok {
+		r0 = rf(departmentID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(departmentID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: id, name, nomenclatureIDs
 func (_m *DepartmentStore) Update(id uuid.UUID, name string, nomenclatureIDs []string) (*models.Department, error) {
 	ret := _m.Called(id, name, nomenclatureIDs)
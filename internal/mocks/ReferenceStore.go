@@ -15,9 +15,9 @@ type ReferenceStore struct {
 	mock.Mock
 }
 
-// CreateDocumentType provides a mock function with given fields: name
-func (_m *ReferenceStore) CreateDocumentType(name string) (*models.DocumentType, error) {
-	ret := _m.Called(name)
+// CreateDocumentType provides a mock function with given fields: name, kinds
+func (_m *ReferenceStore) CreateDocumentType(name string, kinds []models.DocumentKind) (*models.DocumentType, error) {
+	ret := _m.Called(name, kinds)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateDocumentType")
@@ -25,19 +25,19 @@ func (_m *ReferenceStore) CreateDocumentType(name string) (*models.DocumentType,
 
 	var r0 *models.DocumentType
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*models.DocumentType, error)); ok {
-		return rf(name)
+	if rf, ok := ret.Get(0).(func(string, []models.DocumentKind) (*models.DocumentType, error)); ok {
+		return rf(name, kinds)
 	}
-	if rf, ok := ret.Get(0).(func(string) *models.DocumentType); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(string, []models.DocumentKind) *models.DocumentType); ok {
+		r0 = rf(name, kinds)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.DocumentType)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(string, []models.DocumentKind) error); ok {
+		r1 = rf(name, kinds)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -141,6 +141,36 @@ func (_m *ReferenceStore) GetAllDocumentTypes() ([]models.DocumentType, error) {
 	return r0, r1
 }
 
+// GetDocumentTypesByKind provides a mock function with given fields: kind
+func (_m *ReferenceStore) GetDocumentTypesByKind(kind models.DocumentKind) ([]models.DocumentType, error) {
+	ret := _m.Called(kind)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDocumentTypesByKind")
+	}
+
+	var r0 []models.DocumentType
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.DocumentKind) ([]models.DocumentType, error)); ok {
+		return rf(kind)
+	}
+	if rf, ok := ret.Get(0).(func(models.DocumentKind) []models.DocumentType); ok {
+		r0 = rf(kind)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.DocumentType)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.DocumentKind) error); ok {
+		r1 = rf(kind)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAllOrganizations provides a mock function with no fields
 func (_m *ReferenceStore) GetAllOrganizations() ([]models.Organization, error) {
 	ret := _m.Called()
@@ -219,22 +249,34 @@ func (_m *ReferenceStore) SearchOrganizations(query string) ([]models.Organizati
 	return r0, r1
 }
 
-// UpdateDocumentType provides a mock function with given fields: id, name
-func (_m *ReferenceStore) UpdateDocumentType(id uuid.UUID, name string) error {
-	ret := _m.Called(id, name)
+// UpdateDocumentType provides a mock function with given fields: id, name, kinds, isActive
+func (_m *ReferenceStore) UpdateDocumentType(id uuid.UUID, name string, kinds []models.DocumentKind, isActive bool) (*models.DocumentType, error) {
+	ret := _m.Called(id, name, kinds, isActive)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateDocumentType")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, string) error); ok {
-		r0 = rf(id, name)
+	var r0 *models.DocumentType
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, []models.DocumentKind, bool) (*models.DocumentType, error)); ok {
+		return rf(id, name, kinds, isActive)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, []models.DocumentKind, bool) *models.DocumentType); ok {
+		r0 = rf(id, name, kinds, isActive)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.DocumentType)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(uuid.UUID, string, []models.DocumentKind, bool) error); ok {
+		r1 = rf(id, name, kinds, isActive)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // UpdateOrganization provides a mock function with given fields: id, name
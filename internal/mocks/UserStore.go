@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -29,8 +31,12 @@ func (_m *UserStore) ResetPasswordWithOutbox(id uuid.UUID, password string, _ []
 	return _m.ResetPassword(id, password)
 }
 
-func (_m *UserStore) IncrementFailedLoginAttemptsWithOutbox(id uuid.UUID, _ models.OutboxEvent) (int, bool, error) {
-	return _m.IncrementFailedLoginAttempts(id)
+func (_m *UserStore) IncrementFailedLoginAttemptsWithOutbox(id uuid.UUID, threshold int, _ models.OutboxEvent) (int, bool, error) {
+	return _m.IncrementFailedLoginAttempts(id, threshold)
+}
+
+func (_m *UserStore) ReleaseExpiredLockoutWithOutbox(id uuid.UUID, lockoutDuration time.Duration, _ models.OutboxEvent) (bool, error) {
+	return _m.ReleaseExpiredLockout(id, lockoutDuration)
 }
 
 // CountUsers provides a mock function with no fields
@@ -251,6 +257,36 @@ func (_m *UserStore) GetExecutors() ([]models.User, error) {
 	return r0, r1
 }
 
+// GetDepartmentExecutors provides a mock function with given fields: departmentID
+func (_m *UserStore) GetDepartmentExecutors(departmentID uuid.UUID) ([]models.User, error) {
+	ret := _m.Called(departmentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDepartmentExecutors")
+	}
+
+	var r0 []models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) ([]models.User, error)); ok {
+		return rf(departmentID)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) []models.User); ok {
+		r0 = rf(departmentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(departmentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetActiveUsers provides a mock function with no fields
 func (_m *UserStore) GetActiveUsers() ([]models.User, error) {
 	ret := _m.Called()
@@ -281,9 +317,39 @@ func (_m *UserStore) GetActiveUsers() ([]models.User, error) {
 	return r0, r1
 }
 
-// IncrementFailedLoginAttempts provides a mock function with given fields: userID
-func (_m *UserStore) IncrementFailedLoginAttempts(userID uuid.UUID) (int, bool, error) {
-	ret := _m.Called(userID)
+// GetPasswordHistory provides a mock function with given fields: userID, limit
+func (_m *UserStore) GetPasswordHistory(userID uuid.UUID, limit int) ([]string, error) {
+	ret := _m.Called(userID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPasswordHistory")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, int) ([]string, error)); ok {
+		return rf(userID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, int) []string); ok {
+		r0 = rf(userID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, int) error); ok {
+		r1 = rf(userID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementFailedLoginAttempts provides a mock function with given fields: userID, threshold
+func (_m *UserStore) IncrementFailedLoginAttempts(userID uuid.UUID, threshold int) (int, bool, error) {
+	ret := _m.Called(userID, threshold)
 
 	if len(ret) == 0 {
 		panic("no return value specified for IncrementFailedLoginAttempts")
@@ -292,23 +358,23 @@ func (_m *UserStore) IncrementFailedLoginAttempts(userID uuid.UUID) (int, bool,
 	var r0 int
 	var r1 bool
 	var r2 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) (int, bool, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, int) (int, bool, error)); ok {
+		return rf(userID, threshold)
 	}
-	if rf, ok := ret.Get(0).(func(uuid.UUID) int); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, int) int); ok {
+		r0 = rf(userID, threshold)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
-	if rf, ok := ret.Get(1).(func(uuid.UUID) bool); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(uuid.UUID, int) bool); ok {
+		r1 = rf(userID, threshold)
 	} else {
 		r1 = ret.Get(1).(bool)
 	}
 
-	if rf, ok := ret.Get(2).(func(uuid.UUID) error); ok {
-		r2 = rf(userID)
+	if rf, ok := ret.Get(2).(func(uuid.UUID, int) error); ok {
+		r2 = rf(userID, threshold)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -316,6 +382,34 @@ func (_m *UserStore) IncrementFailedLoginAttempts(userID uuid.UUID) (int, bool,
 	return r0, r1, r2
 }
 
+// ReleaseExpiredLockout provides a mock function with given fields: userID, lockoutDuration
+func (_m *UserStore) ReleaseExpiredLockout(userID uuid.UUID, lockoutDuration time.Duration) (bool, error) {
+	ret := _m.Called(userID, lockoutDuration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseExpiredLockout")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, time.Duration) (bool, error)); ok {
+		return rf(userID, lockoutDuration)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, time.Duration) bool); ok {
+		r0 = rf(userID, lockoutDuration)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, time.Duration) error); ok {
+		r1 = rf(userID, lockoutDuration)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ResetPassword provides a mock function with given fields: userID, newPassword
 func (_m *UserStore) ResetPassword(userID uuid.UUID, newPassword string) error {
 	ret := _m.Called(userID, newPassword)
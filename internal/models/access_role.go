@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Источники правил матрицы доступа, попадающие в объяснение решения.
+const (
+	AccessRuleSourceUser           = "user"
+	AccessRuleSourceDepartment     = "department"
+	AccessRuleSourceUserRole       = "user_role"
+	AccessRuleSourceDepartmentRole = "department_role"
+)
+
+// IsRoleSystemPermission сообщает, что системное право можно включить в роль.
+// Право admin ролью не выдается: инвариант «хотя бы один активный
+// администратор» проверяется по прямым правам пользователей.
+func IsRoleSystemPermission(code string) bool {
+	return code != SystemPermissionAdmin && IsSystemPermission(code)
+}
+
+// AccessRolePermission — действие над видом документа, которое разрешает роль.
+type AccessRolePermission struct {
+	KindCode string `json:"kindCode"`
+	Action   string `json:"action"`
+}
+
+// AccessRole — именованный шаблон прав («Регистратор», «Исполнитель»):
+// набор разрешений матрицы доступа и системных прав, назначаемый
+// пользователям и подразделениям.
+type AccessRole struct {
+	ID                uuid.UUID              `json:"id"`
+	Name              string                 `json:"name"`
+	Description       string                 `json:"description"`
+	SystemPermissions []string               `json:"systemPermissions"`
+	Permissions       []AccessRolePermission `json:"permissions"`
+	UserIDs           []uuid.UUID            `json:"userIds"`
+	DepartmentIDs     []uuid.UUID            `json:"departmentIds"`
+	CreatedAt         time.Time              `json:"createdAt"`
+	UpdatedAt         time.Time              `json:"updatedAt"`
+}
+
+// SaveAccessRoleRequest описывает создание или изменение роли. ID пуст при создании.
+type SaveAccessRoleRequest struct {
+	ID                string                 `json:"id"`
+	Name              string                 `json:"name"`
+	Description       string                 `json:"description"`
+	SystemPermissions []string               `json:"systemPermissions"`
+	Permissions       []AccessRolePermission `json:"permissions"`
+}
+
+// UpdateAccessRoleAssignmentsRequest заменяет список пользователей и подразделений роли.
+type UpdateAccessRoleAssignmentsRequest struct {
+	RoleID        string   `json:"roleId"`
+	UserIDs       []string `json:"userIds"`
+	DepartmentIDs []string `json:"departmentIds"`
+}
+
+// AccessRuleMatch — правило матрицы доступа, применимое к пользователю.
+// Для правил ролей заполнены RoleID и RoleName.
+type AccessRuleMatch struct {
+	Source    string `json:"source"`
+	RoleID    string `json:"roleId,omitempty"`
+	RoleName  string `json:"roleName,omitempty"`
+	IsAllowed bool   `json:"isAllowed"`
+}
+
+// ResolveAccessRules сводит применимые правила в решение: действие разрешено,
+// если есть хотя бы одно разрешение и нет ни одного явного запрета.
+func ResolveAccessRules(rules []AccessRuleMatch) bool {
+	allowed := false
+	for _, rule := range rules {
+		if !rule.IsAllowed {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+// DocumentAccessExplanation объясняет администратору, почему пользователь
+// может или не может выполнить действие над документом.
+type DocumentAccessExplanation struct {
+	UserID     string `json:"userId"`
+	DocumentID string `json:"documentId"`
+	KindCode   string `json:"kindCode"`
+	Action     string `json:"action"`
+	Allowed    bool   `json:"allowed"`
+	// Reasons — шаги решения в порядке проверки.
+	Reasons []string `json:"reasons"`
+	// ActionRules — правила матрицы для запрошенного действия.
+	ActionRules []AccessRuleMatch `json:"actionRules"`
+	// ReadRules — правила матрицы для чтения, если Action — не read: любое
+	// действие над документом требует и доступа к нему на чтение.
+	ReadRules []AccessRuleMatch `json:"readRules"`
+}
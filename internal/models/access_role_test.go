@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveAccessRules(t *testing.T) {
+	allow := AccessRuleMatch{Source: AccessRuleSourceUserRole, RoleName: "Регистратор", IsAllowed: true}
+	deny := AccessRuleMatch{Source: AccessRuleSourceUser, IsAllowed: false}
+
+	assert.False(t, ResolveAccessRules(nil), "no rules means no access")
+	assert.True(t, ResolveAccessRules([]AccessRuleMatch{allow}))
+	assert.False(t, ResolveAccessRules([]AccessRuleMatch{allow, deny}), "explicit deny wins")
+	assert.False(t, ResolveAccessRules([]AccessRuleMatch{deny}))
+}
+
+func TestIsRoleSystemPermission(t *testing.T) {
+	assert.True(t, IsRoleSystemPermission(SystemPermissionReferences))
+	assert.False(t, IsRoleSystemPermission(SystemPermissionAdmin))
+	assert.False(t, IsRoleSystemPermission("unknown"))
+}
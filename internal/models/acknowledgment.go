@@ -6,6 +6,13 @@ import (
 	"github.com/google/uuid"
 )
 
+// Состояния срока ознакомления для фильтра активных задач.
+const (
+	AcknowledgmentDeadlineOverdue  = "overdue"  // срок прошел
+	AcknowledgmentDeadlineUpcoming = "upcoming" // срок сегодня или позже
+	AcknowledgmentDeadlineNone     = "none"     // срок не установлен
+)
+
 // Acknowledgment - задача на ознакомление
 type Acknowledgment struct {
 	ID             uuid.UUID `json:"-"`
@@ -19,6 +26,10 @@ type Acknowledgment struct {
 	Content     string     `json:"content"`
 	CreatedAt   time.Time  `json:"createdAt"`
 	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// Deadline — необязательный срок ознакомления.
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Overdue — срок прошел, а ознакомились не все.
+	Overdue bool `json:"overdue"`
 
 	// Пользователи ознакомления
 	Users   []AcknowledgmentUser `json:"users,omitempty"`
@@ -34,6 +45,12 @@ type AcknowledgmentUser struct {
 	ViewedAt         *time.Time `json:"viewedAt,omitempty"`
 	ConfirmedAt      *time.Time `json:"confirmedAt,omitempty"`
 	CreatedAt        time.Time  `json:"createdAt"`
+
+	// ViewedBy и ConfirmedBy заполнены, если действие выполнил замещающий.
+	ViewedBy        *uuid.UUID `json:"-"`
+	ViewedByName    string     `json:"viewedByName,omitempty"`
+	ConfirmedBy     *uuid.UUID `json:"-"`
+	ConfirmedByName string     `json:"confirmedByName,omitempty"`
 }
 
 // AcknowledgmentFilter описывает параметры фильтрации задач на ознакомление.
@@ -42,6 +59,8 @@ type AcknowledgmentFilter struct {
 	UserID       string `json:"userId,omitempty"`
 	Status       string `json:"status,omitempty"` // pending, completed
 	ShowFinished bool   `json:"showFinished"`
+	// DeadlineState отбирает задачи по сроку: overdue, upcoming или none.
+	DeadlineState string `json:"deadlineState,omitempty"`
 
 	// AllowedDocumentKinds — серверный scope, не принимается с клиента.
 	AllowedDocumentKinds []string `json:"-"`
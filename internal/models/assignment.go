@@ -21,6 +21,14 @@ type Assignment struct {
 	Report      string     `json:"report,omitempty"`
 	CompletedAt *time.Time `json:"completedAt,omitempty"`
 
+	// Иерархия: подпоручение создает исполнитель родительского поручения.
+	ParentID         *uuid.UUID `json:"-"`
+	SubtaskCount     int        `json:"subtaskCount"`
+	OpenSubtaskCount int        `json:"openSubtaskCount"` // не принятые и не отмененные подпоручения
+
+	// SeriesID заполнен у поручения, созданного генератором серии.
+	SeriesID *uuid.UUID `json:"-"`
+
 	DocumentNumber  string `json:"documentNumber,omitempty"`
 	DocumentSubject string `json:"documentSubject,omitempty"`
 
@@ -37,6 +45,7 @@ type AssignmentFilter struct {
 	Search       string `json:"search,omitempty"`
 	DocumentID   string `json:"documentId,omitempty"`
 	ExecutorID   string `json:"executorId,omitempty"`
+	SeriesID     string `json:"seriesId,omitempty"`
 	Status       string `json:"status,omitempty"`
 	DateFrom     string `json:"dateFrom,omitempty"`
 	DateTo       string `json:"dateTo,omitempty"`
@@ -49,12 +58,16 @@ type AssignmentFilter struct {
 	AllowedDocumentKinds []string `json:"-"`
 	AccessibleByUserID   string   `json:"-"`
 	AccessibleByUserIDs  []string `json:"-"`
+	// AccessibleByUserKindKeys — пары SubjectKindKey замещений, ограниченных
+	// видами документов: поручение замещаемого видно только по этим видам.
+	AccessibleByUserKindKeys []string `json:"-"`
 }
 
 // DashboardAssignmentFilter — серверный scope для поручений с истекающим сроком.
 // Поля доступа не принимаются с клиента.
 type DashboardAssignmentFilter struct {
-	Days                 int      `json:"-"`
-	AllowedDocumentKinds []string `json:"-"`
-	AccessibleByUserIDs  []string `json:"-"`
+	Days                     int      `json:"-"`
+	AllowedDocumentKinds     []string `json:"-"`
+	AccessibleByUserIDs      []string `json:"-"`
+	AccessibleByUserKindKeys []string `json:"-"`
 }
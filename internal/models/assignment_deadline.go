@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы запроса на продление срока поручения.
+const (
+	DeadlineExtensionPending  = "pending"
+	DeadlineExtensionApproved = "approved"
+	DeadlineExtensionRejected = "rejected"
+)
+
+// Базис расчета просрочки в статистике поручений.
+const (
+	AssignmentDeadlineBasisCurrent  = "current"  // срок с учетом одобренных продлений
+	AssignmentDeadlineBasisOriginal = "original" // первый плановый срок
+)
+
+// AssignmentDeadlineExtension — запрос исполнителя на продление срока поручения.
+type AssignmentDeadlineExtension struct {
+	ID              uuid.UUID `json:"-"`
+	AssignmentID    uuid.UUID `json:"-"`
+	RequestedBy     uuid.UUID `json:"-"`
+	RequestedByName string    `json:"requestedByName"`
+	// OnBehalfOfUserID заполнен, если запрос подал замещающий исполнителя.
+	OnBehalfOfUserID   *uuid.UUID `json:"-"`
+	OnBehalfOfUserName string     `json:"onBehalfOfUserName,omitempty"`
+	PreviousDeadline   *time.Time `json:"previousDeadline,omitempty"`
+	RequestedDeadline  time.Time  `json:"requestedDeadline"`
+	Reason             string     `json:"reason"`
+	Status             string     `json:"status"`
+	DecidedBy          *uuid.UUID `json:"-"`
+	DecidedByName      string     `json:"decidedByName,omitempty"`
+	DecisionComment    string     `json:"decisionComment,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	DecidedAt          *time.Time `json:"decidedAt,omitempty"`
+}
+
+// AssignmentDeadlineChange — запись истории срока поручения.
+type AssignmentDeadlineChange struct {
+	ID            uuid.UUID  `json:"-"`
+	AssignmentID  uuid.UUID  `json:"-"`
+	OldDeadline   *time.Time `json:"oldDeadline,omitempty"`
+	NewDeadline   *time.Time `json:"newDeadline,omitempty"`
+	ChangedBy     uuid.UUID  `json:"-"`
+	ChangedByName string     `json:"changedByName"`
+	// ExtensionID ссылается на одобренный запрос; пуст для правки распорядителем.
+	ExtensionID *uuid.UUID `json:"-"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
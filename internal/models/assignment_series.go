@@ -0,0 +1,304 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды расписания серии поручений.
+const (
+	AssignmentScheduleCron       = "cron"        // дни месяца, месяцы и дни недели в формате cron
+	AssignmentScheduleWorkingDay = "working_day" // N-й рабочий день месяца
+)
+
+// Статусы серии поручений.
+const (
+	AssignmentSeriesActive   = "active"
+	AssignmentSeriesPaused   = "paused"
+	AssignmentSeriesStopped  = "stopped"
+	AssignmentSeriesFinished = "finished" // расписание исчерпано датой окончания
+)
+
+// MaxAssignmentSeriesLeadDays ограничивает, за сколько дней до срока создается поручение серии.
+const MaxAssignmentSeriesLeadDays = 90
+
+// assignmentScheduleHorizon — сколько лет вперед ищется следующий срок:
+// расписание без сроков в этом окне считается невыполнимым ("31 2 *").
+const assignmentScheduleHorizon = 5
+
+// AssignmentSchedule — расписание сроков серии поручений. Срок, выпавший по
+// cron на нерабочий день, переносится на следующий рабочий день.
+type AssignmentSchedule struct {
+	Kind string `json:"kind"`
+	// Cron — три поля cron: день месяца, месяц, день недели (0 или 7 —
+	// воскресенье). Поддерживаются *, списки, диапазоны и шаг: "5 * *",
+	// "1 1,4,7,10 *", "* * 1-5". Если ограничены и день месяца, и день недели,
+	// подходит любой из них, как в cron.
+	Cron string `json:"cron,omitempty"`
+	// WorkingDay — номер рабочего дня месяца; отрицательный считается от конца
+	// месяца: -1 — последний рабочий день.
+	WorkingDay int `json:"workingDay,omitempty"`
+	// MonthInterval — период в месяцах для working_day, отсчитывается от месяца
+	// начала серии: 1 — ежемесячно, 3 — ежеквартально.
+	MonthInterval int `json:"monthInterval,omitempty"`
+}
+
+// AssignmentSeries — повторяющееся поручение по документу. Генератор заранее
+// создает из серии обычные поручения со сроками по расписанию.
+type AssignmentSeries struct {
+	ID             uuid.UUID          `json:"-"`
+	DocumentID     uuid.UUID          `json:"-"`
+	DocumentKind   string             `json:"documentKind"`
+	DocumentNumber string             `json:"documentNumber,omitempty"`
+	ExecutorID     uuid.UUID          `json:"-"`
+	ExecutorName   string             `json:"executorName,omitempty"`
+	CoExecutorIDs  []string           `json:"coExecutorIds,omitempty"`
+	Content        string             `json:"content"`
+	Schedule       AssignmentSchedule `json:"schedule"`
+	// LeadDays — за сколько календарных дней до срока создается поручение.
+	LeadDays int        `json:"leadDays"`
+	StartsOn time.Time  `json:"startsOn"`
+	EndsOn   *time.Time `json:"endsOn,omitempty"`
+	// LastDeadline — срок последнего созданного поручения серии.
+	LastDeadline *time.Time `json:"lastDeadline,omitempty"`
+	// PausedOn и ResumedOn — даты последней приостановки и возобновления.
+	PausedOn      *time.Time `json:"pausedOn,omitempty"`
+	ResumedOn     *time.Time `json:"resumedOn,omitempty"`
+	Status        string     `json:"status"`
+	CreatedBy     uuid.UUID  `json:"-"`
+	CreatedByName string     `json:"createdByName,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+
+	InstanceCount     int `json:"instanceCount"`
+	OpenInstanceCount int `json:"openInstanceCount"` // не принятые и не отмененные поручения серии
+}
+
+// CreateAssignmentSeriesRequest — запрос на создание серии поручений. Даты
+// передаются в формате 2006-01-02; пустая дата начала — сегодня.
+type CreateAssignmentSeriesRequest struct {
+	DocumentID    string             `json:"documentId"`
+	ExecutorID    string             `json:"executorId"`
+	CoExecutorIDs []string           `json:"coExecutorIds"`
+	Content       string             `json:"content"`
+	Schedule      AssignmentSchedule `json:"schedule"`
+	LeadDays      int                `json:"leadDays"`
+	StartsOn      string             `json:"startsOn"`
+	EndsOn        string             `json:"endsOn"`
+}
+
+// NextDeadline возвращает срок следующего поручения серии после LastDeadline
+// (или первый срок не раньше StartsOn) и false, если расписание исчерпано.
+func (s *AssignmentSeries) NextDeadline(calendar *WorkingCalendar) (time.Time, bool) {
+	after := truncateToDay(s.StartsOn).AddDate(0, 0, -1)
+	if s.LastDeadline != nil && !s.LastDeadline.Before(after) {
+		after = truncateToDay(*s.LastDeadline)
+	}
+	next, ok := s.Schedule.next(after, s.StartsOn, calendar)
+	if !ok || (s.EndsOn != nil && next.After(truncateToDay(*s.EndsOn))) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// CreateOn возвращает дату, начиная с которой создается поручение со сроком deadline.
+func (s *AssignmentSeries) CreateOn(deadline time.Time) time.Time {
+	return deadline.AddDate(0, 0, -s.LeadDays)
+}
+
+// PausedAt сообщает, пришелся ли срок deadline на последнюю паузу серии:
+// от даты приостановки до даты возобновления, не включая ее.
+func (s *AssignmentSeries) PausedAt(deadline time.Time) bool {
+	if s.PausedOn == nil || deadline.Before(*s.PausedOn) {
+		return false
+	}
+	return s.ResumedOn == nil || deadline.Before(*s.ResumedOn)
+}
+
+// Validate проверяет расписание.
+func (s AssignmentSchedule) Validate() error {
+	switch s.Kind {
+	case AssignmentScheduleCron:
+		_, err := parseDayCron(s.Cron)
+		return err
+	case AssignmentScheduleWorkingDay:
+		if s.WorkingDay == 0 || s.WorkingDay < -23 || s.WorkingDay > 23 {
+			return fmt.Errorf("номер рабочего дня должен быть от 1 до 23 или от -1 до -23")
+		}
+		if s.MonthInterval < 1 || s.MonthInterval > 12 {
+			return fmt.Errorf("период в месяцах должен быть от 1 до 12")
+		}
+		return nil
+	}
+	return fmt.Errorf("неизвестный вид расписания %q", s.Kind)
+}
+
+// Label возвращает расписание в виде фразы для журнала и карточки серии.
+func (s AssignmentSchedule) Label() string {
+	switch s.Kind {
+	case AssignmentScheduleCron:
+		return "по расписанию «" + strings.Join(strings.Fields(s.Cron), " ") + "»"
+	case AssignmentScheduleWorkingDay:
+		day := strconv.Itoa(s.WorkingDay) + "-й рабочий день"
+		if s.WorkingDay == -1 {
+			day = "последний рабочий день"
+		} else if s.WorkingDay < 0 {
+			day = strconv.Itoa(-s.WorkingDay) + "-й с конца рабочий день"
+		}
+		if s.MonthInterval > 1 {
+			return day + " каждого " + strconv.Itoa(s.MonthInterval) + "-го месяца"
+		}
+		return day + " месяца"
+	}
+	return s.Kind
+}
+
+// next возвращает первый срок строго после after. Месяцы working_day
+// отсчитываются от месяца anchor.
+func (s AssignmentSchedule) next(after, anchor time.Time, calendar *WorkingCalendar) (time.Time, bool) {
+	after = truncateToDay(after)
+	limit := after.AddDate(assignmentScheduleHorizon, 0, 0)
+	switch s.Kind {
+	case AssignmentScheduleCron:
+		spec, err := parseDayCron(s.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		for day := after.AddDate(0, 0, 1); !day.After(limit); day = day.AddDate(0, 0, 1) {
+			if !spec.matches(day) {
+				continue
+			}
+			if deadline := calendar.NextWorkingDay(day); deadline.After(after) {
+				return deadline, true
+			}
+		}
+	case AssignmentScheduleWorkingDay:
+		interval := max(s.MonthInterval, 1)
+		anchorMonth := monthIndex(anchor)
+		month := time.Date(after.Year(), after.Month(), 1, 0, 0, 0, 0, after.Location())
+		for ; !month.After(limit); month = month.AddDate(0, 1, 0) {
+			if (monthIndex(month)-anchorMonth)%interval != 0 {
+				continue
+			}
+			if deadline, ok := nthWorkingDay(month, s.WorkingDay, calendar); ok && deadline.After(after) {
+				return deadline, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func monthIndex(day time.Time) int {
+	return day.Year()*12 + int(day.Month()) - 1
+}
+
+// nthWorkingDay возвращает n-й рабочий день месяца month; n < 0 считается от конца.
+func nthWorkingDay(month time.Time, n int, calendar *WorkingCalendar) (time.Time, bool) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	last := first.AddDate(0, 1, -1)
+	day, step, count := first, 1, n
+	if n < 0 {
+		day, step, count = last, -1, -n
+	}
+	for ; day.Month() == first.Month(); day = day.AddDate(0, 0, step) {
+		if calendar.IsWorkingDay(day) {
+			count--
+			if count == 0 {
+				return day, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// dayCron — разобранные поля cron по дням.
+type dayCron struct {
+	days, months, weekdays map[int]bool
+	anyDay, anyWeekday     bool
+}
+
+func (c dayCron) matches(day time.Time) bool {
+	if !c.months[int(day.Month())] {
+		return false
+	}
+	dayMatch, weekdayMatch := c.days[day.Day()], c.weekdays[int(day.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayMatch
+	case c.anyWeekday:
+		return dayMatch
+	}
+	return dayMatch || weekdayMatch
+}
+
+func parseDayCron(value string) (dayCron, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return dayCron{}, fmt.Errorf("расписание cron должно содержать три поля: день месяца, месяц, день недели")
+	}
+	days, err := parseCronField(fields[0], 1, 31)
+	if err != nil {
+		return dayCron{}, fmt.Errorf("день месяца: %w", err)
+	}
+	months, err := parseCronField(fields[1], 1, 12)
+	if err != nil {
+		return dayCron{}, fmt.Errorf("месяц: %w", err)
+	}
+	weekdays, err := parseCronField(fields[2], 0, 7)
+	if err != nil {
+		return dayCron{}, fmt.Errorf("день недели: %w", err)
+	}
+	if weekdays[7] {
+		weekdays[0] = true
+	}
+	return dayCron{days: days, months: months, weekdays: weekdays, anyDay: fields[0] == "*", anyWeekday: fields[2] == "*"}, nil
+}
+
+// parseCronField разбирает поле cron: "*", "5", "1-5", "*/2", "1-15/7" и их списки.
+func parseCronField(field string, low, high int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			parsed, err := strconv.Atoi(after)
+			if err != nil || parsed < 1 {
+				return nil, fmt.Errorf("неверный шаг %q", part)
+			}
+			rangePart, step = before, parsed
+		}
+		from, to := low, high
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			before, after, _ := strings.Cut(rangePart, "-")
+			var errFrom, errTo error
+			from, errFrom = strconv.Atoi(before)
+			to, errTo = strconv.Atoi(after)
+			if errFrom != nil || errTo != nil || from > to {
+				return nil, fmt.Errorf("неверный диапазон %q", part)
+			}
+		default:
+			parsed, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("неверное значение %q", part)
+			}
+			from, to = parsed, parsed
+			if step > 1 {
+				to = high
+			}
+		}
+		if from < low || to > high {
+			return nil, fmt.Errorf("значение %q вне диапазона %d-%d", part, low, high)
+		}
+		for value := from; value <= to; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
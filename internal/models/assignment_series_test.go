@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seriesDeadlines(t *testing.T, series AssignmentSeries, calendar *WorkingCalendar, count int) []time.Time {
+	t.Helper()
+	var deadlines []time.Time
+	for range count {
+		next, ok := series.NextDeadline(calendar)
+		if !ok {
+			break
+		}
+		deadlines = append(deadlines, next)
+		series.LastDeadline = &next
+	}
+	return deadlines
+}
+
+func TestAssignmentSeriesWorkingDaySchedule(t *testing.T) {
+	// 1 мая 2026 — праздник, 9 мая (суббота) — перенесенный рабочий день.
+	calendar := NewWorkingCalendar([]WorkingCalendarDay{
+		{Day: date(2026, 5, 1), IsWorkingDay: false},
+		{Day: date(2026, 5, 9), IsWorkingDay: true},
+	})
+
+	series := AssignmentSeries{Schedule: AssignmentSchedule{Kind: AssignmentScheduleWorkingDay, WorkingDay: 3, MonthInterval: 1}, StartsOn: date(2026, 4, 10)}
+	assert.Equal(t, []time.Time{date(2026, 5, 6), date(2026, 6, 3), date(2026, 7, 3)}, seriesDeadlines(t, series, calendar, 3))
+
+	series.Schedule.WorkingDay = -1
+	series.StartsOn = date(2026, 4, 1)
+	assert.Equal(t, []time.Time{date(2026, 4, 30), date(2026, 5, 29)}, seriesDeadlines(t, series, calendar, 2))
+
+	// Ежеквартально от месяца начала серии, до даты окончания.
+	endsOn := date(2026, 12, 31)
+	series = AssignmentSeries{Schedule: AssignmentSchedule{Kind: AssignmentScheduleWorkingDay, WorkingDay: 1, MonthInterval: 3}, StartsOn: date(2026, 2, 1), EndsOn: &endsOn}
+	assert.Equal(t, []time.Time{date(2026, 2, 2), date(2026, 5, 4), date(2026, 8, 3), date(2026, 11, 2)}, seriesDeadlines(t, series, calendar, 10))
+}
+
+func TestAssignmentSeriesCronSchedule(t *testing.T) {
+	calendar := NewWorkingCalendar([]WorkingCalendarDay{{Day: date(2026, 5, 1), IsWorkingDay: false}})
+
+	// 1-е число квартала; 1 мая и выходные переносятся на рабочий день.
+	series := AssignmentSeries{Schedule: AssignmentSchedule{Kind: AssignmentScheduleCron, Cron: "1 2,5,8 *"}, StartsOn: date(2026, 1, 15)}
+	assert.Equal(t, []time.Time{date(2026, 2, 2), date(2026, 5, 4), date(2026, 8, 3)}, seriesDeadlines(t, series, calendar, 3))
+
+	// Ежедневно: выходные не дают повторных сроков в понедельник.
+	series = AssignmentSeries{Schedule: AssignmentSchedule{Kind: AssignmentScheduleCron, Cron: "* * *"}, StartsOn: date(2026, 5, 7)}
+	assert.Equal(t, []time.Time{date(2026, 5, 7), date(2026, 5, 8), date(2026, 5, 11), date(2026, 5, 12)}, seriesDeadlines(t, series, calendar, 4))
+
+	// День месяца или день недели, как в cron: 15-е число и пятницы.
+	series = AssignmentSeries{Schedule: AssignmentSchedule{Kind: AssignmentScheduleCron, Cron: "15 7 5"}, StartsOn: date(2026, 7, 9)}
+	assert.Equal(t, []time.Time{date(2026, 7, 10), date(2026, 7, 15), date(2026, 7, 17)}, seriesDeadlines(t, series, calendar, 3))
+
+	series = AssignmentSeries{Schedule: AssignmentSchedule{Kind: AssignmentScheduleCron, Cron: "*/10 1 *"}, StartsOn: date(2027, 1, 1)}
+	assert.Equal(t, []time.Time{date(2027, 1, 1), date(2027, 1, 11), date(2027, 1, 21), date(2027, 2, 1)}, seriesDeadlines(t, series, nil, 4))
+
+	_, ok := (&AssignmentSeries{Schedule: AssignmentSchedule{Kind: AssignmentScheduleCron, Cron: "31 2 *"}, StartsOn: date(2026, 1, 1)}).NextDeadline(nil)
+	assert.False(t, ok)
+}
+
+func TestAssignmentScheduleValidate(t *testing.T) {
+	require.NoError(t, AssignmentSchedule{Kind: AssignmentScheduleCron, Cron: "1,15 */3 1-5"}.Validate())
+	require.NoError(t, AssignmentSchedule{Kind: AssignmentScheduleCron, Cron: "* * 7"}.Validate())
+	require.NoError(t, AssignmentSchedule{Kind: AssignmentScheduleWorkingDay, WorkingDay: -2, MonthInterval: 1}.Validate())
+
+	for _, schedule := range []AssignmentSchedule{
+		{Kind: AssignmentScheduleCron, Cron: "0 9 1 * *"},
+		{Kind: AssignmentScheduleCron, Cron: "32 * *"},
+		{Kind: AssignmentScheduleCron, Cron: "5-1 * *"},
+		{Kind: AssignmentScheduleCron, Cron: "*/0 * *"},
+		{Kind: AssignmentScheduleCron, Cron: "* 13 *"},
+		{Kind: AssignmentScheduleWorkingDay, WorkingDay: 0, MonthInterval: 1},
+		{Kind: AssignmentScheduleWorkingDay, WorkingDay: 24, MonthInterval: 1},
+		{Kind: AssignmentScheduleWorkingDay, WorkingDay: 1, MonthInterval: 0},
+		{Kind: "weekly"},
+	} {
+		assert.Error(t, schedule.Validate(), schedule)
+	}
+}
+
+func TestAssignmentScheduleLabel(t *testing.T) {
+	assert.Equal(t, "5-й рабочий день месяца", AssignmentSchedule{Kind: AssignmentScheduleWorkingDay, WorkingDay: 5, MonthInterval: 1}.Label())
+	assert.Equal(t, "последний рабочий день каждого 3-го месяца", AssignmentSchedule{Kind: AssignmentScheduleWorkingDay, WorkingDay: -1, MonthInterval: 3}.Label())
+	assert.Equal(t, "по расписанию «1 * *»", AssignmentSchedule{Kind: AssignmentScheduleCron, Cron: " 1  * * "}.Label())
+}
@@ -8,16 +8,18 @@ import (
 
 // Attachment представляет собой прикрепленный к документу файл.
 type Attachment struct {
-	ID             uuid.UUID `json:"-"`
-	DocumentID     uuid.UUID `json:"-"`
-	Filename       string    `json:"filename"`
-	Filepath       string    `json:"filepath"` // внутренний путь
-	FileSize       int64     `json:"fileSize"`
-	ContentType    string    `json:"contentType"`
-	StoragePath    string    `json:"-"` // Путь к файлу в MinIO
-	UploadedBy     uuid.UUID `json:"-"`
-	UploadedByName string    `json:"uploadedByName,omitempty"` // заполняется при получении
-	UploadedAt     time.Time `json:"uploadedAt"`
+	ID              uuid.UUID `json:"-"`
+	DocumentID      uuid.UUID `json:"-"`
+	Filename        string    `json:"filename"`
+	Filepath        string    `json:"filepath"` // внутренний путь
+	FileSize        int64     `json:"fileSize"`
+	ContentType     string    `json:"contentType"`
+	StoragePath     string    `json:"-"` // Путь к файлу в MinIO
+	UploadedBy      uuid.UUID `json:"-"`
+	UploadedByName  string    `json:"uploadedByName,omitempty"` // заполняется при получении
+	UploadedAt      time.Time `json:"uploadedAt"`
+	SHA256          string    `json:"sha256,omitempty"` // пусто у вложений, загруженных до появления хэша
+	IntegrityStatus string    `json:"integrityStatus"`
 }
 
 // AttachmentStorageReconciliation is a read-only comparison of attachment
@@ -26,3 +28,60 @@ type AttachmentStorageReconciliation struct {
 	MissingObjects []string `json:"missingObjects"`
 	OrphanObjects  []string `json:"orphanObjects"`
 }
+
+// Статусы проверки целостности объекта вложения в хранилище.
+const (
+	AttachmentIntegrityUnverified = "unverified"
+	AttachmentIntegrityOK         = "ok"
+	AttachmentIntegrityCorrupted  = "corrupted"
+	AttachmentIntegrityTruncated  = "truncated"
+	AttachmentIntegrityMissing    = "missing"
+)
+
+// IsAttachmentIntegrityFailure сообщает, что статус означает повреждение объекта.
+func IsAttachmentIntegrityFailure(status string) bool {
+	switch status {
+	case AttachmentIntegrityCorrupted, AttachmentIntegrityTruncated, AttachmentIntegrityMissing:
+		return true
+	default:
+		return false
+	}
+}
+
+// AttachmentIntegrityResult — результат проверки одного объекта. SHA256
+// заполняется, если проверка вычислила сумму для вложения без сохраненного хэша.
+type AttachmentIntegrityResult struct {
+	AttachmentID uuid.UUID
+	Status       string
+	SHA256       string
+}
+
+// AttachmentIntegritySummary — сводка последних проверок целостности для системной статистики.
+type AttachmentIntegritySummary struct {
+	Unverified    int        `json:"unverified"`
+	OK            int        `json:"ok"`
+	Corrupted     int        `json:"corrupted"`
+	Truncated     int        `json:"truncated"`
+	Missing       int        `json:"missing"`
+	LastCheckedAt *time.Time `json:"lastCheckedAt,omitempty"`
+}
+
+// Статусы извлечения текста из вложения.
+const (
+	AttachmentTextPending     = "pending"
+	AttachmentTextExtracted   = "extracted"
+	AttachmentTextUnsupported = "unsupported"
+	AttachmentTextFailed      = "failed"
+)
+
+// AttachmentText — текст, извлеченный из вложения для поиска и предпросмотра.
+type AttachmentText struct {
+	AttachmentID uuid.UUID  `json:"-"`
+	DocumentID   uuid.UUID  `json:"-"`
+	Status       string     `json:"status"`
+	Content      string     `json:"content"`
+	Truncated    bool       `json:"truncated"`
+	Error        *string    `json:"error,omitempty"`
+	ExtractedAt  *time.Time `json:"extractedAt,omitempty"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы срока ответа на обращение гражданина.
+const (
+	CitizenAppealDeadlineOpen    = "open"
+	CitizenAppealDeadlineDueSoon = "due_soon"
+	CitizenAppealDeadlineOverdue = "overdue"
+	CitizenAppealDeadlineClosed  = "closed"
+)
+
+// IsValidCitizenAppealDeadlineStatus проверяет значение фильтра по сроку ответа.
+func IsValidCitizenAppealDeadlineStatus(status string) bool {
+	switch status {
+	case CitizenAppealDeadlineOpen, CitizenAppealDeadlineDueSoon, CitizenAppealDeadlineOverdue, CitizenAppealDeadlineClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsClosed сообщает, закрыто ли обращение исходящим ответом. Обращение закрыто,
+// пока у него есть связь «ответ» с исходящим письмом, записанная в
+// ClosedByDocumentID; тот же признак используют фильтры и счетчики репозитория.
+func (doc *CitizenAppealDocument) IsClosed() bool {
+	return doc.ClosedByDocumentID != nil
+}
+
+// CitizenAppealDeadlineStatusAt вычисляет статус срока ответа на указанную дату.
+func CitizenAppealDeadlineStatusAt(doc *CitizenAppealDocument, today time.Time, dueSoonDays int) string {
+	if doc == nil {
+		return ""
+	}
+	if doc.IsClosed() {
+		return CitizenAppealDeadlineClosed
+	}
+	if doc.ResponseDueDate == nil {
+		return CitizenAppealDeadlineOpen
+	}
+	today = truncateToDay(today)
+	dueDate := time.Date(doc.ResponseDueDate.Year(), doc.ResponseDueDate.Month(), doc.ResponseDueDate.Day(), 0, 0, 0, 0, today.Location())
+	switch {
+	case dueDate.Before(today):
+		return CitizenAppealDeadlineOverdue
+	case !dueDate.After(today.AddDate(0, 0, dueSoonDays)):
+		return CitizenAppealDeadlineDueSoon
+	default:
+		return CitizenAppealDeadlineOpen
+	}
+}
+
+// CitizenAppealDeadlineExtension описывает продление срока ответа на обращение.
+type CitizenAppealDeadlineExtension struct {
+	ID              uuid.UUID `json:"-"`
+	DocumentID      uuid.UUID `json:"-"`
+	PreviousDueDate time.Time `json:"previousDueDate"`
+	NewDueDate      time.Time `json:"newDueDate"`
+	Reason          string    `json:"reason"`
+	ApprovedBy      uuid.UUID `json:"-"`
+	ApprovedByName  string    `json:"approvedByName,omitempty"`
+	CreatedBy       uuid.UUID `json:"-"`
+	CreatedByName   string    `json:"createdByName,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// ExtendCitizenAppealDeadlineRequest — запрос на продление срока ответа.
+type ExtendCitizenAppealDeadlineRequest struct {
+	DocumentID uuid.UUID
+	NewDueDate time.Time
+	Reason     string
+	ApprovedBy uuid.UUID
+	CreatedBy  uuid.UUID
+}
+
+// CitizenAppealDeadlineCounters содержит счетчики обращений по срокам ответа.
+type CitizenAppealDeadlineCounters struct {
+	Open    int `json:"open"`
+	DueSoon int `json:"dueSoon"`
+	Overdue int `json:"overdue"`
+}
@@ -0,0 +1,310 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomFieldType описывает тип поля пользовательского вида документа.
+type CustomFieldType string
+
+const (
+	CustomFieldText         CustomFieldType = "text"
+	CustomFieldDate         CustomFieldType = "date"
+	CustomFieldNumber       CustomFieldType = "number"
+	CustomFieldEnum         CustomFieldType = "enum"
+	CustomFieldOrganization CustomFieldType = "organization"
+	CustomFieldUser         CustomFieldType = "user"
+)
+
+const customFieldDateLayout = "2006-01-02"
+
+var (
+	customDocumentKindCodePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]{2,39}$`)
+	customDocumentFieldCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+)
+
+// IsValid проверяет, что тип поля поддерживается.
+func (t CustomFieldType) IsValid() bool {
+	switch t {
+	case CustomFieldText, CustomFieldDate, CustomFieldNumber, CustomFieldEnum, CustomFieldOrganization, CustomFieldUser:
+		return true
+	default:
+		return false
+	}
+}
+
+// CustomDocumentKindField — поле карточки пользовательского вида документа.
+type CustomDocumentKindField struct {
+	ID         uuid.UUID       `json:"id"`
+	Code       string          `json:"code"`
+	Label      string          `json:"label"`
+	FieldType  CustomFieldType `json:"fieldType"`
+	IsRequired bool            `json:"isRequired"`
+	Options    []string        `json:"options"`
+	Position   int             `json:"position"`
+}
+
+// CustomDocumentKind — вид документа, схема карточки которого задана администратором.
+type CustomDocumentKind struct {
+	Code           DocumentKind              `json:"code"`
+	Name           string                    `json:"name"`
+	IsActive       bool                      `json:"isActive"`
+	Fields         []CustomDocumentKindField `json:"fields"`
+	DocumentsCount int                       `json:"documentsCount"`
+	CreatedAt      time.Time                 `json:"createdAt"`
+	UpdatedAt      time.Time                 `json:"updatedAt"`
+}
+
+// Spec возвращает метаданные вида для общего каталога видов документов.
+func (k CustomDocumentKind) Spec() DocumentKindSpec {
+	return NewCustomDocumentKindSpec(k.Code, k.Name, k.IsActive)
+}
+
+// Field возвращает поле схемы по коду.
+func (k CustomDocumentKind) Field(code string) (CustomDocumentKindField, bool) {
+	for _, field := range k.Fields {
+		if field.Code == code {
+			return field, true
+		}
+	}
+	return CustomDocumentKindField{}, false
+}
+
+// Normalize приводит схему вида к каноническому виду и проверяет ее.
+func (k *CustomDocumentKind) Normalize() error {
+	k.Code = DocumentKind(strings.ToLower(strings.TrimSpace(string(k.Code))))
+	if !customDocumentKindCodePattern.MatchString(string(k.Code)) {
+		return NewBadRequest("код вида документа должен начинаться с латинской буквы и содержать от 3 до 40 латинских букв, цифр или знаков подчеркивания")
+	}
+	if IsSystemDocumentKind(k.Code) || NormalizeDocumentKind(string(k.Code)) != k.Code {
+		return NewConflict("код вида документа зарезервирован системой")
+	}
+	k.Name = strings.TrimSpace(k.Name)
+	if k.Name == "" {
+		return NewBadRequest("укажите название вида документа")
+	}
+	if len([]rune(k.Name)) > 100 {
+		return NewBadRequest("название вида документа не должно превышать 100 символов")
+	}
+
+	seen := make(map[string]struct{}, len(k.Fields))
+	for i := range k.Fields {
+		field := &k.Fields[i]
+		field.Code = strings.ToLower(strings.TrimSpace(field.Code))
+		if !customDocumentFieldCodePattern.MatchString(field.Code) {
+			return NewBadRequest(fmt.Sprintf("неверный код поля «%s»", field.Code))
+		}
+		if _, ok := seen[field.Code]; ok {
+			return NewBadRequest(fmt.Sprintf("поле с кодом «%s» указано несколько раз", field.Code))
+		}
+		seen[field.Code] = struct{}{}
+
+		field.Label = strings.TrimSpace(field.Label)
+		if field.Label == "" {
+			return NewBadRequest(fmt.Sprintf("укажите название поля «%s»", field.Code))
+		}
+		if !field.FieldType.IsValid() {
+			return NewBadRequest(fmt.Sprintf("неверный тип поля «%s»", field.Label))
+		}
+
+		options := make([]string, 0, len(field.Options))
+		if field.FieldType == CustomFieldEnum {
+			seenOptions := make(map[string]struct{}, len(field.Options))
+			for _, option := range field.Options {
+				option = strings.TrimSpace(option)
+				if option == "" {
+					continue
+				}
+				if _, ok := seenOptions[option]; ok {
+					continue
+				}
+				seenOptions[option] = struct{}{}
+				options = append(options, option)
+			}
+			if len(options) == 0 {
+				return NewBadRequest(fmt.Sprintf("укажите варианты значений поля «%s»", field.Label))
+			}
+		}
+		field.Options = options
+		field.Position = i + 1
+	}
+
+	return nil
+}
+
+// NormalizeFieldValues проверяет значения полей документа по схеме вида
+// и возвращает их в каноническом строковом виде. Пустые значения не сохраняются.
+func (k CustomDocumentKind) NormalizeFieldValues(values map[string]string) (map[string]string, error) {
+	for code := range values {
+		if _, ok := k.Field(code); !ok {
+			return nil, NewBadRequest(fmt.Sprintf("неизвестное поле «%s»", code))
+		}
+	}
+
+	result := make(map[string]string, len(k.Fields))
+	for _, field := range k.Fields {
+		raw := strings.TrimSpace(values[field.Code])
+		if raw == "" {
+			if field.IsRequired {
+				return nil, NewBadRequest(fmt.Sprintf("заполните поле «%s»", field.Label))
+			}
+			continue
+		}
+
+		value, err := normalizeCustomFieldValue(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		result[field.Code] = value
+	}
+
+	return result, nil
+}
+
+// ReferencedIDs возвращает ID организаций и пользователей, на которые ссылаются значения полей.
+func (k CustomDocumentKind) ReferencedIDs(values map[string]string) (organizationIDs, userIDs []uuid.UUID) {
+	for _, field := range k.Fields {
+		value, ok := values[field.Code]
+		if !ok {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			continue
+		}
+		switch field.FieldType {
+		case CustomFieldOrganization:
+			organizationIDs = append(organizationIDs, id)
+		case CustomFieldUser:
+			userIDs = append(userIDs, id)
+		}
+	}
+	return organizationIDs, userIDs
+}
+
+// NormalizeCustomFieldFilterValue приводит значение фильтра к формату хранения поля.
+func NormalizeCustomFieldFilterValue(field CustomDocumentKindField, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if field.FieldType == CustomFieldText {
+		return value, nil
+	}
+	return normalizeCustomFieldValue(field, value)
+}
+
+// customFieldNumberReplacer убирает разделители разрядов и приводит десятичную запятую к точке.
+var customFieldNumberReplacer = strings.NewReplacer(" ", "", "\u00a0", "", ",", ".")
+
+func normalizeCustomFieldValue(field CustomDocumentKindField, value string) (string, error) {
+	switch field.FieldType {
+	case CustomFieldText:
+		return value, nil
+	case CustomFieldDate:
+		parsed, err := time.Parse(customFieldDateLayout, value)
+		if err != nil {
+			return "", NewBadRequest(fmt.Sprintf("неверный формат даты в поле «%s»", field.Label))
+		}
+		return parsed.Format(customFieldDateLayout), nil
+	case CustomFieldNumber:
+		parsed, err := strconv.ParseFloat(customFieldNumberReplacer.Replace(value), 64)
+		if err != nil {
+			return "", NewBadRequest(fmt.Sprintf("неверное число в поле «%s»", field.Label))
+		}
+		return strconv.FormatFloat(parsed, 'f', -1, 64), nil
+	case CustomFieldEnum:
+		for _, option := range field.Options {
+			if option == value {
+				return value, nil
+			}
+		}
+		return "", NewBadRequest(fmt.Sprintf("недопустимое значение поля «%s»", field.Label))
+	case CustomFieldOrganization, CustomFieldUser:
+		id, err := uuid.Parse(value)
+		if err != nil || id == uuid.Nil {
+			return "", NewBadRequest(fmt.Sprintf("неверная ссылка в поле «%s»", field.Label))
+		}
+		return id.String(), nil
+	default:
+		return "", NewBadRequest(fmt.Sprintf("неверный тип поля «%s»", field.Label))
+	}
+}
+
+// CustomDocumentFieldValue — значение поля документа пользовательского вида.
+// Для ссылок на организации и пользователей DisplayValue содержит их имя.
+type CustomDocumentFieldValue struct {
+	Code         string          `json:"code"`
+	Label        string          `json:"label"`
+	FieldType    CustomFieldType `json:"fieldType"`
+	Value        string          `json:"value"`
+	DisplayValue string          `json:"displayValue"`
+}
+
+// CustomDocument — документ пользовательского вида.
+type CustomDocument struct {
+	ID                 uuid.UUID                  `json:"id"`
+	Kind               DocumentKind               `json:"kind"`
+	NomenclatureID     uuid.UUID                  `json:"nomenclatureId"`
+	NomenclatureName   string                     `json:"nomenclatureName"`
+	RegistrationNumber string                     `json:"registrationNumber"`
+	RegistrationDate   time.Time                  `json:"registrationDate"`
+	DocumentTypeID     uuid.UUID                  `json:"documentTypeId"`
+	DocumentTypeName   string                     `json:"documentTypeName"`
+	Content            string                     `json:"content"`
+	PagesCount         int                        `json:"pagesCount"`
+	Fields             []CustomDocumentFieldValue `json:"fields"`
+	CreatedBy          uuid.UUID                  `json:"createdBy"`
+	CreatedByName      string                     `json:"createdByName"`
+	CreatedAt          time.Time                  `json:"createdAt"`
+	UpdatedAt          time.Time                  `json:"updatedAt"`
+}
+
+// CreateCustomDocumentRequest — запрос на создание документа пользовательского вида (уровень репозитория).
+// Пустой DocumentTypeID означает тип по умолчанию для вида.
+type CreateCustomDocumentRequest struct {
+	Kind                DocumentKind
+	KindName            string
+	NomenclatureID      uuid.UUID
+	IdempotencyKey      uuid.UUID
+	AdminNumberOverride *AdminNumberOverride
+	CreatedBy           uuid.UUID
+	RegistrationNumber  string
+	RegistrationDate    time.Time
+	DocumentTypeID      string
+	Content             string
+	PagesCount          int
+	FieldValues         map[string]string
+	OrganizationIDs     []uuid.UUID
+	UserIDs             []uuid.UUID
+}
+
+// UpdateCustomDocumentRequest — запрос на обновление документа пользовательского вида (уровень репозитория).
+type UpdateCustomDocumentRequest struct {
+	ID               uuid.UUID
+	Kind             DocumentKind
+	RegistrationDate time.Time
+	DocumentTypeID   string
+	Content          string
+	PagesCount       int
+	FieldValues      map[string]string
+	OrganizationIDs  []uuid.UUID
+	UserIDs          []uuid.UUID
+}
+
+// CustomFieldFilter — условие фильтра списка по полю пользовательского вида.
+// Value ищет подстроку для текстовых полей и точное значение для остальных;
+// From и To задают диапазон для дат и чисел.
+type CustomFieldFilter struct {
+	Code  string          `json:"code"`
+	Value string          `json:"value,omitempty"`
+	From  string          `json:"from,omitempty"`
+	To    string          `json:"to,omitempty"`
+	Type  CustomFieldType `json:"-"`
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func customKindErrorCode(t *testing.T, err error) int {
+	t.Helper()
+	var appErr *AppError
+	require.True(t, errors.As(err, &appErr), "expected AppError, got %v", err)
+	return appErr.Code
+}
+
+func TestCustomDocumentKindNormalize(t *testing.T) {
+	kind := CustomDocumentKind{
+		Code: " Contract ",
+		Name: "  Договор ",
+		Fields: []CustomDocumentKindField{
+			{Code: " Counterparty ", Label: " Контрагент ", FieldType: CustomFieldOrganization, IsRequired: true, Options: []string{"ignored"}},
+			{Code: "status", Label: "Статус", FieldType: CustomFieldEnum, Options: []string{" Проект ", "", "Подписан", "Проект"}},
+		},
+	}
+
+	require.NoError(t, kind.Normalize())
+	assert.Equal(t, DocumentKind("contract"), kind.Code)
+	assert.Equal(t, "Договор", kind.Name)
+	assert.Equal(t, "counterparty", kind.Fields[0].Code)
+	assert.Equal(t, "Контрагент", kind.Fields[0].Label)
+	assert.Empty(t, kind.Fields[0].Options)
+	assert.Equal(t, 1, kind.Fields[0].Position)
+	assert.Equal(t, []string{"Проект", "Подписан"}, kind.Fields[1].Options)
+	assert.Equal(t, 2, kind.Fields[1].Position)
+}
+
+func TestCustomDocumentKindNormalizeRejectsInvalidSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		kind CustomDocumentKind
+		code int
+	}{
+		{name: "short code", kind: CustomDocumentKind{Code: "ab", Name: "Вид"}, code: 400},
+		{name: "system code", kind: CustomDocumentKind{Code: DocumentKindIncomingLetter, Name: "Вид"}, code: 409},
+		{name: "system alias", kind: CustomDocumentKind{Code: "incoming", Name: "Вид"}, code: 409},
+		{name: "empty name", kind: CustomDocumentKind{Code: "memo", Name: " "}, code: 400},
+		{name: "bad field code", kind: CustomDocumentKind{Code: "memo", Name: "Вид", Fields: []CustomDocumentKindField{{Code: "1x", Label: "Поле", FieldType: CustomFieldText}}}, code: 400},
+		{name: "duplicate field", kind: CustomDocumentKind{Code: "memo", Name: "Вид", Fields: []CustomDocumentKindField{
+			{Code: "a", Label: "A", FieldType: CustomFieldText},
+			{Code: "A", Label: "B", FieldType: CustomFieldText},
+		}}, code: 400},
+		{name: "empty label", kind: CustomDocumentKind{Code: "memo", Name: "Вид", Fields: []CustomDocumentKindField{{Code: "a", FieldType: CustomFieldText}}}, code: 400},
+		{name: "bad type", kind: CustomDocumentKind{Code: "memo", Name: "Вид", Fields: []CustomDocumentKindField{{Code: "a", Label: "A", FieldType: "file"}}}, code: 400},
+		{name: "enum without options", kind: CustomDocumentKind{Code: "memo", Name: "Вид", Fields: []CustomDocumentKindField{{Code: "a", Label: "A", FieldType: CustomFieldEnum, Options: []string{" "}}}}, code: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.kind.Normalize()
+			require.Error(t, err)
+			assert.Equal(t, tt.code, customKindErrorCode(t, err))
+		})
+	}
+}
+
+func TestCustomDocumentKindNormalizeFieldValues(t *testing.T) {
+	orgID := uuid.New()
+	userID := uuid.New()
+	kind := CustomDocumentKind{
+		Code: "contract",
+		Name: "Договор",
+		Fields: []CustomDocumentKindField{
+			{Code: "signed_at", Label: "Дата подписания", FieldType: CustomFieldDate, IsRequired: true},
+			{Code: "amount", Label: "Сумма", FieldType: CustomFieldNumber},
+			{Code: "status", Label: "Статус", FieldType: CustomFieldEnum, Options: []string{"Проект", "Подписан"}},
+			{Code: "counterparty", Label: "Контрагент", FieldType: CustomFieldOrganization},
+			{Code: "curator", Label: "Куратор", FieldType: CustomFieldUser},
+			{Code: "note", Label: "Примечание", FieldType: CustomFieldText},
+		},
+	}
+
+	values, err := kind.NormalizeFieldValues(map[string]string{
+		"signed_at":    "2026-03-01",
+		"amount":       " 1500,50 ",
+		"status":       "Подписан",
+		"counterparty": orgID.String(),
+		"curator":      userID.String(),
+		"note":         " ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"signed_at":    "2026-03-01",
+		"amount":       "1500.5",
+		"status":       "Подписан",
+		"counterparty": orgID.String(),
+		"curator":      userID.String(),
+	}, values)
+
+	organizationIDs, userIDs := kind.ReferencedIDs(values)
+	assert.Equal(t, []uuid.UUID{orgID}, organizationIDs)
+	assert.Equal(t, []uuid.UUID{userID}, userIDs)
+
+	invalid := []map[string]string{
+		{"amount": "1"},
+		{"signed_at": "01.03.2026"},
+		{"signed_at": "2026-03-01", "amount": "много"},
+		{"signed_at": "2026-03-01", "status": "Расторгнут"},
+		{"signed_at": "2026-03-01", "counterparty": "org"},
+		{"signed_at": "2026-03-01", "unknown": "x"},
+	}
+	for _, item := range invalid {
+		_, err := kind.NormalizeFieldValues(item)
+		require.Error(t, err, "%v", item)
+		assert.Equal(t, 400, customKindErrorCode(t, err))
+	}
+}
+
+func TestCustomDocumentKindSpecsCatalog(t *testing.T) {
+	t.Cleanup(func() { SetCustomDocumentKindSpecs(nil) })
+
+	SetCustomDocumentKindSpecs([]DocumentKindSpec{
+		NewCustomDocumentKindSpec("contract", "Договор", true),
+		NewCustomDocumentKindSpec("memo", "Служебная записка", false),
+		NewCustomDocumentKindSpec(DocumentKindIncomingLetter, "Подмена", true),
+	})
+
+	specs := AllDocumentKindSpecs()
+	require.Len(t, specs, 6)
+
+	spec, ok := GetDocumentKindSpec("contract")
+	require.True(t, ok)
+	assert.True(t, spec.IsCustom)
+	assert.Equal(t, CustomDocumentKindFormCode, spec.RegistrationFormCode)
+	assert.Equal(t, "Договор", DocumentKind("contract").Label())
+	assert.True(t, DocumentKind("contract").SupportsAction(string(DocumentActionCreate)))
+	assert.False(t, DocumentKind("memo").SupportsAction(string(DocumentActionCreate)))
+	assert.True(t, DocumentKind("memo").SupportsAction(string(DocumentActionRead)))
+
+	incoming, ok := GetDocumentKindSpec(DocumentKindIncomingLetter)
+	require.True(t, ok)
+	assert.False(t, incoming.IsCustom)
+	assert.Equal(t, "Входящее письмо", incoming.Name)
+	assert.True(t, IsSystemDocumentKind(DocumentKindIncomingLetter))
+	assert.False(t, IsSystemDocumentKind("contract"))
+
+	SetCustomDocumentKindSpecs(nil)
+	_, ok = GetDocumentKindSpec("contract")
+	assert.False(t, ok)
+	assert.Len(t, AllDocumentKindSpecs(), 4)
+}
@@ -7,11 +7,34 @@ import (
 )
 
 // Department представляет собой отдел или подразделение организации.
+// Подразделения образуют дерево: ParentID пуст у подразделений верхнего уровня.
 type Department struct {
 	ID              uuid.UUID      `json:"-"`
 	Name            string         `json:"name"`
+	ParentID        *uuid.UUID     `json:"parentId"`
+	HeadUserID      *uuid.UUID     `json:"headUserId"`
 	NomenclatureIDs []string       `json:"nomenclatureIds"`
 	Nomenclature    []Nomenclature `json:"nomenclature"`
 	CreatedAt       time.Time      `json:"createdAt"`
 	UpdatedAt       time.Time      `json:"updatedAt"`
 }
+
+// IsDepartmentInSubtree сообщает, входит ли подразделение id в поддерево rootID
+// (включая сам rootID). Обход вверх по ParentID защищен от циклов в данных.
+func IsDepartmentInSubtree(departments []Department, rootID, id uuid.UUID) bool {
+	parents := make(map[uuid.UUID]*uuid.UUID, len(departments))
+	for _, department := range departments {
+		parents[department.ID] = department.ParentID
+	}
+	visited := make(map[uuid.UUID]struct{})
+	for current := &id; current != nil; current = parents[*current] {
+		if *current == rootID {
+			return true
+		}
+		if _, seen := visited[*current]; seen {
+			return false
+		}
+		visited[*current] = struct{}{}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDepartmentInSubtree(t *testing.T) {
+	directorate := uuid.New()
+	department := uuid.New()
+	sector := uuid.New()
+	other := uuid.New()
+	departments := []Department{
+		{ID: directorate},
+		{ID: department, ParentID: &directorate},
+		{ID: sector, ParentID: &department},
+		{ID: other},
+	}
+
+	assert.True(t, IsDepartmentInSubtree(departments, directorate, directorate))
+	assert.True(t, IsDepartmentInSubtree(departments, directorate, sector))
+	assert.True(t, IsDepartmentInSubtree(departments, department, sector))
+	assert.False(t, IsDepartmentInSubtree(departments, sector, directorate))
+	assert.False(t, IsDepartmentInSubtree(departments, directorate, other))
+	assert.False(t, IsDepartmentInSubtree(departments, directorate, uuid.New()))
+
+	// Цикл в данных не приводит к зацикливанию обхода.
+	a, b := uuid.New(), uuid.New()
+	cyclic := []Department{{ID: a, ParentID: &b}, {ID: b, ParentID: &a}}
+	assert.False(t, IsDepartmentInSubtree(cyclic, other, a))
+}
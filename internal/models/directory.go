@@ -0,0 +1,42 @@
+package models
+
+import "github.com/google/uuid"
+
+// DirectoryUser — учетная запись пользователя во внешнем каталоге (LDAP / AD).
+type DirectoryUser struct {
+	// ExternalID — неизменяемый идентификатор записи (objectGUID, entryUUID),
+	// который сохраняется при переименовании и переносе учетной записи.
+	ExternalID string
+	DN         string
+	Login      string
+	FullName   string
+	Groups     []string
+}
+
+// DirectoryGroupMapping сопоставляет группе каталога права и подразделение пользователя.
+type DirectoryGroupMapping struct {
+	Group               string
+	SystemPermissions   []string
+	DepartmentID        *uuid.UUID
+	DocumentParticipant bool
+}
+
+// DirectoryPolicy задает правила входа и выдачи прав пользователям каталога.
+type DirectoryPolicy struct {
+	// RequiredGroup — группа, без членства в которой вход запрещен; пусто — любой пользователь каталога.
+	RequiredGroup string
+	Groups        []DirectoryGroupMapping
+}
+
+// DirectoryUserSync — состояние пользователя каталога, записываемое в users
+// и user_system_permissions при входе и синхронизации. DepartmentID == nil
+// оставляет подразделение, назначенное администратором.
+type DirectoryUserSync struct {
+	AuthSource            string
+	ExternalID            string
+	Login                 string
+	FullName              string
+	DepartmentID          *uuid.UUID
+	IsDocumentParticipant bool
+	SystemPermissions     []string
+}
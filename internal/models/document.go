@@ -16,6 +16,7 @@ const (
 	DocumentKindAdministrativeOrder DocumentKind = "administrative_order"
 )
 
+// Типы документов, создаваемые миграцией справочника. Остальные типы задаются в справочнике document_types.
 const (
 	DocumentTypeLetter              = "Письмо"
 	DocumentTypeContract            = "Договор"
@@ -28,41 +29,10 @@ const (
 	DocumentTypeAdministrativeOrder = "Приказ"
 )
 
-var documentTypeSet = map[string]struct{}{
-	DocumentTypeLetter:              {},
-	DocumentTypeContract:            {},
-	DocumentTypeAct:                 {},
-	DocumentTypeInvoice:             {},
-	DocumentTypeRequest:             {},
-	DocumentTypeReply:               {},
-	DocumentTypeNotification:        {},
-	DocumentTypeCitizenAppeal:       {},
-	DocumentTypeAdministrativeOrder: {},
-}
-
-func AllowedDocumentTypes() []string {
-	return []string{
-		DocumentTypeLetter,
-		DocumentTypeContract,
-		DocumentTypeAct,
-		DocumentTypeInvoice,
-		DocumentTypeRequest,
-		DocumentTypeReply,
-		DocumentTypeNotification,
-		DocumentTypeCitizenAppeal,
-		DocumentTypeAdministrativeOrder,
-	}
-}
-
 func NormalizeDocumentType(value string) string {
 	return strings.TrimSpace(value)
 }
 
-func IsAllowedDocumentType(value string) bool {
-	_, ok := documentTypeSet[NormalizeDocumentType(value)]
-	return ok
-}
-
 func (k DocumentKind) IsIncoming() bool {
 	return k == DocumentKindIncomingLetter
 }
@@ -177,6 +147,14 @@ type CitizenAppealDocument struct {
 	HasEnvelope          bool   `json:"hasEnvelope"`
 	ReceivedFromPOS      bool   `json:"receivedFromPos"`
 
+	// Срок ответа и закрытие исходящим ответом
+	ResponseDueDate        *time.Time `json:"responseDueDate,omitempty"`
+	ClosedAt               *time.Time `json:"closedAt,omitempty"`
+	ClosedByDocumentID     *uuid.UUID `json:"-"`
+	ClosedByDocumentNumber string     `json:"closedByDocumentNumber,omitempty"`
+	DeadlineExtensionCount int        `json:"deadlineExtensionCount,omitempty"`
+	DeadlineStatus         string     `json:"deadlineStatus,omitempty"`
+
 	Correspondents []DocumentCorrespondentRegistration `json:"correspondents,omitempty"`
 	Resolutions    []DocumentResolution                `json:"resolutions,omitempty"`
 
@@ -286,6 +264,9 @@ type DocumentAccessScope struct {
 	AllowedNomenclatureIDs []string
 	AccessibleByUserID     string
 	AccessibleByUserIDs    []string
+	// AcknowledgmentUserIDs, if set, replaces AccessibleByUserIDs for access
+	// through acknowledgments: a substitution may cover only one of the areas.
+	AcknowledgmentUserIDs []string
 }
 
 // DocumentFilter — фильтры для журналов
@@ -311,6 +292,8 @@ type DocumentFilter struct {
 	AppealType                string               `json:"appealType,omitempty"`
 	AppealDateFrom            string               `json:"appealDateFrom,omitempty"`
 	AppealDateTo              string               `json:"appealDateTo,omitempty"`
+	AppealDeadlineStatus      string               `json:"appealDeadlineStatus,omitempty"`
+	AppealDueSoonDays         int                  `json:"-"`
 	OutgoingDateFrom          string               `json:"outgoingDateFrom,omitempty"`
 	OutgoingDateTo            string               `json:"outgoingDateTo,omitempty"`
 	Resolution                string               `json:"resolution,omitempty"`
@@ -319,6 +302,7 @@ type DocumentFilter struct {
 	ExecutionController       string               `json:"executionController,omitempty"`
 	OnlyPendingAcknowledgment bool                 `json:"onlyPendingAcknowledgment,omitempty"`
 	OrderActiveStatus         string               `json:"orderActiveStatus,omitempty"`
+	CustomFields              []CustomFieldFilter  `json:"customFields,omitempty"`
 	Page                      int                  `json:"page"`
 	PageSize                  int                  `json:"pageSize"`
 	Cursor                    string               `json:"cursor,omitempty"`
@@ -461,6 +445,7 @@ type CreateCitizenAppealDocRequest struct {
 	AttachmentPagesCount int
 	HasEnvelope          bool
 	ReceivedFromPOS      bool
+	ResponseDueDate      time.Time
 	Correspondents       []DocumentCorrespondentRegistration
 	Resolutions          []DocumentResolution
 }
@@ -480,6 +465,7 @@ type UpdateCitizenAppealDocRequest struct {
 	AttachmentPagesCount int
 	HasEnvelope          bool
 	ReceivedFromPOS      bool
+	ResponseDueDate      time.Time
 	Correspondents       []DocumentCorrespondentRegistration
 	Resolutions          []DocumentResolution
 }
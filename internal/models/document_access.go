@@ -8,6 +8,17 @@ const (
 	SystemPermissionStatsSystem      = "stats_system"
 )
 
+// IsSystemPermission сообщает, что code — известное системное право.
+func IsSystemPermission(code string) bool {
+	switch code {
+	case SystemPermissionAdmin, SystemPermissionReferences, SystemPermissionStatsDocuments,
+		SystemPermissionStatsAssignments, SystemPermissionStatsSystem:
+		return true
+	default:
+		return false
+	}
+}
+
 // UserDocumentPermissionRule описывает прямое назначение действия пользователю.
 type UserDocumentPermissionRule struct {
 	KindCode  string `json:"kindCode"`
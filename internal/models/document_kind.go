@@ -1,5 +1,7 @@
 package models
 
+import "sync"
+
 // DocumentKindAction описывает системное действие над документом.
 type DocumentKindAction string
 
@@ -21,8 +23,15 @@ type DocumentKindSpec struct {
 	RegistrationFormCode string               `json:"registrationFormCode"`
 	RegistryGroup        string               `json:"registryGroup"`
 	SupportedActions     []DocumentKindAction `json:"supportedActions"`
+	IsCustom             bool                 `json:"isCustom"`
 }
 
+// Метаданные пользовательских видов документов, заданных администратором.
+const (
+	CustomDocumentKindFormCode      = "custom_document_form"
+	CustomDocumentKindRegistryGroup = "custom"
+)
+
 var documentKindSpecs = []DocumentKindSpec{
 	{
 		Code:                 DocumentKindIncomingLetter,
@@ -90,14 +99,78 @@ var documentKindSpecs = []DocumentKindSpec{
 	},
 }
 
-// AllDocumentKindSpecs возвращает все системные виды документов.
+var (
+	customDocumentKindSpecsMu sync.RWMutex
+	customDocumentKindSpecs   []DocumentKindSpec
+)
+
+// NewCustomDocumentKindSpec строит метаданные пользовательского вида документа.
+// Неактивный вид остается доступным для работы с уже зарегистрированными документами,
+// но не поддерживает регистрацию новых.
+func NewCustomDocumentKindSpec(code DocumentKind, name string, isActive bool) DocumentKindSpec {
+	actions := make([]DocumentKindAction, 0, 8)
+	if isActive {
+		actions = append(actions, DocumentActionCreate)
+	}
+	actions = append(actions,
+		DocumentActionRead,
+		DocumentActionUpdate,
+		DocumentActionAssign,
+		DocumentActionAcknowledge,
+		DocumentActionUpload,
+		DocumentActionLink,
+		DocumentActionViewJournal,
+	)
+
+	return DocumentKindSpec{
+		Code:                 code,
+		Name:                 name,
+		RegistrationFormCode: CustomDocumentKindFormCode,
+		RegistryGroup:        CustomDocumentKindRegistryGroup,
+		SupportedActions:     actions,
+		IsCustom:             true,
+	}
+}
+
+// SetCustomDocumentKindSpecs заменяет каталог пользовательских видов документов.
+// Виды с кодами системных видов игнорируются.
+func SetCustomDocumentKindSpecs(specs []DocumentKindSpec) {
+	custom := make([]DocumentKindSpec, 0, len(specs))
+	for _, spec := range specs {
+		if IsSystemDocumentKind(spec.Code) {
+			continue
+		}
+		spec.IsCustom = true
+		custom = append(custom, spec)
+	}
+
+	customDocumentKindSpecsMu.Lock()
+	customDocumentKindSpecs = custom
+	customDocumentKindSpecsMu.Unlock()
+}
+
+// IsSystemDocumentKind проверяет, что вид документа встроен в систему.
+func IsSystemDocumentKind(kind DocumentKind) bool {
+	for _, spec := range documentKindSpecs {
+		if spec.Code == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// AllDocumentKindSpecs возвращает системные и пользовательские виды документов.
 func AllDocumentKindSpecs() []DocumentKindSpec {
-	specs := make([]DocumentKindSpec, len(documentKindSpecs))
-	copy(specs, documentKindSpecs)
+	customDocumentKindSpecsMu.RLock()
+	defer customDocumentKindSpecsMu.RUnlock()
+
+	specs := make([]DocumentKindSpec, 0, len(documentKindSpecs)+len(customDocumentKindSpecs))
+	specs = append(specs, documentKindSpecs...)
+	specs = append(specs, customDocumentKindSpecs...)
 	return specs
 }
 
-// GetDocumentKindSpec возвращает метаданные системного вида документа.
+// GetDocumentKindSpec возвращает метаданные системного или пользовательского вида документа.
 func GetDocumentKindSpec(kind DocumentKind) (DocumentKindSpec, bool) {
 	for _, spec := range documentKindSpecs {
 		if spec.Code == kind {
@@ -105,6 +178,14 @@ func GetDocumentKindSpec(kind DocumentKind) (DocumentKindSpec, bool) {
 		}
 	}
 
+	customDocumentKindSpecsMu.RLock()
+	defer customDocumentKindSpecsMu.RUnlock()
+	for _, spec := range customDocumentKindSpecs {
+		if spec.Code == kind {
+			return spec, true
+		}
+	}
+
 	return DocumentKindSpec{}, false
 }
 
@@ -6,14 +6,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestDocumentTypes(t *testing.T) {
-	types := AllowedDocumentTypes()
-
-	assert.Contains(t, types, DocumentTypeLetter)
-	assert.Contains(t, types, DocumentTypeAdministrativeOrder)
+func TestNormalizeDocumentType(t *testing.T) {
 	assert.Equal(t, "Письмо", NormalizeDocumentType("  Письмо  "))
-	assert.True(t, IsAllowedDocumentType(" Письмо "))
-	assert.False(t, IsAllowedDocumentType("Неизвестный тип"))
+	assert.Empty(t, NormalizeDocumentType("   "))
 }
 
 func TestDocumentKindPredicates(t *testing.T) {
@@ -0,0 +1,58 @@
+package models
+
+import "github.com/google/uuid"
+
+// SettingEmailNotificationsEnabled — системная настройка, включающая почтовые
+// уведомления для всех рабочих мест. Ее включают, когда хотя бы на одном
+// рабочем месте настроен SMTP.
+const SettingEmailNotificationsEnabled = "email_notifications_enabled"
+
+// Режимы доставки событий пользователя по электронной почте.
+const (
+	// EmailNotificationsOff — письма не отправляются.
+	EmailNotificationsOff = "off"
+	// EmailNotificationsImmediate — письмо на каждое событие.
+	EmailNotificationsImmediate = "immediate"
+	// EmailNotificationsDigest — одна сводка за прошедший день.
+	EmailNotificationsDigest = "digest"
+)
+
+// IsEmailNotificationMode сообщает, известен ли режим доставки писем.
+func IsEmailNotificationMode(mode string) bool {
+	switch mode {
+	case EmailNotificationsOff, EmailNotificationsImmediate, EmailNotificationsDigest:
+		return true
+	}
+	return false
+}
+
+// EmailNotificationSettings — адрес и режим почтовых уведомлений пользователя.
+type EmailNotificationSettings struct {
+	Email string `json:"email"`
+	Mode  string `json:"mode"`
+	// ChannelEnabled сообщает, включены ли почтовые уведомления администратором.
+	ChannelEnabled bool `json:"channelEnabled"`
+}
+
+// UpdateEmailNotificationSettingsRequest описывает изменение почтовых
+// уведомлений пользователем.
+type UpdateEmailNotificationSettingsRequest struct {
+	Email string `json:"email"`
+	Mode  string `json:"mode"`
+}
+
+// EmailRecipient — получатель писем: активный пользователь с адресом.
+type EmailRecipient struct {
+	UserID   uuid.UUID
+	FullName string
+	Email    string
+	Mode     string
+}
+
+// EmailNotificationPayload — полезная нагрузка outbox-события отправки письма.
+// Заполняется либо Event (письмо о событии), либо DigestDate (сводка за день).
+type EmailNotificationPayload struct {
+	UserID     uuid.UUID               `json:"userId"`
+	Event      *CreateUserEventRequest `json:"event,omitempty"`
+	DigestDate string                  `json:"digestDate,omitempty"`
+}
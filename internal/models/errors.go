@@ -55,10 +55,17 @@ var (
 	ErrUnauthorized           = &AppError{Code: 401, Kind: "UNAUTHORIZED", Message: "требуется авторизация", Production: true}
 	ErrInvalidCredentials     = &AppError{Code: 401, Kind: "INVALID_CREDENTIALS", Message: "неверный логин или пароль", Production: true}
 	ErrUserNotActive          = &AppError{Code: 403, Kind: "USER_INACTIVE", Message: "пользователь деактивирован", Production: true}
-	ErrUserLocked             = &AppError{Code: 403, Kind: "USER_LOCKED", Message: "учетная запись заблокирована после 5 неверных попыток входа; обратитесь к администратору", Production: true}
+	ErrUserLocked             = &AppError{Code: 403, Kind: "USER_LOCKED", Message: "учетная запись заблокирована после неверных попыток входа; повторите попытку позже или обратитесь к администратору", Production: true}
 	ErrPasswordChangeRequired = &AppError{Code: 403, Kind: "PASSWORD_CHANGE_REQUIRED", Message: "необходимо сменить пароль", Production: true}
 	ErrForbidden              = &AppError{Code: 403, Kind: "FORBIDDEN", Message: "недостаточно прав", Production: true}
 	ErrWrongPassword          = &AppError{Code: 400, Kind: "VALIDATION_ERROR", Message: "неверный текущий пароль", Production: true}
+	ErrDirectoryUnavailable   = &AppError{Code: 503, Kind: "DIRECTORY_UNAVAILABLE", Message: "служба каталога недоступна; повторите вход позже", Production: true}
+	ErrSessionLocked          = &AppError{Code: 401, Kind: "SESSION_LOCKED", Message: "сеанс заблокирован; введите пароль для продолжения работы", Production: true}
+	ErrSessionExpired         = &AppError{Code: 401, Kind: "SESSION_EXPIRED", Message: "срок сеанса истек; войдите снова", Production: true}
+	ErrSessionTerminated      = &AppError{Code: 401, Kind: "SESSION_TERMINATED", Message: "сеанс завершен администратором; войдите снова", Production: true}
+	ErrTwoFactorRequired      = &AppError{Code: 401, Kind: "TWO_FACTOR_REQUIRED", Message: "введите код из приложения-аутентификатора или код восстановления", Production: true}
+	ErrTwoFactorEnrollment    = &AppError{Code: 403, Kind: "TWO_FACTOR_ENROLLMENT_REQUIRED", Message: "для входа необходимо подключить двухфакторную аутентификацию", Production: true}
+	ErrInvalidTwoFactorCode   = &AppError{Code: 401, Kind: "INVALID_TWO_FACTOR_CODE", Message: "неверный код подтверждения", Production: true}
 )
 
 // NewBadRequest — ошибка 400 с кастомным сообщением.
@@ -105,6 +112,12 @@ func NewIdempotencyConflict(msg string) *AppError {
 	return &AppError{Code: 409, Kind: "IDEMPOTENCY_CONFLICT", Message: msg, Production: true}
 }
 
+// NewTwoFactorUnavailable — ошибка 503: секрет второго фактора нельзя
+// зашифровать или расшифровать на этой рабочей станции.
+func NewTwoFactorUnavailable(err error) *AppError {
+	return &AppError{Code: 503, Kind: "TWO_FACTOR_UNAVAILABLE", Message: "двухфакторная аутентификация недоступна: на рабочей станции не задан или не совпадает ключ шифрования ENCRYPTION_KEY", Internal: err, Production: true}
+}
+
 func NewInternal(msg string, err error) *AppError {
 	return &AppError{Code: 500, Kind: "INTERNAL_ERROR", Message: msg, Internal: err}
 }
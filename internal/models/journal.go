@@ -12,15 +12,19 @@ type JournalEntry struct {
 	DocumentID uuid.UUID `json:"documentId"`
 	UserID     uuid.UUID `json:"-"`
 	UserName   string    `json:"userName,omitempty"`
-	Action     string    `json:"action"`
-	Details    string    `json:"details"`
-	CreatedAt  time.Time `json:"createdAt"`
+	// OnBehalfOfUserName заполнено, если действие выполнил замещающий за этого пользователя.
+	OnBehalfOfUserName string    `json:"onBehalfOfUserName,omitempty"`
+	Action             string    `json:"action"`
+	Details            string    `json:"details"`
+	CreatedAt          time.Time `json:"createdAt"`
 }
 
 // CreateJournalEntryRequest описывает внутренний запрос на создание записи в журнале.
 type CreateJournalEntryRequest struct {
 	DocumentID uuid.UUID
 	UserID     uuid.UUID
-	Action     string
-	Details    string
+	// OnBehalfOfUserID — замещаемый пользователь, за которого действовал UserID.
+	OnBehalfOfUserID *uuid.UUID
+	Action           string
+	Details          string
 }
@@ -7,10 +7,12 @@ import (
 )
 
 const (
-	OutboxEventUserEvent  = "user_event"
-	OutboxEventJournal    = "journal_entry"
-	OutboxEventAudit      = "admin_audit"
-	OutboxEventFileDelete = "attachment_delete"
+	OutboxEventUserEvent   = "user_event"
+	OutboxEventJournal     = "journal_entry"
+	OutboxEventAudit       = "admin_audit"
+	OutboxEventFileDelete  = "attachment_delete"
+	OutboxEventTextExtract = "attachment_text_extract"
+	OutboxEventEmail       = "email_notification"
 )
 
 // OutboxEvent is a durable request to perform a side effect after commit.
@@ -57,9 +59,15 @@ type FailedOutboxEvent struct {
 
 type AcknowledgmentConfirmationEffects struct {
 	UserEvents []CreateUserEventRequest
+	// ActingUserID — замещающий, подтвердивший ознакомление за пользователя.
+	ActingUserID *uuid.UUID
 }
 
 type AttachmentDeletePayload struct {
 	AttachmentID uuid.UUID `json:"attachmentId"`
 	StoragePath  string    `json:"storagePath"`
 }
+
+type AttachmentTextExtractPayload struct {
+	AttachmentID uuid.UUID `json:"attachmentId"`
+}
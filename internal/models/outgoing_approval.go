@@ -0,0 +1,211 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы черновика исходящего письма.
+const (
+	OutgoingDraftStatusDraft      = "draft"
+	OutgoingDraftStatusInApproval = "in_approval"
+	OutgoingDraftStatusReturned   = "returned"
+	OutgoingDraftStatusRejected   = "rejected"
+	OutgoingDraftStatusApproved   = "approved"
+	OutgoingDraftStatusRegistered = "registered"
+)
+
+// Режимы этапа согласования: участники по очереди или одновременно.
+const (
+	ApprovalStageSequential = "sequential"
+	ApprovalStageParallel   = "parallel"
+)
+
+// Решения согласующего.
+const (
+	ApprovalDecisionApprove = "approve"
+	ApprovalDecisionReject  = "reject"
+	ApprovalDecisionReturn  = "return"
+)
+
+// OutgoingDraft — черновик исходящего письма, проходящий маршрут согласования до регистрации.
+type OutgoingDraft struct {
+	ID         uuid.UUID `json:"-"`
+	AuthorID   uuid.UUID `json:"-"`
+	AuthorName string    `json:"authorName,omitempty"`
+
+	NomenclatureID   uuid.UUID `json:"-"`
+	DocumentTypeID   string    `json:"documentTypeId"`
+	RecipientOrgName string    `json:"recipientOrgName"`
+	Addressee        string    `json:"addressee"`
+	OutgoingDate     time.Time `json:"outgoingDate"`
+	Content          string    `json:"content"`
+	PagesCount       int       `json:"pagesCount"`
+	SenderSignatory  string    `json:"senderSignatory"`
+	SenderExecutor   string    `json:"senderExecutor"`
+
+	Status          string     `json:"status"`
+	Round           int        `json:"round"`
+	Revision        int        `json:"revision"`
+	RegistrationKey uuid.UUID  `json:"-"`
+	DocumentID      *uuid.UUID `json:"-"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+
+	Stages    []ApprovalStage    `json:"stages"`
+	Decisions []ApprovalDecision `json:"decisions,omitempty"`
+}
+
+// ApprovalStage — этап маршрута согласования.
+type ApprovalStage struct {
+	Position  int                `json:"position"`
+	Mode      string             `json:"mode"`
+	Approvers []ApprovalApprover `json:"approvers"`
+}
+
+// ApprovalApprover — участник этапа и его решение в текущем круге согласования.
+type ApprovalApprover struct {
+	UserID        uuid.UUID  `json:"-"`
+	UserName      string     `json:"userName,omitempty"`
+	Position      int        `json:"position"`
+	Decision      string     `json:"decision,omitempty"`
+	DecidedBy     *uuid.UUID `json:"-"`
+	DecidedByName string     `json:"decidedByName,omitempty"`
+	DecidedAt     *time.Time `json:"decidedAt,omitempty"`
+}
+
+// ApprovalDecision — запись истории решений по черновику.
+type ApprovalDecision struct {
+	ID            uuid.UUID `json:"-"`
+	DraftID       uuid.UUID `json:"-"`
+	Round         int       `json:"round"`
+	StagePosition int       `json:"stagePosition"`
+	ApproverID    uuid.UUID `json:"-"`
+	ApproverName  string    `json:"approverName,omitempty"`
+	DecidedBy     uuid.UUID `json:"-"`
+	DecidedByName string    `json:"decidedByName,omitempty"`
+	Decision      string    `json:"decision"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// OutgoingDraftTransition описывает атомарное изменение состояния черновика.
+// Применяется только к версии черновика ExpectedRevision.
+type OutgoingDraftTransition struct {
+	DraftID          uuid.UUID
+	ExpectedRevision int
+	Status           string
+	Round            int
+	DocumentID       *uuid.UUID
+	// ResetDecisions очищает решения текущего круга при повторной отправке на согласование.
+	ResetDecisions bool
+	Decision       *ApprovalDecision
+}
+
+// IsApprovalStageMode проверяет режим этапа.
+func IsApprovalStageMode(mode string) bool {
+	return mode == ApprovalStageSequential || mode == ApprovalStageParallel
+}
+
+// IsApprovalDecision проверяет код решения.
+func IsApprovalDecision(decision string) bool {
+	switch decision {
+	case ApprovalDecisionApprove, ApprovalDecisionReject, ApprovalDecisionReturn:
+		return true
+	}
+	return false
+}
+
+// IsEditable сообщает, может ли автор менять черновик и маршрут.
+func (d *OutgoingDraft) IsEditable() bool {
+	return d.Status == OutgoingDraftStatusDraft || d.Status == OutgoingDraftStatusReturned
+}
+
+// CurrentStage возвращает первый этап, не согласованный всеми участниками, или nil.
+func (d *OutgoingDraft) CurrentStage() *ApprovalStage {
+	for i := range d.Stages {
+		for _, approver := range d.Stages[i].Approvers {
+			if approver.Decision != ApprovalDecisionApprove {
+				return &d.Stages[i]
+			}
+		}
+	}
+	return nil
+}
+
+// RouteApproved сообщает, что все этапы маршрута согласованы.
+func (d *OutgoingDraft) RouteApproved() bool {
+	return len(d.Stages) > 0 && d.CurrentStage() == nil
+}
+
+// PendingApproverIDs возвращает участников, от которых сейчас ожидается решение.
+// На последовательном этапе это первый участник без решения, на параллельном — все участники без решения.
+func (d *OutgoingDraft) PendingApproverIDs() []uuid.UUID {
+	if d.Status != OutgoingDraftStatusInApproval {
+		return nil
+	}
+	stage := d.CurrentStage()
+	if stage == nil {
+		return nil
+	}
+	pending := make([]uuid.UUID, 0, len(stage.Approvers))
+	for _, approver := range stage.Approvers {
+		if approver.Decision != "" {
+			continue
+		}
+		pending = append(pending, approver.UserID)
+		if stage.Mode == ApprovalStageSequential {
+			break
+		}
+	}
+	return pending
+}
+
+// RecordDecision отмечает решение участника, от которого сейчас ожидается решение, и возвращает номер этапа.
+func (d *OutgoingDraft) RecordDecision(approverID, decidedBy uuid.UUID, decision string, at time.Time) (int, bool) {
+	pending := false
+	for _, id := range d.PendingApproverIDs() {
+		pending = pending || id == approverID
+	}
+	if !pending {
+		return 0, false
+	}
+	stage := d.CurrentStage()
+	for i := range stage.Approvers {
+		approver := &stage.Approvers[i]
+		if approver.UserID != approverID {
+			continue
+		}
+		approver.Decision = decision
+		approver.DecidedBy = &decidedBy
+		approver.DecidedAt = &at
+		return stage.Position, true
+	}
+	return 0, false
+}
+
+// ResetDecisions очищает решения маршрута перед новым кругом согласования.
+func (d *OutgoingDraft) ResetDecisions() {
+	for i := range d.Stages {
+		for j := range d.Stages[i].Approvers {
+			approver := &d.Stages[i].Approvers[j]
+			approver.Decision = ""
+			approver.DecidedBy = nil
+			approver.DecidedByName = ""
+			approver.DecidedAt = nil
+		}
+	}
+}
+
+// HasApprover сообщает, участвует ли пользователь в маршруте.
+func (d *OutgoingDraft) HasApprover(userID uuid.UUID) bool {
+	for _, stage := range d.Stages {
+		for _, approver := range stage.Approvers {
+			if approver.UserID == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
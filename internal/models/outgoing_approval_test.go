@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutgoingDraftApprovalRoute(t *testing.T) {
+	first, second, third, fourth := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	draft := &OutgoingDraft{
+		Status: OutgoingDraftStatusInApproval,
+		Stages: []ApprovalStage{
+			{Position: 1, Mode: ApprovalStageSequential, Approvers: []ApprovalApprover{{UserID: first}, {UserID: second}}},
+			{Position: 2, Mode: ApprovalStageParallel, Approvers: []ApprovalApprover{{UserID: third}, {UserID: fourth}}},
+		},
+	}
+	now := time.Now()
+
+	assert.Equal(t, []uuid.UUID{first}, draft.PendingApproverIDs())
+	_, ok := draft.RecordDecision(second, second, ApprovalDecisionApprove, now)
+	assert.False(t, ok, "второй участник последовательного этапа ждет первого")
+	// Замещающий решает за первого участника.
+	stage, ok := draft.RecordDecision(first, fourth, ApprovalDecisionApprove, now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, stage)
+	assert.Equal(t, fourth, *draft.Stages[0].Approvers[0].DecidedBy)
+
+	assert.Equal(t, []uuid.UUID{second}, draft.PendingApproverIDs())
+	draft.RecordDecision(second, second, ApprovalDecisionApprove, now)
+	assert.Equal(t, []uuid.UUID{third, fourth}, draft.PendingApproverIDs())
+
+	stage, _ = draft.RecordDecision(fourth, fourth, ApprovalDecisionApprove, now)
+	assert.Equal(t, 2, stage)
+	assert.Equal(t, []uuid.UUID{third}, draft.PendingApproverIDs())
+	assert.False(t, draft.RouteApproved())
+
+	draft.RecordDecision(third, third, ApprovalDecisionApprove, now)
+	assert.True(t, draft.RouteApproved())
+	assert.Nil(t, draft.CurrentStage())
+	assert.Empty(t, draft.PendingApproverIDs())
+
+	draft.ResetDecisions()
+	assert.Equal(t, 1, draft.CurrentStage().Position)
+	assert.Nil(t, draft.Stages[0].Approvers[0].DecidedBy)
+	assert.True(t, draft.HasApprover(fourth))
+	assert.False(t, draft.HasApprover(uuid.New()))
+}
+
+func TestOutgoingDraftPendingApproversOnlyInApproval(t *testing.T) {
+	draft := &OutgoingDraft{
+		Status: OutgoingDraftStatusReturned,
+		Stages: []ApprovalStage{{Position: 1, Mode: ApprovalStageParallel, Approvers: []ApprovalApprover{{UserID: uuid.New()}}}},
+	}
+	assert.Empty(t, draft.PendingApproverIDs())
+	assert.True(t, draft.IsEditable())
+
+	// Возврат тоже решение: этап не считается согласованным.
+	draft.Stages[0].Approvers[0].Decision = ApprovalDecisionReturn
+	assert.False(t, draft.RouteApproved())
+	assert.True(t, IsApprovalDecision(ApprovalDecisionReturn))
+	assert.False(t, IsApprovalDecision("skip"))
+	assert.False(t, IsApprovalStageMode("mixed"))
+}
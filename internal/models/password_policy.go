@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ключи системных настроек политики паролей.
+const (
+	SettingPasswordLifetimeDays     = "password_lifetime_days"
+	SettingPasswordMinLength        = "password_min_length"
+	SettingPasswordRequiredClasses  = "password_required_classes"
+	SettingPasswordRejectCommon     = "password_reject_common"
+	SettingPasswordHistoryDepth     = "password_history_depth"
+	SettingPasswordLockoutThreshold = "password_lockout_threshold"
+	SettingPasswordLockoutMinutes   = "password_lockout_minutes"
+)
+
+// PasswordPolicySettingKeys перечисляет настройки, из которых складывается
+// политика паролей.
+var PasswordPolicySettingKeys = []string{
+	SettingPasswordLifetimeDays,
+	SettingPasswordMinLength,
+	SettingPasswordRequiredClasses,
+	SettingPasswordRejectCommon,
+	SettingPasswordHistoryDepth,
+	SettingPasswordLockoutThreshold,
+	SettingPasswordLockoutMinutes,
+}
+
+// UserPasswordStatus — состояние пароля локального пользователя для оценки
+// политики. Сам пароль и его характеристики не хранятся.
+type UserPasswordStatus struct {
+	UserID                 uuid.UUID
+	Login                  string
+	FullName               string
+	FailedLoginAttempts    int
+	PasswordChangedAt      *time.Time
+	PasswordChangeRequired bool
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Principal описывает пользователя, от имени которого выполняется операция.
+// Он загружается один раз на вызов (desktop-сессия, HTTP-запрос, CLI) и
+// передается через context.Context, поэтому проверки прав внутри операции
+// не зависят от общего для процесса текущего пользователя.
+type Principal struct {
+	UserID                uuid.UUID
+	Login                 string
+	FullName              string
+	DepartmentID          *uuid.UUID
+	IsDocumentParticipant bool
+	SystemPermissions     []string
+	// SubstitutedUserIDs — пользователи, которых principal сейчас замещает
+	// хотя бы в какой-то области.
+	SubstitutedUserIDs []uuid.UUID
+	// Substitutions — действующие замещения с их ограничениями.
+	Substitutions []SubstitutionGrant
+}
+
+// NewPrincipal строит principal по активному пользователю и его действующим замещениям.
+func NewPrincipal(user *User, substitutions []SubstitutionGrant) *Principal {
+	principal := &Principal{
+		UserID:                user.ID,
+		Login:                 user.Login,
+		FullName:              user.FullName,
+		IsDocumentParticipant: user.IsDocumentParticipant,
+		SystemPermissions:     slices.Clone(user.SystemPermissions),
+	}
+	if user.DepartmentID != nil {
+		departmentID := *user.DepartmentID
+		principal.DepartmentID = &departmentID
+	} else if user.Department != nil && user.Department.ID != uuid.Nil {
+		departmentID := user.Department.ID
+		principal.DepartmentID = &departmentID
+	}
+
+	seen := map[uuid.UUID]struct{}{user.ID: {}}
+	for _, grant := range substitutions {
+		if grant.PrincipalUserID == uuid.Nil || grant.PrincipalUserID == user.ID {
+			continue
+		}
+		principal.Substitutions = append(principal.Substitutions, grant)
+		if _, ok := seen[grant.PrincipalUserID]; ok {
+			continue
+		}
+		seen[grant.PrincipalUserID] = struct{}{}
+		principal.SubstitutedUserIDs = append(principal.SubstitutedUserIDs, grant.PrincipalUserID)
+	}
+	return principal
+}
+
+// HasSystemPermission проверяет прямое системное право principal.
+func (p *Principal) HasSystemPermission(permission string) bool {
+	return slices.Contains(p.SystemPermissions, permission)
+}
+
+// SubjectIDs возвращает пользователя и замещаемых им пользователей: от их имени
+// principal видит поручения, ознакомления и связанные с ними документы.
+func (p *Principal) SubjectIDs() []uuid.UUID {
+	return append([]uuid.UUID{p.UserID}, p.SubstitutedUserIDs...)
+}
+
+// HasActiveSubstitution сообщает, замещает ли principal кого-либо сейчас.
+func (p *Principal) HasActiveSubstitution() bool {
+	return len(p.SubstitutedUserIDs) > 0
+}
+
+// ActsFor сообщает, может ли principal действовать за userID хотя бы в какой-то области.
+func (p *Principal) ActsFor(userID uuid.UUID) bool {
+	return userID == p.UserID || slices.Contains(p.SubstitutedUserIDs, userID)
+}
+
+// ActsForIn сообщает, может ли principal действовать за userID в области
+// scope по документу вида kind.
+func (p *Principal) ActsForIn(userID uuid.UUID, scope string, kind DocumentKind) bool {
+	if userID == p.UserID {
+		return true
+	}
+	for _, grant := range p.Substitutions {
+		if grant.PrincipalUserID == userID && grant.Covers(scope, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// SubjectIDsFor возвращает пользователя и тех замещаемых, за которых он
+// действует в области scope по документам вида kind. Для пустого kind
+// учитываются только замещения без ограничения по видам документов.
+func (p *Principal) SubjectIDsFor(scope string, kind DocumentKind) []uuid.UUID {
+	ids := []uuid.UUID{p.UserID}
+	for _, grant := range p.Substitutions {
+		if grant.Covers(scope, kind) && !slices.Contains(ids, grant.PrincipalUserID) {
+			ids = append(ids, grant.PrincipalUserID)
+		}
+	}
+	return ids
+}
+
+// KindScopedSubjectKeys возвращает ключи SubjectKindKey для замещений области
+// scope, ограниченных видами документов. Вместе с SubjectIDsFor(scope, "")
+// они описывают полномочия principal в списках, объединяющих разные виды.
+func (p *Principal) KindScopedSubjectKeys(scope string) []string {
+	var keys []string
+	for _, grant := range p.Substitutions {
+		if len(grant.DocumentKinds) == 0 || (len(grant.Scopes) > 0 && !slices.Contains(grant.Scopes, scope)) {
+			continue
+		}
+		for _, kind := range grant.DocumentKinds {
+			key := SubjectKindKey(grant.PrincipalUserID, kind)
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// SubjectKindKey кодирует пару «пользователь, вид документа» так же, как
+// SQL-выражение user_id::text || ':' || kind в фильтрах списков.
+func SubjectKindKey(userID uuid.UUID, kind string) string {
+	return userID.String() + ":" + kind
+}
+
+// DepartmentIDString возвращает ID подразделения или пустую строку.
+func (p *Principal) DepartmentIDString() string {
+	if p.DepartmentID == nil {
+		return ""
+	}
+	return p.DepartmentID.String()
+}
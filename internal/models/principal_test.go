@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPrincipal(t *testing.T) {
+	departmentID := uuid.New()
+	user := &User{
+		ID:                    uuid.New(),
+		Login:                 "ivanov",
+		FullName:              "Иванов И.И.",
+		IsDocumentParticipant: true,
+		SystemPermissions:     []string{SystemPermissionReferences},
+		Department:            &Department{ID: departmentID},
+	}
+	first, second := uuid.New(), uuid.New()
+
+	principal := NewPrincipal(user, []SubstitutionGrant{
+		{PrincipalUserID: first},
+		{PrincipalUserID: user.ID},
+		{PrincipalUserID: uuid.Nil},
+		{PrincipalUserID: second},
+		{PrincipalUserID: first, Scopes: []string{SubstitutionScopeApprovals}},
+	})
+
+	assert.Equal(t, []uuid.UUID{first, second}, principal.SubstitutedUserIDs)
+	assert.Equal(t, []uuid.UUID{user.ID, first, second}, principal.SubjectIDs())
+	assert.Equal(t, departmentID.String(), principal.DepartmentIDString())
+	assert.True(t, principal.HasSystemPermission(SystemPermissionReferences))
+	assert.False(t, principal.HasSystemPermission(SystemPermissionAdmin))
+	assert.True(t, principal.HasActiveSubstitution())
+	assert.True(t, principal.ActsFor(user.ID))
+	assert.True(t, principal.ActsFor(second))
+	assert.False(t, principal.ActsFor(uuid.New()))
+
+	user.SystemPermissions[0] = SystemPermissionAdmin
+	assert.False(t, principal.HasSystemPermission(SystemPermissionAdmin), "permissions are cached at load time")
+}
+
+func TestNewPrincipal_WithoutSubstitutions(t *testing.T) {
+	principal := NewPrincipal(&User{ID: uuid.New()}, nil)
+
+	assert.False(t, principal.HasActiveSubstitution())
+	assert.Len(t, principal.SubjectIDs(), 1)
+	assert.Empty(t, principal.DepartmentIDString())
+}
+
+func TestPrincipal_ScopedSubstitutions(t *testing.T) {
+	user := &User{ID: uuid.New()}
+	full, ackOnly, lettersOnly := uuid.New(), uuid.New(), uuid.New()
+	principal := NewPrincipal(user, []SubstitutionGrant{
+		{PrincipalUserID: full},
+		{PrincipalUserID: ackOnly, Scopes: []string{SubstitutionScopeAcknowledgments}},
+		{PrincipalUserID: lettersOnly, DocumentKinds: []string{string(DocumentKindIncomingLetter)}},
+	})
+
+	assert.Equal(t, []uuid.UUID{full, ackOnly, lettersOnly}, principal.SubstitutedUserIDs)
+	assert.Equal(t, []uuid.UUID{user.ID, full}, principal.SubjectIDsFor(SubstitutionScopeAssignments, ""))
+	assert.Equal(t, []uuid.UUID{user.ID, full, lettersOnly}, principal.SubjectIDsFor(SubstitutionScopeAssignments, DocumentKindIncomingLetter))
+	assert.Equal(t, []uuid.UUID{user.ID, full, ackOnly}, principal.SubjectIDsFor(SubstitutionScopeAcknowledgments, DocumentKindOutgoingLetter))
+	assert.Equal(t, []string{SubjectKindKey(lettersOnly, string(DocumentKindIncomingLetter))}, principal.KindScopedSubjectKeys(SubstitutionScopeAssignments))
+
+	assert.True(t, principal.ActsFor(ackOnly))
+	assert.True(t, principal.ActsForIn(ackOnly, SubstitutionScopeAcknowledgments, DocumentKindIncomingLetter))
+	assert.False(t, principal.ActsForIn(ackOnly, SubstitutionScopeAssignments, DocumentKindIncomingLetter))
+	assert.True(t, principal.ActsForIn(lettersOnly, SubstitutionScopeApprovals, DocumentKindIncomingLetter))
+	assert.False(t, principal.ActsForIn(lettersOnly, SubstitutionScopeApprovals, DocumentKindOutgoingLetter))
+	assert.True(t, principal.ActsForIn(user.ID, SubstitutionScopeApprovals, DocumentKindOutgoingLetter))
+}
+
+func TestUserSubstitution_StatusOn(t *testing.T) {
+	day := time.Date(2026, 6, 10, 15, 0, 0, 0, time.Local)
+	date := func(value string) *time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return &parsed
+	}
+
+	assert.Equal(t, SubstitutionStatusActive, (&UserSubstitution{IsActive: true}).StatusOn(day))
+	assert.Equal(t, SubstitutionStatusActive, (&UserSubstitution{IsActive: true, StartsAt: date("2026-06-10"), EndsAt: date("2026-06-10")}).StatusOn(day))
+	assert.Equal(t, SubstitutionStatusScheduled, (&UserSubstitution{IsActive: true, StartsAt: date("2026-06-11")}).StatusOn(day))
+	assert.Equal(t, SubstitutionStatusExpired, (&UserSubstitution{IsActive: true, EndsAt: date("2026-06-09")}).StatusOn(day))
+	assert.Equal(t, SubstitutionStatusDisabled, (&UserSubstitution{}).StatusOn(day))
+	assert.Equal(t, SubstitutionStatusRevoked, (&UserSubstitution{IsActive: true, RevokedAt: &day}).StatusOn(day))
+}
@@ -28,11 +28,16 @@ type Organization struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-// DocumentType — тип документа
+// DocumentType — тип документа. Kinds перечисляет виды документов, для которых тип доступен при регистрации;
+// неактивный тип остается у зарегистрированных документов, но не предлагается для новых.
 type DocumentType struct {
-	ID        uuid.UUID `json:"-"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID         uuid.UUID      `json:"-"`
+	Name       string         `json:"name"`
+	Kinds      []DocumentKind `json:"kinds"`
+	IsActive   bool           `json:"isActive"`
+	UsageCount int            `json:"usageCount"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
 }
 
 // ResolutionExecutor — исполнитель резолюции (автозаполняемый справочник)
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ключи системных настроек правил напоминаний.
+const (
+	SettingReminderAssignmentDaysBefore   = "reminder_assignment_days_before"
+	SettingReminderAssignmentOnDeadline   = "reminder_assignment_on_deadline"
+	SettingReminderAssignmentOverdueEvery = "reminder_assignment_overdue_every_days"
+	SettingReminderAssignmentEscalation   = "reminder_assignment_escalation_days"
+	SettingReminderAcknowledgmentEvery    = "reminder_acknowledgment_every_days"
+)
+
+// MaxReminderDays ограничивает значения правил напоминаний в днях.
+const MaxReminderDays = 90
+
+// Виды напоминаний, которые пользователь может отключить.
+const (
+	ReminderKindAssignmentDeadline   = "assignment_deadline"
+	ReminderKindAssignmentOverdue    = "assignment_overdue"
+	ReminderKindAssignmentEscalation = "assignment_escalation"
+	ReminderKindAcknowledgment       = "acknowledgment_pending"
+)
+
+// ReminderKinds перечисляет виды напоминаний в порядке показа в настройках.
+var ReminderKinds = []string{
+	ReminderKindAssignmentDeadline,
+	ReminderKindAssignmentOverdue,
+	ReminderKindAssignmentEscalation,
+	ReminderKindAcknowledgment,
+}
+
+// IsReminderKind сообщает, известен ли вид напоминания.
+func IsReminderKind(kind string) bool {
+	for _, known := range ReminderKinds {
+		if known == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ReminderRules — действующие правила планировщика напоминаний.
+type ReminderRules struct {
+	// AssignmentDaysBefore — за сколько дней до срока напоминать, по убыванию.
+	AssignmentDaysBefore []int
+	AssignmentOnDeadline bool
+	// AssignmentOverdueEvery — период напоминаний о просрочке; 0 — не напоминать.
+	AssignmentOverdueEvery int
+	// AssignmentEscalationDays — дней просрочки до эскалации; 0 — без эскалации.
+	AssignmentEscalationDays int
+	// AcknowledgmentEvery — период напоминаний об ознакомлении; 0 — не напоминать.
+	AcknowledgmentEvery int
+}
+
+// DefaultReminderRules возвращает правила, заложенные миграцией.
+func DefaultReminderRules() ReminderRules {
+	return ReminderRules{
+		AssignmentDaysBefore:     []int{3, 1},
+		AssignmentOnDeadline:     true,
+		AssignmentOverdueEvery:   1,
+		AssignmentEscalationDays: 3,
+		AcknowledgmentEvery:      2,
+	}
+}
+
+// ParseReminderDaysBefore разбирает значение SettingReminderAssignmentDaysBefore:
+// дни через запятую от 1 до MaxReminderDays. Пустая строка отключает напоминания.
+func ParseReminderDaysBefore(value string) ([]int, error) {
+	seen := make(map[int]struct{})
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 1 || day > MaxReminderDays {
+			return nil, fmt.Errorf("invalid reminder day %q", part)
+		}
+		if _, ok := seen[day]; ok {
+			continue
+		}
+		seen[day] = struct{}{}
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days, nil
+}
+
+// AssignmentReminderCandidate — открытое поручение со сроком, по которому
+// планировщик может отправить напоминание или эскалацию.
+type AssignmentReminderCandidate struct {
+	ID             uuid.UUID
+	DocumentID     uuid.UUID
+	DocumentKind   string
+	DocumentNumber string
+	ExecutorID     uuid.UUID
+	ExecutorName   string
+	ExecutorActive bool
+	CoExecutorIDs  []uuid.UUID
+	Deadline       time.Time
+	// CreatedBy — автор поручения; для старых поручений может быть неизвестен.
+	CreatedBy *uuid.UUID
+	// DepartmentHeadID — руководитель подразделения исполнителя.
+	DepartmentHeadID *uuid.UUID
+}
+
+// AcknowledgmentReminderCandidate — неподтвержденное ознакомление пользователя.
+type AcknowledgmentReminderCandidate struct {
+	AcknowledgmentID uuid.UUID
+	DocumentID       uuid.UUID
+	DocumentKind     string
+	DocumentNumber   string
+	UserID           uuid.UUID
+	CreatedAt        time.Time
+}
+
+// ReminderPreference — настройка вида напоминаний для пользователя.
+type ReminderPreference struct {
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+	Muted bool   `json:"muted"`
+}
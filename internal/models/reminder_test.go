@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminderDaysBefore(t *testing.T) {
+	days, err := ParseReminderDaysBefore(" 1, 7,3 ,1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{7, 3, 1}, days)
+
+	days, err = ParseReminderDaysBefore("")
+	require.NoError(t, err)
+	assert.Empty(t, days)
+
+	for _, value := range []string{"0", "3,x", "91"} {
+		_, err := ParseReminderDaysBefore(value)
+		assert.Error(t, err, value)
+	}
+}
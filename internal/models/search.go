@@ -0,0 +1,101 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Служебные разделители фрагментов ts_headline. Управляющие символы не встречаются
+// в тексте документов, поэтому подсветка передаётся клиенту без HTML-разметки.
+const (
+	SearchHighlightStart      = "\x02"
+	SearchHighlightStop       = "\x03"
+	SearchFragmentDelimiter   = "\x1f"
+	SearchHighlightDocument   = "document"
+	SearchHighlightAttachment = "attachment"
+)
+
+// SearchFilter — параметры сквозного поиска по документам.
+type SearchFilter struct {
+	KindCodes []string `json:"kindCodes,omitempty"`
+	DateFrom  string   `json:"dateFrom,omitempty"`
+	DateTo    string   `json:"dateTo,omitempty"`
+	Limit     int      `json:"limit"`
+	Offset    int      `json:"offset"`
+}
+
+// DocumentSearchQuery — нормализованный запрос к поисковому индексу документов.
+type DocumentSearchQuery struct {
+	Text     string
+	Kinds    []DocumentKind
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Limit    int
+	Offset   int
+}
+
+// DocumentSearchHit — документ, найденный в поисковом индексе.
+type DocumentSearchHit struct {
+	DocumentID         uuid.UUID
+	Kind               DocumentKind
+	RegistrationNumber string
+	RegistrationDate   time.Time
+	Content            string
+	Rank               float64
+	Headline           string
+	AttachmentHeadline string
+}
+
+// SearchHighlightSegment — часть фрагмента подсветки; Match отмечает совпадение с запросом.
+type SearchHighlightSegment struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match"`
+}
+
+// ParseSearchHeadline разбирает результат ts_headline на фрагменты с отмеченными совпадениями.
+// Фрагменты без совпадений отбрасываются: ts_headline возвращает начало текста,
+// даже если запрос совпал только с другими полями документа.
+func ParseSearchHeadline(headline string) [][]SearchHighlightSegment {
+	fragments := make([][]SearchHighlightSegment, 0)
+	for _, fragment := range strings.Split(headline, SearchFragmentDelimiter) {
+		segments := make([]SearchHighlightSegment, 0)
+		matched := false
+		rest := fragment
+		for rest != "" {
+			start := strings.Index(rest, SearchHighlightStart)
+			if start < 0 {
+				segments = appendSearchSegment(segments, rest, false)
+				break
+			}
+			segments = appendSearchSegment(segments, rest[:start], false)
+			rest = rest[start+len(SearchHighlightStart):]
+
+			stop := strings.Index(rest, SearchHighlightStop)
+			if stop < 0 {
+				stop = len(rest)
+			}
+			if rest[:stop] != "" {
+				matched = true
+			}
+			segments = appendSearchSegment(segments, rest[:stop], true)
+			rest = strings.TrimPrefix(rest[stop:], SearchHighlightStop)
+		}
+		if matched {
+			fragments = append(fragments, segments)
+		}
+	}
+	return fragments
+}
+
+func appendSearchSegment(segments []SearchHighlightSegment, text string, match bool) []SearchHighlightSegment {
+	if text == "" {
+		return segments
+	}
+	if n := len(segments); n > 0 && segments[n-1].Match == match {
+		segments[n-1].Text += text
+		return segments
+	}
+	return append(segments, SearchHighlightSegment{Text: text, Match: match})
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearchHeadline(t *testing.T) {
+	headline := "О \x02ремонте\x03 \x02кровли\x03 здания" + SearchFragmentDelimiter + "без совпадений" + SearchFragmentDelimiter + "\x02смета\x03"
+
+	fragments := ParseSearchHeadline(headline)
+
+	assert.Equal(t, [][]SearchHighlightSegment{
+		{
+			{Text: "О "},
+			{Text: "ремонте", Match: true},
+			{Text: " "},
+			{Text: "кровли", Match: true},
+			{Text: " здания"},
+		},
+		{
+			{Text: "смета", Match: true},
+		},
+	}, fragments)
+}
+
+func TestParseSearchHeadline_NoMatches(t *testing.T) {
+	assert.Empty(t, ParseSearchHeadline("Начало текста документа"))
+	assert.Empty(t, ParseSearchHeadline(""))
+}
+
+func TestParseSearchHeadline_UnterminatedMatch(t *testing.T) {
+	fragments := ParseSearchHeadline("акт \x02приёмки")
+
+	assert.Equal(t, [][]SearchHighlightSegment{{{Text: "акт "}, {Text: "приёмки", Match: true}}}, fragments)
+}
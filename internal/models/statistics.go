@@ -22,6 +22,9 @@ type StatisticsReportRow struct {
 	Key   string `json:"key"`
 	Name  string `json:"name"`
 	Count int    `json:"count"`
+	// ParentKey заполнен в группировке по подразделениям: счетчик строки
+	// включает документы всех подчиненных подразделений.
+	ParentKey string `json:"parentKey,omitempty"`
 }
 
 // DocumentStatistics описывает обзорную статистику по документам.
@@ -62,6 +65,7 @@ type AssignmentStatistics struct {
 	MonthlyTotals     []AssignmentMonthlyPoint `json:"monthlyTotals"`
 	MonthlyByExecutor []StatisticsSeriesPoint  `json:"monthlyByExecutor"`
 	OverdueRating     []StatisticsReportRow    `json:"overdueRating"`
+	OverdueByLevel    []StatisticsReportRow    `json:"overdueByLevel"` // 1 — поручения, 2+ — подпоручения
 	StatusCounts      []StatisticsReportRow    `json:"statusCounts"`
 }
 
@@ -80,6 +84,33 @@ type AssignmentStatisticsReport struct {
 	Rows        []StatisticsReportRow `json:"rows"`
 }
 
+// AssignmentSeriesStatisticsRow — исполнение поручений одной серии за период.
+type AssignmentSeriesStatisticsRow struct {
+	SeriesID       string             `json:"seriesId"`
+	DocumentID     string             `json:"documentId"`
+	DocumentKind   string             `json:"documentKind"`
+	DocumentNumber string             `json:"documentNumber,omitempty"`
+	ExecutorName   string             `json:"executorName,omitempty"`
+	Content        string             `json:"content"`
+	Schedule       AssignmentSchedule `json:"schedule"`
+	ScheduleLabel  string             `json:"scheduleLabel"`
+	Status         string             `json:"status"`
+	Total          int                `json:"total"`
+	Completed      int                `json:"completed"` // исполненные и принятые
+	Open           int                `json:"open"`
+	Overdue        int                `json:"overdue"`
+}
+
+// AssignmentSeriesStatisticsReport описывает отчет по сериям поручений за
+// период: в него попадают поручения серий со сроком по расписанию в периоде.
+type AssignmentSeriesStatisticsReport struct {
+	StartDate string                          `json:"startDate"`
+	EndDate   string                          `json:"endDate"`
+	Total     int                             `json:"total"`
+	Overdue   int                             `json:"overdue"`
+	Rows      []AssignmentSeriesStatisticsRow `json:"rows"`
+}
+
 // SystemStatistics описывает системную статистику.
 type SystemStatistics struct {
 	UserCount                int        `json:"userCount"`
@@ -89,6 +120,8 @@ type SystemStatistics struct {
 	StorageSize              string     `json:"storageSize"`
 	StorageRefreshedAt       *time.Time `json:"storageRefreshedAt,omitempty"`
 	StorageRefreshInProgress bool       `json:"storageRefreshInProgress"`
+	// AttachmentIntegrity заполняется, если хранилище метаданных поддерживает сводку проверок.
+	AttachmentIntegrity *AttachmentIntegritySummary `json:"attachmentIntegrity,omitempty"`
 }
 
 // StorageStatisticsSnapshot is the persisted result of the last complete
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SettingTwoFactorRequiredPermissions — системные права, владельцы которых
+// обязаны входить со вторым фактором (через запятую).
+const SettingTwoFactorRequiredPermissions = "two_factor_required_permissions"
+
+// UserTOTP — секрет TOTP пользователя.
+type UserTOTP struct {
+	UserID uuid.UUID
+	Secret string
+	// EnabledAt == nil — подключение начато, но не подтверждено кодом.
+	EnabledAt *time.Time
+	// LastUsedStep — последний принятый интервал TOTP.
+	LastUsedStep int64
+}
+
+// IsEnabled сообщает, что второй фактор подтвержден и проверяется при входе.
+func (t *UserTOTP) IsEnabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// ParseTwoFactorRequiredPermissions разбирает значение настройки
+// SettingTwoFactorRequiredPermissions. Пустая строка — второй фактор никому
+// не обязателен.
+func ParseTwoFactorRequiredPermissions(value string) ([]string, error) {
+	var permissions []string
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+		if !IsSystemPermission(code) {
+			return nil, fmt.Errorf("unknown system permission %q", code)
+		}
+		permissions = append(permissions, code)
+	}
+	return permissions, nil
+}
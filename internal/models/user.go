@@ -17,6 +17,8 @@ type User struct {
 	FailedLoginAttempts    int         `json:"failedLoginAttempts"`
 	PasswordChangedAt      *time.Time  `json:"passwordChangedAt,omitempty"`
 	PasswordChangeRequired bool        `json:"passwordChangeRequired"`
+	AuthSource             string      `json:"authSource"`
+	ExternalID             string      `json:"-"`
 	SystemPermissions      []string    `json:"systemPermissions"`
 	CreatedAt              time.Time   `json:"createdAt"`
 	UpdatedAt              time.Time   `json:"updatedAt"`
@@ -24,6 +26,17 @@ type User struct {
 	Department             *Department `json:"department,omitempty"`
 }
 
+// Источники учетных записей пользователей.
+const (
+	UserAuthSourceLocal = "local"
+	UserAuthSourceLDAP  = "ldap"
+)
+
+// IsDirectoryUser сообщает, что пароль и атрибуты пользователя ведутся во внешнем каталоге.
+func (u *User) IsDirectoryUser() bool {
+	return u != nil && u.AuthSource != "" && u.AuthSource != UserAuthSourceLocal
+}
+
 // SessionPrincipal is the minimum state needed to validate an in-memory
 // session. It deliberately excludes profile, department and permissions.
 type SessionPrincipal struct {
@@ -9,14 +9,29 @@ import (
 const (
 	UserEventEntityAssignment     = "assignment"
 	UserEventEntityAcknowledgment = "acknowledgment"
+	UserEventEntityOutgoingDraft  = "outgoing_draft"
+	UserEventEntitySubstitution   = "user_substitution"
 
 	UserEventAssignmentCreated       = "assignment_created"
 	UserEventAssignmentUpdated       = "assignment_updated"
 	UserEventAssignmentCompleted     = "assignment_completed"
 	UserEventAssignmentFinished      = "assignment_finished"
 	UserEventAssignmentReturned      = "assignment_returned"
+	UserEventExtensionRequested      = "assignment_extension_requested"
+	UserEventExtensionApproved       = "assignment_extension_approved"
+	UserEventExtensionRejected       = "assignment_extension_rejected"
 	UserEventAcknowledgmentCreated   = "acknowledgment_created"
 	UserEventAcknowledgmentConfirmed = "acknowledgment_confirmed"
+	UserEventApprovalRequested       = "approval_requested"
+	UserEventApprovalReturned        = "approval_returned"
+	UserEventApprovalRejected        = "approval_rejected"
+	UserEventApprovalApproved        = "approval_approved"
+	UserEventApprovalRegistered      = "approval_registered"
+	UserEventSubstitutionExpiring    = "substitution_expiring"
+	UserEventAssignmentReminder      = "assignment_reminder"
+	UserEventAssignmentOverdue       = "assignment_overdue"
+	UserEventAssignmentEscalated     = "assignment_escalated"
+	UserEventAcknowledgmentReminder  = "acknowledgment_reminder"
 )
 
 // UserEvent описывает персональное событие пользователя.
@@ -25,17 +40,19 @@ type UserEvent struct {
 	RecipientUserID uuid.UUID  `json:"-"`
 	ActorUserID     *uuid.UUID `json:"-"`
 	ActorUserName   string     `json:"actorUserName,omitempty"`
-	DocumentID      uuid.UUID  `json:"-"`
-	DocumentKind    string     `json:"documentKind"`
-	DocumentNumber  string     `json:"documentNumber,omitempty"`
-	EntityType      string     `json:"entityType"`
-	EntityID        uuid.UUID  `json:"-"`
-	EventType       string     `json:"eventType"`
-	Title           string     `json:"title"`
-	Message         string     `json:"message"`
-	Metadata        string     `json:"metadata,omitempty"`
-	CreatedAt       time.Time  `json:"createdAt"`
-	ReadAt          *time.Time `json:"readAt,omitempty"`
+	// DocumentID пуст для событий черновиков, еще не зарегистрированных как
+	// документ, и для событий замещений.
+	DocumentID     uuid.UUID  `json:"-"`
+	DocumentKind   string     `json:"documentKind"`
+	DocumentNumber string     `json:"documentNumber,omitempty"`
+	EntityType     string     `json:"entityType"`
+	EntityID       uuid.UUID  `json:"-"`
+	EventType      string     `json:"eventType"`
+	Title          string     `json:"title"`
+	Message        string     `json:"message"`
+	Metadata       string     `json:"metadata,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ReadAt         *time.Time `json:"readAt,omitempty"`
 }
 
 // CreateUserEventRequest описывает данные для создания события.
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Причины завершения сеанса.
+const (
+	SessionEndLogout       = "logout"
+	SessionEndExpired      = "expired"
+	SessionEndTerminated   = "terminated"
+	SessionEndUserInactive = "user_inactive"
+	SessionEndReplaced     = "replaced"
+	SessionEndShutdown     = "shutdown"
+)
+
+// Ключи системных настроек сеанса.
+const (
+	SettingSessionIdleTimeoutMinutes   = "session_idle_timeout_minutes"
+	SettingSessionAbsoluteTimeoutHours = "session_absolute_timeout_hours"
+)
+
+// UserSession описывает запись журнала сеансов desktop-клиента.
+type UserSession struct {
+	ID               uuid.UUID  `json:"id"`
+	UserID           uuid.UUID  `json:"userId"`
+	UserLogin        string     `json:"userLogin"`
+	UserFullName     string     `json:"userFullName"`
+	HostName         string     `json:"hostName"`
+	StartedAt        time.Time  `json:"startedAt"`
+	LastActivityAt   time.Time  `json:"lastActivityAt"`
+	EndedAt          *time.Time `json:"endedAt,omitempty"`
+	EndReason        string     `json:"endReason,omitempty"`
+	TerminatedByName string     `json:"terminatedByName,omitempty"`
+}
+
+// IsActive возвращает true, пока сеанс не завершен.
+func (s *UserSession) IsActive() bool {
+	return s.EndedAt == nil
+}
+
+// UserSessionFilter описывает параметры журнала сеансов.
+type UserSessionFilter struct {
+	ActiveOnly bool   `json:"activeOnly"`
+	UserID     string `json:"userId"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"pageSize"`
+}
@@ -1,12 +1,39 @@
 package models
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// UserSubstitution описывает активное или запланированное замещение пользователя.
+// Области действий, которыми можно ограничить замещение.
+const (
+	SubstitutionScopeAssignments     = "assignments"
+	SubstitutionScopeAcknowledgments = "acknowledgments"
+	SubstitutionScopeApprovals       = "approvals"
+)
+
+// Состояния замещения на текущую дату.
+const (
+	SubstitutionStatusScheduled = "scheduled"
+	SubstitutionStatusActive    = "active"
+	SubstitutionStatusExpired   = "expired"
+	SubstitutionStatusDisabled  = "disabled"
+	SubstitutionStatusRevoked   = "revoked"
+)
+
+// IsSubstitutionScope проверяет код области действий замещения.
+func IsSubstitutionScope(scope string) bool {
+	switch scope {
+	case SubstitutionScopeAssignments, SubstitutionScopeAcknowledgments, SubstitutionScopeApprovals:
+		return true
+	default:
+		return false
+	}
+}
+
+// UserSubstitution описывает активное, запланированное или завершенное замещение пользователя.
 type UserSubstitution struct {
 	ID               uuid.UUID  `json:"-"`
 	PrincipalUserID  uuid.UUID  `json:"-"`
@@ -16,12 +43,60 @@ type UserSubstitution struct {
 	StartsAt         *time.Time `json:"startsAt,omitempty"`
 	EndsAt           *time.Time `json:"endsAt,omitempty"`
 	IsActive         bool       `json:"isActive"`
-	CreatedBy        *uuid.UUID `json:"-"`
-	CreatedAt        time.Time  `json:"createdAt"`
-	UpdatedAt        time.Time  `json:"updatedAt"`
+	// DocumentKinds и Scopes ограничивают полномочия замещающего; пустой список — без ограничения.
+	DocumentKinds []string   `json:"documentKinds"`
+	Scopes        []string   `json:"scopes"`
+	RevokedAt     *time.Time `json:"revokedAt,omitempty"`
+	CreatedBy     *uuid.UUID `json:"-"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// StatusOn возвращает состояние замещения на дату day.
+func (s *UserSubstitution) StatusOn(day time.Time) string {
+	date := day.Format("2006-01-02")
+	switch {
+	case s.RevokedAt != nil:
+		return SubstitutionStatusRevoked
+	case !s.IsActive:
+		return SubstitutionStatusDisabled
+	case s.EndsAt != nil && s.EndsAt.Format("2006-01-02") < date:
+		return SubstitutionStatusExpired
+	case s.StartsAt != nil && s.StartsAt.Format("2006-01-02") > date:
+		return SubstitutionStatusScheduled
+	default:
+		return SubstitutionStatusActive
+	}
 }
 
-// UpdateUserSubstitutionRequest описывает запрос на назначение замещающего.
+// Grant возвращает полномочия, которые замещение дает замещающему.
+func (s *UserSubstitution) Grant() SubstitutionGrant {
+	return SubstitutionGrant{PrincipalUserID: s.PrincipalUserID, DocumentKinds: s.DocumentKinds, Scopes: s.Scopes}
+}
+
+// SubstitutionGrant — действующее замещение с точки зрения замещающего:
+// за кого он действует и в каких пределах.
+type SubstitutionGrant struct {
+	PrincipalUserID uuid.UUID
+	DocumentKinds   []string
+	Scopes          []string
+}
+
+// Covers сообщает, распространяется ли замещение на действия области scope
+// с документами вида kind. Пустой kind означает «любой вид»: его покрывает
+// только замещение без ограничения по видам документов.
+func (g SubstitutionGrant) Covers(scope string, kind DocumentKind) bool {
+	if len(g.Scopes) > 0 && !slices.Contains(g.Scopes, scope) {
+		return false
+	}
+	if len(g.DocumentKinds) == 0 {
+		return true
+	}
+	return kind != "" && slices.Contains(g.DocumentKinds, string(kind))
+}
+
+// UpdateUserSubstitutionRequest описывает запрос на назначение единственного
+// замещающего: действующие и запланированные замещения пользователя отменяются.
 type UpdateUserSubstitutionRequest struct {
 	PrincipalUserID  string `json:"principalUserId,omitempty"`
 	SubstituteUserID string `json:"substituteUserId,omitempty"`
@@ -29,3 +104,14 @@ type UpdateUserSubstitutionRequest struct {
 	EndsAt           string `json:"endsAt,omitempty"`
 	IsActive         bool   `json:"isActive"`
 }
+
+// CreateUserSubstitutionRequest описывает добавление замещения к уже
+// существующим: на период, по видам документов и областям действий.
+type CreateUserSubstitutionRequest struct {
+	PrincipalUserID  string   `json:"principalUserId,omitempty"`
+	SubstituteUserID string   `json:"substituteUserId"`
+	StartsAt         string   `json:"startsAt,omitempty"`
+	EndsAt           string   `json:"endsAt,omitempty"`
+	DocumentKinds    []string `json:"documentKinds"`
+	Scopes           []string `json:"scopes"`
+}
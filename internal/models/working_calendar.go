@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// WorkingCalendarDay описывает исключение из стандартной пятидневной недели:
+// праздничный выходной или перенесенный рабочий день.
+type WorkingCalendarDay struct {
+	Day          time.Time `json:"day"`
+	IsWorkingDay bool      `json:"isWorkingDay"`
+	Description  string    `json:"description"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// WorkingCalendar определяет рабочие дни с учетом исключений производственного календаря.
+// Суббота и воскресенье считаются выходными, если день не переопределен явно.
+type WorkingCalendar struct {
+	overrides map[string]bool
+}
+
+// NewWorkingCalendar создает календарь из списка исключений.
+func NewWorkingCalendar(days []WorkingCalendarDay) *WorkingCalendar {
+	overrides := make(map[string]bool, len(days))
+	for _, day := range days {
+		overrides[calendarKey(day.Day)] = day.IsWorkingDay
+	}
+	return &WorkingCalendar{overrides: overrides}
+}
+
+// IsWorkingDay возвращает признак рабочего дня.
+func (c *WorkingCalendar) IsWorkingDay(day time.Time) bool {
+	if c != nil {
+		if isWorking, ok := c.overrides[calendarKey(day)]; ok {
+			return isWorking
+		}
+	}
+	weekday := day.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// NextWorkingDay возвращает ближайший рабочий день, начиная с указанной даты включительно.
+func (c *WorkingCalendar) NextWorkingDay(day time.Time) time.Time {
+	day = truncateToDay(day)
+	for !c.IsWorkingDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// AddWorkingDays прибавляет к дате указанное количество рабочих дней.
+// День начала в расчет не включается.
+func (c *WorkingCalendar) AddWorkingDays(start time.Time, days int) time.Time {
+	day := truncateToDay(start)
+	for days > 0 {
+		day = day.AddDate(0, 0, 1)
+		if c.IsWorkingDay(day) {
+			days--
+		}
+	}
+	return day
+}
+
+func calendarKey(day time.Time) string {
+	return day.Format("2006-01-02")
+}
+
+func truncateToDay(day time.Time) time.Time {
+	year, month, date := day.Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestWorkingCalendar(t *testing.T) {
+	calendar := NewWorkingCalendar([]WorkingCalendarDay{
+		{Day: date(2026, 5, 1), IsWorkingDay: false},
+		{Day: date(2026, 5, 9), IsWorkingDay: true},
+	})
+
+	assert.False(t, calendar.IsWorkingDay(date(2026, 5, 1)))
+	assert.True(t, calendar.IsWorkingDay(date(2026, 5, 9)))
+	assert.False(t, calendar.IsWorkingDay(date(2026, 5, 10)))
+	assert.True(t, calendar.IsWorkingDay(date(2026, 5, 11)))
+
+	// 1 мая — праздник, 2-3 мая — выходные.
+	assert.Equal(t, date(2026, 5, 4), calendar.NextWorkingDay(date(2026, 5, 1)))
+	assert.Equal(t, date(2026, 5, 4), calendar.NextWorkingDay(time.Date(2026, 5, 4, 15, 30, 0, 0, time.UTC)))
+
+	// Рабочие дни после 30 апреля: 4, 5, 6, 7, 8, 9 (перенесенный) мая.
+	assert.Equal(t, date(2026, 5, 9), calendar.AddWorkingDays(date(2026, 4, 30), 6))
+	assert.Equal(t, date(2026, 4, 30), calendar.AddWorkingDays(date(2026, 4, 30), 0))
+}
+
+func TestWorkingCalendarNil(t *testing.T) {
+	var calendar *WorkingCalendar
+
+	assert.True(t, calendar.IsWorkingDay(date(2026, 5, 1)))
+	assert.Equal(t, date(2026, 5, 4), calendar.NextWorkingDay(date(2026, 5, 2)))
+	assert.Equal(t, date(2026, 5, 8), calendar.AddWorkingDays(date(2026, 5, 1), 5))
+}
+
+func TestCitizenAppealDeadlineStatusAt(t *testing.T) {
+	today := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	due := func(value time.Time) *CitizenAppealDocument {
+		return &CitizenAppealDocument{ResponseDueDate: &value}
+	}
+	closedAt := today
+	replyID := uuid.New()
+
+	assert.Equal(t, "", CitizenAppealDeadlineStatusAt(nil, today, 5))
+	assert.Equal(t, CitizenAppealDeadlineOpen, CitizenAppealDeadlineStatusAt(&CitizenAppealDocument{}, today, 5))
+	assert.Equal(t, CitizenAppealDeadlineClosed, CitizenAppealDeadlineStatusAt(&CitizenAppealDocument{ResponseDueDate: &closedAt, ClosedAt: &closedAt, ClosedByDocumentID: &replyID}, today, 5))
+	stale := date(2026, 3, 9)
+	assert.Equal(t, CitizenAppealDeadlineOverdue, CitizenAppealDeadlineStatusAt(&CitizenAppealDocument{ResponseDueDate: &stale, ClosedAt: &closedAt}, today, 5), "closed_at без ответа не закрывает обращение")
+	assert.Equal(t, CitizenAppealDeadlineOverdue, CitizenAppealDeadlineStatusAt(due(date(2026, 3, 9)), today, 5))
+	assert.Equal(t, CitizenAppealDeadlineDueSoon, CitizenAppealDeadlineStatusAt(due(date(2026, 3, 10)), today, 5))
+	assert.Equal(t, CitizenAppealDeadlineDueSoon, CitizenAppealDeadlineStatusAt(due(date(2026, 3, 15)), today, 5))
+	assert.Equal(t, CitizenAppealDeadlineOpen, CitizenAppealDeadlineStatusAt(due(date(2026, 3, 16)), today, 5))
+
+	assert.True(t, IsValidCitizenAppealDeadlineStatus(CitizenAppealDeadlineDueSoon))
+	assert.False(t, IsValidCitizenAppealDeadlineStatus("late"))
+}
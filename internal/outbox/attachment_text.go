@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/repository"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/textextract"
+	"github.com/google/uuid"
+)
+
+// maxExtractionFileSize bounds the temporary copy made for text extraction.
+// Larger attachments stay downloadable but are not indexed.
+const maxExtractionFileSize = 100 << 20
+
+type FileDownloader interface {
+	DownloadFileToWriter(ctx context.Context, objectName string, writer io.Writer, maxSize int64) error
+}
+
+// SetAttachmentTexts enables the text extraction consumer. The storage passed
+// to NewWorker must also implement FileDownloader.
+func (w *Worker) SetAttachmentTexts(texts *repository.AttachmentTextRepository) { w.texts = texts }
+
+// extractAttachmentText stores the outcome of parsing one attachment. Only
+// infrastructure failures are returned for retry; a damaged or unsupported
+// file is a final result recorded in attachment_texts.
+func (w *Worker) extractAttachmentText(ctx context.Context, event models.OutboxEvent) error {
+	downloader, ok := w.storage.(FileDownloader)
+	if w.texts == nil || w.attachments == nil || !ok {
+		return fmt.Errorf("attachment text extraction consumer is not configured")
+	}
+	var payload models.AttachmentTextExtractPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return fmt.Errorf("invalid attachment_text_extract payload: %w", err)
+	}
+	if payload.AttachmentID == uuid.Nil {
+		return fmt.Errorf("invalid attachment_text_extract payload")
+	}
+
+	attachment, err := w.attachments.GetByID(payload.AttachmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The attachment was deleted before extraction; its row goes away with it.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	result := models.AttachmentText{AttachmentID: attachment.ID, DocumentID: attachment.DocumentID}
+	if !textextract.Supports(attachment.Filename) {
+		result.Status = models.AttachmentTextUnsupported
+		return w.texts.SaveResult(result)
+	}
+	if attachment.FileSize > maxExtractionFileSize {
+		return w.saveExtractionFailure(result, fmt.Errorf("file size %d exceeds text extraction limit %d", attachment.FileSize, maxExtractionFileSize))
+	}
+
+	file, err := os.CreateTemp("", "docflow-extract-*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if err := downloader.DownloadFileToWriter(ctx, attachment.StoragePath, file, attachment.FileSize); err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	extracted, err := textextract.Extract(file, info.Size(), attachment.Filename)
+	if err != nil {
+		return w.saveExtractionFailure(result, err)
+	}
+	result.Status = models.AttachmentTextExtracted
+	result.Content = extracted.Text
+	result.Truncated = extracted.Truncated
+	if err := w.texts.SaveResult(result); err != nil {
+		return err
+	}
+	if w.metrics != nil {
+		w.metrics.AddCounter("attachments.text_extract.bytes", float64(len(extracted.Text)))
+	}
+	return nil
+}
+
+func (w *Worker) saveExtractionFailure(result models.AttachmentText, cause error) error {
+	slog.Warn("attachment text extraction failed", "attachment_id", result.AttachmentID, "error", cause)
+	message := cause.Error()
+	result.Status = models.AttachmentTextFailed
+	result.Error = &message
+	if w.metrics != nil {
+		w.metrics.AddCounter("attachments.text_extract.failed", 1)
+	}
+	return w.texts.SaveResult(result)
+}
@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/repository"
+)
+
+type fileDownloaderStub struct {
+	fileDeleterStub
+	content []byte
+	err     error
+}
+
+func (s *fileDownloaderStub) DownloadFileToWriter(_ context.Context, objectName string, writer io.Writer, _ int64) error {
+	s.path = objectName
+	if s.err != nil {
+		return s.err
+	}
+	_, err := writer.Write(s.content)
+	return err
+}
+
+func docxFixture(t *testing.T, text string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	part, err := archive.Create("word/document.xml")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(`<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>` + text + `</w:t></w:r></w:p></w:body></w:document>`))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func setupTextExtractionWorker(t *testing.T, storage FileDeleter) (*Worker, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	wrapped := &database.DB{DB: db}
+	worker := NewWorker(repository.NewOutboxRepository(wrapped), repository.NewUserEventRepository(wrapped), repository.NewJournalRepository(wrapped), repository.NewAdminAuditLogRepository(wrapped), repository.NewAttachmentRepository(wrapped), storage)
+	worker.SetAttachmentTexts(repository.NewAttachmentTextRepository(wrapped))
+	return worker, mock
+}
+
+func textExtractEvent(attachmentID uuid.UUID) models.OutboxEvent {
+	return models.OutboxEvent{EventType: models.OutboxEventTextExtract, DeduplicationKey: "attachment:" + attachmentID.String() + ":text", Payload: `{"attachmentId":"` + attachmentID.String() + `"}`}
+}
+
+func expectAttachmentLookup(mock sqlmock.Sqlmock, attachmentID, documentID uuid.UUID, filename string, size int64) {
+	mock.ExpectQuery(`SELECT id, document_id, filename, storage_path, file_size, content_type, uploaded_by, uploaded_at,(.|\n)*FROM attachments WHERE id = \$1`).
+		WithArgs(attachmentID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "filename", "storage_path", "file_size", "content_type", "uploaded_by", "uploaded_at", "sha256", "integrity_status"}).
+			AddRow(attachmentID, documentID, filename, "objects/"+filename, size, "application/octet-stream", uuid.New(), time.Now(), "", "unverified"))
+}
+
+func TestWorkerExtractsAttachmentText(t *testing.T) {
+	content := docxFixture(t, "О ремонте кровли")
+	storage := &fileDownloaderStub{content: content}
+	worker, mock := setupTextExtractionWorker(t, storage)
+	attachmentID, documentID := uuid.New(), uuid.New()
+
+	expectAttachmentLookup(mock, attachmentID, documentID, "letter.docx", int64(len(content)))
+	mock.ExpectExec(`INSERT INTO attachment_texts`).
+		WithArgs(attachmentID, documentID, models.AttachmentTextExtracted, "О ремонте кровли", false, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.process(context.Background(), textExtractEvent(attachmentID)))
+	require.Equal(t, "objects/letter.docx", storage.path)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerRecordsUnsupportedAndDamagedAttachments(t *testing.T) {
+	storage := &fileDownloaderStub{content: []byte("not a document")}
+	worker, mock := setupTextExtractionWorker(t, storage)
+	scanID, brokenID, documentID := uuid.New(), uuid.New(), uuid.New()
+
+	expectAttachmentLookup(mock, scanID, documentID, "scan.jpg", 10)
+	mock.ExpectExec(`INSERT INTO attachment_texts`).
+		WithArgs(scanID, documentID, models.AttachmentTextUnsupported, "", false, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAttachmentLookup(mock, brokenID, documentID, "broken.pdf", 14)
+	mock.ExpectExec(`INSERT INTO attachment_texts`).
+		WithArgs(brokenID, documentID, models.AttachmentTextFailed, "", false, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.process(context.Background(), textExtractEvent(scanID)))
+	require.NoError(t, worker.process(context.Background(), textExtractEvent(brokenID)))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerTextExtractionSkipsDeletedAttachmentAndRetriesStorageErrors(t *testing.T) {
+	storage := &fileDownloaderStub{err: context.DeadlineExceeded}
+	worker, mock := setupTextExtractionWorker(t, storage)
+	deletedID, attachmentID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`FROM attachments WHERE id = \$1`).WithArgs(deletedID).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectAttachmentLookup(mock, attachmentID, uuid.New(), "letter.docx", 10)
+
+	require.NoError(t, worker.process(context.Background(), textExtractEvent(deletedID)))
+	require.ErrorIs(t, worker.process(context.Background(), textExtractEvent(attachmentID)), context.DeadlineExceeded)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerTextExtractionRequiresConfiguration(t *testing.T) {
+	worker := NewWorker(nil, nil, nil, nil, nil, &fileDeleterStub{})
+
+	require.ErrorContains(t, worker.process(context.Background(), textExtractEvent(uuid.New())), "not configured")
+}
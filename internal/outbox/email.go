@@ -0,0 +1,223 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mailer"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// emailDigestInterval is how often the digest scheduler checks whether the
+// daily digest hour has come. Digest keys are per user and day, so checking
+// more often than once a day is harmless.
+const emailDigestInterval = 15 * time.Minute
+
+// emailQueueTTL bounds how long an e-mail waits for a workstation with SMTP
+// settings. Older messages are stale notifications and are expired instead of
+// piling up in the shared queue.
+const emailQueueTTL = 48 * time.Hour
+
+// EmailSender delivers one message; implemented by mailer.SMTP.
+type EmailSender interface {
+	Send(ctx context.Context, message mailer.Message) error
+}
+
+// EmailRecipientStore reads e-mail addresses and notification modes; implemented
+// by repository.EmailNotificationRepository.
+type EmailRecipientStore interface {
+	GetRecipient(userID uuid.UUID) (*models.EmailRecipient, error)
+	GetDigestRecipients() ([]models.EmailRecipient, error)
+}
+
+// errEmailSenderNotConfigured is returned if an e-mail event reaches a worker
+// without SMTP settings. Such workers do not claim e-mail events, so this only
+// guards against a race; the event is retried by the regular backoff.
+var errEmailSenderNotConfigured = errors.New("e-mail sender is not configured on this workstation")
+
+// SetEmailRecipients enables queueing of e-mails for user events and daily
+// digests. Every workstation queues mail regardless of its own SMTP settings:
+// the outbox is shared, and the message is delivered by any worker that has a
+// sender.
+func (w *Worker) SetEmailRecipients(recipients EmailRecipientStore, digestHour int) {
+	w.mailRecipients = recipients
+	w.digestHour = digestHour
+}
+
+// SetEmailSender lets the worker deliver queued e-mail events. A worker
+// without a sender leaves them to other workstations.
+func (w *Worker) SetEmailSender(sender EmailSender) {
+	w.mail = sender
+}
+
+// enqueueUserEventEmail queues an e-mail copy of a delivered user event for a
+// recipient who asked for immediate e-mails. The key is derived from the user
+// event key, so a retried user event does not queue a second message.
+func (w *Worker) enqueueUserEventEmail(request models.CreateUserEventRequest, deduplicationKey string) error {
+	if w.mailRecipients == nil || deduplicationKey == "" {
+		return nil
+	}
+	recipient, err := w.mailRecipients.GetRecipient(request.RecipientUserID)
+	if err != nil {
+		return err
+	}
+	if recipient == nil || recipient.Mode != models.EmailNotificationsImmediate {
+		return nil
+	}
+	event, err := newEmailOutboxEvent(deduplicationKey+":email", models.EmailNotificationPayload{UserID: recipient.UserID, Event: &request})
+	if err != nil {
+		return err
+	}
+	_, err = w.outbox.EnqueueIfAbsent([]models.OutboxEvent{event})
+	return err
+}
+
+// deliverEmail sends one queued message. Address and mode are read again at
+// delivery time: a user who has switched e-mails off or to the digest since
+// the event was queued does not receive it. SMTP failures are returned, so the
+// event is retried with the regular outbox backoff.
+func (w *Worker) deliverEmail(ctx context.Context, event models.OutboxEvent) error {
+	if w.mail == nil || w.mailRecipients == nil {
+		return errEmailSenderNotConfigured
+	}
+	var payload models.EmailNotificationPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return fmt.Errorf("invalid email_notification payload: %w", err)
+	}
+	if payload.UserID == uuid.Nil || (payload.Event == nil && payload.DigestDate == "") {
+		return fmt.Errorf("invalid email_notification payload")
+	}
+	recipient, err := w.mailRecipients.GetRecipient(payload.UserID)
+	if err != nil {
+		return err
+	}
+	if recipient == nil {
+		return nil
+	}
+
+	var message mailer.Message
+	if payload.Event != nil {
+		if recipient.Mode != models.EmailNotificationsImmediate {
+			return nil
+		}
+		message, err = mailer.RenderUserEvent(recipient.FullName, *payload.Event)
+	} else {
+		if recipient.Mode != models.EmailNotificationsDigest {
+			return nil
+		}
+		date, parseErr := time.ParseInLocation("2006-01-02", payload.DigestDate, time.Local)
+		if parseErr != nil {
+			return fmt.Errorf("invalid email_notification digest date: %w", parseErr)
+		}
+		events, eventsErr := w.events.GetCreatedBetween(recipient.UserID, date, date.AddDate(0, 0, 1))
+		if eventsErr != nil {
+			return eventsErr
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		message, err = mailer.RenderDigest(recipient.FullName, date, events)
+	}
+	if err != nil {
+		return err
+	}
+	message.To = recipient.Email
+	if err := w.mail.Send(ctx, message); err != nil {
+		if w.metrics != nil {
+			w.metrics.AddCounter("outbox.email.failed", 1)
+		}
+		return err
+	}
+	if w.metrics != nil {
+		w.metrics.AddCounter("outbox.email.sent", 1)
+	}
+	return nil
+}
+
+// RunEmailDigests queues the daily digest of the previous day once the digest
+// hour has passed and expires e-mails older than emailQueueTTL. The method
+// blocks until ctx is cancelled.
+func (w *Worker) RunEmailDigests(ctx context.Context) {
+	ticker := time.NewTicker(emailDigestInterval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if err := w.expireEmails(now); err != nil && ctx.Err() == nil {
+			slog.Warn("e-mail expiry failed", "error", err)
+		}
+		if _, err := w.scheduleEmailDigests(now); err != nil && ctx.Err() == nil {
+			slog.Warn("e-mail digest scheduling failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduleEmailDigests queues one digest per digest recipient for the day
+// before now. Keys are per user and day, so repeated runs and restarts queue
+// each digest once.
+func (w *Worker) scheduleEmailDigests(now time.Time) (int, error) {
+	if w.mailRecipients == nil || now.Hour() < w.digestHour {
+		return 0, nil
+	}
+	recipients, err := w.mailRecipients.GetDigestRecipients()
+	if err != nil {
+		return 0, err
+	}
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -1).Format("2006-01-02")
+	events := make([]models.OutboxEvent, 0, len(recipients))
+	for _, recipient := range recipients {
+		event, err := newEmailOutboxEvent("email-digest:"+recipient.UserID.String()+":"+date, models.EmailNotificationPayload{UserID: recipient.UserID, DigestDate: date})
+		if err != nil {
+			return 0, err
+		}
+		events = append(events, event)
+	}
+	return w.outbox.EnqueueIfAbsent(events)
+}
+
+// expireEmails closes e-mails that no worker has delivered within
+// emailQueueTTL, for example because no workstation has SMTP settings.
+func (w *Worker) expireEmails(now time.Time) error {
+	if w.mailRecipients == nil {
+		return nil
+	}
+	expired, err := w.outbox.ExpirePending(models.OutboxEventEmail, now.Add(-emailQueueTTL))
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		slog.Warn("undelivered e-mails expired", "count", expired, "ttl", emailQueueTTL)
+		if w.metrics != nil {
+			w.metrics.AddCounter("outbox.email.expired", float64(expired))
+		}
+	}
+	return nil
+}
+
+func newEmailOutboxEvent(key string, payload models.EmailNotificationPayload) (models.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.OutboxEvent{}, err
+	}
+	return models.OutboxEvent{EventType: models.OutboxEventEmail, DeduplicationKey: key, Payload: string(data)}, nil
+}
+
+// unclaimedEventTypes lists event types this worker cannot deliver, so that
+// ClaimPending leaves them to workers on other workstations and the queue
+// alert does not count them.
+func (w *Worker) unclaimedEventTypes() []string {
+	if w.mail == nil {
+		return []string{models.OutboxEventEmail}
+	}
+	return nil
+}
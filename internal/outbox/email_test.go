@@ -0,0 +1,286 @@
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mailer"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/repository"
+)
+
+type emailSenderStub struct {
+	messages []mailer.Message
+	err      error
+}
+
+func (s *emailSenderStub) Send(_ context.Context, message mailer.Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+var outboxColumns = []string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}
+
+var recipientColumns = []string{"id", "full_name", "email", "email_notifications"}
+
+// newEmailWorker creates a worker that queues e-mails; a nil sender models a
+// workstation without SMTP settings.
+func newEmailWorker(t *testing.T, sender EmailSender) (*Worker, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	wrapped := &database.DB{DB: db}
+	worker := NewWorker(repository.NewOutboxRepository(wrapped), repository.NewUserEventRepository(wrapped), repository.NewJournalRepository(wrapped), repository.NewAdminAuditLogRepository(wrapped), nil, nil)
+	worker.SetEmailRecipients(repository.NewEmailNotificationRepository(wrapped), 8)
+	if sender != nil {
+		worker.SetEmailSender(sender)
+	}
+	return worker, mock
+}
+
+func expectClaim(mock sqlmock.Sqlmock, id uuid.UUID, eventType, key, payload string) {
+	expectClaimSkipping(mock, []string{}, id, eventType, key, payload)
+}
+
+// expectClaimSkipping expects a claim that leaves skipTypes to other workers.
+func expectClaimSkipping(mock sqlmock.Sqlmock, skipTypes []string, id uuid.UUID, eventType, key, payload string) {
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50, pq.Array(skipTypes)).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).AddRow(id, eventType, key, payload, now, now, nil, nil, 1, nil, now))
+	mock.ExpectCommit()
+}
+
+func emailPayload(t *testing.T, payload models.EmailNotificationPayload) string {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestWorkerQueuesEmailForImmediateRecipient(t *testing.T) {
+	worker, mock := newEmailWorker(t, &emailSenderStub{})
+	id, userID := uuid.New(), uuid.New()
+	request := models.CreateUserEventRequest{RecipientUserID: userID, EventType: models.UserEventAssignmentCreated, Title: "Новое поручение"}
+	data, err := json.Marshal(userEventPayload{Request: request})
+	require.NoError(t, err)
+
+	expectClaim(mock, id, models.OutboxEventUserEvent, "assignment:1:created:user_event", string(data))
+	mock.ExpectQuery(`INSERT INTO user_events`).WillReturnError(errors.New("sql: no rows in result set"))
+	mock.ExpectExec(`UPDATE event_outbox.*failed_at = CASE`).WithArgs(id, 1, 10, 1.0, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, worker.ProcessOnce(), "ошибка события не должна ставить письмо")
+
+	expectClaim(mock, id, models.OutboxEventUserEvent, "assignment:1:created:user_event", string(data))
+	mock.ExpectQuery(`INSERT INTO user_events`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(recipientColumns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsImmediate))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO event_outbox(.*)ON CONFLICT \(deduplication_key\) DO NOTHING`).
+		WithArgs(models.OutboxEventEmail, "assignment:1:created:user_event:email", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`UPDATE event_outbox SET processed_at = CURRENT_TIMESTAMP`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.ProcessOnce())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerDoesNotQueueEmailForDigestRecipient(t *testing.T) {
+	worker, mock := newEmailWorker(t, &emailSenderStub{})
+	id, userID := uuid.New(), uuid.New()
+	data, err := json.Marshal(userEventPayload{Request: models.CreateUserEventRequest{RecipientUserID: userID}})
+	require.NoError(t, err)
+
+	expectClaim(mock, id, models.OutboxEventUserEvent, "event-key", string(data))
+	mock.ExpectQuery(`INSERT INTO user_events`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(recipientColumns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsDigest))
+	mock.ExpectExec(`UPDATE event_outbox SET processed_at = CURRENT_TIMESTAMP`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.ProcessOnce())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerSendsEventEmail(t *testing.T) {
+	sender := &emailSenderStub{}
+	worker, mock := newEmailWorker(t, sender)
+	id, userID := uuid.New(), uuid.New()
+	payload := emailPayload(t, models.EmailNotificationPayload{UserID: userID, Event: &models.CreateUserEventRequest{
+		RecipientUserID: userID,
+		DocumentKind:    "incoming_letter",
+		DocumentNumber:  "ВХ-1",
+		EventType:       models.UserEventAssignmentCreated,
+		Title:           "Новое поручение",
+		Message:         "Подготовить ответ",
+	}})
+
+	expectClaim(mock, id, models.OutboxEventEmail, "event-key:email", payload)
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(recipientColumns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsImmediate))
+	mock.ExpectExec(`UPDATE event_outbox SET processed_at = CURRENT_TIMESTAMP`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.ProcessOnce())
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "petrov@example.local", sender.messages[0].To)
+	assert.Equal(t, "Новое поручение по документу № ВХ-1", sender.messages[0].Subject)
+	assert.Contains(t, sender.messages[0].Text, "Подготовить ответ")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerRetriesEmailAfterSMTPFailure(t *testing.T) {
+	worker, mock := newEmailWorker(t, &emailSenderStub{err: errors.New("451 try again later")})
+	id, userID := uuid.New(), uuid.New()
+	payload := emailPayload(t, models.EmailNotificationPayload{UserID: userID, Event: &models.CreateUserEventRequest{Title: "Событие"}})
+
+	expectClaim(mock, id, models.OutboxEventEmail, "event-key:email", payload)
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(recipientColumns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsImmediate))
+	mock.ExpectExec(`UPDATE event_outbox.*failed_at = CASE`).WithArgs(id, 1, 10, retryDelay(1).Seconds(), "451 try again later").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.ProcessOnce())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerSkipsEmailWhenRecipientSwitchedOff(t *testing.T) {
+	sender := &emailSenderStub{}
+	worker, mock := newEmailWorker(t, sender)
+	id, userID := uuid.New(), uuid.New()
+	payload := emailPayload(t, models.EmailNotificationPayload{UserID: userID, Event: &models.CreateUserEventRequest{Title: "Событие"}})
+
+	expectClaim(mock, id, models.OutboxEventEmail, "event-key:email", payload)
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).WillReturnRows(sqlmock.NewRows(recipientColumns))
+	mock.ExpectExec(`UPDATE event_outbox SET processed_at = CURRENT_TIMESTAMP`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.ProcessOnce())
+	assert.Empty(t, sender.messages)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerWithoutSenderQueuesEmailForWorkerWithSender(t *testing.T) {
+	userID := uuid.New()
+	request := models.CreateUserEventRequest{RecipientUserID: userID, EventType: models.UserEventAssignmentCreated, Title: "Новое поручение"}
+	data, err := json.Marshal(userEventPayload{Request: request})
+	require.NoError(t, err)
+
+	// Рабочее место без SMTP доставляет событие и ставит письмо в очередь,
+	// но само письма не забирает.
+	unconfigured, mock := newEmailWorker(t, nil)
+	eventID := uuid.New()
+	expectClaimSkipping(mock, []string{models.OutboxEventEmail}, eventID, models.OutboxEventUserEvent, "event-key", string(data))
+	mock.ExpectQuery(`INSERT INTO user_events`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(recipientColumns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsImmediate))
+	var queued string
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO event_outbox(.*)ON CONFLICT \(deduplication_key\) DO NOTHING`).
+		WithArgs(models.OutboxEventEmail, "event-key:email", payloadCapture{&queued}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`UPDATE event_outbox SET processed_at = CURRENT_TIMESTAMP`).WithArgs(eventID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, unconfigured.ProcessOnce())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// Рабочее место с SMTP забирает письмо и отправляет его.
+	sender := &emailSenderStub{}
+	configured, mock := newEmailWorker(t, sender)
+	emailID := uuid.New()
+	expectClaim(mock, emailID, models.OutboxEventEmail, "event-key:email", queued)
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(recipientColumns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsImmediate))
+	mock.ExpectExec(`UPDATE event_outbox SET processed_at = CURRENT_TIMESTAMP`).WithArgs(emailID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, configured.ProcessOnce())
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "petrov@example.local", sender.messages[0].To)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerWithoutSenderRetriesClaimedEmail(t *testing.T) {
+	worker, mock := newEmailWorker(t, nil)
+	id := uuid.New()
+	payload := emailPayload(t, models.EmailNotificationPayload{UserID: uuid.New(), DigestDate: "2026-07-16"})
+
+	expectClaimSkipping(mock, []string{models.OutboxEventEmail}, id, models.OutboxEventEmail, "email-digest:key", payload)
+	mock.ExpectExec(`UPDATE event_outbox.*failed_at = CASE`).WithArgs(id, 1, 10, retryDelay(1).Seconds(), errEmailSenderNotConfigured.Error()).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.ProcessOnce())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// payloadCapture принимает любой payload и запоминает его для следующего шага теста.
+type payloadCapture struct{ value *string }
+
+func (c payloadCapture) Match(value driver.Value) bool {
+	payload, ok := value.(string)
+	*c.value = payload
+	return ok
+}
+
+func TestWorkerSendsDailyDigest(t *testing.T) {
+	sender := &emailSenderStub{}
+	worker, mock := newEmailWorker(t, sender)
+	id, userID := uuid.New(), uuid.New()
+	from := time.Date(2026, 7, 16, 0, 0, 0, 0, time.Local)
+	payload := emailPayload(t, models.EmailNotificationPayload{UserID: userID, DigestDate: "2026-07-16"})
+
+	expectClaim(mock, id, models.OutboxEventEmail, "email-digest:key", payload)
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(recipientColumns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsDigest))
+	mock.ExpectQuery(`FROM user_events e(.*)e.created_at >= \$2 AND e.created_at < \$3`).WithArgs(userID, from, from.AddDate(0, 0, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient_user_id", "actor_user_id", "actor_name", "document_id", "document_kind", "document_number", "entity_type", "entity_id", "event_type", "title", "message", "metadata", "created_at", "read_at"}).
+			AddRow(uuid.New(), userID, nil, nil, uuid.New(), "incoming_letter", "ВХ-1", models.UserEventEntityAssignment, uuid.New(), models.UserEventAssignmentCreated, "Новое поручение", "Подготовить ответ", `{}`, from.Add(10*time.Hour), nil))
+	mock.ExpectExec(`UPDATE event_outbox SET processed_at = CURRENT_TIMESTAMP`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, worker.ProcessOnce())
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "Сводка событий за 16.07.2026: 1", sender.messages[0].Subject)
+	assert.Contains(t, sender.messages[0].Text, "10:00 Новое поручение")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerScheduleEmailDigests(t *testing.T) {
+	worker, mock := newEmailWorker(t, &emailSenderStub{})
+	userID := uuid.New()
+
+	added, err := worker.scheduleEmailDigests(time.Date(2026, 7, 17, 7, 59, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Zero(t, added)
+
+	mock.ExpectQuery(`FROM users(.*)WHERE email_notifications = 'digest'`).
+		WillReturnRows(sqlmock.NewRows(recipientColumns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsDigest))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO event_outbox(.*)ON CONFLICT \(deduplication_key\) DO NOTHING`).
+		WithArgs(models.OutboxEventEmail, "email-digest:"+userID.String()+":2026-07-16", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	added, err = worker.scheduleEmailDigests(time.Date(2026, 7, 17, 8, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkerExpiresUndeliveredEmails(t *testing.T) {
+	worker, mock := newEmailWorker(t, nil)
+	now := time.Date(2026, 7, 17, 9, 0, 0, 0, time.Local)
+
+	mock.ExpectExec(`UPDATE event_outbox\s+SET processed_at = CURRENT_TIMESTAMP, last_error = 'expired before delivery'`).
+		WithArgs(models.OutboxEventEmail, now.Add(-emailQueueTTL)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, worker.expireEmails(now))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -27,9 +27,13 @@ type Worker struct {
 	journal           *repository.JournalRepository
 	audit             *repository.AdminAuditLogRepository
 	attachments       *repository.AttachmentRepository
+	texts             *repository.AttachmentTextRepository
 	storage           FileDeleter
 	lastRequiredAudit models.RequiredAuditStats
 	metrics           *observability.Registry
+	mail              EmailSender
+	mailRecipients    EmailRecipientStore
+	digestHour        int
 }
 
 const (
@@ -70,7 +74,7 @@ func (w *Worker) Run(ctx context.Context) {
 }
 
 func (w *Worker) observeQueue() {
-	stats, err := w.outbox.Stats()
+	stats, err := w.outbox.Stats(w.unclaimedEventTypes()...)
 	if err != nil {
 		slog.Warn("failed to read outbox queue state", "error", err)
 		return
@@ -115,7 +119,7 @@ func (w *Worker) ProcessOnce() error {
 // ProcessOnceContext delivers one claimed batch while propagating shutdown
 // and per-consumer deadlines to operations that support a context.
 func (w *Worker) ProcessOnceContext(ctx context.Context) error {
-	events, err := w.outbox.ClaimPending(50)
+	events, err := w.outbox.ClaimPending(50, w.unclaimedEventTypes()...)
 	if err != nil {
 		return err
 	}
@@ -160,7 +164,10 @@ func (w *Worker) process(parent context.Context, event models.OutboxEvent) error
 		if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
 			return fmt.Errorf("invalid user_event payload: %w", err)
 		}
-		return w.events.CreateFromOutbox(payload.Request, event.DeduplicationKey)
+		if err := w.events.CreateFromOutbox(payload.Request, event.DeduplicationKey); err != nil {
+			return err
+		}
+		return w.enqueueUserEventEmail(payload.Request, event.DeduplicationKey)
 	case models.OutboxEventJournal:
 		var payload models.CreateJournalEntryRequest
 		if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
@@ -190,6 +197,10 @@ func (w *Worker) process(parent context.Context, event models.OutboxEvent) error
 			return err
 		}
 		return w.attachments.DeleteMarkedAndDecrementStorageStatistics(payload.AttachmentID)
+	case models.OutboxEventTextExtract:
+		return w.extractAttachmentText(ctx, event)
+	case models.OutboxEventEmail:
+		return w.deliverEmail(ctx, event)
 	default:
 		return fmt.Errorf("unsupported outbox event type %q", event.EventType)
 	}
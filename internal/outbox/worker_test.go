@@ -8,6 +8,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
@@ -15,6 +16,9 @@ import (
 	"github.com/Volkov-D-A/docs-register-and-track/internal/repository"
 )
 
+// noSenderClaimSkip is the claim filter of a worker without an e-mail sender.
+var noSenderClaimSkip = pq.Array([]string{models.OutboxEventEmail})
+
 type fileDeleterStub struct {
 	path string
 	err  error
@@ -35,7 +39,7 @@ func TestWorkerProcessOnceMarksAlreadyDeliveredUserEvent(t *testing.T) {
 	worker := NewWorker(repository.NewOutboxRepository(wrapped), repository.NewUserEventRepository(wrapped), repository.NewJournalRepository(wrapped), repository.NewAdminAuditLogRepository(wrapped), nil, nil)
 	id, now := uuid.New(), time.Now()
 	mock.ExpectBegin()
-	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50).
+	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50, noSenderClaimSkip).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}).
 			AddRow(id, models.OutboxEventUserEvent, "event-key", `{"request":{}}`, now, now, nil, nil, 1, nil, now))
 	mock.ExpectCommit()
@@ -54,7 +58,7 @@ func TestWorkerProcessOnceSchedulesRetryForUnsupportedEvent(t *testing.T) {
 	worker := NewWorker(repository.NewOutboxRepository(wrapped), repository.NewUserEventRepository(wrapped), repository.NewJournalRepository(wrapped), repository.NewAdminAuditLogRepository(wrapped), nil, nil)
 	id, now := uuid.New(), time.Now()
 	mock.ExpectBegin()
-	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50).
+	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50, noSenderClaimSkip).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}).
 			AddRow(id, "unknown", "event-key", `{}`, now, now, nil, nil, 1, nil, now))
 	mock.ExpectCommit()
@@ -72,7 +76,7 @@ func TestWorkerProcessOnceDeliversAdministrativeAudit(t *testing.T) {
 	worker := NewWorker(repository.NewOutboxRepository(wrapped), repository.NewUserEventRepository(wrapped), repository.NewJournalRepository(wrapped), repository.NewAdminAuditLogRepository(wrapped), nil, nil)
 	id, userID, now := uuid.New(), uuid.New(), time.Now()
 	mock.ExpectBegin()
-	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50).
+	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50, noSenderClaimSkip).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}).
 			AddRow(id, models.OutboxEventAudit, "audit-key", `{"UserID":"`+userID.String()+`","UserName":"Admin","Action":"SETTINGS_UPDATE","Details":"changed"}`, now, now, nil, nil, 1, nil, now))
 	mock.ExpectCommit()
@@ -93,7 +97,7 @@ func TestWorkerProcessOnceDeletesAttachmentObjectAndMarkedRow(t *testing.T) {
 	eventID, attachmentID, now := uuid.New(), uuid.New(), time.Now()
 	payload := `{"attachmentId":"` + attachmentID.String() + `","storagePath":"attachments/report.pdf"}`
 	mock.ExpectBegin()
-	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50).
+	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50, noSenderClaimSkip).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}).
 			AddRow(eventID, models.OutboxEventFileDelete, "attachment-key", payload, now, now, nil, nil, 1, nil, now))
 	mock.ExpectCommit()
@@ -121,7 +125,7 @@ func TestWorkerProcessOnceRetriesAttachmentDeletionAfterStorageFailure(t *testin
 	eventID, attachmentID, now := uuid.New(), uuid.New(), time.Now()
 	payload := `{"attachmentId":"` + attachmentID.String() + `","storagePath":"attachments/retry.pdf"}`
 	mock.ExpectBegin()
-	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50).
+	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50, noSenderClaimSkip).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}).
 			AddRow(eventID, models.OutboxEventFileDelete, "attachment-key", payload, now, now, nil, nil, 1, nil, now))
 	mock.ExpectCommit()
@@ -140,11 +144,11 @@ func TestWorkerRunReleasesStaleClaimsAndStopsOnContextCancellation(t *testing.T)
 	worker := NewWorker(repository.NewOutboxRepository(wrapped), repository.NewUserEventRepository(wrapped), repository.NewJournalRepository(wrapped), repository.NewAdminAuditLogRepository(wrapped), nil, nil)
 	mock.ExpectExec(`UPDATE event_outbox SET processing_started_at = NULL`).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectBegin()
-	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50).WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}))
+	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50, noSenderClaimSkip).WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}))
 	mock.ExpectCommit()
 	mock.ExpectQuery(`FROM event_outbox\s+WHERE event_type IN`).WithArgs(models.OutboxEventJournal, models.OutboxEventAudit).
 		WillReturnRows(sqlmock.NewRows([]string{"pending", "processing", "failed"}).AddRow(0, 0, 0))
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\) FILTER`).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\) FILTER`).WithArgs(noSenderClaimSkip).
 		WillReturnRows(sqlmock.NewRows([]string{"pending", "processing", "failed", "processed"}).AddRow(0, 0, 0, 0))
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -162,7 +166,7 @@ func TestTwoWorkersClaimIndependently(t *testing.T) {
 	}
 	for range 2 {
 		mock.ExpectBegin()
-		mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50).
+		mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).WithArgs(50, noSenderClaimSkip).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}))
 		mock.ExpectCommit()
 	}
@@ -0,0 +1,120 @@
+package printforms
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const sheetLineHeight = 5.0
+
+// sheetColumns — колонки листа ознакомления; сумма ширин равна ширине
+// области печати листа A4 с полями карточки.
+var sheetColumns = []struct {
+	title string
+	width float64
+}{
+	{"№", 8},
+	{"ФИО", 52},
+	{"Просмотрен", 24},
+	{"Ознакомлен", 24},
+	{"Ознакомление отметил", 42},
+	{"Подпись", 30},
+}
+
+// drawAcknowledgmentSheet размещает лист ознакомления на листе A4: по таблице
+// на каждое ознакомление с отметками о просмотре, подтверждении и о том, кто
+// внес отметку за ознакомляемого. Колонка «Подпись» оставлена для бумажного
+// оригинала.
+func drawAcknowledgmentSheet(pdf *fpdf.Fpdf, form RegistrationForm) {
+	pdf.SetMargins(cardMargin, cardMargin, cardMargin)
+	pdf.SetAutoPageBreak(true, cardMargin)
+	pdf.AddPage()
+	width, _ := pdf.GetPageSize()
+	contentWidth := width - 2*cardMargin
+
+	if form.OrganizationName != "" {
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.MultiCell(contentWidth, 5, form.OrganizationName, "", "C", false)
+		pdf.Ln(2)
+	}
+	pdf.SetFont(fontFamily, "B", 14)
+	pdf.MultiCell(contentWidth, 7, "ЛИСТ ОЗНАКОМЛЕНИЯ", "", "C", false)
+	pdf.SetFont(fontFamily, "", 11)
+	document := form.KindName
+	if form.RegistrationNumber != "" {
+		document += " № " + form.RegistrationNumber
+	}
+	if date := formatDate(form.RegistrationDate); date != "" {
+		document += " от " + date
+	}
+	pdf.MultiCell(contentWidth, 6, document, "", "C", false)
+	pdf.Ln(2)
+
+	for _, list := range form.Acknowledgments {
+		pdf.Ln(3)
+		if list.Title != "" {
+			pdf.SetFont(fontFamily, "B", 11)
+			pdf.MultiCell(contentWidth, cardLineHeight, list.Title, "", "L", false)
+		}
+		if list.Deadline != nil {
+			pdf.SetFont(fontFamily, "", 10)
+			pdf.MultiCell(contentWidth, cardLineHeight, "Срок ознакомления: "+formatDate(*list.Deadline), "", "L", false)
+		}
+		drawSheetHeader(pdf)
+		for i, entry := range list.Entries {
+			drawSheetRow(pdf, "", []string{strconv.Itoa(i + 1), entry.FullName, formatSheetTime(entry.ViewedAt), formatSheetTime(entry.ConfirmedAt), entry.ActedBy, ""})
+		}
+	}
+
+	if !form.PrintedAt.IsZero() {
+		pdf.Ln(4)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.MultiCell(contentWidth, 4, "Сформировано "+form.PrintedAt.Format("02.01.2006 15:04"), "", "R", false)
+	}
+}
+
+func drawSheetHeader(pdf *fpdf.Fpdf) {
+	titles := make([]string, len(sheetColumns))
+	for i, column := range sheetColumns {
+		titles[i] = column.title
+	}
+	drawSheetRow(pdf, "B", titles)
+}
+
+// drawSheetRow выводит строку таблицы с рамками одинаковой высоты; строка
+// целиком переносится на следующую страницу, если не помещается.
+func drawSheetRow(pdf *fpdf.Fpdf, style string, values []string) {
+	pdf.SetFont(fontFamily, style, 9)
+	lines := 1
+	for i, value := range values {
+		if n := len(pdf.SplitText(value, sheetColumns[i].width-2)); n > lines {
+			lines = n
+		}
+	}
+	height := float64(lines) * sheetLineHeight
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	x, y := pdf.GetXY()
+	offset := x
+	for i, value := range values {
+		pdf.SetXY(offset, y)
+		pdf.Rect(offset, y, sheetColumns[i].width, height, "D")
+		pdf.MultiCell(sheetColumns[i].width, sheetLineHeight, value, "", "L", false)
+		offset += sheetColumns[i].width
+	}
+	pdf.SetXY(x, y+height)
+}
+
+func formatSheetTime(value *time.Time) string {
+	if value == nil || value.IsZero() {
+		return ""
+	}
+	return value.Format("02.01.2006\n15:04")
+}
@@ -0,0 +1,97 @@
+package printforms
+
+import (
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	cardMargin     = 15.0
+	cardLabelWidth = 60.0
+	cardLineHeight = 6.0
+)
+
+// drawRegistrationCard размещает регистрационно-контрольную карточку на листе A4.
+func drawRegistrationCard(pdf *fpdf.Fpdf, form RegistrationForm) {
+	pdf.SetMargins(cardMargin, cardMargin, cardMargin)
+	pdf.SetAutoPageBreak(true, cardMargin)
+	pdf.AddPage()
+	width, _ := pdf.GetPageSize()
+	contentWidth := width - 2*cardMargin
+
+	if form.OrganizationName != "" {
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.MultiCell(contentWidth, 5, form.OrganizationName, "", "C", false)
+		pdf.Ln(2)
+	}
+	pdf.SetFont(fontFamily, "B", 14)
+	pdf.MultiCell(contentWidth, 7, "РЕГИСТРАЦИОННО-КОНТРОЛЬНАЯ КАРТОЧКА", "", "C", false)
+	if form.KindName != "" {
+		pdf.SetFont(fontFamily, "", 11)
+		pdf.MultiCell(contentWidth, 6, form.KindName, "", "C", false)
+	}
+	pdf.Ln(4)
+
+	registration := []Field{
+		{Label: "Регистрационный номер", Value: form.RegistrationNumber},
+		{Label: "Дата регистрации", Value: formatDate(form.RegistrationDate)},
+		{Label: "Индекс дела", Value: form.NomenclatureIndex},
+		{Label: "Наименование дела", Value: form.NomenclatureName},
+	}
+	drawCardFields(pdf, contentWidth, registration)
+
+	for _, section := range form.Sections {
+		if len(section.Fields) == 0 {
+			continue
+		}
+		pdf.Ln(3)
+		if section.Title != "" {
+			pdf.SetFont(fontFamily, "B", 11)
+			pdf.MultiCell(contentWidth, cardLineHeight, section.Title, "", "L", false)
+		}
+		drawCardFields(pdf, contentWidth, section.Fields)
+	}
+
+	if !form.PrintedAt.IsZero() {
+		pdf.Ln(4)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.MultiCell(contentWidth, 4, "Сформировано "+form.PrintedAt.Format("02.01.2006 15:04"), "", "R", false)
+	}
+}
+
+// drawCardFields выводит строки карточки в две колонки с рамкой. Высота строки
+// определяется самым длинным из значений, чтобы рамки колонок совпадали.
+func drawCardFields(pdf *fpdf.Fpdf, contentWidth float64, fields []Field) {
+	valueWidth := contentWidth - cardLabelWidth
+	for _, field := range fields {
+		pdf.SetFont(fontFamily, "", 10)
+		value := strings.TrimRight(field.Value, "\r\n")
+		lines := len(pdf.SplitText(value, valueWidth-2))
+		if labelLines := len(pdf.SplitText(field.Label, cardLabelWidth-2)); labelLines > lines {
+			lines = labelLines
+		}
+		if lines == 0 {
+			lines = 1
+		}
+		height := float64(lines) * cardLineHeight
+
+		_, pageHeight := pdf.GetPageSize()
+		_, _, _, bottom := pdf.GetMargins()
+		if pdf.GetY()+height > pageHeight-bottom {
+			pdf.AddPage()
+		}
+
+		x, y := pdf.GetXY()
+		pdf.SetFont(fontFamily, "B", 10)
+		pdf.Rect(x, y, cardLabelWidth, height, "D")
+		pdf.MultiCell(cardLabelWidth, cardLineHeight, field.Label, "", "L", false)
+
+		pdf.SetXY(x+cardLabelWidth, y)
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.Rect(x+cardLabelWidth, y, valueWidth, height, "D")
+		pdf.MultiCell(valueWidth, cardLineHeight, value, "", "L", false)
+
+		pdf.SetXY(x, y+height)
+	}
+}
@@ -0,0 +1,94 @@
+// Package printforms формирует печатные формы документов в PDF:
+// регистрационно-контрольные карточки, регистрационные штампы и листы ознакомления.
+package printforms
+
+import (
+	_ "embed"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const fontFamily = "DejaVu"
+
+// Шрифты DejaVu встраиваются в бинарник, чтобы кириллица печаталась
+// одинаково на всех рабочих местах независимо от установленных шрифтов ОС.
+var (
+	//go:embed fonts/DejaVuSans.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSans-Bold.ttf
+	fontBold []byte
+)
+
+// Field описывает строку печатной формы «наименование — значение».
+type Field struct {
+	Label string
+	Value string
+}
+
+// Section группирует строки печатной формы под общим заголовком.
+type Section struct {
+	Title  string
+	Fields []Field
+}
+
+// AcknowledgmentEntry описывает строку листа ознакомления. ActedBy заполняется,
+// если отметку об ознакомлении за пользователя внес другой сотрудник.
+type AcknowledgmentEntry struct {
+	FullName    string
+	ViewedAt    *time.Time
+	ConfirmedAt *time.Time
+	ActedBy     string
+}
+
+// AcknowledgmentList — список лиц, ознакамливаемых с документом.
+type AcknowledgmentList struct {
+	Title    string
+	Deadline *time.Time
+	Entries  []AcknowledgmentEntry
+}
+
+// RegistrationForm содержит регистрационные данные документа для печати.
+type RegistrationForm struct {
+	OrganizationName   string
+	KindName           string
+	RegistrationNumber string
+	RegistrationDate   time.Time
+	NomenclatureIndex  string
+	NomenclatureName   string
+	Sections           []Section
+	Acknowledgments    []AcknowledgmentList
+	PrintedAt          time.Time
+}
+
+// newDocument создает PDF-документ в миллиметрах со встроенными шрифтами.
+func newDocument(size fpdf.SizeType) *fpdf.Fpdf {
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           size,
+	})
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+	pdf.SetFont(fontFamily, "", 10)
+	return pdf
+}
+
+// output записывает документ в w, сохраняя ошибки, накопленные при разметке.
+func output(pdf *fpdf.Fpdf, w io.Writer, printedAt time.Time) error {
+	if !printedAt.IsZero() {
+		pdf.SetCreationDate(printedAt)
+	}
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+func formatDate(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.Format("02.01.2006")
+}
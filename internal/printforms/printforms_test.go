@@ -0,0 +1,121 @@
+package printforms
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testForm() RegistrationForm {
+	deadline := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	viewedAt := time.Date(2026, 3, 5, 9, 15, 0, 0, time.UTC)
+	confirmedAt := time.Date(2026, 3, 5, 11, 40, 0, 0, time.UTC)
+	return RegistrationForm{
+		OrganizationName:   "ГБУ «Очень длинное наименование учреждения для проверки переноса строк»",
+		KindName:           "Входящее письмо",
+		RegistrationNumber: "01-12/345",
+		RegistrationDate:   time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+		NomenclatureIndex:  "01-12",
+		NomenclatureName:   "Переписка с организациями",
+		Sections: []Section{
+			{Title: "Корреспондент", Fields: []Field{
+				{Label: "Организация", Value: "ООО «Ромашка»"},
+				{Label: "Содержание", Value: strings.Repeat("Длинный текст содержания документа. ", 30)},
+			}},
+			{Title: "Пустой раздел"},
+		},
+		Acknowledgments: []AcknowledgmentList{
+			{Title: "Ознакомление: довести до сведения отдела", Deadline: &deadline, Entries: []AcknowledgmentEntry{
+				{FullName: "Иванов Иван Иванович", ViewedAt: &viewedAt, ConfirmedAt: &confirmedAt},
+				{FullName: "Петров Петр Петрович", ViewedAt: &viewedAt, ConfirmedAt: &confirmedAt, ActedBy: "Сидорова Анна Сергеевна"},
+				{FullName: "Кузнецов Олег Викторович"},
+			}},
+			{Title: "Лист ознакомления с приказом", Entries: []AcknowledgmentEntry{
+				{FullName: "Смирнова Мария Андреевна", ConfirmedAt: &confirmedAt, ActedBy: "Делопроизводитель"},
+			}},
+		},
+		PrintedAt: time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	codes := func(items []Template) []string {
+		result := make([]string, 0, len(items))
+		for _, item := range items {
+			result = append(result, item.Code)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{TemplateRegistrationCard, TemplateRegistrationStamp, TemplateRegistrationStampLabel, TemplateAcknowledgmentSheet}, codes(Templates("incoming_letter")))
+	assert.Equal(t, []string{TemplateRegistrationCard, TemplateAcknowledgmentSheet}, codes(Templates("administrative_order")))
+
+	template, ok := Lookup(TemplateRegistrationStamp)
+	require.True(t, ok)
+	assert.True(t, template.Supports("citizen_appeal"))
+	assert.False(t, template.Supports("administrative_order"))
+
+	sheet, ok := Lookup(TemplateAcknowledgmentSheet)
+	require.True(t, ok)
+	assert.True(t, sheet.Supports("administrative_order"))
+	assert.False(t, sheet.Supports("unknown_kind"))
+
+	_, ok = Lookup("unknown")
+	assert.False(t, ok)
+}
+
+func TestRender(t *testing.T) {
+	for _, code := range []string{TemplateRegistrationCard, TemplateRegistrationStamp, TemplateRegistrationStampLabel, TemplateAcknowledgmentSheet} {
+		t.Run(code, func(t *testing.T) {
+			template, ok := Lookup(code)
+			require.True(t, ok)
+
+			var buf bytes.Buffer
+			require.NoError(t, Render(&buf, template, testForm()))
+
+			assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+			assert.Contains(t, buf.String(), "%%EOF")
+		})
+	}
+}
+
+func TestRenderStampLabelPageSize(t *testing.T) {
+	template, _ := Lookup(TemplateRegistrationStampLabel)
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, template, testForm()))
+
+	// 70×30 мм в пунктах PDF.
+	assert.Contains(t, buf.String(), "/MediaBox [0 0 198.43 85.04]")
+}
+
+func TestRenderIsDeterministic(t *testing.T) {
+	template, _ := Lookup(TemplateRegistrationCard)
+	var first, second bytes.Buffer
+	require.NoError(t, Render(&first, template, testForm()))
+	require.NoError(t, Render(&second, template, testForm()))
+
+	assert.Equal(t, first.Bytes(), second.Bytes())
+}
+
+func TestRenderAcknowledgmentSheetBreaksPages(t *testing.T) {
+	form := testForm()
+	entries := make([]AcknowledgmentEntry, 0, 80)
+	for range 80 {
+		entries = append(entries, AcknowledgmentEntry{FullName: "Сотрудник с достаточно длинными фамилией, именем и отчеством"})
+	}
+	form.Acknowledgments = []AcknowledgmentList{{Title: "Ознакомление", Entries: entries}}
+
+	template, _ := Lookup(TemplateAcknowledgmentSheet)
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, template, form))
+
+	assert.Greater(t, strings.Count(buf.String(), "/Type /Page\n"), 1)
+}
+
+func TestRenderRejectsEmptyTemplate(t *testing.T) {
+	assert.Error(t, Render(&bytes.Buffer{}, Template{Code: "empty"}, RegistrationForm{}))
+}
@@ -0,0 +1,70 @@
+package printforms
+
+import (
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	stampWidth  = 66.0
+	stampHeight = 26.0
+	// stampPageMargin — отступ штампа от правого и нижнего края листа A4.
+	stampPageMargin = 15.0
+	labelMargin     = 2.0
+)
+
+// drawStampOnPage размещает штамп в правом нижнем углу листа A4, где
+// регистрационная отметка проставляется на первом листе документа.
+func drawStampOnPage(pdf *fpdf.Fpdf, form RegistrationForm) {
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	width, height := pdf.GetPageSize()
+	drawStamp(pdf, form, width-stampPageMargin-stampWidth, height-stampPageMargin-stampHeight)
+}
+
+// drawStampLabel размещает штамп на этикетке для принтера этикеток.
+func drawStampLabel(pdf *fpdf.Fpdf, form RegistrationForm) {
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	pdf.AddPage()
+	drawStamp(pdf, form, labelMargin, labelMargin)
+}
+
+// drawStamp рисует рамку штампа: организация, номер, дата и индекс дела.
+func drawStamp(pdf *fpdf.Fpdf, form RegistrationForm, x, y float64) {
+	const padding = 1.5
+	innerWidth := stampWidth - 2*padding
+
+	pdf.SetLineWidth(0.4)
+	pdf.Rect(x, y, stampWidth, stampHeight, "D")
+	pdf.SetLineWidth(0.2)
+
+	pdf.SetXY(x+padding, y+padding)
+	pdf.SetFont(fontFamily, "B", 8)
+	pdf.CellFormat(innerWidth, 4, fitText(pdf, form.OrganizationName, innerWidth), "", 2, "C", false, 0, "")
+
+	rows := []Field{
+		{Label: "Рег. №", Value: form.RegistrationNumber},
+		{Label: "Дата", Value: formatDate(form.RegistrationDate)},
+		{Label: "Дело", Value: form.NomenclatureIndex},
+	}
+	const labelWidth = 14.0
+	for _, row := range rows {
+		pdf.SetX(x + padding)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.CellFormat(labelWidth, 6, row.Label, "", 0, "L", false, 0, "")
+		pdf.SetFont(fontFamily, "B", 10)
+		pdf.CellFormat(innerWidth-labelWidth, 6, fitText(pdf, row.Value, innerWidth-labelWidth), "B", 2, "L", false, 0, "")
+	}
+}
+
+// fitText обрезает строку по ширине ячейки штампа, чтобы текст не выходил за рамку.
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,104 @@
+package printforms
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// Коды шаблонов печатных форм.
+const (
+	TemplateRegistrationCard       = "registration_card"
+	TemplateRegistrationStamp      = "registration_stamp"
+	TemplateRegistrationStampLabel = "registration_stamp_label"
+	TemplateAcknowledgmentSheet    = "acknowledgment_sheet"
+)
+
+// Template описывает шаблон печатной формы и виды документов, для которых он доступен.
+type Template struct {
+	Code  string
+	Name  string
+	kinds []string
+	// action — действие, которое должен поддерживать вид документа.
+	action models.DocumentKindAction
+	draw   func(pdf *fpdf.Fpdf, form RegistrationForm)
+	// size задает формат листа в миллиметрах: ширину и высоту как есть.
+	size fpdf.SizeType
+}
+
+// stampKinds — виды документов, на бумажный оригинал которых проставляется штамп.
+var stampKinds = []string{"incoming_letter", "outgoing_letter", "citizen_appeal"}
+
+var templates = []Template{
+	{
+		Code: TemplateRegistrationCard,
+		Name: "Регистрационно-контрольная карточка",
+		draw: drawRegistrationCard,
+		size: fpdf.SizeType{Wd: 210, Ht: 297},
+	},
+	{
+		Code:  TemplateRegistrationStamp,
+		Name:  "Регистрационный штамп (лист A4)",
+		kinds: stampKinds,
+		draw:  drawStampOnPage,
+		size:  fpdf.SizeType{Wd: 210, Ht: 297},
+	},
+	{
+		Code:  TemplateRegistrationStampLabel,
+		Name:  "Регистрационный штамп (этикетка 70×30 мм)",
+		kinds: stampKinds,
+		draw:  drawStampLabel,
+		size:  fpdf.SizeType{Wd: stampWidth + 2*labelMargin, Ht: stampHeight + 2*labelMargin},
+	},
+	{
+		Code:   TemplateAcknowledgmentSheet,
+		Name:   "Лист ознакомления",
+		action: models.DocumentActionAcknowledge,
+		draw:   drawAcknowledgmentSheet,
+		size:   fpdf.SizeType{Wd: 210, Ht: 297},
+	},
+}
+
+// Supports сообщает, доступен ли шаблон для вида документа.
+func (t Template) Supports(kind string) bool {
+	if t.action != "" && !models.DocumentKind(kind).SupportsAction(string(t.action)) {
+		return false
+	}
+	return len(t.kinds) == 0 || slices.Contains(t.kinds, kind)
+}
+
+// Templates возвращает шаблоны, доступные для вида документа.
+func Templates(kind string) []Template {
+	result := make([]Template, 0, len(templates))
+	for _, template := range templates {
+		if template.Supports(kind) {
+			result = append(result, template)
+		}
+	}
+	return result
+}
+
+// Lookup находит шаблон по коду.
+func Lookup(code string) (Template, bool) {
+	for _, template := range templates {
+		if template.Code == code {
+			return template, true
+		}
+	}
+	return Template{}, false
+}
+
+// Render формирует PDF по шаблону и записывает его в w.
+func Render(w io.Writer, template Template, form RegistrationForm) error {
+	if template.draw == nil {
+		return fmt.Errorf("print template %q is not renderable", template.Code)
+	}
+	pdf := newDocument(template.size)
+	pdf.SetTitle(template.Name+" "+form.RegistrationNumber, true)
+	template.draw(pdf, form)
+	return output(pdf, w, form.PrintedAt)
+}
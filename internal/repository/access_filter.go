@@ -78,6 +78,10 @@ func applyDocumentListAccess(where *[]string, args *[]interface{}, argIdx *int,
 			assignmentArg = pq.Array(accessibleIDs)
 			ackArg = pq.Array(accessibleIDs)
 		}
+		if scope.AcknowledgmentUserIDs != nil {
+			ackUserPredicate = fmt.Sprintf("au.user_id = ANY($%d::uuid[])", *argIdx+1)
+			ackArg = pq.Array(scope.AcknowledgmentUserIDs)
+		}
 
 		accessClauses = append(accessClauses, fmt.Sprintf(`EXISTS (
 			SELECT 1
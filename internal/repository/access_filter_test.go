@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
@@ -32,6 +33,25 @@ func TestApplyDocumentListAccess(t *testing.T) {
 		assert.Equal(t, 5, argIdx)
 	})
 
+	t.Run("limits acknowledgment access to substitutions covering acknowledgments", func(t *testing.T) {
+		where := []string{"d.kind = $1"}
+		args := []interface{}{models.DocumentKindIncomingLetter}
+		argIdx := 2
+
+		applyDocumentListAccess(&where, &args, &argIdx, models.DocumentAccessScope{
+			Restricted:            true,
+			AccessibleByUserID:    "user-1",
+			AccessibleByUserIDs:   []string{"user-1"},
+			AcknowledgmentUserIDs: []string{"user-1", "user-2"},
+		})
+
+		predicate := strings.Join(where, " AND ")
+		assert.Contains(t, predicate, "a.executor_id = ANY($2::uuid[])")
+		assert.Contains(t, predicate, "au.user_id = ANY($3::uuid[])")
+		assert.Equal(t, pq.Array([]string{"user-1"}), args[1])
+		assert.Equal(t, pq.Array([]string{"user-1", "user-2"}), args[2])
+	})
+
 	t.Run("fails closed for an empty restricted scope", func(t *testing.T) {
 		where := []string{"d.kind = $1"}
 		args := []interface{}{models.DocumentKindIncomingLetter}
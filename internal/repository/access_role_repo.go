@@ -0,0 +1,383 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+var errAccessRoleNameTaken = models.NewConflict("роль с таким названием уже существует")
+
+// AccessRoleRepository хранит роли доступа, их правила и назначения.
+type AccessRoleRepository struct {
+	db     *database.DB
+	outbox *OutboxRepository
+}
+
+func (r *AccessRoleRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
+
+// NewAccessRoleRepository создает новый экземпляр AccessRoleRepository.
+func NewAccessRoleRepository(db *database.DB) *AccessRoleRepository {
+	return &AccessRoleRepository{db: db}
+}
+
+type accessRoleQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+const accessRoleSelect = `
+	SELECT r.id, r.name, r.description, r.created_at, r.updated_at
+	FROM access_roles r
+`
+
+// GetAll возвращает все роли с правилами и назначениями.
+func (r *AccessRoleRepository) GetAll() ([]models.AccessRole, error) {
+	rows, err := r.db.Query(accessRoleSelect + ` ORDER BY r.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access roles: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.AccessRole, 0)
+	for rows.Next() {
+		item, err := scanAccessRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadAccessRoleDetails(r.db, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetByID возвращает роль по ID или nil, если ее нет.
+func (r *AccessRoleRepository) GetByID(id uuid.UUID) (*models.AccessRole, error) {
+	return getAccessRole(r.db, id)
+}
+
+// CreateWithOutbox создает роль с правилами.
+func (r *AccessRoleRepository) CreateWithOutbox(role models.AccessRole, effects []models.OutboxEvent) (*models.AccessRole, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	if err := tx.QueryRow(`
+		INSERT INTO access_roles (name, description) VALUES ($1, $2) RETURNING id
+	`, role.Name, role.Description).Scan(&id); err != nil {
+		if isUniqueViolation(err, "idx_access_roles_name") {
+			return nil, errAccessRoleNameTaken
+		}
+		return nil, fmt.Errorf("failed to create access role: %w", err)
+	}
+	if err := replaceAccessRoleRulesTx(tx, id, role); err != nil {
+		return nil, err
+	}
+
+	item, err := getAccessRole(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+	return item, tx.Commit()
+}
+
+// UpdateWithOutbox меняет название, описание и правила роли. Назначения роли не меняются.
+func (r *AccessRoleRepository) UpdateWithOutbox(role models.AccessRole, effects []models.OutboxEvent) (*models.AccessRole, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE access_roles SET name = $2, description = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, role.ID, role.Name, role.Description)
+	if err != nil {
+		if isUniqueViolation(err, "idx_access_roles_name") {
+			return nil, errAccessRoleNameTaken
+		}
+		return nil, fmt.Errorf("failed to update access role: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, models.NewNotFound("роль не найдена")
+	}
+	if err := replaceAccessRoleRulesTx(tx, role.ID, role); err != nil {
+		return nil, err
+	}
+
+	item, err := getAccessRole(tx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+	return item, tx.Commit()
+}
+
+// DeleteWithOutbox удаляет роль. Назначения и системные права роли удаляются каскадно,
+// правила матрицы доступа — явно: document_permissions ссылается на роль по subject_key.
+func (r *AccessRoleRepository) DeleteWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM access_roles WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete access role: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return models.NewNotFound("роль не найдена")
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM document_permissions WHERE subject_type = 'role' AND subject_key = $1
+	`, id.String()); err != nil {
+		return fmt.Errorf("failed to delete access role permissions: %w", err)
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit access role deletion: %w", err)
+	}
+	return nil
+}
+
+// ReplaceAssignmentsWithOutbox заменяет пользователей и подразделения, которым назначена роль.
+func (r *AccessRoleRepository) ReplaceAssignmentsWithOutbox(roleID uuid.UUID, userIDs, departmentIDs []uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка роли сериализует параллельные замены назначений одной роли.
+	var lockedID uuid.UUID
+	err = tx.QueryRow(`SELECT id FROM access_roles WHERE id = $1 FOR UPDATE`, roleID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return models.NewNotFound("роль не найдена")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock access role: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_access_roles WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("failed to clear access role users: %w", err)
+	}
+	if len(userIDs) > 0 {
+		if _, err := tx.Exec(`
+			INSERT INTO user_access_roles (user_id, role_id)
+			SELECT DISTINCT unnest($2::uuid[]), $1
+		`, roleID, pq.Array(userIDs)); err != nil {
+			if isForeignKeyViolation(err) {
+				return models.NewBadRequest("пользователь не найден")
+			}
+			return fmt.Errorf("failed to assign access role to users: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM department_access_roles WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("failed to clear access role departments: %w", err)
+	}
+	if len(departmentIDs) > 0 {
+		if _, err := tx.Exec(`
+			INSERT INTO department_access_roles (department_id, role_id)
+			SELECT DISTINCT unnest($2::uuid[]), $1
+		`, roleID, pq.Array(departmentIDs)); err != nil {
+			if isForeignKeyViolation(err) {
+				return models.NewBadRequest("подразделение не найдено")
+			}
+			return fmt.Errorf("failed to assign access role to departments: %w", err)
+		}
+	}
+
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit access role assignments: %w", err)
+	}
+	return nil
+}
+
+func replaceAccessRoleRulesTx(tx *sql.Tx, roleID uuid.UUID, role models.AccessRole) error {
+	if _, err := tx.Exec(`DELETE FROM access_role_system_permissions WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("failed to clear access role system permissions: %w", err)
+	}
+	for _, permission := range role.SystemPermissions {
+		if _, err := tx.Exec(`
+			INSERT INTO access_role_system_permissions (role_id, permission) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, roleID, permission); err != nil {
+			return fmt.Errorf("failed to insert access role system permission: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM document_permissions WHERE subject_type = 'role' AND subject_key = $1
+	`, roleID.String()); err != nil {
+		return fmt.Errorf("failed to clear access role permissions: %w", err)
+	}
+	for _, permission := range role.Permissions {
+		if _, err := tx.Exec(`
+			INSERT INTO document_permissions (kind_code, subject_type, subject_key, action, is_allowed)
+			VALUES ($1, 'role', $2, $3, true)
+			ON CONFLICT DO NOTHING
+		`, permission.KindCode, roleID.String(), permission.Action); err != nil {
+			return fmt.Errorf("failed to insert access role permission: %w", err)
+		}
+	}
+	return nil
+}
+
+func getAccessRole(db accessRoleQuerier, id uuid.UUID) (*models.AccessRole, error) {
+	item, err := scanAccessRole(db.QueryRow(accessRoleSelect+` WHERE r.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access role: %w", err)
+	}
+	items := []models.AccessRole{*item}
+	if err := loadAccessRoleDetails(db, items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func scanAccessRole(row interface{ Scan(...interface{}) error }) (*models.AccessRole, error) {
+	item := models.AccessRole{
+		SystemPermissions: make([]string, 0),
+		Permissions:       make([]models.AccessRolePermission, 0),
+		UserIDs:           make([]uuid.UUID, 0),
+		DepartmentIDs:     make([]uuid.UUID, 0),
+	}
+	if err := row.Scan(&item.ID, &item.Name, &item.Description, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// loadAccessRoleDetails загружает правила и назначения ролей items одним запросом
+// на каждую связанную таблицу.
+func loadAccessRoleDetails(db accessRoleQuerier, items []models.AccessRole) error {
+	if len(items) == 0 {
+		return nil
+	}
+	index := make(map[uuid.UUID]int, len(items))
+	ids := make([]uuid.UUID, 0, len(items))
+	keys := make([]string, 0, len(items))
+	for i := range items {
+		index[items[i].ID] = i
+		ids = append(ids, items[i].ID)
+		keys = append(keys, items[i].ID.String())
+	}
+
+	if err := queryAccessRoleRows(db, `
+		SELECT role_id, permission FROM access_role_system_permissions
+		WHERE role_id = ANY($1)
+		ORDER BY permission
+	`, pq.Array(ids), func(rows *sql.Rows) error {
+		var roleID uuid.UUID
+		var permission string
+		if err := rows.Scan(&roleID, &permission); err != nil {
+			return err
+		}
+		item := &items[index[roleID]]
+		item.SystemPermissions = append(item.SystemPermissions, permission)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to get access role system permissions: %w", err)
+	}
+
+	if err := queryAccessRoleRows(db, `
+		SELECT subject_key::uuid, kind_code, action FROM document_permissions
+		WHERE subject_type = 'role' AND subject_key = ANY($1)
+		ORDER BY kind_code, action
+	`, pq.Array(keys), func(rows *sql.Rows) error {
+		var roleID uuid.UUID
+		var permission models.AccessRolePermission
+		if err := rows.Scan(&roleID, &permission.KindCode, &permission.Action); err != nil {
+			return err
+		}
+		item := &items[index[roleID]]
+		item.Permissions = append(item.Permissions, permission)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to get access role permissions: %w", err)
+	}
+
+	if err := queryAccessRoleRows(db, `
+		SELECT role_id, user_id FROM user_access_roles
+		WHERE role_id = ANY($1)
+		ORDER BY user_id
+	`, pq.Array(ids), func(rows *sql.Rows) error {
+		var roleID, userID uuid.UUID
+		if err := rows.Scan(&roleID, &userID); err != nil {
+			return err
+		}
+		item := &items[index[roleID]]
+		item.UserIDs = append(item.UserIDs, userID)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to get access role users: %w", err)
+	}
+
+	if err := queryAccessRoleRows(db, `
+		SELECT role_id, department_id FROM department_access_roles
+		WHERE role_id = ANY($1)
+		ORDER BY department_id
+	`, pq.Array(ids), func(rows *sql.Rows) error {
+		var roleID, departmentID uuid.UUID
+		if err := rows.Scan(&roleID, &departmentID); err != nil {
+			return err
+		}
+		item := &items[index[roleID]]
+		item.DepartmentIDs = append(item.DepartmentIDs, departmentID)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to get access role departments: %w", err)
+	}
+	return nil
+}
+
+func queryAccessRoleRows(db accessRoleQuerier, query string, arg interface{}, scan func(*sql.Rows) error) error {
+	rows, err := db.Query(query, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func expectAccessRoleDetails(mock sqlmock.Sqlmock, roleID uuid.UUID, userID uuid.UUID) {
+	mock.ExpectQuery(`SELECT role_id, permission FROM access_role_system_permissions`).
+		WithArgs(pq.Array([]uuid.UUID{roleID})).
+		WillReturnRows(sqlmock.NewRows([]string{"role_id", "permission"}).AddRow(roleID, models.SystemPermissionReferences))
+	mock.ExpectQuery(`SELECT subject_key::uuid, kind_code, action FROM document_permissions\s+WHERE subject_type = 'role'`).
+		WithArgs(pq.Array([]string{roleID.String()})).
+		WillReturnRows(sqlmock.NewRows([]string{"role_id", "kind_code", "action"}).
+			AddRow(roleID, string(models.DocumentKindIncomingLetter), string(models.DocumentActionCreate)))
+	mock.ExpectQuery(`SELECT role_id, user_id FROM user_access_roles`).
+		WithArgs(pq.Array([]uuid.UUID{roleID})).
+		WillReturnRows(sqlmock.NewRows([]string{"role_id", "user_id"}).AddRow(roleID, userID))
+	mock.ExpectQuery(`SELECT role_id, department_id FROM department_access_roles`).
+		WithArgs(pq.Array([]uuid.UUID{roleID})).
+		WillReturnRows(sqlmock.NewRows([]string{"role_id", "department_id"}))
+}
+
+func TestAccessRoleRepository_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewAccessRoleRepository(&database.DB{DB: db})
+
+	roleID, userID := uuid.New(), uuid.New()
+	now := time.Now()
+	mock.ExpectQuery(`SELECT r.id, r.name, r.description, r.created_at, r.updated_at\s+FROM access_roles r\s+ORDER BY r.name`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_at", "updated_at"}).
+			AddRow(roleID, "Регистратор", "", now, now))
+	expectAccessRoleDetails(mock, roleID, userID)
+
+	roles, err := repo.GetAll()
+
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, []string{models.SystemPermissionReferences}, roles[0].SystemPermissions)
+	assert.Equal(t, []models.AccessRolePermission{
+		{KindCode: string(models.DocumentKindIncomingLetter), Action: string(models.DocumentActionCreate)},
+	}, roles[0].Permissions)
+	assert.Equal(t, []uuid.UUID{userID}, roles[0].UserIDs)
+	assert.Empty(t, roles[0].DepartmentIDs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccessRoleRepository_CreateWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewAccessRoleRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+	roleID := uuid.New()
+	now := time.Now()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "access-role:create", Payload: `{}`}
+	role := models.AccessRole{
+		Name:              "Регистратор",
+		SystemPermissions: []string{models.SystemPermissionReferences},
+		Permissions: []models.AccessRolePermission{
+			{KindCode: string(models.DocumentKindIncomingLetter), Action: string(models.DocumentActionCreate)},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO access_roles \(name, description\) VALUES \(\$1, \$2\) RETURNING id`).
+		WithArgs("Регистратор", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(roleID))
+	mock.ExpectExec(`DELETE FROM access_role_system_permissions WHERE role_id = \$1`).WithArgs(roleID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO access_role_system_permissions`).WithArgs(roleID, models.SystemPermissionReferences).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM document_permissions WHERE subject_type = 'role' AND subject_key = \$1`).WithArgs(roleID.String()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO document_permissions \(kind_code, subject_type, subject_key, action, is_allowed\)\s+VALUES \(\$1, 'role', \$2, \$3, true\)`).
+		WithArgs(string(models.DocumentKindIncomingLetter), roleID.String(), string(models.DocumentActionCreate)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FROM access_roles r\s+WHERE r.id = \$1`).
+		WithArgs(roleID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_at", "updated_at"}).
+			AddRow(roleID, "Регистратор", "", now, now))
+	expectAccessRoleDetails(mock, roleID, uuid.New())
+	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	created, err := repo.CreateWithOutbox(role, []models.OutboxEvent{event})
+
+	require.NoError(t, err)
+	assert.Equal(t, roleID, created.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccessRoleRepository_ReplaceAssignmentsWithOutbox(t *testing.T) {
+	roleID, userID, departmentID := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("replaces users and departments", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAccessRoleRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM access_roles WHERE id = \$1 FOR UPDATE`).WithArgs(roleID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(roleID))
+		mock.ExpectExec(`DELETE FROM user_access_roles WHERE role_id = \$1`).WithArgs(roleID).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`INSERT INTO user_access_roles \(user_id, role_id\)\s+SELECT DISTINCT unnest\(\$2::uuid\[\]\), \$1`).
+			WithArgs(roleID, pq.Array([]uuid.UUID{userID})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM department_access_roles WHERE role_id = \$1`).WithArgs(roleID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO department_access_roles \(department_id, role_id\)`).
+			WithArgs(roleID, pq.Array([]uuid.UUID{departmentID})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.ReplaceAssignmentsWithOutbox(roleID, []uuid.UUID{userID}, []uuid.UUID{departmentID}, nil))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown user is rejected", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAccessRoleRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM access_roles`).WithArgs(roleID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(roleID))
+		mock.ExpectExec(`DELETE FROM user_access_roles`).WithArgs(roleID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO user_access_roles`).
+			WithArgs(roleID, pq.Array([]uuid.UUID{userID})).
+			WillReturnError(&pq.Error{Code: "23503"})
+		mock.ExpectRollback()
+
+		err = repo.ReplaceAssignmentsWithOutbox(roleID, []uuid.UUID{userID}, nil, nil)
+
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 400, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing role", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAccessRoleRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM access_roles`).WithArgs(roleID).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err = repo.ReplaceAssignmentsWithOutbox(roleID, nil, nil, nil)
+
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 404, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAccessRoleRepository_DeleteWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewAccessRoleRepository(&database.DB{DB: db})
+
+	roleID := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM access_roles WHERE id = \$1`).WithArgs(roleID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM document_permissions WHERE subject_type = 'role' AND subject_key = \$1`).
+		WithArgs(roleID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.DeleteWithOutbox(roleID, nil))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -43,10 +43,10 @@ func (r *AcknowledgmentRepository) create(a *models.Acknowledgment, effects []mo
 
 	// 1. Создание ознакомления
 	query := `
-		INSERT INTO acknowledgments (id, document_id, creator_id, content, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO acknowledgments (id, document_id, creator_id, content, created_at, deadline)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
-	_, err = tx.Exec(query, a.ID, a.DocumentID, a.CreatorID, a.Content, a.CreatedAt)
+	_, err = tx.Exec(query, a.ID, a.DocumentID, a.CreatorID, a.Content, a.CreatedAt, a.Deadline)
 	if err != nil {
 		return fmt.Errorf("failed to create acknowledgment: %w", err)
 	}
@@ -72,13 +72,14 @@ func (r *AcknowledgmentRepository) create(a *models.Acknowledgment, effects []mo
 // GetByID возвращает задачу по ID (базовая информация без внешних связей).
 func (r *AcknowledgmentRepository) GetByID(id uuid.UUID) (*models.Acknowledgment, error) {
 	query := `
-		SELECT a.id, a.document_id, d.kind, a.creator_id, a.content, a.created_at, a.completed_at
+		SELECT a.id, a.document_id, d.kind, a.creator_id, a.content, a.created_at, a.completed_at,
+			a.deadline, (a.completed_at IS NULL AND a.deadline IS NOT NULL AND a.deadline < CURRENT_DATE) AS overdue
 		FROM acknowledgments a
 		JOIN documents d ON d.id = a.document_id
 		WHERE a.id = $1
 	`
 	var a models.Acknowledgment
-	err := r.db.QueryRow(query, id).Scan(&a.ID, &a.DocumentID, &a.DocumentKind, &a.CreatorID, &a.Content, &a.CreatedAt, &a.CompletedAt)
+	err := r.db.QueryRow(query, id).Scan(&a.ID, &a.DocumentID, &a.DocumentKind, &a.CreatorID, &a.Content, &a.CreatedAt, &a.CompletedAt, &a.Deadline, &a.Overdue)
 	if err != nil {
 		return nil, err
 	}
@@ -90,6 +91,7 @@ func (r *AcknowledgmentRepository) GetByDocumentID(documentID uuid.UUID) ([]mode
 	query := `
 		SELECT 
 			a.id, a.document_id, d.kind, a.creator_id, a.content, a.created_at, a.completed_at,
+			a.deadline, (a.completed_at IS NULL AND a.deadline IS NOT NULL AND a.deadline < CURRENT_DATE) AS overdue,
 			u.full_name as creator_name,
 			d.registration_number as doc_number
 		FROM acknowledgments a
@@ -109,7 +111,7 @@ func (r *AcknowledgmentRepository) GetByDocumentID(documentID uuid.UUID) ([]mode
 		var a models.Acknowledgment
 		var docNumber string
 		err := rows.Scan(
-			&a.ID, &a.DocumentID, &a.DocumentKind, &a.CreatorID, &a.Content, &a.CreatedAt, &a.CompletedAt,
+			&a.ID, &a.DocumentID, &a.DocumentKind, &a.CreatorID, &a.Content, &a.CreatedAt, &a.CompletedAt, &a.Deadline, &a.Overdue,
 			&a.CreatorName, &docNumber,
 		)
 		if err != nil {
@@ -144,9 +146,12 @@ func (r *AcknowledgmentRepository) GetUsersByAcknowledgmentIDs(ackIDs []uuid.UUI
 	query := `
 		SELECT 
 			au.id, au.acknowledgment_id, au.user_id, au.viewed_at, au.confirmed_at, au.created_at,
-			u.full_name as user_name
+			u.full_name as user_name,
+			au.viewed_by, COALESCE(viewer.full_name, ''), au.confirmed_by, COALESCE(confirmer.full_name, '')
 		FROM acknowledgment_users au
 		JOIN users u ON au.user_id = u.id
+		LEFT JOIN users viewer ON viewer.id = au.viewed_by
+		LEFT JOIN users confirmer ON confirmer.id = au.confirmed_by
 		WHERE au.acknowledgment_id = ANY($1)
 		ORDER BY au.acknowledgment_id, au.created_at, au.id
 	`
@@ -161,6 +166,7 @@ func (r *AcknowledgmentRepository) GetUsersByAcknowledgmentIDs(ackIDs []uuid.UUI
 		err := rows.Scan(
 			&au.ID, &au.AcknowledgmentID, &au.UserID, &au.ViewedAt, &au.ConfirmedAt, &au.CreatedAt,
 			&au.UserName,
+			&au.ViewedBy, &au.ViewedByName, &au.ConfirmedBy, &au.ConfirmedByName,
 		)
 		if err != nil {
 			return nil, err
@@ -195,6 +201,7 @@ func (r *AcknowledgmentRepository) GetPendingForUser(userID uuid.UUID) ([]models
 	query := `
 		SELECT 
 			a.id, a.document_id, d.kind, a.creator_id, a.content, a.created_at, a.completed_at,
+			a.deadline, (a.completed_at IS NULL AND a.deadline IS NOT NULL AND a.deadline < CURRENT_DATE) AS overdue,
 			u.full_name as creator_name,
 			d.registration_number as doc_number
 		FROM acknowledgment_users au
@@ -215,7 +222,7 @@ func (r *AcknowledgmentRepository) GetPendingForUser(userID uuid.UUID) ([]models
 		var a models.Acknowledgment
 		var docNumber string
 		err := rows.Scan(
-			&a.ID, &a.DocumentID, &a.DocumentKind, &a.CreatorID, &a.Content, &a.CreatedAt, &a.CompletedAt,
+			&a.ID, &a.DocumentID, &a.DocumentKind, &a.CreatorID, &a.Content, &a.CreatedAt, &a.CompletedAt, &a.Deadline, &a.Overdue,
 			&a.CreatorName, &docNumber,
 		)
 		if err != nil {
@@ -242,6 +249,7 @@ func (r *AcknowledgmentRepository) GetPendingForUsers(userIDs []uuid.UUID) (map[
 		SELECT
 			au.user_id,
 			a.id, a.document_id, d.kind, a.creator_id, a.content, a.created_at, a.completed_at,
+			a.deadline, (a.completed_at IS NULL AND a.deadline IS NOT NULL AND a.deadline < CURRENT_DATE) AS overdue,
 			u.full_name AS creator_name,
 			d.registration_number AS doc_number
 		FROM acknowledgment_users au
@@ -262,7 +270,7 @@ func (r *AcknowledgmentRepository) GetPendingForUsers(userIDs []uuid.UUID) (map[
 		var subjectID uuid.UUID
 		var item models.Acknowledgment
 		var docNumber string
-		if err := rows.Scan(&subjectID, &item.ID, &item.DocumentID, &item.DocumentKind, &item.CreatorID, &item.Content, &item.CreatedAt, &item.CompletedAt, &item.CreatorName, &docNumber); err != nil {
+		if err := rows.Scan(&subjectID, &item.ID, &item.DocumentID, &item.DocumentKind, &item.CreatorID, &item.Content, &item.CreatedAt, &item.CompletedAt, &item.Deadline, &item.Overdue, &item.CreatorName, &docNumber); err != nil {
 			return nil, err
 		}
 		item.DocumentNumber = docNumber
@@ -362,12 +370,13 @@ func (r *AcknowledgmentRepository) MarkViewed(ackID, userID uuid.UUID) error {
 }
 
 // MarkViewedWithOutbox changes the acknowledgement state and persists its
-// journal event in one database transaction.
-func (r *AcknowledgmentRepository) MarkViewedWithOutbox(ackID, userID uuid.UUID, effects []models.OutboxEvent) error {
-	return r.markViewed(ackID, userID, effects)
+// journal event in one database transaction. actingUserID is set when the mark
+// is made by a substitute on behalf of the user.
+func (r *AcknowledgmentRepository) MarkViewedWithOutbox(ackID, userID uuid.UUID, actingUserID *uuid.UUID, effects []models.OutboxEvent) error {
+	return r.markViewed(ackID, userID, actingUserID, effects)
 }
 
-func (r *AcknowledgmentRepository) markViewed(ackID, userID uuid.UUID, effects []models.OutboxEvent) error {
+func (r *AcknowledgmentRepository) markViewed(ackID, userID uuid.UUID, actingUserID *uuid.UUID, effects []models.OutboxEvent) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -375,10 +384,10 @@ func (r *AcknowledgmentRepository) markViewed(ackID, userID uuid.UUID, effects [
 	defer tx.Rollback()
 	query := `
 		UPDATE acknowledgment_users
-		SET viewed_at = $1
+		SET viewed_at = $1, viewed_by = $4
 		WHERE acknowledgment_id = $2 AND user_id = $3 AND viewed_at IS NULL
 	`
-	res, err := tx.Exec(query, time.Now(), ackID, userID)
+	res, err := tx.Exec(query, time.Now(), ackID, userID, substituteID(userID, actingUserID))
 	if err != nil {
 		return err
 	}
@@ -401,10 +410,10 @@ func (r *AcknowledgmentRepository) MarkConfirmedWithEffects(ackID, userID uuid.U
 	if r.outbox == nil {
 		return ErrOutboxNotConfigured
 	}
-	return r.markConfirmed(ackID, userID, effects.UserEvents)
+	return r.markConfirmed(ackID, userID, effects.ActingUserID, effects.UserEvents)
 }
 
-func (r *AcknowledgmentRepository) markConfirmed(ackID, userID uuid.UUID, userEvents []models.CreateUserEventRequest) error {
+func (r *AcknowledgmentRepository) markConfirmed(ackID, userID uuid.UUID, actingUserID *uuid.UUID, userEvents []models.CreateUserEventRequest) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -412,14 +421,17 @@ func (r *AcknowledgmentRepository) markConfirmed(ackID, userID uuid.UUID, userEv
 	defer tx.Rollback()
 
 	now := time.Now()
+	substitute := substituteID(userID, actingUserID)
 
-	// 1. Обновление статуса пользователя
+	// 1. Обновление статуса пользователя; отметку заместителя сохраняем для листа ознакомления
 	query := `
 		UPDATE acknowledgment_users
-		SET confirmed_at = $1, viewed_at = COALESCE(viewed_at, $1)
+		SET confirmed_at = $1, confirmed_by = $4,
+			viewed_by = CASE WHEN viewed_at IS NULL THEN $4 ELSE viewed_by END,
+			viewed_at = COALESCE(viewed_at, $1)
 		WHERE acknowledgment_id = $2 AND user_id = $3 AND confirmed_at IS NULL
 	`
-	res, err := tx.Exec(query, now, ackID, userID)
+	res, err := tx.Exec(query, now, ackID, userID, substitute)
 	if err != nil {
 		return err
 	}
@@ -466,7 +478,12 @@ func (r *AcknowledgmentRepository) markConfirmed(ackID, userID uuid.UUID, userEv
 	if err := tx.QueryRow(`SELECT document_id FROM acknowledgments WHERE id = $1`, ackID).Scan(&documentID); err != nil {
 		return err
 	}
-	payload, err := json.Marshal(models.CreateJournalEntryRequest{DocumentID: documentID, UserID: userID, Action: "ACK_CONFIRM", Details: "Ознакомление подтверждено"})
+	journal := models.CreateJournalEntryRequest{DocumentID: documentID, UserID: userID, Action: "ACK_CONFIRM", Details: "Ознакомление подтверждено"}
+	if substitute != nil {
+		journal.UserID = *substitute
+		journal.OnBehalfOfUserID = &userID
+	}
+	payload, err := json.Marshal(journal)
 	if err != nil {
 		return err
 	}
@@ -489,11 +506,21 @@ func (r *AcknowledgmentRepository) markConfirmed(ackID, userID uuid.UUID, userEv
 	return tx.Commit()
 }
 
+// substituteID возвращает пользователя, сделавшего отметку за другого, или nil,
+// если пользователь отметился сам.
+func substituteID(userID uuid.UUID, actingUserID *uuid.UUID) *uuid.UUID {
+	if actingUserID == nil || *actingUserID == userID {
+		return nil
+	}
+	return actingUserID
+}
+
 // GetAllActive возвращает все активные (не завершенные) задачи на ознакомление.
 func (r *AcknowledgmentRepository) GetAllActive(filter models.AcknowledgmentFilter) ([]models.Acknowledgment, error) {
 	query := `
 		SELECT 
 			a.id, a.document_id, d.kind, a.creator_id, a.content, a.created_at, a.completed_at,
+			a.deadline, (a.completed_at IS NULL AND a.deadline IS NOT NULL AND a.deadline < CURRENT_DATE) AS overdue,
 			u.full_name as creator_name,
 			d.registration_number as doc_number
 		FROM acknowledgments a
@@ -501,9 +528,12 @@ func (r *AcknowledgmentRepository) GetAllActive(filter models.AcknowledgmentFilt
 		JOIN users u ON a.creator_id = u.id
 		WHERE a.completed_at IS NULL
 		  AND d.kind = ANY($1)
+		  AND ($2 = '' OR ($2 = 'overdue' AND a.deadline < CURRENT_DATE)
+		    OR ($2 = 'upcoming' AND a.deadline >= CURRENT_DATE)
+		    OR ($2 = 'none' AND a.deadline IS NULL))
 		ORDER BY a.created_at DESC
 	`
-	rows, err := r.db.Query(query, pq.Array(filter.AllowedDocumentKinds))
+	rows, err := r.db.Query(query, pq.Array(filter.AllowedDocumentKinds), filter.DeadlineState)
 	if err != nil {
 		return nil, err
 	}
@@ -514,7 +544,7 @@ func (r *AcknowledgmentRepository) GetAllActive(filter models.AcknowledgmentFilt
 		var a models.Acknowledgment
 		var docNumber string
 		err := rows.Scan(
-			&a.ID, &a.DocumentID, &a.DocumentKind, &a.CreatorID, &a.Content, &a.CreatedAt, &a.CompletedAt,
+			&a.ID, &a.DocumentID, &a.DocumentKind, &a.CreatorID, &a.Content, &a.CreatedAt, &a.CompletedAt, &a.Deadline, &a.Overdue,
 			&a.CreatorName, &docNumber,
 		)
 		if err != nil {
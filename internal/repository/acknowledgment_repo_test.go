@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"regexp"
 	"testing"
 	"time"
@@ -38,7 +40,7 @@ func TestAcknowledgmentRepository_Create(t *testing.T) {
 	mock.ExpectBegin()
 
 	mock.ExpectExec(`INSERT INTO acknowledgments`).WithArgs(
-		ack.ID, ack.DocumentID, ack.CreatorID, ack.Content, ack.CreatedAt,
+		ack.ID, ack.DocumentID, ack.CreatorID, ack.Content, ack.CreatedAt, ack.Deadline,
 	).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	mock.ExpectExec(`INSERT INTO acknowledgment_users`).WithArgs(
@@ -71,7 +73,7 @@ func TestAcknowledgmentRepositoryCreateWithOutboxRollsBackOnEnqueueFailure(t *te
 	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "ack:" + ack.ID.String(), Payload: `{"action":"ACK_CREATE"}`}
 
 	mock.ExpectBegin()
-	mock.ExpectExec(`INSERT INTO acknowledgments`).WithArgs(ack.ID, ack.DocumentID, ack.CreatorID, ack.Content, ack.CreatedAt).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO acknowledgments`).WithArgs(ack.ID, ack.DocumentID, ack.CreatorID, ack.Content, ack.CreatedAt, ack.Deadline).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec(`INSERT INTO acknowledgment_users`).WithArgs(ack.Users[0].ID, ack.ID, ack.Users[0].UserID, ack.Users[0].CreatedAt).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnError(assert.AnError)
 	mock.ExpectRollback()
@@ -94,10 +96,10 @@ func TestAcknowledgmentRepository_GetByID(t *testing.T) {
 		now := time.Now()
 		completedAt := now.Add(time.Hour)
 
-		query := `SELECT a\.id, a\.document_id, d\.kind, a\.creator_id, a\.content, a\.created_at, a\.completed_at FROM acknowledgments a JOIN documents d ON d\.id = a\.document_id WHERE a\.id = \$1`
+		query := `SELECT a\.id, a\.document_id, d\.kind, a\.creator_id, a\.content, a\.created_at, a\.completed_at, a\.deadline, \(a\.completed_at IS NULL AND a\.deadline IS NOT NULL AND a\.deadline < CURRENT_DATE\) AS overdue FROM acknowledgments a JOIN documents d ON d\.id = a\.document_id WHERE a\.id = \$1`
 		rows := sqlmock.NewRows([]string{
-			"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at",
-		}).AddRow(ackID, docID, string(models.DocumentKindIncomingLetter), creatorID, "Ознакомиться", now, completedAt)
+			"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at", "deadline", "overdue",
+		}).AddRow(ackID, docID, string(models.DocumentKindIncomingLetter), creatorID, "Ознакомиться", now, completedAt, nil, false)
 
 		mock.ExpectQuery(query).WithArgs(ackID).WillReturnRows(rows)
 
@@ -123,7 +125,7 @@ func TestAcknowledgmentRepository_GetByID(t *testing.T) {
 		repo := NewAcknowledgmentRepository(&database.DB{DB: db})
 		ackID := uuid.New()
 
-		query := `SELECT a\.id, a\.document_id, d\.kind, a\.creator_id, a\.content, a\.created_at, a\.completed_at FROM acknowledgments a JOIN documents d ON d\.id = a\.document_id WHERE a\.id = \$1`
+		query := `SELECT a\.id, a\.document_id, d\.kind, a\.creator_id, a\.content, a\.created_at, a\.completed_at, a\.deadline, \(a\.completed_at IS NULL AND a\.deadline IS NOT NULL AND a\.deadline < CURRENT_DATE\) AS overdue FROM acknowledgments a JOIN documents d ON d\.id = a\.document_id WHERE a\.id = \$1`
 		mock.ExpectQuery(query).WithArgs(ackID).WillReturnError(sqlmock.ErrCancelled)
 
 		ack, err := repo.GetByID(ackID)
@@ -147,24 +149,28 @@ func TestAcknowledgmentRepository_GetByDocumentID(t *testing.T) {
 	expectedQuery := `SELECT(.*)FROM acknowledgments a(.*)JOIN documents d ON d.id = a.document_id(.*)WHERE a.document_id = \$1(.*)`
 
 	rows := sqlmock.NewRows([]string{
-		"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at",
+		"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at", "deadline", "overdue",
 		"creator_name", "doc_number",
-	}).AddRow(ackID, docID, "incoming", uuid.New(), "Ознакомиться", now, nil, "Создатель", "ВХ-1").
-		AddRow(uuid.New(), docID, "incoming", uuid.New(), "Второе ознакомление", now, nil, "Создатель", "ВХ-1")
+	}).AddRow(ackID, docID, "incoming", uuid.New(), "Ознакомиться", now, nil, nil, false, "Создатель", "ВХ-1").
+		AddRow(uuid.New(), docID, "incoming", uuid.New(), "Второе ознакомление", now, nil, nil, false, "Создатель", "ВХ-1")
 
 	mock.ExpectQuery(expectedQuery).WithArgs(docID).WillReturnRows(rows)
 
 	usersQuery := `SELECT 
 			au.id, au.acknowledgment_id, au.user_id, au.viewed_at, au.confirmed_at, au.created_at,
-			u.full_name as user_name
+			u.full_name as user_name,
+			au.viewed_by, COALESCE(viewer.full_name, ''), au.confirmed_by, COALESCE(confirmer.full_name, '')
 		FROM acknowledgment_users au
 		JOIN users u ON au.user_id = u.id
+		LEFT JOIN users viewer ON viewer.id = au.viewed_by
+		LEFT JOIN users confirmer ON confirmer.id = au.confirmed_by
 		WHERE au.acknowledgment_id = ANY($1)
 		ORDER BY au.acknowledgment_id, au.created_at, au.id`
 
 	usersRows := sqlmock.NewRows([]string{
 		"id", "acknowledgment_id", "user_id", "viewed_at", "confirmed_at", "created_at", "user_name",
-	}).AddRow(uuid.New(), ackID, uuid.New(), nil, nil, now, "Читатель")
+		"viewed_by", "viewer_name", "confirmed_by", "confirmer_name",
+	}).AddRow(uuid.New(), ackID, uuid.New(), nil, nil, now, "Читатель", nil, "", nil, "")
 
 	mock.ExpectQuery(regexp.QuoteMeta(usersQuery)).WithArgs(sqlmock.AnyArg()).WillReturnRows(usersRows)
 
@@ -205,12 +211,12 @@ func TestAcknowledgmentRepositoryMarkViewedWithOutboxRollsBackOnEnqueueFailure(t
 	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "ack:" + ackID.String() + ":viewed", Payload: `{"action":"ACK_VIEW"}`}
 
 	mock.ExpectBegin()
-	mock.ExpectExec(`UPDATE acknowledgment_users SET viewed_at = \$1 WHERE acknowledgment_id = \$2 AND user_id = \$3 AND viewed_at IS NULL`).
-		WithArgs(sqlmock.AnyArg(), ackID, userID).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE acknowledgment_users SET viewed_at = \$1, viewed_by = \$4 WHERE acknowledgment_id = \$2 AND user_id = \$3 AND viewed_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), ackID, userID, nil).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnError(assert.AnError)
 	mock.ExpectRollback()
 
-	err = repo.MarkViewedWithOutbox(ackID, userID, []models.OutboxEvent{event})
+	err = repo.MarkViewedWithOutbox(ackID, userID, &userID, []models.OutboxEvent{event})
 	require.ErrorIs(t, err, assert.AnError)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -232,6 +238,49 @@ func TestAcknowledgmentRepository_MarkViewedReturnsForbiddenWhenUserRowMissing(t
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAcknowledgmentRepository_MarkConfirmedWithEffectsJournalsSubstitute(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAcknowledgmentRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	ackID, principalID, substituteID, documentID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE acknowledgment_users SET confirmed_at = \$1, confirmed_by = \$4`).
+		WithArgs(sqlmock.AnyArg(), ackID, principalID, substituteID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)`).WithArgs(ackID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT document_id FROM acknowledgments`).WithArgs(ackID).WillReturnRows(sqlmock.NewRows([]string{"document_id"}).AddRow(documentID))
+	mock.ExpectExec(`INSERT INTO event_outbox`).
+		WithArgs(models.OutboxEventJournal, "ack:"+ackID.String()+":confirmed:"+principalID.String()+":journal",
+			journalPayloadArg{userID: substituteID, onBehalfOf: principalID}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = repo.MarkConfirmedWithEffects(ackID, principalID, models.AcknowledgmentConfirmationEffects{ActingUserID: &substituteID})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// journalPayloadArg проверяет автора записи журнала и замещаемого в payload outbox.
+type journalPayloadArg struct {
+	userID     uuid.UUID
+	onBehalfOf uuid.UUID
+}
+
+func (a journalPayloadArg) Match(value driver.Value) bool {
+	payload, ok := value.(string)
+	if !ok {
+		return false
+	}
+	var req models.CreateJournalEntryRequest
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		return false
+	}
+	return req.UserID == a.userID && req.OnBehalfOfUserID != nil && *req.OnBehalfOfUserID == a.onBehalfOf
+}
+
 func TestAcknowledgmentRepository_Delete(t *testing.T) {
 	// Удаление листа ознакомления по его ID
 	db, mock, err := sqlmock.New()
@@ -276,20 +325,24 @@ func TestAcknowledgmentRepository_GetUsersByAcknowledgmentID(t *testing.T) {
 
 	repo := NewAcknowledgmentRepository(&database.DB{DB: db})
 	ackID := uuid.New()
-	userID := uuid.New()
+	userID, substituteID := uuid.New(), uuid.New()
 	now := time.Now()
 
 	query := `SELECT 
 			au.id, au.acknowledgment_id, au.user_id, au.viewed_at, au.confirmed_at, au.created_at,
-			u.full_name as user_name
+			u.full_name as user_name,
+			au.viewed_by, COALESCE\(viewer.full_name, ''\), au.confirmed_by, COALESCE\(confirmer.full_name, ''\)
 		FROM acknowledgment_users au
 		JOIN users u ON au.user_id = u.id
+		LEFT JOIN users viewer ON viewer.id = au.viewed_by
+		LEFT JOIN users confirmer ON confirmer.id = au.confirmed_by
 		WHERE au.acknowledgment_id = ANY\(\$1\)
 		ORDER BY au.acknowledgment_id, au.created_at, au.id`
 
 	rows := sqlmock.NewRows([]string{
 		"id", "acknowledgment_id", "user_id", "viewed_at", "confirmed_at", "created_at", "user_name",
-	}).AddRow(uuid.New(), ackID, userID, now, nil, now, "Читатель")
+		"viewed_by", "viewer_name", "confirmed_by", "confirmer_name",
+	}).AddRow(uuid.New(), ackID, userID, now, nil, now, "Читатель", substituteID, "Заместитель", nil, "")
 
 	mock.ExpectQuery(query).WithArgs(sqlmock.AnyArg()).WillReturnRows(rows)
 
@@ -298,6 +351,10 @@ func TestAcknowledgmentRepository_GetUsersByAcknowledgmentID(t *testing.T) {
 	require.Len(t, users, 1)
 	assert.Equal(t, userID, users[0].UserID)
 	assert.Equal(t, "Читатель", users[0].UserName)
+	require.NotNil(t, users[0].ViewedBy)
+	assert.Equal(t, substituteID, *users[0].ViewedBy)
+	assert.Equal(t, "Заместитель", users[0].ViewedByName)
+	assert.Nil(t, users[0].ConfirmedBy)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -314,22 +371,25 @@ func TestAcknowledgmentRepository_GetPendingForUser(t *testing.T) {
 	query := `SELECT(.*)FROM acknowledgment_users au(.*)JOIN documents d ON d.id = a.document_id(.*)WHERE au.user_id = \$1 AND au.confirmed_at IS NULL ORDER BY a.created_at DESC`
 
 	rows := sqlmock.NewRows([]string{
-		"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at",
+		"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at", "deadline", "overdue",
 		"creator_name", "doc_number",
-	}).AddRow(uuid.New(), uuid.New(), "incoming", uuid.New(), "Ознакомиться", now, nil, "Создатель", "ВХ-1")
+	}).AddRow(uuid.New(), uuid.New(), "incoming", uuid.New(), "Ознакомиться", now, nil, nil, false, "Создатель", "ВХ-1")
 
 	mock.ExpectQuery(query).WithArgs(userID).WillReturnRows(rows)
 
 	usersQuery := `SELECT 
 			au.id, au.acknowledgment_id, au.user_id, au.viewed_at, au.confirmed_at, au.created_at,
-			u.full_name as user_name
+			u.full_name as user_name,
+			au.viewed_by, COALESCE\(viewer.full_name, ''\), au.confirmed_by, COALESCE\(confirmer.full_name, ''\)
 		FROM acknowledgment_users au
 		JOIN users u ON au.user_id = u.id
+		LEFT JOIN users viewer ON viewer.id = au.viewed_by
+		LEFT JOIN users confirmer ON confirmer.id = au.confirmed_by
 		WHERE au.acknowledgment_id = ANY\(\$1\)
 		ORDER BY au.acknowledgment_id, au.created_at, au.id`
 
 	mock.ExpectQuery(usersQuery).WithArgs(sqlmock.AnyArg()).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "acknowledgment_id", "user_id", "viewed_at", "confirmed_at", "created_at", "user_name"}),
+		sqlmock.NewRows([]string{"id", "acknowledgment_id", "user_id", "viewed_at", "confirmed_at", "created_at", "user_name", "viewed_by", "viewer_name", "confirmed_by", "confirmer_name"}),
 	)
 
 	acks, err := repo.GetPendingForUser(userID)
@@ -353,11 +413,11 @@ func TestAcknowledgmentRepository_GetPendingForUsers(t *testing.T) {
 	mock.ExpectQuery(`SELECT(.*)au.user_id(.*)WHERE au.user_id = ANY\(\$1\) AND au.confirmed_at IS NULL`).
 		WithArgs(sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{
-			"user_id", "id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at", "creator_name", "doc_number",
-		}).AddRow(principalID, ackID, uuid.New(), "incoming_letter", uuid.New(), "Ознакомиться", now, nil, "Создатель", "ВХ-1"))
+			"user_id", "id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at", "deadline", "overdue", "creator_name", "doc_number",
+		}).AddRow(principalID, ackID, uuid.New(), "incoming_letter", uuid.New(), "Ознакомиться", now, nil, nil, false, "Создатель", "ВХ-1"))
 	mock.ExpectQuery(`SELECT(.*)FROM acknowledgment_users au(.*)WHERE au.acknowledgment_id = ANY\(\$1\)`).
 		WithArgs(sqlmock.AnyArg()).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "acknowledgment_id", "user_id", "viewed_at", "confirmed_at", "created_at", "user_name"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "acknowledgment_id", "user_id", "viewed_at", "confirmed_at", "created_at", "user_name", "viewed_by", "viewer_name", "confirmed_by", "confirmer_name"}))
 
 	items, err := repo.GetPendingForUsers([]uuid.UUID{userID, principalID})
 	require.NoError(t, err)
@@ -379,11 +439,11 @@ func TestAcknowledgmentRepository_GetAllActive(t *testing.T) {
 	query := `SELECT(.*)FROM acknowledgments a(.*)JOIN documents d ON d.id = a.document_id(.*)WHERE a.completed_at IS NULL(.*)d.kind = ANY\(\$1\)(.*)`
 
 	rows := sqlmock.NewRows([]string{
-		"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at",
+		"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at", "deadline", "overdue",
 		"creator_name", "doc_number",
-	}).AddRow(uuid.New(), uuid.New(), "incoming", uuid.New(), "Ознакомиться", now, nil, "Создатель", "ВХ-1")
+	}).AddRow(uuid.New(), uuid.New(), "incoming", uuid.New(), "Ознакомиться", now, nil, nil, false, "Создатель", "ВХ-1")
 
-	mock.ExpectQuery(query).WithArgs(pq.Array([]string{"incoming_letter"})).WillReturnRows(rows)
+	mock.ExpectQuery(query).WithArgs(pq.Array([]string{"incoming_letter"}), "").WillReturnRows(rows)
 
 	acks, err := repo.GetAllActive(models.AcknowledgmentFilter{AllowedDocumentKinds: []string{"incoming_letter"}})
 	require.NoError(t, err)
@@ -391,6 +451,30 @@ func TestAcknowledgmentRepository_GetAllActive(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAcknowledgmentRepository_GetAllActiveFiltersOverdue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAcknowledgmentRepository(&database.DB{DB: db})
+	deadline := time.Date(2026, 8, 3, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`WHERE a.completed_at IS NULL(.*)\(\$2 = 'overdue' AND a.deadline < CURRENT_DATE\)`).
+		WithArgs(pq.Array([]string{"incoming_letter"}), models.AcknowledgmentDeadlineOverdue).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "document_id", "kind", "creator_id", "content", "created_at", "completed_at", "deadline", "overdue",
+			"creator_name", "doc_number",
+		}).AddRow(uuid.New(), uuid.New(), "incoming_letter", uuid.New(), "Ознакомиться", time.Now(), nil, deadline, true, "Создатель", "ВХ-1"))
+
+	acks, err := repo.GetAllActive(models.AcknowledgmentFilter{AllowedDocumentKinds: []string{"incoming_letter"}, DeadlineState: models.AcknowledgmentDeadlineOverdue})
+	require.NoError(t, err)
+	require.Len(t, acks, 1)
+	require.NotNil(t, acks[0].Deadline)
+	assert.True(t, acks[0].Deadline.Equal(deadline))
+	assert.True(t, acks[0].Overdue)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAcknowledgmentRepository_DocumentAccess(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
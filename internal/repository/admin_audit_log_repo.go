@@ -32,7 +32,9 @@ func (r *AdminAuditLogRepository) create(req models.CreateAdminAuditLogRequest,
 		RETURNING id
 	`
 	var id uuid.UUID
-	err := r.db.QueryRow(query, req.UserID, req.UserName, req.Action, req.Details, key).Scan(&id)
+	// Записи фоновых проверок создаются без пользователя (uuid.Nil хранится как NULL).
+	userID := uuid.NullUUID{UUID: req.UserID, Valid: req.UserID != uuid.Nil}
+	err := r.db.QueryRow(query, userID, req.UserName, req.Action, req.Details, key).Scan(&id)
 	if err == sql.ErrNoRows && key != "" {
 		return uuid.Nil, nil
 	}
@@ -63,9 +65,11 @@ func (r *AdminAuditLogRepository) GetAll(limit, offset int) ([]models.AdminAudit
 	entries := make([]models.AdminAuditLog, 0)
 	for rows.Next() {
 		var entry models.AdminAuditLog
-		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.UserName, &entry.Action, &entry.Details, &entry.CreatedAt); err != nil {
+		var userID uuid.NullUUID
+		if err := rows.Scan(&entry.ID, &userID, &entry.UserName, &entry.Action, &entry.Details, &entry.CreatedAt); err != nil {
 			return nil, 0, err
 		}
+		entry.UserID = userID.UUID
 		entries = append(entries, entry)
 	}
 	if err := rows.Err(); err != nil {
@@ -282,8 +282,8 @@ func (r *AdministrativeOrderRepository) create(req models.CreateAdministrativeOr
 	var id uuid.UUID
 	err = tx.QueryRow(`
 		INSERT INTO documents (
-			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, 1, $8)
+			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by
+		) VALUES ($1, $2, $3, $4, $5, `+defaultDocumentTypeIDExpr("$1", "$6")+`, $7, 1, $8)
 		RETURNING id
 	`,
 		models.DocumentKindAdministrativeOrder,
@@ -307,6 +307,9 @@ func (r *AdministrativeOrderRepository) create(req models.CreateAdministrativeOr
 		if isUniqueViolation(err, "idx_documents_kind_registration_number_year") {
 			return nil, models.NewConflict("документ с таким регистрационным номером уже существует")
 		}
+		if isInvalidDocumentType(err) {
+			return nil, errInvalidDocumentType
+		}
 		return nil, fmt.Errorf("failed to create administrative order root: %w", err)
 	}
 
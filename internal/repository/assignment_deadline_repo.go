@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+const assignmentDeadlineExtensionSelect = `
+	SELECT e.id, e.assignment_id, e.requested_by, COALESCE(rb.full_name, ''),
+		e.on_behalf_of_user_id, COALESCE(ob.full_name, ''),
+		e.previous_deadline, e.requested_deadline, e.reason, e.status,
+		e.decided_by, COALESCE(db.full_name, ''), e.decision_comment, e.created_at, e.decided_at
+	FROM assignment_deadline_extensions e
+	LEFT JOIN users rb ON rb.id = e.requested_by
+	LEFT JOIN users ob ON ob.id = e.on_behalf_of_user_id
+	LEFT JOIN users db ON db.id = e.decided_by`
+
+// CreateDeadlineExtensionWithOutbox сохраняет запрос на продление срока вместе с эффектами outbox.
+// Открытый запрос по поручению может быть только один.
+func (r *AssignmentRepository) CreateDeadlineExtensionWithOutbox(ext models.AssignmentDeadlineExtension, effects []models.OutboxEvent) (*models.AssignmentDeadlineExtension, error) {
+	if r.outbox == nil {
+		return nil, ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	var deadline sql.NullTime
+	err = tx.QueryRow(`SELECT status, deadline FROM assignments WHERE id = $1 FOR UPDATE`, ext.AssignmentID).Scan(&status, &deadline)
+	if err == sql.ErrNoRows {
+		return nil, models.NewNotFound("поручение не найдено")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock assignment: %w", err)
+	}
+	switch status {
+	case "new", "in_progress", "returned":
+	default:
+		return nil, models.NewConflict("продлить можно только поручение в работе")
+	}
+	if !deadline.Valid || ext.PreviousDeadline == nil || deadline.Time.Format("2006-01-02") != ext.PreviousDeadline.Format("2006-01-02") {
+		return nil, models.NewConflict("срок поручения изменен, обновите карточку")
+	}
+
+	if _, err = tx.Exec(`
+		INSERT INTO assignment_deadline_extensions (id, assignment_id, requested_by, on_behalf_of_user_id, previous_deadline, requested_deadline, reason, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, ext.ID, ext.AssignmentID, ext.RequestedBy, ext.OnBehalfOfUserID, ext.PreviousDeadline, ext.RequestedDeadline, ext.Reason, models.DeadlineExtensionPending); err != nil {
+		if isUniqueViolation(err, "idx_assignment_deadline_extensions_pending") {
+			return nil, models.NewConflict("по поручению уже есть запрос на продление срока")
+		}
+		return nil, fmt.Errorf("failed to create deadline extension: %w", err)
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return r.GetDeadlineExtension(ext.ID)
+}
+
+// DecideDeadlineExtensionWithOutbox фиксирует решение по запросу на продление.
+// При одобрении срок поручения переносится, а первоначальный срок сохраняется.
+// Одобрить можно, только пока поручение в работе и его срок не менялся после
+// подачи запроса.
+func (r *AssignmentRepository) DecideDeadlineExtensionWithOutbox(id uuid.UUID, status string, decidedBy uuid.UUID, comment string, effects []models.OutboxEvent) (*models.AssignmentDeadlineExtension, error) {
+	if r.outbox == nil {
+		return nil, ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var assignmentID uuid.UUID
+	var previousDeadline sql.NullTime
+	var requestedDeadline time.Time
+	err = tx.QueryRow(`
+		UPDATE assignment_deadline_extensions
+		SET status = $2, decided_by = $3, decision_comment = $4, decided_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING assignment_id, previous_deadline, requested_deadline
+	`, id, status, decidedBy, comment).Scan(&assignmentID, &previousDeadline, &requestedDeadline)
+	if err == sql.ErrNoRows {
+		return nil, models.NewConflict("запрос на продление уже рассмотрен")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide deadline extension: %w", err)
+	}
+
+	if status == models.DeadlineExtensionApproved {
+		var previous *time.Time
+		if previousDeadline.Valid {
+			previous = &previousDeadline.Time
+		}
+		result, err := tx.Exec(`
+			UPDATE assignments
+			SET original_deadline = COALESCE(original_deadline, deadline), deadline = $2, updated_at = NOW()
+			WHERE id = $1 AND status IN ('new', 'in_progress', 'returned') AND deadline IS NOT DISTINCT FROM $3::date
+		`, assignmentID, requestedDeadline, previous)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extend assignment deadline: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected == 0 {
+			return nil, models.NewConflict("поручение изменено после подачи запроса, обновите карточку")
+		}
+		if _, err = tx.Exec(`
+			INSERT INTO assignment_deadline_history (assignment_id, old_deadline, new_deadline, changed_by, extension_id)
+			VALUES ($1, $2, $3, $4, $5)
+		`, assignmentID, previous, requestedDeadline, decidedBy, id); err != nil {
+			return nil, fmt.Errorf("failed to record deadline history: %w", err)
+		}
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return r.GetDeadlineExtension(id)
+}
+
+// GetDeadlineExtension возвращает запрос на продление по ID.
+func (r *AssignmentRepository) GetDeadlineExtension(id uuid.UUID) (*models.AssignmentDeadlineExtension, error) {
+	ext, err := scanAssignmentDeadlineExtension(r.db.QueryRow(assignmentDeadlineExtensionSelect+` WHERE e.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.NewNotFound("запрос на продление не найден")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deadline extension: %w", err)
+	}
+	return ext, nil
+}
+
+// GetDeadlineExtensions возвращает запросы на продление по поручению в порядке подачи.
+func (r *AssignmentRepository) GetDeadlineExtensions(assignmentID uuid.UUID) ([]models.AssignmentDeadlineExtension, error) {
+	rows, err := r.db.Query(assignmentDeadlineExtensionSelect+` WHERE e.assignment_id = $1 ORDER BY e.created_at, e.id`, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deadline extensions: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.AssignmentDeadlineExtension, 0)
+	for rows.Next() {
+		ext, err := scanAssignmentDeadlineExtension(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *ext)
+	}
+	return items, rows.Err()
+}
+
+// GetDeadlineHistory возвращает историю изменений срока поручения.
+func (r *AssignmentRepository) GetDeadlineHistory(assignmentID uuid.UUID) ([]models.AssignmentDeadlineChange, error) {
+	rows, err := r.db.Query(`
+		SELECT h.id, h.assignment_id, h.old_deadline, h.new_deadline, h.changed_by, COALESCE(u.full_name, ''), h.extension_id, h.created_at
+		FROM assignment_deadline_history h
+		LEFT JOIN users u ON u.id = h.changed_by
+		WHERE h.assignment_id = $1
+		ORDER BY h.created_at, h.id
+	`, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deadline history: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.AssignmentDeadlineChange, 0)
+	for rows.Next() {
+		var change models.AssignmentDeadlineChange
+		var oldDeadline, newDeadline sql.NullTime
+		var extensionID uuid.NullUUID
+		if err := rows.Scan(&change.ID, &change.AssignmentID, &oldDeadline, &newDeadline, &change.ChangedBy, &change.ChangedByName, &extensionID, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		if oldDeadline.Valid {
+			change.OldDeadline = &oldDeadline.Time
+		}
+		if newDeadline.Valid {
+			change.NewDeadline = &newDeadline.Time
+		}
+		if extensionID.Valid {
+			change.ExtensionID = &extensionID.UUID
+		}
+		items = append(items, change)
+	}
+	return items, rows.Err()
+}
+
+// GetOriginalDeadline возвращает первый плановый срок поручения;
+// nil — срок не менялся.
+func (r *AssignmentRepository) GetOriginalDeadline(assignmentID uuid.UUID) (*time.Time, error) {
+	var original sql.NullTime
+	err := r.db.QueryRow(`SELECT original_deadline FROM assignments WHERE id = $1`, assignmentID).Scan(&original)
+	if err == sql.ErrNoRows {
+		return nil, models.NewNotFound("поручение не найдено")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get original deadline: %w", err)
+	}
+	if !original.Valid {
+		return nil, nil
+	}
+	return &original.Time, nil
+}
+
+func scanAssignmentDeadlineExtension(scanner interface{ Scan(dest ...any) error }) (*models.AssignmentDeadlineExtension, error) {
+	var ext models.AssignmentDeadlineExtension
+	var onBehalfOf, decidedBy uuid.NullUUID
+	var previousDeadline, decidedAt sql.NullTime
+	if err := scanner.Scan(
+		&ext.ID, &ext.AssignmentID, &ext.RequestedBy, &ext.RequestedByName,
+		&onBehalfOf, &ext.OnBehalfOfUserName,
+		&previousDeadline, &ext.RequestedDeadline, &ext.Reason, &ext.Status,
+		&decidedBy, &ext.DecidedByName, &ext.DecisionComment, &ext.CreatedAt, &decidedAt,
+	); err != nil {
+		return nil, err
+	}
+	if onBehalfOf.Valid {
+		ext.OnBehalfOfUserID = &onBehalfOf.UUID
+	}
+	if decidedBy.Valid {
+		ext.DecidedBy = &decidedBy.UUID
+	}
+	if previousDeadline.Valid {
+		ext.PreviousDeadline = &previousDeadline.Time
+	}
+	if decidedAt.Valid {
+		ext.DecidedAt = &decidedAt.Time
+	}
+	return &ext, nil
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var assignmentDeadlineExtensionColumns = []string{
+	"id", "assignment_id", "requested_by", "requested_by_name",
+	"on_behalf_of_user_id", "on_behalf_of_user_name",
+	"previous_deadline", "requested_deadline", "reason", "status",
+	"decided_by", "decided_by_name", "decision_comment", "created_at", "decided_at",
+}
+
+func TestAssignmentRepository_CreateDeadlineExtensionWithOutbox(t *testing.T) {
+	assignmentID, requesterID := uuid.New(), uuid.New()
+	previous := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC)
+	requested := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "assignment:extension:journal", Payload: `{}`}
+	lockQuery := `SELECT status, deadline FROM assignments WHERE id = \$1 FOR UPDATE`
+	newExtension := func() models.AssignmentDeadlineExtension {
+		return models.AssignmentDeadlineExtension{ID: uuid.New(), AssignmentID: assignmentID, RequestedBy: requesterID, PreviousDeadline: &previous, RequestedDeadline: requested, Reason: "Ждем ответ смежников"}
+	}
+
+	t.Run("stores pending request", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+		ext := newExtension()
+		now := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(assignmentID).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "deadline"}).AddRow("in_progress", previous))
+		mock.ExpectExec(`INSERT INTO assignment_deadline_extensions`).
+			WithArgs(ext.ID, assignmentID, requesterID, ext.OnBehalfOfUserID, ext.PreviousDeadline, requested, ext.Reason, models.DeadlineExtensionPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT(.*)FROM assignment_deadline_extensions e(.*)WHERE e.id = \$1`).WithArgs(ext.ID).
+			WillReturnRows(sqlmock.NewRows(assignmentDeadlineExtensionColumns).AddRow(ext.ID, assignmentID, requesterID, "Петров", nil, "", previous, requested, ext.Reason, "pending", nil, "", "", now, nil))
+
+		res, err := repo.CreateDeadlineExtensionWithOutbox(ext, []models.OutboxEvent{event})
+		require.NoError(t, err)
+		assert.Equal(t, models.DeadlineExtensionPending, res.Status)
+		assert.Equal(t, "Петров", res.RequestedByName)
+		assert.Nil(t, res.DecidedBy)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stale deadline is a conflict", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(assignmentID).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "deadline"}).AddRow("in_progress", previous.AddDate(0, 0, 3)))
+		mock.ExpectRollback()
+
+		_, err = repo.CreateDeadlineExtensionWithOutbox(newExtension(), []models.OutboxEvent{event})
+		var appErr *models.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("second pending request is a conflict", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(assignmentID).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "deadline"}).AddRow("new", previous))
+		mock.ExpectExec(`INSERT INTO assignment_deadline_extensions`).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_assignment_deadline_extensions_pending"})
+		mock.ExpectRollback()
+
+		_, err = repo.CreateDeadlineExtensionWithOutbox(newExtension(), []models.OutboxEvent{event})
+		var appErr *models.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAssignmentRepository_DecideDeadlineExtensionWithOutbox(t *testing.T) {
+	extensionID, assignmentID, managerID := uuid.New(), uuid.New(), uuid.New()
+	previous := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC)
+	requested := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "assignment:extension:decision", Payload: `{}`}
+	decideQuery := `UPDATE assignment_deadline_extensions(.*)WHERE id = \$1 AND status = 'pending'(.*)RETURNING assignment_id, previous_deadline, requested_deadline`
+
+	t.Run("approval moves deadline and records history", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+		now := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(decideQuery).WithArgs(extensionID, models.DeadlineExtensionApproved, managerID, "").
+			WillReturnRows(sqlmock.NewRows([]string{"assignment_id", "previous_deadline", "requested_deadline"}).AddRow(assignmentID, previous, requested))
+		mock.ExpectExec(`UPDATE assignments(.*)SET original_deadline = COALESCE\(original_deadline, deadline\), deadline = \$2(.*)WHERE id = \$1 AND status IN \('new', 'in_progress', 'returned'\) AND deadline IS NOT DISTINCT FROM \$3::date`).
+			WithArgs(assignmentID, requested, &previous).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO assignment_deadline_history \(assignment_id, old_deadline, new_deadline, changed_by, extension_id\)`).
+			WithArgs(assignmentID, &previous, requested, managerID, extensionID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT(.*)FROM assignment_deadline_extensions e(.*)WHERE e.id = \$1`).WithArgs(extensionID).
+			WillReturnRows(sqlmock.NewRows(assignmentDeadlineExtensionColumns).AddRow(extensionID, assignmentID, uuid.New(), "Петров", nil, "", nil, requested, "Причина", "approved", managerID, "Иванов", "", now, now))
+
+		res, err := repo.DecideDeadlineExtensionWithOutbox(extensionID, models.DeadlineExtensionApproved, managerID, "", []models.OutboxEvent{event})
+		require.NoError(t, err)
+		require.NotNil(t, res.DecidedBy)
+		assert.Equal(t, managerID, *res.DecidedBy)
+		assert.NotNil(t, res.DecidedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejection keeps deadline", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+		now := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(decideQuery).WithArgs(extensionID, models.DeadlineExtensionRejected, managerID, "Срок критичен").
+			WillReturnRows(sqlmock.NewRows([]string{"assignment_id", "previous_deadline", "requested_deadline"}).AddRow(assignmentID, previous, requested))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT(.*)FROM assignment_deadline_extensions e(.*)WHERE e.id = \$1`).WithArgs(extensionID).
+			WillReturnRows(sqlmock.NewRows(assignmentDeadlineExtensionColumns).AddRow(extensionID, assignmentID, uuid.New(), "Петров", nil, "", nil, requested, "Причина", "rejected", managerID, "Иванов", "Срок критичен", now, now))
+
+		res, err := repo.DecideDeadlineExtensionWithOutbox(extensionID, models.DeadlineExtensionRejected, managerID, "Срок критичен", []models.OutboxEvent{event})
+		require.NoError(t, err)
+		assert.Equal(t, models.DeadlineExtensionRejected, res.Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("approval after a deadline or status change is a conflict", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(decideQuery).WithArgs(extensionID, models.DeadlineExtensionApproved, managerID, "").
+			WillReturnRows(sqlmock.NewRows([]string{"assignment_id", "previous_deadline", "requested_deadline"}).AddRow(assignmentID, previous, requested))
+		mock.ExpectExec(`UPDATE assignments`).WithArgs(assignmentID, requested, &previous).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err = repo.DecideDeadlineExtensionWithOutbox(extensionID, models.DeadlineExtensionApproved, managerID, "", []models.OutboxEvent{event})
+		var appErr *models.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already decided request is a conflict", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(decideQuery).WillReturnRows(sqlmock.NewRows([]string{"assignment_id", "previous_deadline", "requested_deadline"}))
+		mock.ExpectRollback()
+
+		_, err = repo.DecideDeadlineExtensionWithOutbox(extensionID, models.DeadlineExtensionApproved, managerID, "", []models.OutboxEvent{event})
+		var appErr *models.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAssignmentRepository_GetDeadlineHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewAssignmentRepository(&database.DB{DB: db})
+	assignmentID, extensionID := uuid.New(), uuid.New()
+	first := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC)
+	second := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM assignment_deadline_history h(.*)WHERE h.assignment_id = \$1(.*)ORDER BY h.created_at, h.id`).WithArgs(assignmentID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "assignment_id", "old_deadline", "new_deadline", "changed_by", "full_name", "extension_id", "created_at"}).
+			AddRow(uuid.New(), assignmentID, nil, first, uuid.New(), "Иванов", nil, now).
+			AddRow(uuid.New(), assignmentID, first, second, uuid.New(), "Иванов", extensionID, now))
+	mock.ExpectQuery(`SELECT original_deadline FROM assignments WHERE id = \$1`).WithArgs(assignmentID).
+		WillReturnRows(sqlmock.NewRows([]string{"original_deadline"}).AddRow(first))
+
+	changes, err := repo.GetDeadlineHistory(assignmentID)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Nil(t, changes[0].OldDeadline)
+	assert.Nil(t, changes[0].ExtensionID)
+	require.NotNil(t, changes[1].ExtensionID)
+	assert.Equal(t, extensionID, *changes[1].ExtensionID)
+
+	original, err := repo.GetOriginalDeadline(assignmentID)
+	require.NoError(t, err)
+	require.NotNil(t, original)
+	assert.True(t, original.Equal(first))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -13,6 +13,28 @@ import (
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 )
 
+// assignmentSelect — общая выборка поручения с документом, исполнителем и
+// счетчиками прямых подпоручений.
+const assignmentSelect = `
+	SELECT
+		a.id, a.document_id, d.kind, a.parent_id, a.series_id,
+		a.executor_id, u_executor.full_name,
+		a.content, a.deadline, a.status, a.report, a.completed_at,
+		a.created_at, a.updated_at,
+		d.registration_number as doc_number,
+		d.content as doc_subject,
+		subtasks.total, subtasks.open
+	FROM assignments a
+	JOIN documents d ON d.id = a.document_id
+	LEFT JOIN users u_executor ON a.executor_id = u_executor.id
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS total,
+		       COUNT(*) FILTER (WHERE c.status NOT IN ('finished', 'cancelled')) AS open
+		FROM assignments c
+		WHERE c.parent_id = a.id
+	) subtasks ON true
+`
+
 // AssignmentRepository предоставляет методы для работы с поручениями в БД.
 type AssignmentRepository struct {
 	db     *database.DB
@@ -21,30 +43,64 @@ type AssignmentRepository struct {
 
 func (r *AssignmentRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
 
+// assignmentInsert — новое поручение. ParentID задается для подпоручения,
+// SeriesID — для поручения серии; срок поручения серии запоминается в
+// series_deadline, по которому серия не создает поручение на срок дважды.
+type assignmentInsert struct {
+	ID            uuid.UUID
+	DocumentID    uuid.UUID
+	ParentID      *uuid.UUID
+	SeriesID      *uuid.UUID
+	ExecutorID    uuid.UUID
+	Content       string
+	Deadline      *time.Time
+	CoExecutorIDs []string
+	CreatedBy     uuid.UUID
+}
+
+// insertAssignmentTx создает поручение и его соисполнителей в транзакции tx.
+func insertAssignmentTx(tx *sql.Tx, a assignmentInsert) error {
+	var seriesDeadline *time.Time
+	if a.SeriesID != nil {
+		seriesDeadline = a.Deadline
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO assignments (id, document_id, parent_id, series_id, series_deadline, executor_id, content, deadline, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, a.ID, a.DocumentID, a.ParentID, a.SeriesID, seriesDeadline, a.ExecutorID, a.Content, a.Deadline, "new", a.CreatedBy); err != nil {
+		if isUniqueViolation(err, "idx_assignments_series_deadline") {
+			return models.NewConflict("поручение серии уже создано")
+		}
+		return fmt.Errorf("failed to create assignment: %w", err)
+	}
+	for _, coExecID := range a.CoExecutorIDs {
+		uid, err := uuid.Parse(coExecID)
+		if err != nil {
+			return fmt.Errorf("invalid co-executor ID %s: %w", coExecID, err)
+		}
+		if _, err = tx.Exec("INSERT INTO assignment_co_executors (assignment_id, user_id) VALUES ($1, $2)", a.ID, uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CreateWithOutbox persists the assignment and all supplied effects in one
 // transaction. It is used by production services that require no post-commit gap.
-func (r *AssignmentRepository) CreateWithOutbox(id, documentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, effects []models.OutboxEvent) (*models.Assignment, error) {
+func (r *AssignmentRepository) CreateWithOutbox(id, documentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, createdBy uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
 	if r.outbox == nil {
 		return nil, ErrOutboxNotConfigured
 	}
-	var createdAt, updatedAt time.Time
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
-	err = tx.QueryRow(`INSERT INTO assignments (id, document_id, executor_id, content, deadline, status) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at, updated_at`, id, documentID, executorID, content, deadline, "new").Scan(&createdAt, &updatedAt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create assignment: %w", err)
-	}
-	for _, coExecID := range coExecutorIDs {
-		uid, err := uuid.Parse(coExecID)
-		if err != nil {
-			return nil, fmt.Errorf("invalid co-executor ID %s: %w", coExecID, err)
-		}
-		if _, err = tx.Exec("INSERT INTO assignment_co_executors (assignment_id, user_id) VALUES ($1, $2)", id, uid); err != nil {
-			return nil, err
-		}
+	if err := insertAssignmentTx(tx, assignmentInsert{
+		ID: id, DocumentID: documentID, ExecutorID: executorID,
+		Content: content, Deadline: deadline, CoExecutorIDs: coExecutorIDs, CreatedBy: createdBy,
+	}); err != nil {
+		return nil, err
 	}
 	for _, effect := range effects {
 		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
@@ -184,7 +240,10 @@ func (r *AssignmentRepository) Update(
 	return r.GetByID(id)
 }
 
-func (r *AssignmentRepository) UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, effects []models.OutboxEvent) (*models.Assignment, error) {
+// UpdateWithOutbox сохраняет поручение и эффекты outbox в одной транзакции.
+// Изменение срока записывается в историю от имени actorID и закрывает отказом
+// открытый запрос на продление; первоначальный срок остается прежним.
+func (r *AssignmentRepository) UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, actorID uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
 	if r.outbox == nil {
 		return nil, ErrOutboxNotConfigured
 	}
@@ -193,7 +252,21 @@ func (r *AssignmentRepository) UpdateWithOutbox(id, executorID uuid.UUID, conten
 		return nil, err
 	}
 	defer tx.Rollback()
-	if _, err = tx.Exec(`UPDATE assignments SET executor_id=$1, content=$2, deadline=$3, status=$4, report=$5, completed_at=$6, updated_at=NOW() WHERE id=$7`, executorID, content, deadline, status, report, completedAt, id); err != nil {
+	if _, err = tx.Exec(`
+		INSERT INTO assignment_deadline_history (assignment_id, old_deadline, new_deadline, changed_by)
+		SELECT id, deadline, $2::date, $3 FROM assignments WHERE id = $1 AND deadline IS DISTINCT FROM $2::date
+	`, id, deadline, actorID); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(`
+		UPDATE assignment_deadline_extensions
+		SET status = 'rejected', decided_by = $3, decision_comment = 'Срок изменен распорядителем', decided_at = NOW()
+		WHERE assignment_id = $1 AND status = 'pending'
+			AND EXISTS (SELECT 1 FROM assignments WHERE id = $1 AND deadline IS DISTINCT FROM $2::date)
+	`, id, deadline, actorID); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(`UPDATE assignments SET executor_id=$1, content=$2, original_deadline=CASE WHEN deadline IS DISTINCT FROM $3::date THEN COALESCE(original_deadline, deadline) ELSE original_deadline END, deadline=$3, status=$4, report=$5, completed_at=$6, updated_at=NOW() WHERE id=$7`, executorID, content, deadline, status, report, completedAt, id); err != nil {
 		return nil, err
 	}
 	if _, err = tx.Exec("DELETE FROM assignment_co_executors WHERE assignment_id = $1", id); err != nil {
@@ -247,35 +320,7 @@ func (r *AssignmentRepository) DeleteWithOutbox(id uuid.UUID, effects []models.O
 
 // GetByID возвращает поручение по его ID.
 func (r *AssignmentRepository) GetByID(id uuid.UUID) (*models.Assignment, error) {
-	query := `
-		SELECT
-			a.id, a.document_id, d.kind,
-			a.executor_id, u_executor.full_name,
-			a.content, a.deadline, a.status, a.report, a.completed_at,
-			a.created_at, a.updated_at,
-			d.registration_number as doc_number,
-			d.content as doc_subject
-		FROM assignments a
-		JOIN documents d ON d.id = a.document_id
-		LEFT JOIN users u_executor ON a.executor_id = u_executor.id
-		WHERE a.id = $1
-	`
-
-	var a models.Assignment
-	var deadline sql.NullTime
-	var completedAt sql.NullTime
-	var report sql.NullString
-	var docNumber sql.NullString
-	var docSubject sql.NullString
-
-	err := r.db.QueryRow(query, id).Scan(
-		&a.ID, &a.DocumentID, &a.DocumentKind,
-		&a.ExecutorID, &a.ExecutorName,
-		&a.Content, &deadline, &a.Status, &report, &completedAt,
-		&a.CreatedAt, &a.UpdatedAt,
-		&docNumber, &docSubject,
-	)
-
+	a, err := scanAssignment(r.db.QueryRow(assignmentSelect+" WHERE a.id = $1", id))
 	if err == sql.ErrNoRows {
 		return nil, nil // Не найдено
 	}
@@ -283,22 +328,6 @@ func (r *AssignmentRepository) GetByID(id uuid.UUID) (*models.Assignment, error)
 		return nil, fmt.Errorf("failed to get assignment: %w", err)
 	}
 
-	if deadline.Valid {
-		a.Deadline = &deadline.Time
-	}
-	if completedAt.Valid {
-		a.CompletedAt = &completedAt.Time
-	}
-	if report.Valid {
-		a.Report = report.String
-	}
-	if docNumber.Valid {
-		a.DocumentNumber = docNumber.String
-	}
-	if docSubject.Valid {
-		a.DocumentSubject = docSubject.String
-	}
-
 	// Получение соисполнителей
 	coExecQuery := `
 		SELECT u.id, u.login, u.full_name
@@ -330,23 +359,56 @@ func (r *AssignmentRepository) GetByID(id uuid.UUID) (*models.Assignment, error)
 	a.CoExecutors = coExecutors
 	a.CoExecutorIDs = coExecutorIDs
 
+	return a, nil
+}
+
+func scanAssignment(scanner interface{ Scan(dest ...any) error }) (*models.Assignment, error) {
+	var a models.Assignment
+	var parentID, seriesID uuid.NullUUID
+	var deadline sql.NullTime
+	var completedAt sql.NullTime
+	var report sql.NullString
+	var docNumber sql.NullString
+	var docSubject sql.NullString
+
+	if err := scanner.Scan(
+		&a.ID, &a.DocumentID, &a.DocumentKind, &parentID, &seriesID,
+		&a.ExecutorID, &a.ExecutorName,
+		&a.Content, &deadline, &a.Status, &report, &completedAt,
+		&a.CreatedAt, &a.UpdatedAt,
+		&docNumber, &docSubject,
+		&a.SubtaskCount, &a.OpenSubtaskCount,
+	); err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		a.ParentID = &parentID.UUID
+	}
+	if seriesID.Valid {
+		a.SeriesID = &seriesID.UUID
+	}
+	if deadline.Valid {
+		a.Deadline = &deadline.Time
+	}
+	if completedAt.Valid {
+		a.CompletedAt = &completedAt.Time
+	}
+	if report.Valid {
+		a.Report = report.String
+	}
+	if docNumber.Valid {
+		a.DocumentNumber = docNumber.String
+	}
+	if docSubject.Valid {
+		a.DocumentSubject = docSubject.String
+	}
 	return &a, nil
 }
 
 // GetList возвращает список поручений с учетом фильтрации и пагинации.
 func (r *AssignmentRepository) GetList(filter models.AssignmentFilter) (*models.PagedResult[models.Assignment], error) {
-	query := `
-		SELECT
-			a.id, a.document_id, d.kind,
-			a.executor_id, u_executor.full_name,
-			a.content, a.deadline, a.status, a.report, a.completed_at,
-			a.created_at, a.updated_at,
-			d.registration_number as doc_number,
-			d.content as doc_subject
-		FROM assignments a
-		JOIN documents d ON d.id = a.document_id
-		LEFT JOIN users u_executor ON a.executor_id = u_executor.id
-	`
+	query := assignmentSelect
 
 	where := []string{"1=1"}
 	args := []interface{}{}
@@ -357,9 +419,14 @@ func (r *AssignmentRepository) GetList(filter models.AssignmentFilter) (*models.
 		args = append(args, filter.DocumentID)
 		argIdx++
 	}
+	if filter.SeriesID != "" {
+		where = append(where, fmt.Sprintf("a.series_id = $%d", argIdx))
+		args = append(args, filter.SeriesID)
+		argIdx++
+	}
 	accessibleIDs := accessibleUserIDs(filter.AccessibleByUserID, filter.AccessibleByUserIDs)
-	if len(filter.AllowedDocumentKinds) > 0 || len(accessibleIDs) > 0 {
-		accessClauses := make([]string, 0, 2)
+	if len(filter.AllowedDocumentKinds) > 0 || len(accessibleIDs) > 0 || len(filter.AccessibleByUserKindKeys) > 0 {
+		accessClauses := make([]string, 0, 3)
 		if len(filter.AllowedDocumentKinds) > 0 {
 			accessClauses = append(accessClauses, fmt.Sprintf("d.kind = ANY($%d)", argIdx))
 			args = append(args, pq.Array(filter.AllowedDocumentKinds))
@@ -375,6 +442,11 @@ func (r *AssignmentRepository) GetList(filter models.AssignmentFilter) (*models.
 			}
 			argIdx++
 		}
+		if len(filter.AccessibleByUserKindKeys) > 0 {
+			accessClauses = append(accessClauses, fmt.Sprintf("((a.executor_id::text || ':' || d.kind) = ANY($%d) OR EXISTS (SELECT 1 FROM assignment_co_executors ce WHERE ce.assignment_id = a.id AND (ce.user_id::text || ':' || d.kind) = ANY($%d)))", argIdx, argIdx))
+			args = append(args, pq.Array(filter.AccessibleByUserKindKeys))
+			argIdx++
+		}
 		where = append(where, "("+strings.Join(accessClauses, " OR ")+")")
 	}
 	if filter.ExecutorID != "" {
@@ -457,78 +529,18 @@ func (r *AssignmentRepository) GetList(filter models.AssignmentFilter) (*models.
 	defer rows.Close()
 
 	items := make([]models.Assignment, 0)
-	assignmentIDs := make([]uuid.UUID, 0)
-	assignmentIndex := map[uuid.UUID]int{} // ID поручения -> индекс в items
-
 	for rows.Next() {
-		var a models.Assignment
-		var deadline sql.NullTime
-		var completedAt sql.NullTime
-		var report sql.NullString
-		var docNumber sql.NullString
-		var docSubject sql.NullString
-
-		if err := rows.Scan(
-			&a.ID, &a.DocumentID, &a.DocumentKind,
-			&a.ExecutorID, &a.ExecutorName,
-			&a.Content, &deadline, &a.Status, &report, &completedAt,
-			&a.CreatedAt, &a.UpdatedAt,
-			&docNumber, &docSubject,
-		); err != nil {
+		a, err := scanAssignment(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		if deadline.Valid {
-			a.Deadline = &deadline.Time
-		}
-		if completedAt.Valid {
-			a.CompletedAt = &completedAt.Time
-		}
-		if report.Valid {
-			a.Report = report.String
-		}
-		if docNumber.Valid {
-			a.DocumentNumber = docNumber.String
-		}
-		if docSubject.Valid {
-			a.DocumentSubject = docSubject.String
-		}
-
-		assignmentIndex[a.ID] = len(items)
-		assignmentIDs = append(assignmentIDs, a.ID)
-		items = append(items, a)
+		items = append(items, *a)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	if len(assignmentIDs) > 0 {
-		coExecQuery := `
-			SELECT ce.assignment_id, u.id, u.login, u.full_name
-			FROM assignment_co_executors ce
-			JOIN users u ON ce.user_id = u.id
-			WHERE ce.assignment_id = ANY($1)
-		`
-		ceRows, err := r.db.Query(coExecQuery, pq.Array(assignmentIDs))
-		if err != nil {
-			return nil, fmt.Errorf("failed to get co-executors: %w", err)
-		}
-		defer ceRows.Close()
-
-		for ceRows.Next() {
-			var assignmentID uuid.UUID
-			var u models.User
-			if err := ceRows.Scan(&assignmentID, &u.ID, &u.Login, &u.FullName); err != nil {
-				return nil, fmt.Errorf("failed to scan co-executor: %w", err)
-			}
-
-			if idx, ok := assignmentIndex[assignmentID]; ok {
-				items[idx].CoExecutors = append(items[idx].CoExecutors, u)
-				items[idx].CoExecutorIDs = append(items[idx].CoExecutorIDs, u.ID.String())
-			}
-		}
-		if err := ceRows.Err(); err != nil {
-			return nil, err
-		}
+	if err := r.loadCoExecutors(items); err != nil {
+		return nil, err
 	}
 
 	return &models.PagedResult[models.Assignment]{
@@ -539,6 +551,125 @@ func (r *AssignmentRepository) GetList(filter models.AssignmentFilter) (*models.
 	}, nil
 }
 
+// GetSubtree возвращает все подпоручения поручения на любой глубине
+// в порядке создания. Само поручение в результат не входит.
+func (r *AssignmentRepository) GetSubtree(rootID uuid.UUID) ([]models.Assignment, error) {
+	rows, err := r.db.Query(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM assignments WHERE parent_id = $1
+			UNION ALL
+			SELECT c.id FROM assignments c JOIN subtree s ON c.parent_id = s.id
+		)
+	`+assignmentSelect+` WHERE a.id IN (SELECT id FROM subtree) ORDER BY a.created_at, a.id`, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment subtree: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.Assignment, 0)
+	for rows.Next() {
+		a, err := scanAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.loadCoExecutors(items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CreateSubtaskWithOutbox создает подпоручение вместе с эффектами outbox.
+// Родительское поручение блокируется до конца транзакции: подпоручение не
+// появится у уже исполненного поручения и не выйдет за измененный срок.
+func (r *AssignmentRepository) CreateSubtaskWithOutbox(id, parentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, createdBy uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
+	if r.outbox == nil {
+		return nil, ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var documentID uuid.UUID
+	var parentStatus string
+	var parentDeadline sql.NullTime
+	err = tx.QueryRow(`SELECT document_id, status, deadline FROM assignments WHERE id = $1 FOR UPDATE`, parentID).Scan(&documentID, &parentStatus, &parentDeadline)
+	if err == sql.ErrNoRows {
+		return nil, models.NewNotFound("поручение не найдено")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock parent assignment: %w", err)
+	}
+	switch parentStatus {
+	case "new", "in_progress", "returned":
+	default:
+		return nil, models.NewConflict("подпоручение можно создать только по поручению в работе")
+	}
+	if parentDeadline.Valid && (deadline == nil || deadline.After(parentDeadline.Time)) {
+		return nil, models.NewConflict("срок поручения изменен, обновите карточку")
+	}
+
+	if err := insertAssignmentTx(tx, assignmentInsert{
+		ID: id, DocumentID: documentID, ParentID: &parentID, ExecutorID: executorID,
+		Content: content, Deadline: deadline, CoExecutorIDs: coExecutorIDs, CreatedBy: createdBy,
+	}); err != nil {
+		return nil, err
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return r.GetByID(id)
+}
+
+func (r *AssignmentRepository) loadCoExecutors(items []models.Assignment) error {
+	if len(items) == 0 {
+		return nil
+	}
+	assignmentIDs := make([]uuid.UUID, 0, len(items))
+	assignmentIndex := make(map[uuid.UUID]int, len(items)) // ID поручения -> индекс в items
+	for i := range items {
+		assignmentIndex[items[i].ID] = i
+		assignmentIDs = append(assignmentIDs, items[i].ID)
+	}
+
+	coExecQuery := `
+		SELECT ce.assignment_id, u.id, u.login, u.full_name
+		FROM assignment_co_executors ce
+		JOIN users u ON ce.user_id = u.id
+		WHERE ce.assignment_id = ANY($1)
+	`
+	ceRows, err := r.db.Query(coExecQuery, pq.Array(assignmentIDs))
+	if err != nil {
+		return fmt.Errorf("failed to get co-executors: %w", err)
+	}
+	defer ceRows.Close()
+
+	for ceRows.Next() {
+		var assignmentID uuid.UUID
+		var u models.User
+		if err := ceRows.Scan(&assignmentID, &u.ID, &u.Login, &u.FullName); err != nil {
+			return fmt.Errorf("failed to scan co-executor: %w", err)
+		}
+
+		if idx, ok := assignmentIndex[assignmentID]; ok {
+			items[idx].CoExecutors = append(items[idx].CoExecutors, u)
+			items[idx].CoExecutorIDs = append(items[idx].CoExecutorIDs, u.ID.String())
+		}
+	}
+	return ceRows.Err()
+}
+
 // HasDocumentAccess проверяет, есть ли у пользователя доступ к документу как у исполнителя или соисполнителя поручения.
 func (r *AssignmentRepository) HasDocumentAccess(userID, documentID uuid.UUID) (bool, error) {
 	var hasAccess bool
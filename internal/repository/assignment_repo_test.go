@@ -15,6 +15,15 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+var assignmentColumns = []string{
+	"id", "document_id", "kind", "parent_id", "series_id",
+	"executor_id", "full_name",
+	"content", "deadline", "status", "report", "completed_at",
+	"created_at", "updated_at",
+	"doc_number", "doc_subject",
+	"total", "open",
+}
+
 func TestAssignmentRepository_GetByID(t *testing.T) {
 	// Получение деталей поручения по его ID
 	db, mock, err := sqlmock.New()
@@ -28,18 +37,13 @@ func TestAssignmentRepository_GetByID(t *testing.T) {
 	expectedQuery := `SELECT(.*)FROM assignments a(.*)JOIN documents d ON d.id = a.document_id(.*)WHERE a.id = \$1`
 
 	t.Run("success without co-executors", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{
-			"id", "document_id", "kind",
-			"executor_id", "full_name",
-			"content", "deadline", "status", "report", "completed_at",
-			"created_at", "updated_at",
-			"doc_number", "doc_subject",
-		}).AddRow(
-			assignID, uuid.New(), "incoming",
+		rows := sqlmock.NewRows(assignmentColumns).AddRow(
+			assignID, uuid.New(), "incoming", nil, nil,
 			uuid.New(), "Иванов И.И.",
 			"Выполнить задачу", now, "new", nil, nil,
 			now, now,
 			"ВХ-1", "Тема",
+			0, 0,
 		)
 
 		mock.ExpectQuery(expectedQuery).WithArgs(assignID).WillReturnRows(rows)
@@ -107,11 +111,39 @@ func TestAssignmentRepositoryDeleteWithOutboxRollsBackOnEnqueueFailure(t *testin
 
 func TestAssignmentRepositoryAtomicMethodsRequireOutbox(t *testing.T) {
 	repo := NewAssignmentRepository(nil)
-	_, err := repo.CreateWithOutbox(uuid.New(), uuid.New(), uuid.New(), "тест", nil, nil, nil)
+	_, err := repo.CreateWithOutbox(uuid.New(), uuid.New(), uuid.New(), "тест", nil, nil, uuid.New(), nil)
 	require.ErrorIs(t, err, ErrOutboxNotConfigured)
-	_, err = repo.UpdateWithOutbox(uuid.New(), uuid.New(), "тест", nil, "new", "", nil, nil, nil)
+	_, err = repo.UpdateWithOutbox(uuid.New(), uuid.New(), "тест", nil, "new", "", nil, nil, uuid.New(), nil)
 	require.ErrorIs(t, err, ErrOutboxNotConfigured)
 	require.ErrorIs(t, repo.DeleteWithOutbox(uuid.New(), nil), ErrOutboxNotConfigured)
+	_, err = repo.CreateSubtaskWithOutbox(uuid.New(), uuid.New(), uuid.New(), "тест", nil, nil, uuid.New(), nil)
+	require.ErrorIs(t, err, ErrOutboxNotConfigured)
+}
+
+func TestAssignmentRepository_UpdateWithOutboxDeadlineChange(t *testing.T) {
+	// Прямое изменение срока закрывает открытый запрос на продление и не
+	// сдвигает первоначальный срок
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewAssignmentRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+	id, executorID, actorID := uuid.New(), uuid.New(), uuid.New()
+	deadline := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO assignment_deadline_history`).WithArgs(id, &deadline, actorID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE assignment_deadline_extensions\s+SET status = 'rejected', decided_by = \$3(.*)WHERE assignment_id = \$1 AND status = 'pending'(.*)deadline IS DISTINCT FROM \$2::date`).
+		WithArgs(id, &deadline, actorID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE assignments SET executor_id=\$1, content=\$2, original_deadline=CASE WHEN deadline IS DISTINCT FROM \$3::date THEN COALESCE\(original_deadline, deadline\) ELSE original_deadline END`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM assignment_co_executors`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`FROM assignments`).WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.UpdateWithOutbox(id, executorID, "тест", &deadline, "in_progress", "", nil, nil, actorID, nil)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestAssignmentRepository_Create(t *testing.T) {
@@ -148,13 +180,7 @@ func TestAssignmentRepository_Create(t *testing.T) {
 	// После Commit идет GetByID
 	expectedGetQuery := `SELECT(.*)FROM assignments a(.*)JOIN documents d ON d.id = a.document_id(.*)WHERE a.id = \$1`
 
-	rows := sqlmock.NewRows([]string{
-		"id", "document_id", "kind",
-		"executor_id", "full_name",
-		"content", "deadline", "status", "report", "completed_at",
-		"created_at", "updated_at",
-		"doc_number", "doc_subject",
-	}).AddRow(assignID, docID, "incoming", execID, "Иванов", "Текст", now, "new", nil, nil, now, now, "", "")
+	rows := sqlmock.NewRows(assignmentColumns).AddRow(assignID, docID, "incoming", nil, nil, execID, "Иванов", "Текст", now, "new", nil, nil, now, now, "", "", 0, 0)
 
 	mock.ExpectQuery(expectedGetQuery).WithArgs(assignID).WillReturnRows(rows)
 	mock.ExpectQuery(`SELECT u.id, u.login, u.full_name FROM assignment_co_executors`).WithArgs(assignID).WillReturnRows(sqlmock.NewRows([]string{"id", "login", "full_name"}))
@@ -192,13 +218,7 @@ func TestAssignmentRepository_Update(t *testing.T) {
 
 	// getByID call mock for the return
 	expectedGetQuery := `SELECT(.*)FROM assignments a(.*)`
-	rows := sqlmock.NewRows([]string{
-		"id", "document_id", "kind",
-		"executor_id", "full_name",
-		"content", "deadline", "status", "report", "completed_at",
-		"created_at", "updated_at",
-		"doc_number", "doc_subject",
-	}).AddRow(assignID, uuid.New(), "incoming", execID, "Иванов", "Обновленный текст", now, "in_progress", "Отчет", now, now, now, "", "")
+	rows := sqlmock.NewRows(assignmentColumns).AddRow(assignID, uuid.New(), "incoming", nil, nil, execID, "Иванов", "Обновленный текст", now, "in_progress", "Отчет", now, now, now, "", "", 0, 0)
 
 	mock.ExpectQuery(expectedGetQuery).WithArgs(assignID).WillReturnRows(rows)
 	mock.ExpectQuery(`SELECT(.*)FROM assignment_co_executors(.*)`).WithArgs(assignID).WillReturnRows(sqlmock.NewRows([]string{"id", "login", "full_name"}))
@@ -230,11 +250,7 @@ func TestAssignmentRepository_GetList(t *testing.T) {
 
 	query := `SELECT(.*)FROM assignments a(.*)JOIN documents d ON d.id = a.document_id(.*)`
 
-	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{
-		"id", "document_id", "kind", "executor_id", "full_name",
-		"content", "deadline", "status", "report", "completed_at",
-		"created_at", "updated_at", "doc_number", "doc_subject",
-	}).AddRow(uuid.New(), uuid.New(), "incoming", uuid.New(), "Executor", "Content", now, "new", nil, nil, now, now, "doc-1", "subj-1"))
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(assignmentColumns).AddRow(uuid.New(), uuid.New(), "incoming", nil, nil, uuid.New(), "Executor", "Content", now, "new", nil, nil, now, now, "doc-1", "subj-1", 0, 0))
 
 	// Co-executors fetching
 	mock.ExpectQuery(`SELECT(.*)FROM assignment_co_executors(.*)`).
@@ -293,11 +309,7 @@ func TestAssignmentRepository_GetListFiltersAndErrors(t *testing.T) {
 		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM assignments a JOIN documents d ON d.id = a.document_id(.*)`).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 		mock.ExpectQuery(`SELECT(.*)FROM assignments a(.*)JOIN documents d ON d.id = a.document_id(.*)`).
-			WillReturnRows(sqlmock.NewRows([]string{
-				"id", "document_id", "kind", "executor_id", "full_name",
-				"content", "deadline", "status", "report", "completed_at",
-				"created_at", "updated_at", "doc_number", "doc_subject",
-			}))
+			WillReturnRows(sqlmock.NewRows(assignmentColumns))
 
 		res, err := repo.GetList(filter)
 		require.NoError(t, err)
@@ -309,6 +321,24 @@ func TestAssignmentRepository_GetListFiltersAndErrors(t *testing.T) {
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("series filter", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		seriesID := uuid.New().String()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM assignments a(.*)a.series_id = \$1`).WithArgs(seriesID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`SELECT(.*)FROM assignments a(.*)a.series_id = \$1`).WithArgs(seriesID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(assignmentColumns))
+
+		_, err = repo.GetList(models.AssignmentFilter{SeriesID: seriesID, ShowFinished: true})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("count database error", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
@@ -410,3 +440,84 @@ func TestAssignmentRepository_GetCountByStatus(t *testing.T) {
 	assert.Equal(t, 12, count)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestAssignmentRepository_CreateSubtaskWithOutbox(t *testing.T) {
+	parentID, subtaskID, documentID, executorID, creatorID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	parentDeadline := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+	deadline := time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC)
+	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "assignment:subtask:journal", Payload: `{}`}
+	lockQuery := `SELECT document_id, status, deadline FROM assignments WHERE id = \$1 FOR UPDATE`
+
+	t.Run("creates subtask under locked parent", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+		now := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(parentID).
+			WillReturnRows(sqlmock.NewRows([]string{"document_id", "status", "deadline"}).AddRow(documentID, "in_progress", parentDeadline))
+		mock.ExpectExec(`INSERT INTO assignments \(id, document_id, parent_id, series_id, series_deadline, executor_id, content, deadline, status, created_by\)`).
+			WithArgs(subtaskID, documentID, parentID, nil, nil, executorID, "Часть работы", &deadline, "new", creatorID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT(.*)FROM assignments a(.*)WHERE a.id = \$1`).WithArgs(subtaskID).
+			WillReturnRows(sqlmock.NewRows(assignmentColumns).AddRow(subtaskID, documentID, "incoming_letter", parentID, nil, executorID, "Петров", "Часть работы", deadline, "new", nil, nil, now, now, "ВХ-1", "Тема", 0, 0))
+		mock.ExpectQuery(`SELECT u.id, u.login, u.full_name FROM assignment_co_executors`).WithArgs(subtaskID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "login", "full_name"}))
+
+		subtask, err := repo.CreateSubtaskWithOutbox(subtaskID, parentID, executorID, "Часть работы", &deadline, nil, creatorID, []models.OutboxEvent{event})
+		require.NoError(t, err)
+		require.NotNil(t, subtask.ParentID)
+		assert.Equal(t, parentID, *subtask.ParentID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("closed parent rejects subtask", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewAssignmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(parentID).
+			WillReturnRows(sqlmock.NewRows([]string{"document_id", "status", "deadline"}).AddRow(documentID, "completed", parentDeadline))
+		mock.ExpectRollback()
+
+		_, err = repo.CreateSubtaskWithOutbox(subtaskID, parentID, executorID, "Часть работы", &deadline, nil, creatorID, []models.OutboxEvent{event})
+		var appErr *models.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAssignmentRepository_GetSubtree(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewAssignmentRepository(&database.DB{DB: db})
+	rootID, childID, grandchildID, coExecutorID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	documentID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`WITH RECURSIVE subtree AS (.*)SELECT(.*)FROM assignments a(.*)WHERE a.id IN \(SELECT id FROM subtree\) ORDER BY a.created_at, a.id`).
+		WithArgs(rootID).
+		WillReturnRows(sqlmock.NewRows(assignmentColumns).
+			AddRow(childID, documentID, "incoming_letter", rootID, nil, uuid.New(), "Петров", "Часть", nil, "in_progress", nil, nil, now, now, "ВХ-1", "Тема", 1, 1).
+			AddRow(grandchildID, documentID, "incoming_letter", childID, nil, uuid.New(), "Сидоров", "Деталь", nil, "new", nil, nil, now, now, "ВХ-1", "Тема", 0, 0))
+	mock.ExpectQuery(`SELECT ce.assignment_id, u.id, u.login, u.full_name(.*)WHERE ce.assignment_id = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"assignment_id", "id", "login", "full_name"}).AddRow(grandchildID, coExecutorID, "co", "Соисполнитель"))
+
+	items, err := repo.GetSubtree(rootID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, rootID, *items[0].ParentID)
+	assert.Equal(t, 1, items[0].OpenSubtaskCount)
+	assert.Equal(t, []string{coExecutorID.String()}, items[1].CoExecutorIDs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// assignmentSeriesSelect — выборка серии с документом, исполнителем, автором
+// и счетчиками созданных поручений.
+const assignmentSeriesSelect = `
+	SELECT s.id, s.document_id, d.kind, COALESCE(d.registration_number, ''),
+		s.executor_id, COALESCE(u_executor.full_name, ''), s.co_executor_ids, s.content,
+		s.schedule_kind, s.cron_expression, s.working_day, s.month_interval,
+		s.lead_days, s.starts_on, s.ends_on, s.last_deadline, s.paused_on, s.resumed_on, s.status,
+		s.created_by, COALESCE(u_author.full_name, ''), s.created_at, s.updated_at,
+		instances.total, instances.open
+	FROM assignment_series s
+	JOIN documents d ON d.id = s.document_id
+	LEFT JOIN users u_executor ON u_executor.id = s.executor_id
+	LEFT JOIN users u_author ON u_author.id = s.created_by
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS total,
+		       COUNT(*) FILTER (WHERE a.status NOT IN ('finished', 'cancelled')) AS open
+		FROM assignments a
+		WHERE a.series_id = s.id
+	) instances ON true`
+
+// CreateSeriesWithOutbox сохраняет серию поручений вместе с эффектами outbox.
+func (r *AssignmentRepository) CreateSeriesWithOutbox(series models.AssignmentSeries, effects []models.OutboxEvent) (*models.AssignmentSeries, error) {
+	if r.outbox == nil {
+		return nil, ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`
+		INSERT INTO assignment_series (
+			id, document_id, executor_id, co_executor_ids, content,
+			schedule_kind, cron_expression, working_day, month_interval,
+			lead_days, starts_on, ends_on, status, created_by
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, series.ID, series.DocumentID, series.ExecutorID, pq.Array(series.CoExecutorIDs), series.Content,
+		series.Schedule.Kind, series.Schedule.Cron, series.Schedule.WorkingDay, series.Schedule.MonthInterval,
+		series.LeadDays, series.StartsOn, series.EndsOn, models.AssignmentSeriesActive, series.CreatedBy); err != nil {
+		return nil, fmt.Errorf("failed to create assignment series: %w", err)
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return r.GetSeries(series.ID)
+}
+
+// UpdateSeriesStatusWithOutbox переводит серию в status, если ее текущий
+// статус входит в from. Приостановка и возобновление запоминают даты паузы.
+// Созданные поручения серии не меняются.
+func (r *AssignmentRepository) UpdateSeriesStatusWithOutbox(id uuid.UUID, from []string, status string, effects []models.OutboxEvent) (*models.AssignmentSeries, error) {
+	if r.outbox == nil {
+		return nil, ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE assignment_series
+		SET paused_on = CASE WHEN $2::varchar = 'paused' THEN CURRENT_DATE ELSE paused_on END,
+			resumed_on = CASE WHEN $2::varchar = 'paused' THEN NULL
+				WHEN status = 'paused' AND $2::varchar = 'active' THEN CURRENT_DATE ELSE resumed_on END,
+			status = $2, updated_at = NOW()
+		WHERE id = $1 AND status = ANY($3)
+	`, id, status, pq.Array(from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update assignment series status: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, models.NewConflict("статус серии поручений изменен, обновите карточку")
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return r.GetSeries(id)
+}
+
+// CreateSeriesOccurrenceWithOutbox создает поручение серии со сроком deadline
+// и запоминает этот срок как последний. Серия блокируется до конца
+// транзакции; если она приостановлена или ее последний срок уже не равен
+// previous (поручение создал параллельный проход), возвращается конфликт.
+func (r *AssignmentRepository) CreateSeriesOccurrenceWithOutbox(seriesID uuid.UUID, previous *time.Time, assignmentID uuid.UUID, deadline time.Time, effects []models.OutboxEvent) (*models.Assignment, error) {
+	if r.outbox == nil {
+		return nil, ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var documentID, executorID, createdBy uuid.UUID
+	var coExecutorIDs []string
+	var content, status string
+	var lastDeadline sql.NullTime
+	err = tx.QueryRow(`
+		SELECT document_id, executor_id, co_executor_ids, content, status, last_deadline, created_by
+		FROM assignment_series WHERE id = $1 FOR UPDATE
+	`, seriesID).Scan(&documentID, &executorID, pq.Array(&coExecutorIDs), &content, &status, &lastDeadline, &createdBy)
+	if err == sql.ErrNoRows {
+		return nil, models.NewNotFound("серия поручений не найдена")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock assignment series: %w", err)
+	}
+	if status != models.AssignmentSeriesActive {
+		return nil, models.NewConflict("серия поручений не активна")
+	}
+	if lastDeadline.Valid != (previous != nil) || (previous != nil && lastDeadline.Time.Format("2006-01-02") != previous.Format("2006-01-02")) {
+		return nil, models.NewConflict("поручение серии уже создано")
+	}
+
+	if err := insertAssignmentTx(tx, assignmentInsert{
+		ID: assignmentID, DocumentID: documentID, SeriesID: &seriesID, ExecutorID: executorID,
+		Content: content, Deadline: &deadline, CoExecutorIDs: coExecutorIDs, CreatedBy: createdBy,
+	}); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(`UPDATE assignment_series SET last_deadline = $2, updated_at = NOW() WHERE id = $1`, seriesID, deadline); err != nil {
+		return nil, fmt.Errorf("failed to advance assignment series: %w", err)
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return r.GetByID(assignmentID)
+}
+
+// SkipSeriesDeadlineWithOutbox запоминает срок серии, поручение на который не
+// создается, вместе с записью журнала о пропуске.
+func (r *AssignmentRepository) SkipSeriesDeadlineWithOutbox(seriesID uuid.UUID, previous *time.Time, deadline time.Time, effects []models.OutboxEvent) error {
+	if r.outbox == nil {
+		return ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE assignment_series SET last_deadline = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'active' AND last_deadline IS NOT DISTINCT FROM $2::date
+	`, seriesID, previous, deadline)
+	if err != nil {
+		return fmt.Errorf("failed to skip assignment series deadline: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return models.NewConflict("серия поручений изменена")
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetSeries возвращает серию поручений по ID.
+func (r *AssignmentRepository) GetSeries(id uuid.UUID) (*models.AssignmentSeries, error) {
+	series, err := scanAssignmentSeries(r.db.QueryRow(assignmentSeriesSelect+` WHERE s.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.NewNotFound("серия поручений не найдена")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment series: %w", err)
+	}
+	return series, nil
+}
+
+// GetSeriesByDocument возвращает серии поручений документа в порядке создания.
+func (r *AssignmentRepository) GetSeriesByDocument(documentID uuid.UUID) ([]models.AssignmentSeries, error) {
+	return r.querySeries(assignmentSeriesSelect+` WHERE s.document_id = $1 ORDER BY s.created_at, s.id`, documentID)
+}
+
+// GetActiveSeries возвращает серии, по которым генератор создает поручения.
+func (r *AssignmentRepository) GetActiveSeries() ([]models.AssignmentSeries, error) {
+	return r.querySeries(assignmentSeriesSelect + ` WHERE s.status = 'active' ORDER BY s.created_at, s.id`)
+}
+
+func (r *AssignmentRepository) querySeries(query string, args ...any) ([]models.AssignmentSeries, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment series: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.AssignmentSeries, 0)
+	for rows.Next() {
+		series, err := scanAssignmentSeries(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *series)
+	}
+	return items, rows.Err()
+}
+
+func scanAssignmentSeries(scanner interface{ Scan(dest ...any) error }) (*models.AssignmentSeries, error) {
+	var s models.AssignmentSeries
+	var endsOn, lastDeadline, pausedOn, resumedOn sql.NullTime
+	if err := scanner.Scan(
+		&s.ID, &s.DocumentID, &s.DocumentKind, &s.DocumentNumber,
+		&s.ExecutorID, &s.ExecutorName, pq.Array(&s.CoExecutorIDs), &s.Content,
+		&s.Schedule.Kind, &s.Schedule.Cron, &s.Schedule.WorkingDay, &s.Schedule.MonthInterval,
+		&s.LeadDays, &s.StartsOn, &endsOn, &lastDeadline, &pausedOn, &resumedOn, &s.Status,
+		&s.CreatedBy, &s.CreatedByName, &s.CreatedAt, &s.UpdatedAt,
+		&s.InstanceCount, &s.OpenInstanceCount,
+	); err != nil {
+		return nil, err
+	}
+	if endsOn.Valid {
+		s.EndsOn = &endsOn.Time
+	}
+	if lastDeadline.Valid {
+		s.LastDeadline = &lastDeadline.Time
+	}
+	if pausedOn.Valid {
+		s.PausedOn = &pausedOn.Time
+	}
+	if resumedOn.Valid {
+		s.ResumedOn = &resumedOn.Time
+	}
+	return &s, nil
+}
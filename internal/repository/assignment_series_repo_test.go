@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var assignmentSeriesColumns = []string{
+	"id", "document_id", "kind", "registration_number",
+	"executor_id", "executor_name", "co_executor_ids", "content",
+	"schedule_kind", "cron_expression", "working_day", "month_interval",
+	"lead_days", "starts_on", "ends_on", "last_deadline", "paused_on", "resumed_on", "status",
+	"created_by", "created_by_name", "created_at", "updated_at",
+	"total", "open",
+}
+
+func newAssignmentSeriesRepo(t *testing.T) (*AssignmentRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	repo := NewAssignmentRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+	return repo, mock
+}
+
+func TestAssignmentRepository_CreateSeriesWithOutbox(t *testing.T) {
+	repo, mock := newAssignmentSeriesRepo(t)
+	now := time.Now()
+	startsOn := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	coExecutorID := uuid.New().String()
+	series := models.AssignmentSeries{
+		ID: uuid.New(), DocumentID: uuid.New(), ExecutorID: uuid.New(), CoExecutorIDs: []string{coExecutorID},
+		Content:  "Ежемесячный отчет",
+		Schedule: models.AssignmentSchedule{Kind: models.AssignmentScheduleWorkingDay, WorkingDay: 5, MonthInterval: 1},
+		LeadDays: 7, StartsOn: startsOn, CreatedBy: uuid.New(),
+	}
+	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "assignment-series:created:journal", Payload: `{}`}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO assignment_series`).
+		WithArgs(series.ID, series.DocumentID, series.ExecutorID, pq.Array(series.CoExecutorIDs), series.Content,
+			models.AssignmentScheduleWorkingDay, "", 5, 1, 7, startsOn, series.EndsOn, models.AssignmentSeriesActive, series.CreatedBy).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT(.*)FROM assignment_series s(.*)WHERE s.id = \$1`).WithArgs(series.ID).
+		WillReturnRows(sqlmock.NewRows(assignmentSeriesColumns).AddRow(
+			series.ID, series.DocumentID, "administrative_order", "П-12",
+			series.ExecutorID, "Петров", "{"+coExecutorID+"}", series.Content,
+			"working_day", "", 5, 1, 7, startsOn, nil, nil, nil, nil, "active",
+			series.CreatedBy, "Иванов", now, now, 0, 0))
+
+	res, err := repo.CreateSeriesWithOutbox(series, []models.OutboxEvent{event})
+	require.NoError(t, err)
+	assert.Equal(t, "П-12", res.DocumentNumber)
+	assert.Equal(t, []string{coExecutorID}, res.CoExecutorIDs)
+	assert.Equal(t, models.AssignmentSchedule{Kind: models.AssignmentScheduleWorkingDay, WorkingDay: 5, MonthInterval: 1}, res.Schedule)
+	assert.Nil(t, res.LastDeadline)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignmentRepository_CreateSeriesOccurrenceWithOutbox(t *testing.T) {
+	seriesID, documentID, executorID, authorID, assignmentID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	coExecutorID := uuid.New()
+	previous := time.Date(2026, 8, 7, 0, 0, 0, 0, time.UTC)
+	deadline := time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC)
+	lockQuery := `SELECT document_id, executor_id, co_executor_ids, content, status, last_deadline, created_by\s+FROM assignment_series WHERE id = \$1 FOR UPDATE`
+	lockRow := func(status string, last any) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"document_id", "executor_id", "co_executor_ids", "content", "status", "last_deadline", "created_by"}).
+			AddRow(documentID, executorID, "{"+coExecutorID.String()+"}", "Отчет", status, last, authorID)
+	}
+
+	t.Run("creates assignment and advances series", func(t *testing.T) {
+		repo, mock := newAssignmentSeriesRepo(t)
+		now := time.Now()
+		event := models.OutboxEvent{EventType: models.OutboxEventUserEvent, DeduplicationKey: "assignment:created:user_event", Payload: `{}`}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(seriesID).WillReturnRows(lockRow("active", previous))
+		mock.ExpectExec(`INSERT INTO assignments \(id, document_id, parent_id, series_id, series_deadline, executor_id, content, deadline, status, created_by\)`).
+			WithArgs(assignmentID, documentID, nil, seriesID, deadline, executorID, "Отчет", deadline, "new", authorID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO assignment_co_executors`).WithArgs(assignmentID, coExecutorID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE assignment_series SET last_deadline = \$2`).WithArgs(seriesID, deadline).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT(.*)FROM assignments a(.*)WHERE a.id = \$1`).WithArgs(assignmentID).
+			WillReturnRows(sqlmock.NewRows(assignmentColumns).AddRow(assignmentID, documentID, "administrative_order", nil, seriesID, executorID, "Петров", "Отчет", deadline, "new", nil, nil, now, now, "П-12", "Тема", 0, 0))
+		mock.ExpectQuery(`SELECT u.id, u.login, u.full_name(.*)FROM assignment_co_executors`).WithArgs(assignmentID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "login", "full_name"}))
+
+		res, err := repo.CreateSeriesOccurrenceWithOutbox(seriesID, &previous, assignmentID, deadline, []models.OutboxEvent{event})
+		require.NoError(t, err)
+		require.NotNil(t, res.SeriesID)
+		assert.Equal(t, seriesID, *res.SeriesID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("parallel run is a conflict", func(t *testing.T) {
+		repo, mock := newAssignmentSeriesRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(seriesID).WillReturnRows(lockRow("active", deadline))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateSeriesOccurrenceWithOutbox(seriesID, &previous, assignmentID, deadline, nil)
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("paused series is a conflict", func(t *testing.T) {
+		repo, mock := newAssignmentSeriesRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(seriesID).WillReturnRows(lockRow("paused", nil))
+		mock.ExpectRollback()
+
+		_, err := repo.CreateSeriesOccurrenceWithOutbox(seriesID, nil, assignmentID, deadline, nil)
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Contains(t, appErr.Message, "не активна")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAssignmentRepository_UpdateSeriesStatusWithOutbox(t *testing.T) {
+	seriesID := uuid.New()
+	from := []string{models.AssignmentSeriesActive}
+
+	t.Run("stale status is a conflict", func(t *testing.T) {
+		repo, mock := newAssignmentSeriesRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE assignment_series SET paused_on = (.*)status = \$2, updated_at = NOW\(\) WHERE id = \$1 AND status = ANY\(\$3\)`).
+			WithArgs(seriesID, models.AssignmentSeriesPaused, pq.Array(from)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.UpdateSeriesStatusWithOutbox(seriesID, from, models.AssignmentSeriesPaused, nil)
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("skip deadline checks last deadline and writes the journal", func(t *testing.T) {
+		repo, mock := newAssignmentSeriesRepo(t)
+		deadline := time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC)
+		event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "assignment-series:skipped:journal", Payload: `{}`}
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE assignment_series SET last_deadline = \$3(.*)last_deadline IS NOT DISTINCT FROM \$2::date`).
+			WithArgs(seriesID, nil, deadline).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.SkipSeriesDeadlineWithOutbox(seriesID, nil, deadline, []models.OutboxEvent{event}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAssignmentRepository_GetActiveSeries(t *testing.T) {
+	repo, mock := newAssignmentSeriesRepo(t)
+	now := time.Now()
+	last := time.Date(2026, 8, 7, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT(.*)FROM assignment_series s(.*)WHERE s.status = 'active'`).
+		WillReturnRows(sqlmock.NewRows(assignmentSeriesColumns).AddRow(
+			uuid.New(), uuid.New(), "incoming_letter", "ВХ-1",
+			uuid.New(), "Петров", "{}", "Сверка",
+			"cron", "1 * *", 0, 1, 3, now, nil, last, nil, nil, "active",
+			uuid.New(), "Иванов", now, now, 4, 1))
+
+	items, err := repo.GetActiveSeries()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "1 * *", items[0].Schedule.Cron)
+	assert.Equal(t, last, *items[0].LastDeadline)
+	assert.Equal(t, 4, items[0].InstanceCount)
+	assert.Equal(t, 1, items[0].OpenInstanceCount)
+	assert.Empty(t, items[0].CoExecutorIDs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
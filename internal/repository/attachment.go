@@ -76,12 +76,7 @@ func (r *AttachmentRepository) Create(a *models.Attachment) error {
 		return err
 	}
 	defer tx.Rollback()
-	if err := tx.QueryRow(
-		`INSERT INTO attachments (document_id, filename, storage_path, file_size, content_type, uploaded_by)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, uploaded_at`,
-		a.DocumentID, a.Filename, a.StoragePath, a.FileSize, a.ContentType, a.UploadedBy,
-	).Scan(&a.ID, &a.UploadedAt); err != nil {
+	if err := insertAttachmentTx(tx, a); err != nil {
 		return err
 	}
 	if err := incrementStorageStatisticsTx(tx, a.FileSize); err != nil {
@@ -90,18 +85,44 @@ func (r *AttachmentRepository) Create(a *models.Attachment) error {
 	return tx.Commit()
 }
 
+// errAttachmentDuplicate возвращается при повторной загрузке того же содержимого в документ.
+var errAttachmentDuplicate = models.NewConflict("файл с таким же содержимым уже прикреплен к документу")
+
+// insertAttachmentTx вставляет вложение; совпадение SHA-256 с другим вложением
+// документа отклоняется уникальным индексом, поэтому проверка не зависит от гонок.
+func insertAttachmentTx(tx *sql.Tx, a *models.Attachment) error {
+	if a.IntegrityStatus == "" {
+		a.IntegrityStatus = models.AttachmentIntegrityUnverified
+	}
+	err := tx.QueryRow(
+		`INSERT INTO attachments (document_id, filename, storage_path, file_size, content_type, uploaded_by, sha256, integrity_status)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		RETURNING id, uploaded_at`,
+		a.DocumentID, a.Filename, a.StoragePath, a.FileSize, a.ContentType, a.UploadedBy, a.SHA256, a.IntegrityStatus,
+	).Scan(&a.ID, &a.UploadedAt)
+	if isUniqueViolation(err, "idx_attachments_document_sha256") {
+		return errAttachmentDuplicate
+	}
+	return err
+}
+
+// CreateWithOutbox сохраняет вложение, ставит его в очередь на извлечение текста
+// и записывает переданные события outbox в одной транзакции.
 func (r *AttachmentRepository) CreateWithOutbox(a *models.Attachment, effects []models.OutboxEvent) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
-	if err := tx.QueryRow(`INSERT INTO attachments (document_id, filename, storage_path, file_size, content_type, uploaded_by) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, uploaded_at`, a.DocumentID, a.Filename, a.StoragePath, a.FileSize, a.ContentType, a.UploadedBy).Scan(&a.ID, &a.UploadedAt); err != nil {
+	if err := insertAttachmentTx(tx, a); err != nil {
 		return err
 	}
 	if err := incrementStorageStatisticsTx(tx, a.FileSize); err != nil {
 		return fmt.Errorf("failed to increment storage statistics: %w", err)
 	}
+	if err := enqueueAttachmentTextExtractionTx(r.outbox, tx, a); err != nil {
+		return err
+	}
 	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
 		return err
 	}
@@ -208,10 +229,11 @@ func (r *AttachmentRepository) DeleteMarkedAndDecrementStorageStatistics(id uuid
 func (r *AttachmentRepository) GetByID(id uuid.UUID) (*models.Attachment, error) {
 	var a models.Attachment
 	if err := r.db.QueryRow(
-		`SELECT id, document_id, filename, storage_path, file_size, content_type, uploaded_by, uploaded_at
+		`SELECT id, document_id, filename, storage_path, file_size, content_type, uploaded_by, uploaded_at,
+			COALESCE(sha256, ''), integrity_status
 		FROM attachments WHERE id = $1 AND deletion_requested_at IS NULL`,
 		id,
-	).Scan(&a.ID, &a.DocumentID, &a.Filename, &a.StoragePath, &a.FileSize, &a.ContentType, &a.UploadedBy, &a.UploadedAt); err != nil {
+	).Scan(&a.ID, &a.DocumentID, &a.Filename, &a.StoragePath, &a.FileSize, &a.ContentType, &a.UploadedBy, &a.UploadedAt, &a.SHA256, &a.IntegrityStatus); err != nil {
 		return nil, err
 	}
 	return &a, nil
@@ -220,7 +242,8 @@ func (r *AttachmentRepository) GetByID(id uuid.UUID) (*models.Attachment, error)
 // GetByDocumentID возвращает все вложения, прикрепленные к определенному документу.
 func (r *AttachmentRepository) GetByDocumentID(docID uuid.UUID) ([]models.Attachment, error) {
 	rows, err := r.db.Query(
-		`SELECT a.id, a.document_id, a.filename, a.file_size, a.content_type, a.storage_path, a.uploaded_by, a.uploaded_at, u.full_name
+		`SELECT a.id, a.document_id, a.filename, a.file_size, a.content_type, a.storage_path, a.uploaded_by, a.uploaded_at, u.full_name,
+			COALESCE(a.sha256, ''), a.integrity_status
 		FROM attachments a
 		LEFT JOIN users u ON a.uploaded_by = u.id
 		WHERE a.document_id = $1 AND a.deletion_requested_at IS NULL
@@ -238,6 +261,7 @@ func (r *AttachmentRepository) GetByDocumentID(docID uuid.UUID) ([]models.Attach
 		var uploadedByName sql.NullString
 		if err := rows.Scan(
 			&a.ID, &a.DocumentID, &a.Filename, &a.FileSize, &a.ContentType, &a.StoragePath, &a.UploadedBy, &a.UploadedAt, &uploadedByName,
+			&a.SHA256, &a.IntegrityStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -318,6 +342,74 @@ func (r *AttachmentRepository) GetAllStoragePaths() ([]string, error) {
 	return paths, rows.Err()
 }
 
+// ClaimIntegrityCheckBatch выбирает вложения, не проверявшиеся с checkedBefore,
+// и сразу отмечает время проверки. SKIP LOCKED и отметка в той же транзакции не
+// дают нескольким рабочим местам перечитывать одни и те же объекты.
+func (r *AttachmentRepository) ClaimIntegrityCheckBatch(checkedBefore time.Time, limit int) ([]models.Attachment, error) {
+	rows, err := r.db.Query(`
+		WITH due AS (
+			SELECT id FROM attachments
+			WHERE deletion_requested_at IS NULL
+			  AND (integrity_checked_at IS NULL OR integrity_checked_at < $1)
+			ORDER BY integrity_checked_at NULLS FIRST
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE attachments a SET integrity_checked_at = CURRENT_TIMESTAMP
+		FROM due
+		WHERE a.id = due.id
+		RETURNING a.id, a.document_id, a.filename, a.storage_path, a.file_size, a.content_type, a.uploaded_by, a.uploaded_at,
+			COALESCE(a.sha256, ''), a.integrity_status`, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]models.Attachment, 0)
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.ID, &a.DocumentID, &a.Filename, &a.StoragePath, &a.FileSize, &a.ContentType, &a.UploadedBy, &a.UploadedAt, &a.SHA256, &a.IntegrityStatus); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// SaveIntegrityResults сохраняет статусы проверки и события outbox (аудит
+// обнаруженных повреждений) в одной транзакции. Хэш старого вложения
+// дописывается, только если он не совпадает с хэшем другого файла документа.
+func (r *AttachmentRepository) SaveIntegrityResults(results []models.AttachmentIntegrityResult, effects []models.OutboxEvent) error {
+	if len(results) == 0 && len(effects) == 0 {
+		return nil
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, result := range results {
+		if _, err := tx.Exec(`
+			UPDATE attachments a
+			SET integrity_status = $2,
+				integrity_checked_at = CURRENT_TIMESTAMP,
+				sha256 = CASE
+					WHEN a.sha256 IS NULL AND $3::text <> '' AND NOT EXISTS (
+						SELECT 1 FROM attachments d
+						WHERE d.document_id = a.document_id AND d.sha256 = $3 AND d.deletion_requested_at IS NULL
+					) THEN $3
+					ELSE a.sha256
+				END
+			WHERE a.id = $1`, result.AttachmentID, result.Status, result.SHA256); err != nil {
+			return fmt.Errorf("failed to save attachment integrity status: %w", err)
+		}
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // EnqueuePendingDeletions migrates legacy deleting tombstones to the common
 // outbox worker. Existing deduplication keys make this safe on every startup.
 func (r *AttachmentRepository) EnqueuePendingDeletions() error {
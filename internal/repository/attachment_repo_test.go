@@ -9,6 +9,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -40,8 +41,8 @@ func TestAttachmentRepository_Create(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectQuery(`INSERT INTO attachments \(document_id, filename, storage_path, file_size, content_type, uploaded_by\)`).
-			WithArgs(attachment.DocumentID, attachment.Filename, attachment.StoragePath, attachment.FileSize, attachment.ContentType, attachment.UploadedBy).
+		mock.ExpectQuery(`INSERT INTO attachments \(document_id, filename, storage_path, file_size, content_type, uploaded_by, sha256, integrity_status\)`).
+			WithArgs(attachment.DocumentID, attachment.Filename, attachment.StoragePath, attachment.FileSize, attachment.ContentType, attachment.UploadedBy, "", models.AttachmentIntegrityUnverified).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "uploaded_at"}).AddRow(expectedID, expectedUploadedAt))
 		mock.ExpectExec(`UPDATE storage_statistics`).WithArgs(attachment.FileSize).WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectCommit()
@@ -80,8 +81,10 @@ func TestAttachmentRepositoryCreateWithOutboxRollsBackOnEnqueueFailure(t *testin
 	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "attachment:test:upload:journal", Payload: `{}`}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO attachments`).WithArgs(attachment.DocumentID, attachment.Filename, attachment.StoragePath, attachment.FileSize, attachment.ContentType, attachment.UploadedBy).WillReturnRows(sqlmock.NewRows([]string{"id", "uploaded_at"}).AddRow(uuid.New(), time.Now()))
+	mock.ExpectQuery(`INSERT INTO attachments`).WithArgs(attachment.DocumentID, attachment.Filename, attachment.StoragePath, attachment.FileSize, attachment.ContentType, attachment.UploadedBy, "", models.AttachmentIntegrityUnverified).WillReturnRows(sqlmock.NewRows([]string{"id", "uploaded_at"}).AddRow(uuid.New(), time.Now()))
 	mock.ExpectExec(`UPDATE storage_statistics`).WithArgs(attachment.FileSize).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO attachment_texts`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(models.OutboxEventTextExtract, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnError(assert.AnError)
 	mock.ExpectRollback()
 
@@ -141,21 +144,23 @@ func TestAttachmentRepository_GetByID(t *testing.T) {
 	attachmentID := uuid.New()
 
 	t.Run("success", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT id, document_id, filename, storage_path, file_size, content_type, uploaded_by, uploaded_at FROM attachments WHERE id = \$1 AND deletion_requested_at IS NULL`).
+		mock.ExpectQuery(`SELECT id, document_id, filename, storage_path, file_size, content_type, uploaded_by, uploaded_at,\s+COALESCE\(sha256, ''\), integrity_status\s+FROM attachments WHERE id = \$1 AND deletion_requested_at IS NULL`).
 			WithArgs(attachmentID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "filename", "storage_path", "file_size", "content_type", "uploaded_by", "uploaded_at"}).
-				AddRow(attachmentID, uuid.New(), "test.txt", "minio-path", 11, "text/plain", uuid.New(), time.Now()))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "filename", "storage_path", "file_size", "content_type", "uploaded_by", "uploaded_at", "sha256", "integrity_status"}).
+				AddRow(attachmentID, uuid.New(), "test.txt", "minio-path", 11, "text/plain", uuid.New(), time.Now(), "abc123", "ok"))
 
 		attachment, err := repo.GetByID(attachmentID)
 
 		require.NoError(t, err)
 		require.NotNil(t, attachment)
 		assert.Equal(t, attachmentID, attachment.ID)
+		assert.Equal(t, "abc123", attachment.SHA256)
+		assert.Equal(t, models.AttachmentIntegrityOK, attachment.IntegrityStatus)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT id, document_id, filename, storage_path, file_size, content_type, uploaded_by, uploaded_at FROM attachments WHERE id = \$1 AND deletion_requested_at IS NULL`).
+		mock.ExpectQuery(`SELECT id, document_id, filename, storage_path, file_size, content_type, uploaded_by, uploaded_at,\s+COALESCE\(sha256, ''\), integrity_status\s+FROM attachments WHERE id = \$1 AND deletion_requested_at IS NULL`).
 			WithArgs(attachmentID).
 			WillReturnError(sql.ErrNoRows)
 
@@ -174,11 +179,11 @@ func TestAttachmentRepository_GetByDocumentID(t *testing.T) {
 	docID := uuid.New()
 
 	t.Run("success", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT a.id, a.document_id, a.filename, a.file_size, a.content_type, a.storage_path, a.uploaded_by, a.uploaded_at, u.full_name FROM attachments a LEFT JOIN users u ON a.uploaded_by = u.id WHERE a.document_id = \$1 AND a.deletion_requested_at IS NULL ORDER BY a.uploaded_at DESC`).
+		mock.ExpectQuery(`SELECT a.id, a.document_id, a.filename, a.file_size, a.content_type, a.storage_path, a.uploaded_by, a.uploaded_at, u.full_name,\s+COALESCE\(a.sha256, ''\), a.integrity_status\s+FROM attachments a LEFT JOIN users u ON a.uploaded_by = u.id WHERE a.document_id = \$1 AND a.deletion_requested_at IS NULL ORDER BY a.uploaded_at DESC`).
 			WithArgs(docID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "filename", "file_size", "content_type", "storage_path", "uploaded_by", "uploaded_at", "full_name"}).
-				AddRow(uuid.New(), docID, "test1.txt", 11, "text/plain", "path1", uuid.New(), time.Now(), "User One").
-				AddRow(uuid.New(), docID, "test2.pdf", 42, "application/pdf", "path2", uuid.New(), time.Now(), "User Two"))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "filename", "file_size", "content_type", "storage_path", "uploaded_by", "uploaded_at", "full_name", "sha256", "integrity_status"}).
+				AddRow(uuid.New(), docID, "test1.txt", 11, "text/plain", "path1", uuid.New(), time.Now(), "User One", "", "unverified").
+				AddRow(uuid.New(), docID, "test2.pdf", 42, "application/pdf", "path2", uuid.New(), time.Now(), "User Two", "abc", "corrupted"))
 
 		attachments, err := repo.GetByDocumentID(docID)
 
@@ -186,13 +191,14 @@ func TestAttachmentRepository_GetByDocumentID(t *testing.T) {
 		require.Len(t, attachments, 2)
 		assert.Equal(t, "User One", attachments[0].UploadedByName)
 		assert.Equal(t, "User Two", attachments[1].UploadedByName)
+		assert.Equal(t, models.AttachmentIntegrityCorrupted, attachments[1].IntegrityStatus)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("no attachments", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT a.id, a.document_id, a.filename, a.file_size, a.content_type, a.storage_path, a.uploaded_by, a.uploaded_at, u.full_name FROM attachments a LEFT JOIN users u ON a.uploaded_by = u.id WHERE a.document_id = \$1 AND a.deletion_requested_at IS NULL ORDER BY a.uploaded_at DESC`).
+		mock.ExpectQuery(`SELECT a.id, a.document_id, a.filename, a.file_size, a.content_type, a.storage_path, a.uploaded_by, a.uploaded_at, u.full_name,\s+COALESCE\(a.sha256, ''\), a.integrity_status\s+FROM attachments a LEFT JOIN users u ON a.uploaded_by = u.id WHERE a.document_id = \$1 AND a.deletion_requested_at IS NULL ORDER BY a.uploaded_at DESC`).
 			WithArgs(docID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "filename", "file_size", "content_type", "storage_path", "uploaded_by", "uploaded_at", "full_name"}))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "filename", "file_size", "content_type", "storage_path", "uploaded_by", "uploaded_at", "full_name", "sha256", "integrity_status"}))
 
 		attachments, err := repo.GetByDocumentID(docID)
 
@@ -267,3 +273,64 @@ func TestAttachmentRepository_GetPendingDeletion(t *testing.T) {
 	assert.Equal(t, attachmentID, attachments[0].ID)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestAttachmentRepositoryCreateRejectsDuplicateContent(t *testing.T) {
+	repo, mock := setupAttachmentRepo(t)
+	attachment := &models.Attachment{DocumentID: uuid.New(), Filename: "copy.pdf", StoragePath: "objects/copy.pdf", FileSize: 3, UploadedBy: uuid.New(), SHA256: "abc"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO attachments`).
+		WithArgs(attachment.DocumentID, attachment.Filename, attachment.StoragePath, attachment.FileSize, attachment.ContentType, attachment.UploadedBy, "abc", models.AttachmentIntegrityUnverified).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_attachments_document_sha256"})
+	mock.ExpectRollback()
+
+	err := repo.Create(attachment)
+	assert.Equal(t, errAttachmentDuplicate, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepositoryClaimIntegrityCheckBatch(t *testing.T) {
+	// Отметка времени проверки ставится тем же запросом, что выбирает пачку.
+	repo, mock := setupAttachmentRepo(t)
+	cutoff := time.Now().Add(-7 * 24 * time.Hour)
+	attachmentID := uuid.New()
+
+	mock.ExpectQuery(`FOR UPDATE SKIP LOCKED(.|\n)*UPDATE attachments a SET integrity_checked_at = CURRENT_TIMESTAMP`).
+		WithArgs(cutoff, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "filename", "storage_path", "file_size", "content_type", "uploaded_by", "uploaded_at", "sha256", "integrity_status"}).
+			AddRow(attachmentID, uuid.New(), "a.pdf", "objects/a.pdf", 5, "application/pdf", uuid.New(), time.Now(), "", "unverified"))
+
+	attachments, err := repo.ClaimIntegrityCheckBatch(cutoff, 20)
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, attachmentID, attachments[0].ID)
+	assert.Empty(t, attachments[0].SHA256)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepositorySaveIntegrityResults(t *testing.T) {
+	repo, mock := setupAttachmentRepo(t)
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	okID := uuid.New()
+	brokenID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "attachments:integrity:1", Payload: `{}`}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE attachments a\s+SET integrity_status = \$2`).
+		WithArgs(okID, models.AttachmentIntegrityOK, "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE attachments a\s+SET integrity_status = \$2`).
+		WithArgs(brokenID, models.AttachmentIntegrityMissing, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO event_outbox`).
+		WithArgs(event.EventType, event.DeduplicationKey, event.Payload).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.SaveIntegrityResults([]models.AttachmentIntegrityResult{
+		{AttachmentID: okID, Status: models.AttachmentIntegrityOK, SHA256: "abc"},
+		{AttachmentID: brokenID, Status: models.AttachmentIntegrityMissing},
+	}, []models.OutboxEvent{event})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
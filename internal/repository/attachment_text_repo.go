@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/google/uuid"
+)
+
+// AttachmentTextRepository хранит текст, извлеченный из вложений.
+// Изменения таблицы attachment_texts обновляют поисковый индекс документов триггерами БД.
+type AttachmentTextRepository struct {
+	db *database.DB
+}
+
+// NewAttachmentTextRepository создает новый экземпляр AttachmentTextRepository.
+func NewAttachmentTextRepository(db *database.DB) *AttachmentTextRepository {
+	return &AttachmentTextRepository{db: db}
+}
+
+// Get возвращает извлеченный текст вложения или nil, если вложение удалено или еще не поставлено в очередь.
+func (r *AttachmentTextRepository) Get(attachmentID uuid.UUID) (*models.AttachmentText, error) {
+	var text models.AttachmentText
+	var errorText sql.NullString
+	var extractedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT t.attachment_id, t.document_id, t.status, t.content, t.truncated, t.error, t.extracted_at, t.updated_at
+		FROM attachment_texts t
+		JOIN attachments a ON a.id = t.attachment_id
+		WHERE t.attachment_id = $1 AND a.deletion_requested_at IS NULL
+	`, attachmentID).Scan(
+		&text.AttachmentID, &text.DocumentID, &text.Status, &text.Content, &text.Truncated,
+		&errorText, &extractedAt, &text.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment text: %w", err)
+	}
+	if errorText.Valid {
+		text.Error = &errorText.String
+	}
+	if extractedAt.Valid {
+		text.ExtractedAt = &extractedAt.Time
+	}
+	return &text, nil
+}
+
+// SaveResult сохраняет результат извлечения. Повторная обработка события перезаписывает прежний результат.
+func (r *AttachmentTextRepository) SaveResult(text models.AttachmentText) error {
+	_, err := r.db.Exec(`
+		INSERT INTO attachment_texts (attachment_id, document_id, status, content, truncated, error, extracted_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $3 = 'extracted' THEN CURRENT_TIMESTAMP END, CURRENT_TIMESTAMP)
+		ON CONFLICT (attachment_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			content = EXCLUDED.content,
+			truncated = EXCLUDED.truncated,
+			error = EXCLUDED.error,
+			extracted_at = EXCLUDED.extracted_at,
+			updated_at = EXCLUDED.updated_at
+	`, text.AttachmentID, text.DocumentID, text.Status, text.Content, text.Truncated, text.Error)
+	if err != nil {
+		return fmt.Errorf("failed to save attachment text: %w", err)
+	}
+	return nil
+}
+
+// enqueueAttachmentTextExtractionTx создает запись в статусе pending и событие извлечения
+// в транзакции загрузки вложения.
+func enqueueAttachmentTextExtractionTx(outbox *OutboxRepository, tx *sql.Tx, attachment *models.Attachment) error {
+	if outbox == nil {
+		return ErrOutboxNotConfigured
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO attachment_texts (attachment_id, document_id)
+		VALUES ($1, $2)
+		ON CONFLICT (attachment_id) DO NOTHING
+	`, attachment.ID, attachment.DocumentID); err != nil {
+		return fmt.Errorf("failed to create attachment text: %w", err)
+	}
+	payload, err := json.Marshal(models.AttachmentTextExtractPayload{AttachmentID: attachment.ID})
+	if err != nil {
+		return err
+	}
+	return outbox.EnqueueTx(tx, models.OutboxEvent{
+		EventType:        models.OutboxEventTextExtract,
+		DeduplicationKey: "attachment:" + attachment.ID.String() + ":text",
+		Payload:          string(payload),
+	})
+}
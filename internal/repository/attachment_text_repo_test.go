@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAttachmentTextRepo(t *testing.T) (*AttachmentTextRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	return NewAttachmentTextRepository(&database.DB{DB: db}), mock
+}
+
+func TestAttachmentTextRepositoryGet(t *testing.T) {
+	repo, mock := setupAttachmentTextRepo(t)
+	attachmentID, documentID := uuid.New(), uuid.New()
+	extractedAt := time.Now()
+
+	mock.ExpectQuery(`FROM attachment_texts t\s+JOIN attachments a ON a.id = t.attachment_id\s+WHERE t.attachment_id = \$1 AND a.deletion_requested_at IS NULL`).
+		WithArgs(attachmentID).
+		WillReturnRows(sqlmock.NewRows([]string{"attachment_id", "document_id", "status", "content", "truncated", "error", "extracted_at", "updated_at"}).
+			AddRow(attachmentID, documentID, models.AttachmentTextExtracted, "О ремонте кровли", true, nil, extractedAt, extractedAt))
+
+	text, err := repo.Get(attachmentID)
+
+	require.NoError(t, err)
+	require.NotNil(t, text)
+	assert.Equal(t, documentID, text.DocumentID)
+	assert.Equal(t, models.AttachmentTextExtracted, text.Status)
+	assert.Equal(t, "О ремонте кровли", text.Content)
+	assert.True(t, text.Truncated)
+	assert.Nil(t, text.Error)
+	require.NotNil(t, text.ExtractedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentTextRepositoryGetReturnsNilWhenMissing(t *testing.T) {
+	repo, mock := setupAttachmentTextRepo(t)
+	attachmentID := uuid.New()
+	mock.ExpectQuery(`FROM attachment_texts`).WithArgs(attachmentID).WillReturnRows(sqlmock.NewRows([]string{"attachment_id"}))
+
+	text, err := repo.Get(attachmentID)
+
+	require.NoError(t, err)
+	assert.Nil(t, text)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentTextRepositorySaveResult(t *testing.T) {
+	repo, mock := setupAttachmentTextRepo(t)
+	failure := "damaged pdf"
+	text := models.AttachmentText{AttachmentID: uuid.New(), DocumentID: uuid.New(), Status: models.AttachmentTextFailed, Error: &failure}
+
+	mock.ExpectExec(`INSERT INTO attachment_texts .* ON CONFLICT \(attachment_id\) DO UPDATE SET`).
+		WithArgs(text.AttachmentID, text.DocumentID, models.AttachmentTextFailed, "", false, &failure).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.SaveResult(text))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepositoryCreateWithOutboxEnqueuesTextExtraction(t *testing.T) {
+	repo, mock := setupAttachmentRepo(t)
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	attachment := &models.Attachment{DocumentID: uuid.New(), Filename: "letter.docx", StoragePath: "objects/letter.docx", FileSize: 1, ContentType: "application/octet-stream", UploadedBy: uuid.New()}
+	attachmentID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO attachments`).WillReturnRows(sqlmock.NewRows([]string{"id", "uploaded_at"}).AddRow(attachmentID, time.Now()))
+	mock.ExpectExec(`UPDATE storage_statistics`).WithArgs(attachment.FileSize).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO attachment_texts \(attachment_id, document_id\)\s+VALUES \(\$1, \$2\)\s+ON CONFLICT \(attachment_id\) DO NOTHING`).
+		WithArgs(attachmentID, attachment.DocumentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO event_outbox`).
+		WithArgs(models.OutboxEventTextExtract, "attachment:"+attachmentID.String()+":text", `{"attachmentId":"`+attachmentID.String()+`"}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.CreateWithOutbox(attachment, nil))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepositoryCreateWithOutboxRequiresOutbox(t *testing.T) {
+	repo, mock := setupAttachmentRepo(t)
+	attachment := &models.Attachment{DocumentID: uuid.New(), Filename: "letter.docx", FileSize: 1}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO attachments`).WillReturnRows(sqlmock.NewRows([]string{"id", "uploaded_at"}).AddRow(uuid.New(), time.Now()))
+	mock.ExpectExec(`UPDATE storage_statistics`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	require.ErrorIs(t, repo.CreateWithOutbox(attachment, nil), ErrOutboxNotConfigured)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
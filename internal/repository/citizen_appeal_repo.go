@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -25,25 +26,39 @@ func NewCitizenAppealRepository(db *database.DB) *CitizenAppealRepository {
 	return &CitizenAppealRepository{db: db}
 }
 
+// Единственный признак закрытого обращения — исходящий ответ, который
+// refreshCitizenAppealReplyState записывает по связи «ответ». closed_at без
+// ответа закрытием не считается.
+const (
+	citizenAppealClosedCondition = "ca.closed_by_document_id IS NOT NULL"
+	citizenAppealOpenCondition   = "ca.closed_by_document_id IS NULL"
+)
+
 const citizenAppealSelectBase = `
 	SELECT d.id, d.nomenclature_id, n.index || ' — ' || n.name,
 		d.registration_number, d.registration_date,
 		ca.appeal_date,
-		d.document_type, d.document_type,
+		d.document_type_id, dt.name,
 		d.content, d.pages_count,
 		ca.applicant_full_name, ca.registration_address,
 		ca.appeal_type, ca.applicant_category,
 		ca.appeal_pages_count, ca.attachment_pages_count,
 		ca.has_envelope, ca.received_from_pos,
+		ca.response_due_date, ca.closed_at,
+		ca.closed_by_document_id, rd.registration_number,
+		(SELECT COUNT(*) FROM citizen_appeal_deadline_extensions ext WHERE ext.document_id = d.id),
 		d.created_by, u.full_name,
 		d.created_at, d.updated_at
 	FROM documents d
 	JOIN citizen_appeal_details ca ON ca.document_id = d.id
+	JOIN document_types dt ON dt.id = d.document_type_id
 	LEFT JOIN nomenclature n ON d.nomenclature_id = n.id
-	LEFT JOIN users u ON d.created_by = u.id`
+	LEFT JOIN users u ON d.created_by = u.id
+	LEFT JOIN documents rd ON rd.id = ca.closed_by_document_id`
 
 func scanCitizenAppealDoc(scanner interface{ Scan(...interface{}) error }) (*models.CitizenAppealDocument, error) {
 	doc := &models.CitizenAppealDocument{}
+	var closedByNumber sql.NullString
 	err := scanner.Scan(
 		&doc.ID, &doc.NomenclatureID, &doc.NomenclatureName,
 		&doc.RegistrationNumber, &doc.RegistrationDate,
@@ -54,9 +69,18 @@ func scanCitizenAppealDoc(scanner interface{ Scan(...interface{}) error }) (*mod
 		&doc.AppealType, &doc.ApplicantCategory,
 		&doc.AppealPagesCount, &doc.AttachmentPagesCount,
 		&doc.HasEnvelope, &doc.ReceivedFromPOS,
+		&doc.ResponseDueDate, &doc.ClosedAt,
+		&doc.ClosedByDocumentID, &closedByNumber,
+		&doc.DeadlineExtensionCount,
 		&doc.CreatedBy, &doc.CreatedByName,
 		&doc.CreatedAt, &doc.UpdatedAt,
 	)
+	if closedByNumber.Valid {
+		doc.ClosedByDocumentNumber = closedByNumber.String
+	}
+	if !doc.IsClosed() {
+		doc.ClosedAt = nil
+	}
 	return doc, err
 }
 
@@ -175,6 +199,18 @@ func (r *CitizenAppealRepository) GetList(filter models.DocumentFilter) (*models
 		args = append(args, filter.AppealType)
 		argIdx++
 	}
+	switch filter.AppealDeadlineStatus {
+	case models.CitizenAppealDeadlineOpen:
+		where = append(where, citizenAppealOpenCondition)
+	case models.CitizenAppealDeadlineClosed:
+		where = append(where, citizenAppealClosedCondition)
+	case models.CitizenAppealDeadlineOverdue:
+		where = append(where, citizenAppealOpenCondition, "ca.response_due_date < CURRENT_DATE")
+	case models.CitizenAppealDeadlineDueSoon:
+		where = append(where, citizenAppealOpenCondition, fmt.Sprintf("ca.response_due_date BETWEEN CURRENT_DATE AND CURRENT_DATE + $%d::int", argIdx))
+		args = append(args, filter.AppealDueSoonDays)
+		argIdx++
+	}
 	if filter.OrgID != "" {
 		where = append(where, fmt.Sprintf(`EXISTS (
 			SELECT 1
@@ -401,8 +437,8 @@ func (r *CitizenAppealRepository) create(req models.CreateCitizenAppealDocReques
 	var id uuid.UUID
 	err = tx.QueryRow(`
 		INSERT INTO documents (
-			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by
+		) VALUES ($1, $2, $3, $4, $5, `+defaultDocumentTypeIDExpr("$1", "$6")+`, $7, $8, $9)
 		RETURNING id
 	`,
 		models.DocumentKindCitizenAppeal, req.NomenclatureID, req.IdempotencyKey, req.RegistrationNumber, req.RegistrationDate,
@@ -420,6 +456,9 @@ func (r *CitizenAppealRepository) create(req models.CreateCitizenAppealDocReques
 		if isUniqueViolation(err, "idx_documents_kind_registration_number_year") {
 			return nil, models.NewConflict("документ с таким регистрационным номером уже существует")
 		}
+		if isInvalidDocumentType(err) {
+			return nil, errInvalidDocumentType
+		}
 		return nil, fmt.Errorf("failed to create citizen appeal root: %w", err)
 	}
 
@@ -427,12 +466,12 @@ func (r *CitizenAppealRepository) create(req models.CreateCitizenAppealDocReques
 		INSERT INTO citizen_appeal_details (
 			document_id, appeal_date, applicant_full_name, registration_address,
 			appeal_type, applicant_category, appeal_pages_count, attachment_pages_count,
-			has_envelope, received_from_pos
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+			has_envelope, received_from_pos, response_due_date
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
 	`,
 		id, req.AppealDate, req.ApplicantFullName, req.RegistrationAddress,
 		req.AppealType, req.ApplicantCategory, req.AppealPagesCount, req.AttachmentPagesCount,
-		req.HasEnvelope, req.ReceivedFromPOS,
+		req.HasEnvelope, req.ReceivedFromPOS, req.ResponseDueDate,
 	); err != nil {
 		return nil, fmt.Errorf("failed to create citizen appeal details: %w", err)
 	}
@@ -507,14 +546,19 @@ func (r *CitizenAppealRepository) update(req models.UpdateCitizenAppealDocReques
 			appeal_pages_count = $6,
 			attachment_pages_count = $7,
 			has_envelope = $8,
-			received_from_pos = $9
+			received_from_pos = $9,
+			response_due_date = CASE
+				WHEN EXISTS (SELECT 1 FROM citizen_appeal_deadline_extensions ext WHERE ext.document_id = $10)
+					THEN response_due_date
+				ELSE $11
+			END
 		WHERE document_id = $10
 	`,
 		req.AppealDate, req.ApplicantFullName, req.RegistrationAddress,
 		req.AppealType, req.ApplicantCategory,
 		req.AppealPagesCount, req.AttachmentPagesCount,
 		req.HasEnvelope, req.ReceivedFromPOS,
-		req.ID,
+		req.ID, req.ResponseDueDate,
 	); err != nil {
 		return nil, fmt.Errorf("failed to update citizen appeal details: %w", err)
 	}
@@ -542,3 +586,133 @@ func (r *CitizenAppealRepository) GetCount() (int, error) {
 	err := r.db.QueryRow(`SELECT COUNT(*) FROM documents WHERE kind = $1`, models.DocumentKindCitizenAppeal).Scan(&count)
 	return count, err
 }
+
+// GetDeadlineExtensions возвращает историю продлений срока ответа на обращение.
+func (r *CitizenAppealRepository) GetDeadlineExtensions(documentID uuid.UUID) ([]models.CitizenAppealDeadlineExtension, error) {
+	rows, err := r.db.Query(`
+		SELECT ext.id, ext.document_id, ext.previous_due_date, ext.new_due_date, ext.reason,
+			ext.approved_by, COALESCE(ua.full_name, ''),
+			ext.created_by, COALESCE(uc.full_name, ''),
+			ext.created_at
+		FROM citizen_appeal_deadline_extensions ext
+		LEFT JOIN users ua ON ua.id = ext.approved_by
+		LEFT JOIN users uc ON uc.id = ext.created_by
+		WHERE ext.document_id = $1
+		ORDER BY ext.created_at, ext.id
+	`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get citizen appeal deadline extensions: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.CitizenAppealDeadlineExtension, 0)
+	for rows.Next() {
+		var item models.CitizenAppealDeadlineExtension
+		if err := rows.Scan(
+			&item.ID, &item.DocumentID, &item.PreviousDueDate, &item.NewDueDate, &item.Reason,
+			&item.ApprovedBy, &item.ApprovedByName,
+			&item.CreatedBy, &item.CreatedByName,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan citizen appeal deadline extension error: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("citizen appeal deadline extensions rows error: %w", err)
+	}
+	return items, nil
+}
+
+// ExtendDeadlineWithOutbox продлевает срок ответа и записывает сопутствующие события в одной транзакции.
+func (r *CitizenAppealRepository) ExtendDeadlineWithOutbox(req models.ExtendCitizenAppealDeadlineRequest, effects []models.OutboxEvent) (*models.CitizenAppealDeadlineExtension, error) {
+	if r.outbox == nil {
+		return nil, ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var currentDueDate time.Time
+	var closed bool
+	err = tx.QueryRow(`
+		SELECT ca.response_due_date, `+citizenAppealClosedCondition+`
+		FROM citizen_appeal_details ca
+		WHERE ca.document_id = $1
+		FOR UPDATE
+	`, req.DocumentID).Scan(&currentDueDate, &closed)
+	if err == sql.ErrNoRows {
+		return nil, models.NewNotFound("обращение не найдено")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock citizen appeal deadline: %w", err)
+	}
+	if closed {
+		return nil, models.NewConflict("обращение уже закрыто исходящим ответом")
+	}
+	if !req.NewDueDate.After(currentDueDate) {
+		return nil, models.NewBadRequest("новый срок должен быть позже текущего срока ответа")
+	}
+
+	extension := &models.CitizenAppealDeadlineExtension{
+		DocumentID:      req.DocumentID,
+		PreviousDueDate: currentDueDate,
+		NewDueDate:      req.NewDueDate,
+		Reason:          req.Reason,
+		ApprovedBy:      req.ApprovedBy,
+		CreatedBy:       req.CreatedBy,
+	}
+	if err := tx.QueryRow(`
+		INSERT INTO citizen_appeal_deadline_extensions (
+			document_id, previous_due_date, new_due_date, reason, approved_by, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`,
+		req.DocumentID, currentDueDate, req.NewDueDate, req.Reason, req.ApprovedBy, req.CreatedBy,
+	).Scan(&extension.ID, &extension.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to create citizen appeal deadline extension: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE citizen_appeal_details SET response_due_date = $1 WHERE document_id = $2
+	`, req.NewDueDate, req.DocumentID); err != nil {
+		return nil, fmt.Errorf("failed to update citizen appeal deadline: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE documents SET updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND kind = $2
+	`, req.DocumentID, models.DocumentKindCitizenAppeal); err != nil {
+		return nil, fmt.Errorf("failed to update citizen appeal root: %w", err)
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return extension, nil
+}
+
+// GetDeadlineCounters возвращает количество незакрытых обращений по срокам ответа
+// в пределах серверного scope доступа.
+func (r *CitizenAppealRepository) GetDeadlineCounters(scope models.DocumentAccessScope, dueSoonDays int) (*models.CitizenAppealDeadlineCounters, error) {
+	where := []string{"d.kind = 'citizen_appeal'", citizenAppealOpenCondition}
+	args := []interface{}{dueSoonDays}
+	argIdx := 2
+	applyDocumentListAccess(&where, &args, &argIdx, scope)
+
+	counters := &models.CitizenAppealDeadlineCounters{}
+	err := r.db.QueryRow(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE ca.response_due_date BETWEEN CURRENT_DATE AND CURRENT_DATE + $1::int),
+			COUNT(*) FILTER (WHERE ca.response_due_date < CURRENT_DATE)
+		FROM documents d
+		JOIN citizen_appeal_details ca ON ca.document_id = d.id
+		WHERE `+strings.Join(where, " AND "), args...).Scan(&counters.Open, &counters.DueSoon, &counters.Overdue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count citizen appeal deadlines: %w", err)
+	}
+	return counters, nil
+}
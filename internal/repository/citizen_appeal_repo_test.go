@@ -27,6 +27,9 @@ func citizenAppealRows(docID uuid.UUID, now time.Time) *sqlmock.Rows {
 		"appeal_type", "applicant_category",
 		"appeal_pages_count", "attachment_pages_count",
 		"has_envelope", "received_from_pos",
+		"response_due_date", "closed_at",
+		"closed_by_document_id", "closed_by_document_number",
+		"deadline_extension_count",
 		"created_by", "created_by_name",
 		"created_at", "updated_at",
 	}).AddRow(
@@ -39,6 +42,9 @@ func citizenAppealRows(docID uuid.UUID, now time.Time) *sqlmock.Rows {
 		"жалоба", "гражданин",
 		2, 1,
 		true, false,
+		now.AddDate(0, 0, 30), nil,
+		nil, nil,
+		0,
 		uuid.New(), "Регистратор",
 		now, now,
 	)
@@ -178,6 +184,9 @@ func TestCitizenAppealRepository_GetList(t *testing.T) {
 				"заявление", "гражданин",
 				3, 1,
 				false, true,
+				now.AddDate(0, 0, 28), now,
+				uuid.New(), "ИСХ-7",
+				1,
 				uuid.New(), "Регистратор",
 				now, now,
 			))
@@ -366,6 +375,7 @@ func TestCitizenAppealRepository_Create(t *testing.T) {
 		req.AttachmentPagesCount,
 		req.HasEnvelope,
 		req.ReceivedFromPOS,
+		req.ResponseDueDate,
 	).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec(`DELETE FROM document_correspondent_registrations`).WithArgs(docID).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(`INSERT INTO document_correspondent_registrations`).WithArgs(
@@ -542,6 +552,7 @@ func TestCitizenAppealRepository_CreateDetailsInsertError(t *testing.T) {
 		req.AttachmentPagesCount,
 		req.HasEnvelope,
 		req.ReceivedFromPOS,
+		req.ResponseDueDate,
 	).WillReturnError(sql.ErrConnDone)
 	mock.ExpectRollback()
 
@@ -611,6 +622,7 @@ func TestCitizenAppealRepository_Update(t *testing.T) {
 			req.HasEnvelope,
 			req.ReceivedFromPOS,
 			req.ID,
+			req.ResponseDueDate,
 		).WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectExec(`DELETE FROM document_correspondent_registrations`).WithArgs(docID).WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectExec(`INSERT INTO document_correspondent_registrations`).WithArgs(
@@ -676,3 +688,155 @@ func TestCitizenAppealRepository_Update(t *testing.T) {
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestCitizenAppealRepository_GetListDeadlineStatusFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCitizenAppealRepository(&database.DB{DB: db})
+	docID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM documents d\s+JOIN citizen_appeal_details ca ON ca.document_id = d.id WHERE .*ca\.closed_by_document_id IS NULL AND ca\.response_due_date BETWEEN CURRENT_DATE AND CURRENT_DATE \+ \$1::int`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(citizenAppealSelectBase)).
+		WithArgs(5, 20, 0).
+		WillReturnRows(citizenAppealRows(docID, now))
+	expectCitizenAppealBatchHydrateEmpty(mock)
+
+	res, err := repo.GetList(models.DocumentFilter{
+		AppealDeadlineStatus: models.CitizenAppealDeadlineDueSoon,
+		AppealDueSoonDays:    5,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, res.Items, 1)
+	require.NotNil(t, res.Items[0].ResponseDueDate)
+	assert.Nil(t, res.Items[0].ClosedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCitizenAppealRepository_ExtendDeadlineWithOutbox(t *testing.T) {
+	currentDueDate := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	newReq := func() models.ExtendCitizenAppealDeadlineRequest {
+		return models.ExtendCitizenAppealDeadlineRequest{
+			DocumentID: uuid.New(),
+			NewDueDate: currentDueDate.AddDate(0, 0, 30),
+			Reason:     "Запрос дополнительных материалов",
+			ApprovedBy: uuid.New(),
+			CreatedBy:  uuid.New(),
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewCitizenAppealRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(repo.db))
+		req := newReq()
+		extensionID := uuid.New()
+		event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "citizen-appeal:extend", Payload: `{"action":"DEADLINE_EXTEND"}`}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT ca\.response_due_date, ca\.closed_by_document_id IS NOT NULL\s+FROM citizen_appeal_details ca\s+WHERE ca\.document_id = \$1\s+FOR UPDATE`).
+			WithArgs(req.DocumentID).
+			WillReturnRows(sqlmock.NewRows([]string{"response_due_date", "closed"}).AddRow(currentDueDate, false))
+		mock.ExpectQuery(`INSERT INTO citizen_appeal_deadline_extensions`).
+			WithArgs(req.DocumentID, currentDueDate, req.NewDueDate, req.Reason, req.ApprovedBy, req.CreatedBy).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(extensionID, time.Now()))
+		mock.ExpectExec(`UPDATE citizen_appeal_details SET response_due_date = \$1 WHERE document_id = \$2`).
+			WithArgs(req.NewDueDate, req.DocumentID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE documents SET updated_at = CURRENT_TIMESTAMP WHERE id = \$1 AND kind = \$2`).
+			WithArgs(req.DocumentID, models.DocumentKindCitizenAppeal).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).
+			WithArgs(event.EventType, event.DeduplicationKey, event.Payload).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		extension, err := repo.ExtendDeadlineWithOutbox(req, []models.OutboxEvent{event})
+
+		require.NoError(t, err)
+		assert.Equal(t, extensionID, extension.ID)
+		assert.Equal(t, currentDueDate, extension.PreviousDueDate)
+		assert.Equal(t, req.NewDueDate, extension.NewDueDate)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("closed appeal is rejected", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewCitizenAppealRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(repo.db))
+		req := newReq()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT ca.response_due_date`).
+			WithArgs(req.DocumentID).
+			WillReturnRows(sqlmock.NewRows([]string{"response_due_date", "closed"}).AddRow(currentDueDate, true))
+		mock.ExpectRollback()
+
+		extension, err := repo.ExtendDeadlineWithOutbox(req, nil)
+
+		assert.Nil(t, extension)
+		var appErr *models.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("new due date must be later than current", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewCitizenAppealRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(repo.db))
+		req := newReq()
+		req.NewDueDate = currentDueDate
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT ca.response_due_date`).
+			WithArgs(req.DocumentID).
+			WillReturnRows(sqlmock.NewRows([]string{"response_due_date", "closed"}).AddRow(currentDueDate, false))
+		mock.ExpectRollback()
+
+		_, err = repo.ExtendDeadlineWithOutbox(req, nil)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "новый срок должен быть позже")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("requires outbox", func(t *testing.T) {
+		repo := NewCitizenAppealRepository(nil)
+		_, err := repo.ExtendDeadlineWithOutbox(newReq(), nil)
+		require.ErrorIs(t, err, ErrOutboxNotConfigured)
+	})
+}
+
+func TestCitizenAppealRepository_GetDeadlineCounters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCitizenAppealRepository(&database.DB{DB: db})
+	nomenclatureID := uuid.New().String()
+
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\),\s+COUNT\(\*\) FILTER .*FROM documents d\s+JOIN citizen_appeal_details ca ON ca.document_id = d.id\s+WHERE d.kind = 'citizen_appeal' AND ca.closed_by_document_id IS NULL AND \(d\.nomenclature_id = ANY\(\$2\)`).
+		WithArgs(3, pq.Array([]string{nomenclatureID})).
+		WillReturnRows(sqlmock.NewRows([]string{"open", "due_soon", "overdue"}).AddRow(12, 4, 2))
+
+	counters, err := repo.GetDeadlineCounters(models.DocumentAccessScope{
+		Restricted:             true,
+		AllowedNomenclatureIDs: []string{nomenclatureID},
+	}, 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, &models.CitizenAppealDeadlineCounters{Open: 12, DueSoon: 4, Overdue: 2}, counters)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// CustomDocumentKindRepository предоставляет методы для работы с пользовательскими видами документов в БД.
+type CustomDocumentKindRepository struct {
+	db     *database.DB
+	outbox *OutboxRepository
+}
+
+func (r *CustomDocumentKindRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
+
+// NewCustomDocumentKindRepository создает новый экземпляр CustomDocumentKindRepository.
+func NewCustomDocumentKindRepository(db *database.DB) *CustomDocumentKindRepository {
+	return &CustomDocumentKindRepository{db: db}
+}
+
+const customDocumentKindSelect = `
+	SELECT
+		k.code, k.name, k.is_active, k.created_at, k.updated_at,
+		(SELECT COUNT(*) FROM documents d WHERE d.kind = k.code)
+	FROM document_kinds k
+	WHERE NOT k.is_system
+`
+
+const customDocumentKindFieldSelect = `
+	SELECT f.id, f.kind_code, f.code, f.label, f.field_type, f.is_required, f.options, f.position
+	FROM document_kind_fields f
+`
+
+// GetAll возвращает все пользовательские виды документов вместе со схемой полей.
+func (r *CustomDocumentKindRepository) GetAll() ([]models.CustomDocumentKind, error) {
+	rows, err := r.db.Query(customDocumentKindSelect + ` ORDER BY k.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom document kinds: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.CustomDocumentKind, 0)
+	codes := make([]string, 0)
+	for rows.Next() {
+		item, err := scanCustomDocumentKind(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+		codes = append(codes, string(item.Code))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return items, nil
+	}
+
+	fields, err := queryCustomDocumentKindFields(r.db, customDocumentKindFieldSelect+`
+		WHERE f.kind_code = ANY($1)
+		ORDER BY f.kind_code, f.position, f.code
+	`, pq.Array(codes))
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Fields = fields[items[i].Code]
+	}
+	return items, nil
+}
+
+// GetByCode возвращает пользовательский вид документа по коду.
+func (r *CustomDocumentKindRepository) GetByCode(code models.DocumentKind) (*models.CustomDocumentKind, error) {
+	return getCustomDocumentKind(r.db, code)
+}
+
+// Create создает пользовательский вид документа.
+func (r *CustomDocumentKindRepository) Create(kind models.CustomDocumentKind) (*models.CustomDocumentKind, error) {
+	return r.CreateWithOutbox(kind, nil)
+}
+
+// CreateWithOutbox создает вид документа со схемой полей и одноименным типом документа.
+// Если тип с таким названием уже есть, вид добавляется в его набор видов.
+func (r *CustomDocumentKindRepository) CreateWithOutbox(kind models.CustomDocumentKind, effects []models.OutboxEvent) (*models.CustomDocumentKind, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO document_kinds (code, name, is_active) VALUES ($1, $2, $3)
+	`, kind.Code, kind.Name, kind.IsActive); err != nil {
+		if isUniqueViolation(err, "document_kinds_pkey") {
+			return nil, models.NewConflict("вид документа с таким кодом уже существует")
+		}
+		if isUniqueViolation(err, "idx_document_kinds_name") {
+			return nil, errCustomDocumentKindNameTaken
+		}
+		return nil, fmt.Errorf("failed to create custom document kind: %w", err)
+	}
+	if err := replaceCustomDocumentKindFieldsTx(tx, kind.Code, kind.Fields); err != nil {
+		return nil, err
+	}
+
+	var documentTypeID uuid.UUID
+	if err := tx.QueryRow(`
+		INSERT INTO document_types (name) VALUES ($1)
+		ON CONFLICT ((lower(name))) DO UPDATE SET updated_at = document_types.updated_at
+		RETURNING id
+	`, kind.Name).Scan(&documentTypeID); err != nil {
+		return nil, fmt.Errorf("failed to create custom document kind type: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO document_type_kinds (document_type_id, kind) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, documentTypeID, kind.Code); err != nil {
+		return nil, fmt.Errorf("failed to link custom document kind type: %w", err)
+	}
+
+	item, err := getCustomDocumentKind(tx, kind.Code)
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+	return item, tx.Commit()
+}
+
+// Update меняет название, активность и схему полей пользовательского вида документа.
+func (r *CustomDocumentKindRepository) Update(kind models.CustomDocumentKind) (*models.CustomDocumentKind, error) {
+	return r.UpdateWithOutbox(kind, nil)
+}
+
+// UpdateWithOutbox меняет название, активность и схему полей пользовательского вида документа.
+// Тип поля нельзя изменить, пока оно заполнено хотя бы в одном документе.
+func (r *CustomDocumentKindRepository) UpdateWithOutbox(kind models.CustomDocumentKind, effects []models.OutboxEvent) (*models.CustomDocumentKind, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE document_kinds SET name = $2, is_active = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE code = $1 AND NOT is_system
+	`, kind.Code, kind.Name, kind.IsActive)
+	if err != nil {
+		if isUniqueViolation(err, "idx_document_kinds_name") {
+			return nil, errCustomDocumentKindNameTaken
+		}
+		return nil, fmt.Errorf("failed to update custom document kind: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, errCustomDocumentKindNotFound
+	}
+	if err := replaceCustomDocumentKindFieldsTx(tx, kind.Code, kind.Fields); err != nil {
+		return nil, err
+	}
+
+	item, err := getCustomDocumentKind(tx, kind.Code)
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+	return item, tx.Commit()
+}
+
+// Delete удаляет неиспользуемый пользовательский вид документа.
+func (r *CustomDocumentKindRepository) Delete(code models.DocumentKind) error {
+	return r.DeleteWithOutbox(code, nil)
+}
+
+// DeleteWithOutbox удаляет вид, по которому нет ни документов, ни дел номенклатуры.
+// Правила доступа и привязки типов документов удаляются вместе с видом.
+func (r *CustomDocumentKindRepository) DeleteWithOutbox(code models.DocumentKind, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM document_kinds WHERE code = $1 AND NOT is_system`, code)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.NewConflict("вид документа уже используется в документах или номенклатуре, его можно только деактивировать")
+		}
+		return fmt.Errorf("failed to delete custom document kind: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errCustomDocumentKindNotFound
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var (
+	errCustomDocumentKindNameTaken = models.NewConflict("вид документа с таким названием уже существует")
+	errCustomDocumentKindNotFound  = models.NewNotFound("вид документа не найден")
+)
+
+type customDocumentKindQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func getCustomDocumentKind(db customDocumentKindQuerier, code models.DocumentKind) (*models.CustomDocumentKind, error) {
+	item, err := scanCustomDocumentKind(db.QueryRow(customDocumentKindSelect+` AND k.code = $1`, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom document kind: %w", err)
+	}
+
+	fields, err := queryCustomDocumentKindFields(db, customDocumentKindFieldSelect+`
+		WHERE f.kind_code = $1
+		ORDER BY f.position, f.code
+	`, code)
+	if err != nil {
+		return nil, err
+	}
+	item.Fields = fields[item.Code]
+	return item, nil
+}
+
+func scanCustomDocumentKind(scanner interface{ Scan(...interface{}) error }) (*models.CustomDocumentKind, error) {
+	var item models.CustomDocumentKind
+	if err := scanner.Scan(&item.Code, &item.Name, &item.IsActive, &item.CreatedAt, &item.UpdatedAt, &item.DocumentsCount); err != nil {
+		return nil, err
+	}
+	item.Fields = []models.CustomDocumentKindField{}
+	return &item, nil
+}
+
+func queryCustomDocumentKindFields(db customDocumentKindQuerier, query string, args ...interface{}) (map[models.DocumentKind][]models.CustomDocumentKindField, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom document kind fields: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[models.DocumentKind][]models.CustomDocumentKindField)
+	for rows.Next() {
+		var field models.CustomDocumentKindField
+		var kindCode models.DocumentKind
+		var options pq.StringArray
+		if err := rows.Scan(&field.ID, &kindCode, &field.Code, &field.Label, &field.FieldType, &field.IsRequired, &options, &field.Position); err != nil {
+			return nil, err
+		}
+		field.Options = []string(options)
+		result[kindCode] = append(result[kindCode], field)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func replaceCustomDocumentKindFieldsTx(tx *sql.Tx, code models.DocumentKind, fields []models.CustomDocumentKindField) error {
+	codes := make([]string, len(fields))
+	types := make([]string, len(fields))
+	for i, field := range fields {
+		codes[i] = field.Code
+		types[i] = string(field.FieldType)
+	}
+
+	var changedLabel string
+	err := tx.QueryRow(`
+		SELECT f.label
+		FROM document_kind_fields f
+		JOIN unnest($2::varchar[], $3::varchar[]) AS n(code, field_type) ON n.code = f.code
+		WHERE f.kind_code = $1
+		  AND f.field_type <> n.field_type
+		  AND EXISTS (
+			SELECT 1
+			FROM custom_document_details cd
+			JOIN documents d ON d.id = cd.document_id
+			WHERE d.kind = f.kind_code AND cd.field_values ? f.code
+		  )
+		LIMIT 1
+	`, code, pq.Array(codes), pq.Array(types)).Scan(&changedLabel)
+	if err == nil {
+		return models.NewConflict(fmt.Sprintf("поле «%s» уже заполнено в документах, его тип нельзя изменить", changedLabel))
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check custom document kind field types: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM document_kind_fields WHERE kind_code = $1 AND NOT (code = ANY($2))
+	`, code, pq.Array(codes)); err != nil {
+		return fmt.Errorf("failed to clear custom document kind fields: %w", err)
+	}
+	for _, field := range fields {
+		if _, err := tx.Exec(`
+			INSERT INTO document_kind_fields (kind_code, code, label, field_type, is_required, options, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (kind_code, code) DO UPDATE SET
+				label = EXCLUDED.label,
+				field_type = EXCLUDED.field_type,
+				is_required = EXCLUDED.is_required,
+				options = EXCLUDED.options,
+				position = EXCLUDED.position
+		`, code, field.Code, field.Label, field.FieldType, field.IsRequired, pq.Array(field.Options), field.Position); err != nil {
+			return fmt.Errorf("failed to save custom document kind field: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	customDocumentKindColumns      = []string{"code", "name", "is_active", "created_at", "updated_at", "documents_count"}
+	customDocumentKindFieldColumns = []string{"id", "kind_code", "code", "label", "field_type", "is_required", "options", "position"}
+)
+
+func TestCustomDocumentKindRepository_GetAll(t *testing.T) {
+	// Виды и поля загружаются двумя запросами, поля раскладываются по видам.
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCustomDocumentKindRepository(&database.DB{DB: db})
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM document_kinds k\s+WHERE NOT k.is_system\s+ORDER BY k.name`).
+		WillReturnRows(sqlmock.NewRows(customDocumentKindColumns).
+			AddRow("contract", "Договор", true, now, now, 3).
+			AddRow("memo", "Служебная записка", false, now, now, 0))
+	mock.ExpectQuery(`FROM document_kind_fields f\s+WHERE f.kind_code = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"contract", "memo"})).
+		WillReturnRows(sqlmock.NewRows(customDocumentKindFieldColumns).
+			AddRow(uuid.New(), "contract", "counterparty", "Контрагент", "organization", true, "{}", 1).
+			AddRow(uuid.New(), "contract", "status", "Статус", "enum", false, "{Проект,Подписан}", 2))
+
+	kinds, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, kinds, 2)
+	assert.Equal(t, 3, kinds[0].DocumentsCount)
+	require.Len(t, kinds[0].Fields, 2)
+	assert.Equal(t, models.CustomFieldEnum, kinds[0].Fields[1].FieldType)
+	assert.Equal(t, []string{"Проект", "Подписан"}, kinds[0].Fields[1].Options)
+	assert.False(t, kinds[1].IsActive)
+	assert.Empty(t, kinds[1].Fields)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCustomDocumentKindRepository_CreateNameTaken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCustomDocumentKindRepository(&database.DB{DB: db})
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO document_kinds`).
+		WithArgs(models.DocumentKind("contract"), "Договор", true).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_document_kinds_name"})
+	mock.ExpectRollback()
+
+	_, err = repo.Create(models.CustomDocumentKind{Code: "contract", Name: "Договор", IsActive: true})
+	assert.Equal(t, errCustomDocumentKindNameTaken, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCustomDocumentKindRepository_CreateLinksDocumentType(t *testing.T) {
+	// Создание вида сохраняет поля и заводит одноименный тип документа.
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCustomDocumentKindRepository(&database.DB{DB: db})
+	typeID := uuid.New()
+	now := time.Now()
+	kind := models.CustomDocumentKind{
+		Code:     "contract",
+		Name:     "Договор",
+		IsActive: true,
+		Fields: []models.CustomDocumentKindField{
+			{Code: "amount", Label: "Сумма", FieldType: models.CustomFieldNumber, Position: 1},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO document_kinds`).
+		WithArgs(kind.Code, kind.Name, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT f.label\s+FROM document_kind_fields f`).
+		WithArgs(kind.Code, pq.Array([]string{"amount"}), pq.Array([]string{"number"})).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`DELETE FROM document_kind_fields`).
+		WithArgs(kind.Code, pq.Array([]string{"amount"})).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO document_kind_fields`).
+		WithArgs(kind.Code, "amount", "Сумма", models.CustomFieldNumber, false, pq.Array([]string(nil)), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO document_types \(name\)`).
+		WithArgs("Договор").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(typeID))
+	mock.ExpectExec(`INSERT INTO document_type_kinds`).
+		WithArgs(typeID, kind.Code).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`AND k.code = \$1`).
+		WithArgs(kind.Code).
+		WillReturnRows(sqlmock.NewRows(customDocumentKindColumns).AddRow("contract", "Договор", true, now, now, 0))
+	mock.ExpectQuery(`WHERE f.kind_code = \$1`).
+		WithArgs(kind.Code).
+		WillReturnRows(sqlmock.NewRows(customDocumentKindFieldColumns).
+			AddRow(uuid.New(), "contract", "amount", "Сумма", "number", false, "{}", 1))
+	mock.ExpectCommit()
+
+	result, err := repo.Create(kind)
+	require.NoError(t, err)
+	require.Len(t, result.Fields, 1)
+	assert.Equal(t, "Сумма", result.Fields[0].Label)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCustomDocumentKindRepository_UpdateRejectsFilledFieldTypeChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCustomDocumentKindRepository(&database.DB{DB: db})
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE document_kinds SET name = \$2`).
+		WithArgs(models.DocumentKind("contract"), "Договор", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT f.label\s+FROM document_kind_fields f`).
+		WillReturnRows(sqlmock.NewRows([]string{"label"}).AddRow("Сумма"))
+	mock.ExpectRollback()
+
+	_, err = repo.Update(models.CustomDocumentKind{
+		Code:     "contract",
+		Name:     "Договор",
+		IsActive: true,
+		Fields:   []models.CustomDocumentKindField{{Code: "amount", Label: "Сумма", FieldType: models.CustomFieldText, Position: 1}},
+	})
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, 409, appErr.Code)
+	assert.Contains(t, appErr.Message, "поле «Сумма» уже заполнено в документах")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCustomDocumentKindRepository_Delete(t *testing.T) {
+	t.Run("используемый вид", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := NewCustomDocumentKindRepository(&database.DB{DB: db})
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM document_kinds WHERE code = \$1 AND NOT is_system`).
+			WithArgs(models.DocumentKind("contract")).
+			WillReturnError(&pq.Error{Code: "23503"})
+		mock.ExpectRollback()
+
+		err = repo.Delete("contract")
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("не найден", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := NewCustomDocumentKindRepository(&database.DB{DB: db})
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM document_kinds`).
+			WithArgs(models.DocumentKind("incoming_letter")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		assert.Equal(t, errCustomDocumentKindNotFound, repo.Delete(models.DocumentKindIncomingLetter))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
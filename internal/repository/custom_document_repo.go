@@ -0,0 +1,487 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// CustomDocumentRepository предоставляет методы для работы с документами пользовательских видов в БД.
+type CustomDocumentRepository struct {
+	db     *database.DB
+	outbox *OutboxRepository
+}
+
+func (r *CustomDocumentRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
+
+// NewCustomDocumentRepository создает новый экземпляр CustomDocumentRepository.
+func NewCustomDocumentRepository(db *database.DB) *CustomDocumentRepository {
+	return &CustomDocumentRepository{db: db}
+}
+
+const customDocumentSelect = `
+	SELECT
+		d.id, d.kind, d.nomenclature_id, n.index || ' — ' || n.name AS nomenclature_name,
+		d.registration_number, d.registration_date, d.document_type_id, dt.name,
+		d.content, d.pages_count,
+		d.created_by, u.full_name AS created_by_name,
+		d.created_at, d.updated_at
+	FROM documents d
+	JOIN custom_document_details cd ON cd.document_id = d.id
+	JOIN document_types dt ON dt.id = d.document_type_id
+	JOIN nomenclature n ON d.nomenclature_id = n.id
+	JOIN users u ON d.created_by = u.id
+`
+
+// Шаблоны значений, которые безопасно приводить к date и numeric в фильтрах.
+const (
+	customFieldDatePattern   = `^\d{4}-\d{2}-\d{2}$`
+	customFieldNumberPattern = `^-?\d+(\.\d+)?$`
+)
+
+// GetList возвращает список документов пользовательского вида с учетом фильтрации и пагинации.
+func (r *CustomDocumentRepository) GetList(kind models.DocumentKind, filter models.DocumentFilter) (*models.PagedResult[models.CustomDocument], error) {
+	where := []string{"d.kind = $1"}
+	args := []interface{}{kind}
+	argIdx := 2
+
+	scope := documentListAccessScope(filter.AccessScope, filter.AllowedNomenclatureIDs, filter.AccessibleByUserID, filter.AccessibleByUserIDs)
+	applyDocumentListAccess(&where, &args, &argIdx, scope)
+
+	if len(filter.NomenclatureIDs) > 0 {
+		where = append(where, fmt.Sprintf("d.nomenclature_id = ANY($%d)", argIdx))
+		args = append(args, pq.Array(filter.NomenclatureIDs))
+		argIdx++
+	}
+	if filter.DocumentTypeID != "" {
+		where = append(where, documentTypeFilterExpr(fmt.Sprintf("$%d", argIdx)))
+		args = append(args, filter.DocumentTypeID)
+		argIdx++
+	}
+	if filter.DateFrom != "" {
+		where = append(where, fmt.Sprintf("d.registration_date >= $%d", argIdx))
+		args = append(args, filter.DateFrom)
+		argIdx++
+	}
+	if filter.DateTo != "" {
+		where = append(where, fmt.Sprintf("d.registration_date <= $%d", argIdx))
+		args = append(args, filter.DateTo)
+		argIdx++
+	}
+	if filter.Search != "" {
+		where = append(where, fmt.Sprintf("(d.content ILIKE $%d OR d.registration_number ILIKE $%d OR cd.field_values::text ILIKE $%d)", argIdx, argIdx, argIdx))
+		args = append(args, "%"+filter.Search+"%")
+		argIdx++
+	}
+	if filter.RegistrationNumber != "" {
+		where = append(where, fmt.Sprintf("d.registration_number ILIKE $%d", argIdx))
+		args = append(args, "%"+filter.RegistrationNumber+"%")
+		argIdx++
+	}
+	applyCustomFieldFilters(&where, &args, &argIdx, filter.CustomFields)
+
+	var totalCount int
+	if err := applyDocumentCursor(&where, &args, &argIdx, filter.CursorPagination, filter.Cursor); err != nil {
+		return nil, err
+	}
+	filter.Page, filter.PageSize = normalizePagination(filter.Page, filter.PageSize)
+	whereClause := strings.Join(where, " AND ")
+	if !filter.CursorPagination {
+		countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM documents d
+			JOIN custom_document_details cd ON cd.document_id = d.id WHERE %s`, whereClause)
+		if err := r.db.QueryRow(countQuery, args...).Scan(&totalCount); err != nil {
+			return nil, fmt.Errorf("failed to count custom documents: %w", err)
+		}
+	}
+	limit := filter.PageSize
+	if filter.CursorPagination {
+		limit++
+	}
+
+	query := fmt.Sprintf(`%s
+		WHERE %s
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $%d%s
+	`, customDocumentSelect, whereClause, argIdx, map[bool]string{true: "", false: fmt.Sprintf(" OFFSET $%d", argIdx+1)}[filter.CursorPagination])
+	args = append(args, limit)
+	if !filter.CursorPagination {
+		args = append(args, (filter.Page-1)*filter.PageSize)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom documents: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.CustomDocument, 0)
+	for rows.Next() {
+		doc, err := scanCustomDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	hasMore := filter.CursorPagination && len(items) > filter.PageSize
+	if hasMore {
+		items = items[:filter.PageSize]
+	}
+	if err := r.attachFieldValues(items); err != nil {
+		return nil, err
+	}
+
+	nextCursor := ""
+	if hasMore {
+		last := items[len(items)-1]
+		nextCursor, err = models.EncodeDocumentCursor(last.CreatedAt, last.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &models.PagedResult[models.CustomDocument]{
+		Items:      items,
+		TotalCount: totalCount,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// applyCustomFieldFilters добавляет условия по значениям полей. Типы полей заранее
+// определяет сервис по схеме вида; код поля передается параметром запроса.
+func applyCustomFieldFilters(where *[]string, args *[]interface{}, argIdx *int, filters []models.CustomFieldFilter) {
+	for _, filter := range filters {
+		if filter.Value == "" && filter.From == "" && filter.To == "" {
+			continue
+		}
+		codeParam := fmt.Sprintf("$%d", *argIdx)
+		*args = append(*args, filter.Code)
+		*argIdx++
+		value := fmt.Sprintf("cd.field_values ->> %s", codeParam)
+
+		switch filter.Type {
+		case models.CustomFieldDate, models.CustomFieldNumber:
+			pattern, cast := customFieldDatePattern, "date"
+			if filter.Type == models.CustomFieldNumber {
+				pattern, cast = customFieldNumberPattern, "numeric"
+			}
+			typed := fmt.Sprintf("(CASE WHEN %s ~ '%s' THEN (%s)::%s END)", value, pattern, value, cast)
+			if filter.Value != "" {
+				*where = append(*where, fmt.Sprintf("%s = $%d::%s", typed, *argIdx, cast))
+				*args = append(*args, filter.Value)
+				*argIdx++
+			}
+			if filter.From != "" {
+				*where = append(*where, fmt.Sprintf("%s >= $%d::%s", typed, *argIdx, cast))
+				*args = append(*args, filter.From)
+				*argIdx++
+			}
+			if filter.To != "" {
+				*where = append(*where, fmt.Sprintf("%s <= $%d::%s", typed, *argIdx, cast))
+				*args = append(*args, filter.To)
+				*argIdx++
+			}
+		case models.CustomFieldText:
+			*where = append(*where, fmt.Sprintf("%s ILIKE $%d", value, *argIdx))
+			*args = append(*args, "%"+filter.Value+"%")
+			*argIdx++
+		default:
+			*where = append(*where, fmt.Sprintf("%s = $%d", value, *argIdx))
+			*args = append(*args, filter.Value)
+			*argIdx++
+		}
+	}
+}
+
+// GetByID возвращает документ пользовательского вида по ID.
+func (r *CustomDocumentRepository) GetByID(id uuid.UUID) (*models.CustomDocument, error) {
+	doc, err := scanCustomDocument(r.db.QueryRow(customDocumentSelect+` WHERE d.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom document: %w", err)
+	}
+
+	items := []models.CustomDocument{*doc}
+	if err := r.attachFieldValues(items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// Create создает документ пользовательского вида.
+func (r *CustomDocumentRepository) Create(req models.CreateCustomDocumentRequest) (*models.CustomDocument, error) {
+	return r.create(req, "", "")
+}
+
+func (r *CustomDocumentRepository) CreateWithJournal(req models.CreateCustomDocumentRequest, action, detailsFormat string) (*models.CustomDocument, error) {
+	return r.create(req, action, detailsFormat)
+}
+
+func (r *CustomDocumentRepository) create(req models.CreateCustomDocumentRequest, journalAction, journalDetailsFormat string) (*models.CustomDocument, error) {
+	fieldValues, err := json.Marshal(req.FieldValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode custom document fields: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var registration *registrationNumberResult
+	if req.AdminNumberOverride != nil {
+		registration, err = resolveAdminRegistrationNumberTx(tx, req.CreatedBy, req.Kind, req.NomenclatureID, req.IdempotencyKey, req.AdminNumberOverride)
+	} else {
+		registration, err = resolveRegistrationNumberTx(tx, req.CreatedBy, req.Kind, req.NomenclatureID, req.IdempotencyKey, req.RegistrationNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if registration.Existing != uuid.Nil {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit idempotent transaction: %w", err)
+		}
+		return r.GetByID(registration.Existing)
+	}
+	req.RegistrationNumber = registration.Number
+
+	if err := requireCustomFieldReferencesTx(tx, req.OrganizationIDs, req.UserIDs); err != nil {
+		return nil, err
+	}
+
+	typeExpr, typeArg := defaultDocumentTypeIDExpr("$1", "$6"), req.KindName
+	if req.DocumentTypeID != "" {
+		typeExpr, typeArg = documentTypeIDExpr("$1", "$6"), req.DocumentTypeID
+	}
+	var id uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO documents (
+			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by
+		) VALUES ($1, $2, $3, $4, $5, `+typeExpr+`, $7, $8, $9)
+		RETURNING id
+	`,
+		req.Kind,
+		req.NomenclatureID,
+		req.IdempotencyKey,
+		req.RegistrationNumber,
+		req.RegistrationDate,
+		typeArg,
+		req.Content,
+		req.PagesCount,
+		req.CreatedBy,
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err, "idx_documents_created_by_kind_idempotency") {
+			_ = tx.Rollback()
+			existingID, lookupErr := findExistingDocumentIDByIdempotency(r.db, req.CreatedBy, req.Kind, req.IdempotencyKey)
+			if lookupErr != nil {
+				return nil, fmt.Errorf("failed to resolve idempotent document: %w", lookupErr)
+			}
+			return r.GetByID(existingID)
+		}
+		if isUniqueViolation(err, "idx_documents_kind_registration_number_year") {
+			return nil, models.NewConflict("документ с таким регистрационным номером уже существует")
+		}
+		if isInvalidDocumentType(err) {
+			return nil, errInvalidDocumentType
+		}
+		return nil, fmt.Errorf("failed to create custom document root: %w", err)
+	}
+
+	if _, err = tx.Exec(`
+		INSERT INTO custom_document_details (document_id, field_values) VALUES ($1, $2)
+	`, id, string(fieldValues)); err != nil {
+		return nil, fmt.Errorf("failed to create custom document details: %w", err)
+	}
+
+	if journalAction != "" {
+		if r.outbox == nil {
+			return nil, fmt.Errorf("outbox repository is required for document journal")
+		}
+		payload := fmt.Sprintf(`{"documentId":"%s","userId":"%s","action":%q,"details":%q}`, id, req.CreatedBy, journalAction, fmt.Sprintf(journalDetailsFormat, req.RegistrationNumber))
+		if err := r.outbox.EnqueueTx(tx, models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "custom-document:" + id.String() + ":create:journal", Payload: payload}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(id)
+}
+
+// Update обновляет документ пользовательского вида.
+func (r *CustomDocumentRepository) Update(req models.UpdateCustomDocumentRequest) (*models.CustomDocument, error) {
+	return r.update(req, nil)
+}
+
+func (r *CustomDocumentRepository) UpdateWithOutbox(req models.UpdateCustomDocumentRequest, effects []models.OutboxEvent) (*models.CustomDocument, error) {
+	return r.update(req, effects)
+}
+
+// update сохраняет текущий тип документа, если DocumentTypeID не передан.
+func (r *CustomDocumentRepository) update(req models.UpdateCustomDocumentRequest, effects []models.OutboxEvent) (*models.CustomDocument, error) {
+	fieldValues, err := json.Marshal(req.FieldValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode custom document fields: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireCustomFieldReferencesTx(tx, req.OrganizationIDs, req.UserIDs); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE documents SET
+			document_type_id = CASE WHEN $1 = '' THEN document_type_id ELSE `+documentTypeIDUpdateExpr("documents", "$6", "$1")+` END,
+			registration_date = $2,
+			content = $3,
+			pages_count = $4,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5 AND kind = $6
+	`, req.DocumentTypeID, req.RegistrationDate, req.Content, req.PagesCount, req.ID, req.Kind)
+	if err != nil {
+		if isInvalidDocumentType(err) {
+			return nil, errInvalidDocumentType
+		}
+		return nil, fmt.Errorf("failed to update custom document root: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, models.NewNotFound("документ не найден")
+	}
+
+	if _, err = tx.Exec(`
+		UPDATE custom_document_details SET field_values = $1 WHERE document_id = $2
+	`, string(fieldValues), req.ID); err != nil {
+		return nil, fmt.Errorf("failed to update custom document details: %w", err)
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(req.ID)
+}
+
+// attachFieldValues загружает значения полей документов одним запросом. Поля, удаленные
+// из схемы вида, не возвращаются; для ссылок подставляется имя организации или пользователя.
+func (r *CustomDocumentRepository) attachFieldValues(items []models.CustomDocument) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+		items[i].Fields = []models.CustomDocumentFieldValue{}
+	}
+
+	rows, err := r.db.Query(`
+		SELECT
+			cd.document_id, f.code, f.label, f.field_type, v.value,
+			COALESCE(o.name, NULLIF(u.full_name, ''), u.login, v.value) AS display_value
+		FROM custom_document_details cd
+		JOIN documents d ON d.id = cd.document_id
+		JOIN document_kind_fields f ON f.kind_code = d.kind
+		CROSS JOIN LATERAL (SELECT cd.field_values ->> f.code AS value) v
+		LEFT JOIN organizations o ON f.field_type = 'organization' AND o.id::text = v.value
+		LEFT JOIN users u ON f.field_type = 'user' AND u.id::text = v.value
+		WHERE cd.document_id = ANY($1) AND v.value IS NOT NULL
+		ORDER BY cd.document_id, f.position, f.code
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get custom document fields: %w", err)
+	}
+	defer rows.Close()
+
+	byDocumentID := make(map[uuid.UUID][]models.CustomDocumentFieldValue, len(items))
+	for rows.Next() {
+		var documentID uuid.UUID
+		var value models.CustomDocumentFieldValue
+		if err := rows.Scan(&documentID, &value.Code, &value.Label, &value.FieldType, &value.Value, &value.DisplayValue); err != nil {
+			return err
+		}
+		byDocumentID[documentID] = append(byDocumentID[documentID], value)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range items {
+		if values, ok := byDocumentID[items[i].ID]; ok {
+			items[i].Fields = values
+		}
+	}
+	return nil
+}
+
+// requireCustomFieldReferencesTx проверяет, что организации и пользователи из полей документа существуют.
+func requireCustomFieldReferencesTx(tx *sql.Tx, organizationIDs, userIDs []uuid.UUID) error {
+	checks := []struct {
+		ids     []uuid.UUID
+		table   string
+		message string
+	}{
+		{organizationIDs, "organizations", "организация из поля документа не найдена"},
+		{userIDs, "users", "пользователь из поля документа не найден"},
+	}
+	for _, check := range checks {
+		if len(check.ids) == 0 {
+			continue
+		}
+		var missing int
+		if err := tx.QueryRow(fmt.Sprintf(`
+			SELECT COUNT(*) FROM unnest($1::uuid[]) AS r(id)
+			WHERE NOT EXISTS (SELECT 1 FROM %s t WHERE t.id = r.id)
+		`, check.table), pq.Array(check.ids)).Scan(&missing); err != nil {
+			return fmt.Errorf("failed to check custom document references: %w", err)
+		}
+		if missing > 0 {
+			return models.NewBadRequest(check.message)
+		}
+	}
+	return nil
+}
+
+type customDocumentScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCustomDocument(scanner customDocumentScanner) (*models.CustomDocument, error) {
+	var doc models.CustomDocument
+	if err := scanner.Scan(
+		&doc.ID, &doc.Kind, &doc.NomenclatureID, &doc.NomenclatureName,
+		&doc.RegistrationNumber, &doc.RegistrationDate, &doc.DocumentTypeID, &doc.DocumentTypeName,
+		&doc.Content, &doc.PagesCount,
+		&doc.CreatedBy, &doc.CreatedByName,
+		&doc.CreatedAt, &doc.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
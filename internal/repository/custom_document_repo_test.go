@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	customDocumentColumns = []string{
+		"id", "kind", "nomenclature_id", "nomenclature_name",
+		"registration_number", "registration_date", "document_type_id", "document_type_name",
+		"content", "pages_count", "created_by", "created_by_name", "created_at", "updated_at",
+	}
+	customDocumentFieldValueColumns = []string{"document_id", "code", "label", "field_type", "value", "display_value"}
+)
+
+func TestApplyCustomFieldFilters(t *testing.T) {
+	where := []string{"d.kind = $1"}
+	args := []interface{}{"contract"}
+	argIdx := 2
+
+	applyCustomFieldFilters(&where, &args, &argIdx, []models.CustomFieldFilter{
+		{Code: "amount", From: "100", To: "200.5", Type: models.CustomFieldNumber},
+		{Code: "note", Value: "срочно", Type: models.CustomFieldText},
+		{Code: "status", Value: "Подписан", Type: models.CustomFieldEnum},
+		{Code: "signed_at", Type: models.CustomFieldDate},
+	})
+
+	require.Len(t, where, 5)
+	assert.Equal(t, `(CASE WHEN cd.field_values ->> $2 ~ '^-?\d+(\.\d+)?$' THEN (cd.field_values ->> $2)::numeric END) >= $3::numeric`, where[1])
+	assert.Equal(t, `(CASE WHEN cd.field_values ->> $2 ~ '^-?\d+(\.\d+)?$' THEN (cd.field_values ->> $2)::numeric END) <= $4::numeric`, where[2])
+	assert.Equal(t, `cd.field_values ->> $5 ILIKE $6`, where[3])
+	assert.Equal(t, `cd.field_values ->> $7 = $8`, where[4])
+	assert.Equal(t, []interface{}{"contract", "amount", "100", "200.5", "note", "%срочно%", "status", "Подписан"}, args)
+	assert.Equal(t, 9, argIdx)
+}
+
+func TestCustomDocumentRepository_GetByID(t *testing.T) {
+	// Карточка собирается из корня документа и значений полей со ссылками на справочники.
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCustomDocumentRepository(&database.DB{DB: db})
+	id := uuid.New()
+	orgID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`JOIN custom_document_details cd ON cd.document_id = d.id(.|\n)*WHERE d.id = \$1`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(customDocumentColumns).AddRow(
+			id, "contract", uuid.New(), "01-01 — Договоры",
+			"Д-1", now, uuid.New(), "Договор",
+			"Договор поставки", 2, uuid.New(), "Иванов", now, now,
+		))
+	mock.ExpectQuery(`CROSS JOIN LATERAL`).
+		WithArgs(pq.Array([]uuid.UUID{id})).
+		WillReturnRows(sqlmock.NewRows(customDocumentFieldValueColumns).
+			AddRow(id, "counterparty", "Контрагент", "organization", orgID.String(), "ООО Ромашка").
+			AddRow(id, "amount", "Сумма", "number", "1500", "1500"))
+
+	doc, err := repo.GetByID(id)
+	require.NoError(t, err)
+	require.NotNil(t, doc)
+	assert.Equal(t, models.DocumentKind("contract"), doc.Kind)
+	assert.Equal(t, "Договор", doc.DocumentTypeName)
+	require.Len(t, doc.Fields, 2)
+	assert.Equal(t, orgID.String(), doc.Fields[0].Value)
+	assert.Equal(t, "ООО Ромашка", doc.Fields[0].DisplayValue)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCustomDocumentRepository_GetByIDNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCustomDocumentRepository(&database.DB{DB: db})
+	id := uuid.New()
+	mock.ExpectQuery(`WHERE d.id = \$1`).WithArgs(id).WillReturnError(sql.ErrNoRows)
+
+	doc, err := repo.GetByID(id)
+	require.NoError(t, err)
+	assert.Nil(t, doc)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCustomDocumentRepository_UpdateRejectsMissingReferences(t *testing.T) {
+	// Ссылки на несуществующие организации отклоняются до изменения документа.
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCustomDocumentRepository(&database.DB{DB: db})
+	orgID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM unnest\(\$1::uuid\[\]\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	_, err = repo.Update(models.UpdateCustomDocumentRequest{
+		ID:              uuid.New(),
+		Kind:            "contract",
+		Content:         "Договор",
+		PagesCount:      1,
+		FieldValues:     map[string]string{"counterparty": orgID.String()},
+		OrganizationIDs: []uuid.UUID{orgID},
+	})
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, 400, appErr.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCustomDocumentRepository_UpdateNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCustomDocumentRepository(&database.DB{DB: db})
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE documents SET(.|\n)*WHERE id = \$5 AND kind = \$6`).
+		WithArgs("", sqlmock.AnyArg(), "Договор", 1, id, models.DocumentKind("contract")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err = repo.Update(models.UpdateCustomDocumentRequest{ID: id, Kind: "contract", Content: "Договор", PagesCount: 1})
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, 404, appErr.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
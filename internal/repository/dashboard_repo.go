@@ -30,8 +30,8 @@ func (r *DashboardRepository) GetExpiringAssignments(filter models.DashboardAssi
 	}
 	args := []interface{}{filter.Days}
 	argIdx := 2
-	if len(filter.AllowedDocumentKinds) > 0 || len(filter.AccessibleByUserIDs) > 0 {
-		accessClauses := make([]string, 0, 2)
+	if len(filter.AllowedDocumentKinds) > 0 || len(filter.AccessibleByUserIDs) > 0 || len(filter.AccessibleByUserKindKeys) > 0 {
+		accessClauses := make([]string, 0, 3)
 		if len(filter.AllowedDocumentKinds) > 0 {
 			accessClauses = append(accessClauses, fmt.Sprintf("d.kind = ANY($%d)", argIdx))
 			args = append(args, pq.Array(filter.AllowedDocumentKinds))
@@ -40,6 +40,11 @@ func (r *DashboardRepository) GetExpiringAssignments(filter models.DashboardAssi
 		if len(filter.AccessibleByUserIDs) > 0 {
 			accessClauses = append(accessClauses, fmt.Sprintf("(a.executor_id = ANY($%d::uuid[]) OR EXISTS (SELECT 1 FROM assignment_co_executors ce WHERE ce.assignment_id = a.id AND ce.user_id = ANY($%d::uuid[])))", argIdx, argIdx))
 			args = append(args, pq.Array(filter.AccessibleByUserIDs))
+			argIdx++
+		}
+		if len(filter.AccessibleByUserKindKeys) > 0 {
+			accessClauses = append(accessClauses, fmt.Sprintf("((a.executor_id::text || ':' || d.kind) = ANY($%d) OR EXISTS (SELECT 1 FROM assignment_co_executors ce WHERE ce.assignment_id = a.id AND (ce.user_id::text || ':' || d.kind) = ANY($%d)))", argIdx, argIdx))
+			args = append(args, pq.Array(filter.AccessibleByUserKindKeys))
 		}
 		where = append(where, "("+strings.Join(accessClauses, " OR ")+")")
 	}
@@ -25,7 +25,7 @@ func NewDepartmentRepository(db *database.DB) *DepartmentRepository {
 // GetAll возвращает список всех подразделений.
 func (r *DepartmentRepository) GetAll() ([]models.Department, error) {
 	query := `
-		SELECT id, name, created_at, updated_at
+		SELECT id, name, parent_id, head_user_id, created_at, updated_at
 		FROM departments
 		ORDER BY name ASC
 	`
@@ -38,7 +38,7 @@ func (r *DepartmentRepository) GetAll() ([]models.Department, error) {
 	departments := make([]models.Department, 0)
 	for rows.Next() {
 		var d models.Department
-		if err := rows.Scan(&d.ID, &d.Name, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		if err := rows.Scan(&d.ID, &d.Name, &d.ParentID, &d.HeadUserID, &d.CreatedAt, &d.UpdatedAt); err != nil {
 			return nil, err
 		}
 
@@ -85,14 +85,65 @@ func (r *DepartmentRepository) loadNomenclature(d *models.Department) error {
 	return rows.Err()
 }
 
-// GetNomenclatureIDs возвращает список ID номенклатур, привязанных к подразделению.
+// departmentAncestorsCTE выбирает подразделение $1 и все вышестоящие подразделения.
+// UNION вместо UNION ALL останавливает обход, даже если в данных оказался цикл.
+const departmentAncestorsCTE = `
+	ancestors AS (
+		SELECT id, parent_id FROM departments WHERE id = $1
+		UNION
+		SELECT d.id, d.parent_id
+		FROM departments d
+		JOIN ancestors a ON d.id = a.parent_id
+	)`
+
+// GetNomenclatureIDs возвращает список ID номенклатур, доступных подразделению:
+// собственных и унаследованных от всех вышестоящих подразделений.
 func (r *DepartmentRepository) GetNomenclatureIDs(departmentID uuid.UUID) ([]string, error) {
 	query := `
-		SELECT nomenclature_id
-		FROM department_nomenclature
-		WHERE department_id = $1
+		WITH RECURSIVE` + departmentAncestorsCTE + `
+		SELECT DISTINCT dn.nomenclature_id
+		FROM department_nomenclature dn
+		JOIN ancestors a ON a.id = dn.department_id
 	`
-	rows, err := r.db.Query(query, departmentID)
+	return r.queryNomenclatureIDs(query, departmentID)
+}
+
+// GetVisibleNomenclatureIDs возвращает номенклатуры, дела которых видит сотрудник:
+// номенклатуры его подразделения с унаследованными от вышестоящих, а для
+// руководителя — также номенклатуры всех подразделений, которыми он руководит,
+// вместе с их подчиненными подразделениями.
+func (r *DepartmentRepository) GetVisibleNomenclatureIDs(departmentID *uuid.UUID, userID uuid.UUID) ([]string, error) {
+	query := `
+		WITH RECURSIVE` + departmentAncestorsCTE + `,
+		headed AS (
+			SELECT id, parent_id FROM departments WHERE head_user_id = $2
+			UNION
+			SELECT d.id, d.parent_id
+			FROM departments d
+			JOIN headed h ON d.parent_id = h.id
+		),
+		headed_ancestors AS (
+			SELECT id, parent_id FROM departments WHERE head_user_id = $2
+			UNION
+			SELECT d.id, d.parent_id
+			FROM departments d
+			JOIN headed_ancestors a ON d.id = a.parent_id
+		)
+		SELECT DISTINCT dn.nomenclature_id
+		FROM department_nomenclature dn
+		WHERE dn.department_id IN (
+			SELECT id FROM ancestors
+			UNION
+			SELECT id FROM headed
+			UNION
+			SELECT id FROM headed_ancestors
+		)
+	`
+	return r.queryNomenclatureIDs(query, departmentID, userID)
+}
+
+func (r *DepartmentRepository) queryNomenclatureIDs(query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -133,10 +184,10 @@ func (r *DepartmentRepository) create(name string, nomenclatureIDs []string, eff
 	query := `
 		INSERT INTO departments (id, name, created_at, updated_at)
 		VALUES ($1, $2, NOW(), NOW())
-		RETURNING id, name, created_at, updated_at
+		RETURNING id, name, parent_id, head_user_id, created_at, updated_at
 	`
 	var d models.Department
-	err = tx.QueryRow(query, id, name).Scan(&d.ID, &d.Name, &d.CreatedAt, &d.UpdatedAt)
+	err = tx.QueryRow(query, id, name).Scan(&d.ID, &d.Name, &d.ParentID, &d.HeadUserID, &d.CreatedAt, &d.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -193,10 +244,10 @@ func (r *DepartmentRepository) update(id uuid.UUID, name string, nomenclatureIDs
 		UPDATE departments
 		SET name = $2, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, created_at, updated_at
+		RETURNING id, name, parent_id, head_user_id, created_at, updated_at
 	`
 	var d models.Department
-	err = tx.QueryRow(query, id, name).Scan(&d.ID, &d.Name, &d.CreatedAt, &d.UpdatedAt)
+	err = tx.QueryRow(query, id, name).Scan(&d.ID, &d.Name, &d.ParentID, &d.HeadUserID, &d.CreatedAt, &d.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.NewNotFound("подразделение не найдено")
@@ -244,7 +295,7 @@ func (r *DepartmentRepository) Delete(id uuid.UUID) error {
 	query := `DELETE FROM departments WHERE id = $1`
 	result, err := r.db.Exec(query, id)
 	if err != nil {
-		return err
+		return mapDepartmentHierarchyError(err)
 	}
 	affected, err := result.RowsAffected()
 	if err != nil {
@@ -263,9 +314,38 @@ func (r *DepartmentRepository) DeleteWithOutbox(id uuid.UUID, effects []models.O
 	}
 	defer tx.Rollback()
 	result, err := tx.Exec(`DELETE FROM departments WHERE id = $1`, id)
+	if err != nil {
+		return mapDepartmentHierarchyError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewNotFound("подразделение не найдено")
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MoveWithOutbox переносит подразделение под parentID (nil — на верхний уровень).
+// Перенос под собственное подчиненное подразделение отклоняет триггер БД.
+func (r *DepartmentRepository) MoveWithOutbox(id uuid.UUID, parentID *uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE departments SET parent_id = $2, updated_at = NOW() WHERE id = $1`, id, parentID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.NewNotFound("вышестоящее подразделение не найдено")
+		}
+		return mapDepartmentHierarchyError(err)
+	}
 	affected, err := result.RowsAffected()
 	if err != nil {
 		return err
@@ -278,3 +358,101 @@ func (r *DepartmentRepository) DeleteWithOutbox(id uuid.UUID, effects []models.O
 	}
 	return tx.Commit()
 }
+
+// SetHeadWithOutbox назначает руководителя подразделения (nil — снять руководителя).
+func (r *DepartmentRepository) SetHeadWithOutbox(id uuid.UUID, headUserID *uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE departments SET head_user_id = $2, updated_at = NOW() WHERE id = $1`, id, headUserID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.NewNotFound("пользователь не найден")
+		}
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewNotFound("подразделение не найдено")
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MergeWithOutbox сливает подразделение sourceID в targetID одной транзакцией:
+// сотрудники, номенклатура, подчиненные подразделения, роли и правила матрицы
+// доступа переходят в targetID (при совпадении правил остается правило targetID),
+// руководитель sourceID становится руководителем targetID, если у того его нет,
+// после чего sourceID удаляется.
+func (r *DepartmentRepository) MergeWithOutbox(sourceID, targetID uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT id FROM departments WHERE id IN ($1, $2) FOR UPDATE
+		) d
+	`, sourceID, targetID).Scan(&locked); err != nil {
+		return err
+	}
+	if locked != 2 {
+		return models.NewNotFound("подразделение не найдено")
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE users SET department_id = $2, updated_at = CURRENT_TIMESTAMP WHERE department_id = $1`, []interface{}{sourceID, targetID}},
+		{`INSERT INTO department_nomenclature (department_id, nomenclature_id)
+		SELECT $2, nomenclature_id FROM department_nomenclature WHERE department_id = $1
+		ON CONFLICT DO NOTHING`, []interface{}{sourceID, targetID}},
+		{`INSERT INTO department_access_roles (department_id, role_id)
+		SELECT $2, role_id FROM department_access_roles WHERE department_id = $1
+		ON CONFLICT DO NOTHING`, []interface{}{sourceID, targetID}},
+		{`INSERT INTO document_permissions (kind_code, subject_type, subject_key, action, is_allowed)
+		SELECT kind_code, subject_type, $2, action, is_allowed
+		FROM document_permissions
+		WHERE subject_type = 'department' AND subject_key = $1
+		ON CONFLICT (kind_code, subject_type, subject_key, action) DO NOTHING`, []interface{}{sourceID.String(), targetID.String()}},
+		{`DELETE FROM document_permissions WHERE subject_type = 'department' AND subject_key = $1`, []interface{}{sourceID.String()}},
+		{`UPDATE departments
+		SET head_user_id = COALESCE(head_user_id, (SELECT head_user_id FROM departments WHERE id = $1)), updated_at = NOW()
+		WHERE id = $2`, []interface{}{sourceID, targetID}},
+		{`UPDATE departments SET parent_id = $2, updated_at = NOW() WHERE parent_id = $1`, []interface{}{sourceID, targetID}},
+		{`DELETE FROM departments WHERE id = $1`, []interface{}{sourceID}},
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement.query, statement.args...); err != nil {
+			return mapDepartmentHierarchyError(err)
+		}
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// mapDepartmentHierarchyError переводит нарушения ограничений дерева подразделений в ошибки приложения.
+func mapDepartmentHierarchyError(err error) error {
+	switch {
+	case isCheckViolation(err, "departments_no_cycle"), isCheckViolation(err, "departments_parent_not_self"):
+		return models.NewConflict("подразделение нельзя подчинить самому себе или своему подчиненному подразделению")
+	case isForeignKeyViolation(err):
+		return models.NewConflict("у подразделения есть подчиненные подразделения: перенесите или объедините их")
+	default:
+		return err
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,8 +26,9 @@ func TestDepartmentRepository_GetAll(t *testing.T) {
 	now := time.Now()
 	depID := uuid.New()
 
-	mock.ExpectQuery(`SELECT id, name, created_at, updated_at FROM departments ORDER BY name ASC`).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).AddRow(depID, "IT Отдел", now, now))
+	parentID := uuid.New()
+	mock.ExpectQuery(`SELECT id, name, parent_id, head_user_id, created_at, updated_at FROM departments ORDER BY name ASC`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id", "head_user_id", "created_at", "updated_at"}).AddRow(depID, "IT Отдел", parentID, nil, now, now))
 
 	nomQuery := `SELECT n.id, n.name, n.index, n.year, n.kind_code, n.separator, n.numbering_mode, n.next_number, n.is_active, n.created_at, n.updated_at
 		FROM nomenclature n
@@ -42,13 +44,16 @@ func TestDepartmentRepository_GetAll(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, deps, 1)
 	assert.Equal(t, "IT Отдел", deps[0].Name)
+	require.NotNil(t, deps[0].ParentID)
+	assert.Equal(t, parentID, *deps[0].ParentID)
+	assert.Nil(t, deps[0].HeadUserID)
 	assert.Len(t, deps[0].Nomenclature, 1)
 	assert.Len(t, deps[0].NomenclatureIDs, 1)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestDepartmentRepository_GetNomenclatureIDs(t *testing.T) {
-	// Получение массива идентификаторов номенклатур для подразделения
+	// Номенклатуры подразделения вместе с унаследованными от вышестоящих
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
@@ -57,7 +62,7 @@ func TestDepartmentRepository_GetNomenclatureIDs(t *testing.T) {
 	depID := uuid.New()
 	nomID := uuid.New()
 
-	mock.ExpectQuery(`SELECT nomenclature_id FROM department_nomenclature WHERE department_id = \$1`).
+	mock.ExpectQuery(`WITH RECURSIVE\s+ancestors AS \(\s+SELECT id, parent_id FROM departments WHERE id = \$1\s+UNION\s+SELECT d.id, d.parent_id\s+FROM departments d\s+JOIN ancestors a ON d.id = a.parent_id\s+\)\s+SELECT DISTINCT dn.nomenclature_id\s+FROM department_nomenclature dn\s+JOIN ancestors a ON a.id = dn.department_id`).
 		WithArgs(depID).WillReturnRows(sqlmock.NewRows([]string{"nomenclature_id"}).AddRow(nomID))
 
 	ids, err := repo.GetNomenclatureIDs(depID)
@@ -67,6 +72,32 @@ func TestDepartmentRepository_GetNomenclatureIDs(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestDepartmentRepository_GetVisibleNomenclatureIDs(t *testing.T) {
+	// Руководитель видит номенклатуры своего подразделения и всех подчиненных
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDepartmentRepository(&database.DB{DB: db})
+	depID := uuid.New()
+	userID := uuid.New()
+	nomID := uuid.New()
+
+	mock.ExpectQuery(`headed AS \(\s+SELECT id, parent_id FROM departments WHERE head_user_id = \$2\s+UNION\s+SELECT d.id, d.parent_id\s+FROM departments d\s+JOIN headed h ON d.parent_id = h.id`).
+		WithArgs(depID, userID).WillReturnRows(sqlmock.NewRows([]string{"nomenclature_id"}).AddRow(nomID))
+	mock.ExpectQuery(`FROM department_nomenclature dn`).
+		WithArgs(nil, userID).WillReturnRows(sqlmock.NewRows([]string{"nomenclature_id"}))
+
+	ids, err := repo.GetVisibleNomenclatureIDs(&depID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{nomID.String()}, ids)
+
+	ids, err = repo.GetVisibleNomenclatureIDs(nil, userID)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestDepartmentRepository_Create(t *testing.T) {
 	// Создание нового подразделения и привязка номенклатуры
 	db, mock, err := sqlmock.New()
@@ -79,9 +110,9 @@ func TestDepartmentRepository_Create(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	mock.ExpectQuery(`INSERT INTO departments \(id, name, created_at, updated_at\) VALUES \(\$1, \$2, NOW\(\), NOW\(\)\) RETURNING id, name, created_at, updated_at`).
+	mock.ExpectQuery(`INSERT INTO departments \(id, name, created_at, updated_at\) VALUES \(\$1, \$2, NOW\(\), NOW\(\)\) RETURNING id, name, parent_id, head_user_id, created_at, updated_at`).
 		WithArgs(sqlmock.AnyArg(), "Новый Отдел").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).AddRow(uuid.New(), "Новый Отдел", now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id", "head_user_id", "created_at", "updated_at"}).AddRow(uuid.New(), "Новый Отдел", nil, nil, now, now))
 
 	mock.ExpectPrepare(`INSERT INTO department_nomenclature \(department_id, nomenclature_id\) VALUES \(\$1, \$2\)`).
 		ExpectExec().WithArgs(sqlmock.AnyArg(), nomID1).WillReturnResult(sqlmock.NewResult(1, 1))
@@ -108,9 +139,9 @@ func TestDepartmentRepository_Update(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	mock.ExpectQuery(`UPDATE departments SET name = \$2, updated_at = NOW\(\) WHERE id = \$1 RETURNING id, name, created_at, updated_at`).
+	mock.ExpectQuery(`UPDATE departments SET name = \$2, updated_at = NOW\(\) WHERE id = \$1 RETURNING id, name, parent_id, head_user_id, created_at, updated_at`).
 		WithArgs(depID, "Обновленный Отдел").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).AddRow(depID, "Обновленный Отдел", now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id", "head_user_id", "created_at", "updated_at"}).AddRow(depID, "Обновленный Отдел", nil, nil, now, now))
 
 	mock.ExpectExec(`DELETE FROM department_nomenclature WHERE department_id = \$1`).WithArgs(depID).WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -161,7 +192,7 @@ func TestDepartmentRepository_Errors(t *testing.T) {
 	t.Run("Create invalid nomenclature id", func(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectQuery(`INSERT INTO departments`).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).AddRow(uuid.New(), "IT", time.Now(), time.Now()))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id", "head_user_id", "created_at", "updated_at"}).AddRow(uuid.New(), "IT", nil, nil, time.Now(), time.Now()))
 
 		mock.ExpectPrepare(`INSERT INTO department_nomenclature`)
 
@@ -195,3 +226,166 @@ func TestDepartmentRepository_Errors(t *testing.T) {
 		assert.Equal(t, 404, appErr.Code)
 	})
 }
+
+func TestDepartmentRepository_MoveWithOutbox(t *testing.T) {
+	// Перенос подразделения в дереве и защита от циклов
+	depID := uuid.New()
+	parentID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "department:move", Payload: `{}`}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewDepartmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE departments SET parent_id = \$2, updated_at = NOW\(\) WHERE id = \$1`).
+			WithArgs(depID, parentID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.MoveWithOutbox(depID, &parentID, []models.OutboxEvent{event}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewDepartmentRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE departments SET parent_id`).
+			WithArgs(depID, parentID).WillReturnError(&pq.Error{Code: "23514", Constraint: "departments_no_cycle"})
+		mock.ExpectRollback()
+
+		err = repo.MoveWithOutbox(depID, &parentID, nil)
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("parent not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewDepartmentRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE departments SET parent_id`).
+			WithArgs(depID, parentID).WillReturnError(&pq.Error{Code: "23503"})
+		mock.ExpectRollback()
+
+		err = repo.MoveWithOutbox(depID, &parentID, nil)
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 404, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDepartmentRepository_SetHeadWithOutbox(t *testing.T) {
+	// Назначение и снятие руководителя подразделения
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewDepartmentRepository(&database.DB{DB: db})
+
+	depID := uuid.New()
+	headID := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE departments SET head_user_id = \$2, updated_at = NOW\(\) WHERE id = \$1`).
+		WithArgs(depID, headID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE departments SET head_user_id`).
+		WithArgs(depID, nil).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	require.NoError(t, repo.SetHeadWithOutbox(depID, &headID, nil))
+	err = repo.SetHeadWithOutbox(depID, nil, nil)
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, 404, appErr.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDepartmentRepository_MergeWithOutbox(t *testing.T) {
+	// Слияние подразделений переносит все связи и удаляет исходное подразделение
+	sourceID := uuid.New()
+	targetID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "department:merge", Payload: `{}`}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewDepartmentRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM departments WHERE id IN \(\$1, \$2\) FOR UPDATE`).
+			WithArgs(sourceID, targetID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec(`UPDATE users SET department_id = \$2, updated_at = CURRENT_TIMESTAMP WHERE department_id = \$1`).
+			WithArgs(sourceID, targetID).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`INSERT INTO department_nomenclature \(department_id, nomenclature_id\)\s+SELECT \$2, nomenclature_id FROM department_nomenclature WHERE department_id = \$1\s+ON CONFLICT DO NOTHING`).
+			WithArgs(sourceID, targetID).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO department_access_roles`).
+			WithArgs(sourceID, targetID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO document_permissions .*WHERE subject_type = 'department' AND subject_key = \$1\s+ON CONFLICT \(kind_code, subject_type, subject_key, action\) DO NOTHING`).
+			WithArgs(sourceID.String(), targetID.String()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM document_permissions WHERE subject_type = 'department' AND subject_key = \$1`).
+			WithArgs(sourceID.String()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`SET head_user_id = COALESCE\(head_user_id, \(SELECT head_user_id FROM departments WHERE id = \$1\)\)`).
+			WithArgs(sourceID, targetID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE departments SET parent_id = \$2, updated_at = NOW\(\) WHERE parent_id = \$1`).
+			WithArgs(sourceID, targetID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM departments WHERE id = \$1`).
+			WithArgs(sourceID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.MergeWithOutbox(sourceID, targetID, []models.OutboxEvent{event}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("department not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewDepartmentRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FOR UPDATE`).WithArgs(sourceID, targetID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err = repo.MergeWithOutbox(sourceID, targetID, nil)
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 404, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDepartmentRepository_DeleteWithChildren(t *testing.T) {
+	// Подразделение с подчиненными подразделениями не удаляется
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewDepartmentRepository(&database.DB{DB: db})
+
+	depID := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM departments WHERE id = \$1`).WithArgs(depID).
+		WillReturnError(&pq.Error{Code: "23503", Constraint: "departments_parent_id_fkey"})
+	mock.ExpectRollback()
+
+	err = repo.DeleteWithOutbox(depID, nil)
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, 409, appErr.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -17,21 +17,45 @@ func NewDocumentAccessRepository(db *database.DB) *DocumentAccessRepository {
 	return &DocumentAccessRepository{db: db}
 }
 
-// HasPermission проверяет, разрешено ли действие для вида документа по одной из subject-привязок пользователя.
+// documentPermissionRulesCTE отбирает правила матрицы для вида $1 и действия $2,
+// применимые к подразделению $3 и пользователю $4: прямые правила, а также
+// разрешения ролей пользователя и его подразделения.
+const documentPermissionRulesCTE = `
+	WITH subject_roles AS (
+		SELECT uar.role_id, 'user_role' AS source
+		FROM user_access_roles uar
+		WHERE $4 <> '' AND uar.user_id::text = $4
+		UNION ALL
+		SELECT dar.role_id, 'department_role' AS source
+		FROM department_access_roles dar
+		WHERE $3 <> '' AND dar.department_id::text = $3
+	),
+	rules AS (
+		SELECT p.subject_type AS source, NULL::uuid AS role_id, p.is_allowed
+		FROM document_permissions p
+		WHERE p.kind_code = $1
+		  AND p.action = $2
+		  AND (
+			($3 <> '' AND p.subject_type = 'department' AND p.subject_key = $3)
+			OR ($4 <> '' AND p.subject_type = 'user' AND p.subject_key = $4)
+		  )
+		UNION ALL
+		SELECT sr.source, sr.role_id, p.is_allowed
+		FROM subject_roles sr
+		JOIN document_permissions p
+		  ON p.subject_type = 'role' AND p.subject_key = sr.role_id::text
+		WHERE p.kind_code = $1
+		  AND p.action = $2
+	)
+`
+
+// HasPermission проверяет, разрешено ли действие для вида документа по одной из subject-привязок
+// пользователя: прямым правилам, правилам подразделения и ролям. Явный запрет сильнее разрешения.
 func (r *DocumentAccessRepository) HasPermission(kindCode, action string, departmentID, userID string) (bool, error) {
 	var allowed bool
-	err := r.db.QueryRow(`
-		SELECT EXISTS (
-			SELECT 1
-			FROM document_permissions p
-			WHERE p.kind_code = $1
-			  AND p.action = $2
-			  AND p.is_allowed = true
-			  AND (
-				($3 <> '' AND p.subject_type = 'department' AND p.subject_key = $3)
-				OR ($4 <> '' AND p.subject_type = 'user' AND p.subject_key = $4)
-			  )
-		)
+	err := r.db.QueryRow(documentPermissionRulesCTE+`
+		SELECT EXISTS (SELECT 1 FROM rules WHERE is_allowed)
+			AND NOT EXISTS (SELECT 1 FROM rules WHERE NOT is_allowed)
 	`, kindCode, action, departmentID, userID).Scan(&allowed)
 	if err != nil {
 		return false, fmt.Errorf("failed to check document permission: %w", err)
@@ -40,16 +64,44 @@ func (r *DocumentAccessRepository) HasPermission(kindCode, action string, depart
 	return allowed, nil
 }
 
-// HasSystemPermission проверяет наличие прямого системного права у пользователя.
+// ExplainPermission возвращает все правила матрицы, которые HasPermission учитывает
+// для пользователя, с указанием источника каждого правила.
+func (r *DocumentAccessRepository) ExplainPermission(kindCode, action string, departmentID, userID string) ([]models.AccessRuleMatch, error) {
+	rows, err := r.db.Query(documentPermissionRulesCTE+`
+		SELECT rules.source, COALESCE(rules.role_id::text, ''), COALESCE(ar.name, ''), rules.is_allowed
+		FROM rules
+		LEFT JOIN access_roles ar ON ar.id = rules.role_id
+		ORDER BY rules.is_allowed, rules.source, ar.name
+	`, kindCode, action, departmentID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain document permission: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.AccessRuleMatch, 0)
+	for rows.Next() {
+		var item models.AccessRuleMatch
+		if err := rows.Scan(&item.Source, &item.RoleID, &item.RoleName, &item.IsAllowed); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// HasSystemPermission проверяет действующее системное право пользователя: прямое или
+// полученное через роль и не запрещенное напрямую.
 func (r *DocumentAccessRepository) HasSystemPermission(permission, userID string) (bool, error) {
 	var allowed bool
 	err := r.db.QueryRow(`
 		SELECT EXISTS (
 			SELECT 1
-			FROM user_system_permissions
+			FROM user_effective_system_permissions
 			WHERE user_id = $1
 			  AND permission = $2
-			  AND is_allowed = true
 		)
 	`, userID, permission).Scan(&allowed)
 	if err != nil {
@@ -27,7 +27,7 @@ func TestDocumentAccessRepository_HasPermission(t *testing.T) {
 	defer cleanup()
 
 	t.Run("returns allowed flag", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT EXISTS`).
+		mock.ExpectQuery(`p.subject_type = 'role' AND p.subject_key = sr.role_id::text.*SELECT EXISTS \(SELECT 1 FROM rules WHERE is_allowed\)\s+AND NOT EXISTS \(SELECT 1 FROM rules WHERE NOT is_allowed\)`).
 			WithArgs(
 				string(models.DocumentKindIncomingLetter),
 				string(models.DocumentActionRead),
@@ -72,6 +72,28 @@ func TestDocumentAccessRepository_HasPermission(t *testing.T) {
 	})
 }
 
+func TestDocumentAccessRepository_ExplainPermission(t *testing.T) {
+	repo, mock, cleanup := setupDocumentAccessRepository(t)
+	defer cleanup()
+
+	roleID := "6b1f2f4e-9a6e-4f4c-8d59-1f3a2b7c9d10"
+	mock.ExpectQuery(`WITH subject_roles AS .*FROM rules\s+LEFT JOIN access_roles ar ON ar.id = rules.role_id`).
+		WithArgs(string(models.DocumentKindIncomingLetter), string(models.DocumentActionUpdate), "department-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "role_id", "role_name", "is_allowed"}).
+			AddRow(models.AccessRuleSourceUser, "", "", false).
+			AddRow(models.AccessRuleSourceDepartmentRole, roleID, "Регистратор", true))
+
+	rules, err := repo.ExplainPermission(string(models.DocumentKindIncomingLetter), string(models.DocumentActionUpdate), "department-1", "user-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.AccessRuleMatch{
+		{Source: models.AccessRuleSourceUser, IsAllowed: false},
+		{Source: models.AccessRuleSourceDepartmentRole, RoleID: roleID, RoleName: "Регистратор", IsAllowed: true},
+	}, rules)
+	assert.False(t, models.ResolveAccessRules(rules))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestDocumentAccessRepository_HasSystemPermission(t *testing.T) {
 	repo, mock, cleanup := setupDocumentAccessRepository(t)
 	defer cleanup()
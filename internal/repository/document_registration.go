@@ -297,6 +297,72 @@ func isUniqueViolation(err error, constraint string) bool {
 	return constraint == "" || pqErr.Constraint == constraint
 }
 
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return err != nil && errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+func isCheckViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if err == nil || !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "23514" && (constraint == "" || pqErr.Constraint == constraint)
+}
+
+// isInvalidDocumentType распознает вставку документа, для которой подзапрос documentTypeIDExpr
+// не нашел подходящий тип: NOT NULL по document_type_id или внешний ключ на набор видов.
+func isInvalidDocumentType(err error) bool {
+	var pqErr *pq.Error
+	if err == nil || !errors.As(err, &pqErr) {
+		return false
+	}
+	return (pqErr.Code == "23502" && pqErr.Column == "document_type_id") ||
+		(pqErr.Code == "23503" && pqErr.Constraint == "documents_document_type_kind_fkey")
+}
+
+// documentTypeIDExpr возвращает подзапрос, который находит действующий тип документа вида
+// из параметра kindParam по ID или по названию из параметра param. Название принимается для
+// клиентов, которые передают тип строкой, как до появления справочника. В текст запроса
+// подставляются только номера параметров, значения передаются через bind.
+func documentTypeIDExpr(kindParam, param string) string {
+	return fmt.Sprintf(`(
+		SELECT t.id FROM document_types t
+		JOIN document_type_kinds k ON k.document_type_id = t.id AND k.kind = %s
+		WHERE (t.id::text = %s OR t.name = %s) AND t.is_active
+	)`, kindParam, param, param)
+}
+
+// documentTypeIDUpdateExpr работает как documentTypeIDExpr, но сохраняет строке таблицы table
+// ее текущий тип, даже если тот деактивирован. Используется только в UPDATE.
+func documentTypeIDUpdateExpr(table, kindParam, param string) string {
+	return fmt.Sprintf(`(
+		SELECT t.id FROM document_types t
+		JOIN document_type_kinds k ON k.document_type_id = t.id AND k.kind = %s
+		WHERE (t.id::text = %s OR t.name = %s) AND (t.is_active OR t.id = %s.document_type_id)
+	)`, kindParam, param, param, table)
+}
+
+// defaultDocumentTypeIDExpr возвращает подзапрос для видов без выбора типа в карточке:
+// берется действующий тип с названием из param, а если его переименовали — первый
+// действующий тип, разрешенный для вида из параметра kindParam.
+func defaultDocumentTypeIDExpr(kindParam, param string) string {
+	return fmt.Sprintf(`(
+		SELECT t.id FROM document_types t
+		JOIN document_type_kinds k ON k.document_type_id = t.id AND k.kind = %s
+		WHERE t.is_active
+		ORDER BY (t.name = %s) DESC, t.name
+		LIMIT 1
+	)`, kindParam, param)
+}
+
+// documentTypeFilterExpr возвращает условие фильтра списка по ID или названию типа.
+func documentTypeFilterExpr(param string) string {
+	return fmt.Sprintf(`d.document_type_id IN (SELECT id FROM document_types WHERE id::text = %s OR name = %s)`, param, param)
+}
+
+var errInvalidDocumentType = models.NewBadRequest("неверный тип документа")
+
 func findExistingDocumentIDByIdempotency(db documentIDLookup, createdBy uuid.UUID, kind models.DocumentKind, idempotencyKey uuid.UUID) (uuid.UUID, error) {
 	var id uuid.UUID
 	err := db.QueryRow(`
@@ -294,8 +294,8 @@ func TestJournalRetentionFKIntegration(t *testing.T) {
 	execSQL(t, sqlDB, `
 		INSERT INTO documents (
 			id, kind, nomenclature_id, idempotency_key, registration_number, registration_date,
-			document_type, content, pages_count, created_by
-		) VALUES ($1, 'outgoing_letter', $2, $3, '03-03/1', DATE '2026-05-28', $4, 'retention', 1, $5)
+			document_type_id, content, pages_count, created_by
+		) VALUES ($1, 'outgoing_letter', $2, $3, '03-03/1', DATE '2026-05-28', (SELECT id FROM document_types WHERE name = $4), 'retention', 1, $5)
 	`, docID, nomID, uuid.New(), models.DocumentTypeLetter, userID)
 	execSQL(t, sqlDB, `
 		INSERT INTO outgoing_document_details (
@@ -347,23 +347,23 @@ func TestDatabaseConstraintsIntegration(t *testing.T) {
 	execSQL(t, sqlDB, `
 		INSERT INTO documents (
 			id, kind, nomenclature_id, idempotency_key, registration_number, registration_date,
-			document_type, content, pages_count, created_by
-		) VALUES ($1, 'outgoing_letter', $2, $3, '04-04/1', DATE '2026-05-28', $4, 'constraint', 1, $5)
+			document_type_id, content, pages_count, created_by
+		) VALUES ($1, 'outgoing_letter', $2, $3, '04-04/1', DATE '2026-05-28', (SELECT id FROM document_types WHERE name = $4), 'constraint', 1, $5)
 	`, docID, nomID, uuid.New(), models.DocumentTypeLetter, userID)
 
 	expectExecError(t, sqlDB, `
 		INSERT INTO documents (
 			kind, nomenclature_id, idempotency_key, registration_number, registration_date,
-			document_type, content, pages_count, created_by
-		) VALUES ('outgoing_letter', $1, $2, '04-04/1', DATE '2026-12-31', $3, 'duplicate number', 1, $4)
+			document_type_id, content, pages_count, created_by
+		) VALUES ('outgoing_letter', $1, $2, '04-04/1', DATE '2026-12-31', (SELECT id FROM document_types WHERE name = $3), 'duplicate number', 1, $4)
 	`, nomID, uuid.New(), models.DocumentTypeLetter, userID)
 	// idempotency_key has a database default, so direct writes remain safe even
 	// if an older client omits the column.
 	execSQL(t, sqlDB, `
 		INSERT INTO documents (
 			kind, nomenclature_id, registration_number, registration_date,
-			document_type, content, pages_count, created_by
-		) VALUES ('outgoing_letter', $1, '04-04/2', DATE '2026-05-28', $2, 'missing idempotency', 1, $3)
+			document_type_id, content, pages_count, created_by
+		) VALUES ('outgoing_letter', $1, '04-04/2', DATE '2026-05-28', (SELECT id FROM document_types WHERE name = $2), 'missing idempotency', 1, $3)
 	`, nomID, models.DocumentTypeLetter, userID)
 	assertScalar(t, sqlDB, `SELECT COUNT(*) FROM documents WHERE created_by = $1 AND idempotency_key IS NOT NULL`, []any{userID}, 2)
 	expectExecError(t, sqlDB, `
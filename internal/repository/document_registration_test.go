@@ -33,6 +33,17 @@ func TestIsUniqueViolation(t *testing.T) {
 	assert.False(t, isUniqueViolation(nil, "documents_number_key"))
 }
 
+func TestDocumentTypeIDExprBindsKind(t *testing.T) {
+	for _, expr := range []string{
+		documentTypeIDExpr("$1", "$6"),
+		documentTypeIDUpdateExpr("documents", "$1", "$6"),
+		defaultDocumentTypeIDExpr("$1", "$6"),
+	} {
+		assert.Contains(t, expr, "k.kind = $1")
+		assert.NotContains(t, expr, "'")
+	}
+}
+
 func TestFindExistingDocumentIDByIdempotency(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
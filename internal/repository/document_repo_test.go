@@ -26,7 +26,7 @@ func setupDocumentRepository(t *testing.T) (*DocumentRepository, sqlmock.Sqlmock
 func documentRepositoryRows(now time.Time, rows ...models.Document) *sqlmock.Rows {
 	result := sqlmock.NewRows([]string{
 		"id", "kind", "nomenclature_id", "registration_number", "registration_date",
-		"document_type", "content", "pages_count", "created_by", "created_at", "updated_at",
+		"document_type_id", "content", "pages_count", "created_by", "created_at", "updated_at",
 	})
 	for _, doc := range rows {
 		createdAt := doc.CreatedAt
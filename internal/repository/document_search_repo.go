@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/lib/pq"
+)
+
+// maxSearchTerms ограничивает размер tsquery для длинных вставленных фрагментов текста.
+const maxSearchTerms = 16
+
+// searchHeadlineOptions задаёт фрагменты ts_headline; разделители разбираются models.ParseSearchHeadline.
+var searchHeadlineOptions = fmt.Sprintf(
+	"StartSel=%s, StopSel=%s, FragmentDelimiter=%s, MaxFragments=3, MaxWords=24, MinWords=8",
+	models.SearchHighlightStart, models.SearchHighlightStop, models.SearchFragmentDelimiter,
+)
+
+// DocumentSearchRepository выполняет полнотекстовый поиск по индексу document_search_index.
+// Индекс поддерживается триггерами БД для документов всех видов.
+type DocumentSearchRepository struct {
+	db *database.DB
+}
+
+// NewDocumentSearchRepository создает новый экземпляр DocumentSearchRepository.
+func NewDocumentSearchRepository(db *database.DB) *DocumentSearchRepository {
+	return &DocumentSearchRepository{db: db}
+}
+
+// Search возвращает документы, упорядоченные по релевантности. Полнотекстовое совпадение
+// дополняется триграммным поиском по номерам, чтобы находить номера с опечатками и фрагменты номеров.
+func (r *DocumentSearchRepository) Search(query models.DocumentSearchQuery) ([]models.DocumentSearchHit, error) {
+	text := strings.TrimSpace(query.Text)
+	tsQuery := buildPrefixTSQuery(text)
+	if tsQuery == "" {
+		return []models.DocumentSearchHit{}, nil
+	}
+
+	args := []interface{}{tsQuery, "%" + escapeLikePattern(text) + "%", text}
+	where := []string{"(si.search_vector @@ q.tsq OR si.numbers_text ILIKE $2 OR $3 <% si.numbers_text)"}
+	argIdx := 4
+	if len(query.Kinds) > 0 {
+		kinds := make([]string, len(query.Kinds))
+		for i, kind := range query.Kinds {
+			kinds[i] = string(kind)
+		}
+		where = append(where, fmt.Sprintf("si.kind = ANY($%d)", argIdx))
+		args = append(args, pq.Array(kinds))
+		argIdx++
+	}
+	if query.DateFrom != nil {
+		where = append(where, fmt.Sprintf("si.registration_date >= $%d", argIdx))
+		args = append(args, *query.DateFrom)
+		argIdx++
+	}
+	if query.DateTo != nil {
+		where = append(where, fmt.Sprintf("si.registration_date <= $%d", argIdx))
+		args = append(args, *query.DateTo)
+		argIdx++
+	}
+
+	_, limit := normalizePagination(1, query.Limit)
+	offset := query.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		WITH q AS (
+			SELECT to_tsquery('russian', $1) || to_tsquery('simple', $1) AS tsq
+		)
+		SELECT
+			ranked.document_id, ranked.kind, d.registration_number, ranked.registration_date,
+			d.content, ranked.rank,
+			ts_headline('russian', si.body_text, q.tsq, $%d),
+			CASE WHEN si.attachment_text = '' THEN ''
+				ELSE ts_headline('russian', si.attachment_text, q.tsq, $%d)
+			END
+		FROM (
+			SELECT
+				si.document_id, si.kind, si.registration_date,
+				ts_rank_cd(si.search_vector, q.tsq)
+					+ CASE WHEN si.numbers_text ILIKE $2 THEN 1 ELSE word_similarity($3, si.numbers_text) END AS rank
+			FROM document_search_index si
+			CROSS JOIN q
+			WHERE %s
+			ORDER BY rank DESC, si.registration_date DESC, si.document_id DESC
+			LIMIT $%d OFFSET $%d
+		) ranked
+		JOIN document_search_index si ON si.document_id = ranked.document_id
+		JOIN documents d ON d.id = ranked.document_id
+		CROSS JOIN q
+		ORDER BY ranked.rank DESC, ranked.registration_date DESC, ranked.document_id DESC
+	`, argIdx, argIdx, strings.Join(where, " AND "), argIdx+1, argIdx+2)
+	args = append(args, searchHeadlineOptions, limit, offset)
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	defer rows.Close()
+
+	hits := make([]models.DocumentSearchHit, 0)
+	for rows.Next() {
+		var hit models.DocumentSearchHit
+		if err := rows.Scan(
+			&hit.DocumentID, &hit.Kind, &hit.RegistrationNumber, &hit.RegistrationDate,
+			&hit.Content, &hit.Rank, &hit.Headline, &hit.AttachmentHeadline,
+		); err != nil {
+			return nil, fmt.Errorf("scan search hit error: %w", err)
+		}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("search hits rows error: %w", err)
+	}
+	return hits, nil
+}
+
+// buildPrefixTSQuery превращает пользовательский ввод в tsquery с префиксным поиском по каждому слову.
+// Операторы tsquery из ввода не пропускаются: запрос собирается только из букв и цифр.
+func buildPrefixTSQuery(text string) string {
+	terms := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
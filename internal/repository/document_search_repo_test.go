@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestDocumentSearchRepository_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDocumentSearchRepository(&database.DB{DB: db})
+	docID := uuid.New()
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	regDate := time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`(?s)to_tsquery\('russian', \$1\) \|\| to_tsquery\('simple', \$1\).*FROM document_search_index si.*si.search_vector @@ q.tsq OR si.numbers_text ILIKE \$2 OR \$3 <% si.numbers_text.*si.kind = ANY\(\$4\) AND si.registration_date >= \$5.*LIMIT \$7 OFFSET \$8`).
+		WithArgs("ремонт:* & 15:*", `%ремонт 15\%%`, "ремонт 15%", pq.Array([]string{"incoming_letter"}), from, searchHeadlineOptions, 20, 40).
+		WillReturnRows(sqlmock.NewRows([]string{"document_id", "kind", "registration_number", "registration_date", "content", "rank", "headline", "attachment_headline"}).
+			AddRow(docID, "incoming_letter", "01-15/7", regDate, "О ремонте кровли", 0.8, "О \x02ремонте\x03 кровли", ""))
+
+	hits, err := repo.Search(models.DocumentSearchQuery{
+		Text:     " ремонт 15% ",
+		Kinds:    []models.DocumentKind{models.DocumentKindIncomingLetter},
+		DateFrom: &from,
+		Offset:   40,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, docID, hits[0].DocumentID)
+	assert.Equal(t, models.DocumentKindIncomingLetter, hits[0].Kind)
+	assert.Equal(t, "01-15/7", hits[0].RegistrationNumber)
+	assert.InDelta(t, 0.8, hits[0].Rank, 0.0001)
+	assert.Equal(t, "О \x02ремонте\x03 кровли", hits[0].Headline)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDocumentSearchRepository_SearchWithoutTermsSkipsQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDocumentSearchRepository(&database.DB{DB: db})
+
+	hits, err := repo.Search(models.DocumentSearchQuery{Text: " !&| "})
+
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBuildPrefixTSQuery(t *testing.T) {
+	assert.Equal(t, "вх:* & 12:* & 345:*", buildPrefixTSQuery("Вх. 12/345"))
+	assert.Equal(t, "ремонт:* & кровли:*", buildPrefixTSQuery("ремонт & (кровли) | !"))
+	assert.Equal(t, "", buildPrefixTSQuery("  :* "))
+}
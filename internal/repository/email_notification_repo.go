@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// emailChannelEnabledCondition проверяет общий выключатель почтовых уведомлений;
+// значения true совпадают с strconv.ParseBool.
+const emailChannelEnabledCondition = `EXISTS (
+		SELECT 1 FROM system_settings
+		WHERE key = 'email_notifications_enabled' AND lower(trim(value)) IN ('true', 't', '1')
+	)`
+
+// EmailNotificationRepository хранит адреса и режимы почтовых уведомлений
+// пользователей.
+type EmailNotificationRepository struct {
+	db *database.DB
+}
+
+// NewEmailNotificationRepository создает новый экземпляр EmailNotificationRepository.
+func NewEmailNotificationRepository(db *database.DB) *EmailNotificationRepository {
+	return &EmailNotificationRepository{db: db}
+}
+
+// GetSettings возвращает адрес и режим почтовых уведомлений пользователя.
+func (r *EmailNotificationRepository) GetSettings(userID uuid.UUID) (*models.EmailNotificationSettings, error) {
+	var settings models.EmailNotificationSettings
+	err := r.db.QueryRow(
+		`SELECT COALESCE(email, ''), email_notifications FROM users WHERE id = $1`,
+		userID,
+	).Scan(&settings.Email, &settings.Mode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.NewNotFound("пользователь не найден")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email notification settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// UpdateSettings сохраняет адрес и режим почтовых уведомлений пользователя.
+func (r *EmailNotificationRepository) UpdateSettings(userID uuid.UUID, email, mode string) error {
+	result, err := r.db.Exec(
+		`UPDATE users SET email = NULLIF($2, ''), email_notifications = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		userID, email, mode,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update email notification settings: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return models.NewNotFound("пользователь не найден")
+	}
+	return nil
+}
+
+// GetRecipient возвращает активного пользователя с адресом для писем или nil,
+// если писем ему отправлять не нужно или почтовые уведомления выключены.
+func (r *EmailNotificationRepository) GetRecipient(userID uuid.UUID) (*models.EmailRecipient, error) {
+	var recipient models.EmailRecipient
+	err := r.db.QueryRow(`
+		SELECT id, full_name, email, email_notifications
+		FROM users
+		WHERE id = $1
+		  AND is_active = true
+		  AND NULLIF(email, '') IS NOT NULL
+		  AND email_notifications <> 'off'
+		  AND `+emailChannelEnabledCondition, userID).Scan(&recipient.UserID, &recipient.FullName, &recipient.Email, &recipient.Mode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email recipient: %w", err)
+	}
+	return &recipient, nil
+}
+
+// GetDigestRecipients возвращает активных пользователей, выбравших
+// ежедневную сводку, если почтовые уведомления включены.
+func (r *EmailNotificationRepository) GetDigestRecipients() ([]models.EmailRecipient, error) {
+	rows, err := r.db.Query(`
+		SELECT id, full_name, email, email_notifications
+		FROM users
+		WHERE email_notifications = 'digest'
+		  AND is_active = true
+		  AND NULLIF(email, '') IS NOT NULL
+		  AND ` + emailChannelEnabledCondition + `
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest recipients: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.EmailRecipient, 0)
+	for rows.Next() {
+		var recipient models.EmailRecipient
+		if err := rows.Scan(&recipient.UserID, &recipient.FullName, &recipient.Email, &recipient.Mode); err != nil {
+			return nil, err
+		}
+		items = append(items, recipient)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestEmailNotificationRepository_Settings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewEmailNotificationRepository(&database.DB{DB: db})
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT COALESCE\(email, ''\), email_notifications FROM users WHERE id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "mode"}).AddRow("", models.EmailNotificationsOff))
+	settings, err := repo.GetSettings(userID)
+	require.NoError(t, err)
+	assert.Equal(t, &models.EmailNotificationSettings{Mode: models.EmailNotificationsOff}, settings)
+
+	mock.ExpectExec(`UPDATE users SET email = NULLIF\(\$2, ''\), email_notifications = \$3`).
+		WithArgs(userID, "petrov@example.local", models.EmailNotificationsDigest).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.UpdateSettings(userID, "petrov@example.local", models.EmailNotificationsDigest))
+
+	mock.ExpectExec(`UPDATE users SET email`).WithArgs(userID, "", models.EmailNotificationsOff).WillReturnResult(sqlmock.NewResult(0, 0))
+	err = repo.UpdateSettings(userID, "", models.EmailNotificationsOff)
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "NOT_FOUND", appErr.Kind)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailNotificationRepository_Recipients(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewEmailNotificationRepository(&database.DB{DB: db})
+	userID := uuid.New()
+	columns := []string{"id", "full_name", "email", "email_notifications"}
+
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1(.*)AND is_active = true(.*)AND email_notifications <> 'off'(.*)key = 'email_notifications_enabled'`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsImmediate))
+	recipient, err := repo.GetRecipient(userID)
+	require.NoError(t, err)
+	assert.Equal(t, &models.EmailRecipient{UserID: userID, FullName: "Петров", Email: "petrov@example.local", Mode: models.EmailNotificationsImmediate}, recipient)
+
+	mock.ExpectQuery(`FROM users(.*)WHERE id = \$1`).WithArgs(userID).WillReturnRows(sqlmock.NewRows(columns))
+	recipient, err = repo.GetRecipient(userID)
+	require.NoError(t, err)
+	assert.Nil(t, recipient)
+
+	mock.ExpectQuery(`FROM users(.*)WHERE email_notifications = 'digest'(.*)AND is_active = true(.*)key = 'email_notifications_enabled'`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(userID, "Петров", "petrov@example.local", models.EmailNotificationsDigest))
+	recipients, err := repo.GetDigestRecipients()
+	require.NoError(t, err)
+	require.Len(t, recipients, 1)
+	assert.Equal(t, models.EmailNotificationsDigest, recipients[0].Mode)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -29,13 +29,14 @@ func NewIncomingDocumentRepository(db *database.DB) *IncomingDocumentRepository
 const incomingDocSelectBase = `
 	SELECT d.id, d.nomenclature_id, n.index || ' — ' || n.name,
 		inc.incoming_number, inc.incoming_date,
-		d.document_type, d.document_type,
+		d.document_type_id, dt.name,
 		d.content, d.pages_count,
 		inc.sender_signatory,
 		d.created_by, u.full_name,
 		d.created_at, d.updated_at
 	FROM documents d
 	JOIN incoming_document_details inc ON inc.document_id = d.id
+	JOIN document_types dt ON dt.id = d.document_type_id
 	LEFT JOIN nomenclature n ON d.nomenclature_id = n.id
 	LEFT JOIN users u ON d.created_by = u.id`
 
@@ -345,7 +346,7 @@ func (r *IncomingDocumentRepository) GetList(filter models.DocumentFilter) (*mod
 		argIdx++
 	}
 	if filter.DocumentTypeID != "" {
-		where = append(where, fmt.Sprintf("d.document_type = $%d", argIdx))
+		where = append(where, documentTypeFilterExpr(fmt.Sprintf("$%d", argIdx)))
 		args = append(args, filter.DocumentTypeID)
 		argIdx++
 	}
@@ -600,8 +601,8 @@ func (r *IncomingDocumentRepository) CreateWithJournal(req models.CreateIncoming
 }
 func (r *IncomingDocumentRepository) create(req models.CreateIncomingDocRequest, effects []models.OutboxEvent, journalAction, journalDetailsFormat string) (*models.IncomingDocument, error) {
 	req.DocumentTypeID = models.NormalizeDocumentType(req.DocumentTypeID)
-	if !models.IsAllowedDocumentType(req.DocumentTypeID) {
-		return nil, models.NewBadRequest("неверный тип документа")
+	if req.DocumentTypeID == "" {
+		return nil, errInvalidDocumentType
 	}
 
 	tx, err := r.db.Begin()
@@ -630,8 +631,8 @@ func (r *IncomingDocumentRepository) create(req models.CreateIncomingDocRequest,
 	var id uuid.UUID
 	err = tx.QueryRow(`
 		INSERT INTO documents (
-			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by
+		) VALUES ($1, $2, $3, $4, $5, `+documentTypeIDExpr("$1", "$6")+`, $7, $8, $9)
 		RETURNING id
 	`,
 		models.DocumentKindIncomingLetter, req.NomenclatureID, req.IdempotencyKey, req.IncomingNumber, req.IncomingDate, req.DocumentTypeID, req.Content, req.PagesCount, req.CreatedBy,
@@ -648,6 +649,9 @@ func (r *IncomingDocumentRepository) create(req models.CreateIncomingDocRequest,
 		if isUniqueViolation(err, "idx_documents_kind_registration_number_year") {
 			return nil, models.NewConflict("документ с таким регистрационным номером уже существует")
 		}
+		if isInvalidDocumentType(err) {
+			return nil, errInvalidDocumentType
+		}
 		return nil, fmt.Errorf("failed to create document root: %w", err)
 	}
 
@@ -698,8 +702,8 @@ func (r *IncomingDocumentRepository) UpdateWithOutbox(req models.UpdateIncomingD
 }
 func (r *IncomingDocumentRepository) update(req models.UpdateIncomingDocRequest, effects []models.OutboxEvent) (*models.IncomingDocument, error) {
 	req.DocumentTypeID = models.NormalizeDocumentType(req.DocumentTypeID)
-	if !models.IsAllowedDocumentType(req.DocumentTypeID) {
-		return nil, models.NewBadRequest("неверный тип документа")
+	if req.DocumentTypeID == "" {
+		return nil, errInvalidDocumentType
 	}
 
 	tx, err := r.db.Begin()
@@ -710,7 +714,7 @@ func (r *IncomingDocumentRepository) update(req models.UpdateIncomingDocRequest,
 
 	if _, err = tx.Exec(`
 		UPDATE documents SET
-			document_type = $1,
+			document_type_id = `+documentTypeIDUpdateExpr("documents", "$5", "$1")+`,
 			content = $2,
 			pages_count = $3,
 			updated_at = CURRENT_TIMESTAMP
@@ -718,6 +722,9 @@ func (r *IncomingDocumentRepository) update(req models.UpdateIncomingDocRequest,
 	`,
 		req.DocumentTypeID, req.Content, req.PagesCount, req.ID, models.DocumentKindIncomingLetter,
 	); err != nil {
+		if isInvalidDocumentType(err) {
+			return nil, errInvalidDocumentType
+		}
 		return nil, fmt.Errorf("failed to update document root: %w", err)
 	}
 
@@ -247,14 +247,14 @@ func TestIncomingDocumentRepository_Create(t *testing.T) {
 }
 
 func TestIncomingDocumentRepository_CreateValidationErrors(t *testing.T) {
-	t.Run("invalid document type", func(t *testing.T) {
+	t.Run("missing document type", func(t *testing.T) {
 		db, _, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
 		repo := NewIncomingDocumentRepository(&database.DB{DB: db})
 
-		doc, err := repo.Create(models.CreateIncomingDocRequest{DocumentTypeID: "unknown"})
+		doc, err := repo.Create(models.CreateIncomingDocRequest{DocumentTypeID: ""})
 		require.Error(t, err)
 		assert.Nil(t, doc)
 		assert.Contains(t, err.Error(), "неверный тип документа")
@@ -295,6 +295,16 @@ func TestIncomingDocumentRepository_CreateRootInsertErrors(t *testing.T) {
 			wantErr:    "документ с таким регистрационным номером уже существует",
 			wantAppErr: true,
 		},
+		{
+			name:      "unknown or inactive document type",
+			insertErr: &pq.Error{Code: "23502", Column: "document_type_id"},
+			wantErr:   "неверный тип документа",
+		},
+		{
+			name:      "document type not allowed for kind",
+			insertErr: &pq.Error{Code: "23503", Constraint: "documents_document_type_kind_fkey"},
+			wantErr:   "неверный тип документа",
+		},
 		{
 			name:      "generic root insert error",
 			insertErr: sql.ErrConnDone,
@@ -624,14 +634,14 @@ func TestIncomingDocumentRepository_Update(t *testing.T) {
 }
 
 func TestIncomingDocumentRepository_UpdateErrors(t *testing.T) {
-	t.Run("invalid document type", func(t *testing.T) {
+	t.Run("missing document type", func(t *testing.T) {
 		db, _, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
 		repo := NewIncomingDocumentRepository(&database.DB{DB: db})
 
-		doc, err := repo.Update(models.UpdateIncomingDocRequest{DocumentTypeID: "unknown"})
+		doc, err := repo.Update(models.UpdateIncomingDocRequest{DocumentTypeID: ""})
 		require.Error(t, err)
 		assert.Nil(t, doc)
 		assert.Contains(t, err.Error(), "неверный тип документа")
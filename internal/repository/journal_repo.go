@@ -27,12 +27,12 @@ func (r *JournalRepository) CreateFromOutbox(ctx context.Context, req models.Cre
 }
 func (r *JournalRepository) create(ctx context.Context, req models.CreateJournalEntryRequest, key string) (uuid.UUID, error) {
 	query := `
-		INSERT INTO document_journal (document_id, user_id, action, details, outbox_deduplication_key)
-		VALUES ($1, $2, $3, $4, NULLIF($5, '')) ON CONFLICT (outbox_deduplication_key) WHERE outbox_deduplication_key IS NOT NULL DO NOTHING
+		INSERT INTO document_journal (document_id, user_id, on_behalf_of_user_id, action, details, outbox_deduplication_key)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')) ON CONFLICT (outbox_deduplication_key) WHERE outbox_deduplication_key IS NOT NULL DO NOTHING
 		RETURNING id
 	`
 	var id uuid.UUID
-	err := r.db.QueryRowContext(ctx, query, req.DocumentID, req.UserID, req.Action, req.Details, key).Scan(&id)
+	err := r.db.QueryRowContext(ctx, query, req.DocumentID, req.UserID, req.OnBehalfOfUserID, req.Action, req.Details, key).Scan(&id)
 	if err == sql.ErrNoRows && key != "" {
 		return uuid.Nil, nil
 	}
@@ -42,10 +42,11 @@ func (r *JournalRepository) create(ctx context.Context, req models.CreateJournal
 func (r *JournalRepository) GetByDocumentID(ctx context.Context, documentID uuid.UUID) ([]models.JournalEntry, error) {
 	query := `
 		SELECT j.id, j.document_id, j.user_id, 
-		       u.full_name, 
+		       u.full_name, COALESCE(obo.full_name, ''),
 		       j.action, j.details, j.created_at
 		FROM document_journal j
 		JOIN users u ON j.user_id = u.id
+		LEFT JOIN users obo ON obo.id = j.on_behalf_of_user_id
 		WHERE j.document_id = $1
 		ORDER BY j.created_at DESC
 	`
@@ -63,6 +64,7 @@ func (r *JournalRepository) GetByDocumentID(ctx context.Context, documentID uuid
 			&entry.DocumentID,
 			&entry.UserID,
 			&entry.UserName,
+			&entry.OnBehalfOfUserName,
 			&entry.Action,
 			&entry.Details,
 			&entry.CreatedAt,
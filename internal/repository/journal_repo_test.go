@@ -35,7 +35,7 @@ func TestJournalRepository_Create(t *testing.T) {
 
 	newID := uuid.New()
 	mock.ExpectQuery(query).
-		WithArgs(req.DocumentID, req.UserID, req.Action, req.Details, "").
+		WithArgs(req.DocumentID, req.UserID, req.OnBehalfOfUserID, req.Action, req.Details, "").
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newID))
 
 	id, err := repo.Create(ctx, req)
@@ -52,7 +52,7 @@ func TestJournalRepository_CreateFromOutboxTreatsDuplicateAsDelivered(t *testing
 	repo := NewJournalRepository(&database.DB{DB: db})
 	req := models.CreateJournalEntryRequest{DocumentID: uuid.New(), UserID: uuid.New(), Action: "TEST", Details: "retry"}
 	mock.ExpectQuery(`INSERT INTO document_journal`).
-		WithArgs(req.DocumentID, req.UserID, req.Action, req.Details, "journal:retry:1").
+		WithArgs(req.DocumentID, req.UserID, req.OnBehalfOfUserID, req.Action, req.Details, "journal:retry:1").
 		WillReturnError(sql.ErrNoRows)
 
 	id, err := repo.CreateFromOutbox(context.Background(), req, "journal:retry:1")
@@ -73,18 +73,19 @@ func TestJournalRepository_GetByDocumentID(t *testing.T) {
 	now := time.Now()
 
 	query := `SELECT j.id, j.document_id, j.user_id, 
-		       u.full_name, 
+		       u.full_name, COALESCE\(obo.full_name, ''\),
 		       j.action, j.details, j.created_at
 		FROM document_journal j
 		JOIN users u ON j.user_id = u.id
+		LEFT JOIN users obo ON obo.id = j.on_behalf_of_user_id
 		WHERE j.document_id = \$1
 		ORDER BY j.created_at DESC`
 
 	rows := sqlmock.NewRows([]string{
-		"id", "document_id", "user_id", "user_name",
+		"id", "document_id", "user_id", "user_name", "on_behalf_of_user_name",
 		"action", "details", "created_at",
 	}).AddRow(
-		uuid.New(), docID, uuid.New(), "Иванов Иван Иванович",
+		uuid.New(), docID, uuid.New(), "Иванов Иван Иванович", "Петров Петр Петрович",
 		"TEST_ACTION", "Тестовое действие", now,
 	)
 
@@ -94,6 +95,7 @@ func TestJournalRepository_GetByDocumentID(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, entries, 1)
 	assert.Equal(t, "Иванов Иван Иванович", entries[0].UserName)
+	assert.Equal(t, "Петров Петр Петрович", entries[0].OnBehalfOfUserName)
 	assert.Equal(t, "TEST_ACTION", entries[0].Action)
 	assert.Equal(t, "Тестовое действие", entries[0].Details)
 	require.NoError(t, mock.ExpectationsWereMet())
@@ -109,16 +111,17 @@ func TestJournalRepository_GetByDocumentID_Empty(t *testing.T) {
 	ctx := context.Background()
 	docID := uuid.New()
 	query := `SELECT j.id, j.document_id, j.user_id, 
-		       u.full_name, 
+		       u.full_name, COALESCE\(obo.full_name, ''\),
 		       j.action, j.details, j.created_at
 		FROM document_journal j
 		JOIN users u ON j.user_id = u.id
+		LEFT JOIN users obo ON obo.id = j.on_behalf_of_user_id
 		WHERE j.document_id = \$1
 		ORDER BY j.created_at DESC`
 
 	// Возвращаем пустой результат
 	rows := sqlmock.NewRows([]string{
-		"id", "document_id", "user_id", "user_name",
+		"id", "document_id", "user_id", "user_name", "on_behalf_of_user_name",
 		"action", "details", "created_at",
 	})
 
@@ -124,6 +124,80 @@ func cancelAdministrativeOrderByLink(ctx context.Context, executor linkSQLExecut
 	return nil
 }
 
+// CreateAndCloseCitizenAppealWithOutbox создаёт связь «ответ» и закрывает обращение исходящим документом.
+func (r *LinkRepository) CreateAndCloseCitizenAppealWithOutbox(ctx context.Context, link *models.DocumentLink, appealID uuid.UUID, effects []models.OutboxEvent) error {
+	if r.outbox == nil {
+		return ErrOutboxNotConfigured
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := tx.QueryRowContext(ctx, `INSERT INTO document_links (id, source_document_id, target_document_id, link_type, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING created_at`, link.ID, link.SourceID, link.TargetID, link.LinkType, link.CreatedBy).Scan(&link.CreatedAt); err != nil {
+		return err
+	}
+	if err := refreshCitizenAppealReplyState(ctx, tx, appealID); err != nil {
+		return err
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteAndRefreshCitizenAppealWithOutbox удаляет связь «ответ» и пересчитывает закрытие обращения
+// по оставшимся исходящим ответам.
+func (r *LinkRepository) DeleteAndRefreshCitizenAppealWithOutbox(ctx context.Context, id, appealID uuid.UUID, effects []models.OutboxEvent) error {
+	if r.outbox == nil {
+		return ErrOutboxNotConfigured
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM document_links WHERE id = $1`, id); err != nil {
+		return err
+	}
+	if err := refreshCitizenAppealReplyState(ctx, tx, appealID); err != nil {
+		return err
+	}
+	for _, effect := range effects {
+		if err := r.outbox.EnqueueTx(tx, effect); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// refreshCitizenAppealReplyState закрывает обращение самым ранним исходящим ответом
+// либо снимает отметку о закрытии, если ответов не осталось.
+func refreshCitizenAppealReplyState(ctx context.Context, executor linkSQLExecutor, appealID uuid.UUID) error {
+	if _, err := executor.ExecContext(ctx, `
+		UPDATE citizen_appeal_details ca
+		SET (closed_by_document_id, closed_at) = (
+			SELECT reply.id, l.created_at
+			FROM document_links l
+			JOIN documents reply ON reply.id = CASE
+				WHEN l.source_document_id = ca.document_id THEN l.target_document_id
+				ELSE l.source_document_id
+			END
+			WHERE l.link_type = 'reply'
+			  AND reply.kind = $2
+			  AND (l.source_document_id = ca.document_id OR l.target_document_id = ca.document_id)
+			ORDER BY l.created_at, l.id
+			LIMIT 1
+		)
+		WHERE ca.document_id = $1
+	`, appealID, models.DocumentKindOutgoingLetter); err != nil {
+		return fmt.Errorf("failed to refresh citizen appeal reply state: %w", err)
+	}
+	return nil
+}
+
 // Delete — удалить связь по ID
 func (r *LinkRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM document_links WHERE id = $1`
@@ -72,6 +72,56 @@ func TestLinkRepositoryAtomicMethodsRequireOutbox(t *testing.T) {
 	require.ErrorIs(t, repo.CreateWithOutbox(context.Background(), link, nil), ErrOutboxNotConfigured)
 	require.ErrorIs(t, repo.CreateAndCancelOrderWithOutbox(context.Background(), link, nil), ErrOutboxNotConfigured)
 	require.ErrorIs(t, repo.DeleteWithOutbox(context.Background(), uuid.New(), nil), ErrOutboxNotConfigured)
+	require.ErrorIs(t, repo.CreateAndCloseCitizenAppealWithOutbox(context.Background(), link, uuid.New(), nil), ErrOutboxNotConfigured)
+	require.ErrorIs(t, repo.DeleteAndRefreshCitizenAppealWithOutbox(context.Background(), uuid.New(), uuid.New(), nil), ErrOutboxNotConfigured)
+}
+
+func TestLinkRepositoryCreateAndCloseCitizenAppealWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLinkRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	appealID := uuid.New()
+	link := &models.DocumentLink{ID: uuid.New(), SourceID: uuid.New(), TargetID: appealID, LinkType: "reply", CreatedBy: uuid.New()}
+	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "link:" + link.ID.String(), Payload: `{"action":"LINK_CREATE"}`}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO document_links`).
+		WithArgs(link.ID, link.SourceID, link.TargetID, link.LinkType, link.CreatedBy).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectExec(`UPDATE citizen_appeal_details ca\s+SET \(closed_by_document_id, closed_at\) = \(`).
+		WithArgs(appealID, models.DocumentKindOutgoingLetter).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = repo.CreateAndCloseCitizenAppealWithOutbox(context.Background(), link, appealID, []models.OutboxEvent{event})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLinkRepositoryDeleteAndRefreshCitizenAppealWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLinkRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	linkID := uuid.New()
+	appealID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM document_links WHERE id = \$1`).WithArgs(linkID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE citizen_appeal_details ca`).
+		WithArgs(appealID, models.DocumentKindOutgoingLetter).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err = repo.DeleteAndRefreshCitizenAppealWithOutbox(context.Background(), linkID, appealID, nil)
+	require.ErrorIs(t, err, assert.AnError)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestLinkRepository_CreateAndCancelOrder(t *testing.T) {
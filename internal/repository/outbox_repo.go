@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
@@ -81,12 +82,54 @@ func (r *OutboxRepository) Enqueue(event models.OutboxEvent) error {
 	return tx.Commit()
 }
 
+// EnqueueIfAbsent stores events whose deduplication keys were never used and
+// returns how many were added. Unlike EnqueueTx, a used key is not a conflict:
+// periodic producers rebuild the same keys on every run, and processed events
+// stay in the table, so a restart cannot repeat an already delivered effect.
+func (r *OutboxRepository) EnqueueIfAbsent(events []models.OutboxEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	added := 0
+	for _, event := range events {
+		if event.EventType == "" || event.DeduplicationKey == "" || event.Payload == "" {
+			return 0, fmt.Errorf("outbox event type, deduplication key and payload are required")
+		}
+		result, err := tx.Exec(`INSERT INTO event_outbox (event_type, deduplication_key, payload)
+			VALUES ($1, $2, $3::jsonb)
+			ON CONFLICT (deduplication_key) DO NOTHING`,
+			event.EventType, event.DeduplicationKey, event.Payload)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("read outbox enqueue result: %w", err)
+		}
+		added += int(rowsAffected)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
 // ClaimPending atomically reserves due events for one worker. SKIP LOCKED
 // allows several application instances to process independent events safely.
-func (r *OutboxRepository) ClaimPending(limit int) ([]models.OutboxEvent, error) {
+// Events of skipTypes stay pending for workers that can deliver them.
+func (r *OutboxRepository) ClaimPending(limit int, skipTypes ...string) ([]models.OutboxEvent, error) {
 	if limit < 1 {
 		return []models.OutboxEvent{}, nil
 	}
+	if skipTypes == nil {
+		// A NULL array would make the filter NULL and exclude every event.
+		skipTypes = []string{}
+	}
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
@@ -95,12 +138,13 @@ func (r *OutboxRepository) ClaimPending(limit int) ([]models.OutboxEvent, error)
 	rows, err := tx.Query(`WITH due AS (
 		SELECT id FROM event_outbox
 		WHERE processed_at IS NULL AND failed_at IS NULL AND processing_started_at IS NULL AND available_at <= CURRENT_TIMESTAMP
+		  AND NOT (event_type = ANY($2))
 		ORDER BY created_at FOR UPDATE SKIP LOCKED LIMIT $1
 	)
 	UPDATE event_outbox e SET processing_started_at = CURRENT_TIMESTAMP, attempts = attempts + 1
 	FROM due WHERE e.id = due.id
 	RETURNING e.id, e.event_type, e.deduplication_key, e.payload::text, e.available_at,
-		e.processing_started_at, e.processed_at, e.failed_at, e.attempts, e.last_error, e.created_at`, limit)
+		e.processing_started_at, e.processed_at, e.failed_at, e.attempts, e.last_error, e.created_at`, limit, pq.Array(skipTypes))
 	if err != nil {
 		return nil, err
 	}
@@ -146,6 +190,20 @@ func (r *OutboxRepository) MarkFailed(id uuid.UUID, attempts int, delay time.Dur
 	return err
 }
 
+// ExpirePending closes undelivered events of eventType created before the
+// given time. They are marked processed with an explanatory last_error, so
+// their deduplication keys keep them from being queued again.
+func (r *OutboxRepository) ExpirePending(eventType string, createdBefore time.Time) (int64, error) {
+	result, err := r.db.Exec(`UPDATE event_outbox
+		SET processed_at = CURRENT_TIMESTAMP, last_error = 'expired before delivery'
+		WHERE event_type = $1 AND processed_at IS NULL AND failed_at IS NULL AND processing_started_at IS NULL
+		  AND created_at < $2`, eventType, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // Requeue is the explicit administrative action for a terminal failure.
 func (r *OutboxRepository) Requeue(id uuid.UUID) error {
 	_, err := r.db.Exec(`UPDATE event_outbox
@@ -154,14 +212,19 @@ func (r *OutboxRepository) Requeue(id uuid.UUID) error {
 	return err
 }
 
-func (r *OutboxRepository) Stats() (models.OutboxStats, error) {
+// Stats counts events by delivery state. Pending events of skipTypes are left
+// out, so that a worker does not alert on events it leaves to other workers.
+func (r *OutboxRepository) Stats(skipTypes ...string) (models.OutboxStats, error) {
+	if skipTypes == nil {
+		skipTypes = []string{}
+	}
 	var stats models.OutboxStats
 	err := r.db.QueryRow(`SELECT
-		COUNT(*) FILTER (WHERE processed_at IS NULL AND failed_at IS NULL AND processing_started_at IS NULL),
+		COUNT(*) FILTER (WHERE processed_at IS NULL AND failed_at IS NULL AND processing_started_at IS NULL AND NOT (event_type = ANY($1))),
 		COUNT(*) FILTER (WHERE processed_at IS NULL AND failed_at IS NULL AND processing_started_at IS NOT NULL),
 		COUNT(*) FILTER (WHERE failed_at IS NOT NULL),
 		COUNT(*) FILTER (WHERE processed_at IS NOT NULL)
-		FROM event_outbox`).Scan(&stats.Pending, &stats.Processing, &stats.Failed, &stats.Processed)
+		FROM event_outbox`, pq.Array(skipTypes)).Scan(&stats.Pending, &stats.Processing, &stats.Failed, &stats.Processed)
 	return stats, err
 }
 
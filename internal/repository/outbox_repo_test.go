@@ -7,6 +7,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
@@ -52,6 +53,28 @@ func TestOutboxRepositoryEnqueueTxRejectsMismatchedDuplicate(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestOutboxRepositoryEnqueueIfAbsentSkipsUsedKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewOutboxRepository(&database.DB{DB: db})
+	events := []models.OutboxEvent{
+		{EventType: models.OutboxEventUserEvent, DeduplicationKey: "reminder:1", Payload: `{"request":{}}`},
+		{EventType: models.OutboxEventUserEvent, DeduplicationKey: "reminder:2", Payload: `{"request":{}}`},
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO event_outbox(.*)ON CONFLICT \(deduplication_key\) DO NOTHING`).
+		WithArgs(models.OutboxEventUserEvent, "reminder:1", `{"request":{}}`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO event_outbox(.*)ON CONFLICT \(deduplication_key\) DO NOTHING`).
+		WithArgs(models.OutboxEventUserEvent, "reminder:2", `{"request":{}}`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	added, err := repo.EnqueueIfAbsent(events)
+	require.NoError(t, err)
+	require.Equal(t, 1, added)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestEnqueueOutboxEffectsRequiresConfiguredOutbox(t *testing.T) {
 	err := enqueueOutboxEffects(nil, nil, []models.OutboxEvent{{
 		EventType:        models.OutboxEventJournal,
@@ -71,7 +94,7 @@ func TestOutboxRepositoryClaimPending(t *testing.T) {
 	now := time.Now()
 	mock.ExpectBegin()
 	mock.ExpectQuery(`WITH due AS \(.*FOR UPDATE SKIP LOCKED.*UPDATE event_outbox`).
-		WithArgs(10).
+		WithArgs(10, pq.Array([]string{})).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "deduplication_key", "payload", "available_at", "processing_started_at", "processed_at", "failed_at", "attempts", "last_error", "created_at"}).
 			AddRow(id, models.OutboxEventUserEvent, "key", `{"request":{}}`, now, now, nil, nil, 1, nil, now))
 	mock.ExpectCommit()
@@ -104,11 +127,31 @@ func TestOutboxRepositoryStats(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 	repo := NewOutboxRepository(&database.DB{DB: db})
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\) FILTER`).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\) FILTER`).WithArgs(pq.Array([]string{})).
 		WillReturnRows(sqlmock.NewRows([]string{"pending", "processing", "failed", "processed"}).AddRow(2, 1, 3, 4))
 	stats, err := repo.Stats()
 	require.NoError(t, err)
 	require.Equal(t, models.OutboxStats{Pending: 2, Processing: 1, Failed: 3, Processed: 4}, stats)
+
+	mock.ExpectQuery(`processing_started_at IS NULL AND NOT \(event_type = ANY\(\$1\)\)`).WithArgs(pq.Array([]string{models.OutboxEventEmail})).
+		WillReturnRows(sqlmock.NewRows([]string{"pending", "processing", "failed", "processed"}).AddRow(1, 1, 3, 4))
+	stats, err = repo.Stats(models.OutboxEventEmail)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Pending)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepositoryExpirePending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewOutboxRepository(&database.DB{DB: db})
+	before := time.Now().Add(-48 * time.Hour)
+	mock.ExpectExec(`UPDATE event_outbox\s+SET processed_at = CURRENT_TIMESTAMP, last_error = 'expired before delivery'\s+WHERE event_type = \$1 AND processed_at IS NULL AND failed_at IS NULL AND processing_started_at IS NULL\s+AND created_at < \$2`).
+		WithArgs(models.OutboxEventEmail, before).WillReturnResult(sqlmock.NewResult(0, 3))
+	expired, err := repo.ExpirePending(models.OutboxEventEmail, before)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), expired)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// OutgoingApprovalRepository хранит черновики исходящих писем и их маршруты согласования.
+type OutgoingApprovalRepository struct {
+	db     *database.DB
+	outbox *OutboxRepository
+}
+
+func (r *OutgoingApprovalRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
+
+// NewOutgoingApprovalRepository создает новый экземпляр OutgoingApprovalRepository.
+func NewOutgoingApprovalRepository(db *database.DB) *OutgoingApprovalRepository {
+	return &OutgoingApprovalRepository{db: db}
+}
+
+const outgoingDraftSelect = `
+	SELECT
+		d.id, d.author_id, u.full_name, d.nomenclature_id, d.document_type_id,
+		d.recipient_org_name, d.addressee, d.outgoing_date, d.content, d.pages_count,
+		d.sender_signatory, d.sender_executor, d.status, d.round, d.revision,
+		d.registration_key, d.document_id, d.created_at, d.updated_at
+	FROM outgoing_drafts d
+	JOIN users u ON u.id = d.author_id
+`
+
+// Create сохраняет черновик вместе с маршрутом согласования.
+func (r *OutgoingApprovalRepository) Create(draft *models.OutgoingDraft) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`
+		INSERT INTO outgoing_drafts (
+			author_id, nomenclature_id, document_type_id, recipient_org_name, addressee,
+			outgoing_date, content, pages_count, sender_signatory, sender_executor
+		) VALUES ($1, $2, `+documentTypeIDExpr("$11", "$3")+`, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, status, round, revision, registration_key, created_at, updated_at
+	`,
+		draft.AuthorID, draft.NomenclatureID, draft.DocumentTypeID, draft.RecipientOrgName, draft.Addressee,
+		draft.OutgoingDate, draft.Content, draft.PagesCount, draft.SenderSignatory, draft.SenderExecutor, models.DocumentKindOutgoingLetter,
+	).Scan(&draft.ID, &draft.Status, &draft.Round, &draft.Revision, &draft.RegistrationKey, &draft.CreatedAt, &draft.UpdatedAt); err != nil {
+		if isInvalidDocumentType(err) {
+			return errInvalidDocumentType
+		}
+		return fmt.Errorf("failed to create outgoing draft: %w", err)
+	}
+	if err := insertApprovalRouteTx(tx, draft.ID, draft.Stages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update заменяет реквизиты и маршрут черновика, если он не изменился с момента чтения.
+func (r *OutgoingApprovalRepository) Update(draft *models.OutgoingDraft) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`
+		UPDATE outgoing_drafts SET
+			nomenclature_id = $3, document_type_id = `+documentTypeIDUpdateExpr("outgoing_drafts", "$12", "$4")+`, recipient_org_name = $5, addressee = $6,
+			outgoing_date = $7, content = $8, pages_count = $9, sender_signatory = $10, sender_executor = $11,
+			revision = revision + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revision = $2 AND status IN ('draft', 'returned')
+		RETURNING revision, updated_at
+	`,
+		draft.ID, draft.Revision, draft.NomenclatureID, draft.DocumentTypeID, draft.RecipientOrgName, draft.Addressee,
+		draft.OutgoingDate, draft.Content, draft.PagesCount, draft.SenderSignatory, draft.SenderExecutor, models.DocumentKindOutgoingLetter,
+	).Scan(&draft.Revision, &draft.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return errOutgoingDraftChanged
+		}
+		if isInvalidDocumentType(err) {
+			return errInvalidDocumentType
+		}
+		return fmt.Errorf("failed to update outgoing draft: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM outgoing_draft_stages WHERE draft_id = $1`, draft.ID); err != nil {
+		return fmt.Errorf("failed to replace approval route: %w", err)
+	}
+	if err := insertApprovalRouteTx(tx, draft.ID, draft.Stages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete удаляет черновик, который еще не находится на согласовании.
+func (r *OutgoingApprovalRepository) Delete(id uuid.UUID, expectedRevision int) error {
+	result, err := r.db.Exec(`
+		DELETE FROM outgoing_drafts
+		WHERE id = $1 AND revision = $2 AND status IN ('draft', 'returned', 'rejected')
+	`, id, expectedRevision)
+	if err != nil {
+		return fmt.Errorf("failed to delete outgoing draft: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errOutgoingDraftChanged
+	}
+	return nil
+}
+
+var errOutgoingDraftChanged = models.NewConflict("черновик был изменен, обновите карточку")
+
+func insertApprovalRouteTx(tx *sql.Tx, draftID uuid.UUID, stages []models.ApprovalStage) error {
+	for _, stage := range stages {
+		if _, err := tx.Exec(`
+			INSERT INTO outgoing_draft_stages (draft_id, position, mode) VALUES ($1, $2, $3)
+		`, draftID, stage.Position, stage.Mode); err != nil {
+			return fmt.Errorf("failed to create approval stage: %w", err)
+		}
+		for _, approver := range stage.Approvers {
+			if _, err := tx.Exec(`
+				INSERT INTO outgoing_draft_approvers (draft_id, stage_position, position, user_id)
+				VALUES ($1, $2, $3, $4)
+			`, draftID, stage.Position, approver.Position, approver.UserID); err != nil {
+				return fmt.Errorf("failed to create approval stage participant: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyTransition атомарно меняет статус черновика, фиксирует решение и ставит в очередь эффекты.
+// Если черновик изменен параллельно, возвращается конфликт и ничего не сохраняется.
+func (r *OutgoingApprovalRepository) ApplyTransition(transition models.OutgoingDraftTransition, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE outgoing_drafts SET
+			status = $3, round = $4, document_id = COALESCE($5, document_id),
+			revision = revision + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revision = $2
+	`, transition.DraftID, transition.ExpectedRevision, transition.Status, transition.Round, transition.DocumentID)
+	if err != nil {
+		return fmt.Errorf("failed to update outgoing draft status: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errOutgoingDraftChanged
+	}
+
+	if transition.ResetDecisions {
+		if _, err := tx.Exec(`
+			UPDATE outgoing_draft_approvers
+			SET decision = NULL, decided_by = NULL, decided_at = NULL
+			WHERE draft_id = $1
+		`, transition.DraftID); err != nil {
+			return fmt.Errorf("failed to reset approval decisions: %w", err)
+		}
+	}
+	if decision := transition.Decision; decision != nil {
+		if _, err := tx.Exec(`
+			UPDATE outgoing_draft_approvers
+			SET decision = $4, decided_by = $5, decided_at = CURRENT_TIMESTAMP
+			WHERE draft_id = $1 AND stage_position = $2 AND user_id = $3 AND decision IS NULL
+		`, transition.DraftID, decision.StagePosition, decision.ApproverID, decision.Decision, decision.DecidedBy); err != nil {
+			return fmt.Errorf("failed to save approval decision: %w", err)
+		}
+		if err := tx.QueryRow(`
+			INSERT INTO outgoing_draft_decisions (draft_id, round, stage_position, approver_id, decided_by, decision, comment)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at
+		`, transition.DraftID, decision.Round, decision.StagePosition, decision.ApproverID, decision.DecidedBy, decision.Decision, decision.Comment,
+		).Scan(&decision.ID, &decision.CreatedAt); err != nil {
+			return fmt.Errorf("failed to record approval decision: %w", err)
+		}
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetByID возвращает черновик с маршрутом и историей решений.
+func (r *OutgoingApprovalRepository) GetByID(id uuid.UUID) (*models.OutgoingDraft, error) {
+	draft, err := scanOutgoingDraft(r.db.QueryRow(outgoingDraftSelect+` WHERE d.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outgoing draft: %w", err)
+	}
+	drafts := []models.OutgoingDraft{*draft}
+	if err := r.loadRoutes(drafts); err != nil {
+		return nil, err
+	}
+	decisions, err := r.getDecisions(id)
+	if err != nil {
+		return nil, err
+	}
+	drafts[0].Decisions = decisions
+	return &drafts[0], nil
+}
+
+// GetByAuthor возвращает черновики автора, начиная с недавно измененных.
+func (r *OutgoingApprovalRepository) GetByAuthor(authorID uuid.UUID) ([]models.OutgoingDraft, error) {
+	return r.queryDrafts(outgoingDraftSelect+` WHERE d.author_id = $1 ORDER BY d.updated_at DESC`, authorID)
+}
+
+// GetInApprovalByApprovers возвращает черновики на согласовании, в маршруте которых есть кто-то из пользователей.
+// Кто из них ожидает решения, определяет сервис по состоянию маршрута.
+func (r *OutgoingApprovalRepository) GetInApprovalByApprovers(userIDs []uuid.UUID) ([]models.OutgoingDraft, error) {
+	if len(userIDs) == 0 {
+		return []models.OutgoingDraft{}, nil
+	}
+	return r.queryDrafts(outgoingDraftSelect+`
+		WHERE d.status = 'in_approval'
+		  AND EXISTS (
+			SELECT 1 FROM outgoing_draft_approvers a
+			WHERE a.draft_id = d.id AND a.user_id = ANY($1)
+		  )
+		ORDER BY d.updated_at
+	`, pq.Array(userIDs))
+}
+
+func (r *OutgoingApprovalRepository) queryDrafts(query string, args ...any) ([]models.OutgoingDraft, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outgoing drafts: %w", err)
+	}
+	defer rows.Close()
+
+	drafts := make([]models.OutgoingDraft, 0)
+	for rows.Next() {
+		draft, err := scanOutgoingDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, *draft)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.loadRoutes(drafts); err != nil {
+		return nil, err
+	}
+	return drafts, nil
+}
+
+func scanOutgoingDraft(scanner interface{ Scan(dest ...any) error }) (*models.OutgoingDraft, error) {
+	var draft models.OutgoingDraft
+	var documentID uuid.NullUUID
+	if err := scanner.Scan(
+		&draft.ID, &draft.AuthorID, &draft.AuthorName, &draft.NomenclatureID, &draft.DocumentTypeID,
+		&draft.RecipientOrgName, &draft.Addressee, &draft.OutgoingDate, &draft.Content, &draft.PagesCount,
+		&draft.SenderSignatory, &draft.SenderExecutor, &draft.Status, &draft.Round, &draft.Revision,
+		&draft.RegistrationKey, &documentID, &draft.CreatedAt, &draft.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if documentID.Valid {
+		draft.DocumentID = &documentID.UUID
+	}
+	return &draft, nil
+}
+
+// loadRoutes загружает этапы и участников для всех черновиков одним запросом.
+func (r *OutgoingApprovalRepository) loadRoutes(drafts []models.OutgoingDraft) error {
+	if len(drafts) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(drafts))
+	index := make(map[uuid.UUID]int, len(drafts))
+	for i := range drafts {
+		ids[i] = drafts[i].ID
+		index[drafts[i].ID] = i
+		drafts[i].Stages = make([]models.ApprovalStage, 0)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT
+			s.draft_id, s.position, s.mode,
+			a.user_id, u.full_name, a.position, COALESCE(a.decision, ''), a.decided_by, COALESCE(du.full_name, ''), a.decided_at
+		FROM outgoing_draft_stages s
+		JOIN outgoing_draft_approvers a ON a.draft_id = s.draft_id AND a.stage_position = s.position
+		JOIN users u ON u.id = a.user_id
+		LEFT JOIN users du ON du.id = a.decided_by
+		WHERE s.draft_id = ANY($1)
+		ORDER BY s.draft_id, s.position, a.position
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get approval routes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var draftID uuid.UUID
+		var stage models.ApprovalStage
+		var approver models.ApprovalApprover
+		var decidedBy uuid.NullUUID
+		var decidedAt sql.NullTime
+		if err := rows.Scan(
+			&draftID, &stage.Position, &stage.Mode,
+			&approver.UserID, &approver.UserName, &approver.Position, &approver.Decision, &decidedBy, &approver.DecidedByName, &decidedAt,
+		); err != nil {
+			return err
+		}
+		if decidedBy.Valid {
+			approver.DecidedBy = &decidedBy.UUID
+		}
+		if decidedAt.Valid {
+			approver.DecidedAt = &decidedAt.Time
+		}
+		draft := &drafts[index[draftID]]
+		if n := len(draft.Stages); n == 0 || draft.Stages[n-1].Position != stage.Position {
+			draft.Stages = append(draft.Stages, stage)
+		}
+		last := &draft.Stages[len(draft.Stages)-1]
+		last.Approvers = append(last.Approvers, approver)
+	}
+	return rows.Err()
+}
+
+func (r *OutgoingApprovalRepository) getDecisions(draftID uuid.UUID) ([]models.ApprovalDecision, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			d.id, d.draft_id, d.round, d.stage_position, d.approver_id, a.full_name,
+			d.decided_by, b.full_name, d.decision, d.comment, d.created_at
+		FROM outgoing_draft_decisions d
+		JOIN users a ON a.id = d.approver_id
+		JOIN users b ON b.id = d.decided_by
+		WHERE d.draft_id = $1
+		ORDER BY d.created_at, d.id
+	`, draftID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval decisions: %w", err)
+	}
+	defer rows.Close()
+
+	decisions := make([]models.ApprovalDecision, 0)
+	for rows.Next() {
+		var decision models.ApprovalDecision
+		if err := rows.Scan(
+			&decision.ID, &decision.DraftID, &decision.Round, &decision.StagePosition, &decision.ApproverID, &decision.ApproverName,
+			&decision.DecidedBy, &decision.DecidedByName, &decision.Decision, &decision.Comment, &decision.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions, rows.Err()
+}
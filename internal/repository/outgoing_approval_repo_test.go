@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOutgoingApprovalRepo(t *testing.T) (*OutgoingApprovalRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	return NewOutgoingApprovalRepository(&database.DB{DB: db}), mock
+}
+
+func TestOutgoingApprovalRepositoryCreateStoresRoute(t *testing.T) {
+	repo, mock := setupOutgoingApprovalRepo(t)
+	draftID, registrationKey := uuid.New(), uuid.New()
+	first, second := uuid.New(), uuid.New()
+	now := time.Now()
+	draft := &models.OutgoingDraft{
+		AuthorID: uuid.New(), NomenclatureID: uuid.New(), DocumentTypeID: models.DocumentTypeLetter,
+		RecipientOrgName: "ООО Получатель", OutgoingDate: now, Content: "О графике", PagesCount: 1,
+		Stages: []models.ApprovalStage{
+			{Position: 1, Mode: models.ApprovalStageParallel, Approvers: []models.ApprovalApprover{{UserID: first, Position: 1}, {UserID: second, Position: 2}}},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO outgoing_drafts`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "round", "revision", "registration_key", "created_at", "updated_at"}).
+			AddRow(draftID, models.OutgoingDraftStatusDraft, 0, 1, registrationKey, now, now))
+	mock.ExpectExec(`INSERT INTO outgoing_draft_stages`).WithArgs(draftID, 1, models.ApprovalStageParallel).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO outgoing_draft_approvers`).WithArgs(draftID, 1, 1, first).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO outgoing_draft_approvers`).WithArgs(draftID, 1, 2, second).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Create(draft))
+	assert.Equal(t, draftID, draft.ID)
+	assert.Equal(t, registrationKey, draft.RegistrationKey)
+	assert.Equal(t, 1, draft.Revision)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutgoingApprovalRepositoryApplyTransitionRecordsDecision(t *testing.T) {
+	repo, mock := setupOutgoingApprovalRepo(t)
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	draftID, approverID, decisionID := uuid.New(), uuid.New(), uuid.New()
+	decision := &models.ApprovalDecision{Round: 2, StagePosition: 1, ApproverID: approverID, DecidedBy: approverID, Decision: models.ApprovalDecisionReturn, Comment: "Уточнить сроки"}
+	event := models.OutboxEvent{EventType: models.OutboxEventUserEvent, DeduplicationKey: "outgoing_draft:test:user_event", Payload: `{}`}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE outgoing_drafts SET`).WithArgs(draftID, 3, models.OutgoingDraftStatusReturned, 2, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE outgoing_draft_approvers\s+SET decision = \$4`).WithArgs(draftID, 1, approverID, models.ApprovalDecisionReturn, approverID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO outgoing_draft_decisions`).
+		WithArgs(draftID, 2, 1, approverID, approverID, models.ApprovalDecisionReturn, "Уточнить сроки").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(decisionID, time.Now()))
+	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.ApplyTransition(models.OutgoingDraftTransition{
+		DraftID: draftID, ExpectedRevision: 3, Status: models.OutgoingDraftStatusReturned, Round: 2, Decision: decision,
+	}, []models.OutboxEvent{event})
+
+	require.NoError(t, err)
+	assert.Equal(t, decisionID, decision.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutgoingApprovalRepositoryApplyTransitionRejectsStaleRevision(t *testing.T) {
+	repo, mock := setupOutgoingApprovalRepo(t)
+	draftID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE outgoing_drafts SET`).WithArgs(draftID, 1, models.OutgoingDraftStatusInApproval, 1, nil).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.ApplyTransition(models.OutgoingDraftTransition{
+		DraftID: draftID, ExpectedRevision: 1, Status: models.OutgoingDraftStatusInApproval, Round: 1, ResetDecisions: true,
+	}, nil)
+
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "CONFLICT", appErr.Kind)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutgoingApprovalRepositoryApplyTransitionRequiresOutboxForEffects(t *testing.T) {
+	repo, mock := setupOutgoingApprovalRepo(t)
+	draftID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE outgoing_drafts SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	err := repo.ApplyTransition(models.OutgoingDraftTransition{DraftID: draftID, ExpectedRevision: 1, Status: models.OutgoingDraftStatusApproved, Round: 1},
+		[]models.OutboxEvent{{EventType: models.OutboxEventUserEvent, DeduplicationKey: "k", Payload: `{}`}})
+
+	require.ErrorIs(t, err, ErrOutboxNotConfigured)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutgoingApprovalRepositoryGetInApprovalByApproversLoadsRoutes(t *testing.T) {
+	repo, mock := setupOutgoingApprovalRepo(t)
+	draftID, authorID, approverID := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM outgoing_drafts d.*WHERE d.status = 'in_approval'`).
+		WithArgs(pq.Array([]uuid.UUID{approverID})).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "author_id", "full_name", "nomenclature_id", "document_type", "recipient_org_name", "addressee", "outgoing_date",
+			"content", "pages_count", "sender_signatory", "sender_executor", "status", "round", "revision", "registration_key",
+			"document_id", "created_at", "updated_at",
+		}).AddRow(draftID, authorID, "Автор", uuid.New(), models.DocumentTypeLetter, "ООО", "", now,
+			"О графике", 1, "", "", models.OutgoingDraftStatusInApproval, 1, 2, uuid.New(), nil, now, now))
+	mock.ExpectQuery(`FROM outgoing_draft_stages s`).
+		WithArgs(pq.Array([]uuid.UUID{draftID})).
+		WillReturnRows(sqlmock.NewRows([]string{"draft_id", "position", "mode", "user_id", "full_name", "position", "decision", "decided_by", "decided_by_name", "decided_at"}).
+			AddRow(draftID, 1, models.ApprovalStageSequential, approverID, "Согласующий", 1, "", nil, "", nil).
+			AddRow(draftID, 2, models.ApprovalStageParallel, uuid.New(), "Руководитель", 1, "", nil, "", nil))
+
+	drafts, err := repo.GetInApprovalByApprovers([]uuid.UUID{approverID})
+
+	require.NoError(t, err)
+	require.Len(t, drafts, 1)
+	assert.Equal(t, "Автор", drafts[0].AuthorName)
+	assert.Nil(t, drafts[0].DocumentID)
+	require.Len(t, drafts[0].Stages, 2)
+	assert.Equal(t, []uuid.UUID{approverID}, drafts[0].PendingApproverIDs())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -42,7 +42,7 @@ func (r *OutgoingDocumentRepository) GetList(filter models.OutgoingDocumentFilte
 		argIdx++
 	}
 	if filter.DocumentTypeID != "" {
-		where = append(where, fmt.Sprintf("d.document_type = $%d", argIdx))
+		where = append(where, documentTypeFilterExpr(fmt.Sprintf("$%d", argIdx)))
 		args = append(args, filter.DocumentTypeID)
 		argIdx++
 	}
@@ -102,7 +102,7 @@ func (r *OutgoingDocumentRepository) GetList(filter models.OutgoingDocumentFilte
 		SELECT 
 			d.id, d.nomenclature_id, n.index || ' — ' || n.name as nomenclature_name,
 			out.outgoing_number, out.outgoing_date,
-			d.document_type, d.document_type as document_type_name,
+			d.document_type_id, dt.name as document_type_name,
 			d.content, d.pages_count,
 			out.sender_signatory, out.sender_executor,
 			out.recipient_org_id, ro.name as recipient_org_name, out.addressee,
@@ -110,6 +110,7 @@ func (r *OutgoingDocumentRepository) GetList(filter models.OutgoingDocumentFilte
 			d.created_at, d.updated_at
 		FROM documents d
 		JOIN outgoing_document_details out ON out.document_id = d.id
+		JOIN document_types dt ON dt.id = d.document_type_id
 		JOIN nomenclature n ON d.nomenclature_id = n.id
 		JOIN organizations ro ON out.recipient_org_id = ro.id
 		JOIN users u ON d.created_by = u.id
@@ -181,7 +182,7 @@ func (r *OutgoingDocumentRepository) GetByID(id uuid.UUID) (*models.OutgoingDocu
 		SELECT 
 			d.id, d.nomenclature_id, n.index || ' — ' || n.name as nomenclature_name,
 			out.outgoing_number, out.outgoing_date,
-			d.document_type, d.document_type as document_type_name,
+			d.document_type_id, dt.name as document_type_name,
 			d.content, d.pages_count,
 			out.sender_signatory, out.sender_executor,
 			out.recipient_org_id, ro.name as recipient_org_name, out.addressee,
@@ -189,6 +190,7 @@ func (r *OutgoingDocumentRepository) GetByID(id uuid.UUID) (*models.OutgoingDocu
 			d.created_at, d.updated_at
 		FROM documents d
 		JOIN outgoing_document_details out ON out.document_id = d.id
+		JOIN document_types dt ON dt.id = d.document_type_id
 		JOIN nomenclature n ON d.nomenclature_id = n.id
 		JOIN organizations ro ON out.recipient_org_id = ro.id
 		JOIN users u ON d.created_by = u.id
@@ -223,7 +225,7 @@ func (r *OutgoingDocumentRepository) GetByIDs(ids []uuid.UUID) ([]models.Outgoin
 		SELECT
 			d.id, d.nomenclature_id, n.index || ' — ' || n.name AS nomenclature_name,
 			out.outgoing_number, out.outgoing_date,
-			d.document_type, d.document_type AS document_type_name,
+			d.document_type_id, dt.name AS document_type_name,
 			d.content, d.pages_count,
 			out.sender_signatory, out.sender_executor,
 			out.recipient_org_id, ro.name AS recipient_org_name, out.addressee,
@@ -231,6 +233,7 @@ func (r *OutgoingDocumentRepository) GetByIDs(ids []uuid.UUID) ([]models.Outgoin
 			d.created_at, d.updated_at
 		FROM documents d
 		JOIN outgoing_document_details out ON out.document_id = d.id
+		JOIN document_types dt ON dt.id = d.document_type_id
 		JOIN nomenclature n ON d.nomenclature_id = n.id
 		JOIN organizations ro ON out.recipient_org_id = ro.id
 		JOIN users u ON d.created_by = u.id
@@ -270,8 +273,8 @@ func (r *OutgoingDocumentRepository) CreateWithJournal(req models.CreateOutgoing
 
 func (r *OutgoingDocumentRepository) create(req models.CreateOutgoingDocRequest, effects []models.OutboxEvent, journalAction, journalDetailsFormat string) (*models.OutgoingDocument, error) {
 	req.DocumentTypeID = models.NormalizeDocumentType(req.DocumentTypeID)
-	if !models.IsAllowedDocumentType(req.DocumentTypeID) {
-		return nil, models.NewBadRequest("неверный тип документа")
+	if req.DocumentTypeID == "" {
+		return nil, errInvalidDocumentType
 	}
 
 	tx, err := r.db.Begin()
@@ -300,8 +303,8 @@ func (r *OutgoingDocumentRepository) create(req models.CreateOutgoingDocRequest,
 	var id uuid.UUID
 	err = tx.QueryRow(`
 		INSERT INTO documents (
-			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by
+		) VALUES ($1, $2, $3, $4, $5, `+documentTypeIDExpr("$1", "$6")+`, $7, $8, $9)
 		RETURNING id
 	`,
 		models.DocumentKindOutgoingLetter, req.NomenclatureID, req.IdempotencyKey, req.OutgoingNumber, req.OutgoingDate, req.DocumentTypeID, req.Content, req.PagesCount, req.CreatedBy,
@@ -318,6 +321,9 @@ func (r *OutgoingDocumentRepository) create(req models.CreateOutgoingDocRequest,
 		if isUniqueViolation(err, "idx_documents_kind_registration_number_year") {
 			return nil, models.NewConflict("документ с таким регистрационным номером уже существует")
 		}
+		if isInvalidDocumentType(err) {
+			return nil, errInvalidDocumentType
+		}
 		return nil, fmt.Errorf("failed to create document root: %w", err)
 	}
 
@@ -365,8 +371,8 @@ func (r *OutgoingDocumentRepository) UpdateWithOutbox(req models.UpdateOutgoingD
 
 func (r *OutgoingDocumentRepository) update(req models.UpdateOutgoingDocRequest, effects []models.OutboxEvent) (*models.OutgoingDocument, error) {
 	req.DocumentTypeID = models.NormalizeDocumentType(req.DocumentTypeID)
-	if !models.IsAllowedDocumentType(req.DocumentTypeID) {
-		return nil, models.NewBadRequest("неверный тип документа")
+	if req.DocumentTypeID == "" {
+		return nil, errInvalidDocumentType
 	}
 
 	tx, err := r.db.Begin()
@@ -377,7 +383,7 @@ func (r *OutgoingDocumentRepository) update(req models.UpdateOutgoingDocRequest,
 
 	if _, err = tx.Exec(`
 		UPDATE documents SET
-			document_type = $1,
+			document_type_id = `+documentTypeIDUpdateExpr("documents", "$5", "$1")+`,
 			content = $2,
 			pages_count = $3,
 			updated_at = CURRENT_TIMESTAMP
@@ -385,6 +391,9 @@ func (r *OutgoingDocumentRepository) update(req models.UpdateOutgoingDocRequest,
 	`,
 		req.DocumentTypeID, req.Content, req.PagesCount, req.ID, models.DocumentKindOutgoingLetter,
 	); err != nil {
+		if isInvalidDocumentType(err) {
+			return nil, errInvalidDocumentType
+		}
 		return nil, fmt.Errorf("failed to update document root: %w", err)
 	}
 
@@ -152,14 +152,14 @@ func TestOutgoingDocumentRepository_Create(t *testing.T) {
 }
 
 func TestOutgoingDocumentRepository_CreateValidationErrors(t *testing.T) {
-	t.Run("invalid document type", func(t *testing.T) {
+	t.Run("missing document type", func(t *testing.T) {
 		db, _, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
 		repo := NewOutgoingDocumentRepository(&database.DB{DB: db})
 
-		doc, err := repo.Create(models.CreateOutgoingDocRequest{DocumentTypeID: "unknown"})
+		doc, err := repo.Create(models.CreateOutgoingDocRequest{DocumentTypeID: ""})
 		require.Error(t, err)
 		assert.Nil(t, doc)
 		assert.Contains(t, err.Error(), "неверный тип документа")
@@ -200,6 +200,11 @@ func TestOutgoingDocumentRepository_CreateRootInsertErrors(t *testing.T) {
 			wantErr:    "документ с таким регистрационным номером уже существует",
 			wantAppErr: true,
 		},
+		{
+			name:      "document type not allowed for kind",
+			insertErr: &pq.Error{Code: "23503", Constraint: "documents_document_type_kind_fkey"},
+			wantErr:   "неверный тип документа",
+		},
 		{
 			name:      "generic root insert error",
 			insertErr: sql.ErrConnDone,
@@ -547,14 +552,14 @@ func TestOutgoingDocumentRepositoryUpdateWithOutboxRollsBackOnEnqueueFailure(t *
 }
 
 func TestOutgoingDocumentRepository_UpdateErrors(t *testing.T) {
-	t.Run("invalid document type", func(t *testing.T) {
+	t.Run("missing document type", func(t *testing.T) {
 		db, _, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
 		repo := NewOutgoingDocumentRepository(&database.DB{DB: db})
 
-		doc, err := repo.Update(models.UpdateOutgoingDocRequest{DocumentTypeID: "unknown"})
+		doc, err := repo.Update(models.UpdateOutgoingDocRequest{DocumentTypeID: ""})
 		require.Error(t, err)
 		assert.Nil(t, doc)
 		assert.Contains(t, err.Error(), "неверный тип документа")
@@ -62,7 +62,7 @@ func seedPerformanceDocuments(tb testing.TB, db *sql.DB, count int) (uuid.UUID,
 		id := uuid.New()
 		date := time.Date(2026, time.Month(i%12+1), 1, 0, 0, 0, 0, time.UTC)
 		number := fmt.Sprintf("PF/%d", i+1)
-		execSQL(tb, db, `INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by, created_at) VALUES ($1, 'outgoing_letter', $2, $3, $4, $5, $6, $7, 1, $8, $9)`, id, nomID, uuid.New(), number, date, models.DocumentTypeLetter, fmt.Sprintf("performance searchable %d", i), owner, date)
+		execSQL(tb, db, `INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by, created_at) VALUES ($1, 'outgoing_letter', $2, $3, $4, $5, (SELECT id FROM document_types WHERE name = $6), $7, 1, $8, $9)`, id, nomID, uuid.New(), number, date, models.DocumentTypeLetter, fmt.Sprintf("performance searchable %d", i), owner, date)
 		execSQL(tb, db, `INSERT INTO outgoing_document_details (document_id, outgoing_number, outgoing_date, sender_signatory, sender_executor, recipient_org_id, addressee) VALUES ($1, $2, $3, 'Signer', 'Executor', $4, 'Addressee')`, id, number, date, orgID)
 	}
 	return nomID, owner, orgID
@@ -64,7 +64,7 @@ func TestLinkGraphAndOutboxLifecycleIntegration(t *testing.T) {
 	execSQL(t, sqlDB, `INSERT INTO nomenclature (id, name, index, year, kind_code, separator, numbering_mode) VALUES ($1, 'Graph', 'GR', 2026, 'outgoing_letter', '/', 'index_and_number')`, nom)
 	ids := []uuid.UUID{root, uuid.New(), uuid.New(), uuid.New()}
 	for i := 1; i < len(ids); i++ {
-		execSQL(t, sqlDB, `INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by) VALUES ($1, 'outgoing_letter', $2, $3, $4, CURRENT_DATE, $5, 'graph', 1, $6)`, ids[i], nom, uuid.New(), "GR/"+string(rune('0'+i)), models.DocumentTypeLetter, user)
+		execSQL(t, sqlDB, `INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by) VALUES ($1, 'outgoing_letter', $2, $3, $4, CURRENT_DATE, (SELECT id FROM document_types WHERE name = $5), 'graph', 1, $6)`, ids[i], nom, uuid.New(), "GR/"+string(rune('0'+i)), models.DocumentTypeLetter, user)
 	}
 	links := NewLinkRepository(db)
 	for _, pair := range [][2]int{{0, 1}, {1, 2}, {2, 0}, {2, 3}} {
@@ -131,9 +131,24 @@ func TestUserSubstitutionAndReferenceMergeIntegration(t *testing.T) {
 	if _, err := repo.ReplaceForPrincipal(principal, &substitute, nil, nil, true, nil); err != nil {
 		t.Fatalf("set substitution: %v", err)
 	}
-	active, err := repo.IsActiveSubstitute(substitute, principal)
-	if err != nil || !active {
-		t.Fatalf("active substitution=%v err=%v", active, err)
+	second := insertIntegrationUser(t, sqlDB, "second_substitute")
+	if _, err := repo.Create(models.UserSubstitution{PrincipalUserID: principal, SubstituteUserID: second, IsActive: true, Scopes: []string{models.SubstitutionScopeAcknowledgments}}); err != nil {
+		t.Fatalf("add concurrent substitution: %v", err)
+	}
+	grants, err := repo.GetActiveGrants(substitute)
+	if err != nil || len(grants) != 1 || grants[0].PrincipalUserID != principal {
+		t.Fatalf("active grants=%+v err=%v", grants, err)
+	}
+	grants, err = repo.GetActiveGrants(second)
+	if err != nil || len(grants) != 1 || !grants[0].Covers(models.SubstitutionScopeAcknowledgments, "") || grants[0].Covers(models.SubstitutionScopeApprovals, "") {
+		t.Fatalf("scoped grants=%+v err=%v", grants, err)
+	}
+	if _, err := repo.ReplaceForPrincipal(principal, &substitute, nil, nil, true, nil); err != nil {
+		t.Fatalf("replace substitution: %v", err)
+	}
+	history, err := repo.GetHistoryByPrincipalID(principal)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("substitution history=%d err=%v", len(history), err)
 	}
 	if _, err := repo.ReplaceForPrincipal(principal, &principal, nil, nil, true, nil); err == nil {
 		t.Fatal("self substitution accepted")
@@ -159,6 +174,6 @@ func TestUserSubstitutionAndReferenceMergeIntegration(t *testing.T) {
 func insertScopedOutgoing(t *testing.T, db *sql.DB, nom, creator, org uuid.UUID, number, content string) {
 	t.Helper()
 	id := uuid.New()
-	execSQL(t, db, `INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by) VALUES ($1, 'outgoing_letter', $2, $3, $4, CURRENT_DATE, $5, $6, 1, $7)`, id, nom, uuid.New(), number, models.DocumentTypeLetter, content, creator)
+	execSQL(t, db, `INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by) VALUES ($1, 'outgoing_letter', $2, $3, $4, CURRENT_DATE, (SELECT id FROM document_types WHERE name = $5), $6, 1, $7)`, id, nom, uuid.New(), number, models.DocumentTypeLetter, content, creator)
 	execSQL(t, db, `INSERT INTO outgoing_document_details (document_id, outgoing_number, outgoing_date, sender_signatory, sender_executor, recipient_org_id, addressee) VALUES ($1, $2, CURRENT_DATE, 'Signer', 'Executor', $3, 'Addressee')`, id, number, org)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
@@ -40,28 +41,199 @@ func NewReferenceRepository(db *database.DB) *ReferenceRepository {
 
 // === Типы документов ===
 
-// GetAllDocumentTypes возвращает все типы документов.
+const documentTypeSelect = `
+	SELECT
+		t.id, t.name, t.is_active, t.created_at, t.updated_at,
+		COALESCE((SELECT array_agg(k.kind ORDER BY k.kind) FROM document_type_kinds k WHERE k.document_type_id = t.id), '{}'),
+		(SELECT COUNT(*) FROM documents d WHERE d.document_type_id = t.id)
+	FROM document_types t
+`
+
+// GetAllDocumentTypes возвращает все типы документов, включая неактивные.
 func (r *ReferenceRepository) GetAllDocumentTypes() ([]models.DocumentType, error) {
-	items := make([]models.DocumentType, 0, len(models.AllowedDocumentTypes()))
-	for _, name := range models.AllowedDocumentTypes() {
-		items = append(items, models.DocumentType{Name: name})
+	return r.queryDocumentTypes(documentTypeSelect + ` ORDER BY t.name`)
+}
+
+// GetDocumentTypesByKind возвращает действующие типы, доступные для регистрации документов вида kind.
+func (r *ReferenceRepository) GetDocumentTypesByKind(kind models.DocumentKind) ([]models.DocumentType, error) {
+	return r.queryDocumentTypes(documentTypeSelect+`
+		WHERE t.is_active
+		  AND EXISTS (SELECT 1 FROM document_type_kinds k WHERE k.document_type_id = t.id AND k.kind = $1)
+		ORDER BY t.name
+	`, kind)
+}
+
+func (r *ReferenceRepository) getDocumentTypeTx(tx *sql.Tx, id uuid.UUID) (*models.DocumentType, error) {
+	return scanDocumentType(tx.QueryRow(documentTypeSelect+` WHERE t.id = $1`, id))
+}
+
+func (r *ReferenceRepository) queryDocumentTypes(query string, args ...interface{}) ([]models.DocumentType, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document types: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.DocumentType, 0)
+	for rows.Next() {
+		item, err := scanDocumentType(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 	return items, nil
 }
 
-// CreateDocumentType создает новый тип документа.
-func (r *ReferenceRepository) CreateDocumentType(name string) (*models.DocumentType, error) {
-	return nil, models.NewBadRequest("типы документов заданы в коде и не редактируются")
+func scanDocumentType(scanner interface{ Scan(...interface{}) error }) (*models.DocumentType, error) {
+	var item models.DocumentType
+	var kinds pq.StringArray
+	if err := scanner.Scan(&item.ID, &item.Name, &item.IsActive, &item.CreatedAt, &item.UpdatedAt, &kinds, &item.UsageCount); err != nil {
+		return nil, err
+	}
+	item.Kinds = make([]models.DocumentKind, len(kinds))
+	for i, kind := range kinds {
+		item.Kinds[i] = models.DocumentKind(kind)
+	}
+	return &item, nil
+}
+
+// CreateDocumentType создает тип документа с набором видов.
+func (r *ReferenceRepository) CreateDocumentType(name string, kinds []models.DocumentKind) (*models.DocumentType, error) {
+	return r.CreateDocumentTypeWithOutbox(name, kinds, nil)
 }
 
-// UpdateDocumentType обновляет тип документа.
-func (r *ReferenceRepository) UpdateDocumentType(id uuid.UUID, name string) error {
-	return models.NewBadRequest("типы документов заданы в коде и не редактируются")
+// CreateDocumentTypeWithOutbox создает тип документа с набором видов и ставит в очередь эффекты.
+func (r *ReferenceRepository) CreateDocumentTypeWithOutbox(name string, kinds []models.DocumentKind, effects []models.OutboxEvent) (*models.DocumentType, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	if err := tx.QueryRow(`INSERT INTO document_types (name) VALUES ($1) RETURNING id`, name).Scan(&id); err != nil {
+		if isUniqueViolation(err, "idx_document_types_name") {
+			return nil, errDocumentTypeNameTaken
+		}
+		return nil, fmt.Errorf("failed to create document type: %w", err)
+	}
+	if err := replaceDocumentTypeKindsTx(tx, id, kinds); err != nil {
+		return nil, err
+	}
+	item, err := r.getDocumentTypeTx(tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document type: %w", err)
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+	return item, tx.Commit()
+}
+
+// UpdateDocumentType меняет название, набор видов и активность типа документа.
+func (r *ReferenceRepository) UpdateDocumentType(id uuid.UUID, name string, kinds []models.DocumentKind, isActive bool) (*models.DocumentType, error) {
+	return r.UpdateDocumentTypeWithOutbox(id, name, kinds, isActive, nil)
+}
+
+// UpdateDocumentTypeWithOutbox меняет название, набор видов и активность типа документа.
+// Вид нельзя убрать из набора, пока есть документы этого вида с данным типом.
+func (r *ReferenceRepository) UpdateDocumentTypeWithOutbox(id uuid.UUID, name string, kinds []models.DocumentKind, isActive bool, effects []models.OutboxEvent) (*models.DocumentType, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE document_types SET name = $2, is_active = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1
+	`, id, name, isActive)
+	if err != nil {
+		if isUniqueViolation(err, "idx_document_types_name") {
+			return nil, errDocumentTypeNameTaken
+		}
+		return nil, fmt.Errorf("failed to update document type: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, models.NewNotFound("тип документа не найден")
+	}
+	if err := replaceDocumentTypeKindsTx(tx, id, kinds); err != nil {
+		return nil, err
+	}
+	item, err := r.getDocumentTypeTx(tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document type: %w", err)
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+	return item, tx.Commit()
 }
 
-// DeleteDocumentType удаляет тип документа.
+// DeleteDocumentType удаляет неиспользуемый тип документа.
 func (r *ReferenceRepository) DeleteDocumentType(id uuid.UUID) error {
-	return models.NewBadRequest("типы документов заданы в коде и не редактируются")
+	return r.DeleteDocumentTypeWithOutbox(id, nil)
+}
+
+// DeleteDocumentTypeWithOutbox удаляет тип, который не используется ни в документах, ни в черновиках.
+// Используемый тип можно только деактивировать.
+func (r *ReferenceRepository) DeleteDocumentTypeWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM document_types WHERE id = $1`, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.NewConflict("тип документа уже используется, его можно только деактивировать")
+		}
+		return fmt.Errorf("failed to delete document type: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return models.NewNotFound("тип документа не найден")
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var errDocumentTypeNameTaken = models.NewConflict("тип документа с таким названием уже существует")
+
+func replaceDocumentTypeKindsTx(tx *sql.Tx, id uuid.UUID, kinds []models.DocumentKind) error {
+	codes := make([]string, len(kinds))
+	for i, kind := range kinds {
+		codes[i] = string(kind)
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM document_type_kinds WHERE document_type_id = $1 AND NOT (kind = ANY($2))
+	`, id, pq.Array(codes)); err != nil {
+		if isForeignKeyViolation(err) {
+			return models.NewConflict("тип документа используется в документах исключаемого вида")
+		}
+		return fmt.Errorf("failed to update document type kinds: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO document_type_kinds (document_type_id, kind)
+		SELECT $1, unnest($2::varchar[])
+		ON CONFLICT DO NOTHING
+	`, id, pq.Array(codes)); err != nil {
+		return fmt.Errorf("failed to update document type kinds: %w", err)
+	}
+	return nil
 }
 
 // === Организации ===
@@ -10,44 +10,115 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 // === Document Types ===
 
+var documentTypeColumns = []string{"id", "name", "is_active", "created_at", "updated_at", "kinds", "usage_count"}
+
 func TestReferenceRepository_GetAllDocumentTypes(t *testing.T) {
-	// Получение всех типов документов из кода.
+	// Получение всех типов документов вместе с видами и числом документов.
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
 	repo := NewReferenceRepository(&database.DB{DB: db})
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM document_types t\s+ORDER BY t.name`).
+		WillReturnRows(sqlmock.NewRows(documentTypeColumns).
+			AddRow(id, "Письмо", true, now, now, "{incoming_letter,outgoing_letter}", 12))
 
 	types, err := repo.GetAllDocumentTypes()
 	require.NoError(t, err)
-	require.Len(t, types, len(models.AllowedDocumentTypes()))
-	assert.Equal(t, models.DocumentTypeLetter, types[0].Name)
+	require.Len(t, types, 1)
+	assert.Equal(t, "Письмо", types[0].Name)
+	assert.Equal(t, []models.DocumentKind{models.DocumentKindIncomingLetter, models.DocumentKindOutgoingLetter}, types[0].Kinds)
+	assert.Equal(t, 12, types[0].UsageCount)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReferenceRepository_GetDocumentTypesByKind(t *testing.T) {
+	// Для вида документа возвращаются только действующие типы из его набора.
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewReferenceRepository(&database.DB{DB: db})
+	now := time.Now()
+
+	mock.ExpectQuery(`WHERE t.is_active\s+AND EXISTS`).
+		WithArgs(models.DocumentKindCitizenAppeal).
+		WillReturnRows(sqlmock.NewRows(documentTypeColumns).
+			AddRow(uuid.New(), "Обращение", true, now, now, "{citizen_appeal}", 0))
+
+	types, err := repo.GetDocumentTypesByKind(models.DocumentKindCitizenAppeal)
+	require.NoError(t, err)
+	require.Len(t, types, 1)
+	assert.Equal(t, "Обращение", types[0].Name)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestReferenceRepository_CreateDocumentType(t *testing.T) {
-	// Типы документов заданы в коде и не редактируются.
+	// Тип создается вместе с набором видов и событием аудита в одной транзакции.
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
 	repo := NewReferenceRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	id := uuid.New()
+	now := time.Now()
+	kinds := []models.DocumentKind{models.DocumentKindIncomingLetter}
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "document_type:create", Payload: `{}`}
 
-	item, err := repo.CreateDocumentType("Справка")
-	require.Error(t, err)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO document_types`).WithArgs("Справка").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	mock.ExpectExec(`DELETE FROM document_type_kinds`).WithArgs(id, pq.Array([]string{"incoming_letter"})).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO document_type_kinds`).WithArgs(id, pq.Array([]string{"incoming_letter"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FROM document_types t\s+WHERE t.id = \$1`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(documentTypeColumns).AddRow(id, "Справка", true, now, now, "{incoming_letter}", 0))
+	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	item, err := repo.CreateDocumentTypeWithOutbox("Справка", kinds, []models.OutboxEvent{event})
+	require.NoError(t, err)
+	assert.Equal(t, id, item.ID)
+	assert.Equal(t, kinds, item.Kinds)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReferenceRepository_CreateDocumentTypeRejectsDuplicateName(t *testing.T) {
+	// Название типа уникально без учета регистра.
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewReferenceRepository(&database.DB{DB: db})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO document_types`).WithArgs("письмо").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_document_types_name"})
+	mock.ExpectRollback()
+
+	item, err := repo.CreateDocumentType("письмо", []models.DocumentKind{models.DocumentKindIncomingLetter})
 	assert.Nil(t, item)
-	assert.Contains(t, err.Error(), "не редактируются")
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "CONFLICT", appErr.Kind)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestReferenceRepository_UpdateDocumentType(t *testing.T) {
-	// Типы документов заданы в коде и не редактируются.
+	// Вид нельзя исключить из набора, пока есть документы этого вида с данным типом.
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
@@ -55,14 +126,43 @@ func TestReferenceRepository_UpdateDocumentType(t *testing.T) {
 	repo := NewReferenceRepository(&database.DB{DB: db})
 	id := uuid.New()
 
-	err = repo.UpdateDocumentType(id, "Новое Имя")
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "не редактируются")
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE document_types SET`).WithArgs(id, "Письмо", false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM document_type_kinds`).WithArgs(id, pq.Array([]string{"outgoing_letter"})).
+		WillReturnError(&pq.Error{Code: "23503", Constraint: "documents_document_type_kind_fkey"})
+	mock.ExpectRollback()
+
+	item, err := repo.UpdateDocumentType(id, "Письмо", []models.DocumentKind{models.DocumentKindOutgoingLetter}, false)
+	assert.Nil(t, item)
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "CONFLICT", appErr.Kind)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReferenceRepository_UpdateDocumentTypeNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewReferenceRepository(&database.DB{DB: db})
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE document_types SET`).WithArgs(id, "Справка", true).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err = repo.UpdateDocumentType(id, "Справка", []models.DocumentKind{models.DocumentKindIncomingLetter}, true)
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "NOT_FOUND", appErr.Kind)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestReferenceRepository_DeleteDocumentType(t *testing.T) {
-	// Типы документов заданы в коде и не редактируются.
+	// Используемый тип не удаляется: его можно только деактивировать.
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
@@ -70,9 +170,16 @@ func TestReferenceRepository_DeleteDocumentType(t *testing.T) {
 	repo := NewReferenceRepository(&database.DB{DB: db})
 	id := uuid.New()
 
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM document_types WHERE id = \$1`).WithArgs(id).
+		WillReturnError(&pq.Error{Code: "23503", Constraint: "documents_document_type_id_fkey"})
+	mock.ExpectRollback()
+
 	err = repo.DeleteDocumentType(id)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "не редактируются")
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "CONFLICT", appErr.Kind)
+	assert.Contains(t, appErr.Message, "деактивировать")
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
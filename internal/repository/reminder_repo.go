@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// ReminderRepository выбирает поручения и ознакомления для планировщика
+// напоминаний и хранит отключенные пользователями виды напоминаний.
+type ReminderRepository struct {
+	db     *database.DB
+	outbox *OutboxRepository
+}
+
+// NewReminderRepository создает новый экземпляр ReminderRepository.
+func NewReminderRepository(db *database.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+func (r *ReminderRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
+
+// GetAssignmentReminderCandidates возвращает открытые поручения со сроком не
+// позже until вместе с автором и руководителем подразделения исполнителя.
+// Автор старого подпоручения — исполнитель родительского поручения;
+// неактивные автор, руководитель и соисполнители не возвращаются.
+func (r *ReminderRepository) GetAssignmentReminderCandidates(until time.Time) ([]models.AssignmentReminderCandidate, error) {
+	rows, err := r.db.Query(`
+		SELECT a.id, a.document_id, d.kind, COALESCE(d.registration_number, ''),
+			a.executor_id, COALESCE(u.full_name, ''), u.is_active, a.deadline,
+			creator.id, head.id,
+			ARRAY(
+				SELECT ce.user_id::text
+				FROM assignment_co_executors ce
+				JOIN users cu ON cu.id = ce.user_id AND cu.is_active = true
+				WHERE ce.assignment_id = a.id
+				ORDER BY ce.user_id
+			)
+		FROM assignments a
+		JOIN documents d ON d.id = a.document_id
+		JOIN users u ON u.id = a.executor_id
+		LEFT JOIN assignments p ON p.id = a.parent_id
+		LEFT JOIN users creator ON creator.id = COALESCE(a.created_by, p.executor_id) AND creator.is_active = true
+		LEFT JOIN departments dep ON dep.id = u.department_id
+		LEFT JOIN users head ON head.id = dep.head_user_id AND head.is_active = true
+		WHERE a.status IN ('new', 'in_progress', 'returned')
+		  AND a.deadline IS NOT NULL
+		  AND a.deadline <= $1::date
+		ORDER BY a.deadline, a.id
+	`, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment reminder candidates: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.AssignmentReminderCandidate, 0)
+	for rows.Next() {
+		var item models.AssignmentReminderCandidate
+		var createdBy, headID uuid.NullUUID
+		var coExecutorIDs pq.StringArray
+		if err := rows.Scan(
+			&item.ID, &item.DocumentID, &item.DocumentKind, &item.DocumentNumber,
+			&item.ExecutorID, &item.ExecutorName, &item.ExecutorActive, &item.Deadline,
+			&createdBy, &headID, &coExecutorIDs,
+		); err != nil {
+			return nil, err
+		}
+		if createdBy.Valid {
+			item.CreatedBy = &createdBy.UUID
+		}
+		if headID.Valid {
+			item.DepartmentHeadID = &headID.UUID
+		}
+		for _, value := range coExecutorIDs {
+			if id, err := uuid.Parse(value); err == nil {
+				item.CoExecutorIDs = append(item.CoExecutorIDs, id)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetPendingAcknowledgmentReminders возвращает неподтвержденные ознакомления
+// активных пользователей по незавершенным листам ознакомления.
+func (r *ReminderRepository) GetPendingAcknowledgmentReminders() ([]models.AcknowledgmentReminderCandidate, error) {
+	rows, err := r.db.Query(`
+		SELECT ak.id, ak.document_id, d.kind, COALESCE(d.registration_number, ''), au.user_id, ak.created_at
+		FROM acknowledgment_users au
+		JOIN acknowledgments ak ON ak.id = au.acknowledgment_id
+		JOIN documents d ON d.id = ak.document_id
+		JOIN users u ON u.id = au.user_id
+		WHERE au.confirmed_at IS NULL
+		  AND ak.completed_at IS NULL
+		  AND u.is_active = true
+		ORDER BY ak.created_at, ak.id, au.user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending acknowledgment reminders: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.AcknowledgmentReminderCandidate, 0)
+	for rows.Next() {
+		var item models.AcknowledgmentReminderCandidate
+		if err := rows.Scan(&item.AcknowledgmentID, &item.DocumentID, &item.DocumentKind, &item.DocumentNumber, &item.UserID, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetReminderMutes возвращает отключенные виды напоминаний всех пользователей.
+func (r *ReminderRepository) GetReminderMutes() (map[uuid.UUID]map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT user_id, reminder_kind FROM user_reminder_mutes`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminder mutes: %w", err)
+	}
+	defer rows.Close()
+	return scanReminderMutes(rows)
+}
+
+// GetUserReminderMutes возвращает отключенные пользователем виды напоминаний.
+func (r *ReminderRepository) GetUserReminderMutes(userID uuid.UUID) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT user_id, reminder_kind FROM user_reminder_mutes WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user reminder mutes: %w", err)
+	}
+	defer rows.Close()
+	mutes, err := scanReminderMutes(rows)
+	if err != nil {
+		return nil, err
+	}
+	if kinds, ok := mutes[userID]; ok {
+		return kinds, nil
+	}
+	return map[string]bool{}, nil
+}
+
+// SetReminderMuted отключает или снова включает вид напоминаний пользователя.
+func (r *ReminderRepository) SetReminderMuted(userID uuid.UUID, kind string, muted bool) error {
+	var err error
+	if muted {
+		_, err = r.db.Exec(`INSERT INTO user_reminder_mutes (user_id, reminder_kind) VALUES ($1, $2) ON CONFLICT (user_id, reminder_kind) DO NOTHING`, userID, kind)
+	} else {
+		_, err = r.db.Exec(`DELETE FROM user_reminder_mutes WHERE user_id = $1 AND reminder_kind = $2`, userID, kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update reminder mute: %w", err)
+	}
+	return nil
+}
+
+// EnqueueReminders ставит в outbox еще не отправленные напоминания.
+func (r *ReminderRepository) EnqueueReminders(effects []models.OutboxEvent) (int, error) {
+	if r.outbox == nil {
+		return 0, ErrOutboxNotConfigured
+	}
+	return r.outbox.EnqueueIfAbsent(effects)
+}
+
+func scanReminderMutes(rows *sql.Rows) (map[uuid.UUID]map[string]bool, error) {
+	mutes := make(map[uuid.UUID]map[string]bool)
+	for rows.Next() {
+		var userID uuid.UUID
+		var kind string
+		if err := rows.Scan(&userID, &kind); err != nil {
+			return nil, err
+		}
+		if mutes[userID] == nil {
+			mutes[userID] = make(map[string]bool)
+		}
+		mutes[userID][kind] = true
+	}
+	return mutes, rows.Err()
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestReminderRepository_GetAssignmentReminderCandidates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewReminderRepository(&database.DB{DB: db})
+	until := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+	assignmentID, documentID, executorID, creatorID, coExecutorID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	deadline := time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`FROM assignments a(.*)LEFT JOIN users creator ON creator.id = COALESCE\(a.created_by, p.executor_id\)(.*)WHERE a.status IN \('new', 'in_progress', 'returned'\)(.*)AND a.deadline <= \$1::date`).
+		WithArgs(until).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "kind", "number", "executor_id", "full_name", "is_active", "deadline", "creator", "head", "co_executors"}).
+			AddRow(assignmentID, documentID, "incoming_letter", "ВХ-1", executorID, "Петров", true, deadline, creatorID, nil, "{"+coExecutorID.String()+"}"))
+
+	items, err := repo.GetAssignmentReminderCandidates(until)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, executorID, items[0].ExecutorID)
+	assert.True(t, items[0].ExecutorActive)
+	require.NotNil(t, items[0].CreatedBy)
+	assert.Equal(t, creatorID, *items[0].CreatedBy)
+	assert.Nil(t, items[0].DepartmentHeadID)
+	assert.Equal(t, []uuid.UUID{coExecutorID}, items[0].CoExecutorIDs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReminderRepository_GetPendingAcknowledgmentReminders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewReminderRepository(&database.DB{DB: db})
+	ackID, userID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM acknowledgment_users au(.*)WHERE au.confirmed_at IS NULL(.*)AND ak.completed_at IS NULL(.*)AND u.is_active = true`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "kind", "number", "user_id", "created_at"}).
+			AddRow(ackID, uuid.New(), "outgoing_letter", "ИСХ-3", userID, now))
+
+	items, err := repo.GetPendingAcknowledgmentReminders()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, ackID, items[0].AcknowledgmentID)
+	assert.Equal(t, userID, items[0].UserID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReminderRepository_Mutes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewReminderRepository(&database.DB{DB: db})
+	userID := uuid.New()
+
+	mock.ExpectExec(`INSERT INTO user_reminder_mutes \(user_id, reminder_kind\) VALUES \(\$1, \$2\) ON CONFLICT`).
+		WithArgs(userID, models.ReminderKindAssignmentOverdue).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SetReminderMuted(userID, models.ReminderKindAssignmentOverdue, true))
+
+	mock.ExpectExec(`DELETE FROM user_reminder_mutes WHERE user_id = \$1 AND reminder_kind = \$2`).
+		WithArgs(userID, models.ReminderKindAcknowledgment).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SetReminderMuted(userID, models.ReminderKindAcknowledgment, false))
+
+	mock.ExpectQuery(`SELECT user_id, reminder_kind FROM user_reminder_mutes WHERE user_id = \$1`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "reminder_kind"}).AddRow(userID, models.ReminderKindAssignmentOverdue))
+	mutes, err := repo.GetUserReminderMutes(userID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{models.ReminderKindAssignmentOverdue: true}, mutes)
+
+	mock.ExpectQuery(`SELECT user_id, reminder_kind FROM user_reminder_mutes$`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "reminder_kind"}))
+	all, err := repo.GetReminderMutes()
+	require.NoError(t, err)
+	assert.Empty(t, all)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReminderRepository_EnqueueRemindersRequiresOutbox(t *testing.T) {
+	repo := NewReminderRepository(nil)
+	_, err := repo.EnqueueReminders([]models.OutboxEvent{{EventType: models.OutboxEventUserEvent, DeduplicationKey: "k", Payload: "{}"}})
+	require.ErrorIs(t, err, ErrOutboxNotConfigured)
+}
@@ -62,6 +62,8 @@ func (r *SettingsRepository) Update(key, value string) error {
 	return err
 }
 
+const upsertSettingQuery = `INSERT INTO system_settings (key, value, updated_at) VALUES ($1, $2, NOW()) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()`
+
 // UpdateWithOutbox records a setting change and its audit event atomically.
 func (r *SettingsRepository) UpdateWithOutbox(key, value string, effects []models.OutboxEvent) error {
 	tx, err := r.db.Begin()
@@ -69,7 +71,33 @@ func (r *SettingsRepository) UpdateWithOutbox(key, value string, effects []model
 		return err
 	}
 	defer tx.Rollback()
-	if _, err := tx.Exec(`INSERT INTO system_settings (key, value, updated_at) VALUES ($1, $2, NOW()) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()`, key, value); err != nil {
+	if _, err := tx.Exec(upsertSettingQuery, key, value); err != nil {
+		return err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdatePasswordPolicyWithOutbox сохраняет ужесточение политики паролей и
+// в той же транзакции требует смены пароля у локальных пользователей:
+// соответствие прежних паролей новой политике проверить нельзя, так как
+// пароли и их характеристики не хранятся.
+func (r *SettingsRepository) UpdatePasswordPolicyWithOutbox(key, value string, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(upsertSettingQuery, key, value); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE users
+		SET password_change_required = true, updated_at = NOW()
+		WHERE auth_source = 'local' AND password_change_required = false
+	`); err != nil {
 		return err
 	}
 	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
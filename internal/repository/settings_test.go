@@ -105,3 +105,22 @@ func TestSettingsRepositoryUpdateWithOutboxRollsBackOnEnqueueFailure(t *testing.
 	require.ErrorIs(t, repo.UpdateWithOutbox("test_key", "new_val", []models.OutboxEvent{event}), assert.AnError)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestSettingsRepositoryUpdatePasswordPolicyWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewSettingsRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "setting:password_min_length:update", Payload: `{}`}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO system_settings`).WithArgs(models.SettingPasswordMinLength, "12").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE users\s+SET password_change_required = true, updated_at = NOW\(\)\s+WHERE auth_source = 'local' AND password_change_required = false`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.UpdatePasswordPolicyWithOutbox(models.SettingPasswordMinLength, "12", []models.OutboxEvent{event}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -29,6 +29,32 @@ const assignmentViolationDateExpr = `
 	END
 `
 
+// assignmentDelegatedDelayCondition отмечает просрочку, вызванную подпоручением:
+// к сроку поручения не было исполнено хотя бы одно из его подпоручений. Такая
+// просрочка засчитывается исполнителю подпоручения (срок подпоручения не позже
+// срока родителя, значит оно просрочено само), а не исполнителю родителя.
+const assignmentDelegatedDelayCondition = `
+	EXISTS (
+		SELECT 1
+		FROM assignments c
+		WHERE c.parent_id = a.id
+		  AND c.status <> 'cancelled'
+		  AND (c.completed_at IS NULL OR c.completed_at::date > a.deadline)
+	)
+`
+
+// assignmentDeadlineConditions возвращает условие просрочки, дату нарушения и
+// условие делегированной просрочки для выбранного базиса срока. Базис original
+// считает просрочку от срока до первого одобренного продления.
+func assignmentDeadlineConditions(deadlineBasis string) (overdue, violationDate, delegatedDelay string) {
+	overdue, violationDate, delegatedDelay = assignmentOverdueCondition, assignmentViolationDateExpr, assignmentDelegatedDelayCondition
+	if deadlineBasis != models.AssignmentDeadlineBasisOriginal {
+		return overdue, violationDate, delegatedDelay
+	}
+	deadline := strings.NewReplacer("a.deadline", "COALESCE(a.original_deadline, a.deadline)")
+	return deadline.Replace(overdue), deadline.Replace(violationDate), deadline.Replace(delegatedDelay)
+}
+
 // StatisticsRepository предоставляет запросы для раздела статистики.
 type StatisticsRepository struct {
 	db *database.DB
@@ -134,6 +160,8 @@ func (r *StatisticsRepository) GetDocumentReport(startDate, endDate time.Time, g
 		selectExpr = "d.created_by::text AS key, COALESCE(NULLIF(u.full_name, ''), u.login, d.created_by::text) AS name"
 		joinClause = "LEFT JOIN users u ON u.id = d.created_by"
 		groupExpr = "d.created_by, u.full_name, u.login"
+	case "department":
+		// Накопительный отчет по дереву подразделений строится отдельным запросом.
 	default:
 		return nil, fmt.Errorf("unsupported document report group: %s", groupBy)
 	}
@@ -157,6 +185,10 @@ func (r *StatisticsRepository) GetDocumentReport(startDate, endDate time.Time, g
 		args = append(args, userID)
 	}
 
+	if groupBy == "department" {
+		return r.getDocumentReportByDepartment(strings.Join(where, " AND "), args)
+	}
+
 	query := fmt.Sprintf(`
 		SELECT %s, COUNT(*) AS count
 		FROM documents d
@@ -175,6 +207,59 @@ func (r *StatisticsRepository) GetDocumentReport(startDate, endDate time.Time, g
 	return scanReportRows(rows)
 }
 
+// getDocumentReportByDepartment группирует документы по подразделению регистратора
+// с накоплением вверх по дереву: строка подразделения учитывает документы всех его
+// подчиненных подразделений. Название строки — полный путь в дереве; документы
+// регистраторов без подразделения попадают в строку с пустым ключом.
+func (r *StatisticsRepository) getDocumentReportByDepartment(where string, args []any) ([]models.StatisticsReportRow, error) {
+	query := fmt.Sprintf(`
+		WITH RECURSIVE subtree AS (
+			SELECT id AS ancestor_id, id AS department_id FROM departments
+			UNION
+			SELECT s.ancestor_id, d.id
+			FROM subtree s
+			JOIN departments d ON d.parent_id = s.department_id
+		),
+		paths AS (
+			SELECT id, parent_id, name::text AS path FROM departments WHERE parent_id IS NULL
+			UNION ALL
+			SELECT d.id, d.parent_id, p.path || ' / ' || d.name
+			FROM departments d
+			JOIN paths p ON d.parent_id = p.id
+		)
+		SELECT COALESCE(s.ancestor_id::text, '') AS key,
+		       COALESCE(p.path, 'Без подразделения') AS name,
+		       COUNT(*) AS count,
+		       COALESCE(p.parent_id::text, '') AS parent_key
+		FROM documents d
+		LEFT JOIN users u ON u.id = d.created_by
+		LEFT JOIN subtree s ON s.department_id = u.department_id
+		LEFT JOIN paths p ON p.id = s.ancestor_id
+		WHERE %s
+		GROUP BY s.ancestor_id, p.path, p.parent_id
+		ORDER BY name
+	`, where)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document report: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.StatisticsReportRow, 0)
+	for rows.Next() {
+		var row models.StatisticsReportRow
+		if err := rows.Scan(&row.Key, &row.Name, &row.Count, &row.ParentKey); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // GetNomenclatureOptions возвращает список номенклатур для фильтров статистики.
 func (r *StatisticsRepository) GetNomenclatureOptions() ([]models.StatisticsOption, error) {
 	rows, err := r.db.Query(`
@@ -219,6 +304,7 @@ func (r *StatisticsRepository) GetAssignmentMonthlyOverview(yearStart, yearEnd t
 			FROM (
 				SELECT %s AS violation_date
 				FROM assignments a
+				WHERE NOT %s
 			) v
 			WHERE violation_date >= $1::date AND violation_date < $2::date
 			GROUP BY month
@@ -228,7 +314,7 @@ func (r *StatisticsRepository) GetAssignmentMonthlyOverview(yearStart, yearEnd t
 		LEFT JOIN totals t ON t.month = m.month
 		LEFT JOIN overdue o ON o.month = m.month
 		ORDER BY m.month
-	`, assignmentViolationDateExpr), yearStart, yearEnd)
+	`, assignmentViolationDateExpr, assignmentDelegatedDelayCondition), yearStart, yearEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get assignment monthly overview: %w", err)
 	}
@@ -282,7 +368,10 @@ func (r *StatisticsRepository) GetAssignmentMonthlyByExecutor(yearStart, yearEnd
 }
 
 // GetAssignmentOverdueRating возвращает рейтинг основных исполнителей по нарушениям сроков.
-func (r *StatisticsRepository) GetAssignmentOverdueRating(yearStart, yearEnd time.Time) ([]models.StatisticsReportRow, error) {
+// Просрочка из-за подпоручения засчитывается исполнителю подпоручения.
+// deadlineBasis выбирает срок: с учетом продлений (current) или первоначальный (original).
+func (r *StatisticsRepository) GetAssignmentOverdueRating(yearStart, yearEnd time.Time, deadlineBasis string) ([]models.StatisticsReportRow, error) {
+	overdue, violationDate, delegatedDelay := assignmentDeadlineConditions(deadlineBasis)
 	rows, err := r.db.Query(fmt.Sprintf(`
 		SELECT
 			a.executor_id::text AS key,
@@ -291,11 +380,12 @@ func (r *StatisticsRepository) GetAssignmentOverdueRating(yearStart, yearEnd tim
 		FROM assignments a
 		LEFT JOIN users u ON u.id = a.executor_id
 		WHERE %s
+		  AND NOT %s
 		  AND (%s) >= $1::date
 		  AND (%s) < $2::date
 		GROUP BY a.executor_id, u.full_name, u.login
 		ORDER BY count DESC, name
-	`, assignmentOverdueCondition, assignmentViolationDateExpr, assignmentViolationDateExpr), yearStart, yearEnd)
+	`, overdue, delegatedDelay, violationDate, violationDate), yearStart, yearEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get assignment overdue rating: %w", err)
 	}
@@ -304,6 +394,33 @@ func (r *StatisticsRepository) GetAssignmentOverdueRating(yearStart, yearEnd tim
 	return scanReportRows(rows)
 }
 
+// GetAssignmentOverdueByLevel возвращает нарушения сроков по уровням иерархии:
+// 1 — поручения по документу, 2 и далее — подпоручения. Ключ строки — номер уровня.
+func (r *StatisticsRepository) GetAssignmentOverdueByLevel(yearStart, yearEnd time.Time) ([]models.StatisticsReportRow, error) {
+	rows, err := r.db.Query(fmt.Sprintf(`
+		WITH RECURSIVE levels AS (
+			SELECT id, 1 AS level FROM assignments WHERE parent_id IS NULL
+			UNION ALL
+			SELECT c.id, l.level + 1 FROM assignments c JOIN levels l ON c.parent_id = l.id
+		)
+		SELECT l.level::text AS key, l.level::text AS name, COUNT(*) AS count
+		FROM assignments a
+		JOIN levels l ON l.id = a.id
+		WHERE %s
+		  AND NOT %s
+		  AND (%s) >= $1::date
+		  AND (%s) < $2::date
+		GROUP BY l.level
+		ORDER BY l.level
+	`, assignmentOverdueCondition, assignmentDelegatedDelayCondition, assignmentViolationDateExpr, assignmentViolationDateExpr), yearStart, yearEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment overdue by level: %w", err)
+	}
+	defer rows.Close()
+
+	return scanReportRows(rows)
+}
+
 // GetAssignmentStatusCounts возвращает количество поручений по статусам.
 func (r *StatisticsRepository) GetAssignmentStatusCounts() ([]models.StatisticsReportRow, error) {
 	rows, err := r.db.Query(`
@@ -329,6 +446,7 @@ func (r *StatisticsRepository) GetAssignmentReport(startDate, endDate time.Time,
 	if onlyOverdue {
 		where = append(where,
 			assignmentOverdueCondition,
+			"NOT "+assignmentDelegatedDelayCondition,
 			fmt.Sprintf("(%s) >= $1::date", assignmentViolationDateExpr),
 			fmt.Sprintf("(%s) <= $2::date", assignmentViolationDateExpr),
 		)
@@ -360,6 +478,51 @@ func (r *StatisticsRepository) GetAssignmentReport(startDate, endDate time.Time,
 	return scanReportRows(rows)
 }
 
+// GetAssignmentSeriesStatistics возвращает исполнение поручений по сериям:
+// учитываются поручения, срок которых по расписанию серии попадает в период.
+func (r *StatisticsRepository) GetAssignmentSeriesStatistics(startDate, endDate time.Time) ([]models.AssignmentSeriesStatisticsRow, error) {
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT
+			s.id::text, s.document_id::text, d.kind, COALESCE(d.registration_number, ''),
+			COALESCE(NULLIF(u.full_name, ''), u.login, ''), s.content,
+			s.schedule_kind, s.cron_expression, s.working_day, s.month_interval, s.status,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE a.status IN ('completed', 'finished')) AS completed,
+			COUNT(*) FILTER (WHERE a.status NOT IN ('completed', 'finished', 'cancelled')) AS open,
+			COUNT(*) FILTER (WHERE %s) AS overdue
+		FROM assignment_series s
+		JOIN assignments a ON a.series_id = s.id
+		JOIN documents d ON d.id = s.document_id
+		LEFT JOIN users u ON u.id = s.executor_id
+		WHERE a.series_deadline >= $1::date
+		  AND a.series_deadline <= $2::date
+		GROUP BY s.id, d.kind, d.registration_number, u.full_name, u.login
+		ORDER BY overdue DESC, total DESC, s.created_at
+	`, assignmentOverdueCondition), startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment series statistics: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.AssignmentSeriesStatisticsRow, 0)
+	for rows.Next() {
+		var row models.AssignmentSeriesStatisticsRow
+		if err := rows.Scan(
+			&row.SeriesID, &row.DocumentID, &row.DocumentKind, &row.DocumentNumber,
+			&row.ExecutorName, &row.Content,
+			&row.Schedule.Kind, &row.Schedule.Cron, &row.Schedule.WorkingDay, &row.Schedule.MonthInterval, &row.Status,
+			&row.Total, &row.Completed, &row.Open, &row.Overdue,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // GetSystemUserCount возвращает общее количество пользователей.
 func (r *StatisticsRepository) GetSystemUserCount() (int, error) {
 	var count int
@@ -471,6 +634,30 @@ func (r *StatisticsRepository) ReleaseStorageStatisticsRefresh(token uuid.UUID)
 	return nil
 }
 
+// GetAttachmentIntegritySummary возвращает число вложений по статусам проверки целостности.
+func (r *StatisticsRepository) GetAttachmentIntegritySummary() (models.AttachmentIntegritySummary, error) {
+	var summary models.AttachmentIntegritySummary
+	var lastCheckedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT
+			COUNT(*) FILTER (WHERE integrity_status = 'unverified'),
+			COUNT(*) FILTER (WHERE integrity_status = 'ok'),
+			COUNT(*) FILTER (WHERE integrity_status = 'corrupted'),
+			COUNT(*) FILTER (WHERE integrity_status = 'truncated'),
+			COUNT(*) FILTER (WHERE integrity_status = 'missing'),
+			MAX(integrity_checked_at)
+		FROM attachments
+		WHERE deletion_requested_at IS NULL
+	`).Scan(&summary.Unverified, &summary.OK, &summary.Corrupted, &summary.Truncated, &summary.Missing, &lastCheckedAt)
+	if err != nil {
+		return models.AttachmentIntegritySummary{}, fmt.Errorf("failed to get attachment integrity summary: %w", err)
+	}
+	if lastCheckedAt.Valid {
+		summary.LastCheckedAt = &lastCheckedAt.Time
+	}
+	return summary, nil
+}
+
 func scanOptions(rows *sql.Rows) ([]models.StatisticsOption, error) {
 	options := make([]models.StatisticsOption, 0)
 	for rows.Next() {
@@ -148,6 +148,23 @@ func TestStatisticsRepository_GetDocumentReport(t *testing.T) {
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("department rolls up subtree", func(t *testing.T) {
+		mock.ExpectQuery(`WITH RECURSIVE subtree AS \(.*JOIN departments d ON d\.parent_id = s\.department_id.*paths AS \(.*LEFT JOIN subtree s ON s\.department_id = u\.department_id.*WHERE d\.registration_date >= \$1::date AND d\.registration_date <= \$2::date AND d\.created_by = \$3::uuid\s+GROUP BY s\.ancestor_id, p\.path, p\.parent_id`).
+			WithArgs(start, end, userID).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "count", "parent_key"}).
+				AddRow("d1", "Управление", 4, "").
+				AddRow("d2", "Управление / Отдел", 3, "d1"))
+
+		rows, err := repo.GetDocumentReport(start, end, "department", "", "", userID)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.StatisticsReportRow{
+			{Key: "d1", Name: "Управление", Count: 4},
+			{Key: "d2", Name: "Управление / Отдел", Count: 3, ParentKey: "d1"},
+		}, rows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("unsupported group", func(t *testing.T) {
 		rows, err := repo.GetDocumentReport(start, end, "unknown", "", "", "")
 
@@ -246,13 +263,52 @@ func TestStatisticsRepository_AssignmentStatistics(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "count"}).
 				AddRow("user-1", "Исполнитель", 2))
 
-		rows, err := repo.GetAssignmentOverdueRating(start, end)
+		rows, err := repo.GetAssignmentOverdueRating(start, end, models.AssignmentDeadlineBasisCurrent)
 
 		require.NoError(t, err)
 		assert.Equal(t, []models.StatisticsReportRow{{Key: "user-1", Name: "Исполнитель", Count: 2}}, rows)
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("overdue rating excludes delays caused by subtasks", func(t *testing.T) {
+		mock.ExpectQuery(`a\.executor_id::text AS key(.*)AND NOT\s+EXISTS \(\s*SELECT 1\s+FROM assignments c\s+WHERE c\.parent_id = a\.id`).
+			WithArgs(start, end).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "count"}))
+
+		rows, err := repo.GetAssignmentOverdueRating(start, end, models.AssignmentDeadlineBasisCurrent)
+
+		require.NoError(t, err)
+		assert.Empty(t, rows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("overdue rating against original deadline", func(t *testing.T) {
+		mock.ExpectQuery(`COALESCE\(a\.original_deadline, a\.deadline\) IS NOT NULL(.*)a\.executor_id`).
+			WithArgs(start, end).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "count"}).
+				AddRow("user-1", "Исполнитель", 3))
+
+		rows, err := repo.GetAssignmentOverdueRating(start, end, models.AssignmentDeadlineBasisOriginal)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.StatisticsReportRow{{Key: "user-1", Name: "Исполнитель", Count: 3}}, rows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("overdue by level", func(t *testing.T) {
+		mock.ExpectQuery(`WITH RECURSIVE levels AS(.*)GROUP BY l\.level`).
+			WithArgs(start, end).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "count"}).
+				AddRow("1", "1", 4).
+				AddRow("2", "2", 1))
+
+		rows, err := repo.GetAssignmentOverdueByLevel(start, end)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.StatisticsReportRow{{Key: "1", Name: "1", Count: 4}, {Key: "2", Name: "2", Count: 1}}, rows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("status counts", func(t *testing.T) {
 		mock.ExpectQuery(`SELECT status AS key, status AS name, COUNT\(\*\) AS count\s+FROM assignments`).
 			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "count"}).
@@ -292,6 +348,24 @@ func TestStatisticsRepository_AssignmentStatistics(t *testing.T) {
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("series report", func(t *testing.T) {
+		seriesID, documentID := "2f0e3c9c-6f0b-4b55-9a1d-8f2a6e0c1b11", "5b0c3c9c-6f0b-4b55-9a1d-8f2a6e0c1b22"
+		mock.ExpectQuery(`FROM assignment_series s\s+JOIN assignments a ON a\.series_id = s\.id(.*)a\.series_deadline >= \$1::date`).
+			WithArgs(start, end).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "document_id", "kind", "registration_number", "executor", "content", "schedule_kind", "cron_expression", "working_day", "month_interval", "status", "total", "completed", "open", "overdue"}).
+				AddRow(seriesID, documentID, "incoming_letter", "ВХ-7", "Петров", "Отчет", "working_day", "", 5, 1, "active", 4, 3, 1, 1))
+
+		rows, err := repo.GetAssignmentSeriesStatistics(start, end)
+
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, models.AssignmentSchedule{Kind: "working_day", WorkingDay: 5, MonthInterval: 1}, rows[0].Schedule)
+		assert.Equal(t, 4, rows[0].Total)
+		assert.Equal(t, 3, rows[0].Completed)
+		assert.Equal(t, 1, rows[0].Overdue)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("status scan error", func(t *testing.T) {
 		mock.ExpectQuery(`SELECT status AS key, status AS name, COUNT\(\*\) AS count\s+FROM assignments`).
 			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "count"}).
@@ -331,3 +405,22 @@ func TestStatisticsRepository_SystemCountsAndDBSize(t *testing.T) {
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestStatisticsRepository_GetAttachmentIntegritySummary(t *testing.T) {
+	repo, mock, cleanup := setupStatisticsRepository(t)
+	defer cleanup()
+	checkedAt := time.Now()
+
+	mock.ExpectQuery(`FILTER \(WHERE integrity_status = 'corrupted'\)(.|\n)*FROM attachments\s+WHERE deletion_requested_at IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"unverified", "ok", "corrupted", "truncated", "missing", "max"}).
+			AddRow(2, 40, 1, 0, 3, checkedAt))
+
+	summary, err := repo.GetAttachmentIntegritySummary()
+	require.NoError(t, err)
+	assert.Equal(t, 40, summary.OK)
+	assert.Equal(t, 1, summary.Corrupted)
+	assert.Equal(t, 3, summary.Missing)
+	require.NotNil(t, summary.LastCheckedAt)
+	assert.Equal(t, checkedAt, *summary.LastCheckedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// SecretCipher шифрует секреты перед записью в БД и расшифровывает при чтении.
+type SecretCipher interface {
+	Encrypt(value string) (string, error)
+	Decrypt(value string) (string, error)
+}
+
+// TwoFactorRepository хранит секреты TOTP и хеши кодов восстановления.
+type TwoFactorRepository struct {
+	db     *database.DB
+	outbox *OutboxRepository
+	cipher SecretCipher
+}
+
+func (r *TwoFactorRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
+
+// SetSecretCipher подключает шифрование секретов TOTP. Без него подключение
+// второго фактора отклоняется, чтобы секреты не хранились в открытом виде.
+func (r *TwoFactorRepository) SetSecretCipher(cipher SecretCipher) { r.cipher = cipher }
+
+// NewTwoFactorRepository создает репозиторий второго фактора.
+func NewTwoFactorRepository(db *database.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// GetTOTP возвращает секрет TOTP пользователя или nil, если он не создавался.
+func (r *TwoFactorRepository) GetTOTP(userID uuid.UUID) (*models.UserTOTP, error) {
+	item := models.UserTOTP{UserID: userID}
+	var enabledAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT secret, enabled_at, last_used_step
+		FROM user_totp
+		WHERE user_id = $1
+	`, userID).Scan(&item.Secret, &enabledAt, &item.LastUsedStep)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user TOTP: %w", err)
+	}
+	if enabledAt.Valid {
+		item.EnabledAt = &enabledAt.Time
+	}
+	if r.cipher != nil {
+		if item.Secret, err = r.cipher.Decrypt(item.Secret); err != nil {
+			return nil, models.NewTwoFactorUnavailable(fmt.Errorf("failed to decrypt user TOTP secret: %w", err))
+		}
+	}
+	return &item, nil
+}
+
+// GetLoginsWithoutTOTP возвращает логины активных пользователей с любым из
+// системных прав permissions, у которых второй фактор не подключен.
+func (r *TwoFactorRepository) GetLoginsWithoutTOTP(permissions []string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT u.login
+		FROM users u
+		JOIN user_effective_system_permissions p ON p.user_id = u.id
+		LEFT JOIN user_totp t ON t.user_id = u.id AND t.enabled_at IS NOT NULL
+		WHERE u.is_active AND p.permission = ANY($1) AND t.user_id IS NULL
+		ORDER BY u.login
+	`, pq.Array(permissions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users without TOTP: %w", err)
+	}
+	defer rows.Close()
+
+	var logins []string
+	for rows.Next() {
+		var login string
+		if err := rows.Scan(&login); err != nil {
+			return nil, fmt.Errorf("failed to scan user without TOTP: %w", err)
+		}
+		logins = append(logins, login)
+	}
+	return logins, rows.Err()
+}
+
+// SavePendingTOTP сохраняет новый неподтвержденный секрет, заменяя прежний
+// неподтвержденный. Подключенный второй фактор не перезаписывается.
+func (r *TwoFactorRepository) SavePendingTOTP(userID uuid.UUID, secret string) error {
+	if r.cipher == nil {
+		return models.NewTwoFactorUnavailable(fmt.Errorf("TOTP secret cipher is not configured"))
+	}
+	secret, err := r.cipher.Encrypt(secret)
+	if err != nil {
+		return models.NewTwoFactorUnavailable(fmt.Errorf("failed to encrypt TOTP secret: %w", err))
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+		WHERE user_totp.enabled_at IS NULL
+	`, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save pending TOTP: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewConflict("двухфакторная аутентификация уже подключена")
+	}
+	return nil
+}
+
+// EnableTOTPWithOutbox подтверждает неподтвержденный секрет кодом интервала
+// step и заменяет коды восстановления хешами codeHashes.
+func (r *TwoFactorRepository) EnableTOTPWithOutbox(userID uuid.UUID, step int64, codeHashes []string, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE user_totp
+		SET enabled_at = CURRENT_TIMESTAMP, last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NULL AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewConflict("подключение двухфакторной аутентификации не начато или уже завершено")
+	}
+	if err := replaceRecoveryCodesTx(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit TOTP enrollment: %w", err)
+	}
+	return nil
+}
+
+// ConsumeTOTPStep отмечает интервал step использованным. Возвращает false,
+// если код этого или более позднего интервала уже принят: один код нельзя
+// использовать дважды, в том числе при параллельном входе.
+func (r *TwoFactorRepository) ConsumeTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume TOTP step: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ConsumeRecoveryCode гасит неиспользованный код восстановления с хешем
+// codeHash. Возвращает false, если такого кода нет или он уже использован.
+func (r *TwoFactorRepository) ConsumeRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// CountRecoveryCodes возвращает число неиспользованных кодов восстановления.
+func (r *TwoFactorRepository) CountRecoveryCodes(userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя.
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := replaceRecoveryCodesTx(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recovery codes: %w", err)
+	}
+	return nil
+}
+
+// DeleteWithOutbox отключает второй фактор пользователя: удаляет секрет и
+// коды восстановления. Возвращает false, если второй фактор не был
+// подключен; события effects в этом случае не записываются.
+func (r *TwoFactorRepository) DeleteWithOutbox(userID uuid.UUID, effects []models.OutboxEvent) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var enabledAt sql.NullTime
+	err = tx.QueryRow(`DELETE FROM user_totp WHERE user_id = $1 RETURNING enabled_at`, userID).Scan(&enabledAt)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to delete user TOTP: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return false, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if !enabledAt.Valid {
+		return false, tx.Commit()
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit two-factor reset: %w", err)
+	}
+	return true, nil
+}
+
+func replaceRecoveryCodesTx(tx *sql.Tx, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`
+			INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hash); err != nil {
+			return fmt.Errorf("failed to save recovery code: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestTwoFactorRepository_GetTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewTwoFactorRepository(&database.DB{DB: db})
+
+	userID := uuid.New()
+	enabledAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT secret, enabled_at, last_used_step\s+FROM user_totp\s+WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"secret", "enabled_at", "last_used_step"}).AddRow("JBSWY3DPEHPK3PXP", enabledAt, int64(42)))
+	mock.ExpectQuery(`FROM user_totp`).WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"secret", "enabled_at", "last_used_step"}))
+
+	factor, err := repo.GetTOTP(userID)
+	require.NoError(t, err)
+	assert.True(t, factor.IsEnabled())
+	assert.Equal(t, int64(42), factor.LastUsedStep)
+
+	factor, err = repo.GetTOTP(userID)
+	require.NoError(t, err)
+	assert.Nil(t, factor)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepository_SavePendingTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewTwoFactorRepository(&database.DB{DB: db})
+	repo.SetSecretCipher(prefixSecretCipher{})
+
+	userID := uuid.New()
+	mock.ExpectExec(`INSERT INTO user_totp \(user_id, secret\)\s+VALUES \(\$1, \$2\)\s+ON CONFLICT \(user_id\) DO UPDATE.*WHERE user_totp.enabled_at IS NULL`).
+		WithArgs(userID, "ENC:SECRET").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.SavePendingTOTP(userID, "SECRET")
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok, "enabled factor is not overwritten")
+	assert.Equal(t, 409, appErr.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepository_GetLoginsWithoutTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewTwoFactorRepository(&database.DB{DB: db})
+
+	mock.ExpectQuery(`SELECT DISTINCT u.login\s+FROM users u\s+JOIN user_effective_system_permissions p ON p.user_id = u.id\s+LEFT JOIN user_totp t ON t.user_id = u.id AND t.enabled_at IS NOT NULL\s+WHERE u.is_active AND p.permission = ANY\(\$1\) AND t.user_id IS NULL`).
+		WithArgs(pq.Array([]string{"admin"})).
+		WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("admin").AddRow("petrov"))
+
+	logins, err := repo.GetLoginsWithoutTOTP([]string{"admin"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "petrov"}, logins)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+type prefixSecretCipher struct{}
+
+func (prefixSecretCipher) Encrypt(value string) (string, error) { return "ENC:" + value, nil }
+
+func (prefixSecretCipher) Decrypt(value string) (string, error) {
+	return strings.TrimPrefix(value, "ENC:"), nil
+}
+
+func TestTwoFactorRepository_SecretCipher(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewTwoFactorRepository(&database.DB{DB: db})
+	repo.SetSecretCipher(prefixSecretCipher{})
+
+	userID := uuid.New()
+	mock.ExpectExec(`INSERT INTO user_totp`).
+		WithArgs(userID, "ENC:SECRET").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FROM user_totp`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"secret", "enabled_at", "last_used_step"}).AddRow("ENC:SECRET", nil, int64(0)))
+
+	require.NoError(t, repo.SavePendingTOTP(userID, "SECRET"))
+	factor, err := repo.GetTOTP(userID)
+	require.NoError(t, err)
+	assert.Equal(t, "SECRET", factor.Secret)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+type failingSecretCipher struct{}
+
+func (failingSecretCipher) Encrypt(string) (string, error) { return "", errors.New("no key") }
+
+func (failingSecretCipher) Decrypt(string) (string, error) { return "", errors.New("no key") }
+
+func TestTwoFactorRepository_SecretCipherUnavailable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewTwoFactorRepository(&database.DB{DB: db})
+	userID := uuid.New()
+
+	// Без шифрования секрет не сохраняется в открытом виде
+	err = repo.SavePendingTOTP(userID, "SECRET")
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "TWO_FACTOR_UNAVAILABLE", appErr.Kind)
+
+	repo.SetSecretCipher(failingSecretCipher{})
+	err = repo.SavePendingTOTP(userID, "SECRET")
+	appErr, ok = models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "TWO_FACTOR_UNAVAILABLE", appErr.Kind)
+
+	mock.ExpectQuery(`FROM user_totp`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"secret", "enabled_at", "last_used_step"}).AddRow("ENC:SECRET", time.Now(), int64(0)))
+	_, err = repo.GetTOTP(userID)
+	appErr, ok = models.AsAppError(err)
+	require.True(t, ok, "undecryptable secret is reported clearly")
+	assert.Equal(t, "TWO_FACTOR_UNAVAILABLE", appErr.Kind)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepository_EnableTOTPWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewTwoFactorRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+	userID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "user:2fa-enabled", Payload: `{}`}
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE user_totp\s+SET enabled_at = CURRENT_TIMESTAMP, last_used_step = \$2\s+WHERE user_id = \$1 AND enabled_at IS NULL AND last_used_step < \$2`).
+		WithArgs(userID, int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM user_recovery_codes WHERE user_id = \$1`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO user_recovery_codes \(user_id, code_hash\)`).WithArgs(userID, "hash-1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO user_recovery_codes \(user_id, code_hash\)`).WithArgs(userID, "hash-2").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.EnableTOTPWithOutbox(userID, 100, []string{"hash-1", "hash-2"}, []models.OutboxEvent{event}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepository_Consume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewTwoFactorRepository(&database.DB{DB: db})
+
+	userID := uuid.New()
+	mock.ExpectExec(`UPDATE user_totp\s+SET last_used_step = \$2\s+WHERE user_id = \$1 AND enabled_at IS NOT NULL AND last_used_step < \$2`).
+		WithArgs(userID, int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE user_recovery_codes\s+SET used_at = CURRENT_TIMESTAMP\s+WHERE user_id = \$1 AND code_hash = \$2 AND used_at IS NULL`).
+		WithArgs(userID, "hash-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	accepted, err := repo.ConsumeTOTPStep(userID, 100)
+	require.NoError(t, err)
+	assert.False(t, accepted, "step already used")
+
+	accepted, err = repo.ConsumeRecoveryCode(userID, "hash-1")
+	require.NoError(t, err)
+	assert.True(t, accepted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepository_DeleteWithOutbox(t *testing.T) {
+	userID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "user:2fa-reset", Payload: `{}`}
+
+	t.Run("enabled factor is reset with audit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewTwoFactorRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`DELETE FROM user_totp WHERE user_id = \$1 RETURNING enabled_at`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"enabled_at"}).AddRow(time.Now()))
+		mock.ExpectExec(`DELETE FROM user_recovery_codes WHERE user_id = \$1`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		reset, err := repo.DeleteWithOutbox(userID, []models.OutboxEvent{event})
+		require.NoError(t, err)
+		assert.True(t, reset)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("pending enrollment is discarded without audit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewTwoFactorRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`DELETE FROM user_totp`).WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"enabled_at"}).AddRow(nil))
+		mock.ExpectExec(`DELETE FROM user_recovery_codes`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		reset, err := repo.DeleteWithOutbox(userID, []models.OutboxEvent{event})
+		require.NoError(t, err)
+		assert.False(t, reset)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
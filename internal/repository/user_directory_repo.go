@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// directoryPasswordHash хранится в password_hash пользователей каталога. Это не
+// bcrypt-хэш, поэтому локальная проверка пароля для них всегда неуспешна.
+const directoryPasswordHash = "!directory"
+
+// SyncDirectoryUserWithOutbox создает или обновляет пользователя каталога по
+// внешнему идентификатору и заменяет его системные права набором из
+// сопоставления групп. createEffects ставятся в outbox только при создании.
+// Триггер «хотя бы один активный администратор» проверяется при commit, и его
+// ошибка возвращается обернутой без изменений.
+func (r *UserRepository) SyncDirectoryUserWithOutbox(sync models.DirectoryUserSync, createEffects []models.OutboxEvent) (*models.User, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	var created bool
+	err = tx.QueryRow(`
+		INSERT INTO users (login, password_hash, full_name, department_id, is_document_participant,
+		                   password_changed_at, password_change_required, auth_source, external_id, directory_synced_at)
+		VALUES ($1, $2, $3, $4, $5, NULL, false, $6, $7, CURRENT_TIMESTAMP)
+		ON CONFLICT (auth_source, external_id) WHERE external_id IS NOT NULL DO UPDATE
+		SET login = EXCLUDED.login,
+		    full_name = EXCLUDED.full_name,
+		    department_id = COALESCE(EXCLUDED.department_id, users.department_id),
+		    is_document_participant = EXCLUDED.is_document_participant,
+		    directory_synced_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING id, (xmax = 0)
+	`, sync.Login, directoryPasswordHash, sync.FullName, sync.DepartmentID, sync.IsDocumentParticipant,
+		sync.AuthSource, sync.ExternalID).Scan(&userID, &created)
+	if err != nil {
+		if isUniqueViolation(err, "users_login_key") {
+			return nil, false, models.NewConflict(fmt.Sprintf("логин «%s» уже занят локальной учетной записью", sync.Login))
+		}
+		return nil, false, fmt.Errorf("failed to sync directory user: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM user_system_permissions
+		WHERE user_id = $1 AND NOT (permission = ANY($2))
+	`, userID, pq.Array(sync.SystemPermissions)); err != nil {
+		return nil, false, fmt.Errorf("failed to clear directory user system permissions: %w", err)
+	}
+	for _, permission := range sync.SystemPermissions {
+		if _, err := tx.Exec(`
+			INSERT INTO user_system_permissions (user_id, permission, is_allowed)
+			VALUES ($1, $2, true)
+			ON CONFLICT (user_id, permission) DO UPDATE SET is_allowed = true
+		`, userID, permission); err != nil {
+			return nil, false, fmt.Errorf("failed to grant directory user system permission: %w", err)
+		}
+	}
+
+	if created {
+		if err := enqueueOutboxEffects(r.outbox, tx, createEffects); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit directory user: %w", err)
+	}
+
+	user, err := r.GetByID(userID)
+	return user, created, err
+}
+
+// GetActiveDirectoryUsers возвращает активных пользователей источника authSource
+// для сверки с каталогом.
+func (r *UserRepository) GetActiveDirectoryUsers(authSource string) ([]models.User, error) {
+	rows, err := r.db.Query(`
+		SELECT id, login, full_name, external_id
+		FROM users
+		WHERE auth_source = $1 AND external_id IS NOT NULL AND is_active = true
+		ORDER BY login
+	`, authSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get directory users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		user := models.User{AuthSource: authSource, IsActive: true}
+		if err := rows.Scan(&user.ID, &user.Login, &user.FullName, &user.ExternalID); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// DeactivateDirectoryUserWithOutbox деактивирует пользователя каталога,
+// удаленного из каталога или исключенного из группы доступа.
+func (r *UserRepository) DeactivateDirectoryUserWithOutbox(userID uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users
+		SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND auth_source <> 'local' AND is_active = true
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate directory user: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit directory user deactivation: %w", err)
+	}
+	return nil
+}
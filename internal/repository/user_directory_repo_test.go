@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestUserRepository_SyncDirectoryUserWithOutbox(t *testing.T) {
+	userID := uuid.New()
+	departmentID := uuid.New()
+	sync := models.DirectoryUserSync{
+		AuthSource:            models.UserAuthSourceLDAP,
+		ExternalID:            "6f1c2a8e-0c7a-4a57-9c43-2b8f1f0e4d11",
+		Login:                 "ivanov",
+		FullName:              "Иванов И.И.",
+		DepartmentID:          &departmentID,
+		IsDocumentParticipant: true,
+		SystemPermissions:     []string{models.SystemPermissionReferences},
+	}
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "user:directory-create", Payload: `{}`}
+
+	expectUpsert := func(mock sqlmock.Sqlmock, created bool) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO users (.+) ON CONFLICT \(auth_source, external_id\) WHERE external_id IS NOT NULL DO UPDATE`).
+			WithArgs(sync.Login, directoryPasswordHash, sync.FullName, sync.DepartmentID, true, models.UserAuthSourceLDAP, sync.ExternalID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created"}).AddRow(userID, created))
+		mock.ExpectExec(`DELETE FROM user_system_permissions\s+WHERE user_id = \$1 AND NOT \(permission = ANY\(\$2\)\)`).
+			WithArgs(userID, pq.Array(sync.SystemPermissions)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO user_system_permissions`).
+			WithArgs(userID, models.SystemPermissionReferences).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	expectReload := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT(.*)FROM users u(.*)`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "login", "password_hash", "full_name", "is_document_participant", "is_active", "failed_login_attempts",
+				"password_changed_at", "password_change_required", "auth_source", "external_id", "created_at", "updated_at",
+				"d.id", "d.name",
+			}).AddRow(userID, sync.Login, directoryPasswordHash, sync.FullName, true, true, 0, nil, false, models.UserAuthSourceLDAP, sync.ExternalID, time.Now(), time.Now(), nil, nil))
+		mock.ExpectQuery(`SELECT permission FROM user_effective_system_permissions WHERE user_id = \$1`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(models.SystemPermissionReferences))
+	}
+
+	t.Run("created user enqueues audit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		expectUpsert(mock, true)
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		expectReload(mock)
+
+		user, created, err := repo.SyncDirectoryUserWithOutbox(sync, []models.OutboxEvent{event})
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, models.UserAuthSourceLDAP, user.AuthSource)
+		assert.Equal(t, sync.ExternalID, user.ExternalID)
+		assert.True(t, user.IsDirectoryUser())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("updated user skips create effects", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserRepository(&database.DB{DB: db})
+
+		expectUpsert(mock, false)
+		mock.ExpectCommit()
+		expectReload(mock)
+
+		_, created, err := repo.SyncDirectoryUserWithOutbox(sync, []models.OutboxEvent{event})
+		require.NoError(t, err)
+		assert.False(t, created)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("login taken by local account", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO users`).WillReturnError(&pq.Error{Code: "23505", Constraint: "users_login_key"})
+		mock.ExpectRollback()
+
+		_, _, err = repo.SyncDirectoryUserWithOutbox(sync, nil)
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("administrator invariant is reported on commit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserRepository(&database.DB{DB: db})
+		invariant := &pq.Error{Code: "P0001", Message: "at least one active administrator must remain"}
+
+		expectUpsert(mock, false)
+		mock.ExpectCommit().WillReturnError(invariant)
+
+		_, _, err = repo.SyncDirectoryUserWithOutbox(sync, nil)
+		require.ErrorIs(t, err, invariant)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_DeactivateDirectoryUserWithOutbox(t *testing.T) {
+	userID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "user:directory-deactivate", Payload: `{}`}
+
+	t.Run("deactivates directory user", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users\s+SET is_active = false, updated_at = CURRENT_TIMESTAMP\s+WHERE id = \$1 AND auth_source <> 'local' AND is_active = true`).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.DeactivateDirectoryUserWithOutbox(userID, []models.OutboxEvent{event}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already inactive user is a no-op", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		require.NoError(t, repo.DeactivateDirectoryUserWithOutbox(userID, []models.OutboxEvent{event}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
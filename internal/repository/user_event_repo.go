@@ -53,7 +53,7 @@ func (r *UserEventRepository) create(req models.CreateUserEventRequest, deduplic
 		query,
 		req.RecipientUserID,
 		req.ActorUserID,
-		req.DocumentID,
+		uuid.NullUUID{UUID: req.DocumentID, Valid: req.DocumentID != uuid.Nil},
 		req.DocumentKind,
 		req.DocumentNumber,
 		req.EntityType,
@@ -153,6 +153,37 @@ func (r *UserEventRepository) GetList(userID uuid.UUID, filter models.UserEventF
 	}, nil
 }
 
+// GetCreatedBetween возвращает события пользователя, созданные в интервале
+// [from, to), в порядке создания. Используется для ежедневной почтовой сводки.
+func (r *UserEventRepository) GetCreatedBetween(userID uuid.UUID, from, to time.Time) ([]models.UserEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			e.id, e.recipient_user_id, e.actor_user_id, actor.full_name,
+			e.document_id, e.document_kind, e.document_number,
+			e.entity_type, e.entity_id, e.event_type,
+			e.title, e.message, e.metadata::text,
+			e.created_at, e.read_at
+		FROM user_events e
+		LEFT JOIN users actor ON actor.id = e.actor_user_id
+		WHERE e.recipient_user_id = $1 AND e.created_at >= $2 AND e.created_at < $3
+		ORDER BY e.created_at, e.id
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user events for period: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.UserEvent, 0)
+	for rows.Next() {
+		event, err := scanUserEventRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *event)
+	}
+	return items, rows.Err()
+}
+
 // CountUnread возвращает количество непрочитанных событий пользователя.
 func (r *UserEventRepository) CountUnread(userID uuid.UUID) (int, error) {
 	var count int
@@ -232,6 +263,7 @@ func scanUserEventValue(scanner userEventScanner) (*models.UserEvent, error) {
 	var event models.UserEvent
 	var actorUserID sql.NullString
 	var actorUserName sql.NullString
+	var documentID uuid.NullUUID
 	var documentNumber sql.NullString
 	var metadata sql.NullString
 	var readAt sql.NullTime
@@ -241,7 +273,7 @@ func scanUserEventValue(scanner userEventScanner) (*models.UserEvent, error) {
 		&event.RecipientUserID,
 		&actorUserID,
 		&actorUserName,
-		&event.DocumentID,
+		&documentID,
 		&event.DocumentKind,
 		&documentNumber,
 		&event.EntityType,
@@ -267,6 +299,9 @@ func scanUserEventValue(scanner userEventScanner) (*models.UserEvent, error) {
 	if actorUserName.Valid {
 		event.ActorUserName = actorUserName.String
 	}
+	if documentID.Valid {
+		event.DocumentID = documentID.UUID
+	}
 	if documentNumber.Valid {
 		event.DocumentNumber = documentNumber.String
 	}
@@ -146,6 +146,31 @@ func TestUserEventRepository_GetList(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserEventRepository_GetCreatedBetween(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserEventRepository(&database.DB{DB: db})
+	userID := uuid.New()
+	from := time.Date(2026, 7, 16, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+
+	mock.ExpectQuery(`FROM user_events e(.*)WHERE e.recipient_user_id = \$1 AND e.created_at >= \$2 AND e.created_at < \$3(.*)ORDER BY e.created_at, e.id`).
+		WithArgs(userID, from, to).
+		WillReturnRows(sqlmock.NewRows(userEventColumns()).AddRow(
+			uuid.New(), userID, nil, nil, uuid.New(), "incoming_letter", "ВХ-1",
+			models.UserEventEntityAssignment, uuid.New(), models.UserEventAssignmentCreated,
+			"Новое поручение", "Назначено поручение", `{}`, from.Add(time.Hour), nil,
+		))
+
+	items, err := repo.GetCreatedBetween(userID, from, to)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, models.UserEventAssignmentCreated, items[0].EventType)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUserEventRepository_MarkRead(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
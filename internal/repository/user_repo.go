@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -30,7 +31,8 @@ func NewUserRepository(db *database.DB) *UserRepository {
 // userSelectBase — базовый SELECT для получения пользователя с department.
 const userSelectBase = `
 	SELECT u.id, u.login, u.password_hash, u.full_name, u.is_document_participant, u.is_active, u.failed_login_attempts,
-	       u.password_changed_at, u.password_change_required, u.created_at, u.updated_at,
+	       u.password_changed_at, u.password_change_required, u.auth_source, COALESCE(u.external_id, ''),
+	       u.created_at, u.updated_at,
 	       d.id, d.name
 	FROM users u
 	LEFT JOIN departments d ON u.department_id = d.id`
@@ -46,7 +48,8 @@ func (r *UserRepository) getUserByCondition(whereClause string, arg interface{})
 	err := r.db.QueryRow(query, arg).Scan(
 		&user.ID, &user.Login, &user.PasswordHash, &user.FullName,
 		&user.IsDocumentParticipant, &user.IsActive, &user.FailedLoginAttempts,
-		&user.PasswordChangedAt, &user.PasswordChangeRequired, &user.CreatedAt, &user.UpdatedAt,
+		&user.PasswordChangedAt, &user.PasswordChangeRequired, &user.AuthSource, &user.ExternalID,
+		&user.CreatedAt, &user.UpdatedAt,
 		&departmentID, &departmentName,
 	)
 
@@ -172,12 +175,9 @@ func (r *UserRepository) GetAll() ([]models.User, error) {
 	return users, nil
 }
 
-// Create создает нового пользователя в БД.
+// Create создает нового пользователя в БД. Пароль проверяется по политике
+// в сервисном слое.
 func (r *UserRepository) Create(req models.CreateUserRequest) (*models.User, error) {
-	if err := security.ValidatePassword(req.Password); err != nil {
-		return nil, models.NewBadRequestWrapped(err.Error(), err)
-	}
-
 	passwordHash, err := security.HashPassword(req.Password)
 	if err != nil {
 		return nil, err
@@ -215,9 +215,6 @@ func (r *UserRepository) Create(req models.CreateUserRequest) (*models.User, err
 }
 
 func (r *UserRepository) CreateWithOutbox(req models.CreateUserRequest, effects []models.OutboxEvent) (*models.User, error) {
-	if err := security.ValidatePassword(req.Password); err != nil {
-		return nil, models.NewBadRequestWrapped(err.Error(), err)
-	}
 	hash, err := security.HashPassword(req.Password)
 	if err != nil {
 		return nil, err
@@ -351,7 +348,7 @@ func (r *UserRepository) UpdateWithOutbox(req models.UpdateUserRequest, effects
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
-	if _, err := tx.Exec(`UPDATE users SET login=$1, full_name=$2, is_active=$3, department_id=$4, is_document_participant=$5, failed_login_attempts=CASE WHEN is_active=false AND $3=true THEN 0 ELSE failed_login_attempts END, updated_at=CURRENT_TIMESTAMP WHERE id=$6`, req.Login, req.FullName, req.IsActive, depID, req.IsDocumentParticipant, uid); err != nil {
+	if _, err := tx.Exec(`UPDATE users SET login=$1, full_name=$2, is_active=$3, department_id=$4, is_document_participant=$5, failed_login_attempts=CASE WHEN is_active=false AND $3=true THEN 0 ELSE failed_login_attempts END, locked_at=CASE WHEN $3=true THEN NULL ELSE locked_at END, updated_at=CURRENT_TIMESTAMP WHERE id=$6`, req.Login, req.FullName, req.IsActive, depID, req.IsDocumentParticipant, uid); err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
@@ -363,10 +360,11 @@ func (r *UserRepository) UpdateWithOutbox(req models.UpdateUserRequest, effects
 	return r.GetByID(uid)
 }
 
-// GetUserSystemPermissions возвращает список системных прав пользователя по его ID.
+// GetUserSystemPermissions возвращает действующие системные права пользователя по его ID:
+// прямые и полученные через роли, за вычетом прямых запретов.
 func (r *UserRepository) GetUserSystemPermissions(userID uuid.UUID) ([]string, error) {
 	rows, err := r.db.Query(`
-		SELECT permission FROM user_system_permissions WHERE user_id = $1 AND is_allowed = true
+		SELECT permission FROM user_effective_system_permissions WHERE user_id = $1
 	`, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user system permissions: %w", err)
@@ -390,7 +388,7 @@ func (r *UserRepository) GetUserSystemPermissions(userID uuid.UUID) ([]string, e
 
 // GetExecutors возвращает список активных пользователей, доступных для назначения и ознакомления.
 func (r *UserRepository) GetExecutors() ([]models.User, error) {
-	rows, err := r.db.Query(`
+	return r.queryUsersWithDepartments("failed to get assignable users", `
 		SELECT u.id, u.login, u.full_name, u.is_document_participant, u.is_active, u.created_at, u.updated_at,
 		       d.id, d.name
 		FROM users u
@@ -398,68 +396,32 @@ func (r *UserRepository) GetExecutors() ([]models.User, error) {
 		WHERE u.is_active = true AND u.is_document_participant = true
 		ORDER BY u.full_name
 	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get assignable users: %w", err)
-	}
-	defer rows.Close()
-
-	users := make([]models.User, 0)
-	userIDs := make([]uuid.UUID, 0)
-	departmentIDs := make([]uuid.UUID, 0)
-	departmentIndexes := make(map[uuid.UUID][]int)
-
-	for rows.Next() {
-		var user models.User
-		var departmentID sql.NullString
-		var departmentName sql.NullString
-
-		if err := rows.Scan(
-			&user.ID, &user.Login, &user.FullName,
-			&user.IsDocumentParticipant, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
-			&departmentID, &departmentName,
-		); err != nil {
-			return nil, err
-		}
-
-		if departmentID.Valid {
-			uid, _ := uuid.Parse(departmentID.String)
-			user.DepartmentID = &uid
-			user.Department = &models.Department{
-				ID:   uid,
-				Name: departmentName.String,
-			}
-			if _, exists := departmentIndexes[uid]; !exists {
-				departmentIDs = append(departmentIDs, uid)
-			}
-			departmentIndexes[uid] = append(departmentIndexes[uid], len(users))
-		}
-
-		userIDs = append(userIDs, user.ID)
-		users = append(users, user)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	if len(users) == 0 {
-		return users, nil
-	}
-
-	if err := r.batchLoadUserSystemPermissions(users, userIDs); err != nil {
-		return nil, err
-	}
-
-	// Batch-загрузка номенклатур подразделений одним запросом
-	if err := r.batchLoadDepartmentNomenclatures(users, departmentIDs, departmentIndexes); err != nil {
-		return nil, err
-	}
+}
 
-	return users, nil
+// GetDepartmentExecutors возвращает активных участников документооборота из
+// подразделения departmentID и всех его подчиненных подразделений.
+func (r *UserRepository) GetDepartmentExecutors(departmentID uuid.UUID) ([]models.User, error) {
+	return r.queryUsersWithDepartments("failed to get department executors", `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM departments WHERE id = $1
+			UNION
+			SELECT d.id
+			FROM departments d
+			JOIN subtree s ON d.parent_id = s.id
+		)
+		SELECT u.id, u.login, u.full_name, u.is_document_participant, u.is_active, u.created_at, u.updated_at,
+		       d.id, d.name
+		FROM users u
+		JOIN subtree s ON s.id = u.department_id
+		JOIN departments d ON u.department_id = d.id
+		WHERE u.is_active = true AND u.is_document_participant = true
+		ORDER BY u.full_name
+	`, departmentID)
 }
 
 // GetActiveUsers возвращает всех активных пользователей.
 func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
-	rows, err := r.db.Query(`
+	return r.queryUsersWithDepartments("failed to get active users", `
 		SELECT u.id, u.login, u.full_name, u.is_document_participant, u.is_active, u.created_at, u.updated_at,
 		       d.id, d.name
 		FROM users u
@@ -467,8 +429,14 @@ func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
 		WHERE u.is_active = true
 		ORDER BY u.full_name
 	`)
+}
+
+// queryUsersWithDepartments читает краткие карточки пользователей с подразделением
+// и догружает системные права и номенклатуры подразделений пакетными запросами.
+func (r *UserRepository) queryUsersWithDepartments(errMessage, query string, args ...interface{}) ([]models.User, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active users: %w", err)
+		return nil, fmt.Errorf("%s: %w", errMessage, err)
 	}
 	defer rows.Close()
 
@@ -517,6 +485,8 @@ func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
 	if err := r.batchLoadUserSystemPermissions(users, userIDs); err != nil {
 		return nil, err
 	}
+
+	// Batch-загрузка номенклатур подразделений одним запросом
 	if err := r.batchLoadDepartmentNomenclatures(users, departmentIDs, departmentIndexes); err != nil {
 		return nil, err
 	}
@@ -524,37 +494,37 @@ func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
 	return users, nil
 }
 
-// UpdatePassword обновляет хэш пароля пользователя.
+// maxPasswordHistoryEntries — сколько предыдущих хешей хранится на
+// пользователя; текущий пароль хранится в users.
+const maxPasswordHistoryEntries = security.MaxPasswordHistoryDepth - 1
+
+// updatePasswordQuery заменяет хеш пароля.
+const updatePasswordQuery = `
+	UPDATE users
+	SET password_hash = $1,
+	    failed_login_attempts = 0,
+	    password_changed_at = CURRENT_TIMESTAMP,
+	    password_change_required = false,
+	    updated_at = CURRENT_TIMESTAMP
+	WHERE id = $2`
+
+// UpdatePassword обновляет хэш пароля пользователя, перенося прежний хэш в
+// историю паролей.
 func (r *UserRepository) UpdatePassword(userID uuid.UUID, newPasswordHash string) error {
-	result, err := r.db.Exec(`
-		UPDATE users
-		SET password_hash = $1,
-		    failed_login_attempts = 0,
-		    password_changed_at = CURRENT_TIMESTAMP,
-		    password_change_required = false,
-		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2
-	`, newPasswordHash, userID)
-
-	if err != nil {
-		return fmt.Errorf("failed to update password: %w", err)
-	}
-	affected, err := result.RowsAffected()
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to check updated password rows: %w", err)
-	}
-	if affected == 0 {
-		return models.NewNotFound("пользователь не найден")
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	return nil
+	if err := replacePasswordTx(tx, userID, newPasswordHash); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // ResetPassword сбрасывает (изменяет) пароль пользователя.
 func (r *UserRepository) ResetPassword(userID uuid.UUID, newPassword string) error {
-	if err := security.ValidatePassword(newPassword); err != nil {
-		return models.NewBadRequestWrapped(err.Error(), err)
-	}
 	hash, err := security.HashPassword(newPassword)
 	if err != nil {
 		return err
@@ -563,9 +533,6 @@ func (r *UserRepository) ResetPassword(userID uuid.UUID, newPassword string) err
 }
 
 func (r *UserRepository) ResetPasswordWithOutbox(userID uuid.UUID, newPassword string, effects []models.OutboxEvent) error {
-	if err := security.ValidatePassword(newPassword); err != nil {
-		return models.NewBadRequestWrapped(err.Error(), err)
-	}
 	hash, err := security.HashPassword(newPassword)
 	if err != nil {
 		return err
@@ -575,21 +542,100 @@ func (r *UserRepository) ResetPasswordWithOutbox(userID uuid.UUID, newPassword s
 		return err
 	}
 	defer tx.Rollback()
-	result, err := tx.Exec(`UPDATE users SET password_hash=$1, failed_login_attempts=0, password_changed_at=CURRENT_TIMESTAMP, password_change_required=false, updated_at=CURRENT_TIMESTAMP WHERE id=$2`, hash, userID)
+	if err := replacePasswordTx(tx, userID, hash); err != nil {
+		return err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replacePasswordTx(tx *sql.Tx, userID uuid.UUID, newPasswordHash string) error {
+	if _, err := tx.Exec(`
+		INSERT INTO user_password_history (user_id, password_hash)
+		SELECT id, password_hash FROM users
+		WHERE id = $1 AND auth_source = 'local'
+	`, userID); err != nil {
+		return fmt.Errorf("failed to save password history: %w", err)
+	}
+
+	result, err := tx.Exec(updatePasswordQuery, newPasswordHash, userID)
 	if err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 	affected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to check updated password rows: %w", err)
 	}
 	if affected == 0 {
 		return models.NewNotFound("пользователь не найден")
 	}
-	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
-		return err
+
+	if _, err := tx.Exec(`
+		DELETE FROM user_password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM user_password_history
+			WHERE user_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		)
+	`, userID, maxPasswordHistoryEntries); err != nil {
+		return fmt.Errorf("failed to trim password history: %w", err)
 	}
-	return tx.Commit()
+	return nil
+}
+
+// GetPasswordHistory возвращает до limit хешей предыдущих паролей, от новых к старым.
+func (r *UserRepository) GetPasswordHistory(userID uuid.UUID, limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT password_hash FROM user_password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password history: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan password history: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// GetPasswordStatuses возвращает состояние паролей активных локальных
+// пользователей для оценки политики паролей.
+func (r *UserRepository) GetPasswordStatuses() ([]models.UserPasswordStatus, error) {
+	rows, err := r.db.Query(`
+		SELECT id, login, full_name, failed_login_attempts, password_changed_at, password_change_required
+		FROM users
+		WHERE is_active = true AND auth_source = 'local'
+		ORDER BY full_name, login
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password statuses: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []models.UserPasswordStatus
+	for rows.Next() {
+		var status models.UserPasswordStatus
+		if err := rows.Scan(
+			&status.UserID, &status.Login, &status.FullName, &status.FailedLoginAttempts,
+			&status.PasswordChangedAt, &status.PasswordChangeRequired,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan password status: %w", err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
 }
 
 // UpdateProfile обновляет данные профиля пользователя (логин, ФИО).
@@ -606,22 +652,36 @@ func (r *UserRepository) UpdateProfile(userID uuid.UUID, req models.UpdateProfil
 	return nil
 }
 
-// IncrementFailedLoginAttempts увеличивает счетчик неудачных входов и деактивирует пользователя после 5-й ошибки.
-func (r *UserRepository) IncrementFailedLoginAttempts(userID uuid.UUID) (int, bool, error) {
+// incrementFailedLoginAttemptsQuery увеличивает счетчик неудачных входов и
+// блокирует пользователя, когда счетчик достигает порога $2. Последний
+// столбец — активность до попытки: по нему видно, что блокировка произошла
+// именно сейчас.
+const incrementFailedLoginAttemptsQuery = `
+	WITH previous AS (
+		SELECT id, is_active FROM users WHERE id = $1 FOR UPDATE
+	)
+	UPDATE users u
+	SET failed_login_attempts = u.failed_login_attempts + 1,
+	    is_active = CASE
+	        WHEN u.failed_login_attempts + 1 >= $2 THEN false
+	        ELSE u.is_active
+	    END,
+	    locked_at = CASE
+	        WHEN u.is_active AND u.failed_login_attempts + 1 >= $2 THEN CURRENT_TIMESTAMP
+	        ELSE u.locked_at
+	    END,
+	    updated_at = CURRENT_TIMESTAMP
+	FROM previous p
+	WHERE u.id = p.id
+	RETURNING u.failed_login_attempts, u.is_active, p.is_active`
+
+// IncrementFailedLoginAttempts увеличивает счетчик неудачных входов и деактивирует пользователя,
+// когда счетчик достигает порога threshold.
+func (r *UserRepository) IncrementFailedLoginAttempts(userID uuid.UUID, threshold int) (int, bool, error) {
 	var attempts int
-	var isActive bool
+	var isActive, wasActive bool
 
-	err := r.db.QueryRow(`
-		UPDATE users
-		SET failed_login_attempts = failed_login_attempts + 1,
-		    is_active = CASE
-		        WHEN failed_login_attempts + 1 >= 5 THEN false
-		        ELSE is_active
-		    END,
-		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
-		RETURNING failed_login_attempts, is_active
-	`, userID).Scan(&attempts, &isActive)
+	err := r.db.QueryRow(incrementFailedLoginAttemptsQuery, userID, threshold).Scan(&attempts, &isActive, &wasActive)
 	if err != nil {
 		return 0, false, fmt.Errorf("failed to increment failed login attempts: %w", err)
 	}
@@ -631,18 +691,18 @@ func (r *UserRepository) IncrementFailedLoginAttempts(userID uuid.UUID) (int, bo
 
 // IncrementFailedLoginAttemptsWithOutbox writes the lock audit only for the
 // transition that reaches the lock threshold, in the same transaction.
-func (r *UserRepository) IncrementFailedLoginAttemptsWithOutbox(userID uuid.UUID, lockEffect models.OutboxEvent) (int, bool, error) {
+func (r *UserRepository) IncrementFailedLoginAttemptsWithOutbox(userID uuid.UUID, threshold int, lockEffect models.OutboxEvent) (int, bool, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return 0, false, err
 	}
 	defer tx.Rollback()
 	var attempts int
-	var isActive bool
-	if err := tx.QueryRow(`UPDATE users SET failed_login_attempts = failed_login_attempts + 1, is_active = CASE WHEN failed_login_attempts + 1 >= 5 THEN false ELSE is_active END, updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING failed_login_attempts, is_active`, userID).Scan(&attempts, &isActive); err != nil {
+	var isActive, wasActive bool
+	if err := tx.QueryRow(incrementFailedLoginAttemptsQuery, userID, threshold).Scan(&attempts, &isActive, &wasActive); err != nil {
 		return 0, false, fmt.Errorf("failed to increment failed login attempts: %w", err)
 	}
-	if attempts == 5 && !isActive && r.outbox != nil {
+	if wasActive && !isActive && r.outbox != nil {
 		if err := r.outbox.EnqueueTx(tx, lockEffect); err != nil {
 			return 0, false, err
 		}
@@ -653,6 +713,61 @@ func (r *UserRepository) IncrementFailedLoginAttemptsWithOutbox(userID uuid.UUID
 	return attempts, isActive, nil
 }
 
+// releaseExpiredLockoutQuery снимает блокировку после неверных попыток
+// входа, продлившуюся не меньше $2 секунд. Время отсчитывается по часам БД:
+// часы рабочих мест могут расходиться. Деактивация администратором не
+// снимается.
+const releaseExpiredLockoutQuery = `
+	UPDATE users
+	SET is_active = true,
+	    failed_login_attempts = 0,
+	    locked_at = NULL,
+	    updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND is_active = false AND locked_at IS NOT NULL
+	  AND locked_at <= CURRENT_TIMESTAMP - $2 * INTERVAL '1 second'`
+
+// ReleaseExpiredLockout снимает блокировку пользователя, если с ее начала
+// прошло не меньше lockoutDuration. Возвращает true, если блокировка снята.
+func (r *UserRepository) ReleaseExpiredLockout(userID uuid.UUID, lockoutDuration time.Duration) (bool, error) {
+	result, err := r.db.Exec(releaseExpiredLockoutQuery, userID, int64(lockoutDuration/time.Second))
+	if err != nil {
+		return false, fmt.Errorf("failed to release lockout: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check released lockout rows: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// ReleaseExpiredLockoutWithOutbox снимает истекшую блокировку и в той же
+// транзакции записывает аудит разблокировки.
+func (r *UserRepository) ReleaseExpiredLockoutWithOutbox(userID uuid.UUID, lockoutDuration time.Duration, unlockEffect models.OutboxEvent) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec(releaseExpiredLockoutQuery, userID, int64(lockoutDuration/time.Second))
+	if err != nil {
+		return false, fmt.Errorf("failed to release lockout: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, []models.OutboxEvent{unlockEffect}); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // ResetFailedLoginAttempts сбрасывает счетчик неудачных входов пользователя.
 func (r *UserRepository) ResetFailedLoginAttempts(userID uuid.UUID) error {
 	_, err := r.db.Exec(`
@@ -681,8 +796,8 @@ func (r *UserRepository) batchLoadUserSystemPermissions(users []models.User, use
 
 	rows, err := r.db.Query(`
 		SELECT user_id, permission
-		FROM user_system_permissions
-		WHERE user_id = ANY($1) AND is_allowed = true
+		FROM user_effective_system_permissions
+		WHERE user_id = ANY($1)
 	`, pq.Array(userIDs))
 	if err != nil {
 		return fmt.Errorf("failed to batch load user system permissions: %w", err)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
@@ -30,15 +31,16 @@ func TestUserRepository_GetByLogin(t *testing.T) {
 	t.Run("success without department", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
 			"id", "login", "password_hash", "full_name", "is_document_participant", "is_active", "failed_login_attempts",
-			"password_changed_at", "password_change_required", "created_at", "updated_at",
+			"password_changed_at", "password_change_required", "auth_source", "external_id", "created_at", "updated_at",
 			"d.id", "d.name",
 		}).AddRow(
-			id, login, "hash", "Test User", true, true, 0, now, false, now, now,
+			id, login, "hash", "Test User", true, true, 0, now, false, models.UserAuthSourceLocal, "", now, now,
 			nil, nil, // нет подразделения
 		)
 
 		expectedQuery := `SELECT u.id, u.login, u.password_hash, u.full_name, u.is_document_participant, u.is_active, u.failed_login_attempts,
-	       u.password_changed_at, u.password_change_required, u.created_at, u.updated_at,
+	       u.password_changed_at, u.password_change_required, u.auth_source, COALESCE\(u.external_id, ''\),
+	       u.created_at, u.updated_at,
 	       d.id, d.name
 	FROM users u
 	LEFT JOIN departments d ON u.department_id = d.id WHERE u.login = \$1`
@@ -46,7 +48,7 @@ func TestUserRepository_GetByLogin(t *testing.T) {
 		mock.ExpectQuery(expectedQuery).WithArgs(login).WillReturnRows(rows)
 
 		systemPermissionRows := sqlmock.NewRows([]string{"permission"}).AddRow("admin")
-		mock.ExpectQuery(`SELECT permission FROM user_system_permissions WHERE user_id = \$1 AND is_allowed = true`).WithArgs(id).WillReturnRows(systemPermissionRows)
+		mock.ExpectQuery(`SELECT permission FROM user_effective_system_permissions WHERE user_id = \$1`).WithArgs(id).WillReturnRows(systemPermissionRows)
 
 		user, err := repo.GetByLogin(login)
 
@@ -62,7 +64,8 @@ func TestUserRepository_GetByLogin(t *testing.T) {
 
 	t.Run("not found", func(t *testing.T) {
 		expectedQuery := `SELECT u.id, u.login, u.password_hash, u.full_name, u.is_document_participant, u.is_active, u.failed_login_attempts,
-	       u.password_changed_at, u.password_change_required, u.created_at, u.updated_at,
+	       u.password_changed_at, u.password_change_required, u.auth_source, COALESCE\(u.external_id, ''\),
+	       u.created_at, u.updated_at,
 	       d.id, d.name
 	FROM users u
 	LEFT JOIN departments d ON u.department_id = d.id WHERE u.login = \$1`
@@ -114,15 +117,103 @@ func TestUserRepositoryIncrementFailedLoginAttemptsWithOutboxRollsBackOnEnqueueF
 	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "user:" + userID.String() + ":locked", Payload: `{}`}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`UPDATE users SET failed_login_attempts`).WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts", "is_active"}).AddRow(5, false))
+	mock.ExpectQuery(`UPDATE users u\s+SET failed_login_attempts`).WithArgs(userID, 5).WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts", "is_active", "is_active"}).AddRow(5, false, true))
 	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnError(assert.AnError)
 	mock.ExpectRollback()
 
-	_, _, err = repo.IncrementFailedLoginAttemptsWithOutbox(userID, event)
+	_, _, err = repo.IncrementFailedLoginAttemptsWithOutbox(userID, 5, event)
 	require.ErrorIs(t, err, assert.AnError)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserRepositoryIncrementFailedLoginAttemptsWithOutboxSkipsAuditForLockedUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewUserRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+	userID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "user:" + userID.String() + ":locked", Payload: `{}`}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE users u`).WithArgs(userID, 3).WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts", "is_active", "is_active"}).AddRow(7, false, false))
+	mock.ExpectCommit()
+
+	attempts, isActive, err := repo.IncrementFailedLoginAttemptsWithOutbox(userID, 3, event)
+	require.NoError(t, err)
+	assert.Equal(t, 7, attempts)
+	assert.False(t, isActive)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepositoryReleaseExpiredLockoutWithOutbox(t *testing.T) {
+	userID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "user:" + userID.String() + ":unlocked", Payload: `{}`}
+	releaseQuery := `UPDATE users\s+SET is_active = true(.*)WHERE id = \$1 AND is_active = false AND locked_at IS NOT NULL\s+AND locked_at <= CURRENT_TIMESTAMP - \$2 \* INTERVAL '1 second'`
+
+	t.Run("releases expired lockout with audit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(releaseQuery).WithArgs(userID, int64(900)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		released, err := repo.ReleaseExpiredLockoutWithOutbox(userID, 15*time.Minute, event)
+		require.NoError(t, err)
+		assert.True(t, released)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lockout still in effect", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(releaseQuery).WithArgs(userID, int64(900)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		released, err := repo.ReleaseExpiredLockoutWithOutbox(userID, 15*time.Minute, event)
+		require.NoError(t, err)
+		assert.False(t, released)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_GetPasswordStatuses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewUserRepository(&database.DB{DB: db})
+	changedID, requiredID := uuid.New(), uuid.New()
+	changedAt := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`FROM users\s+WHERE is_active = true AND auth_source = 'local'`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "login", "full_name", "failed_login_attempts", "password_changed_at", "password_change_required",
+		}).
+			AddRow(changedID, "ivanov", "Иванов И.И.", 0, changedAt, false).
+			AddRow(requiredID, "petrov", "Петров П.П.", 2, nil, true))
+
+	statuses, err := repo.GetPasswordStatuses()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.NotNil(t, statuses[0].PasswordChangedAt)
+	assert.Equal(t, changedAt, *statuses[0].PasswordChangedAt)
+	assert.False(t, statuses[0].PasswordChangeRequired)
+	assert.Nil(t, statuses[1].PasswordChangedAt)
+	assert.True(t, statuses[1].PasswordChangeRequired)
+	assert.Equal(t, 2, statuses[1].FailedLoginAttempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUserRepository_CreateInitialAdmin(t *testing.T) {
 	t.Run("creates user and admin permission in one transaction", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -192,7 +283,7 @@ func TestUserRepository_GetAll(t *testing.T) {
 		}).AddRow(uid, "user1", "User One", true, false, 5, now, false, now, now, depID, "IT Dept"))
 
 	// Expect system permissions
-	mock.ExpectQuery(`SELECT(.*)FROM user_system_permissions(.*)`).
+	mock.ExpectQuery(`SELECT(.*)FROM user_effective_system_permissions(.*)`).
 		WithArgs(pq.Array([]uuid.UUID{uid})).
 		WillReturnRows(sqlmock.NewRows([]string{"user_id", "permission"}).AddRow(uid, "admin"))
 
@@ -214,6 +305,37 @@ func TestUserRepository_GetAll(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserRepository_GetDepartmentExecutors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(&database.DB{DB: db})
+	now := time.Now()
+	userID := uuid.New()
+	departmentID := uuid.New()
+	sectorID := uuid.New()
+
+	mock.ExpectQuery(`WITH RECURSIVE subtree AS \(\s+SELECT id FROM departments WHERE id = \$1\s+UNION\s+SELECT d.id\s+FROM departments d\s+JOIN subtree s ON d.parent_id = s.id\s+\).*JOIN subtree s ON s.id = u.department_id.*WHERE u\.is_active = true AND u\.is_document_participant = true`).
+		WithArgs(departmentID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "login", "full_name", "is_document_participant", "is_active", "created_at", "updated_at",
+			"d.id", "d.name",
+		}).AddRow(userID, "sector", "Sector User", true, true, now, now, sectorID, "Sector"))
+	mock.ExpectQuery(`FROM user_effective_system_permissions`).
+		WithArgs(pq.Array([]uuid.UUID{userID})).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "permission"}))
+	mock.ExpectQuery(`FROM department_nomenclature`).
+		WithArgs(pq.Array([]uuid.UUID{sectorID})).
+		WillReturnRows(sqlmock.NewRows([]string{"department_id", "nomenclature_id"}))
+
+	users, err := repo.GetDepartmentExecutors(departmentID)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, sectorID, users[0].Department.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUserRepository_GetExecutors(t *testing.T) {
 	t.Run("success with department", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -232,7 +354,7 @@ func TestUserRepository_GetExecutors(t *testing.T) {
 				"d.id", "d.name",
 			}).AddRow(userID, "executor", "Executor User", true, true, now, now, departmentID, "Office"))
 
-		mock.ExpectQuery(`SELECT user_id, permission\s+FROM user_system_permissions\s+WHERE user_id = ANY\(\$1\) AND is_allowed = true`).
+		mock.ExpectQuery(`SELECT user_id, permission\s+FROM user_effective_system_permissions\s+WHERE user_id = ANY\(\$1\)`).
 			WithArgs(pq.Array([]uuid.UUID{userID})).
 			WillReturnRows(sqlmock.NewRows([]string{"user_id", "permission"}).AddRow(userID, "documents.assign"))
 
@@ -292,7 +414,7 @@ func TestUserRepository_GetActiveUsers(t *testing.T) {
 			"d.id", "d.name",
 		}).AddRow(userID, "candidate", "Candidate User", false, true, now, now, departmentID, "Office"))
 
-	mock.ExpectQuery(`SELECT user_id, permission\s+FROM user_system_permissions\s+WHERE user_id = ANY\(\$1\) AND is_allowed = true`).
+	mock.ExpectQuery(`SELECT user_id, permission\s+FROM user_effective_system_permissions\s+WHERE user_id = ANY\(\$1\)`).
 		WithArgs(pq.Array([]uuid.UUID{userID})).
 		WillReturnRows(sqlmock.NewRows([]string{"user_id", "permission"}))
 
@@ -342,10 +464,10 @@ func TestUserRepository_Create(t *testing.T) {
 			WithArgs(uid).
 			WillReturnRows(sqlmock.NewRows([]string{
 				"id", "login", "password_hash", "full_name", "is_document_participant", "is_active", "failed_login_attempts",
-				"password_changed_at", "password_change_required", "created_at", "updated_at",
+				"password_changed_at", "password_change_required", "auth_source", "external_id", "created_at", "updated_at",
 				"d.id", "d.name",
-			}).AddRow(uid, req.Login, "hash", req.FullName, true, true, 0, time.Now(), false, time.Now(), time.Now(), nil, nil))
-		mock.ExpectQuery(`SELECT permission FROM user_system_permissions WHERE user_id = \$1 AND is_allowed = true`).
+			}).AddRow(uid, req.Login, "hash", req.FullName, true, true, 0, time.Now(), false, models.UserAuthSourceLocal, "", time.Now(), time.Now(), nil, nil))
+		mock.ExpectQuery(`SELECT permission FROM user_effective_system_permissions WHERE user_id = \$1`).
 			WithArgs(uid).
 			WillReturnRows(sqlmock.NewRows([]string{"permission"}))
 
@@ -355,29 +477,6 @@ func TestUserRepository_Create(t *testing.T) {
 		assert.Equal(t, req.Login, user.Login)
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
-
-	t.Run("weak password returns validation error", func(t *testing.T) {
-		db, mock, err := sqlmock.New()
-		require.NoError(t, err)
-		defer db.Close()
-
-		repo := NewUserRepository(&database.DB{DB: db})
-
-		user, err := repo.Create(models.CreateUserRequest{
-			Login:    "newuser",
-			Password: "weak",
-			FullName: "New User",
-		})
-
-		require.Error(t, err)
-		assert.Nil(t, user)
-		appErr, ok := models.AsAppError(err)
-		require.True(t, ok)
-		assert.Equal(t, "VALIDATION_ERROR", appErr.Kind)
-		assert.Equal(t, 400, appErr.Code)
-		assert.Contains(t, appErr.Message, "минимум 8 символов")
-		require.NoError(t, mock.ExpectationsWereMet())
-	})
 }
 
 func TestUserRepository_Update(t *testing.T) {
@@ -408,10 +507,10 @@ func TestUserRepository_Update(t *testing.T) {
 		WithArgs(uid).
 		WillReturnRows(sqlmock.NewRows([]string{
 			"id", "login", "password_hash", "full_name", "is_document_participant", "is_active", "failed_login_attempts",
-			"password_changed_at", "password_change_required", "created_at", "updated_at",
+			"password_changed_at", "password_change_required", "auth_source", "external_id", "created_at", "updated_at",
 			"d.id", "d.name",
-		}).AddRow(uid, req.Login, "hash", req.FullName, true, true, 0, time.Now(), false, time.Now(), time.Now(), nil, nil))
-	mock.ExpectQuery(`SELECT permission FROM user_system_permissions WHERE user_id = \$1 AND is_allowed = true`).
+		}).AddRow(uid, req.Login, "hash", req.FullName, true, true, 0, time.Now(), false, models.UserAuthSourceLocal, "", time.Now(), time.Now(), nil, nil))
+	mock.ExpectQuery(`SELECT permission FROM user_effective_system_permissions WHERE user_id = \$1`).
 		WithArgs(uid).
 		WillReturnRows(sqlmock.NewRows([]string{"permission"}))
 
@@ -432,38 +531,49 @@ func TestUserRepository_OtherMethods(t *testing.T) {
 	uid := uuid.New()
 
 	t.Run("UpdatePassword", func(t *testing.T) {
-		// Изменение пароля (передача нового хеша)
-		mock.ExpectExec(`UPDATE users SET password_hash`).
+		// Изменение пароля (передача нового хеша), прежний хеш уходит в историю
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO user_password_history \(user_id, password_hash\)\s+SELECT id, password_hash FROM users\s+WHERE id = \$1 AND auth_source = 'local'`).
+			WithArgs(uid).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE users SET password_hash = \$1(.*)password_change_required = false`).
 			WithArgs("newhash", uid).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`DELETE FROM user_password_history`).
+			WithArgs(uid, security.MaxPasswordHistoryDepth-1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
 
 		err = repo.UpdatePassword(uid, "newhash")
 		require.NoError(t, err)
 	})
 
-	t.Run("ResetPassword invalid password", func(t *testing.T) {
-		err = repo.ResetPassword(uid, "weak")
-		require.Error(t, err)
-		appErr, ok := models.AsAppError(err)
-		require.True(t, ok)
-		assert.Equal(t, "VALIDATION_ERROR", appErr.Kind)
-		assert.Equal(t, 400, appErr.Code)
-		assert.Contains(t, appErr.Message, "минимум 8 символов")
-	})
-
 	t.Run("ResetPassword success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO user_password_history`).
+			WithArgs(uid).
+			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectExec(`UPDATE users SET password_hash`).
 			WithArgs(sqlmock.AnyArg(), uid).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`DELETE FROM user_password_history`).
+			WithArgs(uid, security.MaxPasswordHistoryDepth-1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
 
 		err = repo.ResetPassword(uid, "NewPass123!")
 		require.NoError(t, err)
 	})
 
 	t.Run("ResetPassword missing user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO user_password_history`).
+			WithArgs(uid).
+			WillReturnResult(sqlmock.NewResult(0, 0))
 		mock.ExpectExec(`UPDATE users SET password_hash`).
 			WithArgs(sqlmock.AnyArg(), uid).
 			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
 
 		err = repo.ResetPassword(uid, "NewPass123!")
 		appErr, ok := models.AsAppError(err)
@@ -484,11 +594,11 @@ func TestUserRepository_OtherMethods(t *testing.T) {
 	})
 
 	t.Run("IncrementFailedLoginAttempts", func(t *testing.T) {
-		mock.ExpectQuery(`UPDATE users`).
-			WithArgs(uid).
-			WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts", "is_active"}).AddRow(5, false))
+		mock.ExpectQuery(`UPDATE users u`).
+			WithArgs(uid, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"failed_login_attempts", "is_active", "is_active"}).AddRow(5, false, true))
 
-		attempts, isActive, err := repo.IncrementFailedLoginAttempts(uid)
+		attempts, isActive, err := repo.IncrementFailedLoginAttempts(uid, 5)
 		require.NoError(t, err)
 		assert.Equal(t, 5, attempts)
 		assert.False(t, isActive)
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// UserSessionRepository ведет журнал сеансов desktop-клиента.
+type UserSessionRepository struct {
+	db     *database.DB
+	outbox *OutboxRepository
+}
+
+func (r *UserSessionRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
+
+// NewUserSessionRepository создает репозиторий журнала сеансов.
+func NewUserSessionRepository(db *database.DB) *UserSessionRepository {
+	return &UserSessionRepository{db: db}
+}
+
+const userSessionSelect = `
+	SELECT s.id, s.user_id, u.login, u.full_name, s.host_name,
+	       s.started_at, s.last_activity_at, s.ended_at, COALESCE(s.end_reason, ''),
+	       COALESCE(t.full_name, '')
+	FROM user_sessions s
+	JOIN users u ON u.id = s.user_id
+	LEFT JOIN users t ON t.id = s.terminated_by
+`
+
+func scanUserSession(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.UserSession, error) {
+	var item models.UserSession
+	var endedAt sql.NullTime
+	err := scanner.Scan(
+		&item.ID,
+		&item.UserID,
+		&item.UserLogin,
+		&item.UserFullName,
+		&item.HostName,
+		&item.StartedAt,
+		&item.LastActivityAt,
+		&endedAt,
+		&item.EndReason,
+		&item.TerminatedByName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		item.EndedAt = &endedAt.Time
+	}
+	return &item, nil
+}
+
+// Start открывает сеанс пользователя и возвращает его ID.
+func (r *UserSessionRepository) Start(userID uuid.UUID, hostName string, startedAt time.Time) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(`
+		INSERT INTO user_sessions (user_id, host_name, started_at, last_activity_at)
+		VALUES ($1, $2, $3, $3)
+		RETURNING id
+	`, userID, hostName, startedAt).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to start user session: %w", err)
+	}
+	return id, nil
+}
+
+// Touch сохраняет время последнего действия открытого сеанса. Для уже
+// завершенного сеанса возвращает open == false и причину завершения.
+func (r *UserSessionRepository) Touch(id uuid.UUID, lastActivityAt time.Time) (bool, string, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_sessions
+		SET last_activity_at = GREATEST(last_activity_at, $2)
+		WHERE id = $1 AND ended_at IS NULL
+	`, id, lastActivityAt)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to touch user session: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, "", err
+	}
+	if affected > 0 {
+		return true, "", nil
+	}
+
+	var reason string
+	err = r.db.QueryRow(`SELECT COALESCE(end_reason, '') FROM user_sessions WHERE id = $1`, id).Scan(&reason)
+	if err == sql.ErrNoRows {
+		// Запись удалена вместе с пользователем.
+		return false, models.SessionEndUserInactive, nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get user session end reason: %w", err)
+	}
+	return false, reason, nil
+}
+
+// End завершает открытый сеанс с причиной reason. Повторное завершение не
+// меняет первую причину.
+func (r *UserSessionRepository) End(id uuid.UUID, reason string, endedAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE user_sessions
+		SET ended_at = $3, end_reason = $2
+		WHERE id = $1 AND ended_at IS NULL
+	`, id, reason, endedAt)
+	if err != nil {
+		return fmt.Errorf("failed to end user session: %w", err)
+	}
+	return nil
+}
+
+// TerminateWithOutbox принудительно завершает открытый сеанс от имени
+// администратора terminatedBy.
+func (r *UserSessionRepository) TerminateWithOutbox(id, terminatedBy uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE user_sessions
+		SET ended_at = CURRENT_TIMESTAMP, end_reason = $2, terminated_by = $3
+		WHERE id = $1 AND ended_at IS NULL
+	`, id, models.SessionEndTerminated, terminatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to terminate user session: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewConflict("сеанс уже завершен")
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user session termination: %w", err)
+	}
+	return nil
+}
+
+// ExpireStartedBefore завершает открытые сеансы, начатые раньше startedBefore:
+// клиент мог завершиться аварийно, не закрыв сеанс.
+func (r *UserSessionRepository) ExpireStartedBefore(startedBefore, endedAt time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_sessions
+		SET ended_at = $2, end_reason = $3
+		WHERE ended_at IS NULL AND started_at < $1
+	`, startedBefore, endedAt, models.SessionEndExpired)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire user sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetByID возвращает сеанс или nil, если записи нет.
+func (r *UserSessionRepository) GetByID(id uuid.UUID) (*models.UserSession, error) {
+	item, err := scanUserSession(r.db.QueryRow(userSessionSelect+` WHERE s.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user session: %w", err)
+	}
+	return item, nil
+}
+
+// GetList возвращает страницу журнала сеансов, новые сверху.
+func (r *UserSessionRepository) GetList(filter models.UserSessionFilter) (*models.PagedResult[models.UserSession], error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 50
+	}
+	if filter.PageSize > 100 {
+		filter.PageSize = 100
+	}
+
+	var conditions []string
+	var args []interface{}
+	if filter.ActiveOnly {
+		conditions = append(conditions, "s.ended_at IS NULL")
+	}
+	if filter.UserID != "" {
+		userID, err := uuid.Parse(filter.UserID)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный ID пользователя", err)
+		}
+		args = append(args, userID)
+		conditions = append(conditions, fmt.Sprintf("s.user_id = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM user_sessions s "+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count user sessions: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	query := fmt.Sprintf("%s %s ORDER BY s.started_at DESC LIMIT $%d OFFSET $%d", userSessionSelect, where, len(args)+1, len(args)+2)
+	rows, err := r.db.Query(query, append(args, filter.PageSize, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.UserSession, 0)
+	for rows.Next() {
+		item, err := scanUserSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.PagedResult[models.UserSession]{
+		Items:      items,
+		TotalCount: total,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestUserSessionRepository_Touch(t *testing.T) {
+	sessionID := uuid.New()
+	at := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+
+	t.Run("open session", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserSessionRepository(&database.DB{DB: db})
+
+		mock.ExpectExec(`UPDATE user_sessions\s+SET last_activity_at = GREATEST\(last_activity_at, \$2\)\s+WHERE id = \$1 AND ended_at IS NULL`).
+			WithArgs(sessionID, at).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		open, reason, err := repo.Touch(sessionID, at)
+		require.NoError(t, err)
+		assert.True(t, open)
+		assert.Empty(t, reason)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("terminated session reports the reason", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserSessionRepository(&database.DB{DB: db})
+
+		mock.ExpectExec(`UPDATE user_sessions`).WithArgs(sessionID, at).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT COALESCE\(end_reason, ''\) FROM user_sessions WHERE id = \$1`).
+			WithArgs(sessionID).
+			WillReturnRows(sqlmock.NewRows([]string{"end_reason"}).AddRow(models.SessionEndTerminated))
+
+		open, reason, err := repo.Touch(sessionID, at)
+		require.NoError(t, err)
+		assert.False(t, open)
+		assert.Equal(t, models.SessionEndTerminated, reason)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserSessionRepository_TerminateWithOutbox(t *testing.T) {
+	sessionID := uuid.New()
+	adminID := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "session:terminate", Payload: `{}`}
+
+	t.Run("terminates open session", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserSessionRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(&database.DB{DB: db}))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE user_sessions\s+SET ended_at = CURRENT_TIMESTAMP, end_reason = \$2, terminated_by = \$3\s+WHERE id = \$1 AND ended_at IS NULL`).
+			WithArgs(sessionID, models.SessionEndTerminated, adminID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.TerminateWithOutbox(sessionID, adminID, []models.OutboxEvent{event}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already ended session is a conflict", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		repo := NewUserSessionRepository(&database.DB{DB: db})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE user_sessions`).WithArgs(sessionID, models.SessionEndTerminated, adminID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = repo.TerminateWithOutbox(sessionID, adminID, []models.OutboxEvent{event})
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserSessionRepository_GetList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewUserSessionRepository(&database.DB{DB: db})
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	startedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM user_sessions s WHERE s.ended_at IS NULL AND s.user_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`FROM user_sessions s\s+JOIN users u ON u.id = s.user_id\s+LEFT JOIN users t ON t.id = s.terminated_by\s+WHERE s.ended_at IS NULL AND s.user_id = \$1 ORDER BY s.started_at DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs(userID, 20, 20).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "login", "full_name", "host_name", "started_at", "last_activity_at", "ended_at", "end_reason", "terminated_by_name",
+		}).AddRow(sessionID, userID, "ivanov", "Иванов И.И.", "WS-101", startedAt, startedAt, nil, "", ""))
+
+	result, err := repo.GetList(models.UserSessionFilter{ActiveOnly: true, UserID: userID.String(), Page: 2, PageSize: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TotalCount)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "WS-101", result.Items[0].HostName)
+	assert.True(t, result.Items[0].IsActive())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = repo.GetList(models.UserSessionFilter{UserID: "not-a-uuid"})
+	appErr, ok := models.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, 400, appErr.Code)
+}
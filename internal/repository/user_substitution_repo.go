@@ -6,12 +6,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 )
 
 // UserSubstitutionRepository предоставляет методы работы с замещениями пользователей.
+// Замещения не удаляются: отмененное замещение остается в истории с revoked_at.
 type UserSubstitutionRepository struct {
 	db     *database.DB
 	outbox *OutboxRepository
@@ -30,6 +32,7 @@ func scanUserSubstitution(scanner interface {
 	var item models.UserSubstitution
 	var startsAt sql.NullTime
 	var endsAt sql.NullTime
+	var revokedAt sql.NullTime
 	var createdBy sql.NullString
 	err := scanner.Scan(
 		&item.ID,
@@ -40,6 +43,9 @@ func scanUserSubstitution(scanner interface {
 		&startsAt,
 		&endsAt,
 		&item.IsActive,
+		pq.Array(&item.DocumentKinds),
+		pq.Array(&item.Scopes),
+		&revokedAt,
 		&createdBy,
 		&item.CreatedAt,
 		&item.UpdatedAt,
@@ -53,25 +59,78 @@ func scanUserSubstitution(scanner interface {
 	if endsAt.Valid {
 		item.EndsAt = &endsAt.Time
 	}
+	if revokedAt.Valid {
+		item.RevokedAt = &revokedAt.Time
+	}
 	if createdBy.Valid {
 		if uid, err := uuid.Parse(createdBy.String); err == nil {
 			item.CreatedBy = &uid
 		}
 	}
+	if item.DocumentKinds == nil {
+		item.DocumentKinds = []string{}
+	}
+	if item.Scopes == nil {
+		item.Scopes = []string{}
+	}
 	return &item, nil
 }
 
 const userSubstitutionSelect = `
 	SELECT us.id, us.principal_user_id, us.substitute_user_id,
 	       principal.full_name, substitute.full_name,
-	       us.starts_at, us.ends_at, us.is_active, us.created_by, us.created_at, us.updated_at
+	       us.starts_at, us.ends_at, us.is_active, us.document_kinds, us.scopes, us.revoked_at,
+	       us.created_by, us.created_at, us.updated_at
 	FROM user_substitutions us
 	JOIN users principal ON principal.id = us.principal_user_id
 	JOIN users substitute ON substitute.id = us.substitute_user_id`
 
-// GetByPrincipalID возвращает настройку замещения пользователя.
+// userSubstitutionInEffect отбирает замещения, действующие сегодня.
+const userSubstitutionInEffect = `
+	  AND us.is_active = true
+	  AND us.revoked_at IS NULL
+	  AND (us.starts_at IS NULL OR us.starts_at <= CURRENT_DATE)
+	  AND (us.ends_at IS NULL OR us.ends_at >= CURRENT_DATE)`
+
+func (r *UserSubstitutionRepository) queryUserSubstitutions(errMessage, query string, args ...interface{}) ([]models.UserSubstitution, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errMessage, err)
+	}
+	defer rows.Close()
+
+	items := make([]models.UserSubstitution, 0)
+	for rows.Next() {
+		item, err := scanUserSubstitution(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// GetByID возвращает замещение по ID.
+func (r *UserSubstitutionRepository) GetByID(id uuid.UUID) (*models.UserSubstitution, error) {
+	item, err := scanUserSubstitution(r.db.QueryRow(userSubstitutionSelect+` WHERE us.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user substitution: %w", err)
+	}
+	return item, nil
+}
+
+// GetByPrincipalID возвращает последнее назначенное неотмененное и не
+// завершившееся замещение пользователя.
 func (r *UserSubstitutionRepository) GetByPrincipalID(principalUserID uuid.UUID) (*models.UserSubstitution, error) {
-	item, err := scanUserSubstitution(r.db.QueryRow(userSubstitutionSelect+` WHERE us.principal_user_id = $1`, principalUserID))
+	item, err := scanUserSubstitution(r.db.QueryRow(userSubstitutionSelect+`
+		WHERE us.principal_user_id = $1
+		  AND us.revoked_at IS NULL
+		  AND (us.ends_at IS NULL OR us.ends_at >= CURRENT_DATE)
+		ORDER BY us.created_at DESC
+		LIMIT 1`, principalUserID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -81,53 +140,138 @@ func (r *UserSubstitutionRepository) GetByPrincipalID(principalUserID uuid.UUID)
 	return item, nil
 }
 
-// GetActivePrincipalIDs возвращает пользователей, которых сейчас замещает substituteUserID.
-func (r *UserSubstitutionRepository) GetActivePrincipalIDs(substituteUserID uuid.UUID) ([]uuid.UUID, error) {
+// GetHistoryByPrincipalID возвращает все замещения пользователя, включая
+// запланированные, завершенные и отмененные, начиная с последних.
+func (r *UserSubstitutionRepository) GetHistoryByPrincipalID(principalUserID uuid.UUID) ([]models.UserSubstitution, error) {
+	return r.queryUserSubstitutions("failed to get user substitution history",
+		userSubstitutionSelect+` WHERE us.principal_user_id = $1 ORDER BY us.created_at DESC`, principalUserID)
+}
+
+// GetActiveByPrincipalID возвращает замещения пользователя, действующие сегодня.
+func (r *UserSubstitutionRepository) GetActiveByPrincipalID(principalUserID uuid.UUID) ([]models.UserSubstitution, error) {
+	return r.queryUserSubstitutions("failed to get active user substitutions",
+		userSubstitutionSelect+` WHERE us.principal_user_id = $1`+userSubstitutionInEffect+` ORDER BY us.created_at`, principalUserID)
+}
+
+// GetActiveGrants возвращает действующие сегодня замещения, в которых
+// substituteUserID — замещающий, вместе с их ограничениями.
+func (r *UserSubstitutionRepository) GetActiveGrants(substituteUserID uuid.UUID) ([]models.SubstitutionGrant, error) {
 	rows, err := r.db.Query(`
-		SELECT principal_user_id
-		FROM user_substitutions
-		WHERE substitute_user_id = $1
-		  AND is_active = true
-		  AND (starts_at IS NULL OR starts_at <= CURRENT_DATE)
-		  AND (ends_at IS NULL OR ends_at >= CURRENT_DATE)
+		SELECT us.principal_user_id, us.document_kinds, us.scopes
+		FROM user_substitutions us
+		WHERE us.substitute_user_id = $1`+userSubstitutionInEffect+`
+		ORDER BY us.created_at
 	`, substituteUserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active principals: %w", err)
+		return nil, fmt.Errorf("failed to get active substitutions: %w", err)
 	}
 	defer rows.Close()
 
-	ids := make([]uuid.UUID, 0)
+	grants := make([]models.SubstitutionGrant, 0)
 	for rows.Next() {
-		var id uuid.UUID
-		if err := rows.Scan(&id); err != nil {
+		var grant models.SubstitutionGrant
+		if err := rows.Scan(&grant.PrincipalUserID, pq.Array(&grant.DocumentKinds), pq.Array(&grant.Scopes)); err != nil {
 			return nil, err
 		}
-		ids = append(ids, id)
-	}
-	return ids, rows.Err()
-}
-
-// IsActiveSubstitute проверяет, может ли substituteUserID действовать за principalUserID.
-func (r *UserSubstitutionRepository) IsActiveSubstitute(substituteUserID, principalUserID uuid.UUID) (bool, error) {
-	var ok bool
-	err := r.db.QueryRow(`
-		SELECT EXISTS (
-			SELECT 1
-			FROM user_substitutions
-			WHERE substitute_user_id = $1
-			  AND principal_user_id = $2
-			  AND is_active = true
-			  AND (starts_at IS NULL OR starts_at <= CURRENT_DATE)
-			  AND (ends_at IS NULL OR ends_at >= CURRENT_DATE)
+		grants = append(grants, grant)
+	}
+	return grants, rows.Err()
+}
+
+// Create добавляет замещение, не затрагивая остальные замещения пользователя.
+func (r *UserSubstitutionRepository) Create(substitution models.UserSubstitution) (*models.UserSubstitution, error) {
+	return r.create(substitution, nil)
+}
+
+// CreateWithOutbox добавляет замещение и сохраняет событие аудита в одной транзакции.
+func (r *UserSubstitutionRepository) CreateWithOutbox(substitution models.UserSubstitution, effects []models.OutboxEvent) (*models.UserSubstitution, error) {
+	return r.create(substitution, effects)
+}
+
+func (r *UserSubstitutionRepository) create(substitution models.UserSubstitution, effects []models.OutboxEvent) (*models.UserSubstitution, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	id, err := insertUserSubstitution(tx, substitution)
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func insertUserSubstitution(tx *sql.Tx, substitution models.UserSubstitution) (uuid.UUID, error) {
+	documentKinds := substitution.DocumentKinds
+	if documentKinds == nil {
+		documentKinds = []string{}
+	}
+	scopes := substitution.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	var id uuid.UUID
+	err := tx.QueryRow(`
+		INSERT INTO user_substitutions (
+			principal_user_id, substitute_user_id, starts_at, ends_at, is_active,
+			document_kinds, scopes, created_by
 		)
-	`, substituteUserID, principalUserID).Scan(&ok)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, substitution.PrincipalUserID, substitution.SubstituteUserID, substitution.StartsAt, substitution.EndsAt,
+		substitution.IsActive, pq.Array(documentKinds), pq.Array(scopes), substitution.CreatedBy).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create user substitution: %w", err)
+	}
+	return id, nil
+}
+
+// Revoke отменяет замещение. Запись остается в истории.
+func (r *UserSubstitutionRepository) Revoke(id uuid.UUID) error {
+	return r.revoke(id, nil)
+}
+
+// RevokeWithOutbox отменяет замещение и сохраняет событие аудита в одной транзакции.
+func (r *UserSubstitutionRepository) RevokeWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error {
+	return r.revoke(id, effects)
+}
+
+func (r *UserSubstitutionRepository) revoke(id uuid.UUID, effects []models.OutboxEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`
+		UPDATE user_substitutions
+		SET revoked_at = CURRENT_TIMESTAMP, is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id)
 	if err != nil {
-		return false, fmt.Errorf("failed to check active substitution: %w", err)
+		return fmt.Errorf("failed to revoke user substitution: %w", err)
 	}
-	return ok, nil
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewConflict("замещение уже отменено")
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// ReplaceForPrincipal сохраняет единственную настройку замещения для пользователя.
+// ReplaceForPrincipal назначает пользователю единственного замещающего:
+// действующие и запланированные замещения отменяются и остаются в истории.
+// Если substituteUserID пуст, замещения только отменяются.
 func (r *UserSubstitutionRepository) ReplaceForPrincipal(
 	principalUserID uuid.UUID,
 	substituteUserID *uuid.UUID,
@@ -162,46 +306,33 @@ func (r *UserSubstitutionRepository) replaceForPrincipal(
 	createdBy *uuid.UUID,
 	effects []models.OutboxEvent,
 ) (*models.UserSubstitution, error) {
-	if effects == nil {
-		if substituteUserID == nil || *substituteUserID == uuid.Nil {
-			if _, err := r.db.Exec(`DELETE FROM user_substitutions WHERE principal_user_id = $1`, principalUserID); err != nil {
-				return nil, fmt.Errorf("failed to delete user substitution: %w", err)
-			}
-			return nil, nil
-		}
-		_, err := r.db.Exec(`
-			INSERT INTO user_substitutions (principal_user_id, substitute_user_id, starts_at, ends_at, is_active, created_by)
-			VALUES ($1, $2, $3, $4, $5, $6)
-			ON CONFLICT (principal_user_id) DO UPDATE SET substitute_user_id = EXCLUDED.substitute_user_id, starts_at = EXCLUDED.starts_at, ends_at = EXCLUDED.ends_at, is_active = EXCLUDED.is_active, created_by = EXCLUDED.created_by, updated_at = CURRENT_TIMESTAMP
-		`, principalUserID, *substituteUserID, startsAt, endsAt, isActive, createdBy)
-		if err != nil {
-			return nil, fmt.Errorf("failed to replace user substitution: %w", err)
-		}
-		return r.GetByPrincipalID(principalUserID)
-	}
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
-	if substituteUserID == nil || *substituteUserID == uuid.Nil {
-		if _, err := tx.Exec(`DELETE FROM user_substitutions WHERE principal_user_id = $1`, principalUserID); err != nil {
-			return nil, fmt.Errorf("failed to delete user substitution: %w", err)
+	if _, err := tx.Exec(`
+		UPDATE user_substitutions
+		SET revoked_at = CURRENT_TIMESTAMP, is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE principal_user_id = $1
+		  AND revoked_at IS NULL
+		  AND (ends_at IS NULL OR ends_at >= CURRENT_DATE)
+	`, principalUserID); err != nil {
+		return nil, fmt.Errorf("failed to revoke user substitutions: %w", err)
+	}
+	id := uuid.Nil
+	if substituteUserID != nil && *substituteUserID != uuid.Nil {
+		id, err = insertUserSubstitution(tx, models.UserSubstitution{
+			PrincipalUserID:  principalUserID,
+			SubstituteUserID: *substituteUserID,
+			StartsAt:         startsAt,
+			EndsAt:           endsAt,
+			IsActive:         isActive,
+			CreatedBy:        createdBy,
+		})
+		if err != nil {
+			return nil, err
 		}
-	} else if _, err := tx.Exec(`
-		INSERT INTO user_substitutions (
-			principal_user_id, substitute_user_id, starts_at, ends_at, is_active, created_by
-		)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (principal_user_id) DO UPDATE
-		SET substitute_user_id = EXCLUDED.substitute_user_id,
-		    starts_at = EXCLUDED.starts_at,
-		    ends_at = EXCLUDED.ends_at,
-		    is_active = EXCLUDED.is_active,
-		    created_by = EXCLUDED.created_by,
-		    updated_at = CURRENT_TIMESTAMP
-		`, principalUserID, *substituteUserID, startsAt, endsAt, isActive, createdBy); err != nil {
-		return nil, fmt.Errorf("failed to replace user substitution: %w", err)
 	}
 	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
 		return nil, err
@@ -209,8 +340,49 @@ func (r *UserSubstitutionRepository) replaceForPrincipal(
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
-	if substituteUserID == nil || *substituteUserID == uuid.Nil {
+	if id == uuid.Nil {
 		return nil, nil
 	}
-	return r.GetByPrincipalID(principalUserID)
+	return r.GetByID(id)
+}
+
+// GetPendingExpiryNotifications возвращает действующие замещения, которые
+// заканчиваются не позже завтрашнего дня и о которых еще не уведомляли.
+func (r *UserSubstitutionRepository) GetPendingExpiryNotifications() ([]models.UserSubstitution, error) {
+	return r.queryUserSubstitutions("failed to get expiring user substitutions", userSubstitutionSelect+`
+		WHERE us.is_active = true
+		  AND us.revoked_at IS NULL
+		  AND us.expiry_notified_at IS NULL
+		  AND us.ends_at <= CURRENT_DATE + 1
+		ORDER BY us.ends_at, us.created_at`)
+}
+
+// MarkExpiryNotifiedWithOutbox отмечает уведомление об окончании замещения и
+// сохраняет события в одной транзакции. false означает, что уведомление уже
+// отправлено другим процессом.
+func (r *UserSubstitutionRepository) MarkExpiryNotifiedWithOutbox(id uuid.UUID, effects []models.OutboxEvent) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`
+		UPDATE user_substitutions
+		SET expiry_notified_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND expiry_notified_at IS NULL
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark substitution expiry notified: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
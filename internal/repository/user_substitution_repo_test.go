@@ -6,6 +6,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -13,6 +14,12 @@ import (
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 )
 
+var userSubstitutionColumns = []string{
+	"id", "principal_user_id", "substitute_user_id", "principal_name", "substitute_name",
+	"starts_at", "ends_at", "is_active", "document_kinds", "scopes", "revoked_at",
+	"created_by", "created_at", "updated_at",
+}
+
 func TestUserSubstitutionRepository_GetByPrincipalID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -24,12 +31,10 @@ func TestUserSubstitutionRepository_GetByPrincipalID(t *testing.T) {
 	substituteID := uuid.New()
 	now := time.Now()
 
-	mock.ExpectQuery(`SELECT us\.id, us\.principal_user_id, us\.substitute_user_id,`).
+	mock.ExpectQuery(`SELECT us\.id, us\.principal_user_id, us\.substitute_user_id,.*us\.revoked_at IS NULL.*ORDER BY us\.created_at DESC\s+LIMIT 1`).
 		WithArgs(principalID).
-		WillReturnRows(sqlmock.NewRows([]string{
-			"id", "principal_user_id", "substitute_user_id", "principal_name", "substitute_name",
-			"starts_at", "ends_at", "is_active", "created_by", "created_at", "updated_at",
-		}).AddRow(id, principalID, substituteID, "Principal", "Substitute", now, nil, true, nil, now, now))
+		WillReturnRows(sqlmock.NewRows(userSubstitutionColumns).
+			AddRow(id, principalID, substituteID, "Principal", "Substitute", now, nil, true, "{}", "{acknowledgments}", nil, nil, now, now))
 
 	result, err := repo.GetByPrincipalID(principalID)
 
@@ -42,31 +47,141 @@ func TestUserSubstitutionRepository_GetByPrincipalID(t *testing.T) {
 	assert.Equal(t, "Substitute", result.SubstituteName)
 	require.NotNil(t, result.StartsAt)
 	assert.Nil(t, result.EndsAt)
+	assert.Equal(t, []string{}, result.DocumentKinds)
+	assert.Equal(t, []string{models.SubstitutionScopeAcknowledgments}, result.Scopes)
+	assert.Nil(t, result.RevokedAt)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestUserSubstitutionRepository_GetActivePrincipalIDs(t *testing.T) {
+func TestUserSubstitutionRepository_GetHistoryByPrincipalID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
 	repo := NewUserSubstitutionRepository(&database.DB{DB: db})
-	substituteID := uuid.New()
 	principalID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM user_substitutions us.*WHERE us\.principal_user_id = \$1 ORDER BY us\.created_at DESC`).
+		WithArgs(principalID).
+		WillReturnRows(sqlmock.NewRows(userSubstitutionColumns).
+			AddRow(uuid.New(), principalID, uuid.New(), "Principal", "First", nil, nil, true, "{incoming_letter}", "{}", nil, nil, now, now).
+			AddRow(uuid.New(), principalID, uuid.New(), "Principal", "Second", nil, nil, false, "{}", "{}", now, nil, now, now))
+
+	result, err := repo.GetHistoryByPrincipalID(principalID)
 
-	mock.ExpectQuery(`SELECT principal_user_id\s+FROM user_substitutions`).
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, []string{"incoming_letter"}, result[0].DocumentKinds)
+	require.NotNil(t, result[1].RevokedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserSubstitutionRepository_GetActiveGrants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserSubstitutionRepository(&database.DB{DB: db})
+	substituteID := uuid.New()
+	firstPrincipal, secondPrincipal := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT us\.principal_user_id, us\.document_kinds, us\.scopes\s+FROM user_substitutions us\s+WHERE us\.substitute_user_id = \$1\s+AND us\.is_active = true\s+AND us\.revoked_at IS NULL`).
 		WithArgs(substituteID).
-		WillReturnRows(sqlmock.NewRows([]string{"principal_user_id"}).AddRow(principalID))
+		WillReturnRows(sqlmock.NewRows([]string{"principal_user_id", "document_kinds", "scopes"}).
+			AddRow(firstPrincipal, "{}", "{}").
+			AddRow(secondPrincipal, "{incoming_letter}", "{assignments,acknowledgments}"))
+
+	result, err := repo.GetActiveGrants(substituteID)
 
-	result, err := repo.GetActivePrincipalIDs(substituteID)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, firstPrincipal, result[0].PrincipalUserID)
+	assert.True(t, result[0].Covers(models.SubstitutionScopeApprovals, models.DocumentKindOutgoingLetter))
+	assert.Equal(t, []string{"incoming_letter"}, result[1].DocumentKinds)
+	assert.Equal(t, []string{"assignments", "acknowledgments"}, result[1].Scopes)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
 
+func TestUserSubstitutionRepository_CreateKeepsExistingSubstitutions(t *testing.T) {
+	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
-	assert.Equal(t, []uuid.UUID{principalID}, result)
+	defer db.Close()
+
+	repo := NewUserSubstitutionRepository(&database.DB{DB: db})
+	id, principalID, substituteID := uuid.New(), uuid.New(), uuid.New()
+	endsAt := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO user_substitutions`).
+		WithArgs(principalID, substituteID, (*time.Time)(nil), &endsAt, true,
+			pq.Array([]string{"incoming_letter"}), pq.Array([]string{}), (*uuid.UUID)(nil)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`FROM user_substitutions us.*WHERE us\.id = \$1`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(userSubstitutionColumns).
+			AddRow(id, principalID, substituteID, "Principal", "Substitute", nil, endsAt, true, "{incoming_letter}", "{}", nil, nil, now, now))
+
+	result, err := repo.Create(models.UserSubstitution{
+		PrincipalUserID:  principalID,
+		SubstituteUserID: substituteID,
+		EndsAt:           &endsAt,
+		IsActive:         true,
+		DocumentKinds:    []string{"incoming_letter"},
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, id, result.ID)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserSubstitutionRepository_Revoke(t *testing.T) {
+	t.Run("keeps revoked substitution in history", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := NewUserSubstitutionRepository(&database.DB{DB: db})
+		repo.SetOutbox(NewOutboxRepository(repo.db))
+		id := uuid.New()
+		event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "substitution:" + id.String(), Payload: `{"action":"revoke"}`}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE user_substitutions\s+SET revoked_at = CURRENT_TIMESTAMP, is_active = false`).
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.RevokeWithOutbox(id, []models.OutboxEvent{event}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects already revoked substitution", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		repo := NewUserSubstitutionRepository(&database.DB{DB: db})
+		id := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE user_substitutions`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = repo.Revoke(id)
+		var appErr *models.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 409, appErr.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestUserSubstitutionRepository_ReplaceForPrincipal(t *testing.T) {
-	t.Run("deletes substitution when substitute is empty", func(t *testing.T) {
+	t.Run("revokes substitutions when substitute is empty", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
@@ -74,9 +189,11 @@ func TestUserSubstitutionRepository_ReplaceForPrincipal(t *testing.T) {
 		repo := NewUserSubstitutionRepository(&database.DB{DB: db})
 		principalID := uuid.New()
 
-		mock.ExpectExec(`DELETE FROM user_substitutions WHERE principal_user_id = \$1`).
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE user_substitutions\s+SET revoked_at = CURRENT_TIMESTAMP.*WHERE principal_user_id = \$1`).
 			WithArgs(principalID).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
 
 		result, err := repo.ReplaceForPrincipal(principalID, nil, nil, nil, false, nil)
 
@@ -85,33 +202,39 @@ func TestUserSubstitutionRepository_ReplaceForPrincipal(t *testing.T) {
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("upserts substitution and reloads it", func(t *testing.T) {
+	t.Run("revokes current substitutions, inserts new one and reloads it", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
 		repo := NewUserSubstitutionRepository(&database.DB{DB: db})
+		id := uuid.New()
 		principalID := uuid.New()
 		substituteID := uuid.New()
 		createdBy := uuid.New()
 		startsAt := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
 		now := time.Now()
 
-		mock.ExpectExec(`INSERT INTO user_substitutions`).
-			WithArgs(principalID, substituteID, &startsAt, (*time.Time)(nil), true, &createdBy).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectQuery(`SELECT us\.id, us\.principal_user_id, us\.substitute_user_id,`).
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE user_substitutions`).
 			WithArgs(principalID).
-			WillReturnRows(sqlmock.NewRows([]string{
-				"id", "principal_user_id", "substitute_user_id", "principal_name", "substitute_name",
-				"starts_at", "ends_at", "is_active", "created_by", "created_at", "updated_at",
-			}).AddRow(uuid.New(), principalID, substituteID, "Principal", "Substitute", startsAt, nil, true, createdBy.String(), now, now))
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO user_substitutions`).
+			WithArgs(principalID, substituteID, &startsAt, (*time.Time)(nil), true, pq.Array([]string{}), pq.Array([]string{}), &createdBy).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT us\.id, us\.principal_user_id, us\.substitute_user_id,`).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows(userSubstitutionColumns).
+				AddRow(id, principalID, substituteID, "Principal", "Substitute", startsAt, nil, true, "{}", "{}", nil, createdBy.String(), now, now))
 
 		result, err := repo.ReplaceForPrincipal(principalID, &substituteID, &startsAt, nil, true, &createdBy)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
 		assert.Equal(t, substituteID, result.SubstituteUserID)
+		require.NotNil(t, result.CreatedBy)
+		assert.Equal(t, createdBy, *result.CreatedBy)
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 }
@@ -127,9 +250,10 @@ func TestUserSubstitutionRepositoryReplaceForPrincipalWithOutboxRollsBackOnEnque
 	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "substitution:" + principalID.String(), Payload: `{"action":"update"}`}
 
 	mock.ExpectBegin()
-	mock.ExpectExec(`INSERT INTO user_substitutions`).
-		WithArgs(principalID, substituteID, (*time.Time)(nil), (*time.Time)(nil), true, (*uuid.UUID)(nil)).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE user_substitutions`).WithArgs(principalID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO user_substitutions`).
+		WithArgs(principalID, substituteID, (*time.Time)(nil), (*time.Time)(nil), true, pq.Array([]string{}), pq.Array([]string{}), (*uuid.UUID)(nil)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
 	mock.ExpectExec(`INSERT INTO event_outbox`).WithArgs(event.EventType, event.DeduplicationKey, event.Payload).WillReturnError(assert.AnError)
 	mock.ExpectRollback()
 
@@ -137,3 +261,25 @@ func TestUserSubstitutionRepositoryReplaceForPrincipalWithOutboxRollsBackOnEnque
 	require.ErrorIs(t, err, assert.AnError)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestUserSubstitutionRepository_MarkExpiryNotifiedWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserSubstitutionRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	id := uuid.New()
+	event := models.OutboxEvent{EventType: models.OutboxEventUserEvent, DeduplicationKey: "user-substitution:" + id.String(), Payload: `{}`}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE user_substitutions\s+SET expiry_notified_at = CURRENT_TIMESTAMP\s+WHERE id = \$1 AND expiry_notified_at IS NULL`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	notified, err := repo.MarkExpiryNotifiedWithOutbox(id, []models.OutboxEvent{event})
+	require.NoError(t, err)
+	assert.False(t, notified, "повторное уведомление не отправляется")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -83,13 +83,13 @@ func TestAtomicOutboxWorkflowsIntegration(t *testing.T) {
 	deadline := time.Now().UTC().Add(24 * time.Hour)
 	assignmentID := uuid.New()
 	event := models.OutboxEvent{EventType: models.OutboxEventJournal, DeduplicationKey: "assignment-ok", Payload: `{}`}
-	if _, err := assignments.CreateWithOutbox(assignmentID, documentID, userID, "integration", &deadline, nil, []models.OutboxEvent{event}); err != nil {
+	if _, err := assignments.CreateWithOutbox(assignmentID, documentID, userID, "integration", &deadline, nil, userID, []models.OutboxEvent{event}); err != nil {
 		t.Fatalf("create assignment with outbox: %v", err)
 	}
 	assertScalar(t, sqlDB, `SELECT COUNT(*) FROM assignments WHERE id = $1`, []any{assignmentID}, 1)
 	assertScalar(t, sqlDB, `SELECT COUNT(*) FROM event_outbox WHERE deduplication_key = 'assignment-ok'`, nil, 1)
 
-	_, err := assignments.CreateWithOutbox(uuid.New(), documentID, userID, "rollback", &deadline, nil, []models.OutboxEvent{{EventType: models.OutboxEventJournal, DeduplicationKey: "assignment-bad", Payload: "{"}})
+	_, err := assignments.CreateWithOutbox(uuid.New(), documentID, userID, "rollback", &deadline, nil, userID, []models.OutboxEvent{{EventType: models.OutboxEventJournal, DeduplicationKey: "assignment-bad", Payload: "{"}})
 	if err == nil {
 		t.Fatal("invalid JSON outbox payload unexpectedly committed")
 	}
@@ -144,7 +144,7 @@ func seedIntegrationDocument(t *testing.T, db *sql.DB) (uuid.UUID, uuid.UUID) {
 	orgID := uuid.New()
 	execSQL(t, db, `INSERT INTO nomenclature (id, name, index, year, kind_code, separator, numbering_mode, next_number) VALUES ($1, 'Integration', 'IT', 2026, 'outgoing_letter', '/', 'index_and_number', 2)`, nomID)
 	execSQL(t, db, `INSERT INTO organizations (id, name) VALUES ($1, 'Workflow Recipient')`, orgID)
-	execSQL(t, db, `INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by) VALUES ($1, 'outgoing_letter', $2, $3, 'IT/1', CURRENT_DATE, $4, 'integration document', 1, $5)`, docID, nomID, uuid.New(), models.DocumentTypeLetter, userID)
+	execSQL(t, db, `INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by) VALUES ($1, 'outgoing_letter', $2, $3, 'IT/1', CURRENT_DATE, (SELECT id FROM document_types WHERE name = $4), 'integration document', 1, $5)`, docID, nomID, uuid.New(), models.DocumentTypeLetter, userID)
 	execSQL(t, db, `INSERT INTO outgoing_document_details (document_id, outgoing_number, outgoing_date, sender_signatory, sender_executor, recipient_org_id, addressee) VALUES ($1, 'IT/1', CURRENT_DATE, 'Signer', 'Executor', $2, 'Addressee')`, docID, orgID)
 	return userID, docID
 }
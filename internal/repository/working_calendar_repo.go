@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// WorkingCalendarRepository предоставляет методы работы с исключениями производственного календаря.
+type WorkingCalendarRepository struct {
+	db     *database.DB
+	outbox *OutboxRepository
+}
+
+func (r *WorkingCalendarRepository) SetOutbox(outbox *OutboxRepository) { r.outbox = outbox }
+
+// NewWorkingCalendarRepository создает новый экземпляр WorkingCalendarRepository.
+func NewWorkingCalendarRepository(db *database.DB) *WorkingCalendarRepository {
+	return &WorkingCalendarRepository{db: db}
+}
+
+// GetDays возвращает исключения календаря в диапазоне дат включительно.
+func (r *WorkingCalendarRepository) GetDays(from, to time.Time) ([]models.WorkingCalendarDay, error) {
+	rows, err := r.db.Query(`
+		SELECT day, is_working_day, description, updated_at
+		FROM working_calendar_days
+		WHERE day BETWEEN $1 AND $2
+		ORDER BY day
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working calendar days: %w", err)
+	}
+	defer rows.Close()
+
+	days := make([]models.WorkingCalendarDay, 0)
+	for rows.Next() {
+		var day models.WorkingCalendarDay
+		if err := rows.Scan(&day.Day, &day.IsWorkingDay, &day.Description, &day.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan working calendar day error: %w", err)
+		}
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("working calendar days rows error: %w", err)
+	}
+	return days, nil
+}
+
+// SaveWithOutbox создает или обновляет исключение календаря вместе с событием аудита.
+func (r *WorkingCalendarRepository) SaveWithOutbox(day models.WorkingCalendarDay, effects []models.OutboxEvent) error {
+	if r.outbox == nil {
+		return ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`
+		INSERT INTO working_calendar_days (day, is_working_day, description, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (day) DO UPDATE
+		SET is_working_day = EXCLUDED.is_working_day, description = EXCLUDED.description, updated_at = NOW()
+	`, day.Day, day.IsWorkingDay, day.Description); err != nil {
+		return fmt.Errorf("failed to save working calendar day: %w", err)
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteWithOutbox удаляет исключение календаря вместе с событием аудита.
+func (r *WorkingCalendarRepository) DeleteWithOutbox(day time.Time, effects []models.OutboxEvent) error {
+	if r.outbox == nil {
+		return ErrOutboxNotConfigured
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM working_calendar_days WHERE day = $1`, day); err != nil {
+		return fmt.Errorf("failed to delete working calendar day: %w", err)
+	}
+	if err := enqueueOutboxEffects(r.outbox, tx, effects); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
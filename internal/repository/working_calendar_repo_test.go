@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestWorkingCalendarRepository_GetDays(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWorkingCalendarRepository(&database.DB{DB: db})
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT day, is_working_day, description, updated_at\s+FROM working_calendar_days\s+WHERE day BETWEEN \$1 AND \$2`).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "is_working_day", "description", "updated_at"}).
+			AddRow(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), false, "Новогодние каникулы", now).
+			AddRow(time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), true, "Перенос рабочего дня", now))
+
+	days, err := repo.GetDays(from, to)
+
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.False(t, days[0].IsWorkingDay)
+	assert.True(t, days[1].IsWorkingDay)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkingCalendarRepository_SaveWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWorkingCalendarRepository(&database.DB{DB: db})
+	repo.SetOutbox(NewOutboxRepository(repo.db))
+	day := models.WorkingCalendarDay{Day: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Description: "Праздник весны и труда"}
+	event := models.OutboxEvent{EventType: models.OutboxEventAudit, DeduplicationKey: "working-calendar:2026-05-01", Payload: `{"action":"WORKING_CALENDAR_UPDATE"}`}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO working_calendar_days`).
+		WithArgs(day.Day, false, day.Description).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO event_outbox`).
+		WithArgs(event.EventType, event.DeduplicationKey, event.Payload).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err = repo.SaveWithOutbox(day, []models.OutboxEvent{event})
+
+	require.ErrorIs(t, err, assert.AnError)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkingCalendarRepositoryRequiresOutbox(t *testing.T) {
+	repo := NewWorkingCalendarRepository(nil)
+	require.ErrorIs(t, repo.SaveWithOutbox(models.WorkingCalendarDay{}, nil), ErrOutboxNotConfigured)
+	require.ErrorIs(t, repo.DeleteWithOutbox(time.Now(), nil), ErrOutboxNotConfigured)
+}
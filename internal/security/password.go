@@ -14,40 +14,6 @@ func VerifyPassword(hashedPassword, password string) bool {
 	return err == nil
 }
 
-// ValidatePassword проверяет пароль на требования безопасности:
-// минимум 8 символов, заглавные и строчные буквы, цифра или спецсимвол
-func ValidatePassword(password string) error {
-	if len(password) < 8 {
-		return fmt.Errorf("пароль должен содержать минимум 8 символов")
-	}
-
-	var hasUpper, hasLower, hasDigit, hasSpecial bool
-	for _, ch := range password {
-		switch {
-		case 'A' <= ch && ch <= 'Z':
-			hasUpper = true
-		case 'a' <= ch && ch <= 'z':
-			hasLower = true
-		case '0' <= ch && ch <= '9':
-			hasDigit = true
-		default:
-			hasSpecial = true
-		}
-	}
-
-	if !hasUpper {
-		return fmt.Errorf("пароль должен содержать хотя бы одну заглавную букву")
-	}
-	if !hasLower {
-		return fmt.Errorf("пароль должен содержать хотя бы одну строчную букву")
-	}
-	if !hasDigit && !hasSpecial {
-		return fmt.Errorf("пароль должен содержать хотя бы одну цифру или спецсимвол")
-	}
-
-	return nil
-}
-
 // HashPassword хеширует пароль с использованием алгоритма bcrypt.
 func HashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -57,14 +23,23 @@ func HashPassword(password string) (string, error) {
 	return string(hash), nil
 }
 
+// temporaryPasswordLength — длина временного пароля, если политика не требует большей.
+const temporaryPasswordLength = 14
+
 // GenerateTemporaryPassword создает временный пароль, соответствующий требованиям сложности.
 func GenerateTemporaryPassword() (string, error) {
+	return generateTemporaryPassword(temporaryPasswordLength)
+}
+
+// generateTemporaryPassword создает пароль длины length, содержащий символы
+// всех классов.
+func generateTemporaryPassword(length int) (string, error) {
 	const letters = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
 	const specials = "!@#$%*-_"
 	parts := []byte{'A', 'a', '7', '!'}
 	alphabet := letters + specials
 
-	for len(parts) < 14 {
+	for len(parts) < length {
 		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
 		if err != nil {
 			return "", fmt.Errorf("failed to generate temporary password: %w", err)
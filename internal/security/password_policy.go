@@ -0,0 +1,277 @@
+package security
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Границы параметров политики паролей.
+const (
+	MinPasswordMinLength    = 6
+	MaxPasswordMinLength    = 64
+	MaxPasswordHistoryDepth = 24
+	MinLockoutThreshold     = 3
+	MaxLockoutThreshold     = 20
+)
+
+// DefaultRequiredClasses — требования к классам символов по умолчанию:
+// заглавная буква, строчная буква, цифра или спецсимвол.
+const DefaultRequiredClasses = "upper,lower,digit|special"
+
+// CharClass — набор классов символов пароля (битовая маска).
+type CharClass int
+
+// Классы символов пароля.
+const (
+	ClassUpper CharClass = 1 << iota
+	ClassLower
+	ClassDigit
+	ClassSpecial
+)
+
+var charClassNames = []struct {
+	class CharClass
+	name  string
+	label string
+}{
+	{ClassUpper, "upper", "заглавную букву"},
+	{ClassLower, "lower", "строчную букву"},
+	{ClassDigit, "digit", "цифру"},
+	{ClassSpecial, "special", "спецсимвол"},
+}
+
+// Правила политики, по которым группируются нарушения.
+const (
+	PasswordRuleLength  = "length"
+	PasswordRuleClasses = "classes"
+	PasswordRuleCommon  = "common"
+)
+
+// PasswordPolicy — требования к паролям локальных учетных записей и
+// параметры блокировки после неверных попыток входа.
+type PasswordPolicy struct {
+	MinLength int
+	// RequiredClasses — каждое требование выполняется, если в пароле есть
+	// символ хотя бы одного класса из маски.
+	RequiredClasses []CharClass
+	RejectCommon    bool
+	// HistoryDepth — сколько последних паролей, включая текущий, нельзя
+	// использовать повторно; 0 отключает проверку.
+	HistoryDepth int
+	// LockoutThreshold — число неверных попыток подряд до блокировки.
+	LockoutThreshold int
+	// LockoutDuration — через сколько блокировка снимается автоматически;
+	// 0 — только администратором.
+	LockoutDuration time.Duration
+}
+
+// DefaultPasswordPolicy возвращает политику, действующую без настроек.
+func DefaultPasswordPolicy() PasswordPolicy {
+	classes, _ := ParseRequiredClasses(DefaultRequiredClasses)
+	return PasswordPolicy{
+		MinLength:        8,
+		RequiredClasses:  classes,
+		LockoutThreshold: 5,
+	}
+}
+
+// PasswordProfile — характеристики пароля, по которым он проверяется на
+// соответствие политике. Длина ограничена MaxPasswordMinLength.
+type PasswordProfile struct {
+	Length  int
+	Classes CharClass
+	Common  bool
+}
+
+// PasswordViolation — невыполненное требование политики.
+type PasswordViolation struct {
+	Rule    string
+	Message string
+}
+
+// ProfilePassword вычисляет профиль пароля.
+func ProfilePassword(password string) PasswordProfile {
+	profile := PasswordProfile{
+		Length: min(utf8.RuneCountInString(password), MaxPasswordMinLength),
+		Common: IsCommonPassword(password),
+	}
+	for _, ch := range password {
+		switch {
+		case unicode.IsUpper(ch):
+			profile.Classes |= ClassUpper
+		case unicode.IsLower(ch):
+			profile.Classes |= ClassLower
+		case unicode.IsDigit(ch):
+			profile.Classes |= ClassDigit
+		default:
+			profile.Classes |= ClassSpecial
+		}
+	}
+	return profile
+}
+
+// Check возвращает все требования политики, которым не соответствует пароль
+// с профилем profile.
+func (p PasswordPolicy) Check(profile PasswordProfile) []PasswordViolation {
+	var violations []PasswordViolation
+	if profile.Length < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleLength,
+			Message: fmt.Sprintf("пароль должен содержать минимум %d символов", p.MinLength),
+		})
+	}
+	for _, required := range p.RequiredClasses {
+		if profile.Classes&required == 0 {
+			violations = append(violations, PasswordViolation{
+				Rule:    PasswordRuleClasses,
+				Message: "пароль должен содержать хотя бы " + classLabel(required),
+			})
+		}
+	}
+	if p.RejectCommon && profile.Common {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleCommon,
+			Message: "пароль входит в список распространенных паролей",
+		})
+	}
+	return violations
+}
+
+// Validate проверяет новый пароль по политике и возвращает первое нарушение.
+func (p PasswordPolicy) Validate(password string) error {
+	if violations := p.Check(ProfilePassword(password)); len(violations) > 0 {
+		return errors.New(violations[0].Message)
+	}
+	return nil
+}
+
+// StricterThan сообщает, может ли пароль, соответствующий требованиям к
+// составу политики previous, не соответствовать политике p. История и
+// параметры блокировки не учитываются: они не относятся к уже заданному
+// паролю.
+func (p PasswordPolicy) StricterThan(previous PasswordPolicy) bool {
+	if p.MinLength > previous.MinLength || p.RejectCommon && !previous.RejectCommon {
+		return true
+	}
+	for _, required := range p.RequiredClasses {
+		// Требование выполнено заранее, если прежде требовалась маска,
+		// целиком входящая в новую.
+		implied := false
+		for _, prev := range previous.RequiredClasses {
+			if prev&^required == 0 {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckHistory отклоняет пароль, совпадающий с одним из hashes — хешей
+// текущего и предыдущих паролей, от новых к старым. Проверяются не более
+// HistoryDepth хешей.
+func (p PasswordPolicy) CheckHistory(password string, hashes []string) error {
+	if p.HistoryDepth <= 0 {
+		return nil
+	}
+	if len(hashes) > p.HistoryDepth {
+		hashes = hashes[:p.HistoryDepth]
+	}
+	for _, hash := range hashes {
+		if VerifyPassword(hash, password) {
+			if p.HistoryDepth == 1 {
+				return errors.New("новый пароль должен отличаться от текущего")
+			}
+			return fmt.Errorf("пароль совпадает с одним из %d последних паролей", p.HistoryDepth)
+		}
+	}
+	return nil
+}
+
+// GenerateTemporary создает временный пароль, удовлетворяющий политике.
+func (p PasswordPolicy) GenerateTemporary() (string, error) {
+	return generateTemporaryPassword(max(temporaryPasswordLength, p.MinLength))
+}
+
+// ParseRequiredClasses разбирает требования к классам символов: требования
+// перечисляются через запятую, альтернативы внутри требования — через «|»,
+// например «upper,lower,digit|special». Пустая строка — без требований.
+func ParseRequiredClasses(value string) ([]CharClass, error) {
+	var classes []CharClass
+	for _, requirement := range strings.Split(value, ",") {
+		requirement = strings.TrimSpace(requirement)
+		if requirement == "" {
+			continue
+		}
+		var mask CharClass
+		for _, name := range strings.Split(requirement, "|") {
+			class, ok := charClassByName(strings.ToLower(strings.TrimSpace(name)))
+			if !ok {
+				return nil, fmt.Errorf("неизвестный класс символов %q", strings.TrimSpace(name))
+			}
+			mask |= class
+		}
+		classes = append(classes, mask)
+	}
+	return classes, nil
+}
+
+func charClassByName(name string) (CharClass, bool) {
+	for _, item := range charClassNames {
+		if item.name == name {
+			return item.class, true
+		}
+	}
+	return 0, false
+}
+
+// classLabel согласует числительное с первым классом маски: «одну цифру
+// или спецсимвол», «один спецсимвол».
+func classLabel(mask CharClass) string {
+	labels := make([]string, 0, len(charClassNames))
+	for _, item := range charClassNames {
+		if mask&item.class != 0 {
+			labels = append(labels, item.label)
+		}
+	}
+	quantifier := "одну "
+	if len(labels) > 0 && labels[0] == "спецсимвол" {
+		quantifier = "один "
+	}
+	return quantifier + strings.Join(labels, " или ")
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+var (
+	commonPasswordsOnce sync.Once
+	commonPasswords     map[string]struct{}
+)
+
+// IsCommonPassword сообщает, входит ли пароль (без учета регистра) во
+// встроенный список распространенных паролей.
+func IsCommonPassword(password string) bool {
+	commonPasswordsOnce.Do(func() {
+		lines := strings.Split(commonPasswordsList, "\n")
+		commonPasswords = make(map[string]struct{}, len(lines))
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			commonPasswords[strings.ToLower(line)] = struct{}{}
+		}
+	})
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
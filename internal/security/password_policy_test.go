@@ -0,0 +1,113 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequiredClasses(t *testing.T) {
+	classes, err := ParseRequiredClasses(DefaultRequiredClasses)
+	require.NoError(t, err)
+	assert.Equal(t, []CharClass{ClassUpper, ClassLower, ClassDigit | ClassSpecial}, classes)
+
+	classes, err = ParseRequiredClasses(" ")
+	require.NoError(t, err)
+	assert.Empty(t, classes)
+
+	_, err = ParseRequiredClasses("upper,emoji")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "emoji")
+}
+
+func TestProfilePassword(t *testing.T) {
+	profile := ProfilePassword("Пароль-2026")
+	assert.Equal(t, 11, profile.Length)
+	assert.Equal(t, ClassUpper|ClassLower|ClassDigit|ClassSpecial, profile.Classes)
+	assert.False(t, profile.Common)
+
+	assert.True(t, ProfilePassword("P@ssw0rd").Common)
+	assert.Equal(t, MaxPasswordMinLength, ProfilePassword(string(make([]byte, 100))).Length)
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:       12,
+		RequiredClasses: []CharClass{ClassUpper, ClassDigit, ClassSpecial},
+		RejectCommon:    true,
+	}
+
+	violations := policy.Check(ProfilePassword("P@ssw0rd"))
+	require.Len(t, violations, 2)
+	assert.Equal(t, PasswordRuleLength, violations[0].Rule)
+	assert.Equal(t, "пароль должен содержать минимум 12 символов", violations[0].Message)
+	assert.Equal(t, PasswordRuleCommon, violations[1].Rule)
+
+	violations = policy.Check(ProfilePassword("longpasswordwithoutclasses"))
+	require.Len(t, violations, 3)
+	assert.Equal(t, "пароль должен содержать хотя бы одну заглавную букву", violations[0].Message)
+	assert.Equal(t, "пароль должен содержать хотя бы одну цифру", violations[1].Message)
+	assert.Equal(t, "пароль должен содержать хотя бы один спецсимвол", violations[2].Message)
+
+	assert.NoError(t, policy.Validate("Длинный-Пароль-2026"))
+	assert.NoError(t, DefaultPasswordPolicy().Validate("P@ssw0rd"), "common passwords are allowed unless the policy rejects them")
+}
+
+func TestPasswordPolicyStricterThan(t *testing.T) {
+	base := DefaultPasswordPolicy()
+
+	assert.False(t, base.StricterThan(base))
+
+	longer := base
+	longer.MinLength = 12
+	assert.True(t, longer.StricterThan(base))
+	assert.False(t, base.StricterThan(longer))
+
+	common := base
+	common.RejectCommon = true
+	assert.True(t, common.StricterThan(base))
+
+	digit := base
+	digit.RequiredClasses = []CharClass{ClassUpper, ClassLower, ClassDigit}
+	assert.True(t, digit.StricterThan(base), "digit alone is stricter than digit or special")
+	assert.False(t, base.StricterThan(digit), "digit or special is implied by digit")
+
+	relaxed := base
+	relaxed.RequiredClasses = []CharClass{ClassLower}
+	relaxed.HistoryDepth = 10
+	relaxed.LockoutThreshold = 3
+	assert.False(t, relaxed.StricterThan(base), "history and lockout do not affect the current password")
+}
+
+func TestPasswordPolicyCheckHistory(t *testing.T) {
+	current, err := HashPassword("Current-Passw0rd")
+	require.NoError(t, err)
+	previous, err := HashPassword("Previous-Passw0rd")
+	require.NoError(t, err)
+	hashes := []string{current, previous}
+
+	assert.NoError(t, PasswordPolicy{}.CheckHistory("Current-Passw0rd", hashes))
+
+	err = PasswordPolicy{HistoryDepth: 1}.CheckHistory("Current-Passw0rd", hashes)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "отличаться от текущего")
+	assert.NoError(t, PasswordPolicy{HistoryDepth: 1}.CheckHistory("Previous-Passw0rd", hashes))
+
+	err = PasswordPolicy{HistoryDepth: 3}.CheckHistory("Previous-Passw0rd", hashes)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "3 последних паролей")
+}
+
+func TestPasswordPolicyGenerateTemporary(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:       20,
+		RequiredClasses: []CharClass{ClassUpper, ClassLower, ClassDigit, ClassSpecial},
+		RejectCommon:    true,
+	}
+
+	password, err := policy.GenerateTemporary()
+	require.NoError(t, err)
+	assert.Len(t, password, 20)
+	assert.NoError(t, policy.Validate(password))
+}
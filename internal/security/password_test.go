@@ -26,7 +26,7 @@ func TestHashAndVerifyPassword(t *testing.T) {
 	assert.False(t, isValid)
 }
 
-func TestValidatePassword(t *testing.T) {
+func TestDefaultPasswordPolicyValidate(t *testing.T) {
 	// Проверка валидации сложности пароля (длина, регистр, спецсимволы)
 	tests := []struct {
 		name          string
@@ -62,7 +62,7 @@ func TestValidatePassword(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := ValidatePassword(tt.password)
+			err := DefaultPasswordPolicy().Validate(tt.password)
 			if tt.expectedError == "" {
 				assert.NoError(t, err)
 			} else {
@@ -78,7 +78,7 @@ func TestGenerateTemporaryPassword(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.Len(t, password, 14)
-	assert.NoError(t, ValidatePassword(password))
+	assert.NoError(t, DefaultPasswordPolicy().Validate(password))
 }
 
 func TestGenerateTemporaryPasswordReturnsDifferentValues(t *testing.T) {
@@ -0,0 +1,153 @@
+package security
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image/png"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// Параметры TOTP совместимы с Google Authenticator и аналогами.
+const (
+	TOTPIssuer = "docflow"
+	totpPeriod = 30
+	// totpSkew — сколько соседних интервалов принимается из-за расхождения часов.
+	totpSkew     = 1
+	totpQRSize   = 256
+	totpCodeSize = 6
+)
+
+// RecoveryCodeCount — сколько кодов восстановления выдается при подключении
+// второго фактора.
+const RecoveryCodeCount = 10
+
+var totpOptions = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// TOTPKey — новый секрет TOTP и представления для приложения-аутентификатора.
+type TOTPKey struct {
+	Secret string
+	URL    string
+	// QRCode — PNG с QR-кодом URL в виде data URL. Строится локально, секрет
+	// не передается внешним сервисам.
+	QRCode string
+}
+
+// GenerateTOTPKey создает секрет TOTP для учетной записи account.
+func GenerateTOTPKey(account string) (*TOTPKey, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      TOTPIssuer,
+		AccountName: account,
+		Period:      totpPeriod,
+		Digits:      totpOptions.Digits,
+		Algorithm:   totpOptions.Algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	img, err := key.Image(totpQRSize, totpQRSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render TOTP QR code: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode TOTP QR code: %w", err)
+	}
+	return &TOTPKey{
+		Secret: key.Secret(),
+		URL:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// VerifyTOTP проверяет код code для секрета secret в момент at и возвращает
+// номер интервала, которому соответствует код. Коды интервалов не новее
+// lastStep отклоняются, чтобы один код нельзя было использовать повторно.
+func VerifyTOTP(secret, code string, at time.Time, lastStep int64) (int64, bool) {
+	code = normalizeOneTimeCode(code)
+	if len(code) != totpCodeSize {
+		return 0, false
+	}
+	current := at.Unix() / totpPeriod
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		step := current + offset
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOptions)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// IsTOTPCode сообщает, похож ли code на код приложения-аутентификатора, а не
+// на код восстановления.
+func IsTOTPCode(code string) bool {
+	code = normalizeOneTimeCode(code)
+	if len(code) != totpCodeSize {
+		return false
+	}
+	for _, ch := range code {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateRecoveryCodes создает коды восстановления вида «xxxxx-xxxxx».
+func GenerateRecoveryCodes() ([]string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	const length = 10
+	codes := make([]string, 0, RecoveryCodeCount)
+	for len(codes) < RecoveryCodeCount {
+		code := make([]byte, 0, length+1)
+		for i := 0; i < length; i++ {
+			if i == length/2 {
+				code = append(code, '-')
+			}
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+			}
+			code = append(code, alphabet[n.Int64()])
+		}
+		codes = append(codes, string(code))
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode возвращает хеш кода восстановления для хранения. Код
+// нормализуется: регистр, пробелы и дефисы не учитываются. Коды случайные и
+// длинные, поэтому медленный хеш не нужен.
+func HashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(normalizeOneTimeCode(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeOneTimeCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '\t' {
+			return -1
+		}
+		return r
+	}, code)
+}
@@ -0,0 +1,66 @@
+package security
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTOTPKey(t *testing.T) {
+	key, err := GenerateTOTPKey("ivanov")
+	require.NoError(t, err)
+	assert.NotEmpty(t, key.Secret)
+	assert.True(t, strings.HasPrefix(key.URL, "otpauth://totp/docflow:ivanov?"))
+	assert.Contains(t, key.URL, "secret="+key.Secret)
+	assert.True(t, strings.HasPrefix(key.QRCode, "data:image/png;base64,"))
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	key, err := GenerateTOTPKey("ivanov")
+	require.NoError(t, err)
+	at := time.Date(2026, 3, 2, 9, 0, 10, 0, time.UTC)
+	current := at.Unix() / totpPeriod
+	code, err := totp.GenerateCode(key.Secret, at)
+	require.NoError(t, err)
+
+	step, ok := VerifyTOTP(key.Secret, code, at, 0)
+	require.True(t, ok)
+	assert.Equal(t, current, step)
+
+	step, ok = VerifyTOTP(key.Secret, code[:3]+" "+code[3:], at.Add(totpPeriod*time.Second), 0)
+	require.True(t, ok, "previous interval is accepted for clock skew")
+	assert.Equal(t, current, step)
+
+	_, ok = VerifyTOTP(key.Secret, code, at, current)
+	assert.False(t, ok, "used interval is rejected")
+
+	_, ok = VerifyTOTP(key.Secret, code, at.Add(2*totpPeriod*time.Second), 0)
+	assert.False(t, ok, "stale code is rejected")
+
+	_, ok = VerifyTOTP(key.Secret, "12345", at, 0)
+	assert.False(t, ok)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+
+	format := regexp.MustCompile(`^[a-z2-9]{5}-[a-z2-9]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		assert.Regexp(t, format, code)
+		assert.False(t, IsTOTPCode(code))
+		seen[code] = true
+	}
+	assert.Len(t, seen, RecoveryCodeCount)
+
+	assert.Equal(t, HashRecoveryCode("abcde-fghjk"), HashRecoveryCode(" ABCDE FGHJK "))
+	assert.NotEqual(t, HashRecoveryCode("abcde-fghjk"), HashRecoveryCode("abcde-fghjm"))
+	assert.True(t, IsTOTPCode("123 456"))
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+const maxAccessRoleNameLength = 100
+
+// AccessRoleService управляет ролями доступа — шаблонами прав, которые
+// назначаются пользователям и подразделениям вместо ручной настройки матрицы
+// для каждого сотрудника, — и объясняет администратору решения матрицы.
+type AccessRoleService struct {
+	auth      *AuthService
+	repo      AccessRoleStore
+	explainer DocumentAccessExplainer
+	access    *DocumentAccessService
+	userRepo  UserStore
+}
+
+// NewAccessRoleService создает новый экземпляр AccessRoleService.
+func NewAccessRoleService(auth *AuthService, repo AccessRoleStore, explainer DocumentAccessExplainer, access *DocumentAccessService, userRepo UserStore) *AccessRoleService {
+	return &AccessRoleService{
+		auth:      auth,
+		repo:      repo,
+		explainer: explainer,
+		access:    access,
+		userRepo:  userRepo,
+	}
+}
+
+func (s *AccessRoleService) auditEffect(key, action, details string) (models.OutboxEvent, error) {
+	userID, userName := s.auth.GetCurrentAuditInfo()
+	return NewAdminAuditOutboxEvent(key, models.CreateAdminAuditLogRequest{UserID: userID, UserName: userName, Action: action, Details: details})
+}
+
+// GetAccessRoles возвращает все роли с правилами и назначениями.
+func (s *AccessRoleService) GetAccessRoles() ([]models.AccessRole, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAll()
+}
+
+// CreateAccessRole создает роль.
+func (s *AccessRoleService) CreateAccessRole(req models.SaveAccessRoleRequest) (*models.AccessRole, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	role, err := accessRoleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.auditEffect("access-role:"+uuid.NewString()+":create", "ACCESS_ROLE_CREATE", fmt.Sprintf("Создана роль «%s»", role.Name))
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.CreateWithOutbox(role, []models.OutboxEvent{event})
+}
+
+// UpdateAccessRole меняет название, описание и правила роли. Изменение сразу
+// действует для всех пользователей и подразделений, которым роль назначена.
+func (s *AccessRoleService) UpdateAccessRole(req models.SaveAccessRoleRequest) (*models.AccessRole, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	id, err := parseUUID(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := accessRoleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	role.ID = id
+	event, err := s.auditEffect("access-role:"+id.String()+":update:"+uuid.NewString(), "ACCESS_ROLE_UPDATE", fmt.Sprintf("Обновлена роль «%s»", role.Name))
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateWithOutbox(role, []models.OutboxEvent{event})
+}
+
+// DeleteAccessRole удаляет роль вместе с ее назначениями.
+func (s *AccessRoleService) DeleteAccessRole(id string) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	uid, err := parseUUID(id)
+	if err != nil {
+		return err
+	}
+	role, err := s.repo.GetByID(uid)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return models.NewNotFound("роль не найдена")
+	}
+	event, err := s.auditEffect("access-role:"+uid.String()+":delete", "ACCESS_ROLE_DELETE", fmt.Sprintf("Удалена роль «%s»", role.Name))
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteWithOutbox(uid, []models.OutboxEvent{event})
+}
+
+// UpdateAccessRoleAssignments заменяет пользователей и подразделения, которым назначена роль.
+// Роль подразделения действует для всех его сотрудников.
+func (s *AccessRoleService) UpdateAccessRoleAssignments(req models.UpdateAccessRoleAssignmentsRequest) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	roleID, err := parseUUID(req.RoleID)
+	if err != nil {
+		return err
+	}
+	userIDs, err := parseUUIDList(req.UserIDs)
+	if err != nil {
+		return err
+	}
+	departmentIDs, err := parseUUIDList(req.DepartmentIDs)
+	if err != nil {
+		return err
+	}
+	role, err := s.repo.GetByID(roleID)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return models.NewNotFound("роль не найдена")
+	}
+	event, err := s.auditEffect("access-role:"+roleID.String()+":assign:"+uuid.NewString(), "ACCESS_ROLE_ASSIGN",
+		fmt.Sprintf("Роль «%s» назначена: пользователей — %d, подразделений — %d", role.Name, len(userIDs), len(departmentIDs)))
+	if err != nil {
+		return err
+	}
+	return s.repo.ReplaceAssignmentsWithOutbox(roleID, userIDs, departmentIDs, []models.OutboxEvent{event})
+}
+
+// ExplainDocumentAccess объясняет, может ли пользователь выполнить действие над документом
+// и почему: какие прямые правила, правила подразделения и роли учтены, и откуда у
+// пользователя доступ к документу на чтение, без которого недоступно ни одно действие.
+func (s *AccessRoleService) ExplainDocumentAccess(userID, documentID, action string) (*models.DocumentAccessExplanation, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	uid, err := parseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	docID, err := parseUUID(documentID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(uid)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, models.NewNotFound("пользователь не найден")
+	}
+	doc, err := s.access.RequireExists(docID)
+	if err != nil {
+		return nil, err
+	}
+	if !doc.Kind.SupportsAction(action) {
+		return nil, models.NewBadRequest(fmt.Sprintf("действие %q не поддерживается для вида документа %q", action, doc.Kind))
+	}
+
+	explanation := &models.DocumentAccessExplanation{
+		UserID:      uid.String(),
+		DocumentID:  docID.String(),
+		KindCode:    string(doc.Kind),
+		Action:      action,
+		Reasons:     make([]string, 0),
+		ActionRules: make([]models.AccessRuleMatch, 0),
+		ReadRules:   make([]models.AccessRuleMatch, 0),
+	}
+	if !user.IsActive {
+		explanation.Reasons = append(explanation.Reasons, "учетная запись пользователя деактивирована")
+		return explanation, nil
+	}
+	principal, err := s.auth.loadPrincipal(uid)
+	if err != nil {
+		return nil, err
+	}
+	departmentID := principal.DepartmentIDString()
+
+	explanation.ActionRules, err = s.explainer.ExplainPermission(string(doc.Kind), action, departmentID, uid.String())
+	if err != nil {
+		return nil, err
+	}
+	actionAllowed := models.ResolveAccessRules(explanation.ActionRules)
+	explanation.Reasons = append(explanation.Reasons, describeAccessRules(action, explanation.ActionRules))
+
+	readAllowed := actionAllowed
+	if action != string(models.DocumentActionRead) {
+		explanation.ReadRules, err = s.explainer.ExplainPermission(string(doc.Kind), string(models.DocumentActionRead), departmentID, uid.String())
+		if err != nil {
+			return nil, err
+		}
+		readAllowed = models.ResolveAccessRules(explanation.ReadRules)
+		explanation.Reasons = append(explanation.Reasons, describeAccessRules(string(models.DocumentActionRead), explanation.ReadRules))
+	}
+	if !readAllowed {
+		reason, err := s.access.implicitReadReason(principal, doc)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			readAllowed = true
+			explanation.Reasons = append(explanation.Reasons, "документ доступен на чтение: "+reason)
+		} else {
+			explanation.Reasons = append(explanation.Reasons, "документ недоступен на чтение: нет ни права read, ни доступа через номенклатуру подразделения, поручения или ознакомления")
+		}
+	}
+
+	if action == string(models.DocumentActionRead) {
+		explanation.Allowed = readAllowed
+	} else {
+		explanation.Allowed = actionAllowed && readAllowed
+	}
+	return explanation, nil
+}
+
+func accessRoleFromRequest(req models.SaveAccessRoleRequest) (models.AccessRole, error) {
+	role := models.AccessRole{
+		Name:              strings.TrimSpace(req.Name),
+		Description:       strings.TrimSpace(req.Description),
+		SystemPermissions: make([]string, 0, len(req.SystemPermissions)),
+		Permissions:       make([]models.AccessRolePermission, 0, len(req.Permissions)),
+	}
+	if role.Name == "" {
+		return role, models.NewBadRequest("название роли обязательно")
+	}
+	if utf8.RuneCountInString(role.Name) > maxAccessRoleNameLength {
+		return role, models.NewBadRequest(fmt.Sprintf("название роли не должно превышать %d символов", maxAccessRoleNameLength))
+	}
+	for _, permission := range req.SystemPermissions {
+		if permission == models.SystemPermissionAdmin {
+			return role, models.NewBadRequest("право администратора назначается пользователю напрямую, а не через роль")
+		}
+		if !models.IsRoleSystemPermission(permission) {
+			return role, models.NewBadRequest(fmt.Sprintf("неизвестное системное право: %s", permission))
+		}
+		role.SystemPermissions = append(role.SystemPermissions, permission)
+	}
+	for _, permission := range req.Permissions {
+		if err := validateDocumentPermissionRule(permission.KindCode, permission.Action); err != nil {
+			return role, err
+		}
+		role.Permissions = append(role.Permissions, models.AccessRolePermission{
+			KindCode: string(models.NormalizeDocumentKind(permission.KindCode)),
+			Action:   permission.Action,
+		})
+	}
+	return role, nil
+}
+
+func parseUUIDList(values []string) ([]uuid.UUID, error) {
+	result := make([]uuid.UUID, 0, len(values))
+	for _, value := range values {
+		id, err := parseUUID(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, id)
+	}
+	return result, nil
+}
+
+// describeAccessRules формулирует решение матрицы для действия action по правилам rules.
+func describeAccessRules(action string, rules []models.AccessRuleMatch) string {
+	if len(rules) == 0 {
+		return fmt.Sprintf("действие «%s»: в матрице доступа нет правил для пользователя", action)
+	}
+	denied := make([]string, 0)
+	allowed := make([]string, 0)
+	for _, rule := range rules {
+		if rule.IsAllowed {
+			allowed = append(allowed, accessRuleSourceLabel(rule))
+		} else {
+			denied = append(denied, accessRuleSourceLabel(rule))
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Sprintf("действие «%s» запрещено явно: %s", action, strings.Join(denied, "; "))
+	}
+	return fmt.Sprintf("действие «%s» разрешено: %s", action, strings.Join(allowed, "; "))
+}
+
+func accessRuleSourceLabel(rule models.AccessRuleMatch) string {
+	switch rule.Source {
+	case models.AccessRuleSourceUser:
+		return "прямое правило пользователя"
+	case models.AccessRuleSourceDepartment:
+		return "правило подразделения"
+	case models.AccessRuleSourceUserRole:
+		return fmt.Sprintf("роль «%s», назначенная пользователю", rule.RoleName)
+	case models.AccessRuleSourceDepartmentRole:
+		return fmt.Sprintf("роль «%s», назначенная подразделению", rule.RoleName)
+	default:
+		return rule.Source
+	}
+}
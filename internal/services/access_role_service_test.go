@@ -0,0 +1,301 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type accessRoleStoreStub struct {
+	roles         map[uuid.UUID]models.AccessRole
+	saved         *models.AccessRole
+	userIDs       []uuid.UUID
+	departmentIDs []uuid.UUID
+	effects       []models.OutboxEvent
+}
+
+func newAccessRoleStoreStub(roles ...models.AccessRole) *accessRoleStoreStub {
+	store := &accessRoleStoreStub{roles: map[uuid.UUID]models.AccessRole{}}
+	for _, role := range roles {
+		store.roles[role.ID] = role
+	}
+	return store
+}
+
+func (s *accessRoleStoreStub) GetAll() ([]models.AccessRole, error) {
+	res := make([]models.AccessRole, 0, len(s.roles))
+	for _, role := range s.roles {
+		res = append(res, role)
+	}
+	return res, nil
+}
+
+func (s *accessRoleStoreStub) GetByID(id uuid.UUID) (*models.AccessRole, error) {
+	role, ok := s.roles[id]
+	if !ok {
+		return nil, nil
+	}
+	return &role, nil
+}
+
+func (s *accessRoleStoreStub) CreateWithOutbox(role models.AccessRole, effects []models.OutboxEvent) (*models.AccessRole, error) {
+	role.ID = uuid.New()
+	return s.UpdateWithOutbox(role, effects)
+}
+
+func (s *accessRoleStoreStub) UpdateWithOutbox(role models.AccessRole, effects []models.OutboxEvent) (*models.AccessRole, error) {
+	s.roles[role.ID] = role
+	s.saved = &role
+	s.effects = effects
+	return &role, nil
+}
+
+func (s *accessRoleStoreStub) DeleteWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error {
+	delete(s.roles, id)
+	s.effects = effects
+	return nil
+}
+
+func (s *accessRoleStoreStub) ReplaceAssignmentsWithOutbox(roleID uuid.UUID, userIDs, departmentIDs []uuid.UUID, effects []models.OutboxEvent) error {
+	s.userIDs = userIDs
+	s.departmentIDs = departmentIDs
+	s.effects = effects
+	return nil
+}
+
+func (s *accessRoleStoreStub) auditRequest(t *testing.T) models.CreateAdminAuditLogRequest {
+	t.Helper()
+	require.Len(t, s.effects, 1)
+	var request models.CreateAdminAuditLogRequest
+	require.NoError(t, json.Unmarshal([]byte(s.effects[0].Payload), &request))
+	return request
+}
+
+// accessExplainerStub возвращает заранее заданные правила по действию.
+type accessExplainerStub struct {
+	rules map[string][]models.AccessRuleMatch
+}
+
+func (s *accessExplainerStub) ExplainPermission(kindCode, action string, departmentID, userID string) ([]models.AccessRuleMatch, error) {
+	return s.rules[action], nil
+}
+
+type accessRoleTestDeps struct {
+	svc       *AccessRoleService
+	store     *accessRoleStoreStub
+	explainer *accessExplainerStub
+	userRepo  *mocks.UserStore
+	depRepo   *mocks.DepartmentStore
+	docs      *documentAccessDocumentStore
+}
+
+func setupAccessRoleService(t *testing.T, permissions ...string) *accessRoleTestDeps {
+	t.Helper()
+	userRepo := mocks.NewUserStore(t)
+	auth := NewAuthService(nil, userRepo)
+	auth.SetAccessStore(newRoleMappedDocumentAccessStore(permissions...))
+	admin := documentAccessUser(false, nil)
+	auth.currentUserID = admin.ID
+	userRepo.On("GetByID", admin.ID).Return(admin, nil).Maybe()
+
+	deps := &accessRoleTestDeps{
+		store:     newAccessRoleStoreStub(),
+		explainer: &accessExplainerStub{rules: map[string][]models.AccessRuleMatch{}},
+		userRepo:  userRepo,
+		depRepo:   mocks.NewDepartmentStore(t),
+		docs:      &documentAccessDocumentStore{docs: map[uuid.UUID]models.Document{}},
+	}
+	access := NewDocumentAccessService(auth, deps.depRepo, nil, nil, newRoleMappedDocumentAccessStore(), deps.docs, nil, nil)
+	deps.svc = NewAccessRoleService(auth, deps.store, deps.explainer, access, userRepo)
+	return deps
+}
+
+func TestAccessRoleService_CreateAccessRole(t *testing.T) {
+	registrar := models.SaveAccessRoleRequest{
+		Name:              "  Регистратор ",
+		SystemPermissions: []string{models.SystemPermissionReferences},
+		Permissions: []models.AccessRolePermission{
+			{KindCode: string(models.DocumentKindIncomingLetter), Action: string(models.DocumentActionCreate)},
+			{KindCode: string(models.DocumentKindIncomingLetter), Action: string(models.DocumentActionRead)},
+		},
+	}
+
+	t.Run("requires admin", func(t *testing.T) {
+		deps := setupAccessRoleService(t)
+		_, err := deps.svc.CreateAccessRole(registrar)
+		assert.ErrorIs(t, err, models.ErrForbidden)
+	})
+
+	t.Run("creates role with audit", func(t *testing.T) {
+		deps := setupAccessRoleService(t, models.SystemPermissionAdmin)
+
+		role, err := deps.svc.CreateAccessRole(registrar)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Регистратор", role.Name)
+		assert.Len(t, role.Permissions, 2)
+		assert.Equal(t, []string{models.SystemPermissionReferences}, role.SystemPermissions)
+		audit := deps.store.auditRequest(t)
+		assert.Equal(t, "ACCESS_ROLE_CREATE", audit.Action)
+		assert.Contains(t, audit.Details, "Регистратор")
+	})
+
+	t.Run("admin permission is not granted by role", func(t *testing.T) {
+		deps := setupAccessRoleService(t, models.SystemPermissionAdmin)
+		req := registrar
+		req.SystemPermissions = []string{models.SystemPermissionAdmin}
+
+		_, err := deps.svc.CreateAccessRole(req)
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "напрямую")
+		assert.Nil(t, deps.store.saved)
+	})
+
+	t.Run("rejects unsupported action", func(t *testing.T) {
+		deps := setupAccessRoleService(t, models.SystemPermissionAdmin)
+		req := registrar
+		req.Permissions = []models.AccessRolePermission{{KindCode: string(models.DocumentKindIncomingLetter), Action: "approve"}}
+
+		_, err := deps.svc.CreateAccessRole(req)
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "не поддерживается")
+	})
+
+	t.Run("rejects empty name", func(t *testing.T) {
+		deps := setupAccessRoleService(t, models.SystemPermissionAdmin)
+		_, err := deps.svc.CreateAccessRole(models.SaveAccessRoleRequest{Name: "  "})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "название роли")
+	})
+}
+
+func TestAccessRoleService_UpdateAccessRoleAssignments(t *testing.T) {
+	role := models.AccessRole{ID: uuid.New(), Name: "Исполнитель"}
+	userID, departmentID := uuid.New(), uuid.New()
+
+	t.Run("replaces assignments with audit", func(t *testing.T) {
+		deps := setupAccessRoleService(t, models.SystemPermissionAdmin)
+		deps.store.roles[role.ID] = role
+
+		err := deps.svc.UpdateAccessRoleAssignments(models.UpdateAccessRoleAssignmentsRequest{
+			RoleID:        role.ID.String(),
+			UserIDs:       []string{userID.String()},
+			DepartmentIDs: []string{departmentID.String()},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{userID}, deps.store.userIDs)
+		assert.Equal(t, []uuid.UUID{departmentID}, deps.store.departmentIDs)
+		assert.Equal(t, "ACCESS_ROLE_ASSIGN", deps.store.auditRequest(t).Action)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		deps := setupAccessRoleService(t, models.SystemPermissionAdmin)
+		err := deps.svc.UpdateAccessRoleAssignments(models.UpdateAccessRoleAssignmentsRequest{RoleID: role.ID.String()})
+		requireAppError(t, err, "NOT_FOUND", 404, "роль не найдена")
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		deps := setupAccessRoleService(t, models.SystemPermissionAdmin)
+		deps.store.roles[role.ID] = role
+		err := deps.svc.UpdateAccessRoleAssignments(models.UpdateAccessRoleAssignmentsRequest{RoleID: role.ID.String(), UserIDs: []string{"bad"}})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный UUID")
+		assert.Nil(t, deps.store.effects)
+	})
+}
+
+func TestAccessRoleService_ExplainDocumentAccess(t *testing.T) {
+	departmentID := uuid.New()
+	nomenclatureID := uuid.New()
+	doc := models.Document{ID: uuid.New(), Kind: models.DocumentKindIncomingLetter, NomenclatureID: nomenclatureID}
+	roleGrant := models.AccessRuleMatch{Source: models.AccessRuleSourceDepartmentRole, RoleID: uuid.NewString(), RoleName: "Регистратор", IsAllowed: true}
+
+	setup := func(t *testing.T, target *models.User) *accessRoleTestDeps {
+		t.Helper()
+		deps := setupAccessRoleService(t, models.SystemPermissionAdmin)
+		deps.docs.docs[doc.ID] = doc
+		deps.userRepo.On("GetByID", target.ID).Return(target, nil).Maybe()
+		return deps
+	}
+
+	t.Run("role grant allows action", func(t *testing.T) {
+		target := documentAccessUser(false, &departmentID)
+		deps := setup(t, target)
+		deps.explainer.rules["update"] = []models.AccessRuleMatch{roleGrant}
+		deps.explainer.rules["read"] = []models.AccessRuleMatch{roleGrant}
+
+		res, err := deps.svc.ExplainDocumentAccess(target.ID.String(), doc.ID.String(), "update")
+
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, string(models.DocumentKindIncomingLetter), res.KindCode)
+		require.Len(t, res.Reasons, 2)
+		assert.Contains(t, res.Reasons[0], "роль «Регистратор», назначенная подразделению")
+		assert.Len(t, res.ReadRules, 1)
+	})
+
+	t.Run("explicit user deny wins over role grant", func(t *testing.T) {
+		target := documentAccessUser(false, &departmentID)
+		deps := setup(t, target)
+		deps.explainer.rules["update"] = []models.AccessRuleMatch{
+			{Source: models.AccessRuleSourceUser, IsAllowed: false},
+			roleGrant,
+		}
+		deps.explainer.rules["read"] = []models.AccessRuleMatch{roleGrant}
+
+		res, err := deps.svc.ExplainDocumentAccess(target.ID.String(), doc.ID.String(), "update")
+
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Contains(t, res.Reasons[0], "запрещено явно: прямое правило пользователя")
+	})
+
+	t.Run("read through department nomenclature", func(t *testing.T) {
+		target := documentAccessUser(true, &departmentID)
+		deps := setup(t, target)
+		deps.depRepo.On("GetVisibleNomenclatureIDs", &departmentID, target.ID).Return([]string{nomenclatureID.String()}, nil).Once()
+
+		res, err := deps.svc.ExplainDocumentAccess(target.ID.String(), doc.ID.String(), "read")
+
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Empty(t, res.ActionRules)
+		assert.Contains(t, res.Reasons[1], "номенклатуры подразделения")
+	})
+
+	t.Run("action allowed without read access is denied", func(t *testing.T) {
+		target := documentAccessUser(false, nil)
+		deps := setup(t, target)
+		deps.explainer.rules["upload"] = []models.AccessRuleMatch{{Source: models.AccessRuleSourceUser, IsAllowed: true}}
+
+		res, err := deps.svc.ExplainDocumentAccess(target.ID.String(), doc.ID.String(), "upload")
+
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Contains(t, res.Reasons[2], "недоступен на чтение")
+	})
+
+	t.Run("inactive user", func(t *testing.T) {
+		target := documentAccessUser(false, nil)
+		target.IsActive = false
+		deps := setup(t, target)
+
+		res, err := deps.svc.ExplainDocumentAccess(target.ID.String(), doc.ID.String(), "read")
+
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, []string{"учетная запись пользователя деактивирована"}, res.Reasons)
+	})
+
+	t.Run("unknown document", func(t *testing.T) {
+		target := documentAccessUser(false, nil)
+		deps := setup(t, target)
+		_, err := deps.svc.ExplainDocumentAccess(target.ID.String(), uuid.NewString(), "read")
+		requireAppError(t, err, "NOT_FOUND", 404, "документ не найден")
+	})
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -12,19 +13,18 @@ import (
 
 // AcknowledgmentService предоставляет бизнес-логику для работы с задачами на ознакомление.
 type AcknowledgmentService struct {
-	repo          AcknowledgmentStore
-	userRepo      UserStore
-	auth          *AuthService
-	access        *DocumentAccessService
-	events        *UserEventService
-	substitutions UserSubstitutionStore
+	repo     AcknowledgmentStore
+	userRepo UserStore
+	auth     *AuthService
+	access   *DocumentAccessService
+	events   *UserEventService
 }
 
 type acknowledgmentConfirmationOutboxStore interface {
 	MarkConfirmedWithEffects(uuid.UUID, uuid.UUID, models.AcknowledgmentConfirmationEffects) error
 }
 type acknowledgmentViewedOutboxStore interface {
-	MarkViewedWithOutbox(uuid.UUID, uuid.UUID, []models.OutboxEvent) error
+	MarkViewedWithOutbox(uuid.UUID, uuid.UUID, *uuid.UUID, []models.OutboxEvent) error
 }
 type acknowledgmentDeleteOutboxStore interface {
 	DeleteWithOutbox(uuid.UUID, []models.OutboxEvent) error
@@ -58,38 +58,6 @@ func NewAcknowledgmentService(
 	return s
 }
 
-// SetSubstitutionStore подключает источник активных замещений.
-func (s *AcknowledgmentService) SetSubstitutionStore(store UserSubstitutionStore) {
-	s.substitutions = store
-}
-
-func (s *AcknowledgmentService) currentUserAndSubstitutionSubjectIDs() ([]uuid.UUID, error) {
-	currentUserID, err := s.auth.GetCurrentUserUUID()
-	if err != nil {
-		return nil, err
-	}
-	ids := []uuid.UUID{currentUserID}
-	if s.substitutions == nil {
-		return ids, nil
-	}
-	principalIDs, err := s.substitutions.GetActivePrincipalIDs(currentUserID)
-	if err != nil {
-		return nil, err
-	}
-	seen := map[uuid.UUID]struct{}{currentUserID: {}}
-	for _, principalID := range principalIDs {
-		if principalID == uuid.Nil {
-			continue
-		}
-		if _, ok := seen[principalID]; ok {
-			continue
-		}
-		seen[principalID] = struct{}{}
-		ids = append(ids, principalID)
-	}
-	return ids, nil
-}
-
 func acknowledgmentListContainsUser(acknowledgments []models.Acknowledgment, ackID uuid.UUID) bool {
 	for _, ack := range acknowledgments {
 		if ack.ID == ackID {
@@ -115,53 +83,86 @@ func (s *AcknowledgmentService) pendingForSubjects(subjectIDs []uuid.UUID) (map[
 	return result, nil
 }
 
-func (s *AcknowledgmentService) resolveAcknowledgmentSubjectUserID(ackID uuid.UUID) (uuid.UUID, error) {
-	currentUserID, err := s.auth.GetCurrentUserUUID()
-	if err != nil {
-		return uuid.Nil, err
-	}
-	if s.substitutions == nil {
-		return currentUserID, nil
+func (s *AcknowledgmentService) resolveAcknowledgmentSubjectUserID(principal *models.Principal, ackID uuid.UUID) (uuid.UUID, error) {
+	if !principal.HasActiveSubstitution() {
+		return principal.UserID, nil
 	}
-	principalIDs, err := s.substitutions.GetActivePrincipalIDs(currentUserID)
+	pendingBySubject, err := s.pendingForSubjects(principal.SubstitutedUserIDs)
 	if err != nil {
 		return uuid.Nil, err
 	}
-	pendingBySubject, err := s.pendingForSubjects(principalIDs)
-	if err != nil {
-		return uuid.Nil, err
-	}
-	for _, subjectID := range principalIDs {
-		if acknowledgmentListContainsUser(pendingBySubject[subjectID], ackID) {
+	for _, subjectID := range principal.SubstitutedUserIDs {
+		pending := principalAcknowledgments(principal, subjectID, pendingBySubject[subjectID])
+		if acknowledgmentListContainsUser(pending, ackID) {
 			return subjectID, nil
 		}
 	}
-	return currentUserID, nil
+	return principal.UserID, nil
 }
 
-// Create создает новую задачу на ознакомление для указанных пользователей.
+// principalAcknowledgments оставляет из ознакомлений subjectID те, которые
+// principal вправе подтверждать: замещение может не распространяться на
+// ознакомления или на вид документа.
+func principalAcknowledgments(principal *models.Principal, subjectID uuid.UUID, acknowledgments []models.Acknowledgment) []models.Acknowledgment {
+	if subjectID == principal.UserID {
+		return acknowledgments
+	}
+	result := make([]models.Acknowledgment, 0, len(acknowledgments))
+	for _, ack := range acknowledgments {
+		if principal.ActsForIn(subjectID, models.SubstitutionScopeAcknowledgments, models.NormalizeDocumentKind(ack.DocumentKind)) {
+			result = append(result, ack)
+		}
+	}
+	return result
+}
+
+// Create создает новую задачу на ознакомление для указанных пользователей без срока.
 func (s *AcknowledgmentService) Create(
 	documentID string,
 	content string,
 	userIds []string,
 ) (*dto.Acknowledgment, error) {
+	return s.CreateWithDeadline(documentID, content, "", userIds)
+}
+
+// CreateWithDeadline создает задачу на ознакомление, как Create, со сроком
+// ознакомления (YYYY-MM-DD); пустой срок означает бессрочное ознакомление.
+func (s *AcknowledgmentService) CreateWithDeadline(
+	documentID string,
+	content string,
+	deadline string,
+	userIds []string,
+) (*dto.Acknowledgment, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, documentID, content, deadline, userIds)
+}
+
+func (s *AcknowledgmentService) create(ctx context.Context, documentID, content, deadline string, userIds []string) (*dto.Acknowledgment, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	docUUID, err := uuid.Parse(documentID)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 	}
-	if err := s.access.RequireDocumentAction(docUUID, "acknowledge"); err != nil {
+	deadlineDate, err := parseOptionalDate(deadline, "неверный формат срока ознакомления")
+	if err != nil {
 		return nil, err
 	}
-	doc, err := s.access.RequireExists(docUUID)
-	if err != nil {
+	if err := s.access.RequireDocumentAction(ctx, docUUID, "acknowledge"); err != nil {
 		return nil, err
 	}
-
-	creatorUUID, err := s.auth.GetCurrentUserUUID()
+	doc, err := s.access.RequireExists(docUUID)
 	if err != nil {
 		return nil, err
 	}
 
+	creatorUUID := principal.UserID
+
 	ack := &models.Acknowledgment{
 		ID:           uuid.New(),
 		DocumentID:   docUUID,
@@ -169,6 +170,7 @@ func (s *AcknowledgmentService) Create(
 		CreatorID:    creatorUUID,
 		Content:      content,
 		CreatedAt:    time.Now(),
+		Deadline:     deadlineDate,
 	}
 
 	for _, uidStr := range userIds {
@@ -193,13 +195,19 @@ func (s *AcknowledgmentService) Create(
 		return nil, errAcknowledgmentOutboxStoreRequired
 	}
 	effects := make([]models.OutboxEvent, 0, len(ack.Users)+1)
-	journal, buildErr := NewJournalOutboxEvent("ack:"+ack.ID.String()+":created:journal", models.CreateJournalEntryRequest{DocumentID: docUUID, UserID: creatorUUID, Action: "ACK_CREATE", Details: "Отправлен на ознакомление"})
+	details, message, metadata := "Отправлен на ознакомление", "Вам направлен документ на ознакомление", map[string]string{"status": "pending"}
+	if deadlineDate != nil {
+		details += " со сроком до " + deadlineDate.Format("02.01.2006")
+		message += " со сроком до " + deadlineDate.Format("02.01.2006")
+		metadata["deadline"] = deadlineDate.Format("2006-01-02")
+	}
+	journal, buildErr := NewJournalOutboxEvent("ack:"+ack.ID.String()+":created:journal", models.CreateJournalEntryRequest{DocumentID: docUUID, UserID: creatorUUID, Action: "ACK_CREATE", Details: details})
 	if buildErr != nil {
 		return nil, buildErr
 	}
 	effects = append(effects, journal)
 	for _, user := range ack.Users {
-		request := models.CreateUserEventRequest{RecipientUserID: user.UserID, ActorUserID: &creatorUUID, DocumentID: docUUID, DocumentKind: string(doc.Kind), DocumentNumber: doc.RegistrationNumber, EntityType: models.UserEventEntityAcknowledgment, EntityID: ack.ID, EventType: models.UserEventAcknowledgmentCreated, Title: "Новое ознакомление", Message: "Вам направлен документ на ознакомление", Metadata: userEventMetadata(map[string]string{"status": "pending"})}
+		request := models.CreateUserEventRequest{RecipientUserID: user.UserID, ActorUserID: &creatorUUID, DocumentID: docUUID, DocumentKind: string(doc.Kind), DocumentNumber: doc.RegistrationNumber, EntityType: models.UserEventEntityAcknowledgment, EntityID: ack.ID, EventType: models.UserEventAcknowledgmentCreated, Title: "Новое ознакомление", Message: message, Metadata: userEventMetadata(metadata)}
 		event, buildErr := NewUserEventOutboxEvent("ack:"+ack.ID.String()+":created:"+user.UserID.String(), request)
 		if buildErr != nil {
 			return nil, buildErr
@@ -215,11 +223,19 @@ func (s *AcknowledgmentService) Create(
 
 // GetList возвращает список задач на ознакомление для конкретного документа.
 func (s *AcknowledgmentService) GetList(documentID string) ([]dto.Acknowledgment, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.getList(ctx, documentID)
+}
+
+func (s *AcknowledgmentService) getList(ctx context.Context, documentID string) ([]dto.Acknowledgment, error) {
 	docUUID, err := uuid.Parse(documentID)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 	}
-	if err := s.access.RequireDocumentAction(docUUID, "acknowledge"); err != nil {
+	if err := s.access.RequireDocumentAction(ctx, docUUID, "acknowledge"); err != nil {
 		return nil, err
 	}
 	res, err := s.repo.GetByDocumentID(docUUID)
@@ -228,13 +244,22 @@ func (s *AcknowledgmentService) GetList(documentID string) ([]dto.Acknowledgment
 
 // GetPendingForCurrentUser возвращает список невыполненных задач на ознакомление для текущего авторизованного пользователя.
 func (s *AcknowledgmentService) GetPendingForCurrentUser() ([]dto.Acknowledgment, error) {
-	if err := s.access.RequireDomainRead(); err != nil {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
 		return nil, err
 	}
-	subjectIDs, err := s.currentUserAndSubstitutionSubjectIDs()
+	return s.getPendingForPrincipal(ctx)
+}
+
+func (s *AcknowledgmentService) getPendingForPrincipal(ctx context.Context) ([]dto.Acknowledgment, error) {
+	principal, err := requirePrincipal(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.access.RequireDomainRead(ctx); err != nil {
+		return nil, err
+	}
+	subjectIDs := principal.SubjectIDs()
 	pendingBySubject, err := s.pendingForSubjects(subjectIDs)
 	if err != nil {
 		return nil, err
@@ -242,7 +267,7 @@ func (s *AcknowledgmentService) GetPendingForCurrentUser() ([]dto.Acknowledgment
 	result := make([]models.Acknowledgment, 0)
 	seen := make(map[uuid.UUID]struct{})
 	for _, subjectID := range subjectIDs {
-		for _, ack := range pendingBySubject[subjectID] {
+		for _, ack := range principalAcknowledgments(principal, subjectID, pendingBySubject[subjectID]) {
 			if _, ok := seen[ack.ID]; ok {
 				continue
 			}
@@ -255,17 +280,22 @@ func (s *AcknowledgmentService) GetPendingForCurrentUser() ([]dto.Acknowledgment
 
 // GetCurrentUserPendingByDocument возвращает ожидающие подтверждения ознакомления текущего пользователя по документу.
 func (s *AcknowledgmentService) GetCurrentUserPendingByDocument(documentID string) ([]dto.Acknowledgment, error) {
-	if err := s.access.RequireDomainRead(); err != nil {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
 		return nil, err
 	}
-	docUUID, err := uuid.Parse(documentID)
+	principal, err := requirePrincipal(ctx)
 	if err != nil {
-		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+		return nil, err
 	}
-	subjectIDs, err := s.currentUserAndSubstitutionSubjectIDs()
-	if err != nil {
+	if err := s.access.RequireDomainRead(ctx); err != nil {
 		return nil, err
 	}
+	docUUID, err := uuid.Parse(documentID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+	}
+	subjectIDs := principal.SubjectIDs()
 
 	pendingBySubject, err := s.pendingForSubjects(subjectIDs)
 	if err != nil {
@@ -274,7 +304,7 @@ func (s *AcknowledgmentService) GetCurrentUserPendingByDocument(documentID strin
 	filtered := make([]models.Acknowledgment, 0)
 	seen := make(map[uuid.UUID]struct{})
 	for _, subjectID := range subjectIDs {
-		for _, ack := range pendingBySubject[subjectID] {
+		for _, ack := range principalAcknowledgments(principal, subjectID, pendingBySubject[subjectID]) {
 			if ack.DocumentID != docUUID {
 				continue
 			}
@@ -291,7 +321,22 @@ func (s *AcknowledgmentService) GetCurrentUserPendingByDocument(documentID strin
 // GetAllActive возвращает список всех активных (не завершенных) задач на ознакомление в системе.
 // Доступно только делопроизводителям.
 func (s *AcknowledgmentService) GetAllActive() ([]dto.Acknowledgment, error) {
-	allowedKinds, err := s.access.GetDocumentKindsWithAction("acknowledge")
+	return s.GetAllActiveFiltered("")
+}
+
+// GetAllActiveFiltered работает как GetAllActive; deadlineState ограничивает выборку
+// просроченными, ожидающими или бессрочными задачами, пустое значение возвращает все.
+func (s *AcknowledgmentService) GetAllActiveFiltered(deadlineState string) ([]dto.Acknowledgment, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	switch deadlineState {
+	case "", models.AcknowledgmentDeadlineOverdue, models.AcknowledgmentDeadlineUpcoming, models.AcknowledgmentDeadlineNone:
+	default:
+		return nil, models.NewBadRequest("некорректный фильтр срока ознакомления")
+	}
+	allowedKinds, err := s.access.GetDocumentKindsWithAction(ctx, "acknowledge")
 	if err != nil {
 		return nil, err
 	}
@@ -300,6 +345,7 @@ func (s *AcknowledgmentService) GetAllActive() ([]dto.Acknowledgment, error) {
 	}
 	res, err := s.repo.GetAllActive(models.AcknowledgmentFilter{
 		AllowedDocumentKinds: documentKindCodes(allowedKinds),
+		DeadlineState:        deadlineState,
 	})
 	if err != nil {
 		return nil, err
@@ -309,7 +355,7 @@ func (s *AcknowledgmentService) GetAllActive() ([]dto.Acknowledgment, error) {
 	for _, ack := range res {
 		documentIDs = append(documentIDs, ack.DocumentID)
 	}
-	readableDocuments, err := s.access.ResolveReadableDocuments(documentIDs)
+	readableDocuments, err := s.access.ResolveReadableDocuments(ctx, documentIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -332,14 +378,23 @@ func (s *AcknowledgmentService) GetAllActive() ([]dto.Acknowledgment, error) {
 
 // MarkViewed отмечает задачу на ознакомление как просмотренную текущим пользователем.
 func (s *AcknowledgmentService) MarkViewed(ackID string) error {
-	if err := s.auth.RequireAuthenticated(); err != nil {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return err
+	}
+	return s.markViewed(ctx, ackID)
+}
+
+func (s *AcknowledgmentService) markViewed(ctx context.Context, ackID string) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
 		return err
 	}
 	ackUUID, err := uuid.Parse(ackID)
 	if err != nil {
 		return models.NewBadRequestWrapped("неверный ID строки ознакомления", err)
 	}
-	userUUID, err := s.resolveAcknowledgmentSubjectUserID(ackUUID)
+	userUUID, err := s.resolveAcknowledgmentSubjectUserID(principal, ackUUID)
 	if err != nil {
 		return err
 	}
@@ -355,23 +410,39 @@ func (s *AcknowledgmentService) MarkViewed(ackID string) error {
 	if !ok {
 		return errAcknowledgmentOutboxStoreRequired
 	}
-	event, buildErr := NewJournalOutboxEvent("ack:"+ackUUID.String()+":viewed:"+userUUID.String()+":journal", models.CreateJournalEntryRequest{DocumentID: ack.DocumentID, UserID: userUUID, Action: "ACK_VIEW", Details: "Документ просмотрен в рамках ознакомления"})
+	journal := models.CreateJournalEntryRequest{DocumentID: ack.DocumentID, UserID: userUUID, Action: "ACK_VIEW", Details: "Документ просмотрен в рамках ознакомления"}
+	var actingUserID *uuid.UUID
+	if userUUID != principal.UserID {
+		journal.UserID = principal.UserID
+		journal.OnBehalfOfUserID = &userUUID
+		actingUserID = &principal.UserID
+	}
+	event, buildErr := NewJournalOutboxEvent("ack:"+ackUUID.String()+":viewed:"+userUUID.String()+":journal", journal)
 	if buildErr != nil {
 		return buildErr
 	}
-	return store.MarkViewedWithOutbox(ackUUID, userUUID, []models.OutboxEvent{event})
+	return store.MarkViewedWithOutbox(ackUUID, userUUID, actingUserID, []models.OutboxEvent{event})
 }
 
 // MarkConfirmed отмечает задачу на ознакомление как выполненную (подтвержденную) текущим пользователем.
 func (s *AcknowledgmentService) MarkConfirmed(ackID string) error {
-	if err := s.auth.RequireAuthenticated(); err != nil {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return err
+	}
+	return s.markConfirmed(ctx, ackID)
+}
+
+func (s *AcknowledgmentService) markConfirmed(ctx context.Context, ackID string) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
 		return err
 	}
 	ackUUID, err := uuid.Parse(ackID)
 	if err != nil {
 		return models.NewBadRequestWrapped("неверный ID строки ознакомления", err)
 	}
-	userUUID, err := s.resolveAcknowledgmentSubjectUserID(ackUUID)
+	userUUID, err := s.resolveAcknowledgmentSubjectUserID(principal, ackUUID)
 	if err != nil {
 		return err
 	}
@@ -392,19 +463,23 @@ func (s *AcknowledgmentService) MarkConfirmed(ackID string) error {
 	if doc != nil {
 		documentNumber = doc.RegistrationNumber
 	}
-	err = store.MarkConfirmedWithEffects(ackUUID, userUUID, models.AcknowledgmentConfirmationEffects{UserEvents: s.acknowledgmentConfirmedEventRequests(ack, documentNumber, &userUUID)})
+	effects := models.AcknowledgmentConfirmationEffects{UserEvents: s.acknowledgmentConfirmedEventRequests(ctx, ack, documentNumber, &userUUID)}
+	if userUUID != principal.UserID {
+		effects.ActingUserID = &principal.UserID
+	}
+	err = store.MarkConfirmedWithEffects(ackUUID, userUUID, effects)
 	if errors.Is(err, models.ErrAlreadyConfirmed) {
 		return nil
 	}
 	return err
 }
 
-func (s *AcknowledgmentService) acknowledgmentConfirmedEventRequests(ack *models.Acknowledgment, documentNumber string, actorID *uuid.UUID) []models.CreateUserEventRequest {
+func (s *AcknowledgmentService) acknowledgmentConfirmedEventRequests(ctx context.Context, ack *models.Acknowledgment, documentNumber string, actorID *uuid.UUID) []models.CreateUserEventRequest {
 	if ack == nil {
 		return nil
 	}
 
-	excluded := eventActorExcluded(s.auth)
+	excluded := eventActorExcluded(ctx)
 	requests := make([]models.CreateUserEventRequest, 0)
 	recipients := appendUniqueUserID(nil, ack.CreatorID)
 	controlRecipients, err := collectUserIDsWithDocumentAction(s.userRepo, s.access, ack.DocumentKind, "acknowledge", excluded)
@@ -439,6 +514,18 @@ func (s *AcknowledgmentService) acknowledgmentConfirmedEventRequests(ack *models
 
 // Delete удаляет задачу на ознакомление по её ID.
 func (s *AcknowledgmentService) Delete(id string) error {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return err
+	}
+	return s.delete(ctx, id)
+}
+
+func (s *AcknowledgmentService) delete(ctx context.Context, id string) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
 	ackUUID, err := uuid.Parse(id)
 	if err != nil {
 		return models.NewBadRequestWrapped("неверный ID строки ознакомления", err)
@@ -451,7 +538,7 @@ func (s *AcknowledgmentService) Delete(id string) error {
 	if ack == nil {
 		return nil
 	}
-	if err := s.access.RequireDocumentAction(ack.DocumentID, "acknowledge"); err != nil {
+	if err := s.access.RequireDocumentAction(ctx, ack.DocumentID, "acknowledge"); err != nil {
 		return err
 	}
 
@@ -459,8 +546,7 @@ func (s *AcknowledgmentService) Delete(id string) error {
 	if !ok {
 		return errAcknowledgmentOutboxStoreRequired
 	}
-	currentUserID, _ := s.auth.GetCurrentUserUUID()
-	event, buildErr := NewJournalOutboxEvent("ack:"+ackUUID.String()+":deleted:journal", models.CreateJournalEntryRequest{DocumentID: ack.DocumentID, UserID: currentUserID, Action: "ACK_DELETE", Details: "Ознакомление удалено"})
+	event, buildErr := NewJournalOutboxEvent("ack:"+ackUUID.String()+":deleted:journal", models.CreateJournalEntryRequest{DocumentID: ack.DocumentID, UserID: principal.UserID, Action: "ACK_DELETE", Details: "Ознакомление удалено"})
 	if buildErr != nil {
 		return buildErr
 	}
@@ -5,6 +5,7 @@ import (
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -52,6 +53,7 @@ type atomicAcknowledgmentStore struct {
 	*mocks.AcknowledgmentStore
 	effects             []models.OutboxEvent
 	confirmationEffects models.AcknowledgmentConfirmationEffects
+	viewedBy            *uuid.UUID
 }
 
 func (s *atomicAcknowledgmentStore) CreateWithOutbox(ack *models.Acknowledgment, effects []models.OutboxEvent) error {
@@ -59,7 +61,8 @@ func (s *atomicAcknowledgmentStore) CreateWithOutbox(ack *models.Acknowledgment,
 	return s.AcknowledgmentStore.Create(ack)
 }
 
-func (s *atomicAcknowledgmentStore) MarkViewedWithOutbox(ackID, userID uuid.UUID, effects []models.OutboxEvent) error {
+func (s *atomicAcknowledgmentStore) MarkViewedWithOutbox(ackID, userID uuid.UUID, actingUserID *uuid.UUID, effects []models.OutboxEvent) error {
+	s.viewedBy = actingUserID
 	s.effects = append([]models.OutboxEvent(nil), effects...)
 	return s.AcknowledgmentStore.MarkViewed(ackID, userID)
 }
@@ -140,6 +143,28 @@ func TestAcknowledgmentService_Create(t *testing.T) {
 		requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный ID документа")
 		assert.Nil(t, result)
 	})
+
+	t.Run("stores deadline", func(t *testing.T) {
+		svc, repo, _, _, incomingRepo := setupAckService(t, "clerk")
+		incomingRepo.On("GetByID", docID).Return(&models.IncomingDocument{ID: docID, NomenclatureID: uuid.New()}, nil).Maybe()
+		repo.On("Create", mock.MatchedBy(func(ack *models.Acknowledgment) bool {
+			return ack.Deadline != nil && ack.Deadline.Equal(time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC))
+		})).Return(nil).Once()
+
+		result, err := svc.CreateWithDeadline(docID.String(), "text", "2026-09-15", []string{user1.String()})
+		require.NoError(t, err)
+		require.NotNil(t, result.Deadline)
+		atomicRepo := svc.repo.(*atomicAcknowledgmentStore)
+		assert.Contains(t, assignmentJournalRequest(t, atomicRepo.effects).Details, "со сроком до 15.09.2026")
+	})
+
+	t.Run("invalid deadline", func(t *testing.T) {
+		svc, _, _, _, incomingRepo := setupAckService(t, "clerk")
+		incomingRepo.On("GetByID", docID).Return(&models.IncomingDocument{ID: docID, NomenclatureID: uuid.New()}, nil).Maybe()
+		result, err := svc.CreateWithDeadline(docID.String(), "text", "15.09.2026", []string{user1.String()})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный формат срока ознакомления")
+		assert.Nil(t, result)
+	})
 }
 
 func TestAcknowledgmentServiceCreatePassesJournalAndUserEffectsToAtomicStore(t *testing.T) {
@@ -221,6 +246,27 @@ func TestAcknowledgmentService_GetPendingForCurrentUser(t *testing.T) {
 		assert.Len(t, result, 1)
 	})
 
+	t.Run("substitution limited by document kind hides other kinds", func(t *testing.T) {
+		principalID := uuid.New()
+		svc, repo, _, auth, _ := setupAckService(t, "executor")
+		userUUID, _ := uuid.Parse(auth.GetCurrentUserID())
+		auth.SetSubstitutionStore(&userSubstitutionStoreStub{grants: []models.SubstitutionGrant{
+			{PrincipalUserID: principalID, DocumentKinds: []string{string(models.DocumentKindIncomingLetter)}},
+		}})
+		incoming := models.Acknowledgment{ID: uuid.New(), DocumentKind: string(models.DocumentKindIncomingLetter)}
+		repo.On("GetPendingForUser", userUUID).Return([]models.Acknowledgment{}, nil).Once()
+		repo.On("GetPendingForUser", principalID).Return([]models.Acknowledgment{
+			incoming,
+			{ID: uuid.New(), DocumentKind: string(models.DocumentKindOutgoingLetter)},
+		}, nil).Once()
+
+		result, err := svc.GetPendingForCurrentUser()
+
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, incoming.ID.String(), result[0].ID)
+	})
+
 	t.Run("not authenticated", func(t *testing.T) {
 		svc := setupAckServiceNotAuth(t)
 		result, err := svc.GetPendingForCurrentUser()
@@ -329,6 +375,23 @@ func TestAcknowledgmentService_GetAllActive(t *testing.T) {
 		assert.Empty(t, result)
 	})
 
+	t.Run("passes deadline filter", func(t *testing.T) {
+		svc, repo, _, _, _ := setupAckService(t, "clerk")
+		repo.On("GetAllActive", mock.MatchedBy(func(filter models.AcknowledgmentFilter) bool {
+			return filter.DeadlineState == models.AcknowledgmentDeadlineOverdue
+		})).Return([]models.Acknowledgment{}, nil).Once()
+		result, err := svc.GetAllActiveFiltered(models.AcknowledgmentDeadlineOverdue)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("invalid deadline filter", func(t *testing.T) {
+		svc, _, _, _, _ := setupAckService(t, "clerk")
+		result, err := svc.GetAllActiveFiltered("soon")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "некорректный фильтр срока")
+		assert.Nil(t, result)
+	})
+
 	t.Run("forbidden executor", func(t *testing.T) {
 		svc, _, _, _, _ := setupAckService(t, "executor")
 		result, err := svc.GetAllActive()
@@ -392,6 +455,29 @@ func TestAcknowledgmentServiceMarkViewedPassesJournalEffectToAtomicStore(t *test
 	require.NoError(t, err)
 	require.Len(t, atomicRepo.effects, 1)
 	assert.Equal(t, models.OutboxEventJournal, atomicRepo.effects[0].EventType)
+	assert.Nil(t, atomicRepo.viewedBy)
+}
+
+func TestAcknowledgmentServiceMarkViewedRecordsSubstitute(t *testing.T) {
+	ackID, principalID := uuid.New(), uuid.New()
+	svc, repo, _, auth, _ := setupAckService(t, "")
+	substituteID, _ := uuid.Parse(auth.GetCurrentUserID())
+	auth.SetSubstitutionStore(&userSubstitutionStoreStub{activePrincipals: []uuid.UUID{principalID}})
+	repo.On("GetPendingForUser", principalID).Return([]models.Acknowledgment{
+		{ID: ackID, DocumentID: uuid.New(), DocumentKind: "incoming_letter"},
+	}, nil).Once()
+	repo.On("GetByID", ackID).Return(&models.Acknowledgment{ID: ackID, DocumentID: uuid.New(), DocumentKind: "incoming_letter"}, nil).Once()
+	repo.On("MarkViewed", ackID, principalID).Return(nil).Once()
+
+	err := svc.MarkViewed(ackID.String())
+
+	require.NoError(t, err)
+	atomicRepo := svc.repo.(*atomicAcknowledgmentStore)
+	require.NotNil(t, atomicRepo.viewedBy)
+	assert.Equal(t, substituteID, *atomicRepo.viewedBy)
+	journal := assignmentJournalRequest(t, atomicRepo.effects)
+	require.NotNil(t, journal.OnBehalfOfUserID)
+	assert.Equal(t, principalID, *journal.OnBehalfOfUserID)
 }
 
 func TestAcknowledgmentService_MarkConfirmed(t *testing.T) {
@@ -415,7 +501,7 @@ func TestAcknowledgmentService_MarkConfirmed(t *testing.T) {
 		principalID := uuid.New()
 		svc, repo, _, auth, _ := setupAckService(t, "")
 		substituteID, _ := uuid.Parse(auth.GetCurrentUserID())
-		svc.SetSubstitutionStore(&userSubstitutionStoreStub{
+		auth.SetSubstitutionStore(&userSubstitutionStoreStub{
 			activePrincipals: []uuid.UUID{principalID},
 		})
 		repo.On("GetPendingForUser", principalID).Return([]models.Acknowledgment{
@@ -432,6 +518,27 @@ func TestAcknowledgmentService_MarkConfirmed(t *testing.T) {
 
 		require.NoError(t, err)
 		assert.NotEqual(t, substituteID, principalID)
+		effects := svc.repo.(*atomicAcknowledgmentStore).confirmationEffects
+		require.NotNil(t, effects.ActingUserID)
+		assert.Equal(t, substituteID, *effects.ActingUserID)
+	})
+
+	t.Run("substitute without acknowledgment scope cannot confirm principal row", func(t *testing.T) {
+		principalID := uuid.New()
+		svc, repo, _, auth, _ := setupAckService(t, "")
+		substituteID, _ := uuid.Parse(auth.GetCurrentUserID())
+		auth.SetSubstitutionStore(&userSubstitutionStoreStub{grants: []models.SubstitutionGrant{
+			{PrincipalUserID: principalID, Scopes: []string{models.SubstitutionScopeAssignments}},
+		}})
+		repo.On("GetPendingForUser", principalID).Return([]models.Acknowledgment{
+			{ID: ackID, DocumentID: uuid.New(), DocumentKind: "incoming_letter"},
+		}, nil).Once()
+		repo.On("GetByID", ackID).Return(&models.Acknowledgment{ID: ackID, DocumentID: uuid.New(), DocumentKind: "incoming_letter"}, nil).Once()
+		repo.On("MarkConfirmed", ackID, substituteID).Return(models.ErrForbidden).Once()
+
+		err := svc.MarkConfirmed(ackID.String())
+
+		require.ErrorIs(t, err, models.ErrForbidden)
 	})
 
 	t.Run("not authenticated", func(t *testing.T) {
@@ -53,7 +53,7 @@ func TestIncomingLetterCommandHandler_CreateAdminDraft(t *testing.T) {
 		documentID := uuid.New()
 		registrationDate := time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC)
 		deps := setupIncomingLetterCommandHandler(t, nil)
-		deps.auth.SetAccessStore(newRoleMappedDocumentAccessStore(models.SystemPermissionAdmin))
+		deps.user.SystemPermissions = []string{models.SystemPermissionAdmin}
 
 		deps.refRepo.On("FindOrCreateOrganization", adminDraftPlaceholder).Return(&models.Organization{ID: orgID, Name: adminDraftPlaceholder}, nil).Once()
 		deps.repo.On("Create", mock.MatchedBy(func(req models.CreateIncomingDocRequest) bool {
@@ -81,7 +81,7 @@ func TestIncomingLetterCommandHandler_CreateAdminDraft(t *testing.T) {
 			PagesCount:     1,
 			CreatedBy:      deps.user.ID,
 		}, nil).Once()
-		result, err := deps.handler.CreateAdminDraft(validAdminDraftCreateRequest(nomenclatureID))
+		result, err := deps.handler.CreateAdminDraft(principalTestContext(deps.user), validAdminDraftCreateRequest(nomenclatureID))
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -90,7 +90,7 @@ func TestIncomingLetterCommandHandler_CreateAdminDraft(t *testing.T) {
 	t.Run("rejects missing admin permission", func(t *testing.T) {
 		deps := setupIncomingLetterCommandHandler(t, nil)
 
-		result, err := deps.handler.CreateAdminDraft(validAdminDraftCreateRequest(uuid.New()))
+		result, err := deps.handler.CreateAdminDraft(principalTestContext(deps.user), validAdminDraftCreateRequest(uuid.New()))
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, result)
@@ -103,7 +103,7 @@ func TestOutgoingLetterCommandHandler_CreateAdminDraft(t *testing.T) {
 	documentID := uuid.New()
 	registrationDate := time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC)
 	deps := setupOutgoingLetterCommandHandler(t, nil)
-	deps.auth.SetAccessStore(newRoleMappedDocumentAccessStore(models.SystemPermissionAdmin))
+	deps.user.SystemPermissions = []string{models.SystemPermissionAdmin}
 
 	deps.refRepo.On("FindOrCreateOrganization", adminDraftPlaceholder).Return(&models.Organization{ID: orgID, Name: adminDraftPlaceholder}, nil).Once()
 	deps.repo.On("Create", mock.MatchedBy(func(req models.CreateOutgoingDocRequest) bool {
@@ -130,7 +130,7 @@ func TestOutgoingLetterCommandHandler_CreateAdminDraft(t *testing.T) {
 		PagesCount:     1,
 		CreatedBy:      deps.user.ID,
 	}, nil).Once()
-	result, err := deps.handler.CreateAdminDraft(validAdminDraftCreateRequest(nomenclatureID))
+	result, err := deps.handler.CreateAdminDraft(principalTestContext(deps.user), validAdminDraftCreateRequest(nomenclatureID))
 
 	require.NoError(t, err)
 	require.NotNil(t, result)
@@ -141,7 +141,7 @@ func TestCitizenAppealCommandHandler_CreateAdminDraft(t *testing.T) {
 	documentID := uuid.New()
 	registrationDate := time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC)
 	deps := setupCitizenAppealCommandHandler(t, nil)
-	deps.auth.SetAccessStore(newRoleMappedDocumentAccessStore(models.SystemPermissionAdmin))
+	deps.user.SystemPermissions = []string{models.SystemPermissionAdmin}
 	deps.repo.createResult = &models.CitizenAppealDocument{
 		ID:                 documentID,
 		NomenclatureID:     nomenclatureID,
@@ -151,7 +151,7 @@ func TestCitizenAppealCommandHandler_CreateAdminDraft(t *testing.T) {
 		Content:            adminDraftPlaceholder,
 		CreatedBy:          deps.user.ID,
 	}
-	result, err := deps.handler.CreateAdminDraft(validAdminDraftCreateRequest(nomenclatureID))
+	result, err := deps.handler.CreateAdminDraft(principalTestContext(deps.user), validAdminDraftCreateRequest(nomenclatureID))
 
 	require.NoError(t, err)
 	require.NotNil(t, result)
@@ -177,7 +177,7 @@ func TestAdministrativeOrderCommandHandler_CreateAdminDraft(t *testing.T) {
 	documentID := uuid.New()
 	registrationDate := time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC)
 	deps := setupAdministrativeOrderCommandHandler(t, nil)
-	deps.auth.SetAccessStore(newRoleMappedDocumentAccessStore(models.SystemPermissionAdmin))
+	deps.user.SystemPermissions = []string{models.SystemPermissionAdmin}
 	deps.repo.createResult = &models.AdministrativeOrderDocument{
 		ID:                  documentID,
 		NomenclatureID:      nomenclatureID,
@@ -188,7 +188,7 @@ func TestAdministrativeOrderCommandHandler_CreateAdminDraft(t *testing.T) {
 		IsActive:            true,
 		CreatedBy:           deps.user.ID,
 	}
-	result, err := deps.handler.CreateAdminDraft(validAdminDraftCreateRequest(nomenclatureID))
+	result, err := deps.handler.CreateAdminDraft(principalTestContext(deps.user), validAdminDraftCreateRequest(nomenclatureID))
 
 	require.NoError(t, err)
 	require.NotNil(t, result)
@@ -214,15 +214,15 @@ func (h *documentRegistrationServiceDraftHandler) Kind() models.DocumentKind {
 	return h.kind
 }
 
-func (h *documentRegistrationServiceDraftHandler) RegisterDocument(req any) (any, error) {
+func (h *documentRegistrationServiceDraftHandler) RegisterDocument(_ context.Context, req any) (any, error) {
 	return nil, models.ErrForbidden
 }
 
-func (h *documentRegistrationServiceDraftHandler) UpdateDocument(req any) (any, error) {
+func (h *documentRegistrationServiceDraftHandler) UpdateDocument(_ context.Context, req any) (any, error) {
 	return nil, models.ErrForbidden
 }
 
-func (h *documentRegistrationServiceDraftHandler) CreateAdminDraft(req AdminDraftCreateRequest) (any, error) {
+func (h *documentRegistrationServiceDraftHandler) CreateAdminDraft(_ context.Context, req AdminDraftCreateRequest) (any, error) {
 	h.req = &req
 	return "created", nil
 }
@@ -235,21 +235,21 @@ func (h *documentRegistrationServicePlainHandler) Kind() models.DocumentKind {
 	return h.kind
 }
 
-func (h *documentRegistrationServicePlainHandler) RegisterDocument(req any) (any, error) {
+func (h *documentRegistrationServicePlainHandler) RegisterDocument(_ context.Context, req any) (any, error) {
 	return nil, models.ErrForbidden
 }
 
-func (h *documentRegistrationServicePlainHandler) UpdateDocument(req any) (any, error) {
+func (h *documentRegistrationServicePlainHandler) UpdateDocument(_ context.Context, req any) (any, error) {
 	return nil, models.ErrForbidden
 }
 
 func TestDocumentRegistrationService_CreateAdminDraft(t *testing.T) {
 	t.Run("delegates to admin draft handler", func(t *testing.T) {
 		handler := &documentRegistrationServiceDraftHandler{kind: models.DocumentKindIncomingLetter}
-		service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler))
+		service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler), nil)
 		req := validAdminDraftCreateRequest(uuid.New())
 
-		result, err := service.CreateAdminDraft(string(models.DocumentKindIncomingLetter), req)
+		result, err := service.createAdminDraft(principalTestContext(documentAccessUser(false, nil)), string(models.DocumentKindIncomingLetter), req)
 
 		require.NoError(t, err)
 		assert.Equal(t, "created", result)
@@ -259,9 +259,9 @@ func TestDocumentRegistrationService_CreateAdminDraft(t *testing.T) {
 
 	t.Run("rejects handler without admin draft support", func(t *testing.T) {
 		handler := &documentRegistrationServicePlainHandler{kind: models.DocumentKindIncomingLetter}
-		service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler))
+		service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler), nil)
 
-		result, err := service.CreateAdminDraft(string(models.DocumentKindIncomingLetter), validAdminDraftCreateRequest(uuid.New()))
+		result, err := service.createAdminDraft(principalTestContext(documentAccessUser(false, nil)), string(models.DocumentKindIncomingLetter), validAdminDraftCreateRequest(uuid.New()))
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, result)
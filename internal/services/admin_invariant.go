@@ -11,9 +11,15 @@ import (
 const activeAdministratorInvariantMessage = "at least one active administrator must remain"
 
 func activeAdministratorInvariantConflict(err error) error {
-	var pqErr *pq.Error
-	if !errors.As(err, &pqErr) || pqErr.Code != "P0001" || pqErr.Message != activeAdministratorInvariantMessage {
+	if !isActiveAdministratorInvariantViolation(err) {
 		return err
 	}
 	return models.NewConflict("нельзя деактивировать или лишить права последнего активного администратора")
 }
+
+// isActiveAdministratorInvariantViolation распознает ошибку триггера
+// ensure_active_administrator_exists.
+func isActiveAdministratorInvariantViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "P0001" && pqErr.Message == activeAdministratorInvariantMessage
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -42,7 +43,6 @@ type AdministrativeOrderUpdateRequest struct {
 type AdministrativeOrderCommandHandler struct {
 	repo    AdministrativeOrderDocStore
 	nomRepo NomenclatureStore
-	auth    *AuthService
 	journal *JournalService
 	access  *DocumentAccessService
 }
@@ -57,14 +57,12 @@ type administrativeOrderJournalStore interface {
 func NewAdministrativeOrderCommandHandler(
 	repo AdministrativeOrderDocStore,
 	nomRepo NomenclatureStore,
-	auth *AuthService,
 	journal *JournalService,
 	access *DocumentAccessService,
 ) *AdministrativeOrderCommandHandler {
 	return &AdministrativeOrderCommandHandler{
 		repo:    repo,
 		nomRepo: nomRepo,
-		auth:    auth,
 		journal: journal,
 		access:  access,
 	}
@@ -76,17 +74,21 @@ func (h *AdministrativeOrderCommandHandler) Kind() models.DocumentKind {
 }
 
 // Register регистрирует приказ.
-func (h *AdministrativeOrderCommandHandler) Register(req AdministrativeOrderRegisterRequest) (*dto.AdministrativeOrderDocument, error) {
+func (h *AdministrativeOrderCommandHandler) Register(ctx context.Context, req AdministrativeOrderRegisterRequest) (*dto.AdministrativeOrderDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	adminOverride, err := buildAdminNumberOverride(req.AdminNumberOverride)
 	if err != nil {
 		return nil, err
 	}
 	if adminOverride != nil {
-		if err := h.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
-			return nil, err
+		if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+			return nil, models.ErrForbidden
 		}
 	} else {
-		if err := h.access.RequireCreate(models.DocumentKindAdministrativeOrder); err != nil {
+		if err := h.access.RequireCreate(ctx, models.DocumentKindAdministrativeOrder); err != nil {
 			return nil, err
 		}
 	}
@@ -121,10 +123,7 @@ func (h *AdministrativeOrderCommandHandler) Register(req AdministrativeOrderRegi
 	}
 
 	orderNumber := strings.TrimSpace(req.RegistrationNumber)
-	createdBy, err := h.auth.GetCurrentUserUUID()
-	if err != nil {
-		return nil, ErrNotAuthenticated
-	}
+	createdBy := principal.UserID
 
 	createReq := models.CreateAdministrativeOrderDocRequest{
 		NomenclatureID:          nomID,
@@ -149,19 +148,23 @@ func (h *AdministrativeOrderCommandHandler) Register(req AdministrativeOrderRegi
 }
 
 // RegisterDocument реализует общий command-интерфейс по виду документа.
-func (h *AdministrativeOrderCommandHandler) RegisterDocument(req any) (any, error) {
+func (h *AdministrativeOrderCommandHandler) RegisterDocument(ctx context.Context, req any) (any, error) {
 	typedReq, ok := req.(AdministrativeOrderRegisterRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid register request for kind %s", h.Kind())
 	}
-	return h.Register(typedReq)
+	return h.Register(ctx, typedReq)
 }
 
 // CreateAdminDraft создает черновик приказа с административно заданным номером.
-func (h *AdministrativeOrderCommandHandler) CreateAdminDraft(req AdminDraftCreateRequest) (any, error) {
-	if err := h.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+func (h *AdministrativeOrderCommandHandler) CreateAdminDraft(ctx context.Context, req AdminDraftCreateRequest) (any, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
 		return nil, err
 	}
+	if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+		return nil, models.ErrForbidden
+	}
 	nomID, err := uuid.Parse(req.NomenclatureID)
 	if err != nil {
 		return nil, models.NewBadRequest("неверный ID номенклатуры")
@@ -177,10 +180,7 @@ func (h *AdministrativeOrderCommandHandler) CreateAdminDraft(req AdminDraftCreat
 	if adminOverride == nil {
 		return nil, models.NewBadRequest("укажите административный номер")
 	}
-	createdBy, err := h.auth.GetCurrentUserUUID()
-	if err != nil {
-		return nil, ErrNotAuthenticated
-	}
+	createdBy := principal.UserID
 
 	createReq := models.CreateAdministrativeOrderDocRequest{
 		NomenclatureID:          nomID,
@@ -202,12 +202,16 @@ func (h *AdministrativeOrderCommandHandler) CreateAdminDraft(req AdminDraftCreat
 }
 
 // Update обновляет приказ.
-func (h *AdministrativeOrderCommandHandler) Update(req AdministrativeOrderUpdateRequest) (*dto.AdministrativeOrderDocument, error) {
+func (h *AdministrativeOrderCommandHandler) Update(ctx context.Context, req AdministrativeOrderUpdateRequest) (*dto.AdministrativeOrderDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	uid, err := uuid.Parse(req.ID)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 	}
-	if err := h.access.RequireDocumentAction(uid, "update"); err != nil {
+	if err := h.access.RequireDocumentAction(ctx, uid, "update"); err != nil {
 		return nil, err
 	}
 
@@ -245,8 +249,7 @@ func (h *AdministrativeOrderCommandHandler) Update(req AdministrativeOrderUpdate
 	if !ok {
 		return nil, fmt.Errorf("administrative order store must support atomic outbox operations")
 	}
-	currentUserID, _ := h.auth.GetCurrentUserUUID()
-	event, buildErr := NewJournalOutboxEvent("administrative-order:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: currentUserID, Action: "UPDATE", Details: "Приказ отредактирован"})
+	event, buildErr := NewJournalOutboxEvent("administrative-order:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: principal.UserID, Action: "UPDATE", Details: "Приказ отредактирован"})
 	if buildErr != nil {
 		return nil, buildErr
 	}
@@ -255,12 +258,12 @@ func (h *AdministrativeOrderCommandHandler) Update(req AdministrativeOrderUpdate
 }
 
 // UpdateDocument реализует общий command-интерфейс по виду документа.
-func (h *AdministrativeOrderCommandHandler) UpdateDocument(req any) (any, error) {
+func (h *AdministrativeOrderCommandHandler) UpdateDocument(ctx context.Context, req any) (any, error) {
 	typedReq, ok := req.(AdministrativeOrderUpdateRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid update request for kind %s", h.Kind())
 	}
-	return h.Update(typedReq)
+	return h.Update(ctx, typedReq)
 }
 
 func parseOptionalDate(value string, message string) (*time.Time, error) {
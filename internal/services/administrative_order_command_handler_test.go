@@ -112,7 +112,7 @@ func setupAdministrativeOrderCommandHandler(t *testing.T, allowed map[models.Doc
 		nil,
 	)
 	journal := NewJournalService(journalRepo, auth, access)
-	handler := NewAdministrativeOrderCommandHandler(repo, nomRepo, auth, journal, access)
+	handler := NewAdministrativeOrderCommandHandler(repo, nomRepo, journal, access)
 
 	return &administrativeOrderHandlerDeps{
 		handler:     handler,
@@ -158,7 +158,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 			CreatedBy:           deps.user.ID,
 		}
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -181,7 +181,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		deps := setupAdministrativeOrderCommandHandler(t, nil)
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, result)
@@ -196,7 +196,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 		req.NomenclatureID = "bad-id"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ID номенклатуры")
@@ -212,7 +212,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 		req.IdempotencyKey = uuid.Nil.String()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ключ идемпотентности")
@@ -228,7 +228,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 		req.OrderDate = "03.06.2026"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный формат даты приказа")
@@ -244,7 +244,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 		req.CancelledAt = "2026-07-01"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "для действующего приказа дата отмены должна быть пустой")
@@ -260,7 +260,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 		req.IsActive = false
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "для недействующего приказа укажите дату отмены")
@@ -276,7 +276,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 		req.ExecutionController = "  "
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "укажите контроль за выполнением")
@@ -292,7 +292,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 		req.ExecutionDeadline = "30.06.2026"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный формат срока выполнения")
@@ -309,7 +309,7 @@ func TestAdministrativeOrderCommandHandler_Register(t *testing.T) {
 		deps.repo.createErr = expectedErr
 		req := validAdministrativeOrderRegisterRequest(uuid.New(), uuid.New())
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, result)
@@ -351,7 +351,7 @@ func TestAdministrativeOrderCommandHandler_Update(t *testing.T) {
 			AcknowledgmentFullNames: []string{" Сидор Сидоров "},
 		}
 
-		result, err := deps.handler.Update(req)
+		result, err := deps.handler.Update(principalTestContext(deps.user), req)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -372,7 +372,7 @@ func TestAdministrativeOrderCommandHandler_Update(t *testing.T) {
 			allowDocumentActions(models.DocumentKindAdministrativeOrder, "read", "update"),
 		)
 
-		result, err := deps.handler.Update(AdministrativeOrderUpdateRequest{ID: "bad-id"})
+		result, err := deps.handler.Update(principalTestContext(deps.user), AdministrativeOrderUpdateRequest{ID: "bad-id"})
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ID документа")
@@ -392,7 +392,7 @@ func TestAdministrativeOrderCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(AdministrativeOrderUpdateRequest{ID: documentID.String()})
+		result, err := deps.handler.Update(principalTestContext(deps.user), AdministrativeOrderUpdateRequest{ID: documentID.String()})
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, result)
@@ -411,7 +411,7 @@ func TestAdministrativeOrderCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(AdministrativeOrderUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), AdministrativeOrderUpdateRequest{
 			ID:                  documentID.String(),
 			OrderDate:           "2026-06-04",
 			ExecutionController: "Контроль",
@@ -437,7 +437,7 @@ func TestAdministrativeOrderCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(AdministrativeOrderUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), AdministrativeOrderUpdateRequest{
 			ID:                  documentID.String(),
 			OrderDate:           "04.06.2026",
 			ExecutionController: "Контроль",
@@ -462,7 +462,7 @@ func TestAdministrativeOrderCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(AdministrativeOrderUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), AdministrativeOrderUpdateRequest{
 			ID:                  documentID.String(),
 			OrderDate:           "2026-06-04",
 			ExecutionDeadline:   "30.06.2026",
@@ -488,7 +488,7 @@ func TestAdministrativeOrderCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(AdministrativeOrderUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), AdministrativeOrderUpdateRequest{
 			ID:                  documentID.String(),
 			OrderDate:           "2026-06-04",
 			ExecutionController: " ",
@@ -515,7 +515,7 @@ func TestAdministrativeOrderCommandHandler_Update(t *testing.T) {
 		}
 		deps.repo.updateErr = expectedErr
 
-		result, err := deps.handler.Update(AdministrativeOrderUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), AdministrativeOrderUpdateRequest{
 			ID:                  documentID.String(),
 			OrderDate:           "2026-06-04",
 			Title:               "Обновленный приказ",
@@ -536,12 +536,12 @@ func TestAdministrativeOrderCommandHandler_CommandInterface(t *testing.T) {
 		allowDocumentActions(models.DocumentKindAdministrativeOrder, "create", "read", "update"),
 	)
 
-	registered, err := deps.handler.RegisterDocument(struct{}{})
+	registered, err := deps.handler.RegisterDocument(principalTestContext(deps.user), struct{}{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid register request")
 	assert.Nil(t, registered)
 
-	updated, err := deps.handler.UpdateDocument(struct{}{})
+	updated, err := deps.handler.UpdateDocument(principalTestContext(deps.user), struct{}{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid update request")
 	assert.Nil(t, updated)
@@ -34,6 +34,10 @@ func NewAdministrativeOrderService(
 
 // MarkAcknowledged проставляет отметку ознакомления для строки листа приказа.
 func (s *AdministrativeOrderService) MarkAcknowledged(personIDStr string) (*dto.AdministrativeOrderAcknowledgmentPerson, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
 	personID, err := uuid.Parse(personIDStr)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID строки ознакомления", err)
@@ -46,7 +50,7 @@ func (s *AdministrativeOrderService) MarkAcknowledged(personIDStr string) (*dto.
 	if person == nil {
 		return nil, models.NewNotFound("строка ознакомления не найдена")
 	}
-	if err := s.access.RequireDocumentAction(person.DocumentID, "update"); err != nil {
+	if err := s.access.RequireDocumentAction(ctx, person.DocumentID, "update"); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// assignmentDeadlineStore хранит запросы на продление срока и историю сроков поручения.
+type assignmentDeadlineStore interface {
+	CreateDeadlineExtensionWithOutbox(ext models.AssignmentDeadlineExtension, effects []models.OutboxEvent) (*models.AssignmentDeadlineExtension, error)
+	DecideDeadlineExtensionWithOutbox(id uuid.UUID, status string, decidedBy uuid.UUID, comment string, effects []models.OutboxEvent) (*models.AssignmentDeadlineExtension, error)
+	GetDeadlineExtension(id uuid.UUID) (*models.AssignmentDeadlineExtension, error)
+	GetDeadlineExtensions(assignmentID uuid.UUID) ([]models.AssignmentDeadlineExtension, error)
+	GetDeadlineHistory(assignmentID uuid.UUID) ([]models.AssignmentDeadlineChange, error)
+	GetOriginalDeadline(assignmentID uuid.UUID) (*time.Time, error)
+}
+
+func (s *AssignmentService) deadlineStore() (assignmentDeadlineStore, error) {
+	repo, ok := s.repo.(assignmentDeadlineStore)
+	if !ok {
+		return nil, fmt.Errorf("assignment store must support deadline extensions")
+	}
+	return repo, nil
+}
+
+// RequestDeadlineExtension подает запрос исполнителя на продление срока поручения.
+func (s *AssignmentService) RequestDeadlineExtension(assignmentID, deadline, reason string) (*dto.AssignmentDeadlineExtension, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.requestDeadlineExtension(ctx, assignmentID, deadline, reason)
+}
+
+func (s *AssignmentService) requestDeadlineExtension(ctx context.Context, assignmentID, deadline, reason string) (*dto.AssignmentDeadlineExtension, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(assignmentID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID поручения", err)
+	}
+	existing, err := s.repo.GetByID(uid)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, models.NewNotFound("поручение не найдено")
+	}
+	if !actsForAssignmentExecutor(principal, existing) {
+		return nil, models.NewForbidden("продление срока может запросить только исполнитель поручения")
+	}
+	if _, open := executorAssignmentTransitions[existing.Status]; !open {
+		return nil, models.NewConflict("продлить можно только поручение в работе")
+	}
+	if existing.Deadline == nil {
+		return nil, models.NewBadRequest("у поручения не установлен срок")
+	}
+	requested, err := time.Parse("2006-01-02", deadline)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный формат срока исполнения", err)
+	}
+	if !requested.After(*existing.Deadline) {
+		return nil, models.NewBadRequest("новый срок должен быть позже текущего")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, models.NewBadRequest("укажите обоснование продления срока")
+	}
+	parent, err := s.parentAssignment(existing)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSubtaskDeadline(parent, &requested); err != nil {
+		return nil, err
+	}
+	repo, err := s.deadlineStore()
+	if err != nil {
+		return nil, err
+	}
+
+	ext := models.AssignmentDeadlineExtension{ID: uuid.New(), AssignmentID: existing.ID, RequestedBy: principal.UserID, PreviousDeadline: existing.Deadline, RequestedDeadline: requested, Reason: reason}
+	journalRequest := models.CreateJournalEntryRequest{DocumentID: existing.DocumentID, UserID: principal.UserID, Action: "ASSIGNMENT_DEADLINE_EXTENSION_REQUEST", Details: fmt.Sprintf("Запрошено продление срока поручения с %s до %s: %s", existing.Deadline.Format("02.01.2006"), requested.Format("02.01.2006"), reason)}
+	if existing.ExecutorID != principal.UserID {
+		ext.OnBehalfOfUserID = &existing.ExecutorID
+		journalRequest.OnBehalfOfUserID = &existing.ExecutorID
+	}
+	transition := "extension:" + ext.ID.String() + ":requested"
+	journal, err := NewJournalOutboxEvent(assignmentOutboxKey(existing.ID, transition, "", nil, "journal"), journalRequest)
+	if err != nil {
+		return nil, err
+	}
+	effects := []models.OutboxEvent{journal}
+	// Запрос рассматривает тот, кто выдал поручение: для подпоручения —
+	// исполнитель родительского поручения, иначе распорядители документа.
+	var recipients []uuid.UUID
+	if parent != nil {
+		recipients = []uuid.UUID{parent.ExecutorID}
+	} else if s.events != nil {
+		excluded := map[uuid.UUID]struct{}{principal.UserID: {}, existing.ExecutorID: {}}
+		recipients, err = collectUserIDsWithDocumentAction(s.userRepo, s.access, existing.DocumentKind, "assign", excluded)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.events != nil {
+		message := fmt.Sprintf("Исполнитель просит продлить срок поручения по документу %s до %s", documentNumberLabel(existing.DocumentNumber), requested.Format("02.01.2006"))
+		for _, recipient := range recipients {
+			request := models.CreateUserEventRequest{RecipientUserID: recipient, ActorUserID: &principal.UserID, DocumentID: existing.DocumentID, DocumentKind: existing.DocumentKind, DocumentNumber: existing.DocumentNumber, EntityType: models.UserEventEntityAssignment, EntityID: existing.ID, EventType: models.UserEventExtensionRequested, Title: "Запрос на продление срока", Message: message, Metadata: userEventMetadata(map[string]string{"extensionId": ext.ID.String(), "deadline": deadline, "reason": reason})}
+			event, buildErr := NewUserEventOutboxEvent(assignmentOutboxKey(existing.ID, transition, "", &recipient, "user_event"), request)
+			if buildErr != nil {
+				return nil, buildErr
+			}
+			effects = append(effects, event)
+		}
+	}
+	res, err := repo.CreateDeadlineExtensionWithOutbox(ext, effects)
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapAssignmentDeadlineExtension(res), nil
+}
+
+// ApproveDeadlineExtension одобряет запрос и переносит срок поручения.
+func (s *AssignmentService) ApproveDeadlineExtension(id, comment string) (*dto.AssignmentDeadlineExtension, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.decideDeadlineExtension(ctx, id, models.DeadlineExtensionApproved, comment)
+}
+
+// RejectDeadlineExtension отклоняет запрос; срок поручения не меняется.
+func (s *AssignmentService) RejectDeadlineExtension(id, comment string) (*dto.AssignmentDeadlineExtension, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.decideDeadlineExtension(ctx, id, models.DeadlineExtensionRejected, comment)
+}
+
+func (s *AssignmentService) decideDeadlineExtension(ctx context.Context, id, status, comment string) (*dto.AssignmentDeadlineExtension, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	extID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID запроса", err)
+	}
+	repo, err := s.deadlineStore()
+	if err != nil {
+		return nil, err
+	}
+	ext, err := repo.GetDeadlineExtension(extID)
+	if err != nil {
+		return nil, err
+	}
+	if ext.Status != models.DeadlineExtensionPending {
+		return nil, models.NewConflict("запрос на продление уже рассмотрен")
+	}
+	existing, err := s.repo.GetByID(ext.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, models.NewNotFound("поручение не найдено")
+	}
+	parent, err := s.parentAssignment(existing)
+	if err != nil {
+		return nil, err
+	}
+	if _, canManage := s.assignmentActorAccess(ctx, principal, existing, parent); !canManage {
+		return nil, models.ErrForbidden
+	}
+	if ext.RequestedBy == principal.UserID || actsForAssignmentExecutor(principal, existing) {
+		return nil, models.NewForbidden("нельзя рассмотреть собственный запрос на продление срока")
+	}
+	comment = strings.TrimSpace(comment)
+	if status == models.DeadlineExtensionRejected && comment == "" {
+		return nil, models.NewBadRequest("укажите причину отказа в продлении срока")
+	}
+	if status == models.DeadlineExtensionApproved {
+		// Срок родителя мог сократиться после подачи запроса.
+		if err := validateSubtaskDeadline(parent, &ext.RequestedDeadline); err != nil {
+			return nil, err
+		}
+	}
+
+	requested := ext.RequestedDeadline.Format("02.01.2006")
+	action, details := "ASSIGNMENT_DEADLINE_EXTENSION_APPROVE", fmt.Sprintf("Срок поручения продлен до %s", requested)
+	eventType, title, message := models.UserEventExtensionApproved, "Срок поручения продлен", fmt.Sprintf("Срок поручения по документу %s продлен до %s", documentNumberLabel(existing.DocumentNumber), requested)
+	if status == models.DeadlineExtensionRejected {
+		action, details = "ASSIGNMENT_DEADLINE_EXTENSION_REJECT", fmt.Sprintf("Отклонено продление срока поручения до %s: %s", requested, comment)
+		eventType, title, message = models.UserEventExtensionRejected, "В продлении срока отказано", fmt.Sprintf("Запрос на продление срока поручения по документу %s отклонен: %s", documentNumberLabel(existing.DocumentNumber), comment)
+	} else if comment != "" {
+		details += ": " + comment
+	}
+	transition := "extension:" + ext.ID.String() + ":" + status
+	journal, err := NewJournalOutboxEvent(assignmentOutboxKey(existing.ID, transition, "", nil, "journal"), models.CreateJournalEntryRequest{DocumentID: existing.DocumentID, UserID: principal.UserID, Action: action, Details: details})
+	if err != nil {
+		return nil, err
+	}
+	effects := []models.OutboxEvent{journal}
+	if s.events != nil {
+		recipients := appendUniqueUserID(assignmentExecutorRecipientIDs(existing), ext.RequestedBy)
+		for _, recipient := range recipients {
+			request := models.CreateUserEventRequest{RecipientUserID: recipient, ActorUserID: &principal.UserID, DocumentID: existing.DocumentID, DocumentKind: existing.DocumentKind, DocumentNumber: existing.DocumentNumber, EntityType: models.UserEventEntityAssignment, EntityID: existing.ID, EventType: eventType, Title: title, Message: message, Metadata: userEventMetadata(map[string]string{"extensionId": ext.ID.String(), "deadline": ext.RequestedDeadline.Format("2006-01-02"), "comment": comment})}
+			event, buildErr := NewUserEventOutboxEvent(assignmentOutboxKey(existing.ID, transition, "", &recipient, "user_event"), request)
+			if buildErr != nil {
+				return nil, buildErr
+			}
+			effects = append(effects, event)
+		}
+	}
+	res, err := repo.DecideDeadlineExtensionWithOutbox(ext.ID, status, principal.UserID, comment, effects)
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapAssignmentDeadlineExtension(res), nil
+}
+
+// GetDeadlineHistory возвращает историю срока поручения и запросы на продление.
+func (s *AssignmentService) GetDeadlineHistory(assignmentID string) (*dto.AssignmentDeadlineHistory, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.getDeadlineHistory(ctx, assignmentID)
+}
+
+func (s *AssignmentService) getDeadlineHistory(ctx context.Context, assignmentID string) (*dto.AssignmentDeadlineHistory, error) {
+	// Историю видит тот, кому доступна карточка поручения.
+	assignment, err := s.getByID(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(assignment.ID)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := s.deadlineStore()
+	if err != nil {
+		return nil, err
+	}
+	original, err := repo.GetOriginalDeadline(uid)
+	if err != nil {
+		return nil, err
+	}
+	extensions, err := repo.GetDeadlineExtensions(uid)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := repo.GetDeadlineHistory(uid)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.AssignmentDeadlineHistory{
+		OriginalDeadline: original,
+		Deadline:         assignment.Deadline,
+		Extensions:       dto.MapAssignmentDeadlineExtensions(extensions),
+		Changes:          dto.MapAssignmentDeadlineChanges(changes),
+	}, nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// deadlineAssignmentStore дополняет хранилище подпоручений запросами на
+// продление и историей срока.
+type deadlineAssignmentStore struct {
+	*subtaskAssignmentStore
+	extensions map[uuid.UUID]models.AssignmentDeadlineExtension
+	changes    []models.AssignmentDeadlineChange
+	original   map[uuid.UUID]time.Time
+}
+
+func newDeadlineAssignmentStore(store *subtaskAssignmentStore) *deadlineAssignmentStore {
+	return &deadlineAssignmentStore{subtaskAssignmentStore: store, extensions: map[uuid.UUID]models.AssignmentDeadlineExtension{}, original: map[uuid.UUID]time.Time{}}
+}
+
+func (s *deadlineAssignmentStore) CreateDeadlineExtensionWithOutbox(ext models.AssignmentDeadlineExtension, effects []models.OutboxEvent) (*models.AssignmentDeadlineExtension, error) {
+	for _, existing := range s.extensions {
+		if existing.AssignmentID == ext.AssignmentID && existing.Status == models.DeadlineExtensionPending {
+			return nil, models.NewConflict("по поручению уже есть запрос на продление срока")
+		}
+	}
+	ext.Status = models.DeadlineExtensionPending
+	s.extensions[ext.ID] = ext
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	return &ext, nil
+}
+
+func (s *deadlineAssignmentStore) DecideDeadlineExtensionWithOutbox(id uuid.UUID, status string, decidedBy uuid.UUID, comment string, effects []models.OutboxEvent) (*models.AssignmentDeadlineExtension, error) {
+	ext := s.extensions[id]
+	ext.Status, ext.DecidedBy, ext.DecisionComment = status, &decidedBy, comment
+	s.extensions[id] = ext
+	if status == models.DeadlineExtensionApproved {
+		item := s.items[ext.AssignmentID]
+		if _, extended := s.original[item.ID]; !extended {
+			s.original[item.ID] = *item.Deadline
+		}
+		s.changes = append(s.changes, models.AssignmentDeadlineChange{ID: uuid.New(), AssignmentID: item.ID, OldDeadline: item.Deadline, NewDeadline: &ext.RequestedDeadline, ChangedBy: decidedBy, ExtensionID: &id})
+		item.Deadline = &ext.RequestedDeadline
+		s.items[item.ID] = item
+	}
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	return &ext, nil
+}
+
+func (s *deadlineAssignmentStore) GetDeadlineExtension(id uuid.UUID) (*models.AssignmentDeadlineExtension, error) {
+	ext, ok := s.extensions[id]
+	if !ok {
+		return nil, models.NewNotFound("запрос на продление не найден")
+	}
+	return &ext, nil
+}
+
+func (s *deadlineAssignmentStore) GetDeadlineExtensions(assignmentID uuid.UUID) ([]models.AssignmentDeadlineExtension, error) {
+	var items []models.AssignmentDeadlineExtension
+	for _, ext := range s.extensions {
+		if ext.AssignmentID == assignmentID {
+			items = append(items, ext)
+		}
+	}
+	return items, nil
+}
+
+func (s *deadlineAssignmentStore) GetDeadlineHistory(assignmentID uuid.UUID) ([]models.AssignmentDeadlineChange, error) {
+	return s.changes, nil
+}
+
+func (s *deadlineAssignmentStore) GetOriginalDeadline(assignmentID uuid.UUID) (*time.Time, error) {
+	original, ok := s.original[assignmentID]
+	if !ok {
+		return nil, nil
+	}
+	return &original, nil
+}
+
+func TestAssignmentService_RequestDeadlineExtension(t *testing.T) {
+	deadline := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC)
+	parentDeadline := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+
+	t.Run("subtask executor asks parent executor", func(t *testing.T) {
+		svc, repo, userRepo, auth, _ := setupAssignmentService(t, "executor")
+		executorID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		svc.events = NewUserEventService(&fakeUserEventStore{}, auth)
+		parent := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: uuid.New(), Status: "in_progress", Deadline: &parentDeadline}
+		child := models.Assignment{ID: uuid.New(), ParentID: &parent.ID, DocumentID: parent.DocumentID, DocumentKind: "incoming_letter", ExecutorID: executorID, Status: "in_progress", Deadline: &deadline}
+		store := newDeadlineAssignmentStore(newSubtaskAssignmentStore(repo, parent, child))
+		svc.repo = store
+
+		ext, err := svc.RequestDeadlineExtension(child.ID.String(), "2026-07-18", "  Ждем ответ смежников ")
+		require.NoError(t, err)
+		assert.Equal(t, models.DeadlineExtensionPending, ext.Status)
+		assert.Equal(t, "Ждем ответ смежников", ext.Reason)
+		require.NotNil(t, ext.PreviousDeadline)
+		assert.True(t, ext.PreviousDeadline.Equal(deadline))
+		journal := assignmentJournalRequest(t, store.effects)
+		assert.Equal(t, "ASSIGNMENT_DEADLINE_EXTENSION_REQUEST", journal.Action)
+		assert.Contains(t, journal.Details, "с 10.07.2026 до 18.07.2026")
+		assert.Nil(t, journal.OnBehalfOfUserID)
+		assert.Equal(t, []uuid.UUID{parent.ExecutorID}, userEventRecipients(t, store.effects, models.UserEventExtensionRequested))
+		userRepo.AssertNotCalled(t, "GetAll")
+
+		_, err = svc.RequestDeadlineExtension(child.ID.String(), "2026-07-19", "Еще причина")
+		requireAppError(t, err, "CONFLICT", 409, "уже есть запрос")
+	})
+
+	t.Run("top level request goes to document managers", func(t *testing.T) {
+		svc, repo, userRepo, auth, _ := setupAssignmentService(t, "clerk")
+		executorID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		svc.events = NewUserEventService(&fakeUserEventStore{}, auth)
+		managerID := uuid.New()
+		userRepo.On("GetAll").Return([]models.User{{ID: executorID, IsActive: true}, {ID: managerID, IsActive: true}, {ID: uuid.New()}}, nil).Once()
+		assignment := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: executorID, Status: "new", Deadline: &deadline}
+		store := newDeadlineAssignmentStore(newSubtaskAssignmentStore(repo, assignment))
+		svc.repo = store
+
+		_, err = svc.RequestDeadlineExtension(assignment.ID.String(), "2026-08-01", "Большой объем")
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{managerID}, userEventRecipients(t, store.effects, models.UserEventExtensionRequested))
+	})
+
+	t.Run("validates requester, date and reason", func(t *testing.T) {
+		svc, repo, _, auth, _ := setupAssignmentService(t, "executor")
+		executorID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		parent := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: uuid.New(), Status: "in_progress", Deadline: &parentDeadline}
+		own := models.Assignment{ID: uuid.New(), ParentID: &parent.ID, DocumentID: parent.DocumentID, DocumentKind: "incoming_letter", ExecutorID: executorID, Status: "in_progress", Deadline: &deadline}
+		foreign := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: uuid.New(), Status: "in_progress", Deadline: &deadline}
+		closed := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: executorID, Status: "completed", Deadline: &deadline}
+		store := newDeadlineAssignmentStore(newSubtaskAssignmentStore(repo, parent, own, foreign, closed))
+		svc.repo = store
+
+		_, err = svc.RequestDeadlineExtension(foreign.ID.String(), "2026-07-15", "Причина")
+		requireAppError(t, err, "FORBIDDEN", 403, "только исполнитель")
+		_, err = svc.RequestDeadlineExtension(closed.ID.String(), "2026-07-15", "Причина")
+		requireAppError(t, err, "CONFLICT", 409, "поручение в работе")
+		_, err = svc.RequestDeadlineExtension(own.ID.String(), "2026-07-10", "Причина")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "позже текущего")
+		_, err = svc.RequestDeadlineExtension(own.ID.String(), "2026-07-25", "Причина")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "не может быть позже срока поручения (20.07.2026)")
+		_, err = svc.RequestDeadlineExtension(own.ID.String(), "2026-07-15", " ")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "обоснование")
+		assert.Empty(t, store.extensions)
+	})
+}
+
+func TestAssignmentService_DecideDeadlineExtension(t *testing.T) {
+	deadline := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC)
+	requested := time.Date(2026, 7, 18, 0, 0, 0, 0, time.UTC)
+	setup := func(t *testing.T, executorID uuid.UUID) (*AssignmentService, *deadlineAssignmentStore, models.Assignment, models.AssignmentDeadlineExtension) {
+		svc, repo, _, auth, _ := setupAssignmentService(t, "clerk")
+		svc.events = NewUserEventService(&fakeUserEventStore{}, auth)
+		if executorID == uuid.Nil {
+			executorID = uuid.New()
+		}
+		assignment := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: executorID, Status: "in_progress", Deadline: &deadline}
+		store := newDeadlineAssignmentStore(newSubtaskAssignmentStore(repo, assignment))
+		ext := models.AssignmentDeadlineExtension{ID: uuid.New(), AssignmentID: assignment.ID, RequestedBy: executorID, PreviousDeadline: &deadline, RequestedDeadline: requested, Reason: "Причина", Status: models.DeadlineExtensionPending}
+		store.extensions[ext.ID] = ext
+		svc.repo = store
+		return svc, store, assignment, ext
+	}
+
+	t.Run("approval extends deadline and keeps original", func(t *testing.T) {
+		svc, store, assignment, ext := setup(t, uuid.Nil)
+
+		res, err := svc.ApproveDeadlineExtension(ext.ID.String(), "")
+		require.NoError(t, err)
+		assert.Equal(t, models.DeadlineExtensionApproved, res.Status)
+		assert.True(t, store.items[assignment.ID].Deadline.Equal(requested))
+		assert.Equal(t, "ASSIGNMENT_DEADLINE_EXTENSION_APPROVE", assignmentJournalRequest(t, store.effects).Action)
+		assert.Equal(t, []uuid.UUID{assignment.ExecutorID}, userEventRecipients(t, store.effects, models.UserEventExtensionApproved))
+
+		history, err := svc.GetDeadlineHistory(assignment.ID.String())
+		require.NoError(t, err)
+		require.NotNil(t, history.OriginalDeadline)
+		assert.True(t, history.OriginalDeadline.Equal(deadline))
+		assert.True(t, history.Deadline.Equal(requested))
+		require.Len(t, history.Changes, 1)
+		assert.Equal(t, ext.ID.String(), history.Changes[0].ExtensionID)
+		require.Len(t, history.Extensions, 1)
+
+		_, err = svc.RejectDeadlineExtension(ext.ID.String(), "Поздно")
+		requireAppError(t, err, "CONFLICT", 409, "уже рассмотрен")
+	})
+
+	t.Run("rejection requires comment and notifies requester", func(t *testing.T) {
+		svc, store, assignment, ext := setup(t, uuid.Nil)
+		substituteID := uuid.New()
+		ext.RequestedBy, ext.OnBehalfOfUserID = substituteID, &assignment.ExecutorID
+		store.extensions[ext.ID] = ext
+
+		_, err := svc.RejectDeadlineExtension(ext.ID.String(), " ")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "причину отказа")
+
+		res, err := svc.RejectDeadlineExtension(ext.ID.String(), "Срок установлен руководством")
+		require.NoError(t, err)
+		assert.Equal(t, models.DeadlineExtensionRejected, res.Status)
+		assert.True(t, store.items[assignment.ID].Deadline.Equal(deadline))
+		assert.Contains(t, assignmentJournalRequest(t, store.effects).Details, "Срок установлен руководством")
+		assert.Equal(t, []uuid.UUID{assignment.ExecutorID, substituteID}, userEventRecipients(t, store.effects, models.UserEventExtensionRejected))
+	})
+
+	t.Run("executor cannot decide own request", func(t *testing.T) {
+		svc, repo, _, auth, _ := setupAssignmentService(t, "clerk")
+		currentID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		assignment := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: currentID, Status: "in_progress", Deadline: &deadline}
+		store := newDeadlineAssignmentStore(newSubtaskAssignmentStore(repo, assignment))
+		ext := models.AssignmentDeadlineExtension{ID: uuid.New(), AssignmentID: assignment.ID, RequestedBy: currentID, RequestedDeadline: requested, Status: models.DeadlineExtensionPending}
+		store.extensions[ext.ID] = ext
+		svc.repo = store
+
+		_, err = svc.ApproveDeadlineExtension(ext.ID.String(), "")
+		requireAppError(t, err, "FORBIDDEN", 403, "собственный запрос")
+	})
+
+	t.Run("executor without assign permission cannot decide", func(t *testing.T) {
+		svc, repo, _, _, _ := setupAssignmentService(t, "executor")
+		assignment := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: uuid.New(), Status: "in_progress", Deadline: &deadline}
+		store := newDeadlineAssignmentStore(newSubtaskAssignmentStore(repo, assignment))
+		ext := models.AssignmentDeadlineExtension{ID: uuid.New(), AssignmentID: assignment.ID, RequestedBy: assignment.ExecutorID, RequestedDeadline: requested, Status: models.DeadlineExtensionPending}
+		store.extensions[ext.ID] = ext
+		svc.repo = store
+
+		_, err := svc.ApproveDeadlineExtension(ext.ID.String(), "")
+		require.ErrorIs(t, err, models.ErrForbidden)
+	})
+}
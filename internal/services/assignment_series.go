@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// assignmentSeriesGeneratorInterval — период создания поручений по сериям.
+const assignmentSeriesGeneratorInterval = time.Hour
+
+// assignmentSeriesStore хранит серии повторяющихся поручений и создает их
+// поручения атомарно со сдвигом последнего срока серии.
+type assignmentSeriesStore interface {
+	CreateSeriesWithOutbox(series models.AssignmentSeries, effects []models.OutboxEvent) (*models.AssignmentSeries, error)
+	UpdateSeriesStatusWithOutbox(id uuid.UUID, from []string, status string, effects []models.OutboxEvent) (*models.AssignmentSeries, error)
+	CreateSeriesOccurrenceWithOutbox(seriesID uuid.UUID, previous *time.Time, assignmentID uuid.UUID, deadline time.Time, effects []models.OutboxEvent) (*models.Assignment, error)
+	SkipSeriesDeadlineWithOutbox(seriesID uuid.UUID, previous *time.Time, deadline time.Time, effects []models.OutboxEvent) error
+	GetSeries(id uuid.UUID) (*models.AssignmentSeries, error)
+	GetSeriesByDocument(documentID uuid.UUID) ([]models.AssignmentSeries, error)
+	GetActiveSeries() ([]models.AssignmentSeries, error)
+}
+
+func (s *AssignmentService) seriesStore() (assignmentSeriesStore, error) {
+	repo, ok := s.repo.(assignmentSeriesStore)
+	if !ok {
+		return nil, fmt.Errorf("assignment store must support assignment series")
+	}
+	return repo, nil
+}
+
+// SetWorkingCalendar подключает производственный календарь для расчета сроков
+// серий. Без календаря нерабочими считаются только выходные.
+func (s *AssignmentService) SetWorkingCalendar(calendar *WorkingCalendarService) {
+	s.calendar = calendar
+}
+
+// seriesCalendar загружает календарь на период, в который попадают прошедшие
+// сроки серий и сроки, поручения на которые создаются заранее.
+func (s *AssignmentService) seriesCalendar(today time.Time) (*models.WorkingCalendar, error) {
+	return s.calendar.Calendar(today.AddDate(-1, 0, 0), today.AddDate(0, 0, models.MaxAssignmentSeriesLeadDays+62))
+}
+
+// CreateSeries создает серию повторяющихся поручений по документу. Поручения
+// серии создает генератор за LeadDays дней до каждого срока.
+func (s *AssignmentService) CreateSeries(req models.CreateAssignmentSeriesRequest) (*dto.AssignmentSeries, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.createSeries(ctx, req)
+}
+
+func (s *AssignmentService) createSeries(ctx context.Context, req models.CreateAssignmentSeriesRequest) (*dto.AssignmentSeries, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	docUUID, err := uuid.Parse(req.DocumentID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+	}
+	if err := s.access.RequireDocumentAction(ctx, docUUID, "assign"); err != nil {
+		return nil, err
+	}
+	if _, err := s.access.RequireExists(docUUID); err != nil {
+		return nil, err
+	}
+	execUUID, err := uuid.Parse(req.ExecutorID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID исполнителя", err)
+	}
+	coExecutorIDs := make([]string, 0, len(req.CoExecutorIDs))
+	for _, id := range req.CoExecutorIDs {
+		coExecUUID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный ID соисполнителя", err)
+		}
+		coExecutorIDs = append(coExecutorIDs, coExecUUID.String())
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, models.NewBadRequest("укажите содержание поручения")
+	}
+
+	schedule := req.Schedule
+	if schedule.Kind == models.AssignmentScheduleWorkingDay && schedule.MonthInterval == 0 {
+		schedule.MonthInterval = 1
+	}
+	if schedule.Kind == models.AssignmentScheduleCron {
+		schedule.Cron = strings.Join(strings.Fields(schedule.Cron), " ")
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, models.NewBadRequest(err.Error())
+	}
+	if req.LeadDays < 0 || req.LeadDays > models.MaxAssignmentSeriesLeadDays {
+		return nil, models.NewBadRequest(fmt.Sprintf("поручение создается не ранее чем за %d дней до срока", models.MaxAssignmentSeriesLeadDays))
+	}
+
+	today := reminderDate(s.now())
+	startsOn := today
+	if req.StartsOn != "" {
+		startsOn, err = time.Parse("2006-01-02", req.StartsOn)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный формат даты начала серии", err)
+		}
+	}
+	var endsOn *time.Time
+	if req.EndsOn != "" {
+		t, err := time.Parse("2006-01-02", req.EndsOn)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный формат даты окончания серии", err)
+		}
+		if t.Before(startsOn) {
+			return nil, models.NewBadRequest("дата окончания серии раньше даты начала")
+		}
+		endsOn = &t
+	}
+
+	series := models.AssignmentSeries{ID: uuid.New(), DocumentID: docUUID, ExecutorID: execUUID, CoExecutorIDs: coExecutorIDs, Content: content, Schedule: schedule, LeadDays: req.LeadDays, StartsOn: startsOn, EndsOn: endsOn, CreatedBy: principal.UserID}
+	calendar, err := s.seriesCalendar(today)
+	if err != nil {
+		return nil, err
+	}
+	first, ok := series.NextDeadline(calendar)
+	if !ok {
+		return nil, models.NewBadRequest("по расписанию нет ни одного срока до даты окончания серии")
+	}
+	repo, err := s.seriesStore()
+	if err != nil {
+		return nil, err
+	}
+
+	journalRequest := models.CreateJournalEntryRequest{DocumentID: docUUID, UserID: principal.UserID, Action: "ASSIGNMENT_SERIES_CREATE", Details: fmt.Sprintf("Создана серия поручений: %s, первый срок %s", schedule.Label(), first.Format("02.01.2006"))}
+	journal, err := NewJournalOutboxEvent(assignmentSeriesOutboxKey(series.ID, "created", ""), journalRequest)
+	if err != nil {
+		return nil, err
+	}
+	res, err := repo.CreateSeriesWithOutbox(series, []models.OutboxEvent{journal})
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapAssignmentSeries(res, seriesNextDeadline(res, calendar)), nil
+}
+
+// GetDocumentSeries возвращает серии поручений документа.
+func (s *AssignmentService) GetDocumentSeries(documentID string) ([]dto.AssignmentSeries, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	docUUID, err := uuid.Parse(documentID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+	}
+	if err := s.access.RequireDocumentAction(ctx, docUUID, "assign"); err != nil {
+		return nil, err
+	}
+	repo, err := s.seriesStore()
+	if err != nil {
+		return nil, err
+	}
+	items, err := repo.GetSeriesByDocument(docUUID)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := s.seriesCalendar(reminderDate(s.now()))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]dto.AssignmentSeries, len(items))
+	for i := range items {
+		res[i] = *dto.MapAssignmentSeries(&items[i], seriesNextDeadline(&items[i], calendar))
+	}
+	return res, nil
+}
+
+// PauseSeries приостанавливает создание поручений серии. Сроки, прошедшие за
+// время паузы, после возобновления пропускаются с записью в журнале.
+func (s *AssignmentService) PauseSeries(id string) (*dto.AssignmentSeries, error) {
+	return s.changeSeriesStatus(id, []string{models.AssignmentSeriesActive}, models.AssignmentSeriesPaused, "ASSIGNMENT_SERIES_PAUSE", "Серия поручений приостановлена")
+}
+
+// ResumeSeries возобновляет приостановленную серию.
+func (s *AssignmentService) ResumeSeries(id string) (*dto.AssignmentSeries, error) {
+	return s.changeSeriesStatus(id, []string{models.AssignmentSeriesPaused}, models.AssignmentSeriesActive, "ASSIGNMENT_SERIES_RESUME", "Серия поручений возобновлена")
+}
+
+// StopSeries окончательно останавливает серию. Уже созданные поручения серии
+// остаются в работе.
+func (s *AssignmentService) StopSeries(id string) (*dto.AssignmentSeries, error) {
+	return s.changeSeriesStatus(id, []string{models.AssignmentSeriesActive, models.AssignmentSeriesPaused}, models.AssignmentSeriesStopped, "ASSIGNMENT_SERIES_STOP", "Серия поручений остановлена")
+}
+
+func (s *AssignmentService) changeSeriesStatus(id string, from []string, status, action, details string) (*dto.AssignmentSeries, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seriesID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID серии поручений", err)
+	}
+	repo, err := s.seriesStore()
+	if err != nil {
+		return nil, err
+	}
+	series, err := repo.GetSeries(seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.RequireDocumentAction(ctx, series.DocumentID, "assign"); err != nil {
+		return nil, err
+	}
+
+	journalRequest := models.CreateJournalEntryRequest{DocumentID: series.DocumentID, UserID: principal.UserID, Action: action, Details: fmt.Sprintf("%s: %s", details, series.Schedule.Label())}
+	journal, err := NewJournalOutboxEvent(assignmentSeriesOutboxKey(series.ID, status, series.UpdatedAt.UTC().Format(time.RFC3339Nano)), journalRequest)
+	if err != nil {
+		return nil, err
+	}
+	res, err := repo.UpdateSeriesStatusWithOutbox(series.ID, from, status, []models.OutboxEvent{journal})
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := s.seriesCalendar(reminderDate(s.now()))
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapAssignmentSeries(res, seriesNextDeadline(res, calendar)), nil
+}
+
+// RunSeriesGenerator периодически создает поручения активных серий. Метод
+// блокируется до отмены ctx.
+func (s *AssignmentService) RunSeriesGenerator(ctx context.Context) {
+	ticker := time.NewTicker(assignmentSeriesGeneratorInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.generateSeriesAssignments(); err != nil && ctx.Err() == nil {
+			slog.Warn("assignment series generation failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// generateSeriesAssignments создает поручения, срок создания которых наступил,
+// и возвращает их количество. Следующий срок каждый раз рассчитывается от
+// последнего по текущему календарю, поэтому изменения календаря учитываются
+// для еще не созданных поручений. Ошибка одной серии не останавливает
+// остальные: ошибки серий собираются и возвращаются вместе.
+func (s *AssignmentService) generateSeriesAssignments() (int, error) {
+	repo, err := s.seriesStore()
+	if err != nil {
+		return 0, err
+	}
+	items, err := repo.GetActiveSeries()
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+	today := reminderDate(s.now())
+	calendar, err := s.seriesCalendar(today)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	var errs []error
+	for i := range items {
+		n, err := s.generateSeries(repo, &items[i], today, calendar)
+		created += n
+		if err != nil {
+			// Серию приостановили или ее обработал параллельный проход.
+			if appErr, ok := models.AsAppError(err); ok && appErr.Code == 409 {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("assignment series %s: %w", items[i].ID, err))
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+func (s *AssignmentService) generateSeries(repo assignmentSeriesStore, series *models.AssignmentSeries, today time.Time, calendar *models.WorkingCalendar) (int, error) {
+	created := 0
+	for {
+		deadline, ok := series.NextDeadline(calendar)
+		if !ok {
+			journalRequest := models.CreateJournalEntryRequest{DocumentID: series.DocumentID, UserID: series.CreatedBy, Action: "ASSIGNMENT_SERIES_FINISH", Details: fmt.Sprintf("Серия поручений завершена по дате окончания: %s", series.Schedule.Label())}
+			journal, err := NewJournalOutboxEvent(assignmentSeriesOutboxKey(series.ID, models.AssignmentSeriesFinished, ""), journalRequest)
+			if err != nil {
+				return created, err
+			}
+			_, err = repo.UpdateSeriesStatusWithOutbox(series.ID, []string{models.AssignmentSeriesActive}, models.AssignmentSeriesFinished, []models.OutboxEvent{journal})
+			return created, err
+		}
+		if series.CreateOn(deadline).After(today) {
+			return created, nil
+		}
+		// Прошедший срок пропускается, только если пришелся на паузу серии;
+		// иначе (приложение не работало) поручение создается просроченным.
+		if deadline.Before(today) && series.PausedAt(deadline) {
+			journalRequest := models.CreateJournalEntryRequest{DocumentID: series.DocumentID, UserID: series.CreatedBy, Action: "ASSIGNMENT_SERIES_SKIP", Details: fmt.Sprintf("Поручение серии со сроком %s не создано: срок пришелся на паузу серии", deadline.Format("02.01.2006"))}
+			journal, err := NewJournalOutboxEvent(assignmentSeriesOutboxKey(series.ID, "skipped", deadline.Format("2006-01-02")), journalRequest)
+			if err != nil {
+				return created, err
+			}
+			if err := repo.SkipSeriesDeadlineWithOutbox(series.ID, series.LastDeadline, deadline, []models.OutboxEvent{journal}); err != nil {
+				return created, err
+			}
+		} else {
+			assignmentID := uuid.New()
+			effects, err := seriesOccurrenceEffects(series, assignmentID, deadline)
+			if err != nil {
+				return created, err
+			}
+			if _, err := repo.CreateSeriesOccurrenceWithOutbox(series.ID, series.LastDeadline, assignmentID, deadline, effects); err != nil {
+				return created, err
+			}
+			created++
+		}
+		series.LastDeadline = &deadline
+	}
+}
+
+// seriesOccurrenceEffects строит запись журнала и уведомления исполнителям о
+// поручении серии. Автором поручения считается автор серии.
+func seriesOccurrenceEffects(series *models.AssignmentSeries, assignmentID uuid.UUID, deadline time.Time) ([]models.OutboxEvent, error) {
+	journalRequest := models.CreateJournalEntryRequest{DocumentID: series.DocumentID, UserID: series.CreatedBy, Action: "ASSIGNMENT_CREATE", Details: fmt.Sprintf("Создано поручение серии (%s) со сроком %s", series.Schedule.Label(), deadline.Format("02.01.2006"))}
+	journal, err := NewJournalOutboxEvent(assignmentOutboxKey(assignmentID, "created", "", nil, "journal"), journalRequest)
+	if err != nil {
+		return nil, err
+	}
+	effects := []models.OutboxEvent{journal}
+	assignment := &models.Assignment{ID: assignmentID, ExecutorID: series.ExecutorID, CoExecutorIDs: series.CoExecutorIDs}
+	for _, recipientID := range assignmentExecutorRecipientIDs(assignment) {
+		request := models.CreateUserEventRequest{RecipientUserID: recipientID, ActorUserID: &series.CreatedBy, DocumentID: series.DocumentID, DocumentKind: series.DocumentKind, DocumentNumber: series.DocumentNumber, EntityType: models.UserEventEntityAssignment, EntityID: assignmentID, EventType: models.UserEventAssignmentCreated, Title: "Новое поручение", Message: fmt.Sprintf("Вам назначено поручение по документу %s со сроком %s", documentNumberLabel(series.DocumentNumber), deadline.Format("02.01.2006")), Metadata: userEventMetadata(map[string]string{"status": "new", "seriesId": series.ID.String()})}
+		event, err := NewUserEventOutboxEvent(assignmentOutboxKey(assignmentID, "created", "", &recipientID, "user_event"), request)
+		if err != nil {
+			return nil, err
+		}
+		effects = append(effects, event)
+	}
+	return effects, nil
+}
+
+func seriesNextDeadline(series *models.AssignmentSeries, calendar *models.WorkingCalendar) *time.Time {
+	if series.Status != models.AssignmentSeriesActive && series.Status != models.AssignmentSeriesPaused {
+		return nil
+	}
+	next, ok := series.NextDeadline(calendar)
+	if !ok {
+		return nil
+	}
+	return &next
+}
+
+func assignmentSeriesOutboxKey(id uuid.UUID, transition, revision string) string {
+	parts := []string{"assignment-series", id.String(), transition}
+	if revision != "" {
+		parts = append(parts, revision)
+	}
+	return strings.Join(append(parts, "journal"), ":")
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// seriesAssignmentStore дополняет хранилище подпоручений сериями поручений и
+// проверяет последний срок серии так же, как репозиторий.
+type seriesAssignmentStore struct {
+	*subtaskAssignmentStore
+	series  map[uuid.UUID]models.AssignmentSeries
+	skipped []time.Time
+	// failing — серии, поручения которых не удается создать.
+	failing map[uuid.UUID]error
+}
+
+func newSeriesAssignmentStore(store *subtaskAssignmentStore, items ...models.AssignmentSeries) *seriesAssignmentStore {
+	res := &seriesAssignmentStore{subtaskAssignmentStore: store, series: map[uuid.UUID]models.AssignmentSeries{}}
+	for _, item := range items {
+		res.series[item.ID] = item
+	}
+	return res
+}
+
+func (s *seriesAssignmentStore) CreateSeriesWithOutbox(series models.AssignmentSeries, effects []models.OutboxEvent) (*models.AssignmentSeries, error) {
+	series.Status = models.AssignmentSeriesActive
+	s.series[series.ID] = series
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	return &series, nil
+}
+
+func (s *seriesAssignmentStore) UpdateSeriesStatusWithOutbox(id uuid.UUID, from []string, status string, effects []models.OutboxEvent) (*models.AssignmentSeries, error) {
+	series := s.series[id]
+	for _, allowed := range from {
+		if series.Status == allowed {
+			series.Status = status
+			s.series[id] = series
+			s.effects = append([]models.OutboxEvent(nil), effects...)
+			return &series, nil
+		}
+	}
+	return nil, models.NewConflict("статус серии поручений изменен, обновите карточку")
+}
+
+func (s *seriesAssignmentStore) advance(seriesID uuid.UUID, previous *time.Time, deadline time.Time) error {
+	series := s.series[seriesID]
+	if series.Status != models.AssignmentSeriesActive {
+		return models.NewConflict("серия поручений не активна")
+	}
+	if (series.LastDeadline == nil) != (previous == nil) || (previous != nil && !series.LastDeadline.Equal(*previous)) {
+		return models.NewConflict("поручение серии уже создано")
+	}
+	series.LastDeadline = &deadline
+	s.series[seriesID] = series
+	return nil
+}
+
+func (s *seriesAssignmentStore) CreateSeriesOccurrenceWithOutbox(seriesID uuid.UUID, previous *time.Time, assignmentID uuid.UUID, deadline time.Time, effects []models.OutboxEvent) (*models.Assignment, error) {
+	if err := s.failing[seriesID]; err != nil {
+		return nil, err
+	}
+	if err := s.advance(seriesID, previous, deadline); err != nil {
+		return nil, err
+	}
+	series := s.series[seriesID]
+	item := models.Assignment{ID: assignmentID, DocumentID: series.DocumentID, SeriesID: &seriesID, ExecutorID: series.ExecutorID, CoExecutorIDs: series.CoExecutorIDs, Content: series.Content, Deadline: &deadline, Status: "new"}
+	s.items[assignmentID] = item
+	s.effects = append(s.effects, effects...)
+	return &item, nil
+}
+
+func (s *seriesAssignmentStore) SkipSeriesDeadlineWithOutbox(seriesID uuid.UUID, previous *time.Time, deadline time.Time, effects []models.OutboxEvent) error {
+	if err := s.advance(seriesID, previous, deadline); err != nil {
+		return err
+	}
+	s.skipped = append(s.skipped, deadline)
+	s.effects = append(s.effects, effects...)
+	return nil
+}
+
+func (s *seriesAssignmentStore) GetSeries(id uuid.UUID) (*models.AssignmentSeries, error) {
+	series, ok := s.series[id]
+	if !ok {
+		return nil, models.NewNotFound("серия поручений не найдена")
+	}
+	return &series, nil
+}
+
+func (s *seriesAssignmentStore) GetSeriesByDocument(documentID uuid.UUID) ([]models.AssignmentSeries, error) {
+	var items []models.AssignmentSeries
+	for _, series := range s.series {
+		if series.DocumentID == documentID {
+			items = append(items, series)
+		}
+	}
+	return items, nil
+}
+
+func (s *seriesAssignmentStore) GetActiveSeries() ([]models.AssignmentSeries, error) {
+	var items []models.AssignmentSeries
+	for _, series := range s.series {
+		if series.Status == models.AssignmentSeriesActive {
+			items = append(items, series)
+		}
+	}
+	return items, nil
+}
+
+func TestAssignmentService_CreateSeries(t *testing.T) {
+	docID, execID := uuid.New(), uuid.New()
+	setup := func(t *testing.T) (*AssignmentService, *seriesAssignmentStore) {
+		svc, repo, _, _, incomingRepo := setupAssignmentService(t, "clerk")
+		incomingRepo.On("GetByID", docID).Return(&models.IncomingDocument{ID: docID, NomenclatureID: uuid.New()}, nil).Maybe()
+		store := newSeriesAssignmentStore(newSubtaskAssignmentStore(repo))
+		svc.repo = store
+		svc.now = func() time.Time { return time.Date(2026, 8, 3, 10, 0, 0, 0, time.UTC) }
+		return svc, store
+	}
+	request := func() models.CreateAssignmentSeriesRequest {
+		return models.CreateAssignmentSeriesRequest{
+			DocumentID: docID.String(), ExecutorID: execID.String(), Content: " Отчет о выполнении ",
+			Schedule: models.AssignmentSchedule{Kind: models.AssignmentScheduleWorkingDay, WorkingDay: 5},
+			LeadDays: 7,
+		}
+	}
+
+	t.Run("creates active series from today", func(t *testing.T) {
+		svc, store := setup(t)
+
+		res, err := svc.CreateSeries(request())
+		require.NoError(t, err)
+		assert.Equal(t, models.AssignmentSeriesActive, res.Status)
+		assert.Equal(t, "Отчет о выполнении", res.Content)
+		assert.Equal(t, 1, res.Schedule.MonthInterval)
+		assert.Equal(t, "5-й рабочий день месяца", res.ScheduleLabel)
+		assert.True(t, res.StartsOn.Equal(time.Date(2026, 8, 3, 0, 0, 0, 0, time.UTC)))
+		require.NotNil(t, res.NextDeadline)
+		assert.True(t, res.NextDeadline.Equal(time.Date(2026, 8, 7, 0, 0, 0, 0, time.UTC)))
+		journal := assignmentJournalRequest(t, store.effects)
+		assert.Equal(t, "ASSIGNMENT_SERIES_CREATE", journal.Action)
+		assert.Contains(t, journal.Details, "первый срок 07.08.2026")
+	})
+
+	t.Run("validates schedule, lead days and dates", func(t *testing.T) {
+		svc, store := setup(t)
+
+		req := request()
+		req.Schedule = models.AssignmentSchedule{Kind: models.AssignmentScheduleCron, Cron: "0 9 1 * *"}
+		_, err := svc.CreateSeries(req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "")
+
+		req = request()
+		req.LeadDays = models.MaxAssignmentSeriesLeadDays + 1
+		_, err = svc.CreateSeries(req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "не ранее чем за 90 дней")
+
+		req = request()
+		req.StartsOn, req.EndsOn = "2026-09-01", "2026-08-31"
+		_, err = svc.CreateSeries(req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "раньше даты начала")
+
+		req = request()
+		req.StartsOn, req.EndsOn = "2026-09-08", "2026-09-30"
+		_, err = svc.CreateSeries(req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "нет ни одного срока")
+
+		req = request()
+		req.Content = " "
+		_, err = svc.CreateSeries(req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "содержание")
+		assert.Empty(t, store.series)
+	})
+}
+
+func TestAssignmentService_GenerateSeriesAssignments(t *testing.T) {
+	today := time.Date(2026, 8, 3, 0, 0, 0, 0, time.UTC)
+	setup := func(t *testing.T, series ...models.AssignmentSeries) (*AssignmentService, *seriesAssignmentStore) {
+		svc, repo, _, _, _ := setupAssignmentService(t, "clerk")
+		store := newSeriesAssignmentStore(newSubtaskAssignmentStore(repo), series...)
+		svc.repo = store
+		svc.now = func() time.Time { return today.Add(9 * time.Hour) }
+		return svc, store
+	}
+	monthly := func() models.AssignmentSeries {
+		return models.AssignmentSeries{
+			ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", DocumentNumber: "ВХ-7",
+			ExecutorID: uuid.New(), CoExecutorIDs: []string{uuid.New().String()}, Content: "Отчет",
+			Schedule: models.AssignmentSchedule{Kind: models.AssignmentScheduleWorkingDay, WorkingDay: 5, MonthInterval: 1},
+			LeadDays: 7, StartsOn: time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), Status: models.AssignmentSeriesActive, CreatedBy: uuid.New(),
+		}
+	}
+
+	t.Run("skips deadlines inside the pause and creates due assignment once", func(t *testing.T) {
+		series := monthly()
+		series.StartsOn = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+		pausedOn, resumedOn := time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+		series.PausedOn, series.ResumedOn = &pausedOn, &resumedOn
+		svc, store := setup(t, series)
+
+		created, err := svc.generateSeriesAssignments()
+		require.NoError(t, err)
+		assert.Equal(t, 1, created)
+		assert.Equal(t, []time.Time{time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 7, 0, 0, 0, 0, time.UTC)}, store.skipped)
+		skip := assignmentJournalRequest(t, store.effects)
+		assert.Equal(t, "ASSIGNMENT_SERIES_SKIP", skip.Action)
+		assert.Contains(t, skip.Details, "05.06.2026")
+		require.Len(t, store.items, 1)
+		for _, item := range store.items {
+			assert.True(t, item.Deadline.Equal(time.Date(2026, 8, 7, 0, 0, 0, 0, time.UTC)))
+			assert.Equal(t, series.ID, *item.SeriesID)
+		}
+		journal := assignmentJournalRequest(t, store.effects[2:])
+		assert.Equal(t, "ASSIGNMENT_CREATE", journal.Action)
+		assert.Equal(t, series.CreatedBy, journal.UserID)
+		recipients := userEventRecipients(t, store.effects, models.UserEventAssignmentCreated)
+		assert.ElementsMatch(t, []uuid.UUID{series.ExecutorID, uuid.MustParse(series.CoExecutorIDs[0])}, recipients)
+
+		created, err = svc.generateSeriesAssignments()
+		require.NoError(t, err)
+		assert.Zero(t, created)
+		assert.Len(t, store.items, 1)
+	})
+
+	t.Run("deadlines missed outside a pause are created overdue", func(t *testing.T) {
+		series := monthly()
+		series.StartsOn = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+		pausedOn, resumedOn := time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+		series.PausedOn, series.ResumedOn = &pausedOn, &resumedOn
+		svc, store := setup(t, series)
+
+		created, err := svc.generateSeriesAssignments()
+		require.NoError(t, err)
+		assert.Equal(t, 3, created)
+		assert.Empty(t, store.skipped)
+		var deadlines []time.Time
+		for _, item := range store.items {
+			deadlines = append(deadlines, *item.Deadline)
+		}
+		assert.ElementsMatch(t, []time.Time{
+			time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 7, 0, 0, 0, 0, time.UTC), time.Date(2026, 8, 7, 0, 0, 0, 0, time.UTC),
+		}, deadlines)
+	})
+
+	t.Run("failed series does not stop the others", func(t *testing.T) {
+		broken, healthy := monthly(), monthly()
+		svc, store := setup(t, broken, healthy)
+		store.failing = map[uuid.UUID]error{broken.ID: assert.AnError}
+
+		created, err := svc.generateSeriesAssignments()
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, err.Error(), broken.ID.String())
+		assert.Equal(t, 1, created)
+		require.Len(t, store.items, 1)
+		for _, item := range store.items {
+			assert.Equal(t, healthy.ID, *item.SeriesID)
+		}
+	})
+
+	t.Run("finishes exhausted series", func(t *testing.T) {
+		series := monthly()
+		endsOn, last := time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC), time.Date(2026, 8, 7, 0, 0, 0, 0, time.UTC)
+		series.EndsOn, series.LastDeadline = &endsOn, &last
+		svc, store := setup(t, series)
+
+		created, err := svc.generateSeriesAssignments()
+		require.NoError(t, err)
+		assert.Zero(t, created)
+		assert.Equal(t, models.AssignmentSeriesFinished, store.series[series.ID].Status)
+		assert.Equal(t, "ASSIGNMENT_SERIES_FINISH", assignmentJournalRequest(t, store.effects).Action)
+	})
+
+	t.Run("paused series is not generated", func(t *testing.T) {
+		series := monthly()
+		svc, store := setup(t, series)
+
+		paused, err := svc.PauseSeries(series.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, models.AssignmentSeriesPaused, paused.Status)
+		assert.Equal(t, "ASSIGNMENT_SERIES_PAUSE", assignmentJournalRequest(t, store.effects).Action)
+		_, err = svc.PauseSeries(series.ID.String())
+		requireAppError(t, err, "CONFLICT", 409, "статус серии")
+
+		created, err := svc.generateSeriesAssignments()
+		require.NoError(t, err)
+		assert.Zero(t, created)
+		assert.Empty(t, store.items)
+
+		_, err = svc.ResumeSeries(series.ID.String())
+		require.NoError(t, err)
+		created, err = svc.generateSeriesAssignments()
+		require.NoError(t, err)
+		assert.Equal(t, 1, created)
+
+		stopped, err := svc.StopSeries(series.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, models.AssignmentSeriesStopped, stopped.Status)
+		assert.Nil(t, stopped.NextDeadline)
+		_, err = svc.ResumeSeries(series.ID.String())
+		requireAppError(t, err, "CONFLICT", 409, "")
+	})
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,20 +15,32 @@ import (
 
 // AssignmentService предоставляет бизнес-логику для работы с поручениями.
 type AssignmentService struct {
-	repo          AssignmentStore
-	userRepo      UserStore
-	auth          *AuthService
-	access        *DocumentAccessService
-	events        *UserEventService
-	substitutions UserSubstitutionStore
+	repo     AssignmentStore
+	userRepo UserStore
+	auth     *AuthService
+	access   *DocumentAccessService
+	events   *UserEventService
+	calendar *WorkingCalendarService
+	now      func() time.Time
 }
 
 type assignmentOutboxStore interface {
-	CreateWithOutbox(id, documentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, effects []models.OutboxEvent) (*models.Assignment, error)
-	UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, effects []models.OutboxEvent) (*models.Assignment, error)
+	CreateWithOutbox(id, documentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, createdBy uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error)
+	UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, actorID uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error)
 	DeleteWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error
 }
 
+// assignmentHierarchyStore поддерживает подпоручения: создание под блокировкой
+// родителя и загрузку поддерева для карточки.
+type assignmentHierarchyStore interface {
+	CreateSubtaskWithOutbox(id, parentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, createdBy uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error)
+	GetSubtree(rootID uuid.UUID) ([]models.Assignment, error)
+}
+
+// maxAssignmentDepth ограничивает вложенность: поручение по документу и
+// до четырех уровней подпоручений.
+const maxAssignmentDepth = 5
+
 // NewAssignmentService создает новый экземпляр AssignmentService.
 func NewAssignmentService(
 	repo AssignmentStore,
@@ -40,6 +54,7 @@ func NewAssignmentService(
 		userRepo: userRepo,
 		auth:     auth,
 		access:   access,
+		now:      time.Now,
 	}
 	if len(events) > 0 {
 		s.events = events[0]
@@ -47,49 +62,78 @@ func NewAssignmentService(
 	return s
 }
 
-// SetSubstitutionStore подключает источник активных замещений.
-func (s *AssignmentService) SetSubstitutionStore(store UserSubstitutionStore) {
-	s.substitutions = store
+// assignmentActorAccess определяет роли пользователя в поручении. Подпоручением
+// кроме распорядителей документа управляет исполнитель родительского поручения:
+// он выдал его, принимает и возвращает на доработку.
+func (s *AssignmentService) assignmentActorAccess(ctx context.Context, principal *models.Principal, existing, parent *models.Assignment) (canActAsExecutor, canManageAssignment bool) {
+	canActAsExecutor = actsForAssignmentExecutor(principal, existing)
+	canManageAssignment = s.access.RequireDocumentAction(ctx, existing.DocumentID, "assign") == nil ||
+		actsForAssignmentExecutor(principal, parent)
+	return canActAsExecutor, canManageAssignment
+}
+
+func actsForAssignmentExecutor(principal *models.Principal, assignment *models.Assignment) bool {
+	if assignment == nil {
+		return false
+	}
+	return principal.ActsForIn(assignment.ExecutorID, models.SubstitutionScopeAssignments, models.NormalizeDocumentKind(assignment.DocumentKind))
 }
 
-func (s *AssignmentService) currentUserAndSubstitutionSubjectIDs() ([]uuid.UUID, []string, error) {
-	currentUserID, err := s.auth.GetCurrentUserUUID()
+// parentAssignment загружает родительское поручение; для поручения верхнего
+// уровня возвращает nil.
+func (s *AssignmentService) parentAssignment(assignment *models.Assignment) (*models.Assignment, error) {
+	if assignment.ParentID == nil {
+		return nil, nil
+	}
+	parent, err := s.repo.GetByID(*assignment.ParentID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	ids := []uuid.UUID{currentUserID}
-	if s.substitutions != nil {
-		principalIDs, err := s.substitutions.GetActivePrincipalIDs(currentUserID)
-		if err != nil {
-			return nil, nil, err
-		}
-		seen := map[uuid.UUID]struct{}{currentUserID: {}}
-		for _, principalID := range principalIDs {
-			if principalID == uuid.Nil {
-				continue
-			}
-			if _, ok := seen[principalID]; ok {
-				continue
-			}
-			seen[principalID] = struct{}{}
-			ids = append(ids, principalID)
-		}
+	if parent == nil {
+		return nil, models.NewNotFound("родительское поручение не найдено")
 	}
-	return ids, uuidStrings(ids), nil
+	return parent, nil
 }
 
-func (s *AssignmentService) assignmentActorAccess(existing *models.Assignment, currentUserID uuid.UUID) (bool, bool, bool, error) {
-	isExecutor := existing.ExecutorID == currentUserID
-	isSubstituteExecutor := false
-	if !isExecutor && s.substitutions != nil {
-		ok, err := s.substitutions.IsActiveSubstitute(currentUserID, existing.ExecutorID)
+// assignmentAncestors возвращает цепочку родителей от ближайшего к корню.
+func (s *AssignmentService) assignmentAncestors(assignment *models.Assignment) ([]*models.Assignment, error) {
+	var ancestors []*models.Assignment
+	current := assignment
+	for current.ParentID != nil && len(ancestors) < maxAssignmentDepth {
+		parent, err := s.parentAssignment(current)
 		if err != nil {
-			return false, false, false, err
+			return nil, err
 		}
-		isSubstituteExecutor = ok
+		ancestors = append(ancestors, parent)
+		current = parent
 	}
-	canManageAssignment := s.access.RequireDocumentAction(existing.DocumentID, "assign") == nil
-	return isExecutor, isSubstituteExecutor, canManageAssignment, nil
+	return ancestors, nil
+}
+
+// checkOpenSubtasks не дает исполнить или принять поручение, пока открыты его
+// подпоручения. Завершение с открытыми подпоручениями требует явного
+// подтверждения и отмечается в журнале.
+func checkOpenSubtasks(existing *models.Assignment, status string, override bool) error {
+	if existing.OpenSubtaskCount == 0 || override {
+		return nil
+	}
+	switch status {
+	case "completed", "finished":
+		return models.NewConflict(fmt.Sprintf("не завершены подпоручения (%d): примите их или подтвердите завершение поручения", existing.OpenSubtaskCount))
+	}
+	return nil
+}
+
+// validateSubtaskDeadline проверяет, что срок подпоручения не позже срока
+// родительского поручения. Если у родителя есть срок, он обязателен.
+func validateSubtaskDeadline(parent *models.Assignment, deadline *time.Time) error {
+	if parent == nil || parent.Deadline == nil {
+		return nil
+	}
+	if deadline == nil || deadline.After(*parent.Deadline) {
+		return models.NewBadRequest(fmt.Sprintf("срок подпоручения не может быть позже срока поручения (%s)", parent.Deadline.Format("02.01.2006")))
+	}
+	return nil
 }
 
 type assignmentStatusUpdate struct {
@@ -177,11 +221,23 @@ func (s *AssignmentService) Create(
 	deadline string,
 	coExecutorIDs []string,
 ) (*dto.Assignment, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, documentID, executorID, content, deadline, coExecutorIDs)
+}
+
+func (s *AssignmentService) create(ctx context.Context, documentID, executorID, content, deadline string, coExecutorIDs []string) (*dto.Assignment, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	docUUID, err := uuid.Parse(documentID)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 	}
-	if err := s.access.RequireDocumentAction(docUUID, "assign"); err != nil {
+	if err := s.access.RequireDocumentAction(ctx, docUUID, "assign"); err != nil {
 		return nil, err
 	}
 	doc, err := s.access.RequireExists(docUUID)
@@ -210,8 +266,7 @@ func (s *AssignmentService) Create(
 	var res *models.Assignment
 	{
 		assignmentID := uuid.New()
-		currentUserID, _ := s.auth.GetCurrentUserUUID()
-		journalRequest := models.CreateJournalEntryRequest{DocumentID: docUUID, UserID: currentUserID, Action: "ASSIGNMENT_CREATE", Details: fmt.Sprintf("Создано поручение для %s", doc.Kind)}
+		journalRequest := models.CreateJournalEntryRequest{DocumentID: docUUID, UserID: principal.UserID, Action: "ASSIGNMENT_CREATE", Details: fmt.Sprintf("Создано поручение для %s", doc.Kind)}
 		effects := make([]models.OutboxEvent, 0, 1+len(coExecutorIDs))
 		journalEvent, buildErr := NewJournalOutboxEvent(assignmentOutboxKey(assignmentID, "created", "", nil, "journal"), journalRequest)
 		if buildErr != nil {
@@ -220,18 +275,115 @@ func (s *AssignmentService) Create(
 		effects = append(effects, journalEvent)
 		assignment := &models.Assignment{ID: assignmentID, DocumentID: docUUID, DocumentKind: string(doc.Kind), DocumentNumber: doc.RegistrationNumber, ExecutorID: execUUID, CoExecutorIDs: coExecutorIDs, Status: "new"}
 		for _, recipientID := range assignmentExecutorRecipientIDs(assignment) {
-			request := models.CreateUserEventRequest{RecipientUserID: recipientID, ActorUserID: eventActorID(s.auth), DocumentID: docUUID, DocumentKind: string(doc.Kind), DocumentNumber: doc.RegistrationNumber, EntityType: models.UserEventEntityAssignment, EntityID: assignmentID, EventType: models.UserEventAssignmentCreated, Title: "Новое поручение", Message: fmt.Sprintf("Вам назначено поручение по документу %s", documentNumberLabel(doc.RegistrationNumber)), Metadata: userEventMetadata(map[string]string{"status": "new"})}
+			request := models.CreateUserEventRequest{RecipientUserID: recipientID, ActorUserID: &principal.UserID, DocumentID: docUUID, DocumentKind: string(doc.Kind), DocumentNumber: doc.RegistrationNumber, EntityType: models.UserEventEntityAssignment, EntityID: assignmentID, EventType: models.UserEventAssignmentCreated, Title: "Новое поручение", Message: fmt.Sprintf("Вам назначено поручение по документу %s", documentNumberLabel(doc.RegistrationNumber)), Metadata: userEventMetadata(map[string]string{"status": "new"})}
 			event, buildErr := NewUserEventOutboxEvent(assignmentOutboxKey(assignmentID, "created", "", &recipientID, "user_event"), request)
 			if buildErr != nil {
 				return nil, buildErr
 			}
 			effects = append(effects, event)
 		}
-		res, err = repo.CreateWithOutbox(assignmentID, docUUID, execUUID, content, deadlineTime, coExecutorIDs, effects)
+		res, err = repo.CreateWithOutbox(assignmentID, docUUID, execUUID, content, deadlineTime, coExecutorIDs, principal.UserID, effects)
 	}
 	return dto.MapAssignment(res), err
 }
 
+// CreateSubtask создает подпоручение: исполнитель поручения передает его часть
+// другому сотруднику. Без срока подпоручение получает срок родителя.
+func (s *AssignmentService) CreateSubtask(parentID, executorID, content, deadline string, coExecutorIDs []string) (*dto.Assignment, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.createSubtask(ctx, parentID, executorID, content, deadline, coExecutorIDs)
+}
+
+func (s *AssignmentService) createSubtask(ctx context.Context, parentID, executorID, content, deadline string, coExecutorIDs []string) (*dto.Assignment, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parentUUID, err := uuid.Parse(parentID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID поручения", err)
+	}
+	parent, err := s.repo.GetByID(parentUUID)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, models.NewNotFound("поручение не найдено")
+	}
+	if !actsForAssignmentExecutor(principal, parent) {
+		return nil, models.NewForbidden("подпоручение может создать только исполнитель поручения")
+	}
+	if _, open := executorAssignmentTransitions[parent.Status]; !open {
+		return nil, models.NewConflict("подпоручение можно создать только по поручению в работе")
+	}
+	ancestors, err := s.assignmentAncestors(parent)
+	if err != nil {
+		return nil, err
+	}
+	if len(ancestors)+2 > maxAssignmentDepth {
+		return nil, models.NewBadRequest(fmt.Sprintf("допускается не более %d уровней подпоручений", maxAssignmentDepth-1))
+	}
+
+	execUUID, err := uuid.Parse(executorID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID исполнителя", err)
+	}
+	if execUUID == parent.ExecutorID {
+		return nil, models.NewBadRequest("исполнитель подпоручения должен отличаться от исполнителя поручения")
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, models.NewBadRequest("укажите содержание подпоручения")
+	}
+
+	deadlineTime := parent.Deadline
+	if deadline != "" {
+		t, err := time.Parse("2006-01-02", deadline)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный формат срока исполнения", err)
+		}
+		deadlineTime = &t
+	}
+	if err := validateSubtaskDeadline(parent, deadlineTime); err != nil {
+		return nil, err
+	}
+
+	repo, ok := s.repo.(assignmentHierarchyStore)
+	if !ok {
+		return nil, fmt.Errorf("assignment store must support sub-tasks")
+	}
+	subtaskID := uuid.New()
+	journalRequest := models.CreateJournalEntryRequest{DocumentID: parent.DocumentID, UserID: principal.UserID, Action: "ASSIGNMENT_SUBTASK_CREATE", Details: "Создано подпоручение"}
+	if parent.ExecutorID != principal.UserID {
+		journalRequest.OnBehalfOfUserID = &parent.ExecutorID
+	}
+	journalEvent, err := NewJournalOutboxEvent(assignmentOutboxKey(subtaskID, "created", "", nil, "journal"), journalRequest)
+	if err != nil {
+		return nil, err
+	}
+	effects := []models.OutboxEvent{journalEvent}
+	subtask := &models.Assignment{ID: subtaskID, DocumentID: parent.DocumentID, DocumentKind: parent.DocumentKind, DocumentNumber: parent.DocumentNumber, ExecutorID: execUUID, CoExecutorIDs: coExecutorIDs}
+	for _, recipientID := range assignmentExecutorRecipientIDs(subtask) {
+		request := models.CreateUserEventRequest{RecipientUserID: recipientID, ActorUserID: &principal.UserID, DocumentID: parent.DocumentID, DocumentKind: parent.DocumentKind, DocumentNumber: parent.DocumentNumber, EntityType: models.UserEventEntityAssignment, EntityID: subtaskID, EventType: models.UserEventAssignmentCreated, Title: "Новое подпоручение", Message: fmt.Sprintf("Вам передана часть поручения по документу %s", documentNumberLabel(parent.DocumentNumber)), Metadata: userEventMetadata(map[string]string{"status": "new", "parentId": parent.ID.String()})}
+		event, buildErr := NewUserEventOutboxEvent(assignmentOutboxKey(subtaskID, "created", "", &recipientID, "user_event"), request)
+		if buildErr != nil {
+			return nil, buildErr
+		}
+		effects = append(effects, event)
+	}
+	res, err := repo.CreateSubtaskWithOutbox(subtaskID, parent.ID, execUUID, content, deadlineTime, coExecutorIDs, principal.UserID, effects)
+	if err != nil {
+		return nil, err
+	}
+	mapped := dto.MapAssignment(res)
+	if mapped != nil {
+		mapped.CanAct = actsForAssignmentExecutor(principal, res)
+	}
+	return mapped, nil
+}
+
 // Update — редактирование (админ)
 func (s *AssignmentService) Update(
 	id string,
@@ -240,6 +392,14 @@ func (s *AssignmentService) Update(
 	deadline string,
 	coExecutorIDs []string,
 ) (*dto.Assignment, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID поручения", err)
@@ -253,12 +413,16 @@ func (s *AssignmentService) Update(
 	if existing == nil {
 		return nil, models.NewNotFound("поручение не найдено")
 	}
-	if err := s.access.RequireDocumentAction(existing.DocumentID, "assign"); err != nil {
+	parent, err := s.parentAssignment(existing)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.RequireDocumentAction(ctx, existing.DocumentID, "assign"); err != nil && !actsForAssignmentExecutor(principal, parent) {
 		return nil, err
 	}
 
 	// Проверка прав
-	// Редактировать могут админ и делопроизводитель
+	// Редактировать могут админ и делопроизводитель, подпоручение — также его автор
 	// Завершенные поручения редактировать нельзя
 	if existing.Status == "finished" {
 		return nil, models.NewConflict("нельзя редактировать завершённое поручение")
@@ -277,6 +441,12 @@ func (s *AssignmentService) Update(
 		}
 		deadlineTime = &t
 	}
+	if err := validateSubtaskDeadline(parent, deadlineTime); err != nil {
+		return nil, err
+	}
+	if err := s.validateDeadlineCoversSubtasks(existing, deadlineTime); err != nil {
+		return nil, err
+	}
 
 	repo, ok := s.repo.(assignmentOutboxStore)
 	if !ok {
@@ -285,29 +455,69 @@ func (s *AssignmentService) Update(
 	var res *models.Assignment
 	{
 		revision := time.Now().UTC().Format(time.RFC3339Nano)
-		currentUserID, _ := s.auth.GetCurrentUserUUID()
-		journal, buildErr := NewJournalOutboxEvent(assignmentOutboxKey(uid, "updated", revision, nil, "journal"), models.CreateJournalEntryRequest{DocumentID: existing.DocumentID, UserID: currentUserID, Action: "ASSIGNMENT_UPDATE", Details: "Поручение отредактировано"})
+		journal, buildErr := NewJournalOutboxEvent(assignmentOutboxKey(uid, "updated", revision, nil, "journal"), models.CreateJournalEntryRequest{DocumentID: existing.DocumentID, UserID: principal.UserID, Action: "ASSIGNMENT_UPDATE", Details: "Поручение отредактировано"})
 		if buildErr != nil {
 			return nil, buildErr
 		}
 		effects := []models.OutboxEvent{journal}
 		updated := &models.Assignment{ID: uid, DocumentID: existing.DocumentID, DocumentKind: existing.DocumentKind, DocumentNumber: existing.DocumentNumber, ExecutorID: execUUID, CoExecutorIDs: coExecutorIDs, Status: existing.Status, UpdatedAt: time.Now()}
 		for _, recipient := range assignmentExecutorRecipientIDs(updated) {
-			request := models.CreateUserEventRequest{RecipientUserID: recipient, ActorUserID: eventActorID(s.auth), DocumentID: updated.DocumentID, DocumentKind: updated.DocumentKind, DocumentNumber: updated.DocumentNumber, EntityType: models.UserEventEntityAssignment, EntityID: updated.ID, EventType: models.UserEventAssignmentUpdated, Title: "Поручение изменено", Message: fmt.Sprintf("Изменено поручение по документу %s", documentNumberLabel(updated.DocumentNumber)), Metadata: userEventMetadata(map[string]string{"status": updated.Status})}
+			request := models.CreateUserEventRequest{RecipientUserID: recipient, ActorUserID: &principal.UserID, DocumentID: updated.DocumentID, DocumentKind: updated.DocumentKind, DocumentNumber: updated.DocumentNumber, EntityType: models.UserEventEntityAssignment, EntityID: updated.ID, EventType: models.UserEventAssignmentUpdated, Title: "Поручение изменено", Message: fmt.Sprintf("Изменено поручение по документу %s", documentNumberLabel(updated.DocumentNumber)), Metadata: userEventMetadata(map[string]string{"status": updated.Status})}
 			event, buildErr := NewUserEventOutboxEvent(assignmentOutboxKey(uid, "updated", revision, &recipient, "user_event"), request)
 			if buildErr != nil {
 				return nil, buildErr
 			}
 			effects = append(effects, event)
 		}
-		res, err = repo.UpdateWithOutbox(uid, execUUID, content, deadlineTime, existing.Status, existing.Report, existing.CompletedAt, coExecutorIDs, effects)
+		res, err = repo.UpdateWithOutbox(uid, execUUID, content, deadlineTime, existing.Status, existing.Report, existing.CompletedAt, coExecutorIDs, principal.UserID, effects)
 	}
 	return dto.MapAssignment(res), err
 }
 
+// validateDeadlineCoversSubtasks не дает сократить срок поручения раньше
+// сроков уже выданных подпоручений.
+func (s *AssignmentService) validateDeadlineCoversSubtasks(existing *models.Assignment, deadline *time.Time) error {
+	if existing.SubtaskCount == 0 || deadline == nil {
+		return nil
+	}
+	repo, ok := s.repo.(assignmentHierarchyStore)
+	if !ok {
+		return fmt.Errorf("assignment store must support sub-tasks")
+	}
+	subtree, err := repo.GetSubtree(existing.ID)
+	if err != nil {
+		return err
+	}
+	for _, subtask := range subtree {
+		if subtask.Deadline != nil && subtask.Deadline.After(*deadline) {
+			return models.NewBadRequest(fmt.Sprintf("срок поручения не может быть раньше срока подпоручения (%s)", subtask.Deadline.Format("02.01.2006")))
+		}
+	}
+	return nil
+}
+
 // UpdateStatus — изменение статуса (исполнитель или админ)
 func (s *AssignmentService) UpdateStatus(id, status, report string) (*dto.Assignment, error) {
-	if err := s.auth.RequireAuthenticated(); err != nil {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.updateStatus(ctx, id, status, report, false)
+}
+
+// UpdateStatusOverridingSubtasks изменяет статус поручения, подтверждая
+// завершение при открытых подпоручениях.
+func (s *AssignmentService) UpdateStatusOverridingSubtasks(id, status, report string) (*dto.Assignment, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.updateStatus(ctx, id, status, report, true)
+}
+
+func (s *AssignmentService) updateStatus(ctx context.Context, id, status, report string, overrideOpenSubtasks bool) (*dto.Assignment, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
 		return nil, err
 	}
 
@@ -324,16 +534,16 @@ func (s *AssignmentService) UpdateStatus(id, status, report string) (*dto.Assign
 		return nil, models.NewNotFound("поручение не найдено")
 	}
 
-	currentUserUUID, err := s.auth.GetCurrentUserUUID()
+	parent, err := s.parentAssignment(existing)
 	if err != nil {
 		return nil, err
 	}
-	isExecutor, isSubstituteExecutor, canManageAssignment, err := s.assignmentActorAccess(existing, currentUserUUID)
+	canActAsExecutor, canManageAssignment := s.assignmentActorAccess(ctx, principal, existing, parent)
+	statusUpdate, err := resolveAssignmentStatusUpdate(existing, status, report, canManageAssignment, canActAsExecutor)
 	if err != nil {
 		return nil, err
 	}
-	statusUpdate, err := resolveAssignmentStatusUpdate(existing, status, report, canManageAssignment, isExecutor || isSubstituteExecutor)
-	if err != nil {
+	if err := checkOpenSubtasks(existing, status, overrideOpenSubtasks); err != nil {
 		return nil, err
 	}
 
@@ -344,8 +554,15 @@ func (s *AssignmentService) UpdateStatus(id, status, report string) (*dto.Assign
 	var res *models.Assignment
 	{
 		revision := time.Now().UTC().Format(time.RFC3339Nano)
-		currentUserID, _ := s.auth.GetCurrentUserUUID()
-		journal, buildErr := NewJournalOutboxEvent(assignmentOutboxKey(uid, "status:"+status, revision, nil, "journal"), models.CreateJournalEntryRequest{DocumentID: existing.DocumentID, UserID: currentUserID, Action: "ASSIGNMENT_STATUS", Details: fmt.Sprintf("Статус поручения изменен на %s", status)})
+		journalEntry := models.CreateJournalEntryRequest{DocumentID: existing.DocumentID, UserID: principal.UserID, Action: "ASSIGNMENT_STATUS", Details: fmt.Sprintf("Статус поручения изменен на %s", status)}
+		if _, executorTransition := executorAssignmentTransitions[existing.Status][status]; executorTransition && canActAsExecutor && existing.ExecutorID != principal.UserID {
+			// Исполнитель замещен: в журнале остается, за кого действовал пользователь.
+			journalEntry.OnBehalfOfUserID = &existing.ExecutorID
+		}
+		if (status == "completed" || status == "finished") && existing.OpenSubtaskCount > 0 {
+			journalEntry.Details += fmt.Sprintf("; не завершены подпоручения: %d", existing.OpenSubtaskCount)
+		}
+		journal, buildErr := NewJournalOutboxEvent(assignmentOutboxKey(uid, "status:"+status, revision, nil, "journal"), journalEntry)
 		if buildErr != nil {
 			return nil, buildErr
 		}
@@ -359,7 +576,10 @@ func (s *AssignmentService) UpdateStatus(id, status, report string) (*dto.Assign
 		var eventType, title, message string
 		switch status {
 		case "completed":
-			if s.events != nil {
+			// Подпоручение принимает выдавший его исполнитель родительского поручения.
+			if parent != nil {
+				recipients = []uuid.UUID{parent.ExecutorID}
+			} else if s.events != nil {
 				recipients, _ = collectUserIDsWithDocumentAction(s.userRepo, s.access, updated.DocumentKind, "assign", nil)
 			}
 			eventType, title, message = models.UserEventAssignmentCompleted, "Поручение ожидает приемки", fmt.Sprintf("Исполнитель отправил поручение по документу %s на приемку", documentNumberLabel(updated.DocumentNumber))
@@ -372,7 +592,7 @@ func (s *AssignmentService) UpdateStatus(id, status, report string) (*dto.Assign
 		}
 		if s.events != nil {
 			for _, recipient := range recipients {
-				request := models.CreateUserEventRequest{RecipientUserID: recipient, ActorUserID: eventActorID(s.auth), DocumentID: updated.DocumentID, DocumentKind: updated.DocumentKind, DocumentNumber: updated.DocumentNumber, EntityType: models.UserEventEntityAssignment, EntityID: updated.ID, EventType: eventType, Title: title, Message: message, Metadata: userEventMetadata(map[string]string{"status": status, "report": statusUpdate.report})}
+				request := models.CreateUserEventRequest{RecipientUserID: recipient, ActorUserID: &principal.UserID, DocumentID: updated.DocumentID, DocumentKind: updated.DocumentKind, DocumentNumber: updated.DocumentNumber, EntityType: models.UserEventEntityAssignment, EntityID: updated.ID, EventType: eventType, Title: title, Message: message, Metadata: userEventMetadata(map[string]string{"status": status, "report": statusUpdate.report})}
 				event, buildErr := NewUserEventOutboxEvent(assignmentOutboxKey(uid, eventType, revision, &recipient, "user_event"), request)
 				if buildErr != nil {
 					return nil, buildErr
@@ -380,18 +600,30 @@ func (s *AssignmentService) UpdateStatus(id, status, report string) (*dto.Assign
 				effects = append(effects, event)
 			}
 		}
-		res, err = repo.UpdateWithOutbox(uid, existing.ExecutorID, existing.Content, existing.Deadline, status, statusUpdate.report, statusUpdate.completedAt, existing.CoExecutorIDs, effects)
+		res, err = repo.UpdateWithOutbox(uid, existing.ExecutorID, existing.Content, existing.Deadline, status, statusUpdate.report, statusUpdate.completedAt, existing.CoExecutorIDs, principal.UserID, effects)
 	}
 	mapped := dto.MapAssignment(res)
 	if mapped != nil {
-		mapped.CanAct = isExecutor || isSubstituteExecutor
+		mapped.CanAct = canActAsExecutor
 	}
 	return mapped, err
 }
 
 // GetByID возвращает поручение по его ID.
 func (s *AssignmentService) GetByID(id string) (*dto.Assignment, error) {
-	if err := s.access.RequireDomainRead(); err != nil {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.getByID(ctx, id)
+}
+
+func (s *AssignmentService) getByID(ctx context.Context, id string) (*dto.Assignment, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.RequireDomainRead(ctx); err != nil {
 		return nil, err
 	}
 	uid, err := uuid.Parse(id)
@@ -405,31 +637,81 @@ func (s *AssignmentService) GetByID(id string) (*dto.Assignment, error) {
 	if res == nil {
 		return nil, models.NewNotFound("поручение не найдено")
 	}
-	if err := s.access.RequireDocumentAction(res.DocumentID, "assign"); err != nil {
-		_, subjectIDs, subjectsErr := s.currentUserAndSubstitutionSubjectIDs()
-		if subjectsErr != nil {
-			return nil, subjectsErr
+	subjectIDs := uuidStrings(principal.SubjectIDsFor(models.SubstitutionScopeAssignments, models.NormalizeDocumentKind(res.DocumentKind)))
+	if err := s.access.RequireDocumentAction(ctx, res.DocumentID, "assign"); err != nil && !isAssignmentAccessibleToAnyExecutor(subjectIDs, res) {
+		// Исполнитель поручения видит выданные им подпоручения на любой глубине.
+		ancestors, err := s.assignmentAncestors(res)
+		if err != nil {
+			return nil, err
 		}
-		if !isAssignmentAccessibleToAnyExecutor(subjectIDs, res) {
+		if !slices.ContainsFunc(ancestors, func(ancestor *models.Assignment) bool {
+			return isAssignmentAccessibleToAnyExecutor(subjectIDs, ancestor)
+		}) {
 			return nil, models.ErrForbidden
 		}
 	}
 	mapped := dto.MapAssignment(res)
 	if mapped != nil {
-		_, subjectIDs, subjectsErr := s.currentUserAndSubstitutionSubjectIDs()
-		if subjectsErr != nil {
-			return nil, subjectsErr
-		}
 		mapped.CanAct = isAssignmentExecutorInSubjects(subjectIDs, res)
+		if err := s.attachSubtaskTree(mapped, res, principal); err != nil {
+			return nil, err
+		}
 	}
 	return mapped, nil
 }
 
+// attachSubtaskTree добавляет в карточку поручения дерево его подпоручений.
+func (s *AssignmentService) attachSubtaskTree(root *dto.Assignment, assignment *models.Assignment, principal *models.Principal) error {
+	if assignment.SubtaskCount == 0 {
+		return nil
+	}
+	repo, ok := s.repo.(assignmentHierarchyStore)
+	if !ok {
+		return fmt.Errorf("assignment store must support sub-tasks")
+	}
+	subtree, err := repo.GetSubtree(assignment.ID)
+	if err != nil {
+		return err
+	}
+	byParent := make(map[string][]dto.Assignment, len(subtree))
+	for i := range subtree {
+		mapped := dto.MapAssignment(&subtree[i])
+		mapped.CanAct = actsForAssignmentExecutor(principal, &subtree[i])
+		byParent[mapped.ParentID] = append(byParent[mapped.ParentID], *mapped)
+	}
+	attachAssignmentChildren(root, byParent)
+	return nil
+}
+
+func attachAssignmentChildren(node *dto.Assignment, byParent map[string][]dto.Assignment) {
+	children := byParent[node.ID]
+	for i := range children {
+		attachAssignmentChildren(&children[i], byParent)
+	}
+	node.Children = children
+}
+
 // GetList возвращает список поручений с учетом фильтрации.
 func (s *AssignmentService) GetList(filter models.AssignmentFilter) (*dto.PagedResult[dto.Assignment], error) {
-	if err := s.access.RequireDomainRead(); err != nil {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.getList(ctx, filter)
+}
+
+func (s *AssignmentService) getList(ctx context.Context, filter models.AssignmentFilter) (*dto.PagedResult[dto.Assignment], error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.RequireDomainRead(ctx); err != nil {
 		return nil, err
 	}
+	// Замещения, ограниченные видами документов, передаются отдельно: в общем
+	// списке поручение замещаемого видно только по этим видам.
+	subjectIDs := uuidStrings(principal.SubjectIDsFor(models.SubstitutionScopeAssignments, ""))
+	kindKeys := principal.KindScopedSubjectKeys(models.SubstitutionScopeAssignments)
 	// Значения по умолчанию
 	if filter.Page < 1 {
 		filter.Page = 1
@@ -442,36 +724,35 @@ func (s *AssignmentService) GetList(filter models.AssignmentFilter) (*dto.PagedR
 		if err != nil {
 			return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 		}
-		if err := s.access.RequireDocumentAction(docUUID, "assign"); err != nil {
-			_, subjectIDs, subjectsErr := s.currentUserAndSubstitutionSubjectIDs()
-			if subjectsErr != nil {
-				return nil, subjectsErr
-			}
+		if err := s.access.RequireDocumentAction(ctx, docUUID, "assign"); err != nil {
 			filter.AccessibleByUserID = subjectIDs[0]
-			if len(subjectIDs) == 1 {
+			if len(subjectIDs) == 1 && len(kindKeys) == 0 {
 				filter.ExecutorID = subjectIDs[0]
 			} else {
 				filter.ExecutorID = ""
 				filter.AccessibleByUserIDs = subjectIDs
+				filter.AccessibleByUserKindKeys = kindKeys
 			}
 		}
 	}
-
-	assignableKinds, err := s.access.GetDocumentKindsWithAction("assign")
-	if err != nil {
-		return nil, err
+	if filter.SeriesID != "" {
+		if _, err := uuid.Parse(filter.SeriesID); err != nil {
+			return nil, models.NewBadRequestWrapped("неверный ID серии поручений", err)
+		}
 	}
-	_, subjectIDs, err := s.currentUserAndSubstitutionSubjectIDs()
+
+	assignableKinds, err := s.access.GetDocumentKindsWithAction(ctx, "assign")
 	if err != nil {
 		return nil, err
 	}
 	if len(assignableKinds) == 0 {
-		if len(subjectIDs) == 1 {
+		if len(subjectIDs) == 1 && len(kindKeys) == 0 {
 			filter.ExecutorID = subjectIDs[0]
 		} else {
 			filter.ExecutorID = ""
 			filter.AccessibleByUserID = subjectIDs[0]
 			filter.AccessibleByUserIDs = subjectIDs
+			filter.AccessibleByUserKindKeys = kindKeys
 		}
 	} else if len(assignableKinds) < len(models.AllDocumentKindSpecs()) {
 		filter.AllowedDocumentKinds = documentKindCodes(assignableKinds)
@@ -479,6 +760,7 @@ func (s *AssignmentService) GetList(filter models.AssignmentFilter) (*dto.PagedR
 		if len(subjectIDs) > 1 {
 			filter.AccessibleByUserIDs = subjectIDs
 		}
+		filter.AccessibleByUserKindKeys = kindKeys
 	}
 
 	res, err := s.repo.GetList(filter)
@@ -486,7 +768,7 @@ func (s *AssignmentService) GetList(filter models.AssignmentFilter) (*dto.PagedR
 		return nil, err
 	}
 	items := dto.MapAssignments(res.Items)
-	markAssignmentsCanAct(items, subjectIDs, res.Items)
+	markAssignmentsCanAct(items, principal, res.Items)
 	return &dto.PagedResult[dto.Assignment]{
 		Items:      items,
 		TotalCount: res.TotalCount,
@@ -495,10 +777,10 @@ func (s *AssignmentService) GetList(filter models.AssignmentFilter) (*dto.PagedR
 	}, nil
 }
 
-func markAssignmentsCanAct(items []dto.Assignment, subjectIDs []string, assignments []models.Assignment) {
+func markAssignmentsCanAct(items []dto.Assignment, principal *models.Principal, assignments []models.Assignment) {
 	for i := range items {
 		if i < len(assignments) {
-			items[i].CanAct = isAssignmentExecutorInSubjects(subjectIDs, &assignments[i])
+			items[i].CanAct = principal.ActsForIn(assignments[i].ExecutorID, models.SubstitutionScopeAssignments, models.NormalizeDocumentKind(assignments[i].DocumentKind))
 		}
 	}
 }
@@ -562,6 +844,14 @@ func assignmentRevision(assignment *models.Assignment) string {
 
 // Delete удаляет поручение по его ID (только для незавершенных, если не админ).
 func (s *AssignmentService) Delete(id string) error {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return err
+	}
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return models.NewBadRequestWrapped("неверный ID поручения", err)
@@ -574,7 +864,11 @@ func (s *AssignmentService) Delete(id string) error {
 	if existing == nil {
 		return models.NewNotFound("поручение не найдено")
 	}
-	if err := s.access.RequireDocumentAction(existing.DocumentID, "assign"); err != nil {
+	parent, err := s.parentAssignment(existing)
+	if err != nil {
+		return err
+	}
+	if err := s.access.RequireDocumentAction(ctx, existing.DocumentID, "assign"); err != nil && !actsForAssignmentExecutor(principal, parent) {
 		return err
 	}
 
@@ -582,13 +876,15 @@ func (s *AssignmentService) Delete(id string) error {
 	if existing.Status == "finished" {
 		return models.NewConflict("нельзя удалить завершённое поручение")
 	}
+	if existing.SubtaskCount > 0 {
+		return models.NewConflict("нельзя удалить поручение с подпоручениями")
+	}
 
 	repo, ok := s.repo.(assignmentOutboxStore)
 	if !ok {
 		return fmt.Errorf("assignment store must support atomic outbox operations")
 	}
-	currentUserID, _ := s.auth.GetCurrentUserUUID()
-	event, buildErr := NewJournalOutboxEvent(assignmentOutboxKey(uid, "deleted", "", nil, "journal"), models.CreateJournalEntryRequest{DocumentID: existing.DocumentID, UserID: currentUserID, Action: "ASSIGNMENT_DELETE", Details: "Поручение удалено"})
+	event, buildErr := NewJournalOutboxEvent(assignmentOutboxKey(uid, "deleted", "", nil, "journal"), models.CreateJournalEntryRequest{DocumentID: existing.DocumentID, UserID: principal.UserID, Action: "ASSIGNMENT_DELETE", Details: "Поручение удалено"})
 	if buildErr != nil {
 		return buildErr
 	}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"encoding/json"
+
 	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
@@ -23,12 +25,12 @@ type atomicAssignmentStore struct {
 	effects []models.OutboxEvent
 }
 
-func (s *atomicAssignmentStore) CreateWithOutbox(_ uuid.UUID, documentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, effects []models.OutboxEvent) (*models.Assignment, error) {
+func (s *atomicAssignmentStore) CreateWithOutbox(_ uuid.UUID, documentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, _ uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
 	s.effects = append([]models.OutboxEvent(nil), effects...)
 	return s.AssignmentStore.Create(documentID, executorID, content, deadline, coExecutorIDs)
 }
 
-func (s *atomicAssignmentStore) UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, effects []models.OutboxEvent) (*models.Assignment, error) {
+func (s *atomicAssignmentStore) UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, _ uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
 	s.effects = append([]models.OutboxEvent(nil), effects...)
 	return s.AssignmentStore.Update(id, executorID, content, deadline, status, report, completedAt, coExecutorIDs)
 }
@@ -550,10 +552,8 @@ func TestAssignmentService_UpdateStatus(t *testing.T) {
 		repo.On("HasDocumentAccess", mock.Anything, mock.Anything).Return(true, nil).Maybe()
 		accessSvc := NewDocumentAccessService(authSvc, nil, repo, nil, newRoleMappedDocumentAccessStore(), nil, incomingRepo, outgoingRepo)
 		svc2 := NewAssignmentService(repo, userRepo, authSvc, accessSvc)
-		svc2.SetSubstitutionStore(&userSubstitutionStoreStub{
-			isActive: map[[2]uuid.UUID]bool{
-				{substituteID, execID}: true,
-			},
+		authSvc.SetSubstitutionStore(&userSubstitutionStoreStub{
+			activePrincipals: []uuid.UUID{execID},
 		})
 
 		repo.On("GetByID", assignmentID).Return(existing, nil).Once()
@@ -1201,8 +1201,7 @@ func TestAssignmentService_GetList(t *testing.T) {
 		substitutions := &userSubstitutionStoreStub{
 			activePrincipals: []uuid.UUID{principalID},
 		}
-		svc.SetSubstitutionStore(substitutions)
-		svc.access.substitutionRepo = substitutions
+		auth.SetSubstitutionStore(substitutions)
 
 		filter := models.AssignmentFilter{
 			Page:       1,
@@ -1332,3 +1331,247 @@ func TestAssignmentService_Delete(t *testing.T) {
 		assert.Equal(t, 404, appErr.Code)
 	})
 }
+
+// subtaskAssignmentStore хранит поручения в памяти и считает подпоручения так
+// же, как репозиторий: открытыми считаются не принятые и не отмененные.
+type subtaskAssignmentStore struct {
+	*mocks.AssignmentStore
+	items   map[uuid.UUID]models.Assignment
+	effects []models.OutboxEvent
+}
+
+func newSubtaskAssignmentStore(repo *mocks.AssignmentStore, items ...models.Assignment) *subtaskAssignmentStore {
+	store := &subtaskAssignmentStore{AssignmentStore: repo, items: map[uuid.UUID]models.Assignment{}}
+	for _, item := range items {
+		store.items[item.ID] = item
+	}
+	return store
+}
+
+func (s *subtaskAssignmentStore) GetByID(id uuid.UUID) (*models.Assignment, error) {
+	item, ok := s.items[id]
+	if !ok {
+		return nil, nil
+	}
+	item.SubtaskCount, item.OpenSubtaskCount = 0, 0
+	for _, child := range s.items {
+		if child.ParentID != nil && *child.ParentID == id {
+			item.SubtaskCount++
+			if child.Status != "finished" && child.Status != "cancelled" {
+				item.OpenSubtaskCount++
+			}
+		}
+	}
+	return &item, nil
+}
+
+func (s *subtaskAssignmentStore) GetSubtree(rootID uuid.UUID) ([]models.Assignment, error) {
+	var subtree []models.Assignment
+	queue := []uuid.UUID{rootID}
+	for len(queue) > 0 {
+		parentID := queue[0]
+		queue = queue[1:]
+		for id, item := range s.items {
+			if item.ParentID != nil && *item.ParentID == parentID {
+				child, _ := s.GetByID(id)
+				subtree = append(subtree, *child)
+				queue = append(queue, id)
+			}
+		}
+	}
+	return subtree, nil
+}
+
+func (s *subtaskAssignmentStore) CreateSubtaskWithOutbox(id, parentID, executorID uuid.UUID, content string, deadline *time.Time, coExecutorIDs []string, _ uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
+	parent := s.items[parentID]
+	s.items[id] = models.Assignment{ID: id, ParentID: &parentID, DocumentID: parent.DocumentID, DocumentKind: parent.DocumentKind, ExecutorID: executorID, Content: content, Deadline: deadline, Status: "new", CoExecutorIDs: coExecutorIDs}
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	return s.GetByID(id)
+}
+
+func (s *subtaskAssignmentStore) UpdateWithOutbox(id, executorID uuid.UUID, content string, deadline *time.Time, status, report string, completedAt *time.Time, coExecutorIDs []string, _ uuid.UUID, effects []models.OutboxEvent) (*models.Assignment, error) {
+	item := s.items[id]
+	item.ExecutorID, item.Content, item.Deadline, item.Status, item.Report, item.CompletedAt, item.CoExecutorIDs = executorID, content, deadline, status, report, completedAt, coExecutorIDs
+	s.items[id] = item
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	return s.GetByID(id)
+}
+
+func (s *subtaskAssignmentStore) DeleteWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error {
+	delete(s.items, id)
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	return nil
+}
+
+func assignmentJournalRequest(t *testing.T, effects []models.OutboxEvent) models.CreateJournalEntryRequest {
+	t.Helper()
+	for _, effect := range effects {
+		if effect.EventType == models.OutboxEventJournal {
+			var request models.CreateJournalEntryRequest
+			require.NoError(t, json.Unmarshal([]byte(effect.Payload), &request))
+			return request
+		}
+	}
+	t.Fatal("journal effect not found")
+	return models.CreateJournalEntryRequest{}
+}
+
+func TestAssignmentService_CreateSubtask(t *testing.T) {
+	parentDeadline := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+	newParent := func(executorID uuid.UUID, status string) models.Assignment {
+		return models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", DocumentNumber: "ВХ-7", ExecutorID: executorID, Status: status, Deadline: &parentDeadline}
+	}
+
+	t.Run("executor delegates part with inherited deadline", func(t *testing.T) {
+		svc, repo, _, auth, _ := setupAssignmentService(t, "executor")
+		executorID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		parent := newParent(executorID, "in_progress")
+		store := newSubtaskAssignmentStore(repo, parent)
+		svc.repo = store
+		subExecutorID := uuid.New()
+
+		result, err := svc.CreateSubtask(parent.ID.String(), subExecutorID.String(), "Подготовить справку", "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, parent.ID.String(), result.ParentID)
+		require.NotNil(t, result.Deadline)
+		assert.True(t, result.Deadline.Equal(parentDeadline))
+		assert.Equal(t, "ASSIGNMENT_SUBTASK_CREATE", assignmentJournalRequest(t, store.effects).Action)
+		assert.Equal(t, []uuid.UUID{subExecutorID}, userEventRecipients(t, store.effects, models.UserEventAssignmentCreated))
+	})
+
+	t.Run("rejects deadline after parent and self delegation", func(t *testing.T) {
+		svc, repo, _, auth, _ := setupAssignmentService(t, "executor")
+		executorID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		parent := newParent(executorID, "in_progress")
+		store := newSubtaskAssignmentStore(repo, parent)
+		svc.repo = store
+
+		_, err = svc.CreateSubtask(parent.ID.String(), uuid.NewString(), "Часть", "2026-07-25", nil)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "не может быть позже срока поручения (20.07.2026)")
+		_, err = svc.CreateSubtask(parent.ID.String(), executorID.String(), "Часть", "", nil)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "должен отличаться")
+		assert.Len(t, store.items, 1)
+	})
+
+	t.Run("only open assignment of the current executor", func(t *testing.T) {
+		svc, repo, _, auth, _ := setupAssignmentService(t, "executor")
+		executorID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		foreign := newParent(uuid.New(), "in_progress")
+		closed := newParent(executorID, "completed")
+		svc.repo = newSubtaskAssignmentStore(repo, foreign, closed)
+
+		_, err = svc.CreateSubtask(foreign.ID.String(), uuid.NewString(), "Часть", "", nil)
+		requireAppError(t, err, "FORBIDDEN", 403, "только исполнитель поручения")
+		_, err = svc.CreateSubtask(closed.ID.String(), uuid.NewString(), "Часть", "", nil)
+		requireAppError(t, err, "CONFLICT", 409, "по поручению в работе")
+	})
+
+	t.Run("limits nesting depth", func(t *testing.T) {
+		svc, repo, _, auth, _ := setupAssignmentService(t, "executor")
+		executorID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		chain := []models.Assignment{newParent(uuid.New(), "in_progress")}
+		for len(chain) < maxAssignmentDepth {
+			next := newParent(uuid.New(), "in_progress")
+			next.ParentID = &chain[len(chain)-1].ID
+			chain = append(chain, next)
+		}
+		chain[len(chain)-1].ExecutorID = executorID
+		svc.repo = newSubtaskAssignmentStore(repo, chain...)
+
+		_, err = svc.CreateSubtask(chain[len(chain)-1].ID.String(), uuid.NewString(), "Часть", "", nil)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "не более 4 уровней")
+	})
+}
+
+func TestAssignmentService_SubtaskRollUp(t *testing.T) {
+	deadline := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+
+	t.Run("parent completes only after subtasks or with override", func(t *testing.T) {
+		svc, repo, _, auth, _ := setupAssignmentService(t, "executor")
+		executorID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		parent := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: executorID, Status: "in_progress", Deadline: &deadline}
+		child := models.Assignment{ID: uuid.New(), ParentID: &parent.ID, DocumentID: parent.DocumentID, DocumentKind: "incoming_letter", ExecutorID: uuid.New(), Status: "completed", Deadline: &deadline}
+		store := newSubtaskAssignmentStore(repo, parent, child)
+		svc.repo = store
+
+		_, err = svc.UpdateStatus(parent.ID.String(), "completed", "Исполнено")
+		requireAppError(t, err, "CONFLICT", 409, "не завершены подпоручения (1)")
+
+		result, err := svc.UpdateStatusOverridingSubtasks(parent.ID.String(), "completed", "Исполнено")
+		require.NoError(t, err)
+		assert.Equal(t, "completed", result.Status)
+		assert.Contains(t, assignmentJournalRequest(t, store.effects).Details, "не завершены подпоручения: 1")
+	})
+
+	t.Run("parent executor accepts subtask reported to them", func(t *testing.T) {
+		svc, repo, userRepo, auth, _ := setupAssignmentService(t, "executor")
+		currentID, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+		svc.events = NewUserEventService(&fakeUserEventStore{}, auth)
+		parent := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: uuid.New(), Status: "in_progress"}
+		child := models.Assignment{ID: uuid.New(), ParentID: &parent.ID, DocumentID: parent.DocumentID, DocumentKind: "incoming_letter", ExecutorID: currentID, Status: "in_progress"}
+		store := newSubtaskAssignmentStore(repo, parent, child)
+		svc.repo = store
+
+		_, err = svc.UpdateStatus(child.ID.String(), "completed", "Справка готова")
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{parent.ExecutorID}, userEventRecipients(t, store.effects, models.UserEventAssignmentCompleted))
+		userRepo.AssertNotCalled(t, "GetAll")
+
+		// Исполнитель родителя управляет подпоручением без права assign на документ.
+		parent.ExecutorID, child.ExecutorID = currentID, uuid.New()
+		child.Status = "completed"
+		store.items[parent.ID], store.items[child.ID] = parent, child
+		result, err := svc.UpdateStatus(child.ID.String(), "finished", "")
+		require.NoError(t, err)
+		assert.Equal(t, "finished", result.Status)
+	})
+}
+
+func TestAssignmentService_SubtaskTreeAndConstraints(t *testing.T) {
+	svc, repo, _, auth, _ := setupAssignmentService(t, "executor")
+	executorID, err := auth.GetCurrentUserUUID()
+	require.NoError(t, err)
+	parentDeadline := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+	childDeadline := time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC)
+	root := models.Assignment{ID: uuid.New(), DocumentID: uuid.New(), DocumentKind: "incoming_letter", ExecutorID: executorID, Status: "in_progress", Deadline: &parentDeadline}
+	child := models.Assignment{ID: uuid.New(), ParentID: &root.ID, DocumentID: root.DocumentID, DocumentKind: "incoming_letter", ExecutorID: uuid.New(), Status: "in_progress", Deadline: &childDeadline}
+	grandchild := models.Assignment{ID: uuid.New(), ParentID: &child.ID, DocumentID: root.DocumentID, DocumentKind: "incoming_letter", ExecutorID: uuid.New(), Status: "new", Deadline: &childDeadline}
+	store := newSubtaskAssignmentStore(repo, root, child, grandchild)
+	svc.repo = store
+
+	t.Run("card contains subtask tree", func(t *testing.T) {
+		result, err := svc.GetByID(root.ID.String())
+		require.NoError(t, err)
+		assert.True(t, result.CanAct)
+		assert.Equal(t, 1, result.OpenSubtaskCount)
+		require.Len(t, result.Children, 1)
+		assert.Equal(t, child.ID.String(), result.Children[0].ID)
+		require.Len(t, result.Children[0].Children, 1)
+		assert.Equal(t, grandchild.ID.String(), result.Children[0].Children[0].ID)
+		assert.False(t, result.Children[0].CanAct)
+	})
+
+	t.Run("ancestor executor sees nested subtask", func(t *testing.T) {
+		result, err := svc.GetByID(grandchild.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, child.ID.String(), result.ParentID)
+	})
+
+	t.Run("subtask deadline bounded by parent and children", func(t *testing.T) {
+		_, err := svc.Update(child.ID.String(), child.ExecutorID.String(), "Часть", "2026-07-21", nil)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "не может быть позже срока поручения")
+		_, err = svc.Update(child.ID.String(), child.ExecutorID.String(), "Часть", "2026-07-10", nil)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "не может быть раньше срока подпоручения (15.07.2026)")
+	})
+
+	t.Run("subtask with own subtasks cannot be deleted", func(t *testing.T) {
+		requireAppError(t, svc.Delete(child.ID.String()), "CONFLICT", 409, "с подпоручениями")
+		assert.Equal(t, models.ErrForbidden, svc.Delete(grandchild.ID.String()))
+	})
+}
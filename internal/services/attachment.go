@@ -2,11 +2,13 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/observability"
+	"io"
 	"mime"
 	"os"
 	"os/exec"
@@ -27,6 +29,7 @@ type AttachmentService struct {
 	settingsService *SettingsService
 	authService     *AuthService
 	fileStorage     FileStorage
+	texts           AttachmentTextStore
 	access          *DocumentAccessService
 	lifecycle       *OperationLifecycle
 	uiContext       context.Context
@@ -75,6 +78,12 @@ func (s *AttachmentService) SetOperationLifecycle(lifecycle *OperationLifecycle)
 
 func (s *AttachmentService) SetOperationMetrics(metrics *observability.Registry) { s.metrics = metrics }
 
+// SetTextStore подключает хранилище извлеченного текста для предпросмотра в карточке документа.
+func (s *AttachmentService) SetTextStore(texts AttachmentTextStore) { s.texts = texts }
+
+// attachmentTextPreviewRunes ограничивает объем текста, передаваемого в карточку документа.
+const attachmentTextPreviewRunes = 20000
+
 // ReconcileStorage compares database metadata and MinIO without modifying
 // either side. It is intentionally available only to administrators.
 func (s *AttachmentService) ReconcileStorage() (*models.AttachmentStorageReconciliation, error) {
@@ -142,13 +151,17 @@ func (s *AttachmentService) Upload(documentIDStr string) ([]dto.Attachment, erro
 		if s.uiContext == nil {
 			return nil, fmt.Errorf("file picker is not initialized")
 		}
+		principalCtx, err := s.authService.sessionContext()
+		if err != nil {
+			return nil, err
+		}
 		paths, err := wailsruntime.OpenMultipleFilesDialog(s.uiContext, wailsruntime.OpenDialogOptions{Title: "Выберите файлы для вложения"})
 		if err != nil {
 			return nil, fmt.Errorf("failed to choose files: %w", err)
 		}
 		attachments := make([]dto.Attachment, 0, len(paths))
 		for _, path := range paths {
-			attachment, err := s.uploadPath(documentIDStr, path)
+			attachment, err := s.uploadPath(principalCtx, documentIDStr, path)
 			if err != nil {
 				return nil, err
 			}
@@ -158,52 +171,66 @@ func (s *AttachmentService) Upload(documentIDStr string) ([]dto.Attachment, erro
 	})
 }
 
-func (s *AttachmentService) uploadPath(documentIDStr, path string) (*dto.Attachment, error) {
+func (s *AttachmentService) uploadPath(principalCtx context.Context, documentIDStr, path string) (*dto.Attachment, error) {
 	ctx, release := serviceOperationContext(s.lifecycle)
 	defer release()
 
-	currentUser, err := s.authService.GetCurrentUser()
+	principal, documentID, err := s.authorizeUpload(principalCtx, documentIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("не удалось открыть выбранный файл", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, models.NewBadRequest("выбранный путь не является обычным файлом")
+	}
+	return s.storeUpload(ctx, principal, documentID, filepath.Base(path), file, info.Size())
+}
+
+// authorizeUpload проверяет, что principal может добавить файл к документу
+// напрямую или как исполнитель поручения.
+func (s *AttachmentService) authorizeUpload(ctx context.Context, documentIDStr string) (*models.Principal, uuid.UUID, error) {
+	principal, err := requirePrincipal(ctx)
 	if err != nil {
-		return nil, models.ErrUnauthorized
+		return nil, uuid.Nil, err
 	}
 
 	documentID, err := uuid.Parse(documentIDStr)
 	if err != nil {
-		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+		return nil, uuid.Nil, models.NewBadRequestWrapped("неверный ID документа", err)
 	}
 	if _, err := s.access.RequireExists(documentID); err != nil {
-		return nil, err
+		return nil, uuid.Nil, err
 	}
 
-	canUploadDirectly := s.access.RequireDocumentAction(documentID, "upload") == nil
+	canUploadDirectly := s.access.RequireDocumentAction(ctx, documentID, "upload") == nil
 	if !canUploadDirectly {
 		if !s.settingsService.IsAssignmentCompletionAttachmentsEnabled() {
-			return nil, models.NewForbidden("загрузка файлов при завершении поручения отключена в настройках")
+			return nil, uuid.Nil, models.NewForbidden("загрузка файлов при завершении поручения отключена в настройках")
 		}
 
-		hasAssignmentAccess, err := s.access.HasAssignmentAccess(documentID)
+		hasAssignmentAccess, err := s.access.HasAssignmentAccess(ctx, documentID)
 		if err != nil {
-			return nil, err
+			return nil, uuid.Nil, err
 		}
 		if !hasAssignmentAccess {
-			return nil, models.ErrForbidden
+			return nil, uuid.Nil, models.ErrForbidden
 		}
 	}
+	return principal, documentID, nil
+}
 
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, models.NewBadRequestWrapped("не удалось открыть выбранный файл", err)
-	}
-	defer file.Close()
-	info, err := file.Stat()
-	if err != nil || !info.Mode().IsRegular() {
-		return nil, models.NewBadRequest("выбранный путь не является обычным файлом")
-	}
-	filename := filepath.Base(path)
-
+// storeUpload проверяет размер и тип файла, передает содержимое в хранилище и
+// сохраняет метаданные вложения. content должен содержать ровно size байт.
+func (s *AttachmentService) storeUpload(ctx context.Context, principal *models.Principal, documentID uuid.UUID, filename string, content io.Reader, size int64) (*dto.Attachment, error) {
 	// Проверка размера до чтения содержимого.
 	maxSize, _ := s.settingsService.GetMaxFileSize() // returns bytes
-	if info.Size() > maxSize {
+	if size > maxSize {
 		return nil, models.NewBadRequest(fmt.Sprintf("размер файла превышает максимально допустимый (%d МБ)", maxSize/(1024*1024)))
 	}
 
@@ -226,24 +253,27 @@ func (s *AttachmentService) uploadPath(documentIDStr, path string) (*dto.Attachm
 		contentType = "application/octet-stream"
 	}
 
+	// SHA-256 считается по тем же байтам, что уходят в хранилище, без повторного чтения файла.
 	objectName := uuid.New().String() + ext
-	if err := s.fileStorage.UploadFile(ctx, objectName, file, info.Size(), contentType); err != nil {
+	digest := newAttachmentDigest()
+	if err := s.fileStorage.UploadFile(ctx, objectName, io.TeeReader(content, digest), size, contentType); err != nil {
 		return nil, fmt.Errorf("failed to upload file to storage: %v", err)
 	}
-
-	// 4. Сохранение в БД
-	userID, err := uuid.Parse(currentUser.ID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid current user ID: %w", err)
+	if digest.size != size {
+		_ = s.fileStorage.DeleteFile(ctx, objectName)
+		return nil, fmt.Errorf("failed to upload file to storage: %d of %d bytes were read", digest.size, size)
 	}
 
+	// 4. Сохранение в БД
+	userID := principal.UserID
 	attachment := &models.Attachment{
 		DocumentID:  documentID,
 		Filename:    filename,
-		FileSize:    info.Size(),
+		FileSize:    size,
 		ContentType: contentType,
 		StoragePath: objectName,
 		UploadedBy:  userID,
+		SHA256:      digest.Sum(),
 	}
 
 	outboxRepo, ok := s.repo.(attachmentOutboxStore)
@@ -255,14 +285,14 @@ func (s *AttachmentService) uploadPath(documentIDStr, path string) (*dto.Attachm
 	if buildErr != nil {
 		return nil, buildErr
 	}
-	err = outboxRepo.CreateWithOutbox(attachment, []models.OutboxEvent{event})
-	if err != nil {
+	// Повтор того же содержимого в документе отклоняется уникальным индексом по SHA-256.
+	if err := outboxRepo.CreateWithOutbox(attachment, []models.OutboxEvent{event}); err != nil {
 		// Попытка откатить (удалить) файл из хранилища, если сохранение в БД не удалось
 		_ = s.fileStorage.DeleteFile(ctx, objectName)
 		return nil, err
 	}
 
-	attachment.UploadedByName = currentUser.FullName
+	attachment.UploadedByName = principal.FullName
 	if s.metrics != nil {
 		s.metrics.AddCounter("attachments.upload.bytes", float64(attachment.FileSize))
 	}
@@ -272,12 +302,20 @@ func (s *AttachmentService) uploadPath(documentIDStr, path string) (*dto.Attachm
 
 // GetList — получить вложения документа
 func (s *AttachmentService) GetList(documentIDStr string) ([]dto.Attachment, error) {
+	ctx, err := s.authService.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.getList(ctx, documentIDStr)
+}
+
+func (s *AttachmentService) getList(ctx context.Context, documentIDStr string) ([]dto.Attachment, error) {
 	return measureOperation(s.metrics, "attachments.get_list", func() ([]dto.Attachment, error) {
 		documentID, err := uuid.Parse(documentIDStr)
 		if err != nil {
 			return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 		}
-		if err := s.access.RequireReadAnyType(documentID); err != nil {
+		if err := s.access.RequireReadAnyType(ctx, documentID); err != nil {
 			return nil, err
 		}
 		res, err := s.repo.GetByDocumentID(documentID)
@@ -291,9 +329,22 @@ func (s *AttachmentService) GetList(documentIDStr string) ([]dto.Attachment, err
 
 // Delete — удалить вложение
 func (s *AttachmentService) Delete(idStr string) error {
+	ctx, err := s.authService.sessionContext()
+	if err != nil {
+		return err
+	}
+	return s.delete(ctx, idStr)
+}
+
+func (s *AttachmentService) delete(ctx context.Context, idStr string) error {
 	_, release := serviceOperationContext(s.lifecycle)
 	defer release()
 
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
 	// Проверка прав доступа
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -308,7 +359,7 @@ func (s *AttachmentService) Delete(idStr string) error {
 	if attachment == nil {
 		return nil
 	}
-	if err := s.access.RequireDocumentAction(attachment.DocumentID, "upload"); err != nil {
+	if err := s.access.RequireDocumentAction(ctx, attachment.DocumentID, "upload"); err != nil {
 		return err
 	}
 
@@ -318,40 +369,75 @@ func (s *AttachmentService) Delete(idStr string) error {
 	if !ok || !outboxRepo.OutboxEnabled() {
 		return fmt.Errorf("attachment store must support atomic outbox operations")
 	}
-	currentUserID, _ := s.authService.GetCurrentUserUUID()
-	event, buildErr := NewJournalOutboxEvent("attachment:"+attachment.ID.String()+":delete:journal", models.CreateJournalEntryRequest{DocumentID: attachment.DocumentID, UserID: currentUserID, Action: "FILE_DELETE", Details: fmt.Sprintf("Удален файл: %s", attachment.Filename)})
+	event, buildErr := NewJournalOutboxEvent("attachment:"+attachment.ID.String()+":delete:journal", models.CreateJournalEntryRequest{DocumentID: attachment.DocumentID, UserID: principal.UserID, Action: "FILE_DELETE", Details: fmt.Sprintf("Удален файл: %s", attachment.Filename)})
 	if buildErr != nil {
 		return buildErr
 	}
 	return outboxRepo.MarkDeletingWithEffects(*attachment, []models.OutboxEvent{event})
 }
 
-// DownloadToDisk — сохранить файл в папку «Загрузки» пользователя и вернуть полный путь
-func (s *AttachmentService) DownloadToDisk(idStr string) (string, error) {
-	return measureOperation(s.metrics, "attachments.download", func() (string, error) {
-		ctx, release := serviceOperationContext(s.lifecycle)
-		defer release()
+// GetTextPreview — получить текст, извлеченный из вложения.
+// Пока обработчик outbox не извлек текст, возвращается статус pending.
+func (s *AttachmentService) GetTextPreview(idStr string) (*dto.AttachmentText, error) {
+	return measureOperation(s.metrics, "attachments.text_preview", func() (*dto.AttachmentText, error) {
+		ctx, err := s.authService.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.access.RequireDomainRead(ctx); err != nil {
+			return nil, err
+		}
+		if s.texts == nil {
+			return nil, fmt.Errorf("attachment text store is not configured")
+		}
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный ID файла", err)
+		}
+		attachment, err := s.repo.GetByID(id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && attachment == nil) {
+			return nil, models.NewNotFound("файл не найден")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := s.access.RequireReadAnyType(ctx, attachment.DocumentID); err != nil {
+			return nil, err
+		}
 
-		if err := s.access.RequireDomainRead(); err != nil {
-			return "", err
+		text, err := s.texts.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if text == nil {
+			return &dto.AttachmentText{AttachmentID: id.String(), Status: models.AttachmentTextPending}, nil
 		}
+		preview := dto.MapAttachmentText(text)
+		if runes := []rune(preview.Text); len(runes) > attachmentTextPreviewRunes {
+			preview.Text = string(runes[:attachmentTextPreviewRunes])
+			preview.Truncated = true
+		}
+		return preview, nil
+	})
+}
 
-		id, err := uuid.Parse(idStr)
+// DownloadToDisk — сохранить файл в папку «Загрузки» пользователя и вернуть полный путь
+func (s *AttachmentService) DownloadToDisk(idStr string) (string, error) {
+	return measureOperation(s.metrics, "attachments.download", func() (string, error) {
+		principalCtx, err := s.authService.sessionContext()
 		if err != nil {
-			return "", models.NewBadRequestWrapped("неверный ID файла", err)
+			return "", err
 		}
+		ctx, release := serviceOperationContext(s.lifecycle)
+		defer release()
 
-		// Получение метаданных
-		attachment, err := s.repo.GetByID(id)
+		attachment, err := s.authorizeDownload(principalCtx, idStr)
 		if err != nil {
 			return "", err
 		}
 		if attachment == nil {
 			return "", nil
 		}
-		if err := s.access.RequireReadAnyType(attachment.DocumentID); err != nil {
-			return "", err
-		}
 
 		// Получение содержимого
 		// Определение пути для сохранения
@@ -370,8 +456,11 @@ func (s *AttachmentService) DownloadToDisk(idStr string) (string, error) {
 
 		maxSize, _ := s.settingsService.GetMaxFileSize()
 		fullPath, err := writeDownloadFileFromStorage(downloadDir, attachment.Filename, func(file *os.File) error {
-			return s.fileStorage.DownloadFileToWriter(ctx, attachment.StoragePath, file, maxSize)
+			return s.downloadVerified(ctx, *attachment, file, maxSize)
 		})
+		if appErr, ok := models.AsAppError(err); ok {
+			return "", appErr
+		}
 		if err != nil {
 			return "", fmt.Errorf("failed to write file: %v", err)
 		}
@@ -383,6 +472,32 @@ func (s *AttachmentService) DownloadToDisk(idStr string) (string, error) {
 	})
 }
 
+// authorizeDownload возвращает метаданные вложения, если principal может
+// читать документ. Для отсутствующего вложения возвращается nil.
+func (s *AttachmentService) authorizeDownload(ctx context.Context, idStr string) (*models.Attachment, error) {
+	if err := s.access.RequireDomainRead(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID файла", err)
+	}
+
+	// Получение метаданных
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, nil
+	}
+	if err := s.access.RequireReadAnyType(ctx, attachment.DocumentID); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
 func writeDownloadFileFromStorage(downloadDir, filename string, write func(*os.File) error) (string, error) {
 	cleanFilename := safeDownloadFilename(filename)
 	ext := filepath.Ext(cleanFilename)
@@ -460,6 +575,11 @@ func safeDownloadFilename(filename string) string {
 
 // getDownloadDir — получить путь к папке «Загрузки» текущего пользователя
 func (s *AttachmentService) getDownloadDir() (string, error) {
+	return userDownloadDir()
+}
+
+// userDownloadDir возвращает путь к папке «Загрузки» пользователя ОС.
+func userDownloadDir() (string, error) {
 	currentUser, err := user.Current()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current user: %v", err)
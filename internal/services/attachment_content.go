@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// AttachmentContentService передает содержимое вложений потоками для HTTP API.
+// AttachmentService работает с файлами рабочего места через диалоги Wails, а
+// этот сервис принимает и отдает io.Reader/io.Writer с теми же проверками прав,
+// размера, типа и целостности от имени principal из ctx. В Wails он не
+// привязывается.
+type AttachmentContentService struct {
+	attachments *AttachmentService
+}
+
+// NewAttachmentContentService создает потоковый фасад над AttachmentService.
+func NewAttachmentContentService(attachments *AttachmentService) *AttachmentContentService {
+	return &AttachmentContentService{attachments: attachments}
+}
+
+// Upload сохраняет content как вложение документа. size — точный объем
+// содержимого в байтах; при расхождении объект в хранилище не сохраняется.
+func (s *AttachmentContentService) Upload(ctx context.Context, documentID, filename string, content io.Reader, size int64) (*dto.Attachment, error) {
+	return measureOperation(s.attachments.metrics, "attachments.upload", func() (*dto.Attachment, error) {
+		principal, docUUID, err := s.attachments.authorizeUpload(ctx, documentID)
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(strings.ReplaceAll(strings.TrimSpace(filename), "\\", "/"))
+		if name == "" || name == "." || name == "/" {
+			return nil, models.NewBadRequest("не указано имя файла")
+		}
+		if size < 0 {
+			return nil, models.NewBadRequest("не указан размер файла")
+		}
+		operationCtx, release := serviceOperationContext(s.attachments.lifecycle)
+		defer release()
+		return s.attachments.storeUpload(operationCtx, principal, docUUID, name, content, size)
+	})
+}
+
+// Download пишет содержимое вложения в writer и возвращает его метаданные.
+// Содержимое сверяется с SHA-256 только после записи, поэтому при ошибке
+// целостности уже записанные данные должны быть отброшены вызывающим кодом.
+func (s *AttachmentContentService) Download(ctx context.Context, id string, writer io.Writer) (*dto.Attachment, error) {
+	return measureOperation(s.attachments.metrics, "attachments.download", func() (*dto.Attachment, error) {
+		attachment, err := s.attachments.authorizeDownload(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if attachment == nil {
+			return nil, models.NewNotFound("файл не найден")
+		}
+
+		operationCtx, release := serviceOperationContext(s.attachments.lifecycle)
+		defer release()
+		maxSize, _ := s.attachments.settingsService.GetMaxFileSize()
+		if err := s.attachments.downloadVerified(operationCtx, *attachment, writer, maxSize); err != nil {
+			return nil, err
+		}
+		if s.attachments.metrics != nil {
+			s.attachments.metrics.AddCounter("attachments.download.bytes", float64(attachment.FileSize))
+		}
+		return dto.MapAttachment(attachment), nil
+	})
+}
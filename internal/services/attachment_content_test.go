@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentContentServiceUploadStreamsReader(t *testing.T) {
+	docID := uuid.New()
+	svc, repo, settingsRepo, storage, incomingRepo, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+	atomicRepo := &atomicAttachmentStore{AttachmentStore: repo}
+	svc.repo = atomicRepo
+	incomingRepo.On("GetByID", docID).Return(&models.IncomingDocument{ID: docID, NomenclatureID: uuid.New()}, nil).Maybe()
+	settingsRepo.On("Get", "max_file_size_mb").Return(&models.SystemSetting{Key: "max_file_size_mb", Value: "10"}, nil).Once()
+	settingsRepo.On("Get", "allowed_file_types").Return(&models.SystemSetting{Key: "allowed_file_types", Value: ".txt"}, nil).Once()
+	storage.On("UploadFile", mock.Anything, mock.AnythingOfType("string"), mock.Anything, int64(13), "text/plain; charset=utf-8").
+		Run(func(args mock.Arguments) { _, _ = io.Copy(io.Discard, args.Get(2).(io.Reader)) }).
+		Return(nil).Once()
+	repo.On("Create", mock.MatchedBy(func(a *models.Attachment) bool {
+		return a.Filename == "report.txt" && a.SHA256 == sha256Hex("Hello, world!")
+	})).Return(nil).Once()
+
+	// Имя файла от клиента приводится к базовому, в том числе для путей Windows.
+	attachment, err := NewAttachmentContentService(svc).Upload(sessionTestContext(t, svc.authService), docID.String(), `C:\Users\clerk\report.txt`, strings.NewReader("Hello, world!"), 13)
+	require.NoError(t, err)
+	assert.Equal(t, "report.txt", attachment.Filename)
+	require.Len(t, atomicRepo.effects, 1)
+}
+
+func TestAttachmentContentServiceUploadRequiresFilename(t *testing.T) {
+	docID := uuid.New()
+	svc, _, _, _, incomingRepo, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+	incomingRepo.On("GetByID", docID).Return(&models.IncomingDocument{ID: docID, NomenclatureID: uuid.New()}, nil).Maybe()
+
+	_, err := NewAttachmentContentService(svc).Upload(sessionTestContext(t, svc.authService), docID.String(), "  ", strings.NewReader("x"), 1)
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "не указано имя файла")
+}
+
+func TestAttachmentContentServiceDownload(t *testing.T) {
+	t.Run("verified content", func(t *testing.T) {
+		svc, repo, settingsRepo, _, _, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+		attachment := integrityAttachment("report.pdf", "original", models.AttachmentIntegrityOK)
+		svc.fileStorage = &memoryObjectStorage{objects: map[string][]byte{attachment.StoragePath: []byte("original")}}
+		repo.On("GetByID", attachment.ID).Return(&attachment, nil).Once()
+		settingsRepo.On("Get", "max_file_size_mb").Return(&models.SystemSetting{Key: "max_file_size_mb", Value: "10"}, nil).Once()
+
+		var out bytes.Buffer
+		result, err := NewAttachmentContentService(svc).Download(sessionTestContext(t, svc.authService), attachment.ID.String(), &out)
+		require.NoError(t, err)
+		assert.Equal(t, "original", out.String())
+		assert.Equal(t, "report.pdf", result.Filename)
+	})
+
+	t.Run("missing attachment", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+		id := uuid.New()
+		repo.On("GetByID", id).Return(nil, nil).Once()
+
+		_, err := NewAttachmentContentService(svc).Download(sessionTestContext(t, svc.authService), id.String(), io.Discard)
+		requireAppError(t, err, "NOT_FOUND", 404, "файл не найден")
+	})
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// attachmentIntegrityInterval — период запуска фоновой проверки.
+	attachmentIntegrityInterval = time.Hour
+	// attachmentIntegrityRecheckAfter — через сколько объект проверяется повторно.
+	attachmentIntegrityRecheckAfter = 7 * 24 * time.Hour
+	attachmentIntegrityBatchSize    = 20
+	// attachmentIntegrityAuditLimit ограничивает число файлов в одной записи аудита.
+	attachmentIntegrityAuditLimit = 20
+	attachmentIntegrityAuditUser  = "Система"
+)
+
+type attachmentIntegrityStore interface {
+	ClaimIntegrityCheckBatch(checkedBefore time.Time, limit int) ([]models.Attachment, error)
+	SaveIntegrityResults(results []models.AttachmentIntegrityResult, effects []models.OutboxEvent) error
+}
+
+// attachmentDigest считает SHA-256 и объем проходящего через него потока.
+type attachmentDigest struct {
+	hash hash.Hash
+	size int64
+}
+
+func newAttachmentDigest() *attachmentDigest {
+	return &attachmentDigest{hash: sha256.New()}
+}
+
+func (d *attachmentDigest) Write(p []byte) (int, error) {
+	d.size += int64(len(p))
+	return d.hash.Write(p)
+}
+
+func (d *attachmentDigest) Sum() string {
+	return hex.EncodeToString(d.hash.Sum(nil))
+}
+
+// verifyAttachmentDigest сравнивает прочитанный объект с метаданными вложения.
+// У вложений без сохраненного хэша проверяется только размер.
+func verifyAttachmentDigest(attachment models.Attachment, digest *attachmentDigest) string {
+	switch {
+	case digest.size < attachment.FileSize:
+		return models.AttachmentIntegrityTruncated
+	case digest.size > attachment.FileSize:
+		return models.AttachmentIntegrityCorrupted
+	case attachment.SHA256 != "" && digest.Sum() != attachment.SHA256:
+		return models.AttachmentIntegrityCorrupted
+	default:
+		return models.AttachmentIntegrityOK
+	}
+}
+
+func newAttachmentIntegrityError(filename string) error {
+	return models.NewConflict(fmt.Sprintf("файл «%s» поврежден в хранилище: содержимое не совпадает с загруженным", filename))
+}
+
+// downloadVerified пишет объект вложения в writer и сверяет прочитанное с
+// размером и SHA-256 из метаданных. При расхождении вложение отмечается
+// поврежденным, а вызывающий код удаляет уже записанный файл.
+func (s *AttachmentService) downloadVerified(ctx context.Context, attachment models.Attachment, writer io.Writer, maxSize int64) error {
+	digest := newAttachmentDigest()
+	if err := s.fileStorage.DownloadFileToWriter(ctx, attachment.StoragePath, io.MultiWriter(writer, digest), maxSize); err != nil {
+		return err
+	}
+	if status := verifyAttachmentDigest(attachment, digest); status != models.AttachmentIntegrityOK {
+		s.recordIntegrityFailure(attachment, status)
+		return newAttachmentIntegrityError(attachment.Filename)
+	}
+	return nil
+}
+
+// attachmentIntegrityCheck связывает результат проверки с проверенным вложением.
+type attachmentIntegrityCheck struct {
+	attachment models.Attachment
+	result     models.AttachmentIntegrityResult
+}
+
+// attachmentIntegrityReport — итоги одного прохода фоновой проверки.
+type attachmentIntegrityReport struct {
+	Checked int
+	Flagged int
+}
+
+// RunIntegrityVerification периодически перечитывает объекты вложений и
+// сверяет их размер и SHA-256 с метаданными. Метод блокируется до отмены ctx,
+// поэтому запускается фоновым жизненным циклом приложения.
+func (s *AttachmentService) RunIntegrityVerification(ctx context.Context) {
+	ticker := time.NewTicker(attachmentIntegrityInterval)
+	defer ticker.Stop()
+	for {
+		report, err := s.verifyDueAttachments(ctx, time.Now().Add(-attachmentIntegrityRecheckAfter))
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("attachment integrity verification failed", "error", err)
+		}
+		if report.Flagged > 0 {
+			slog.Error("attachment integrity violations detected", "flagged", report.Flagged, "checked", report.Checked)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// verifyDueAttachments проверяет пачками все вложения, не проверявшиеся с
+// checkedBefore. Ошибка чтения отдельного объекта (сеть, отмена) не меняет
+// его статус и не останавливает проход по остальным.
+func (s *AttachmentService) verifyDueAttachments(ctx context.Context, checkedBefore time.Time) (attachmentIntegrityReport, error) {
+	var report attachmentIntegrityReport
+	store, ok := s.repo.(attachmentIntegrityStore)
+	if !ok {
+		return report, fmt.Errorf("attachment integrity verification is not supported")
+	}
+
+	var errs []error
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+		batch, err := store.ClaimIntegrityCheckBatch(checkedBefore, attachmentIntegrityBatchSize)
+		if err != nil {
+			return report, errors.Join(append(errs, fmt.Errorf("failed to claim attachments for integrity check: %w", err))...)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		checks := make([]attachmentIntegrityCheck, 0, len(batch))
+		for _, attachment := range batch {
+			status, sum, err := s.checkAttachmentObject(ctx, attachment)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("attachment %s: %w", attachment.ID, err))
+				continue
+			}
+			result := models.AttachmentIntegrityResult{AttachmentID: attachment.ID, Status: status}
+			if attachment.SHA256 == "" && status == models.AttachmentIntegrityOK {
+				result.SHA256 = sum
+			}
+			checks = append(checks, attachmentIntegrityCheck{attachment: attachment, result: result})
+		}
+		flagged, err := s.saveIntegrityChecks(store, checks)
+		if err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+		report.Checked += len(checks)
+		report.Flagged += flagged
+	}
+
+	if s.metrics != nil {
+		s.metrics.AddCounter("attachments.integrity.checked", float64(report.Checked))
+		s.metrics.AddCounter("attachments.integrity.flagged", float64(report.Flagged))
+	}
+	return report, errors.Join(errs...)
+}
+
+// checkAttachmentObject читает объект целиком, не сохраняя его, и возвращает
+// статус целостности и вычисленную сумму.
+func (s *AttachmentService) checkAttachmentObject(ctx context.Context, attachment models.Attachment) (string, string, error) {
+	digest := newAttachmentDigest()
+	err := s.fileStorage.DownloadFileToWriter(ctx, attachment.StoragePath, digest, attachment.FileSize)
+	switch {
+	case errors.Is(err, storage.ErrObjectNotFound):
+		return models.AttachmentIntegrityMissing, "", nil
+	case errors.Is(err, storage.ErrObjectTooLarge):
+		return models.AttachmentIntegrityCorrupted, "", nil
+	case err != nil:
+		return "", "", err
+	}
+	return verifyAttachmentDigest(attachment, digest), digest.Sum(), nil
+}
+
+// recordIntegrityFailure сохраняет повреждение, обнаруженное при скачивании.
+// Ошибка сохранения только журналируется: пользователь в любом случае получает
+// отказ в выдаче поврежденного файла.
+func (s *AttachmentService) recordIntegrityFailure(attachment models.Attachment, status string) {
+	store, ok := s.repo.(attachmentIntegrityStore)
+	if !ok {
+		return
+	}
+	check := attachmentIntegrityCheck{
+		attachment: attachment,
+		result:     models.AttachmentIntegrityResult{AttachmentID: attachment.ID, Status: status},
+	}
+	if _, err := s.saveIntegrityChecks(store, []attachmentIntegrityCheck{check}); err != nil {
+		slog.Warn("failed to record attachment integrity failure", "attachment_id", attachment.ID, "error", err)
+	}
+}
+
+// saveIntegrityChecks сохраняет результаты и в той же транзакции ставит в аудит
+// вложения, повреждение которых обнаружено впервые. Повторные проверки уже
+// отмеченного объекта не дублируют записи журнала.
+func (s *AttachmentService) saveIntegrityChecks(store attachmentIntegrityStore, checks []attachmentIntegrityCheck) (int, error) {
+	results := make([]models.AttachmentIntegrityResult, 0, len(checks))
+	var flagged []attachmentIntegrityCheck
+	for _, check := range checks {
+		results = append(results, check.result)
+		if models.IsAttachmentIntegrityFailure(check.result.Status) && !models.IsAttachmentIntegrityFailure(check.attachment.IntegrityStatus) {
+			flagged = append(flagged, check)
+		}
+	}
+
+	var effects []models.OutboxEvent
+	if len(flagged) > 0 {
+		event, err := NewAdminAuditOutboxEvent("attachments:integrity:"+uuid.NewString(), models.CreateAdminAuditLogRequest{
+			UserName: attachmentIntegrityAuditUser,
+			Action:   "ATTACHMENT_INTEGRITY_FAILED",
+			Details:  attachmentIntegrityAuditDetails(flagged),
+		})
+		if err != nil {
+			return 0, err
+		}
+		effects = append(effects, event)
+	}
+	if err := store.SaveIntegrityResults(results, effects); err != nil {
+		return 0, fmt.Errorf("failed to save attachment integrity results: %w", err)
+	}
+	return len(flagged), nil
+}
+
+func attachmentIntegrityAuditDetails(flagged []attachmentIntegrityCheck) string {
+	items := make([]string, 0, min(len(flagged), attachmentIntegrityAuditLimit))
+	for i, check := range flagged {
+		if i == attachmentIntegrityAuditLimit {
+			break
+		}
+		items = append(items, fmt.Sprintf("%s (документ %s): %s", check.attachment.Filename, check.attachment.DocumentID, attachmentIntegrityStatusLabel(check.result.Status)))
+	}
+	details := fmt.Sprintf("Нарушена целостность вложений (%d): %s", len(flagged), strings.Join(items, "; "))
+	if rest := len(flagged) - len(items); rest > 0 {
+		details += fmt.Sprintf("; и еще %d", rest)
+	}
+	return details
+}
+
+func attachmentIntegrityStatusLabel(status string) string {
+	switch status {
+	case models.AttachmentIntegrityCorrupted:
+		return "содержимое повреждено"
+	case models.AttachmentIntegrityTruncated:
+		return "файл усечен"
+	case models.AttachmentIntegrityMissing:
+		return "объект отсутствует в хранилище"
+	default:
+		return status
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type integrityAttachmentStore struct {
+	*mocks.AttachmentStore
+	batches [][]models.Attachment
+	results []models.AttachmentIntegrityResult
+	effects []models.OutboxEvent
+}
+
+func (s *integrityAttachmentStore) ClaimIntegrityCheckBatch(time.Time, int) ([]models.Attachment, error) {
+	if len(s.batches) == 0 {
+		return nil, nil
+	}
+	batch := s.batches[0]
+	s.batches = s.batches[1:]
+	return batch, nil
+}
+
+func (s *integrityAttachmentStore) SaveIntegrityResults(results []models.AttachmentIntegrityResult, effects []models.OutboxEvent) error {
+	s.results = append(s.results, results...)
+	s.effects = append(s.effects, effects...)
+	return nil
+}
+
+// memoryObjectStorage воспроизводит ошибки драйверов хранилища для отсутствующих и больших объектов.
+type memoryObjectStorage struct {
+	objects map[string][]byte
+	failing map[string]error
+}
+
+func (m *memoryObjectStorage) UploadFile(_ context.Context, objectName string, data io.Reader, _ int64, _ string) error {
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	m.objects[objectName] = content
+	return nil
+}
+
+func (m *memoryObjectStorage) DownloadFileToWriter(_ context.Context, objectName string, writer io.Writer, maxSize int64) error {
+	if err := m.failing[objectName]; err != nil {
+		return err
+	}
+	content, ok := m.objects[objectName]
+	if !ok {
+		return fmt.Errorf("failed to stat object: %w", storage.ErrObjectNotFound)
+	}
+	if int64(len(content)) > maxSize {
+		return fmt.Errorf("object size %d: %w", len(content), storage.ErrObjectTooLarge)
+	}
+	_, err := writer.Write(content)
+	return err
+}
+
+func (m *memoryObjectStorage) DeleteFile(_ context.Context, objectName string) error {
+	delete(m.objects, objectName)
+	return nil
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func integrityAttachment(name, content string, status string) models.Attachment {
+	return models.Attachment{
+		ID:              uuid.New(),
+		DocumentID:      uuid.New(),
+		Filename:        name,
+		StoragePath:     name,
+		FileSize:        int64(len(content)),
+		SHA256:          sha256Hex(content),
+		IntegrityStatus: status,
+	}
+}
+
+func TestVerifyAttachmentDigest(t *testing.T) {
+	attachment := integrityAttachment("a.pdf", "hello", models.AttachmentIntegrityUnverified)
+	legacy := attachment
+	legacy.SHA256 = ""
+
+	tests := []struct {
+		name       string
+		attachment models.Attachment
+		content    string
+		want       string
+	}{
+		{"совпадает", attachment, "hello", models.AttachmentIntegrityOK},
+		{"изменено содержимое", attachment, "hellO", models.AttachmentIntegrityCorrupted},
+		{"усечен", attachment, "hel", models.AttachmentIntegrityTruncated},
+		{"длиннее", attachment, "hello!", models.AttachmentIntegrityCorrupted},
+		{"без хэша проверяется размер", legacy, "HELLO", models.AttachmentIntegrityOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest := newAttachmentDigest()
+			_, _ = digest.Write([]byte(tt.content))
+			assert.Equal(t, tt.want, verifyAttachmentDigest(tt.attachment, digest))
+		})
+	}
+}
+
+func TestAttachmentService_VerifyDueAttachments(t *testing.T) {
+	healthy := integrityAttachment("healthy.pdf", "hello", models.AttachmentIntegrityOK)
+	corrupted := integrityAttachment("corrupted.pdf", "hello", models.AttachmentIntegrityOK)
+	truncated := integrityAttachment("truncated.pdf", "hello", models.AttachmentIntegrityUnverified)
+	missing := integrityAttachment("missing.pdf", "hello", models.AttachmentIntegrityUnverified)
+	oversized := integrityAttachment("oversized.pdf", "hello", models.AttachmentIntegrityUnverified)
+	alreadyFlagged := integrityAttachment("flagged.pdf", "hello", models.AttachmentIntegrityCorrupted)
+	legacy := integrityAttachment("legacy.pdf", "legacy", models.AttachmentIntegrityUnverified)
+	legacy.SHA256 = ""
+	unreachable := integrityAttachment("unreachable.pdf", "hello", models.AttachmentIntegrityOK)
+
+	files := &memoryObjectStorage{
+		objects: map[string][]byte{
+			healthy.StoragePath:        []byte("hello"),
+			corrupted.StoragePath:      []byte("jello"),
+			truncated.StoragePath:      []byte("hel"),
+			oversized.StoragePath:      []byte("hello world"),
+			alreadyFlagged.StoragePath: []byte("jello"),
+			legacy.StoragePath:         []byte("legacy"),
+		},
+		failing: map[string]error{unreachable.StoragePath: errors.New("connection reset")},
+	}
+	store := &integrityAttachmentStore{
+		AttachmentStore: mocks.NewAttachmentStore(t),
+		batches: [][]models.Attachment{
+			{healthy, corrupted, truncated, missing},
+			{oversized, alreadyFlagged, legacy, unreachable},
+		},
+	}
+	svc := NewAttachmentService(store, nil, nil, files, nil)
+
+	report, err := svc.verifyDueAttachments(context.Background(), time.Now())
+	require.ErrorContains(t, err, "connection reset")
+	assert.Equal(t, 7, report.Checked)
+	assert.Equal(t, 4, report.Flagged)
+
+	statuses := make(map[uuid.UUID]models.AttachmentIntegrityResult, len(store.results))
+	for _, result := range store.results {
+		statuses[result.AttachmentID] = result
+	}
+	assert.Equal(t, models.AttachmentIntegrityOK, statuses[healthy.ID].Status)
+	assert.Equal(t, models.AttachmentIntegrityCorrupted, statuses[corrupted.ID].Status)
+	assert.Equal(t, models.AttachmentIntegrityTruncated, statuses[truncated.ID].Status)
+	assert.Equal(t, models.AttachmentIntegrityMissing, statuses[missing.ID].Status)
+	assert.Equal(t, models.AttachmentIntegrityCorrupted, statuses[oversized.ID].Status)
+	assert.Equal(t, models.AttachmentIntegrityCorrupted, statuses[alreadyFlagged.ID].Status)
+	assert.Equal(t, models.AttachmentIntegrityOK, statuses[legacy.ID].Status)
+	assert.Equal(t, sha256Hex("legacy"), statuses[legacy.ID].SHA256, "хэш старого вложения дописывается")
+	assert.Empty(t, statuses[healthy.ID].SHA256)
+	assert.NotContains(t, statuses, unreachable.ID, "ошибка чтения не меняет статус")
+
+	// Уже отмеченное вложение не попадает в аудит повторно.
+	require.Len(t, store.effects, 2)
+	var audit models.CreateAdminAuditLogRequest
+	require.NoError(t, json.Unmarshal([]byte(store.effects[0].Payload), &audit))
+	assert.Equal(t, models.OutboxEventAudit, store.effects[0].EventType)
+	assert.Equal(t, "ATTACHMENT_INTEGRITY_FAILED", audit.Action)
+	assert.Equal(t, uuid.Nil, audit.UserID)
+	assert.Contains(t, audit.Details, "Нарушена целостность вложений (3)")
+	assert.Contains(t, audit.Details, "truncated.pdf (документ "+truncated.DocumentID.String()+"): файл усечен")
+	require.NoError(t, json.Unmarshal([]byte(store.effects[1].Payload), &audit))
+	assert.Contains(t, audit.Details, "Нарушена целостность вложений (1): oversized.pdf")
+	assert.NotContains(t, audit.Details, "flagged.pdf")
+}
+
+func TestAttachmentIntegrityAuditDetailsLimit(t *testing.T) {
+	flagged := make([]attachmentIntegrityCheck, attachmentIntegrityAuditLimit+3)
+	for i := range flagged {
+		flagged[i] = attachmentIntegrityCheck{
+			attachment: models.Attachment{Filename: fmt.Sprintf("f%d.pdf", i)},
+			result:     models.AttachmentIntegrityResult{Status: models.AttachmentIntegrityMissing},
+		}
+	}
+
+	details := attachmentIntegrityAuditDetails(flagged)
+	assert.Contains(t, details, fmt.Sprintf("(%d)", len(flagged)))
+	assert.Contains(t, details, "; и еще 3")
+	assert.NotContains(t, details, fmt.Sprintf("f%d.pdf", attachmentIntegrityAuditLimit))
+}
+
+func TestAttachmentService_DownloadVerified(t *testing.T) {
+	attachment := integrityAttachment("report.pdf", "original", models.AttachmentIntegrityOK)
+
+	t.Run("совпадающий объект", func(t *testing.T) {
+		files := &memoryObjectStorage{objects: map[string][]byte{attachment.StoragePath: []byte("original")}}
+		store := &integrityAttachmentStore{AttachmentStore: mocks.NewAttachmentStore(t)}
+		svc := NewAttachmentService(store, nil, nil, files, nil)
+
+		var out bytes.Buffer
+		require.NoError(t, svc.downloadVerified(context.Background(), attachment, &out, 1024))
+		assert.Equal(t, "original", out.String())
+		assert.Empty(t, store.results)
+	})
+
+	t.Run("поврежденный объект", func(t *testing.T) {
+		files := &memoryObjectStorage{objects: map[string][]byte{attachment.StoragePath: []byte("0riginal")}}
+		store := &integrityAttachmentStore{AttachmentStore: mocks.NewAttachmentStore(t)}
+		svc := NewAttachmentService(store, nil, nil, files, nil)
+
+		err := svc.downloadVerified(context.Background(), attachment, &bytes.Buffer{}, 1024)
+		requireAppError(t, err, "CONFLICT", 409, "файл «report.pdf» поврежден в хранилище")
+		require.Len(t, store.results, 1)
+		assert.Equal(t, models.AttachmentIntegrityCorrupted, store.results[0].Status)
+		require.Len(t, store.effects, 1)
+		assert.Equal(t, models.OutboxEventAudit, store.effects[0].EventType)
+	})
+}
@@ -2,12 +2,16 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -301,16 +305,63 @@ func TestAttachmentServiceUploadPathStreamsSelectedFile(t *testing.T) {
 	incomingRepo.On("GetByID", docID).Return(&models.IncomingDocument{ID: docID, NomenclatureID: uuid.New()}, nil).Maybe()
 	settingsRepo.On("Get", "max_file_size_mb").Return(&models.SystemSetting{Key: "max_file_size_mb", Value: "10"}, nil).Once()
 	settingsRepo.On("Get", "allowed_file_types").Return(&models.SystemSetting{Key: "allowed_file_types", Value: ".txt"}, nil).Once()
-	storage.On("UploadFile", mock.Anything, mock.AnythingOfType("string"), mock.Anything, int64(13), "text/plain; charset=utf-8").Return(nil).Once()
-	repo.On("Create", mock.AnythingOfType("*models.Attachment")).Return(nil).Once()
-
-	attachment, err := svc.uploadPath(docID.String(), path)
+	storage.On("UploadFile", mock.Anything, mock.AnythingOfType("string"), mock.Anything, int64(13), "text/plain; charset=utf-8").
+		Run(func(args mock.Arguments) { _, _ = io.Copy(io.Discard, args.Get(2).(io.Reader)) }).
+		Return(nil).Once()
+	repo.On("Create", mock.MatchedBy(func(a *models.Attachment) bool {
+		return a.SHA256 == sha256Hex("Hello, world!")
+	})).Return(nil).Once()
+
+	attachment, err := svc.uploadPath(sessionTestContext(t, svc.authService), docID.String(), path)
 	require.NoError(t, err)
 	assert.Equal(t, "test.txt", attachment.Filename)
+	assert.Equal(t, sha256Hex("Hello, world!"), attachment.SHA256)
 	require.Len(t, atomicRepo.effects, 1)
 	assert.Equal(t, models.OutboxEventJournal, atomicRepo.effects[0].EventType)
 }
 
+func TestAttachmentServiceUploadPathRejectsDuplicateContent(t *testing.T) {
+	// Уникальный индекс по SHA-256 отклоняет повтор, загруженный объект удаляется.
+	docID := uuid.New()
+	path := filepath.Join(t.TempDir(), "copy.txt")
+	require.NoError(t, os.WriteFile(path, []byte("Hello, world!"), 0600))
+	svc, repo, settingsRepo, storage, incomingRepo, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+	svc.repo = &atomicAttachmentStore{AttachmentStore: repo}
+	incomingRepo.On("GetByID", docID).Return(&models.IncomingDocument{ID: docID, NomenclatureID: uuid.New()}, nil).Maybe()
+	settingsRepo.On("Get", "max_file_size_mb").Return(&models.SystemSetting{Key: "max_file_size_mb", Value: "10"}, nil).Once()
+	settingsRepo.On("Get", "allowed_file_types").Return(&models.SystemSetting{Key: "allowed_file_types", Value: ".txt"}, nil).Once()
+	var objectName string
+	storage.On("UploadFile", mock.Anything, mock.AnythingOfType("string"), mock.Anything, int64(13), mock.Anything).
+		Run(func(args mock.Arguments) {
+			objectName = args.String(1)
+			_, _ = io.Copy(io.Discard, args.Get(2).(io.Reader))
+		}).
+		Return(nil).Once()
+	repo.On("Create", mock.AnythingOfType("*models.Attachment")).
+		Return(models.NewConflict("файл с таким же содержимым уже прикреплен к документу")).Once()
+	storage.On("DeleteFile", mock.Anything, mock.MatchedBy(func(name string) bool { return name == objectName })).Return(nil).Once()
+
+	attachment, err := svc.uploadPath(sessionTestContext(t, svc.authService), docID.String(), path)
+	requireAppError(t, err, "CONFLICT", 409, "уже прикреплен")
+	assert.Nil(t, attachment)
+}
+
+func TestAttachmentServiceUploadPathRequiresStorageToReadWholeFile(t *testing.T) {
+	docID := uuid.New()
+	path := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(path, []byte("Hello, world!"), 0600))
+	svc, repo, settingsRepo, storage, incomingRepo, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+	svc.repo = &atomicAttachmentStore{AttachmentStore: repo}
+	incomingRepo.On("GetByID", docID).Return(&models.IncomingDocument{ID: docID, NomenclatureID: uuid.New()}, nil).Maybe()
+	settingsRepo.On("Get", "max_file_size_mb").Return(&models.SystemSetting{Key: "max_file_size_mb", Value: "10"}, nil).Once()
+	settingsRepo.On("Get", "allowed_file_types").Return(&models.SystemSetting{Key: "allowed_file_types", Value: ".txt"}, nil).Once()
+	storage.On("UploadFile", mock.Anything, mock.AnythingOfType("string"), mock.Anything, int64(13), mock.Anything).Return(nil).Once()
+	storage.On("DeleteFile", mock.Anything, mock.AnythingOfType("string")).Return(nil).Once()
+
+	_, err := svc.uploadPath(sessionTestContext(t, svc.authService), docID.String(), path)
+	require.EqualError(t, err, "failed to upload file to storage: 0 of 13 bytes were read")
+}
+
 func TestAttachmentService_GetList(t *testing.T) {
 	// Получение списка всех вложений для заданного документа
 	docID := uuid.New()
@@ -334,6 +385,73 @@ func TestAttachmentService_GetList(t *testing.T) {
 	})
 }
 
+type attachmentTextStoreStub struct {
+	text *models.AttachmentText
+	err  error
+}
+
+func (s *attachmentTextStoreStub) Get(uuid.UUID) (*models.AttachmentText, error) {
+	return s.text, s.err
+}
+
+func TestAttachmentService_GetTextPreview(t *testing.T) {
+	// Предпросмотр текста, извлеченного из вложения
+	attID := uuid.New()
+
+	t.Run("extracted text is capped for the card", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+		svc.SetTextStore(&attachmentTextStoreStub{text: &models.AttachmentText{AttachmentID: attID, Status: models.AttachmentTextExtracted, Content: strings.Repeat("я", attachmentTextPreviewRunes+5)}})
+		repo.On("GetByID", attID).Return(&models.Attachment{ID: attID, DocumentID: uuid.New()}, nil).Once()
+
+		result, err := svc.GetTextPreview(attID.String())
+		require.NoError(t, err)
+		assert.Equal(t, models.AttachmentTextExtracted, result.Status)
+		assert.Equal(t, attachmentTextPreviewRunes, utf8.RuneCountInString(result.Text))
+		assert.True(t, result.Truncated)
+	})
+
+	t.Run("pending before extraction", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+		svc.SetTextStore(&attachmentTextStoreStub{})
+		repo.On("GetByID", attID).Return(&models.Attachment{ID: attID, DocumentID: uuid.New()}, nil).Once()
+
+		result, err := svc.GetTextPreview(attID.String())
+		require.NoError(t, err)
+		assert.Equal(t, models.AttachmentTextPending, result.Status)
+		assert.Empty(t, result.Text)
+	})
+
+	t.Run("failed extraction exposes error", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+		failure := "invalid pdf file"
+		svc.SetTextStore(&attachmentTextStoreStub{text: &models.AttachmentText{AttachmentID: attID, Status: models.AttachmentTextFailed, Error: &failure}})
+		repo.On("GetByID", attID).Return(&models.Attachment{ID: attID, DocumentID: uuid.New()}, nil).Once()
+
+		result, err := svc.GetTextPreview(attID.String())
+		require.NoError(t, err)
+		assert.Equal(t, failure, result.Error)
+	})
+
+	t.Run("deleted attachment", func(t *testing.T) {
+		svc, repo, _, _, _, _, _, _, _, _, _ := setupAttachmentService(t, "clerk")
+		svc.SetTextStore(&attachmentTextStoreStub{})
+		repo.On("GetByID", attID).Return(nil, sql.ErrNoRows).Once()
+
+		result, err := svc.GetTextPreview(attID.String())
+		requireAppError(t, err, "NOT_FOUND", 404, "файл не найден")
+		assert.Nil(t, result)
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		svc, _, _, _, _, _, _, _, _, _, _ := setupAttachmentService(t, "executor")
+		svc.SetTextStore(&attachmentTextStoreStub{})
+
+		result, err := svc.GetTextPreview("not-a-uuid")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный ID файла")
+		assert.Nil(t, result)
+	})
+}
+
 func TestWriteDownloadFileWithoutOverwrite(t *testing.T) {
 	downloadDir := t.TempDir()
 	originalPath := filepath.Join(downloadDir, "report.pdf")
@@ -382,7 +500,7 @@ func TestAttachmentService_Delete(t *testing.T) {
 	})
 
 	t.Run("executor can delete with upload access", func(t *testing.T) {
-		svc, repo, _, _, _, _, _, _, _, _, _ := setupAttachmentService(t, "executor")
+		svc, repo, _, _, _, _, depRepo, _, _, _, _ := setupAttachmentService(t, "executor")
 		atomicRepo := &atomicAttachmentStore{AttachmentStore: repo}
 		svc.repo = atomicRepo
 		att := &models.Attachment{
@@ -392,6 +510,7 @@ func TestAttachmentService_Delete(t *testing.T) {
 		}
 		repo.On("GetByID", attID).Return(att, nil).Once()
 		repo.On("MarkDeleting", attID).Return(nil).Once()
+		depRepo.On("GetVisibleNomenclatureIDs", (*uuid.UUID)(nil), mock.Anything).Return(nil, nil).Once()
 		err := svc.Delete(attID.String())
 		require.NoError(t, err)
 	})
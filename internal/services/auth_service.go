@@ -1,9 +1,12 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,28 +29,46 @@ var (
 	ErrPasswordChangeRequired = models.ErrPasswordChangeRequired
 	ErrNotAuthenticated       = models.ErrUnauthorized
 	ErrWrongPassword          = models.ErrWrongPassword
+
+	errDirectoryPasswordChange = models.NewConflict("пароль учетной записи каталога меняется средствами каталога")
 )
 
 // AuthService предоставляет бизнес-логику для аутентификации и авторизации пользователей.
 type AuthService struct {
-	db              *database.DB
-	userRepo        UserStore
-	settingsRepo    SettingsStore
-	accessRepo      DocumentAccessStore
-	currentUserID   uuid.UUID
-	mu              sync.RWMutex
-	metrics         *observability.Registry
-	schemaLifecycle SchemaLifecycle
+	db               *database.DB
+	userRepo         UserStore
+	settingsRepo     SettingsStore
+	accessRepo       DocumentAccessStore
+	substitutionRepo UserSubstitutionStore
+	sessionRepo      UserSessionStore
+	currentUserID    uuid.UUID
+	session          desktopSession
+	hostName         string
+	now              func() time.Time
+	mu               sync.RWMutex
+	metrics          *observability.Registry
+	schemaLifecycle  SchemaLifecycle
+	authenticator    Authenticator
+	twoFactorRepo    TwoFactorStore
+	// pending — вход, ожидающий второго фактора.
+	pending *pendingLogin
+	// localLoginAdminsOnly оставляет локальный вход при подключенном
+	// authenticator только администраторам (break-glass).
+	localLoginAdminsOnly bool
 }
 type userLockOutboxStore interface {
-	IncrementFailedLoginAttemptsWithOutbox(uuid.UUID, models.OutboxEvent) (int, bool, error)
+	IncrementFailedLoginAttemptsWithOutbox(uuid.UUID, int, models.OutboxEvent) (int, bool, error)
+	ReleaseExpiredLockoutWithOutbox(uuid.UUID, time.Duration, models.OutboxEvent) (bool, error)
 }
 
 // NewAuthService создает новый экземпляр AuthService.
 func NewAuthService(db *database.DB, userRepo UserStore) *AuthService {
+	hostName, _ := os.Hostname()
 	return &AuthService{
 		db:       db,
 		userRepo: userRepo,
+		hostName: hostName,
+		now:      time.Now,
 	}
 }
 
@@ -56,6 +77,18 @@ func (s *AuthService) SetAccessStore(accessRepo DocumentAccessStore) {
 	s.accessRepo = accessRepo
 }
 
+// SetSubstitutionStore подключает источник активных замещений для principal.
+func (s *AuthService) SetSubstitutionStore(substitutionRepo UserSubstitutionStore) {
+	s.substitutionRepo = substitutionRepo
+}
+
+// SetAuthenticator подключает внешний способ входа. При localLoginAdminsOnly
+// локальные учетные записи без права admin входить не могут.
+func (s *AuthService) SetAuthenticator(authenticator Authenticator, localLoginAdminsOnly bool) {
+	s.authenticator = authenticator
+	s.localLoginAdminsOnly = localLoginAdminsOnly
+}
+
 // SetSettingsStore подключает источник системных настроек.
 func (s *AuthService) SetSettingsStore(settingsRepo SettingsStore) {
 	s.settingsRepo = settingsRepo
@@ -75,54 +108,151 @@ func isTableNotExistsError(err error) bool {
 // Login — вход пользователя (Wails binding)
 func (s *AuthService) Login(login, password string) (*dto.User, error) {
 	return measureOperation(s.metrics, "auth.login", func() (*dto.User, error) {
-		if err := s.ensureCompatibleSchema(); err != nil {
+		user, factor, err := s.authenticate(login, password)
+		if err != nil {
 			return nil, err
 		}
-
-		user, err := s.userRepo.GetByLogin(login)
-		if err != nil {
+		switch factor {
+		case secondFactorVerify:
+			s.beginPendingLogin(user.ID, factor)
+			return nil, models.ErrTwoFactorRequired
+		case secondFactorEnroll:
+			s.beginPendingLogin(user.ID, factor)
+			return nil, models.ErrTwoFactorEnrollment
+		}
+		if err := s.openSession(user.ID); err != nil {
 			return nil, err
 		}
+		return dto.MapUser(user), nil
+	})
+}
 
-		if user == nil {
-			return nil, ErrInvalidCredentials
+// AuthenticateCredentials проверяет логин и пароль по тем же правилам, что и
+// Login (блокировка, смена пароля, второй фактор), но не открывает сессию в
+// auth. Используется HTTP API, где сессия — это выданный токен, а не
+// состояние сервиса; код второго фактора передается вместе с паролем в
+// oneTimeCode. Подключить второй фактор через HTTP API нельзя.
+func AuthenticateCredentials(auth *AuthService, login, password, oneTimeCode string) (*dto.User, error) {
+	return measureOperation(auth.metrics, "auth.authenticate", func() (*dto.User, error) {
+		user, factor, err := auth.authenticate(login, password)
+		if err != nil {
+			return nil, err
 		}
-
-		if !security.VerifyPassword(user.PasswordHash, password) {
-			_, isActive, err := s.incrementFailedLoginAttempts(user)
-			if err != nil {
-				return nil, err
+		switch factor {
+		case secondFactorEnroll:
+			return nil, models.ErrTwoFactorEnrollment
+		case secondFactorVerify:
+			if strings.TrimSpace(oneTimeCode) == "" {
+				return nil, models.ErrTwoFactorRequired
 			}
-			if !isActive {
-				return nil, ErrUserLocked
+			if err := auth.verifySecondFactor(user, oneTimeCode); err != nil {
+				return nil, err
 			}
-			return nil, ErrInvalidCredentials
 		}
+		return dto.MapUser(user), nil
+	})
+}
 
-		if !user.IsActive {
-			if user.FailedLoginAttempts >= 5 {
-				return nil, ErrUserLocked
-			}
-			return nil, ErrUserNotActive
+// authenticate проверяет пароль и возвращает пользователя вместе с тем, что
+// требуется от него после пароля.
+func (s *AuthService) authenticate(login, password string) (*models.User, secondFactor, error) {
+	if err := s.ensureCompatibleSchema(); err != nil {
+		return nil, secondFactorNone, err
+	}
+
+	user, err := s.userRepo.GetByLogin(login)
+	if err != nil {
+		return nil, secondFactorNone, err
+	}
+
+	if s.authenticator != nil && (user == nil || user.AuthSource == s.authenticator.AuthSource()) {
+		user, err := s.authenticateExternal(login, password)
+		if err != nil {
+			return nil, secondFactorNone, err
+		}
+		factor, err := s.secondFactorFor(user)
+		if err != nil {
+			return nil, secondFactorNone, err
 		}
+		return user, factor, nil
+	}
+	if user == nil {
+		return nil, secondFactorNone, ErrInvalidCredentials
+	}
+	if user.IsDirectoryUser() {
+		// Каталог пользователя отключен в конфигурации: локального пароля у него нет.
+		return nil, secondFactorNone, models.ErrDirectoryUnavailable
+	}
 
-		if user.FailedLoginAttempts > 0 {
-			if err := s.userRepo.ResetFailedLoginAttempts(user.ID); err != nil {
-				return nil, err
-			}
-			user.FailedLoginAttempts = 0
+	policy := s.passwordPolicy()
+	if err := s.releaseExpiredLockout(user, policy); err != nil {
+		return nil, secondFactorNone, err
+	}
+
+	if !security.VerifyPassword(user.PasswordHash, password) {
+		_, isActive, err := s.incrementFailedLoginAttempts(user, policy)
+		if err != nil {
+			return nil, secondFactorNone, err
+		}
+		if !isActive {
+			return nil, secondFactorNone, ErrUserLocked
 		}
+		return nil, secondFactorNone, ErrInvalidCredentials
+	}
 
-		if s.isPasswordChangeRequired(user) {
-			return nil, ErrPasswordChangeRequired
+	if !user.IsActive {
+		if isLockedOut(user, policy) {
+			return nil, secondFactorNone, ErrUserLocked
 		}
+		return nil, secondFactorNone, ErrUserNotActive
+	}
 
-		s.mu.Lock()
-		s.currentUserID = user.ID
-		s.mu.Unlock()
+	if s.authenticator != nil && s.localLoginAdminsOnly && !slices.Contains(user.SystemPermissions, models.SystemPermissionAdmin) {
+		return nil, secondFactorNone, models.NewForbidden("локальный вход разрешен только администраторам; войдите с учетной записью каталога")
+	}
 
-		return dto.MapUser(user), nil
-	})
+	factor, err := s.secondFactorFor(user)
+	if err != nil {
+		return nil, secondFactorNone, err
+	}
+	// При подключенном втором факторе счетчик сбрасывает только верный код:
+	// иначе повторный ввод пароля обнулял бы неверные коды.
+	if user.FailedLoginAttempts > 0 && factor != secondFactorVerify {
+		if err := s.userRepo.ResetFailedLoginAttempts(user.ID); err != nil {
+			return nil, secondFactorNone, err
+		}
+		user.FailedLoginAttempts = 0
+	}
+
+	if s.isPasswordChangeRequired(user) {
+		return nil, secondFactorNone, ErrPasswordChangeRequired
+	}
+	return user, factor, nil
+}
+
+// authenticateExternal проверяет пароль во внешнем источнике. Блокировку
+// после неверных паролей и срок действия пароля для таких учетных записей
+// ведет сам источник; блокировка после неверных кодов второго фактора
+// снимается по тем же правилам, что и у локальных учетных записей.
+func (s *AuthService) authenticateExternal(login, password string) (*models.User, error) {
+	user, err := s.authenticator.Authenticate(context.Background(), login, password)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		policy := s.passwordPolicy()
+		if err := s.releaseExpiredLockout(user, policy); err != nil {
+			return nil, err
+		}
+		if user.IsActive {
+			return user, nil
+		}
+		if isLockedOut(user, policy) {
+			return nil, ErrUserLocked
+		}
+		return nil, ErrUserNotActive
+	}
+	return user, nil
 }
 
 func (s *AuthService) isPasswordChangeRequired(user *models.User) bool {
@@ -150,7 +280,7 @@ func (s *AuthService) getPasswordLifetimeDays() int {
 		return 0
 	}
 
-	setting, err := s.settingsRepo.Get("password_lifetime_days")
+	setting, err := s.settingsRepo.Get(models.SettingPasswordLifetimeDays)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0
@@ -178,12 +308,10 @@ func (s *AuthService) ensureCompatibleSchema() error {
 	return nil
 }
 
-// Logout — выход
+// Logout — выход. Работает и для заблокированного сеанса.
 func (s *AuthService) Logout() error {
 	return measureOperationError(s.metrics, "auth.logout", func() error {
-		s.mu.Lock()
-		s.currentUserID = uuid.Nil
-		s.mu.Unlock()
+		s.closeSession(models.SessionEndLogout)
 		return nil
 	})
 }
@@ -228,12 +356,26 @@ func (s *AuthService) checkSchemaReady() error {
 }
 
 func (s *AuthService) getActiveSessionPrincipal() (*models.SessionPrincipal, error) {
-	s.mu.RLock()
-	userID := s.currentUserID
-	s.mu.RUnlock()
+	return s.sessionPrincipal(true)
+}
 
-	if userID == uuid.Nil {
-		return nil, ErrNotAuthenticated
+// pollCurrentUserUUID работает как GetCurrentUserUUID, но не считается
+// действием пользователя: периодический опрос интерфейса не продлевает сеанс.
+func (s *AuthService) pollCurrentUserUUID() (uuid.UUID, error) {
+	if err := s.checkSchemaReady(); err != nil {
+		return uuid.Nil, err
+	}
+	principal, err := s.sessionPrincipal(false)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return principal.ID, nil
+}
+
+func (s *AuthService) sessionPrincipal(activity bool) (*models.SessionPrincipal, error) {
+	userID, err := s.sessionUserID(activity)
+	if err != nil {
+		return nil, err
 	}
 
 	principal, err := s.userRepo.GetSessionPrincipal(userID)
@@ -241,11 +383,7 @@ func (s *AuthService) getActiveSessionPrincipal() (*models.SessionPrincipal, err
 		return nil, err
 	}
 	if principal == nil || !principal.IsActive {
-		s.mu.Lock()
-		if s.currentUserID == userID {
-			s.currentUserID = uuid.Nil
-		}
-		s.mu.Unlock()
+		s.dropSession(userID, models.SessionEndUserInactive)
 		return nil, ErrNotAuthenticated
 	}
 
@@ -253,12 +391,9 @@ func (s *AuthService) getActiveSessionPrincipal() (*models.SessionPrincipal, err
 }
 
 func (s *AuthService) getActiveCurrentUser() (*models.User, error) {
-	s.mu.RLock()
-	userID := s.currentUserID
-	s.mu.RUnlock()
-
-	if userID == uuid.Nil {
-		return nil, ErrNotAuthenticated
+	userID, err := s.sessionUserID(true)
+	if err != nil {
+		return nil, err
 	}
 
 	user, err := s.userRepo.GetByID(userID)
@@ -266,11 +401,7 @@ func (s *AuthService) getActiveCurrentUser() (*models.User, error) {
 		return nil, err
 	}
 	if user == nil || !user.IsActive {
-		s.mu.Lock()
-		if s.currentUserID == userID {
-			s.currentUserID = uuid.Nil
-		}
-		s.mu.Unlock()
+		s.dropSession(userID, models.SessionEndUserInactive)
 		return nil, ErrNotAuthenticated
 	}
 
@@ -293,12 +424,16 @@ func (s *AuthService) ChangePassword(oldPassword, newPassword string) error {
 			return ErrNotAuthenticated
 		}
 
+		if dbUser.IsDirectoryUser() {
+			return errDirectoryPasswordChange
+		}
+
 		if !security.VerifyPassword(dbUser.PasswordHash, oldPassword) {
 			return ErrWrongPassword
 		}
 
-		if err := security.ValidatePassword(newPassword); err != nil {
-			return wrapPasswordPolicyError(err)
+		if err := s.validateNewPassword(dbUser, newPassword, s.passwordPolicy()); err != nil {
+			return err
 		}
 
 		newHash, err := security.HashPassword(newPassword)
@@ -306,7 +441,10 @@ func (s *AuthService) ChangePassword(oldPassword, newPassword string) error {
 			return err
 		}
 
-		return s.userRepo.UpdatePassword(userID, newHash)
+		if err := s.userRepo.UpdatePassword(userID, newHash); err != nil {
+			return err
+		}
+		return nil
 	})
 }
 
@@ -320,15 +458,22 @@ func (s *AuthService) ChangeRequiredPassword(login, oldPassword, newPassword str
 		if user == nil {
 			return ErrInvalidCredentials
 		}
+		if user.IsDirectoryUser() {
+			return errDirectoryPasswordChange
+		}
+		policy := s.passwordPolicy()
+		if err := s.releaseExpiredLockout(user, policy); err != nil {
+			return err
+		}
 		if !user.IsActive {
-			if user.FailedLoginAttempts >= 5 {
+			if isLockedOut(user, policy) {
 				return ErrUserLocked
 			}
 			return ErrUserNotActive
 		}
 
 		if !security.VerifyPassword(user.PasswordHash, oldPassword) {
-			_, isActive, err := s.incrementFailedLoginAttempts(user)
+			_, isActive, err := s.incrementFailedLoginAttempts(user, policy)
 			if err != nil {
 				return err
 			}
@@ -341,8 +486,8 @@ func (s *AuthService) ChangeRequiredPassword(login, oldPassword, newPassword str
 		if !s.isPasswordChangeRequired(user) {
 			return models.NewConflict("смена пароля сейчас не требуется")
 		}
-		if err := security.ValidatePassword(newPassword); err != nil {
-			return wrapPasswordPolicyError(err)
+		if err := s.validateNewPassword(user, newPassword, policy); err != nil {
+			return err
 		}
 
 		newHash, err := security.HashPassword(newPassword)
@@ -350,11 +495,14 @@ func (s *AuthService) ChangeRequiredPassword(login, oldPassword, newPassword str
 			return err
 		}
 
-		return s.userRepo.UpdatePassword(user.ID, newHash)
+		if err := s.userRepo.UpdatePassword(user.ID, newHash); err != nil {
+			return err
+		}
+		return nil
 	})
 }
 
-func (s *AuthService) incrementFailedLoginAttempts(user *models.User) (int, bool, error) {
+func (s *AuthService) incrementFailedLoginAttempts(user *models.User, policy security.PasswordPolicy) (int, bool, error) {
 	store, ok := s.userRepo.(userLockOutboxStore)
 	if !ok {
 		return 0, false, fmt.Errorf("user store must support atomic lock outbox operation")
@@ -363,11 +511,11 @@ func (s *AuthService) incrementFailedLoginAttempts(user *models.User) (int, bool
 	if name == "" {
 		name = user.Login
 	}
-	event, err := NewAdminAuditOutboxEvent("user:"+user.ID.String()+":locked:"+uuid.NewString(), models.CreateAdminAuditLogRequest{UserID: user.ID, UserName: name, Action: "USER_LOCKED", Details: fmt.Sprintf("Пользователь «%s» (%s) автоматически заблокирован после 5 неверных попыток входа", name, user.Login)})
+	event, err := NewAdminAuditOutboxEvent("user:"+user.ID.String()+":locked:"+uuid.NewString(), models.CreateAdminAuditLogRequest{UserID: user.ID, UserName: name, Action: "USER_LOCKED", Details: fmt.Sprintf("Пользователь «%s» (%s) автоматически заблокирован после %d неверных попыток входа", name, user.Login, policy.LockoutThreshold)})
 	if err != nil {
 		return 0, false, err
 	}
-	return store.IncrementFailedLoginAttemptsWithOutbox(user.ID, event)
+	return store.IncrementFailedLoginAttemptsWithOutbox(user.ID, policy.LockoutThreshold, event)
 }
 
 // UpdateProfile — обновление профиля текущего пользователя
@@ -522,7 +670,7 @@ func (s *AuthService) InitialSetup(password string) error {
 		}
 	}
 
-	if err := security.ValidatePassword(password); err != nil {
+	if err := s.passwordPolicy().Validate(password); err != nil {
 		return wrapPasswordPolicyError(err)
 	}
 
@@ -109,7 +109,7 @@ func TestAuthService_Login(t *testing.T) {
 
 	t.Run("wrong password", func(t *testing.T) {
 		mockRepo.On("GetByLogin", login).Return(activeUser, nil).Once()
-		mockRepo.On("IncrementFailedLoginAttempts", userID).Return(1, true, nil).Once()
+		mockRepo.On("IncrementFailedLoginAttempts", userID, 5).Return(1, true, nil).Once()
 
 		userDTO, err := authService.Login(login, "WrongPass1!")
 
@@ -130,7 +130,7 @@ func TestAuthService_Login(t *testing.T) {
 			FailedLoginAttempts: 4,
 		}
 		mockRepo.On("GetByLogin", login).Return(userAtLimit, nil).Once()
-		mockRepo.On("IncrementFailedLoginAttempts", userID).Return(5, false, nil).Once()
+		mockRepo.On("IncrementFailedLoginAttempts", userID, 5).Return(5, false, nil).Once()
 
 		userDTO, err := authService.Login(login, "WrongPass1!")
 
@@ -218,6 +218,7 @@ func TestAuthService_Login(t *testing.T) {
 
 		mockRepo.On("GetByLogin", login).Return(&userWithExpiredPassword, nil).Once()
 		settingsRepo.On("Get", "password_lifetime_days").Return(&models.SystemSetting{Key: "password_lifetime_days", Value: "1"}, nil).Once()
+		settingsRepo.On("Get", mock.Anything).Return(nil, assert.AnError).Maybe()
 
 		userDTO, err := authWithSettings.Login(login, password)
 
@@ -250,7 +251,7 @@ func TestAuthService_ChangeRequiredPassword(t *testing.T) {
 		mockRepo := mocks.NewUserStore(t)
 		authService := NewAuthService(nil, mockRepo)
 		mockRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
-		mockRepo.On("IncrementFailedLoginAttempts", user.ID).Return(1, true, nil).Once()
+		mockRepo.On("IncrementFailedLoginAttempts", user.ID, 5).Return(1, true, nil).Once()
 
 		err := authService.ChangeRequiredPassword(user.Login, "WrongPassw0rd!", newPassword)
 
@@ -387,6 +388,18 @@ func TestAuthService_SessionPrincipalChecks(t *testing.T) {
 	})
 }
 
+func TestAuthenticateCredentialsDoesNotOpenSession(t *testing.T) {
+	user, password := newTestUser()
+	mockRepo := mocks.NewUserStore(t)
+	authService := NewAuthService(nil, mockRepo)
+	mockRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+
+	result, err := AuthenticateCredentials(authService, user.Login, password, "")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), result.ID)
+	assert.False(t, authService.IsAuthenticated())
+}
+
 // ---------- TestAuthService_ChangePassword ----------
 
 func TestAuthService_ChangePassword(t *testing.T) {
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -79,12 +80,12 @@ type CitizenAppealResolutionRequest struct {
 
 // CitizenAppealCommandHandler инкапсулирует write-операции по обращениям граждан.
 type CitizenAppealCommandHandler struct {
-	repo    CitizenAppealDocStore
-	nomRepo NomenclatureStore
-	refRepo ReferenceStore
-	auth    *AuthService
-	journal *JournalService
-	access  *DocumentAccessService
+	repo      CitizenAppealDocStore
+	nomRepo   NomenclatureStore
+	refRepo   ReferenceStore
+	journal   *JournalService
+	access    *DocumentAccessService
+	deadlines *CitizenAppealDeadlinePolicy
 }
 type citizenAppealOutboxStore interface {
 	UpdateWithOutbox(models.UpdateCitizenAppealDocRequest, []models.OutboxEvent) (*models.CitizenAppealDocument, error)
@@ -98,7 +99,6 @@ func NewCitizenAppealCommandHandler(
 	repo CitizenAppealDocStore,
 	nomRepo NomenclatureStore,
 	refRepo ReferenceStore,
-	auth *AuthService,
 	journal *JournalService,
 	access *DocumentAccessService,
 ) *CitizenAppealCommandHandler {
@@ -106,29 +106,37 @@ func NewCitizenAppealCommandHandler(
 		repo:    repo,
 		nomRepo: nomRepo,
 		refRepo: refRepo,
-		auth:    auth,
 		journal: journal,
 		access:  access,
 	}
 }
 
+// SetDeadlinePolicy подключает расчет срока ответа по настройкам и производственному календарю.
+func (h *CitizenAppealCommandHandler) SetDeadlinePolicy(policy *CitizenAppealDeadlinePolicy) {
+	h.deadlines = policy
+}
+
 // Kind возвращает системный вид документа, поддерживаемый handler'ом.
 func (h *CitizenAppealCommandHandler) Kind() models.DocumentKind {
 	return models.DocumentKindCitizenAppeal
 }
 
 // Register регистрирует обращения граждан.
-func (h *CitizenAppealCommandHandler) Register(req CitizenAppealRegisterRequest) (*dto.CitizenAppealDocument, error) {
+func (h *CitizenAppealCommandHandler) Register(ctx context.Context, req CitizenAppealRegisterRequest) (*dto.CitizenAppealDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	adminOverride, err := buildAdminNumberOverride(req.AdminNumberOverride)
 	if err != nil {
 		return nil, err
 	}
 	if adminOverride != nil {
-		if err := h.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
-			return nil, err
+		if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+			return nil, models.ErrForbidden
 		}
 	} else {
-		if err := h.access.RequireCreate(models.DocumentKindCitizenAppeal); err != nil {
+		if err := h.access.RequireCreate(ctx, models.DocumentKindCitizenAppeal); err != nil {
 			return nil, err
 		}
 	}
@@ -170,12 +178,13 @@ func (h *CitizenAppealCommandHandler) Register(req CitizenAppealRegisterRequest)
 	if err != nil {
 		return nil, err
 	}
-
-	createdBy, err := h.auth.GetCurrentUserUUID()
+	responseDueDate, err := h.deadlines.DueDate(registrationDate)
 	if err != nil {
-		return nil, ErrNotAuthenticated
+		return nil, err
 	}
 
+	createdBy := principal.UserID
+
 	createReq := models.CreateCitizenAppealDocRequest{
 		NomenclatureID:       nomID,
 		IdempotencyKey:       idempotencyKey,
@@ -193,6 +202,7 @@ func (h *CitizenAppealCommandHandler) Register(req CitizenAppealRegisterRequest)
 		AttachmentPagesCount: req.AttachmentPagesCount,
 		HasEnvelope:          req.HasEnvelope,
 		ReceivedFromPOS:      req.ReceivedFromPOS,
+		ResponseDueDate:      responseDueDate,
 		Correspondents:       correspondents,
 		Resolutions:          resolutions,
 	}
@@ -205,20 +215,24 @@ func (h *CitizenAppealCommandHandler) Register(req CitizenAppealRegisterRequest)
 }
 
 // RegisterDocument реализует общий command-интерфейс по виду документа.
-func (h *CitizenAppealCommandHandler) RegisterDocument(req any) (any, error) {
+func (h *CitizenAppealCommandHandler) RegisterDocument(ctx context.Context, req any) (any, error) {
 	typedReq, ok := req.(CitizenAppealRegisterRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid register request for kind %s", h.Kind())
 	}
 
-	return h.Register(typedReq)
+	return h.Register(ctx, typedReq)
 }
 
 // CreateAdminDraft создает черновик обращения граждан с административно заданным номером.
-func (h *CitizenAppealCommandHandler) CreateAdminDraft(req AdminDraftCreateRequest) (any, error) {
-	if err := h.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+func (h *CitizenAppealCommandHandler) CreateAdminDraft(ctx context.Context, req AdminDraftCreateRequest) (any, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
 		return nil, err
 	}
+	if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+		return nil, models.ErrForbidden
+	}
 	nomID, err := uuid.Parse(req.NomenclatureID)
 	if err != nil {
 		return nil, models.NewBadRequest("неверный ID номенклатуры")
@@ -234,10 +248,11 @@ func (h *CitizenAppealCommandHandler) CreateAdminDraft(req AdminDraftCreateReque
 	if adminOverride == nil {
 		return nil, models.NewBadRequest("укажите административный номер")
 	}
-	createdBy, err := h.auth.GetCurrentUserUUID()
+	responseDueDate, err := h.deadlines.DueDate(registrationDate)
 	if err != nil {
-		return nil, ErrNotAuthenticated
+		return nil, err
 	}
+	createdBy := principal.UserID
 
 	createReq := models.CreateCitizenAppealDocRequest{
 		NomenclatureID:       nomID,
@@ -253,6 +268,7 @@ func (h *CitizenAppealCommandHandler) CreateAdminDraft(req AdminDraftCreateReque
 		ApplicantCategory:    adminDraftPlaceholder,
 		AppealPagesCount:     1,
 		AttachmentPagesCount: 0,
+		ResponseDueDate:      responseDueDate,
 	}
 	store, ok := h.repo.(citizenAppealJournalStore)
 	if !ok {
@@ -263,12 +279,16 @@ func (h *CitizenAppealCommandHandler) CreateAdminDraft(req AdminDraftCreateReque
 }
 
 // Update обновляет обращения граждан.
-func (h *CitizenAppealCommandHandler) Update(req CitizenAppealUpdateRequest) (*dto.CitizenAppealDocument, error) {
+func (h *CitizenAppealCommandHandler) Update(ctx context.Context, req CitizenAppealUpdateRequest) (*dto.CitizenAppealDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	uid, err := uuid.Parse(req.ID)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 	}
-	if err := h.access.RequireDocumentAction(uid, "update"); err != nil {
+	if err := h.access.RequireDocumentAction(ctx, uid, "update"); err != nil {
 		return nil, err
 	}
 
@@ -303,6 +323,11 @@ func (h *CitizenAppealCommandHandler) Update(req CitizenAppealUpdateRequest) (*d
 	if err != nil {
 		return nil, err
 	}
+	// Срок пересчитывается от даты регистрации, пока его не продлевали вручную.
+	responseDueDate, err := h.deadlines.DueDate(registrationDate)
+	if err != nil {
+		return nil, err
+	}
 
 	updateReq := models.UpdateCitizenAppealDocRequest{
 		ID:                   uid,
@@ -318,6 +343,7 @@ func (h *CitizenAppealCommandHandler) Update(req CitizenAppealUpdateRequest) (*d
 		AttachmentPagesCount: req.AttachmentPagesCount,
 		HasEnvelope:          req.HasEnvelope,
 		ReceivedFromPOS:      req.ReceivedFromPOS,
+		ResponseDueDate:      responseDueDate,
 		Correspondents:       correspondents,
 		Resolutions:          resolutions,
 	}
@@ -325,8 +351,7 @@ func (h *CitizenAppealCommandHandler) Update(req CitizenAppealUpdateRequest) (*d
 	if !ok {
 		return nil, fmt.Errorf("citizen appeal store must support atomic outbox operations")
 	}
-	currentUserID, _ := h.auth.GetCurrentUserUUID()
-	event, buildErr := NewJournalOutboxEvent("citizen-appeal:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: currentUserID, Action: "UPDATE", Details: "Обращение отредактировано"})
+	event, buildErr := NewJournalOutboxEvent("citizen-appeal:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: principal.UserID, Action: "UPDATE", Details: "Обращение отредактировано"})
 	if buildErr != nil {
 		return nil, buildErr
 	}
@@ -335,13 +360,13 @@ func (h *CitizenAppealCommandHandler) Update(req CitizenAppealUpdateRequest) (*d
 }
 
 // UpdateDocument реализует общий command-интерфейс по виду документа.
-func (h *CitizenAppealCommandHandler) UpdateDocument(req any) (any, error) {
+func (h *CitizenAppealCommandHandler) UpdateDocument(ctx context.Context, req any) (any, error) {
 	typedReq, ok := req.(CitizenAppealUpdateRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid update request for kind %s", h.Kind())
 	}
 
-	return h.Update(typedReq)
+	return h.Update(ctx, typedReq)
 }
 
 func (h *CitizenAppealCommandHandler) buildCorrespondents(reqs []CitizenAppealCorrespondentRequest) ([]models.DocumentCorrespondentRegistration, error) {
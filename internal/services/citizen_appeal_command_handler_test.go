@@ -98,7 +98,7 @@ func setupCitizenAppealCommandHandler(t *testing.T, allowed map[models.DocumentK
 		nil,
 	)
 	journal := NewJournalService(journalRepo, auth, access)
-	handler := NewCitizenAppealCommandHandler(repo, nomRepo, refRepo, auth, journal, access)
+	handler := NewCitizenAppealCommandHandler(repo, nomRepo, refRepo, journal, access)
 
 	return &citizenAppealHandlerDeps{
 		handler:     handler,
@@ -175,7 +175,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		deps.refRepo.On("FindOrCreateOrganization", "Администрация").Return(&models.Organization{ID: correspondentOrgID, Name: "Администрация"}, nil).Once()
 		deps.refRepo.On("FindOrCreateResolutionExecutor", "Исполнитель 1").Return(&models.ResolutionExecutor{ID: uuid.New(), Name: "Исполнитель 1"}, nil).Once()
 		deps.refRepo.On("FindOrCreateResolutionExecutor", "Исполнитель 2").Return(&models.ResolutionExecutor{ID: uuid.New(), Name: "Исполнитель 2"}, nil).Once()
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -202,7 +202,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		deps := setupCitizenAppealCommandHandler(t, nil)
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, result)
@@ -217,7 +217,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 		req.NomenclatureID = "bad-id"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ID номенклатуры")
@@ -233,7 +233,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 		req.IdempotencyKey = uuid.Nil.String()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ключ идемпотентности")
@@ -249,7 +249,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 		req.RegistrationDate = "03.06.2026"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный формат даты регистрации")
@@ -265,7 +265,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 		req.AppealType = "запрос"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный вид обращения")
@@ -281,7 +281,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 		req.ApplicantFullName = "  "
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "укажите ФИО обратившегося")
@@ -297,7 +297,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 		req.AppealPagesCount = 0
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "укажите количество листов обращения")
@@ -313,7 +313,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 		req.AttachmentPagesCount = -1
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "количество листов приложения не может быть отрицательным")
@@ -329,7 +329,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req := validCitizenAppealRegisterRequest(uuid.New(), uuid.New())
 		req.Correspondents[0].CorrespondentName = " "
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "укажите корреспондента")
@@ -347,7 +347,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req.Resolutions = nil
 		deps.refRepo.On("FindOrCreateOrganization", "Администрация").Return(nil, expectedErr).Once()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.Contains(t, err.Error(), "ошибка корреспондента")
@@ -364,7 +364,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req.Correspondents = nil
 		req.Resolutions = []CitizenAppealResolutionRequest{{ResolutionAuthor: "Руководитель"}}
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "укажите текст резолюции")
@@ -383,7 +383,7 @@ func TestCitizenAppealCommandHandler_Register(t *testing.T) {
 		req.Resolutions = nil
 		deps.refRepo.On("FindOrCreateOrganization", "Администрация").Return(&models.Organization{ID: uuid.New(), Name: "Администрация"}, nil).Once()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, result)
@@ -441,7 +441,7 @@ func TestCitizenAppealCommandHandler_Update(t *testing.T) {
 		}
 
 		deps.refRepo.On("FindOrCreateOrganization", "Прокуратура").Return(&models.Organization{ID: correspondentOrgID, Name: "Прокуратура"}, nil).Once()
-		result, err := deps.handler.Update(req)
+		result, err := deps.handler.Update(principalTestContext(deps.user), req)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -462,7 +462,7 @@ func TestCitizenAppealCommandHandler_Update(t *testing.T) {
 			allowDocumentActions(models.DocumentKindCitizenAppeal, "read", "update"),
 		)
 
-		result, err := deps.handler.Update(CitizenAppealUpdateRequest{ID: "bad-id"})
+		result, err := deps.handler.Update(principalTestContext(deps.user), CitizenAppealUpdateRequest{ID: "bad-id"})
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ID документа")
@@ -482,7 +482,7 @@ func TestCitizenAppealCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(CitizenAppealUpdateRequest{ID: documentID.String()})
+		result, err := deps.handler.Update(principalTestContext(deps.user), CitizenAppealUpdateRequest{ID: documentID.String()})
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, result)
@@ -501,7 +501,7 @@ func TestCitizenAppealCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(CitizenAppealUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), CitizenAppealUpdateRequest{
 			ID:                 documentID.String(),
 			RegistrationNumber: "  ",
 		})
@@ -524,7 +524,7 @@ func TestCitizenAppealCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(CitizenAppealUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), CitizenAppealUpdateRequest{
 			ID:                   documentID.String(),
 			RegistrationNumber:   "CA-20",
 			RegistrationDate:     "2026-06-04",
@@ -558,7 +558,7 @@ func TestCitizenAppealCommandHandler_Update(t *testing.T) {
 		}
 		deps.repo.updateErr = expectedErr
 
-		result, err := deps.handler.Update(CitizenAppealUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), CitizenAppealUpdateRequest{
 			ID:                   documentID.String(),
 			RegistrationNumber:   "CA-20",
 			RegistrationDate:     "2026-06-04",
@@ -585,12 +585,12 @@ func TestCitizenAppealCommandHandler_CommandInterface(t *testing.T) {
 		allowDocumentActions(models.DocumentKindCitizenAppeal, "create", "read", "update"),
 	)
 
-	registered, err := deps.handler.RegisterDocument(struct{}{})
+	registered, err := deps.handler.RegisterDocument(principalTestContext(deps.user), struct{}{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid register request")
 	assert.Nil(t, registered)
 
-	updated, err := deps.handler.UpdateDocument(struct{}{})
+	updated, err := deps.handler.UpdateDocument(principalTestContext(deps.user), struct{}{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid update request")
 	assert.Nil(t, updated)
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+const (
+	defaultCitizenAppealResponseDays = 30
+	defaultCitizenAppealDueSoonDays  = 5
+)
+
+// CitizenAppealDeadlinePolicy рассчитывает нормативный срок ответа на обращение
+// гражданина по системным настройкам и производственному календарю.
+//
+// В календарном режиме срок отсчитывается от даты регистрации, а выпавший на
+// выходной последний день переносится на ближайший рабочий. В режиме рабочих
+// дней учитываются только рабочие дни календаря.
+type CitizenAppealDeadlinePolicy struct {
+	settings *SettingsService
+	calendar *WorkingCalendarService
+	now      func() time.Time
+}
+
+// NewCitizenAppealDeadlinePolicy создает политику сроков ответа на обращения.
+func NewCitizenAppealDeadlinePolicy(settings *SettingsService, calendar *WorkingCalendarService) *CitizenAppealDeadlinePolicy {
+	return &CitizenAppealDeadlinePolicy{settings: settings, calendar: calendar, now: time.Now}
+}
+
+// DueDate возвращает срок ответа для обращения, зарегистрированного в указанную дату.
+func (p *CitizenAppealDeadlinePolicy) DueDate(registrationDate time.Time) (time.Time, error) {
+	days := defaultCitizenAppealResponseDays
+	workingDays := false
+	if p != nil && p.settings != nil {
+		days = p.settings.GetCitizenAppealResponseDays()
+		workingDays = p.settings.IsCitizenAppealDeadlineInWorkingDays()
+	}
+
+	// Календарь загружается с запасом на праздничные периоды.
+	var calendar *models.WorkingCalendar
+	if p != nil && p.calendar != nil {
+		var err error
+		calendar, err = p.calendar.Calendar(registrationDate, registrationDate.AddDate(0, 0, days*2+30))
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if workingDays {
+		return calendar.AddWorkingDays(registrationDate, days), nil
+	}
+	return calendar.NextWorkingDay(registrationDate.AddDate(0, 0, days)), nil
+}
+
+// DueSoonDays возвращает порог, с которого обращение считается подходящим к сроку.
+func (p *CitizenAppealDeadlinePolicy) DueSoonDays() int {
+	if p == nil || p.settings == nil {
+		return defaultCitizenAppealDueSoonDays
+	}
+	return p.settings.GetCitizenAppealDueSoonDays()
+}
+
+// ApplyStatus заполняет вычисляемый статус срока ответа для обращений.
+func (p *CitizenAppealDeadlinePolicy) ApplyStatus(docs ...*models.CitizenAppealDocument) {
+	today := time.Now()
+	if p != nil && p.now != nil {
+		today = p.now()
+	}
+	dueSoonDays := p.DueSoonDays()
+	for _, doc := range docs {
+		if doc != nil {
+			doc.DeadlineStatus = models.CitizenAppealDeadlineStatusAt(doc, today, dueSoonDays)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type workingCalendarStoreStub struct {
+	days    []models.WorkingCalendarDay
+	saved   *models.WorkingCalendarDay
+	effects []models.OutboxEvent
+}
+
+func (s *workingCalendarStoreStub) GetDays(from, to time.Time) ([]models.WorkingCalendarDay, error) {
+	return s.days, nil
+}
+
+func (s *workingCalendarStoreStub) SaveWithOutbox(day models.WorkingCalendarDay, effects []models.OutboxEvent) error {
+	s.saved = &day
+	s.effects = effects
+	return nil
+}
+
+func (s *workingCalendarStoreStub) DeleteWithOutbox(day time.Time, effects []models.OutboxEvent) error {
+	s.effects = effects
+	return nil
+}
+
+func TestCitizenAppealDeadlinePolicy_DueDate(t *testing.T) {
+	registered := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	holidays := &workingCalendarStoreStub{days: []models.WorkingCalendarDay{
+		{Day: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), IsWorkingDay: false},
+	}}
+
+	t.Run("calendar days shift to next working day", func(t *testing.T) {
+		settings, repo := setupSettingsService(t, "admin")
+		repo.On("Get", "citizen_appeal_response_days").Return(&models.SystemSetting{Value: "30"}, nil).Once()
+		repo.On("Get", "citizen_appeal_deadline_working_days").Return(&models.SystemSetting{Value: "false"}, nil).Once()
+		policy := NewCitizenAppealDeadlinePolicy(settings, NewWorkingCalendarService(holidays, nil))
+
+		due, err := policy.DueDate(registered)
+
+		require.NoError(t, err)
+		// 1 мая — праздник, 2-3 мая — выходные.
+		assert.Equal(t, time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC), due)
+	})
+
+	t.Run("working days skip holidays", func(t *testing.T) {
+		settings, repo := setupSettingsService(t, "admin")
+		repo.On("Get", "citizen_appeal_response_days").Return(&models.SystemSetting{Value: "22"}, nil).Once()
+		repo.On("Get", "citizen_appeal_deadline_working_days").Return(&models.SystemSetting{Value: "true"}, nil).Once()
+		policy := NewCitizenAppealDeadlinePolicy(settings, NewWorkingCalendarService(holidays, nil))
+
+		due, err := policy.DueDate(registered)
+
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC), due)
+	})
+
+	t.Run("nil policy uses defaults", func(t *testing.T) {
+		var policy *CitizenAppealDeadlinePolicy
+
+		due, err := policy.DueDate(registered)
+
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), due)
+		assert.Equal(t, defaultCitizenAppealDueSoonDays, policy.DueSoonDays())
+	})
+}
+
+func TestCitizenAppealDeadlinePolicy_ApplyStatus(t *testing.T) {
+	settings, repo := setupSettingsService(t, "admin")
+	repo.On("Get", "citizen_appeal_due_soon_days").Return(&models.SystemSetting{Value: "3"}, nil).Once()
+	policy := NewCitizenAppealDeadlinePolicy(settings, nil)
+	policy.now = func() time.Time { return time.Date(2026, 4, 10, 9, 0, 0, 0, time.UTC) }
+
+	overdue := time.Date(2026, 4, 9, 0, 0, 0, 0, time.UTC)
+	dueSoon := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	open := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC)
+	replyID := uuid.New()
+	docs := []*models.CitizenAppealDocument{
+		{ResponseDueDate: &overdue},
+		{ResponseDueDate: &dueSoon},
+		{ResponseDueDate: &open},
+		{ResponseDueDate: &overdue, ClosedAt: &overdue, ClosedByDocumentID: &replyID},
+	}
+
+	policy.ApplyStatus(append(docs, nil)...)
+
+	assert.Equal(t, models.CitizenAppealDeadlineOverdue, docs[0].DeadlineStatus)
+	assert.Equal(t, models.CitizenAppealDeadlineDueSoon, docs[1].DeadlineStatus)
+	assert.Equal(t, models.CitizenAppealDeadlineOpen, docs[2].DeadlineStatus)
+	assert.Equal(t, models.CitizenAppealDeadlineClosed, docs[3].DeadlineStatus)
+}
+
+func TestWorkingCalendarService_SetDay(t *testing.T) {
+	t.Run("admin writes day with audit", func(t *testing.T) {
+		settings, _ := setupSettingsService(t, "admin")
+		store := &workingCalendarStoreStub{}
+		svc := NewWorkingCalendarService(store, settings.authService)
+
+		err := svc.SetDay("2026-06-12", false, " День России ")
+
+		require.NoError(t, err)
+		require.NotNil(t, store.saved)
+		assert.Equal(t, "День России", store.saved.Description)
+		require.Len(t, store.effects, 1)
+		assert.Equal(t, models.OutboxEventAudit, store.effects[0].EventType)
+	})
+
+	t.Run("non admin is forbidden", func(t *testing.T) {
+		settings, _ := setupSettingsService(t, "clerk")
+		store := &workingCalendarStoreStub{}
+		svc := NewWorkingCalendarService(store, settings.authService)
+
+		err := svc.SetDay("2026-06-12", false, "")
+
+		require.ErrorIs(t, err, models.ErrForbidden)
+		assert.Nil(t, store.saved)
+	})
+
+	t.Run("period is validated", func(t *testing.T) {
+		settings, _ := setupSettingsService(t, "clerk")
+		svc := NewWorkingCalendarService(&workingCalendarStoreStub{}, settings.authService)
+
+		_, err := svc.GetDays("2026-06-12", "2026-06-01")
+
+		require.Error(t, err)
+	})
+}
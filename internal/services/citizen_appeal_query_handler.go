@@ -9,7 +9,8 @@ import (
 
 // CitizenAppealQueryHandler обслуживает read-only операции по обращениям граждан.
 type CitizenAppealQueryHandler struct {
-	repo CitizenAppealDocStore
+	repo      CitizenAppealDocStore
+	deadlines *CitizenAppealDeadlinePolicy
 }
 
 // NewCitizenAppealQueryHandler создает обработчик обращений граждан.
@@ -17,6 +18,11 @@ func NewCitizenAppealQueryHandler(repo CitizenAppealDocStore) *CitizenAppealQuer
 	return &CitizenAppealQueryHandler{repo: repo}
 }
 
+// SetDeadlinePolicy подключает вычисление статуса срока ответа и порог «подходит срок».
+func (h *CitizenAppealQueryHandler) SetDeadlinePolicy(policy *CitizenAppealDeadlinePolicy) {
+	h.deadlines = policy
+}
+
 // Kind возвращает вид документа, который обслуживает handler.
 func (h *CitizenAppealQueryHandler) Kind() models.DocumentKind {
 	return models.DocumentKindCitizenAppeal
@@ -28,16 +34,25 @@ func (h *CitizenAppealQueryHandler) GetCard(id uuid.UUID) (*dto.DocumentCard, er
 	if err != nil {
 		return nil, err
 	}
+	h.deadlines.ApplyStatus(doc)
 
 	return dto.MapCitizenAppealDocumentCard(doc), nil
 }
 
 // GetList возвращает общий список обращений граждан.
 func (h *CitizenAppealQueryHandler) GetList(filter models.DocumentFilter) (*dto.PagedResult[dto.DocumentListItem], error) {
+	if filter.AppealDeadlineStatus != "" && !models.IsValidCitizenAppealDeadlineStatus(filter.AppealDeadlineStatus) {
+		return nil, models.NewBadRequest("неверный фильтр по сроку ответа")
+	}
+	filter.AppealDueSoonDays = h.deadlines.DueSoonDays()
+
 	res, err := h.repo.GetList(filter)
 	if err != nil {
 		return nil, err
 	}
+	for i := range res.Items {
+		h.deadlines.ApplyStatus(&res.Items[i])
+	}
 
 	return &dto.PagedResult[dto.DocumentListItem]{
 		Items:      dto.MapDocumentListItemsFromCitizenAppeals(res.Items),
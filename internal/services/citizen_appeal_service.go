@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// CitizenAppealService предоставляет дополнительные операции по срокам ответа на обращения граждан.
+type CitizenAppealService struct {
+	repo     CitizenAppealDeadlineStore
+	userRepo UserStore
+	auth     *AuthService
+	access   *DocumentAccessService
+}
+
+// CitizenAppealDeadlineExtensionRequest описывает команду продления срока ответа.
+type CitizenAppealDeadlineExtensionRequest struct {
+	DocumentID   string `json:"documentId"`
+	NewDueDate   string `json:"newDueDate"`
+	Reason       string `json:"reason"`
+	ApprovedByID string `json:"approvedById"`
+}
+
+// NewCitizenAppealService создает сервис сроков ответа на обращения.
+func NewCitizenAppealService(
+	repo CitizenAppealDeadlineStore,
+	userRepo UserStore,
+	auth *AuthService,
+	access *DocumentAccessService,
+) *CitizenAppealService {
+	return &CitizenAppealService{
+		repo:     repo,
+		userRepo: userRepo,
+		auth:     auth,
+		access:   access,
+	}
+}
+
+// ExtendDeadline продлевает срок ответа с указанием основания и утвердившего руководителя.
+func (s *CitizenAppealService) ExtendDeadline(req CitizenAppealDeadlineExtensionRequest) (*dto.CitizenAppealDeadlineExtension, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	documentID, err := uuid.Parse(req.DocumentID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+	}
+	if err := s.access.RequireDocumentAction(ctx, documentID, "update"); err != nil {
+		return nil, err
+	}
+
+	newDueDate, err := parseCommandDate(req.NewDueDate, "нового срока ответа")
+	if err != nil {
+		return nil, err
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, models.NewBadRequest("укажите основание продления срока")
+	}
+	approverID, err := uuid.Parse(req.ApprovedByID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID утвердившего продление", err)
+	}
+	approver, err := s.userRepo.GetByID(approverID)
+	if err != nil {
+		return nil, err
+	}
+	if approver == nil || !approver.IsActive {
+		return nil, models.NewBadRequest("утвердивший продление должен быть активным пользователем")
+	}
+
+	currentUserID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return nil, ErrNotAuthenticated
+	}
+
+	details := fmt.Sprintf("Срок ответа продлен до %s. Основание: %s. Утвердил: %s", newDueDate.Format("02.01.2006"), reason, approver.FullName)
+	event, buildErr := NewJournalOutboxEvent("citizen-appeal:"+documentID.String()+":deadline-extend:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: documentID, UserID: currentUserID, Action: "DEADLINE_EXTEND", Details: details})
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	extension, err := s.repo.ExtendDeadlineWithOutbox(models.ExtendCitizenAppealDeadlineRequest{
+		DocumentID: documentID,
+		NewDueDate: newDueDate,
+		Reason:     reason,
+		ApprovedBy: approverID,
+		CreatedBy:  currentUserID,
+	}, []models.OutboxEvent{event})
+	if err != nil {
+		return nil, err
+	}
+	extension.ApprovedByName = approver.FullName
+
+	mapped := dto.MapCitizenAppealDeadlineExtensions([]models.CitizenAppealDeadlineExtension{*extension})
+	return &mapped[0], nil
+}
+
+// GetDeadlineExtensions возвращает историю продлений срока ответа на обращение.
+func (s *CitizenAppealService) GetDeadlineExtensions(documentIDStr string) ([]dto.CitizenAppealDeadlineExtension, error) {
+	ctx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	documentID, err := uuid.Parse(documentIDStr)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+	}
+	if err := s.access.RequireReadAnyType(ctx, documentID); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetDeadlineExtensions(documentID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapCitizenAppealDeadlineExtensions(items), nil
+}
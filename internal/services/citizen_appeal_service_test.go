@@ -0,0 +1,126 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type citizenAppealDeadlineStoreStub struct {
+	extendReq  *models.ExtendCitizenAppealDeadlineRequest
+	effects    []models.OutboxEvent
+	extensions []models.CitizenAppealDeadlineExtension
+}
+
+func (s *citizenAppealDeadlineStoreStub) GetDeadlineExtensions(documentID uuid.UUID) ([]models.CitizenAppealDeadlineExtension, error) {
+	return s.extensions, nil
+}
+
+func (s *citizenAppealDeadlineStoreStub) ExtendDeadlineWithOutbox(req models.ExtendCitizenAppealDeadlineRequest, effects []models.OutboxEvent) (*models.CitizenAppealDeadlineExtension, error) {
+	s.extendReq = &req
+	s.effects = effects
+	return &models.CitizenAppealDeadlineExtension{
+		ID:              uuid.New(),
+		DocumentID:      req.DocumentID,
+		PreviousDueDate: req.NewDueDate.AddDate(0, 0, -30),
+		NewDueDate:      req.NewDueDate,
+		Reason:          req.Reason,
+		ApprovedBy:      req.ApprovedBy,
+		CreatedBy:       req.CreatedBy,
+		CreatedAt:       time.Now(),
+	}, nil
+}
+
+func (s *citizenAppealDeadlineStoreStub) GetDeadlineCounters(scope models.DocumentAccessScope, dueSoonDays int) (*models.CitizenAppealDeadlineCounters, error) {
+	return &models.CitizenAppealDeadlineCounters{}, nil
+}
+
+func setupCitizenAppealService(t *testing.T, actions ...string) (*CitizenAppealService, *citizenAppealDeadlineStoreStub, *documentAccessTestDeps, uuid.UUID) {
+	t.Helper()
+	deps := setupDocumentAccessService(t, documentAccessUser(false, nil), allowDocumentActions(models.DocumentKindCitizenAppeal, actions...))
+	docID := uuid.New()
+	deps.docRepo.docs[docID] = documentAccessDoc(docID, uuid.New(), models.DocumentKindCitizenAppeal)
+	store := &citizenAppealDeadlineStoreStub{}
+	return NewCitizenAppealService(store, deps.userRepo, deps.auth, deps.service), store, deps, docID
+}
+
+func TestCitizenAppealService_ExtendDeadline(t *testing.T) {
+	t.Run("extends deadline with journal event", func(t *testing.T) {
+		svc, store, deps, docID := setupCitizenAppealService(t, "read", "update")
+		approver := &models.User{ID: uuid.New(), FullName: "Петров П.П.", IsActive: true}
+		deps.userRepo.On("GetByID", approver.ID).Return(approver, nil).Once()
+
+		result, err := svc.ExtendDeadline(CitizenAppealDeadlineExtensionRequest{
+			DocumentID:   docID.String(),
+			NewDueDate:   "2026-07-15",
+			Reason:       " Запрос дополнительных материалов ",
+			ApprovedByID: approver.ID.String(),
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, store.extendReq)
+		assert.Equal(t, "Запрос дополнительных материалов", store.extendReq.Reason)
+		assert.Equal(t, deps.user.ID, store.extendReq.CreatedBy)
+		assert.Equal(t, "Петров П.П.", result.ApprovedByName)
+		require.Len(t, store.effects, 1)
+		assert.Equal(t, models.OutboxEventJournal, store.effects[0].EventType)
+		assert.Contains(t, store.effects[0].Payload, "DEADLINE_EXTEND")
+	})
+
+	t.Run("reason is required", func(t *testing.T) {
+		svc, store, _, docID := setupCitizenAppealService(t, "read", "update")
+
+		_, err := svc.ExtendDeadline(CitizenAppealDeadlineExtensionRequest{
+			DocumentID:   docID.String(),
+			NewDueDate:   "2026-07-15",
+			Reason:       " ",
+			ApprovedByID: uuid.NewString(),
+		})
+
+		var appErr *models.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 400, appErr.Code)
+		assert.Nil(t, store.extendReq)
+	})
+
+	t.Run("inactive approver is rejected", func(t *testing.T) {
+		svc, store, deps, docID := setupCitizenAppealService(t, "read", "update")
+		approver := &models.User{ID: uuid.New(), IsActive: false}
+		deps.userRepo.On("GetByID", approver.ID).Return(approver, nil).Once()
+
+		_, err := svc.ExtendDeadline(CitizenAppealDeadlineExtensionRequest{
+			DocumentID:   docID.String(),
+			NewDueDate:   "2026-07-15",
+			Reason:       "Основание",
+			ApprovedByID: approver.ID.String(),
+		})
+
+		require.Error(t, err)
+		assert.Nil(t, store.extendReq)
+	})
+
+	t.Run("update permission is required", func(t *testing.T) {
+		svc, store, _, docID := setupCitizenAppealService(t, "read")
+
+		_, err := svc.ExtendDeadline(CitizenAppealDeadlineExtensionRequest{DocumentID: docID.String()})
+
+		require.ErrorIs(t, err, models.ErrForbidden)
+		assert.Nil(t, store.extendReq)
+	})
+}
+
+func TestCitizenAppealService_GetDeadlineExtensions(t *testing.T) {
+	svc, store, _, docID := setupCitizenAppealService(t, "read")
+	store.extensions = []models.CitizenAppealDeadlineExtension{{ID: uuid.New(), DocumentID: docID, Reason: "Основание"}}
+
+	items, err := svc.GetDeadlineExtensions(docID.String())
+
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "Основание", items[0].Reason)
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// CustomDocumentRegisterRequest описывает команду регистрации документа пользовательского вида.
+// Fields содержит значения полей схемы вида по их кодам.
+type CustomDocumentRegisterRequest struct {
+	NomenclatureID      string                      `json:"nomenclatureId"`
+	IdempotencyKey      string                      `json:"idempotencyKey"`
+	RegistrationDate    string                      `json:"registrationDate"`
+	RegistrationNumber  string                      `json:"registrationNumber"`
+	DocumentTypeID      string                      `json:"documentTypeId"`
+	Content             string                      `json:"content"`
+	PagesCount          int                         `json:"pagesCount"`
+	Fields              map[string]string           `json:"fields"`
+	AdminNumberOverride *AdminNumberOverrideRequest `json:"adminNumberOverride"`
+}
+
+// CustomDocumentUpdateRequest описывает команду обновления документа пользовательского вида.
+type CustomDocumentUpdateRequest struct {
+	ID               string            `json:"id"`
+	RegistrationDate string            `json:"registrationDate"`
+	DocumentTypeID   string            `json:"documentTypeId"`
+	Content          string            `json:"content"`
+	PagesCount       int               `json:"pagesCount"`
+	Fields           map[string]string `json:"fields"`
+}
+
+// CustomDocumentCommandHandler инкапсулирует write-операции по документам пользовательских видов.
+// Один экземпляр обслуживает все такие виды: registry получает копию, привязанную к виду, через ForKind.
+type CustomDocumentCommandHandler struct {
+	kind   models.DocumentKind
+	kinds  CustomDocumentKindStore
+	repo   CustomDocumentStore
+	access *DocumentAccessService
+}
+type customDocumentOutboxStore interface {
+	UpdateWithOutbox(models.UpdateCustomDocumentRequest, []models.OutboxEvent) (*models.CustomDocument, error)
+}
+type customDocumentJournalStore interface {
+	CreateWithJournal(models.CreateCustomDocumentRequest, string, string) (*models.CustomDocument, error)
+}
+
+// NewCustomDocumentCommandHandler создает handler команд документов пользовательских видов.
+func NewCustomDocumentCommandHandler(
+	kinds CustomDocumentKindStore,
+	repo CustomDocumentStore,
+	access *DocumentAccessService,
+) *CustomDocumentCommandHandler {
+	return &CustomDocumentCommandHandler{
+		kinds:  kinds,
+		repo:   repo,
+		access: access,
+	}
+}
+
+// ForKind возвращает обработчик, привязанный к пользовательскому виду документа.
+func (h *CustomDocumentCommandHandler) ForKind(kind models.DocumentKind) *CustomDocumentCommandHandler {
+	bound := *h
+	bound.kind = kind
+	return &bound
+}
+
+// ResolveDocumentKind возвращает обработчик для пользовательского вида из каталога видов.
+func (h *CustomDocumentCommandHandler) ResolveDocumentKind(kind models.DocumentKind) (DocumentKindCommandHandler, bool) {
+	spec, ok := models.GetDocumentKindSpec(kind)
+	if !ok || !spec.IsCustom {
+		return nil, false
+	}
+	return h.ForKind(kind), true
+}
+
+// Kind возвращает вид документа, к которому привязан обработчик.
+func (h *CustomDocumentCommandHandler) Kind() models.DocumentKind {
+	return h.kind
+}
+
+// Register регистрирует документ пользовательского вида.
+func (h *CustomDocumentCommandHandler) Register(ctx context.Context, req CustomDocumentRegisterRequest) (*dto.CustomDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	adminOverride, err := buildAdminNumberOverride(req.AdminNumberOverride)
+	if err != nil {
+		return nil, err
+	}
+	if adminOverride != nil {
+		if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+			return nil, models.ErrForbidden
+		}
+	} else {
+		if err := h.access.RequireCreate(ctx, h.kind); err != nil {
+			return nil, err
+		}
+	}
+
+	kind, err := h.loadKind()
+	if err != nil {
+		return nil, err
+	}
+	if !kind.IsActive {
+		return nil, models.NewBadRequest("регистрация документов этого вида отключена")
+	}
+
+	nomID, err := uuid.Parse(req.NomenclatureID)
+	if err != nil {
+		return nil, models.NewBadRequest("неверный ID номенклатуры")
+	}
+	idempotencyKey, err := uuid.Parse(req.IdempotencyKey)
+	if err != nil || idempotencyKey == uuid.Nil {
+		return nil, models.NewBadRequest("неверный ключ идемпотентности")
+	}
+	registrationDate, err := parseCommandDate(req.RegistrationDate, "даты регистрации")
+	if err != nil {
+		return nil, err
+	}
+	content, pagesCount, err := normalizeCustomDocumentBody(req.Content, req.PagesCount)
+	if err != nil {
+		return nil, err
+	}
+	values, err := kind.NormalizeFieldValues(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	organizationIDs, userIDs := kind.ReferencedIDs(values)
+
+	createdBy := principal.UserID
+
+	createReq := models.CreateCustomDocumentRequest{
+		Kind:                kind.Code,
+		KindName:            kind.Name,
+		NomenclatureID:      nomID,
+		IdempotencyKey:      idempotencyKey,
+		AdminNumberOverride: adminOverride,
+		CreatedBy:           createdBy,
+		RegistrationNumber:  strings.TrimSpace(req.RegistrationNumber),
+		RegistrationDate:    registrationDate,
+		DocumentTypeID:      models.NormalizeDocumentType(req.DocumentTypeID),
+		Content:             content,
+		PagesCount:          pagesCount,
+		FieldValues:         values,
+		OrganizationIDs:     organizationIDs,
+		UserIDs:             userIDs,
+	}
+	store, ok := h.repo.(customDocumentJournalStore)
+	if !ok {
+		return nil, fmt.Errorf("custom document store must support atomic journal operations")
+	}
+	res, err := store.CreateWithJournal(createReq, "CREATE", "Документ зарегистрирован. Рег. номер: %s")
+	return dto.MapCustomDocument(res), err
+}
+
+// RegisterDocument реализует общий command-интерфейс по виду документа.
+func (h *CustomDocumentCommandHandler) RegisterDocument(ctx context.Context, req any) (any, error) {
+	typedReq, ok := req.(CustomDocumentRegisterRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid register request for kind %s", h.Kind())
+	}
+	return h.Register(ctx, typedReq)
+}
+
+// Update обновляет документ пользовательского вида. Пустой тип документа сохраняет текущий.
+func (h *CustomDocumentCommandHandler) Update(ctx context.Context, req CustomDocumentUpdateRequest) (*dto.CustomDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(req.ID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+	}
+	if err := h.access.RequireDocumentAction(ctx, uid, "update"); err != nil {
+		return nil, err
+	}
+
+	kind, err := h.loadKind()
+	if err != nil {
+		return nil, err
+	}
+	registrationDate, err := parseCommandDate(req.RegistrationDate, "даты регистрации")
+	if err != nil {
+		return nil, err
+	}
+	content, pagesCount, err := normalizeCustomDocumentBody(req.Content, req.PagesCount)
+	if err != nil {
+		return nil, err
+	}
+	values, err := kind.NormalizeFieldValues(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	organizationIDs, userIDs := kind.ReferencedIDs(values)
+
+	updateReq := models.UpdateCustomDocumentRequest{
+		ID:               uid,
+		Kind:             kind.Code,
+		RegistrationDate: registrationDate,
+		DocumentTypeID:   models.NormalizeDocumentType(req.DocumentTypeID),
+		Content:          content,
+		PagesCount:       pagesCount,
+		FieldValues:      values,
+		OrganizationIDs:  organizationIDs,
+		UserIDs:          userIDs,
+	}
+	store, ok := h.repo.(customDocumentOutboxStore)
+	if !ok {
+		return nil, fmt.Errorf("custom document store must support atomic outbox operations")
+	}
+	event, buildErr := NewJournalOutboxEvent("custom-document:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: principal.UserID, Action: "UPDATE", Details: "Документ отредактирован"})
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	res, err := store.UpdateWithOutbox(updateReq, []models.OutboxEvent{event})
+	return dto.MapCustomDocument(res), err
+}
+
+// UpdateDocument реализует общий command-интерфейс по виду документа.
+func (h *CustomDocumentCommandHandler) UpdateDocument(ctx context.Context, req any) (any, error) {
+	typedReq, ok := req.(CustomDocumentUpdateRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid update request for kind %s", h.Kind())
+	}
+	return h.Update(ctx, typedReq)
+}
+
+func (h *CustomDocumentCommandHandler) loadKind() (*models.CustomDocumentKind, error) {
+	kind, err := h.kinds.GetByCode(h.kind)
+	if err != nil {
+		return nil, err
+	}
+	if kind == nil {
+		return nil, models.NewNotFound("вид документа не найден")
+	}
+	return kind, nil
+}
+
+func normalizeCustomDocumentBody(content string, pagesCount int) (string, int, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", 0, models.NewBadRequest("укажите краткое содержание документа")
+	}
+	if pagesCount < 0 {
+		return "", 0, models.NewBadRequest("количество листов не может быть отрицательным")
+	}
+	if pagesCount == 0 {
+		pagesCount = 1
+	}
+	return content, pagesCount, nil
+}
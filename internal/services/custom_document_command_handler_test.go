@@ -0,0 +1,228 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type customDocumentStoreFake struct {
+	docs          map[uuid.UUID]models.CustomDocument
+	listKind      models.DocumentKind
+	listFilter    *models.DocumentFilter
+	createReq     *models.CreateCustomDocumentRequest
+	createAction  string
+	updateReq     *models.UpdateCustomDocumentRequest
+	updateEffects []models.OutboxEvent
+}
+
+func (s *customDocumentStoreFake) GetList(kind models.DocumentKind, filter models.DocumentFilter) (*models.PagedResult[models.CustomDocument], error) {
+	s.listKind = kind
+	s.listFilter = &filter
+	items := make([]models.CustomDocument, 0, len(s.docs))
+	for _, doc := range s.docs {
+		items = append(items, doc)
+	}
+	return &models.PagedResult[models.CustomDocument]{Items: items, TotalCount: len(items), Page: filter.Page, PageSize: filter.PageSize}, nil
+}
+
+func (s *customDocumentStoreFake) GetByID(id uuid.UUID) (*models.CustomDocument, error) {
+	doc, ok := s.docs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &doc, nil
+}
+
+func (s *customDocumentStoreFake) Create(req models.CreateCustomDocumentRequest) (*models.CustomDocument, error) {
+	s.createReq = &req
+	return &models.CustomDocument{ID: uuid.New(), Kind: req.Kind, RegistrationNumber: "Д-1", Content: req.Content}, nil
+}
+
+func (s *customDocumentStoreFake) CreateWithJournal(req models.CreateCustomDocumentRequest, action string, _ string) (*models.CustomDocument, error) {
+	s.createAction = action
+	return s.Create(req)
+}
+
+func (s *customDocumentStoreFake) Update(req models.UpdateCustomDocumentRequest) (*models.CustomDocument, error) {
+	s.updateReq = &req
+	return &models.CustomDocument{ID: req.ID, Kind: req.Kind, Content: req.Content}, nil
+}
+
+func (s *customDocumentStoreFake) UpdateWithOutbox(req models.UpdateCustomDocumentRequest, effects []models.OutboxEvent) (*models.CustomDocument, error) {
+	s.updateEffects = effects
+	return s.Update(req)
+}
+
+type customDocumentHandlerDeps struct {
+	handler *CustomDocumentCommandHandler
+	kinds   *customDocumentKindStoreFake
+	repo    *customDocumentStoreFake
+	user    *models.User
+}
+
+func contractKind(isActive bool) models.CustomDocumentKind {
+	return models.CustomDocumentKind{
+		Code:     "contract",
+		Name:     "Договор",
+		IsActive: isActive,
+		Fields: []models.CustomDocumentKindField{
+			{Code: "counterparty", Label: "Контрагент", FieldType: models.CustomFieldOrganization, IsRequired: true},
+			{Code: "amount", Label: "Сумма", FieldType: models.CustomFieldNumber},
+			{Code: "signed_at", Label: "Дата подписания", FieldType: models.CustomFieldDate},
+		},
+	}
+}
+
+func setupCustomDocumentCommandHandler(t *testing.T, kind models.CustomDocumentKind, allowed map[models.DocumentKind]map[string]bool) *customDocumentHandlerDeps {
+	t.Helper()
+	models.SetCustomDocumentKindSpecs([]models.DocumentKindSpec{kind.Spec()})
+	t.Cleanup(func() { models.SetCustomDocumentKindSpecs(nil) })
+
+	userRepo := mocks.NewUserStore(t)
+	auth := NewAuthService(nil, userRepo)
+	user := documentAccessUser(false, nil)
+	auth.currentUserID = user.ID
+	userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+
+	access := NewDocumentAccessService(
+		auth,
+		&documentAccessDepartmentStore{},
+		&documentAccessAssignmentStore{accessible: map[uuid.UUID]struct{}{}},
+		&documentAccessAcknowledgmentStore{accessible: map[uuid.UUID]struct{}{}},
+		&kindActionDocumentAccessStore{allowed: allowed},
+		nil,
+		nil,
+		nil,
+	)
+	kinds := newCustomDocumentKindStoreFake(kind)
+	repo := &customDocumentStoreFake{docs: map[uuid.UUID]models.CustomDocument{}}
+
+	return &customDocumentHandlerDeps{
+		handler: NewCustomDocumentCommandHandler(kinds, repo, access),
+		kinds:   kinds,
+		repo:    repo,
+		user:    user,
+	}
+}
+
+func TestCustomDocumentCommandHandler_RegisterThroughRegistry(t *testing.T) {
+	deps := setupCustomDocumentCommandHandler(t, contractKind(true), allowDocumentActions("contract", "create"))
+	registry := NewDocumentKindCommandRegistry()
+	registry.SetResolver(deps.handler)
+	service := NewDocumentRegistrationService(registry, nil)
+
+	nomenclatureID := uuid.New()
+	orgID := uuid.New()
+	result, err := service.register(principalTestContext(deps.user), "contract", map[string]any{
+		"nomenclatureId":   nomenclatureID.String(),
+		"idempotencyKey":   uuid.NewString(),
+		"registrationDate": "2026-03-02",
+		"content":          " Договор поставки ",
+		"fields": map[string]any{
+			"counterparty": orgID.String(),
+			"amount":       "1200,5",
+		},
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.NotNil(t, deps.repo.createReq)
+	assert.Equal(t, "CREATE", deps.repo.createAction)
+	assert.Equal(t, models.DocumentKind("contract"), deps.repo.createReq.Kind)
+	assert.Equal(t, "Договор", deps.repo.createReq.KindName)
+	assert.Equal(t, nomenclatureID, deps.repo.createReq.NomenclatureID)
+	assert.Equal(t, deps.user.ID, deps.repo.createReq.CreatedBy)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), deps.repo.createReq.RegistrationDate)
+	assert.Equal(t, "Договор поставки", deps.repo.createReq.Content)
+	assert.Equal(t, 1, deps.repo.createReq.PagesCount)
+	assert.Equal(t, map[string]string{"counterparty": orgID.String(), "amount": "1200.5"}, deps.repo.createReq.FieldValues)
+	assert.Equal(t, []uuid.UUID{orgID}, deps.repo.createReq.OrganizationIDs)
+	assert.Empty(t, deps.repo.createReq.UserIDs)
+}
+
+func TestCustomDocumentCommandHandler_RegisterValidation(t *testing.T) {
+	validRequest := func() CustomDocumentRegisterRequest {
+		return CustomDocumentRegisterRequest{
+			NomenclatureID:   uuid.NewString(),
+			IdempotencyKey:   uuid.NewString(),
+			RegistrationDate: "2026-03-02",
+			Content:          "Договор поставки",
+			Fields:           map[string]string{"counterparty": uuid.NewString()},
+		}
+	}
+
+	t.Run("rejects missing create permission", func(t *testing.T) {
+		deps := setupCustomDocumentCommandHandler(t, contractKind(true), nil)
+		_, err := deps.handler.ForKind("contract").Register(principalTestContext(deps.user), validRequest())
+		require.ErrorIs(t, err, models.ErrForbidden)
+		assert.Nil(t, deps.repo.createReq)
+	})
+
+	t.Run("rejects inactive kind", func(t *testing.T) {
+		deps := setupCustomDocumentCommandHandler(t, contractKind(false), allowDocumentActions("contract", "create"))
+		deps.kinds.kinds["contract"] = contractKind(false)
+		models.SetCustomDocumentKindSpecs([]models.DocumentKindSpec{contractKind(true).Spec()})
+		_, err := deps.handler.ForKind("contract").Register(principalTestContext(deps.user), validRequest())
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "регистрация документов этого вида отключена")
+		assert.Nil(t, deps.repo.createReq)
+	})
+
+	t.Run("rejects missing required field", func(t *testing.T) {
+		deps := setupCustomDocumentCommandHandler(t, contractKind(true), allowDocumentActions("contract", "create"))
+		req := validRequest()
+		req.Fields = map[string]string{"amount": "10"}
+		_, err := deps.handler.ForKind("contract").Register(principalTestContext(deps.user), req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "заполните поле «Контрагент»")
+		assert.Nil(t, deps.repo.createReq)
+	})
+
+	t.Run("rejects invalid field value", func(t *testing.T) {
+		deps := setupCustomDocumentCommandHandler(t, contractKind(true), allowDocumentActions("contract", "create"))
+		req := validRequest()
+		req.Fields["signed_at"] = "02.03.2026"
+		_, err := deps.handler.ForKind("contract").Register(principalTestContext(deps.user), req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный формат даты в поле «Дата подписания»")
+	})
+
+	t.Run("rejects empty content", func(t *testing.T) {
+		deps := setupCustomDocumentCommandHandler(t, contractKind(true), allowDocumentActions("contract", "create"))
+		req := validRequest()
+		req.Content = " "
+		_, err := deps.handler.ForKind("contract").Register(principalTestContext(deps.user), req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "укажите краткое содержание документа")
+	})
+}
+
+func TestCustomDocumentCommandHandler_ResolveOnlyCustomKinds(t *testing.T) {
+	deps := setupCustomDocumentCommandHandler(t, contractKind(true), nil)
+
+	handler, ok := deps.handler.ResolveDocumentKind("contract")
+	require.True(t, ok)
+	assert.Equal(t, models.DocumentKind("contract"), handler.Kind())
+
+	_, ok = deps.handler.ResolveDocumentKind(models.DocumentKindIncomingLetter)
+	assert.False(t, ok)
+	_, ok = deps.handler.ResolveDocumentKind("memo")
+	assert.False(t, ok)
+
+	registry := NewDocumentKindCommandRegistry()
+	registry.SetResolver(deps.handler)
+	_, err := registry.Get("memo")
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "неподдерживаемый вид документа")
+}
+
+func TestCustomDocumentCommandHandler_UpdateRejectsInvalidID(t *testing.T) {
+	deps := setupCustomDocumentCommandHandler(t, contractKind(true), allowDocumentActions("contract", "update"))
+
+	_, err := deps.handler.ForKind("contract").Update(principalTestContext(deps.user), CustomDocumentUpdateRequest{ID: "bad-id", Content: "x"})
+
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный ID документа")
+	assert.Nil(t, deps.repo.updateReq)
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// CustomDocumentKindFieldRequest описывает поле в команде сохранения пользовательского вида документа.
+type CustomDocumentKindFieldRequest struct {
+	Code       string   `json:"code"`
+	Label      string   `json:"label"`
+	FieldType  string   `json:"fieldType"`
+	IsRequired bool     `json:"isRequired"`
+	Options    []string `json:"options"`
+}
+
+// CustomDocumentKindRequest описывает команду создания или обновления пользовательского вида документа.
+type CustomDocumentKindRequest struct {
+	Code     string                           `json:"code"`
+	Name     string                           `json:"name"`
+	IsActive bool                             `json:"isActive"`
+	Fields   []CustomDocumentKindFieldRequest `json:"fields"`
+}
+
+// customDocumentKindCatalogTTL — как долго каталог видов считается актуальным,
+// прежде чем RefreshCatalog перечитает его из БД.
+const customDocumentKindCatalogTTL = 30 * time.Second
+
+// CustomDocumentKindService предоставляет администрирование пользовательских видов документов
+// и поддерживает актуальным общий каталог видов.
+type CustomDocumentKindService struct {
+	repo CustomDocumentKindStore
+	auth *AuthService
+	now  func() time.Time
+
+	catalogMu       sync.Mutex
+	catalogLoadedAt time.Time
+}
+type customDocumentKindOutboxStore interface {
+	CreateWithOutbox(models.CustomDocumentKind, []models.OutboxEvent) (*models.CustomDocumentKind, error)
+	UpdateWithOutbox(models.CustomDocumentKind, []models.OutboxEvent) (*models.CustomDocumentKind, error)
+	DeleteWithOutbox(models.DocumentKind, []models.OutboxEvent) error
+}
+
+var errCustomDocumentKindOutboxStoreRequired = fmt.Errorf("custom document kind store must support atomic outbox operations")
+
+// NewCustomDocumentKindService создает новый экземпляр CustomDocumentKindService.
+func NewCustomDocumentKindService(repo CustomDocumentKindStore, auth *AuthService) *CustomDocumentKindService {
+	return &CustomDocumentKindService{repo: repo, auth: auth, now: time.Now}
+}
+
+func (s *CustomDocumentKindService) auditEffect(key, action, details string) (models.OutboxEvent, error) {
+	userID, userName := s.auth.GetCurrentAuditInfo()
+	return NewAdminAuditOutboxEvent(key, models.CreateAdminAuditLogRequest{UserID: userID, UserName: userName, Action: action, Details: details})
+}
+
+// ReloadCatalog перечитывает пользовательские виды из БД в общий каталог видов документов.
+func (s *CustomDocumentKindService) ReloadCatalog() error {
+	kinds, err := s.repo.GetAll()
+	if err != nil {
+		return err
+	}
+	specs := make([]models.DocumentKindSpec, 0, len(kinds))
+	for _, kind := range kinds {
+		specs = append(specs, kind.Spec())
+	}
+	models.SetCustomDocumentKindSpecs(specs)
+	s.catalogMu.Lock()
+	s.catalogLoadedAt = s.now()
+	s.catalogMu.Unlock()
+	return nil
+}
+
+// RefreshCatalog перечитывает каталог, если с последней загрузки прошло больше
+// customDocumentKindCatalogTTL. Вызывается на пути запросов процессов без
+// desktop-навигации (HTTP API), чтобы виды, измененные на другом рабочем месте,
+// становились доступны без перезапуска.
+func (s *CustomDocumentKindService) RefreshCatalog() error {
+	s.catalogMu.Lock()
+	fresh := !s.catalogLoadedAt.IsZero() && s.now().Sub(s.catalogLoadedAt) < customDocumentKindCatalogTTL
+	s.catalogMu.Unlock()
+	if fresh {
+		return nil
+	}
+	return s.ReloadCatalog()
+}
+
+// GetAll возвращает все пользовательские виды документов со схемой полей.
+func (s *CustomDocumentKindService) GetAll() ([]dto.CustomDocumentKind, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapCustomDocumentKinds(res), nil
+}
+
+// GetByCode возвращает схему полей пользовательского вида для формы регистрации и фильтров.
+func (s *CustomDocumentKindService) GetByCode(code string) (*dto.CustomDocumentKind, error) {
+	if err := s.auth.RequireAuthenticated(); err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetByCode(models.DocumentKind(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, models.NewNotFound("вид документа не найден")
+	}
+	return dto.MapCustomDocumentKind(res), nil
+}
+
+// Create создает пользовательский вид документа. Новый вид сразу появляется в матрице доступа,
+// номенклатуре и справочнике типов документов.
+func (s *CustomDocumentKindService) Create(req CustomDocumentKindRequest) (*dto.CustomDocumentKind, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	kind, err := buildCustomDocumentKind(req)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := s.repo.(customDocumentKindOutboxStore)
+	if !ok {
+		return nil, errCustomDocumentKindOutboxStoreRequired
+	}
+	event, buildErr := s.auditEffect("document_kind:"+string(kind.Code)+":create:"+uuid.NewString(), "DOCKIND_CREATE", fmt.Sprintf("Создан вид документа «%s» (%s), полей: %d", kind.Name, kind.Code, len(kind.Fields)))
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	res, err := store.CreateWithOutbox(kind, []models.OutboxEvent{event})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ReloadCatalog(); err != nil {
+		return nil, err
+	}
+	return dto.MapCustomDocumentKind(res), nil
+}
+
+// Update меняет название, активность и схему полей пользовательского вида документа.
+// Деактивированный вид остается доступным для работы с документами, но не для регистрации.
+func (s *CustomDocumentKindService) Update(req CustomDocumentKindRequest) (*dto.CustomDocumentKind, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	kind, err := buildCustomDocumentKind(req)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := s.repo.(customDocumentKindOutboxStore)
+	if !ok {
+		return nil, errCustomDocumentKindOutboxStoreRequired
+	}
+	details := fmt.Sprintf("Обновлен вид документа «%s» (%s), полей: %d", kind.Name, kind.Code, len(kind.Fields))
+	if !kind.IsActive {
+		details += " (деактивирован)"
+	}
+	event, buildErr := s.auditEffect("document_kind:"+string(kind.Code)+":update:"+uuid.NewString(), "DOCKIND_UPDATE", details)
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	res, err := store.UpdateWithOutbox(kind, []models.OutboxEvent{event})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ReloadCatalog(); err != nil {
+		return nil, err
+	}
+	return dto.MapCustomDocumentKind(res), nil
+}
+
+// Delete удаляет пользовательский вид документа, по которому еще нет документов и дел.
+func (s *CustomDocumentKindService) Delete(code string) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	kind := models.DocumentKind(strings.TrimSpace(code))
+	if models.IsSystemDocumentKind(kind) {
+		return models.NewBadRequest("системный вид документа нельзя удалить")
+	}
+	store, ok := s.repo.(customDocumentKindOutboxStore)
+	if !ok {
+		return errCustomDocumentKindOutboxStoreRequired
+	}
+	event, buildErr := s.auditEffect("document_kind:"+string(kind)+":delete", "DOCKIND_DELETE", fmt.Sprintf("Удален вид документа (%s)", kind))
+	if buildErr != nil {
+		return buildErr
+	}
+	if err := store.DeleteWithOutbox(kind, []models.OutboxEvent{event}); err != nil {
+		return err
+	}
+	return s.ReloadCatalog()
+}
+
+func buildCustomDocumentKind(req CustomDocumentKindRequest) (models.CustomDocumentKind, error) {
+	kind := models.CustomDocumentKind{
+		Code:     models.DocumentKind(req.Code),
+		Name:     req.Name,
+		IsActive: req.IsActive,
+		Fields:   make([]models.CustomDocumentKindField, len(req.Fields)),
+	}
+	for i, field := range req.Fields {
+		kind.Fields[i] = models.CustomDocumentKindField{
+			Code:       field.Code,
+			Label:      field.Label,
+			FieldType:  models.CustomFieldType(strings.TrimSpace(field.FieldType)),
+			IsRequired: field.IsRequired,
+			Options:    field.Options,
+		}
+	}
+	if err := kind.Normalize(); err != nil {
+		return models.CustomDocumentKind{}, err
+	}
+	return kind, nil
+}
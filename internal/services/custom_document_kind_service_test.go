@@ -0,0 +1,234 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type customDocumentKindStoreFake struct {
+	kinds   map[models.DocumentKind]models.CustomDocumentKind
+	saved   *models.CustomDocumentKind
+	deleted models.DocumentKind
+	effects []models.OutboxEvent
+	err     error
+}
+
+func newCustomDocumentKindStoreFake(kinds ...models.CustomDocumentKind) *customDocumentKindStoreFake {
+	store := &customDocumentKindStoreFake{kinds: map[models.DocumentKind]models.CustomDocumentKind{}}
+	for _, kind := range kinds {
+		store.kinds[kind.Code] = kind
+	}
+	return store
+}
+
+func (s *customDocumentKindStoreFake) GetAll() ([]models.CustomDocumentKind, error) {
+	res := make([]models.CustomDocumentKind, 0, len(s.kinds))
+	for _, kind := range s.kinds {
+		res = append(res, kind)
+	}
+	return res, nil
+}
+
+func (s *customDocumentKindStoreFake) GetByCode(code models.DocumentKind) (*models.CustomDocumentKind, error) {
+	kind, ok := s.kinds[code]
+	if !ok {
+		return nil, nil
+	}
+	return &kind, nil
+}
+
+func (s *customDocumentKindStoreFake) Create(kind models.CustomDocumentKind) (*models.CustomDocumentKind, error) {
+	return s.CreateWithOutbox(kind, nil)
+}
+
+func (s *customDocumentKindStoreFake) Update(kind models.CustomDocumentKind) (*models.CustomDocumentKind, error) {
+	return s.UpdateWithOutbox(kind, nil)
+}
+
+func (s *customDocumentKindStoreFake) Delete(code models.DocumentKind) error {
+	return s.DeleteWithOutbox(code, nil)
+}
+
+func (s *customDocumentKindStoreFake) CreateWithOutbox(kind models.CustomDocumentKind, effects []models.OutboxEvent) (*models.CustomDocumentKind, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.saved = &kind
+	s.effects = effects
+	s.kinds[kind.Code] = kind
+	return &kind, nil
+}
+
+func (s *customDocumentKindStoreFake) UpdateWithOutbox(kind models.CustomDocumentKind, effects []models.OutboxEvent) (*models.CustomDocumentKind, error) {
+	return s.CreateWithOutbox(kind, effects)
+}
+
+func (s *customDocumentKindStoreFake) DeleteWithOutbox(code models.DocumentKind, effects []models.OutboxEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.deleted = code
+	s.effects = effects
+	delete(s.kinds, code)
+	return nil
+}
+
+func setupCustomDocumentKindService(t *testing.T, permissions ...string) (*CustomDocumentKindService, *customDocumentKindStoreFake) {
+	t.Helper()
+	t.Cleanup(func() { models.SetCustomDocumentKindSpecs(nil) })
+
+	userRepo := mocks.NewUserStore(t)
+	auth := NewAuthService(nil, userRepo)
+	auth.SetAccessStore(newRoleMappedDocumentAccessStore(permissions...))
+	user := documentAccessUser(false, nil)
+	auth.currentUserID = user.ID
+	userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+
+	store := newCustomDocumentKindStoreFake()
+	return NewCustomDocumentKindService(store, auth), store
+}
+
+func contractKindRequest() CustomDocumentKindRequest {
+	return CustomDocumentKindRequest{
+		Code:     "contract",
+		Name:     "Договор",
+		IsActive: true,
+		Fields: []CustomDocumentKindFieldRequest{
+			{Code: "counterparty", Label: "Контрагент", FieldType: "organization", IsRequired: true},
+			{Code: "amount", Label: "Сумма", FieldType: "number"},
+		},
+	}
+}
+
+func TestCustomDocumentKindService_Create(t *testing.T) {
+	t.Run("запрещено (не админ)", func(t *testing.T) {
+		svc, store := setupCustomDocumentKindService(t, "clerk")
+		result, err := svc.Create(contractKindRequest())
+		assert.Equal(t, models.ErrForbidden, err)
+		assert.Nil(t, result)
+		assert.Nil(t, store.saved)
+	})
+
+	t.Run("невалидная схема", func(t *testing.T) {
+		svc, store := setupCustomDocumentKindService(t, models.SystemPermissionAdmin)
+		req := contractKindRequest()
+		req.Fields[1].FieldType = "file"
+		_, err := svc.Create(req)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный тип поля «Сумма»")
+		assert.Nil(t, store.saved)
+	})
+
+	t.Run("системный код", func(t *testing.T) {
+		svc, _ := setupCustomDocumentKindService(t, models.SystemPermissionAdmin)
+		req := contractKindRequest()
+		req.Code = "incoming"
+		_, err := svc.Create(req)
+		requireAppError(t, err, "CONFLICT", 409, "зарезервирован")
+	})
+
+	t.Run("успех с аудитом и обновлением каталога", func(t *testing.T) {
+		svc, store := setupCustomDocumentKindService(t, models.SystemPermissionAdmin)
+		result, err := svc.Create(contractKindRequest())
+		require.NoError(t, err)
+		assert.Equal(t, "contract", result.Code)
+		require.Len(t, result.Fields, 2)
+		assert.Equal(t, 2, result.Fields[1].Position)
+		require.Len(t, store.effects, 1)
+		assert.Contains(t, store.effects[0].Payload, "DOCKIND_CREATE")
+
+		spec, ok := models.GetDocumentKindSpec("contract")
+		require.True(t, ok)
+		assert.True(t, spec.IsCustom)
+		assert.Equal(t, "Договор", spec.Name)
+	})
+}
+
+func TestCustomDocumentKindService_UpdateDeactivates(t *testing.T) {
+	svc, store := setupCustomDocumentKindService(t, models.SystemPermissionAdmin)
+	_, err := svc.Create(contractKindRequest())
+	require.NoError(t, err)
+
+	req := contractKindRequest()
+	req.IsActive = false
+	result, err := svc.Update(req)
+	require.NoError(t, err)
+	assert.False(t, result.IsActive)
+	require.Len(t, store.effects, 1)
+	assert.Contains(t, store.effects[0].Payload, "DOCKIND_UPDATE")
+	assert.Contains(t, store.effects[0].Payload, "деактивирован")
+	assert.False(t, models.DocumentKind("contract").SupportsAction(string(models.DocumentActionCreate)))
+	assert.True(t, models.DocumentKind("contract").SupportsAction(string(models.DocumentActionRead)))
+}
+
+func TestCustomDocumentKindService_Delete(t *testing.T) {
+	t.Run("системный вид", func(t *testing.T) {
+		svc, store := setupCustomDocumentKindService(t, models.SystemPermissionAdmin)
+		err := svc.Delete(string(models.DocumentKindIncomingLetter))
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "системный вид документа нельзя удалить")
+		assert.Empty(t, store.deleted)
+	})
+
+	t.Run("конфликт из хранилища", func(t *testing.T) {
+		svc, store := setupCustomDocumentKindService(t, models.SystemPermissionAdmin)
+		store.err = models.NewConflict("по виду документа есть документы или дела, его можно только деактивировать")
+		err := svc.Delete("contract")
+		requireAppError(t, err, "CONFLICT", 409, "можно только деактивировать")
+	})
+
+	t.Run("успех", func(t *testing.T) {
+		svc, store := setupCustomDocumentKindService(t, models.SystemPermissionAdmin)
+		_, err := svc.Create(contractKindRequest())
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Delete(" contract "))
+		assert.Equal(t, models.DocumentKind("contract"), store.deleted)
+		require.Len(t, store.effects, 1)
+		assert.Contains(t, store.effects[0].Payload, "DOCKIND_DELETE")
+		_, ok := models.GetDocumentKindSpec("contract")
+		assert.False(t, ok)
+	})
+}
+
+func TestCustomDocumentKindService_RefreshCatalog(t *testing.T) {
+	svc, store := setupCustomDocumentKindService(t, "clerk")
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	require.NoError(t, svc.RefreshCatalog())
+
+	store.kinds["memo"] = models.CustomDocumentKind{Code: "memo", Name: "Служебная записка", IsActive: true}
+	require.NoError(t, svc.RefreshCatalog())
+	_, ok := models.GetDocumentKindSpec("memo")
+	assert.False(t, ok, "каталог не перечитывается до истечения TTL")
+
+	now = now.Add(customDocumentKindCatalogTTL)
+	require.NoError(t, svc.RefreshCatalog())
+	_, ok = models.GetDocumentKindSpec("memo")
+	assert.True(t, ok, "вид, созданный на другом рабочем месте, появляется после TTL")
+}
+
+func TestCustomDocumentKindService_GetByCode(t *testing.T) {
+	svc, store := setupCustomDocumentKindService(t, "clerk")
+	store.kinds["memo"] = models.CustomDocumentKind{
+		Code:   "memo",
+		Name:   "Служебная записка",
+		Fields: []models.CustomDocumentKindField{{ID: uuid.New(), Code: "status", Label: "Статус", FieldType: models.CustomFieldEnum, Options: []string{"Новая"}}},
+	}
+
+	result, err := svc.GetByCode("memo")
+	require.NoError(t, err)
+	assert.Equal(t, "Служебная записка", result.Name)
+	assert.Equal(t, []string{"Новая"}, result.Fields[0].Options)
+
+	_, err = svc.GetByCode("unknown")
+	requireAppError(t, err, "NOT_FOUND", 404, "вид документа не найден")
+
+	_, err = svc.GetAll()
+	assert.Equal(t, models.ErrForbidden, err)
+}
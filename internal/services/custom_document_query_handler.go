@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// CustomDocumentQueryHandler предоставляет read-only операции по документам пользовательских видов.
+type CustomDocumentQueryHandler struct {
+	kind  models.DocumentKind
+	kinds CustomDocumentKindStore
+	repo  CustomDocumentStore
+}
+
+// NewCustomDocumentQueryHandler создает query handler документов пользовательских видов.
+func NewCustomDocumentQueryHandler(kinds CustomDocumentKindStore, repo CustomDocumentStore) *CustomDocumentQueryHandler {
+	return &CustomDocumentQueryHandler{kinds: kinds, repo: repo}
+}
+
+// ForKind возвращает обработчик, привязанный к пользовательскому виду документа.
+func (h *CustomDocumentQueryHandler) ForKind(kind models.DocumentKind) *CustomDocumentQueryHandler {
+	bound := *h
+	bound.kind = kind
+	return &bound
+}
+
+// ResolveDocumentKind возвращает обработчик для пользовательского вида из каталога видов.
+func (h *CustomDocumentQueryHandler) ResolveDocumentKind(kind models.DocumentKind) (DocumentKindQueryHandler, bool) {
+	spec, ok := models.GetDocumentKindSpec(kind)
+	if !ok || !spec.IsCustom {
+		return nil, false
+	}
+	return h.ForKind(kind), true
+}
+
+// Kind возвращает вид документа, к которому привязан обработчик.
+func (h *CustomDocumentQueryHandler) Kind() models.DocumentKind {
+	return h.kind
+}
+
+// GetCard возвращает карточку документа пользовательского вида.
+func (h *CustomDocumentQueryHandler) GetCard(id uuid.UUID) (*dto.DocumentCard, error) {
+	doc, err := h.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.Kind != h.kind {
+		return nil, models.NewNotFound("документ не найден")
+	}
+	return dto.MapCustomDocumentCard(doc), nil
+}
+
+// GetList возвращает список документов пользовательского вида с фильтрами по полям схемы.
+func (h *CustomDocumentQueryHandler) GetList(filter models.DocumentFilter) (*dto.PagedResult[dto.DocumentListItem], error) {
+	if len(filter.CustomFields) > 0 {
+		customFields, err := h.resolveFieldFilters(filter.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		filter.CustomFields = customFields
+	}
+
+	result, err := h.repo.GetList(h.kind, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PagedResult[dto.DocumentListItem]{
+		Items:      dto.MapDocumentListItemsFromCustomDocuments(result.Items),
+		TotalCount: result.TotalCount,
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		NextCursor: result.NextCursor,
+		HasMore:    result.HasMore,
+	}, nil
+}
+
+// resolveFieldFilters подставляет типы полей из схемы вида и приводит значения фильтра
+// к формату хранения. Пустые условия отбрасываются.
+func (h *CustomDocumentQueryHandler) resolveFieldFilters(filters []models.CustomFieldFilter) ([]models.CustomFieldFilter, error) {
+	kind, err := h.kinds.GetByCode(h.kind)
+	if err != nil {
+		return nil, err
+	}
+	if kind == nil {
+		return nil, models.NewNotFound("вид документа не найден")
+	}
+
+	result := make([]models.CustomFieldFilter, 0, len(filters))
+	for _, filter := range filters {
+		field, ok := kind.Field(filter.Code)
+		if !ok {
+			return nil, models.NewBadRequest(fmt.Sprintf("неизвестное поле фильтра «%s»", filter.Code))
+		}
+		resolved := models.CustomFieldFilter{Code: field.Code, Type: field.FieldType}
+		if resolved.Value, err = models.NormalizeCustomFieldFilterValue(field, filter.Value); err != nil {
+			return nil, err
+		}
+		if field.FieldType == models.CustomFieldDate || field.FieldType == models.CustomFieldNumber {
+			if resolved.From, err = models.NormalizeCustomFieldFilterValue(field, filter.From); err != nil {
+				return nil, err
+			}
+			if resolved.To, err = models.NormalizeCustomFieldFilterValue(field, filter.To); err != nil {
+				return nil, err
+			}
+		}
+		if resolved.Value == "" && resolved.From == "" && resolved.To == "" {
+			continue
+		}
+		result = append(result, resolved)
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func setupCustomDocumentQueryHandler(t *testing.T) (*CustomDocumentQueryHandler, *customDocumentStoreFake) {
+	t.Helper()
+	kind := contractKind(true)
+	kind.Fields = append(kind.Fields,
+		models.CustomDocumentKindField{Code: "status", Label: "Статус", FieldType: models.CustomFieldEnum, Options: []string{"Проект", "Подписан"}},
+		models.CustomDocumentKindField{Code: "note", Label: "Примечание", FieldType: models.CustomFieldText},
+	)
+	models.SetCustomDocumentKindSpecs([]models.DocumentKindSpec{kind.Spec()})
+	t.Cleanup(func() { models.SetCustomDocumentKindSpecs(nil) })
+
+	repo := &customDocumentStoreFake{docs: map[uuid.UUID]models.CustomDocument{}}
+	return NewCustomDocumentQueryHandler(newCustomDocumentKindStoreFake(kind), repo).ForKind("contract"), repo
+}
+
+func TestCustomDocumentQueryHandler_GetListResolvesFieldFilters(t *testing.T) {
+	handler, repo := setupCustomDocumentQueryHandler(t)
+	docID := uuid.New()
+	repo.docs[docID] = models.CustomDocument{
+		ID:                 docID,
+		Kind:               "contract",
+		RegistrationNumber: "Д-1",
+		RegistrationDate:   time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Fields: []models.CustomDocumentFieldValue{
+			{Code: "amount", Label: "Сумма", FieldType: models.CustomFieldNumber, Value: "10", DisplayValue: "10"},
+		},
+	}
+
+	result, err := handler.GetList(models.DocumentFilter{
+		Page:     1,
+		PageSize: 20,
+		CustomFields: []models.CustomFieldFilter{
+			{Code: "amount", From: "1 000,5"},
+			{Code: "signed_at", From: "2026-01-01", To: "2026-12-31"},
+			{Code: "status", Value: "Подписан"},
+			{Code: "note", Value: " срочно ", From: "ignored"},
+			{Code: "counterparty"},
+		},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "Договор", result.Items[0].KindName)
+	require.Len(t, result.Items[0].CustomFields, 1)
+	assert.Equal(t, models.DocumentKind("contract"), repo.listKind)
+	require.NotNil(t, repo.listFilter)
+	assert.Equal(t, []models.CustomFieldFilter{
+		{Code: "amount", From: "1000.5", Type: models.CustomFieldNumber},
+		{Code: "signed_at", From: "2026-01-01", To: "2026-12-31", Type: models.CustomFieldDate},
+		{Code: "status", Value: "Подписан", Type: models.CustomFieldEnum},
+		{Code: "note", Value: "срочно", Type: models.CustomFieldText},
+	}, repo.listFilter.CustomFields)
+}
+
+func TestCustomDocumentQueryHandler_GetListRejectsInvalidFilters(t *testing.T) {
+	handler, repo := setupCustomDocumentQueryHandler(t)
+
+	_, err := handler.GetList(models.DocumentFilter{CustomFields: []models.CustomFieldFilter{{Code: "unknown", Value: "x"}}})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "неизвестное поле фильтра «unknown»")
+
+	_, err = handler.GetList(models.DocumentFilter{CustomFields: []models.CustomFieldFilter{{Code: "status", Value: "Расторгнут"}}})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "недопустимое значение поля «Статус»")
+	assert.Nil(t, repo.listFilter)
+}
+
+func TestCustomDocumentQueryHandler_GetCardChecksKind(t *testing.T) {
+	handler, repo := setupCustomDocumentQueryHandler(t)
+	docID := uuid.New()
+	otherID := uuid.New()
+	repo.docs[docID] = models.CustomDocument{ID: docID, Kind: "contract", Content: "Договор поставки"}
+	repo.docs[otherID] = models.CustomDocument{ID: otherID, Kind: "memo"}
+
+	card, err := handler.GetCard(docID)
+	require.NoError(t, err)
+	assert.Equal(t, "Договор поставки", card.Content)
+	require.NotNil(t, card.CustomDocument)
+	assert.Equal(t, "contract", card.CustomDocument.KindCode)
+
+	_, err = handler.GetCard(otherID)
+	requireAppError(t, err, "NOT_FOUND", 404, "документ не найден")
+
+	registry := NewDocumentKindQueryRegistry()
+	registry.SetResolver(handler)
+	resolved, err := registry.Get("contract")
+	require.NoError(t, err)
+	assert.Equal(t, models.DocumentKind("contract"), resolved.Kind())
+	_, err = registry.Get(models.DocumentKindIncomingLetter)
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "неподдерживаемый вид документа")
+}
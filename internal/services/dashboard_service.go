@@ -1,6 +1,8 @@
 package services
 
 import (
+	"errors"
+
 	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/observability"
@@ -8,10 +10,12 @@ import (
 
 // DashboardService предоставляет данные текущей активности для дашборда.
 type DashboardService struct {
-	repo    DashboardStore
-	auth    *AuthService
-	access  *DocumentAccessService
-	metrics *observability.Registry
+	repo            DashboardStore
+	auth            *AuthService
+	access          *DocumentAccessService
+	appealDeadlines CitizenAppealDeadlineStore
+	appealPolicy    *CitizenAppealDeadlinePolicy
+	metrics         *observability.Registry
 }
 
 func (s *DashboardService) SetOperationMetrics(metrics *observability.Registry) {
@@ -23,14 +27,20 @@ func NewDashboardService(repo DashboardStore, auth *AuthService, access *Documen
 	return &DashboardService{repo: repo, auth: auth, access: access}
 }
 
+// SetCitizenAppealDeadlines подключает счетчики сроков ответа на обращения граждан.
+func (s *DashboardService) SetCitizenAppealDeadlines(store CitizenAppealDeadlineStore, policy *CitizenAppealDeadlinePolicy) {
+	s.appealDeadlines = store
+	s.appealPolicy = policy
+}
+
 // GetActivity возвращает оперативные данные для главного экрана.
 func (s *DashboardService) GetActivity() (*dto.DashboardActivity, error) {
 	return measureOperation(s.metrics, "dashboard.get_activity", func() (*dto.DashboardActivity, error) {
-		if err := s.auth.RequireAuthenticated(); err != nil {
+		ctx, err := s.auth.sessionContext()
+		if err != nil {
 			return nil, err
 		}
-
-		user, err := s.auth.GetCurrentUser()
+		principal, err := requirePrincipal(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -43,29 +53,23 @@ func (s *DashboardService) GetActivity() (*dto.DashboardActivity, error) {
 			return activity, nil
 		}
 
-		readableKinds, err := s.access.GetDocumentKindsWithAction("read")
+		readableKinds, err := s.access.GetDocumentKindsWithAction(ctx, "read")
 		if err != nil {
 			return nil, err
 		}
-		if len(readableKinds) == 0 && !user.IsDocumentParticipant {
+		if len(readableKinds) == 0 && !principal.IsDocumentParticipant {
 			return activity, nil
 		}
 
 		filter := models.DashboardAssignmentFilter{Days: 7}
-		if user.IsDocumentParticipant {
+		if principal.IsDocumentParticipant {
 			filter.Days = 3
-			subjectIDs, err := s.access.getCurrentUserAndSubstitutionSubjectIDs()
-			if err != nil {
-				return nil, err
-			}
-			filter.AccessibleByUserIDs = uuidStrings(subjectIDs)
+			filter.AccessibleByUserIDs = uuidStrings(principal.SubjectIDsFor(models.SubstitutionScopeAssignments, ""))
+			filter.AccessibleByUserKindKeys = principal.KindScopedSubjectKeys(models.SubstitutionScopeAssignments)
 		} else if len(readableKinds) < len(models.AllDocumentKindSpecs()) {
 			filter.AllowedDocumentKinds = documentKindCodes(readableKinds)
-			subjectIDs, err := s.access.getCurrentUserAndSubstitutionSubjectIDs()
-			if err != nil {
-				return nil, err
-			}
-			filter.AccessibleByUserIDs = uuidStrings(subjectIDs)
+			filter.AccessibleByUserIDs = uuidStrings(principal.SubjectIDsFor(models.SubstitutionScopeAssignments, ""))
+			filter.AccessibleByUserKindKeys = principal.KindScopedSubjectKeys(models.SubstitutionScopeAssignments)
 		}
 
 		assignments, err := s.repo.GetExpiringAssignments(filter)
@@ -79,3 +83,27 @@ func (s *DashboardService) GetActivity() (*dto.DashboardActivity, error) {
 		return activity, nil
 	})
 }
+
+// GetCitizenAppealDeadlines возвращает счетчики незакрытых, подходящих к сроку
+// и просроченных обращений граждан в пределах доступа текущего пользователя.
+func (s *DashboardService) GetCitizenAppealDeadlines() (*models.CitizenAppealDeadlineCounters, error) {
+	return measureOperation(s.metrics, "dashboard.get_citizen_appeal_deadlines", func() (*models.CitizenAppealDeadlineCounters, error) {
+		ctx, err := s.auth.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+		if s.access == nil || s.appealDeadlines == nil {
+			return &models.CitizenAppealDeadlineCounters{}, nil
+		}
+
+		scope, err := s.access.ResolveReadScope(ctx, models.DocumentKindCitizenAppeal)
+		if errors.Is(err, models.ErrForbidden) {
+			return &models.CitizenAppealDeadlineCounters{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return s.appealDeadlines.GetDeadlineCounters(*scope, s.appealPolicy.DueSoonDays())
+	})
+}
@@ -93,10 +93,8 @@ func TestDashboardService_GetActivity(t *testing.T) {
 		_, err := auth.Login(user.Login, password)
 		require.NoError(t, err)
 		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
-		access := NewDocumentAccessService(
-			auth, nil, nil, nil, accessStore, nil, nil, nil,
-			&userSubstitutionStoreStub{activePrincipals: []uuid.UUID{principalID}},
-		)
+		auth.SetSubstitutionStore(&userSubstitutionStoreStub{activePrincipals: []uuid.UUID{principalID}})
+		access := NewDocumentAccessService(auth, nil, nil, nil, accessStore, nil, nil, nil)
 		svc := NewDashboardService(repo, auth, access)
 
 		repo.On("GetExpiringAssignments", mock.MatchedBy(func(filter models.DashboardAssignmentFilter) bool {
@@ -17,6 +17,9 @@ type departmentOutboxStore interface {
 	CreateWithOutbox(string, []string, []models.OutboxEvent) (*models.Department, error)
 	UpdateWithOutbox(uuid.UUID, string, []string, []models.OutboxEvent) (*models.Department, error)
 	DeleteWithOutbox(uuid.UUID, []models.OutboxEvent) error
+	MoveWithOutbox(uuid.UUID, *uuid.UUID, []models.OutboxEvent) error
+	SetHeadWithOutbox(uuid.UUID, *uuid.UUID, []models.OutboxEvent) error
+	MergeWithOutbox(uuid.UUID, uuid.UUID, []models.OutboxEvent) error
 }
 
 var errDepartmentOutboxStoreRequired = fmt.Errorf("department store must support atomic outbox operations")
@@ -114,3 +117,156 @@ func (s *DepartmentService) DeleteDepartment(id string) error {
 
 	return nil
 }
+
+// MoveDepartment переносит подразделение под вышестоящее parentID; пустой parentID
+// переносит его на верхний уровень. Подчинить подразделение самому себе или своему
+// подчиненному подразделению нельзя.
+func (s *DepartmentService) MoveDepartment(id, parentID string) (*dto.Department, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID отдела", err)
+	}
+	var parentUID *uuid.UUID
+	if parentID != "" {
+		parsed, err := uuid.Parse(parentID)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный ID вышестоящего отдела", err)
+		}
+		parentUID = &parsed
+	}
+	store, ok := s.repo.(departmentOutboxStore)
+	if !ok {
+		return nil, errDepartmentOutboxStoreRequired
+	}
+	departments, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	department := findDepartment(departments, uid)
+	if department == nil {
+		return nil, models.NewNotFound("подразделение не найдено")
+	}
+	details := fmt.Sprintf("Подразделение «%s» перенесено на верхний уровень", department.Name)
+	if parentUID != nil {
+		parent := findDepartment(departments, *parentUID)
+		if parent == nil {
+			return nil, models.NewNotFound("вышестоящее подразделение не найдено")
+		}
+		if models.IsDepartmentInSubtree(departments, uid, *parentUID) {
+			return nil, models.NewConflict("подразделение нельзя подчинить самому себе или своему подчиненному подразделению")
+		}
+		details = fmt.Sprintf("Подразделение «%s» подчинено подразделению «%s»", department.Name, parent.Name)
+	}
+	userID, userName := s.auth.GetCurrentAuditInfo()
+	event, err := NewAdminAuditOutboxEvent("department:"+uid.String()+":move:"+uuid.NewString(), models.CreateAdminAuditLogRequest{UserID: userID, UserName: userName, Action: "DEPT_MOVE", Details: details})
+	if err != nil {
+		return nil, err
+	}
+	if err := store.MoveWithOutbox(uid, parentUID, []models.OutboxEvent{event}); err != nil {
+		return nil, err
+	}
+	moved := *department
+	moved.ParentID = parentUID
+	return dto.MapDepartment(&moved), nil
+}
+
+// SetDepartmentHead назначает руководителя подразделения; пустой headUserID снимает
+// руководителя. Руководитель видит документы всех подчиненных подразделений.
+func (s *DepartmentService) SetDepartmentHead(id, headUserID string) (*dto.Department, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID отдела", err)
+	}
+	var headUID *uuid.UUID
+	if headUserID != "" {
+		parsed, err := uuid.Parse(headUserID)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный ID пользователя", err)
+		}
+		headUID = &parsed
+	}
+	store, ok := s.repo.(departmentOutboxStore)
+	if !ok {
+		return nil, errDepartmentOutboxStoreRequired
+	}
+	departments, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	department := findDepartment(departments, uid)
+	if department == nil {
+		return nil, models.NewNotFound("подразделение не найдено")
+	}
+	details := fmt.Sprintf("Снят руководитель подразделения «%s»", department.Name)
+	if headUID != nil {
+		details = fmt.Sprintf("Назначен руководитель подразделения «%s» (ID: %s)", department.Name, headUID)
+	}
+	userID, userName := s.auth.GetCurrentAuditInfo()
+	event, err := NewAdminAuditOutboxEvent("department:"+uid.String()+":head:"+uuid.NewString(), models.CreateAdminAuditLogRequest{UserID: userID, UserName: userName, Action: "DEPT_HEAD", Details: details})
+	if err != nil {
+		return nil, err
+	}
+	if err := store.SetHeadWithOutbox(uid, headUID, []models.OutboxEvent{event}); err != nil {
+		return nil, err
+	}
+	updated := *department
+	updated.HeadUserID = headUID
+	return dto.MapDepartment(&updated), nil
+}
+
+// MergeDepartments объединяет подразделение sourceID с targetID: сотрудники,
+// номенклатура, подчиненные подразделения, роли и правила доступа переходят в
+// targetID, а sourceID удаляется.
+func (s *DepartmentService) MergeDepartments(sourceID, targetID string) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	sourceUID, err := uuid.Parse(sourceID)
+	if err != nil {
+		return models.NewBadRequestWrapped("неверный ID отдела", err)
+	}
+	targetUID, err := uuid.Parse(targetID)
+	if err != nil {
+		return models.NewBadRequestWrapped("неверный ID отдела", err)
+	}
+	if sourceUID == targetUID {
+		return models.NewBadRequest("подразделение нельзя объединить само с собой")
+	}
+	store, ok := s.repo.(departmentOutboxStore)
+	if !ok {
+		return errDepartmentOutboxStoreRequired
+	}
+	departments, err := s.repo.GetAll()
+	if err != nil {
+		return err
+	}
+	source := findDepartment(departments, sourceUID)
+	target := findDepartment(departments, targetUID)
+	if source == nil || target == nil {
+		return models.NewNotFound("подразделение не найдено")
+	}
+	if models.IsDepartmentInSubtree(departments, sourceUID, targetUID) {
+		return models.NewConflict("подразделение нельзя объединить с его подчиненным подразделением")
+	}
+	userID, userName := s.auth.GetCurrentAuditInfo()
+	event, err := NewAdminAuditOutboxEvent("department:"+sourceUID.String()+":merge", models.CreateAdminAuditLogRequest{UserID: userID, UserName: userName, Action: "DEPT_MERGE", Details: fmt.Sprintf("Подразделение «%s» объединено с подразделением «%s»", source.Name, target.Name)})
+	if err != nil {
+		return err
+	}
+	return store.MergeWithOutbox(sourceUID, targetUID, []models.OutboxEvent{event})
+}
+
+func findDepartment(departments []models.Department, id uuid.UUID) *models.Department {
+	for i := range departments {
+		if departments[i].ID == id {
+			return &departments[i]
+		}
+	}
+	return nil
+}
@@ -66,6 +66,9 @@ func setupDepartmentServiceWithRoles(t *testing.T, roles []string) (*DepartmentS
 type atomicDepartmentStore struct {
 	*mocks.DepartmentStore
 	effects []models.OutboxEvent
+	moved   map[uuid.UUID]*uuid.UUID
+	heads   map[uuid.UUID]*uuid.UUID
+	merged  [][2]uuid.UUID
 }
 
 func (s *atomicDepartmentStore) CreateWithOutbox(name string, nomenclatureIDs []string, effects []models.OutboxEvent) (*models.Department, error) {
@@ -83,6 +86,39 @@ func (s *atomicDepartmentStore) DeleteWithOutbox(id uuid.UUID, effects []models.
 	return s.DepartmentStore.Delete(id)
 }
 
+func (s *atomicDepartmentStore) MoveWithOutbox(id uuid.UUID, parentID *uuid.UUID, effects []models.OutboxEvent) error {
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	if s.moved == nil {
+		s.moved = make(map[uuid.UUID]*uuid.UUID)
+	}
+	s.moved[id] = parentID
+	return nil
+}
+
+func (s *atomicDepartmentStore) SetHeadWithOutbox(id uuid.UUID, headUserID *uuid.UUID, effects []models.OutboxEvent) error {
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	if s.heads == nil {
+		s.heads = make(map[uuid.UUID]*uuid.UUID)
+	}
+	s.heads[id] = headUserID
+	return nil
+}
+
+func (s *atomicDepartmentStore) MergeWithOutbox(sourceID, targetID uuid.UUID, effects []models.OutboxEvent) error {
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	s.merged = append(s.merged, [2]uuid.UUID{sourceID, targetID})
+	return nil
+}
+
+// departmentTree возвращает цепочку «управление → отдел → сектор» и отдельное подразделение.
+func departmentTree() (directorate, department, sector, other models.Department) {
+	directorate = models.Department{ID: uuid.New(), Name: "Управление"}
+	department = models.Department{ID: uuid.New(), Name: "Отдел", ParentID: &directorate.ID}
+	sector = models.Department{ID: uuid.New(), Name: "Сектор", ParentID: &department.ID}
+	other = models.Department{ID: uuid.New(), Name: "Канцелярия"}
+	return directorate, department, sector, other
+}
+
 func TestDepartmentService_GetAllDepartments(t *testing.T) {
 	// Получение списка всех подразделений организации
 	t.Run("успех", func(t *testing.T) {
@@ -246,3 +282,122 @@ func TestDepartmentService_DeleteDepartment(t *testing.T) {
 		assert.Equal(t, models.ErrForbidden, err)
 	})
 }
+
+func TestDepartmentService_MoveDepartment(t *testing.T) {
+	// Перенос подразделения в дереве с защитой от циклов
+	directorate, department, sector, other := departmentTree()
+	all := []models.Department{directorate, department, sector, other}
+
+	t.Run("успех", func(t *testing.T) {
+		svc, repo, _ := setupDepartmentService(t, "admin")
+		store := svc.repo.(*atomicDepartmentStore)
+		repo.On("GetAll").Return(all, nil).Once()
+
+		result, err := svc.MoveDepartment(sector.ID.String(), other.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, other.ID.String(), result.ParentID)
+		assert.Equal(t, &other.ID, store.moved[sector.ID])
+		require.Len(t, store.effects, 1)
+		assert.Contains(t, store.effects[0].Payload, "DEPT_MOVE")
+	})
+
+	t.Run("на верхний уровень", func(t *testing.T) {
+		svc, repo, _ := setupDepartmentService(t, "admin")
+		store := svc.repo.(*atomicDepartmentStore)
+		repo.On("GetAll").Return(all, nil).Once()
+
+		result, err := svc.MoveDepartment(department.ID.String(), "")
+		require.NoError(t, err)
+		assert.Empty(t, result.ParentID)
+		assert.Nil(t, store.moved[department.ID])
+	})
+
+	t.Run("под подчиненное подразделение", func(t *testing.T) {
+		svc, repo, _ := setupDepartmentService(t, "admin")
+		store := svc.repo.(*atomicDepartmentStore)
+		repo.On("GetAll").Return(all, nil).Twice()
+
+		_, err := svc.MoveDepartment(directorate.ID.String(), sector.ID.String())
+		requireAppError(t, err, "CONFLICT", 409, "подчиненному подразделению")
+		_, err = svc.MoveDepartment(directorate.ID.String(), directorate.ID.String())
+		requireAppError(t, err, "CONFLICT", 409, "самому себе")
+		assert.Empty(t, store.moved)
+	})
+
+	t.Run("вышестоящее не найдено", func(t *testing.T) {
+		svc, repo, _ := setupDepartmentService(t, "admin")
+		repo.On("GetAll").Return(all, nil).Once()
+
+		_, err := svc.MoveDepartment(sector.ID.String(), uuid.NewString())
+		requireAppError(t, err, "NOT_FOUND", 404, "вышестоящее подразделение не найдено")
+	})
+
+	t.Run("запрещено (не админ)", func(t *testing.T) {
+		svc, _, _ := setupDepartmentService(t, "clerk")
+		_, err := svc.MoveDepartment(sector.ID.String(), "")
+		assert.Equal(t, models.ErrForbidden, err)
+	})
+}
+
+func TestDepartmentService_SetDepartmentHead(t *testing.T) {
+	// Назначение и снятие руководителя подразделения
+	_, department, _, _ := departmentTree()
+	headID := uuid.New()
+
+	svc, repo, _ := setupDepartmentService(t, "admin")
+	store := svc.repo.(*atomicDepartmentStore)
+	repo.On("GetAll").Return([]models.Department{department}, nil).Twice()
+
+	result, err := svc.SetDepartmentHead(department.ID.String(), headID.String())
+	require.NoError(t, err)
+	assert.Equal(t, headID.String(), result.HeadUserID)
+	assert.Equal(t, &headID, store.heads[department.ID])
+	assert.Contains(t, store.effects[0].Payload, "DEPT_HEAD")
+
+	result, err = svc.SetDepartmentHead(department.ID.String(), "")
+	require.NoError(t, err)
+	assert.Empty(t, result.HeadUserID)
+	assert.Nil(t, store.heads[department.ID])
+
+	_, err = svc.SetDepartmentHead(department.ID.String(), "bad")
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный ID пользователя")
+}
+
+func TestDepartmentService_MergeDepartments(t *testing.T) {
+	// Слияние подразделений
+	directorate, department, sector, other := departmentTree()
+	all := []models.Department{directorate, department, sector, other}
+
+	t.Run("успех", func(t *testing.T) {
+		svc, repo, _ := setupDepartmentService(t, "admin")
+		store := svc.repo.(*atomicDepartmentStore)
+		repo.On("GetAll").Return(all, nil).Once()
+
+		require.NoError(t, svc.MergeDepartments(sector.ID.String(), other.ID.String()))
+		assert.Equal(t, [][2]uuid.UUID{{sector.ID, other.ID}}, store.merged)
+		assert.Contains(t, store.effects[0].Payload, "DEPT_MERGE")
+	})
+
+	t.Run("вышестоящее в подчиненное", func(t *testing.T) {
+		svc, repo, _ := setupDepartmentService(t, "admin")
+		store := svc.repo.(*atomicDepartmentStore)
+		repo.On("GetAll").Return(all, nil).Once()
+
+		err := svc.MergeDepartments(directorate.ID.String(), sector.ID.String())
+		requireAppError(t, err, "CONFLICT", 409, "подчиненным подразделением")
+		assert.Empty(t, store.merged)
+	})
+
+	t.Run("само с собой", func(t *testing.T) {
+		svc, _, _ := setupDepartmentService(t, "admin")
+		err := svc.MergeDepartments(sector.ID.String(), sector.ID.String())
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "само с собой")
+	})
+
+	t.Run("не найдено", func(t *testing.T) {
+		svc, repo, _ := setupDepartmentService(t, "admin")
+		repo.On("GetAll").Return(all, nil).Once()
+		err := svc.MergeDepartments(uuid.NewString(), sector.ID.String())
+		requireAppError(t, err, "NOT_FOUND", 404, "подразделение не найдено")
+	})
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+const directoryAuditUser = "Синхронизация с каталогом"
+
+// DirectoryService — вход пользователей внешнего каталога и синхронизация их
+// учетных записей. Реализует Authenticator: при успешной проверке пароля
+// пользователь создается (just-in-time) или обновляется по сопоставлению
+// групп, а фоновая синхронизация деактивирует пропавших из каталога.
+//
+// Каталог — источник истины для ФИО, логина, признака участника
+// документооборота и системных прав; подразделение перезаписывается, только
+// если его задает одна из групп пользователя.
+type DirectoryService struct {
+	directory    UserDirectory
+	store        DirectoryUserStore
+	policy       models.DirectoryPolicy
+	authSource   string
+	syncInterval time.Duration
+}
+
+// directorySyncReport — итог одного прохода синхронизации.
+type directorySyncReport struct {
+	Updated     int
+	Deactivated int
+	Skipped     int
+}
+
+// NewDirectoryService создает сервис каталога authSource. syncInterval == 0
+// отключает фоновую синхронизацию.
+func NewDirectoryService(directory UserDirectory, store DirectoryUserStore, authSource string, policy models.DirectoryPolicy, syncInterval time.Duration) *DirectoryService {
+	return &DirectoryService{
+		directory:    directory,
+		store:        store,
+		policy:       policy,
+		authSource:   authSource,
+		syncInterval: syncInterval,
+	}
+}
+
+// AuthSource возвращает источник учетных записей, которые ведет сервис.
+func (s *DirectoryService) AuthSource() string {
+	return s.authSource
+}
+
+// Authenticate проверяет пароль в каталоге и возвращает созданного или
+// обновленного пользователя. Деактивированный пользователь возвращается как
+// есть: решение о входе принимает AuthService.
+func (s *DirectoryService) Authenticate(ctx context.Context, login, password string) (*models.User, error) {
+	entry, err := s.directory.Authenticate(ctx, login, password)
+	if err != nil {
+		return nil, err
+	}
+	sync, allowed := s.mapUser(entry)
+	if !allowed {
+		return nil, models.NewForbidden("учетная запись каталога не входит в группу доступа к системе")
+	}
+
+	event, err := NewAdminAuditOutboxEvent("user:"+uuid.NewString()+":directory-create", models.CreateAdminAuditLogRequest{
+		UserName: directoryAuditUser,
+		Action:   "USER_CREATE",
+		Details:  fmt.Sprintf("Создан пользователь каталога «%s» (%s) при первом входе", sync.FullName, sync.Login),
+	})
+	if err != nil {
+		return nil, err
+	}
+	user, _, err := s.syncUser(sync, []models.OutboxEvent{event})
+	return user, err
+}
+
+// RunSync периодически сверяет активных пользователей каталога с каталогом.
+// Метод блокируется до отмены ctx, поэтому запускается фоновым жизненным
+// циклом приложения.
+func (s *DirectoryService) RunSync(ctx context.Context) {
+	if s.syncInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.syncInterval)
+	defer ticker.Stop()
+	for {
+		report, err := s.syncUsers(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("directory user sync failed", "source", s.authSource, "error", err)
+		}
+		if report.Deactivated > 0 || report.Skipped > 0 {
+			slog.Info("directory user sync finished", "source", s.authSource,
+				"updated", report.Updated, "deactivated", report.Deactivated, "skipped", report.Skipped)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncUsers обновляет атрибуты и права найденных в каталоге пользователей и
+// деактивирует удаленных из каталога или исключенных из группы доступа.
+// Ошибка отдельного пользователя не останавливает проход по остальным.
+// Новые пользователи здесь не создаются — только при первом входе.
+func (s *DirectoryService) syncUsers(ctx context.Context) (directorySyncReport, error) {
+	var report directorySyncReport
+	entries, err := s.directory.ListUsers(ctx)
+	if err != nil {
+		return report, err
+	}
+	users, err := s.store.GetActiveDirectoryUsers(s.authSource)
+	if err != nil {
+		return report, err
+	}
+	// Пустой ответ при наличии пользователей почти всегда означает ошибку
+	// настройки (baseDN, фильтр), а не увольнение всех сотрудников.
+	if len(entries) == 0 && len(users) > 0 {
+		return report, fmt.Errorf("directory returned no users; deactivation of %d users skipped", len(users))
+	}
+
+	byExternalID := make(map[string]*models.DirectoryUser, len(entries))
+	for i := range entries {
+		byExternalID[entries[i].ExternalID] = &entries[i]
+	}
+
+	var errs []error
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+		if entry, ok := byExternalID[user.ExternalID]; ok {
+			if sync, allowed := s.mapUser(entry); allowed {
+				if _, _, err := s.syncUser(sync, nil); err != nil {
+					errs = append(errs, fmt.Errorf("sync user %s: %w", user.Login, err))
+					continue
+				}
+				report.Updated++
+				continue
+			}
+		}
+
+		if err := s.deactivate(user); err != nil {
+			if isActiveAdministratorInvariantViolation(err) {
+				slog.Warn("directory user kept active: last active administrator", "source", s.authSource, "login", user.Login)
+				report.Skipped++
+				continue
+			}
+			errs = append(errs, fmt.Errorf("deactivate user %s: %w", user.Login, err))
+			continue
+		}
+		report.Deactivated++
+	}
+	return report, errors.Join(errs...)
+}
+
+// syncUser записывает состояние пользователя из каталога. Если каталог лишает
+// права admin последнего активного администратора, право сохраняется, чтобы
+// система не осталась без администратора.
+func (s *DirectoryService) syncUser(sync models.DirectoryUserSync, createEffects []models.OutboxEvent) (*models.User, bool, error) {
+	user, created, err := s.store.SyncDirectoryUserWithOutbox(sync, createEffects)
+	if !isActiveAdministratorInvariantViolation(err) {
+		return user, created, err
+	}
+	slog.Warn("directory user keeps admin permission: last active administrator", "source", s.authSource, "login", sync.Login)
+	sync.SystemPermissions = append(sync.SystemPermissions, models.SystemPermissionAdmin)
+	return s.store.SyncDirectoryUserWithOutbox(sync, createEffects)
+}
+
+func (s *DirectoryService) deactivate(user models.User) error {
+	name := user.FullName
+	if name == "" {
+		name = user.Login
+	}
+	event, err := NewAdminAuditOutboxEvent("user:"+user.ID.String()+":directory-deactivate:"+uuid.NewString(), models.CreateAdminAuditLogRequest{
+		UserName: directoryAuditUser,
+		Action:   "USER_UPDATE",
+		Details:  fmt.Sprintf("Пользователь «%s» (%s) деактивирован: учетная запись удалена из каталога или исключена из группы доступа", name, user.Login),
+	})
+	if err != nil {
+		return err
+	}
+	return s.store.DeactivateDirectoryUserWithOutbox(user.ID, []models.OutboxEvent{event})
+}
+
+// mapUser применяет сопоставление групп. allowed == false, если пользователь
+// не входит в обязательную группу. Подразделение берется из первой
+// подходящей группы в порядке настройки.
+func (s *DirectoryService) mapUser(entry *models.DirectoryUser) (models.DirectoryUserSync, bool) {
+	groups := make(map[string]struct{}, len(entry.Groups))
+	for _, group := range entry.Groups {
+		groups[strings.ToLower(strings.TrimSpace(group))] = struct{}{}
+	}
+	memberOf := func(group string) bool {
+		_, ok := groups[strings.ToLower(strings.TrimSpace(group))]
+		return ok
+	}
+	if s.policy.RequiredGroup != "" && !memberOf(s.policy.RequiredGroup) {
+		return models.DirectoryUserSync{}, false
+	}
+
+	sync := models.DirectoryUserSync{
+		AuthSource:        s.authSource,
+		ExternalID:        entry.ExternalID,
+		Login:             entry.Login,
+		FullName:          entry.FullName,
+		SystemPermissions: []string{},
+	}
+	for _, mapping := range s.policy.Groups {
+		if !memberOf(mapping.Group) {
+			continue
+		}
+		for _, permission := range mapping.SystemPermissions {
+			if !slices.Contains(sync.SystemPermissions, permission) {
+				sync.SystemPermissions = append(sync.SystemPermissions, permission)
+			}
+		}
+		if sync.DepartmentID == nil && mapping.DepartmentID != nil {
+			sync.DepartmentID = mapping.DepartmentID
+		}
+		sync.IsDocumentParticipant = sync.IsDocumentParticipant || mapping.DocumentParticipant
+	}
+	slices.Sort(sync.SystemPermissions)
+	return sync, true
+}
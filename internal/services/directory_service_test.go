@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/directory"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/testutil/ldapstub"
+)
+
+const (
+	testDirectoryBaseDN    = "dc=example,dc=local"
+	testDirectoryServiceDN = "cn=svc-docflow,ou=service,dc=example,dc=local"
+	testDirectoryUsersDN   = "cn=Docflow-Users,ou=groups,dc=example,dc=local"
+	testDirectoryAdminsDN  = "cn=Docflow-Admins,ou=groups,dc=example,dc=local"
+)
+
+// directoryUserStoreStub хранит пользователей каталога в памяти. lastAdminID
+// имитирует триггер «хотя бы один активный администратор» для одного пользователя.
+type directoryUserStoreStub struct {
+	users       map[string]*models.User
+	createdWith [][]models.OutboxEvent
+	deactivated []uuid.UUID
+	lastAdminID uuid.UUID
+}
+
+func newDirectoryUserStoreStub() *directoryUserStoreStub {
+	return &directoryUserStoreStub{users: make(map[string]*models.User)}
+}
+
+func activeAdministratorInvariantError() error {
+	return &pq.Error{Code: "P0001", Message: activeAdministratorInvariantMessage}
+}
+
+func (s *directoryUserStoreStub) SyncDirectoryUserWithOutbox(sync models.DirectoryUserSync, createEffects []models.OutboxEvent) (*models.User, bool, error) {
+	user, exists := s.users[sync.ExternalID]
+	if exists && user.ID == s.lastAdminID && user.IsActive && !slices.Contains(sync.SystemPermissions, models.SystemPermissionAdmin) {
+		return nil, false, activeAdministratorInvariantError()
+	}
+	if !exists {
+		user = &models.User{ID: uuid.New(), IsActive: true, AuthSource: sync.AuthSource, ExternalID: sync.ExternalID}
+		s.users[sync.ExternalID] = user
+		s.createdWith = append(s.createdWith, createEffects)
+	}
+	user.Login = sync.Login
+	user.FullName = sync.FullName
+	user.IsDocumentParticipant = sync.IsDocumentParticipant
+	user.SystemPermissions = append([]string(nil), sync.SystemPermissions...)
+	if sync.DepartmentID != nil {
+		user.DepartmentID = sync.DepartmentID
+	}
+	copied := *user
+	return &copied, !exists, nil
+}
+
+func (s *directoryUserStoreStub) GetActiveDirectoryUsers(authSource string) ([]models.User, error) {
+	var users []models.User
+	for _, user := range s.users {
+		if user.AuthSource == authSource && user.IsActive {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+func (s *directoryUserStoreStub) DeactivateDirectoryUserWithOutbox(userID uuid.UUID, _ []models.OutboxEvent) error {
+	if userID == s.lastAdminID {
+		return activeAdministratorInvariantError()
+	}
+	for _, user := range s.users {
+		if user.ID == userID {
+			user.IsActive = false
+			s.deactivated = append(s.deactivated, userID)
+		}
+	}
+	return nil
+}
+
+func (s *directoryUserStoreStub) byLogin(login string) *models.User {
+	for _, user := range s.users {
+		if user.Login == login {
+			return user
+		}
+	}
+	return nil
+}
+
+func directoryEntry(dn, id, login, name, password string, groups ...string) ldapstub.Entry {
+	return ldapstub.Entry{
+		DN:       dn,
+		Password: password,
+		Attributes: map[string][]string{
+			"objectClass":    {"user"},
+			"entryUUID":      {id},
+			"sAMAccountName": {login},
+			"displayName":    {name},
+			"memberOf":       groups,
+		},
+	}
+}
+
+// newTestDirectory поднимает LDAP-заглушку и DirectoryService поверх настоящего LDAP-клиента.
+func newTestDirectory(t *testing.T, departmentID uuid.UUID) (*DirectoryService, *ldapstub.Server, *directoryUserStoreStub) {
+	t.Helper()
+	server := ldapstub.Start(t,
+		ldapstub.Entry{DN: testDirectoryServiceDN, Password: "service-secret"},
+		directoryEntry("cn=Ivanov,ou=staff,dc=example,dc=local", "id-ivanov", "ivanov", "Иванов И.И.", "ivanov-secret", testDirectoryUsersDN),
+		directoryEntry("cn=Admin,ou=staff,dc=example,dc=local", "id-admin", "dir.admin", "Администратор каталога", "admin-secret", testDirectoryUsersDN, testDirectoryAdminsDN),
+		directoryEntry("cn=Outsider,ou=staff,dc=example,dc=local", "id-outsider", "outsider", "Посторонний", "outsider-secret"),
+	)
+	ldapDirectory, err := directory.NewLDAP(config.LDAPConfig{
+		Enabled:      true,
+		URL:          server.URL(),
+		BindDN:       testDirectoryServiceDN,
+		BindPassword: "service-secret",
+		BaseDN:       testDirectoryBaseDN,
+		UserFilter:   "(objectClass=user)",
+		IDAttribute:  "entryUUID",
+	})
+	require.NoError(t, err)
+
+	store := newDirectoryUserStoreStub()
+	policy := models.DirectoryPolicy{
+		RequiredGroup: testDirectoryUsersDN,
+		Groups: []models.DirectoryGroupMapping{
+			{Group: "CN=docflow-users,OU=Groups,DC=example,DC=local", DepartmentID: &departmentID, DocumentParticipant: true},
+			{Group: testDirectoryAdminsDN, SystemPermissions: []string{models.SystemPermissionAdmin, models.SystemPermissionReferences}},
+		},
+	}
+	return NewDirectoryService(ldapDirectory, store, models.UserAuthSourceLDAP, policy, 0), server, store
+}
+
+func TestDirectoryService_Authenticate(t *testing.T) {
+	departmentID := uuid.New()
+	svc, _, store := newTestDirectory(t, departmentID)
+	ctx := context.Background()
+
+	user, err := svc.Authenticate(ctx, "ivanov", "ivanov-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "ivanov", user.Login)
+	assert.Equal(t, "Иванов И.И.", user.FullName)
+	assert.Equal(t, &departmentID, user.DepartmentID)
+	assert.True(t, user.IsDocumentParticipant)
+	assert.Empty(t, user.SystemPermissions)
+	require.Len(t, store.createdWith, 1, "first login creates the user with an audit event")
+	assert.Len(t, store.createdWith[0], 1)
+
+	_, err = svc.Authenticate(ctx, "ivanov", "ivanov-secret")
+	require.NoError(t, err)
+	assert.Len(t, store.createdWith, 1, "repeated login updates the existing user")
+
+	admin, err := svc.Authenticate(ctx, "dir.admin", "admin-secret")
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.SystemPermissionAdmin, models.SystemPermissionReferences}, admin.SystemPermissions)
+
+	_, err = svc.Authenticate(ctx, "outsider", "outsider-secret")
+	requireAppError(t, err, "FORBIDDEN", 403, "группу доступа")
+	assert.Nil(t, store.byLogin("outsider"), "users outside the required group are not provisioned")
+
+	_, err = svc.Authenticate(ctx, "ivanov", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestDirectoryService_SyncUsers(t *testing.T) {
+	svc, server, store := newTestDirectory(t, uuid.New())
+	ctx := context.Background()
+	for login, password := range map[string]string{"ivanov": "ivanov-secret", "dir.admin": "admin-secret"} {
+		_, err := svc.Authenticate(ctx, login, password)
+		require.NoError(t, err)
+	}
+
+	t.Run("renamed user is updated", func(t *testing.T) {
+		server.SetEntries(
+			ldapstub.Entry{DN: testDirectoryServiceDN, Password: "service-secret"},
+			directoryEntry("cn=Ivanova,ou=staff,dc=example,dc=local", "id-ivanov", "ivanova", "Иванова И.И.", "", testDirectoryUsersDN),
+			directoryEntry("cn=Admin,ou=staff,dc=example,dc=local", "id-admin", "dir.admin", "Администратор каталога", "", testDirectoryUsersDN, testDirectoryAdminsDN),
+		)
+		report, err := svc.syncUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, directorySyncReport{Updated: 2}, report)
+		assert.NotNil(t, store.byLogin("ivanova"))
+	})
+
+	t.Run("empty directory deactivates nobody", func(t *testing.T) {
+		server.SetEntries(ldapstub.Entry{DN: testDirectoryServiceDN, Password: "service-secret"})
+		_, err := svc.syncUsers(ctx)
+		assert.Error(t, err)
+		assert.Empty(t, store.deactivated)
+	})
+
+	t.Run("removed users are deactivated except the last administrator", func(t *testing.T) {
+		store.lastAdminID = store.byLogin("dir.admin").ID
+		server.SetEntries(
+			ldapstub.Entry{DN: testDirectoryServiceDN, Password: "service-secret"},
+			directoryEntry("cn=Other,ou=staff,dc=example,dc=local", "id-other", "other", "Другой", "", testDirectoryUsersDN),
+		)
+		report, err := svc.syncUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, directorySyncReport{Deactivated: 1, Skipped: 1}, report)
+		assert.Equal(t, []uuid.UUID{store.byLogin("ivanova").ID}, store.deactivated)
+		assert.True(t, store.byLogin("dir.admin").IsActive)
+		assert.Nil(t, store.byLogin("other"), "sync does not create users")
+	})
+
+	t.Run("last administrator keeps admin permission", func(t *testing.T) {
+		server.SetEntries(
+			ldapstub.Entry{DN: testDirectoryServiceDN, Password: "service-secret"},
+			directoryEntry("cn=Admin,ou=staff,dc=example,dc=local", "id-admin", "dir.admin", "Администратор каталога", "", testDirectoryUsersDN),
+		)
+		report, err := svc.syncUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, directorySyncReport{Updated: 1}, report)
+		assert.Equal(t, []string{models.SystemPermissionAdmin}, store.byLogin("dir.admin").SystemPermissions)
+	})
+}
+
+func TestAuthService_LoginWithDirectory(t *testing.T) {
+	directoryService, server, store := newTestDirectory(t, uuid.New())
+
+	localAdmin, adminPassword := newTestUser()
+	localAdmin.Login = "admin"
+	localAdmin.AuthSource = models.UserAuthSourceLocal
+	localAdmin.SystemPermissions = []string{models.SystemPermissionAdmin}
+	localUser, userPassword := newTestUser()
+	localUser.AuthSource = models.UserAuthSourceLocal
+
+	newAuth := func(t *testing.T, adminsOnly bool) (*AuthService, *mocks.UserStore) {
+		mockRepo := mocks.NewUserStore(t)
+		auth := NewAuthService(nil, mockRepo)
+		auth.SetAuthenticator(directoryService, adminsOnly)
+		return auth, mockRepo
+	}
+
+	t.Run("unknown login is provisioned from the directory", func(t *testing.T) {
+		auth, mockRepo := newAuth(t, true)
+		mockRepo.On("GetByLogin", "ivanov").Return(nil, nil).Once()
+
+		user, err := auth.Login("ivanov", "ivanov-secret")
+		require.NoError(t, err)
+		assert.Equal(t, "ivanov", user.Login)
+		assert.True(t, auth.IsAuthenticated())
+	})
+
+	t.Run("deactivated directory user cannot log in", func(t *testing.T) {
+		auth, mockRepo := newAuth(t, true)
+		store.byLogin("ivanov").IsActive = false
+		t.Cleanup(func() { store.byLogin("ivanov").IsActive = true })
+		mockRepo.On("GetByLogin", "ivanov").Return(store.byLogin("ivanov"), nil).Once()
+
+		_, err := auth.Login("ivanov", "ivanov-secret")
+		assert.ErrorIs(t, err, ErrUserNotActive)
+	})
+
+	t.Run("break-glass administrator logs in while the directory is down", func(t *testing.T) {
+		auth, mockRepo := newAuth(t, true)
+		server.SetDown(true)
+		t.Cleanup(func() { server.SetDown(false) })
+
+		mockRepo.On("GetByLogin", "ivanov").Return(store.byLogin("ivanov"), nil).Once()
+		_, err := auth.Login("ivanov", "ivanov-secret")
+		assert.ErrorIs(t, err, models.ErrDirectoryUnavailable)
+
+		mockRepo.On("GetByLogin", localAdmin.Login).Return(localAdmin, nil).Once()
+		user, err := auth.Login(localAdmin.Login, adminPassword)
+		require.NoError(t, err)
+		assert.Equal(t, "admin", user.Login)
+	})
+
+	t.Run("local non-administrators are rejected in admins-only mode", func(t *testing.T) {
+		auth, mockRepo := newAuth(t, true)
+		mockRepo.On("GetByLogin", localUser.Login).Return(localUser, nil).Once()
+
+		_, err := auth.Login(localUser.Login, userPassword)
+		requireAppError(t, err, "FORBIDDEN", 403, "только администраторам")
+		assert.False(t, auth.IsAuthenticated())
+	})
+
+	t.Run("local accounts keep working in all mode", func(t *testing.T) {
+		auth, mockRepo := newAuth(t, false)
+		mockRepo.On("GetByLogin", localUser.Login).Return(localUser, nil).Once()
+
+		_, err := auth.Login(localUser.Login, userPassword)
+		require.NoError(t, err)
+	})
+
+	t.Run("directory user cannot change password locally", func(t *testing.T) {
+		mockRepo := mocks.NewUserStore(t)
+		auth := NewAuthService(nil, mockRepo)
+		directoryUser := *store.byLogin("ivanov")
+		mockRepo.On("GetByLogin", "ivanov").Return(&directoryUser, nil).Once()
+
+		_, err := auth.Login("ivanov", "ivanov-secret")
+		requireAppError(t, err, "DIRECTORY_UNAVAILABLE", 503, "")
+
+		mockRepo.On("GetByLogin", "ivanov").Return(&directoryUser, nil).Once()
+		err = auth.ChangeRequiredPassword("ivanov", "ivanov-secret", "NewPassw0rd!")
+		requireAppError(t, err, "CONFLICT", 409, "средствами каталога")
+	})
+}
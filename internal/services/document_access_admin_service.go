@@ -54,14 +54,22 @@ func (s *DocumentAccessAdminService) UpdateUserAccessProfile(req models.UpdateUs
 	}
 
 	for _, permission := range req.Permissions {
-		kind := models.NormalizeDocumentKind(permission.KindCode)
-		if _, ok := models.GetDocumentKindSpec(kind); !ok {
-			return models.NewBadRequest(fmt.Sprintf("неизвестный вид документа: %s", permission.KindCode))
-		}
-		if !kind.SupportsAction(permission.Action) {
-			return models.NewBadRequest(fmt.Sprintf("действие %q не поддерживается для вида документа %q", permission.Action, permission.KindCode))
+		if err := validateDocumentPermissionRule(permission.KindCode, permission.Action); err != nil {
+			return err
 		}
 	}
 
 	return activeAdministratorInvariantConflict(s.accessRepo.ReplaceUserAccessProfile(req.UserID, req.SystemPermissions, req.Permissions))
 }
+
+// validateDocumentPermissionRule проверяет, что действие action поддерживается видом документа kindCode.
+func validateDocumentPermissionRule(kindCode, action string) error {
+	kind := models.NormalizeDocumentKind(kindCode)
+	if _, ok := models.GetDocumentKindSpec(kind); !ok {
+		return models.NewBadRequest(fmt.Sprintf("неизвестный вид документа: %s", kindCode))
+	}
+	if !kind.SupportsAction(action) {
+		return models.NewBadRequest(fmt.Sprintf("действие %q не поддерживается для вида документа %q", action, kindCode))
+	}
+	return nil
+}
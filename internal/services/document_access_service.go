@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
@@ -8,12 +10,12 @@ import (
 
 // DocumentAccessService инкапсулирует политику доступа к документному домену.
 // Нужен как единая точка переиспользования для сервисов документов, файлов, журнала и связанных сущностей.
+// Проверки выполняются от имени principal из context.Context.
 type DocumentAccessService struct {
 	auth               *AuthService
 	depRepo            DepartmentStore
 	assignmentRepo     AssignmentStore
 	acknowledgmentRepo AcknowledgmentStore
-	substitutionRepo   UserSubstitutionStore
 	accessRepo         DocumentAccessStore
 	documentRepo       DocumentStore
 	incomingRepo       IncomingDocStore
@@ -34,9 +36,8 @@ func NewDocumentAccessService(
 	documentRepo DocumentStore,
 	incomingRepo IncomingDocStore,
 	outgoingRepo OutgoingDocStore,
-	substitutionRepos ...UserSubstitutionStore,
 ) *DocumentAccessService {
-	svc := &DocumentAccessService{
+	return &DocumentAccessService{
 		auth:               auth,
 		depRepo:            depRepo,
 		assignmentRepo:     assignmentRepo,
@@ -46,38 +47,26 @@ func NewDocumentAccessService(
 		incomingRepo:       incomingRepo,
 		outgoingRepo:       outgoingRepo,
 	}
-	if len(substitutionRepos) > 0 {
-		svc.substitutionRepo = substitutionRepos[0]
-	}
-	return svc
 }
 
 // RequireDomainRead проверяет базовый доступ к document-domain.
-func (s *DocumentAccessService) RequireDomainRead() error {
-	if err := s.auth.RequireAuthenticated(); err != nil {
-		return err
-	}
-	if s.accessRepo == nil {
-		return models.ErrForbidden
-	}
-
-	user, err := s.auth.GetCurrentUser()
+func (s *DocumentAccessService) RequireDomainRead(ctx context.Context) error {
+	principal, err := requirePrincipal(ctx)
 	if err != nil {
 		return err
 	}
-	if user != nil && user.IsDocumentParticipant {
-		return nil
-	}
+	return s.requireDomainRead(principal)
+}
 
-	subjectIDs, err := s.getCurrentUserAndSubstitutionSubjectIDs()
-	if err != nil {
-		return err
+func (s *DocumentAccessService) requireDomainRead(principal *models.Principal) error {
+	if s.accessRepo == nil {
+		return models.ErrForbidden
 	}
-	if len(subjectIDs) > 1 {
+	if principal.IsDocumentParticipant || principal.HasActiveSubstitution() {
 		return nil
 	}
 
-	hasDocumentPermissions, err := s.hasAnyDocumentPermission()
+	hasDocumentPermissions, err := s.hasAnyDocumentPermission(principal)
 	if err != nil {
 		return err
 	}
@@ -192,38 +181,10 @@ func (s *DocumentAccessService) RequireExists(documentID uuid.UUID) (*models.Doc
 	return doc, nil
 }
 
-func (s *DocumentAccessService) getCurrentSubjects() (departmentID, userID string, err error) {
-	user, err := s.auth.GetCurrentUser()
-	if err != nil {
-		return "", "", err
-	}
-	if user == nil {
-		return "", "", models.ErrUnauthorized
-	}
-
-	departmentID = ""
-	if user.Department != nil {
-		departmentID = user.Department.ID
-	}
-
-	return departmentID, user.ID, nil
-}
-
-func (s *DocumentAccessService) isCurrentUserDocumentParticipant() (bool, error) {
-	user, err := s.auth.GetCurrentUser()
-	if err != nil {
-		return false, err
-	}
-	if user == nil {
-		return false, models.ErrUnauthorized
-	}
-	return user.IsDocumentParticipant, nil
-}
-
-func (s *DocumentAccessService) hasAnyDocumentPermission() (bool, error) {
+func (s *DocumentAccessService) hasAnyDocumentPermission(principal *models.Principal) (bool, error) {
 	for _, spec := range models.AllDocumentKindSpecs() {
 		for _, action := range spec.SupportedActions {
-			allowed, err := s.hasPermission(spec.Code, string(action))
+			allowed, err := s.hasPermission(principal, spec.Code, string(action))
 			if err != nil {
 				return false, err
 			}
@@ -235,46 +196,11 @@ func (s *DocumentAccessService) hasAnyDocumentPermission() (bool, error) {
 	return false, nil
 }
 
-func (s *DocumentAccessService) hasPermission(kind models.DocumentKind, action string) (bool, error) {
+func (s *DocumentAccessService) hasPermission(principal *models.Principal, kind models.DocumentKind, action string) (bool, error) {
 	if s.accessRepo == nil {
 		return false, models.ErrForbidden
 	}
-
-	departmentID, userID, err := s.getCurrentSubjects()
-	if err != nil {
-		return false, err
-	}
-
-	return s.accessRepo.HasPermission(string(kind), action, departmentID, userID)
-}
-
-func (s *DocumentAccessService) getCurrentUserAndSubstitutionSubjectIDs() ([]uuid.UUID, error) {
-	currentUserID, err := s.auth.GetCurrentUserUUID()
-	if err != nil {
-		return nil, err
-	}
-
-	ids := []uuid.UUID{currentUserID}
-	if s.substitutionRepo == nil {
-		return ids, nil
-	}
-
-	principalIDs, err := s.substitutionRepo.GetActivePrincipalIDs(currentUserID)
-	if err != nil {
-		return nil, err
-	}
-	seen := map[uuid.UUID]struct{}{currentUserID: {}}
-	for _, principalID := range principalIDs {
-		if principalID == uuid.Nil {
-			continue
-		}
-		if _, ok := seen[principalID]; ok {
-			continue
-		}
-		seen[principalID] = struct{}{}
-		ids = append(ids, principalID)
-	}
-	return ids, nil
+	return s.accessRepo.HasPermission(string(kind), action, principal.DepartmentIDString(), principal.UserID.String())
 }
 
 func uuidStrings(ids []uuid.UUID) []string {
@@ -287,7 +213,11 @@ func uuidStrings(ids []uuid.UUID) []string {
 	return result
 }
 
-func (s *DocumentAccessService) GetAvailableActions(kind models.DocumentKind) ([]string, error) {
+func (s *DocumentAccessService) GetAvailableActions(ctx context.Context, kind models.DocumentKind) ([]string, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	spec, ok := models.GetDocumentKindSpec(kind)
 	if !ok {
 		return nil, nil
@@ -295,7 +225,7 @@ func (s *DocumentAccessService) GetAvailableActions(kind models.DocumentKind) ([
 
 	actions := make([]string, 0, len(spec.SupportedActions))
 	for _, action := range spec.SupportedActions {
-		allowed, err := s.hasPermission(kind, string(action))
+		allowed, err := s.hasPermission(principal, kind, string(action))
 		if err != nil {
 			return nil, err
 		}
@@ -307,13 +237,21 @@ func (s *DocumentAccessService) GetAvailableActions(kind models.DocumentKind) ([
 	return actions, nil
 }
 
-func (s *DocumentAccessService) HasDocumentAction(kind models.DocumentKind, action string) (bool, error) {
-	return s.hasPermission(kind, action)
+func (s *DocumentAccessService) HasDocumentAction(ctx context.Context, kind models.DocumentKind, action string) (bool, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return false, err
+	}
+	return s.hasPermission(principal, kind, action)
 }
 
-func (s *DocumentAccessService) HasAnyDocumentAction(action string) (bool, error) {
+func (s *DocumentAccessService) HasAnyDocumentAction(ctx context.Context, action string) (bool, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return false, err
+	}
 	for _, spec := range models.AllDocumentKindSpecs() {
-		allowed, err := s.hasPermission(spec.Code, action)
+		allowed, err := s.hasPermission(principal, spec.Code, action)
 		if err != nil {
 			return false, err
 		}
@@ -324,10 +262,14 @@ func (s *DocumentAccessService) HasAnyDocumentAction(action string) (bool, error
 	return false, nil
 }
 
-func (s *DocumentAccessService) GetDocumentKindsWithAction(action string) ([]models.DocumentKind, error) {
+func (s *DocumentAccessService) GetDocumentKindsWithAction(ctx context.Context, action string) ([]models.DocumentKind, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	kinds := make([]models.DocumentKind, 0)
 	for _, spec := range models.AllDocumentKindSpecs() {
-		allowed, err := s.hasPermission(spec.Code, action)
+		allowed, err := s.hasPermission(principal, spec.Code, action)
 		if err != nil {
 			return nil, err
 		}
@@ -338,25 +280,18 @@ func (s *DocumentAccessService) GetDocumentKindsWithAction(action string) ([]mod
 	return kinds, nil
 }
 
-func (s *DocumentAccessService) getDepartmentNomenclatureIDs() ([]string, error) {
-	user, err := s.auth.GetCurrentUser()
-	if err != nil {
-		return nil, err
-	}
-	if user == nil || user.Department == nil || user.Department.ID == "" {
-		return nil, nil
-	}
-
-	departmentID, err := uuid.Parse(user.Department.ID)
-	if err != nil {
+// getDepartmentNomenclatureIDs возвращает номенклатуры, дела которых участник видит
+// без права read: своего и вышестоящих подразделений, а руководитель — также
+// подчиненных подразделений.
+func (s *DocumentAccessService) getDepartmentNomenclatureIDs(principal *models.Principal) ([]string, error) {
+	if s.depRepo == nil {
 		return nil, nil
 	}
-
-	return s.depRepo.GetNomenclatureIDs(departmentID)
+	return s.depRepo.GetVisibleNomenclatureIDs(principal.DepartmentID, principal.UserID)
 }
 
-func (s *DocumentAccessService) hasDepartmentNomenclatureAccess(nomenclatureID uuid.UUID) (bool, error) {
-	allowedNomenclatures, err := s.getDepartmentNomenclatureIDs()
+func (s *DocumentAccessService) hasDepartmentNomenclatureAccess(principal *models.Principal, nomenclatureID uuid.UUID) (bool, error) {
+	allowedNomenclatures, err := s.getDepartmentNomenclatureIDs(principal)
 	if err != nil {
 		return false, err
 	}
@@ -371,63 +306,66 @@ func (s *DocumentAccessService) hasDepartmentNomenclatureAccess(nomenclatureID u
 	return false, nil
 }
 
-func (s *DocumentAccessService) hasImplicitReadAccess(doc *models.Document) (bool, error) {
-	if doc == nil {
-		return false, models.NewNotFound("документ не найден")
-	}
-
-	isParticipant, err := s.isCurrentUserDocumentParticipant()
-	if err != nil {
-		return false, err
-	}
+func (s *DocumentAccessService) hasImplicitReadAccess(principal *models.Principal, doc *models.Document) (bool, error) {
+	reason, err := s.implicitReadReason(principal, doc)
+	return reason != "", err
+}
 
-	subjectIDs, err := s.getCurrentUserAndSubstitutionSubjectIDs()
-	if err != nil {
-		return false, err
+// implicitReadReason объясняет, почему principal видит документ без права read
+// в матрице доступа. Пустая строка означает, что такого основания нет.
+func (s *DocumentAccessService) implicitReadReason(principal *models.Principal, doc *models.Document) (string, error) {
+	if doc == nil {
+		return "", models.NewNotFound("документ не найден")
 	}
 
-	if isParticipant {
-		ok, err := s.hasDepartmentNomenclatureAccess(doc.NomenclatureID)
+	if principal.IsDocumentParticipant {
+		ok, err := s.hasDepartmentNomenclatureAccess(principal, doc.NomenclatureID)
 		if err == nil && ok {
-			return true, nil
+			return "документ зарегистрирован в деле номенклатуры подразделения пользователя, вышестоящего подразделения или подразделения, которым пользователь руководит", nil
 		}
-	} else if len(subjectIDs) <= 1 {
-		return false, nil
+	} else if !principal.HasActiveSubstitution() {
+		return "", nil
 	}
 
 	if s.assignmentRepo != nil {
-		for _, subjectID := range subjectIDs {
+		for _, subjectID := range principal.SubjectIDsFor(models.SubstitutionScopeAssignments, doc.Kind) {
 			ok, err := s.assignmentRepo.HasDocumentAccess(subjectID, doc.ID)
 			if err != nil {
-				return false, err
+				return "", err
 			}
 			if ok {
-				return true, nil
+				if subjectID != principal.UserID {
+					return "по документу есть поручение замещаемого пользователя", nil
+				}
+				return "пользователь участвует в поручении по документу", nil
 			}
 		}
 	}
 
 	if s.acknowledgmentRepo != nil {
-		for _, subjectID := range subjectIDs {
+		for _, subjectID := range principal.SubjectIDsFor(models.SubstitutionScopeAcknowledgments, doc.Kind) {
 			ok, err := s.acknowledgmentRepo.HasDocumentAccess(subjectID, doc.ID)
 			if err != nil {
-				return false, err
+				return "", err
 			}
 			if ok {
-				return true, nil
+				if subjectID != principal.UserID {
+					return "документ направлен на ознакомление замещаемому пользователю", nil
+				}
+				return "документ направлен пользователю на ознакомление", nil
 			}
 		}
 	}
 
-	return false, nil
+	return "", nil
 }
 
-func (s *DocumentAccessService) canReadResolved(doc *models.Document) (bool, error) {
+func (s *DocumentAccessService) canReadResolved(principal *models.Principal, doc *models.Document) (bool, error) {
 	if doc == nil {
 		return false, models.NewNotFound("документ не найден")
 	}
 
-	allowed, err := s.hasPermission(doc.Kind, "read")
+	allowed, err := s.hasPermission(principal, doc.Kind, "read")
 	if err != nil {
 		return false, err
 	}
@@ -435,15 +373,16 @@ func (s *DocumentAccessService) canReadResolved(doc *models.Document) (bool, err
 		return true, nil
 	}
 
-	return s.hasImplicitReadAccess(doc)
+	return s.hasImplicitReadAccess(principal, doc)
 }
 
-func (s *DocumentAccessService) RequireCreate(kind models.DocumentKind) error {
-	if err := s.auth.RequireAuthenticated(); err != nil {
+func (s *DocumentAccessService) RequireCreate(ctx context.Context, kind models.DocumentKind) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
 		return err
 	}
 
-	allowed, err := s.hasPermission(kind, "create")
+	allowed, err := s.hasPermission(principal, kind, "create")
 	if err != nil {
 		return err
 	}
@@ -453,12 +392,16 @@ func (s *DocumentAccessService) RequireCreate(kind models.DocumentKind) error {
 	return nil
 }
 
-func (s *DocumentAccessService) ResolveReadScope(kind models.DocumentKind) (*DocumentReadScope, error) {
-	if err := s.RequireDomainRead(); err != nil {
+func (s *DocumentAccessService) ResolveReadScope(ctx context.Context, kind models.DocumentKind) (*DocumentReadScope, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireDomainRead(principal); err != nil {
 		return nil, err
 	}
 
-	allowed, err := s.hasPermission(kind, "read")
+	allowed, err := s.hasPermission(principal, kind, "read")
 	if err != nil {
 		return nil, err
 	}
@@ -466,43 +409,45 @@ func (s *DocumentAccessService) ResolveReadScope(kind models.DocumentKind) (*Doc
 		return &DocumentReadScope{}, nil
 	}
 
-	subjectIDs, err := s.getCurrentUserAndSubstitutionSubjectIDs()
-	if err != nil {
-		return nil, err
-	}
-	subjectIDStrings := uuidStrings(subjectIDs)
-	accessibleByUserID := ""
-	if len(subjectIDStrings) > 0 {
-		accessibleByUserID = subjectIDStrings[0]
+	subjectIDStrings := uuidStrings(principal.SubjectIDsFor(models.SubstitutionScopeAssignments, kind))
+	accessibleByUserID := subjectIDStrings[0]
+	var acknowledgmentUserIDs []string
+	if principal.HasActiveSubstitution() {
+		acknowledgmentUserIDs = uuidStrings(principal.SubjectIDsFor(models.SubstitutionScopeAcknowledgments, kind))
 	}
 
-	allowedNomenclatureIDs, err := s.getDepartmentNomenclatureIDs()
+	allowedNomenclatureIDs, err := s.getDepartmentNomenclatureIDs(principal)
 	if err != nil {
 		return nil, err
 	}
 
-	isParticipant, err := s.isCurrentUserDocumentParticipant()
-	if err != nil {
-		return nil, err
-	}
-	if !isParticipant {
-		return &DocumentReadScope{Restricted: true, AccessibleByUserID: accessibleByUserID, AccessibleByUserIDs: subjectIDStrings}, nil
+	if !principal.IsDocumentParticipant {
+		return &DocumentReadScope{Restricted: true, AccessibleByUserID: accessibleByUserID, AccessibleByUserIDs: subjectIDStrings, AcknowledgmentUserIDs: acknowledgmentUserIDs}, nil
 	}
 
 	return &DocumentReadScope{
 		Restricted:             true,
 		AccessibleByUserID:     accessibleByUserID,
 		AccessibleByUserIDs:    subjectIDStrings,
+		AcknowledgmentUserIDs:  acknowledgmentUserIDs,
 		AllowedNomenclatureIDs: allowedNomenclatureIDs,
 	}, nil
 }
 
-func (s *DocumentAccessService) RequireReadResolved(doc *models.Document) error {
-	if err := s.RequireDomainRead(); err != nil {
+func (s *DocumentAccessService) RequireReadResolved(ctx context.Context, doc *models.Document) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	return s.requireReadResolved(principal, doc)
+}
+
+func (s *DocumentAccessService) requireReadResolved(principal *models.Principal, doc *models.Document) error {
+	if err := s.requireDomainRead(principal); err != nil {
 		return err
 	}
 
-	allowed, err := s.canReadResolved(doc)
+	allowed, err := s.canReadResolved(principal, doc)
 	if err != nil {
 		return err
 	}
@@ -513,14 +458,18 @@ func (s *DocumentAccessService) RequireReadResolved(doc *models.Document) error
 }
 
 // ResolveReadableDocuments возвращает документы из переданного набора, доступные текущему пользователю на чтение.
-func (s *DocumentAccessService) ResolveReadableDocuments(documentIDs []uuid.UUID) (map[uuid.UUID]*models.Document, error) {
+func (s *DocumentAccessService) ResolveReadableDocuments(ctx context.Context, documentIDs []uuid.UUID) (map[uuid.UUID]*models.Document, error) {
 	readable := make(map[uuid.UUID]*models.Document)
 	uniqueIDs := uniqueDocumentIDs(documentIDs)
 	if len(uniqueIDs) == 0 {
 		return readable, nil
 	}
 
-	if err := s.RequireDomainRead(); err != nil {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireDomainRead(principal); err != nil {
 		return nil, err
 	}
 
@@ -532,46 +481,31 @@ func (s *DocumentAccessService) ResolveReadableDocuments(documentIDs []uuid.UUID
 		return readable, nil
 	}
 
-	user, err := s.auth.GetCurrentUser()
-	if err != nil {
-		return nil, err
-	}
-	if user == nil {
-		return nil, models.ErrUnauthorized
-	}
-
-	userID := user.ID
-	departmentID := ""
-	if user.Department != nil {
-		departmentID = user.Department.ID
-	}
-	subjectIDs, err := s.getCurrentUserAndSubstitutionSubjectIDs()
-	if err != nil {
-		return nil, err
-	}
+	userID := principal.UserID.String()
+	departmentID := principal.DepartmentIDString()
+	subjectIDs := principal.SubjectIDs()
 
 	allowedNomenclatures := make(map[uuid.UUID]struct{})
-	if user.IsDocumentParticipant && s.depRepo != nil && user.Department != nil && user.Department.ID != "" {
-		departmentUUID, err := uuid.Parse(user.Department.ID)
-		if err == nil {
-			nomenclatureIDs, err := s.depRepo.GetNomenclatureIDs(departmentUUID)
-			if err != nil {
-				return nil, err
-			}
-			for _, nomenclatureID := range nomenclatureIDs {
-				parsedID, err := uuid.Parse(nomenclatureID)
-				if err == nil {
-					allowedNomenclatures[parsedID] = struct{}{}
-				}
+	if principal.IsDocumentParticipant {
+		nomenclatureIDs, err := s.getDepartmentNomenclatureIDs(principal)
+		if err != nil {
+			return nil, err
+		}
+		for _, nomenclatureID := range nomenclatureIDs {
+			parsedID, err := uuid.Parse(nomenclatureID)
+			if err == nil {
+				allowedNomenclatures[parsedID] = struct{}{}
 			}
 		}
 	}
 
-	assignmentAccessibleDocuments := make(map[uuid.UUID]struct{})
+	// Для каждого документа запоминается, через кого из subjectIDs он доступен:
+	// замещение может не распространяться на его вид или на область действий.
+	assignmentAccessibleDocuments := make(map[uuid.UUID][]uuid.UUID)
 	assignmentBulkAvailable := false
-	acknowledgmentAccessibleDocuments := make(map[uuid.UUID]struct{})
+	acknowledgmentAccessibleDocuments := make(map[uuid.UUID][]uuid.UUID)
 	acknowledgmentBulkAvailable := false
-	if user.IsDocumentParticipant || len(subjectIDs) > 1 {
+	if principal.IsDocumentParticipant || principal.HasActiveSubstitution() {
 		for _, subjectID := range subjectIDs {
 			ids, bulkAvailable, err := resolveBulkAccessibleDocumentIDs(s.assignmentRepo, subjectID, uniqueIDs)
 			if err != nil {
@@ -579,7 +513,7 @@ func (s *DocumentAccessService) ResolveReadableDocuments(documentIDs []uuid.UUID
 			}
 			assignmentBulkAvailable = assignmentBulkAvailable || bulkAvailable
 			for documentID := range ids {
-				assignmentAccessibleDocuments[documentID] = struct{}{}
+				assignmentAccessibleDocuments[documentID] = append(assignmentAccessibleDocuments[documentID], subjectID)
 			}
 
 			ids, bulkAvailable, err = resolveBulkAccessibleDocumentIDs(s.acknowledgmentRepo, subjectID, uniqueIDs)
@@ -588,7 +522,7 @@ func (s *DocumentAccessService) ResolveReadableDocuments(documentIDs []uuid.UUID
 			}
 			acknowledgmentBulkAvailable = acknowledgmentBulkAvailable || bulkAvailable
 			for documentID := range ids {
-				acknowledgmentAccessibleDocuments[documentID] = struct{}{}
+				acknowledgmentAccessibleDocuments[documentID] = append(acknowledgmentAccessibleDocuments[documentID], subjectID)
 			}
 		}
 	}
@@ -610,11 +544,11 @@ func (s *DocumentAccessService) ResolveReadableDocuments(documentIDs []uuid.UUID
 			continue
 		}
 
-		if !user.IsDocumentParticipant && len(subjectIDs) <= 1 {
+		if !principal.IsDocumentParticipant && !principal.HasActiveSubstitution() {
 			continue
 		}
 
-		if user.IsDocumentParticipant {
+		if principal.IsDocumentParticipant {
 			if _, ok := allowedNomenclatures[doc.NomenclatureID]; ok {
 				readable[doc.ID] = doc
 				continue
@@ -622,12 +556,12 @@ func (s *DocumentAccessService) ResolveReadableDocuments(documentIDs []uuid.UUID
 		}
 
 		if s.assignmentRepo != nil {
-			if _, ok := assignmentAccessibleDocuments[doc.ID]; ok {
+			if actsForAny(principal, assignmentAccessibleDocuments[doc.ID], models.SubstitutionScopeAssignments, doc.Kind) {
 				readable[doc.ID] = doc
 				continue
 			}
 			if !assignmentBulkAvailable {
-				for _, subjectID := range subjectIDs {
+				for _, subjectID := range principal.SubjectIDsFor(models.SubstitutionScopeAssignments, doc.Kind) {
 					allowed, err := s.assignmentRepo.HasDocumentAccess(subjectID, doc.ID)
 					if err != nil {
 						return nil, err
@@ -644,12 +578,12 @@ func (s *DocumentAccessService) ResolveReadableDocuments(documentIDs []uuid.UUID
 		}
 
 		if s.acknowledgmentRepo != nil {
-			if _, ok := acknowledgmentAccessibleDocuments[doc.ID]; ok {
+			if actsForAny(principal, acknowledgmentAccessibleDocuments[doc.ID], models.SubstitutionScopeAcknowledgments, doc.Kind) {
 				readable[doc.ID] = doc
 				continue
 			}
 			if !acknowledgmentBulkAvailable {
-				for _, subjectID := range subjectIDs {
+				for _, subjectID := range principal.SubjectIDsFor(models.SubstitutionScopeAcknowledgments, doc.Kind) {
 					allowed, err := s.acknowledgmentRepo.HasDocumentAccess(subjectID, doc.ID)
 					if err != nil {
 						return nil, err
@@ -666,6 +600,17 @@ func (s *DocumentAccessService) ResolveReadableDocuments(documentIDs []uuid.UUID
 	return readable, nil
 }
 
+// actsForAny сообщает, действует ли principal хотя бы за одного из subjectIDs
+// в области scope по документу вида kind.
+func actsForAny(principal *models.Principal, subjectIDs []uuid.UUID, scope string, kind models.DocumentKind) bool {
+	for _, subjectID := range subjectIDs {
+		if principal.ActsForIn(subjectID, scope, kind) {
+			return true
+		}
+	}
+	return false
+}
+
 func resolveBulkAccessibleDocumentIDs(store interface{}, userID uuid.UUID, documentIDs []uuid.UUID) (map[uuid.UUID]struct{}, bool, error) {
 	empty := make(map[uuid.UUID]struct{})
 	bulkStore, ok := store.(DocumentAccessByUserBulkStore)
@@ -681,23 +626,23 @@ func resolveBulkAccessibleDocumentIDs(store interface{}, userID uuid.UUID, docum
 }
 
 // RequireRead проверяет доступ к конкретному документу по его виду и ID.
-func (s *DocumentAccessService) RequireRead(documentKind string, documentID uuid.UUID) error {
-	doc, err := s.RequireExists(documentID)
-	if err != nil {
-		return err
-	}
-	return s.RequireReadResolved(doc)
+func (s *DocumentAccessService) RequireRead(ctx context.Context, documentKind string, documentID uuid.UUID) error {
+	return s.RequireReadAnyType(ctx, documentID)
 }
 
 // RequireResolvedRead проверяет доступ к уже загруженному документу без повторного чтения из репозитория.
-func (s *DocumentAccessService) RequireResolvedRead(documentKind string, documentID, nomenclatureID uuid.UUID) error {
-	if err := s.RequireDomainRead(); err != nil {
+func (s *DocumentAccessService) RequireResolvedRead(ctx context.Context, documentKind string, documentID, nomenclatureID uuid.UUID) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.requireDomainRead(principal); err != nil {
 		return err
 	}
 
 	kind := models.NormalizeDocumentKind(documentKind)
 
-	allowed, err := s.hasPermission(kind, "read")
+	allowed, err := s.hasPermission(principal, kind, "read")
 	if err != nil {
 		return err
 	}
@@ -705,26 +650,16 @@ func (s *DocumentAccessService) RequireResolvedRead(documentKind string, documen
 		return nil
 	}
 
-	isParticipant, err := s.isCurrentUserDocumentParticipant()
-	if err != nil {
-		return err
-	}
-
-	subjectIDs, err := s.getCurrentUserAndSubstitutionSubjectIDs()
-	if err != nil {
-		return err
-	}
-
-	if isParticipant {
-		ok, err := s.hasDepartmentNomenclatureAccess(nomenclatureID)
+	if principal.IsDocumentParticipant {
+		ok, err := s.hasDepartmentNomenclatureAccess(principal, nomenclatureID)
 		if err == nil && ok {
 			return nil
 		}
-	} else if len(subjectIDs) <= 1 {
+	} else if !principal.HasActiveSubstitution() {
 		return models.ErrForbidden
 	}
 	if s.assignmentRepo != nil {
-		for _, subjectID := range subjectIDs {
+		for _, subjectID := range principal.SubjectIDsFor(models.SubstitutionScopeAssignments, kind) {
 			ok, err := s.assignmentRepo.HasDocumentAccess(subjectID, documentID)
 			if err != nil {
 				return err
@@ -735,7 +670,7 @@ func (s *DocumentAccessService) RequireResolvedRead(documentKind string, documen
 		}
 	}
 	if s.acknowledgmentRepo != nil {
-		for _, subjectID := range subjectIDs {
+		for _, subjectID := range principal.SubjectIDsFor(models.SubstitutionScopeAcknowledgments, kind) {
 			ok, err := s.acknowledgmentRepo.HasDocumentAccess(subjectID, documentID)
 			if err != nil {
 				return err
@@ -749,13 +684,17 @@ func (s *DocumentAccessService) RequireResolvedRead(documentKind string, documen
 	return models.ErrForbidden
 }
 
-func (s *DocumentAccessService) RequireDocumentAction(documentID uuid.UUID, action string) error {
+func (s *DocumentAccessService) RequireDocumentAction(ctx context.Context, documentID uuid.UUID, action string) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
 	doc, err := s.RequireExists(documentID)
 	if err != nil {
 		return err
 	}
 
-	allowed, err := s.hasPermission(doc.Kind, action)
+	allowed, err := s.hasPermission(principal, doc.Kind, action)
 	if err != nil {
 		return err
 	}
@@ -763,27 +702,34 @@ func (s *DocumentAccessService) RequireDocumentAction(documentID uuid.UUID, acti
 		return models.ErrForbidden
 	}
 
-	return s.RequireReadResolved(doc)
+	return s.requireReadResolved(principal, doc)
 }
 
-func (s *DocumentAccessService) RequireLink(sourceID, targetID uuid.UUID) error {
-	_, _, err := s.ResolveLink(sourceID, targetID)
+func (s *DocumentAccessService) RequireLink(ctx context.Context, sourceID, targetID uuid.UUID) error {
+	_, _, err := s.ResolveLink(ctx, sourceID, targetID)
 	return err
 }
 
-func (s *DocumentAccessService) HasAssignmentAccess(documentID uuid.UUID) (bool, error) {
-	if err := s.RequireDomainRead(); err != nil {
+func (s *DocumentAccessService) HasAssignmentAccess(ctx context.Context, documentID uuid.UUID) (bool, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := s.requireDomainRead(principal); err != nil {
 		return false, err
 	}
 	if s.assignmentRepo == nil {
 		return false, nil
 	}
 
-	subjectIDs, err := s.getCurrentUserAndSubstitutionSubjectIDs()
-	if err != nil {
-		return false, err
+	subjectIDs := []uuid.UUID{principal.UserID}
+	if principal.HasActiveSubstitution() {
+		doc, err := s.RequireExists(documentID)
+		if err != nil {
+			return false, err
+		}
+		subjectIDs = principal.SubjectIDsFor(models.SubstitutionScopeAssignments, doc.Kind)
 	}
-
 	for _, subjectID := range subjectIDs {
 		ok, err := s.assignmentRepo.HasDocumentAccess(subjectID, documentID)
 		if err != nil {
@@ -796,7 +742,11 @@ func (s *DocumentAccessService) HasAssignmentAccess(documentID uuid.UUID) (bool,
 	return false, nil
 }
 
-func (s *DocumentAccessService) ResolveLink(sourceID, targetID uuid.UUID) (*models.Document, *models.Document, error) {
+func (s *DocumentAccessService) ResolveLink(ctx context.Context, sourceID, targetID uuid.UUID) (*models.Document, *models.Document, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 	sourceDoc, err := s.RequireExists(sourceID)
 	if err != nil {
 		return nil, nil, err
@@ -806,15 +756,15 @@ func (s *DocumentAccessService) ResolveLink(sourceID, targetID uuid.UUID) (*mode
 		return nil, nil, err
 	}
 
-	if err := s.RequireReadResolved(sourceDoc); err != nil {
+	if err := s.requireReadResolved(principal, sourceDoc); err != nil {
 		return nil, nil, err
 	}
-	if err := s.RequireReadResolved(targetDoc); err != nil {
+	if err := s.requireReadResolved(principal, targetDoc); err != nil {
 		return nil, nil, err
 	}
 
 	for _, doc := range []*models.Document{sourceDoc, targetDoc} {
-		allowed, err := s.hasPermission(doc.Kind, "link")
+		allowed, err := s.hasPermission(principal, doc.Kind, "link")
 		if err != nil {
 			return nil, nil, err
 		}
@@ -826,9 +776,13 @@ func (s *DocumentAccessService) ResolveLink(sourceID, targetID uuid.UUID) (*mode
 	return sourceDoc, targetDoc, nil
 }
 
-func (s *DocumentAccessService) RequireViewJournal(documentID uuid.UUID) error {
+func (s *DocumentAccessService) RequireViewJournal(ctx context.Context, documentID uuid.UUID) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
 	if s.accessRepo == nil {
-		return s.RequireDomainRead()
+		return s.requireDomainRead(principal)
 	}
 
 	doc, err := s.RequireExists(documentID)
@@ -836,7 +790,7 @@ func (s *DocumentAccessService) RequireViewJournal(documentID uuid.UUID) error {
 		return err
 	}
 
-	allowed, err := s.hasPermission(doc.Kind, "view_journal")
+	allowed, err := s.hasPermission(principal, doc.Kind, "view_journal")
 	if err != nil {
 		return err
 	}
@@ -844,14 +798,18 @@ func (s *DocumentAccessService) RequireViewJournal(documentID uuid.UUID) error {
 		return models.ErrForbidden
 	}
 
-	return s.RequireReadResolved(doc)
+	return s.requireReadResolved(principal, doc)
 }
 
 // RequireReadAnyType проверяет доступ к документу, определяя тип по ID.
-func (s *DocumentAccessService) RequireReadAnyType(documentID uuid.UUID) error {
+func (s *DocumentAccessService) RequireReadAnyType(ctx context.Context, documentID uuid.UUID) error {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
 	doc, err := s.RequireExists(documentID)
 	if err != nil {
 		return err
 	}
-	return s.RequireReadResolved(doc)
+	return s.requireReadResolved(principal, doc)
 }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -28,7 +29,9 @@ type documentAccessTestDeps struct {
 
 type documentAccessDepartmentStore struct {
 	nomenclatureIDs []string
-	err             error
+	// headNomenclatureIDs — номенклатуры подчиненных подразделений по ID руководителя.
+	headNomenclatureIDs map[uuid.UUID][]string
+	err                 error
 }
 
 func (s *documentAccessDepartmentStore) GetAll() ([]models.Department, error) {
@@ -42,6 +45,17 @@ func (s *documentAccessDepartmentStore) GetNomenclatureIDs(departmentID uuid.UUI
 	return s.nomenclatureIDs, nil
 }
 
+func (s *documentAccessDepartmentStore) GetVisibleNomenclatureIDs(departmentID *uuid.UUID, userID uuid.UUID) ([]string, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	ids := append([]string(nil), s.headNomenclatureIDs[userID]...)
+	if departmentID != nil {
+		ids = append(ids, s.nomenclatureIDs...)
+	}
+	return ids, nil
+}
+
 func (s *documentAccessDepartmentStore) Create(name string, nomenclatureIDs []string) (*models.Department, error) {
 	return nil, nil
 }
@@ -191,6 +205,16 @@ func (s *documentAccessDocumentStore) GetByIDs(ids []uuid.UUID) ([]models.Docume
 	return docs, nil
 }
 
+// sessionCtx возвращает контекст desktop-сессии так же, как его строят
+// Wails-адаптеры. Без активной сессии principal в контексте отсутствует.
+func (d *documentAccessTestDeps) sessionCtx() context.Context {
+	ctx, err := d.auth.sessionContext()
+	if err != nil {
+		return context.Background()
+	}
+	return ctx
+}
+
 func setupDocumentAccessService(t *testing.T, user *models.User, allowed map[models.DocumentKind]map[string]bool) *documentAccessTestDeps {
 	t.Helper()
 
@@ -207,7 +231,8 @@ func setupDocumentAccessService(t *testing.T, user *models.User, allowed map[mod
 	ackRepo := &documentAccessAcknowledgmentStore{accessible: map[uuid.UUID]struct{}{}}
 	docRepo := &documentAccessDocumentStore{docs: map[uuid.UUID]models.Document{}}
 	subRepo := &userSubstitutionStoreStub{}
-	service := NewDocumentAccessService(auth, depRepo, assignRepo, ackRepo, accessRepo, docRepo, nil, nil, subRepo)
+	auth.SetSubstitutionStore(subRepo)
+	service := NewDocumentAccessService(auth, depRepo, assignRepo, ackRepo, accessRepo, docRepo, nil, nil)
 
 	return &documentAccessTestDeps{
 		auth:       auth,
@@ -270,7 +295,7 @@ func TestDocumentAccessService_RequireDomainRead(t *testing.T) {
 	t.Run("unauthorized user is rejected", func(t *testing.T) {
 		deps := setupDocumentAccessService(t, nil, nil)
 
-		err := deps.service.RequireDomainRead()
+		err := deps.service.RequireDomainRead(deps.sessionCtx())
 
 		require.ErrorIs(t, err, models.ErrUnauthorized)
 	})
@@ -280,7 +305,7 @@ func TestDocumentAccessService_RequireDomainRead(t *testing.T) {
 		deps := setupDocumentAccessService(t, user, nil)
 		user.IsActive = false
 
-		err := deps.service.RequireDomainRead()
+		err := deps.service.RequireDomainRead(deps.sessionCtx())
 
 		require.ErrorIs(t, err, models.ErrUnauthorized)
 		assert.False(t, deps.auth.IsAuthenticated())
@@ -289,7 +314,7 @@ func TestDocumentAccessService_RequireDomainRead(t *testing.T) {
 	t.Run("document participant is allowed without explicit document permissions", func(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(true, nil), nil)
 
-		err := deps.service.RequireDomainRead()
+		err := deps.service.RequireDomainRead(deps.sessionCtx())
 
 		require.NoError(t, err)
 	})
@@ -297,7 +322,7 @@ func TestDocumentAccessService_RequireDomainRead(t *testing.T) {
 	t.Run("non participant without document permissions is forbidden", func(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(false, nil), nil)
 
-		err := deps.service.RequireDomainRead()
+		err := deps.service.RequireDomainRead(deps.sessionCtx())
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 	})
@@ -309,7 +334,7 @@ func TestDocumentAccessService_RequireDomainRead(t *testing.T) {
 			allowDocumentActions(models.DocumentKindIncomingLetter, "upload"),
 		)
 
-		err := deps.service.RequireDomainRead()
+		err := deps.service.RequireDomainRead(deps.sessionCtx())
 
 		require.NoError(t, err)
 	})
@@ -319,7 +344,7 @@ func TestDocumentAccessService_RequireDomainRead(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(false, nil), nil)
 		deps.subRepo.activePrincipals = []uuid.UUID{principalID}
 
-		err := deps.service.RequireDomainRead()
+		err := deps.service.RequireDomainRead(deps.sessionCtx())
 
 		require.NoError(t, err)
 	})
@@ -329,7 +354,7 @@ func TestDocumentAccessService_RequireCreate(t *testing.T) {
 	t.Run("requires authentication", func(t *testing.T) {
 		deps := setupDocumentAccessService(t, nil, nil)
 
-		err := deps.service.RequireCreate(models.DocumentKindIncomingLetter)
+		err := deps.service.RequireCreate(deps.sessionCtx(), models.DocumentKindIncomingLetter)
 
 		require.ErrorIs(t, err, models.ErrUnauthorized)
 	})
@@ -341,7 +366,7 @@ func TestDocumentAccessService_RequireCreate(t *testing.T) {
 			allowDocumentActions(models.DocumentKindIncomingLetter, "create"),
 		)
 
-		err := deps.service.RequireCreate(models.DocumentKindIncomingLetter)
+		err := deps.service.RequireCreate(deps.sessionCtx(), models.DocumentKindIncomingLetter)
 
 		require.NoError(t, err)
 	})
@@ -349,7 +374,7 @@ func TestDocumentAccessService_RequireCreate(t *testing.T) {
 	t.Run("rejects missing create permission", func(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(false, nil), nil)
 
-		err := deps.service.RequireCreate(models.DocumentKindIncomingLetter)
+		err := deps.service.RequireCreate(deps.sessionCtx(), models.DocumentKindIncomingLetter)
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 	})
@@ -363,7 +388,7 @@ func TestDocumentAccessService_ResolveReadScope(t *testing.T) {
 			allowDocumentActions(models.DocumentKindIncomingLetter, "read"),
 		)
 
-		scope, err := deps.service.ResolveReadScope(models.DocumentKindIncomingLetter)
+		scope, err := deps.service.ResolveReadScope(deps.sessionCtx(), models.DocumentKindIncomingLetter)
 
 		require.NoError(t, err)
 		require.NotNil(t, scope)
@@ -379,7 +404,7 @@ func TestDocumentAccessService_ResolveReadScope(t *testing.T) {
 		deps := setupDocumentAccessService(t, user, nil)
 		deps.depRepo.nomenclatureIDs = []string{nomenclatureID.String()}
 
-		scope, err := deps.service.ResolveReadScope(models.DocumentKindIncomingLetter)
+		scope, err := deps.service.ResolveReadScope(deps.sessionCtx(), models.DocumentKindIncomingLetter)
 
 		require.NoError(t, err)
 		require.NotNil(t, scope)
@@ -388,6 +413,21 @@ func TestDocumentAccessService_ResolveReadScope(t *testing.T) {
 		assert.Equal(t, []string{nomenclatureID.String()}, scope.AllowedNomenclatureIDs)
 	})
 
+	t.Run("department head without own department sees subordinate nomenclature", func(t *testing.T) {
+		nomenclatureID := uuid.New()
+		user := documentAccessUser(true, nil)
+		deps := setupDocumentAccessService(t, user, nil)
+		deps.depRepo.nomenclatureIDs = []string{uuid.NewString()}
+		deps.depRepo.headNomenclatureIDs = map[uuid.UUID][]string{user.ID: {nomenclatureID.String()}}
+
+		scope, err := deps.service.ResolveReadScope(deps.sessionCtx(), models.DocumentKindIncomingLetter)
+
+		require.NoError(t, err)
+		require.NotNil(t, scope)
+		assert.True(t, scope.Restricted)
+		assert.Equal(t, []string{nomenclatureID.String()}, scope.AllowedNomenclatureIDs)
+	})
+
 	t.Run("non participant with domain access receives personal restricted scope only", func(t *testing.T) {
 		user := documentAccessUser(false, nil)
 		deps := setupDocumentAccessService(
@@ -396,7 +436,7 @@ func TestDocumentAccessService_ResolveReadScope(t *testing.T) {
 			allowDocumentActions(models.DocumentKindIncomingLetter, "upload"),
 		)
 
-		scope, err := deps.service.ResolveReadScope(models.DocumentKindIncomingLetter)
+		scope, err := deps.service.ResolveReadScope(deps.sessionCtx(), models.DocumentKindIncomingLetter)
 
 		require.NoError(t, err)
 		require.NotNil(t, scope)
@@ -442,7 +482,7 @@ func TestDocumentAccessService_ResolveReadableDocuments(t *testing.T) {
 		},
 	}
 
-	readable, err := deps.service.ResolveReadableDocuments([]uuid.UUID{
+	readable, err := deps.service.ResolveReadableDocuments(deps.sessionCtx(), []uuid.UUID{
 		uuid.Nil,
 		byDepartmentID,
 		byDepartmentID,
@@ -472,7 +512,7 @@ func TestDocumentAccessService_ResolveReadableDocumentsForSubstitute(t *testing.
 		deniedID:       documentAccessDoc(deniedID, uuid.New(), models.DocumentKindIncomingLetter),
 	}
 
-	readable, err := deps.service.ResolveReadableDocuments([]uuid.UUID{byAssignmentID, deniedID})
+	readable, err := deps.service.ResolveReadableDocuments(deps.sessionCtx(), []uuid.UUID{byAssignmentID, deniedID})
 
 	require.NoError(t, err)
 	assert.Contains(t, readable, byAssignmentID)
@@ -527,7 +567,7 @@ func TestDocumentAccessService_HasImplicitReadAccess(t *testing.T) {
 	t.Run("nil document is not found", func(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(true, nil), nil)
 
-		ok, err := deps.service.hasImplicitReadAccess(nil)
+		ok, err := deps.service.hasImplicitReadAccess(models.NewPrincipal(deps.user, nil), nil)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "документ не найден")
@@ -537,7 +577,7 @@ func TestDocumentAccessService_HasImplicitReadAccess(t *testing.T) {
 	t.Run("non participant is denied without repository checks", func(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(false, nil), nil)
 
-		ok, err := deps.service.hasImplicitReadAccess(&models.Document{ID: uuid.New()})
+		ok, err := deps.service.hasImplicitReadAccess(models.NewPrincipal(deps.user, nil), &models.Document{ID: uuid.New()})
 
 		require.NoError(t, err)
 		assert.False(t, ok)
@@ -549,7 +589,7 @@ func TestDocumentAccessService_HasImplicitReadAccess(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(true, &departmentID), nil)
 		deps.assignRepo.err = expectedErr
 
-		ok, err := deps.service.hasImplicitReadAccess(&models.Document{ID: uuid.New(), NomenclatureID: uuid.New()})
+		ok, err := deps.service.hasImplicitReadAccess(models.NewPrincipal(deps.user, nil), &models.Document{ID: uuid.New(), NomenclatureID: uuid.New()})
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.False(t, ok)
@@ -561,7 +601,7 @@ func TestDocumentAccessService_HasImplicitReadAccess(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(true, &departmentID), nil)
 		deps.ackRepo.err = expectedErr
 
-		ok, err := deps.service.hasImplicitReadAccess(&models.Document{ID: uuid.New(), NomenclatureID: uuid.New()})
+		ok, err := deps.service.hasImplicitReadAccess(models.NewPrincipal(deps.user, nil), &models.Document{ID: uuid.New(), NomenclatureID: uuid.New()})
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.False(t, ok)
@@ -578,7 +618,7 @@ func TestDocumentAccessService_RequireResolvedRead(t *testing.T) {
 			allowDocumentActions(models.DocumentKindIncomingLetter, "read"),
 		)
 
-		err := deps.service.RequireResolvedRead(
+		err := deps.service.RequireResolvedRead(deps.sessionCtx(),
 			string(models.DocumentKindIncomingLetter),
 			documentID,
 			nomenclatureID,
@@ -593,7 +633,7 @@ func TestDocumentAccessService_RequireResolvedRead(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(true, &departmentID), nil)
 		deps.depRepo.nomenclatureIDs = []string{nomenclatureID.String()}
 
-		err := deps.service.RequireResolvedRead(
+		err := deps.service.RequireResolvedRead(deps.sessionCtx(),
 			string(models.DocumentKindIncomingLetter),
 			uuid.New(),
 			nomenclatureID,
@@ -608,7 +648,7 @@ func TestDocumentAccessService_RequireResolvedRead(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(true, &departmentID), nil)
 		deps.assignRepo.accessible[documentID] = struct{}{}
 
-		err := deps.service.RequireResolvedRead(
+		err := deps.service.RequireResolvedRead(deps.sessionCtx(),
 			string(models.DocumentKindIncomingLetter),
 			documentID,
 			uuid.New(),
@@ -623,7 +663,7 @@ func TestDocumentAccessService_RequireResolvedRead(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(true, &departmentID), nil)
 		deps.ackRepo.accessible[documentID] = struct{}{}
 
-		err := deps.service.RequireResolvedRead(
+		err := deps.service.RequireResolvedRead(deps.sessionCtx(),
 			string(models.DocumentKindIncomingLetter),
 			documentID,
 			uuid.New(),
@@ -636,7 +676,7 @@ func TestDocumentAccessService_RequireResolvedRead(t *testing.T) {
 		departmentID := uuid.New()
 		deps := setupDocumentAccessService(t, documentAccessUser(true, &departmentID), nil)
 
-		err := deps.service.RequireResolvedRead(
+		err := deps.service.RequireResolvedRead(deps.sessionCtx(),
 			string(models.DocumentKindIncomingLetter),
 			uuid.New(),
 			uuid.New(),
@@ -652,7 +692,7 @@ func TestDocumentAccessService_RequireResolvedRead(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(true, &departmentID), nil)
 		deps.assignRepo.err = expectedErr
 
-		err := deps.service.RequireResolvedRead(
+		err := deps.service.RequireResolvedRead(deps.sessionCtx(),
 			string(models.DocumentKindIncomingLetter),
 			documentID,
 			uuid.New(),
@@ -677,7 +717,7 @@ func TestDocumentAccessService_RequireRead(t *testing.T) {
 			models.DocumentKindIncomingLetter,
 		)
 
-		err := deps.service.RequireRead(string(models.DocumentKindIncomingLetter), documentID)
+		err := deps.service.RequireRead(deps.sessionCtx(), string(models.DocumentKindIncomingLetter), documentID)
 
 		require.NoError(t, err)
 	})
@@ -689,7 +729,7 @@ func TestDocumentAccessService_RequireRead(t *testing.T) {
 			allowDocumentActions(models.DocumentKindIncomingLetter, "read"),
 		)
 
-		err := deps.service.RequireRead(string(models.DocumentKindIncomingLetter), uuid.New())
+		err := deps.service.RequireRead(deps.sessionCtx(), string(models.DocumentKindIncomingLetter), uuid.New())
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "документ не найден")
@@ -704,7 +744,7 @@ func TestDocumentAccessService_RequireRead(t *testing.T) {
 		)
 		deps.docRepo.err = expectedErr
 
-		err := deps.service.RequireRead(string(models.DocumentKindIncomingLetter), uuid.New())
+		err := deps.service.RequireRead(deps.sessionCtx(), string(models.DocumentKindIncomingLetter), uuid.New())
 
 		require.ErrorIs(t, err, expectedErr)
 	})
@@ -725,7 +765,7 @@ func TestDocumentAccessService_RequireDocumentAction(t *testing.T) {
 			models.DocumentKindIncomingLetter,
 		)
 
-		err := deps.service.RequireDocumentAction(documentID, "update")
+		err := deps.service.RequireDocumentAction(deps.sessionCtx(), documentID, "update")
 
 		require.NoError(t, err)
 	})
@@ -744,7 +784,7 @@ func TestDocumentAccessService_RequireDocumentAction(t *testing.T) {
 			models.DocumentKindIncomingLetter,
 		)
 
-		err := deps.service.RequireDocumentAction(documentID, "update")
+		err := deps.service.RequireDocumentAction(deps.sessionCtx(), documentID, "update")
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 	})
@@ -763,7 +803,7 @@ func TestDocumentAccessService_RequireDocumentAction(t *testing.T) {
 			models.DocumentKindIncomingLetter,
 		)
 
-		err := deps.service.RequireDocumentAction(documentID, "update")
+		err := deps.service.RequireDocumentAction(deps.sessionCtx(), documentID, "update")
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 	})
@@ -774,22 +814,22 @@ func TestDocumentAccessService_DocumentActionQueries(t *testing.T) {
 	allowed = addDocumentActions(allowed, models.DocumentKindOutgoingLetter, "read")
 	deps := setupDocumentAccessService(t, documentAccessUser(false, nil), allowed)
 
-	actions, err := deps.service.GetAvailableActions(models.DocumentKindIncomingLetter)
+	actions, err := deps.service.GetAvailableActions(deps.sessionCtx(), models.DocumentKindIncomingLetter)
 	require.NoError(t, err)
 	assert.Equal(t, []string{"read", "upload"}, actions)
 
-	hasUpload, err := deps.service.HasAnyDocumentAction("upload")
+	hasUpload, err := deps.service.HasAnyDocumentAction(deps.sessionCtx(), "upload")
 	require.NoError(t, err)
 	assert.True(t, hasUpload)
 
-	kinds, err := deps.service.GetDocumentKindsWithAction("read")
+	kinds, err := deps.service.GetDocumentKindsWithAction(deps.sessionCtx(), "read")
 	require.NoError(t, err)
 	assert.ElementsMatch(t, []models.DocumentKind{
 		models.DocumentKindIncomingLetter,
 		models.DocumentKindOutgoingLetter,
 	}, kinds)
 
-	hasAssign, err := deps.service.HasDocumentAction(models.DocumentKindIncomingLetter, "assign")
+	hasAssign, err := deps.service.HasDocumentAction(deps.sessionCtx(), models.DocumentKindIncomingLetter, "assign")
 	require.NoError(t, err)
 	assert.False(t, hasAssign)
 }
@@ -804,7 +844,7 @@ func TestDocumentAccessService_HasAssignmentAccess(t *testing.T) {
 		)
 		deps.assignRepo.accessible[documentID] = struct{}{}
 
-		ok, err := deps.service.HasAssignmentAccess(documentID)
+		ok, err := deps.service.HasAssignmentAccess(deps.sessionCtx(), documentID)
 
 		require.NoError(t, err)
 		assert.True(t, ok)
@@ -813,7 +853,7 @@ func TestDocumentAccessService_HasAssignmentAccess(t *testing.T) {
 	t.Run("rejects users without document domain access", func(t *testing.T) {
 		deps := setupDocumentAccessService(t, documentAccessUser(false, nil), nil)
 
-		ok, err := deps.service.HasAssignmentAccess(uuid.New())
+		ok, err := deps.service.HasAssignmentAccess(deps.sessionCtx(), uuid.New())
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.False(t, ok)
@@ -834,7 +874,7 @@ func TestDocumentAccessService_RequireViewJournal(t *testing.T) {
 			models.DocumentKindIncomingLetter,
 		)
 
-		err := deps.service.RequireViewJournal(documentID)
+		err := deps.service.RequireViewJournal(deps.sessionCtx(), documentID)
 
 		require.NoError(t, err)
 	})
@@ -852,7 +892,7 @@ func TestDocumentAccessService_RequireViewJournal(t *testing.T) {
 			models.DocumentKindIncomingLetter,
 		)
 
-		err := deps.service.RequireViewJournal(documentID)
+		err := deps.service.RequireViewJournal(deps.sessionCtx(), documentID)
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 	})
@@ -871,7 +911,7 @@ func TestDocumentAccessService_RequireReadAnyType(t *testing.T) {
 		models.DocumentKindOutgoingLetter,
 	)
 
-	err := deps.service.RequireReadAnyType(documentID)
+	err := deps.service.RequireReadAnyType(deps.sessionCtx(), documentID)
 
 	require.NoError(t, err)
 }
@@ -973,7 +1013,7 @@ func TestDocumentAccessService_ResolveLink(t *testing.T) {
 		deps.docRepo.docs[sourceID] = documentAccessDoc(sourceID, uuid.New(), models.DocumentKindIncomingLetter)
 		deps.docRepo.docs[targetID] = documentAccessDoc(targetID, uuid.New(), models.DocumentKindOutgoingLetter)
 
-		sourceDoc, targetDoc, err := deps.service.ResolveLink(sourceID, targetID)
+		sourceDoc, targetDoc, err := deps.service.ResolveLink(deps.sessionCtx(), sourceID, targetID)
 
 		require.NoError(t, err)
 		require.NotNil(t, sourceDoc)
@@ -991,7 +1031,7 @@ func TestDocumentAccessService_ResolveLink(t *testing.T) {
 		deps.docRepo.docs[sourceID] = documentAccessDoc(sourceID, uuid.New(), models.DocumentKindIncomingLetter)
 		deps.docRepo.docs[targetID] = documentAccessDoc(targetID, uuid.New(), models.DocumentKindOutgoingLetter)
 
-		sourceDoc, targetDoc, err := deps.service.ResolveLink(sourceID, targetID)
+		sourceDoc, targetDoc, err := deps.service.ResolveLink(deps.sessionCtx(), sourceID, targetID)
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, sourceDoc)
@@ -1003,7 +1043,7 @@ func TestDocumentAccessService_RequireViewJournalWithoutAccessRepository(t *test
 	deps := setupDocumentAccessService(t, documentAccessUser(false, nil), nil)
 	deps.service.accessRepo = nil
 
-	err := deps.service.RequireViewJournal(uuid.New())
+	err := deps.service.RequireViewJournal(deps.sessionCtx(), uuid.New())
 
 	require.ErrorIs(t, err, models.ErrForbidden)
 }
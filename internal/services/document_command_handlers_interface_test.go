@@ -51,22 +51,23 @@ func TestDocumentCommandHandlers_InterfaceMethods(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			assert.Equal(t, tt.kind, tt.handler.Kind())
+			ctx := principalTestContext(documentAccessUser(false, nil))
 
-			result, err := tt.handler.RegisterDocument("bad request")
+			result, err := tt.handler.RegisterDocument(ctx, "bad request")
 			require.Error(t, err)
 			assert.Nil(t, result)
 			assert.Contains(t, err.Error(), "invalid register request")
 
-			result, err = tt.handler.RegisterDocument(tt.registerReq)
+			result, err = tt.handler.RegisterDocument(ctx, tt.registerReq)
 			require.ErrorIs(t, err, models.ErrForbidden)
 			assert.Nil(t, result)
 
-			result, err = tt.handler.UpdateDocument("bad request")
+			result, err = tt.handler.UpdateDocument(ctx, "bad request")
 			require.Error(t, err)
 			assert.Nil(t, result)
 			assert.Contains(t, err.Error(), "invalid update request")
 
-			result, err = tt.handler.UpdateDocument(tt.updateReq)
+			result, err = tt.handler.UpdateDocument(ctx, tt.updateReq)
 			require.Error(t, err)
 			assert.Nil(t, result)
 			assert.Contains(t, err.Error(), "неверный ID документа")
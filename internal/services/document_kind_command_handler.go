@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
@@ -9,19 +10,27 @@ import (
 )
 
 // DocumentKindCommandHandler описывает write-обработчик конкретного вида документа.
+// Команды выполняются от имени principal из ctx.
 type DocumentKindCommandHandler interface {
 	Kind() models.DocumentKind
-	RegisterDocument(req any) (any, error)
-	UpdateDocument(req any) (any, error)
+	RegisterDocument(ctx context.Context, req any) (any, error)
+	UpdateDocument(ctx context.Context, req any) (any, error)
 }
 
 type AdminDraftCommandHandler interface {
-	CreateAdminDraft(req AdminDraftCreateRequest) (any, error)
+	CreateAdminDraft(ctx context.Context, req AdminDraftCreateRequest) (any, error)
 }
 
 // DocumentKindCommandRegistry хранит обработчики command-операций по видам документов.
 type DocumentKindCommandRegistry struct {
 	handlers map[models.DocumentKind]DocumentKindCommandHandler
+	resolver DocumentKindCommandResolver
+}
+
+// DocumentKindCommandResolver подбирает обработчик для видов документов, не зарегистрированных явно,
+// например для пользовательских видов, заданных администратором.
+type DocumentKindCommandResolver interface {
+	ResolveDocumentKind(kind models.DocumentKind) (DocumentKindCommandHandler, bool)
 }
 
 // NewDocumentKindCommandRegistry создает registry command-обработчиков документов.
@@ -39,24 +48,34 @@ func NewDocumentKindCommandRegistry(handlers ...DocumentKindCommandHandler) *Doc
 
 // Get возвращает обработчик command-операций по виду документа.
 func (r *DocumentKindCommandRegistry) Get(kind models.DocumentKind) (DocumentKindCommandHandler, error) {
-	handler, ok := r.handlers[kind]
-	if !ok {
-		return nil, models.NewBadRequest("неподдерживаемый вид документа")
+	if handler, ok := r.handlers[kind]; ok {
+		return handler, nil
 	}
+	if r.resolver != nil {
+		if handler, ok := r.resolver.ResolveDocumentKind(kind); ok {
+			return handler, nil
+		}
+	}
+
+	return nil, models.NewBadRequest("неподдерживаемый вид документа")
+}
 
-	return handler, nil
+// SetResolver задает обработчик видов документов, отсутствующих в registry.
+func (r *DocumentKindCommandRegistry) SetResolver(resolver DocumentKindCommandResolver) {
+	r.resolver = resolver
 }
 
 // DocumentRegistrationService предоставляет общий command API для регистрации и обновления документов.
 type DocumentRegistrationService struct {
 	registry  *DocumentKindCommandRegistry
+	auth      *AuthService
 	lifecycle *OperationLifecycle
 	metrics   *observability.Registry
 }
 
 // NewDocumentRegistrationService создает новый экземпляр DocumentRegistrationService.
-func NewDocumentRegistrationService(registry *DocumentKindCommandRegistry) *DocumentRegistrationService {
-	return &DocumentRegistrationService{registry: registry}
+func NewDocumentRegistrationService(registry *DocumentKindCommandRegistry, auth *AuthService) *DocumentRegistrationService {
+	return &DocumentRegistrationService{registry: registry, auth: auth}
 }
 
 func (s *DocumentRegistrationService) SetOperationLifecycle(lifecycle *OperationLifecycle) {
@@ -69,6 +88,14 @@ func (s *DocumentRegistrationService) SetOperationMetrics(metrics *observability
 
 // Register делегирует регистрацию документа обработчику по kindCode.
 func (s *DocumentRegistrationService) Register(kindCode string, req any) (any, error) {
+	principalCtx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.register(principalCtx, kindCode, req)
+}
+
+func (s *DocumentRegistrationService) register(principalCtx context.Context, kindCode string, req any) (any, error) {
 	return measureOperation(s.metrics, "documents.register", func() (any, error) {
 		ctx, release := serviceOperationContext(s.lifecycle)
 		defer release()
@@ -87,7 +114,7 @@ func (s *DocumentRegistrationService) Register(kindCode string, req any) (any, e
 			return nil, err
 		}
 
-		result, err := handler.RegisterDocument(normalizedReq)
+		result, err := handler.RegisterDocument(principalCtx, normalizedReq)
 		if err != nil {
 			return nil, err
 		}
@@ -100,6 +127,14 @@ func (s *DocumentRegistrationService) Register(kindCode string, req any) (any, e
 
 // Update делегирует обновление документа обработчику по kindCode.
 func (s *DocumentRegistrationService) Update(kindCode string, req any) (any, error) {
+	principalCtx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.update(principalCtx, kindCode, req)
+}
+
+func (s *DocumentRegistrationService) update(principalCtx context.Context, kindCode string, req any) (any, error) {
 	return measureOperation(s.metrics, "documents.update", func() (any, error) {
 		ctx, release := serviceOperationContext(s.lifecycle)
 		defer release()
@@ -118,7 +153,7 @@ func (s *DocumentRegistrationService) Update(kindCode string, req any) (any, err
 			return nil, err
 		}
 
-		result, err := handler.UpdateDocument(normalizedReq)
+		result, err := handler.UpdateDocument(principalCtx, normalizedReq)
 		if err != nil {
 			return nil, err
 		}
@@ -131,6 +166,14 @@ func (s *DocumentRegistrationService) Update(kindCode string, req any) (any, err
 
 // CreateAdminDraft создает административный черновик с зарезервированным номером.
 func (s *DocumentRegistrationService) CreateAdminDraft(kindCode string, req AdminDraftCreateRequest) (any, error) {
+	principalCtx, err := s.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.createAdminDraft(principalCtx, kindCode, req)
+}
+
+func (s *DocumentRegistrationService) createAdminDraft(principalCtx context.Context, kindCode string, req AdminDraftCreateRequest) (any, error) {
 	return measureOperation(s.metrics, "documents.create_admin_draft", func() (any, error) {
 		ctx, release := serviceOperationContext(s.lifecycle)
 		defer release()
@@ -148,7 +191,7 @@ func (s *DocumentRegistrationService) CreateAdminDraft(kindCode string, req Admi
 			return nil, models.ErrForbidden
 		}
 
-		result, err := draftHandler.CreateAdminDraft(req)
+		result, err := draftHandler.CreateAdminDraft(principalCtx, req)
 		if err != nil {
 			return nil, err
 		}
@@ -206,7 +249,19 @@ func normalizeRegisterRequest(kind models.DocumentKind, req any) (any, error) {
 
 		return typedReq, nil
 	default:
-		return nil, models.ErrForbidden
+		if spec, ok := models.GetDocumentKindSpec(kind); !ok || !spec.IsCustom {
+			return nil, models.ErrForbidden
+		}
+		if typedReq, ok := req.(CustomDocumentRegisterRequest); ok {
+			return typedReq, nil
+		}
+
+		var typedReq CustomDocumentRegisterRequest
+		if err := decodeDocumentCommandRequest(req, &typedReq); err != nil {
+			return nil, err
+		}
+
+		return typedReq, nil
 	}
 }
 
@@ -257,7 +312,19 @@ func normalizeUpdateRequest(kind models.DocumentKind, req any) (any, error) {
 
 		return typedReq, nil
 	default:
-		return nil, models.ErrForbidden
+		if spec, ok := models.GetDocumentKindSpec(kind); !ok || !spec.IsCustom {
+			return nil, models.ErrForbidden
+		}
+		if typedReq, ok := req.(CustomDocumentUpdateRequest); ok {
+			return typedReq, nil
+		}
+
+		var typedReq CustomDocumentUpdateRequest
+		if err := decodeDocumentCommandRequest(req, &typedReq); err != nil {
+			return nil, err
+		}
+
+		return typedReq, nil
 	}
 }
 
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -19,11 +20,11 @@ func (h stubDocumentKindCommandHandler) Kind() models.DocumentKind {
 	return h.kind
 }
 
-func (h stubDocumentKindCommandHandler) RegisterDocument(req any) (any, error) {
+func (h stubDocumentKindCommandHandler) RegisterDocument(_ context.Context, req any) (any, error) {
 	return h.registerFunc(req)
 }
 
-func (h stubDocumentKindCommandHandler) UpdateDocument(req any) (any, error) {
+func (h stubDocumentKindCommandHandler) UpdateDocument(_ context.Context, req any) (any, error) {
 	return h.updateFunc(req)
 }
 
@@ -49,9 +50,9 @@ func TestDocumentRegistrationService_RegisterRoutesToKindHandler(t *testing.T) {
 		},
 	}
 
-	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler))
+	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler), nil)
 
-	got, err := service.Register(string(models.DocumentKindIncomingLetter), expectedReq)
+	got, err := service.register(principalTestContext(documentAccessUser(false, nil)), string(models.DocumentKindIncomingLetter), expectedReq)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -82,9 +83,9 @@ func TestDocumentRegistrationService_UpdateRoutesToKindHandler(t *testing.T) {
 		},
 	}
 
-	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler))
+	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler), nil)
 
-	got, err := service.Update(string(models.DocumentKindOutgoingLetter), expectedReq)
+	got, err := service.update(principalTestContext(documentAccessUser(false, nil)), string(models.DocumentKindOutgoingLetter), expectedReq)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -96,9 +97,9 @@ func TestDocumentRegistrationService_UpdateRoutesToKindHandler(t *testing.T) {
 func TestDocumentRegistrationService_UnsupportedKindReturnsForbidden(t *testing.T) {
 	t.Parallel()
 
-	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry())
+	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(), nil)
 
-	_, err := service.Register("unknown_kind", nil)
+	_, err := service.register(principalTestContext(documentAccessUser(false, nil)), "unknown_kind", nil)
 	if !errors.Is(err, models.ErrForbidden) {
 		t.Fatalf("expected ErrForbidden, got %v", err)
 	}
@@ -224,9 +225,9 @@ func TestDocumentRegistrationService_NormalizesRegisterRequests(t *testing.T) {
 					return nil, nil
 				},
 			}
-			service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler))
+			service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler), nil)
 
-			got, err := service.Register(string(tt.kind), tt.payload)
+			got, err := service.register(principalTestContext(documentAccessUser(false, nil)), string(tt.kind), tt.payload)
 
 			require.NoError(t, err)
 			assert.Equal(t, tt.expectedResp, got)
@@ -332,9 +333,9 @@ func TestDocumentRegistrationService_NormalizesUpdateRequests(t *testing.T) {
 					return "updated", nil
 				},
 			}
-			service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler))
+			service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler), nil)
 
-			got, err := service.Update(string(tt.kind), tt.payload)
+			got, err := service.update(principalTestContext(documentAccessUser(false, nil)), string(tt.kind), tt.payload)
 
 			require.NoError(t, err)
 			assert.Equal(t, "updated", got)
@@ -356,9 +357,9 @@ func TestDocumentRegistrationService_RejectsMalformedCommandPayload(t *testing.T
 			return nil, nil
 		},
 	}
-	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler))
+	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(handler), nil)
 
-	_, err := service.Register(string(models.DocumentKindIncomingLetter), map[string]any{
+	_, err := service.register(principalTestContext(documentAccessUser(false, nil)), string(models.DocumentKindIncomingLetter), map[string]any{
 		"content":      "test",
 		"unknownField": "boom",
 	})
@@ -370,9 +371,9 @@ func TestDocumentRegistrationService_RejectsMalformedCommandPayload(t *testing.T
 func TestDocumentRegistrationService_UnsupportedUpdateKindReturnsForbidden(t *testing.T) {
 	t.Parallel()
 
-	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry())
+	service := NewDocumentRegistrationService(NewDocumentKindCommandRegistry(), nil)
 
-	_, err := service.Update("unknown_kind", nil)
+	_, err := service.update(principalTestContext(documentAccessUser(false, nil)), "unknown_kind", nil)
 
 	require.ErrorIs(t, err, models.ErrForbidden)
 }
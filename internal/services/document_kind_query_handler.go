@@ -17,6 +17,13 @@ type DocumentKindQueryHandler interface {
 // DocumentKindQueryRegistry хранит обработчики query-операций по видам документов.
 type DocumentKindQueryRegistry struct {
 	handlers map[models.DocumentKind]DocumentKindQueryHandler
+	resolver DocumentKindQueryResolver
+}
+
+// DocumentKindQueryResolver подбирает обработчик для видов документов, не зарегистрированных явно,
+// например для пользовательских видов, заданных администратором.
+type DocumentKindQueryResolver interface {
+	ResolveDocumentKind(kind models.DocumentKind) (DocumentKindQueryHandler, bool)
 }
 
 // NewDocumentKindQueryRegistry создает registry обработчиков документов.
@@ -34,10 +41,19 @@ func NewDocumentKindQueryRegistry(handlers ...DocumentKindQueryHandler) *Documen
 
 // Get возвращает обработчик query-операций по виду документа.
 func (r *DocumentKindQueryRegistry) Get(kind models.DocumentKind) (DocumentKindQueryHandler, error) {
-	handler, ok := r.handlers[kind]
-	if !ok {
-		return nil, models.NewBadRequest("неподдерживаемый вид документа")
+	if handler, ok := r.handlers[kind]; ok {
+		return handler, nil
+	}
+	if r.resolver != nil {
+		if handler, ok := r.resolver.ResolveDocumentKind(kind); ok {
+			return handler, nil
+		}
 	}
 
-	return handler, nil
+	return nil, models.NewBadRequest("неподдерживаемый вид документа")
+}
+
+// SetResolver задает обработчик видов документов, отсутствующих в registry.
+func (r *DocumentKindQueryRegistry) SetResolver(resolver DocumentKindQueryResolver) {
+	r.resolver = resolver
 }
@@ -7,7 +7,8 @@ import (
 
 // DocumentKindService предоставляет системные метаданные видов документов.
 type DocumentKindService struct {
-	access *DocumentAccessService
+	access      *DocumentAccessService
+	loadCatalog func() error
 }
 
 // NewDocumentKindService создает новый сервис метаданных видов документов.
@@ -15,21 +16,29 @@ func NewDocumentKindService(access *DocumentAccessService) *DocumentKindService
 	return &DocumentKindService{access: access}
 }
 
+// SetCatalogLoader задает перечитывание пользовательских видов документов перед построением
+// access-модели, чтобы виды, добавленные на другом рабочем месте, появлялись без перезапуска.
+func (s *DocumentKindService) SetCatalogLoader(loader func() error) {
+	s.loadCatalog = loader
+}
+
 // GetCurrentAccessSummary возвращает текущую access-модель для навигации и UI.
 func (s *DocumentKindService) GetCurrentAccessSummary() (*dto.CurrentAccessSummary, error) {
 	if s.access == nil || s.access.auth == nil {
 		return nil, models.ErrUnauthorized
 	}
-	if err := s.access.auth.RequireAuthenticated(); err != nil {
+	ctx, err := s.access.auth.sessionContext()
+	if err != nil {
 		return nil, err
 	}
-
-	user, err := s.access.auth.GetCurrentUser()
+	principal, err := requirePrincipal(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
-		return nil, models.ErrUnauthorized
+	if s.loadCatalog != nil {
+		if err := s.loadCatalog(); err != nil {
+			return nil, err
+		}
 	}
 
 	specs := models.AllDocumentKindSpecs()
@@ -37,15 +46,11 @@ func (s *DocumentKindService) GetCurrentAccessSummary() (*dto.CurrentAccessSumma
 	registrationKinds := make([]string, 0)
 	hasAnyAction := false
 	hasAnyAssign := false
-	subjectIDs, err := s.access.getCurrentUserAndSubstitutionSubjectIDs()
-	if err != nil {
-		return nil, err
-	}
-	hasActiveSubstitution := len(subjectIDs) > 1
+	hasActiveSubstitution := principal.HasActiveSubstitution()
 
 	for _, spec := range specs {
 		base := dto.MapDocumentKindSpec(spec)
-		actions, err := s.access.GetAvailableActions(spec.Code)
+		actions, err := s.access.GetAvailableActions(ctx, spec.Code)
 		if err != nil {
 			return nil, err
 		}
@@ -53,7 +58,7 @@ func (s *DocumentKindService) GetCurrentAccessSummary() (*dto.CurrentAccessSumma
 		canRegister := containsAction(actions, string(models.DocumentActionCreate))
 		canReadFull := containsAction(actions, string(models.DocumentActionRead))
 		canAssign := containsAction(actions, string(models.DocumentActionAssign))
-		canOpenPage := user.IsDocumentParticipant || hasActiveSubstitution || canReadFull || canRegister
+		canOpenPage := principal.IsDocumentParticipant || hasActiveSubstitution || canReadFull || canRegister
 
 		if len(actions) > 0 {
 			hasAnyAction = true
@@ -75,10 +80,11 @@ func (s *DocumentKindService) GetCurrentAccessSummary() (*dto.CurrentAccessSumma
 			CanOpenPage:          canOpenPage,
 			CanRegister:          canRegister,
 			CanReadFull:          canReadFull,
+			IsCustom:             base.IsCustom,
 		})
 	}
 
-	documentDomainAccess := user.IsDocumentParticipant || hasActiveSubstitution || hasAnyAction
+	documentDomainAccess := principal.IsDocumentParticipant || hasActiveSubstitution || hasAnyAction
 	systemPermissions := []string{}
 	for _, permission := range []string{
 		models.SystemPermissionAdmin,
@@ -93,7 +99,7 @@ func (s *DocumentKindService) GetCurrentAccessSummary() (*dto.CurrentAccessSumma
 	}
 
 	return &dto.CurrentAccessSummary{
-		IsDocumentParticipant: user.IsDocumentParticipant,
+		IsDocumentParticipant: principal.IsDocumentParticipant,
 		DocumentDomainAccess:  documentDomainAccess,
 		Sections: dto.AccessSections{
 			Dashboard:   documentDomainAccess,
@@ -101,7 +107,7 @@ func (s *DocumentKindService) GetCurrentAccessSummary() (*dto.CurrentAccessSumma
 			Outgoing:    canOpenDocumentKindPage(documentKinds, string(models.DocumentKindOutgoingLetter)),
 			Appeals:     canOpenDocumentKindPage(documentKinds, string(models.DocumentKindCitizenAppeal)),
 			Orders:      canOpenDocumentKindPage(documentKinds, string(models.DocumentKindAdministrativeOrder)),
-			Assignments: user.IsDocumentParticipant || hasActiveSubstitution || hasAnyAssign,
+			Assignments: principal.IsDocumentParticipant || hasActiveSubstitution || hasAnyAssign,
 			References:  containsAction(systemPermissions, models.SystemPermissionReferences),
 			Statistics:  containsAnyAction(systemPermissions, models.SystemPermissionStatsDocuments, models.SystemPermissionStatsAssignments, models.SystemPermissionStatsSystem),
 			Settings:    containsAction(systemPermissions, models.SystemPermissionAdmin),
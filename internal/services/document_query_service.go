@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
@@ -32,8 +34,16 @@ func NewDocumentQueryService(
 
 // GetByID возвращает общую карточку документа по его ID.
 func (s *DocumentQueryService) GetByID(id string) (*dto.DocumentCard, error) {
+	ctx, err := s.access.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.getByID(ctx, id)
+}
+
+func (s *DocumentQueryService) getByID(ctx context.Context, id string) (*dto.DocumentCard, error) {
 	return measureOperation(s.metrics, "documents.get_card", func() (*dto.DocumentCard, error) {
-		if err := s.access.RequireDomainRead(); err != nil {
+		if err := s.access.RequireDomainRead(ctx); err != nil {
 			return nil, err
 		}
 
@@ -46,7 +56,7 @@ func (s *DocumentQueryService) GetByID(id string) (*dto.DocumentCard, error) {
 		if err != nil {
 			return nil, err
 		}
-		if err := s.access.RequireReadResolved(doc); err != nil {
+		if err := s.access.RequireReadResolved(ctx, doc); err != nil {
 			return nil, err
 		}
 
@@ -61,13 +71,21 @@ func (s *DocumentQueryService) GetByID(id string) (*dto.DocumentCard, error) {
 
 // GetList возвращает общий список документов указанного вида.
 func (s *DocumentQueryService) GetList(kindCode string, filter models.DocumentFilter) (*dto.PagedResult[dto.DocumentListItem], error) {
+	ctx, err := s.access.auth.sessionContext()
+	if err != nil {
+		return nil, err
+	}
+	return s.getList(ctx, kindCode, filter)
+}
+
+func (s *DocumentQueryService) getList(ctx context.Context, kindCode string, filter models.DocumentFilter) (*dto.PagedResult[dto.DocumentListItem], error) {
 	return measureOperation(s.metrics, "documents.get_list", func() (*dto.PagedResult[dto.DocumentListItem], error) {
-		if err := s.access.RequireDomainRead(); err != nil {
+		if err := s.access.RequireDomainRead(ctx); err != nil {
 			return nil, err
 		}
 
 		kind := models.DocumentKind(kindCode)
-		scope, err := s.access.ResolveReadScope(kind)
+		scope, err := s.access.ResolveReadScope(ctx, kind)
 		if err != nil {
 			return nil, err
 		}
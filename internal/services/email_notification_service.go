@@ -0,0 +1,86 @@
+package services
+
+import (
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// EmailNotificationService управляет адресом и режимом почтовых уведомлений
+// текущего пользователя. Сами письма ставит в очередь и отправляет
+// outbox.Worker.
+type EmailNotificationService struct {
+	repo     EmailNotificationStore
+	auth     *AuthService
+	settings SettingsStore
+}
+
+// NewEmailNotificationService создает сервис почтовых уведомлений. Канал
+// включается системной настройкой email_notifications_enabled.
+func NewEmailNotificationService(repo EmailNotificationStore, auth *AuthService, settings SettingsStore) *EmailNotificationService {
+	return &EmailNotificationService{repo: repo, auth: auth, settings: settings}
+}
+
+// channelEnabled сообщает, включил ли администратор почтовые уведомления.
+func (s *EmailNotificationService) channelEnabled() bool {
+	if s.settings == nil {
+		return false
+	}
+	value, ok := storeSettingLookup(s.settings)(models.SettingEmailNotificationsEnabled)
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err == nil && enabled
+}
+
+// GetMyEmailNotificationSettings возвращает почтовые уведомления текущего
+// пользователя.
+func (s *EmailNotificationService) GetMyEmailNotificationSettings() (*models.EmailNotificationSettings, error) {
+	userID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.repo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.ChannelEnabled = s.channelEnabled()
+	return settings, nil
+}
+
+// UpdateMyEmailNotificationSettings сохраняет адрес и режим почтовых
+// уведомлений текущего пользователя. Режим, отличный от "off", требует адреса
+// и включенного администратором почтового канала.
+func (s *EmailNotificationService) UpdateMyEmailNotificationSettings(req models.UpdateEmailNotificationSettingsRequest) (*models.EmailNotificationSettings, error) {
+	userID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return nil, err
+	}
+	email := strings.TrimSpace(req.Email)
+	mode := strings.TrimSpace(req.Mode)
+	if mode == "" {
+		mode = models.EmailNotificationsOff
+	}
+	if !models.IsEmailNotificationMode(mode) {
+		return nil, models.NewBadRequest("неизвестный режим почтовых уведомлений")
+	}
+	if email != "" {
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Address != email || address.Name != "" {
+			return nil, models.NewBadRequest("некорректный адрес электронной почты")
+		}
+	} else if mode != models.EmailNotificationsOff {
+		return nil, models.NewBadRequest("для почтовых уведомлений укажите адрес электронной почты")
+	}
+	channelEnabled := s.channelEnabled()
+	if mode != models.EmailNotificationsOff && !channelEnabled {
+		return nil, models.NewBadRequest("почтовые уведомления отключены администратором")
+	}
+	if err := s.repo.UpdateSettings(userID, email, mode); err != nil {
+		return nil, err
+	}
+	return &models.EmailNotificationSettings{Email: email, Mode: mode, ChannelEnabled: channelEnabled}, nil
+}
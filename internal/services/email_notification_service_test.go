@@ -0,0 +1,106 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type emailNotificationStoreStub struct {
+	settings map[uuid.UUID]models.EmailNotificationSettings
+}
+
+func (s *emailNotificationStoreStub) GetSettings(userID uuid.UUID) (*models.EmailNotificationSettings, error) {
+	settings, ok := s.settings[userID]
+	if !ok {
+		return &models.EmailNotificationSettings{Mode: models.EmailNotificationsOff}, nil
+	}
+	return &settings, nil
+}
+
+func (s *emailNotificationStoreStub) UpdateSettings(userID uuid.UUID, email, mode string) error {
+	if s.settings == nil {
+		s.settings = make(map[uuid.UUID]models.EmailNotificationSettings)
+	}
+	s.settings[userID] = models.EmailNotificationSettings{Email: email, Mode: mode}
+	return nil
+}
+
+func TestEmailNotificationService_Settings(t *testing.T) {
+	user := &models.User{ID: uuid.New(), IsActive: true}
+	userRepo := mocks.NewUserStore(t)
+	userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+	auth := NewAuthService(nil, userRepo)
+	auth.currentUserID = user.ID
+	store := &emailNotificationStoreStub{}
+	svc := NewEmailNotificationService(store, auth, newPolicySettingsStore(t, map[string]string{models.SettingEmailNotificationsEnabled: "true"}))
+
+	settings, err := svc.GetMyEmailNotificationSettings()
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailNotificationsOff, settings.Mode)
+	assert.True(t, settings.ChannelEnabled)
+
+	settings, err = svc.UpdateMyEmailNotificationSettings(models.UpdateEmailNotificationSettingsRequest{Email: " petrov@example.local ", Mode: models.EmailNotificationsDigest})
+	require.NoError(t, err)
+	assert.Equal(t, "petrov@example.local", settings.Email)
+	assert.Equal(t, models.EmailNotificationSettings{Email: "petrov@example.local", Mode: models.EmailNotificationsDigest}, store.settings[user.ID])
+
+	settings, err = svc.UpdateMyEmailNotificationSettings(models.UpdateEmailNotificationSettingsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailNotificationsOff, settings.Mode)
+	assert.Empty(t, store.settings[user.ID].Email)
+}
+
+func TestEmailNotificationService_RejectsInvalidSettings(t *testing.T) {
+	user := &models.User{ID: uuid.New(), IsActive: true}
+	userRepo := mocks.NewUserStore(t)
+	userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+	auth := NewAuthService(nil, userRepo)
+	auth.currentUserID = user.ID
+	store := &emailNotificationStoreStub{}
+	svc := NewEmailNotificationService(store, auth, newPolicySettingsStore(t, map[string]string{models.SettingEmailNotificationsEnabled: "true"}))
+
+	_, err := svc.UpdateMyEmailNotificationSettings(models.UpdateEmailNotificationSettingsRequest{Email: "petrov@example.local", Mode: "weekly"})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "режим")
+	_, err = svc.UpdateMyEmailNotificationSettings(models.UpdateEmailNotificationSettingsRequest{Email: "Петров <petrov@example.local>", Mode: models.EmailNotificationsImmediate})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "адрес")
+	_, err = svc.UpdateMyEmailNotificationSettings(models.UpdateEmailNotificationSettingsRequest{Email: "petrov", Mode: models.EmailNotificationsImmediate})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "адрес")
+	_, err = svc.UpdateMyEmailNotificationSettings(models.UpdateEmailNotificationSettingsRequest{Mode: models.EmailNotificationsImmediate})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "укажите адрес")
+	assert.Empty(t, store.settings)
+}
+
+func TestEmailNotificationService_RejectsModeWhenChannelDisabled(t *testing.T) {
+	user := &models.User{ID: uuid.New(), IsActive: true}
+	userRepo := mocks.NewUserStore(t)
+	userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+	auth := NewAuthService(nil, userRepo)
+	auth.currentUserID = user.ID
+	store := &emailNotificationStoreStub{}
+	svc := NewEmailNotificationService(store, auth, newPolicySettingsStore(t, map[string]string{models.SettingEmailNotificationsEnabled: "false"}))
+
+	settings, err := svc.GetMyEmailNotificationSettings()
+	require.NoError(t, err)
+	assert.False(t, settings.ChannelEnabled)
+	for _, mode := range []string{models.EmailNotificationsImmediate, models.EmailNotificationsDigest} {
+		_, err = svc.UpdateMyEmailNotificationSettings(models.UpdateEmailNotificationSettingsRequest{Email: "petrov@example.local", Mode: mode})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "отключены администратором")
+	}
+	assert.Empty(t, store.settings)
+
+	settings, err = svc.UpdateMyEmailNotificationSettings(models.UpdateEmailNotificationSettingsRequest{Email: "petrov@example.local"})
+	require.NoError(t, err, "address can be kept with e-mails off")
+	assert.Equal(t, models.EmailNotificationsOff, settings.Mode)
+}
+
+func TestEmailNotificationService_RequiresLogin(t *testing.T) {
+	svc := NewEmailNotificationService(&emailNotificationStoreStub{}, NewAuthService(nil, nil), nil)
+	_, err := svc.GetMyEmailNotificationSettings()
+	require.Error(t, err)
+}
@@ -125,6 +125,13 @@ func (m *MockDepartmentStore) GetNomenclatureIDs(departmentID uuid.UUID) ([]stri
 	return nil, nil
 }
 
+func (m *MockDepartmentStore) GetVisibleNomenclatureIDs(departmentID *uuid.UUID, userID uuid.UUID) ([]string, error) {
+	if departmentID == nil {
+		return nil, nil
+	}
+	return m.GetNomenclatureIDs(*departmentID)
+}
+
 func (m *MockDepartmentStore) Create(name string, nomenclatureIDs []string) (*models.Department, error) {
 	return nil, nil
 }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -53,7 +54,6 @@ type IncomingLetterCommandHandler struct {
 	repo    IncomingDocStore
 	nomRepo NomenclatureStore
 	refRepo ReferenceStore
-	auth    *AuthService
 	journal *JournalService
 	access  *DocumentAccessService
 }
@@ -74,7 +74,6 @@ func NewIncomingLetterCommandHandler(
 	repo IncomingDocStore,
 	nomRepo NomenclatureStore,
 	refRepo ReferenceStore,
-	auth *AuthService,
 	journal *JournalService,
 	access *DocumentAccessService,
 ) *IncomingLetterCommandHandler {
@@ -82,24 +81,27 @@ func NewIncomingLetterCommandHandler(
 		repo:    repo,
 		nomRepo: nomRepo,
 		refRepo: refRepo,
-		auth:    auth,
 		journal: journal,
 		access:  access,
 	}
 }
 
 // Register регистрирует входящее письмо.
-func (h *IncomingLetterCommandHandler) Register(req IncomingLetterRegisterRequest) (*dto.IncomingDocument, error) {
+func (h *IncomingLetterCommandHandler) Register(ctx context.Context, req IncomingLetterRegisterRequest) (*dto.IncomingDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	adminOverride, err := buildAdminNumberOverride(req.AdminNumberOverride)
 	if err != nil {
 		return nil, err
 	}
 	if adminOverride != nil {
-		if err := h.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
-			return nil, err
+		if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+			return nil, models.ErrForbidden
 		}
 	} else {
-		if err := h.access.RequireCreate(models.DocumentKindIncomingLetter); err != nil {
+		if err := h.access.RequireCreate(ctx, models.DocumentKindIncomingLetter); err != nil {
 			return nil, err
 		}
 	}
@@ -113,7 +115,7 @@ func (h *IncomingLetterCommandHandler) Register(req IncomingLetterRegisterReques
 		return nil, models.NewBadRequest("неверный ключ идемпотентности")
 	}
 	docTypeID := models.NormalizeDocumentType(req.DocumentTypeID)
-	if !models.IsAllowedDocumentType(docTypeID) {
+	if docTypeID == "" {
 		return nil, models.NewBadRequest("неверный тип документа")
 	}
 
@@ -149,10 +151,7 @@ func (h *IncomingLetterCommandHandler) Register(req IncomingLetterRegisterReques
 		resExecutorsPtr = &req.ResolutionExecutors
 	}
 
-	createdBy, err := h.auth.GetCurrentUserUUID()
-	if err != nil {
-		return nil, err
-	}
+	createdBy := principal.UserID
 
 	createReq := models.CreateIncomingDocRequest{
 		NomenclatureID:      nomID,
@@ -179,20 +178,24 @@ func (h *IncomingLetterCommandHandler) Register(req IncomingLetterRegisterReques
 }
 
 // RegisterDocument реализует общий command-интерфейс по виду документа.
-func (h *IncomingLetterCommandHandler) RegisterDocument(req any) (any, error) {
+func (h *IncomingLetterCommandHandler) RegisterDocument(ctx context.Context, req any) (any, error) {
 	typedReq, ok := req.(IncomingLetterRegisterRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid register request for kind %s", h.Kind())
 	}
 
-	return h.Register(typedReq)
+	return h.Register(ctx, typedReq)
 }
 
 // CreateAdminDraft создает черновик входящего письма с административно заданным номером.
-func (h *IncomingLetterCommandHandler) CreateAdminDraft(req AdminDraftCreateRequest) (any, error) {
-	if err := h.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+func (h *IncomingLetterCommandHandler) CreateAdminDraft(ctx context.Context, req AdminDraftCreateRequest) (any, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
 		return nil, err
 	}
+	if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+		return nil, models.ErrForbidden
+	}
 	nomID, err := uuid.Parse(req.NomenclatureID)
 	if err != nil {
 		return nil, models.NewBadRequest("неверный ID номенклатуры")
@@ -208,10 +211,7 @@ func (h *IncomingLetterCommandHandler) CreateAdminDraft(req AdminDraftCreateRequ
 	if adminOverride == nil {
 		return nil, models.NewBadRequest("укажите административный номер")
 	}
-	createdBy, err := h.auth.GetCurrentUserUUID()
-	if err != nil {
-		return nil, ErrNotAuthenticated
-	}
+	createdBy := principal.UserID
 	org, err := h.refRepo.FindOrCreateOrganization(adminDraftPlaceholder)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка организации корреспондента: %w", err)
@@ -243,16 +243,20 @@ func (h *IncomingLetterCommandHandler) CreateAdminDraft(req AdminDraftCreateRequ
 }
 
 // Update обновляет входящее письмо.
-func (h *IncomingLetterCommandHandler) Update(req IncomingLetterUpdateRequest) (*dto.IncomingDocument, error) {
+func (h *IncomingLetterCommandHandler) Update(ctx context.Context, req IncomingLetterUpdateRequest) (*dto.IncomingDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	uid, err := uuid.Parse(req.ID)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 	}
-	if err := h.access.RequireDocumentAction(uid, "update"); err != nil {
+	if err := h.access.RequireDocumentAction(ctx, uid, "update"); err != nil {
 		return nil, err
 	}
 	docTypeID := models.NormalizeDocumentType(req.DocumentTypeID)
-	if !models.IsAllowedDocumentType(docTypeID) {
+	if docTypeID == "" {
 		return nil, models.NewBadRequest("неверный тип документа")
 	}
 
@@ -298,8 +302,7 @@ func (h *IncomingLetterCommandHandler) Update(req IncomingLetterUpdateRequest) (
 	if !ok {
 		return nil, fmt.Errorf("incoming document store must support atomic outbox operations")
 	}
-	currentUserID, _ := h.auth.GetCurrentUserUUID()
-	event, buildErr := NewJournalOutboxEvent("incoming:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: currentUserID, Action: "UPDATE", Details: "Документ отредактирован"})
+	event, buildErr := NewJournalOutboxEvent("incoming:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: principal.UserID, Action: "UPDATE", Details: "Документ отредактирован"})
 	if buildErr != nil {
 		return nil, buildErr
 	}
@@ -358,11 +361,11 @@ func (h *IncomingLetterCommandHandler) buildCorrespondents(reqs []IncomingLetter
 }
 
 // UpdateDocument реализует общий command-интерфейс по виду документа.
-func (h *IncomingLetterCommandHandler) UpdateDocument(req any) (any, error) {
+func (h *IncomingLetterCommandHandler) UpdateDocument(ctx context.Context, req any) (any, error) {
 	typedReq, ok := req.(IncomingLetterUpdateRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid update request for kind %s", h.Kind())
 	}
 
-	return h.Update(typedReq)
+	return h.Update(ctx, typedReq)
 }
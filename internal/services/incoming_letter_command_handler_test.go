@@ -47,7 +47,7 @@ func setupIncomingLetterCommandHandler(t *testing.T, allowed map[models.Document
 		nil,
 	)
 	journal := NewJournalService(journalRepo, auth, access)
-	handler := NewIncomingLetterCommandHandler(repo, nomRepo, refRepo, auth, journal, access)
+	handler := NewIncomingLetterCommandHandler(repo, nomRepo, refRepo, journal, access)
 
 	return &incomingLetterHandlerDeps{
 		handler:     handler,
@@ -124,7 +124,7 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 			PagesCount:     req.PagesCount,
 			CreatedBy:      deps.user.ID,
 		}, nil).Once()
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -136,7 +136,7 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 		deps := setupIncomingLetterCommandHandler(t, nil)
 		req := validIncomingLetterRegisterRequest(uuid.New(), uuid.New())
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, result)
@@ -150,7 +150,7 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 		req := validIncomingLetterRegisterRequest(uuid.New(), uuid.New())
 		req.IdempotencyKey = uuid.Nil.String()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ключ идемпотентности")
@@ -165,22 +165,22 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 		req := validIncomingLetterRegisterRequest(uuid.New(), uuid.New())
 		req.NomenclatureID = "bad-id"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ID номенклатуры")
 		assert.Nil(t, result)
 	})
 
-	t.Run("rejects invalid document type", func(t *testing.T) {
+	t.Run("rejects missing document type", func(t *testing.T) {
 		deps := setupIncomingLetterCommandHandler(
 			t,
 			allowDocumentActions(models.DocumentKindIncomingLetter, "create"),
 		)
 		req := validIncomingLetterRegisterRequest(uuid.New(), uuid.New())
-		req.DocumentTypeID = "Неизвестный тип"
+		req.DocumentTypeID = " "
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный тип документа")
@@ -195,7 +195,7 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 		req := validIncomingLetterRegisterRequest(uuid.New(), uuid.New())
 		req.IncomingDate = "03.06.2026"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный формат даты поступления")
@@ -210,7 +210,7 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 		req := validIncomingLetterRegisterRequest(uuid.New(), uuid.New())
 		req.Correspondents[0].RegistrationDate = "03.06.2026"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный формат даты регистрации корреспондента")
@@ -225,7 +225,7 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 		req := validIncomingLetterRegisterRequest(uuid.New(), uuid.New())
 		req.Correspondents = []IncomingLetterCorrespondentRequest{{}}
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "укажите реквизиты корреспондента")
@@ -241,7 +241,7 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 		expectedErr := errors.New("organization failed")
 		deps.refRepo.On("FindOrCreateOrganization", "ООО Ромашка").Return(nil, expectedErr).Once()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.Contains(t, err.Error(), "ошибка корреспондента")
@@ -262,7 +262,7 @@ func TestIncomingLetterCommandHandler_Register(t *testing.T) {
 		deps.refRepo.On("FindOrCreateOrganization", "ООО Ромашка").Return(&models.Organization{ID: orgID, Name: "ООО Ромашка"}, nil).Once()
 		deps.repo.On("Create", mock.Anything).Return(nil, expectedErr).Once()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, result)
@@ -317,7 +317,7 @@ func TestIncomingLetterCommandHandler_Update(t *testing.T) {
 			PagesCount:     req.PagesCount,
 			CreatedBy:      deps.user.ID,
 		}, nil).Once()
-		result, err := deps.handler.Update(req)
+		result, err := deps.handler.Update(principalTestContext(deps.user), req)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -331,7 +331,7 @@ func TestIncomingLetterCommandHandler_Update(t *testing.T) {
 			allowDocumentActions(models.DocumentKindIncomingLetter, "read", "update"),
 		)
 
-		result, err := deps.handler.Update(IncomingLetterUpdateRequest{ID: "bad-id"})
+		result, err := deps.handler.Update(principalTestContext(deps.user), IncomingLetterUpdateRequest{ID: "bad-id"})
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ID документа")
@@ -350,7 +350,7 @@ func TestIncomingLetterCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(IncomingLetterUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), IncomingLetterUpdateRequest{
 			ID:             documentID.String(),
 			DocumentTypeID: models.DocumentTypeLetter,
 			Correspondents: []IncomingLetterCorrespondentRequest{
@@ -366,7 +366,7 @@ func TestIncomingLetterCommandHandler_Update(t *testing.T) {
 		assert.Nil(t, result)
 	})
 
-	t.Run("rejects invalid document type", func(t *testing.T) {
+	t.Run("rejects missing document type", func(t *testing.T) {
 		documentID := uuid.New()
 		deps := setupIncomingLetterCommandHandler(
 			t,
@@ -378,9 +378,9 @@ func TestIncomingLetterCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(IncomingLetterUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), IncomingLetterUpdateRequest{
 			ID:             documentID.String(),
-			DocumentTypeID: "unknown",
+			DocumentTypeID: " ",
 		})
 
 		require.Error(t, err)
@@ -411,7 +411,7 @@ func TestIncomingLetterCommandHandler_Update(t *testing.T) {
 				updateReq.ResolutionExecutors != nil
 		})).Return(nil, expectedErr).Once()
 
-		result, err := deps.handler.Update(IncomingLetterUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), IncomingLetterUpdateRequest{
 			ID:                  documentID.String(),
 			DocumentTypeID:      models.DocumentTypeLetter,
 			Content:             "Updated content",
@@ -439,12 +439,12 @@ func TestIncomingLetterCommandHandler_CommandInterface(t *testing.T) {
 		allowDocumentActions(models.DocumentKindIncomingLetter, "create", "read", "update"),
 	)
 
-	registered, err := deps.handler.RegisterDocument(struct{}{})
+	registered, err := deps.handler.RegisterDocument(principalTestContext(deps.user), struct{}{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid register request")
 	assert.Nil(t, registered)
 
-	updated, err := deps.handler.UpdateDocument(struct{}{})
+	updated, err := deps.handler.UpdateDocument(principalTestContext(deps.user), struct{}{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid update request")
 	assert.Nil(t, updated)
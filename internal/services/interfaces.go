@@ -24,21 +24,82 @@ type UserStore interface {
 	UpdatePassword(userID uuid.UUID, newPasswordHash string) error
 	ResetPassword(userID uuid.UUID, newPassword string) error
 	UpdateProfile(userID uuid.UUID, req models.UpdateProfileRequest) error
-	IncrementFailedLoginAttempts(userID uuid.UUID) (int, bool, error)
+	IncrementFailedLoginAttempts(userID uuid.UUID, threshold int) (int, bool, error)
+	ReleaseExpiredLockout(userID uuid.UUID, lockoutDuration time.Duration) (bool, error)
 	ResetFailedLoginAttempts(userID uuid.UUID) error
+	GetPasswordHistory(userID uuid.UUID, limit int) ([]string, error)
 	CountUsers() (int, error)
 }
 
+// PasswordStatusStore — состояние паролей пользователей для оценки политики паролей.
+type PasswordStatusStore interface {
+	GetPasswordStatuses() ([]models.UserPasswordStatus, error)
+}
+
 // InitialSetupStore выполняет атомарную первичную настройку после применения миграций.
 type InitialSetupStore interface {
 	CreateInitialAdmin(passwordHash string) error
 }
 
+// Authenticator — внешний способ входа, подключаемый к AuthService.Login.
+// AuthService направляет к нему логины, неизвестные локально, и пользователей
+// с auth_source, равным AuthSource().
+type Authenticator interface {
+	AuthSource() string
+	Authenticate(ctx context.Context, login, password string) (*models.User, error)
+}
+
+// UserDirectory — внешний каталог пользователей (LDAP / AD).
+type UserDirectory interface {
+	Authenticate(ctx context.Context, login, password string) (*models.DirectoryUser, error)
+	ListUsers(ctx context.Context) ([]models.DirectoryUser, error)
+}
+
+// DirectoryUserStore — учетные записи пользователей, ведущиеся во внешнем каталоге.
+type DirectoryUserStore interface {
+	SyncDirectoryUserWithOutbox(sync models.DirectoryUserSync, createEffects []models.OutboxEvent) (*models.User, bool, error)
+	GetActiveDirectoryUsers(authSource string) ([]models.User, error)
+	DeactivateDirectoryUserWithOutbox(userID uuid.UUID, effects []models.OutboxEvent) error
+}
+
+// UserSessionStore — интерфейс журнала сеансов desktop-клиента.
+type UserSessionStore interface {
+	Start(userID uuid.UUID, hostName string, startedAt time.Time) (uuid.UUID, error)
+	Touch(id uuid.UUID, lastActivityAt time.Time) (bool, string, error)
+	End(id uuid.UUID, reason string, endedAt time.Time) error
+	TerminateWithOutbox(id, terminatedBy uuid.UUID, effects []models.OutboxEvent) error
+	ExpireStartedBefore(startedBefore, endedAt time.Time) (int64, error)
+	GetByID(id uuid.UUID) (*models.UserSession, error)
+	GetList(filter models.UserSessionFilter) (*models.PagedResult[models.UserSession], error)
+}
+
+// TwoFactorStore — интерфейс хранилища второго фактора (TOTP и кодов восстановления).
+type TwoFactorStore interface {
+	GetTOTP(userID uuid.UUID) (*models.UserTOTP, error)
+	SavePendingTOTP(userID uuid.UUID, secret string) error
+	EnableTOTPWithOutbox(userID uuid.UUID, step int64, codeHashes []string, effects []models.OutboxEvent) error
+	ConsumeTOTPStep(userID uuid.UUID, step int64) (bool, error)
+	ConsumeRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+	CountRecoveryCodes(userID uuid.UUID) (int, error)
+	ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error
+	DeleteWithOutbox(userID uuid.UUID, effects []models.OutboxEvent) (bool, error)
+}
+
+// TwoFactorCoverageStore сообщает, кто из владельцев системных прав еще не
+// подключил второй фактор.
+type TwoFactorCoverageStore interface {
+	GetLoginsWithoutTOTP(permissions []string) ([]string, error)
+}
+
 // UserSubstitutionStore — интерфейс для работы с замещениями пользователей.
 type UserSubstitutionStore interface {
+	GetByID(id uuid.UUID) (*models.UserSubstitution, error)
 	GetByPrincipalID(principalUserID uuid.UUID) (*models.UserSubstitution, error)
-	GetActivePrincipalIDs(substituteUserID uuid.UUID) ([]uuid.UUID, error)
-	IsActiveSubstitute(substituteUserID, principalUserID uuid.UUID) (bool, error)
+	GetHistoryByPrincipalID(principalUserID uuid.UUID) ([]models.UserSubstitution, error)
+	GetActiveByPrincipalID(principalUserID uuid.UUID) ([]models.UserSubstitution, error)
+	GetActiveGrants(substituteUserID uuid.UUID) ([]models.SubstitutionGrant, error)
+	Create(substitution models.UserSubstitution) (*models.UserSubstitution, error)
+	Revoke(id uuid.UUID) error
 	ReplaceForPrincipal(
 		principalUserID uuid.UUID,
 		substituteUserID *uuid.UUID,
@@ -67,6 +128,13 @@ type CitizenAppealDocStore interface {
 	GetCount() (int, error)
 }
 
+// CitizenAppealDeadlineStore — интерфейс для работы со сроками ответа на обращения граждан.
+type CitizenAppealDeadlineStore interface {
+	GetDeadlineExtensions(documentID uuid.UUID) ([]models.CitizenAppealDeadlineExtension, error)
+	ExtendDeadlineWithOutbox(req models.ExtendCitizenAppealDeadlineRequest, effects []models.OutboxEvent) (*models.CitizenAppealDeadlineExtension, error)
+	GetDeadlineCounters(scope models.DocumentAccessScope, dueSoonDays int) (*models.CitizenAppealDeadlineCounters, error)
+}
+
 // AdministrativeOrderDocStore — интерфейс для работы с приказами в хранилище.
 type AdministrativeOrderDocStore interface {
 	GetList(filter models.DocumentFilter) (*models.PagedResult[models.AdministrativeOrderDocument], error)
@@ -80,6 +148,23 @@ type AdministrativeOrderDocStore interface {
 	GetCount() (int, error)
 }
 
+// CustomDocumentKindStore — интерфейс для работы с пользовательскими видами документов в хранилище.
+type CustomDocumentKindStore interface {
+	GetAll() ([]models.CustomDocumentKind, error)
+	GetByCode(code models.DocumentKind) (*models.CustomDocumentKind, error)
+	Create(kind models.CustomDocumentKind) (*models.CustomDocumentKind, error)
+	Update(kind models.CustomDocumentKind) (*models.CustomDocumentKind, error)
+	Delete(code models.DocumentKind) error
+}
+
+// CustomDocumentStore — интерфейс для работы с документами пользовательских видов в хранилище.
+type CustomDocumentStore interface {
+	GetList(kind models.DocumentKind, filter models.DocumentFilter) (*models.PagedResult[models.CustomDocument], error)
+	GetByID(id uuid.UUID) (*models.CustomDocument, error)
+	Create(req models.CreateCustomDocumentRequest) (*models.CustomDocument, error)
+	Update(req models.UpdateCustomDocumentRequest) (*models.CustomDocument, error)
+}
+
 // DocumentStore — интерфейс для общей корневой сущности документа.
 type DocumentStore interface {
 	GetByID(id uuid.UUID) (*models.Document, error)
@@ -108,6 +193,21 @@ type DocumentAccessStore interface {
 	ReplaceUserAccessProfile(userID string, systemPermissions []models.UserSystemPermissionRule, permissions []models.UserDocumentPermissionRule) error
 }
 
+// DocumentAccessExplainer — интерфейс для объяснения решений матрицы доступа.
+type DocumentAccessExplainer interface {
+	ExplainPermission(kindCode, action string, departmentID, userID string) ([]models.AccessRuleMatch, error)
+}
+
+// AccessRoleStore — интерфейс хранилища ролей доступа.
+type AccessRoleStore interface {
+	GetAll() ([]models.AccessRole, error)
+	GetByID(id uuid.UUID) (*models.AccessRole, error)
+	CreateWithOutbox(role models.AccessRole, effects []models.OutboxEvent) (*models.AccessRole, error)
+	UpdateWithOutbox(role models.AccessRole, effects []models.OutboxEvent) (*models.AccessRole, error)
+	DeleteWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error
+	ReplaceAssignmentsWithOutbox(roleID uuid.UUID, userIDs, departmentIDs []uuid.UUID, effects []models.OutboxEvent) error
+}
+
 // OutgoingDocStore — интерфейс для работы с исходящими документами в хранилище.
 type OutgoingDocStore interface {
 	GetList(filter models.OutgoingDocumentFilter) (*models.PagedResult[models.OutgoingDocument], error)
@@ -117,6 +217,17 @@ type OutgoingDocStore interface {
 	GetCount() (int, error)
 }
 
+// OutgoingApprovalStore — интерфейс для работы с черновиками исходящих писем на согласовании.
+type OutgoingApprovalStore interface {
+	Create(draft *models.OutgoingDraft) error
+	Update(draft *models.OutgoingDraft) error
+	Delete(id uuid.UUID, expectedRevision int) error
+	ApplyTransition(transition models.OutgoingDraftTransition, effects []models.OutboxEvent) error
+	GetByID(id uuid.UUID) (*models.OutgoingDraft, error)
+	GetByAuthor(authorID uuid.UUID) ([]models.OutgoingDraft, error)
+	GetInApprovalByApprovers(userIDs []uuid.UUID) ([]models.OutgoingDraft, error)
+}
+
 // NomenclatureStore — интерфейс для работы с номенклатурой дел в хранилище.
 type NomenclatureStore interface {
 	GetAll(year int, kindCode string) ([]models.Nomenclature, error)
@@ -131,8 +242,9 @@ type NomenclatureStore interface {
 // ReferenceStore — интерфейс для работы со справочниками (типы документов, организации, исполнители резолюции) в хранилище.
 type ReferenceStore interface {
 	GetAllDocumentTypes() ([]models.DocumentType, error)
-	CreateDocumentType(name string) (*models.DocumentType, error)
-	UpdateDocumentType(id uuid.UUID, name string) error
+	GetDocumentTypesByKind(kind models.DocumentKind) ([]models.DocumentType, error)
+	CreateDocumentType(name string, kinds []models.DocumentKind) (*models.DocumentType, error)
+	UpdateDocumentType(id uuid.UUID, name string, kinds []models.DocumentKind, isActive bool) (*models.DocumentType, error)
 	DeleteDocumentType(id uuid.UUID) error
 	GetAllOrganizations() ([]models.Organization, error)
 	FindOrCreateOrganization(name string) (*models.Organization, error)
@@ -169,6 +281,7 @@ type AssignmentStore interface {
 type DepartmentStore interface {
 	GetAll() ([]models.Department, error)
 	GetNomenclatureIDs(departmentID uuid.UUID) ([]string, error)
+	GetVisibleNomenclatureIDs(departmentID *uuid.UUID, userID uuid.UUID) ([]string, error)
 	Create(name string, nomenclatureIDs []string) (*models.Department, error)
 	Update(id uuid.UUID, name string, nomenclatureIDs []string) (*models.Department, error)
 	Delete(id uuid.UUID) error
@@ -181,6 +294,34 @@ type SettingsStore interface {
 	Update(key, value string) error
 }
 
+// ReminderStore — интерфейс для планировщика напоминаний.
+type ReminderStore interface {
+	GetAssignmentReminderCandidates(until time.Time) ([]models.AssignmentReminderCandidate, error)
+	GetPendingAcknowledgmentReminders() ([]models.AcknowledgmentReminderCandidate, error)
+	GetReminderMutes() (map[uuid.UUID]map[string]bool, error)
+	GetUserReminderMutes(userID uuid.UUID) (map[string]bool, error)
+	SetReminderMuted(userID uuid.UUID, kind string, muted bool) error
+	EnqueueReminders(effects []models.OutboxEvent) (int, error)
+}
+
+// EmailNotificationStore — интерфейс для настроек почтовых уведомлений.
+type EmailNotificationStore interface {
+	GetSettings(userID uuid.UUID) (*models.EmailNotificationSettings, error)
+	UpdateSettings(userID uuid.UUID, email, mode string) error
+}
+
+// WorkingCalendarStore — интерфейс для работы с производственным календарем.
+type WorkingCalendarStore interface {
+	GetDays(from, to time.Time) ([]models.WorkingCalendarDay, error)
+	SaveWithOutbox(day models.WorkingCalendarDay, effects []models.OutboxEvent) error
+	DeleteWithOutbox(day time.Time, effects []models.OutboxEvent) error
+}
+
+// DocumentSearchStore — интерфейс полнотекстового поиска по индексу документов.
+type DocumentSearchStore interface {
+	Search(query models.DocumentSearchQuery) ([]models.DocumentSearchHit, error)
+}
+
 // AttachmentStore — интерфейс для работы с вложениями (файлами) в хранилище.
 type AttachmentStore interface {
 	Create(a *models.Attachment) error
@@ -193,6 +334,11 @@ type AttachmentStore interface {
 	GetPendingDeletion() ([]models.Attachment, error)
 }
 
+// AttachmentTextStore — интерфейс для чтения текста, извлеченного из вложений.
+type AttachmentTextStore interface {
+	Get(attachmentID uuid.UUID) (*models.AttachmentText, error)
+}
+
 // FileStorage — интерфейс для работы с внешним файловым хранилищем (например, MinIO).
 type FileStorage interface {
 	// UploadFile загружает файл в хранилище.
@@ -251,9 +397,11 @@ type StatisticsStore interface {
 	GetUserOptions() ([]models.StatisticsOption, error)
 	GetAssignmentMonthlyOverview(yearStart, yearEnd time.Time) ([]models.AssignmentMonthlyPoint, error)
 	GetAssignmentMonthlyByExecutor(yearStart, yearEnd time.Time) ([]models.StatisticsSeriesPoint, error)
-	GetAssignmentOverdueRating(yearStart, yearEnd time.Time) ([]models.StatisticsReportRow, error)
+	GetAssignmentOverdueRating(yearStart, yearEnd time.Time, deadlineBasis string) ([]models.StatisticsReportRow, error)
+	GetAssignmentOverdueByLevel(yearStart, yearEnd time.Time) ([]models.StatisticsReportRow, error)
 	GetAssignmentStatusCounts() ([]models.StatisticsReportRow, error)
 	GetAssignmentReport(startDate, endDate time.Time, onlyOverdue bool, userID string) ([]models.StatisticsReportRow, error)
+	GetAssignmentSeriesStatistics(startDate, endDate time.Time) ([]models.AssignmentSeriesStatisticsRow, error)
 	GetSystemUserCount() (int, error)
 	GetSystemDocumentCount() (int, error)
 	GetDBSize() string
@@ -37,7 +37,11 @@ func (s *JournalService) GetByDocumentID(documentIDStr string) ([]dto.JournalEnt
 	}
 
 	if s.access != nil {
-		if err := s.access.RequireViewJournal(docID); err != nil {
+		principalCtx, err := s.auth.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.access.RequireViewJournal(principalCtx, docID); err != nil {
 			return nil, err
 		}
 	} else {
@@ -32,6 +32,13 @@ type linkOutboxStore interface {
 	CreateAndCancelOrderWithOutbox(ctx context.Context, link *models.DocumentLink, effects []models.OutboxEvent) error
 }
 
+// linkCitizenAppealReplyStore закрывает обращение гражданина исходящим ответом
+// в той же транзакции, что и изменение связи.
+type linkCitizenAppealReplyStore interface {
+	CreateAndCloseCitizenAppealWithOutbox(ctx context.Context, link *models.DocumentLink, appealID uuid.UUID, effects []models.OutboxEvent) error
+	DeleteAndRefreshCitizenAppealWithOutbox(ctx context.Context, id, appealID uuid.UUID, effects []models.OutboxEvent) error
+}
+
 var errLinkOutboxStoreRequired = fmt.Errorf("link store must support atomic outbox operations")
 
 // Optional bulk interfaces preserve compatibility with lightweight stores in
@@ -94,6 +101,11 @@ func (s *LinkService) LinkDocuments(sourceIDStr, targetIDStr, linkType string) (
 		ctx, release := serviceOperationContext(s.lifecycle)
 		defer release()
 
+		principalCtx, err := s.authService.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+
 		userID, err := s.authService.GetCurrentUserUUID()
 		if err != nil {
 			return nil, err
@@ -112,7 +124,7 @@ func (s *LinkService) LinkDocuments(sourceIDStr, targetIDStr, linkType string) (
 		if sourceID == targetID {
 			return nil, models.NewBadRequest("нельзя связать документ с самим собой")
 		}
-		sourceDoc, targetDoc, err := s.access.ResolveLink(sourceID, targetID)
+		sourceDoc, targetDoc, err := s.access.ResolveLink(principalCtx, sourceID, targetID)
 		if err != nil {
 			return nil, err
 		}
@@ -138,7 +150,13 @@ func (s *LinkService) LinkDocuments(sourceIDStr, targetIDStr, linkType string) (
 		if buildErr != nil {
 			return nil, buildErr
 		}
-		if linkType == "order_cancels" {
+		if appealID, ok := citizenAppealReplyTarget(link); ok {
+			replyStore, ok := s.repo.(linkCitizenAppealReplyStore)
+			if !ok {
+				return nil, errLinkOutboxStoreRequired
+			}
+			err = replyStore.CreateAndCloseCitizenAppealWithOutbox(ctx, link, appealID, effects)
+		} else if linkType == "order_cancels" {
 			err = repo.CreateAndCancelOrderWithOutbox(ctx, link, effects)
 		} else {
 			err = repo.CreateWithOutbox(ctx, link, effects)
@@ -157,6 +175,11 @@ func (s *LinkService) UnlinkDocument(idStr string) error {
 		ctx, release := serviceOperationContext(s.lifecycle)
 		defer release()
 
+		principalCtx, err := s.authService.sessionContext()
+		if err != nil {
+			return err
+		}
+
 		id, err := uuid.Parse(idStr)
 		if err != nil {
 			return models.NewBadRequestWrapped("неверный ID связи", err)
@@ -169,7 +192,7 @@ func (s *LinkService) UnlinkDocument(idStr string) error {
 		if link == nil {
 			return nil
 		}
-		if err := s.access.RequireLink(link.SourceID, link.TargetID); err != nil {
+		if err := s.access.RequireLink(principalCtx, link.SourceID, link.TargetID); err != nil {
 			return err
 		}
 
@@ -182,6 +205,13 @@ func (s *LinkService) UnlinkDocument(idStr string) error {
 		if buildErr != nil {
 			return buildErr
 		}
+		if appealID, ok := citizenAppealReplyTarget(link); ok {
+			replyStore, ok := s.repo.(linkCitizenAppealReplyStore)
+			if !ok {
+				return errLinkOutboxStoreRequired
+			}
+			return replyStore.DeleteAndRefreshCitizenAppealWithOutbox(ctx, id, appealID, effects)
+		}
 		return repo.DeleteWithOutbox(ctx, id, effects)
 	})
 }
@@ -192,11 +222,16 @@ func (s *LinkService) GetDocumentLinks(docIDStr string) ([]dto.DocumentLink, err
 		ctx, release := serviceOperationContext(s.lifecycle)
 		defer release()
 
+		principalCtx, err := s.authService.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+
 		docID, err := uuid.Parse(docIDStr)
 		if err != nil {
 			return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 		}
-		if err := s.access.RequireDocumentAction(docID, "link"); err != nil {
+		if err := s.access.RequireDocumentAction(principalCtx, docID, "link"); err != nil {
 			return nil, err
 		}
 		res, err := s.repo.GetByDocumentID(ctx, docID)
@@ -208,7 +243,7 @@ func (s *LinkService) GetDocumentLinks(docIDStr string) ([]dto.DocumentLink, err
 		for _, link := range res {
 			docIDs = append(docIDs, link.SourceID, link.TargetID)
 		}
-		readableDocs, err := s.access.ResolveReadableDocuments(docIDs)
+		readableDocs, err := s.access.ResolveReadableDocuments(principalCtx, docIDs)
 		if err != nil {
 			return nil, err
 		}
@@ -234,11 +269,16 @@ func (s *LinkService) GetDocumentFlow(rootIDStr string) (*models.GraphData, erro
 		ctx, release := serviceOperationContext(s.lifecycle)
 		defer release()
 
+		principalCtx, err := s.authService.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+
 		rootID, err := uuid.Parse(rootIDStr)
 		if err != nil {
 			return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 		}
-		if err := s.access.RequireDocumentAction(rootID, "link"); err != nil {
+		if err := s.access.RequireDocumentAction(principalCtx, rootID, "link"); err != nil {
 			return nil, err
 		}
 
@@ -260,7 +300,7 @@ func (s *LinkService) GetDocumentFlow(rootIDStr string) (*models.GraphData, erro
 			docIDs[l.TargetID] = string(l.TargetKind)
 		}
 
-		readableDocs, err := s.access.ResolveReadableDocuments(mapKeys(docIDs))
+		readableDocs, err := s.access.ResolveReadableDocuments(principalCtx, mapKeys(docIDs))
 		if err != nil {
 			return nil, err
 		}
@@ -492,6 +532,22 @@ func validateDocumentLinkType(sourceKind, targetKind models.DocumentKind, linkTy
 	}
 }
 
+// citizenAppealReplyTarget возвращает обращение, которое закрывает связь «ответ»
+// с исходящим письмом. Направление связи не имеет значения.
+func citizenAppealReplyTarget(link *models.DocumentLink) (uuid.UUID, bool) {
+	if link == nil || link.LinkType != "reply" {
+		return uuid.Nil, false
+	}
+	switch {
+	case link.SourceKind == models.DocumentKindCitizenAppeal && link.TargetKind == models.DocumentKindOutgoingLetter:
+		return link.SourceID, true
+	case link.SourceKind == models.DocumentKindOutgoingLetter && link.TargetKind == models.DocumentKindCitizenAppeal:
+		return link.TargetID, true
+	default:
+		return uuid.Nil, false
+	}
+}
+
 func mapKeys(values map[uuid.UUID]string) []uuid.UUID {
 	keys := make([]uuid.UUID, 0, len(values))
 	for key := range values {
@@ -664,3 +664,22 @@ func TestLinkService_GetDocumentFlow(t *testing.T) {
 		assert.Nil(t, result)
 	})
 }
+
+func TestCitizenAppealReplyTarget(t *testing.T) {
+	appealID, outgoingID := uuid.New(), uuid.New()
+
+	id, ok := citizenAppealReplyTarget(&models.DocumentLink{SourceID: outgoingID, SourceKind: models.DocumentKindOutgoingLetter, TargetID: appealID, TargetKind: models.DocumentKindCitizenAppeal, LinkType: "reply"})
+	require.True(t, ok)
+	assert.Equal(t, appealID, id)
+
+	id, ok = citizenAppealReplyTarget(&models.DocumentLink{SourceID: appealID, SourceKind: models.DocumentKindCitizenAppeal, TargetID: outgoingID, TargetKind: models.DocumentKindOutgoingLetter, LinkType: "reply"})
+	require.True(t, ok)
+	assert.Equal(t, appealID, id)
+
+	_, ok = citizenAppealReplyTarget(&models.DocumentLink{SourceKind: models.DocumentKindCitizenAppeal, TargetKind: models.DocumentKindOutgoingLetter, LinkType: "related"})
+	assert.False(t, ok)
+	_, ok = citizenAppealReplyTarget(&models.DocumentLink{SourceKind: models.DocumentKindIncomingLetter, TargetKind: models.DocumentKindOutgoingLetter, LinkType: "reply"})
+	assert.False(t, ok)
+	_, ok = citizenAppealReplyTarget(nil)
+	assert.False(t, ok)
+}
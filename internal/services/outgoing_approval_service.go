@@ -0,0 +1,807 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/observability"
+)
+
+const (
+	maxApprovalStages         = 20
+	maxApprovalStageApprovers = 20
+)
+
+// ApprovalStageRequest описывает этап маршрута согласования.
+type ApprovalStageRequest struct {
+	Mode        string   `json:"mode"`
+	ApproverIDs []string `json:"approverIds"`
+}
+
+// OutgoingDraftRequest описывает команду создания или изменения черновика исходящего письма.
+type OutgoingDraftRequest struct {
+	ID               string                 `json:"id,omitempty"`
+	NomenclatureID   string                 `json:"nomenclatureId"`
+	DocumentTypeID   string                 `json:"documentTypeId"`
+	RecipientOrgName string                 `json:"recipientOrgName"`
+	Addressee        string                 `json:"addressee"`
+	OutgoingDate     string                 `json:"outgoingDate"`
+	Content          string                 `json:"content"`
+	PagesCount       int                    `json:"pagesCount"`
+	SenderSignatory  string                 `json:"senderSignatory"`
+	SenderExecutor   string                 `json:"senderExecutor"`
+	Stages           []ApprovalStageRequest `json:"stages"`
+}
+
+// OutgoingApprovalService ведет черновики исходящих писем по маршруту согласования:
+// черновик → этапы согласования → автоматическая регистрация после решения последнего этапа.
+type OutgoingApprovalService struct {
+	repo          OutgoingApprovalStore
+	userRepo      UserStore
+	nomRepo       NomenclatureStore
+	auth          *AuthService
+	access        *DocumentAccessService
+	registry      *DocumentKindCommandRegistry
+	substitutions UserSubstitutionStore
+	metrics       *observability.Registry
+}
+
+// NewOutgoingApprovalService создает новый экземпляр OutgoingApprovalService.
+func NewOutgoingApprovalService(
+	repo OutgoingApprovalStore,
+	userRepo UserStore,
+	nomRepo NomenclatureStore,
+	auth *AuthService,
+	access *DocumentAccessService,
+	registry *DocumentKindCommandRegistry,
+) *OutgoingApprovalService {
+	return &OutgoingApprovalService{
+		repo:     repo,
+		userRepo: userRepo,
+		nomRepo:  nomRepo,
+		auth:     auth,
+		access:   access,
+		registry: registry,
+	}
+}
+
+// SetSubstitutionStore подключает источник активных замещений.
+func (s *OutgoingApprovalService) SetSubstitutionStore(store UserSubstitutionStore) {
+	s.substitutions = store
+}
+
+func (s *OutgoingApprovalService) SetOperationMetrics(metrics *observability.Registry) {
+	s.metrics = metrics
+}
+
+// CreateDraft создает черновик с маршрутом согласования. Черновик не регистрируется и не получает номер.
+func (s *OutgoingApprovalService) CreateDraft(req OutgoingDraftRequest) (*dto.OutgoingDraft, error) {
+	return measureOperation(s.metrics, "outgoing_approval.create_draft", func() (*dto.OutgoingDraft, error) {
+		ctx, err := s.auth.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.access.RequireCreate(ctx, models.DocumentKindOutgoingLetter); err != nil {
+			return nil, err
+		}
+		authorID, err := s.auth.GetCurrentUserUUID()
+		if err != nil {
+			return nil, err
+		}
+		draft, err := s.buildDraft(req, authorID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.Create(draft); err != nil {
+			return nil, err
+		}
+		return s.getDraftDTO(draft.ID)
+	})
+}
+
+// UpdateDraft изменяет реквизиты и маршрут черновика до отправки на согласование или после возврата.
+func (s *OutgoingApprovalService) UpdateDraft(req OutgoingDraftRequest) (*dto.OutgoingDraft, error) {
+	return measureOperation(s.metrics, "outgoing_approval.update_draft", func() (*dto.OutgoingDraft, error) {
+		existing, err := s.getAuthorDraft(req.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !existing.IsEditable() {
+			return nil, models.NewBadRequest("черновик нельзя изменить в текущем статусе")
+		}
+		draft, err := s.buildDraft(req, existing.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		draft.ID = existing.ID
+		draft.Revision = existing.Revision
+		if err := s.repo.Update(draft); err != nil {
+			return nil, err
+		}
+		return s.getDraftDTO(draft.ID)
+	})
+}
+
+// DeleteDraft удаляет черновик, который не находится на согласовании и не зарегистрирован.
+func (s *OutgoingApprovalService) DeleteDraft(id string) error {
+	return measureOperationError(s.metrics, "outgoing_approval.delete_draft", func() error {
+		draft, err := s.getAuthorDraft(id)
+		if err != nil {
+			return err
+		}
+		if !draft.IsEditable() && draft.Status != models.OutgoingDraftStatusRejected {
+			return models.NewBadRequest("черновик нельзя удалить в текущем статусе")
+		}
+		return s.repo.Delete(draft.ID, draft.Revision)
+	})
+}
+
+// Submit отправляет черновик на согласование. Повторная отправка после возврата начинает новый круг с первого этапа.
+func (s *OutgoingApprovalService) Submit(id string) (*dto.OutgoingDraft, error) {
+	return measureOperation(s.metrics, "outgoing_approval.submit", func() (*dto.OutgoingDraft, error) {
+		ctx, err := s.auth.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+		draft, err := s.getAuthorDraft(id)
+		if err != nil {
+			return nil, err
+		}
+		if !draft.IsEditable() {
+			return nil, models.NewBadRequest("черновик уже отправлен на согласование")
+		}
+		if err := s.access.RequireCreate(ctx, models.DocumentKindOutgoingLetter); err != nil {
+			return nil, err
+		}
+
+		draft.Status = models.OutgoingDraftStatusInApproval
+		draft.Round++
+		draft.ResetDecisions()
+		effects, err := s.approvalRequestEffects(ctx, draft, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.ApplyTransition(models.OutgoingDraftTransition{
+			DraftID:          draft.ID,
+			ExpectedRevision: draft.Revision,
+			Status:           draft.Status,
+			Round:            draft.Round,
+			ResetDecisions:   true,
+		}, effects); err != nil {
+			return nil, err
+		}
+		return s.getDraftDTO(draft.ID)
+	})
+}
+
+// Decide фиксирует решение текущего согласующего или его замещающего.
+// Отклонение завершает маршрут, возврат передает черновик автору на доработку;
+// согласование последнего этапа запускает регистрацию письма.
+func (s *OutgoingApprovalService) Decide(id, decision, comment string) (*dto.OutgoingDraft, error) {
+	return measureOperation(s.metrics, "outgoing_approval.decide", func() (*dto.OutgoingDraft, error) {
+		if !models.IsApprovalDecision(decision) {
+			return nil, models.NewBadRequest("неверное решение по согласованию")
+		}
+		comment = strings.TrimSpace(comment)
+		if decision != models.ApprovalDecisionApprove && comment == "" {
+			return nil, models.NewBadRequest("укажите комментарий к решению")
+		}
+		ctx, err := s.auth.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+		currentUserID, err := s.auth.GetCurrentUserUUID()
+		if err != nil {
+			return nil, err
+		}
+		draft, err := s.getDraft(id)
+		if err != nil {
+			return nil, err
+		}
+		if draft.Status != models.OutgoingDraftStatusInApproval {
+			return nil, models.NewBadRequest("черновик не находится на согласовании")
+		}
+		principalID, err := s.resolveActingApprover(draft, currentUserID)
+		if err != nil {
+			return nil, err
+		}
+
+		previousPending := draft.PendingApproverIDs()
+		stagePosition, ok := draft.RecordDecision(principalID, currentUserID, decision, time.Now())
+		if !ok {
+			return nil, models.NewConflict("черновик был изменен, обновите карточку")
+		}
+		transition := models.OutgoingDraftTransition{
+			DraftID:          draft.ID,
+			ExpectedRevision: draft.Revision,
+			Round:            draft.Round,
+			Decision: &models.ApprovalDecision{
+				DraftID:       draft.ID,
+				Round:         draft.Round,
+				StagePosition: stagePosition,
+				ApproverID:    principalID,
+				DecidedBy:     currentUserID,
+				Decision:      decision,
+				Comment:       comment,
+			},
+		}
+
+		var effects []models.OutboxEvent
+		switch {
+		case decision == models.ApprovalDecisionReject:
+			transition.Status = models.OutgoingDraftStatusRejected
+			effects, err = s.authorEffects(ctx, draft, models.UserEventApprovalRejected, "Черновик отклонен", fmt.Sprintf("Черновик «%s» отклонен: %s", draftSubject(draft), comment))
+		case decision == models.ApprovalDecisionReturn:
+			transition.Status = models.OutgoingDraftStatusReturned
+			effects, err = s.authorEffects(ctx, draft, models.UserEventApprovalReturned, "Черновик возвращен на доработку", fmt.Sprintf("Черновик «%s» возвращен: %s", draftSubject(draft), comment))
+		case draft.RouteApproved():
+			transition.Status = models.OutgoingDraftStatusApproved
+			effects, err = s.authorEffects(ctx, draft, models.UserEventApprovalApproved, "Черновик согласован", fmt.Sprintf("Черновик «%s» согласован всеми участниками", draftSubject(draft)))
+		default:
+			transition.Status = models.OutgoingDraftStatusInApproval
+			draft.Status = transition.Status
+			effects, err = s.approvalRequestEffects(ctx, draft, previousPending)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.ApplyTransition(transition, effects); err != nil {
+			return nil, err
+		}
+
+		if transition.Status == models.OutgoingDraftStatusApproved {
+			// Решение уже сохранено. Если регистрация не удалась, черновик остается
+			// согласованным и регистрируется повторно через RegisterApproved.
+			if _, err := s.registerApproved(ctx, draft.ID); err != nil {
+				slog.Warn("failed to register approved outgoing draft", "draft_id", draft.ID, "error", err)
+			}
+		}
+		return s.getDraftDTO(draft.ID)
+	})
+}
+
+// RegisterApproved повторяет регистрацию согласованного черновика, если автоматическая регистрация не удалась.
+func (s *OutgoingApprovalService) RegisterApproved(id string) (*dto.OutgoingDraft, error) {
+	return measureOperation(s.metrics, "outgoing_approval.register", func() (*dto.OutgoingDraft, error) {
+		ctx, err := s.auth.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+		currentUserID, err := s.auth.GetCurrentUserUUID()
+		if err != nil {
+			return nil, err
+		}
+		draft, err := s.getDraft(id)
+		if err != nil {
+			return nil, err
+		}
+		if draft.AuthorID != currentUserID {
+			if err := s.access.RequireCreate(ctx, models.DocumentKindOutgoingLetter); err != nil {
+				return nil, err
+			}
+		}
+		if draft.Status != models.OutgoingDraftStatusApproved {
+			return nil, models.NewBadRequest("зарегистрировать можно только согласованный черновик")
+		}
+		if _, err := s.registerApproved(ctx, draft.ID); err != nil {
+			return nil, err
+		}
+		return s.getDraftDTO(draft.ID)
+	})
+}
+
+// GetDraft возвращает черновик автору, участникам маршрута и их замещающим.
+func (s *OutgoingApprovalService) GetDraft(id string) (*dto.OutgoingDraft, error) {
+	return measureOperation(s.metrics, "outgoing_approval.get_draft", func() (*dto.OutgoingDraft, error) {
+		draft, err := s.getDraft(id)
+		if err != nil {
+			return nil, err
+		}
+		currentUserID, subjectIDs, err := s.currentUserAndSubstitutionSubjectIDs()
+		if err != nil {
+			return nil, err
+		}
+		if !canViewOutgoingDraft(draft, currentUserID, subjectIDs) {
+			return nil, models.ErrForbidden
+		}
+		return mapOutgoingDraft(draft, currentUserID, subjectIDs), nil
+	})
+}
+
+// GetMyDrafts возвращает черновики текущего пользователя.
+func (s *OutgoingApprovalService) GetMyDrafts() ([]dto.OutgoingDraft, error) {
+	return measureOperation(s.metrics, "outgoing_approval.get_my_drafts", func() ([]dto.OutgoingDraft, error) {
+		currentUserID, subjectIDs, err := s.currentUserAndSubstitutionSubjectIDs()
+		if err != nil {
+			return nil, err
+		}
+		drafts, err := s.repo.GetByAuthor(currentUserID)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]dto.OutgoingDraft, 0, len(drafts))
+		for i := range drafts {
+			result = append(result, *mapOutgoingDraft(&drafts[i], currentUserID, subjectIDs))
+		}
+		return result, nil
+	})
+}
+
+// GetPendingApprovals возвращает черновики, ожидающие решения текущего пользователя
+// или пользователей, которых он замещает.
+func (s *OutgoingApprovalService) GetPendingApprovals() ([]dto.OutgoingDraft, error) {
+	return measureOperation(s.metrics, "outgoing_approval.get_pending", func() ([]dto.OutgoingDraft, error) {
+		currentUserID, subjectIDs, err := s.currentUserAndSubstitutionSubjectIDs()
+		if err != nil {
+			return nil, err
+		}
+		drafts, err := s.repo.GetInApprovalByApprovers(subjectIDs)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]dto.OutgoingDraft, 0, len(drafts))
+		for i := range drafts {
+			if len(intersectUserIDs(drafts[i].PendingApproverIDs(), subjectIDs)) == 0 {
+				continue
+			}
+			result = append(result, *mapOutgoingDraft(&drafts[i], currentUserID, subjectIDs))
+		}
+		return result, nil
+	})
+}
+
+// registerApproved регистрирует письмо через общий реестр обработчиков и переводит черновик в статус registered.
+// Повторный вызов безопасен: ключ идемпотентности черновика возвращает уже созданный документ.
+func (s *OutgoingApprovalService) registerApproved(ctx context.Context, draftID uuid.UUID) (*models.OutgoingDraft, error) {
+	draft, err := s.repo.GetByID(draftID)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return nil, models.NewNotFound("черновик не найден")
+	}
+	handler, err := s.registry.Get(models.DocumentKindOutgoingLetter)
+	if err != nil {
+		return nil, err
+	}
+	result, err := handler.RegisterDocument(ctx, OutgoingLetterRegisterRequest{
+		NomenclatureID:   draft.NomenclatureID.String(),
+		IdempotencyKey:   draft.RegistrationKey.String(),
+		DocumentTypeID:   draft.DocumentTypeID,
+		RecipientOrgName: draft.RecipientOrgName,
+		Addressee:        draft.Addressee,
+		OutgoingDate:     draft.OutgoingDate.Format("2006-01-02"),
+		Content:          draft.Content,
+		PagesCount:       draft.PagesCount,
+		SenderSignatory:  draft.SenderSignatory,
+		SenderExecutor:   draft.SenderExecutor,
+		approvedDraft:    draft,
+	})
+	if err != nil {
+		return nil, err
+	}
+	document, ok := result.(*dto.OutgoingDocument)
+	if !ok || document == nil {
+		return nil, fmt.Errorf("unexpected outgoing registration result %T", result)
+	}
+	documentID, err := uuid.Parse(document.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registered document ID: %w", err)
+	}
+
+	effects, err := approvalJournalEffects(draft, documentID)
+	if err != nil {
+		return nil, err
+	}
+	request := models.CreateUserEventRequest{
+		RecipientUserID: draft.AuthorID,
+		ActorUserID:     eventActorID(ctx),
+		DocumentID:      documentID,
+		DocumentKind:    string(models.DocumentKindOutgoingLetter),
+		DocumentNumber:  document.OutgoingNumber,
+		EntityType:      models.UserEventEntityOutgoingDraft,
+		EntityID:        draft.ID,
+		EventType:       models.UserEventApprovalRegistered,
+		Title:           "Исходящее письмо зарегистрировано",
+		Message:         fmt.Sprintf("Согласованный черновик «%s» зарегистрирован под номером %s", draftSubject(draft), documentNumberLabel(document.OutgoingNumber)),
+		Metadata:        userEventMetadata(map[string]string{"draftId": draft.ID.String()}),
+	}
+	event, err := NewUserEventOutboxEvent(outgoingDraftOutboxKey(draft.ID, "registered", "user_event"), request)
+	if err != nil {
+		return nil, err
+	}
+	effects = append(effects, event)
+
+	if err := s.repo.ApplyTransition(models.OutgoingDraftTransition{
+		DraftID:          draft.ID,
+		ExpectedRevision: draft.Revision,
+		Status:           models.OutgoingDraftStatusRegistered,
+		Round:            draft.Round,
+		DocumentID:       &documentID,
+	}, effects); err != nil {
+		return nil, err
+	}
+	draft.Status = models.OutgoingDraftStatusRegistered
+	draft.DocumentID = &documentID
+	return draft, nil
+}
+
+// approvalJournalEffects переносит историю решений всех кругов в журнал зарегистрированного документа.
+func approvalJournalEffects(draft *models.OutgoingDraft, documentID uuid.UUID) ([]models.OutboxEvent, error) {
+	effects := make([]models.OutboxEvent, 0, len(draft.Decisions))
+	for _, decision := range draft.Decisions {
+		participant := decision.ApproverName
+		var onBehalfOf *uuid.UUID
+		if decision.DecidedBy != decision.ApproverID {
+			participant = fmt.Sprintf("%s за %s", decision.DecidedByName, decision.ApproverName)
+			approverID := decision.ApproverID
+			onBehalfOf = &approverID
+		}
+		details := fmt.Sprintf("Согласование (круг %d, этап %d, %s): %s — %s",
+			decision.Round, decision.StagePosition, decision.CreatedAt.Format("02.01.2006 15:04"), participant, approvalDecisionLabel(decision.Decision))
+		if decision.Comment != "" {
+			details += ". Комментарий: " + decision.Comment
+		}
+		event, err := NewJournalOutboxEvent(outgoingDraftOutboxKey(draft.ID, "decision:"+decision.ID.String(), "journal"), models.CreateJournalEntryRequest{
+			DocumentID:       documentID,
+			UserID:           decision.DecidedBy,
+			OnBehalfOfUserID: onBehalfOf,
+			Action:           "APPROVAL_DECISION",
+			Details:          details,
+		})
+		if err != nil {
+			return nil, err
+		}
+		effects = append(effects, event)
+	}
+	return effects, nil
+}
+
+func approvalDecisionLabel(decision string) string {
+	switch decision {
+	case models.ApprovalDecisionApprove:
+		return "согласовано"
+	case models.ApprovalDecisionReject:
+		return "отклонено"
+	case models.ApprovalDecisionReturn:
+		return "возвращено на доработку"
+	default:
+		return decision
+	}
+}
+
+// approvalRequestEffects уведомляет участников, от которых решение ожидается впервые, и их активных замещающих.
+func (s *OutgoingApprovalService) approvalRequestEffects(ctx context.Context, draft *models.OutgoingDraft, previousPending []uuid.UUID) ([]models.OutboxEvent, error) {
+	notified := make(map[uuid.UUID]struct{}, len(previousPending))
+	for _, id := range previousPending {
+		notified[id] = struct{}{}
+	}
+	stage := draft.CurrentStage()
+	effects := make([]models.OutboxEvent, 0)
+	for _, approverID := range draft.PendingApproverIDs() {
+		if _, ok := notified[approverID]; ok {
+			continue
+		}
+		recipients := []uuid.UUID{approverID}
+		substituteIDs, err := s.activeSubstitutes(approverID)
+		if err != nil {
+			return nil, err
+		}
+		for _, substituteID := range substituteIDs {
+			recipients = appendUniqueUserID(recipients, substituteID)
+		}
+		for _, recipientID := range recipients {
+			message := fmt.Sprintf("Требуется решение по черновику исходящего письма «%s»", draftSubject(draft))
+			if recipientID != approverID {
+				message += " (замещение)"
+			}
+			request := models.CreateUserEventRequest{
+				RecipientUserID: recipientID,
+				ActorUserID:     eventActorID(ctx),
+				DocumentKind:    string(models.DocumentKindOutgoingLetter),
+				EntityType:      models.UserEventEntityOutgoingDraft,
+				EntityID:        draft.ID,
+				EventType:       models.UserEventApprovalRequested,
+				Title:           "Согласование исходящего письма",
+				Message:         message,
+				Metadata: userEventMetadata(map[string]string{
+					"round":      strconv.Itoa(draft.Round),
+					"stage":      strconv.Itoa(stage.Position),
+					"approverId": approverID.String(),
+				}),
+			}
+			key := outgoingDraftOutboxKey(draft.ID, fmt.Sprintf("round:%d:approver:%s:recipient:%s", draft.Round, approverID, recipientID), "user_event")
+			event, err := NewUserEventOutboxEvent(key, request)
+			if err != nil {
+				return nil, err
+			}
+			effects = append(effects, event)
+		}
+	}
+	return effects, nil
+}
+
+func (s *OutgoingApprovalService) authorEffects(ctx context.Context, draft *models.OutgoingDraft, eventType, title, message string) ([]models.OutboxEvent, error) {
+	request := models.CreateUserEventRequest{
+		RecipientUserID: draft.AuthorID,
+		ActorUserID:     eventActorID(ctx),
+		DocumentKind:    string(models.DocumentKindOutgoingLetter),
+		EntityType:      models.UserEventEntityOutgoingDraft,
+		EntityID:        draft.ID,
+		EventType:       eventType,
+		Title:           title,
+		Message:         message,
+		Metadata:        userEventMetadata(map[string]string{"round": strconv.Itoa(draft.Round)}),
+	}
+	event, err := NewUserEventOutboxEvent(outgoingDraftOutboxKey(draft.ID, fmt.Sprintf("round:%d:%s", draft.Round, eventType), "user_event"), request)
+	if err != nil {
+		return nil, err
+	}
+	return []models.OutboxEvent{event}, nil
+}
+
+func outgoingDraftOutboxKey(draftID uuid.UUID, action, effect string) string {
+	return "outgoing_draft:" + draftID.String() + ":" + action + ":" + effect
+}
+
+// resolveActingApprover определяет, за кого действует текущий пользователь:
+// за себя, если он ожидаемый участник, или за участника, которого замещает
+// с правом согласования исходящих писем.
+func (s *OutgoingApprovalService) resolveActingApprover(draft *models.OutgoingDraft, currentUserID uuid.UUID) (uuid.UUID, error) {
+	pending := draft.PendingApproverIDs()
+	for _, approverID := range pending {
+		if approverID == currentUserID {
+			return approverID, nil
+		}
+	}
+	principalIDs, err := s.approvalPrincipalIDs(currentUserID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, approverID := range pending {
+		if slices.Contains(principalIDs, approverID) {
+			return approverID, nil
+		}
+	}
+	return uuid.Nil, models.NewForbidden("решение по черновику ожидается от другого участника")
+}
+
+// approvalPrincipalIDs возвращает пользователей, за которых substituteID
+// вправе согласовывать исходящие письма.
+func (s *OutgoingApprovalService) approvalPrincipalIDs(substituteID uuid.UUID) ([]uuid.UUID, error) {
+	if s.substitutions == nil {
+		return nil, nil
+	}
+	grants, err := s.substitutions.GetActiveGrants(substituteID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(grants))
+	for _, grant := range grants {
+		if grant.Covers(models.SubstitutionScopeApprovals, models.DocumentKindOutgoingLetter) {
+			ids = appendUniqueUserID(ids, grant.PrincipalUserID)
+		}
+	}
+	return ids, nil
+}
+
+// activeSubstitutes возвращает действующих замещающих principalID с правом согласования.
+func (s *OutgoingApprovalService) activeSubstitutes(principalID uuid.UUID) ([]uuid.UUID, error) {
+	if s.substitutions == nil {
+		return nil, nil
+	}
+	substitutions, err := s.substitutions.GetActiveByPrincipalID(principalID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(substitutions))
+	for i := range substitutions {
+		if substitutions[i].Grant().Covers(models.SubstitutionScopeApprovals, models.DocumentKindOutgoingLetter) {
+			ids = appendUniqueUserID(ids, substitutions[i].SubstituteUserID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *OutgoingApprovalService) currentUserAndSubstitutionSubjectIDs() (uuid.UUID, []uuid.UUID, error) {
+	currentUserID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	principalIDs, err := s.approvalPrincipalIDs(currentUserID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	ids := []uuid.UUID{currentUserID}
+	for _, principalID := range principalIDs {
+		ids = appendUniqueUserID(ids, principalID)
+	}
+	return currentUserID, ids, nil
+}
+
+func (s *OutgoingApprovalService) getDraft(idStr string) (*models.OutgoingDraft, error) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID черновика", err)
+	}
+	draft, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return nil, models.NewNotFound("черновик не найден")
+	}
+	return draft, nil
+}
+
+func (s *OutgoingApprovalService) getAuthorDraft(idStr string) (*models.OutgoingDraft, error) {
+	currentUserID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return nil, err
+	}
+	draft, err := s.getDraft(idStr)
+	if err != nil {
+		return nil, err
+	}
+	if draft.AuthorID != currentUserID {
+		return nil, models.NewForbidden("черновик может изменять только его автор")
+	}
+	return draft, nil
+}
+
+func (s *OutgoingApprovalService) getDraftDTO(id uuid.UUID) (*dto.OutgoingDraft, error) {
+	draft, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return nil, models.NewNotFound("черновик не найден")
+	}
+	currentUserID, subjectIDs, err := s.currentUserAndSubstitutionSubjectIDs()
+	if err != nil {
+		return nil, err
+	}
+	return mapOutgoingDraft(draft, currentUserID, subjectIDs), nil
+}
+
+// buildDraft проверяет реквизиты письма и маршрут. Номенклатура должна нумеровать письма
+// автоматически: после согласования номер присваивается без участия автора.
+func (s *OutgoingApprovalService) buildDraft(req OutgoingDraftRequest, authorID uuid.UUID) (*models.OutgoingDraft, error) {
+	nomID, err := uuid.Parse(req.NomenclatureID)
+	if err != nil {
+		return nil, models.NewBadRequest("неверный ID номенклатуры")
+	}
+	nomenclature, err := s.nomRepo.GetByID(nomID)
+	if err != nil {
+		return nil, err
+	}
+	if nomenclature == nil || nomenclature.KindCode != string(models.DocumentKindOutgoingLetter) || !nomenclature.IsActive {
+		return nil, models.NewBadRequest("выберите действующую номенклатуру исходящих писем")
+	}
+	if nomenclature.NumberingMode == NumberingModeManualOnly {
+		return nil, models.NewBadRequest("номенклатура с ручной нумерацией не подходит для регистрации после согласования")
+	}
+	docTypeID := models.NormalizeDocumentType(req.DocumentTypeID)
+	if docTypeID == "" {
+		return nil, models.NewBadRequest("неверный тип документа")
+	}
+	outgoingDate, err := parseCommandDate(req.OutgoingDate, "даты исходящего документа")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.RecipientOrgName) == "" || strings.TrimSpace(req.Content) == "" {
+		return nil, models.NewBadRequest("укажите получателя и содержание письма")
+	}
+	if req.PagesCount < 1 {
+		req.PagesCount = 1
+	}
+	stages, err := s.buildApprovalRoute(req.Stages, authorID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutgoingDraft{
+		AuthorID:         authorID,
+		NomenclatureID:   nomID,
+		DocumentTypeID:   docTypeID,
+		RecipientOrgName: strings.TrimSpace(req.RecipientOrgName),
+		Addressee:        strings.TrimSpace(req.Addressee),
+		OutgoingDate:     outgoingDate,
+		Content:          strings.TrimSpace(req.Content),
+		PagesCount:       req.PagesCount,
+		SenderSignatory:  strings.TrimSpace(req.SenderSignatory),
+		SenderExecutor:   strings.TrimSpace(req.SenderExecutor),
+		Stages:           stages,
+	}, nil
+}
+
+func (s *OutgoingApprovalService) buildApprovalRoute(req []ApprovalStageRequest, authorID uuid.UUID) ([]models.ApprovalStage, error) {
+	if len(req) == 0 {
+		return nil, models.NewBadRequest("добавьте хотя бы один этап согласования")
+	}
+	if len(req) > maxApprovalStages {
+		return nil, models.NewBadRequest(fmt.Sprintf("маршрут не может содержать более %d этапов", maxApprovalStages))
+	}
+	stages := make([]models.ApprovalStage, 0, len(req))
+	for i, stageReq := range req {
+		if !models.IsApprovalStageMode(stageReq.Mode) {
+			return nil, models.NewBadRequest(fmt.Sprintf("неверный режим этапа %d", i+1))
+		}
+		if len(stageReq.ApproverIDs) == 0 || len(stageReq.ApproverIDs) > maxApprovalStageApprovers {
+			return nil, models.NewBadRequest(fmt.Sprintf("этап %d должен содержать от 1 до %d участников", i+1, maxApprovalStageApprovers))
+		}
+		stage := models.ApprovalStage{Position: i + 1, Mode: stageReq.Mode, Approvers: make([]models.ApprovalApprover, 0, len(stageReq.ApproverIDs))}
+		seen := make(map[uuid.UUID]struct{}, len(stageReq.ApproverIDs))
+		for _, rawID := range stageReq.ApproverIDs {
+			approverID, err := uuid.Parse(rawID)
+			if err != nil {
+				return nil, models.NewBadRequestWrapped("неверный ID согласующего", err)
+			}
+			if approverID == authorID {
+				return nil, models.NewBadRequest("автор не может согласовывать собственный черновик")
+			}
+			if _, ok := seen[approverID]; ok {
+				return nil, models.NewBadRequest(fmt.Sprintf("участник указан на этапе %d повторно", i+1))
+			}
+			seen[approverID] = struct{}{}
+			user, err := s.userRepo.GetByID(approverID)
+			if err != nil {
+				return nil, err
+			}
+			if user == nil || !user.IsActive {
+				return nil, models.NewBadRequest("согласующий не найден или заблокирован")
+			}
+			stage.Approvers = append(stage.Approvers, models.ApprovalApprover{UserID: approverID, UserName: user.FullName, Position: len(stage.Approvers) + 1})
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+func canViewOutgoingDraft(draft *models.OutgoingDraft, currentUserID uuid.UUID, subjectIDs []uuid.UUID) bool {
+	if draft.AuthorID == currentUserID {
+		return true
+	}
+	for _, subjectID := range subjectIDs {
+		if draft.HasApprover(subjectID) {
+			return true
+		}
+	}
+	return false
+}
+
+func intersectUserIDs(left, right []uuid.UUID) []uuid.UUID {
+	result := make([]uuid.UUID, 0)
+	for _, l := range left {
+		for _, r := range right {
+			if l == r {
+				result = append(result, l)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func draftSubject(draft *models.OutgoingDraft) string {
+	runes := []rune(draft.Content)
+	if len(runes) > 80 {
+		return string(runes[:80]) + "…"
+	}
+	return draft.Content
+}
+
+func mapOutgoingDraft(draft *models.OutgoingDraft, currentUserID uuid.UUID, subjectIDs []uuid.UUID) *dto.OutgoingDraft {
+	result := dto.MapOutgoingDraft(draft)
+	result.CanEdit = draft.AuthorID == currentUserID && draft.IsEditable()
+	result.CanDecide = len(intersectUserIDs(draft.PendingApproverIDs(), subjectIDs)) > 0
+	return result
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// memoryOutgoingApprovalStore повторяет семантику репозитория: проверку ревизии и атомарное применение перехода.
+type memoryOutgoingApprovalStore struct {
+	drafts  map[uuid.UUID]*models.OutgoingDraft
+	effects []models.OutboxEvent
+	now     time.Time
+}
+
+func newMemoryOutgoingApprovalStore() *memoryOutgoingApprovalStore {
+	return &memoryOutgoingApprovalStore{drafts: map[uuid.UUID]*models.OutgoingDraft{}, now: time.Date(2026, 6, 3, 10, 0, 0, 0, time.UTC)}
+}
+
+func (s *memoryOutgoingApprovalStore) Create(draft *models.OutgoingDraft) error {
+	draft.ID = uuid.New()
+	draft.Status = models.OutgoingDraftStatusDraft
+	draft.Revision = 1
+	draft.RegistrationKey = uuid.New()
+	s.drafts[draft.ID] = cloneOutgoingDraft(draft)
+	return nil
+}
+
+func (s *memoryOutgoingApprovalStore) Update(draft *models.OutgoingDraft) error {
+	stored := s.drafts[draft.ID]
+	if stored == nil || stored.Revision != draft.Revision || !stored.IsEditable() {
+		return errOutgoingDraftConflict
+	}
+	next := cloneOutgoingDraft(draft)
+	next.Status, next.Round, next.RegistrationKey, next.Decisions = stored.Status, stored.Round, stored.RegistrationKey, stored.Decisions
+	next.Revision++
+	s.drafts[draft.ID] = next
+	return nil
+}
+
+func (s *memoryOutgoingApprovalStore) Delete(id uuid.UUID, expectedRevision int) error {
+	if stored := s.drafts[id]; stored == nil || stored.Revision != expectedRevision {
+		return errOutgoingDraftConflict
+	}
+	delete(s.drafts, id)
+	return nil
+}
+
+func (s *memoryOutgoingApprovalStore) ApplyTransition(transition models.OutgoingDraftTransition, effects []models.OutboxEvent) error {
+	stored := s.drafts[transition.DraftID]
+	if stored == nil || stored.Revision != transition.ExpectedRevision {
+		return errOutgoingDraftConflict
+	}
+	stored.Status, stored.Round = transition.Status, transition.Round
+	if transition.DocumentID != nil {
+		stored.DocumentID = transition.DocumentID
+	}
+	stored.Revision++
+	if transition.ResetDecisions {
+		stored.ResetDecisions()
+	}
+	if decision := transition.Decision; decision != nil {
+		s.now = s.now.Add(time.Minute)
+		for i := range stored.Stages {
+			for j := range stored.Stages[i].Approvers {
+				approver := &stored.Stages[i].Approvers[j]
+				if stored.Stages[i].Position == decision.StagePosition && approver.UserID == decision.ApproverID {
+					decidedBy, at := decision.DecidedBy, s.now
+					approver.Decision, approver.DecidedBy, approver.DecidedAt = decision.Decision, &decidedBy, &at
+				}
+			}
+		}
+		decision.ID = uuid.New()
+		decision.CreatedAt = s.now
+		recorded := *decision
+		recorded.ApproverName = "user-" + recorded.ApproverID.String()[:4]
+		recorded.DecidedByName = "user-" + recorded.DecidedBy.String()[:4]
+		stored.Decisions = append(stored.Decisions, recorded)
+	}
+	s.effects = append(s.effects, effects...)
+	return nil
+}
+
+func (s *memoryOutgoingApprovalStore) GetByID(id uuid.UUID) (*models.OutgoingDraft, error) {
+	if stored := s.drafts[id]; stored != nil {
+		return cloneOutgoingDraft(stored), nil
+	}
+	return nil, nil
+}
+
+func (s *memoryOutgoingApprovalStore) GetByAuthor(authorID uuid.UUID) ([]models.OutgoingDraft, error) {
+	result := make([]models.OutgoingDraft, 0)
+	for _, draft := range s.drafts {
+		if draft.AuthorID == authorID {
+			result = append(result, *cloneOutgoingDraft(draft))
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryOutgoingApprovalStore) GetInApprovalByApprovers(userIDs []uuid.UUID) ([]models.OutgoingDraft, error) {
+	result := make([]models.OutgoingDraft, 0)
+	for _, draft := range s.drafts {
+		if draft.Status != models.OutgoingDraftStatusInApproval {
+			continue
+		}
+		for _, userID := range userIDs {
+			if draft.HasApprover(userID) {
+				result = append(result, *cloneOutgoingDraft(draft))
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryOutgoingApprovalStore) takeEffects() []models.OutboxEvent {
+	effects := s.effects
+	s.effects = nil
+	return effects
+}
+
+var errOutgoingDraftConflict = models.NewConflict("черновик был изменен, обновите карточку")
+
+func cloneOutgoingDraft(draft *models.OutgoingDraft) *models.OutgoingDraft {
+	clone := *draft
+	clone.Stages = make([]models.ApprovalStage, len(draft.Stages))
+	for i, stage := range draft.Stages {
+		clone.Stages[i] = stage
+		clone.Stages[i].Approvers = append([]models.ApprovalApprover(nil), stage.Approvers...)
+	}
+	clone.Decisions = append([]models.ApprovalDecision(nil), draft.Decisions...)
+	return &clone
+}
+
+type outgoingApprovalTestDeps struct {
+	svc           *OutgoingApprovalService
+	store         *memoryOutgoingApprovalStore
+	auth          *AuthService
+	author        *models.User
+	users         map[string]*models.User
+	substitutions *userSubstitutionStoreStub
+	registered    []OutgoingLetterRegisterRequest
+	registerErr   error
+	nomenclature  *models.Nomenclature
+}
+
+func setupOutgoingApprovalService(t *testing.T) *outgoingApprovalTestDeps {
+	t.Helper()
+
+	userRepo := mocks.NewUserStore(t)
+	auth := NewAuthService(nil, userRepo)
+	deps := &outgoingApprovalTestDeps{
+		store:         newMemoryOutgoingApprovalStore(),
+		auth:          auth,
+		users:         map[string]*models.User{},
+		substitutions: &userSubstitutionStoreStub{},
+		nomenclature: &models.Nomenclature{
+			ID:            uuid.New(),
+			KindCode:      string(models.DocumentKindOutgoingLetter),
+			NumberingMode: NumberingModeIndexAndNumber,
+			IsActive:      true,
+		},
+	}
+	for _, name := range []string{"author", "first", "second", "third", "deputy", "blocked"} {
+		user := documentAccessUser(false, nil)
+		user.FullName = name
+		user.IsActive = name != "blocked"
+		deps.users[name] = user
+		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+	}
+	deps.author = deps.users["author"]
+	auth.currentUserID = deps.author.ID
+
+	nomRepo := mocks.NewNomenclatureStore(t)
+	nomRepo.On("GetByID", deps.nomenclature.ID).Return(deps.nomenclature, nil).Maybe()
+	access := NewDocumentAccessService(
+		auth,
+		&documentAccessDepartmentStore{},
+		&documentAccessAssignmentStore{accessible: map[uuid.UUID]struct{}{}},
+		&documentAccessAcknowledgmentStore{accessible: map[uuid.UUID]struct{}{}},
+		&kindActionDocumentAccessStore{allowed: allowDocumentActions(models.DocumentKindOutgoingLetter, "create")},
+		nil,
+		nil,
+		nil,
+	)
+	registry := NewDocumentKindCommandRegistry(stubDocumentKindCommandHandler{
+		kind: models.DocumentKindOutgoingLetter,
+		registerFunc: func(req any) (any, error) {
+			typedReq := req.(OutgoingLetterRegisterRequest)
+			deps.registered = append(deps.registered, typedReq)
+			if deps.registerErr != nil {
+				return nil, deps.registerErr
+			}
+			return &dto.OutgoingDocument{ID: uuid.NewSHA1(uuid.Nil, []byte(typedReq.IdempotencyKey)).String(), OutgoingNumber: "01-05/7"}, nil
+		},
+	})
+	deps.svc = NewOutgoingApprovalService(deps.store, userRepo, nomRepo, auth, access, registry)
+	deps.svc.SetSubstitutionStore(deps.substitutions)
+	return deps
+}
+
+func (d *outgoingApprovalTestDeps) as(name string) {
+	d.auth.currentUserID = d.users[name].ID
+}
+
+func (d *outgoingApprovalTestDeps) draftRequest(stages ...ApprovalStageRequest) OutgoingDraftRequest {
+	return OutgoingDraftRequest{
+		NomenclatureID:   d.nomenclature.ID.String(),
+		DocumentTypeID:   models.DocumentTypeLetter,
+		RecipientOrgName: "ООО Получатель",
+		OutgoingDate:     "2026-06-03",
+		Content:          "О согласовании графика",
+		PagesCount:       1,
+		Stages:           stages,
+	}
+}
+
+func (d *outgoingApprovalTestDeps) stage(mode string, names ...string) ApprovalStageRequest {
+	stage := ApprovalStageRequest{Mode: mode}
+	for _, name := range names {
+		stage.ApproverIDs = append(stage.ApproverIDs, d.users[name].ID.String())
+	}
+	return stage
+}
+
+func userEventRecipients(t *testing.T, effects []models.OutboxEvent, eventType string) []uuid.UUID {
+	t.Helper()
+	recipients := make([]uuid.UUID, 0)
+	for _, effect := range effects {
+		if effect.EventType != models.OutboxEventUserEvent {
+			continue
+		}
+		var payload struct {
+			Request models.CreateUserEventRequest `json:"request"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(effect.Payload), &payload))
+		if payload.Request.EventType == eventType {
+			recipients = append(recipients, payload.Request.RecipientUserID)
+		}
+	}
+	return recipients
+}
+
+func TestOutgoingApprovalService_SequentialThenParallelRouteRegistersLetter(t *testing.T) {
+	deps := setupOutgoingApprovalService(t)
+	draft, err := deps.svc.CreateDraft(deps.draftRequest(
+		deps.stage(models.ApprovalStageSequential, "first", "second"),
+		deps.stage(models.ApprovalStageParallel, "third", "deputy"),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, models.OutgoingDraftStatusDraft, draft.Status)
+	assert.True(t, draft.CanEdit)
+
+	draft, err = deps.svc.Submit(draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutgoingDraftStatusInApproval, draft.Status)
+	assert.Equal(t, 1, draft.Round)
+	assert.Equal(t, []uuid.UUID{deps.users["first"].ID}, userEventRecipients(t, deps.store.takeEffects(), models.UserEventApprovalRequested))
+
+	deps.as("second")
+	_, err = deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	requireAppError(t, err, "FORBIDDEN", 403, "решение по черновику ожидается от другого участника")
+
+	deps.as("first")
+	_, err = deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{deps.users["second"].ID}, userEventRecipients(t, deps.store.takeEffects(), models.UserEventApprovalRequested))
+
+	deps.as("second")
+	_, err = deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{deps.users["third"].ID, deps.users["deputy"].ID}, userEventRecipients(t, deps.store.takeEffects(), models.UserEventApprovalRequested))
+
+	deps.as("deputy")
+	draft, err = deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	require.NoError(t, err)
+	assert.Empty(t, userEventRecipients(t, deps.store.takeEffects(), models.UserEventApprovalRequested), "third was already notified")
+	assert.Equal(t, models.OutgoingDraftStatusInApproval, draft.Status)
+	require.Empty(t, deps.registered)
+
+	deps.as("third")
+	draft, err = deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.OutgoingDraftStatusRegistered, draft.Status)
+	assert.NotEmpty(t, draft.DocumentID)
+	assert.Len(t, draft.Decisions, 4)
+
+	require.Len(t, deps.registered, 1)
+	registered := deps.registered[0]
+	require.NotNil(t, registered.approvedDraft)
+	assert.Equal(t, deps.author.ID, registered.approvedDraft.AuthorID)
+	assert.Equal(t, deps.store.drafts[uuid.MustParse(draft.ID)].RegistrationKey.String(), registered.IdempotencyKey)
+	assert.Equal(t, "2026-06-03", registered.OutgoingDate)
+
+	effects := deps.store.takeEffects()
+	assert.Equal(t, []uuid.UUID{deps.author.ID}, userEventRecipients(t, effects, models.UserEventApprovalApproved))
+	assert.Equal(t, []uuid.UUID{deps.author.ID}, userEventRecipients(t, effects, models.UserEventApprovalRegistered))
+	journal := 0
+	for _, effect := range effects {
+		if effect.EventType == models.OutboxEventJournal {
+			journal++
+			assert.Contains(t, effect.Payload, draft.DocumentID)
+			assert.Contains(t, effect.Payload, "APPROVAL_DECISION")
+		}
+	}
+	assert.Equal(t, 4, journal)
+}
+
+func TestOutgoingApprovalService_ReturnAndResubmitStartsNewRound(t *testing.T) {
+	deps := setupOutgoingApprovalService(t)
+	draft, err := deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageSequential, "first", "second")))
+	require.NoError(t, err)
+	_, err = deps.svc.Submit(draft.ID)
+	require.NoError(t, err)
+
+	deps.as("first")
+	_, err = deps.svc.Decide(draft.ID, models.ApprovalDecisionReturn, " ")
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "укажите комментарий к решению")
+	_, err = deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	require.NoError(t, err)
+	deps.as("second")
+	returned, err := deps.svc.Decide(draft.ID, models.ApprovalDecisionReturn, "Уточнить сроки")
+	require.NoError(t, err)
+	assert.Equal(t, models.OutgoingDraftStatusReturned, returned.Status)
+	assert.False(t, returned.CanEdit)
+	deps.store.takeEffects()
+
+	deps.as("author")
+	req := deps.draftRequest(deps.stage(models.ApprovalStageSequential, "first", "second"))
+	req.ID = draft.ID
+	req.Content = "О согласовании графика (ред. 2)"
+	updated, err := deps.svc.UpdateDraft(req)
+	require.NoError(t, err)
+	assert.Equal(t, req.Content, updated.Content)
+
+	resubmitted, err := deps.svc.Submit(draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resubmitted.Round)
+	for _, approver := range resubmitted.Stages[0].Approvers {
+		assert.Empty(t, approver.Decision)
+	}
+	assert.Len(t, resubmitted.Decisions, 2, "history of the first round is kept")
+	assert.Equal(t, []uuid.UUID{deps.users["first"].ID}, userEventRecipients(t, deps.store.takeEffects(), models.UserEventApprovalRequested))
+}
+
+func TestOutgoingApprovalService_RejectIsFinal(t *testing.T) {
+	deps := setupOutgoingApprovalService(t)
+	draft, err := deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageParallel, "first", "second")))
+	require.NoError(t, err)
+	_, err = deps.svc.Submit(draft.ID)
+	require.NoError(t, err)
+	deps.store.takeEffects()
+
+	deps.as("second")
+	rejected, err := deps.svc.Decide(draft.ID, models.ApprovalDecisionReject, "Не требуется")
+	require.NoError(t, err)
+	assert.Equal(t, models.OutgoingDraftStatusRejected, rejected.Status)
+	assert.Equal(t, []uuid.UUID{deps.author.ID}, userEventRecipients(t, deps.store.takeEffects(), models.UserEventApprovalRejected))
+
+	deps.as("first")
+	_, err = deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "черновик не находится на согласовании")
+
+	deps.as("author")
+	_, err = deps.svc.Submit(draft.ID)
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "черновик уже отправлен на согласование")
+	require.NoError(t, deps.svc.DeleteDraft(draft.ID))
+	assert.Empty(t, deps.store.drafts)
+}
+
+func TestOutgoingApprovalService_SubstituteDecidesForPrincipal(t *testing.T) {
+	deps := setupOutgoingApprovalService(t)
+	first, deputy := deps.users["first"].ID, deps.users["deputy"].ID
+	deps.substitutions.byPrincipal = map[uuid.UUID]*models.UserSubstitution{first: {PrincipalUserID: first, SubstituteUserID: deputy}}
+
+	draft, err := deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageSequential, "first")))
+	require.NoError(t, err)
+	_, err = deps.svc.Submit(draft.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{first, deputy}, userEventRecipients(t, deps.store.takeEffects(), models.UserEventApprovalRequested))
+
+	deps.as("deputy")
+	deps.substitutions.activePrincipals = []uuid.UUID{first}
+	pending, err := deps.svc.GetPendingApprovals()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.True(t, pending[0].CanDecide)
+
+	decided, err := deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.OutgoingDraftStatusRegistered, decided.Status)
+	require.Len(t, decided.Decisions, 1)
+	assert.Equal(t, first.String(), decided.Decisions[0].ApproverID)
+	assert.Equal(t, deputy.String(), decided.Decisions[0].DecidedBy)
+	for _, effect := range deps.store.takeEffects() {
+		if effect.EventType == models.OutboxEventJournal {
+			assert.Contains(t, effect.Payload, " за ")
+		}
+	}
+}
+
+func TestOutgoingApprovalService_FailedRegistrationCanBeRetried(t *testing.T) {
+	deps := setupOutgoingApprovalService(t)
+	draft, err := deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageSequential, "first")))
+	require.NoError(t, err)
+	_, err = deps.svc.Submit(draft.ID)
+	require.NoError(t, err)
+
+	deps.registerErr = errors.New("numbering unavailable")
+	deps.as("first")
+	approved, err := deps.svc.Decide(draft.ID, models.ApprovalDecisionApprove, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.OutgoingDraftStatusApproved, approved.Status)
+	assert.Empty(t, approved.DocumentID)
+
+	deps.registerErr = nil
+	deps.as("author")
+	registered, err := deps.svc.RegisterApproved(draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutgoingDraftStatusRegistered, registered.Status)
+	require.Len(t, deps.registered, 2)
+	assert.Equal(t, deps.registered[0].IdempotencyKey, deps.registered[1].IdempotencyKey)
+}
+
+func TestOutgoingApprovalService_ValidatesRouteAndNomenclature(t *testing.T) {
+	deps := setupOutgoingApprovalService(t)
+
+	_, err := deps.svc.CreateDraft(deps.draftRequest())
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "добавьте хотя бы один этап согласования")
+
+	_, err = deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageParallel, "first", "first")))
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "участник указан на этапе 1 повторно")
+
+	_, err = deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageParallel, "author")))
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "автор не может согласовывать собственный черновик")
+
+	_, err = deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageParallel, "blocked")))
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "согласующий не найден или заблокирован")
+
+	_, err = deps.svc.CreateDraft(deps.draftRequest(deps.stage("random", "first")))
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный режим этапа 1")
+
+	deps.nomenclature.NumberingMode = NumberingModeManualOnly
+	_, err = deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageParallel, "first")))
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "номенклатура с ручной нумерацией не подходит для регистрации после согласования")
+}
+
+func TestOutgoingApprovalService_OnlyParticipantsSeeDraft(t *testing.T) {
+	deps := setupOutgoingApprovalService(t)
+	draft, err := deps.svc.CreateDraft(deps.draftRequest(deps.stage(models.ApprovalStageSequential, "first")))
+	require.NoError(t, err)
+
+	deps.as("first")
+	_, err = deps.svc.GetDraft(draft.ID)
+	require.NoError(t, err)
+	_, err = deps.svc.UpdateDraft(OutgoingDraftRequest{ID: draft.ID})
+	requireAppError(t, err, "FORBIDDEN", 403, "черновик может изменять только его автор")
+
+	deps.as("third")
+	_, err = deps.svc.GetDraft(draft.ID)
+	assert.ErrorIs(t, err, models.ErrForbidden)
+}
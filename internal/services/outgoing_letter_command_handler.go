@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -25,6 +26,9 @@ type OutgoingLetterRegisterRequest struct {
 	SenderExecutor      string                      `json:"senderExecutor"`
 	RegistrationNumber  string                      `json:"registrationNumber"`
 	AdminNumberOverride *AdminNumberOverrideRequest `json:"adminNumberOverride"`
+
+	// approvedDraft задается только маршрутом согласования и не принимается с клиента.
+	approvedDraft *models.OutgoingDraft
 }
 
 // OutgoingLetterUpdateRequest описывает команду обновления исходящего письма.
@@ -45,7 +49,6 @@ type OutgoingLetterCommandHandler struct {
 	repo    OutgoingDocStore
 	refRepo ReferenceStore
 	nomRepo NomenclatureStore
-	auth    *AuthService
 	journal *JournalService
 	access  *DocumentAccessService
 }
@@ -66,7 +69,6 @@ func NewOutgoingLetterCommandHandler(
 	repo OutgoingDocStore,
 	refRepo ReferenceStore,
 	nomRepo NomenclatureStore,
-	auth *AuthService,
 	journal *JournalService,
 	access *DocumentAccessService,
 ) *OutgoingLetterCommandHandler {
@@ -74,24 +76,34 @@ func NewOutgoingLetterCommandHandler(
 		repo:    repo,
 		refRepo: refRepo,
 		nomRepo: nomRepo,
-		auth:    auth,
 		journal: journal,
 		access:  access,
 	}
 }
 
 // Register регистрирует исходящее письмо.
-func (h *OutgoingLetterCommandHandler) Register(req OutgoingLetterRegisterRequest) (*dto.OutgoingDocument, error) {
+func (h *OutgoingLetterCommandHandler) Register(ctx context.Context, req OutgoingLetterRegisterRequest) (*dto.OutgoingDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	adminOverride, err := buildAdminNumberOverride(req.AdminNumberOverride)
 	if err != nil {
 		return nil, err
 	}
-	if adminOverride != nil {
-		if err := h.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
-			return nil, err
+	switch {
+	case req.approvedDraft != nil:
+		// Право на создание проверено у автора при отправке черновика на согласование;
+		// регистрацию завершает последний согласующий.
+		if req.approvedDraft.Status != models.OutgoingDraftStatusApproved || adminOverride != nil {
+			return nil, models.ErrForbidden
+		}
+	case adminOverride != nil:
+		if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+			return nil, models.ErrForbidden
 		}
-	} else {
-		if err := h.access.RequireCreate(models.DocumentKindOutgoingLetter); err != nil {
+	default:
+		if err := h.access.RequireCreate(ctx, models.DocumentKindOutgoingLetter); err != nil {
 			return nil, err
 		}
 	}
@@ -105,7 +117,7 @@ func (h *OutgoingLetterCommandHandler) Register(req OutgoingLetterRegisterReques
 		return nil, models.NewBadRequest("неверный ключ идемпотентности")
 	}
 	docTypeID := models.NormalizeDocumentType(req.DocumentTypeID)
-	if !models.IsAllowedDocumentType(docTypeID) {
+	if docTypeID == "" {
 		return nil, models.NewBadRequest("неверный тип документа")
 	}
 
@@ -120,9 +132,9 @@ func (h *OutgoingLetterCommandHandler) Register(req OutgoingLetterRegisterReques
 		return nil, models.NewBadRequest("неверный формат даты исходящего документа")
 	}
 
-	createdBy, err := h.auth.GetCurrentUserUUID()
-	if err != nil {
-		return nil, err
+	createdBy := principal.UserID
+	if req.approvedDraft != nil {
+		createdBy = req.approvedDraft.AuthorID
 	}
 
 	createReq := models.CreateOutgoingDocRequest{
@@ -150,20 +162,24 @@ func (h *OutgoingLetterCommandHandler) Register(req OutgoingLetterRegisterReques
 }
 
 // RegisterDocument реализует общий command-интерфейс по виду документа.
-func (h *OutgoingLetterCommandHandler) RegisterDocument(req any) (any, error) {
+func (h *OutgoingLetterCommandHandler) RegisterDocument(ctx context.Context, req any) (any, error) {
 	typedReq, ok := req.(OutgoingLetterRegisterRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid register request for kind %s", h.Kind())
 	}
 
-	return h.Register(typedReq)
+	return h.Register(ctx, typedReq)
 }
 
 // CreateAdminDraft создает черновик исходящего письма с административно заданным номером.
-func (h *OutgoingLetterCommandHandler) CreateAdminDraft(req AdminDraftCreateRequest) (any, error) {
-	if err := h.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+func (h *OutgoingLetterCommandHandler) CreateAdminDraft(ctx context.Context, req AdminDraftCreateRequest) (any, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
 		return nil, err
 	}
+	if !principal.HasSystemPermission(models.SystemPermissionAdmin) {
+		return nil, models.ErrForbidden
+	}
 	nomID, err := uuid.Parse(req.NomenclatureID)
 	if err != nil {
 		return nil, models.NewBadRequest("неверный ID номенклатуры")
@@ -179,10 +195,7 @@ func (h *OutgoingLetterCommandHandler) CreateAdminDraft(req AdminDraftCreateRequ
 	if adminOverride == nil {
 		return nil, models.NewBadRequest("укажите административный номер")
 	}
-	createdBy, err := h.auth.GetCurrentUserUUID()
-	if err != nil {
-		return nil, ErrNotAuthenticated
-	}
+	createdBy := principal.UserID
 	recipientOrg, err := h.refRepo.FindOrCreateOrganization(adminDraftPlaceholder)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка организации получателя: %w", err)
@@ -212,16 +225,20 @@ func (h *OutgoingLetterCommandHandler) CreateAdminDraft(req AdminDraftCreateRequ
 }
 
 // Update обновляет исходящее письмо.
-func (h *OutgoingLetterCommandHandler) Update(req OutgoingLetterUpdateRequest) (*dto.OutgoingDocument, error) {
+func (h *OutgoingLetterCommandHandler) Update(ctx context.Context, req OutgoingLetterUpdateRequest) (*dto.OutgoingDocument, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	uid, err := uuid.Parse(req.ID)
 	if err != nil {
 		return nil, models.NewBadRequestWrapped("неверный ID документа", err)
 	}
-	if err := h.access.RequireDocumentAction(uid, "update"); err != nil {
+	if err := h.access.RequireDocumentAction(ctx, uid, "update"); err != nil {
 		return nil, err
 	}
 	docTypeID := models.NormalizeDocumentType(req.DocumentTypeID)
-	if !models.IsAllowedDocumentType(docTypeID) {
+	if docTypeID == "" {
 		return nil, models.NewBadRequest("неверный тип документа")
 	}
 
@@ -251,8 +268,7 @@ func (h *OutgoingLetterCommandHandler) Update(req OutgoingLetterUpdateRequest) (
 	if !ok {
 		return nil, fmt.Errorf("outgoing document store must support atomic outbox operations")
 	}
-	currentUserID, _ := h.auth.GetCurrentUserUUID()
-	event, buildErr := NewJournalOutboxEvent("outgoing:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: currentUserID, Action: "UPDATE", Details: "Документ отредактирован"})
+	event, buildErr := NewJournalOutboxEvent("outgoing:"+uid.String()+":update:"+uuid.NewString(), models.CreateJournalEntryRequest{DocumentID: uid, UserID: principal.UserID, Action: "UPDATE", Details: "Документ отредактирован"})
 	if buildErr != nil {
 		return nil, buildErr
 	}
@@ -261,11 +277,11 @@ func (h *OutgoingLetterCommandHandler) Update(req OutgoingLetterUpdateRequest) (
 }
 
 // UpdateDocument реализует общий command-интерфейс по виду документа.
-func (h *OutgoingLetterCommandHandler) UpdateDocument(req any) (any, error) {
+func (h *OutgoingLetterCommandHandler) UpdateDocument(ctx context.Context, req any) (any, error) {
 	typedReq, ok := req.(OutgoingLetterUpdateRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid update request for kind %s", h.Kind())
 	}
 
-	return h.Update(typedReq)
+	return h.Update(ctx, typedReq)
 }
@@ -47,7 +47,7 @@ func setupOutgoingLetterCommandHandler(t *testing.T, allowed map[models.Document
 		nil,
 	)
 	journal := NewJournalService(journalRepo, auth, access)
-	handler := NewOutgoingLetterCommandHandler(repo, refRepo, nomRepo, auth, journal, access)
+	handler := NewOutgoingLetterCommandHandler(repo, refRepo, nomRepo, journal, access)
 
 	return &outgoingLetterHandlerDeps{
 		handler:     handler,
@@ -113,7 +113,7 @@ func TestOutgoingLetterCommandHandler_Register(t *testing.T) {
 			PagesCount:       req.PagesCount,
 			CreatedBy:        deps.user.ID,
 		}, nil).Once()
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -126,7 +126,7 @@ func TestOutgoingLetterCommandHandler_Register(t *testing.T) {
 		deps := setupOutgoingLetterCommandHandler(t, nil)
 		req := validOutgoingLetterRegisterRequest(uuid.New(), uuid.New())
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, models.ErrForbidden)
 		assert.Nil(t, result)
@@ -140,7 +140,7 @@ func TestOutgoingLetterCommandHandler_Register(t *testing.T) {
 		req := validOutgoingLetterRegisterRequest(uuid.New(), uuid.New())
 		req.IdempotencyKey = uuid.Nil.String()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ключ идемпотентности")
@@ -155,22 +155,22 @@ func TestOutgoingLetterCommandHandler_Register(t *testing.T) {
 		req := validOutgoingLetterRegisterRequest(uuid.New(), uuid.New())
 		req.NomenclatureID = "bad-id"
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ID номенклатуры")
 		assert.Nil(t, result)
 	})
 
-	t.Run("rejects invalid document type", func(t *testing.T) {
+	t.Run("rejects missing document type", func(t *testing.T) {
 		deps := setupOutgoingLetterCommandHandler(
 			t,
 			allowDocumentActions(models.DocumentKindOutgoingLetter, "create"),
 		)
 		req := validOutgoingLetterRegisterRequest(uuid.New(), uuid.New())
-		req.DocumentTypeID = "unknown"
+		req.DocumentTypeID = " "
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный тип документа")
@@ -186,7 +186,7 @@ func TestOutgoingLetterCommandHandler_Register(t *testing.T) {
 		req.OutgoingDate = "03.06.2026"
 		deps.refRepo.On("FindOrCreateOrganization", "ООО Получатель").Return(&models.Organization{ID: uuid.New(), Name: "ООО Получатель"}, nil).Once()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный формат даты исходящего документа")
@@ -202,7 +202,7 @@ func TestOutgoingLetterCommandHandler_Register(t *testing.T) {
 		req := validOutgoingLetterRegisterRequest(uuid.New(), uuid.New())
 		deps.refRepo.On("FindOrCreateOrganization", "ООО Получатель").Return((*models.Organization)(nil), expectedErr).Once()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.Contains(t, err.Error(), "ошибка организации получателя")
@@ -220,7 +220,7 @@ func TestOutgoingLetterCommandHandler_Register(t *testing.T) {
 		deps.refRepo.On("FindOrCreateOrganization", "ООО Получатель").Return(&models.Organization{ID: recipientOrgID, Name: "ООО Получатель"}, nil).Once()
 		deps.repo.On("Create", mock.Anything).Return(nil, expectedErr).Once()
 
-		result, err := deps.handler.Register(req)
+		result, err := deps.handler.Register(principalTestContext(deps.user), req)
 
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, result)
@@ -275,7 +275,7 @@ func TestOutgoingLetterCommandHandler_Update(t *testing.T) {
 			PagesCount:       req.PagesCount,
 			CreatedBy:        deps.user.ID,
 		}, nil).Once()
-		result, err := deps.handler.Update(req)
+		result, err := deps.handler.Update(principalTestContext(deps.user), req)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -290,7 +290,7 @@ func TestOutgoingLetterCommandHandler_Update(t *testing.T) {
 			allowDocumentActions(models.DocumentKindOutgoingLetter, "read", "update"),
 		)
 
-		result, err := deps.handler.Update(OutgoingLetterUpdateRequest{ID: "bad-id"})
+		result, err := deps.handler.Update(principalTestContext(deps.user), OutgoingLetterUpdateRequest{ID: "bad-id"})
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "неверный ID документа")
@@ -309,7 +309,7 @@ func TestOutgoingLetterCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(OutgoingLetterUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), OutgoingLetterUpdateRequest{
 			ID:               documentID.String(),
 			DocumentTypeID:   models.DocumentTypeLetter,
 			RecipientOrgName: "АО Новый получатель",
@@ -320,7 +320,7 @@ func TestOutgoingLetterCommandHandler_Update(t *testing.T) {
 		assert.Nil(t, result)
 	})
 
-	t.Run("rejects invalid document type", func(t *testing.T) {
+	t.Run("rejects missing document type", func(t *testing.T) {
 		documentID := uuid.New()
 		deps := setupOutgoingLetterCommandHandler(
 			t,
@@ -332,9 +332,9 @@ func TestOutgoingLetterCommandHandler_Update(t *testing.T) {
 			},
 		}
 
-		result, err := deps.handler.Update(OutgoingLetterUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), OutgoingLetterUpdateRequest{
 			ID:             documentID.String(),
-			DocumentTypeID: "unknown",
+			DocumentTypeID: " ",
 		})
 
 		require.Error(t, err)
@@ -356,7 +356,7 @@ func TestOutgoingLetterCommandHandler_Update(t *testing.T) {
 		}
 		deps.refRepo.On("FindOrCreateOrganization", "АО Новый получатель").Return((*models.Organization)(nil), expectedErr).Once()
 
-		result, err := deps.handler.Update(OutgoingLetterUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), OutgoingLetterUpdateRequest{
 			ID:               documentID.String(),
 			DocumentTypeID:   models.DocumentTypeLetter,
 			RecipientOrgName: "АО Новый получатель",
@@ -380,7 +380,7 @@ func TestOutgoingLetterCommandHandler_Update(t *testing.T) {
 		}
 		deps.refRepo.On("FindOrCreateOrganization", "АО Новый получатель").Return(&models.Organization{ID: uuid.New(), Name: "АО Новый получатель"}, nil).Once()
 
-		result, err := deps.handler.Update(OutgoingLetterUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), OutgoingLetterUpdateRequest{
 			ID:               documentID.String(),
 			DocumentTypeID:   models.DocumentTypeLetter,
 			RecipientOrgName: "АО Новый получатель",
@@ -407,7 +407,7 @@ func TestOutgoingLetterCommandHandler_Update(t *testing.T) {
 		deps.refRepo.On("FindOrCreateOrganization", "АО Новый получатель").Return(&models.Organization{ID: uuid.New(), Name: "АО Новый получатель"}, nil).Once()
 		deps.repo.On("Update", mock.Anything).Return(nil, expectedErr).Once()
 
-		result, err := deps.handler.Update(OutgoingLetterUpdateRequest{
+		result, err := deps.handler.Update(principalTestContext(deps.user), OutgoingLetterUpdateRequest{
 			ID:               documentID.String(),
 			DocumentTypeID:   models.DocumentTypeLetter,
 			RecipientOrgName: "АО Новый получатель",
@@ -426,12 +426,12 @@ func TestOutgoingLetterCommandHandler_CommandInterface(t *testing.T) {
 		allowDocumentActions(models.DocumentKindOutgoingLetter, "create", "read", "update"),
 	)
 
-	registered, err := deps.handler.RegisterDocument(struct{}{})
+	registered, err := deps.handler.RegisterDocument(principalTestContext(deps.user), struct{}{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid register request")
 	assert.Nil(t, registered)
 
-	updated, err := deps.handler.UpdateDocument(struct{}{})
+	updated, err := deps.handler.UpdateDocument(principalTestContext(deps.user), struct{}{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid update request")
 	assert.Nil(t, updated)
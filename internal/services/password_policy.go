@@ -0,0 +1,248 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
+)
+
+// settingLookup возвращает значение системной настройки и признак его наличия.
+type settingLookup func(key string) (string, bool)
+
+func storeSettingLookup(store SettingsStore) settingLookup {
+	return func(key string) (string, bool) {
+		setting, err := store.Get(key)
+		if err != nil || setting == nil {
+			return "", false
+		}
+		return strings.TrimSpace(setting.Value), true
+	}
+}
+
+// loadPasswordPolicy собирает политику паролей из настроек. Отсутствующие и
+// некорректные значения заменяются значениями DefaultPasswordPolicy.
+func loadPasswordPolicy(lookup settingLookup) security.PasswordPolicy {
+	policy := security.DefaultPasswordPolicy()
+	value := func(key string) (string, bool) {
+		v, ok := lookup(key)
+		if !ok || validateSystemSettingValue(key, v) != nil {
+			return "", false
+		}
+		return strings.TrimSpace(v), true
+	}
+
+	if v, ok := value(models.SettingPasswordMinLength); ok {
+		policy.MinLength, _ = strconv.Atoi(v)
+	}
+	if v, ok := value(models.SettingPasswordRequiredClasses); ok {
+		policy.RequiredClasses, _ = security.ParseRequiredClasses(v)
+	}
+	if v, ok := value(models.SettingPasswordRejectCommon); ok {
+		policy.RejectCommon, _ = strconv.ParseBool(v)
+	}
+	if v, ok := value(models.SettingPasswordHistoryDepth); ok {
+		policy.HistoryDepth, _ = strconv.Atoi(v)
+	}
+	if v, ok := value(models.SettingPasswordLockoutThreshold); ok {
+		policy.LockoutThreshold, _ = strconv.Atoi(v)
+	}
+	if v, ok := value(models.SettingPasswordLockoutMinutes); ok {
+		minutes, _ := strconv.Atoi(v)
+		policy.LockoutDuration = time.Duration(minutes) * time.Minute
+	}
+	return policy
+}
+
+// passwordPolicy возвращает действующую политику паролей.
+func (s *AuthService) passwordPolicy() security.PasswordPolicy {
+	if s.settingsRepo == nil {
+		return security.DefaultPasswordPolicy()
+	}
+	return loadPasswordPolicy(storeSettingLookup(s.settingsRepo))
+}
+
+// validateNewPassword проверяет новый пароль пользователя по политике,
+// включая историю паролей.
+func (s *AuthService) validateNewPassword(user *models.User, password string, policy security.PasswordPolicy) error {
+	if err := policy.Validate(password); err != nil {
+		return wrapPasswordPolicyError(err)
+	}
+	if policy.HistoryDepth <= 0 {
+		return nil
+	}
+	hashes := []string{user.PasswordHash}
+	if policy.HistoryDepth > 1 {
+		previous, err := s.userRepo.GetPasswordHistory(user.ID, policy.HistoryDepth-1)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, previous...)
+	}
+	if err := policy.CheckHistory(password, hashes); err != nil {
+		return wrapPasswordPolicyError(err)
+	}
+	return nil
+}
+
+// isLockedOut отличает блокировку после неверных попыток входа от
+// деактивации администратором.
+func isLockedOut(user *models.User, policy security.PasswordPolicy) bool {
+	return !user.IsActive && user.FailedLoginAttempts >= policy.LockoutThreshold
+}
+
+// releaseExpiredLockout снимает блокировку пользователя, если истек период
+// автоматической разблокировки, и обновляет user.
+func (s *AuthService) releaseExpiredLockout(user *models.User, policy security.PasswordPolicy) error {
+	if user.IsActive || user.FailedLoginAttempts == 0 || policy.LockoutDuration <= 0 {
+		return nil
+	}
+	store, ok := s.userRepo.(userLockOutboxStore)
+	if !ok {
+		return fmt.Errorf("user store must support atomic lock outbox operation")
+	}
+	name := user.FullName
+	if name == "" {
+		name = user.Login
+	}
+	event, err := NewAdminAuditOutboxEvent("user:"+user.ID.String()+":unlocked:"+uuid.NewString(), models.CreateAdminAuditLogRequest{
+		UserID:   user.ID,
+		UserName: name,
+		Action:   "USER_UNLOCKED",
+		Details:  fmt.Sprintf("Пользователь «%s» (%s) автоматически разблокирован по истечении %d мин.", name, user.Login, int(policy.LockoutDuration/time.Minute)),
+	})
+	if err != nil {
+		return err
+	}
+	released, err := store.ReleaseExpiredLockoutWithOutbox(user.ID, policy.LockoutDuration, event)
+	if err != nil {
+		return err
+	}
+	if released {
+		user.IsActive = true
+		user.FailedLoginAttempts = 0
+	}
+	return nil
+}
+
+// passwordPolicyOutboxStore сохраняет ужесточение политики паролей вместе
+// с требованием смены пароля у локальных пользователей.
+type passwordPolicyOutboxStore interface {
+	UpdatePasswordPolicyWithOutbox(key, value string, effects []models.OutboxEvent) error
+}
+
+// SetPasswordStatusStore подключает состояние паролей для отчета об оценке политики.
+func (s *SettingsService) SetPasswordStatusStore(store PasswordStatusStore) {
+	s.passwords = store
+}
+
+// proposedSettingLookup возвращает настройки с учетом предлагаемых значений.
+func (s *SettingsService) proposedSettingLookup(proposed map[string]string) settingLookup {
+	current := storeSettingLookup(s.repo)
+	return func(key string) (string, bool) {
+		if value, ok := proposed[key]; ok {
+			return strings.TrimSpace(value), true
+		}
+		return current(key)
+	}
+}
+
+// passwordCompositionSettingKeys — настройки требований к составу пароля.
+var passwordCompositionSettingKeys = []string{
+	models.SettingPasswordMinLength,
+	models.SettingPasswordRequiredClasses,
+	models.SettingPasswordRejectCommon,
+}
+
+// tightensPasswordPolicy сообщает, ужесточает ли новое значение настройки
+// требования к составу пароля. Пароли не хранятся ни в открытом виде, ни
+// в виде характеристик, поэтому после ужесточения соответствие текущих
+// паролей неизвестно и локальным пользователям требуется их смена.
+func (s *SettingsService) tightensPasswordPolicy(key, value string) bool {
+	if !slices.Contains(passwordCompositionSettingKeys, key) {
+		return false
+	}
+	current := loadPasswordPolicy(storeSettingLookup(s.repo))
+	proposed := loadPasswordPolicy(s.proposedSettingLookup(map[string]string{key: value}))
+	return proposed.StricterThan(current)
+}
+
+// EvaluatePasswordPolicy оценивает, как политика паролей с изменениями
+// proposed (ключ настройки — новое значение) затронет активных локальных
+// пользователей, до сохранения настроек (только для администраторов).
+// Если политика ужесточает требования к составу пароля, смена пароля
+// потребуется у всех локальных пользователей: характеристики текущих
+// паролей не хранятся.
+func (s *SettingsService) EvaluatePasswordPolicy(proposed map[string]string) (*dto.PasswordPolicyReport, error) {
+	if err := s.authService.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	for key, value := range proposed {
+		if !slices.Contains(models.PasswordPolicySettingKeys, key) {
+			return nil, models.NewBadRequest(fmt.Sprintf("настройка «%s» не относится к политике паролей", key))
+		}
+		if err := validateSystemSettingValue(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if s.passwords == nil {
+		return nil, fmt.Errorf("password status store is not configured")
+	}
+
+	lookup := s.proposedSettingLookup(proposed)
+	policy := loadPasswordPolicy(lookup)
+	stricter := policy.StricterThan(loadPasswordPolicy(storeSettingLookup(s.repo)))
+	lifetimeDays := 0
+	if value, ok := lookup(models.SettingPasswordLifetimeDays); ok && validateSystemSettingValue(models.SettingPasswordLifetimeDays, value) == nil {
+		lifetimeDays, _ = strconv.Atoi(value)
+	}
+
+	statuses, err := s.passwords.GetPasswordStatuses()
+	if err != nil {
+		return nil, err
+	}
+	return buildPasswordPolicyReport(policy, stricter, lifetimeDays, statuses, s.authService.now()), nil
+}
+
+func buildPasswordPolicyReport(policy security.PasswordPolicy, stricter bool, lifetimeDays int, statuses []models.UserPasswordStatus, now time.Time) *dto.PasswordPolicyReport {
+	report := &dto.PasswordPolicyReport{
+		EvaluatedAt:    now,
+		StricterPolicy: stricter,
+		TotalUsers:     len(statuses),
+		Users:          []dto.PasswordPolicyReportUser{},
+	}
+
+	for _, status := range statuses {
+		user := dto.PasswordPolicyReportUser{
+			ID:                  status.UserID.String(),
+			Login:               status.Login,
+			FullName:            status.FullName,
+			ChangeRequired:      stricter || status.PasswordChangeRequired,
+			PasswordChangedAt:   status.PasswordChangedAt,
+			FailedLoginAttempts: status.FailedLoginAttempts,
+		}
+		if user.ChangeRequired {
+			report.ChangeRequired++
+		}
+		if lifetimeDays > 0 && (status.PasswordChangedAt == nil || !now.Before(status.PasswordChangedAt.AddDate(0, 0, lifetimeDays))) {
+			user.PasswordExpired = true
+			report.PasswordExpired++
+		}
+		lockedOnNextFailure := status.FailedLoginAttempts+1 >= policy.LockoutThreshold
+		if lockedOnNextFailure {
+			report.LockedOnNextFailure++
+		}
+
+		if user.ChangeRequired || user.PasswordExpired || lockedOnNextFailure {
+			report.Users = append(report.Users, user)
+		}
+	}
+	return report
+}
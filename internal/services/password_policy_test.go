@@ -0,0 +1,269 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
+)
+
+type passwordStatusStoreStub struct {
+	statuses []models.UserPasswordStatus
+}
+
+func (s *passwordStatusStoreStub) GetPasswordStatuses() ([]models.UserPasswordStatus, error) {
+	return s.statuses, nil
+}
+
+func newPolicySettingsStore(t *testing.T, values map[string]string) *mocks.SettingsStore {
+	t.Helper()
+	settingsRepo := mocks.NewSettingsStore(t)
+	for key, value := range values {
+		settingsRepo.On("Get", key).Return(&models.SystemSetting{Key: key, Value: value}, nil).Maybe()
+	}
+	settingsRepo.On("Get", mock.Anything).Return(nil, assert.AnError).Maybe()
+	return settingsRepo
+}
+
+func TestLoadPasswordPolicy(t *testing.T) {
+	settings := map[string]string{
+		models.SettingPasswordMinLength:        "12",
+		models.SettingPasswordRequiredClasses:  "upper,digit|special",
+		models.SettingPasswordRejectCommon:     "true",
+		models.SettingPasswordHistoryDepth:     "4",
+		models.SettingPasswordLockoutThreshold: "1",
+		models.SettingPasswordLockoutMinutes:   "30",
+	}
+	policy := loadPasswordPolicy(func(key string) (string, bool) {
+		value, ok := settings[key]
+		return value, ok
+	})
+
+	assert.Equal(t, 12, policy.MinLength)
+	assert.Equal(t, []security.CharClass{security.ClassUpper, security.ClassDigit | security.ClassSpecial}, policy.RequiredClasses)
+	assert.True(t, policy.RejectCommon)
+	assert.Equal(t, 4, policy.HistoryDepth)
+	assert.Equal(t, security.DefaultPasswordPolicy().LockoutThreshold, policy.LockoutThreshold, "out of range value falls back to the default")
+	assert.Equal(t, 30*time.Minute, policy.LockoutDuration)
+}
+
+func TestAuthService_PasswordPolicyLockout(t *testing.T) {
+	policySettings := map[string]string{
+		models.SettingPasswordLockoutThreshold: "3",
+		models.SettingPasswordLockoutMinutes:   "15",
+	}
+
+	t.Run("lockout threshold comes from settings", func(t *testing.T) {
+		userRepo := mocks.NewUserStore(t)
+		auth := NewAuthService(nil, userRepo)
+		auth.SetSettingsStore(newPolicySettingsStore(t, policySettings))
+		user, _ := newTestUser()
+
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("IncrementFailedLoginAttempts", user.ID, 3).Return(3, false, nil).Once()
+
+		_, err := auth.Login(user.Login, "WrongPassw0rd!")
+		assert.Equal(t, ErrUserLocked, err)
+	})
+
+	t.Run("expired lockout is released on login", func(t *testing.T) {
+		userRepo := mocks.NewUserStore(t)
+		auth := NewAuthService(nil, userRepo)
+		auth.SetSettingsStore(newPolicySettingsStore(t, policySettings))
+		user, password := newTestUser()
+		user.IsActive = false
+		user.FailedLoginAttempts = 3
+
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("ReleaseExpiredLockout", user.ID, 15*time.Minute).Return(true, nil).Once()
+
+		userDTO, err := auth.Login(user.Login, password)
+		require.NoError(t, err)
+		assert.True(t, userDTO.IsActive)
+		assert.Equal(t, 0, userDTO.FailedLoginAttempts)
+	})
+
+	t.Run("lockout in effect keeps the account locked", func(t *testing.T) {
+		userRepo := mocks.NewUserStore(t)
+		auth := NewAuthService(nil, userRepo)
+		auth.SetSettingsStore(newPolicySettingsStore(t, policySettings))
+		user, password := newTestUser()
+		user.IsActive = false
+		user.FailedLoginAttempts = 3
+
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("ReleaseExpiredLockout", user.ID, 15*time.Minute).Return(false, nil).Once()
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, ErrUserLocked, err)
+	})
+
+	t.Run("deactivated user is not reported as locked", func(t *testing.T) {
+		userRepo := mocks.NewUserStore(t)
+		auth := NewAuthService(nil, userRepo)
+		auth.SetSettingsStore(newPolicySettingsStore(t, policySettings))
+		user, password := newTestUser()
+		user.IsActive = false
+
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, ErrUserNotActive, err)
+	})
+}
+
+func TestAuthService_ChangePasswordPolicy(t *testing.T) {
+	setup := func(t *testing.T, settings map[string]string) (*AuthService, *mocks.UserStore, *models.User, string) {
+		t.Helper()
+		userRepo := mocks.NewUserStore(t)
+		user, password := newTestUser()
+		auth := loginUser(t, userRepo, user, password)
+		auth.SetSettingsStore(newPolicySettingsStore(t, settings))
+		return auth, userRepo, user, password
+	}
+
+	t.Run("rejects password from history", func(t *testing.T) {
+		auth, userRepo, user, password := setup(t, map[string]string{models.SettingPasswordHistoryDepth: "3"})
+		previousHash, err := security.HashPassword("PreviousPassw0rd!")
+		require.NoError(t, err)
+		userRepo.On("GetPasswordHistory", user.ID, 2).Return([]string{previousHash}, nil).Once()
+
+		err = auth.ChangePassword(password, "PreviousPassw0rd!")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "3 последних паролей")
+	})
+
+	t.Run("rejects current password when history depth is one", func(t *testing.T) {
+		auth, _, _, password := setup(t, map[string]string{models.SettingPasswordHistoryDepth: "1"})
+
+		err := auth.ChangePassword(password, password)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "отличаться от текущего")
+	})
+
+	t.Run("rejects common password", func(t *testing.T) {
+		auth, _, _, password := setup(t, map[string]string{models.SettingPasswordRejectCommon: "true"})
+
+		err := auth.ChangePassword(password, "P@ssw0rd1")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "распространенных паролей")
+	})
+
+	t.Run("applies configured minimum length", func(t *testing.T) {
+		auth, userRepo, user, password := setup(t, map[string]string{models.SettingPasswordMinLength: "16"})
+
+		err := auth.ChangePassword(password, "Short-Passw0rd")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "минимум 16 символов")
+
+		userRepo.On("UpdatePassword", user.ID, mock.AnythingOfType("string")).Return(nil).Once()
+		require.NoError(t, auth.ChangePassword(password, "Long-Enough-Passw0rd"))
+	})
+}
+
+func TestUserService_PasswordPolicy(t *testing.T) {
+	userRepo := mocks.NewUserStore(t)
+	admin, password := newTestUser()
+	auth := loginUser(t, userRepo, admin, password)
+	auth.SetAccessStore(newRoleMappedDocumentAccessStore("admin"))
+	auth.SetSettingsStore(newPolicySettingsStore(t, map[string]string{models.SettingPasswordMinLength: "20"}))
+	svc := NewUserService(userRepo, auth)
+
+	_, err := svc.CreateUser(models.CreateUserRequest{Login: "new", FullName: "New User", Password: "Short-Passw0rd"})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "минимум 20 символов")
+
+	userRepo.On("Create", mock.MatchedBy(func(req models.CreateUserRequest) bool {
+		return len(req.Password) == 20
+	})).Return(&models.User{ID: uuid.New(), Login: "new"}, nil).Once()
+	created, err := svc.CreateUser(models.CreateUserRequest{Login: "new", FullName: "New User"})
+	require.NoError(t, err)
+	assert.Len(t, created.TemporaryPassword, 20)
+}
+
+func TestSettingsService_EvaluatePasswordPolicy(t *testing.T) {
+	recent := time.Now().AddDate(0, 0, -10)
+	old := time.Now().AddDate(0, -6, 0)
+
+	current := models.UserPasswordStatus{
+		UserID: uuid.New(), Login: "current", FullName: "Current",
+		PasswordChangedAt: &recent,
+	}
+	expired := models.UserPasswordStatus{
+		UserID: uuid.New(), Login: "expired", FullName: "Expired",
+		PasswordChangedAt: &old,
+	}
+	failing := models.UserPasswordStatus{
+		UserID: uuid.New(), Login: "failing", FullName: "Failing",
+		PasswordChangedAt:      &recent,
+		PasswordChangeRequired: true,
+		FailedLoginAttempts:    2,
+	}
+	statuses := &passwordStatusStoreStub{statuses: []models.UserPasswordStatus{current, expired, failing}}
+	mockCurrentPolicy := func(repo *mocks.SettingsStore) {
+		repo.On("Get", models.SettingPasswordLifetimeDays).Return(&models.SystemSetting{Key: models.SettingPasswordLifetimeDays, Value: "90"}, nil).Maybe()
+		repo.On("Get", models.SettingPasswordRequiredClasses).Return(&models.SystemSetting{Key: models.SettingPasswordRequiredClasses, Value: "upper,lower,digit"}, nil).Maybe()
+		repo.On("Get", mock.Anything).Return(nil, assert.AnError).Maybe()
+	}
+
+	t.Run("stricter policy requires every user to change password", func(t *testing.T) {
+		svc, repo := setupSettingsService(t, "admin")
+		svc.SetPasswordStatusStore(statuses)
+		mockCurrentPolicy(repo)
+
+		report, err := svc.EvaluatePasswordPolicy(map[string]string{
+			models.SettingPasswordMinLength:        "12",
+			models.SettingPasswordLockoutThreshold: "3",
+		})
+		require.NoError(t, err)
+
+		assert.True(t, report.StricterPolicy)
+		assert.Equal(t, 3, report.TotalUsers)
+		assert.Equal(t, 3, report.ChangeRequired)
+		assert.Equal(t, 1, report.PasswordExpired)
+		assert.Equal(t, 1, report.LockedOnNextFailure)
+		require.Len(t, report.Users, 3)
+		assert.True(t, report.Users[1].PasswordExpired)
+	})
+
+	t.Run("relaxed policy keeps current passwords", func(t *testing.T) {
+		svc, repo := setupSettingsService(t, "admin")
+		svc.SetPasswordStatusStore(statuses)
+		mockCurrentPolicy(repo)
+
+		report, err := svc.EvaluatePasswordPolicy(map[string]string{
+			models.SettingPasswordRequiredClasses: "upper,lower,digit|special",
+			models.SettingPasswordHistoryDepth:    "5",
+		})
+		require.NoError(t, err)
+
+		assert.False(t, report.StricterPolicy)
+		assert.Equal(t, 1, report.ChangeRequired)
+		assert.Equal(t, 0, report.LockedOnNextFailure)
+		require.Len(t, report.Users, 2)
+		assert.Equal(t, "expired", report.Users[0].Login)
+		assert.False(t, report.Users[0].ChangeRequired)
+		assert.Equal(t, "failing", report.Users[1].Login)
+		assert.True(t, report.Users[1].ChangeRequired)
+	})
+
+	t.Run("rejects settings outside the password policy", func(t *testing.T) {
+		svc, _ := setupSettingsService(t, "admin")
+		svc.SetPasswordStatusStore(&passwordStatusStoreStub{})
+
+		_, err := svc.EvaluatePasswordPolicy(map[string]string{"organization_name": "ООО"})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "не относится к политике паролей")
+
+		_, err = svc.EvaluatePasswordPolicy(map[string]string{models.SettingPasswordMinLength: "4"})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "Минимальная длина пароля")
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		svc, _ := setupSettingsService(t, "clerk")
+
+		_, err := svc.EvaluatePasswordPolicy(nil)
+		assert.Equal(t, models.ErrForbidden, err)
+	})
+}
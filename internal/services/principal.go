@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type principalContextKey struct{}
+
+// WithPrincipal возвращает контекст, операции в котором выполняются от имени principal.
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext возвращает principal, переданный через WithPrincipal.
+func PrincipalFromContext(ctx context.Context) (*models.Principal, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	principal, ok := ctx.Value(principalContextKey{}).(*models.Principal)
+	return principal, ok && principal != nil
+}
+
+// requirePrincipal возвращает principal операции. Контекст без principal
+// считается неаутентифицированным: неявного перехода к desktop-сессии нет.
+func requirePrincipal(ctx context.Context) (*models.Principal, error) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrNotAuthenticated
+	}
+	return principal, nil
+}
+
+// PrincipalContext загружает активного пользователя userID с его системными
+// правами и замещениями и возвращает ctx с этим principal. Используется
+// вызывающими вне desktop-сессии: HTTP API, CLI, тестами.
+func PrincipalContext(ctx context.Context, auth *AuthService, userID uuid.UUID) (context.Context, error) {
+	principal, err := auth.loadPrincipal(userID)
+	if err != nil {
+		return nil, err
+	}
+	return WithPrincipal(ctx, principal), nil
+}
+
+// sessionContext возвращает контекст с principal desktop-сессии. Wails-методы
+// сервисов вызывают его на входе и передают контекст дальше. Удаленный или
+// деактивированный пользователь теряет локальную сессию.
+func (s *AuthService) sessionContext() (context.Context, error) {
+	userID, err := s.sessionUserID(true)
+	if err != nil {
+		return nil, err
+	}
+	principal, err := s.loadPrincipal(userID)
+	if errors.Is(err, ErrNotAuthenticated) {
+		s.dropSession(userID, models.SessionEndUserInactive)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return WithPrincipal(context.Background(), principal), nil
+}
+
+func (s *AuthService) loadPrincipal(userID uuid.UUID) (*models.Principal, error) {
+	if err := s.checkSchemaReady(); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrNotAuthenticated
+	}
+
+	var substitutions []models.SubstitutionGrant
+	if s.substitutionRepo != nil {
+		substitutions, err = s.substitutionRepo.GetActiveGrants(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return models.NewPrincipal(user, substitutions), nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// Фасады этого файла открывают операции сервисов от имени principal из ctx
+// для вызывающих вне desktop-сессии (HTTP API, CLI). Сами сервисы привязаны
+// к Wails, и экспортированный метод с ctx на них стал бы Wails-методом,
+// поэтому фасады — отдельные типы, которые в Wails не привязываются.
+
+// CurrentUserAPI возвращает пользователя principal.
+type CurrentUserAPI struct {
+	auth *AuthService
+}
+
+// NewCurrentUserAPI создает фасад текущего пользователя.
+func NewCurrentUserAPI(auth *AuthService) *CurrentUserAPI {
+	return &CurrentUserAPI{auth: auth}
+}
+
+// GetCurrentUser возвращает пользователя, от имени которого выполняется ctx.
+func (a *CurrentUserAPI) GetCurrentUser(ctx context.Context) (*dto.User, error) {
+	principal, err := requirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.auth.checkSchemaReady(); err != nil {
+		return nil, err
+	}
+	user, err := a.auth.userRepo.GetByID(principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrNotAuthenticated
+	}
+	return dto.MapUser(user), nil
+}
+
+// DocumentAPI — чтение и регистрация документов от имени principal.
+type DocumentAPI struct {
+	query        *DocumentQueryService
+	registration *DocumentRegistrationService
+}
+
+// NewDocumentAPI создает фасад документов.
+func NewDocumentAPI(query *DocumentQueryService, registration *DocumentRegistrationService) *DocumentAPI {
+	return &DocumentAPI{query: query, registration: registration}
+}
+
+// GetByID возвращает карточку документа.
+func (a *DocumentAPI) GetByID(ctx context.Context, id string) (*dto.DocumentCard, error) {
+	return a.query.getByID(ctx, id)
+}
+
+// GetList возвращает страницу документов вида kindCode.
+func (a *DocumentAPI) GetList(ctx context.Context, kindCode string, filter models.DocumentFilter) (*dto.PagedResult[dto.DocumentListItem], error) {
+	return a.query.getList(ctx, kindCode, filter)
+}
+
+// Register регистрирует документ вида kindCode.
+func (a *DocumentAPI) Register(ctx context.Context, kindCode string, req any) (any, error) {
+	return a.registration.register(ctx, kindCode, req)
+}
+
+// Update изменяет документ вида kindCode.
+func (a *DocumentAPI) Update(ctx context.Context, kindCode string, req any) (any, error) {
+	return a.registration.update(ctx, kindCode, req)
+}
+
+// AssignmentAPI — поручения от имени principal.
+type AssignmentAPI struct {
+	assignments *AssignmentService
+}
+
+// NewAssignmentAPI создает фасад поручений.
+func NewAssignmentAPI(assignments *AssignmentService) *AssignmentAPI {
+	return &AssignmentAPI{assignments: assignments}
+}
+
+// Create создает поручение по документу.
+func (a *AssignmentAPI) Create(ctx context.Context, documentID, executorID, content, deadline string, coExecutorIDs []string) (*dto.Assignment, error) {
+	return a.assignments.create(ctx, documentID, executorID, content, deadline, coExecutorIDs)
+}
+
+// UpdateStatus изменяет статус поручения.
+func (a *AssignmentAPI) UpdateStatus(ctx context.Context, id, status, report string) (*dto.Assignment, error) {
+	return a.assignments.updateStatus(ctx, id, status, report, false)
+}
+
+// CreateSubtask создает подпоручение от имени исполнителя поручения.
+func (a *AssignmentAPI) CreateSubtask(ctx context.Context, parentID, executorID, content, deadline string, coExecutorIDs []string) (*dto.Assignment, error) {
+	return a.assignments.createSubtask(ctx, parentID, executorID, content, deadline, coExecutorIDs)
+}
+
+// RequestDeadlineExtension подает запрос на продление срока поручения.
+func (a *AssignmentAPI) RequestDeadlineExtension(ctx context.Context, assignmentID, deadline, reason string) (*dto.AssignmentDeadlineExtension, error) {
+	return a.assignments.requestDeadlineExtension(ctx, assignmentID, deadline, reason)
+}
+
+// DecideDeadlineExtension одобряет или отклоняет запрос на продление срока.
+func (a *AssignmentAPI) DecideDeadlineExtension(ctx context.Context, id string, approve bool, comment string) (*dto.AssignmentDeadlineExtension, error) {
+	status := models.DeadlineExtensionRejected
+	if approve {
+		status = models.DeadlineExtensionApproved
+	}
+	return a.assignments.decideDeadlineExtension(ctx, id, status, comment)
+}
+
+// GetDeadlineHistory возвращает историю срока поручения.
+func (a *AssignmentAPI) GetDeadlineHistory(ctx context.Context, assignmentID string) (*dto.AssignmentDeadlineHistory, error) {
+	return a.assignments.getDeadlineHistory(ctx, assignmentID)
+}
+
+// GetByID возвращает поручение.
+func (a *AssignmentAPI) GetByID(ctx context.Context, id string) (*dto.Assignment, error) {
+	return a.assignments.getByID(ctx, id)
+}
+
+// GetList возвращает страницу поручений.
+func (a *AssignmentAPI) GetList(ctx context.Context, filter models.AssignmentFilter) (*dto.PagedResult[dto.Assignment], error) {
+	return a.assignments.getList(ctx, filter)
+}
+
+// AcknowledgmentAPI — ознакомления от имени principal.
+type AcknowledgmentAPI struct {
+	acknowledgments *AcknowledgmentService
+}
+
+// NewAcknowledgmentAPI создает фасад ознакомлений.
+func NewAcknowledgmentAPI(acknowledgments *AcknowledgmentService) *AcknowledgmentAPI {
+	return &AcknowledgmentAPI{acknowledgments: acknowledgments}
+}
+
+// Create направляет документ на ознакомление с необязательным сроком.
+func (a *AcknowledgmentAPI) Create(ctx context.Context, documentID, content, deadline string, userIDs []string) (*dto.Acknowledgment, error) {
+	return a.acknowledgments.create(ctx, documentID, content, deadline, userIDs)
+}
+
+// GetList возвращает ознакомления по документу.
+func (a *AcknowledgmentAPI) GetList(ctx context.Context, documentID string) ([]dto.Acknowledgment, error) {
+	return a.acknowledgments.getList(ctx, documentID)
+}
+
+// GetPendingForCurrentUser возвращает ознакомления, ожидающие principal
+// или пользователей, которых он замещает.
+func (a *AcknowledgmentAPI) GetPendingForCurrentUser(ctx context.Context) ([]dto.Acknowledgment, error) {
+	return a.acknowledgments.getPendingForPrincipal(ctx)
+}
+
+// MarkViewed отмечает документ просмотренным.
+func (a *AcknowledgmentAPI) MarkViewed(ctx context.Context, id string) error {
+	return a.acknowledgments.markViewed(ctx, id)
+}
+
+// MarkConfirmed подтверждает ознакомление.
+func (a *AcknowledgmentAPI) MarkConfirmed(ctx context.Context, id string) error {
+	return a.acknowledgments.markConfirmed(ctx, id)
+}
+
+// AttachmentAPI — метаданные вложений от имени principal. Содержимое
+// передает AttachmentContentService.
+type AttachmentAPI struct {
+	attachments *AttachmentService
+}
+
+// NewAttachmentAPI создает фасад вложений.
+func NewAttachmentAPI(attachments *AttachmentService) *AttachmentAPI {
+	return &AttachmentAPI{attachments: attachments}
+}
+
+// GetList возвращает вложения документа.
+func (a *AttachmentAPI) GetList(ctx context.Context, documentID string) ([]dto.Attachment, error) {
+	return a.attachments.getList(ctx, documentID)
+}
+
+// Delete удаляет вложение.
+func (a *AttachmentAPI) Delete(ctx context.Context, id string) error {
+	return a.attachments.delete(ctx, id)
+}
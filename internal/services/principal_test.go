@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+func TestPrincipalFromContext(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	assert.False(t, ok)
+	_, err := requirePrincipal(context.Background())
+	assert.ErrorIs(t, err, ErrNotAuthenticated)
+
+	user, _ := newTestUser()
+	ctx := WithPrincipal(context.Background(), models.NewPrincipal(user, nil))
+	principal, err := requirePrincipal(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, principal.UserID)
+}
+
+func TestPrincipalContext(t *testing.T) {
+	user, _ := newTestUser()
+	user.SystemPermissions = []string{models.SystemPermissionAdmin}
+	substituted := uuid.New()
+
+	mockRepo := mocks.NewUserStore(t)
+	auth := NewAuthService(nil, mockRepo)
+	auth.SetSubstitutionStore(&userSubstitutionStoreStub{activePrincipals: []uuid.UUID{substituted}})
+
+	mockRepo.On("GetByID", user.ID).Return(user, nil).Once()
+	ctx, err := PrincipalContext(context.Background(), auth, user.ID)
+	require.NoError(t, err)
+	principal, ok := PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, user.ID, principal.UserID)
+	assert.True(t, principal.HasSystemPermission(models.SystemPermissionAdmin))
+	assert.Equal(t, []uuid.UUID{user.ID, substituted}, principal.SubjectIDs())
+	assert.False(t, auth.IsAuthenticated(), "principal context must not open the desktop session")
+
+	inactive := *user
+	inactive.IsActive = false
+	mockRepo.On("GetByID", user.ID).Return(&inactive, nil).Once()
+	_, err = PrincipalContext(context.Background(), auth, user.ID)
+	assert.ErrorIs(t, err, ErrNotAuthenticated)
+}
+
+func TestAuthService_SessionContext(t *testing.T) {
+	user, password := newTestUser()
+
+	t.Run("not logged in", func(t *testing.T) {
+		auth := NewAuthService(nil, mocks.NewUserStore(t))
+		_, err := auth.sessionContext()
+		assert.ErrorIs(t, err, ErrNotAuthenticated)
+	})
+
+	t.Run("session principal", func(t *testing.T) {
+		mockRepo := mocks.NewUserStore(t)
+		auth := loginUser(t, mockRepo, user, password)
+		ctx, err := auth.sessionContext()
+		require.NoError(t, err)
+		principal, ok := PrincipalFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, user.ID, principal.UserID)
+	})
+
+	t.Run("deactivated user loses session", func(t *testing.T) {
+		mockRepo := mocks.NewUserStore(t)
+		auth := NewAuthService(nil, mockRepo)
+		mockRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		_, err := auth.Login(user.Login, password)
+		require.NoError(t, err)
+
+		inactive := *user
+		inactive.IsActive = false
+		mockRepo.On("GetByID", user.ID).Return(&inactive, nil).Once()
+		_, err = auth.sessionContext()
+		assert.ErrorIs(t, err, ErrNotAuthenticated)
+		assert.False(t, auth.IsAuthenticated())
+	})
+}
+
+// principalTestContext возвращает контекст операции от имени user без замещений.
+func principalTestContext(user *models.User) context.Context {
+	return WithPrincipal(context.Background(), models.NewPrincipal(user, nil))
+}
+
+// sessionTestContext возвращает контекст desktop-сессии auth, как его строят Wails-адаптеры.
+func sessionTestContext(t *testing.T, auth *AuthService) context.Context {
+	t.Helper()
+	ctx, err := auth.sessionContext()
+	require.NoError(t, err)
+	return ctx
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/observability"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/printforms"
+)
+
+// PrintFormService формирует печатные формы документов: регистрационно-контрольную
+// карточку, регистрационный штамп и лист ознакомления. Данные читаются через
+// DocumentQueryService, поэтому печать доступна только для документов, которые
+// пользователь может открыть.
+type PrintFormService struct {
+	queries          *DocumentQueryService
+	nomenclatureRepo NomenclatureStore
+	settings         *SettingsService
+	auth             *AuthService
+	acknowledgments  AcknowledgmentStore
+	metrics          *observability.Registry
+	now              func() time.Time
+}
+
+// NewPrintFormService создает сервис печатных форм.
+func NewPrintFormService(queries *DocumentQueryService, nomenclatureRepo NomenclatureStore, settings *SettingsService, auth *AuthService) *PrintFormService {
+	return &PrintFormService{
+		queries:          queries,
+		nomenclatureRepo: nomenclatureRepo,
+		settings:         settings,
+		auth:             auth,
+		now:              time.Now,
+	}
+}
+
+func (s *PrintFormService) SetOperationMetrics(metrics *observability.Registry) {
+	s.metrics = metrics
+}
+
+// SetAcknowledgments подключает электронные ознакомления для листа ознакомления.
+func (s *PrintFormService) SetAcknowledgments(repo AcknowledgmentStore) {
+	s.acknowledgments = repo
+}
+
+// GetTemplates возвращает шаблоны печатных форм, доступные для вида документа.
+func (s *PrintFormService) GetTemplates(kindCode string) ([]dto.PrintTemplate, error) {
+	if err := s.auth.RequireAuthenticated(); err != nil {
+		return nil, err
+	}
+	templates := printforms.Templates(kindCode)
+	result := make([]dto.PrintTemplate, 0, len(templates))
+	for _, template := range templates {
+		result = append(result, dto.PrintTemplate{Code: template.Code, Name: template.Name})
+	}
+	return result, nil
+}
+
+// GenerateToDisk сохраняет печатную форму документа в папку «Загрузки» и возвращает путь к файлу.
+func (s *PrintFormService) GenerateToDisk(documentIDStr, templateCode string) (string, error) {
+	return measureOperation(s.metrics, "documents.print_form", func() (string, error) {
+		card, template, err := s.prepare(documentIDStr, templateCode)
+		if err != nil {
+			return "", err
+		}
+		form, err := s.buildForm(card, template)
+		if err != nil {
+			return "", err
+		}
+
+		downloadDir, err := userDownloadDir()
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(downloadDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create download directory: %v", err)
+		}
+		return writeDownloadFileFromStorage(downloadDir, printFormFilename(template, card), func(file *os.File) error {
+			return printforms.Render(file, template, form)
+		})
+	})
+}
+
+// WritePrintForm записывает печатную форму документа в w.
+func (s *PrintFormService) WritePrintForm(w io.Writer, documentIDStr, templateCode string) error {
+	return measureOperationError(s.metrics, "documents.print_form", func() error {
+		card, template, err := s.prepare(documentIDStr, templateCode)
+		if err != nil {
+			return err
+		}
+		form, err := s.buildForm(card, template)
+		if err != nil {
+			return err
+		}
+		return printforms.Render(w, template, form)
+	})
+}
+
+func (s *PrintFormService) prepare(documentIDStr, templateCode string) (*dto.DocumentCard, printforms.Template, error) {
+	template, ok := printforms.Lookup(templateCode)
+	if !ok {
+		return nil, printforms.Template{}, models.NewBadRequest("неизвестный шаблон печатной формы")
+	}
+	card, err := s.queries.GetByID(documentIDStr)
+	if err != nil {
+		return nil, printforms.Template{}, err
+	}
+	if card == nil {
+		return nil, printforms.Template{}, models.NewNotFound("документ не найден")
+	}
+	if !template.Supports(card.KindCode) {
+		return nil, printforms.Template{}, models.NewBadRequest("шаблон печатной формы недоступен для этого вида документа")
+	}
+	return card, template, nil
+}
+
+func (s *PrintFormService) buildForm(card *dto.DocumentCard, template printforms.Template) (printforms.RegistrationForm, error) {
+	form := printforms.RegistrationForm{
+		KindName:           card.KindName,
+		RegistrationNumber: card.RegistrationNumber,
+		RegistrationDate:   card.RegistrationDate,
+		NomenclatureName:   card.NomenclatureName,
+		Sections:           printFormSections(card),
+		PrintedAt:          s.now(),
+	}
+	if s.settings != nil {
+		form.OrganizationName = s.settings.GetOrganizationShortName()
+		if form.OrganizationName == "" {
+			form.OrganizationName = s.settings.GetOrganizationName()
+		}
+	}
+	if nomenclatureID, err := uuid.Parse(card.NomenclatureID); err == nil && s.nomenclatureRepo != nil {
+		nomenclature, err := s.nomenclatureRepo.GetByID(nomenclatureID)
+		if err != nil {
+			return printforms.RegistrationForm{}, err
+		}
+		if nomenclature != nil {
+			form.NomenclatureIndex = nomenclature.Index
+			if form.NomenclatureName == "" {
+				form.NomenclatureName = nomenclature.Name
+			}
+		}
+	}
+	if template.Code == printforms.TemplateAcknowledgmentSheet {
+		lists, err := s.acknowledgmentLists(card)
+		if err != nil {
+			return printforms.RegistrationForm{}, err
+		}
+		form.Acknowledgments = lists
+	}
+	return form, nil
+}
+
+// acknowledgmentLists собирает листы ознакомления документа: электронные
+// ознакомления с отметками пользователей и их заместителей и именной список
+// ознакомления с приказом, отметки в котором вносит делопроизводитель. Как и
+// другие печатные формы, лист доступен при праве чтения документа, которое
+// проверено при получении карточки.
+func (s *PrintFormService) acknowledgmentLists(card *dto.DocumentCard) ([]printforms.AcknowledgmentList, error) {
+	lists := make([]printforms.AcknowledgmentList, 0)
+	if s.acknowledgments != nil {
+		documentID, err := uuid.Parse(card.ID)
+		if err != nil {
+			return nil, models.NewBadRequestWrapped("неверный ID документа", err)
+		}
+		acknowledgments, err := s.acknowledgments.GetByDocumentID(documentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, ack := range acknowledgments {
+			list := printforms.AcknowledgmentList{Title: "Ознакомление от " + ack.CreatedAt.Format("02.01.2006"), Deadline: ack.Deadline}
+			if content := strings.TrimSpace(ack.Content); content != "" {
+				list.Title += ": " + content
+			}
+			for _, user := range ack.Users {
+				list.Entries = append(list.Entries, printforms.AcknowledgmentEntry{FullName: user.UserName, ViewedAt: user.ViewedAt, ConfirmedAt: user.ConfirmedAt, ActedBy: user.ConfirmedByName})
+			}
+			lists = append(lists, list)
+		}
+	}
+	if order := card.AdministrativeOrder; order != nil && len(order.AcknowledgmentPeople) > 0 {
+		list := printforms.AcknowledgmentList{Title: "Ознакомление с приказом"}
+		for _, person := range order.AcknowledgmentPeople {
+			list.Entries = append(list.Entries, printforms.AcknowledgmentEntry{FullName: person.FullName, ConfirmedAt: person.AcknowledgedAt, ActedBy: person.AcknowledgedByName})
+		}
+		lists = append(lists, list)
+	}
+	if len(lists) == 0 {
+		return nil, models.NewBadRequest("у документа нет листов ознакомления")
+	}
+	return lists, nil
+}
+
+// printFormSections формирует разделы карточки с реквизитами конкретного вида документа.
+func printFormSections(card *dto.DocumentCard) []printforms.Section {
+	common := printforms.Section{Title: "Документ", Fields: []printforms.Field{
+		{Label: "Тип документа", Value: card.DocumentTypeName},
+		{Label: "Краткое содержание", Value: card.Content},
+	}}
+	registeredBy := printforms.Section{Title: "Регистрация", Fields: []printforms.Field{
+		{Label: "Зарегистрировал", Value: card.CreatedByName},
+		{Label: "Дата внесения", Value: card.CreatedAt.Format("02.01.2006 15:04")},
+	}}
+
+	switch {
+	case card.IncomingLetter != nil:
+		doc := card.IncomingLetter
+		common.Fields = append(common.Fields,
+			printforms.Field{Label: "Количество листов", Value: strconv.Itoa(doc.PagesCount)},
+			printforms.Field{Label: "Подписант", Value: doc.SenderSignatory},
+		)
+		return []printforms.Section{
+			{Title: "Корреспонденты", Fields: printFormCorrespondents(doc.Correspondents)},
+			common,
+			{Title: "Резолюция", Fields: printFormResolution(doc.Resolution, doc.ResolutionAuthor, doc.ResolutionExecutors)},
+			registeredBy,
+		}
+	case card.OutgoingLetter != nil:
+		doc := card.OutgoingLetter
+		common.Fields = append(common.Fields,
+			printforms.Field{Label: "Количество листов", Value: strconv.Itoa(doc.PagesCount)},
+		)
+		return []printforms.Section{
+			{Title: "Получатель", Fields: []printforms.Field{
+				{Label: "Организация", Value: doc.RecipientOrgName},
+				{Label: "Адресат", Value: doc.Addressee},
+			}},
+			common,
+			{Title: "Отправитель", Fields: []printforms.Field{
+				{Label: "Подписал", Value: doc.SenderSignatory},
+				{Label: "Исполнитель", Value: doc.SenderExecutor},
+			}},
+			registeredBy,
+		}
+	case card.CitizenAppeal != nil:
+		doc := card.CitizenAppeal
+		common.Fields = append(common.Fields,
+			printforms.Field{Label: "Листов обращения", Value: strconv.Itoa(doc.AppealPagesCount)},
+			printforms.Field{Label: "Листов приложений", Value: strconv.Itoa(doc.AttachmentPagesCount)},
+		)
+		sections := []printforms.Section{
+			{Title: "Заявитель", Fields: []printforms.Field{
+				{Label: "ФИО", Value: doc.ApplicantFullName},
+				{Label: "Адрес", Value: doc.RegistrationAddress},
+				{Label: "Категория заявителя", Value: doc.ApplicantCategory},
+				{Label: "Вид обращения", Value: doc.AppealType},
+				{Label: "Дата обращения", Value: doc.AppealDate.Format("02.01.2006")},
+			}},
+			{Title: "Поступило через", Fields: printFormCorrespondents(doc.Correspondents)},
+			common,
+		}
+		for _, resolution := range doc.Resolutions {
+			sections = append(sections, printforms.Section{Title: "Резолюция", Fields: printFormResolution(resolution.Resolution, resolution.ResolutionAuthor, resolution.ResolutionExecutors)})
+		}
+		control := printforms.Section{Title: "Контроль"}
+		if doc.ResponseDueDate != nil {
+			control.Fields = append(control.Fields, printforms.Field{Label: "Срок ответа", Value: doc.ResponseDueDate.Format("02.01.2006")})
+		}
+		if doc.ClosedAt != nil {
+			control.Fields = append(control.Fields, printforms.Field{Label: "Ответ", Value: strings.TrimSpace(doc.ClosedByDocumentNumber + " от " + doc.ClosedAt.Format("02.01.2006"))})
+		}
+		return append(sections, control, registeredBy)
+	case card.AdministrativeOrder != nil:
+		doc := card.AdministrativeOrder
+		order := printforms.Section{Title: "Приказ", Fields: []printforms.Field{
+			{Label: "Заголовок", Value: doc.Title},
+			{Label: "Контроль исполнения", Value: doc.ExecutionController},
+		}}
+		if doc.ExecutionDeadline != nil {
+			order.Fields = append(order.Fields, printforms.Field{Label: "Срок исполнения", Value: doc.ExecutionDeadline.Format("02.01.2006")})
+		}
+		if doc.CancelledAt != nil {
+			order.Fields = append(order.Fields, printforms.Field{Label: "Отменен", Value: doc.CancelledAt.Format("02.01.2006")})
+		}
+		return []printforms.Section{order, registeredBy}
+	default:
+		return []printforms.Section{common, registeredBy}
+	}
+}
+
+func printFormCorrespondents(items []dto.DocumentCorrespondentRegistration) []printforms.Field {
+	fields := make([]printforms.Field, 0, len(items))
+	for _, item := range items {
+		value := item.CorrespondentName
+		if number := strings.TrimSpace(item.RegistrationNumber); number != "" {
+			value += ", исх. № " + number
+		}
+		if !item.RegistrationDate.IsZero() {
+			value += " от " + item.RegistrationDate.Format("02.01.2006")
+		}
+		fields = append(fields, printforms.Field{Label: "Корреспондент", Value: strings.TrimPrefix(value, ", ")})
+	}
+	return fields
+}
+
+func printFormResolution(resolution, author, executors *string) []printforms.Field {
+	fields := make([]printforms.Field, 0, 3)
+	if value := registerOptional(resolution); value != "" {
+		fields = append(fields, printforms.Field{Label: "Текст резолюции", Value: value})
+	}
+	if value := registerOptional(author); value != "" {
+		fields = append(fields, printforms.Field{Label: "Автор", Value: value})
+	}
+	if value := registerOptional(executors); value != "" {
+		fields = append(fields, printforms.Field{Label: "Исполнители", Value: value})
+	}
+	return fields
+}
+
+func printFormFilename(template printforms.Template, card *dto.DocumentCard) string {
+	number := strings.NewReplacer("/", "-", "\\", "-", ":", "-", "*", "-", "?", "-", "\"", "-", "<", "-", ">", "-", "|", "-").Replace(strings.TrimSpace(card.RegistrationNumber))
+	if number == "" {
+		number = card.ID
+	}
+	return fmt.Sprintf("%s - %s.pdf", template.Name, number)
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/printforms"
+)
+
+func setupPrintFormService(t *testing.T, kind models.DocumentKind, card *dto.DocumentCard, actions ...string) (*PrintFormService, *mocks.SettingsStore, *mocks.NomenclatureStore, uuid.UUID) {
+	t.Helper()
+	deps := setupDocumentAccessService(t, documentAccessUser(false, nil), allowDocumentActions(kind, append([]string{"read"}, actions...)...))
+	documentID := uuid.New()
+	deps.docRepo.docs[documentID] = documentAccessDoc(documentID, uuid.New(), kind)
+	handler := &stubDocumentKindQueryHandler{kind: kind, card: card}
+	queries := NewDocumentQueryService(NewDocumentKindQueryRegistry(handler), deps.service)
+
+	settings, settingsRepo := setupSettingsService(t, "clerk")
+	nomenclatureRepo := mocks.NewNomenclatureStore(t)
+	svc := NewPrintFormService(queries, nomenclatureRepo, settings, deps.auth)
+	svc.now = func() time.Time { return time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC) }
+	svc.SetAcknowledgments(mocks.NewAcknowledgmentStore(t))
+	return svc, settingsRepo, nomenclatureRepo, documentID
+}
+
+func TestPrintFormService_WritePrintForm(t *testing.T) {
+	t.Run("renders stamp with organization and nomenclature index", func(t *testing.T) {
+		nomenclatureID := uuid.New()
+		card := &dto.DocumentCard{
+			KindCode:           string(models.DocumentKindIncomingLetter),
+			KindName:           models.DocumentKindIncomingLetter.Label(),
+			RegistrationNumber: "01-12/7",
+			RegistrationDate:   time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+			NomenclatureID:     nomenclatureID.String(),
+			IncomingLetter:     &dto.IncomingDocument{PagesCount: 2},
+		}
+		svc, settingsRepo, nomenclatureRepo, documentID := setupPrintFormService(t, models.DocumentKindIncomingLetter, card)
+		settingsRepo.On("Get", "organization_short_name").Return(&models.SystemSetting{Value: "ГБУ «Ромашка»"}, nil).Once()
+		nomenclatureRepo.On("GetByID", nomenclatureID).Return(&models.Nomenclature{ID: nomenclatureID, Index: "01-12", Name: "Переписка"}, nil).Once()
+
+		var buf bytes.Buffer
+		err := svc.WritePrintForm(&buf, documentID.String(), printforms.TemplateRegistrationStamp)
+
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	})
+
+	t.Run("rejects template unavailable for kind", func(t *testing.T) {
+		card := &dto.DocumentCard{KindCode: string(models.DocumentKindAdministrativeOrder)}
+		svc, _, _, documentID := setupPrintFormService(t, models.DocumentKindAdministrativeOrder, card)
+
+		err := svc.WritePrintForm(&bytes.Buffer{}, documentID.String(), printforms.TemplateRegistrationStamp)
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "шаблон печатной формы недоступен для этого вида документа")
+	})
+
+	t.Run("rejects unknown template before reading document", func(t *testing.T) {
+		svc, _, _, documentID := setupPrintFormService(t, models.DocumentKindIncomingLetter, nil)
+
+		err := svc.WritePrintForm(&bytes.Buffer{}, documentID.String(), "unknown")
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неизвестный шаблон печатной формы")
+	})
+}
+
+func TestPrintFormService_AcknowledgmentSheet(t *testing.T) {
+	viewedAt := time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)
+	confirmedAt := time.Date(2026, 3, 5, 11, 0, 0, 0, time.UTC)
+	deadline := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	newCard := func() *dto.DocumentCard {
+		return &dto.DocumentCard{
+			KindCode:           string(models.DocumentKindAdministrativeOrder),
+			KindName:           models.DocumentKindAdministrativeOrder.Label(),
+			RegistrationNumber: "15-од",
+			AdministrativeOrder: &dto.AdministrativeOrderDocument{AcknowledgmentPeople: []dto.AdministrativeOrderAcknowledgmentPerson{
+				{FullName: "Смирнова М. А.", AcknowledgedAt: &confirmedAt, AcknowledgedByName: "Делопроизводитель"},
+				{FullName: "Орлов П. С."},
+			}},
+		}
+	}
+
+	t.Run("combines electronic and name-based lists with substitutes", func(t *testing.T) {
+		card := newCard()
+		svc, settingsRepo, _, documentID := setupPrintFormService(t, models.DocumentKindAdministrativeOrder, card)
+		card.ID = documentID.String()
+		settingsRepo.On("Get", "organization_short_name").Return(&models.SystemSetting{}, nil).Maybe()
+		settingsRepo.On("Get", "organization_name").Return(&models.SystemSetting{}, nil).Maybe()
+		ackRepo := svc.acknowledgments.(*mocks.AcknowledgmentStore)
+		ackRepo.On("GetByDocumentID", documentID).Return([]models.Acknowledgment{{
+			ID: uuid.New(), DocumentID: documentID, Content: "Довести до сведения", CreatedAt: viewedAt, Deadline: &deadline,
+			Users: []models.AcknowledgmentUser{
+				{UserName: "Иванов И. И.", ViewedAt: &viewedAt, ConfirmedAt: &confirmedAt},
+				{UserName: "Петров П. П.", ViewedAt: &viewedAt, ConfirmedAt: &confirmedAt, ViewedByName: "Сидорова А. С.", ConfirmedByName: "Сидорова А. С."},
+				{UserName: "Кузнецов О. В.", ViewedAt: &viewedAt, ViewedByName: "Сидорова А. С."},
+			},
+		}}, nil)
+
+		lists, err := svc.acknowledgmentLists(card)
+		require.NoError(t, err)
+		require.Len(t, lists, 2)
+		assert.Equal(t, "Ознакомление от 05.03.2026: Довести до сведения", lists[0].Title)
+		assert.Equal(t, &deadline, lists[0].Deadline)
+		require.Len(t, lists[0].Entries, 3)
+		assert.Empty(t, lists[0].Entries[0].ActedBy)
+		assert.Equal(t, "Сидорова А. С.", lists[0].Entries[1].ActedBy)
+		assert.Empty(t, lists[0].Entries[2].ActedBy, "просмотр за пользователя не считается отметкой об ознакомлении")
+		assert.Equal(t, []printforms.AcknowledgmentEntry{
+			{FullName: "Смирнова М. А.", ConfirmedAt: &confirmedAt, ActedBy: "Делопроизводитель"},
+			{FullName: "Орлов П. С."},
+		}, lists[1].Entries)
+
+		var buf bytes.Buffer
+		require.NoError(t, svc.WritePrintForm(&buf, documentID.String(), printforms.TemplateAcknowledgmentSheet))
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	})
+
+	t.Run("is unavailable for kinds without acknowledgments", func(t *testing.T) {
+		card := &dto.DocumentCard{KindCode: "unknown_kind"}
+		svc, _, _, documentID := setupPrintFormService(t, models.DocumentKindIncomingLetter, card)
+
+		err := svc.WritePrintForm(&bytes.Buffer{}, documentID.String(), printforms.TemplateAcknowledgmentSheet)
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "шаблон печатной формы недоступен для этого вида документа")
+	})
+
+	t.Run("rejects document without acknowledgments", func(t *testing.T) {
+		card := &dto.DocumentCard{KindCode: string(models.DocumentKindIncomingLetter), IncomingLetter: &dto.IncomingDocument{}}
+		svc, _, _, documentID := setupPrintFormService(t, models.DocumentKindIncomingLetter, card)
+		card.ID = documentID.String()
+		svc.acknowledgments.(*mocks.AcknowledgmentStore).On("GetByDocumentID", documentID).Return(nil, nil).Once()
+
+		_, err := svc.acknowledgmentLists(card)
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "нет листов ознакомления")
+	})
+}
+
+func TestPrintFormService_GetTemplates(t *testing.T) {
+	svc, _, _, _ := setupPrintFormService(t, models.DocumentKindAdministrativeOrder, nil)
+
+	items, err := svc.GetTemplates(string(models.DocumentKindAdministrativeOrder))
+
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, printforms.TemplateRegistrationCard, items[0].Code)
+	assert.Equal(t, printforms.TemplateAcknowledgmentSheet, items[1].Code)
+}
+
+func TestPrintFormSections(t *testing.T) {
+	resolution := "Подготовить ответ"
+	due := time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC)
+	card := &dto.DocumentCard{
+		CitizenAppeal: &dto.CitizenAppealDocument{
+			ApplicantFullName: "Иванов И.И.",
+			ResponseDueDate:   &due,
+			Resolutions:       []dto.DocumentResolution{{Resolution: &resolution}},
+		},
+	}
+
+	sections := printFormSections(card)
+
+	titles := make([]string, 0, len(sections))
+	for _, section := range sections {
+		titles = append(titles, section.Title)
+	}
+	assert.Equal(t, []string{"Заявитель", "Поступило через", "Документ", "Резолюция", "Контроль", "Регистрация"}, titles)
+	assert.Equal(t, printforms.Field{Label: "Срок ответа", Value: "03.04.2026"}, sections[4].Fields[0])
+	assert.Equal(t, "Документ - 01-12-7.pdf", printFormFilename(printforms.Template{Name: "Документ"}, &dto.DocumentCard{RegistrationNumber: "01-12/7"}))
+}
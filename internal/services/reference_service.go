@@ -20,6 +20,9 @@ type referenceOutboxStore interface {
 	MergeOrganizationsWithOutbox(uuid.UUID, uuid.UUID, []models.OutboxEvent) error
 	UpdateResolutionExecutorWithOutbox(uuid.UUID, string, []models.OutboxEvent) error
 	DeleteResolutionExecutorWithOutbox(uuid.UUID, []models.OutboxEvent) error
+	CreateDocumentTypeWithOutbox(string, []models.DocumentKind, []models.OutboxEvent) (*models.DocumentType, error)
+	UpdateDocumentTypeWithOutbox(uuid.UUID, string, []models.DocumentKind, bool, []models.OutboxEvent) (*models.DocumentType, error)
+	DeleteDocumentTypeWithOutbox(uuid.UUID, []models.OutboxEvent) error
 }
 
 var errReferenceOutboxStoreRequired = fmt.Errorf("reference store must support atomic outbox operations")
@@ -40,35 +43,132 @@ func (s *ReferenceService) requireReferenceManagement() error {
 
 // === Типы документов ===
 
-// GetDocumentTypes возвращает список всех типов документов.
+// GetDocumentTypes возвращает все типы документов, включая неактивные, с разрешенными видами.
 func (s *ReferenceService) GetDocumentTypes() ([]dto.DocumentType, error) {
 	if err := s.auth.RequireAuthenticated(); err != nil {
 		return nil, err
 	}
+	res, err := s.repo.GetAllDocumentTypes()
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapDocumentTypes(res), nil
+}
 
-	items := make([]dto.DocumentType, 0, len(models.AllowedDocumentTypes()))
-	for _, name := range models.AllowedDocumentTypes() {
-		items = append(items, dto.DocumentType{
-			ID:   name,
-			Name: name,
-		})
+// GetDocumentTypesByKind возвращает действующие типы, доступные при регистрации документа вида.
+func (s *ReferenceService) GetDocumentTypesByKind(kindCode string) ([]dto.DocumentType, error) {
+	if err := s.auth.RequireAuthenticated(); err != nil {
+		return nil, err
 	}
-	return items, nil
+	kind := models.NormalizeDocumentKind(kindCode)
+	if _, ok := models.GetDocumentKindSpec(kind); !ok {
+		return nil, models.NewBadRequest("неизвестный вид документа")
+	}
+	res, err := s.repo.GetDocumentTypesByKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapDocumentTypes(res), nil
 }
 
 // CreateDocumentType создает новый тип документа для пользователей с доступом к справочникам.
-func (s *ReferenceService) CreateDocumentType(name string) (*dto.DocumentType, error) {
-	return nil, models.NewBadRequest("типы документов заданы в коде и не редактируются")
+func (s *ReferenceService) CreateDocumentType(name string, kindCodes []string) (*dto.DocumentType, error) {
+	if err := s.requireReferenceManagement(); err != nil {
+		return nil, err
+	}
+	name, kinds, err := normalizeDocumentTypeInput(name, kindCodes)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := s.repo.(referenceOutboxStore)
+	if !ok {
+		return nil, errReferenceOutboxStoreRequired
+	}
+	event, buildErr := s.auditEffect("document_type:create:"+uuid.NewString(), "DOCTYPE_CREATE", fmt.Sprintf("Создан тип документа «%s»", name))
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	res, err := store.CreateDocumentTypeWithOutbox(name, kinds, []models.OutboxEvent{event})
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapDocumentType(res), nil
 }
 
-// UpdateDocumentType обновляет название типа документа для пользователей с доступом к справочникам.
-func (s *ReferenceService) UpdateDocumentType(id string, name string) error {
-	return models.NewBadRequest("типы документов заданы в коде и не редактируются")
+// UpdateDocumentType меняет название, набор видов и активность типа документа.
+// Деактивированный тип остается у зарегистрированных документов, но не предлагается для новых.
+func (s *ReferenceService) UpdateDocumentType(id string, name string, kindCodes []string, isActive bool) (*dto.DocumentType, error) {
+	if err := s.requireReferenceManagement(); err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID записи справочника", err)
+	}
+	name, kinds, err := normalizeDocumentTypeInput(name, kindCodes)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := s.repo.(referenceOutboxStore)
+	if !ok {
+		return nil, errReferenceOutboxStoreRequired
+	}
+	details := fmt.Sprintf("Обновлен тип документа «%s»", name)
+	if !isActive {
+		details += " (деактивирован)"
+	}
+	event, buildErr := s.auditEffect("document_type:"+uid.String()+":update:"+uuid.NewString(), "DOCTYPE_UPDATE", details)
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	res, err := store.UpdateDocumentTypeWithOutbox(uid, name, kinds, isActive, []models.OutboxEvent{event})
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapDocumentType(res), nil
 }
 
-// DeleteDocumentType удаляет тип документа по его ID для пользователей с доступом к справочникам.
+// DeleteDocumentType удаляет тип документа, который еще нигде не использовался.
 func (s *ReferenceService) DeleteDocumentType(id string) error {
-	return models.NewBadRequest("типы документов заданы в коде и не редактируются")
+	if err := s.requireReferenceManagement(); err != nil {
+		return err
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return models.NewBadRequestWrapped("неверный ID записи справочника", err)
+	}
+	store, ok := s.repo.(referenceOutboxStore)
+	if !ok {
+		return errReferenceOutboxStoreRequired
+	}
+	event, buildErr := s.auditEffect("document_type:"+uid.String()+":delete", "DOCTYPE_DELETE", fmt.Sprintf("Удален тип документа (ID: %s)", id))
+	if buildErr != nil {
+		return buildErr
+	}
+	return store.DeleteDocumentTypeWithOutbox(uid, []models.OutboxEvent{event})
+}
+
+func normalizeDocumentTypeInput(name string, kindCodes []string) (string, []models.DocumentKind, error) {
+	name = models.NormalizeDocumentType(name)
+	if name == "" {
+		return "", nil, models.NewBadRequest("название типа документа обязательно")
+	}
+	kinds := make([]models.DocumentKind, 0, len(kindCodes))
+	seen := make(map[models.DocumentKind]bool, len(kindCodes))
+	for _, code := range kindCodes {
+		kind := models.NormalizeDocumentKind(code)
+		if _, ok := models.GetDocumentKindSpec(kind); !ok {
+			return "", nil, models.NewBadRequest(fmt.Sprintf("неизвестный вид документа: %s", code))
+		}
+		if !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) == 0 {
+		return "", nil, models.NewBadRequest("укажите хотя бы один вид документа для типа")
+	}
+	return name, kinds, nil
 }
 
 // === Организации ===
@@ -90,19 +90,37 @@ func (s *atomicReferenceStore) DeleteResolutionExecutorWithOutbox(id uuid.UUID,
 	s.record(effects)
 	return s.ReferenceStore.DeleteResolutionExecutor(id)
 }
+func (s *atomicReferenceStore) CreateDocumentTypeWithOutbox(name string, kinds []models.DocumentKind, effects []models.OutboxEvent) (*models.DocumentType, error) {
+	s.record(effects)
+	return s.ReferenceStore.CreateDocumentType(name, kinds)
+}
+func (s *atomicReferenceStore) UpdateDocumentTypeWithOutbox(id uuid.UUID, name string, kinds []models.DocumentKind, isActive bool, effects []models.OutboxEvent) (*models.DocumentType, error) {
+	s.record(effects)
+	return s.ReferenceStore.UpdateDocumentType(id, name, kinds, isActive)
+}
+func (s *atomicReferenceStore) DeleteDocumentTypeWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error {
+	s.record(effects)
+	return s.ReferenceStore.DeleteDocumentType(id)
+}
 
 // === Типы документов ===
 
 func TestReferenceService_GetDocumentTypes(t *testing.T) {
-	// Получение списка всех типов документов из кода.
+	// Получение справочника типов документов, включая неактивные.
 	t.Run("успех (авторизован)", func(t *testing.T) {
-		svc, _, _, _ := setupReferenceService(t, "clerk")
+		svc, repo, _, _ := setupReferenceService(t, "clerk")
+		id := uuid.New()
+		repo.On("GetAllDocumentTypes").Return([]models.DocumentType{
+			{ID: id, Name: models.DocumentTypeLetter, Kinds: []models.DocumentKind{models.DocumentKindIncomingLetter}, IsActive: true},
+			{ID: uuid.New(), Name: "Телеграмма", Kinds: []models.DocumentKind{models.DocumentKindIncomingLetter}},
+		}, nil).Once()
 
 		result, err := svc.GetDocumentTypes()
 		require.NoError(t, err)
-		assert.Len(t, result, len(models.AllowedDocumentTypes()))
-		assert.Equal(t, models.DocumentTypeLetter, result[0].Name)
-		assert.Equal(t, models.DocumentTypeLetter, result[0].ID)
+		require.Len(t, result, 2)
+		assert.Equal(t, id.String(), result[0].ID)
+		assert.Equal(t, []string{"incoming_letter"}, result[0].Kinds)
+		assert.False(t, result[1].IsActive)
 	})
 
 	t.Run("не авторизован", func(t *testing.T) {
@@ -114,34 +132,107 @@ func TestReferenceService_GetDocumentTypes(t *testing.T) {
 	})
 }
 
+func TestReferenceService_GetDocumentTypesByKind(t *testing.T) {
+	t.Run("legacy-код вида приводится к системному", func(t *testing.T) {
+		svc, repo, _, _ := setupReferenceService(t, "clerk")
+		repo.On("GetDocumentTypesByKind", models.DocumentKindOutgoingLetter).
+			Return([]models.DocumentType{{ID: uuid.New(), Name: models.DocumentTypeLetter, IsActive: true}}, nil).Once()
+
+		result, err := svc.GetDocumentTypesByKind("outgoing")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, models.DocumentTypeLetter, result[0].Name)
+	})
+
+	t.Run("неизвестный вид", func(t *testing.T) {
+		svc, _, _, _ := setupReferenceService(t, "clerk")
+		_, err := svc.GetDocumentTypesByKind("memo")
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неизвестный вид документа")
+	})
+}
+
 func TestReferenceService_CreateDocumentType(t *testing.T) {
-	// Типы документов заданы в коде и не редактируются через сервис.
-	t.Run("запрещено", func(t *testing.T) {
+	// Создание типа документа с набором видов, для которых он разрешен.
+	t.Run("запрещено (не админ)", func(t *testing.T) {
 		svc, _, _, _ := setupReferenceService(t, "clerk")
-		result, err := svc.CreateDocumentType("Test")
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "не редактируются")
+		result, err := svc.CreateDocumentType("Справка", []string{"incoming_letter"})
+		assert.Equal(t, models.ErrForbidden, err)
 		assert.Nil(t, result)
 	})
+
+	t.Run("пустое название", func(t *testing.T) {
+		svc, _, _, _ := setupReferenceService(t, models.SystemPermissionReferences)
+		_, err := svc.CreateDocumentType("  ", []string{"incoming_letter"})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "название типа документа обязательно")
+	})
+
+	t.Run("без видов", func(t *testing.T) {
+		svc, _, _, _ := setupReferenceService(t, models.SystemPermissionReferences)
+		_, err := svc.CreateDocumentType("Справка", nil)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "укажите хотя бы один вид документа для типа")
+	})
+
+	t.Run("неизвестный вид", func(t *testing.T) {
+		svc, _, _, _ := setupReferenceService(t, models.SystemPermissionReferences)
+		_, err := svc.CreateDocumentType("Справка", []string{"memo"})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неизвестный вид документа: memo")
+	})
+
+	t.Run("успех с аудитом", func(t *testing.T) {
+		svc, repo, _, _ := setupReferenceService(t, models.SystemPermissionReferences)
+		id := uuid.New()
+		kinds := []models.DocumentKind{models.DocumentKindIncomingLetter, models.DocumentKindOutgoingLetter}
+		repo.On("CreateDocumentType", "Справка", kinds).
+			Return(&models.DocumentType{ID: id, Name: "Справка", Kinds: kinds, IsActive: true}, nil).Once()
+
+		result, err := svc.CreateDocumentType(" Справка ", []string{"incoming", "outgoing_letter", "incoming_letter"})
+		require.NoError(t, err)
+		assert.Equal(t, id.String(), result.ID)
+		atomicRepo := svc.repo.(*atomicReferenceStore)
+		require.Len(t, atomicRepo.effects, 1)
+		assert.Contains(t, atomicRepo.effects[0].Payload, "DOCTYPE_CREATE")
+	})
 }
 
 func TestReferenceService_UpdateDocumentType(t *testing.T) {
-	// Типы документов заданы в коде и не редактируются через сервис.
-	t.Run("запрещено", func(t *testing.T) {
-		svc, _, _, _ := setupReferenceService(t, "clerk")
-		err := svc.UpdateDocumentType("Письмо", "Test")
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "не редактируются")
+	t.Run("невалидный ID", func(t *testing.T) {
+		svc, _, _, _ := setupReferenceService(t, models.SystemPermissionReferences)
+		_, err := svc.UpdateDocumentType("Письмо", "Письмо", []string{"incoming_letter"}, true)
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный ID записи справочника")
+	})
+
+	t.Run("деактивация", func(t *testing.T) {
+		svc, repo, _, _ := setupReferenceService(t, models.SystemPermissionReferences)
+		id := uuid.New()
+		kinds := []models.DocumentKind{models.DocumentKindIncomingLetter}
+		repo.On("UpdateDocumentType", id, "Телеграмма", kinds, false).
+			Return(&models.DocumentType{ID: id, Name: "Телеграмма", Kinds: kinds, UsageCount: 4}, nil).Once()
+
+		result, err := svc.UpdateDocumentType(id.String(), "Телеграмма", []string{"incoming_letter"}, false)
+		require.NoError(t, err)
+		assert.False(t, result.IsActive)
+		assert.Equal(t, 4, result.UsageCount)
+		atomicRepo := svc.repo.(*atomicReferenceStore)
+		require.Len(t, atomicRepo.effects, 1)
+		assert.Contains(t, atomicRepo.effects[0].Payload, "DOCTYPE_UPDATE")
 	})
 }
 
 func TestReferenceService_DeleteDocumentType(t *testing.T) {
-	// Типы документов заданы в коде и не редактируются через сервис.
-	t.Run("запрещено", func(t *testing.T) {
+	t.Run("запрещено (не админ)", func(t *testing.T) {
 		svc, _, _, _ := setupReferenceService(t, "clerk")
-		err := svc.DeleteDocumentType("Письмо")
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "не редактируются")
+		err := svc.DeleteDocumentType(uuid.New().String())
+		assert.Equal(t, models.ErrForbidden, err)
+	})
+
+	t.Run("используемый тип", func(t *testing.T) {
+		svc, repo, _, _ := setupReferenceService(t, models.SystemPermissionReferences)
+		id := uuid.New()
+		repo.On("DeleteDocumentType", id).
+			Return(models.NewConflict("тип документа уже используется, его можно только деактивировать")).Once()
+
+		err := svc.DeleteDocumentType(id.String())
+		requireAppError(t, err, "CONFLICT", 409, "тип документа уже используется, его можно только деактивировать")
 	})
 }
 
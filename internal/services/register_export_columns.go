@@ -0,0 +1,177 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// registerColumn описывает колонку журнала регистрации в выгрузке.
+type registerColumn struct {
+	title string
+	value func(*dto.DocumentListItem) string
+}
+
+// registerColumnsFor возвращает набор колонок журнала для вида документа
+// или nil, если выгрузка вида не поддерживается.
+func registerColumnsFor(kind models.DocumentKind) []registerColumn {
+	switch kind {
+	case models.DocumentKindIncomingLetter:
+		return []registerColumn{
+			{"Рег. номер", func(d *dto.DocumentListItem) string { return d.IncomingNumber }},
+			{"Дата регистрации", func(d *dto.DocumentListItem) string { return registerDate(d.IncomingDate) }},
+			{"Корреспондент", func(d *dto.DocumentListItem) string { return registerCorrespondentNames(d.Correspondents) }},
+			{"Исх. номер и дата корреспондента", func(d *dto.DocumentListItem) string { return registerCorrespondentNumbers(d.Correspondents) }},
+			{"Тип документа", func(d *dto.DocumentListItem) string { return d.DocumentTypeName }},
+			{"Краткое содержание", func(d *dto.DocumentListItem) string { return d.Content }},
+			{"Листов", func(d *dto.DocumentListItem) string { return strconv.Itoa(d.PagesCount) }},
+			{"Подписант", func(d *dto.DocumentListItem) string { return d.SenderSignatory }},
+			{"Резолюция", func(d *dto.DocumentListItem) string { return registerOptional(d.Resolution) }},
+			{"Автор резолюции", func(d *dto.DocumentListItem) string { return registerOptional(d.ResolutionAuthor) }},
+			{"Исполнители", func(d *dto.DocumentListItem) string { return registerOptional(d.ResolutionExecutors) }},
+			{"Дело", func(d *dto.DocumentListItem) string { return d.NomenclatureName }},
+			{"Зарегистрировал", func(d *dto.DocumentListItem) string { return d.CreatedByName }},
+		}
+	case models.DocumentKindOutgoingLetter:
+		return []registerColumn{
+			{"Рег. номер", func(d *dto.DocumentListItem) string { return d.OutgoingNumber }},
+			{"Дата регистрации", func(d *dto.DocumentListItem) string { return registerDate(d.OutgoingDate) }},
+			{"Получатель", func(d *dto.DocumentListItem) string { return d.RecipientOrgName }},
+			{"Адресат", func(d *dto.DocumentListItem) string { return d.Addressee }},
+			{"Тип документа", func(d *dto.DocumentListItem) string { return d.DocumentTypeName }},
+			{"Краткое содержание", func(d *dto.DocumentListItem) string { return d.Content }},
+			{"Листов", func(d *dto.DocumentListItem) string { return strconv.Itoa(d.PagesCount) }},
+			{"Подписал", func(d *dto.DocumentListItem) string { return d.SenderSignatory }},
+			{"Исполнитель", func(d *dto.DocumentListItem) string { return d.SenderExecutor }},
+			{"Дело", func(d *dto.DocumentListItem) string { return d.NomenclatureName }},
+			{"Зарегистрировал", func(d *dto.DocumentListItem) string { return d.CreatedByName }},
+		}
+	case models.DocumentKindCitizenAppeal:
+		return []registerColumn{
+			{"Рег. номер", func(d *dto.DocumentListItem) string { return d.RegistrationNumber }},
+			{"Дата регистрации", func(d *dto.DocumentListItem) string { return registerDate(&d.RegistrationDate) }},
+			{"Дата обращения", func(d *dto.DocumentListItem) string { return registerDate(d.AppealDate) }},
+			{"Заявитель", func(d *dto.DocumentListItem) string { return d.ApplicantFullName }},
+			{"Адрес", func(d *dto.DocumentListItem) string { return d.RegistrationAddress }},
+			{"Вид обращения", func(d *dto.DocumentListItem) string { return d.AppealType }},
+			{"Категория заявителя", func(d *dto.DocumentListItem) string { return d.ApplicantCategory }},
+			{"Поступило через", func(d *dto.DocumentListItem) string { return registerCorrespondentNames(d.Correspondents) }},
+			{"Краткое содержание", func(d *dto.DocumentListItem) string { return d.Content }},
+			{"Листов обращения", func(d *dto.DocumentListItem) string { return strconv.Itoa(d.AppealPagesCount) }},
+			{"Листов приложений", func(d *dto.DocumentListItem) string { return strconv.Itoa(d.AttachmentPagesCount) }},
+			{"Резолюция", func(d *dto.DocumentListItem) string { return registerOptional(d.Resolution) }},
+			{"Исполнители", func(d *dto.DocumentListItem) string { return registerOptional(d.ResolutionExecutors) }},
+			{"Срок ответа", func(d *dto.DocumentListItem) string { return registerDate(d.ResponseDueDate) }},
+			{"Состояние срока", func(d *dto.DocumentListItem) string { return registerDeadlineStatus(d.DeadlineStatus) }},
+			{"Ответ", func(d *dto.DocumentListItem) string {
+				if d.ClosedAt == nil {
+					return ""
+				}
+				return strings.TrimSpace(d.ClosedByDocumentNumber + " от " + registerDate(d.ClosedAt))
+			}},
+			{"Дело", func(d *dto.DocumentListItem) string { return d.NomenclatureName }},
+		}
+	case models.DocumentKindAdministrativeOrder:
+		return []registerColumn{
+			{"Номер приказа", func(d *dto.DocumentListItem) string { return d.OrderNumber }},
+			{"Дата приказа", func(d *dto.DocumentListItem) string { return registerDate(d.OrderDate) }},
+			{"Заголовок", func(d *dto.DocumentListItem) string { return d.Title }},
+			{"Контроль исполнения", func(d *dto.DocumentListItem) string { return d.ExecutionController }},
+			{"Срок исполнения", func(d *dto.DocumentListItem) string { return registerDate(d.ExecutionDeadline) }},
+			{"Статус", func(d *dto.DocumentListItem) string {
+				if d.IsActive {
+					return "Действует"
+				}
+				return "Отменен " + registerDate(d.CancelledAt)
+			}},
+			{"Не ознакомлены", func(d *dto.DocumentListItem) string { return strconv.Itoa(d.PendingAcknowledgmentsCount) }},
+			{"Дело", func(d *dto.DocumentListItem) string { return d.NomenclatureName }},
+			{"Зарегистрировал", func(d *dto.DocumentListItem) string { return d.CreatedByName }},
+		}
+	default:
+		if spec, ok := models.GetDocumentKindSpec(kind); ok && spec.IsCustom {
+			return []registerColumn{
+				{"Рег. номер", func(d *dto.DocumentListItem) string { return d.RegistrationNumber }},
+				{"Дата регистрации", func(d *dto.DocumentListItem) string { return registerDate(&d.RegistrationDate) }},
+				{"Тип документа", func(d *dto.DocumentListItem) string { return d.DocumentTypeName }},
+				{"Краткое содержание", func(d *dto.DocumentListItem) string { return d.Content }},
+				{"Реквизиты", func(d *dto.DocumentListItem) string { return registerCustomFields(d.CustomFields) }},
+				{"Листов", func(d *dto.DocumentListItem) string { return strconv.Itoa(d.PagesCount) }},
+				{"Дело", func(d *dto.DocumentListItem) string { return d.NomenclatureName }},
+				{"Зарегистрировал", func(d *dto.DocumentListItem) string { return d.CreatedByName }},
+			}
+		}
+		return nil
+	}
+}
+
+func registerDate(value *time.Time) string {
+	if value == nil || value.IsZero() {
+		return ""
+	}
+	return value.Format("02.01.2006")
+}
+
+// registerCustomFields собирает заполненные поля пользовательского вида в одну ячейку.
+func registerCustomFields(items []dto.CustomDocumentFieldValue) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		value := item.DisplayValue
+		if value == "" {
+			value = item.Value
+		}
+		if value != "" {
+			parts = append(parts, item.Label+": "+value)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func registerOptional(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func registerCorrespondentNames(items []dto.DocumentCorrespondentRegistration) string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.CorrespondentName != "" {
+			names = append(names, item.CorrespondentName)
+		}
+	}
+	return strings.Join(names, "; ")
+}
+
+func registerCorrespondentNumbers(items []dto.DocumentCorrespondentRegistration) string {
+	numbers := make([]string, 0, len(items))
+	for _, item := range items {
+		number := item.RegistrationNumber
+		if date := registerDate(&item.RegistrationDate); date != "" {
+			number = strings.TrimSpace(number + " от " + date)
+		}
+		if number != "" {
+			numbers = append(numbers, number)
+		}
+	}
+	return strings.Join(numbers, "; ")
+}
+
+func registerDeadlineStatus(status string) string {
+	switch status {
+	case models.CitizenAppealDeadlineOpen:
+		return "В работе"
+	case models.CitizenAppealDeadlineDueSoon:
+		return "Подходит срок"
+	case models.CitizenAppealDeadlineOverdue:
+		return "Просрочено"
+	case models.CitizenAppealDeadlineClosed:
+		return "Закрыто"
+	default:
+		return status
+	}
+}
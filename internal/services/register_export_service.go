@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/export"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/observability"
+)
+
+// registerExportPageSize совпадает с максимальным размером страницы репозиториев.
+const registerExportPageSize = 100
+
+// RegisterExportService выгружает журналы регистрации документов в табличные форматы.
+// Список читается курсорными страницами через DocumentQueryService, поэтому к выгрузке
+// применяются тот же фильтр и та же серверная область доступа, что и к экранному списку.
+type RegisterExportService struct {
+	queries *DocumentQueryService
+	auth    *AuthService
+	audit   *AdminAuditLogService
+	metrics *observability.Registry
+}
+
+// NewRegisterExportService создает сервис выгрузки журналов регистрации.
+func NewRegisterExportService(queries *DocumentQueryService, auth *AuthService, audit *AdminAuditLogService) *RegisterExportService {
+	return &RegisterExportService{
+		queries: queries,
+		auth:    auth,
+		audit:   audit,
+	}
+}
+
+func (s *RegisterExportService) SetOperationMetrics(metrics *observability.Registry) {
+	s.metrics = metrics
+}
+
+// ExportToDisk сохраняет журнал регистрации в папку «Загрузки» и возвращает путь к файлу.
+func (s *RegisterExportService) ExportToDisk(kindCode, format string, filter models.DocumentFilter) (string, error) {
+	return measureOperation(s.metrics, "documents.export", func() (string, error) {
+		kind, exportFormat, err := s.prepare(kindCode, format)
+		if err != nil {
+			return "", err
+		}
+
+		downloadDir, err := userDownloadDir()
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(downloadDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create download directory: %v", err)
+		}
+
+		var rows int
+		path, err := writeDownloadFileFromStorage(downloadDir, registerExportFilename(kind, exportFormat, time.Now()), func(file *os.File) error {
+			var writeErr error
+			rows, writeErr = s.write(file, kind, exportFormat, filter)
+			return writeErr
+		})
+		if err != nil {
+			return "", err
+		}
+		s.logExport(kind, exportFormat, filter, rows)
+		return path, nil
+	})
+}
+
+// WriteRegister записывает журнал регистрации в w и возвращает количество выгруженных документов.
+func (s *RegisterExportService) WriteRegister(w io.Writer, kindCode, format string, filter models.DocumentFilter) (int, error) {
+	return measureOperation(s.metrics, "documents.export", func() (int, error) {
+		kind, exportFormat, err := s.prepare(kindCode, format)
+		if err != nil {
+			return 0, err
+		}
+		rows, err := s.write(w, kind, exportFormat, filter)
+		if err != nil {
+			return rows, err
+		}
+		s.logExport(kind, exportFormat, filter, rows)
+		return rows, nil
+	})
+}
+
+func (s *RegisterExportService) prepare(kindCode, format string) (models.DocumentKind, export.Format, error) {
+	if err := s.auth.RequireAuthenticated(); err != nil {
+		return "", "", err
+	}
+	exportFormat, err := export.ParseFormat(format)
+	if err != nil {
+		return "", "", models.NewBadRequestWrapped("неподдерживаемый формат выгрузки", err)
+	}
+	kind := models.DocumentKind(kindCode)
+	if registerColumnsFor(kind) == nil {
+		return "", "", models.NewBadRequest("неподдерживаемый вид документа")
+	}
+	return kind, exportFormat, nil
+}
+
+func (s *RegisterExportService) write(w io.Writer, kind models.DocumentKind, format export.Format, filter models.DocumentFilter) (int, error) {
+	columns := registerColumnsFor(kind)
+	table, err := export.NewTableWriter(format, w, kind.Label())
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]string, len(columns)+1)
+	header[0] = "№ п/п"
+	for i, column := range columns {
+		header[i+1] = column.title
+	}
+	if err := table.WriteRow(header); err != nil {
+		return 0, err
+	}
+
+	filter.Page = 1
+	filter.PageSize = registerExportPageSize
+	filter.CursorPagination = true
+	filter.Cursor = ""
+
+	rows := 0
+	cells := make([]string, len(columns)+1)
+	for {
+		page, err := s.queries.GetList(string(kind), filter)
+		if err != nil {
+			return rows, err
+		}
+		for i := range page.Items {
+			rows++
+			cells[0] = strconv.Itoa(rows)
+			for j, column := range columns {
+				cells[j+1] = column.value(&page.Items[i])
+			}
+			if err := table.WriteRow(cells); err != nil {
+				return rows, err
+			}
+		}
+		if !page.HasMore || page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	if err := table.Close(); err != nil {
+		return rows, err
+	}
+	if s.metrics != nil {
+		s.metrics.AddCounter("documents.export.rows", float64(rows))
+	}
+	return rows, nil
+}
+
+func (s *RegisterExportService) logExport(kind models.DocumentKind, format export.Format, filter models.DocumentFilter, rows int) {
+	userID, userName := s.auth.GetCurrentAuditInfo()
+	details := fmt.Sprintf("Выгружен журнал регистрации «%s» в формате %s: %d док.", kind.Label(), strings.ToUpper(string(format)), rows)
+	if period := registerExportPeriod(filter); period != "" {
+		details += "; период: " + period
+	}
+	s.audit.LogAction(userID, userName, "REGISTER_EXPORT", details)
+}
+
+func registerExportPeriod(filter models.DocumentFilter) string {
+	switch {
+	case filter.DateFrom != "" && filter.DateTo != "":
+		return filter.DateFrom + " — " + filter.DateTo
+	case filter.DateFrom != "":
+		return "с " + filter.DateFrom
+	case filter.DateTo != "":
+		return "по " + filter.DateTo
+	default:
+		return ""
+	}
+}
+
+func registerExportFilename(kind models.DocumentKind, format export.Format, now time.Time) string {
+	return fmt.Sprintf("Журнал регистрации - %s - %s.%s", kind.Label(), now.Format("2006-01-02 15-04"), format.Extension())
+}
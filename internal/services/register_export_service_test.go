@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// pagedDocumentKindQueryHandler отдает заранее подготовленные страницы по курсору.
+type pagedDocumentKindQueryHandler struct {
+	kind    models.DocumentKind
+	pages   map[string]*dto.PagedResult[dto.DocumentListItem]
+	filters []models.DocumentFilter
+}
+
+func (h *pagedDocumentKindQueryHandler) Kind() models.DocumentKind {
+	return h.kind
+}
+
+func (h *pagedDocumentKindQueryHandler) GetCard(id uuid.UUID) (*dto.DocumentCard, error) {
+	return nil, nil
+}
+
+func (h *pagedDocumentKindQueryHandler) GetList(filter models.DocumentFilter) (*dto.PagedResult[dto.DocumentListItem], error) {
+	h.filters = append(h.filters, filter)
+	return h.pages[filter.Cursor], nil
+}
+
+func setupRegisterExportService(t *testing.T, handler DocumentKindQueryHandler, allowed map[models.DocumentKind]map[string]bool) (*RegisterExportService, *captureAdminAuditLogStore, *documentAccessTestDeps) {
+	t.Helper()
+	deps := setupDocumentAccessService(t, documentAccessUser(false, nil), allowed)
+	auditRepo := &captureAdminAuditLogStore{}
+	queries := NewDocumentQueryService(NewDocumentKindQueryRegistry(handler), deps.service)
+	return NewRegisterExportService(queries, deps.auth, NewAdminAuditLogService(auditRepo, deps.auth)), auditRepo, deps
+}
+
+func TestRegisterExportService_WriteRegister(t *testing.T) {
+	t.Run("pages through cursor and writes csv", func(t *testing.T) {
+		date := time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC)
+		handler := &pagedDocumentKindQueryHandler{
+			kind: models.DocumentKindOutgoingLetter,
+			pages: map[string]*dto.PagedResult[dto.DocumentListItem]{
+				"": {
+					Items:      []dto.DocumentListItem{{OutgoingNumber: "И-1", OutgoingDate: &date, RecipientOrgName: "ООО «Ромашка»", PagesCount: 2}},
+					HasMore:    true,
+					NextCursor: "page-2",
+				},
+				"page-2": {
+					Items: []dto.DocumentListItem{{OutgoingNumber: "И-2", OutgoingDate: &date, Content: "Ответ; повторный"}},
+				},
+			},
+		}
+		svc, auditRepo, _ := setupRegisterExportService(t, handler, allowDocumentActions(models.DocumentKindOutgoingLetter, "read"))
+
+		var buf bytes.Buffer
+		rows, err := svc.WriteRegister(&buf, string(models.DocumentKindOutgoingLetter), "csv", models.DocumentFilter{
+			DateFrom: "2026-01-01",
+			DateTo:   "2026-12-31",
+			Page:     5,
+			PageSize: 10,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, rows)
+		require.Len(t, handler.filters, 2)
+		for _, filter := range handler.filters {
+			assert.True(t, filter.CursorPagination)
+			assert.Equal(t, registerExportPageSize, filter.PageSize)
+			assert.Equal(t, "2026-01-01", filter.DateFrom)
+			assert.NotNil(t, filter.AccessScope)
+		}
+		assert.Equal(t, "page-2", handler.filters[1].Cursor)
+		require.Len(t, auditRepo.requests, 1)
+		assert.Equal(t, "REGISTER_EXPORT", auditRepo.requests[0].Action)
+		assert.Contains(t, auditRepo.requests[0].Details, "2 док.")
+		assert.Contains(t, auditRepo.requests[0].Details, "2026-01-01 — 2026-12-31")
+
+		lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(buf.String(), "\ufeff"), "\r\n"), "\r\n")
+		require.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[0], "№ п/п;Рег. номер;Дата регистрации;Получатель"))
+		assert.True(t, strings.HasPrefix(lines[1], "1;И-1;03.02.2026;ООО «Ромашка»"))
+		assert.Contains(t, lines[2], `"Ответ; повторный"`)
+	})
+
+	t.Run("rejects unsupported format", func(t *testing.T) {
+		handler := &pagedDocumentKindQueryHandler{kind: models.DocumentKindIncomingLetter}
+		svc, _, _ := setupRegisterExportService(t, handler, allowDocumentActions(models.DocumentKindIncomingLetter, "read"))
+
+		_, err := svc.WriteRegister(&bytes.Buffer{}, string(models.DocumentKindIncomingLetter), "pdf", models.DocumentFilter{})
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неподдерживаемый формат выгрузки")
+		assert.Empty(t, handler.filters)
+	})
+
+	t.Run("rejects unsupported kind", func(t *testing.T) {
+		handler := &pagedDocumentKindQueryHandler{kind: models.DocumentKindIncomingLetter}
+		svc, _, _ := setupRegisterExportService(t, handler, allowDocumentActions(models.DocumentKindIncomingLetter, "read"))
+
+		_, err := svc.WriteRegister(&bytes.Buffer{}, "unknown", "xlsx", models.DocumentFilter{})
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неподдерживаемый вид документа")
+	})
+
+	t.Run("returns forbidden without read access", func(t *testing.T) {
+		handler := &pagedDocumentKindQueryHandler{kind: models.DocumentKindIncomingLetter}
+		svc, auditRepo, _ := setupRegisterExportService(t, handler, nil)
+
+		_, err := svc.WriteRegister(&bytes.Buffer{}, string(models.DocumentKindIncomingLetter), "xlsx", models.DocumentFilter{})
+
+		require.ErrorIs(t, err, models.ErrForbidden)
+		assert.Empty(t, handler.filters)
+		assert.Empty(t, auditRepo.requests)
+	})
+}
+
+func TestRegisterColumnsFor(t *testing.T) {
+	for _, kind := range []models.DocumentKind{
+		models.DocumentKindIncomingLetter,
+		models.DocumentKindOutgoingLetter,
+		models.DocumentKindCitizenAppeal,
+		models.DocumentKindAdministrativeOrder,
+	} {
+		columns := registerColumnsFor(kind)
+		require.NotEmpty(t, columns, kind)
+		item := &dto.DocumentListItem{}
+		for _, column := range columns {
+			assert.NotEmpty(t, column.title)
+			assert.NotPanics(t, func() { column.value(item) })
+		}
+	}
+	assert.Nil(t, registerColumnsFor("unknown"))
+	assert.Equal(t, "Просрочено", registerDeadlineStatus(models.CitizenAppealDeadlineOverdue))
+}
+
+func TestRegisterColumnsForCustomKind(t *testing.T) {
+	assert.Nil(t, registerColumnsFor("contract"))
+
+	models.SetCustomDocumentKindSpecs([]models.DocumentKindSpec{models.NewCustomDocumentKindSpec("contract", "Договор", true)})
+	t.Cleanup(func() { models.SetCustomDocumentKindSpecs(nil) })
+
+	columns := registerColumnsFor("contract")
+	require.NotEmpty(t, columns)
+	item := &dto.DocumentListItem{CustomFields: []dto.CustomDocumentFieldValue{
+		{Label: "Контрагент", Value: "id", DisplayValue: "ООО Ромашка"},
+		{Label: "Сумма", Value: "1500"},
+		{Label: "Пустое"},
+	}}
+	var fields string
+	for _, column := range columns {
+		if column.title == "Реквизиты" {
+			fields = column.value(item)
+		}
+	}
+	assert.Equal(t, "Контрагент: ООО Ромашка; Сумма: 1500", fields)
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// reminderSchedulerInterval — период проверки сроков поручений и ознакомлений.
+const reminderSchedulerInterval = time.Hour
+
+// reminderKindLabels — названия видов напоминаний в настройках пользователя.
+var reminderKindLabels = map[string]string{
+	models.ReminderKindAssignmentDeadline:   "Приближение срока поручения",
+	models.ReminderKindAssignmentOverdue:    "Просрочка поручения",
+	models.ReminderKindAssignmentEscalation: "Эскалация просроченных поручений",
+	models.ReminderKindAcknowledgment:       "Неподтвержденное ознакомление",
+}
+
+// ReminderService по расписанию напоминает исполнителям о сроках поручений,
+// эскалирует просрочку автору поручения и руководителю подразделения и
+// напоминает о неподтвержденных ознакомлениях.
+//
+// Напоминания ставятся в outbox с ключом, который определяется поручением,
+// его сроком, правилом и получателем. Ключ обработанного события остается в
+// outbox, поэтому повторный проход и перезапуск приложения не повторяют уже
+// отправленное напоминание, а пропущенное, пока приложение было закрыто,
+// отправляется при следующем проходе.
+type ReminderService struct {
+	repo     ReminderStore
+	settings SettingsStore
+	auth     *AuthService
+	now      func() time.Time
+}
+
+// NewReminderService создает сервис напоминаний.
+func NewReminderService(repo ReminderStore, settings SettingsStore, auth *AuthService) *ReminderService {
+	return &ReminderService{repo: repo, settings: settings, auth: auth, now: time.Now}
+}
+
+// RunScheduler периодически ставит в outbox напоминания и эскалации. Метод
+// блокируется до отмены ctx.
+func (s *ReminderService) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(reminderSchedulerInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.scheduleReminders(); err != nil && ctx.Err() == nil {
+			slog.Warn("reminder scheduling failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReminderService) scheduleReminders() error {
+	rules := s.reminderRules()
+	today := reminderDate(s.now())
+	mutes, err := s.repo.GetReminderMutes()
+	if err != nil {
+		return err
+	}
+
+	until := today
+	if len(rules.AssignmentDaysBefore) > 0 {
+		until = today.AddDate(0, 0, rules.AssignmentDaysBefore[0])
+	}
+	assignments, err := s.repo.GetAssignmentReminderCandidates(until)
+	if err != nil {
+		return err
+	}
+	effects := make([]models.OutboxEvent, 0)
+	for i := range assignments {
+		items, err := assignmentReminderEffects(&assignments[i], rules, today, mutes)
+		if err != nil {
+			return err
+		}
+		effects = append(effects, items...)
+	}
+
+	if rules.AcknowledgmentEvery > 0 {
+		acknowledgments, err := s.repo.GetPendingAcknowledgmentReminders()
+		if err != nil {
+			return err
+		}
+		for i := range acknowledgments {
+			event, ok, err := acknowledgmentReminderEffect(&acknowledgments[i], rules, today, mutes)
+			if err != nil {
+				return err
+			}
+			if ok {
+				effects = append(effects, event)
+			}
+		}
+	}
+
+	if len(effects) == 0 {
+		return nil
+	}
+	added, err := s.repo.EnqueueReminders(effects)
+	if err != nil {
+		return err
+	}
+	if added > 0 {
+		slog.Info("reminders scheduled", "count", added)
+	}
+	return nil
+}
+
+// reminderRules читает правила из системных настроек. Неверное или
+// отсутствующее значение заменяется значением по умолчанию.
+func (s *ReminderService) reminderRules() models.ReminderRules {
+	rules := models.DefaultReminderRules()
+	if value, ok := s.reminderSetting(models.SettingReminderAssignmentDaysBefore); ok {
+		if days, err := models.ParseReminderDaysBefore(value); err == nil {
+			rules.AssignmentDaysBefore = days
+		}
+	}
+	if value, ok := s.reminderSetting(models.SettingReminderAssignmentOnDeadline); ok {
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			rules.AssignmentOnDeadline = enabled
+		}
+	}
+	rules.AssignmentOverdueEvery = s.reminderDaysSetting(models.SettingReminderAssignmentOverdueEvery, rules.AssignmentOverdueEvery)
+	rules.AssignmentEscalationDays = s.reminderDaysSetting(models.SettingReminderAssignmentEscalation, rules.AssignmentEscalationDays)
+	rules.AcknowledgmentEvery = s.reminderDaysSetting(models.SettingReminderAcknowledgmentEvery, rules.AcknowledgmentEvery)
+	return rules
+}
+
+func (s *ReminderService) reminderSetting(key string) (string, bool) {
+	if s.settings == nil {
+		return "", false
+	}
+	setting, err := s.settings.Get(key)
+	if err != nil || setting == nil {
+		return "", false
+	}
+	return strings.TrimSpace(setting.Value), true
+}
+
+func (s *ReminderService) reminderDaysSetting(key string, fallback int) int {
+	value, ok := s.reminderSetting(key)
+	if !ok {
+		return fallback
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 || days > models.MaxReminderDays {
+		return fallback
+	}
+	return days
+}
+
+// reminderDate отбрасывает время: сроки поручений хранятся как даты.
+func reminderDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func reminderDaysBetween(from, to time.Time) int {
+	return int(reminderDate(to).Sub(reminderDate(from)).Hours() / 24)
+}
+
+// assignmentReminderRule выбирает правило, по которому поручению положено
+// напоминание в день today. Перед сроком выбирается ближайший порог, не
+// меньший оставшихся дней: если приложение было закрыто в день порога,
+// напоминание по нему уйдет позже, но не будет повторено следующим порогом
+// того же интервала.
+func assignmentReminderRule(rules models.ReminderRules, daysLeft int) (rule, kind string) {
+	switch {
+	case daysLeft > 0:
+		threshold := 0
+		for _, days := range rules.AssignmentDaysBefore {
+			if days >= daysLeft {
+				threshold = days
+			}
+		}
+		if threshold == 0 {
+			return "", ""
+		}
+		return "before:" + strconv.Itoa(threshold), models.ReminderKindAssignmentDeadline
+	case daysLeft == 0:
+		if !rules.AssignmentOnDeadline {
+			return "", ""
+		}
+		return "due", models.ReminderKindAssignmentDeadline
+	default:
+		if rules.AssignmentOverdueEvery < 1 {
+			return "", ""
+		}
+		period := (-daysLeft - 1) / rules.AssignmentOverdueEvery
+		return "overdue:" + strconv.Itoa(period), models.ReminderKindAssignmentOverdue
+	}
+}
+
+// assignmentReminderEffects готовит напоминания исполнителю и соисполнителям
+// и, при длительной просрочке, эскалацию автору поручения и руководителю
+// подразделения исполнителя.
+func assignmentReminderEffects(
+	item *models.AssignmentReminderCandidate,
+	rules models.ReminderRules,
+	today time.Time,
+	mutes map[uuid.UUID]map[string]bool,
+) ([]models.OutboxEvent, error) {
+	daysLeft := reminderDaysBetween(today, item.Deadline)
+	deadline := item.Deadline.Format("02.01.2006")
+	number := documentNumberLabel(item.DocumentNumber)
+	effects := make([]models.OutboxEvent, 0)
+
+	if rule, kind := assignmentReminderRule(rules, daysLeft); rule != "" {
+		eventType := models.UserEventAssignmentReminder
+		title := "Приближается срок поручения"
+		message := fmt.Sprintf("До срока поручения по документу № %s осталось дней: %d (срок %s)", number, daysLeft, deadline)
+		switch {
+		case daysLeft == 0:
+			title = "Срок поручения истекает сегодня"
+			message = fmt.Sprintf("Срок поручения по документу № %s истекает сегодня, %s", number, deadline)
+		case daysLeft < 0:
+			eventType = models.UserEventAssignmentOverdue
+			title = "Поручение просрочено"
+			message = fmt.Sprintf("Поручение по документу № %s просрочено на дней: %d (срок %s)", number, -daysLeft, deadline)
+		}
+		recipients := make([]uuid.UUID, 0, len(item.CoExecutorIDs)+1)
+		if item.ExecutorActive {
+			recipients = appendUniqueUserID(recipients, item.ExecutorID)
+		}
+		for _, id := range item.CoExecutorIDs {
+			recipients = appendUniqueUserID(recipients, id)
+		}
+		for _, recipientID := range recipients {
+			if mutes[recipientID][kind] {
+				continue
+			}
+			event, err := assignmentReminderEvent(item, rule, recipientID, eventType, title, message)
+			if err != nil {
+				return nil, err
+			}
+			effects = append(effects, event)
+		}
+	}
+
+	if daysLeft < 0 && rules.AssignmentEscalationDays > 0 && -daysLeft >= rules.AssignmentEscalationDays {
+		recipients := make([]uuid.UUID, 0, 2)
+		if item.CreatedBy != nil {
+			recipients = appendUniqueUserID(recipients, *item.CreatedBy)
+		}
+		if item.DepartmentHeadID != nil {
+			recipients = appendUniqueUserID(recipients, *item.DepartmentHeadID)
+		}
+		message := fmt.Sprintf("Поручение по документу № %s, исполнитель %s, просрочено на дней: %d (срок %s)", number, item.ExecutorName, -daysLeft, deadline)
+		for _, recipientID := range recipients {
+			if recipientID == item.ExecutorID || mutes[recipientID][models.ReminderKindAssignmentEscalation] {
+				continue
+			}
+			event, err := assignmentReminderEvent(item, "escalation", recipientID, models.UserEventAssignmentEscalated, "Эскалация просроченного поручения", message)
+			if err != nil {
+				return nil, err
+			}
+			effects = append(effects, event)
+		}
+	}
+	return effects, nil
+}
+
+func assignmentReminderEvent(item *models.AssignmentReminderCandidate, rule string, recipientID uuid.UUID, eventType, title, message string) (models.OutboxEvent, error) {
+	deadline := item.Deadline.Format("2006-01-02")
+	key := "reminder:assignment:" + item.ID.String() + ":" + deadline + ":" + rule + ":" + recipientID.String() + ":user_event"
+	return NewUserEventOutboxEvent(key, models.CreateUserEventRequest{
+		RecipientUserID: recipientID,
+		DocumentID:      item.DocumentID,
+		DocumentKind:    item.DocumentKind,
+		DocumentNumber:  item.DocumentNumber,
+		EntityType:      models.UserEventEntityAssignment,
+		EntityID:        item.ID,
+		EventType:       eventType,
+		Title:           title,
+		Message:         message,
+		Metadata: userEventMetadata(map[string]string{
+			"assignmentId": item.ID.String(),
+			"executorId":   item.ExecutorID.String(),
+			"deadline":     deadline,
+			"rule":         rule,
+		}),
+	})
+}
+
+// acknowledgmentReminderEffect готовит очередное напоминание об ознакомлении:
+// первое — через AcknowledgmentEvery дней после создания, затем с тем же шагом.
+func acknowledgmentReminderEffect(
+	item *models.AcknowledgmentReminderCandidate,
+	rules models.ReminderRules,
+	today time.Time,
+	mutes map[uuid.UUID]map[string]bool,
+) (models.OutboxEvent, bool, error) {
+	if rules.AcknowledgmentEvery < 1 || mutes[item.UserID][models.ReminderKindAcknowledgment] {
+		return models.OutboxEvent{}, false, nil
+	}
+	days := reminderDaysBetween(item.CreatedAt, today)
+	if days < rules.AcknowledgmentEvery {
+		return models.OutboxEvent{}, false, nil
+	}
+	step := strconv.Itoa(days / rules.AcknowledgmentEvery)
+	key := "reminder:acknowledgment:" + item.AcknowledgmentID.String() + ":" + step + ":" + item.UserID.String() + ":user_event"
+	event, err := NewUserEventOutboxEvent(key, models.CreateUserEventRequest{
+		RecipientUserID: item.UserID,
+		DocumentID:      item.DocumentID,
+		DocumentKind:    item.DocumentKind,
+		DocumentNumber:  item.DocumentNumber,
+		EntityType:      models.UserEventEntityAcknowledgment,
+		EntityID:        item.AcknowledgmentID,
+		EventType:       models.UserEventAcknowledgmentReminder,
+		Title:           "Ожидается ознакомление",
+		Message:         fmt.Sprintf("Ознакомление с документом № %s ожидает подтверждения дней: %d", documentNumberLabel(item.DocumentNumber), days),
+		Metadata: userEventMetadata(map[string]string{
+			"acknowledgmentId": item.AcknowledgmentID.String(),
+		}),
+	})
+	if err != nil {
+		return models.OutboxEvent{}, false, err
+	}
+	return event, true, nil
+}
+
+// GetReminderPreferences возвращает виды напоминаний текущего пользователя с
+// признаком отключения.
+func (s *ReminderService) GetReminderPreferences() ([]models.ReminderPreference, error) {
+	userID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return nil, err
+	}
+	mutes, err := s.repo.GetUserReminderMutes(userID)
+	if err != nil {
+		return nil, err
+	}
+	preferences := make([]models.ReminderPreference, 0, len(models.ReminderKinds))
+	for _, kind := range models.ReminderKinds {
+		preferences = append(preferences, models.ReminderPreference{Kind: kind, Label: reminderKindLabels[kind], Muted: mutes[kind]})
+	}
+	return preferences, nil
+}
+
+// SetReminderMuted отключает или снова включает вид напоминаний для текущего
+// пользователя. Уже поставленные в очередь напоминания не отзываются.
+func (s *ReminderService) SetReminderMuted(kind string, muted bool) error {
+	userID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return err
+	}
+	if !models.IsReminderKind(kind) {
+		return models.NewBadRequest("неизвестный вид напоминаний")
+	}
+	return s.repo.SetReminderMuted(userID, kind, muted)
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// reminderStoreStub хранит ключи поставленных напоминаний, как outbox:
+// повторный ключ не добавляет событие.
+type reminderStoreStub struct {
+	assignments     []models.AssignmentReminderCandidate
+	acknowledgments []models.AcknowledgmentReminderCandidate
+	mutes           map[uuid.UUID]map[string]bool
+	keys            map[string]bool
+	queued          []models.OutboxEvent
+	until           time.Time
+}
+
+func (s *reminderStoreStub) GetAssignmentReminderCandidates(until time.Time) ([]models.AssignmentReminderCandidate, error) {
+	s.until = until
+	items := make([]models.AssignmentReminderCandidate, 0)
+	for _, item := range s.assignments {
+		if !item.Deadline.After(until) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (s *reminderStoreStub) GetPendingAcknowledgmentReminders() ([]models.AcknowledgmentReminderCandidate, error) {
+	return s.acknowledgments, nil
+}
+
+func (s *reminderStoreStub) GetReminderMutes() (map[uuid.UUID]map[string]bool, error) {
+	return s.mutes, nil
+}
+
+func (s *reminderStoreStub) GetUserReminderMutes(userID uuid.UUID) (map[string]bool, error) {
+	if kinds, ok := s.mutes[userID]; ok {
+		return kinds, nil
+	}
+	return map[string]bool{}, nil
+}
+
+func (s *reminderStoreStub) SetReminderMuted(userID uuid.UUID, kind string, muted bool) error {
+	if s.mutes == nil {
+		s.mutes = make(map[uuid.UUID]map[string]bool)
+	}
+	if s.mutes[userID] == nil {
+		s.mutes[userID] = make(map[string]bool)
+	}
+	if muted {
+		s.mutes[userID][kind] = true
+	} else {
+		delete(s.mutes[userID], kind)
+	}
+	return nil
+}
+
+func (s *reminderStoreStub) EnqueueReminders(effects []models.OutboxEvent) (int, error) {
+	if s.keys == nil {
+		s.keys = make(map[string]bool)
+	}
+	added := 0
+	for _, effect := range effects {
+		if s.keys[effect.DeduplicationKey] {
+			continue
+		}
+		s.keys[effect.DeduplicationKey] = true
+		s.queued = append(s.queued, effect)
+		added++
+	}
+	return added, nil
+}
+
+func (s *reminderStoreStub) take() []models.OutboxEvent {
+	queued := s.queued
+	s.queued = nil
+	return queued
+}
+
+func setupReminderService(t *testing.T, today time.Time) (*ReminderService, *reminderStoreStub) {
+	t.Helper()
+	store := &reminderStoreStub{}
+	svc := NewReminderService(store, nil, nil)
+	svc.now = func() time.Time { return today }
+	return svc, store
+}
+
+func reminderDay(day int) time.Time {
+	return time.Date(2026, 7, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestAssignmentReminderRule(t *testing.T) {
+	rules := models.DefaultReminderRules()
+	tests := []struct {
+		daysLeft int
+		rule     string
+		kind     string
+	}{
+		{daysLeft: 5},
+		{daysLeft: 3, rule: "before:3", kind: models.ReminderKindAssignmentDeadline},
+		{daysLeft: 2, rule: "before:3", kind: models.ReminderKindAssignmentDeadline},
+		{daysLeft: 1, rule: "before:1", kind: models.ReminderKindAssignmentDeadline},
+		{daysLeft: 0, rule: "due", kind: models.ReminderKindAssignmentDeadline},
+		{daysLeft: -1, rule: "overdue:0", kind: models.ReminderKindAssignmentOverdue},
+		{daysLeft: -2, rule: "overdue:1", kind: models.ReminderKindAssignmentOverdue},
+	}
+	for _, tt := range tests {
+		rule, kind := assignmentReminderRule(rules, tt.daysLeft)
+		assert.Equal(t, tt.rule, rule, "days left %d", tt.daysLeft)
+		assert.Equal(t, tt.kind, kind, "days left %d", tt.daysLeft)
+	}
+
+	rules.AssignmentOverdueEvery = 3
+	rule, _ := assignmentReminderRule(rules, -3)
+	assert.Equal(t, "overdue:0", rule)
+	rule, _ = assignmentReminderRule(rules, -4)
+	assert.Equal(t, "overdue:1", rule)
+
+	rules.AssignmentOnDeadline = false
+	rules.AssignmentOverdueEvery = 0
+	rule, _ = assignmentReminderRule(rules, 0)
+	assert.Empty(t, rule)
+	rule, _ = assignmentReminderRule(rules, -5)
+	assert.Empty(t, rule)
+}
+
+func TestReminderService_ScheduleAssignmentRemindersIsIdempotent(t *testing.T) {
+	svc, store := setupReminderService(t, reminderDay(14))
+	executorID, coExecutorID := uuid.New(), uuid.New()
+	assignment := models.AssignmentReminderCandidate{
+		ID:             uuid.New(),
+		DocumentID:     uuid.New(),
+		DocumentKind:   "incoming_letter",
+		DocumentNumber: "ВХ-7",
+		ExecutorID:     executorID,
+		ExecutorActive: true,
+		CoExecutorIDs:  []uuid.UUID{coExecutorID},
+		Deadline:       time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC),
+	}
+	store.assignments = []models.AssignmentReminderCandidate{assignment}
+
+	require.NoError(t, svc.scheduleReminders())
+	assert.Equal(t, time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC), store.until)
+	effects := store.take()
+	assert.ElementsMatch(t, []uuid.UUID{executorID, coExecutorID}, userEventRecipients(t, effects, models.UserEventAssignmentReminder))
+	assert.Contains(t, effects[0].DeduplicationKey, ":2026-07-17:before:3:")
+
+	require.NoError(t, svc.scheduleReminders())
+	assert.Empty(t, store.take(), "повторный проход не должен повторять напоминания")
+
+	// День 15: тот же порог "за 3 дня" уже отправлен.
+	svc.now = func() time.Time { return reminderDay(15) }
+	require.NoError(t, svc.scheduleReminders())
+	assert.Empty(t, store.take())
+
+	svc.now = func() time.Time { return reminderDay(16) }
+	require.NoError(t, svc.scheduleReminders())
+	assert.Len(t, store.take(), 2)
+
+	svc.now = func() time.Time { return reminderDay(17) }
+	require.NoError(t, svc.scheduleReminders())
+	effects = store.take()
+	require.Len(t, effects, 2)
+	assert.Contains(t, effects[0].Payload, "истекает сегодня")
+
+	// Перенос срока начинает новую серию напоминаний.
+	store.assignments[0].Deadline = time.Date(2026, 7, 18, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, svc.scheduleReminders())
+	assert.Len(t, store.take(), 2)
+}
+
+func TestReminderService_EscalatesOverdueAssignment(t *testing.T) {
+	svc, store := setupReminderService(t, reminderDay(20))
+	executorID, creatorID, headID := uuid.New(), uuid.New(), uuid.New()
+	store.assignments = []models.AssignmentReminderCandidate{{
+		ID:               uuid.New(),
+		DocumentID:       uuid.New(),
+		DocumentKind:     "incoming_letter",
+		ExecutorID:       executorID,
+		ExecutorName:     "Петров П.П.",
+		ExecutorActive:   true,
+		Deadline:         time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC),
+		CreatedBy:        &creatorID,
+		DepartmentHeadID: &headID,
+	}}
+
+	require.NoError(t, svc.scheduleReminders())
+	effects := store.take()
+	assert.Equal(t, []uuid.UUID{executorID}, userEventRecipients(t, effects, models.UserEventAssignmentOverdue))
+	assert.ElementsMatch(t, []uuid.UUID{creatorID, headID}, userEventRecipients(t, effects, models.UserEventAssignmentEscalated))
+	for _, effect := range effects {
+		if strings.Contains(effect.DeduplicationKey, ":escalation:") {
+			assert.Contains(t, effect.Payload, "Петров П.П.")
+		}
+	}
+
+	// Эскалация отправляется один раз на срок, напоминание — каждый период.
+	svc.now = func() time.Time { return reminderDay(21) }
+	require.NoError(t, svc.scheduleReminders())
+	effects = store.take()
+	assert.Equal(t, []uuid.UUID{executorID}, userEventRecipients(t, effects, models.UserEventAssignmentOverdue))
+	assert.Empty(t, userEventRecipients(t, effects, models.UserEventAssignmentEscalated))
+}
+
+func TestReminderService_EscalationSkipsExecutorAndMutedRecipients(t *testing.T) {
+	svc, store := setupReminderService(t, reminderDay(25))
+	executorID, headID := uuid.New(), uuid.New()
+	store.assignments = []models.AssignmentReminderCandidate{{
+		ID:               uuid.New(),
+		DocumentID:       uuid.New(),
+		ExecutorID:       executorID,
+		ExecutorActive:   true,
+		Deadline:         time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC),
+		CreatedBy:        &executorID,
+		DepartmentHeadID: &headID,
+	}}
+	store.mutes = map[uuid.UUID]map[string]bool{
+		executorID: {models.ReminderKindAssignmentOverdue: true},
+		headID:     {models.ReminderKindAssignmentEscalation: true},
+	}
+
+	require.NoError(t, svc.scheduleReminders())
+	assert.Empty(t, store.take())
+}
+
+func TestReminderService_InactiveExecutorIsNotReminded(t *testing.T) {
+	svc, store := setupReminderService(t, reminderDay(17))
+	coExecutorID := uuid.New()
+	store.assignments = []models.AssignmentReminderCandidate{{
+		ID:            uuid.New(),
+		DocumentID:    uuid.New(),
+		ExecutorID:    uuid.New(),
+		CoExecutorIDs: []uuid.UUID{coExecutorID},
+		Deadline:      time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC),
+	}}
+
+	require.NoError(t, svc.scheduleReminders())
+	assert.Equal(t, []uuid.UUID{coExecutorID}, userEventRecipients(t, store.take(), models.UserEventAssignmentReminder))
+}
+
+func TestReminderService_AcknowledgmentReminders(t *testing.T) {
+	svc, store := setupReminderService(t, reminderDay(11))
+	userID, mutedID := uuid.New(), uuid.New()
+	ackID := uuid.New()
+	createdAt := time.Date(2026, 7, 10, 15, 0, 0, 0, time.UTC)
+	store.acknowledgments = []models.AcknowledgmentReminderCandidate{
+		{AcknowledgmentID: ackID, DocumentID: uuid.New(), UserID: userID, CreatedAt: createdAt},
+		{AcknowledgmentID: ackID, DocumentID: uuid.New(), UserID: mutedID, CreatedAt: createdAt},
+	}
+	store.mutes = map[uuid.UUID]map[string]bool{mutedID: {models.ReminderKindAcknowledgment: true}}
+
+	require.NoError(t, svc.scheduleReminders())
+	assert.Empty(t, store.take(), "первое напоминание — через два дня после создания")
+
+	svc.now = func() time.Time { return reminderDay(12) }
+	require.NoError(t, svc.scheduleReminders())
+	assert.Equal(t, []uuid.UUID{userID}, userEventRecipients(t, store.take(), models.UserEventAcknowledgmentReminder))
+
+	svc.now = func() time.Time { return reminderDay(13) }
+	require.NoError(t, svc.scheduleReminders())
+	assert.Empty(t, store.take())
+
+	svc.now = func() time.Time { return reminderDay(14) }
+	require.NoError(t, svc.scheduleReminders())
+	assert.Len(t, store.take(), 1)
+}
+
+func TestReminderService_ReadsRulesFromSettings(t *testing.T) {
+	svc, store := setupReminderService(t, reminderDay(10))
+	settings := mocks.NewSettingsStore(t)
+	settings.On("Get", models.SettingReminderAssignmentDaysBefore).Return(&models.SystemSetting{Value: "7, 2"}, nil)
+	settings.On("Get", models.SettingReminderAssignmentOnDeadline).Return(&models.SystemSetting{Value: "false"}, nil)
+	settings.On("Get", models.SettingReminderAcknowledgmentEvery).Return(&models.SystemSetting{Value: "0"}, nil)
+	settings.On("Get", mock.Anything).Return(&models.SystemSetting{Value: "invalid"}, nil)
+	svc.settings = settings
+
+	rules := svc.reminderRules()
+	assert.Equal(t, []int{7, 2}, rules.AssignmentDaysBefore)
+	assert.False(t, rules.AssignmentOnDeadline)
+	assert.Equal(t, 1, rules.AssignmentOverdueEvery)
+	assert.Equal(t, 3, rules.AssignmentEscalationDays)
+	assert.Equal(t, 0, rules.AcknowledgmentEvery)
+
+	store.acknowledgments = []models.AcknowledgmentReminderCandidate{{AcknowledgmentID: uuid.New(), UserID: uuid.New(), CreatedAt: reminderDay(1)}}
+	require.NoError(t, svc.scheduleReminders())
+	assert.Equal(t, time.Date(2026, 7, 17, 0, 0, 0, 0, time.UTC), store.until)
+	assert.Empty(t, store.take())
+}
+
+func TestReminderService_Preferences(t *testing.T) {
+	user := &models.User{ID: uuid.New(), IsActive: true}
+	userRepo := mocks.NewUserStore(t)
+	userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+	auth := NewAuthService(nil, userRepo)
+	auth.currentUserID = user.ID
+	store := &reminderStoreStub{}
+	svc := NewReminderService(store, nil, auth)
+
+	require.NoError(t, svc.SetReminderMuted(models.ReminderKindAssignmentOverdue, true))
+	preferences, err := svc.GetReminderPreferences()
+	require.NoError(t, err)
+	require.Len(t, preferences, len(models.ReminderKinds))
+	for _, preference := range preferences {
+		assert.NotEmpty(t, preference.Label)
+		assert.Equal(t, preference.Kind == models.ReminderKindAssignmentOverdue, preference.Muted)
+	}
+
+	require.NoError(t, svc.SetReminderMuted(models.ReminderKindAssignmentOverdue, false))
+	assert.Empty(t, store.mutes[user.ID])
+
+	requireAppError(t, svc.SetReminderMuted("weekly_digest", true), "VALIDATION_ERROR", 400, "вид напоминаний")
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/observability"
+)
+
+const (
+	searchDefaultLimit   = 20
+	searchMaxLimit       = 100
+	searchMinQueryLength = 2
+	searchMaxQueryLength = 200
+	// searchMaxBatches ограничивает число страниц индекса, просматриваемых за один вызов,
+	// когда большая часть совпадений недоступна пользователю.
+	searchMaxBatches = 10
+)
+
+// SearchService выполняет сквозной полнотекстовый поиск по документам всех видов.
+// Индекс не учитывает права доступа, поэтому каждая страница кандидатов проходит
+// через DocumentAccessService.ResolveReadableDocuments.
+type SearchService struct {
+	repo    DocumentSearchStore
+	auth    *AuthService
+	access  *DocumentAccessService
+	metrics *observability.Registry
+}
+
+// NewSearchService создает сервис полнотекстового поиска.
+func NewSearchService(repo DocumentSearchStore, auth *AuthService, access *DocumentAccessService) *SearchService {
+	return &SearchService{
+		repo:   repo,
+		auth:   auth,
+		access: access,
+	}
+}
+
+func (s *SearchService) SetOperationMetrics(metrics *observability.Registry) {
+	s.metrics = metrics
+}
+
+// Search возвращает найденные документы, упорядоченные по релевантности, с подсвеченными фрагментами.
+// Offset в filter — позиция в ранжированной выдаче индекса; для следующей страницы
+// передается NextOffset из предыдущего результата.
+func (s *SearchService) Search(query string, filter models.SearchFilter) (*dto.SearchResult, error) {
+	return measureOperation(s.metrics, "documents.search", func() (*dto.SearchResult, error) {
+		ctx, err := s.auth.sessionContext()
+		if err != nil {
+			return nil, err
+		}
+		return s.search(ctx, query, filter)
+	})
+}
+
+func (s *SearchService) search(ctx context.Context, query string, filter models.SearchFilter) (*dto.SearchResult, error) {
+	if _, err := requirePrincipal(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.access.RequireDomainRead(ctx); err != nil {
+		return nil, err
+	}
+	searchQuery, err := buildDocumentSearchQuery(query, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := searchQuery.Limit
+	batchSize := min(max(limit+1, searchDefaultLimit), searchMaxLimit)
+	searchQuery.Limit = batchSize
+
+	result := &dto.SearchResult{Items: make([]dto.SearchHit, 0, limit)}
+	for batch := 0; batch < searchMaxBatches; batch++ {
+		hits, err := s.repo.Search(searchQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]uuid.UUID, len(hits))
+		for i, hit := range hits {
+			ids[i] = hit.DocumentID
+		}
+		readable, err := s.access.ResolveReadableDocuments(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range hits {
+			if _, ok := readable[hits[i].DocumentID]; !ok {
+				continue
+			}
+			if len(result.Items) == limit {
+				result.NextOffset = searchQuery.Offset + i
+				result.HasMore = true
+				return result, nil
+			}
+			result.Items = append(result.Items, *dto.MapSearchHit(&hits[i]))
+		}
+
+		searchQuery.Offset += len(hits)
+		result.NextOffset = searchQuery.Offset
+		if len(hits) < batchSize {
+			return result, nil
+		}
+	}
+
+	// Лимит просмотра исчерпан: продолжение поиска возможно с NextOffset.
+	result.HasMore = true
+	return result, nil
+}
+
+func buildDocumentSearchQuery(query string, filter models.SearchFilter) (models.DocumentSearchQuery, error) {
+	text := strings.Join(strings.Fields(query), " ")
+	if utf8.RuneCountInString(text) < searchMinQueryLength {
+		return models.DocumentSearchQuery{}, models.NewBadRequest("поисковый запрос должен содержать не менее 2 символов")
+	}
+	if utf8.RuneCountInString(text) > searchMaxQueryLength {
+		return models.DocumentSearchQuery{}, models.NewBadRequest("поисковый запрос слишком длинный")
+	}
+	if !strings.ContainsFunc(text, isSearchTermRune) {
+		return models.DocumentSearchQuery{}, models.NewBadRequest("поисковый запрос должен содержать буквы или цифры")
+	}
+
+	result := models.DocumentSearchQuery{Text: text, Limit: filter.Limit, Offset: filter.Offset}
+	if result.Limit <= 0 {
+		result.Limit = searchDefaultLimit
+	}
+	if result.Limit > searchMaxLimit {
+		result.Limit = searchMaxLimit
+	}
+	if result.Offset < 0 {
+		return models.DocumentSearchQuery{}, models.NewBadRequest("неверное смещение результатов поиска")
+	}
+
+	for _, code := range filter.KindCodes {
+		kind := models.DocumentKind(strings.TrimSpace(code))
+		if _, ok := models.GetDocumentKindSpec(kind); !ok {
+			return models.DocumentSearchQuery{}, models.NewBadRequest("неподдерживаемый вид документа")
+		}
+		result.Kinds = append(result.Kinds, kind)
+	}
+
+	if strings.TrimSpace(filter.DateFrom) != "" {
+		dateFrom, err := parseCommandDate(filter.DateFrom, "даты начала периода")
+		if err != nil {
+			return models.DocumentSearchQuery{}, err
+		}
+		result.DateFrom = &dateFrom
+	}
+	if strings.TrimSpace(filter.DateTo) != "" {
+		dateTo, err := parseCommandDate(filter.DateTo, "даты окончания периода")
+		if err != nil {
+			return models.DocumentSearchQuery{}, err
+		}
+		result.DateTo = &dateTo
+	}
+	if result.DateFrom != nil && result.DateTo != nil && result.DateTo.Before(*result.DateFrom) {
+		return models.DocumentSearchQuery{}, models.NewBadRequest("дата окончания периода раньше даты начала")
+	}
+	return result, nil
+}
+
+func isSearchTermRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
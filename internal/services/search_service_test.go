@@ -0,0 +1,134 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type documentSearchStoreStub struct {
+	hits    []models.DocumentSearchHit
+	queries []models.DocumentSearchQuery
+}
+
+func (s *documentSearchStoreStub) Search(query models.DocumentSearchQuery) ([]models.DocumentSearchHit, error) {
+	s.queries = append(s.queries, query)
+	if query.Offset >= len(s.hits) {
+		return []models.DocumentSearchHit{}, nil
+	}
+	end := min(query.Offset+query.Limit, len(s.hits))
+	return s.hits[query.Offset:end], nil
+}
+
+func setupSearchService(t *testing.T, readable, denied int) (*SearchService, *documentSearchStoreStub, []uuid.UUID) {
+	t.Helper()
+
+	user := documentAccessUser(false, nil)
+	deps := setupDocumentAccessService(t, user, allowDocumentActions(models.DocumentKindIncomingLetter, "read"))
+	store := &documentSearchStoreStub{}
+	readableIDs := make([]uuid.UUID, 0, readable)
+	total := readable + denied
+	for i := 0; i < total; i++ {
+		id := uuid.New()
+		kind := models.DocumentKindIncomingLetter
+		// Недоступные документы чередуются с доступными в начале выдачи.
+		if i%2 == 1 && denied > 0 {
+			kind = models.DocumentKindOutgoingLetter
+			denied--
+		} else {
+			readableIDs = append(readableIDs, id)
+		}
+		deps.docRepo.docs[id] = documentAccessDoc(id, uuid.New(), kind)
+		store.hits = append(store.hits, models.DocumentSearchHit{
+			DocumentID:         id,
+			Kind:               kind,
+			RegistrationNumber: "№" + id.String()[:4],
+			Headline:           "\x02ремонт\x03 кровли",
+		})
+	}
+	return NewSearchService(store, deps.auth, deps.service), store, readableIDs
+}
+
+func TestSearchService_Search_FiltersUnreadableDocuments(t *testing.T) {
+	service, store, readableIDs := setupSearchService(t, 3, 2)
+
+	result, err := service.Search("  ремонт   кровли ", models.SearchFilter{KindCodes: []string{"incoming_letter", "outgoing_letter"}})
+
+	require.NoError(t, err)
+	require.Len(t, result.Items, 3)
+	for i, item := range result.Items {
+		assert.Equal(t, readableIDs[i].String(), item.DocumentID)
+		require.Len(t, item.Highlights, 1)
+		assert.Equal(t, models.SearchHighlightDocument, item.Highlights[0].Source)
+	}
+	assert.False(t, result.HasMore)
+	assert.Equal(t, 5, result.NextOffset)
+	require.Len(t, store.queries, 1)
+	assert.Equal(t, "ремонт кровли", store.queries[0].Text)
+	assert.Equal(t, []models.DocumentKind{models.DocumentKindIncomingLetter, models.DocumentKindOutgoingLetter}, store.queries[0].Kinds)
+}
+
+func TestSearchService_Search_PaginatesAcrossBatches(t *testing.T) {
+	service, store, readableIDs := setupSearchService(t, 30, 10)
+
+	first, err := service.Search("ремонт", models.SearchFilter{Limit: 25})
+	require.NoError(t, err)
+	require.Len(t, first.Items, 25)
+	assert.True(t, first.HasMore)
+	assert.Len(t, store.queries, 2)
+
+	second, err := service.Search("ремонт", models.SearchFilter{Limit: 25, Offset: first.NextOffset})
+	require.NoError(t, err)
+	require.Len(t, second.Items, 5)
+	assert.False(t, second.HasMore)
+	assert.Equal(t, readableIDs[25].String(), second.Items[0].DocumentID)
+	assert.Equal(t, readableIDs[29].String(), second.Items[4].DocumentID)
+}
+
+func TestSearchService_Search_Validation(t *testing.T) {
+	service, store, _ := setupSearchService(t, 1, 0)
+
+	_, err := service.Search(" а ", models.SearchFilter{})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "поисковый запрос должен содержать не менее 2 символов")
+
+	_, err = service.Search("?!", models.SearchFilter{})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "поисковый запрос должен содержать буквы или цифры")
+
+	_, err = service.Search("ремонт", models.SearchFilter{KindCodes: []string{"memo"}})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "неподдерживаемый вид документа")
+
+	_, err = service.Search("ремонт", models.SearchFilter{DateFrom: "2026-03-01", DateTo: "2026-02-01"})
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "дата окончания периода раньше даты начала")
+
+	assert.Empty(t, store.queries)
+}
+
+func TestSearchService_Search_PassesPeriod(t *testing.T) {
+	service, store, _ := setupSearchService(t, 1, 0)
+
+	_, err := service.Search("ремонт", models.SearchFilter{DateFrom: "2026-01-01", DateTo: "2026-01-31", Limit: 500})
+
+	require.NoError(t, err)
+	require.Len(t, store.queries, 1)
+	require.NotNil(t, store.queries[0].DateFrom)
+	require.NotNil(t, store.queries[0].DateTo)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *store.queries[0].DateFrom)
+	assert.Equal(t, time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), *store.queries[0].DateTo)
+	assert.Equal(t, searchMaxLimit, store.queries[0].Limit)
+}
+
+func TestSearchService_Search_RequiresAuthentication(t *testing.T) {
+	deps := setupDocumentAccessService(t, nil, nil)
+	store := &documentSearchStoreStub{}
+	service := NewSearchService(store, deps.auth, deps.service)
+
+	_, err := service.Search("ремонт", models.SearchFilter{})
+
+	require.ErrorIs(t, err, models.ErrUnauthorized)
+	assert.Empty(t, store.queries)
+}
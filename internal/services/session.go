@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
+)
+
+// sessionSyncInterval — как часто desktop-сеанс сверяется с журналом: время
+// последнего действия сохраняется, принудительное завершение администратором
+// вступает в силу, перечитываются тайм-ауты из системных настроек.
+const sessionSyncInterval = 30 * time.Second
+
+// Тайм-ауты по умолчанию выключены: в desktop-клиенте пока нет экрана
+// блокировки и обработки завершения сеанса, и пользователь не смог бы
+// разблокировать сеанс или войти заново.
+const (
+	defaultSessionIdleTimeoutMinutes   = 0
+	defaultSessionAbsoluteTimeoutHours = 0
+)
+
+// desktopSession — состояние сеанса desktop-клиента. Нулевое значение
+// означает сеанс без записи в журнале и без тайм-аутов.
+type desktopSession struct {
+	id              uuid.UUID
+	startedAt       time.Time
+	lastActivity    time.Time
+	lastSync        time.Time
+	locked          bool
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+}
+
+// SetSessionStore подключает журнал сеансов.
+func (s *AuthService) SetSessionStore(sessionRepo UserSessionStore) {
+	s.sessionRepo = sessionRepo
+}
+
+// openSession делает userID пользователем desktop-сеанса и открывает запись
+// журнала. Незакрытый предыдущий сеанс завершается как замененный.
+func (s *AuthService) openSession(userID uuid.UUID) error {
+	now := s.now()
+	idle, absolute := s.sessionTimeouts()
+	session := desktopSession{
+		startedAt:       now,
+		lastActivity:    now,
+		lastSync:        now,
+		idleTimeout:     idle,
+		absoluteTimeout: absolute,
+	}
+	if s.sessionRepo != nil {
+		id, err := s.sessionRepo.Start(userID, s.hostName, now)
+		if err != nil {
+			return err
+		}
+		session.id = id
+	}
+
+	s.mu.Lock()
+	previous := s.session
+	s.currentUserID = userID
+	s.session = session
+	s.mu.Unlock()
+
+	s.endSessionRecord(previous.id, models.SessionEndReplaced)
+	return nil
+}
+
+// closeSession завершает desktop-сеанс с причиной reason.
+func (s *AuthService) closeSession(reason string) {
+	s.mu.Lock()
+	session := s.session
+	s.currentUserID = uuid.Nil
+	s.session = desktopSession{}
+	s.pending = nil
+	s.mu.Unlock()
+
+	s.endSessionRecord(session.id, reason)
+}
+
+// dropSession завершает сеанс, только если он все еще принадлежит userID:
+// параллельный вход другого пользователя не сбрасывается. reason == ""
+// означает, что запись журнала уже завершена.
+func (s *AuthService) dropSession(userID uuid.UUID, reason string) {
+	s.mu.Lock()
+	if s.currentUserID != userID {
+		s.mu.Unlock()
+		return
+	}
+	session := s.session
+	s.currentUserID = uuid.Nil
+	s.session = desktopSession{}
+	s.mu.Unlock()
+
+	if reason != "" {
+		s.endSessionRecord(session.id, reason)
+	}
+}
+
+func (s *AuthService) endSessionRecord(id uuid.UUID, reason string) {
+	if s.sessionRepo == nil || id == uuid.Nil {
+		return
+	}
+	if err := s.sessionRepo.End(id, reason, s.now()); err != nil {
+		slog.Warn("failed to close user session record", "session_id", id, "reason", reason, "error", err)
+	}
+}
+
+// sessionUserID возвращает пользователя desktop-сеанса, применяя тайм-ауты.
+// activity отмечает вызов как действие пользователя; фоновые опросы
+// интерфейса передают false, чтобы не продлевать простой. Для
+// заблокированного сеанса возвращается ID пользователя вместе с
+// ErrSessionLocked — его использует Unlock.
+func (s *AuthService) sessionUserID(activity bool) (uuid.UUID, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	userID := s.currentUserID
+	if userID == uuid.Nil {
+		s.mu.Unlock()
+		return uuid.Nil, ErrNotAuthenticated
+	}
+	session := &s.session
+	if session.absoluteTimeout > 0 && !now.Before(session.startedAt.Add(session.absoluteTimeout)) {
+		s.mu.Unlock()
+		s.dropSession(userID, models.SessionEndExpired)
+		return uuid.Nil, models.ErrSessionExpired
+	}
+	if !session.locked && session.idleTimeout > 0 && !now.Before(session.lastActivity.Add(session.idleTimeout)) {
+		session.locked = true
+	}
+	if session.locked {
+		s.mu.Unlock()
+		return userID, models.ErrSessionLocked
+	}
+	if activity {
+		session.lastActivity = now
+	}
+	sync := now.Sub(session.lastSync) >= sessionSyncInterval
+	if sync {
+		session.lastSync = now
+	}
+	sessionID, lastActivity := session.id, session.lastActivity
+	s.mu.Unlock()
+
+	if sync {
+		if err := s.syncSession(userID, sessionID, lastActivity); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return userID, nil
+}
+
+// syncSession перечитывает тайм-ауты и сохраняет время последнего действия.
+// Сеанс, завершенный в журнале администратором или по сроку, закрывается и
+// в клиенте.
+func (s *AuthService) syncSession(userID, sessionID uuid.UUID, lastActivity time.Time) error {
+	idle, absolute := s.sessionTimeouts()
+	s.mu.Lock()
+	if s.currentUserID == userID && s.session.id == sessionID {
+		s.session.idleTimeout = idle
+		s.session.absoluteTimeout = absolute
+	}
+	s.mu.Unlock()
+
+	if s.sessionRepo == nil || sessionID == uuid.Nil {
+		return nil
+	}
+	open, reason, err := s.sessionRepo.Touch(sessionID, lastActivity)
+	if err != nil {
+		return err
+	}
+	if open {
+		return nil
+	}
+	s.dropSession(userID, "")
+	switch reason {
+	case models.SessionEndTerminated:
+		return models.ErrSessionTerminated
+	case models.SessionEndExpired:
+		return models.ErrSessionExpired
+	default:
+		return ErrNotAuthenticated
+	}
+}
+
+// sessionTimeouts возвращает тайм-ауты бездействия и общей длительности
+// сеанса. Без хранилища настроек тайм-ауты отключены.
+func (s *AuthService) sessionTimeouts() (time.Duration, time.Duration) {
+	if s.settingsRepo == nil {
+		return 0, 0
+	}
+	idle := s.getSessionSetting(models.SettingSessionIdleTimeoutMinutes, defaultSessionIdleTimeoutMinutes)
+	absolute := s.getSessionSetting(models.SettingSessionAbsoluteTimeoutHours, defaultSessionAbsoluteTimeoutHours)
+	return time.Duration(idle) * time.Minute, time.Duration(absolute) * time.Hour
+}
+
+func (s *AuthService) getSessionSetting(key string, fallback int) int {
+	setting, err := s.settingsRepo.Get(key)
+	if err != nil || setting == nil {
+		return fallback
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(setting.Value))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+// currentSessionID возвращает ID записи журнала текущего desktop-сеанса.
+func (s *AuthService) currentSessionID() uuid.UUID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.session.id
+}
+
+// CloseDesktopSession завершает desktop-сеанс при закрытии приложения.
+// Функция, а не метод AuthService, чтобы не попасть в Wails bindings.
+func CloseDesktopSession(auth *AuthService) {
+	if auth.IsAuthenticated() {
+		auth.closeSession(models.SessionEndShutdown)
+	}
+}
+
+// GetSessionState возвращает состояние текущего сеанса для экрана блокировки
+// (Wails binding). Вызов не считается действием пользователя.
+func (s *AuthService) GetSessionState() (*dto.SessionState, error) {
+	if _, err := s.sessionUserID(false); err != nil && !errors.Is(err, models.ErrSessionLocked) {
+		return nil, err
+	}
+	s.mu.RLock()
+	session := s.session
+	s.mu.RUnlock()
+
+	state := &dto.SessionState{
+		StartedAt:          session.startedAt,
+		Locked:             session.locked,
+		IdleTimeoutSeconds: int(session.idleTimeout / time.Second),
+	}
+	if session.id != uuid.Nil {
+		state.SessionID = session.id.String()
+	}
+	if session.absoluteTimeout > 0 {
+		expiresAt := session.startedAt.Add(session.absoluteTimeout)
+		state.ExpiresAt = &expiresAt
+	}
+	return state, nil
+}
+
+// ReportActivity отмечает действие пользователя в интерфейсе, не требующее
+// обращения к backend (например, чтение открытого документа) (Wails binding).
+func (s *AuthService) ReportActivity() error {
+	_, err := s.sessionUserID(true)
+	return err
+}
+
+// Lock блокирует текущий сеанс до повторного ввода пароля (Wails binding).
+func (s *AuthService) Lock() error {
+	userID, err := s.sessionUserID(false)
+	if err != nil && !errors.Is(err, models.ErrSessionLocked) {
+		return err
+	}
+	s.mu.Lock()
+	if s.currentUserID == userID {
+		s.session.locked = true
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Unlock снимает блокировку сеанса после проверки пароля текущего
+// пользователя (Wails binding). Неверный пароль учитывается в счетчике
+// неудачных попыток входа. Сменить пользователя можно только выходом.
+func (s *AuthService) Unlock(password string) (*dto.User, error) {
+	return measureOperation(s.metrics, "auth.unlock", func() (*dto.User, error) {
+		userID, err := s.sessionUserID(false)
+		if err != nil && !errors.Is(err, models.ErrSessionLocked) {
+			return nil, err
+		}
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || !user.IsActive {
+			s.dropSession(userID, models.SessionEndUserInactive)
+			return nil, ErrNotAuthenticated
+		}
+		if err := s.verifySessionPassword(user, password); err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		if s.currentUserID == userID {
+			s.session.locked = false
+			s.session.lastActivity = s.now()
+		}
+		s.mu.Unlock()
+		return dto.MapUser(user), nil
+	})
+}
+
+func (s *AuthService) verifySessionPassword(user *models.User, password string) error {
+	if user.IsDirectoryUser() {
+		if s.authenticator == nil || s.authenticator.AuthSource() != user.AuthSource {
+			return models.ErrDirectoryUnavailable
+		}
+		directoryUser, err := s.authenticator.Authenticate(context.Background(), user.Login, password)
+		if errors.Is(err, ErrInvalidCredentials) {
+			return ErrWrongPassword
+		}
+		if err != nil {
+			return err
+		}
+		if directoryUser.ID != user.ID {
+			// Логин в каталоге передан другой учетной записи.
+			return ErrWrongPassword
+		}
+		if !directoryUser.IsActive {
+			s.dropSession(user.ID, models.SessionEndUserInactive)
+			return ErrUserNotActive
+		}
+		return nil
+	}
+
+	if !security.VerifyPassword(user.PasswordHash, password) {
+		_, isActive, err := s.incrementFailedLoginAttempts(user, s.passwordPolicy())
+		if err != nil {
+			return err
+		}
+		if !isActive {
+			s.dropSession(user.ID, models.SessionEndUserInactive)
+			return ErrUserLocked
+		}
+		return ErrWrongPassword
+	}
+	if user.FailedLoginAttempts > 0 {
+		return s.userRepo.ResetFailedLoginAttempts(user.ID)
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+type userSessionStoreStub struct {
+	mu         sync.Mutex
+	sessions   map[uuid.UUID]*models.UserSession
+	terminated []models.OutboxEvent
+}
+
+func newUserSessionStoreStub() *userSessionStoreStub {
+	return &userSessionStoreStub{sessions: make(map[uuid.UUID]*models.UserSession)}
+}
+
+func (s *userSessionStoreStub) Start(userID uuid.UUID, hostName string, startedAt time.Time) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.New()
+	s.sessions[id] = &models.UserSession{ID: id, UserID: userID, UserLogin: "user", HostName: hostName, StartedAt: startedAt, LastActivityAt: startedAt}
+	return id, nil
+}
+
+func (s *userSessionStoreStub) Touch(id uuid.UUID, lastActivityAt time.Time) (bool, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return false, models.SessionEndUserInactive, nil
+	}
+	if !session.IsActive() {
+		return false, session.EndReason, nil
+	}
+	if lastActivityAt.After(session.LastActivityAt) {
+		session.LastActivityAt = lastActivityAt
+	}
+	return true, "", nil
+}
+
+func (s *userSessionStoreStub) End(id uuid.UUID, reason string, endedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.sessions[id]; ok && session.IsActive() {
+		session.EndedAt = &endedAt
+		session.EndReason = reason
+	}
+	return nil
+}
+
+func (s *userSessionStoreStub) TerminateWithOutbox(id, terminatedBy uuid.UUID, effects []models.OutboxEvent) error {
+	if err := s.End(id, models.SessionEndTerminated, time.Now()); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.terminated = append(s.terminated, effects...)
+	return nil
+}
+
+func (s *userSessionStoreStub) ExpireStartedBefore(startedBefore, endedAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired int64
+	for _, session := range s.sessions {
+		if session.IsActive() && session.StartedAt.Before(startedBefore) {
+			session.EndedAt = &endedAt
+			session.EndReason = models.SessionEndExpired
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (s *userSessionStoreStub) GetByID(id uuid.UUID) (*models.UserSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (s *userSessionStoreStub) GetList(filter models.UserSessionFilter) (*models.PagedResult[models.UserSession], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := make([]models.UserSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		if filter.ActiveOnly && !session.IsActive() {
+			continue
+		}
+		items = append(items, *session)
+	}
+	return &models.PagedResult[models.UserSession]{Items: items, TotalCount: len(items), Page: 1, PageSize: 50}, nil
+}
+
+func (s *userSessionStoreStub) only(t *testing.T) models.UserSession {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.Len(t, s.sessions, 1)
+	for _, session := range s.sessions {
+		return *session
+	}
+	return models.UserSession{}
+}
+
+type sessionTestClock struct {
+	now time.Time
+}
+
+func (c *sessionTestClock) Now() time.Time          { return c.now }
+func (c *sessionTestClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func sessionSetting(key string, value int) *models.SystemSetting {
+	return &models.SystemSetting{Key: key, Value: strconv.Itoa(value)}
+}
+
+// setupSessionAuth логинит пользователя с тайм-аутами idleMinutes и absoluteHours.
+func setupSessionAuth(t *testing.T, idleMinutes, absoluteHours int) (*AuthService, *mocks.UserStore, *userSessionStoreStub, *sessionTestClock, *models.User, string) {
+	t.Helper()
+	userRepo := mocks.NewUserStore(t)
+	settingsRepo := mocks.NewSettingsStore(t)
+	settingsRepo.On("Get", models.SettingSessionIdleTimeoutMinutes).Return(sessionSetting(models.SettingSessionIdleTimeoutMinutes, idleMinutes), nil).Maybe()
+	settingsRepo.On("Get", models.SettingSessionAbsoluteTimeoutHours).Return(sessionSetting(models.SettingSessionAbsoluteTimeoutHours, absoluteHours), nil).Maybe()
+	settingsRepo.On("Get", mock.Anything).Return(nil, assert.AnError).Maybe()
+	sessions := newUserSessionStoreStub()
+	clock := &sessionTestClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	auth := NewAuthService(nil, userRepo)
+	auth.SetSettingsStore(settingsRepo)
+	auth.SetSessionStore(sessions)
+	auth.hostName = "WS-101"
+	auth.now = clock.Now
+
+	user, password := newTestUser()
+	userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+	_, err := auth.Login(user.Login, password)
+	require.NoError(t, err)
+	userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+	userRepo.On("GetSessionPrincipal", user.ID).Return(&models.SessionPrincipal{ID: user.ID, IsActive: true}, nil).Maybe()
+	return auth, userRepo, sessions, clock, user, password
+}
+
+func TestAuthService_SessionJournal(t *testing.T) {
+	t.Run("login opens and logout closes the session record", func(t *testing.T) {
+		auth, _, sessions, _, user, _ := setupSessionAuth(t, 30, 12)
+
+		session := sessions.only(t)
+		assert.Equal(t, user.ID, session.UserID)
+		assert.Equal(t, "WS-101", session.HostName)
+		assert.True(t, session.IsActive())
+
+		require.NoError(t, auth.Logout())
+		session = sessions.only(t)
+		assert.Equal(t, models.SessionEndLogout, session.EndReason)
+		assert.False(t, auth.IsAuthenticated())
+	})
+
+	t.Run("second login replaces the open session", func(t *testing.T) {
+		auth, userRepo, sessions, _, user, password := setupSessionAuth(t, 30, 12)
+		first := sessions.only(t)
+
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		_, err := auth.Login(user.Login, password)
+		require.NoError(t, err)
+
+		replaced, err := sessions.GetByID(first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.SessionEndReplaced, replaced.EndReason)
+		assert.NotEqual(t, first.ID, auth.currentSessionID())
+	})
+
+	t.Run("application shutdown closes the session", func(t *testing.T) {
+		auth, _, sessions, _, _, _ := setupSessionAuth(t, 30, 12)
+
+		CloseDesktopSession(auth)
+
+		assert.Equal(t, models.SessionEndShutdown, sessions.only(t).EndReason)
+		assert.False(t, auth.IsAuthenticated())
+	})
+
+	t.Run("activity is persisted on sync", func(t *testing.T) {
+		auth, _, sessions, clock, _, _ := setupSessionAuth(t, 30, 12)
+
+		clock.Advance(sessionSyncInterval)
+		_, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+
+		assert.Equal(t, clock.Now(), sessions.only(t).LastActivityAt)
+	})
+}
+
+func TestAuthService_SessionIdleLock(t *testing.T) {
+	t.Run("inactivity locks the session until the password is entered", func(t *testing.T) {
+		auth, userRepo, _, clock, user, password := setupSessionAuth(t, 10, 0)
+
+		clock.Advance(9 * time.Minute)
+		_, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+
+		clock.Advance(10 * time.Minute)
+		_, err = auth.GetCurrentUserUUID()
+		assert.ErrorIs(t, err, models.ErrSessionLocked)
+		assert.True(t, auth.IsAuthenticated(), "a locked session is still open")
+		state, err := auth.GetSessionState()
+		require.NoError(t, err)
+		assert.True(t, state.Locked)
+		assert.Equal(t, 600, state.IdleTimeoutSeconds)
+		assert.Nil(t, state.ExpiresAt)
+
+		userRepo.On("IncrementFailedLoginAttempts", user.ID, 5).Return(1, true, nil).Once()
+		_, err = auth.Unlock("wrong")
+		assert.ErrorIs(t, err, ErrWrongPassword)
+
+		userRepo.On("ResetFailedLoginAttempts", user.ID).Return(nil).Maybe()
+		_, err = auth.Unlock(password)
+		require.NoError(t, err)
+		_, err = auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+	})
+
+	t.Run("polling does not extend the session", func(t *testing.T) {
+		auth, _, _, clock, _, _ := setupSessionAuth(t, 10, 0)
+
+		clock.Advance(6 * time.Minute)
+		_, err := auth.pollCurrentUserUUID()
+		require.NoError(t, err)
+
+		clock.Advance(5 * time.Minute)
+		_, err = auth.pollCurrentUserUUID()
+		assert.ErrorIs(t, err, models.ErrSessionLocked)
+	})
+
+	t.Run("reported activity extends the session", func(t *testing.T) {
+		auth, _, _, clock, _, _ := setupSessionAuth(t, 10, 0)
+
+		clock.Advance(6 * time.Minute)
+		require.NoError(t, auth.ReportActivity())
+		clock.Advance(6 * time.Minute)
+
+		_, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err)
+	})
+
+	t.Run("user can lock the session manually", func(t *testing.T) {
+		auth, _, _, _, _, _ := setupSessionAuth(t, 0, 0)
+
+		require.NoError(t, auth.Lock())
+
+		_, err := auth.GetCurrentUser()
+		assert.ErrorIs(t, err, models.ErrSessionLocked)
+		require.NoError(t, auth.Logout(), "logout works from the lock screen")
+		assert.False(t, auth.IsAuthenticated())
+	})
+
+	t.Run("failed unlock attempts lock the account and end the session", func(t *testing.T) {
+		auth, userRepo, sessions, _, user, _ := setupSessionAuth(t, 0, 0)
+		require.NoError(t, auth.Lock())
+
+		userRepo.On("IncrementFailedLoginAttempts", user.ID, 5).Return(5, false, nil).Once()
+		_, err := auth.Unlock("wrong")
+
+		assert.ErrorIs(t, err, ErrUserLocked)
+		assert.False(t, auth.IsAuthenticated())
+		assert.Equal(t, models.SessionEndUserInactive, sessions.only(t).EndReason)
+	})
+}
+
+func TestAuthService_SessionEnd(t *testing.T) {
+	t.Run("absolute timeout ends the session", func(t *testing.T) {
+		auth, _, sessions, clock, _, _ := setupSessionAuth(t, 0, 1)
+
+		state, err := auth.GetSessionState()
+		require.NoError(t, err)
+		require.NotNil(t, state.ExpiresAt)
+		assert.Equal(t, clock.Now().Add(time.Hour), *state.ExpiresAt)
+
+		clock.Advance(time.Hour)
+		_, err = auth.GetCurrentUserUUID()
+
+		assert.ErrorIs(t, err, models.ErrSessionExpired)
+		assert.False(t, auth.IsAuthenticated())
+		assert.Equal(t, models.SessionEndExpired, sessions.only(t).EndReason)
+	})
+
+	t.Run("absolute timeout applies to a locked session", func(t *testing.T) {
+		auth, _, _, clock, _, password := setupSessionAuth(t, 10, 1)
+
+		clock.Advance(time.Hour)
+		_, err := auth.Unlock(password)
+
+		assert.ErrorIs(t, err, models.ErrSessionExpired)
+	})
+
+	t.Run("session terminated by administrator ends on the next sync", func(t *testing.T) {
+		auth, _, sessions, clock, _, _ := setupSessionAuth(t, 0, 0)
+		require.NoError(t, sessions.TerminateWithOutbox(auth.currentSessionID(), uuid.New(), nil))
+
+		clock.Advance(sessionSyncInterval - time.Second)
+		_, err := auth.GetCurrentUserUUID()
+		require.NoError(t, err, "termination is noticed on sync")
+
+		clock.Advance(time.Second)
+		_, err = auth.GetCurrentUserUUID()
+		assert.ErrorIs(t, err, models.ErrSessionTerminated)
+		assert.False(t, auth.IsAuthenticated())
+	})
+
+	t.Run("deactivated user loses the session", func(t *testing.T) {
+		sessions := newUserSessionStoreStub()
+		userRepo := mocks.NewUserStore(t)
+		auth := NewAuthService(nil, userRepo)
+		auth.SetSessionStore(sessions)
+		user, password := newTestUser()
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		_, err := auth.Login(user.Login, password)
+		require.NoError(t, err)
+
+		userRepo.On("GetSessionPrincipal", user.ID).Return(&models.SessionPrincipal{ID: user.ID, IsActive: false}, nil).Once()
+		_, err = auth.GetCurrentUserUUID()
+
+		assert.ErrorIs(t, err, ErrNotAuthenticated)
+		assert.Equal(t, models.SessionEndUserInactive, sessions.only(t).EndReason)
+	})
+}
+
+func TestUserSessionService(t *testing.T) {
+	setup := func(t *testing.T, roles ...string) (*UserSessionService, *userSessionStoreStub, *AuthService) {
+		t.Helper()
+		auth, _, sessions, _, _, _ := setupSessionAuth(t, 30, 12)
+		auth.SetAccessStore(newRoleMappedDocumentAccessStore(roles...))
+		return NewUserSessionService(sessions, auth), sessions, auth
+	}
+
+	t.Run("admin terminates another session", func(t *testing.T) {
+		svc, sessions, auth := setup(t, models.SystemPermissionAdmin)
+		otherID, err := sessions.Start(uuid.New(), "WS-202", time.Now())
+		require.NoError(t, err)
+
+		page, err := svc.GetSessions(models.UserSessionFilter{ActiveOnly: true})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		for _, item := range page.Items {
+			assert.Equal(t, item.ID == auth.currentSessionID().String(), item.IsCurrent)
+		}
+
+		require.NoError(t, svc.TerminateSession(otherID.String()))
+		terminated, err := sessions.GetByID(otherID)
+		require.NoError(t, err)
+		assert.Equal(t, models.SessionEndTerminated, terminated.EndReason)
+		require.Len(t, sessions.terminated, 1)
+		assert.Contains(t, sessions.terminated[0].Payload, "SESSION_TERMINATE")
+
+		err = svc.TerminateSession(otherID.String())
+		requireAppError(t, err, "CONFLICT", 409, "сеанс уже завершен")
+	})
+
+	t.Run("current session is closed by logout", func(t *testing.T) {
+		svc, _, auth := setup(t, models.SystemPermissionAdmin)
+
+		err := svc.TerminateSession(auth.currentSessionID().String())
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "текущий сеанс завершается выходом из системы")
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		svc, _, _ := setup(t, "clerk")
+
+		_, err := svc.GetSessions(models.UserSessionFilter{})
+		assert.Equal(t, models.ErrForbidden, err)
+		assert.Equal(t, models.ErrForbidden, svc.TerminateSession(uuid.NewString()))
+	})
+
+	t.Run("expiry closes sessions over the absolute timeout", func(t *testing.T) {
+		svc, sessions, auth := setup(t, models.SystemPermissionAdmin)
+		staleID, err := sessions.Start(uuid.New(), "WS-303", auth.now().Add(-13*time.Hour))
+		require.NoError(t, err)
+
+		require.NoError(t, svc.expireSessions())
+
+		stale, err := sessions.GetByID(staleID)
+		require.NoError(t, err)
+		assert.Equal(t, models.SessionEndExpired, stale.EndReason)
+		current, err := sessions.GetByID(auth.currentSessionID())
+		require.NoError(t, err)
+		assert.True(t, current.IsActive())
+	})
+}
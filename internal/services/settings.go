@@ -11,6 +11,7 @@ import (
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
 )
 
 const rollbackMigrationConfirmationPhrase = "ОТКАТ МИГРАЦИИ"
@@ -22,6 +23,8 @@ type SettingsService struct {
 	authService     *AuthService
 	auditService    *AdminAuditLogService
 	schemaLifecycle SchemaLifecycle
+	passwords       PasswordStatusStore
+	twoFactors      TwoFactorCoverageStore
 	migrationMu     sync.Mutex
 }
 
@@ -68,9 +71,16 @@ func (s *SettingsService) Update(key, value string) error {
 	if err == nil && current != nil && current.Value == value {
 		return nil
 	}
+	if err := s.checkTwoFactorCoverage(key, value); err != nil {
+		return err
+	}
 
 	userID, userName := s.authService.GetCurrentAuditInfo()
 	details := fmt.Sprintf("Изменена настройка %s: %s", s.getSettingAuditLabel(key, current), value)
+	tightens := s.tightensPasswordPolicy(key, value)
+	if tightens {
+		details += "; локальным пользователям потребуется смена пароля"
+	}
 	store, ok := s.repo.(settingsOutboxStore)
 	if !ok {
 		return errSettingsOutboxStoreRequired
@@ -79,6 +89,13 @@ func (s *SettingsService) Update(key, value string) error {
 	if buildErr != nil {
 		return buildErr
 	}
+	if tightens {
+		policyStore, ok := s.repo.(passwordPolicyOutboxStore)
+		if !ok {
+			return errSettingsOutboxStoreRequired
+		}
+		return policyStore.UpdatePasswordPolicyWithOutbox(key, value, []models.OutboxEvent{event})
+	}
 	err = store.UpdateWithOutbox(key, value, []models.OutboxEvent{event})
 	if err != nil {
 		return err
@@ -88,11 +105,84 @@ func (s *SettingsService) Update(key, value string) error {
 
 func validateSystemSettingValue(key, value string) error {
 	switch key {
-	case "password_lifetime_days":
+	case models.SettingPasswordLifetimeDays:
 		days, err := strconv.Atoi(strings.TrimSpace(value))
 		if err != nil || days < 0 {
 			return models.NewBadRequest("Срок жизни пароля должен быть целым числом от 0 дней")
 		}
+	case "citizen_appeal_response_days":
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 1 || days > 365 {
+			return models.NewBadRequest("Срок ответа на обращение должен быть целым числом от 1 до 365 дней")
+		}
+	case "citizen_appeal_due_soon_days":
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 0 || days > 60 {
+			return models.NewBadRequest("Порог приближения срока должен быть целым числом от 0 до 60 дней")
+		}
+	case "citizen_appeal_deadline_working_days":
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return models.NewBadRequest("Признак расчета в рабочих днях должен быть true или false")
+		}
+	case models.SettingSessionIdleTimeoutMinutes:
+		minutes, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || minutes < 0 || minutes > 1440 {
+			return models.NewBadRequest("Время бездействия до блокировки должно быть целым числом от 0 до 1440 минут")
+		}
+	case models.SettingSessionAbsoluteTimeoutHours:
+		hours, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || hours < 0 || hours > 168 {
+			return models.NewBadRequest("Максимальная длительность сеанса должна быть целым числом от 0 до 168 часов")
+		}
+	case models.SettingPasswordMinLength:
+		length, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || length < security.MinPasswordMinLength || length > security.MaxPasswordMinLength {
+			return models.NewBadRequest(fmt.Sprintf("Минимальная длина пароля должна быть целым числом от %d до %d символов", security.MinPasswordMinLength, security.MaxPasswordMinLength))
+		}
+	case models.SettingPasswordRequiredClasses:
+		if _, err := security.ParseRequiredClasses(value); err != nil {
+			return models.NewBadRequestWrapped("Классы символов пароля указываются через запятую из upper, lower, digit, special; альтернативы — через |", err)
+		}
+	case models.SettingPasswordRejectCommon:
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return models.NewBadRequest("Признак запрета распространенных паролей должен быть true или false")
+		}
+	case models.SettingPasswordHistoryDepth:
+		depth, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || depth < 0 || depth > security.MaxPasswordHistoryDepth {
+			return models.NewBadRequest(fmt.Sprintf("Глубина истории паролей должна быть целым числом от 0 до %d", security.MaxPasswordHistoryDepth))
+		}
+	case models.SettingPasswordLockoutThreshold:
+		attempts, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || attempts < security.MinLockoutThreshold || attempts > security.MaxLockoutThreshold {
+			return models.NewBadRequest(fmt.Sprintf("Порог блокировки должен быть целым числом от %d до %d попыток", security.MinLockoutThreshold, security.MaxLockoutThreshold))
+		}
+	case models.SettingPasswordLockoutMinutes:
+		minutes, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || minutes < 0 || minutes > 1440 {
+			return models.NewBadRequest("Время автоматической разблокировки должно быть целым числом от 0 до 1440 минут")
+		}
+	case models.SettingTwoFactorRequiredPermissions:
+		if _, err := models.ParseTwoFactorRequiredPermissions(value); err != nil {
+			return models.NewBadRequestWrapped("Права, требующие второго фактора, указываются через запятую из admin, references, stats_documents, stats_assignments, stats_system", err)
+		}
+	case models.SettingReminderAssignmentDaysBefore:
+		if _, err := models.ParseReminderDaysBefore(value); err != nil {
+			return models.NewBadRequestWrapped(fmt.Sprintf("Дни напоминаний до срока указываются через запятую целыми числами от 1 до %d", models.MaxReminderDays), err)
+		}
+	case models.SettingEmailNotificationsEnabled:
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return models.NewBadRequest("Признак включения почтовых уведомлений должен быть true или false")
+		}
+	case models.SettingReminderAssignmentOnDeadline:
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return models.NewBadRequest("Признак напоминания в день срока должен быть true или false")
+		}
+	case models.SettingReminderAssignmentOverdueEvery, models.SettingReminderAssignmentEscalation, models.SettingReminderAcknowledgmentEvery:
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days < 0 || days > models.MaxReminderDays {
+			return models.NewBadRequest(fmt.Sprintf("Период напоминаний должен быть целым числом от 0 до %d дней", models.MaxReminderDays))
+		}
 	}
 	return nil
 }
@@ -239,6 +329,46 @@ func (s *SettingsService) IsAssignmentCompletionAttachmentsEnabled() bool {
 	}
 }
 
+// GetCitizenAppealResponseDays возвращает нормативный срок ответа на обращение гражданина.
+func (s *SettingsService) GetCitizenAppealResponseDays() int {
+	return s.getPositiveIntSetting("citizen_appeal_response_days", 30)
+}
+
+// GetCitizenAppealDueSoonDays возвращает порог, с которого обращение считается подходящим к сроку.
+func (s *SettingsService) GetCitizenAppealDueSoonDays() int {
+	setting, err := s.repo.Get("citizen_appeal_due_soon_days")
+	if err != nil || setting == nil {
+		return 5
+	}
+	days, err := strconv.Atoi(strings.TrimSpace(setting.Value))
+	if err != nil || days < 0 {
+		return 5
+	}
+	return days
+}
+
+// IsCitizenAppealDeadlineInWorkingDays возвращает признак расчета срока ответа в рабочих днях.
+func (s *SettingsService) IsCitizenAppealDeadlineInWorkingDays() bool {
+	setting, err := s.repo.Get("citizen_appeal_deadline_working_days")
+	if err != nil || setting == nil {
+		return false
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(setting.Value))
+	return err == nil && enabled
+}
+
+func (s *SettingsService) getPositiveIntSetting(key string, fallback int) int {
+	setting, err := s.repo.Get(key)
+	if err != nil || setting == nil {
+		return fallback
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(setting.Value))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
 func validateRollbackMigrationRequest(req models.RollbackMigrationRequest) error {
 	if !req.BackupCompleted {
 		return models.NewBadRequest("Перед откатом миграции подтвердите свежую резервную копию PostgreSQL и MinIO")
@@ -290,8 +420,42 @@ func (s *SettingsService) getSettingAuditLabel(key string, current *models.Syste
 		return "Разрешенные типы файлов"
 	case "assignment_completion_attachments_enabled":
 		return "Файлы при завершении поручения"
-	case "password_lifetime_days":
+	case models.SettingPasswordLifetimeDays:
 		return "Срок жизни пароля"
+	case models.SettingPasswordMinLength:
+		return "Минимальная длина пароля"
+	case models.SettingPasswordRequiredClasses:
+		return "Классы символов пароля"
+	case models.SettingPasswordRejectCommon:
+		return "Запрет распространенных паролей"
+	case models.SettingPasswordHistoryDepth:
+		return "Глубина истории паролей"
+	case models.SettingPasswordLockoutThreshold:
+		return "Порог блокировки после неверных попыток входа"
+	case models.SettingPasswordLockoutMinutes:
+		return "Автоматическая разблокировка"
+	case models.SettingTwoFactorRequiredPermissions:
+		return "Права, требующие двухфакторной аутентификации"
+	case "citizen_appeal_response_days":
+		return "Срок ответа на обращение"
+	case "citizen_appeal_due_soon_days":
+		return "Порог приближения срока обращения"
+	case "citizen_appeal_deadline_working_days":
+		return "Расчет срока обращения в рабочих днях"
+	case models.SettingSessionIdleTimeoutMinutes:
+		return "Блокировка сеанса при бездействии"
+	case models.SettingSessionAbsoluteTimeoutHours:
+		return "Максимальная длительность сеанса"
+	case models.SettingReminderAssignmentDaysBefore:
+		return "Напоминания до срока поручения"
+	case models.SettingReminderAssignmentOnDeadline:
+		return "Напоминание в день срока поручения"
+	case models.SettingReminderAssignmentOverdueEvery:
+		return "Период напоминаний о просрочке поручения"
+	case models.SettingReminderAssignmentEscalation:
+		return "Эскалация просроченного поручения"
+	case models.SettingReminderAcknowledgmentEvery:
+		return "Период напоминаний об ознакомлении"
 	}
 
 	if current != nil && strings.TrimSpace(current.Description) != "" {
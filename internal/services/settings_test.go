@@ -1,6 +1,7 @@
 package services
 
 import (
+	"database/sql"
 	"testing"
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/database"
@@ -11,6 +12,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -70,7 +72,8 @@ func setupSettingsServiceWithRoles(t *testing.T, roles []string) (*SettingsServi
 
 type atomicSettingsStore struct {
 	*mocks.SettingsStore
-	effects []models.OutboxEvent
+	effects                []models.OutboxEvent
+	passwordChangeRequired bool
 }
 
 func (s *atomicSettingsStore) UpdateWithOutbox(key, value string, effects []models.OutboxEvent) error {
@@ -78,6 +81,11 @@ func (s *atomicSettingsStore) UpdateWithOutbox(key, value string, effects []mode
 	return s.SettingsStore.Update(key, value)
 }
 
+func (s *atomicSettingsStore) UpdatePasswordPolicyWithOutbox(key, value string, effects []models.OutboxEvent) error {
+	s.passwordChangeRequired = true
+	return s.UpdateWithOutbox(key, value, effects)
+}
+
 type captureSettingsAuditLogStore struct {
 	requests []models.CreateAdminAuditLogRequest
 }
@@ -111,6 +119,19 @@ func TestSettingsService_GetAll(t *testing.T) {
 		assert.Len(t, result, 1)
 	})
 
+	t.Run("rejects invalid reminder rules", func(t *testing.T) {
+		svc, _ := setupSettingsService(t, "admin")
+
+		for key, value := range map[string]string{
+			models.SettingReminderAssignmentDaysBefore:   "3,0",
+			models.SettingReminderAssignmentOnDeadline:   "sometimes",
+			models.SettingReminderAssignmentOverdueEvery: "-1",
+			models.SettingReminderAcknowledgmentEvery:    "91",
+		} {
+			requireAppError(t, svc.Update(key, value), "VALIDATION_ERROR", 400, "")
+		}
+	})
+
 	t.Run("forbidden executor", func(t *testing.T) {
 		svc, _ := setupSettingsService(t, "executor")
 
@@ -195,6 +216,55 @@ func TestSettingsService_Update(t *testing.T) {
 		require.Len(t, svc.repo.(*atomicSettingsStore).effects, 1)
 	})
 
+	t.Run("stricter password policy requires password change", func(t *testing.T) {
+		svc, repo, _, _ := setupSettingsServiceWithRoles(t, []string{"admin"})
+
+		repo.On("Get", models.SettingPasswordMinLength).Return(&models.SystemSetting{Key: models.SettingPasswordMinLength, Value: "8"}, nil)
+		repo.On("Get", mock.Anything).Return(nil, sql.ErrNoRows).Maybe()
+		repo.On("Update", models.SettingPasswordMinLength, "12").Return(nil).Once()
+
+		require.NoError(t, svc.Update(models.SettingPasswordMinLength, "12"))
+		atomicRepo := svc.repo.(*atomicSettingsStore)
+		assert.True(t, atomicRepo.passwordChangeRequired)
+		require.Len(t, atomicRepo.effects, 1)
+		assert.Contains(t, atomicRepo.effects[0].Payload, "потребуется смена пароля")
+	})
+
+	t.Run("relaxed password policy keeps current passwords", func(t *testing.T) {
+		svc, repo, _, _ := setupSettingsServiceWithRoles(t, []string{"admin"})
+
+		repo.On("Get", models.SettingPasswordMinLength).Return(&models.SystemSetting{Key: models.SettingPasswordMinLength, Value: "12"}, nil)
+		repo.On("Get", mock.Anything).Return(nil, sql.ErrNoRows).Maybe()
+		repo.On("Update", models.SettingPasswordMinLength, "10").Return(nil).Once()
+
+		require.NoError(t, svc.Update(models.SettingPasswordMinLength, "10"))
+		assert.False(t, svc.repo.(*atomicSettingsStore).passwordChangeRequired)
+	})
+
+	t.Run("required second factor needs every affected user enrolled", func(t *testing.T) {
+		svc, repo, _, _ := setupSettingsServiceWithRoles(t, []string{"admin"})
+		coverage := &twoFactorCoverageStub{logins: []string{"admin", "petrov"}}
+		svc.SetTwoFactorCoverageStore(coverage)
+		repo.On("Get", models.SettingTwoFactorRequiredPermissions).Return(&models.SystemSetting{Key: models.SettingTwoFactorRequiredPermissions, Value: ""}, nil)
+
+		err := svc.Update(models.SettingTwoFactorRequiredPermissions, "admin")
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, "CONFLICT", appErr.Kind)
+		assert.Contains(t, appErr.Message, "admin, petrov")
+		assert.Equal(t, []string{"admin"}, coverage.permissions)
+
+		coverage.logins = nil
+		repo.On("Update", models.SettingTwoFactorRequiredPermissions, "admin").Return(nil).Once()
+		require.NoError(t, svc.Update(models.SettingTwoFactorRequiredPermissions, "admin"))
+
+		svc.SetTwoFactorCoverageStore(nil)
+		err = svc.Update(models.SettingTwoFactorRequiredPermissions, "admin")
+		appErr, ok = models.AsAppError(err)
+		require.True(t, ok, "requirement is refused when coverage cannot be checked")
+		assert.Equal(t, "CONFLICT", appErr.Kind)
+	})
+
 	t.Run("rejects negative password lifetime", func(t *testing.T) {
 		svc, _ := setupSettingsService(t, "admin")
 
@@ -691,3 +761,13 @@ func TestSettingsService_RollbackCoordinatesSchemaLifecycle(t *testing.T) {
 		assert.Zero(t, db.rollbackCalls)
 	})
 }
+
+type twoFactorCoverageStub struct {
+	logins      []string
+	permissions []string
+}
+
+func (s *twoFactorCoverageStub) GetLoginsWithoutTOTP(permissions []string) ([]string, error) {
+	s.permissions = permissions
+	return s.logins, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -31,6 +32,11 @@ type StatisticsService struct {
 	metrics   *observability.Registry
 }
 
+// attachmentIntegritySummaryStore — необязательная сводка проверок целостности вложений.
+type attachmentIntegritySummaryStore interface {
+	GetAttachmentIntegritySummary() (models.AttachmentIntegritySummary, error)
+}
+
 // NewStatisticsService создает новый экземпляр StatisticsService.
 func NewStatisticsService(repo StatisticsStore, auth *AuthService, storage StorageInfoProvider) *StatisticsService {
 	return &StatisticsService{repo: repo, auth: auth, storage: storage}
@@ -93,7 +99,7 @@ func (s *StatisticsService) GetDocumentReport(startDateStr, endDateStr, groupBy,
 		if groupBy == "" {
 			groupBy = "kind"
 		}
-		if groupBy != "kind" && groupBy != "nomenclature" && groupBy != "user" {
+		if groupBy != "kind" && groupBy != "nomenclature" && groupBy != "user" && groupBy != "department" {
 			return nil, models.NewBadRequest("неподдерживаемая группировка статистики документов")
 		}
 		if kindCode != "" {
@@ -121,12 +127,19 @@ func (s *StatisticsService) GetDocumentReport(startDateStr, endDateStr, groupBy,
 			rows = withDocumentKindReportLabels(rows)
 		}
 
+		total := sumReportRows(rows)
+		if groupBy == "department" {
+			// Строки подразделений накопительные: документ учтен во всех
+			// вышестоящих подразделениях, поэтому итог — по верхнему уровню.
+			total = sumRootReportRows(rows)
+		}
+
 		return &models.DocumentStatisticsReport{
 			StartDate: startDate.Format("2006-01-02"),
 			EndDate:   endDate.Format("2006-01-02"),
 			GroupBy:   groupBy,
 			Rows:      rows,
-			Total:     sumReportRows(rows),
+			Total:     total,
 		}, nil
 	})
 }
@@ -166,7 +179,7 @@ func (s *StatisticsService) GetAssignmentStatistics() (*models.AssignmentStatist
 
 		var monthlyTotals []models.AssignmentMonthlyPoint
 		var monthlyByExecutor []models.StatisticsSeriesPoint
-		var overdueRating, statusCounts []models.StatisticsReportRow
+		var overdueRating, overdueByLevel, statusCounts []models.StatisticsReportRow
 		if err := runStatisticsQueries(
 			func() error {
 				var err error
@@ -180,7 +193,12 @@ func (s *StatisticsService) GetAssignmentStatistics() (*models.AssignmentStatist
 			},
 			func() error {
 				var err error
-				overdueRating, err = s.repo.GetAssignmentOverdueRating(yearStart, yearEnd)
+				overdueRating, err = s.repo.GetAssignmentOverdueRating(yearStart, yearEnd, models.AssignmentDeadlineBasisCurrent)
+				return err
+			},
+			func() error {
+				var err error
+				overdueByLevel, err = s.repo.GetAssignmentOverdueByLevel(yearStart, yearEnd)
 				return err
 			},
 			func() error {
@@ -196,17 +214,40 @@ func (s *StatisticsService) GetAssignmentStatistics() (*models.AssignmentStatist
 		}
 		monthlyByExecutor = completeMonthlySeries(withMonthPeriods(monthlyByExecutor), categoriesFromPoints(monthlyByExecutor))
 		statusCounts = withAssignmentStatusLabels(statusCounts)
+		overdueByLevel = withAssignmentLevelLabels(overdueByLevel)
 
 		return &models.AssignmentStatistics{
 			Year:              year,
 			MonthlyTotals:     monthlyTotals,
 			MonthlyByExecutor: monthlyByExecutor,
 			OverdueRating:     overdueRating,
+			OverdueByLevel:    overdueByLevel,
 			StatusCounts:      statusCounts,
 		}, nil
 	})
 }
 
+// GetAssignmentOverdueRating возвращает рейтинг исполнителей по нарушениям сроков
+// за текущий год. deadlineBasis: current — срок с учетом продлений, original —
+// первый плановый срок до продлений и правок распорядителя.
+func (s *StatisticsService) GetAssignmentOverdueRating(deadlineBasis string) ([]models.StatisticsReportRow, error) {
+	return measureOperation(s.metrics, "statistics.get_assignment_overdue_rating", func() ([]models.StatisticsReportRow, error) {
+		if err := s.requirePermission(models.SystemPermissionStatsAssignments); err != nil {
+			return nil, err
+		}
+		switch deadlineBasis {
+		case "":
+			deadlineBasis = models.AssignmentDeadlineBasisCurrent
+		case models.AssignmentDeadlineBasisCurrent, models.AssignmentDeadlineBasisOriginal:
+		default:
+			return nil, models.NewBadRequest("некорректный базис срока")
+		}
+
+		_, yearStart, yearEnd := currentYearRange()
+		return s.repo.GetAssignmentOverdueRating(yearStart, yearEnd, deadlineBasis)
+	})
+}
+
 // runStatisticsQueries runs independent database queries with a deliberately
 // small concurrency limit. Repositories do not yet accept contexts, so after
 // an error it stops scheduling new work but lets already-running queries end.
@@ -277,6 +318,37 @@ func (s *StatisticsService) GetAssignmentReport(startDateStr, endDateStr string,
 	})
 }
 
+// GetAssignmentSeriesReport возвращает исполнение повторяющихся поручений по сериям за период.
+func (s *StatisticsService) GetAssignmentSeriesReport(startDateStr, endDateStr string) (*models.AssignmentSeriesStatisticsReport, error) {
+	return measureOperation(s.metrics, "statistics.get_assignment_series_report", func() (*models.AssignmentSeriesStatisticsReport, error) {
+		if err := s.requirePermission(models.SystemPermissionStatsAssignments); err != nil {
+			return nil, err
+		}
+
+		startDate, endDate, err := parseStatisticsDateRange(startDateStr, endDateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := s.repo.GetAssignmentSeriesStatistics(startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+
+		report := &models.AssignmentSeriesStatisticsReport{
+			StartDate: startDate.Format("2006-01-02"),
+			EndDate:   endDate.Format("2006-01-02"),
+			Rows:      rows,
+		}
+		for i := range rows {
+			rows[i].ScheduleLabel = rows[i].Schedule.Label()
+			report.Total += rows[i].Total
+			report.Overdue += rows[i].Overdue
+		}
+		return report, nil
+	})
+}
+
 // GetAssignmentFilterOptions возвращает значения фильтров для статистики поручений.
 func (s *StatisticsService) GetAssignmentFilterOptions() (*models.AssignmentStatisticsFilters, error) {
 	return measureOperation(s.metrics, "statistics.get_assignment_filters", func() (*models.AssignmentStatisticsFilters, error) {
@@ -342,6 +414,15 @@ func (s *StatisticsService) GetSystemStatistics() (*models.SystemStatistics, err
 			}
 		}
 
+		if integrity, ok := s.repo.(attachmentIntegritySummaryStore); ok {
+			summary, err := integrity.GetAttachmentIntegritySummary()
+			if err != nil {
+				slog.Warn("failed to get attachment integrity summary", "error", err)
+			} else {
+				result.AttachmentIntegrity = &summary
+			}
+		}
+
 		return result, nil
 	})
 }
@@ -486,6 +567,21 @@ func withAssignmentStatusLabels(rows []models.StatisticsReportRow) []models.Stat
 	return rows
 }
 
+// withAssignmentLevelLabels подписывает уровни иерархии поручений.
+func withAssignmentLevelLabels(rows []models.StatisticsReportRow) []models.StatisticsReportRow {
+	for i := range rows {
+		level, err := strconv.Atoi(rows[i].Key)
+		switch {
+		case err != nil:
+		case level <= 1:
+			rows[i].Name = "Поручения по документам"
+		default:
+			rows[i].Name = fmt.Sprintf("Подпоручения %d уровня", level-1)
+		}
+	}
+	return rows
+}
+
 func withMonthPeriods(points []models.StatisticsSeriesPoint) []models.StatisticsSeriesPoint {
 	for i := range points {
 		points[i].Period = monthLabel(points[i].Month)
@@ -544,6 +640,16 @@ func sumReportRows(rows []models.StatisticsReportRow) int {
 	return total
 }
 
+func sumRootReportRows(rows []models.StatisticsReportRow) int {
+	total := 0
+	for _, row := range rows {
+		if row.ParentKey == "" {
+			total += row.Count
+		}
+	}
+	return total
+}
+
 func monthLabel(month int) string {
 	labels := []string{"Янв", "Фев", "Мар", "Апр", "Май", "Июн", "Июл", "Авг", "Сен", "Окт", "Ноя", "Дек"}
 	if month < 1 || month > len(labels) {
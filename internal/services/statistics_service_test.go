@@ -25,8 +25,10 @@ type fakeStatisticsStore struct {
 	assignmentMonthly   []models.AssignmentMonthlyPoint
 	assignmentExecutor  []models.StatisticsSeriesPoint
 	assignmentOverdue   []models.StatisticsReportRow
+	assignmentLevels    []models.StatisticsReportRow
 	assignmentStatuses  []models.StatisticsReportRow
 	assignmentReport    []models.StatisticsReportRow
+	assignmentSeries    []models.AssignmentSeriesStatisticsRow
 	systemUserCount     int
 	systemDocumentCount int
 	dbSize              string
@@ -39,6 +41,7 @@ type fakeStatisticsStore struct {
 	lastDocumentReportGroupBy string
 	lastAssignmentOnlyOverdue bool
 	lastAssignmentUserID      string
+	lastDeadlineBasis         string
 }
 
 func (s *fakeStatisticsStore) GetDocumentTotalByYear(yearStart, yearEnd time.Time) (int, error) {
@@ -74,10 +77,15 @@ func (s *fakeStatisticsStore) GetAssignmentMonthlyByExecutor(yearStart, yearEnd
 	return s.assignmentExecutor, s.err
 }
 
-func (s *fakeStatisticsStore) GetAssignmentOverdueRating(yearStart, yearEnd time.Time) ([]models.StatisticsReportRow, error) {
+func (s *fakeStatisticsStore) GetAssignmentOverdueRating(yearStart, yearEnd time.Time, deadlineBasis string) ([]models.StatisticsReportRow, error) {
+	s.lastDeadlineBasis = deadlineBasis
 	return s.assignmentOverdue, s.err
 }
 
+func (s *fakeStatisticsStore) GetAssignmentOverdueByLevel(yearStart, yearEnd time.Time) ([]models.StatisticsReportRow, error) {
+	return s.assignmentLevels, s.err
+}
+
 func (s *fakeStatisticsStore) GetAssignmentStatusCounts() ([]models.StatisticsReportRow, error) {
 	return s.assignmentStatuses, s.err
 }
@@ -88,6 +96,10 @@ func (s *fakeStatisticsStore) GetAssignmentReport(startDate, endDate time.Time,
 	return s.assignmentReport, s.err
 }
 
+func (s *fakeStatisticsStore) GetAssignmentSeriesStatistics(startDate, endDate time.Time) ([]models.AssignmentSeriesStatisticsRow, error) {
+	return s.assignmentSeries, s.err
+}
+
 func (s *fakeStatisticsStore) GetSystemUserCount() (int, error) {
 	return s.systemUserCount, s.err
 }
@@ -247,6 +259,22 @@ func TestStatisticsService_GetDocumentReport(t *testing.T) {
 	assert.Nil(t, report)
 }
 
+func TestStatisticsService_GetDocumentReportByDepartment(t *testing.T) {
+	svc, store, _, _ := setupStatisticsService(t, models.SystemPermissionStatsDocuments)
+	store.documentReport = []models.StatisticsReportRow{
+		{Key: "directorate", Name: "Управление", Count: 5},
+		{Key: "department", Name: "Управление / Отдел", Count: 3, ParentKey: "directorate"},
+		{Key: "", Name: "Без подразделения", Count: 1},
+	}
+
+	report, err := svc.GetDocumentReport("2026-01-01", "2026-01-31", "department", "", "", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "department", store.lastDocumentReportGroupBy)
+	assert.Equal(t, 6, report.Total, "subordinate rows are already included in their parents")
+	assert.Len(t, report.Rows, 3)
+}
+
 func TestStatisticsService_GetDocumentFilterOptions(t *testing.T) {
 	svc, store, _, _ := setupStatisticsService(t, models.SystemPermissionStatsDocuments)
 	store.nomenclatureOptions = []models.StatisticsOption{{Value: "nom-1", Label: "01-01"}}
@@ -272,6 +300,7 @@ func TestStatisticsService_GetAssignmentStatistics(t *testing.T) {
 	}}
 	store.assignmentOverdue = []models.StatisticsReportRow{{Key: "user-1", Name: "Исполнитель", Count: 1}}
 	store.assignmentStatuses = []models.StatisticsReportRow{{Key: "completed", Count: 5}}
+	store.assignmentLevels = []models.StatisticsReportRow{{Key: "1", Name: "1", Count: 2}, {Key: "3", Name: "3", Count: 1}}
 
 	stats, err := svc.GetAssignmentStatistics()
 
@@ -282,6 +311,28 @@ func TestStatisticsService_GetAssignmentStatistics(t *testing.T) {
 	assert.Equal(t, "Апр", stats.MonthlyByExecutor[3].Period)
 	assert.Equal(t, "Исполнено", stats.StatusCounts[0].Name)
 	assert.Equal(t, store.assignmentOverdue, stats.OverdueRating)
+	assert.Equal(t, models.AssignmentDeadlineBasisCurrent, store.lastDeadlineBasis)
+	assert.Equal(t, []models.StatisticsReportRow{
+		{Key: "1", Name: "Поручения по документам", Count: 2},
+		{Key: "3", Name: "Подпоручения 2 уровня", Count: 1},
+	}, stats.OverdueByLevel)
+}
+
+func TestStatisticsService_GetAssignmentOverdueRatingBasis(t *testing.T) {
+	svc, store, _, _ := setupStatisticsService(t, models.SystemPermissionStatsAssignments)
+	store.assignmentOverdue = []models.StatisticsReportRow{{Key: "user-1", Name: "Исполнитель", Count: 2}}
+
+	rows, err := svc.GetAssignmentOverdueRating(models.AssignmentDeadlineBasisOriginal)
+	require.NoError(t, err)
+	assert.Equal(t, store.assignmentOverdue, rows)
+	assert.Equal(t, models.AssignmentDeadlineBasisOriginal, store.lastDeadlineBasis)
+
+	_, err = svc.GetAssignmentOverdueRating("")
+	require.NoError(t, err)
+	assert.Equal(t, models.AssignmentDeadlineBasisCurrent, store.lastDeadlineBasis)
+
+	_, err = svc.GetAssignmentOverdueRating("planned")
+	requireAppError(t, err, "VALIDATION_ERROR", 400, "базис")
 }
 
 func TestStatisticsService_GetAssignmentReportAndFilters(t *testing.T) {
@@ -309,6 +360,25 @@ func TestStatisticsService_GetAssignmentReportAndFilters(t *testing.T) {
 	assert.Nil(t, report)
 }
 
+func TestStatisticsService_GetAssignmentSeriesReport(t *testing.T) {
+	svc, store, _, _ := setupStatisticsService(t, models.SystemPermissionStatsAssignments)
+	store.assignmentSeries = []models.AssignmentSeriesStatisticsRow{
+		{SeriesID: uuid.New().String(), Schedule: models.AssignmentSchedule{Kind: models.AssignmentScheduleWorkingDay, WorkingDay: 5, MonthInterval: 1}, Total: 3, Completed: 2, Open: 1, Overdue: 1},
+		{SeriesID: uuid.New().String(), Schedule: models.AssignmentSchedule{Kind: models.AssignmentScheduleCron, Cron: "1 * *"}, Total: 2, Completed: 2},
+	}
+
+	report, err := svc.GetAssignmentSeriesReport("2026-01-01", "2026-06-30")
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 1, report.Overdue)
+	require.Len(t, report.Rows, 2)
+	assert.Equal(t, "5-й рабочий день месяца", report.Rows[0].ScheduleLabel)
+	assert.Equal(t, "по расписанию «1 * *»", report.Rows[1].ScheduleLabel)
+
+	_, err = svc.GetAssignmentSeriesReport("2026-06-30", "2026-01-01")
+	require.Error(t, err)
+}
+
 func TestStatisticsService_GetSystemStatistics(t *testing.T) {
 	svc, store, _, _ := setupStatisticsService(t, models.SystemPermissionStatsSystem)
 	store.systemUserCount = 4
@@ -333,6 +403,31 @@ func TestStatisticsService_GetSystemStatistics(t *testing.T) {
 	assert.Zero(t, stats.StorageObjects)
 }
 
+type integrityStatisticsStore struct {
+	*fakeStatisticsStore
+	summary models.AttachmentIntegritySummary
+}
+
+func (s *integrityStatisticsStore) GetAttachmentIntegritySummary() (models.AttachmentIntegritySummary, error) {
+	return s.summary, nil
+}
+
+func TestStatisticsService_GetSystemStatisticsIncludesAttachmentIntegrity(t *testing.T) {
+	svc, store, _, _ := setupStatisticsService(t, models.SystemPermissionStatsSystem)
+	store.storageSnapshot = models.StorageStatisticsSnapshot{RefreshedAt: time.Now()}
+
+	stats, err := svc.GetSystemStatistics()
+	require.NoError(t, err)
+	assert.Nil(t, stats.AttachmentIntegrity)
+
+	svc.repo = &integrityStatisticsStore{fakeStatisticsStore: store, summary: models.AttachmentIntegritySummary{OK: 10, Corrupted: 1, Missing: 2}}
+	stats, err = svc.GetSystemStatistics()
+	require.NoError(t, err)
+	require.NotNil(t, stats.AttachmentIntegrity)
+	assert.Equal(t, 1, stats.AttachmentIntegrity.Corrupted)
+	assert.Equal(t, 2, stats.AttachmentIntegrity.Missing)
+}
+
 func TestStatisticsService_GetSystemStatisticsStartsStaleStorageRefreshInBackground(t *testing.T) {
 	svc, store, storage, _ := setupStatisticsService(t, models.SystemPermissionStatsSystem)
 	store.storageSnapshot = models.StorageStatisticsSnapshot{
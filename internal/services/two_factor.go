@@ -0,0 +1,507 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
+)
+
+// pendingSecondFactorTTL — сколько после проверки пароля ждать код второго
+// фактора или подключения TOTP.
+const pendingSecondFactorTTL = 5 * time.Minute
+
+// secondFactor — что требуется после проверки пароля.
+type secondFactor int
+
+const (
+	secondFactorNone secondFactor = iota
+	// secondFactorVerify — код TOTP или код восстановления.
+	secondFactorVerify
+	// secondFactorEnroll — второй фактор обязателен, но не подключен.
+	secondFactorEnroll
+)
+
+// pendingLogin — вход, ожидающий второго фактора. Пароль уже проверен,
+// сеанс еще не открыт.
+type pendingLogin struct {
+	userID    uuid.UUID
+	factor    secondFactor
+	expiresAt time.Time
+	failures  int
+}
+
+// SetTwoFactorStore подключает хранилище второго фактора. Без него второй
+// фактор не запрашивается.
+func (s *AuthService) SetTwoFactorStore(twoFactorRepo TwoFactorStore) {
+	s.twoFactorRepo = twoFactorRepo
+}
+
+// secondFactorFor определяет, что требуется от user после проверки пароля.
+func (s *AuthService) secondFactorFor(user *models.User) (secondFactor, error) {
+	if s.twoFactorRepo == nil {
+		return secondFactorNone, nil
+	}
+	factor, err := s.twoFactorRepo.GetTOTP(user.ID)
+	if err != nil {
+		return secondFactorNone, err
+	}
+	if factor.IsEnabled() {
+		return secondFactorVerify, nil
+	}
+	if s.isTwoFactorRequired(user) {
+		return secondFactorEnroll, nil
+	}
+	return secondFactorNone, nil
+}
+
+// isTwoFactorRequired сообщает, что у user есть системное право, для
+// которого настройкой требуется второй фактор.
+func (s *AuthService) isTwoFactorRequired(user *models.User) bool {
+	if s.settingsRepo == nil {
+		return false
+	}
+	value, ok := storeSettingLookup(s.settingsRepo)(models.SettingTwoFactorRequiredPermissions)
+	if !ok {
+		return false
+	}
+	required, err := models.ParseTwoFactorRequiredPermissions(value)
+	if err != nil {
+		return false
+	}
+	for _, permission := range required {
+		if slices.Contains(user.SystemPermissions, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// beginPendingLogin запоминает вход userID, ожидающий второго фактора.
+// Открытый сеанс завершается: как и Login, вход другого пользователя его
+// заменяет, а подключение TOTP не должно достаться прежнему пользователю.
+func (s *AuthService) beginPendingLogin(userID uuid.UUID, factor secondFactor) {
+	s.mu.Lock()
+	session := s.session
+	hadSession := s.currentUserID != uuid.Nil
+	s.currentUserID = uuid.Nil
+	s.session = desktopSession{}
+	s.pending = &pendingLogin{userID: userID, factor: factor, expiresAt: s.now().Add(pendingSecondFactorTTL)}
+	s.mu.Unlock()
+
+	if hadSession {
+		s.endSessionRecord(session.id, models.SessionEndReplaced)
+	}
+}
+
+// pendingLoginFor возвращает неистекший вход, ожидающий factor.
+func (s *AuthService) pendingLoginFor(factor secondFactor) (pendingLogin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil || s.pending.factor != factor {
+		return pendingLogin{}, false
+	}
+	if !s.now().Before(s.pending.expiresAt) {
+		s.pending = nil
+		return pendingLogin{}, false
+	}
+	return *s.pending, true
+}
+
+// countPendingFailure учитывает неверный код ожидающего входа. После
+// LockoutThreshold ошибок вход начинается заново с пароля.
+func (s *AuthService) countPendingFailure(userID uuid.UUID, policy security.PasswordPolicy) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil || s.pending.userID != userID {
+		return false
+	}
+	s.pending.failures++
+	if s.pending.failures >= policy.LockoutThreshold {
+		s.pending = nil
+		return false
+	}
+	return true
+}
+
+func (s *AuthService) clearPendingLogin(userID uuid.UUID) {
+	s.mu.Lock()
+	if s.pending != nil && s.pending.userID == userID {
+		s.pending = nil
+	}
+	s.mu.Unlock()
+}
+
+// VerifyTwoFactor завершает вход кодом из приложения-аутентификатора или
+// кодом восстановления (Wails binding). Вызывается после того, как Login
+// вернул TWO_FACTOR_REQUIRED.
+func (s *AuthService) VerifyTwoFactor(code string) (*dto.User, error) {
+	return measureOperation(s.metrics, "auth.verify_two_factor", func() (*dto.User, error) {
+		pending, ok := s.pendingLoginFor(secondFactorVerify)
+		if !ok {
+			return nil, ErrNotAuthenticated
+		}
+		user, err := s.pendingUser(pending)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.verifySecondFactor(user, code); err != nil {
+			switch {
+			case errors.Is(err, models.ErrInvalidTwoFactorCode):
+				if !s.countPendingFailure(user.ID, s.passwordPolicy()) {
+					return nil, ErrNotAuthenticated
+				}
+			case errors.Is(err, ErrUserLocked), errors.Is(err, ErrNotAuthenticated):
+				s.clearPendingLogin(user.ID)
+			}
+			return nil, err
+		}
+
+		s.clearPendingLogin(user.ID)
+		if err := s.openSession(user.ID); err != nil {
+			return nil, err
+		}
+		return dto.MapUser(user), nil
+	})
+}
+
+func (s *AuthService) pendingUser(pending pendingLogin) (*models.User, error) {
+	user, err := s.userRepo.GetByID(pending.userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		s.clearPendingLogin(pending.userID)
+		return nil, ErrNotAuthenticated
+	}
+	return user, nil
+}
+
+// verifySecondFactor проверяет код TOTP или код восстановления user. Неверный
+// код учитывается в счетчике неудачных попыток входа и блокирует после порога
+// учетные записи любого источника: второй фактор, в отличие от пароля
+// учетной записи каталога, проверяет само приложение. Верный код сбрасывает
+// счетчик.
+func (s *AuthService) verifySecondFactor(user *models.User, code string) error {
+	factor, err := s.twoFactorRepo.GetTOTP(user.ID)
+	if err != nil {
+		return err
+	}
+	if !factor.IsEnabled() {
+		// Второй фактор сброшен администратором после проверки пароля.
+		return ErrNotAuthenticated
+	}
+
+	var accepted bool
+	if security.IsTOTPCode(code) {
+		if step, ok := security.VerifyTOTP(factor.Secret, code, s.now(), factor.LastUsedStep); ok {
+			accepted, err = s.twoFactorRepo.ConsumeTOTPStep(user.ID, step)
+		}
+	} else if strings.TrimSpace(code) != "" {
+		accepted, err = s.twoFactorRepo.ConsumeRecoveryCode(user.ID, security.HashRecoveryCode(code))
+	}
+	if err != nil {
+		return err
+	}
+
+	if !accepted {
+		_, isActive, err := s.incrementFailedLoginAttempts(user, s.passwordPolicy())
+		if err != nil {
+			return err
+		}
+		if !isActive {
+			return ErrUserLocked
+		}
+		return models.ErrInvalidTwoFactorCode
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := s.userRepo.ResetFailedLoginAttempts(user.ID); err != nil {
+			return err
+		}
+		user.FailedLoginAttempts = 0
+	}
+	return nil
+}
+
+// enrollmentUser возвращает пользователя, подключающего второй фактор: вход,
+// ожидающий обязательного подключения, или пользователя текущего сеанса.
+func (s *AuthService) enrollmentUser() (*models.User, bool, error) {
+	if pending, ok := s.pendingLoginFor(secondFactorEnroll); ok {
+		user, err := s.pendingUser(pending)
+		return user, true, err
+	}
+	if s.twoFactorRepo == nil {
+		return nil, false, fmt.Errorf("two-factor store is not configured")
+	}
+	user, err := s.getActiveCurrentUser()
+	return user, false, err
+}
+
+// BeginTwoFactorEnrollment создает новый секрет TOTP и возвращает QR-код для
+// приложения-аутентификатора (Wails binding). Доступно в текущем сеансе и
+// после того, как Login вернул TWO_FACTOR_ENROLLMENT_REQUIRED.
+func (s *AuthService) BeginTwoFactorEnrollment() (*dto.TwoFactorEnrollment, error) {
+	user, _, err := s.enrollmentUser()
+	if err != nil {
+		return nil, err
+	}
+	key, err := security.GenerateTOTPKey(user.Login)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.SavePendingTOTP(user.ID, key.Secret); err != nil {
+		return nil, err
+	}
+	return &dto.TwoFactorEnrollment{Secret: key.Secret, URL: key.URL, QRCode: key.QRCode}, nil
+}
+
+// ConfirmTwoFactorEnrollment подключает второй фактор после проверки первого
+// кода из приложения и выдает коды восстановления (Wails binding). Если
+// подключение было обязательным при входе, открывает сеанс.
+func (s *AuthService) ConfirmTwoFactorEnrollment(code string) (*dto.TwoFactorEnrollmentResult, error) {
+	return measureOperation(s.metrics, "auth.confirm_two_factor", func() (*dto.TwoFactorEnrollmentResult, error) {
+		user, completesLogin, err := s.enrollmentUser()
+		if err != nil {
+			return nil, err
+		}
+		factor, err := s.twoFactorRepo.GetTOTP(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if factor == nil || factor.IsEnabled() {
+			return nil, models.NewConflict("подключение двухфакторной аутентификации не начато")
+		}
+		step, ok := security.VerifyTOTP(factor.Secret, code, s.now(), factor.LastUsedStep)
+		if !ok {
+			return nil, models.ErrInvalidTwoFactorCode
+		}
+
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			return nil, err
+		}
+		event, err := twoFactorAuditEvent(user, user.ID, userDisplayName(user), "enabled", "USER_2FA_ENABLED",
+			fmt.Sprintf("Пользователь «%s» (%s) подключил двухфакторную аутентификацию", userDisplayName(user), user.Login))
+		if err != nil {
+			return nil, err
+		}
+		if err := s.twoFactorRepo.EnableTOTPWithOutbox(user.ID, step, hashes, []models.OutboxEvent{event}); err != nil {
+			return nil, err
+		}
+
+		result := &dto.TwoFactorEnrollmentResult{RecoveryCodes: codes}
+		if completesLogin {
+			s.clearPendingLogin(user.ID)
+			if err := s.openSession(user.ID); err != nil {
+				return nil, err
+			}
+			result.User = dto.MapUser(user)
+		}
+		return result, nil
+	})
+}
+
+// GetTwoFactorStatus возвращает состояние второго фактора текущего
+// пользователя (Wails binding).
+func (s *AuthService) GetTwoFactorStatus() (*dto.TwoFactorStatus, error) {
+	user, err := s.getActiveCurrentUser()
+	if err != nil {
+		return nil, err
+	}
+	status := &dto.TwoFactorStatus{Required: s.isTwoFactorRequired(user)}
+	if s.twoFactorRepo == nil {
+		return status, nil
+	}
+	factor, err := s.twoFactorRepo.GetTOTP(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !factor.IsEnabled() {
+		return status, nil
+	}
+	status.Enabled = true
+	status.EnabledAt = factor.EnabledAt
+	status.RecoveryCodesLeft, err = s.twoFactorRepo.CountRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления текущего пользователя
+// новыми после проверки кода второго фактора (Wails binding).
+func (s *AuthService) RegenerateRecoveryCodes(code string) ([]string, error) {
+	user, err := s.getActiveCurrentUser()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.confirmSecondFactor(user, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor отключает второй фактор текущего пользователя после
+// проверки кода (Wails binding). Недоступно, если второй фактор обязателен
+// для учетной записи.
+func (s *AuthService) DisableTwoFactor(code string) error {
+	user, err := s.getActiveCurrentUser()
+	if err != nil {
+		return err
+	}
+	if s.isTwoFactorRequired(user) {
+		return models.NewForbidden("двухфакторная аутентификация обязательна для вашей учетной записи")
+	}
+	if err := s.confirmSecondFactor(user, code); err != nil {
+		return err
+	}
+	event, err := twoFactorAuditEvent(user, user.ID, userDisplayName(user), "disabled", "USER_2FA_DISABLED",
+		fmt.Sprintf("Пользователь «%s» (%s) отключил двухфакторную аутентификацию", userDisplayName(user), user.Login))
+	if err != nil {
+		return err
+	}
+	_, err = s.twoFactorRepo.DeleteWithOutbox(user.ID, []models.OutboxEvent{event})
+	return err
+}
+
+// confirmSecondFactor подтверждает изменение второго фактора пользователем
+// текущего сеанса его кодом. Блокировка после неверных кодов завершает сеанс.
+func (s *AuthService) confirmSecondFactor(user *models.User, code string) error {
+	errNotEnabled := models.NewConflict("двухфакторная аутентификация не подключена")
+	if s.twoFactorRepo == nil {
+		return errNotEnabled
+	}
+	factor, err := s.twoFactorRepo.GetTOTP(user.ID)
+	if err != nil {
+		return err
+	}
+	if !factor.IsEnabled() {
+		return errNotEnabled
+	}
+	if err := s.verifySecondFactor(user, code); err != nil {
+		if errors.Is(err, ErrUserLocked) {
+			s.dropSession(user.ID, models.SessionEndUserInactive)
+		}
+		return err
+	}
+	return nil
+}
+
+// ResetTwoFactor отключает второй фактор пользователя, потерявшего
+// приложение-аутентификатор и коды восстановления (только для
+// администраторов). При обязательном втором факторе пользователь подключит
+// его заново при следующем входе.
+func (s *UserService) ResetTwoFactor(userID string) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	uid, err := parseUUID(userID)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(uid)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return models.NewNotFound("пользователь не найден")
+	}
+	if s.auth.twoFactorRepo == nil {
+		return fmt.Errorf("two-factor store is not configured")
+	}
+
+	adminID, adminName := s.auth.GetCurrentAuditInfo()
+	event, err := twoFactorAuditEvent(user, adminID, adminName, "reset", "USER_2FA_RESET",
+		fmt.Sprintf("Сброшена двухфакторная аутентификация пользователя «%s» (%s)", userDisplayName(user), user.Login))
+	if err != nil {
+		return err
+	}
+	reset, err := s.auth.twoFactorRepo.DeleteWithOutbox(uid, []models.OutboxEvent{event})
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return models.NewConflict("у пользователя не подключена двухфакторная аутентификация")
+	}
+	return nil
+}
+
+func twoFactorAuditEvent(user *models.User, actorID uuid.UUID, actorName, keySuffix, action, details string) (models.OutboxEvent, error) {
+	return NewAdminAuditOutboxEvent("user:"+user.ID.String()+":2fa-"+keySuffix+":"+uuid.NewString(), models.CreateAdminAuditLogRequest{
+		UserID:   actorID,
+		UserName: actorName,
+		Action:   action,
+		Details:  details,
+	})
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := security.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = security.HashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+func userDisplayName(user *models.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return user.Login
+}
+
+// maxListedLoginsWithoutTOTP ограничивает число логинов в сообщении об отказе.
+const maxListedLoginsWithoutTOTP = 10
+
+// SetTwoFactorCoverageStore подключает проверку того, что обязательный второй
+// фактор уже подключен у всех, кого касается настройка.
+func (s *SettingsService) SetTwoFactorCoverageStore(store TwoFactorCoverageStore) {
+	s.twoFactors = store
+}
+
+// checkTwoFactorCoverage отклоняет обязательный второй фактор для прав, владельцы
+// которых, включая самого администратора, его еще не подключили: клиент не
+// умеет подключать второй фактор при входе, и такие пользователи не смогли бы войти.
+func (s *SettingsService) checkTwoFactorCoverage(key, value string) error {
+	if key != models.SettingTwoFactorRequiredPermissions {
+		return nil
+	}
+	permissions, err := models.ParseTwoFactorRequiredPermissions(value)
+	if err != nil || len(permissions) == 0 {
+		return err
+	}
+	if s.twoFactors == nil {
+		return models.NewConflict("Обязательный второй фактор нельзя включить: проверка подключения второго фактора недоступна")
+	}
+	logins, err := s.twoFactors.GetLoginsWithoutTOTP(permissions)
+	if err != nil {
+		return err
+	}
+	if len(logins) == 0 {
+		return nil
+	}
+	listed := strings.Join(logins, ", ")
+	if len(logins) > maxListedLoginsWithoutTOTP {
+		listed = fmt.Sprintf("%s и еще %d", strings.Join(logins[:maxListedLoginsWithoutTOTP], ", "), len(logins)-maxListedLoginsWithoutTOTP)
+	}
+	return models.NewConflict("Обязательный второй фактор нельзя включить, пока его не подключат все затронутые пользователи: " + listed)
+}
@@ -0,0 +1,426 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/mocks"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
+)
+
+type twoFactorStoreStub struct {
+	factors map[uuid.UUID]*models.UserTOTP
+	codes   map[uuid.UUID]map[string]bool
+	effects []models.OutboxEvent
+	getErr  error
+}
+
+func newTwoFactorStoreStub() *twoFactorStoreStub {
+	return &twoFactorStoreStub{factors: map[uuid.UUID]*models.UserTOTP{}, codes: map[uuid.UUID]map[string]bool{}}
+}
+
+func (s *twoFactorStoreStub) GetTOTP(userID uuid.UUID) (*models.UserTOTP, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	factor, ok := s.factors[userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *factor
+	return &copied, nil
+}
+
+func (s *twoFactorStoreStub) SavePendingTOTP(userID uuid.UUID, secret string) error {
+	if s.factors[userID].IsEnabled() {
+		return models.NewConflict("двухфакторная аутентификация уже подключена")
+	}
+	s.factors[userID] = &models.UserTOTP{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (s *twoFactorStoreStub) EnableTOTPWithOutbox(userID uuid.UUID, step int64, codeHashes []string, effects []models.OutboxEvent) error {
+	factor, ok := s.factors[userID]
+	if !ok || factor.IsEnabled() || factor.LastUsedStep >= step {
+		return models.NewConflict("подключение двухфакторной аутентификации не начато или уже завершено")
+	}
+	enabledAt := time.Now()
+	factor.EnabledAt = &enabledAt
+	factor.LastUsedStep = step
+	if err := s.ReplaceRecoveryCodes(userID, codeHashes); err != nil {
+		return err
+	}
+	s.effects = append(s.effects, effects...)
+	return nil
+}
+
+func (s *twoFactorStoreStub) ConsumeTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	factor, ok := s.factors[userID]
+	if !ok || !factor.IsEnabled() || factor.LastUsedStep >= step {
+		return false, nil
+	}
+	factor.LastUsedStep = step
+	return true, nil
+}
+
+func (s *twoFactorStoreStub) ConsumeRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	used, ok := s.codes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	s.codes[userID][codeHash] = true
+	return true, nil
+}
+
+func (s *twoFactorStoreStub) CountRecoveryCodes(userID uuid.UUID) (int, error) {
+	count := 0
+	for _, used := range s.codes[userID] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *twoFactorStoreStub) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	s.codes[userID] = map[string]bool{}
+	for _, hash := range codeHashes {
+		s.codes[userID][hash] = false
+	}
+	return nil
+}
+
+func (s *twoFactorStoreStub) DeleteWithOutbox(userID uuid.UUID, effects []models.OutboxEvent) (bool, error) {
+	factor, ok := s.factors[userID]
+	delete(s.factors, userID)
+	delete(s.codes, userID)
+	if !ok || !factor.IsEnabled() {
+		return false, nil
+	}
+	s.effects = append(s.effects, effects...)
+	return true, nil
+}
+
+// enable подключает пользователю второй фактор с кодом восстановления recoveryCode.
+func (s *twoFactorStoreStub) enable(t *testing.T, userID uuid.UUID, recoveryCode string) string {
+	t.Helper()
+	key, err := security.GenerateTOTPKey("testuser")
+	require.NoError(t, err)
+	enabledAt := time.Now()
+	s.factors[userID] = &models.UserTOTP{UserID: userID, Secret: key.Secret, EnabledAt: &enabledAt}
+	s.codes[userID] = map[string]bool{security.HashRecoveryCode(recoveryCode): false}
+	return key.Secret
+}
+
+func (s *twoFactorStoreStub) auditRequest(t *testing.T, i int) models.CreateAdminAuditLogRequest {
+	t.Helper()
+	require.Greater(t, len(s.effects), i)
+	var request models.CreateAdminAuditLogRequest
+	require.NoError(t, json.Unmarshal([]byte(s.effects[i].Payload), &request))
+	return request
+}
+
+// staticAuthenticator — внешний источник, принимающий любой пароль user.
+type staticAuthenticator struct {
+	user *models.User
+}
+
+func (a *staticAuthenticator) AuthSource() string { return a.user.AuthSource }
+
+func (a *staticAuthenticator) Authenticate(context.Context, string, string) (*models.User, error) {
+	copied := *a.user
+	return &copied, nil
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCode(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+func setupTwoFactorAuth(t *testing.T, settings map[string]string) (*AuthService, *mocks.UserStore, *twoFactorStoreStub, *sessionTestClock) {
+	t.Helper()
+	userRepo := mocks.NewUserStore(t)
+	store := newTwoFactorStoreStub()
+	clock := &sessionTestClock{now: time.Now()}
+	auth := NewAuthService(nil, userRepo)
+	auth.SetSettingsStore(newPolicySettingsStore(t, settings))
+	auth.SetTwoFactorStore(store)
+	auth.now = clock.Now
+	return auth, userRepo, store, clock
+}
+
+func TestAuthService_TwoFactorLogin(t *testing.T) {
+	t.Run("session opens only after a valid code", func(t *testing.T) {
+		auth, userRepo, store, clock := setupTwoFactorAuth(t, nil)
+		user, password := newTestUser()
+		secret := store.enable(t, user.ID, "abcde-fghjk")
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+		assert.False(t, auth.IsAuthenticated())
+
+		result, err := auth.VerifyTwoFactor(totpCode(t, secret, clock.now))
+		require.NoError(t, err)
+		assert.Equal(t, user.Login, result.Login)
+		assert.True(t, auth.IsAuthenticated())
+	})
+
+	t.Run("code and recovery code cannot be reused", func(t *testing.T) {
+		auth, userRepo, store, clock := setupTwoFactorAuth(t, nil)
+		user, password := newTestUser()
+		secret := store.enable(t, user.ID, "abcde-fghjk")
+		userRepo.On("GetByLogin", user.Login).Return(user, nil)
+		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+		userRepo.On("IncrementFailedLoginAttempts", user.ID, 5).Return(1, true, nil).Twice()
+		code := totpCode(t, secret, clock.now)
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+		_, err = auth.VerifyTwoFactor(code)
+		require.NoError(t, err)
+
+		require.NoError(t, auth.Logout())
+		_, err = auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+		_, err = auth.VerifyTwoFactor(code)
+		assert.Equal(t, models.ErrInvalidTwoFactorCode, err)
+
+		_, err = auth.VerifyTwoFactor("ABCDE FGHJK")
+		require.NoError(t, err, "recovery code is case and separator insensitive")
+
+		require.NoError(t, auth.Logout())
+		_, err = auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+		_, err = auth.VerifyTwoFactor("abcde-fghjk")
+		assert.Equal(t, models.ErrInvalidTwoFactorCode, err)
+		assert.False(t, auth.IsAuthenticated())
+	})
+
+	t.Run("wrong codes lock the account", func(t *testing.T) {
+		auth, userRepo, store, _ := setupTwoFactorAuth(t, nil)
+		user, password := newTestUser()
+		store.enable(t, user.ID, "abcde-fghjk")
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+		userRepo.On("IncrementFailedLoginAttempts", user.ID, 5).Return(5, false, nil).Once()
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+		_, err = auth.VerifyTwoFactor("000000")
+		assert.Equal(t, ErrUserLocked, err)
+
+		_, err = auth.VerifyTwoFactor("000000")
+		assert.Equal(t, ErrNotAuthenticated, err, "locked login must start over")
+	})
+
+	t.Run("wrong codes lock directory accounts", func(t *testing.T) {
+		auth, userRepo, store, _ := setupTwoFactorAuth(t, nil)
+		user := &models.User{ID: uuid.New(), Login: "ivanov", FullName: "Иванов И.И.", AuthSource: "ldap", ExternalID: "ivanov", IsActive: true}
+		store.enable(t, user.ID, "abcde-fghjk")
+		directory := &staticAuthenticator{user: user}
+		auth.SetAuthenticator(directory, false)
+		userRepo.On("GetByLogin", user.Login).Return(user, nil)
+		userRepo.On("IncrementFailedLoginAttempts", user.ID, 5).Return(5, false, nil).Once()
+
+		_, err := AuthenticateCredentials(auth, user.Login, "directory-secret", "000000")
+		assert.Equal(t, ErrUserLocked, err)
+
+		directory.user = &models.User{ID: user.ID, Login: user.Login, AuthSource: "ldap", ExternalID: "ivanov", FailedLoginAttempts: 5}
+		_, err = AuthenticateCredentials(auth, user.Login, "directory-secret", "")
+		assert.Equal(t, ErrUserLocked, err, "directory password does not bypass the lockout")
+	})
+
+	t.Run("undecryptable secret is not a wrong code", func(t *testing.T) {
+		auth, userRepo, store, clock := setupTwoFactorAuth(t, nil)
+		user, password := newTestUser()
+		secret := store.enable(t, user.ID, "abcde-fghjk")
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+		store.getErr = models.NewTwoFactorUnavailable(errors.New("encryption key is not set"))
+
+		_, err = auth.VerifyTwoFactor(totpCode(t, secret, clock.now))
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, "TWO_FACTOR_UNAVAILABLE", appErr.Kind)
+		userRepo.AssertNotCalled(t, "IncrementFailedLoginAttempts", user.ID, 5)
+		assert.False(t, auth.IsAuthenticated())
+	})
+
+	t.Run("password alone does not reset failed attempts", func(t *testing.T) {
+		auth, userRepo, store, clock := setupTwoFactorAuth(t, nil)
+		user, password := newTestUser()
+		user.FailedLoginAttempts = 2
+		secret := store.enable(t, user.ID, "abcde-fghjk")
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+		userRepo.AssertNotCalled(t, "ResetFailedLoginAttempts", user.ID)
+
+		userRepo.On("ResetFailedLoginAttempts", user.ID).Return(nil).Once()
+		_, err = auth.VerifyTwoFactor(totpCode(t, secret, clock.now))
+		require.NoError(t, err)
+	})
+
+	t.Run("pending login expires", func(t *testing.T) {
+		auth, userRepo, store, clock := setupTwoFactorAuth(t, nil)
+		user, password := newTestUser()
+		secret := store.enable(t, user.ID, "abcde-fghjk")
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+		clock.Advance(pendingSecondFactorTTL)
+
+		_, err = auth.VerifyTwoFactor(totpCode(t, secret, clock.now))
+		assert.Equal(t, ErrNotAuthenticated, err)
+		assert.False(t, auth.IsAuthenticated())
+	})
+
+	t.Run("http credentials carry the code", func(t *testing.T) {
+		auth, userRepo, store, clock := setupTwoFactorAuth(t, nil)
+		user, password := newTestUser()
+		secret := store.enable(t, user.ID, "abcde-fghjk")
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Twice()
+
+		_, err := AuthenticateCredentials(auth, user.Login, password, "")
+		assert.Equal(t, models.ErrTwoFactorRequired, err)
+
+		result, err := AuthenticateCredentials(auth, user.Login, password, totpCode(t, secret, clock.now))
+		require.NoError(t, err)
+		assert.Equal(t, user.Login, result.Login)
+		assert.False(t, auth.IsAuthenticated())
+	})
+}
+
+func TestAuthService_TwoFactorEnrollment(t *testing.T) {
+	required := map[string]string{models.SettingTwoFactorRequiredPermissions: "admin"}
+
+	t.Run("required enrollment completes the login", func(t *testing.T) {
+		auth, userRepo, store, clock := setupTwoFactorAuth(t, required)
+		user, password := newTestUser()
+		user.SystemPermissions = []string{models.SystemPermissionAdmin}
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+
+		_, err := auth.Login(user.Login, password)
+		assert.Equal(t, models.ErrTwoFactorEnrollment, err)
+		assert.False(t, auth.IsAuthenticated())
+
+		enrollment, err := auth.BeginTwoFactorEnrollment()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(enrollment.QRCode, "data:image/png;base64,"))
+		assert.Contains(t, enrollment.URL, "secret="+enrollment.Secret)
+
+		_, err = auth.ConfirmTwoFactorEnrollment("000000")
+		assert.Equal(t, models.ErrInvalidTwoFactorCode, err)
+
+		result, err := auth.ConfirmTwoFactorEnrollment(totpCode(t, enrollment.Secret, clock.now))
+		require.NoError(t, err)
+		require.Len(t, result.RecoveryCodes, security.RecoveryCodeCount)
+		require.NotNil(t, result.User)
+		assert.True(t, auth.IsAuthenticated())
+
+		for _, code := range result.RecoveryCodes {
+			used, stored := store.codes[user.ID][security.HashRecoveryCode(code)]
+			assert.True(t, stored)
+			assert.False(t, used)
+			_, plaintext := store.codes[user.ID][code]
+			assert.False(t, plaintext)
+		}
+		audit := store.auditRequest(t, 0)
+		assert.Equal(t, "USER_2FA_ENABLED", audit.Action)
+		assert.Equal(t, user.ID, audit.UserID)
+
+		userRepo.On("GetSessionPrincipal", user.ID).Return(&models.SessionPrincipal{ID: user.ID, IsActive: true}, nil).Maybe()
+		requireAppError(t, auth.DisableTwoFactor("000000"), "FORBIDDEN", 403, "обязательна")
+	})
+
+	t.Run("user without required permission logs in with password", func(t *testing.T) {
+		auth, userRepo, _, _ := setupTwoFactorAuth(t, required)
+		user, password := newTestUser()
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+
+		_, err := auth.Login(user.Login, password)
+		require.NoError(t, err)
+		assert.True(t, auth.IsAuthenticated())
+	})
+
+	t.Run("voluntary enrollment and disable", func(t *testing.T) {
+		auth, userRepo, store, clock := setupTwoFactorAuth(t, nil)
+		user, password := newTestUser()
+		userRepo.On("GetByLogin", user.Login).Return(user, nil).Once()
+		userRepo.On("GetByID", user.ID).Return(user, nil).Maybe()
+		userRepo.On("GetSessionPrincipal", user.ID).Return(&models.SessionPrincipal{ID: user.ID, IsActive: true}, nil).Maybe()
+		_, err := auth.Login(user.Login, password)
+		require.NoError(t, err)
+
+		enrollment, err := auth.BeginTwoFactorEnrollment()
+		require.NoError(t, err)
+		result, err := auth.ConfirmTwoFactorEnrollment(totpCode(t, enrollment.Secret, clock.now))
+		require.NoError(t, err)
+		assert.Nil(t, result.User)
+
+		status, err := auth.GetTwoFactorStatus()
+		require.NoError(t, err)
+		assert.True(t, status.Enabled)
+		assert.False(t, status.Required)
+		assert.Equal(t, security.RecoveryCodeCount, status.RecoveryCodesLeft)
+
+		_, err = auth.BeginTwoFactorEnrollment()
+		appErr, ok := models.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, 409, appErr.Code)
+
+		clock.Advance(30 * time.Second)
+		require.NoError(t, auth.DisableTwoFactor(totpCode(t, enrollment.Secret, clock.now)))
+		assert.Equal(t, "USER_2FA_DISABLED", store.auditRequest(t, 1).Action)
+		assert.Empty(t, store.factors)
+		assert.Empty(t, store.codes)
+	})
+}
+
+func TestUserService_ResetTwoFactor(t *testing.T) {
+	t.Run("admin reset is audited", func(t *testing.T) {
+		svc, repo := setupUserService(t, "admin")
+		store := newTwoFactorStoreStub()
+		svc.auth.SetTwoFactorStore(store)
+		target := &models.User{ID: uuid.New(), Login: "ivanov", FullName: "Иванов И.И.", IsActive: true}
+		store.enable(t, target.ID, "abcde-fghjk")
+		repo.On("GetByID", target.ID).Return(target, nil)
+
+		require.NoError(t, svc.ResetTwoFactor(target.ID.String()))
+		assert.NotContains(t, store.factors, target.ID)
+		assert.NotContains(t, store.codes, target.ID)
+		audit := store.auditRequest(t, 0)
+		assert.Equal(t, "USER_2FA_RESET", audit.Action)
+		assert.Contains(t, audit.Details, "Иванов И.И.")
+		assert.NotEqual(t, target.ID, audit.UserID, "audit records the administrator")
+
+		requireAppError(t, svc.ResetTwoFactor(target.ID.String()), "CONFLICT", 409, "не подключена")
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		svc, _ := setupUserService(t, "clerk")
+		svc.auth.SetTwoFactorStore(newTwoFactorStoreStub())
+		assert.Equal(t, models.ErrForbidden, svc.ResetTwoFactor(uuid.NewString()))
+	})
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/google/uuid"
@@ -80,20 +81,18 @@ func documentNumberLabel(number string) string {
 	return number
 }
 
-func eventActorID(auth *AuthService) *uuid.UUID {
-	if auth == nil {
+func eventActorID(ctx context.Context) *uuid.UUID {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.UserID == uuid.Nil {
 		return nil
 	}
-	currentUserID, err := auth.GetCurrentUserUUID()
-	if err != nil || currentUserID == uuid.Nil {
-		return nil
-	}
-	return &currentUserID
+	actorID := principal.UserID
+	return &actorID
 }
 
-func eventActorExcluded(auth *AuthService) map[uuid.UUID]struct{} {
+func eventActorExcluded(ctx context.Context) map[uuid.UUID]struct{} {
 	excluded := make(map[uuid.UUID]struct{})
-	actorID := eventActorID(auth)
+	actorID := eventActorID(ctx)
 	if actorID != nil {
 		excluded[*actorID] = struct{}{}
 	}
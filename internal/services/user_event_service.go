@@ -35,9 +35,10 @@ func (s *UserEventService) create(req models.CreateUserEventRequest) (*dto.UserE
 	return dto.MapUserEvent(event), err
 }
 
-// GetCurrentUserEvents возвращает события текущего пользователя.
+// GetCurrentUserEvents возвращает события текущего пользователя. Список
+// опрашивается интерфейсом периодически, поэтому вызов не продлевает сеанс.
 func (s *UserEventService) GetCurrentUserEvents(filter models.UserEventFilter) (*dto.PagedResult[dto.UserEvent], error) {
-	userID, err := s.currentUserUUID()
+	userID, err := s.pollUserUUID()
 	if err != nil {
 		return nil, err
 	}
@@ -60,9 +61,10 @@ func (s *UserEventService) GetCurrentUserEvents(filter models.UserEventFilter) (
 	}, nil
 }
 
-// GetUnreadCount возвращает количество непрочитанных событий текущего пользователя.
+// GetUnreadCount возвращает количество непрочитанных событий текущего
+// пользователя. Как и GetCurrentUserEvents, не продлевает сеанс.
 func (s *UserEventService) GetUnreadCount() (int, error) {
-	userID, err := s.currentUserUUID()
+	userID, err := s.pollUserUUID()
 	if err != nil {
 		return 0, err
 	}
@@ -110,3 +112,10 @@ func (s *UserEventService) currentUserUUID() (uuid.UUID, error) {
 	}
 	return s.auth.GetCurrentUserUUID()
 }
+
+func (s *UserEventService) pollUserUUID() (uuid.UUID, error) {
+	if s.auth == nil {
+		return uuid.Nil, ErrNotAuthenticated
+	}
+	return s.auth.pollCurrentUserUUID()
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
-	"github.com/Volkov-D-A/docs-register-and-track/internal/security"
 )
 
 // UserService предоставляет бизнес-логику для управления пользователями.
@@ -23,6 +22,12 @@ type userOutboxStore interface {
 
 var errUserOutboxStoreRequired = fmt.Errorf("user store must support atomic outbox operations")
 
+type departmentExecutorStore interface {
+	GetDepartmentExecutors(departmentID uuid.UUID) ([]models.User, error)
+}
+
+var errDepartmentExecutorStoreRequired = fmt.Errorf("user store must support department executor lookup")
+
 func (s *UserService) auditEffect(key, action, details string) (models.OutboxEvent, error) {
 	userID, userName := s.auth.GetCurrentAuditInfo()
 	return NewAdminAuditOutboxEvent(key, models.CreateAdminAuditLogRequest{UserID: userID, UserName: userName, Action: action, Details: details})
@@ -51,14 +56,17 @@ func (s *UserService) CreateUser(req models.CreateUserRequest) (*dto.User, error
 		return nil, err
 	}
 
+	policy := s.auth.passwordPolicy()
 	temporaryPassword := ""
 	if req.Password == "" {
-		generatedPassword, err := security.GenerateTemporaryPassword()
+		generatedPassword, err := policy.GenerateTemporary()
 		if err != nil {
 			return nil, err
 		}
 		req.Password = generatedPassword
 		temporaryPassword = generatedPassword
+	} else if err := policy.Validate(req.Password); err != nil {
+		return nil, wrapPasswordPolicyError(err)
 	}
 	req.PasswordChangeRequired = true
 
@@ -125,6 +133,12 @@ func (s *UserService) ResetPassword(userID string, newPassword string) error {
 	if user == nil {
 		return models.NewNotFound("пользователь не найден")
 	}
+	if user.IsDirectoryUser() {
+		return errDirectoryPasswordChange
+	}
+	if err := s.auth.passwordPolicy().Validate(newPassword); err != nil {
+		return wrapPasswordPolicyError(err)
+	}
 
 	targetUserName := user.FullName
 	if targetUserName == "" {
@@ -156,6 +170,24 @@ func (s *UserService) GetExecutors() ([]dto.User, error) {
 	return dto.MapUsers(res), err
 }
 
+// GetDepartmentExecutors возвращает активных исполнителей подразделения и всех его
+// подчиненных подразделений — для выбора исполнителя в пределах структуры руководителя.
+func (s *UserService) GetDepartmentExecutors(departmentID string) ([]dto.User, error) {
+	if err := s.auth.RequireAuthenticated(); err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(departmentID)
+	if err != nil {
+		return nil, models.NewBadRequestWrapped("неверный ID отдела", err)
+	}
+	store, ok := s.userRepo.(departmentExecutorStore)
+	if !ok {
+		return nil, errDepartmentExecutorStoreRequired
+	}
+	res, err := store.GetDepartmentExecutors(uid)
+	return dto.MapUsers(res), err
+}
+
 // GetSubstitutionCandidates возвращает активных пользователей, которых можно выбрать замещающими.
 func (s *UserService) GetSubstitutionCandidates() ([]dto.User, error) {
 	if err := s.auth.RequireAuthenticated(); err != nil {
@@ -318,3 +318,27 @@ func TestUserService_GetExecutors(t *testing.T) {
 		assert.Nil(t, result)
 	})
 }
+
+func TestUserService_GetDepartmentExecutors(t *testing.T) {
+	// Исполнители подразделения вместе с подчиненными подразделениями
+	departmentID := uuid.New()
+
+	t.Run("success", func(t *testing.T) {
+		svc, repo := setupUserService(t, "executor")
+		repo.On("GetDepartmentExecutors", departmentID).Return([]models.User{{ID: uuid.New()}, {ID: uuid.New()}}, nil).Once()
+
+		result, err := svc.GetDepartmentExecutors(departmentID.String())
+
+		require.NoError(t, err)
+		assert.Len(t, result, 2)
+	})
+
+	t.Run("invalid department id", func(t *testing.T) {
+		svc, _ := setupUserService(t, "executor")
+
+		result, err := svc.GetDepartmentExecutors("bad")
+
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неверный ID отдела")
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/dto"
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// sessionExpiryInterval — период закрытия сеансов, превысивших максимальную
+// длительность. Работающий клиент закрывает свой сеанс сам; проход нужен для
+// клиентов, завершившихся аварийно.
+const sessionExpiryInterval = 5 * time.Minute
+
+// UserSessionService предоставляет администратору журнал сеансов и
+// принудительное завершение сеанса.
+type UserSessionService struct {
+	repo UserSessionStore
+	auth *AuthService
+}
+
+// NewUserSessionService создает новый экземпляр UserSessionService.
+func NewUserSessionService(repo UserSessionStore, auth *AuthService) *UserSessionService {
+	return &UserSessionService{repo: repo, auth: auth}
+}
+
+// GetSessions возвращает страницу журнала сеансов (только для администраторов).
+func (s *UserSessionService) GetSessions(filter models.UserSessionFilter) (*dto.PagedResult[dto.UserSession], error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	result, err := s.repo.GetList(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	items := dto.MapUserSessions(result.Items)
+	currentID := s.auth.currentSessionID()
+	for i := range items {
+		items[i].IsCurrent = currentID != uuid.Nil && result.Items[i].ID == currentID
+	}
+	return &dto.PagedResult[dto.UserSession]{
+		Items:      items,
+		TotalCount: result.TotalCount,
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+	}, nil
+}
+
+// TerminateSession принудительно завершает открытый сеанс (только для
+// администраторов). Клиент узнает о завершении при следующей сверке с
+// журналом, не позже чем через sessionSyncInterval.
+func (s *UserSessionService) TerminateSession(id string) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return models.NewBadRequestWrapped("неверный ID сеанса", err)
+	}
+	if sessionID == s.auth.currentSessionID() {
+		return models.NewBadRequest("текущий сеанс завершается выходом из системы")
+	}
+	session, err := s.repo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return models.NewNotFound("сеанс не найден")
+	}
+	if !session.IsActive() {
+		return models.NewConflict("сеанс уже завершен")
+	}
+
+	adminID, adminName := s.auth.GetCurrentAuditInfo()
+	host := session.HostName
+	if host == "" {
+		host = "неизвестно"
+	}
+	event, err := NewAdminAuditOutboxEvent("session:"+session.ID.String()+":terminate", models.CreateAdminAuditLogRequest{
+		UserID:   adminID,
+		UserName: adminName,
+		Action:   "SESSION_TERMINATE",
+		Details: fmt.Sprintf("Завершен сеанс пользователя «%s» (%s), рабочее место: %s, начат %s",
+			session.UserFullName, session.UserLogin, host, session.StartedAt.Local().Format("02.01.2006 15:04")),
+	})
+	if err != nil {
+		return err
+	}
+	return s.repo.TerminateWithOutbox(session.ID, adminID, []models.OutboxEvent{event})
+}
+
+// RunExpiry периодически закрывает сеансы, превысившие максимальную
+// длительность. Метод блокируется до отмены ctx.
+func (s *UserSessionService) RunExpiry(ctx context.Context) {
+	ticker := time.NewTicker(sessionExpiryInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.expireSessions(); err != nil && ctx.Err() == nil {
+			slog.Warn("user session expiry failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *UserSessionService) expireSessions() error {
+	_, absolute := s.auth.sessionTimeouts()
+	if absolute <= 0 {
+		return nil
+	}
+	now := s.auth.now()
+	expired, err := s.repo.ExpireStartedBefore(now.Add(-absolute), now)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		slog.Info("expired user sessions closed", "count", expired)
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,21 +13,34 @@ import (
 	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
 )
 
+// substitutionExpiryInterval — период проверки замещений, которые скоро закончатся.
+const substitutionExpiryInterval = time.Hour
+
 // UserSubstitutionService управляет замещающими исполнителями пользователя.
+// У пользователя может быть несколько замещающих одновременно, каждый — на
+// свой период, по своим видам документов и областям действий.
 type UserSubstitutionService struct {
 	repo     UserSubstitutionStore
 	userRepo UserStore
 	auth     *AuthService
+	now      func() time.Time
 }
 type userSubstitutionOutboxStore interface {
 	ReplaceForPrincipalWithOutbox(uuid.UUID, *uuid.UUID, *time.Time, *time.Time, bool, *uuid.UUID, []models.OutboxEvent) (*models.UserSubstitution, error)
+	CreateWithOutbox(substitution models.UserSubstitution, effects []models.OutboxEvent) (*models.UserSubstitution, error)
+	RevokeWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error
+}
+
+type userSubstitutionExpiryStore interface {
+	GetPendingExpiryNotifications() ([]models.UserSubstitution, error)
+	MarkExpiryNotifiedWithOutbox(id uuid.UUID, effects []models.OutboxEvent) (bool, error)
 }
 
 var errUserSubstitutionOutboxStoreRequired = fmt.Errorf("user substitution store must support atomic outbox operations")
 
 // NewUserSubstitutionService создает сервис замещений.
 func NewUserSubstitutionService(repo UserSubstitutionStore, userRepo UserStore, auth *AuthService) *UserSubstitutionService {
-	return &UserSubstitutionService{repo: repo, userRepo: userRepo, auth: auth}
+	return &UserSubstitutionService{repo: repo, userRepo: userRepo, auth: auth, now: time.Now}
 }
 
 func parseOptionalSubstitutionDate(value string) (*time.Time, error) {
@@ -38,23 +54,23 @@ func parseOptionalSubstitutionDate(value string) (*time.Time, error) {
 	return &t, nil
 }
 
-func (s *UserSubstitutionService) validateSubstitution(principal *models.User, req models.UpdateUserSubstitutionRequest) (*uuid.UUID, *time.Time, *time.Time, error) {
-	startsAt, err := parseOptionalSubstitutionDate(req.StartsAt)
+func (s *UserSubstitutionService) validateSubstitution(principal *models.User, substituteUserID, startsAtValue, endsAtValue string) (*uuid.UUID, *time.Time, *time.Time, error) {
+	startsAt, err := parseOptionalSubstitutionDate(startsAtValue)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	endsAt, err := parseOptionalSubstitutionDate(req.EndsAt)
+	endsAt, err := parseOptionalSubstitutionDate(endsAtValue)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	if startsAt != nil && endsAt != nil && startsAt.After(*endsAt) {
 		return nil, nil, nil, models.NewBadRequest("дата начала замещения не может быть позже даты окончания")
 	}
-	if req.SubstituteUserID == "" {
+	if substituteUserID == "" {
 		return nil, startsAt, endsAt, nil
 	}
 
-	substituteID, err := parseUUID(req.SubstituteUserID)
+	substituteID, err := parseUUID(substituteUserID)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -126,7 +142,7 @@ func (s *UserSubstitutionService) saveForPrincipal(principalID uuid.UUID, req mo
 		return nil, models.NewBadRequest("замещение доступно только участникам документооборота")
 	}
 
-	substituteID, startsAt, endsAt, err := s.validateSubstitution(principal, req)
+	substituteID, startsAt, endsAt, err := s.validateSubstitution(principal, req.SubstituteUserID, req.StartsAt, req.EndsAt)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +184,8 @@ func (s *UserSubstitutionService) GetMySubstitution() (*dto.UserSubstitution, er
 	return dto.MapUserSubstitution(res), err
 }
 
-// UpdateMySubstitution обновляет замещающего текущего пользователя.
+// UpdateMySubstitution назначает текущему пользователю единственного замещающего:
+// действующие и запланированные замещения отменяются и остаются в истории.
 func (s *UserSubstitutionService) UpdateMySubstitution(req models.UpdateUserSubstitutionRequest) (*dto.UserSubstitution, error) {
 	userID, err := s.auth.GetCurrentUserUUID()
 	if err != nil {
@@ -190,7 +207,8 @@ func (s *UserSubstitutionService) GetUserSubstitution(userID string) (*dto.UserS
 	return dto.MapUserSubstitution(res), err
 }
 
-// UpdateUserSubstitution обновляет замещение выбранного пользователя. Доступно администратору.
+// UpdateUserSubstitution назначает выбранному пользователю единственного
+// замещающего, как UpdateMySubstitution. Доступно администратору.
 func (s *UserSubstitutionService) UpdateUserSubstitution(req models.UpdateUserSubstitutionRequest) (*dto.UserSubstitution, error) {
 	principalID, err := parseUUID(req.PrincipalUserID)
 	if err != nil {
@@ -198,3 +216,288 @@ func (s *UserSubstitutionService) UpdateUserSubstitution(req models.UpdateUserSu
 	}
 	return s.saveForPrincipal(principalID, req, true)
 }
+
+// GetMySubstitutions возвращает историю замещений текущего пользователя:
+// запланированные, действующие, завершенные и отмененные.
+func (s *UserSubstitutionService) GetMySubstitutions() ([]dto.UserSubstitution, error) {
+	userID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetHistoryByPrincipalID(userID)
+	return dto.MapUserSubstitutions(res), err
+}
+
+// AddMySubstitution добавляет текущему пользователю замещающего, не затрагивая
+// остальные замещения.
+func (s *UserSubstitutionService) AddMySubstitution(req models.CreateUserSubstitutionRequest) (*dto.UserSubstitution, error) {
+	userID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return nil, err
+	}
+	return s.addForPrincipal(userID, req, false)
+}
+
+// RevokeMySubstitution отменяет замещение текущего пользователя.
+func (s *UserSubstitutionService) RevokeMySubstitution(id string) error {
+	userID, err := s.auth.GetCurrentUserUUID()
+	if err != nil {
+		return err
+	}
+	substitution, err := s.getSubstitution(id)
+	if err != nil {
+		return err
+	}
+	if substitution.PrincipalUserID != userID {
+		return models.NewNotFound("замещение не найдено")
+	}
+	return s.repo.Revoke(substitution.ID)
+}
+
+// GetUserSubstitutions возвращает историю замещений выбранного пользователя. Доступно администратору.
+func (s *UserSubstitutionService) GetUserSubstitutions(userID string) ([]dto.UserSubstitution, error) {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return nil, err
+	}
+	uid, err := parseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetHistoryByPrincipalID(uid)
+	return dto.MapUserSubstitutions(res), err
+}
+
+// AddUserSubstitution добавляет замещающего выбранному пользователю. Доступно администратору.
+func (s *UserSubstitutionService) AddUserSubstitution(req models.CreateUserSubstitutionRequest) (*dto.UserSubstitution, error) {
+	principalID, err := parseUUID(req.PrincipalUserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.addForPrincipal(principalID, req, true)
+}
+
+// RevokeUserSubstitution отменяет замещение выбранного пользователя. Доступно администратору.
+func (s *UserSubstitutionService) RevokeUserSubstitution(id string) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	substitution, err := s.getSubstitution(id)
+	if err != nil {
+		return err
+	}
+	store, ok := s.repo.(userSubstitutionOutboxStore)
+	if !ok {
+		return errUserSubstitutionOutboxStoreRequired
+	}
+	actorID, actorName := s.auth.GetCurrentAuditInfo()
+	event, err := NewAdminAuditOutboxEvent("user-substitution:"+substitution.ID.String()+":revoke", models.CreateAdminAuditLogRequest{
+		UserID:   actorID,
+		UserName: actorName,
+		Action:   "USER_SUBSTITUTION_REVOKE",
+		Details:  fmt.Sprintf("Отменено замещение пользователя «%s» пользователем «%s»", substitution.PrincipalName, substitution.SubstituteName),
+	})
+	if err != nil {
+		return err
+	}
+	return store.RevokeWithOutbox(substitution.ID, []models.OutboxEvent{event})
+}
+
+func (s *UserSubstitutionService) getSubstitution(id string) (*models.UserSubstitution, error) {
+	uid, err := parseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+	substitution, err := s.repo.GetByID(uid)
+	if err != nil {
+		return nil, err
+	}
+	if substitution == nil {
+		return nil, models.NewNotFound("замещение не найдено")
+	}
+	if substitution.RevokedAt != nil {
+		return nil, models.NewConflict("замещение уже отменено")
+	}
+	return substitution, nil
+}
+
+func (s *UserSubstitutionService) addForPrincipal(principalID uuid.UUID, req models.CreateUserSubstitutionRequest, requireAdmin bool) (*dto.UserSubstitution, error) {
+	if requireAdmin {
+		if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+			return nil, err
+		}
+	} else if err := s.auth.RequireAuthenticated(); err != nil {
+		return nil, err
+	}
+	if req.SubstituteUserID == "" {
+		return nil, models.NewBadRequest("укажите замещающего")
+	}
+
+	principal, err := s.userRepo.GetByID(principalID)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, models.NewNotFound("пользователь не найден")
+	}
+	if !principal.IsDocumentParticipant {
+		return nil, models.NewBadRequest("замещение доступно только участникам документооборота")
+	}
+	substituteID, startsAt, endsAt, err := s.validateSubstitution(principal, req.SubstituteUserID, req.StartsAt, req.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	if endsAt != nil && endsAt.Format("2006-01-02") < s.now().Format("2006-01-02") {
+		return nil, models.NewBadRequest("дата окончания замещения уже прошла")
+	}
+	scopes, err := normalizeSubstitutionScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	documentKinds, err := normalizeSubstitutionDocumentKinds(req.DocumentKinds)
+	if err != nil {
+		return nil, err
+	}
+
+	actorID, actorName := s.auth.GetCurrentAuditInfo()
+	substitution := models.UserSubstitution{
+		PrincipalUserID:  principalID,
+		SubstituteUserID: *substituteID,
+		StartsAt:         startsAt,
+		EndsAt:           endsAt,
+		IsActive:         true,
+		DocumentKinds:    documentKinds,
+		Scopes:           scopes,
+	}
+	if actorID != uuid.Nil {
+		substitution.CreatedBy = &actorID
+	}
+	var res *models.UserSubstitution
+	if requireAdmin {
+		store, ok := s.repo.(userSubstitutionOutboxStore)
+		if !ok {
+			return nil, errUserSubstitutionOutboxStoreRequired
+		}
+		event, buildErr := NewAdminAuditOutboxEvent("user-substitution:"+principalID.String()+":add:"+uuid.NewString(), models.CreateAdminAuditLogRequest{
+			UserID:   actorID,
+			UserName: actorName,
+			Action:   "USER_SUBSTITUTION_ADD",
+			Details:  fmt.Sprintf("Добавлено замещение пользователя «%s»", principal.FullName),
+		})
+		if buildErr != nil {
+			return nil, buildErr
+		}
+		res, err = store.CreateWithOutbox(substitution, []models.OutboxEvent{event})
+	} else {
+		res, err = s.repo.Create(substitution)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapUserSubstitution(res), nil
+}
+
+func normalizeSubstitutionScopes(values []string) ([]string, error) {
+	scopes := make([]string, 0, len(values))
+	for _, scope := range values {
+		if !models.IsSubstitutionScope(scope) {
+			return nil, models.NewBadRequest(fmt.Sprintf("неизвестная область замещения: %s", scope))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func normalizeSubstitutionDocumentKinds(values []string) ([]string, error) {
+	kinds := make([]string, 0, len(values))
+	for _, value := range values {
+		kind := models.NormalizeDocumentKind(value)
+		if _, ok := models.GetDocumentKindSpec(kind); !ok {
+			return nil, models.NewBadRequest(fmt.Sprintf("неизвестный вид документа: %s", value))
+		}
+		if !slices.Contains(kinds, string(kind)) {
+			kinds = append(kinds, string(kind))
+		}
+	}
+	return kinds, nil
+}
+
+// RunExpiryNotifications периодически уведомляет замещаемого и замещающего о
+// том, что замещение заканчивается. Метод блокируется до отмены ctx.
+func (s *UserSubstitutionService) RunExpiryNotifications(ctx context.Context) {
+	ticker := time.NewTicker(substitutionExpiryInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.notifyExpiringSubstitutions(); err != nil && ctx.Err() == nil {
+			slog.Warn("user substitution expiry notification failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *UserSubstitutionService) notifyExpiringSubstitutions() error {
+	store, ok := s.repo.(userSubstitutionExpiryStore)
+	if !ok {
+		return nil
+	}
+	substitutions, err := store.GetPendingExpiryNotifications()
+	if err != nil {
+		return err
+	}
+	for i := range substitutions {
+		effects, err := substitutionExpiryEffects(&substitutions[i], s.now())
+		if err != nil {
+			return err
+		}
+		if _, err := store.MarkExpiryNotifiedWithOutbox(substitutions[i].ID, effects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// substitutionExpiryEffects готовит уведомления замещаемому и замещающему об
+// окончании замещения.
+func substitutionExpiryEffects(substitution *models.UserSubstitution, now time.Time) ([]models.OutboxEvent, error) {
+	if substitution.EndsAt == nil {
+		return nil, nil
+	}
+	when := "завтра"
+	if substitution.EndsAt.Format("2006-01-02") <= now.Format("2006-01-02") {
+		when = "сегодня"
+	}
+	endsAt := substitution.EndsAt.Format("02.01.2006")
+	recipients := []struct {
+		userID  uuid.UUID
+		message string
+	}{
+		{substitution.PrincipalUserID, fmt.Sprintf("Замещение пользователем «%s» заканчивается %s, %s", substitution.SubstituteName, when, endsAt)},
+		{substitution.SubstituteUserID, fmt.Sprintf("Замещение пользователя «%s» заканчивается %s, %s", substitution.PrincipalName, when, endsAt)},
+	}
+	effects := make([]models.OutboxEvent, 0, len(recipients))
+	for _, recipient := range recipients {
+		event, err := NewUserEventOutboxEvent("user-substitution:"+substitution.ID.String()+":expiring:"+recipient.userID.String()+":user_event", models.CreateUserEventRequest{
+			RecipientUserID: recipient.userID,
+			EntityType:      models.UserEventEntitySubstitution,
+			EntityID:        substitution.ID,
+			EventType:       models.UserEventSubstitutionExpiring,
+			Title:           "Замещение заканчивается",
+			Message:         recipient.message,
+			Metadata: userEventMetadata(map[string]string{
+				"principalUserId":  substitution.PrincipalUserID.String(),
+				"substituteUserId": substitution.SubstituteUserID.String(),
+				"endsAt":           substitution.EndsAt.Format("2006-01-02"),
+			}),
+		})
+		if err != nil {
+			return nil, err
+		}
+		effects = append(effects, event)
+	}
+	return effects, nil
+}
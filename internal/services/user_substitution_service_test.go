@@ -42,6 +42,16 @@ func (s *atomicUserSubstitutionStore) ReplaceForPrincipalWithOutbox(
 	return s.userSubstitutionStoreStub.ReplaceForPrincipal(principalUserID, substituteUserID, startsAt, endsAt, isActive, createdBy)
 }
 
+func (s *atomicUserSubstitutionStore) CreateWithOutbox(substitution models.UserSubstitution, effects []models.OutboxEvent) (*models.UserSubstitution, error) {
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	return s.userSubstitutionStoreStub.Create(substitution)
+}
+
+func (s *atomicUserSubstitutionStore) RevokeWithOutbox(id uuid.UUID, effects []models.OutboxEvent) error {
+	s.effects = append([]models.OutboxEvent(nil), effects...)
+	return s.userSubstitutionStoreStub.Revoke(id)
+}
+
 func TestUserSubstitutionService_UpdateMySubstitution(t *testing.T) {
 	t.Run("allows active substitute from same department without document participant flag", func(t *testing.T) {
 		departmentID := uuid.New()
@@ -145,6 +155,143 @@ func TestUserSubstitutionServiceUpdateUserSubstitutionPassesAuditEffectToAtomicS
 	assert.Equal(t, models.OutboxEventAudit, atomicStore.effects[0].EventType)
 }
 
+type expiringUserSubstitutionStore struct {
+	*userSubstitutionStoreStub
+	pending  []models.UserSubstitution
+	notified map[uuid.UUID][]models.OutboxEvent
+}
+
+func (s *expiringUserSubstitutionStore) GetPendingExpiryNotifications() ([]models.UserSubstitution, error) {
+	return s.pending, nil
+}
+
+func (s *expiringUserSubstitutionStore) MarkExpiryNotifiedWithOutbox(id uuid.UUID, effects []models.OutboxEvent) (bool, error) {
+	if _, ok := s.notified[id]; ok {
+		return false, nil
+	}
+	s.notified[id] = effects
+	return true, nil
+}
+
+func TestUserSubstitutionService_AddMySubstitution(t *testing.T) {
+	departmentID := uuid.New()
+	newUsers := func() (*models.User, *models.User, *models.User) {
+		principal := &models.User{ID: uuid.New(), IsActive: true, IsDocumentParticipant: true, DepartmentID: &departmentID}
+		first := &models.User{ID: uuid.New(), IsActive: true, DepartmentID: &departmentID}
+		second := &models.User{ID: uuid.New(), IsActive: true, DepartmentID: &departmentID}
+		return principal, first, second
+	}
+
+	t.Run("keeps concurrent substitutes with their own scopes", func(t *testing.T) {
+		principal, first, second := newUsers()
+		svc, store, userRepo, _ := setupUserSubstitutionService(t, principal)
+		svc.now = func() time.Time { return time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC) }
+		userRepo.On("GetByID", first.ID).Return(first, nil).Once()
+		userRepo.On("GetByID", second.ID).Return(second, nil).Once()
+
+		_, err := svc.AddMySubstitution(models.CreateUserSubstitutionRequest{
+			SubstituteUserID: first.ID.String(),
+			EndsAt:           "2026-06-10",
+			DocumentKinds:    []string{"incoming"},
+		})
+		require.NoError(t, err)
+		result, err := svc.AddMySubstitution(models.CreateUserSubstitutionRequest{
+			SubstituteUserID: second.ID.String(),
+			StartsAt:         "2026-06-05",
+			Scopes:           []string{models.SubstitutionScopeAcknowledgments, models.SubstitutionScopeAcknowledgments},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, store.items, 2)
+		assert.Equal(t, []string{string(models.DocumentKindIncomingLetter)}, store.items[0].DocumentKinds)
+		assert.Equal(t, []string{models.SubstitutionScopeAcknowledgments}, result.Scopes)
+		assert.Empty(t, store.replaceCalls)
+	})
+
+	t.Run("rejects unknown scope and finished period", func(t *testing.T) {
+		principal, first, _ := newUsers()
+		svc, store, userRepo, _ := setupUserSubstitutionService(t, principal)
+		svc.now = func() time.Time { return time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC) }
+		userRepo.On("GetByID", first.ID).Return(first, nil).Twice()
+
+		_, err := svc.AddMySubstitution(models.CreateUserSubstitutionRequest{SubstituteUserID: first.ID.String(), Scopes: []string{"everything"}})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "неизвестная область замещения")
+		_, err = svc.AddMySubstitution(models.CreateUserSubstitutionRequest{SubstituteUserID: first.ID.String(), EndsAt: "2026-05-31"})
+		requireAppError(t, err, "VALIDATION_ERROR", 400, "дата окончания замещения уже прошла")
+		assert.Empty(t, store.items)
+	})
+}
+
+func TestUserSubstitutionService_RevokeMySubstitution(t *testing.T) {
+	principal := &models.User{ID: uuid.New(), IsActive: true}
+	svc, store, _, _ := setupUserSubstitutionService(t, principal)
+	own, err := store.Create(models.UserSubstitution{PrincipalUserID: principal.ID, SubstituteUserID: uuid.New(), IsActive: true})
+	require.NoError(t, err)
+	foreign, err := store.Create(models.UserSubstitution{PrincipalUserID: uuid.New(), SubstituteUserID: uuid.New(), IsActive: true})
+	require.NoError(t, err)
+
+	requireAppError(t, svc.RevokeMySubstitution(foreign.ID.String()), "NOT_FOUND", 404, "замещение не найдено")
+	require.NoError(t, svc.RevokeMySubstitution(own.ID.String()))
+	requireAppError(t, svc.RevokeMySubstitution(own.ID.String()), "CONFLICT", 409, "уже отменено")
+
+	history, err := svc.GetMySubstitutions()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, models.SubstitutionStatusRevoked, history[0].Status)
+}
+
+func TestUserSubstitutionService_AdminAddAndRevokeAreAudited(t *testing.T) {
+	departmentID := uuid.New()
+	admin := &models.User{ID: uuid.New(), FullName: "Администратор", IsActive: true}
+	principal := &models.User{ID: uuid.New(), FullName: "Основной пользователь", IsActive: true, IsDocumentParticipant: true, DepartmentID: &departmentID}
+	substitute := &models.User{ID: uuid.New(), IsActive: true, DepartmentID: &departmentID}
+	svc, store, userRepo, _ := setupUserSubstitutionService(t, admin)
+	atomicStore := &atomicUserSubstitutionStore{userSubstitutionStoreStub: store}
+	svc.repo = atomicStore
+	userRepo.On("GetByID", principal.ID).Return(principal, nil).Once()
+	userRepo.On("GetByID", substitute.ID).Return(substitute, nil).Once()
+
+	added, err := svc.AddUserSubstitution(models.CreateUserSubstitutionRequest{
+		PrincipalUserID:  principal.ID.String(),
+		SubstituteUserID: substitute.ID.String(),
+		Scopes:           []string{models.SubstitutionScopeApprovals},
+	})
+	require.NoError(t, err)
+	require.Len(t, atomicStore.effects, 1)
+	assert.Contains(t, atomicStore.effects[0].Payload, "USER_SUBSTITUTION_ADD")
+
+	require.NoError(t, svc.RevokeUserSubstitution(added.ID))
+	require.Len(t, atomicStore.effects, 1)
+	assert.Contains(t, atomicStore.effects[0].Payload, "USER_SUBSTITUTION_REVOKE")
+	assert.Equal(t, []uuid.UUID{store.items[0].ID}, store.revokeCalls)
+}
+
+func TestUserSubstitutionService_NotifyExpiringSubstitutions(t *testing.T) {
+	svc, stub, _, _ := setupUserSubstitutionService(t, nil)
+	svc.now = func() time.Time { return time.Date(2026, 6, 9, 8, 0, 0, 0, time.UTC) }
+	endsAt := time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC)
+	substitution := models.UserSubstitution{
+		ID:               uuid.New(),
+		PrincipalUserID:  uuid.New(),
+		SubstituteUserID: uuid.New(),
+		PrincipalName:    "Основной",
+		SubstituteName:   "Заместитель",
+		EndsAt:           &endsAt,
+		IsActive:         true,
+	}
+	store := &expiringUserSubstitutionStore{userSubstitutionStoreStub: stub, pending: []models.UserSubstitution{substitution}, notified: map[uuid.UUID][]models.OutboxEvent{}}
+	svc.repo = store
+
+	require.NoError(t, svc.notifyExpiringSubstitutions())
+	require.NoError(t, svc.notifyExpiringSubstitutions())
+
+	effects := store.notified[substitution.ID]
+	assert.ElementsMatch(t, []uuid.UUID{substitution.PrincipalUserID, substitution.SubstituteUserID}, userEventRecipients(t, effects, models.UserEventSubstitutionExpiring))
+	for _, effect := range effects {
+		assert.Contains(t, effect.Payload, "заканчивается завтра, 10.06.2026")
+	}
+}
+
 func TestUserService_GetSubstitutionCandidates(t *testing.T) {
 	svc, repo := setupUserService(t, "executor")
 	expected := []models.User{{ID: uuid.New(), FullName: "Active user", IsActive: true}}
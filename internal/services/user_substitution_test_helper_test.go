@@ -9,10 +9,13 @@ import (
 )
 
 type userSubstitutionStoreStub struct {
-	byPrincipal      map[uuid.UUID]*models.UserSubstitution
+	byPrincipal map[uuid.UUID]*models.UserSubstitution
+	// activePrincipals — замещаемые без ограничений по видам документов и областям.
 	activePrincipals []uuid.UUID
-	isActive         map[[2]uuid.UUID]bool
+	grants           []models.SubstitutionGrant
+	items            []models.UserSubstitution
 	replaceCalls     []userSubstitutionReplaceCall
+	revokeCalls      []uuid.UUID
 	err              error
 }
 
@@ -35,21 +38,81 @@ func (s *userSubstitutionStoreStub) GetByPrincipalID(principalUserID uuid.UUID)
 	return s.byPrincipal[principalUserID], nil
 }
 
-func (s *userSubstitutionStoreStub) GetActivePrincipalIDs(substituteUserID uuid.UUID) ([]uuid.UUID, error) {
+func (s *userSubstitutionStoreStub) GetByID(id uuid.UUID) (*models.UserSubstitution, error) {
 	if s.err != nil {
 		return nil, s.err
 	}
-	return s.activePrincipals, nil
+	for i := range s.items {
+		if s.items[i].ID == id {
+			item := s.items[i]
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *userSubstitutionStoreStub) GetHistoryByPrincipalID(principalUserID uuid.UUID) ([]models.UserSubstitution, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	items := make([]models.UserSubstitution, 0)
+	for _, item := range s.items {
+		if item.PrincipalUserID == principalUserID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (s *userSubstitutionStoreStub) GetActiveByPrincipalID(principalUserID uuid.UUID) ([]models.UserSubstitution, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	items := make([]models.UserSubstitution, 0)
+	if substitution := s.byPrincipal[principalUserID]; substitution != nil {
+		items = append(items, *substitution)
+	}
+	for _, item := range s.items {
+		if item.PrincipalUserID == principalUserID && item.StatusOn(time.Now()) == models.SubstitutionStatusActive {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (s *userSubstitutionStoreStub) GetActiveGrants(substituteUserID uuid.UUID) ([]models.SubstitutionGrant, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	grants := make([]models.SubstitutionGrant, 0, len(s.activePrincipals)+len(s.grants))
+	for _, principalID := range s.activePrincipals {
+		grants = append(grants, models.SubstitutionGrant{PrincipalUserID: principalID})
+	}
+	return append(grants, s.grants...), nil
+}
+
+func (s *userSubstitutionStoreStub) Create(substitution models.UserSubstitution) (*models.UserSubstitution, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	substitution.ID = uuid.New()
+	s.items = append(s.items, substitution)
+	return &substitution, nil
 }
 
-func (s *userSubstitutionStoreStub) IsActiveSubstitute(substituteUserID, principalUserID uuid.UUID) (bool, error) {
+func (s *userSubstitutionStoreStub) Revoke(id uuid.UUID) error {
 	if s.err != nil {
-		return false, s.err
+		return s.err
 	}
-	if s.isActive == nil {
-		return false, nil
+	s.revokeCalls = append(s.revokeCalls, id)
+	now := time.Now()
+	for i := range s.items {
+		if s.items[i].ID == id {
+			s.items[i].RevokedAt = &now
+			s.items[i].IsActive = false
+		}
 	}
-	return s.isActive[[2]uuid.UUID{substituteUserID, principalUserID}], nil
+	return nil
 }
 
 func (s *userSubstitutionStoreStub) ReplaceForPrincipal(
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/models"
+)
+
+// WorkingCalendarService управляет производственным календарем: праздничными
+// выходными и перенесенными рабочими днями.
+type WorkingCalendarService struct {
+	repo WorkingCalendarStore
+	auth *AuthService
+}
+
+// NewWorkingCalendarService создает сервис производственного календаря.
+func NewWorkingCalendarService(repo WorkingCalendarStore, auth *AuthService) *WorkingCalendarService {
+	return &WorkingCalendarService{repo: repo, auth: auth}
+}
+
+// GetDays возвращает исключения календаря за период.
+func (s *WorkingCalendarService) GetDays(fromStr, toStr string) ([]models.WorkingCalendarDay, error) {
+	if err := s.auth.RequireAuthenticated(); err != nil {
+		return nil, err
+	}
+	from, err := parseCommandDate(fromStr, "даты начала периода")
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseCommandDate(toStr, "даты окончания периода")
+	if err != nil {
+		return nil, err
+	}
+	if to.Before(from) {
+		return nil, models.NewBadRequest("дата окончания периода не может быть раньше даты начала")
+	}
+	return s.repo.GetDays(from, to)
+}
+
+// SetDay помечает дату как рабочую или выходную (только admin).
+func (s *WorkingCalendarService) SetDay(dayStr string, isWorkingDay bool, description string) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	day, err := parseCommandDate(dayStr, "даты календаря")
+	if err != nil {
+		return err
+	}
+
+	kind := "выходной"
+	if isWorkingDay {
+		kind = "рабочий"
+	}
+	userID, userName := s.auth.GetCurrentAuditInfo()
+	event, buildErr := NewAdminAuditOutboxEvent("working-calendar:"+day.Format("2006-01-02")+":set:"+uuid.NewString(), models.CreateAdminAuditLogRequest{UserID: userID, UserName: userName, Action: "WORKING_CALENDAR_UPDATE", Details: fmt.Sprintf("День %s отмечен как %s", day.Format("02.01.2006"), kind)})
+	if buildErr != nil {
+		return buildErr
+	}
+	return s.repo.SaveWithOutbox(models.WorkingCalendarDay{
+		Day:          day,
+		IsWorkingDay: isWorkingDay,
+		Description:  strings.TrimSpace(description),
+	}, []models.OutboxEvent{event})
+}
+
+// DeleteDay возвращает дате стандартный режим пятидневной недели (только admin).
+func (s *WorkingCalendarService) DeleteDay(dayStr string) error {
+	if err := s.auth.RequireSystemPermission(models.SystemPermissionAdmin); err != nil {
+		return err
+	}
+	day, err := parseCommandDate(dayStr, "даты календаря")
+	if err != nil {
+		return err
+	}
+
+	userID, userName := s.auth.GetCurrentAuditInfo()
+	event, buildErr := NewAdminAuditOutboxEvent("working-calendar:"+day.Format("2006-01-02")+":delete:"+uuid.NewString(), models.CreateAdminAuditLogRequest{UserID: userID, UserName: userName, Action: "WORKING_CALENDAR_DELETE", Details: fmt.Sprintf("Удалено исключение календаря на %s", day.Format("02.01.2006"))})
+	if buildErr != nil {
+		return buildErr
+	}
+	return s.repo.DeleteWithOutbox(day, []models.OutboxEvent{event})
+}
+
+// Calendar загружает календарь с исключениями за период для внутренних расчетов сроков.
+func (s *WorkingCalendarService) Calendar(from, to time.Time) (*models.WorkingCalendar, error) {
+	if s == nil || s.repo == nil {
+		return models.NewWorkingCalendar(nil), nil
+	}
+	days, err := s.repo.GetDays(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewWorkingCalendar(days), nil
+}
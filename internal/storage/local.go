@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	localObjectsDir = "objects"
+	localTempDir    = "tmp"
+)
+
+// LocalService хранит вложения в каталоге файловой системы.
+//
+// Объекты раскладываются по двум уровням каталогов по SHA-256 имени объекта,
+// а имя файла — base64url от имени объекта, поэтому имя можно восстановить
+// для сверки и миграции. Запись идет во временный файл на том же томе и
+// публикуется rename, так что читатель никогда не видит недописанный файл.
+type LocalService struct {
+	root string
+}
+
+// NewLocalService создает хранилище в каталоге root и готовит служебные подкаталоги.
+func NewLocalService(root string) (*LocalService, error) {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return nil, fmt.Errorf("local storage path is empty")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve local storage path: %w", err)
+	}
+	for _, dir := range []string{localObjectsDir, localTempDir} {
+		if err := os.MkdirAll(filepath.Join(absRoot, dir), 0o750); err != nil {
+			return nil, fmt.Errorf("failed to prepare local storage: %w", err)
+		}
+	}
+	return &LocalService{root: absRoot}, nil
+}
+
+// UploadFile атомарно записывает объект. Если size неотрицателен, поток должен
+// содержать ровно size байт, иначе объект не публикуется.
+func (l *LocalService) UploadFile(ctx context.Context, objectName string, data io.Reader, size int64, contentType string) error {
+	target, err := l.objectPath(objectName)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("failed to prepare object directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(l.root, localTempDir), "upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary object file: %w", err)
+	}
+	tmpName := tmp.Name()
+	published := false
+	defer func() {
+		if !published {
+			_ = tmp.Close()
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	reader := io.Reader(&contextReader{ctx: ctx, reader: data})
+	if size >= 0 {
+		reader = io.LimitReader(reader, size+1)
+	}
+	written, err := io.Copy(tmp, reader)
+	if err != nil {
+		return fmt.Errorf("failed to write object data: %w", err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("object size mismatch: expected %d bytes, got %d", size, written)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to flush object data: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary object file: %w", err)
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		return fmt.Errorf("failed to publish object: %w", err)
+	}
+	published = true
+	syncDir(filepath.Dir(target))
+	return nil
+}
+
+// DownloadFileToWriter streams a bounded object directly to writer.
+func (l *LocalService) DownloadFileToWriter(ctx context.Context, objectName string, writer io.Writer, maxSize int64) error {
+	path, err := l.objectPath(objectName)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to stat object: %w", ErrObjectNotFound)
+		}
+		return fmt.Errorf("failed to open object: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat object: %w", err)
+	}
+	if info.Size() > maxSize {
+		return &objectSizeError{size: info.Size(), maxSize: maxSize}
+	}
+
+	limited := io.LimitReader(&contextReader{ctx: ctx, reader: file}, maxSize+1)
+	written, err := io.Copy(writer, limited)
+	if err != nil {
+		return fmt.Errorf("failed to read object data: %w", err)
+	}
+	if written > maxSize {
+		return &objectSizeError{size: -1, maxSize: maxSize}
+	}
+	return nil
+}
+
+// DeleteFile удаляет объект. Удаление отсутствующего объекта не считается ошибкой,
+// как и в MinIO, чтобы повтор outbox-удаления был идемпотентным.
+func (l *LocalService) DeleteFile(ctx context.Context, objectName string) error {
+	path, err := l.objectPath(objectName)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove object: %w", err)
+	}
+	return nil
+}
+
+// GetStorageInfo возвращает число объектов и их суммарный размер.
+// Обход каталога дешевле листинга бакета, поэтому результат не кэшируется.
+func (l *LocalService) GetStorageInfo(ctx context.Context) (objectCount int, totalSize string, err error) {
+	count, size, err := l.RefreshStorageUsage(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	return count, formatSize(size), nil
+}
+
+// RefreshStorageInfo повторяет GetStorageInfo; метод нужен для паритета с MinioService.
+func (l *LocalService) RefreshStorageInfo(ctx context.Context) (objectCount int, totalSize string, err error) {
+	return l.GetStorageInfo(ctx)
+}
+
+// RefreshStorageUsage обходит каталог объектов и возвращает точный размер в байтах.
+func (l *LocalService) RefreshStorageUsage(ctx context.Context) (objectCount int, totalBytes int64, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err = l.walkObjects(ctx, func(_ string, info fs.FileInfo) {
+		objectCount++
+		totalBytes += info.Size()
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return objectCount, totalBytes, nil
+}
+
+// ListObjectNames is used by the read-only attachment reconciliation command.
+func (l *LocalService) ListObjectNames(ctx context.Context) ([]string, error) {
+	objects := make([]string, 0)
+	err := l.walkObjects(ctx, func(name string, _ fs.FileInfo) {
+		objects = append(objects, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (l *LocalService) walkObjects(ctx context.Context, visit func(name string, info fs.FileInfo)) error {
+	err := filepath.WalkDir(filepath.Join(l.root, localObjectsDir), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		name, decodeErr := base64.RawURLEncoding.DecodeString(entry.Name())
+		if decodeErr != nil {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		visit(string(name), info)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list objects in local storage: %w", err)
+	}
+	return nil
+}
+
+// objectPath возвращает путь файла объекта: objects/ab/cd/<base64url(name)>.
+func (l *LocalService) objectPath(objectName string) (string, error) {
+	if objectName == "" {
+		return "", fmt.Errorf("object name is empty")
+	}
+	sum := sha256.Sum256([]byte(objectName))
+	shard := hex.EncodeToString(sum[:2])
+	return filepath.Join(l.root, localObjectsDir, shard[:2], shard[2:], base64.RawURLEncoding.EncodeToString([]byte(objectName))), nil
+}
+
+// syncDir фиксирует rename на диске. Ошибка не критична: объект уже опубликован.
+func syncDir(dir string) {
+	handle, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = handle.Sync()
+	_ = handle.Close()
+}
+
+// contextReader прерывает копирование при отмене операции.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalServiceUploadDownloadDelete(t *testing.T) {
+	ctx := context.Background()
+	service, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, service.UploadFile(ctx, "a1.pdf", strings.NewReader("hello"), 5, "application/pdf"))
+
+	path, err := service.objectPath("a1.pdf")
+	require.NoError(t, err)
+	rel, err := filepath.Rel(service.root, path)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(rel, string(filepath.Separator)), 4, "objects/ab/cd/name")
+
+	var out bytes.Buffer
+	require.NoError(t, service.DownloadFileToWriter(ctx, "a1.pdf", &out, 5))
+	assert.Equal(t, "hello", out.String())
+
+	err = service.DownloadFileToWriter(ctx, "a1.pdf", &bytes.Buffer{}, 4)
+	assert.EqualError(t, err, "object size 5 exceeds maximum allowed size 4")
+	assert.ErrorIs(t, err, ErrObjectTooLarge)
+
+	require.NoError(t, service.DeleteFile(ctx, "a1.pdf"))
+	require.NoError(t, service.DeleteFile(ctx, "a1.pdf"))
+	err = service.DownloadFileToWriter(ctx, "a1.pdf", &bytes.Buffer{}, 5)
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+func TestLocalServiceUploadSizeMismatchLeavesNoObject(t *testing.T) {
+	ctx := context.Background()
+	service, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+
+	err = service.UploadFile(ctx, "short.pdf", strings.NewReader("abc"), 5, "application/pdf")
+	assert.EqualError(t, err, "object size mismatch: expected 5 bytes, got 3")
+	err = service.UploadFile(ctx, "long.pdf", strings.NewReader("abcdef"), 5, "application/pdf")
+	assert.EqualError(t, err, "object size mismatch: expected 5 bytes, got 6")
+
+	names, err := service.ListObjectNames(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+	tmpEntries, err := os.ReadDir(filepath.Join(service.root, localTempDir))
+	require.NoError(t, err)
+	assert.Empty(t, tmpEntries)
+}
+
+func TestLocalServiceUploadRespectsCancellation(t *testing.T) {
+	service, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = service.UploadFile(ctx, "a.pdf", strings.NewReader("data"), 4, "")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLocalServiceStorageInfoAndListing(t *testing.T) {
+	ctx := context.Background()
+	service, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, service.UploadFile(ctx, "b.pdf", strings.NewReader("1234"), 4, ""))
+	require.NoError(t, service.UploadFile(ctx, "nested/../odd name.docx", strings.NewReader("12"), -1, ""))
+	require.NoError(t, os.WriteFile(filepath.Join(service.root, localObjectsDir, "not base64!"), []byte("x"), 0o600))
+
+	names, err := service.ListObjectNames(ctx)
+	require.NoError(t, err)
+	sort.Strings(names)
+	assert.Equal(t, []string{"b.pdf", "nested/../odd name.docx"}, names)
+
+	count, size, err := service.RefreshStorageUsage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(6), size)
+
+	count, formatted, err := service.GetStorageInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "6 B", formatted)
+}
+
+func TestNewLocalServiceRequiresPath(t *testing.T) {
+	service, err := NewLocalService("  ")
+	require.Error(t, err)
+	assert.Nil(t, service)
+}
+
+func TestOpenSelectsDriver(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := New(config.Config{Storage: config.StorageConfig{Driver: "local", LocalPath: dir}})
+	require.NoError(t, err)
+	assert.IsType(t, &LocalService{}, backend)
+
+	_, err = New(config.Config{Storage: config.StorageConfig{Driver: "local"}})
+	require.Error(t, err)
+	backend, err = Open("s3", config.Config{})
+	require.Error(t, err)
+	assert.Nil(t, backend)
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// MigrationOptions задает параметры копирования объектов между хранилищами.
+type MigrationOptions struct {
+	// MaxObjectSize ограничивает размер одного объекта.
+	MaxObjectSize int64
+	// Overwrite разрешает заменить объект назначения с другим содержимым.
+	Overwrite bool
+	// Progress вызывается после обработки каждого объекта.
+	Progress func(objectName string, status MigrationStatus)
+}
+
+// MigrationStatus описывает результат переноса одного объекта.
+type MigrationStatus string
+
+const (
+	MigrationCopied  MigrationStatus = "copied"
+	MigrationSkipped MigrationStatus = "skipped"
+)
+
+// MigrationReport содержит итоги переноса.
+type MigrationReport struct {
+	Copied  int
+	Skipped int
+	Bytes   int64
+}
+
+// MigrateObjects копирует все объекты из src в dst. Содержимое каждого объекта
+// сверяется по SHA-256: объект назначения с той же суммой пропускается, а после
+// записи объект перечитывается из dst и сравнивается с исходным. Исходное
+// хранилище не изменяется, поэтому прерванную миграцию можно запустить повторно.
+func MigrateObjects(ctx context.Context, src, dst Backend, opts MigrationOptions) (*MigrationReport, error) {
+	if opts.MaxObjectSize <= 0 {
+		return nil, fmt.Errorf("max object size must be positive")
+	}
+	names, err := src.ListObjectNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{}
+	for _, name := range names {
+		status, size, err := migrateObject(ctx, src, dst, name, opts)
+		if err != nil {
+			return report, fmt.Errorf("object %s: %w", name, err)
+		}
+		switch status {
+		case MigrationCopied:
+			report.Copied++
+			report.Bytes += size
+		case MigrationSkipped:
+			report.Skipped++
+		}
+		if opts.Progress != nil {
+			opts.Progress(name, status)
+		}
+	}
+	return report, nil
+}
+
+func migrateObject(ctx context.Context, src, dst Backend, name string, opts MigrationOptions) (MigrationStatus, int64, error) {
+	tmp, err := os.CreateTemp("", "docflow-storage-migrate-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	sourceHash := sha256.New()
+	if err := src.DownloadFileToWriter(ctx, name, io.MultiWriter(tmp, sourceHash), opts.MaxObjectSize); err != nil {
+		return "", 0, fmt.Errorf("read source: %w", err)
+	}
+	sourceSum := sourceHash.Sum(nil)
+
+	existingSum, err := objectChecksum(ctx, dst, name, opts.MaxObjectSize)
+	switch {
+	case err == nil && bytes.Equal(existingSum, sourceSum):
+		return MigrationSkipped, 0, nil
+	case err == nil && !opts.Overwrite:
+		return "", 0, fmt.Errorf("destination object differs from source")
+	case err != nil && !errors.Is(err, ErrObjectNotFound):
+		return "", 0, fmt.Errorf("read destination: %w", err)
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+	if err := dst.UploadFile(ctx, name, tmp, size, objectContentType(name)); err != nil {
+		return "", 0, fmt.Errorf("write destination: %w", err)
+	}
+
+	copiedSum, err := objectChecksum(ctx, dst, name, opts.MaxObjectSize)
+	if err != nil {
+		return "", 0, fmt.Errorf("verify destination: %w", err)
+	}
+	if !bytes.Equal(copiedSum, sourceSum) {
+		return "", 0, fmt.Errorf("checksum mismatch after copy")
+	}
+	return MigrationCopied, size, nil
+}
+
+func objectChecksum(ctx context.Context, backend Backend, name string, maxSize int64) ([]byte, error) {
+	sum := sha256.New()
+	if err := backend.DownloadFileToWriter(ctx, name, sum, maxSize); err != nil {
+		return nil, err
+	}
+	return sum.Sum(nil), nil
+}
+
+func objectContentType(name string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
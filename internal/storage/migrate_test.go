@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateObjectsCopiesAndVerifies(t *testing.T) {
+	ctx := context.Background()
+	src, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+	dst, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, src.UploadFile(ctx, "a.pdf", strings.NewReader("alpha"), 5, ""))
+	require.NoError(t, src.UploadFile(ctx, "b.pdf", strings.NewReader("beta"), 4, ""))
+	require.NoError(t, dst.UploadFile(ctx, "b.pdf", strings.NewReader("beta"), 4, ""))
+
+	var progress []string
+	report, err := MigrateObjects(ctx, src, dst, MigrationOptions{
+		MaxObjectSize: 1024,
+		Progress: func(name string, status MigrationStatus) {
+			progress = append(progress, name+":"+string(status))
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &MigrationReport{Copied: 1, Skipped: 1, Bytes: 5}, report)
+	sort.Strings(progress)
+	assert.Equal(t, []string{"a.pdf:copied", "b.pdf:skipped"}, progress)
+
+	var out bytes.Buffer
+	require.NoError(t, dst.DownloadFileToWriter(ctx, "a.pdf", &out, 1024))
+	assert.Equal(t, "alpha", out.String())
+
+	report, err = MigrateObjects(ctx, src, dst, MigrationOptions{MaxObjectSize: 1024})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Skipped)
+}
+
+func TestMigrateObjectsRefusesToOverwriteDifferentObject(t *testing.T) {
+	ctx := context.Background()
+	src, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+	dst, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, src.UploadFile(ctx, "a.pdf", strings.NewReader("new"), 3, ""))
+	require.NoError(t, dst.UploadFile(ctx, "a.pdf", strings.NewReader("old"), 3, ""))
+
+	_, err = MigrateObjects(ctx, src, dst, MigrationOptions{MaxObjectSize: 1024})
+	assert.EqualError(t, err, "object a.pdf: destination object differs from source")
+
+	report, err := MigrateObjects(ctx, src, dst, MigrationOptions{MaxObjectSize: 1024, Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Copied)
+}
+
+// flippingBackend портит данные при записи, чтобы проверить сверку после копирования.
+type flippingBackend struct {
+	*LocalService
+}
+
+func (b *flippingBackend) UploadFile(ctx context.Context, objectName string, data io.Reader, size int64, contentType string) error {
+	payload, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	payload[0] ^= 0xff
+	return b.LocalService.UploadFile(ctx, objectName, bytes.NewReader(payload), size, contentType)
+}
+
+func TestMigrateObjectsDetectsChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	src, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+	dstLocal, err := NewLocalService(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, src.UploadFile(ctx, "a.pdf", strings.NewReader("alpha"), 5, ""))
+
+	dst := &flippingBackend{LocalService: dstLocal}
+	_, err = MigrateObjects(ctx, src, dst, MigrationOptions{MaxObjectSize: 1024})
+	assert.EqualError(t, err, "object a.pdf: checksum mismatch after copy")
+}
@@ -98,10 +98,13 @@ func (m *MinioService) UploadFile(ctx context.Context, objectName string, data i
 func (m *MinioService) DownloadFileToWriter(ctx context.Context, objectName string, writer io.Writer, maxSize int64) error {
 	info, err := m.client.StatObject(ctx, m.bucketName, objectName, minio.StatObjectOptions{})
 	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return fmt.Errorf("failed to stat object: %w", ErrObjectNotFound)
+		}
 		return fmt.Errorf("failed to stat object: %w", err)
 	}
 	if info.Size > maxSize {
-		return fmt.Errorf("object size %d exceeds maximum allowed size %d", info.Size, maxSize)
+		return &objectSizeError{size: info.Size, maxSize: maxSize}
 	}
 	obj, err := m.client.GetObject(ctx, m.bucketName, objectName, minio.GetObjectOptions{})
 	if err != nil {
@@ -115,7 +118,7 @@ func (m *MinioService) DownloadFileToWriter(ctx context.Context, objectName stri
 		return fmt.Errorf("failed to read object data: %w", err)
 	}
 	if written > maxSize {
-		return fmt.Errorf("object exceeds maximum allowed size %d", maxSize)
+		return &objectSizeError{size: -1, maxSize: maxSize}
 	}
 	return nil
 }
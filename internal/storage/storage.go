@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Volkov-D-A/docs-register-and-track/internal/config"
+)
+
+// ErrObjectNotFound возвращается при чтении отсутствующего объекта.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrObjectTooLarge возвращается, если объект больше переданного maxSize.
+var ErrObjectTooLarge = errors.New("object exceeds maximum allowed size")
+
+// objectSizeError сохраняет прежний текст ошибок размера и распознается
+// через errors.Is(err, ErrObjectTooLarge). size < 0 означает, что превышение
+// обнаружено при чтении, а не по метаданным объекта.
+type objectSizeError struct {
+	size    int64
+	maxSize int64
+}
+
+func (e *objectSizeError) Error() string {
+	if e.size < 0 {
+		return fmt.Sprintf("object exceeds maximum allowed size %d", e.maxSize)
+	}
+	return fmt.Sprintf("object size %d exceeds maximum allowed size %d", e.size, e.maxSize)
+}
+
+func (e *objectSizeError) Is(target error) bool { return target == ErrObjectTooLarge }
+
+// Backend — общий набор операций драйверов файлового хранилища вложений.
+type Backend interface {
+	UploadFile(ctx context.Context, objectName string, data io.Reader, size int64, contentType string) error
+	DownloadFileToWriter(ctx context.Context, objectName string, writer io.Writer, maxSize int64) error
+	DeleteFile(ctx context.Context, objectName string) error
+	GetStorageInfo(ctx context.Context) (objectCount int, totalSize string, err error)
+	RefreshStorageUsage(ctx context.Context) (objectCount int, totalBytes int64, err error)
+	ListObjectNames(ctx context.Context) ([]string, error)
+}
+
+var (
+	_ Backend = (*MinioService)(nil)
+	_ Backend = (*LocalService)(nil)
+)
+
+// New создает драйвер хранилища, выбранный в cfg.Storage.
+func New(cfg config.Config) (Backend, error) {
+	if err := cfg.Storage.Validate(); err != nil {
+		return nil, err
+	}
+	return Open(cfg.Storage.DriverName(), cfg)
+}
+
+// Open создает драйвер хранилища по имени, используя настройки из cfg.
+func Open(driver string, cfg config.Config) (Backend, error) {
+	switch driver {
+	case config.StorageDriverMinio:
+		service, err := NewMinioService(cfg.Minio)
+		if err != nil {
+			return nil, err
+		}
+		return service, nil
+	case config.StorageDriverLocal:
+		service, err := NewLocalService(cfg.Storage.LocalPath)
+		if err != nil {
+			return nil, err
+		}
+		return service, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
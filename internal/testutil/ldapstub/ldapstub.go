@@ -0,0 +1,374 @@
+// Package ldapstub provides an in-process LDAP server for directory tests. It
+// implements just enough of LDAPv3 for the application's client: simple bind,
+// subtree search with and/or/not/equality/presence/substring filters and
+// unbind. Unsupported filter items (e.g. AD extensible matches) never match.
+package ldapstub
+
+import (
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// LDAP result codes used by the stub.
+const (
+	resultSuccess            = 0
+	resultProtocolError      = 2
+	resultSizeLimitExceeded  = 4
+	resultInvalidCredentials = 49
+	resultUnwillingToPerform = 53
+)
+
+// Application tags of LDAP protocol operations.
+const (
+	opBindRequest      = 0
+	opBindResponse     = 1
+	opUnbindRequest    = 2
+	opSearchRequest    = 3
+	opSearchEntry      = 4
+	opSearchDone       = 5
+	opExtendedRequest  = 23
+	opExtendedResponse = 24
+)
+
+// Context tags of search filter items and substring parts.
+const (
+	filterAnd           = 0
+	filterOr            = 1
+	filterNot           = 2
+	filterEqualityMatch = 3
+	filterSubstrings    = 4
+	filterPresent       = 7
+
+	substringInitial = 0
+	substringAny     = 1
+	substringFinal   = 2
+
+	authenticationSimple = 0
+)
+
+// Entry is a directory object. Entries with a Password accept simple binds.
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// Server is a running stub directory.
+type Server struct {
+	listener net.Listener
+
+	mu      sync.RWMutex
+	entries []Entry
+	binds   []string
+	down    bool
+	conns   map[net.Conn]struct{}
+
+	wg sync.WaitGroup
+}
+
+// Start listens on a loopback port and serves entries until the test ends.
+func Start(t testing.TB, entries ...Entry) *Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ldapstub: listen: %v", err)
+	}
+	s := &Server{listener: listener, entries: entries, conns: make(map[net.Conn]struct{})}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// URL returns the ldap:// address of the server.
+func (s *Server) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// Close stops the listener, drops open connections and waits for their handlers.
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// SetEntries replaces the directory content.
+func (s *Server) SetEntries(entries ...Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = entries
+}
+
+// SetDown makes every operation fail with "unwilling to perform", which the
+// client treats as an unavailable directory.
+func (s *Server) SetDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+// Binds returns the DNs of all bind attempts in order.
+func (s *Server) Binds() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.binds...)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				_ = conn.Close()
+			}()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+		messageID, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		if op.ClassType != ber.ClassApplication {
+			return
+		}
+
+		var responses []*ber.Packet
+		switch op.Tag {
+		case opBindRequest:
+			responses = []*ber.Packet{s.bind(op)}
+		case opSearchRequest:
+			responses = s.search(op)
+		case opUnbindRequest:
+			return
+		case opExtendedRequest:
+			responses = []*ber.Packet{result(opExtendedResponse, resultProtocolError, "extended operations are not supported")}
+		default:
+			continue
+		}
+		for _, response := range responses {
+			if err := writeMessage(conn, messageID, response); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) bind(op *ber.Packet) *ber.Packet {
+	if len(op.Children) < 3 || op.Children[2].Tag != authenticationSimple {
+		return result(opBindResponse, resultProtocolError, "only simple bind is supported")
+	}
+	dn := op.Children[1].Data.String()
+	password := op.Children[2].Data.String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.binds = append(s.binds, dn)
+	if s.down {
+		return result(opBindResponse, resultUnwillingToPerform, "directory is down")
+	}
+	if dn == "" && password == "" {
+		return result(opBindResponse, resultSuccess, "")
+	}
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			return result(opBindResponse, resultSuccess, "")
+		}
+	}
+	return result(opBindResponse, resultInvalidCredentials, "invalid credentials")
+}
+
+func (s *Server) search(op *ber.Packet) []*ber.Packet {
+	if len(op.Children) < 8 {
+		return []*ber.Packet{result(opSearchDone, resultProtocolError, "malformed search request")}
+	}
+	baseDN := strings.ToLower(op.Children[0].Data.String())
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var requested []string
+	for _, attribute := range op.Children[7].Children {
+		requested = append(requested, attribute.Data.String())
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.down {
+		return []*ber.Packet{result(opSearchDone, resultUnwillingToPerform, "directory is down")}
+	}
+
+	var responses []*ber.Packet
+	for _, entry := range s.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), baseDN) || !matches(filter, entry) {
+			continue
+		}
+		if sizeLimit > 0 && int64(len(responses)) == sizeLimit {
+			return append(responses, result(opSearchDone, resultSizeLimitExceeded, "size limit exceeded"))
+		}
+		responses = append(responses, searchEntry(entry, requested))
+	}
+	return append(responses, result(opSearchDone, resultSuccess, ""))
+}
+
+func matches(filter *ber.Packet, entry Entry) bool {
+	if filter.ClassType != ber.ClassContext {
+		return false
+	}
+	switch filter.Tag {
+	case filterAnd:
+		for _, child := range filter.Children {
+			if !matches(child, entry) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, child := range filter.Children {
+			if matches(child, entry) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return len(filter.Children) == 1 && !matches(filter.Children[0], entry)
+	case filterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		want := filter.Children[1].Data.String()
+		for _, value := range attributeValues(entry, filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, want) {
+				return true
+			}
+		}
+		return false
+	case filterSubstrings:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		for _, value := range attributeValues(entry, filter.Children[0].Data.String()) {
+			if matchesSubstrings(strings.ToLower(value), filter.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	case filterPresent:
+		return len(attributeValues(entry, filter.Data.String())) > 0
+	default:
+		return false
+	}
+}
+
+func matchesSubstrings(value string, parts []*ber.Packet) bool {
+	for _, part := range parts {
+		needle := strings.ToLower(part.Data.String())
+		switch part.Tag {
+		case substringInitial:
+			if !strings.HasPrefix(value, needle) {
+				return false
+			}
+			value = value[len(needle):]
+		case substringAny:
+			index := strings.Index(value, needle)
+			if index < 0 {
+				return false
+			}
+			value = value[index+len(needle):]
+		case substringFinal:
+			if !strings.HasSuffix(value, needle) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func attributeValues(entry Entry, name string) []string {
+	if strings.EqualFold(name, "distinguishedName") {
+		return []string{entry.DN}
+	}
+	return lookup(entry.Attributes, name)
+}
+
+func lookup(attributes map[string][]string, name string) []string {
+	for key, values := range attributes {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+func searchEntry(entry Entry, requested []string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, opSearchEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "Object Name"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.Attributes {
+		if len(requested) > 0 && !containsFold(requested, name) {
+			continue
+		}
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	op.AppendChild(attributes)
+	return op
+}
+
+func result(tag ber.Tag, code int64, message string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, "Diagnostic Message"))
+	return op
+}
+
+func writeMessage(w io.Writer, messageID int64, op *ber.Packet) error {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "Message ID"))
+	packet.AppendChild(op)
+	_, err := w.Write(packet.Bytes())
+	return err
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
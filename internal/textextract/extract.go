@@ -0,0 +1,106 @@
+// Package textextract извлекает текст из вложений для полнотекстового поиска и предпросмотра.
+// Все парсеры написаны на Go и не требуют внешних утилит на рабочем месте.
+package textextract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTextBytes ограничивает объем сохраняемого текста одного вложения.
+const MaxTextBytes = 1 << 20
+
+// maxPartBytes ограничивает распакованный размер XML-части офисного документа.
+const maxPartBytes = 128 << 20
+
+// ErrUnsupportedFormat возвращается для файлов, из которых текст не извлекается.
+var ErrUnsupportedFormat = errors.New("unsupported attachment format")
+
+// errTextLimit прерывает разбор, когда набран MaxTextBytes текста.
+var errTextLimit = errors.New("text limit reached")
+
+// Result — извлеченный текст вложения.
+type Result struct {
+	Text      string
+	Truncated bool
+}
+
+type extractor func(r io.ReaderAt, size int64, out *textBuilder) error
+
+var extractors = map[string]extractor{
+	".pdf":  extractPDF,
+	".docx": extractDOCX,
+	".xlsx": extractXLSX,
+	".odt":  extractODT,
+	".ods":  extractODS,
+}
+
+// Supports сообщает, поддерживается ли извлечение текста для имени файла.
+func Supports(filename string) bool {
+	_, ok := extractors[strings.ToLower(filepath.Ext(filename))]
+	return ok
+}
+
+// Extract извлекает текст из файла; формат определяется по расширению имени.
+func Extract(r io.ReaderAt, size int64, filename string) (Result, error) {
+	extract, ok := extractors[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return Result{}, ErrUnsupportedFormat
+	}
+	out := &textBuilder{}
+	if err := extract(r, size, out); err != nil && !errors.Is(err, errTextLimit) {
+		return Result{}, err
+	}
+	return Result{Text: normalizeText(out.String()), Truncated: out.truncated}, nil
+}
+
+// textBuilder накапливает текст до MaxTextBytes, не разрывая UTF-8 последовательности.
+type textBuilder struct {
+	strings.Builder
+	truncated bool
+}
+
+func (b *textBuilder) write(s string) error {
+	if b.truncated {
+		return errTextLimit
+	}
+	if b.Len()+len(s) > MaxTextBytes {
+		s = s[:MaxTextBytes-b.Len()]
+		for len(s) > 0 && !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+		b.WriteString(s)
+		b.truncated = true
+		return errTextLimit
+	}
+	b.WriteString(s)
+	return nil
+}
+
+// normalizeText убирает хвостовые пробелы строк и повторяющиеся пустые строки.
+func normalizeText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(lines))
+	blank := true
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r\u00a0")
+		if line == "" {
+			if !blank {
+				result = append(result, "")
+			}
+			blank = true
+			continue
+		}
+		result = append(result, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
+
+func invalidFormat(format string, err error) error {
+	return fmt.Errorf("invalid %s file: %w", format, err)
+}
@@ -0,0 +1,147 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipArchive(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		part, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func extractBytes(t *testing.T, data []byte, filename string) Result {
+	t.Helper()
+	result, err := Extract(bytes.NewReader(data), int64(len(data)), filename)
+	require.NoError(t, err)
+	return result
+}
+
+func TestSupports(t *testing.T) {
+	for _, name := range []string{"a.pdf", "b.DOCX", "c.xlsx", "d.odt", "e.ods"} {
+		assert.True(t, Supports(name), name)
+	}
+	for _, name := range []string{"scan.jpg", "legacy.doc", "archive.zip", "noext"} {
+		assert.False(t, Supports(name), name)
+	}
+}
+
+func TestExtractUnsupportedFormat(t *testing.T) {
+	_, err := Extract(bytes.NewReader(nil), 0, "scan.tiff")
+
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestExtractDOCX(t *testing.T) {
+	data := zipArchive(t, map[string]string{
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>О ремонте</w:t></w:r><w:r><w:t xml:space="preserve"> кровли</w:t></w:r></w:p>
+<w:p><w:r><w:t>Срок:</w:t><w:tab/><w:t>май</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Смета</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+<w:p><w:del><w:r><w:delText>удаленный текст</w:delText></w:r></w:del></w:p>
+</w:body></w:document>`,
+	})
+
+	result := extractBytes(t, data, "letter.docx")
+
+	assert.Equal(t, "О ремонте кровли\nСрок:\tмай\nСмета", result.Text)
+	assert.False(t, result.Truncated)
+}
+
+func TestExtractXLSX(t *testing.T) {
+	data := zipArchive(t, map[string]string{
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Наименование</t></si><si><r><t>Кров</t></r><r><t>ля</t></r></si></sst>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row><c t="inlineStr"><is><t>Второй лист</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row><c t="s"><v>0</v></c><c><v>Сумма</v></c></row>
+<row><c t="s"><v>1</v></c><c><f>SUM(B1)</f><v>1500</v></c></row>
+</sheetData></worksheet>`,
+	})
+
+	result := extractBytes(t, data, "smeta.xlsx")
+
+	assert.Equal(t, "Наименование\tСумма\nКровля\t1500\n\nВторой лист", result.Text)
+}
+
+func TestExtractODT(t *testing.T) {
+	data := zipArchive(t, map[string]string{
+		"mimetype": "application/vnd.oasis.opendocument.text",
+		"content.xml": `<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:text>` +
+			`<text:h>Приказ</text:h><text:p>О<text:s text:c="2"/>назначении<office:annotation><text:p>заметка</text:p></office:annotation></text:p>` +
+			`<text:p>Строка<text:line-break/>перенос</text:p></office:text></office:body></office:document-content>`,
+	})
+
+	result := extractBytes(t, data, "order.odt")
+
+	assert.Equal(t, "Приказ\nО  назначении\nСтрока\nперенос", result.Text)
+}
+
+func TestExtractODS(t *testing.T) {
+	data := zipArchive(t, map[string]string{
+		"content.xml": `<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:spreadsheet><table:table>` +
+			`<table:table-row><table:table-cell><text:p>Исполнитель</text:p></table:table-cell><table:table-cell><text:p>Срок</text:p></table:table-cell></table:table-row>` +
+			`<table:table-row><table:table-cell><text:p>Иванов</text:p><text:p>И.И.</text:p></table:table-cell><table:table-cell><text:p>01.06</text:p></table:table-cell></table:table-row>` +
+			`</table:table></office:spreadsheet></office:body></office:document-content>`,
+	})
+
+	result := extractBytes(t, data, "plan.ods")
+
+	assert.Equal(t, "Исполнитель \tСрок\nИванов И.И. \t01.06", result.Text)
+}
+
+func TestExtractPDF(t *testing.T) {
+	doc := fpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Helvetica", "", 12)
+	doc.AddPage()
+	doc.Cell(100, 10, "Registration card 01-15/42")
+	doc.AddPage()
+	doc.Cell(100, 10, "Second page")
+	var buf bytes.Buffer
+	require.NoError(t, doc.Output(&buf))
+
+	result := extractBytes(t, buf.Bytes(), "card.pdf")
+
+	assert.Equal(t, "Registration card 01-15/42\n\nSecond page", result.Text)
+}
+
+func TestExtractRejectsDamagedFiles(t *testing.T) {
+	for _, name := range []string{"broken.pdf", "broken.docx", "broken.xlsx", "broken.odt"} {
+		_, err := Extract(bytes.NewReader([]byte("not a document")), 14, name)
+		assert.Error(t, err, name)
+		assert.NotErrorIs(t, err, ErrUnsupportedFormat, name)
+	}
+
+	archive := zipArchive(t, map[string]string{"other.xml": "<a/>"})
+	_, err := Extract(bytes.NewReader(archive), int64(len(archive)), "empty.docx")
+	assert.ErrorContains(t, err, "word/document.xml not found")
+}
+
+func TestExtractTruncatesLongText(t *testing.T) {
+	paragraph := `<w:p><w:r><w:t>` + strings.Repeat("я", 1000) + `</w:t></w:r></w:p>`
+	data := zipArchive(t, map[string]string{
+		"word/document.xml": `<w:document xmlns:w="w"><w:body>` + strings.Repeat(paragraph, MaxTextBytes/2000+10) + `</w:body></w:document>`,
+	})
+
+	result := extractBytes(t, data, "long.docx")
+
+	assert.True(t, result.Truncated)
+	assert.LessOrEqual(t, len(result.Text), MaxTextBytes)
+	assert.True(t, strings.HasSuffix(result.Text, "я"))
+}
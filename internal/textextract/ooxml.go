@@ -0,0 +1,223 @@
+package textextract
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func extractDOCX(r io.ReaderAt, size int64, out *textBuilder) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return invalidFormat("docx", err)
+	}
+	part := findZipPart(archive, "word/document.xml")
+	if part == nil {
+		return invalidFormat("docx", errors.New("word/document.xml not found"))
+	}
+	return walkZipXML(part, "docx", func(decoder *xml.Decoder, token xml.Token) error {
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				text, err := readElementText(decoder)
+				if err != nil {
+					return err
+				}
+				return out.write(text)
+			case "tab":
+				return out.write("\t")
+			case "br", "cr":
+				return out.write("\n")
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "p":
+				return out.write("\n")
+			case "tc":
+				return out.write("\t")
+			}
+		}
+		return nil
+	})
+}
+
+func extractXLSX(r io.ReaderAt, size int64, out *textBuilder) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return invalidFormat("xlsx", err)
+	}
+	sharedStrings, err := readSharedStrings(archive)
+	if err != nil {
+		return err
+	}
+
+	sheets := make([]*zip.File, 0)
+	for _, file := range archive.File {
+		if strings.HasPrefix(file.Name, "xl/worksheets/") && path.Ext(file.Name) == ".xml" {
+			sheets = append(sheets, file)
+		}
+	}
+	if len(sheets) == 0 {
+		return invalidFormat("xlsx", errors.New("worksheets not found"))
+	}
+	sort.Slice(sheets, func(i, j int) bool {
+		return worksheetNumber(sheets[i].Name) < worksheetNumber(sheets[j].Name)
+	})
+
+	for _, sheet := range sheets {
+		cellType := ""
+		err := walkZipXML(sheet, "xlsx", func(decoder *xml.Decoder, token xml.Token) error {
+			switch element := token.(type) {
+			case xml.StartElement:
+				switch element.Name.Local {
+				case "c":
+					cellType = xmlAttr(element, "t")
+				case "v":
+					value, err := readElementText(decoder)
+					if err != nil {
+						return err
+					}
+					if cellType == "s" {
+						index, convErr := strconv.Atoi(strings.TrimSpace(value))
+						if convErr != nil || index < 0 || index >= len(sharedStrings) {
+							return nil
+						}
+						value = sharedStrings[index]
+					}
+					return out.write(value)
+				case "t":
+					// Встроенная строка ячейки: <c t="inlineStr"><is><t>…</t></is></c>.
+					text, err := readElementText(decoder)
+					if err != nil {
+						return err
+					}
+					return out.write(text)
+				}
+			case xml.EndElement:
+				switch element.Name.Local {
+				case "c":
+					return out.write("\t")
+				case "row":
+					return out.write("\n")
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := out.write("\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSharedStrings(archive *zip.Reader) ([]string, error) {
+	part := findZipPart(archive, "xl/sharedStrings.xml")
+	if part == nil {
+		return nil, nil
+	}
+	values := make([]string, 0)
+	var current strings.Builder
+	err := walkZipXML(part, "xlsx", func(decoder *xml.Decoder, token xml.Token) error {
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Local == "t" {
+				text, err := readElementText(decoder)
+				if err != nil {
+					return err
+				}
+				current.WriteString(text)
+			}
+		case xml.EndElement:
+			if element.Name.Local == "si" {
+				values = append(values, current.String())
+				current.Reset()
+			}
+		}
+		return nil
+	})
+	return values, err
+}
+
+func worksheetNumber(name string) int {
+	base := strings.TrimSuffix(path.Base(name), ".xml")
+	number, err := strconv.Atoi(strings.TrimPrefix(base, "sheet"))
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return number
+}
+
+func findZipPart(archive *zip.Reader, name string) *zip.File {
+	for _, file := range archive.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
+
+// walkZipXML потоково разбирает XML-часть архива, не загружая ее в память целиком.
+func walkZipXML(part *zip.File, format string, visit func(*xml.Decoder, xml.Token) error) error {
+	rc, err := part.Open()
+	if err != nil {
+		return invalidFormat(format, err)
+	}
+	defer rc.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxPartBytes))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return invalidFormat(format, err)
+		}
+		if err := visit(decoder, token); err != nil {
+			if errors.Is(err, errTextLimit) {
+				return err
+			}
+			return invalidFormat(format, err)
+		}
+	}
+}
+
+// readElementText читает текстовое содержимое текущего элемента до его закрывающего тега.
+func readElementText(decoder *xml.Decoder) (string, error) {
+	var text strings.Builder
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		switch element := token.(type) {
+		case xml.CharData:
+			text.Write(element)
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return text.String(), nil
+}
+
+func xmlAttr(element xml.StartElement, local string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
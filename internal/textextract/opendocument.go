@@ -0,0 +1,71 @@
+package textextract
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func extractODT(r io.ReaderAt, size int64, out *textBuilder) error {
+	return extractOpenDocument(r, size, "odt", out)
+}
+
+func extractODS(r io.ReaderAt, size int64, out *textBuilder) error {
+	return extractOpenDocument(r, size, "ods", out)
+}
+
+// extractOpenDocument разбирает content.xml текстовых документов и таблиц ODF.
+// Абзацы внутри ячейки таблицы разделяются пробелом, чтобы строка таблицы оставалась одной строкой текста.
+func extractOpenDocument(r io.ReaderAt, size int64, format string, out *textBuilder) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return invalidFormat(format, err)
+	}
+	part := findZipPart(archive, "content.xml")
+	if part == nil {
+		return invalidFormat(format, errors.New("content.xml not found"))
+	}
+
+	cellDepth := 0
+	return walkZipXML(part, format, func(decoder *xml.Decoder, token xml.Token) error {
+		switch element := token.(type) {
+		case xml.CharData:
+			return out.write(string(element))
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "table-cell", "covered-table-cell":
+				cellDepth++
+			case "s":
+				count, err := strconv.Atoi(xmlAttr(element, "c"))
+				if err != nil || count < 1 {
+					count = 1
+				}
+				return out.write(strings.Repeat(" ", min(count, 80)))
+			case "tab":
+				return out.write("\t")
+			case "line-break":
+				return out.write("\n")
+			case "annotation", "tracked-changes":
+				// Примечания и история правок не входят в текст документа.
+				return decoder.Skip()
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "p", "h":
+				if cellDepth > 0 {
+					return out.write(" ")
+				}
+				return out.write("\n")
+			case "table-cell", "covered-table-cell":
+				cellDepth--
+				return out.write("\t")
+			case "table-row":
+				return out.write("\n")
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,38 @@
+package textextract
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ledongthuc/pdf"
+)
+
+func extractPDF(r io.ReaderAt, size int64, out *textBuilder) (err error) {
+	// Парсер PDF паникует на части поврежденных файлов; такая ошибка не должна
+	// останавливать обработчик outbox.
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = invalidFormat("pdf", fmt.Errorf("%v", recovered))
+		}
+	}()
+
+	reader, err := pdf.NewReader(r, size)
+	if err != nil {
+		return invalidFormat("pdf", err)
+	}
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		// Имена шрифтов локальны для ресурсов страницы, поэтому кэш шрифтов не разделяется.
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return invalidFormat("pdf", err)
+		}
+		if err := out.write(text + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
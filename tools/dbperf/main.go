@@ -249,7 +249,7 @@ func seed(db *sql.DB, count int) uuid.UUID {
 		if i%100 == 0 {
 			content += " needle"
 		}
-		if _, err := tx.Exec(`INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type, content, pages_count, created_by, created_at) VALUES ($1, 'outgoing_letter', $2, $3, $4, $5, $6, $7, 1, $8, $9)`, id, nomID, uuid.New(), number, date, models.DocumentTypeLetter, content, userID, date); err != nil {
+		if _, err := tx.Exec(`INSERT INTO documents (id, kind, nomenclature_id, idempotency_key, registration_number, registration_date, document_type_id, content, pages_count, created_by, created_at) VALUES ($1, 'outgoing_letter', $2, $3, $4, $5, (SELECT id FROM document_types WHERE name = $6), $7, 1, $8, $9)`, id, nomID, uuid.New(), number, date, models.DocumentTypeLetter, content, userID, date); err != nil {
 			fail("seed document: %v", err)
 		}
 		if _, err := tx.Exec(`INSERT INTO outgoing_document_details (document_id, outgoing_number, outgoing_date, sender_signatory, sender_executor, recipient_org_id, addressee) VALUES ($1, $2, $3, 'Signer', 'Executor', $4, 'Addressee')`, id, number, date, orgID); err != nil {